@@ -5,9 +5,14 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,14 +21,22 @@ import (
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/gogo/protobuf/proto"
 	"github.com/oklog/run"
 	"github.com/oklog/ulid"
 	"github.com/olekukonko/tablewriter"
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/route"
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/compact"
@@ -32,6 +45,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/extflag"
 	"github.com/thanos-io/thanos/pkg/extprom"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	thanosmodel "github.com/thanos-io/thanos/pkg/model"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
@@ -43,6 +57,7 @@ import (
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
 )
 
 const extpromPrefix = "thanos_bucket_"
@@ -50,6 +65,7 @@ const extpromPrefix = "thanos_bucket_"
 var (
 	issuesMap = map[string]verifier.Issue{
 		verifier.IndexIssueID:                verifier.IndexIssue,
+		verifier.ChunkIssueID:                verifier.ChunkIssue,
 		verifier.OverlappedBlocksIssueID:     verifier.OverlappedBlocksIssue,
 		verifier.DuplicatedCompactionIssueID: verifier.DuplicatedCompactionIssue,
 	}
@@ -74,6 +90,13 @@ func registerBucket(m map[string]setupFunc, app *kingpin.Application, name strin
 	registerBucketWeb(m, cmd, name, objStoreConfig)
 	registerBucketReplicate(m, cmd, name, objStoreConfig)
 	registerBucketDownsample(m, cmd, name, objStoreConfig)
+	registerBucketUndelete(m, cmd, name, objStoreConfig)
+	registerBucketAnalyze(m, cmd, name, objStoreConfig)
+	registerBucketRewrite(m, cmd, name, objStoreConfig)
+	registerBucketMark(m, cmd, name, objStoreConfig)
+	registerBucketRetention(m, cmd, name, objStoreConfig)
+	registerBucketCleanup(m, cmd, name, objStoreConfig)
+	registerBucketSplit(m, cmd, name, objStoreConfig)
 }
 
 func registerBucketVerify(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
@@ -91,6 +114,7 @@ func registerBucketVerify(m map[string]setupFunc, root *kingpin.CmdClause, name
 		"Note that deleting blocks immediately can cause query failures, if store gateway still has the block loaded, "+
 		"or compactor is ignoring the deletion because it's compacting the block at the same time.").
 		Default("0s"))
+	concurrency := cmd.Flag("concurrency", "Number of blocks verified (and optionally repaired) at once.").Default("4").Int()
 	m[name+" verify"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
 		confContentYaml, err := objStoreConfig.Content()
 		if err != nil {
@@ -146,9 +170,9 @@ func registerBucketVerify(m map[string]setupFunc, root *kingpin.CmdClause, name
 		}
 
 		if *repair {
-			v = verifier.NewWithRepair(logger, reg, bkt, backupBkt, fetcher, time.Duration(*deleteDelay), issues)
+			v = verifier.NewWithRepair(logger, reg, bkt, backupBkt, fetcher, time.Duration(*deleteDelay), *concurrency, issues)
 		} else {
-			v = verifier.New(logger, reg, bkt, fetcher, time.Duration(*deleteDelay), issues)
+			v = verifier.New(logger, reg, bkt, fetcher, time.Duration(*deleteDelay), *concurrency, issues)
 		}
 
 		var idMatcher func(ulid.ULID) bool = nil
@@ -176,9 +200,15 @@ func registerBucketVerify(m map[string]setupFunc, root *kingpin.CmdClause, name
 
 func registerBucketLs(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
 	cmd := root.Command("ls", "List all blocks in the bucket")
-	output := cmd.Flag("output", "Optional format in which to print each block's information. Options are 'json', 'wide' or a custom template.").
+	output := cmd.Flag("output", "Optional format in which to print each block's information. Options are 'json', 'wide', 'csv', 'prometheus' or a custom template.").
 		Short('o').Default("").String()
+	selection := regBlockSelectionFlags(cmd)
 	m[name+" ls"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		sel, err := selection.parse()
+		if err != nil {
+			return err
+		}
+
 		confContentYaml, err := objStoreConfig.Content()
 		if err != nil {
 			return err
@@ -206,6 +236,7 @@ func registerBucketLs(m map[string]setupFunc, root *kingpin.CmdClause, name stri
 			format     = *output
 			objects    = 0
 			printBlock func(m *metadata.Meta) error
+			printAll   func(metas []*metadata.Meta) error
 		)
 
 		switch format {
@@ -233,6 +264,10 @@ func registerBucketLs(m map[string]setupFunc, root *kingpin.CmdClause, name stri
 			printBlock = func(m *metadata.Meta) error {
 				return enc.Encode(&m)
 			}
+		case "csv":
+			printAll = func(metas []*metadata.Meta) error { return printBlocksCSV(os.Stdout, metas) }
+		case "prometheus":
+			printAll = func(metas []*metadata.Meta) error { return printBlocksPrometheus(os.Stdout, metas) }
 		default:
 			tmpl, err := template.New("").Parse(format)
 			if err != nil {
@@ -252,7 +287,21 @@ func registerBucketLs(m map[string]setupFunc, root *kingpin.CmdClause, name stri
 			return err
 		}
 
-		for _, meta := range metas {
+		selected, err := sel.selectBlocks(ctx, bkt, metas)
+		if err != nil {
+			return err
+		}
+
+		if printAll != nil {
+			objects = len(selected)
+			if err := printAll(selected); err != nil {
+				return errors.Wrap(err, "print")
+			}
+		}
+		for _, meta := range selected {
+			if printAll != nil {
+				break
+			}
 			objects++
 			if err := printBlock(meta); err != nil {
 				return errors.Wrap(err, "iter")
@@ -265,18 +314,18 @@ func registerBucketLs(m map[string]setupFunc, root *kingpin.CmdClause, name stri
 
 func registerBucketInspect(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
 	cmd := root.Command("inspect", "Inspect all blocks in the bucket in detailed, table-like way")
-	selector := cmd.Flag("selector", "Selects blocks based on label, e.g. '-l key1=\\\"value1\\\" -l key2=\\\"value2\\\"'. All key value pairs must match.").Short('l').
-		PlaceHolder("<name>=\\\"<value>\\\"").Strings()
+	selection := regBlockSelectionFlags(cmd)
+	output := cmd.Flag("output", "Format of the output. Options are 'table', 'json', 'csv' or 'prometheus'.").Short('o').
+		Default("table").Enum("table", "json", "csv", "prometheus")
 	sortBy := cmd.Flag("sort-by", "Sort by columns. It's also possible to sort by multiple columns, e.g. '--sort-by FROM --sort-by UNTIL'. I.e., if the 'FROM' value is equal the rows are then further sorted by the 'UNTIL' value.").
 		Default("FROM", "UNTIL").Enums(inspectColumns...)
 	timeout := cmd.Flag("timeout", "Timeout to download metadata from remote storage").Default("5m").Duration()
 
 	m[name+" inspect"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
 
-		// Parse selector.
-		selectorLabels, err := parseFlagLabels(*selector)
+		sel, err := selection.parse()
 		if err != nil {
-			return errors.Wrap(err, "error parsing selector flag")
+			return err
 		}
 
 		confContentYaml, err := objStoreConfig.Content()
@@ -308,12 +357,12 @@ func registerBucketInspect(m map[string]setupFunc, root *kingpin.CmdClause, name
 			return err
 		}
 
-		blockMetas := make([]*metadata.Meta, 0, len(metas))
-		for _, meta := range metas {
-			blockMetas = append(blockMetas, meta)
+		blockMetas, err := sel.selectBlocks(ctx, bkt, metas)
+		if err != nil {
+			return err
 		}
 
-		return printTable(blockMetas, selectorLabels, *sortBy)
+		return printInspect(os.Stdout, blockMetas, *output, *sortBy)
 	}
 }
 
@@ -428,12 +477,19 @@ func registerBucketReplicate(m map[string]setupFunc, root *kingpin.CmdClause, na
 	compaction := cmd.Flag("compaction", "Only blocks with this compaction level will be replicated.").Default("1").Int()
 	matcherStrs := cmd.Flag("matcher", "Only blocks whose external labels exactly match this matcher will be replicated.").PlaceHolder("key=\"value\"").Strings()
 	singleRun := cmd.Flag("single-run", "Run replication only one time, then exit.").Default("false").Bool()
+	interval := modelDuration(cmd.Flag("interval", "How often replication runs, unless --single-run is set.").Default("1m"))
+	concurrency := cmd.Flag("concurrency", "Number of blocks replicated at once. Blocks still start in order of their min time.").Default("1").Int()
+	bandwidthLimit := cmd.Flag("bandwidth-limit", "Maximum total bandwidth of replication per second, e.g. 10MB. 0 means no limit.").Default("0").Bytes()
+	mirrorDeletions := cmd.Flag("mirror-deletions", "If true, blocks replicated to the target bucket are deleted from it once they are deleted from the origin bucket. Only blocks recorded in the replication state of the target bucket are deleted.").Default("false").Bool()
 
 	m[name+" replicate"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ <-chan struct{}, _ bool) error {
 		matchers, err := replicate.ParseFlagMatchers(*matcherStrs)
 		if err != nil {
 			return errors.Wrap(err, "parse block label matchers")
 		}
+		if !*singleRun && *interval <= 0 {
+			return errors.New("--interval has to be positive")
+		}
 
 		return replicate.RunReplicate(
 			g,
@@ -448,6 +504,10 @@ func registerBucketReplicate(m map[string]setupFunc, root *kingpin.CmdClause, na
 			objStoreConfig,
 			toObjStoreConfig,
 			*singleRun,
+			time.Duration(*interval),
+			*concurrency,
+			int64(*bandwidthLimit),
+			*mirrorDeletions,
 		)
 	}
 
@@ -461,94 +521,1245 @@ func registerBucketDownsample(m map[string]setupFunc, root *kingpin.CmdClause, n
 
 	dataDir := cmd.Flag("data-dir", "Data directory in which to cache blocks and process downsamplings.").
 		Default("./data").String()
+	shardFilter := regShardFlags(cmd)
 
 	m[name+" "+comp.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ <-chan struct{}, _ bool) error {
-		return RunDownsample(g, logger, reg, *httpAddr, time.Duration(*httpGracePeriod), *dataDir, objStoreConfig, comp)
+		shard, err := shardFilter()
+		if err != nil {
+			return err
+		}
+		return RunDownsample(g, logger, reg, *httpAddr, time.Duration(*httpGracePeriod), *dataDir, objStoreConfig, comp, shard)
 	}
 }
 
-func printTable(blockMetas []*metadata.Meta, selectorLabels labels.Labels, sortBy []string) error {
-	header := inspectColumns
+func registerBucketRetention(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
+	cmd := root.Command("retention", "Mark blocks older than the retention of their resolution for deletion. Runs once, independently of compactor, which deletes the marked blocks.")
+	retentionRaw := modelDuration(cmd.Flag("retention.resolution-raw", "How long to retain raw samples in bucket. Setting this to 0d will retain samples of this resolution forever").Default("0d"))
+	retention5m := modelDuration(cmd.Flag("retention.resolution-5m", "How long to retain samples of resolution 1 (5 minutes) in bucket. Setting this to 0d will retain samples of this resolution forever").Default("0d"))
+	retention1h := modelDuration(cmd.Flag("retention.resolution-1h", "How long to retain samples of resolution 2 (1 hour) in bucket. Setting this to 0d will retain samples of this resolution forever").Default("0d"))
+	timeout := cmd.Flag("timeout", "Timeout to fetch metas and mark blocks.").Default("30m").Duration()
+	shardFilter := regShardFlags(cmd)
+
+	m[name+" retention"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		retentionByResolution := map[compact.ResolutionLevel]time.Duration{
+			compact.ResolutionLevelRaw: time.Duration(*retentionRaw),
+			compact.ResolutionLevel5m:  time.Duration(*retention5m),
+			compact.ResolutionLevel1h:  time.Duration(*retention1h),
+		}
+		if *retentionRaw == 0 && *retention5m == 0 && *retention1h == 0 {
+			return errors.New("no retention specified; set at least one of the retention.resolution-* flags")
+		}
+		shard, err := shardFilter()
+		if err != nil {
+			return err
+		}
 
-	var lines [][]string
-	p := message.NewPrinter(language.English)
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
 
-	for _, blockMeta := range blockMetas {
-		if !matchesSelector(blockMeta, selectorLabels) {
-			continue
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, name)
+		if err != nil {
+			return err
 		}
 
-		timeRange := time.Duration((blockMeta.MaxTime - blockMeta.MinTime) * int64(time.Millisecond))
+		// Blocks already marked for deletion are not marked again.
+		fetcher, err := block.NewMetaFetcher(logger, fetcherConcurrency, bkt, "", extprom.WrapRegistererWithPrefix(extpromPrefix, reg), []block.MetadataFilter{
+			block.NewIgnoreDeletionMarkFilter(logger, bkt, 0),
+			shard,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		blocksMarkedForDeletion := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_bucket_retention_blocks_marked_for_deletion_total",
+			Help: "Total number of blocks marked for deletion by retention.",
+		})
 
-		untilDown := "-"
-		if until, err := compact.UntilNextDownsampling(blockMeta); err == nil {
-			untilDown = until.String()
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+
+		return compact.ApplyRetentionPolicyByResolution(ctx, logger, bkt, fetcher, retentionByResolution, blocksMarkedForDeletion)
+	}
+}
+
+func registerBucketCleanup(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
+	cmd := root.Command("cleanup", "Delete aged partial uploads, block directories with dangling markers only and empty block directories. Meant for buckets not served by a compactor, which cleans partial uploads otherwise.")
+	partialUploadDelay := modelDuration(cmd.Flag("partial-upload.grace-period", "Age of block (by the time in its ID) without meta.json after which it is assumed to be an aborted upload. Keep it long, as the age is based on block creation, not upload start.").
+		Default("48h"))
+	markerDelay := modelDuration(cmd.Flag("marker.grace-period", "Age of the newest marker of a block directory with markers only, after which the markers are assumed to be left by an interrupted deletion.").
+		Default("24h"))
+	dryRun := cmd.Flag("dry-run", "Only print block directories which would be deleted, without deleting them.").Bool()
+	timeout := cmd.Flag("timeout", "Timeout to find and delete block directories.").Default("30m").Duration()
+
+	m[name+" cleanup"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
 		}
-		var labels []string
-		for _, key := range getKeysAlphabetically(blockMeta.Thanos.Labels) {
-			labels = append(labels, fmt.Sprintf("%s=%s", key, blockMeta.Thanos.Labels[key]))
+
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, name)
+		if err != nil {
+			return err
 		}
 
-		var line []string
-		line = append(line, blockMeta.ULID.String())
-		line = append(line, time.Unix(blockMeta.MinTime/1000, 0).Format("02-01-2006 15:04:05"))
-		line = append(line, time.Unix(blockMeta.MaxTime/1000, 0).Format("02-01-2006 15:04:05"))
-		line = append(line, timeRange.String())
-		line = append(line, untilDown)
-		line = append(line, p.Sprintf("%d", blockMeta.Stats.NumSeries))
-		line = append(line, p.Sprintf("%d", blockMeta.Stats.NumSamples))
-		line = append(line, p.Sprintf("%d", blockMeta.Stats.NumChunks))
-		line = append(line, p.Sprintf("%d", blockMeta.Compaction.Level))
-		line = append(line, p.Sprintf("%t", blockMeta.Compaction.Failed))
-		line = append(line, strings.Join(labels, ","))
-		line = append(line, time.Duration(blockMeta.Thanos.Downsample.Resolution*int64(time.Millisecond)).String())
-		line = append(line, string(blockMeta.Thanos.Source))
-		lines = append(lines, line)
-	}
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
 
-	var sortByColNum []int
-	for _, col := range sortBy {
-		index := getIndex(header, col)
-		if index == -1 {
-			return errors.Errorf("column %s not found", col)
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+
+		garbage, err := block.FindGarbage(ctx, logger, bkt, time.Now(), time.Duration(*partialUploadDelay), time.Duration(*markerDelay))
+		if err != nil {
+			return err
 		}
-		sortByColNum = append(sortByColNum, index)
-	}
+		printGarbage(os.Stdout, garbage)
 
-	t := Table{Header: header, Lines: lines, SortIndices: sortByColNum}
-	sort.Sort(t)
+		var reclaimed uint64
+		for _, gb := range garbage {
+			if !*dryRun {
+				if err := block.DeleteGarbage(ctx, logger, bkt, gb); err != nil {
+					return err
+				}
+			}
+			reclaimed += gb.Bytes
+		}
+		level.Info(logger).Log("msg", "cleanup done", "blocks", len(garbage), "reclaimedBytes", reclaimed, "dryRun", *dryRun)
+		return nil
+	}
+}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader(t.Header)
+// printGarbage prints block directories found for deletion and their sizes.
+func printGarbage(w io.Writer, garbage []block.Garbage) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"ULID", "KIND", "FILES", "BYTES"})
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
 	table.SetAutoWrapText(false)
-	table.SetReflowDuringAutoWrap(false)
 	table.SetAlignment(tablewriter.ALIGN_LEFT)
-	table.AppendBulk(t.Lines)
+
+	for _, g := range garbage {
+		table.Append([]string{g.ID.String(), string(g.Kind), strconv.Itoa(len(g.Files)), strconv.FormatUint(g.Bytes, 10)})
+	}
 	table.Render()
+}
 
-	return nil
+// regShardFlags registers flags splitting blocks into shards, so the command can be run as multiple parallel jobs.
+func regShardFlags(cmd *kingpin.CmdClause) func() (*block.ShardFilter, error) {
+	shards := cmd.Flag("shard.count", "Number of shards blocks are split into by the hash of their compaction group key (external labels and resolution). Each shard can be processed by a separate job in parallel.").
+		Default("1").Int()
+	shard := cmd.Flag("shard.index", "Index of the shard processed by this job, from 0 to shard.count - 1.").
+		Default("0").Int()
+	return func() (*block.ShardFilter, error) {
+		return block.NewShardFilter(*shards, *shard, func(m *metadata.Meta) string { return compact.GroupKey(m.Thanos) })
+	}
 }
 
-func getKeysAlphabetically(labels map[string]string) []string {
-	var keys []string
-	for k := range labels {
-		keys = append(keys, k)
+func registerBucketUndelete(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
+	cmd := root.Command("undelete", "List or restore deleted blocks from previous versions of their files. Requires object versioning enabled in the bucket (supported by GCS, S3 and OBS).")
+	ids := cmd.Flag("id", "ID (ULID) of the deleted block to restore (repeated). If none is specified, deleted blocks that can be restored are listed.").Strings()
+	timeout := cmd.Flag("timeout", "Timeout to list and restore blocks.").Default("30m").Duration()
+
+	m[name+" undelete"] = func(g *run.Group, logger log.Logger, _ *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		var blockIDs []ulid.ULID
+		for _, id := range *ids {
+			u, err := ulid.Parse(id)
+			if err != nil {
+				return errors.Wrapf(err, "invalid block ID %q", id)
+			}
+			blockIDs = append(blockIDs, u)
+		}
+
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+
+		bkt, err := client.NewVersionedBucket(logger, confContentYaml, name)
+		if err != nil {
+			return err
+		}
+
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+
+		deleted, err := block.DeletedBlocks(ctx, bkt, blockIDs...)
+		if err != nil {
+			return err
+		}
+
+		if len(blockIDs) == 0 {
+			for _, b := range deleted {
+				if !b.Restorable() {
+					continue
+				}
+				fmt.Fprintf(os.Stdout, "%s -- files: %d\n", b.ID, len(b.Files))
+			}
+			return nil
+		}
+
+		if len(deleted) != len(blockIDs) {
+			found := map[ulid.ULID]struct{}{}
+			for _, b := range deleted {
+				found[b.ID] = struct{}{}
+			}
+			for _, id := range blockIDs {
+				if _, ok := found[id]; !ok {
+					return errors.Errorf("no deleted files of block %s found", id)
+				}
+			}
+		}
+		for _, b := range deleted {
+			if err := block.Undelete(ctx, logger, bkt, b); err != nil {
+				return errors.Wrapf(err, "undelete block %s", b.ID)
+			}
+		}
+		level.Info(logger).Log("msg", "undelete done", "blocks", len(deleted))
+		return nil
 	}
-	sort.Strings(keys)
-	return keys
 }
 
-// matchesSelector checks if blockMeta contains every label from
-// the selector with the correct value.
-func matchesSelector(blockMeta *metadata.Meta, selectorLabels labels.Labels) bool {
-	for _, l := range selectorLabels {
-		if v, ok := blockMeta.Thanos.Labels[l.Name]; !ok || v != l.Value {
-			return false
+// blockAnalysis is the analyze report of a single block.
+type blockAnalysis struct {
+	ULID    ulid.ULID         `json:"ulid"`
+	MinTime int64             `json:"minTime"`
+	MaxTime int64             `json:"maxTime"`
+	Labels  map[string]string `json:"labels"`
+	block.IndexCardinality
+	// Churn is computed against the previous block of the same stream and resolution, if any.
+	Churn *seriesChurn `json:"churn,omitempty"`
+}
+
+type seriesChurn struct {
+	Previous ulid.ULID `json:"previous"`
+	Added    int       `json:"added"`
+	Removed  int       `json:"removed"`
+}
+
+func registerBucketAnalyze(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
+	cmd := root.Command("analyze", "Analyze label cardinality of block indexes and series churn between consecutive blocks. Indexes are downloaded to a temporary directory one by one.")
+	ids := cmd.Flag("id", "ID (ULID) of the block to analyze (repeated). If none is specified, all blocks matching the selector are analyzed.").Strings()
+	selector := cmd.Flag("selector", "Selects blocks based on label, e.g. '-l key1=\\\"value1\\\" -l key2=\\\"value2\\\"'. All key value pairs must match.").Short('l').
+		PlaceHolder("<name>=\\\"<value>\\\"").Strings()
+	limit := cmd.Flag("limit", "Number of top label names and label pairs to report for each block. 0 reports all.").Default("10").Int()
+	output := cmd.Flag("output", "Format of the report. Options are 'table' or 'json'.").Default("table").Enum("table", "json")
+	timeout := cmd.Flag("timeout", "Timeout to download and analyze indexes.").Default("1h").Duration()
+
+	m[name+" analyze"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		selectorLabels, err := parseFlagLabels(*selector)
+		if err != nil {
+			return errors.Wrap(err, "error parsing selector flag")
+		}
+		only := map[ulid.ULID]struct{}{}
+		for _, id := range *ids {
+			u, err := ulid.Parse(id)
+			if err != nil {
+				return errors.Wrapf(err, "invalid block ID %q", id)
+			}
+			only[u] = struct{}{}
+		}
+
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, name)
+		if err != nil {
+			return err
+		}
+
+		fetcher, err := block.NewMetaFetcher(logger, fetcherConcurrency, bkt, "", extprom.WrapRegistererWithPrefix(extpromPrefix, reg), nil, nil)
+		if err != nil {
+			return err
+		}
+
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+
+		metas, _, err := fetcher.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+
+		var blockMetas []*metadata.Meta
+		for id, meta := range metas {
+			if _, ok := only[id]; len(only) > 0 && !ok {
+				continue
+			}
+			if !matchesSelector(meta, selectorLabels) {
+				continue
+			}
+			blockMetas = append(blockMetas, meta)
+		}
+		// Blocks of the same stream and resolution are consecutive, ordered by time, for churn computation.
+		sort.Slice(blockMetas, func(i, j int) bool {
+			gi, gj := compact.GroupKey(blockMetas[i].Thanos), compact.GroupKey(blockMetas[j].Thanos)
+			if gi != gj {
+				return gi < gj
+			}
+			return blockMetas[i].MinTime < blockMetas[j].MinTime
+		})
+
+		tmpDir, err := ioutil.TempDir("", "thanos-bucket-analyze")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpDir); err != nil {
+				level.Warn(logger).Log("msg", "failed to remove temporary directory", "dir", tmpDir, "err", err)
+			}
+		}()
+
+		var (
+			analyses   = make([]blockAnalysis, 0, len(blockMetas))
+			prev       *metadata.Meta
+			prevSeries block.SeriesSet
+		)
+		for _, meta := range blockMetas {
+			fn := filepath.Join(tmpDir, meta.ULID.String()+"-"+block.IndexFilename)
+			if err := objstore.DownloadFile(ctx, logger, bkt, path.Join(meta.ULID.String(), block.IndexFilename), fn); err != nil {
+				return errors.Wrapf(err, "download index of block %s", meta.ULID)
+			}
+			c, series, err := block.AnalyzeIndex(fn, *limit)
+			if err != nil {
+				return errors.Wrapf(err, "analyze index of block %s", meta.ULID)
+			}
+			if err := os.Remove(fn); err != nil {
+				return err
+			}
+
+			a := blockAnalysis{
+				ULID:             meta.ULID,
+				MinTime:          meta.MinTime,
+				MaxTime:          meta.MaxTime,
+				Labels:           meta.Thanos.Labels,
+				IndexCardinality: c,
+			}
+			if prev != nil && compact.GroupKey(prev.Thanos) == compact.GroupKey(meta.Thanos) {
+				added, removed := series.Churn(prevSeries)
+				a.Churn = &seriesChurn{Previous: prev.ULID, Added: added, Removed: removed}
+			}
+			analyses = append(analyses, a)
+			prev, prevSeries = meta, series
+			level.Debug(logger).Log("msg", "analyzed block", "id", meta.ULID, "series", c.Series)
 		}
+
+		if *output == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(analyses)
+		}
+		printAnalysis(analyses)
+		return nil
 	}
-	return true
+}
+
+func printAnalysis(analyses []blockAnalysis) {
+	p := message.NewPrinter(language.English)
+	newTable := func(header ...string) *tablewriter.Table {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader(header)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.SetAutoWrapText(false)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		return table
+	}
+
+	for _, a := range analyses {
+		var lbls []string
+		for _, key := range getKeysAlphabetically(a.Labels) {
+			lbls = append(lbls, fmt.Sprintf("%s=%s", key, a.Labels[key]))
+		}
+		fmt.Fprintf(os.Stdout, "Block %s (%s - %s) %s\n", a.ULID,
+			time.Unix(a.MinTime/1000, 0).Format("02-01-2006 15:04:05"),
+			time.Unix(a.MaxTime/1000, 0).Format("02-01-2006 15:04:05"),
+			strings.Join(lbls, ","),
+		)
+		fmt.Fprint(os.Stdout, p.Sprintf("Series: %d", a.Series))
+		if a.Churn != nil {
+			fmt.Fprint(os.Stdout, p.Sprintf(", churn since %s: +%d -%d", a.Churn.Previous, a.Churn.Added, a.Churn.Removed))
+		}
+		fmt.Fprint(os.Stdout, "\n\n")
+
+		names := newTable("LABEL NAME", "#VALUES", "#SERIES")
+		for _, n := range a.LabelNames {
+			names.Append([]string{n.Name, p.Sprintf("%d", n.Values), p.Sprintf("%d", n.Series)})
+		}
+		names.Render()
+		fmt.Fprintln(os.Stdout)
+
+		pairs := newTable("LABEL PAIR", "#SERIES")
+		for _, l := range a.LabelPairs {
+			pairs.Append([]string{fmt.Sprintf("%s=%q", l.Name, l.Value), p.Sprintf("%d", l.Series)})
+		}
+		pairs.Render()
+		fmt.Fprintln(os.Stdout)
+	}
+}
+
+// rewriteConfig is the configuration of changes made by bucket rewrite.
+type rewriteConfig struct {
+	Deletions      []rewriteDeletion `yaml:"deletions"`
+	RelabelConfigs []*relabel.Config `yaml:"relabel_configs"`
+}
+
+type rewriteDeletion struct {
+	// Matchers is the series selector, e.g. {__name__="up", job="node"}.
+	Matchers string `yaml:"matchers"`
+	// MinTime and MaxTime in RFC3339 limit the deletion to the time range. Unbounded, if empty.
+	MinTime string `yaml:"min_time"`
+	MaxTime string `yaml:"max_time"`
+}
+
+func parseRewriteConfig(content []byte) ([]block.DeletionRequest, []*relabel.Config, error) {
+	var conf rewriteConfig
+	if err := yaml.UnmarshalStrict(content, &conf); err != nil {
+		return nil, nil, errors.Wrap(err, "parse rewrite configuration")
+	}
+
+	deletions := make([]block.DeletionRequest, 0, len(conf.Deletions))
+	for _, d := range conf.Deletions {
+		matchers, err := promql.ParseMetricSelector(d.Matchers)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "parse deletion matchers %q", d.Matchers)
+		}
+		del := block.NewDeletionRequest(matchers...)
+		for _, t := range []struct {
+			value string
+			ts    *int64
+		}{{d.MinTime, &del.MinTime}, {d.MaxTime, &del.MaxTime}} {
+			if t.value == "" {
+				continue
+			}
+			parsed, err := time.Parse(time.RFC3339, t.value)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "parse deletion time %q", t.value)
+			}
+			*t.ts = timestamp.FromTime(parsed)
+		}
+		if del.MinTime > del.MaxTime {
+			return nil, nil, errors.Errorf("deletion of %s: min_time after max_time", d.Matchers)
+		}
+		deletions = append(deletions, del)
+	}
+	if len(deletions) == 0 && len(conf.RelabelConfigs) == 0 {
+		return nil, nil, errors.New("no deletions or relabel configs specified")
+	}
+	return deletions, conf.RelabelConfigs, nil
+}
+
+func registerBucketRewrite(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
+	cmd := root.Command("rewrite", "Rewrite blocks deleting samples of series and relabeling series. Rewritten blocks are uploaded as new blocks and the original ones are marked for deletion.")
+	ids := cmd.Flag("id", "ID (ULID) of the block to rewrite (repeated). If none is specified, all blocks matching the selector are rewritten.").Strings()
+	selector := cmd.Flag("selector", "Selects blocks based on label, e.g. '-l key1=\\\"value1\\\" -l key2=\\\"value2\\\"'. All key value pairs must match.").Short('l').
+		PlaceHolder("<name>=\\\"<value>\\\"").Strings()
+	rewriteConf := extflag.RegisterPathOrContent(cmd, "rewrite.config", "YAML file that contains deletions and relabel configs applied to series of blocks. See format details: https://thanos.io/components/bucket.md/#rewrite", true)
+	dataDir := cmd.Flag("data-dir", "Data directory in which blocks are downloaded and rewritten, one at a time.").Default("./data").String()
+	dryRun := cmd.Flag("dry-run", "Only report changes which would be made to blocks, without uploading the rewritten ones.").Bool()
+	deleteOriginals := cmd.Flag("delete-originals", "Mark rewritten blocks for deletion. Otherwise both original and rewritten blocks are left in the bucket, overlapping each other.").Default("true").Bool()
+
+	m[name+" rewrite"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		selectorLabels, err := parseFlagLabels(*selector)
+		if err != nil {
+			return errors.Wrap(err, "error parsing selector flag")
+		}
+		only := map[ulid.ULID]struct{}{}
+		for _, id := range *ids {
+			u, err := ulid.Parse(id)
+			if err != nil {
+				return errors.Wrapf(err, "invalid block ID %q", id)
+			}
+			only[u] = struct{}{}
+		}
+
+		rewriteContent, err := rewriteConf.Content()
+		if err != nil {
+			return err
+		}
+		deletions, relabelConfigs, err := parseRewriteConfig(rewriteContent)
+		if err != nil {
+			return err
+		}
+
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, name)
+		if err != nil {
+			return err
+		}
+
+		// Blocks already marked for deletion, e.g. rewritten before, are not rewritten again.
+		fetcher, err := block.NewMetaFetcher(logger, fetcherConcurrency, bkt, "", extprom.WrapRegistererWithPrefix(extpromPrefix, reg), []block.MetadataFilter{
+			block.NewIgnoreDeletionMarkFilter(logger, bkt, 0),
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx := context.Background()
+		metas, _, err := fetcher.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+
+		var blockMetas []*metadata.Meta
+		for id, meta := range metas {
+			if _, ok := only[id]; len(only) > 0 && !ok {
+				continue
+			}
+			if !matchesSelector(meta, selectorLabels) {
+				continue
+			}
+			blockMetas = append(blockMetas, meta)
+		}
+		sort.Slice(blockMetas, func(i, j int) bool { return blockMetas[i].ULID.Compare(blockMetas[j].ULID) < 0 })
+
+		if err := os.MkdirAll(*dataDir, 0777); err != nil {
+			return errors.Wrap(err, "create data directory")
+		}
+
+		var rewritten int
+		for i, meta := range blockMetas {
+			level.Info(logger).Log("msg", "rewriting block", "id", meta.ULID, "progress", fmt.Sprintf("%d/%d", i+1, len(blockMetas)))
+
+			changed, err := rewriteBlock(ctx, logger, bkt, *dataDir, meta.ULID, deletions, relabelConfigs, *dryRun, *deleteOriginals)
+			if err != nil {
+				return errors.Wrapf(err, "rewrite block %s", meta.ULID)
+			}
+			if changed {
+				rewritten++
+			}
+		}
+		level.Info(logger).Log("msg", "rewrite done", "blocks", len(blockMetas), "changed", rewritten, "dryRun", *dryRun)
+		return nil
+	}
+}
+
+// rewriteBlock downloads and rewrites the block. If it is changed, the new block is uploaded and the original one is
+// optionally marked for deletion, unless it is a dry run.
+func rewriteBlock(
+	ctx context.Context,
+	logger log.Logger,
+	bkt objstore.Bucket,
+	dataDir string,
+	id ulid.ULID,
+	deletions []block.DeletionRequest,
+	relabelConfigs []*relabel.Config,
+	dryRun, deleteOriginal bool,
+) (bool, error) {
+	bdir := filepath.Join(dataDir, id.String())
+	defer func() {
+		if err := os.RemoveAll(bdir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove block directory", "dir", bdir, "err", err)
+		}
+	}()
+	if err := block.Download(ctx, logger, bkt, id, bdir); err != nil {
+		return false, errors.Wrap(err, "download block")
+	}
+
+	resid, stats, err := block.Rewrite(logger, dataDir, id, metadata.BucketRewriteSource, deletions, relabelConfigs)
+	resdir := filepath.Join(dataDir, resid.String())
+	defer func() {
+		if err := os.RemoveAll(resdir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove block directory", "dir", resdir, "err", err)
+		}
+	}()
+	if err != nil {
+		return false, err
+	}
+
+	if !stats.Changed() {
+		level.Info(logger).Log("msg", "block not changed by rewrite, skipping", "id", id)
+		return false, nil
+	}
+	level.Info(logger).Log("msg", "block changed by rewrite", "id", id,
+		"seriesRelabeled", stats.SeriesRelabeled, "seriesDropped", stats.SeriesDropped,
+		"seriesDeleted", stats.SeriesDeleted, "samplesDeleted", stats.SamplesDeleted)
+	if dryRun {
+		return true, nil
+	}
+
+	if err := block.Upload(ctx, logger, bkt, resdir); err != nil {
+		return false, errors.Wrapf(err, "upload rewritten block %s", resid)
+	}
+	level.Info(logger).Log("msg", "uploaded rewritten block", "id", id, "newID", resid)
+	if deleteOriginal {
+		if err := block.MarkForDeletion(ctx, logger, bkt, id); err != nil {
+			return false, errors.Wrap(err, "mark original block for deletion")
+		}
+	}
+	return true, nil
+}
+
+func registerBucketMark(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
+	cmd := root.Command("mark", "Mark blocks for deletion, no compaction or no downsampling. Blocks are selected by their IDs or by external labels, time range, resolution, compaction level and markers.")
+	ids := cmd.Flag("id", "ID (ULID) of the block to mark (repeated). If specified, only these blocks are considered by the other selection flags.").Strings()
+	sel := regBlockSelectionFlags(cmd)
+	mark := cmd.Flag("mark", "Marker to apply to selected blocks. Options are 'deletion', 'no-compact' or 'no-downsample'.").
+		Required().Enum(markerDeletion, markerNoCompact, markerNoDownsample)
+	details := cmd.Flag("details", "Human readable explanation why the blocks are marked, recorded in no-compact and no-downsample markers.").String()
+	dryRun := cmd.Flag("dry-run", "Only print blocks which would be marked, without marking them.").Bool()
+
+	m[name+" mark"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		selector, err := sel.parse()
+		if err != nil {
+			return err
+		}
+		only := map[ulid.ULID]struct{}{}
+		for _, id := range *ids {
+			u, err := ulid.Parse(id)
+			if err != nil {
+				return errors.Wrapf(err, "invalid block ID %q", id)
+			}
+			only[u] = struct{}{}
+		}
+		// Marking every block in the bucket is rarely intended, e.g. after a typo in the flags.
+		if len(only) == 0 && selector.all {
+			return errors.New("no blocks selected; specify --id or at least one selection flag")
+		}
+
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, name)
+		if err != nil {
+			return err
+		}
+
+		fetcher, err := block.NewMetaFetcher(logger, fetcherConcurrency, bkt, "", extprom.WrapRegistererWithPrefix(extpromPrefix, reg), nil, nil)
+		if err != nil {
+			return err
+		}
+
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx := context.Background()
+		metas, _, err := fetcher.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+		if len(only) > 0 {
+			for id := range metas {
+				if _, ok := only[id]; !ok {
+					delete(metas, id)
+				}
+			}
+			for id := range only {
+				if _, ok := metas[id]; !ok {
+					return errors.Errorf("block %s not found in the bucket", id)
+				}
+			}
+		}
+
+		blockMetas, err := selector.selectBlocks(ctx, bkt, metas)
+		if err != nil {
+			return err
+		}
+
+		if *dryRun {
+			if err := printInspect(os.Stdout, blockMetas, "table", nil); err != nil {
+				return err
+			}
+			level.Info(logger).Log("msg", "dry run, no blocks marked", "blocks", len(blockMetas), "mark", *mark)
+			return nil
+		}
+
+		var marked int
+		for _, meta := range blockMetas {
+			ok, err := markBlock(ctx, logger, bkt, meta.ULID, *mark, *details)
+			if err != nil {
+				return errors.Wrapf(err, "mark block %s", meta.ULID)
+			}
+			if ok {
+				marked++
+			}
+		}
+		level.Info(logger).Log("msg", "marking done", "blocks", len(blockMetas), "marked", marked, "mark", *mark)
+		return nil
+	}
+}
+
+// markBlock applies the marker to the block. It returns false if the block already has the marker.
+func markBlock(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, mark, details string) (bool, error) {
+	markFiles := map[string]string{
+		markerDeletion:     metadata.DeletionMarkFilename,
+		markerNoCompact:    metadata.NoCompactMarkFilename,
+		markerNoDownsample: metadata.NoDownsampleMarkFilename,
+	}
+	ok, err := bkt.Exists(ctx, path.Join(id.String(), markFiles[mark]))
+	if err != nil {
+		return false, errors.Wrapf(err, "check %s mark", mark)
+	}
+	if ok {
+		level.Info(logger).Log("msg", "block already marked, skipping", "block", id, "mark", mark)
+		return false, nil
+	}
+
+	switch mark {
+	case markerDeletion:
+		return true, block.MarkForDeletion(ctx, logger, bkt, id)
+	case markerNoCompact:
+		return true, block.MarkForNoCompact(ctx, logger, bkt, id, metadata.ManualNoCompactReason, details)
+	case markerNoDownsample:
+		return true, block.MarkForNoDownsample(ctx, logger, bkt, id, details)
+	}
+	return false, errors.Errorf("unknown mark %q", mark)
+}
+
+func registerBucketSplit(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *extflag.PathOrContent) {
+	cmd := root.Command("split", "Split blocks exceeding index size or duration limits into multiple smaller blocks, e.g. blocks with indexes too big to be loaded by store gateways. Split blocks are uploaded as new blocks and the original ones are marked for deletion.")
+	ids := cmd.Flag("id", "ID (ULID) of the block to split (repeated). If specified, only these blocks are considered by the other selection flags.").Strings()
+	sel := regBlockSelectionFlags(cmd)
+	maxIndexSize := cmd.Flag("max-index-size", "Maximum size of the block index, e.g. 64GB. Bigger blocks are split into parts of index size below the limit, as estimated from the size of the original index. 0 means no limit.").
+		Default("0").Bytes()
+	maxDuration := modelDuration(cmd.Flag("max-duration", "Maximum time range of the block. Longer blocks are split into parts of equal time range below the limit. Requires --by=time. 0 means no limit.").Default("0s"))
+	by := cmd.Flag("by", "How to split blocks. 'time' splits them into consecutive time ranges, which keeps series spanning the whole block in all parts. 'series' splits series between parts by the hash of their labels, keeping the block time range. Downsampled blocks can be split by series only.").
+		Default(string(block.SplitBySeries)).Enum(string(block.SplitByTime), string(block.SplitBySeries))
+	dataDir := cmd.Flag("data-dir", "Data directory in which blocks are downloaded and split, one at a time.").Default("./data").String()
+	dryRun := cmd.Flag("dry-run", "Only report blocks which would be split, without splitting them.").Bool()
+	deleteOriginals := cmd.Flag("delete-originals", "Mark original blocks for deletion once they are split. Otherwise both original and new blocks are left in the bucket, overlapping each other.").Default("true").Bool()
+	markNoCompact := cmd.Flag("mark-no-compact", "Mark new blocks for no compaction, so compactor doesn't merge them back. Blocks split by series overlap each other, so compactor would compact them again, or halt without vertical compaction enabled.").Default("true").Bool()
+
+	m[name+" split"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		if *maxIndexSize == 0 && *maxDuration == 0 {
+			return errors.New("no limit specified; specify --max-index-size or --max-duration")
+		}
+		if *maxDuration > 0 && block.SplitBy(*by) != block.SplitByTime {
+			return errors.New("--max-duration requires --by=time")
+		}
+		selector, err := sel.parse()
+		if err != nil {
+			return err
+		}
+		only := map[ulid.ULID]struct{}{}
+		for _, id := range *ids {
+			u, err := ulid.Parse(id)
+			if err != nil {
+				return errors.Wrapf(err, "invalid block ID %q", id)
+			}
+			only[u] = struct{}{}
+		}
+
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, name)
+		if err != nil {
+			return err
+		}
+
+		// Blocks already marked for deletion, e.g. split before, are not split again.
+		fetcher, err := block.NewMetaFetcher(logger, fetcherConcurrency, bkt, "", extprom.WrapRegistererWithPrefix(extpromPrefix, reg), []block.MetadataFilter{
+			block.NewIgnoreDeletionMarkFilter(logger, bkt, 0),
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx := context.Background()
+		metas, _, err := fetcher.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+		if len(only) > 0 {
+			for id := range metas {
+				if _, ok := only[id]; !ok {
+					delete(metas, id)
+				}
+			}
+		}
+
+		blockMetas, err := selector.selectBlocks(ctx, bkt, metas)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(*dataDir, 0777); err != nil {
+			return errors.Wrap(err, "create data directory")
+		}
+
+		var split int
+		for _, meta := range blockMetas {
+			indexSize, err := bkt.ObjectSize(ctx, path.Join(meta.ULID.String(), block.IndexFilename))
+			if err != nil {
+				return errors.Wrapf(err, "get index size of block %s", meta.ULID)
+			}
+			n := splitParts(meta, indexSize, uint64(*maxIndexSize), time.Duration(*maxDuration))
+			if n < 2 {
+				continue
+			}
+			if block.SplitBy(*by) == block.SplitByTime && meta.Thanos.Downsample.Resolution > 0 {
+				level.Warn(logger).Log("msg", "downsampled block can't be split by time, skipping", "id", meta.ULID)
+				continue
+			}
+			split++
+
+			level.Info(logger).Log("msg", "splitting block", "id", meta.ULID, "indexSize", indexSize,
+				"duration", time.Duration(meta.MaxTime-meta.MinTime)*time.Millisecond, "parts", n, "by", *by)
+			if *dryRun {
+				continue
+			}
+			if _, err := splitBlock(ctx, logger, bkt, *dataDir, meta.ULID, block.SplitBy(*by), n, *deleteOriginals, *markNoCompact); err != nil {
+				return errors.Wrapf(err, "split block %s", meta.ULID)
+			}
+		}
+		level.Info(logger).Log("msg", "split done", "blocks", len(blockMetas), "split", split, "dryRun", *dryRun)
+		return nil
+	}
+}
+
+// splitParts returns the number of parts the block has to be split into to fit within the given limits. Zero limits
+// are ignored.
+func splitParts(meta *metadata.Meta, indexSize, maxIndexSize uint64, maxDuration time.Duration) int {
+	n := 1
+	if maxIndexSize > 0 && indexSize > maxIndexSize {
+		n = int((indexSize + maxIndexSize - 1) / maxIndexSize)
+	}
+	if max := maxDuration.Milliseconds(); max > 0 {
+		if d := meta.MaxTime - meta.MinTime; d > max {
+			if p := int((d + max - 1) / max); p > n {
+				n = p
+			}
+		}
+	}
+	return n
+}
+
+// splitBlock downloads and splits the block into n parts, which are uploaded as new blocks. The original block is
+// optionally marked for deletion and the new ones for no compaction. It returns IDs of the new blocks.
+func splitBlock(
+	ctx context.Context,
+	logger log.Logger,
+	bkt objstore.Bucket,
+	dataDir string,
+	id ulid.ULID,
+	by block.SplitBy,
+	n int,
+	deleteOriginal, markNoCompact bool,
+) ([]ulid.ULID, error) {
+	bdir := filepath.Join(dataDir, id.String())
+	defer func() {
+		if err := os.RemoveAll(bdir); err != nil {
+			level.Warn(logger).Log("msg", "failed to remove block directory", "dir", bdir, "err", err)
+		}
+	}()
+	if err := block.Download(ctx, logger, bkt, id, bdir); err != nil {
+		return nil, errors.Wrap(err, "download block")
+	}
+
+	resids, err := block.Split(logger, dataDir, id, metadata.BucketSplitSource, by, n)
+	defer func() {
+		for _, resid := range resids {
+			resdir := filepath.Join(dataDir, resid.String())
+			if err := os.RemoveAll(resdir); err != nil {
+				level.Warn(logger).Log("msg", "failed to remove block directory", "dir", resdir, "err", err)
+			}
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resid := range resids {
+		if err := block.Upload(ctx, logger, bkt, filepath.Join(dataDir, resid.String())); err != nil {
+			return nil, errors.Wrapf(err, "upload split block %s", resid)
+		}
+		if markNoCompact {
+			if err := block.MarkForNoCompact(ctx, logger, bkt, resid, metadata.ManualNoCompactReason, fmt.Sprintf("split from block %s", id)); err != nil {
+				return nil, errors.Wrapf(err, "mark split block %s for no compaction", resid)
+			}
+		}
+	}
+	level.Info(logger).Log("msg", "uploaded split blocks", "id", id, "newIDs", fmt.Sprint(resids))
+	if deleteOriginal {
+		if err := block.MarkForDeletion(ctx, logger, bkt, id); err != nil {
+			return nil, errors.Wrap(err, "mark original block for deletion")
+		}
+	}
+	return resids, nil
+}
+
+// inspectRows are table lines of blocks, sorted together with the blocks.
+type inspectRows struct {
+	Table
+	metas []*metadata.Meta
+}
+
+func (r inspectRows) Swap(i, j int) {
+	r.Table.Swap(i, j)
+	r.metas[i], r.metas[j] = r.metas[j], r.metas[i]
+}
+
+// printInspect prints blocks sorted by the given columns in the given format.
+func printInspect(w io.Writer, blockMetas []*metadata.Meta, output string, sortBy []string) error {
+	header := inspectColumns
+
+	var lines [][]string
+	p := message.NewPrinter(language.English)
+
+	for _, blockMeta := range blockMetas {
+		timeRange := time.Duration((blockMeta.MaxTime - blockMeta.MinTime) * int64(time.Millisecond))
+
+		untilDown := "-"
+		if until, err := compact.UntilNextDownsampling(blockMeta); err == nil {
+			untilDown = until.String()
+		}
+		var labels []string
+		for _, key := range getKeysAlphabetically(blockMeta.Thanos.Labels) {
+			labels = append(labels, fmt.Sprintf("%s=%s", key, blockMeta.Thanos.Labels[key]))
+		}
+
+		var line []string
+		line = append(line, blockMeta.ULID.String())
+		line = append(line, time.Unix(blockMeta.MinTime/1000, 0).Format("02-01-2006 15:04:05"))
+		line = append(line, time.Unix(blockMeta.MaxTime/1000, 0).Format("02-01-2006 15:04:05"))
+		line = append(line, timeRange.String())
+		line = append(line, untilDown)
+		line = append(line, p.Sprintf("%d", blockMeta.Stats.NumSeries))
+		line = append(line, p.Sprintf("%d", blockMeta.Stats.NumSamples))
+		line = append(line, p.Sprintf("%d", blockMeta.Stats.NumChunks))
+		line = append(line, p.Sprintf("%d", blockMeta.Compaction.Level))
+		line = append(line, p.Sprintf("%t", blockMeta.Compaction.Failed))
+		line = append(line, strings.Join(labels, ","))
+		line = append(line, time.Duration(blockMeta.Thanos.Downsample.Resolution*int64(time.Millisecond)).String())
+		line = append(line, string(blockMeta.Thanos.Source))
+		lines = append(lines, line)
+	}
+
+	var sortByColNum []int
+	for _, col := range sortBy {
+		index := getIndex(header, col)
+		if index == -1 {
+			return errors.Errorf("column %s not found", col)
+		}
+		sortByColNum = append(sortByColNum, index)
+	}
+
+	metas := append([]*metadata.Meta{}, blockMetas...)
+	t := inspectRows{Table: Table{Header: header, Lines: lines, SortIndices: sortByColNum}, metas: metas}
+	sort.Sort(t)
+
+	switch output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(metas)
+	case "csv":
+		return printBlocksCSV(w, metas)
+	case "prometheus":
+		return printBlocksPrometheus(w, metas)
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(t.Header)
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetAutoWrapText(false)
+	table.SetReflowDuringAutoWrap(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.AppendBulk(t.Lines)
+	table.Render()
+
+	return nil
+}
+
+// printBlocksCSV prints blocks as CSV with the inspect columns. Times are in RFC3339 format and numbers are not
+// formatted, so the output can be easily consumed by other tools.
+func printBlocksCSV(w io.Writer, metas []*metadata.Meta) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(inspectColumns); err != nil {
+		return err
+	}
+	for _, m := range metas {
+		untilDown := ""
+		if until, err := compact.UntilNextDownsampling(m); err == nil {
+			untilDown = until.String()
+		}
+		var lbls []string
+		for _, key := range getKeysAlphabetically(m.Thanos.Labels) {
+			lbls = append(lbls, fmt.Sprintf("%s=%s", key, m.Thanos.Labels[key]))
+		}
+		if err := cw.Write([]string{
+			m.ULID.String(),
+			timestamp.Time(m.MinTime).UTC().Format(time.RFC3339),
+			timestamp.Time(m.MaxTime).UTC().Format(time.RFC3339),
+			(time.Duration(m.MaxTime-m.MinTime) * time.Millisecond).String(),
+			untilDown,
+			strconv.FormatUint(m.Stats.NumSeries, 10),
+			strconv.FormatUint(m.Stats.NumSamples, 10),
+			strconv.FormatUint(m.Stats.NumChunks, 10),
+			strconv.Itoa(m.Compaction.Level),
+			strconv.FormatBool(m.Compaction.Failed),
+			strings.Join(lbls, ","),
+			(time.Duration(m.Thanos.Downsample.Resolution) * time.Millisecond).String(),
+			string(m.Thanos.Source),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// printBlocksPrometheus prints blocks as gauges in the Prometheus text exposition format, e.g. to be exposed by the
+// node exporter textfile collector. Series are labeled by block ID, resolution, compaction level, source and the
+// external labels of the block.
+func printBlocksPrometheus(w io.Writer, metas []*metadata.Meta) error {
+	type blockGauge struct {
+		name, help string
+		value      func(m *metadata.Meta) float64
+	}
+	gauges := []blockGauge{
+		{"thanos_bucket_block_min_time_seconds", "Start of the block time range.", func(m *metadata.Meta) float64 { return float64(m.MinTime) / 1000 }},
+		{"thanos_bucket_block_max_time_seconds", "End of the block time range.", func(m *metadata.Meta) float64 { return float64(m.MaxTime) / 1000 }},
+		{"thanos_bucket_block_series", "Number of series in the block.", func(m *metadata.Meta) float64 { return float64(m.Stats.NumSeries) }},
+		{"thanos_bucket_block_samples", "Number of samples in the block.", func(m *metadata.Meta) float64 { return float64(m.Stats.NumSamples) }},
+		{"thanos_bucket_block_chunks", "Number of chunks in the block.", func(m *metadata.Meta) float64 { return float64(m.Stats.NumChunks) }},
+	}
+
+	for _, g := range gauges {
+		mf := &dto.MetricFamily{
+			Name: proto.String(g.name),
+			Help: proto.String(g.help),
+			Type: dto.MetricType_GAUGE.Enum(),
+		}
+		for _, m := range metas {
+			mf.Metric = append(mf.Metric, &dto.Metric{
+				Label: blockMetricLabels(m),
+				Gauge: &dto.Gauge{Value: proto.Float64(g.value(m))},
+			})
+		}
+		if _, err := expfmt.MetricFamilyToText(w, mf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func blockMetricLabels(m *metadata.Meta) []*dto.LabelPair {
+	lset := labels.Labels{
+		{Name: "ulid", Value: m.ULID.String()},
+		{Name: "resolution", Value: (time.Duration(m.Thanos.Downsample.Resolution) * time.Millisecond).String()},
+		{Name: "compaction_level", Value: strconv.Itoa(m.Compaction.Level)},
+		{Name: "source", Value: string(m.Thanos.Source)},
+	}
+	for name, value := range m.Thanos.Labels {
+		// External labels clashing with block labels are prefixed, as Prometheus does for exported labels.
+		if lset.Has(name) {
+			name = "exported_" + name
+		}
+		lset = append(lset, labels.Label{Name: name, Value: value})
+	}
+	sort.Sort(lset)
+
+	pairs := make([]*dto.LabelPair, 0, len(lset))
+	for _, l := range lset {
+		pairs = append(pairs, &dto.LabelPair{Name: proto.String(l.Name), Value: proto.String(l.Value)})
+	}
+	return pairs
+}
+
+func getKeysAlphabetically(labels map[string]string) []string {
+	var keys []string
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matchesSelector checks if blockMeta contains every label from
+// the selector with the correct value.
+func matchesSelector(blockMeta *metadata.Meta, selectorLabels labels.Labels) bool {
+	for _, l := range selectorLabels {
+		if v, ok := blockMeta.Thanos.Labels[l.Name]; !ok || v != l.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// Marker states of blocks selectable by the --marker flag.
+const (
+	markerAny          = "any"
+	markerNone         = "none"
+	markerDeletion     = "deletion"
+	markerNoCompact    = "no-compact"
+	markerNoDownsample = "no-downsample"
+
+	// Defaults of time range selection flags, selecting blocks of any time.
+	selectionMinTime = "0000-01-01T00:00:00Z"
+	selectionMaxTime = "9999-12-31T23:59:59Z"
+)
+
+// blockSelectionFlags are flags of bucket subcommands selecting blocks by their meta and markers.
+type blockSelectionFlags struct {
+	selector    *[]string
+	minTime     *thanosmodel.TimeOrDurationValue
+	maxTime     *thanosmodel.TimeOrDurationValue
+	resolutions *[]string
+	compactions *[]int
+	marker      *string
+}
+
+func regBlockSelectionFlags(cmd *kingpin.CmdClause) *blockSelectionFlags {
+	return &blockSelectionFlags{
+		selector: cmd.Flag("selector", "Selects blocks based on label, e.g. '-l key1=\\\"value1\\\" -l key2=~\\\"value2.*\\\"'. All matchers must match.").Short('l').
+			PlaceHolder("<name>=\\\"<value>\\\"").Strings(),
+		minTime: thanosmodel.TimeOrDuration(cmd.Flag("min-time", "Selects blocks overlapping the time range starting at this time. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+			Default(selectionMinTime)),
+		maxTime: thanosmodel.TimeOrDuration(cmd.Flag("max-time", "Selects blocks overlapping the time range ending at this time. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+			Default(selectionMaxTime)),
+		resolutions: cmd.Flag("resolution", "Selects blocks of this resolution, e.g. 0s, 5m or 1h (repeated). If none is specified, blocks of all resolutions are selected.").
+			PlaceHolder("<duration>").Strings(),
+		compactions: cmd.Flag("compaction-level", "Selects blocks of this compaction level (repeated). If none is specified, blocks of all levels are selected.").
+			PlaceHolder("<level>").Ints(),
+		marker: cmd.Flag("marker", "Selects blocks by their markers: 'any' selects all blocks, 'none' blocks without any marker, 'deletion' blocks marked for deletion, 'no-compact' blocks marked for no compaction and 'no-downsample' blocks marked for no downsampling.").
+			Default(markerAny).Enum(markerAny, markerNone, markerDeletion, markerNoCompact, markerNoDownsample),
+	}
+}
+
+// blockSelector selects blocks by their meta and markers.
+type blockSelector struct {
+	matchers         []*labels.Matcher
+	minTime, maxTime int64
+	resolutions      map[int64]struct{}
+	compactions      map[int]struct{}
+	marker           string
+	// all is true if no selection flag was set, so all blocks are selected.
+	all bool
+}
+
+func (f *blockSelectionFlags) parse() (*blockSelector, error) {
+	sel := &blockSelector{
+		minTime:     f.minTime.PrometheusTimestamp(),
+		maxTime:     f.maxTime.PrometheusTimestamp(),
+		resolutions: map[int64]struct{}{},
+		compactions: map[int]struct{}{},
+		marker:      *f.marker,
+	}
+	if sel.minTime > sel.maxTime {
+		return nil, errors.Errorf("invalid time range: --min-time %s is after --max-time %s", f.minTime, f.maxTime)
+	}
+	for _, s := range *f.selector {
+		// Matchers are parsed as part of a selector, so the former key="value" syntax keeps working.
+		ms, err := promql.ParseMetricSelector("{" + s + "}")
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse selector %q", s)
+		}
+		sel.matchers = append(sel.matchers, ms...)
+	}
+	for _, r := range *f.resolutions {
+		d, err := model.ParseDuration(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse resolution %q", r)
+		}
+		sel.resolutions[int64(time.Duration(d)/time.Millisecond)] = struct{}{}
+	}
+	for _, c := range *f.compactions {
+		sel.compactions[c] = struct{}{}
+	}
+	sel.all = len(sel.matchers) == 0 && len(sel.resolutions) == 0 && len(sel.compactions) == 0 && sel.marker == markerAny &&
+		isDefaultTime(f.minTime, selectionMinTime) && isDefaultTime(f.maxTime, selectionMaxTime)
+	return sel, nil
+}
+
+func isDefaultTime(v *thanosmodel.TimeOrDurationValue, def string) bool {
+	return v.Time != nil && v.Time.Format(time.RFC3339) == def
+}
+
+// matches returns true if the block meta matches the selector, regardless of markers.
+func (s *blockSelector) matches(m *metadata.Meta) bool {
+	for _, matcher := range s.matchers {
+		if !matcher.Matches(m.Thanos.Labels[matcher.Name]) {
+			return false
+		}
+	}
+	if m.MaxTime < s.minTime || m.MinTime > s.maxTime {
+		return false
+	}
+	if _, ok := s.resolutions[m.Thanos.Downsample.Resolution]; len(s.resolutions) > 0 && !ok {
+		return false
+	}
+	if _, ok := s.compactions[m.Compaction.Level]; len(s.compactions) > 0 && !ok {
+		return false
+	}
+	return true
+}
+
+// selectBlocks returns blocks matching the selector, sorted by ID. Markers are checked only for blocks matching the
+// selector and only if selecting by marker state.
+func (s *blockSelector) selectBlocks(ctx context.Context, bkt objstore.BucketReader, metas map[ulid.ULID]*metadata.Meta) ([]*metadata.Meta, error) {
+	selected := make([]*metadata.Meta, 0, len(metas))
+	for _, m := range metas {
+		if !s.matches(m) {
+			continue
+		}
+		if s.marker != markerAny {
+			deletion, err := bkt.Exists(ctx, path.Join(m.ULID.String(), metadata.DeletionMarkFilename))
+			if err != nil {
+				return nil, errors.Wrapf(err, "check deletion mark of block %s", m.ULID)
+			}
+			noCompact, err := bkt.Exists(ctx, path.Join(m.ULID.String(), metadata.NoCompactMarkFilename))
+			if err != nil {
+				return nil, errors.Wrapf(err, "check no-compact mark of block %s", m.ULID)
+			}
+			noDownsample, err := bkt.Exists(ctx, path.Join(m.ULID.String(), metadata.NoDownsampleMarkFilename))
+			if err != nil {
+				return nil, errors.Wrapf(err, "check no-downsample mark of block %s", m.ULID)
+			}
+			switch {
+			case s.marker == markerNone && (deletion || noCompact || noDownsample),
+				s.marker == markerDeletion && !deletion,
+				s.marker == markerNoCompact && !noCompact,
+				s.marker == markerNoDownsample && !noDownsample:
+				continue
+			}
+		}
+		selected = append(selected, m)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].ULID.Compare(selected[j].ULID) < 0 })
+	return selected, nil
 }
 
 // getIndex calculates the index of s in strs.
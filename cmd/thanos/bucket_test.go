@@ -0,0 +1,215 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestParseRewriteConfig(t *testing.T) {
+	deletions, relabelConfigs, err := parseRewriteConfig([]byte(`
+deletions:
+  - matchers: '{__name__="up", job=~"node.*"}'
+  - matchers: '{job="test"}'
+    min_time: 2020-01-01T00:00:00Z
+    max_time: 2020-01-02T00:00:00Z
+relabel_configs:
+  - action: labeldrop
+    regex: pod
+`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(deletions))
+	testutil.Equals(t, 2, len(deletions[0].Matchers))
+	testutil.Equals(t, labels.MatchRegexp, deletions[0].Matchers[1].Type)
+	testutil.Equals(t, int64(math.MinInt64), deletions[0].MinTime)
+	testutil.Equals(t, int64(math.MaxInt64), deletions[0].MaxTime)
+	testutil.Equals(t, int64(1577836800000), deletions[1].MinTime)
+	testutil.Equals(t, int64(1577923200000), deletions[1].MaxTime)
+	testutil.Equals(t, 1, len(relabelConfigs))
+
+	for _, conf := range []string{
+		``,
+		`deletions: [{matchers: '{job='}]`,
+		`deletions: [{matchers: '{job="a"}', min_time: 2020-01-02T00:00:00Z, max_time: 2020-01-01T00:00:00Z}]`,
+		`deletions: [{matchers: '{job="a"}', min_time: yesterday}]`,
+		`unknown: []`,
+	} {
+		_, _, err := parseRewriteConfig([]byte(conf))
+		testutil.NotOk(t, err)
+	}
+}
+
+func TestBlockSelector(t *testing.T) {
+	ctx := context.Background()
+	bkt := inmem.NewBucket()
+
+	newMeta := func(id uint64, cluster string, mint, maxt int64, res int64, level int) *metadata.Meta {
+		m := &metadata.Meta{}
+		m.ULID = ulid.MustNew(id, nil)
+		m.MinTime, m.MaxTime = mint, maxt
+		m.Compaction.Level = level
+		m.Thanos.Labels = map[string]string{"cluster": cluster}
+		m.Thanos.Downsample.Resolution = res
+		return m
+	}
+	metas := map[ulid.ULID]*metadata.Meta{}
+	for _, m := range []*metadata.Meta{
+		newMeta(1, "eu-1", 0, 100, 0, 1),
+		newMeta(2, "eu-2", 100, 200, 300000, 2),
+		newMeta(3, "us-1", 200, 300, 0, 1),
+	} {
+		metas[m.ULID] = m
+	}
+	testutil.Ok(t, block.MarkForDeletion(ctx, log.NewNopLogger(), bkt, ulid.MustNew(3, nil)))
+
+	for _, c := range []struct {
+		args     []string
+		expected []uint64
+	}{
+		{expected: []uint64{1, 2, 3}},
+		{args: []string{"-l", `cluster=~"eu.*"`}, expected: []uint64{1, 2}},
+		{args: []string{"-l", `cluster="eu-1"`}, expected: []uint64{1}},
+		{args: []string{"--min-time=1970-01-01T00:00:00.150Z", "--max-time=1970-01-01T00:00:00.250Z"}, expected: []uint64{2, 3}},
+		{args: []string{"--resolution=5m"}, expected: []uint64{2}},
+		{args: []string{"--compaction-level=1"}, expected: []uint64{1, 3}},
+		{args: []string{"--marker=deletion"}, expected: []uint64{3}},
+		{args: []string{"--marker=none", "--compaction-level=1"}, expected: []uint64{1}},
+	} {
+		t.Run(strings.Join(c.args, " "), func(t *testing.T) {
+			app := kingpin.New("test", "")
+			cmd := app.Command("ls", "")
+			flags := regBlockSelectionFlags(cmd)
+			_, err := app.Parse(append([]string{"ls"}, c.args...))
+			testutil.Ok(t, err)
+
+			sel, err := flags.parse()
+			testutil.Ok(t, err)
+			selected, err := sel.selectBlocks(ctx, bkt, metas)
+			testutil.Ok(t, err)
+
+			var ids []uint64
+			for _, m := range selected {
+				ids = append(ids, m.ULID.Time())
+			}
+			testutil.Equals(t, c.expected, ids)
+		})
+	}
+}
+
+func TestPrintBlocks(t *testing.T) {
+	m := &metadata.Meta{}
+	m.ULID = ulid.MustNew(1, nil)
+	m.MinTime, m.MaxTime = 0, 7200000
+	m.Stats.NumSeries = 10
+	m.Compaction.Level = 1
+	m.Thanos.Labels = map[string]string{"cluster": "eu-1", "source": "x"}
+	m.Thanos.Source = metadata.SidecarSource
+
+	var b bytes.Buffer
+	testutil.Ok(t, printBlocksCSV(&b, []*metadata.Meta{m}))
+	testutil.Equals(t, `ULID,FROM,UNTIL,RANGE,UNTIL-DOWN,#SERIES,#SAMPLES,#CHUNKS,COMP-LEVEL,COMP-FAILED,LABELS,RESOLUTION,SOURCE
+00000000010000000000000000,1970-01-01T00:00:00Z,1970-01-01T02:00:00Z,2h0m0s,38h0m0s,10,0,0,1,false,"cluster=eu-1,source=x",0s,sidecar
+`, b.String())
+
+	b.Reset()
+	testutil.Ok(t, printBlocksPrometheus(&b, []*metadata.Meta{m}))
+	testutil.Assert(t, strings.Contains(b.String(), `thanos_bucket_block_series{cluster="eu-1",compaction_level="1",exported_source="x",resolution="0s",source="sidecar",ulid="00000000010000000000000000"} 10`), b.String())
+}
+
+func TestMarkBlock(t *testing.T) {
+	ctx := context.Background()
+	bkt := inmem.NewBucket()
+	id := ulid.MustNew(1, nil)
+
+	for _, mark := range []string{markerDeletion, markerNoCompact, markerNoDownsample} {
+		ok, err := markBlock(ctx, log.NewNopLogger(), bkt, id, mark, "test")
+		testutil.Ok(t, err)
+		testutil.Assert(t, ok, "block should be marked for %s", mark)
+
+		// Already marked blocks are skipped.
+		ok, err = markBlock(ctx, log.NewNopLogger(), bkt, id, mark, "test")
+		testutil.Ok(t, err)
+		testutil.Assert(t, !ok, "block should not be marked for %s again", mark)
+	}
+
+	noCompact, err := metadata.ReadNoCompactMark(ctx, bkt, log.NewNopLogger(), id.String())
+	testutil.Ok(t, err)
+	testutil.Equals(t, metadata.ManualNoCompactReason, noCompact.Reason)
+	testutil.Equals(t, "test", noCompact.Details)
+
+	noDownsample, err := metadata.ReadNoDownsampleMark(ctx, bkt, log.NewNopLogger(), id.String())
+	testutil.Ok(t, err)
+	testutil.Equals(t, "test", noDownsample.Details)
+}
+
+func TestSplitParts(t *testing.T) {
+	meta := &metadata.Meta{}
+	meta.MinTime, meta.MaxTime = 0, int64(10*time.Hour/time.Millisecond)
+
+	testutil.Equals(t, 1, splitParts(meta, 100, 0, 0))
+	testutil.Equals(t, 1, splitParts(meta, 100, 100, 10*time.Hour))
+	testutil.Equals(t, 2, splitParts(meta, 101, 100, 0))
+	testutil.Equals(t, 4, splitParts(meta, 100, 0, 3*time.Hour))
+	testutil.Equals(t, 5, splitParts(meta, 450, 100, 3*time.Hour))
+}
+
+func TestSplitBlock(t *testing.T) {
+	ctx := context.Background()
+	bkt := inmem.NewBucket()
+
+	tmpDir, err := ioutil.TempDir("", "test-split-block")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	var series []labels.Labels
+	for i := 0; i < 10; i++ {
+		series = append(series, labels.FromStrings("a", fmt.Sprint(i)))
+	}
+	id, err := e2eutil.CreateBlock(ctx, tmpDir, series, 10, 0, 1000, labels.Labels{{Name: "ext1", Value: "val1"}}, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(tmpDir, id.String())))
+
+	dataDir := filepath.Join(tmpDir, "data")
+	testutil.Ok(t, os.MkdirAll(dataDir, 0777))
+	resids, err := splitBlock(ctx, log.NewNopLogger(), bkt, dataDir, id, block.SplitByTime, 2, true, true)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(resids))
+
+	_, err = metadata.ReadDeletionMark(ctx, bkt, log.NewNopLogger(), id.String())
+	testutil.Ok(t, err)
+	for _, resid := range resids {
+		ok, err := bkt.Exists(ctx, path.Join(resid.String(), block.MetaFilename))
+		testutil.Ok(t, err)
+		testutil.Assert(t, ok, "split block %s not uploaded", resid)
+
+		noCompact, err := metadata.ReadNoCompactMark(ctx, bkt, log.NewNopLogger(), resid.String())
+		testutil.Ok(t, err)
+		testutil.Equals(t, metadata.ManualNoCompactReason, noCompact.Reason)
+	}
+
+	// Local copies of blocks are removed.
+	files, err := ioutil.ReadDir(dataDir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(files))
+}
@@ -4,15 +4,25 @@
 package main
 
 import (
+	"encoding/json"
+	"io"
 	"io/ioutil"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/oklog/run"
 	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/rulefmt"
-	"github.com/prometheus/prometheus/tsdb/errors"
+	"github.com/prometheus/prometheus/promql"
+	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
+	thanosrule "github.com/thanos-io/thanos/pkg/rule"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"gopkg.in/alecthomas/kingpin.v2"
 	"gopkg.in/yaml.v2"
 )
@@ -28,38 +38,88 @@ func registerCheckRules(m map[string]setupFunc, root *kingpin.CmdClause, name st
 		"rule-files",
 		"The rule files to check.",
 	).Required().ExistingFiles()
+	evalInterval := modelDuration(checkRulesCmd.Flag("eval-interval", "The default evaluation interval of groups without an interval, as configured in Thanos Rule.").
+		Default("30s"))
+	lookbackDelta := modelDuration(checkRulesCmd.Flag("query.lookback-delta", "Lookback delta of the queried query API. Groups evaluated less often than that produce series with gaps when queried by instant vector selectors.").
+		Default("5m"))
+	retention := modelDuration(checkRulesCmd.Flag("retention", "Retention of the data queried by rules. Rules looking further back in time, including the query offset of their groups, fail the check. 0d disables the check.").
+		Default("0d"))
+	output := checkRulesCmd.Flag("output", "Format of the results. Options are 'text', which logs results, or 'json', which prints results of all files to stdout.").
+		Default("text").Enum("text", "json")
 
 	m[name+" rules"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
 		// Dummy actor to immediately kill the group after the run function returns.
 		g.Add(func() error { return nil }, func(error) {})
-		return checkRulesFiles(logger, ruleFiles)
+
+		conf := rulesCheckConfig{
+			evalInterval:  time.Duration(*evalInterval),
+			lookbackDelta: time.Duration(*lookbackDelta),
+			retention:     time.Duration(*retention),
+		}
+		results, err := checkRulesFiles(logger, ruleFiles, conf)
+		if *output == "json" {
+			if err := printRulesCheckResults(os.Stdout, results); err != nil {
+				return err
+			}
+		}
+		return err
 	}
 }
 
-func checkRulesFiles(logger log.Logger, files *[]string) error {
-	failed := errors.MultiError{}
+// rulesCheckConfig configures checks of rules against the setup they are evaluated in.
+type rulesCheckConfig struct {
+	evalInterval  time.Duration
+	lookbackDelta time.Duration
+	// retention is the retention of queried data. Zero means unlimited retention.
+	retention time.Duration
+}
+
+// rulesCheckResult is the result of the check of a single rule file.
+type rulesCheckResult struct {
+	File    string   `json:"file"`
+	Success bool     `json:"success"`
+	Rules   int      `json:"rules"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+func checkRulesFiles(logger log.Logger, files *[]string, conf rulesCheckConfig) ([]rulesCheckResult, error) {
+	failed := tsdberrors.MultiError{}
+	results := make([]rulesCheckResult, 0, len(*files))
 
 	for _, f := range *files {
-		n, errs := checkRules(logger, f)
+		n, errs := checkRules(logger, f, conf)
+		res := rulesCheckResult{File: f, Success: errs.Err() == nil, Rules: n}
 		if errs.Err() != nil {
 			level.Error(logger).Log("result", "FAILED")
 			for _, e := range errs {
 				level.Error(logger).Log("error", e.Error())
 				failed.Add(e)
+				res.Errors = append(res.Errors, e.Error())
 			}
 			level.Info(logger).Log()
+			results = append(results, res)
 			continue
 		}
 		level.Info(logger).Log("result", "SUCCESS", "rules found", n)
+		results = append(results, res)
 	}
 	if failed.Err() != nil {
-		return failed
+		return results, failed
 	}
-	return nil
+	return results, nil
+}
+
+func printRulesCheckResults(w io.Writer, results []rulesCheckResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
 }
 
 type ThanosRuleGroup struct {
-	PartialResponseStrategy string `yaml:"partial_response_strategy"`
+	PartialResponseStrategy string         `yaml:"partial_response_strategy"`
+	QueryConfigName         string         `yaml:"query_config_name"`
+	SourceTenants           []string       `yaml:"source_tenants"`
+	QueryOffset             model.Duration `yaml:"query_offset"`
 	rulefmt.RuleGroup       `yaml:",inline"`
 }
 
@@ -67,9 +127,9 @@ type ThanosRuleGroups struct {
 	Groups []ThanosRuleGroup `yaml:"groups"`
 }
 
-func checkRules(logger log.Logger, filename string) (int, errors.MultiError) {
+func checkRules(logger log.Logger, filename string, conf rulesCheckConfig) (int, tsdberrors.MultiError) {
 	level.Info(logger).Log("msg", "checking", "filename", filename)
-	checkErrors := errors.MultiError{}
+	checkErrors := tsdberrors.MultiError{}
 
 	b, err := ioutil.ReadFile(filename)
 	if err != nil {
@@ -92,6 +152,15 @@ func checkRules(logger log.Logger, filename string) (int, errors.MultiError) {
 		return 0, checkErrors
 	}
 
+	for _, rg := range rgs.Groups {
+		for _, err := range checkThanosRuleGroup(rg, conf) {
+			checkErrors.Add(errors.Wrapf(err, "group %q", rg.Name))
+		}
+	}
+	if checkErrors.Err() != nil {
+		return 0, checkErrors
+	}
+
 	numRules := 0
 	for _, rg := range rgs.Groups {
 		numRules += len(rg.Rules)
@@ -128,3 +197,73 @@ func thanosRuleGroupsToPromRuleGroups(ruleGroups ThanosRuleGroups) rulefmt.RuleG
 	}
 	return promRuleGroups
 }
+
+// checkThanosRuleGroup validates Thanos extensions of the rule group and checks if its rules can be evaluated with the
+// given configuration.
+func checkThanosRuleGroup(rg ThanosRuleGroup, conf rulesCheckConfig) []error {
+	var errs []error
+	if rg.PartialResponseStrategy != "" {
+		if _, ok := storepb.PartialResponseStrategy_value[strings.ToUpper(rg.PartialResponseStrategy)]; !ok {
+			errs = append(errs, errors.Errorf("invalid partial_response_strategy %q, possible values are %s",
+				rg.PartialResponseStrategy, strings.Join(storepb.PartialResponseStrategyValues, ",")))
+		}
+	}
+	if err := thanosrule.ValidateSourceTenants(rg.SourceTenants); err != nil {
+		errs = append(errs, err)
+	}
+
+	interval := time.Duration(rg.Interval)
+	if interval == 0 {
+		interval = conf.evalInterval
+	}
+	if conf.lookbackDelta > 0 && interval > conf.lookbackDelta {
+		errs = append(errs, errors.Errorf("interval %v exceeds query lookback delta %v, so series of the group have gaps between evaluations",
+			model.Duration(interval), model.Duration(conf.lookbackDelta)))
+	}
+
+	if conf.retention == 0 {
+		return errs
+	}
+	for _, r := range rg.Rules {
+		expr, err := promql.ParseExpr(r.Expr)
+		if err != nil {
+			// Already reported by the Prometheus validation.
+			continue
+		}
+		if lookback := ruleLookback(expr, conf.lookbackDelta) + time.Duration(rg.QueryOffset); lookback > conf.retention {
+			name := r.Record
+			if name == "" {
+				name = r.Alert
+			}
+			errs = append(errs, errors.Errorf("rule %q queries data %v back in time, beyond retention %v",
+				name, model.Duration(lookback), model.Duration(conf.retention)))
+		}
+	}
+	return errs
+}
+
+// ruleLookback returns how far back in time from the evaluation time the expression reads data.
+func ruleLookback(expr promql.Expr, lookbackDelta time.Duration) time.Duration {
+	var max time.Duration
+	promql.Inspect(expr, func(node promql.Node, path []promql.Node) error {
+		var d time.Duration
+		switch n := node.(type) {
+		case *promql.VectorSelector:
+			d = n.Offset + lookbackDelta
+		case *promql.MatrixSelector:
+			d = n.Offset + n.Range
+		default:
+			return nil
+		}
+		for _, p := range path {
+			if sq, ok := p.(*promql.SubqueryExpr); ok {
+				d += sq.Offset + sq.Range
+			}
+		}
+		if d > max {
+			max = d
+		}
+		return nil
+	})
+	return max
+}
@@ -4,9 +4,13 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -14,6 +18,7 @@ func Test_checkRules(t *testing.T) {
 
 	validFiles := []string{
 		"./testdata/rules-files/valid.yaml",
+		"./testdata/rules-files/long-range.yaml",
 	}
 
 	invalidFiles := [][]string{
@@ -21,13 +26,64 @@ func Test_checkRules(t *testing.T) {
 		[]string{"./testdata/rules-files/invalid-yaml-format.yaml"},
 		[]string{"./testdata/rules-files/invalid-rules-data.yaml"},
 		[]string{"./testdata/rules-files/invalid-unknown-field.yaml"},
+		[]string{"./testdata/rules-files/invalid-thanos-fields.yaml"},
+		[]string{"./testdata/rules-files/invalid-lookback.yaml"},
 	}
 
 	logger := log.NewNopLogger()
+	conf := rulesCheckConfig{evalInterval: 30 * time.Second, lookbackDelta: 5 * time.Minute}
 
-	testutil.Ok(t, checkRulesFiles(logger, &validFiles))
+	_, err := checkRulesFiles(logger, &validFiles, conf)
+	testutil.Ok(t, err)
 
 	for _, fn := range invalidFiles {
-		testutil.NotOk(t, checkRulesFiles(logger, &fn))
+		_, err := checkRulesFiles(logger, &fn, conf)
+		testutil.NotOk(t, err)
+	}
+
+	// Rules looking back beyond retention fail, including the query offset of their group.
+	conf.retention = 30 * 24 * time.Hour
+	_, err = checkRulesFiles(logger, &[]string{"./testdata/rules-files/long-range.yaml"}, conf)
+	testutil.NotOk(t, err)
+	conf.retention = 31 * 24 * time.Hour
+	_, err = checkRulesFiles(logger, &[]string{"./testdata/rules-files/long-range.yaml"}, conf)
+	testutil.Ok(t, err)
+}
+
+func Test_checkRules_JSON(t *testing.T) {
+	files := []string{
+		"./testdata/rules-files/valid.yaml",
+		"./testdata/rules-files/invalid-thanos-fields.yaml",
+	}
+	results, err := checkRulesFiles(log.NewNopLogger(), &files, rulesCheckConfig{evalInterval: 30 * time.Second})
+	testutil.NotOk(t, err)
+
+	var buf bytes.Buffer
+	testutil.Ok(t, printRulesCheckResults(&buf, results))
+
+	var got []rulesCheckResult
+	testutil.Ok(t, json.Unmarshal(buf.Bytes(), &got))
+	testutil.Equals(t, 2, len(got))
+	testutil.Equals(t, rulesCheckResult{File: files[0], Success: true, Rules: 3}, got[0])
+	testutil.Assert(t, !got[1].Success, "expected failed check of %s", files[1])
+	testutil.Equals(t, 2, len(got[1].Errors))
+}
+
+func Test_ruleLookback(t *testing.T) {
+	for _, tcase := range []struct {
+		expr     string
+		expected time.Duration
+	}{
+		{expr: `1`, expected: 0},
+		{expr: `up`, expected: 5 * time.Minute},
+		{expr: `up offset 1h`, expected: time.Hour + 5*time.Minute},
+		{expr: `rate(http_requests_total[10m]) / up`, expected: 10 * time.Minute},
+		{expr: `max_over_time(rate(http_requests_total[5m])[1h:1m] offset 1d)`, expected: 25*time.Hour + 5*time.Minute},
+	} {
+		t.Run(tcase.expr, func(t *testing.T) {
+			expr, err := promql.ParseExpr(tcase.expr)
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.expected, ruleLookback(expr, 5*time.Minute))
+		})
 	}
 }
@@ -129,6 +129,8 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 	compactionConcurrency := cmd.Flag("compact.concurrency", "Number of goroutines to use when compacting groups.").
 		Default("1").Int()
 
+	groupConfig := extflag.RegisterPathOrContent(cmd, "compact.group-config", "YAML file with the priority and maximum concurrency of compaction groups selected by their external labels, e.g. to compact the groups of an important tenant first. See format details: https://thanos.io/components/compact.md/#compaction-group-priority-and-concurrency", false)
+
 	deleteDelay := modelDuration(cmd.Flag("delete-delay", "Time before a block marked for deletion is deleted from bucket. "+
 		"If delete-delay is non zero, blocks will be marked for deletion and compactor component will delete blocks marked for deletion from the bucket. "+
 		"If delete-delay is 0, blocks will be deleted straight away. "+
@@ -136,6 +138,10 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 		"or compactor is ignoring the deletion because it's compacting the block at the same time.").
 		Default("48h"))
 
+	noCompactMarkExpiry := modelDuration(cmd.Flag("no-compact-mark-expiry", "Time after which no-compact-mark.json files are ignored. Blocks are marked for no compaction e.g. by sidecar uploading their compacted replacement. "+
+		"Expiry makes sure blocks are eventually compacted if the replacement was never uploaded. Marks made with the bucket mark command never expire.").
+		Default("24h"))
+
 	dedupReplicaLabels := cmd.Flag("deduplication.replica-label", "Label to treat as a replica indicator of blocks that can be deduplicated (repeated flag). This will merge multiple replica blocks into one. This process is irreversible."+
 		"Experimental. When it is set true, this will given labels from blocks so that vertical compaction could merge blocks."+
 		"Please note that this uses a NAIVE algorithm for merging (no smart replica deduplication, just chaining samples together)."+
@@ -156,6 +162,7 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 			objStoreConfig,
 			time.Duration(*consistencyDelay),
 			time.Duration(*deleteDelay),
+			time.Duration(*noCompactMarkExpiry),
 			*haltOnError,
 			*acceptMalformedIndex,
 			*wait,
@@ -170,6 +177,7 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 			*maxCompactionLevel,
 			*blockSyncConcurrency,
 			*compactionConcurrency,
+			groupConfig,
 			*dedupReplicaLabels,
 			selectorRelabelConf,
 			*waitInterval,
@@ -190,12 +198,14 @@ func runCompact(
 	objStoreConfig *extflag.PathOrContent,
 	consistencyDelay time.Duration,
 	deleteDelay time.Duration,
+	noCompactMarkExpiry time.Duration,
 	haltOnError, acceptMalformedIndex, wait, generateMissingIndexCacheFiles bool,
 	retentionByResolution map[compact.ResolutionLevel]time.Duration,
 	component component.Component,
 	disableDownsampling bool,
 	maxCompactionLevel, blockSyncConcurrency int,
 	concurrency int,
+	groupConfig *extflag.PathOrContent,
 	dedupReplicaLabels []string,
 	selectorRelabelConf *extflag.PathOrContent,
 	waitInterval time.Duration,
@@ -282,6 +292,16 @@ func runCompact(
 		return err
 	}
 
+	groupContentYaml, err := groupConfig.Content()
+	if err != nil {
+		return errors.Wrap(err, "get content of group configuration")
+	}
+
+	groupConfigs, err := compact.ParseGroupConfigs(groupContentYaml)
+	if err != nil {
+		return err
+	}
+
 	// Ensure we close up everything properly.
 	defer func() {
 		if err != nil {
@@ -303,6 +323,8 @@ func runCompact(
 		block.NewConsistencyDelayMetaFilter(logger, consistencyDelay, extprom.WrapRegistererWithPrefix("thanos_", reg)),
 		ignoreDeletionMarkFilter,
 		duplicateBlocksFilter,
+		// Filtered after duplicates, so marked blocks which replacement is already uploaded are garbage collected.
+		block.NewIgnoreNoCompactMarkFilter(logger, bkt, noCompactMarkExpiry),
 	}, []block.MetadataModifier{block.NewReplicaLabelRemover(logger, dedupReplicaLabels)})
 	enableVerticalCompaction := false
 	if len(dedupReplicaLabels) > 0 {
@@ -345,7 +367,7 @@ func runCompact(
 	}
 
 	blocksCleaner := compact.NewBlocksCleaner(logger, bkt, ignoreDeletionMarkFilter, deleteDelay, blocksCleaned, blockCleanupFailures)
-	compactor, err := compact.NewBucketCompactor(logger, sy, comp, compactDir, bkt, concurrency)
+	compactor, err := compact.NewBucketCompactor(logger, sy, comp, compactDir, bkt, concurrency, groupConfigs)
 	if err != nil {
 		cancel()
 		return errors.Wrap(err, "create bucket compactor")
@@ -372,13 +394,13 @@ func runCompact(
 			// for 5m downsamplings created in the first run.
 			level.Info(logger).Log("msg", "start first pass of downsampling")
 
-			if err := downsampleBucket(ctx, logger, downsampleMetrics, bkt, compactFetcher, downsamplingDir); err != nil {
+			if err := downsampleBucket(ctx, logger, downsampleMetrics, bkt, compactFetcher, downsamplingDir, nil); err != nil {
 				return errors.Wrap(err, "first pass of downsampling failed")
 			}
 
 			level.Info(logger).Log("msg", "start second pass of downsampling")
 
-			if err := downsampleBucket(ctx, logger, downsampleMetrics, bkt, compactFetcher, downsamplingDir); err != nil {
+			if err := downsampleBucket(ctx, logger, downsampleMetrics, bkt, compactFetcher, downsamplingDir, nil); err != nil {
 				return errors.Wrap(err, "second pass of downsampling failed")
 			}
 			level.Info(logger).Log("msg", "downsampling iterations done")
@@ -61,6 +61,7 @@ func RunDownsample(
 	dataDir string,
 	objStoreConfig *extflag.PathOrContent,
 	comp component.Component,
+	shard *block.ShardFilter,
 ) error {
 	confContentYaml, err := objStoreConfig.Content()
 	if err != nil {
@@ -103,13 +104,13 @@ func RunDownsample(
 
 			level.Info(logger).Log("msg", "start first pass of downsampling")
 
-			if err := downsampleBucket(ctx, logger, metrics, bkt, metaFetcher, dataDir); err != nil {
+			if err := downsampleBucket(ctx, logger, metrics, bkt, metaFetcher, dataDir, shard); err != nil {
 				return errors.Wrap(err, "downsampling failed")
 			}
 
 			level.Info(logger).Log("msg", "start second pass of downsampling")
 
-			if err := downsampleBucket(ctx, logger, metrics, bkt, metaFetcher, dataDir); err != nil {
+			if err := downsampleBucket(ctx, logger, metrics, bkt, metaFetcher, dataDir, shard); err != nil {
 				return errors.Wrap(err, "downsampling failed")
 			}
 
@@ -146,6 +147,7 @@ func downsampleBucket(
 	bkt objstore.Bucket,
 	fetcher block.MetadataFetcher,
 	dir string,
+	shard *block.ShardFilter,
 ) error {
 	if err := os.RemoveAll(dir); err != nil {
 		return errors.Wrap(err, "clean working directory")
@@ -188,6 +190,10 @@ func downsampleBucket(
 	}
 
 	for _, m := range metas {
+		// All blocks are needed to find the already downsampled ones, but only blocks of the shard are downsampled.
+		if !shard.Contains(m) {
+			continue
+		}
 		switch m.Thanos.Downsample.Resolution {
 		case downsample.ResLevel0:
 			missing := false
@@ -206,6 +212,13 @@ func downsampleBucket(
 			if m.MaxTime-m.MinTime < downsample.DownsampleRange0 {
 				continue
 			}
+			marked, err := markedForNoDownsample(ctx, logger, bkt, m.ULID)
+			if err != nil {
+				return err
+			}
+			if marked {
+				continue
+			}
 			if err := processDownsampling(ctx, logger, bkt, m, dir, downsample.ResLevel1); err != nil {
 				metrics.downsampleFailures.WithLabelValues(compact.GroupKey(m.Thanos)).Inc()
 				return errors.Wrap(err, "downsampling to 5 min")
@@ -229,6 +242,13 @@ func downsampleBucket(
 			if m.MaxTime-m.MinTime < downsample.DownsampleRange1 {
 				continue
 			}
+			marked, err := markedForNoDownsample(ctx, logger, bkt, m.ULID)
+			if err != nil {
+				return err
+			}
+			if marked {
+				continue
+			}
 			if err := processDownsampling(ctx, logger, bkt, m, dir, downsample.ResLevel2); err != nil {
 				metrics.downsampleFailures.WithLabelValues(compact.GroupKey(m.Thanos))
 				return errors.Wrap(err, "downsampling to 60 min")
@@ -239,6 +259,23 @@ func downsampleBucket(
 	return nil
 }
 
+// markedForNoDownsample returns true if the block has a valid no-downsample mark.
+func markedForNoDownsample(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, id ulid.ULID) (bool, error) {
+	mark, err := metadata.ReadNoDownsampleMark(ctx, bkt, logger, id.String())
+	if err == metadata.ErrorNoDownsampleMarkNotFound {
+		return false, nil
+	}
+	if errors.Cause(err) == metadata.ErrorUnmarshalNoDownsampleMark {
+		level.Warn(logger).Log("msg", "found partial no-downsample-mark.json; if we will see it happening often for the same block, consider manually deleting no-downsample-mark.json from the object storage", "block", id, "err", err)
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "read no-downsample mark of block %s", id)
+	}
+	level.Info(logger).Log("msg", "skipping downsampling of block marked for no downsampling", "block", id, "details", mark.Details)
+	return true, nil
+}
+
 func processDownsampling(ctx context.Context, logger log.Logger, bkt objstore.Bucket, m *metadata.Meta, dir string, resolution int64) error {
 	begin := time.Now()
 	bdir := filepath.Join(dir, m.ULID.String())
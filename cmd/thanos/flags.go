@@ -4,10 +4,28 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
+	"github.com/alecthomas/units"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/run"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/audit"
+	"github.com/thanos-io/thanos/pkg/discovery/gossip"
 	"github.com/thanos-io/thanos/pkg/extflag"
+	"github.com/thanos-io/thanos/pkg/logging"
+	"github.com/thanos-io/thanos/pkg/memlimit"
+	"github.com/thanos-io/thanos/pkg/ratelimit"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	grpcserver "github.com/thanos-io/thanos/pkg/server/grpc"
+	httpserver "github.com/thanos-io/thanos/pkg/server/http"
+	"github.com/thanos-io/thanos/pkg/strutil"
 
 	"github.com/prometheus/common/model"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -42,6 +60,89 @@ func regGRPCFlags(cmd *kingpin.CmdClause) (
 		grpcTLSSrvClientCA
 }
 
+func regZoneFlag(cmd *kingpin.CmdClause) *string {
+	return cmd.Flag("availability-zone", "Availability zone of this component, advertised by the Info API of its StoreAPI. Queriers in the same zone prefer it over stores in other zones with the same label sets and time range, to reduce cross-zone data transfer.").
+		Default("").String()
+}
+
+type gossipConfig struct {
+	bindAddr         *string
+	advertiseAddr    *string
+	peers            *[]string
+	interval         *model.Duration
+	pushPullInterval *model.Duration
+	refreshInterval  *model.Duration
+}
+
+func regGossipFlags(cmd *kingpin.CmdClause) *gossipConfig {
+	return &gossipConfig{
+		bindAddr: cmd.Flag("gossip.address", "Listen host:port for gossip with other members of the gossip cluster, over both TCP and UDP. Components of the cluster discover each other's StoreAPIs and receive hashrings. Gossip is disabled if empty.").
+			Default("").String(),
+		advertiseAddr: cmd.Flag("gossip.advertise-address", "Address advertised to other members of the gossip cluster. Derived from --gossip.address, or a private IP of the host if it listens on all interfaces.").
+			Default("").String(),
+		peers: cmd.Flag("gossip.peer", "Address of a member of the gossip cluster to join (repeatable). Host names resolving to multiple addresses join all of them, e.g. of a headless Kubernetes service.").
+			PlaceHolder("<host:port>").Strings(),
+		interval: modelDuration(cmd.Flag("gossip.interval", "Interval between gossip messages to random members.").
+			Default("200ms")),
+		pushPullInterval: modelDuration(cmd.Flag("gossip.pushpull-interval", "Interval between full state synchronizations with a random member.").
+			Default("30s")),
+		refreshInterval: modelDuration(cmd.Flag("gossip.refresh-interval", "Interval of joining the peers again while no other member is known, e.g. because all peers were unavailable at startup.").
+			Default("15s")),
+	}
+}
+
+// newGossipPeer returns the member of the gossip cluster configured by the flags registered by regGossipFlags, which
+// joins the cluster in the background, or nil if gossip is disabled.
+func newGossipPeer(g *run.Group, logger log.Logger, reg prometheus.Registerer, conf *gossipConfig, state gossip.State) (*gossip.Peer, error) {
+	if *conf.bindAddr == "" {
+		return nil, nil
+	}
+	peer, err := gossip.NewPeer(log.With(logger, "component", "gossip"), reg, gossip.Config{
+		BindAddress:      *conf.bindAddr,
+		AdvertiseAddress: *conf.advertiseAddr,
+		Peers:            *conf.peers,
+		GossipInterval:   time.Duration(*conf.interval),
+		PushPullInterval: time.Duration(*conf.pushPullInterval),
+		RefreshInterval:  time.Duration(*conf.refreshInterval),
+	}, state)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.Add(func() error {
+		return peer.Run(ctx)
+	}, func(error) {
+		cancel()
+	})
+	return peer, nil
+}
+
+func regGRPCPeerAllowlistFlags(cmd *kingpin.CmdClause) *extflag.PathOrContent {
+	return extflag.RegisterPathOrContent(
+		cmd,
+		"grpc-server-tls-peer-allowlist",
+		"YAML file with rules allowing gRPC services to be called only by peers with matching URI (e.g. SPIFFE ID) or DNS name SANs in their client certificates. Requires --grpc-server-tls-client-ca. Peers are not restricted if empty. See format details: https://thanos.io/tls.md/#peer-allowlist ",
+		false,
+	)
+}
+
+// newGRPCPeerAllowlist returns the peer allowlist configured by the flags registered by regGRPCPeerAllowlistFlags, or
+// nil if peers are not restricted.
+func newGRPCPeerAllowlist(logger log.Logger, reg prometheus.Registerer, allowlistConfig *extflag.PathOrContent, clientCA string) (*grpcserver.PeerAllowlist, error) {
+	confContentYaml, err := allowlistConfig.Content()
+	if err != nil {
+		return nil, err
+	}
+	if len(confContentYaml) == 0 {
+		return nil, nil
+	}
+	if clientCA == "" {
+		return nil, errors.New("gRPC peer allowlist requires client certificates verified by --grpc-server-tls-client-ca")
+	}
+	return grpcserver.NewPeerAllowlist(logger, reg, confContentYaml)
+}
+
 func regHTTPFlags(cmd *kingpin.CmdClause) (httpBindAddr *string, httpGracePeriod *model.Duration) {
 	httpBindAddr = cmd.Flag("http-address", "Listen host:port for HTTP endpoints.").Default("0.0.0.0:10902").String()
 	httpGracePeriod = modelDuration(cmd.Flag("http-grace-period", "Time to wait after an interrupt received for HTTP Server.").Default("2m")) // by default it's the same as query.timeout.
@@ -49,6 +150,148 @@ func regHTTPFlags(cmd *kingpin.CmdClause) (httpBindAddr *string, httpGracePeriod
 	return httpBindAddr, httpGracePeriod
 }
 
+func regHTTPAuthFlags(cmd *kingpin.CmdClause) *extflag.PathOrContent {
+	return extflag.RegisterPathOrContent(
+		cmd,
+		"http.auth-config",
+		"YAML file with configuration of basic auth and OIDC authentication of HTTP requests. Authentication is disabled if empty. See format details: https://thanos.io/authentication.md/#configuration ",
+		false,
+	)
+}
+
+// newHTTPAuthenticator returns the authenticator configured by the flags registered by regHTTPAuthFlags, or nil if
+// authentication is disabled.
+func newHTTPAuthenticator(logger log.Logger, reg prometheus.Registerer, authConfig *extflag.PathOrContent) (*httpserver.Authenticator, error) {
+	confContentYaml, err := authConfig.Content()
+	if err != nil {
+		return nil, err
+	}
+	if len(confContentYaml) == 0 {
+		return nil, nil
+	}
+	return httpserver.NewAuthenticator(logger, reg, confContentYaml)
+}
+
+func regTenantSignatureFlags(cmd *kingpin.CmdClause) *extflag.PathOrContent {
+	return extflag.RegisterPathOrContent(
+		cmd,
+		"http.tenant-signature-config",
+		"YAML file with configuration of HMAC keys verifying signatures of tenant headers, so tenants set by a trusted gateway cannot be spoofed. Signatures are not required if empty. See format details: https://thanos.io/authentication.md/#signed-tenant-headers ",
+		false,
+	)
+}
+
+// newTenantVerifier returns the verifier configured by the flags registered by regTenantSignatureFlags, or nil if
+// signatures are not required.
+func newTenantVerifier(logger log.Logger, reg prometheus.Registerer, signatureConfig *extflag.PathOrContent, tenantHeader string) (*httpserver.TenantVerifier, error) {
+	confContentYaml, err := signatureConfig.Content()
+	if err != nil {
+		return nil, err
+	}
+	if len(confContentYaml) == 0 {
+		return nil, nil
+	}
+	return httpserver.NewTenantVerifier(logger, reg, confContentYaml, tenantHeader)
+}
+
+func regAuditFlags(cmd *kingpin.CmdClause) *extflag.PathOrContent {
+	return extflag.RegisterPathOrContent(
+		cmd,
+		"audit.config",
+		"YAML file with configuration of audit logging of API requests. Audit logging is disabled if empty. See format details: https://thanos.io/audit.md/#configuration ",
+		false,
+	)
+}
+
+// newAuditLogger returns the audit logger configured by the flags registered by regAuditFlags, or nil if audit
+// logging is disabled.
+func newAuditLogger(logger log.Logger, reg prometheus.Registerer, auditConfig *extflag.PathOrContent) (*audit.Logger, error) {
+	confContentYaml, err := auditConfig.Content()
+	if err != nil {
+		return nil, err
+	}
+	if len(confContentYaml) == 0 {
+		return nil, nil
+	}
+	return audit.NewLogger(logger, reg, confContentYaml)
+}
+
+func regRateLimitFlags(cmd *kingpin.CmdClause, endpoints string) *extflag.PathOrContent {
+	return extflag.RegisterPathOrContent(
+		cmd,
+		"rate-limit.config",
+		fmt.Sprintf("YAML file with configuration of rate limits of %s by tenant or client IP. Rate limiting is disabled if empty. See format details: https://thanos.io/rate-limiting.md/#configuration ", endpoints),
+		false,
+	)
+}
+
+// newRateLimiter returns the rate limiter configured by the flags registered by regRateLimitFlags, or nil if rate
+// limiting is disabled.
+func newRateLimiter(reg prometheus.Registerer, rateLimitConfig *extflag.PathOrContent, tenantHeader string) (*ratelimit.Limiter, error) {
+	confContentYaml, err := rateLimitConfig.Content()
+	if err != nil {
+		return nil, err
+	}
+	if len(confContentYaml) == 0 {
+		return nil, nil
+	}
+	return ratelimit.NewLimiter(reg, confContentYaml, tenantHeader)
+}
+
+type requestLoggingConfig struct {
+	config         *extflag.PathOrContent
+	reloadInterval *model.Duration
+}
+
+func regRequestLoggingFlags(cmd *kingpin.CmdClause) *requestLoggingConfig {
+	return &requestLoggingConfig{
+		config: extflag.RegisterPathOrContent(
+			cmd,
+			"request-logging.config",
+			"YAML file with rules deciding per path, method and status class whether HTTP and gRPC requests are logged when started and finished, with payload sizes and at which sample rate. Requests are not logged if empty. See format details: https://thanos.io/logging.md/#request-logging ",
+			false,
+		),
+		reloadInterval: modelDuration(cmd.Flag("request-logging.config-reload-interval", "Interval of reloading the file given by --request-logging.config-file, so rules can be changed at runtime. 0 disables reloading.").
+			Default("1m")),
+	}
+}
+
+// newRequestLogger returns the request logger configured by the flags registered by regRequestLoggingFlags, which
+// reloads its configuration file in the background, or nil if request logging is disabled.
+func newRequestLogger(g *run.Group, logger log.Logger, reg prometheus.Registerer, conf *requestLoggingConfig) (*logging.RequestLogger, error) {
+	confContentYaml, err := conf.config.Content()
+	if err != nil {
+		return nil, err
+	}
+	if len(confContentYaml) == 0 {
+		return nil, nil
+	}
+	reqLogger, err := logging.NewRequestLogger(log.With(logger, "component", "request-logging"), reg, confContentYaml)
+	if err != nil {
+		return nil, err
+	}
+	if *conf.reloadInterval <= 0 {
+		return reqLogger, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.Add(func() error {
+		return runutil.Repeat(time.Duration(*conf.reloadInterval), ctx.Done(), func() error {
+			c, err := conf.config.Content()
+			if err == nil {
+				err = reqLogger.ApplyConfig(c)
+			}
+			if err != nil {
+				level.Error(logger).Log("msg", "reloading request logging config failed, keeping previous config", "err", err)
+			}
+			return nil
+		})
+	}, func(error) {
+		cancel()
+	})
+	return reqLogger, nil
+}
+
 func regCommonObjStoreFlags(cmd *kingpin.CmdClause, suffix string, required bool, extraDesc ...string) *extflag.PathOrContent {
 	help := fmt.Sprintf("YAML file that contains object store%s configuration. See format details: https://thanos.io/storage.md/#configuration ", suffix)
 	help = strings.Join(append([]string{help}, extraDesc...), " ")
@@ -65,6 +308,110 @@ func regCommonTracingFlags(app *kingpin.Application) *extflag.PathOrContent {
 	)
 }
 
+// flagSetAction returns a flag action recording that the flag is set explicitly, rather than by its default.
+func flagSetAction(set *bool) kingpin.Action {
+	return func(*kingpin.ParseContext) error {
+		*set = true
+		return nil
+	}
+}
+
+type memoryLimitConfig struct {
+	limit          *units.Base2Bytes
+	autoLimit      *bool
+	autoLimitRatio *float64
+}
+
+func regCommonProfilingFlags(app *kingpin.Application) *extflag.PathOrContent {
+	return extflag.RegisterPathOrContent(
+		app,
+		"profiling.config",
+		"YAML file with configuration of continuous profiling, pushing profiles periodically to a profiling backend. Continuous profiling is disabled if empty. See format details: https://thanos.io/profiling.md/#configuration ",
+		false,
+	)
+}
+
+func regCommonMemoryFlags(app *kingpin.Application) *memoryLimitConfig {
+	return &memoryLimitConfig{
+		limit: app.Flag("memory.limit", "Soft memory limit of the Go runtime, as set by the GOMEMLIMIT environment variable, which takes precedence. The garbage collector runs more often when the heap approaches it, and memory budgets of components not configured explicitly are derived from it. 0 means no limit, unless --memory.auto-limit is set.").
+			Default("0B").Bytes(),
+		autoLimit: app.Flag("memory.auto-limit", "If true and --memory.limit is not set, the soft memory limit is set to --memory.auto-limit-ratio of the memory limit of the cgroup Thanos runs in, e.g. of its container.").
+			Default("false").Bool(),
+		autoLimitRatio: app.Flag("memory.auto-limit-ratio", "Ratio of the cgroup memory limit to set as the soft memory limit with --memory.auto-limit. The rest is left for memory not managed by the Go runtime, e.g. mmaped files.").
+			Default("0.9").Float64(),
+	}
+}
+
+// setMemoryLimit sets the soft memory limit of the Go runtime by the given flags, unless it is set by the environment.
+func setMemoryLimit(logger log.Logger, conf *memoryLimitConfig) error {
+	if v := os.Getenv(memlimit.EnvName); v != "" {
+		level.Info(logger).Log("msg", "soft memory limit set by environment", memlimit.EnvName, v)
+		return nil
+	}
+
+	limit := int64(*conf.limit)
+	if limit == 0 && *conf.autoLimit {
+		if *conf.autoLimitRatio <= 0 || *conf.autoLimitRatio > 1 {
+			return errors.Errorf("--memory.auto-limit-ratio must be in (0, 1], got %v", *conf.autoLimitRatio)
+		}
+		cgroupLimit, err := memlimit.CgroupLimit()
+		if err != nil {
+			return errors.Wrap(err, "detect cgroup memory limit")
+		}
+		if cgroupLimit == 0 {
+			level.Info(logger).Log("msg", "cgroup has no memory limit, soft memory limit is not set")
+			return nil
+		}
+		limit = int64(float64(cgroupLimit) * *conf.autoLimitRatio)
+	}
+	if limit == 0 {
+		return nil
+	}
+
+	if err := memlimit.Set(limit); err != nil {
+		return err
+	}
+	level.Info(logger).Log("msg", "soft memory limit set", "limit", units.Base2Bytes(limit).String())
+	return nil
+}
+
+type labelInternConfig struct {
+	maxSize       *int
+	evictInterval *model.Duration
+}
+
+func regLabelInternFlags(cmd *kingpin.CmdClause) *labelInternConfig {
+	return &labelInternConfig{
+		maxSize: cmd.Flag("label-intern.max-size", "Maximum number of label names and values of series interned, so equal ones are shared in memory by all series and requests instead of being allocated for each. Strings longer than 256 bytes are not interned. 0 disables interning.").
+			Default("100000").Int(),
+		evictInterval: modelDuration(cmd.Flag("label-intern.evict-interval", "Interval of evicting interned label names and values not used since the previous eviction.").
+			Default("5m")),
+	}
+}
+
+// newLabelInterner returns the interner configured by the flags, which evicts unused strings in the background, or nil
+// if interning is disabled.
+func newLabelInterner(g *run.Group, reg prometheus.Registerer, conf *labelInternConfig) (*strutil.Interner, error) {
+	if *conf.maxSize <= 0 {
+		return nil, nil
+	}
+	if *conf.evictInterval <= 0 {
+		return nil, errors.New("--label-intern.evict-interval must be positive")
+	}
+
+	interner := strutil.NewInterner(reg, *conf.maxSize)
+	ctx, cancel := context.WithCancel(context.Background())
+	g.Add(func() error {
+		return runutil.Repeat(time.Duration(*conf.evictInterval), ctx.Done(), func() error {
+			interner.Evict()
+			return nil
+		})
+	}, func(error) {
+		cancel()
+	})
+	return interner, nil
+}
+
 func regSelectorRelabelFlags(cmd *kingpin.CmdClause) *extflag.PathOrContent {
 	return extflag.RegisterPathOrContent(
 		cmd,
@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 
 	gmetrics "github.com/armon/go-metrics"
@@ -22,6 +23,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/version"
+	"github.com/thanos-io/thanos/pkg/extgrpc"
+	"github.com/thanos-io/thanos/pkg/profiling"
 	"github.com/thanos-io/thanos/pkg/tracing/client"
 	"go.uber.org/automaxprocs/maxprocs"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -53,6 +56,8 @@ func main() {
 		Default(logFormatLogfmt).Enum(logFormatLogfmt, logFormatJson)
 
 	tracingConfig := regCommonTracingFlags(app)
+	profilingConfig := regCommonProfilingFlags(app)
+	memoryLimitConfig := regCommonMemoryFlags(app)
 
 	cmds := map[string]setupFunc{}
 	registerSidecar(cmds, app)
@@ -63,6 +68,7 @@ func main() {
 	registerBucket(cmds, app, "bucket")
 	registerReceive(cmds, app)
 	registerChecks(cmds, app, "check")
+	registerTools(cmds, app, "tools")
 
 	cmd, err := app.Parse(os.Args[1:])
 	if err != nil {
@@ -111,6 +117,11 @@ func main() {
 		fmt.Fprintln(os.Stderr, errors.Wrapf(err, "failed to set GOMAXPROCS: %v", err))
 	}
 
+	if err := setMemoryLimit(logger, memoryLimitConfig); err != nil {
+		level.Error(logger).Log("msg", "failed to set soft memory limit", "err", err)
+		os.Exit(1)
+	}
+
 	metrics := prometheus.NewRegistry()
 	metrics.MustRegister(
 		version.NewCollector("thanos"),
@@ -118,6 +129,9 @@ func main() {
 		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
 	)
 
+	// gRPC servers of all components support compressed requests.
+	extgrpc.RegisterCompressors(metrics)
+
 	// Some packages still use default Register. Replace to have those metrics.
 	prometheus.DefaultRegisterer = metrics
 	// Memberlist uses go-metrics.
@@ -178,6 +192,30 @@ func main() {
 		})
 	}
 
+	// Setup optional continuous profiling.
+	{
+		confContentYaml, err := profilingConfig.Content()
+		if err != nil {
+			level.Error(logger).Log("msg", "getting profiling config failed", "err", err)
+			os.Exit(1)
+		}
+
+		if len(confContentYaml) > 0 {
+			profiler, err := profiling.NewProfiler(logger, metrics, confContentYaml, strings.Replace(cmd, " ", "_", -1))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, errors.Wrapf(err, "profiling failed"))
+				os.Exit(1)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			g.Add(func() error {
+				return profiler.Run(ctx)
+			}, func(error) {
+				cancel()
+			})
+		}
+	}
+
 	// Create a signal channel to dispatch reload events to sub-commands.
 	reloadCh := make(chan struct{}, 1)
 
@@ -5,6 +5,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
@@ -119,9 +120,99 @@ func TestCleanupDownsampleCacheFolder(t *testing.T) {
 	metaFetcher, err := block.NewMetaFetcher(nil, 32, bkt, "", nil, nil, nil)
 	testutil.Ok(t, err)
 
-	testutil.Ok(t, downsampleBucket(ctx, logger, metrics, bkt, metaFetcher, dir))
+	testutil.Ok(t, downsampleBucket(ctx, logger, metrics, bkt, metaFetcher, dir, nil))
 	testutil.Equals(t, 1.0, promtest.ToFloat64(metrics.downsamples.WithLabelValues(compact.GroupKey(meta.Thanos))))
 
 	_, err = os.Stat(dir)
 	testutil.Assert(t, os.IsNotExist(err), "index cache dir should not exist at the end of execution")
 }
+
+func TestDownsampleBucket_NoDownsampleMark(t *testing.T) {
+	logger := log.NewNopLogger()
+	dir, err := ioutil.TempDir("", "test-downsample-no-downsample-mark")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	bkt := inmem.NewBucket()
+	id, err := e2eutil.CreateBlock(
+		ctx,
+		dir,
+		[]labels.Labels{{{Name: "a", Value: "1"}}},
+		1, 0, downsample.DownsampleRange0+1, // Pass the minimum DownsampleRange0 check.
+		labels.Labels{{Name: "e1", Value: "1"}},
+		downsample.ResLevel0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, block.Upload(ctx, logger, bkt, path.Join(dir, id.String())))
+	testutil.Ok(t, block.MarkForNoDownsample(ctx, logger, bkt, id, "test"))
+
+	meta, err := block.DownloadMeta(ctx, logger, bkt, id)
+	testutil.Ok(t, err)
+
+	metrics := newDownsampleMetrics(prometheus.NewRegistry())
+	metaFetcher, err := block.NewMetaFetcher(nil, 32, bkt, "", nil, nil, nil)
+	testutil.Ok(t, err)
+
+	testutil.Ok(t, downsampleBucket(ctx, logger, metrics, bkt, metaFetcher, dir, nil))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(metrics.downsamples.WithLabelValues(compact.GroupKey(meta.Thanos))))
+
+	metas, _, err := metaFetcher.Fetch(ctx)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(metas))
+}
+
+func TestDownsampleBucket_Sharded(t *testing.T) {
+	logger := log.NewNopLogger()
+	dir, err := ioutil.TempDir("", "test-downsample-sharded")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	bkt := inmem.NewBucket()
+	var metas []*metadata.Meta
+	for i := 0; i < 4; i++ {
+		id, err := e2eutil.CreateBlock(
+			ctx,
+			dir,
+			[]labels.Labels{{{Name: "a", Value: "1"}}},
+			1, 0, downsample.DownsampleRange0+1, // Pass the minimum DownsampleRange0 check.
+			labels.Labels{{Name: "e1", Value: fmt.Sprintf("%d", i)}},
+			downsample.ResLevel0)
+		testutil.Ok(t, err)
+		testutil.Ok(t, block.Upload(ctx, logger, bkt, path.Join(dir, id.String())))
+
+		meta, err := block.DownloadMeta(ctx, logger, bkt, id)
+		testutil.Ok(t, err)
+		metas = append(metas, &meta)
+	}
+
+	const shards = 2
+	groupKey := func(m *metadata.Meta) string { return compact.GroupKey(m.Thanos) }
+	metrics := newDownsampleMetrics(prometheus.NewRegistry())
+	for i := 0; i < shards; i++ {
+		shard, err := block.NewShardFilter(shards, i, groupKey)
+		testutil.Ok(t, err)
+		metaFetcher, err := block.NewMetaFetcher(nil, 32, bkt, "", nil, nil, nil)
+		testutil.Ok(t, err)
+		testutil.Ok(t, downsampleBucket(ctx, logger, metrics, bkt, metaFetcher, dir, shard))
+
+		for _, m := range metas {
+			// Only blocks of this and previous shards are downsampled.
+			exp := 0.0
+			for j := 0; j <= i; j++ {
+				if f, _ := block.NewShardFilter(shards, j, groupKey); f.Contains(m) {
+					exp = 1
+				}
+			}
+			testutil.Equals(t, exp, promtest.ToFloat64(metrics.downsamples.WithLabelValues(groupKey(m))))
+		}
+	}
+	// Every block is downsampled exactly once.
+	for _, m := range metas {
+		testutil.Equals(t, 1.0, promtest.ToFloat64(metrics.downsamples.WithLabelValues(compact.GroupKey(m.Thanos))))
+	}
+}
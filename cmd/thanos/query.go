@@ -24,23 +24,37 @@ import (
 	"github.com/prometheus/prometheus/discovery/targetgroup"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/promql"
+	"google.golang.org/grpc"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/thanos-io/thanos/pkg/audit"
+	"github.com/thanos-io/thanos/pkg/authz"
 	"github.com/thanos-io/thanos/pkg/component"
 	"github.com/thanos-io/thanos/pkg/discovery/cache"
 	"github.com/thanos-io/thanos/pkg/discovery/dns"
+	"github.com/thanos-io/thanos/pkg/discovery/gossip"
+	httpsd "github.com/thanos-io/thanos/pkg/discovery/http"
+	"github.com/thanos-io/thanos/pkg/extflag"
 	"github.com/thanos-io/thanos/pkg/extgrpc"
 	"github.com/thanos-io/thanos/pkg/extprom"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/logging"
+	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
+	"github.com/thanos-io/thanos/pkg/profiling"
 	"github.com/thanos-io/thanos/pkg/query"
 	v1 "github.com/thanos-io/thanos/pkg/query/api"
+	"github.com/thanos-io/thanos/pkg/querylog"
+	"github.com/thanos-io/thanos/pkg/ratelimit"
+	"github.com/thanos-io/thanos/pkg/receive"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	grpcserver "github.com/thanos-io/thanos/pkg/server/grpc"
 	httpserver "github.com/thanos-io/thanos/pkg/server/http"
 	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/strutil"
 	"github.com/thanos-io/thanos/pkg/tls"
 	"github.com/thanos-io/thanos/pkg/ui"
+	"github.com/thanos-io/thanos/pkg/usage"
 )
 
 // registerQuery registers a query command.
@@ -49,13 +63,26 @@ func registerQuery(m map[string]setupFunc, app *kingpin.Application) {
 	cmd := app.Command(comp.String(), "query node exposing PromQL enabled Query API with data retrieved from multiple store nodes")
 
 	httpBindAddr, httpGracePeriod := regHTTPFlags(cmd)
+	httpAuthConfig := regHTTPAuthFlags(cmd)
 	grpcBindAddr, grpcGracePeriod, grpcCert, grpcKey, grpcClientCA := regGRPCFlags(cmd)
+	grpcPeerAllowlistConfig := regGRPCPeerAllowlistFlags(cmd)
+	requestLoggingConfig := regRequestLoggingFlags(cmd)
+
+	zone := cmd.Flag("availability-zone", "Availability zone of the querier, advertised by the Info API of its StoreAPI. Stores in the zone, as advertised by them or configured for their store group, are preferred over stores in other zones with the same label sets and time range, to reduce cross-zone data transfer.").
+		Default("").String()
+	gossipConfig := regGossipFlags(cmd)
 
 	secure := cmd.Flag("grpc-client-tls-secure", "Use TLS when talking to the gRPC server").Default("false").Bool()
 	cert := cmd.Flag("grpc-client-tls-cert", "TLS Certificates to use to identify this client to the server").Default("").String()
 	key := cmd.Flag("grpc-client-tls-key", "TLS Key for the client's certificate").Default("").String()
 	caCert := cmd.Flag("grpc-client-tls-ca", "TLS CA Certificates to use to verify gRPC servers").Default("").String()
 	serverName := cmd.Flag("grpc-client-server-name", "Server name to verify the hostname on the returned gRPC certificates. See https://tools.ietf.org/html/rfc4366#section-3.1").Default("").String()
+	compression := cmd.Flag("grpc-client-compression", "Compression of requests to StoreAPIs, whose responses are compressed likewise. Thanos servers support zstd since this version; servers not supporting it are detected by their health checks and requested uncompressed. Compression reduces network transfer of chunks at the cost of CPU time. Possible options: none, zstd.").
+		Default(extgrpc.CompressionNone).Enum(extgrpc.CompressionNone, extgrpc.CompressionZstd)
+	windowSize := cmd.Flag("grpc-client-initial-window-size", "Initial HTTP/2 flow control window of each stream from StoreAPIs, i.e. how much a store can send before waiting for the querier to acknowledge it, e.g. 1MB. Larger windows speed up Series calls returning lots of data over links with high latency. 0 means the gRPC default, which adapts the window to the bandwidth and latency of the link; setting it disables that. Values below 64KB are ignored.").
+		Default("0").Bytes()
+	connWindowSize := cmd.Flag("grpc-client-initial-conn-window-size", "Initial HTTP/2 flow control window of each connection to StoreAPIs, shared by all its streams, e.g. 4MB. 0 means the gRPC default, which adapts the window to the bandwidth and latency of the link; setting it disables that. Values below 64KB are ignored.").
+		Default("0").Bytes()
 
 	webRoutePrefix := cmd.Flag("web.route-prefix", "Prefix for API and UI endpoints. This allows thanos UI to be served on a sub-path. This option is analogous to --web.route-prefix of Promethus.").Default("").String()
 	webExternalPrefix := cmd.Flag("web.external-prefix", "Static prefix for all HTML links and redirect URLs in the UI query web interface. Actual endpoints are still served on / or the web.route-prefix. This allows thanos UI to be served behind a reverse proxy that strips a URL sub-path.").Default("").String()
@@ -87,25 +114,90 @@ func registerQuery(m map[string]setupFunc, app *kingpin.Application) {
 	fileSDInterval := modelDuration(cmd.Flag("store.sd-interval", "Refresh interval to re-read file SD files. It is used as a resync fallback.").
 		Default("5m"))
 
-	// TODO(bwplotka): Grab this from TTL at some point.
+	httpSDURLs := cmd.Flag("store.sd-http-url", "URL of an HTTP service discovery endpoint returning addresses of store API servers as JSON target groups, like Prometheus HTTP SD, e.g. served by a service inventory (repeatable).").
+		PlaceHolder("<url>").Strings()
+
+	httpSDInterval := modelDuration(cmd.Flag("store.sd-http-interval", "Refresh interval of fetching addresses from HTTP service discovery endpoints.").
+		Default("1m"))
+
 	dnsSDInterval := modelDuration(cmd.Flag("store.sd-dns-interval", "Interval between DNS resolutions.").
 		Default("30s"))
 
+	dnsSDHonorTTL := cmd.Flag("store.sd-dns-honor-ttl", "Resolve DNS names again when their records expire instead of every --store.sd-dns-interval, which is then only used if TTLs are unknown. Never resolves more often than every second. Requires the miekgdns resolver.").
+		Default("false").Bool()
+
 	dnsSDResolver := cmd.Flag("store.sd-dns-resolver", fmt.Sprintf("Resolver to use. Possible options: [%s, %s]", dns.GolangResolverType, dns.MiekgdnsResolverType)).
 		Default(string(dns.GolangResolverType)).Hidden().String()
 
+	storeGroupsConfig := extflag.RegisterPathOrContent(cmd, "store.groups-config", "YAML file with groups of stores with priorities, e.g. store gateways of the local region preferred over replicas in remote regions. Stores of groups with lower priority are only queried if groups of higher priority are unhealthy or do not cover the requested time range. See format details: https://thanos.io/components/query.md/#store-groups", false)
+
 	unhealthyStoreTimeout := modelDuration(cmd.Flag("store.unhealthy-timeout", "Timeout before an unhealthy store is cleaned from the store UI page.").Default("5m"))
 
+	enableStoreDrain := cmd.Flag("store.enable-drain-endpoint", "If true, querier exposes POST /api/v1/stores/drain and /api/v1/stores/undrain HTTP endpoints with addr parameter and corresponding actions on the store UI page. Drained stores are excluded from queries until undrained, e.g. during their maintenance.").
+		Default("false").Bool()
+
+	storeAdaptiveConcurrency := cmd.Flag("store.adaptive-concurrency", "If true, concurrent Series calls of each store are limited to a limit adapted to its load: increased additively with each successful call and halved on failed ones, or ones slower than --store.adaptive-concurrency.latency-threshold. Calls above the limit wait in the querier, so overloaded or recovering stores are not overwhelmed, e.g. by retried queries.").
+		Default("false").Bool()
+
+	storeConcurrencyMinLimit := cmd.Flag("store.adaptive-concurrency.min-limit", "Minimum concurrency limit of each store with --store.adaptive-concurrency. Limits of new stores start at it.").
+		Default("4").Int()
+
+	storeConcurrencyMaxLimit := cmd.Flag("store.adaptive-concurrency.max-limit", "Maximum concurrency limit of each store with --store.adaptive-concurrency.").
+		Default("100").Int()
+
+	storeConcurrencyLatencyThreshold := modelDuration(cmd.Flag("store.adaptive-concurrency.latency-threshold", "Duration of Series calls above which they decrease the concurrency limit of their store as failed ones do, with --store.adaptive-concurrency. 0 means only failed calls do.").
+		Default("0s"))
+
 	enableAutodownsampling := cmd.Flag("query.auto-downsampling", "Enable automatic adjustment (step / 5) to what source of data should be used in store gateways if no max_source_resolution param is specified.").
 		Default("false").Bool()
 
+	mixedResolutionAge := modelDuration(cmd.Flag("query.mixed-resolution-age", "Age of data from which queries with a max_source_resolution below 1h select data downsampled to 1h, while more recent data is selected with the requested resolution. This allows long range queries to span data beyond the retention of raw data. 0 disables mixing resolutions.").
+		Default("0s"))
+
 	enablePartialResponse := cmd.Flag("query.partial-response", "Enable partial response for queries if no partial_response param is specified. --no-query.partial-response for disabling.").
 		Default("true").Bool()
 
 	defaultEvaluationInterval := modelDuration(cmd.Flag("query.default-evaluation-interval", "Set default evaluation interval for sub queries.").Default("1m"))
 
+	queryCostBudget := cmd.Flag("query.cost-budget", "Maximum number of samples queries are estimated to read before they are executed. Estimates are based on cardinality statistics of stores implementing the Status API, e.g. sidecars and receivers. Queries exceeding it are rejected with an explanatory error, or downsampled with --query.cost-downsample. 0 disables cost estimation.").
+		Default("0").Uint64()
+
+	queryCostSampleInterval := modelDuration(cmd.Flag("query.cost-sample-interval", "Interval of raw samples assumed by query cost estimation, e.g. the most common scrape interval.").Default("15s"))
+
+	queryCostDownsample := cmd.Flag("query.cost-downsample", "If true, queries exceeding --query.cost-budget are evaluated with the lowest max_source_resolution of downsampled data fitting the budget, with a warning, instead of being rejected, if there is one.").
+		Default("false").Bool()
+
+	queryCostRefreshInterval := modelDuration(cmd.Flag("query.cost-refresh-interval", "Interval of refreshing cardinality statistics of stores for query cost estimation.").Default("1m"))
+
+	authzConfig := extflag.RegisterPathOrContent(cmd, "query.authorization-config", "YAML file with configuration of authorization of Query API requests by tenant, API and series matchers. Authorization is disabled if empty. See format details: https://thanos.io/components/query.md/#authorization", false)
+
+	tenantHeader := cmd.Flag("query.tenant-header", "HTTP header determining the tenant of Query API requests, passed to authorization and audit logs.").Default(receive.DefaultTenantHeader).String()
+
+	tenantSignatureConfig := regTenantSignatureFlags(cmd)
+
+	auditConfig := regAuditFlags(cmd)
+
+	tenantEndpointsConfig := extflag.RegisterPathOrContent(cmd, "grpc.tenant-endpoints-config", "YAML file with virtual StoreAPI endpoints of tenants, each serving the series of a tenant with its own external labels on its own gRPC address, so upstream queriers can select tenants by external labels. See format details: https://thanos.io/components/query.md/#tenant-endpoints", false)
+
+	queryLogConfig := extflag.RegisterPathOrContent(cmd, "query-log.config", "YAML file with configuration of structured logs of executed PromQL queries. Query logging is disabled if empty. See format details: https://thanos.io/components/query.md/#query-log ", false)
+
+	exportConfig := regExportFlags(cmd)
+
+	rateLimitConfig := regRateLimitFlags(cmd, "Query API and gRPC Store API requests")
+
 	storeResponseTimeout := modelDuration(cmd.Flag("store.response-timeout", "If a Store doesn't send any data in this specified duration then a Store will be ignored and partial data will be returned if it's enabled. 0 disables timeout.").Default("0ms"))
 
+	storeResponseBatchSize := cmd.Flag("store.response-batch-size", "Size of batches of series stores are requested to send in a single response, e.g. 1MB. Batches save the overhead of a gRPC message per series for queries returning lots of small series. Store Gateways and Queriers support batches since this version, other stores send a response per series. 0 disables batches.").
+		Default("0").Bytes()
+
+	storeResponseSortWindow := cmd.Flag("store.response-sort-window", "Number of series received from each store that are held to sort series the store sends out of order, before merging them with series of other stores. Series within each response are always sorted. Stores are expected to send series sorted, so this is needed only for stores that do not. 0 holds no series.").
+		Default("0").Int()
+
+	enableArrowSeries := cmd.Flag("experimental.enable-arrow-series", "If true, Querier will request series of raw data as Arrow record batches from stores advertising the arrow_series capability, and advertise it itself.").
+		Hidden().Default("false").Bool()
+
+	labelInternConfig := regLabelInternFlags(cmd)
+
 	m[comp.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ <-chan struct{}, _ bool) error {
 		selectorLset, err := parseFlagLabels(*selectorLabels)
 		if err != nil {
@@ -121,17 +213,137 @@ func registerQuery(m map[string]setupFunc, app *kingpin.Application) {
 			lookupStores[s] = struct{}{}
 		}
 
-		var fileSD *file.Discovery
+		var storeSDs []targetGroupDiscoverer
 		if len(*fileSDFiles) > 0 {
 			conf := &file.SDConfig{
 				Files:           *fileSDFiles,
 				RefreshInterval: *fileSDInterval,
 			}
-			fileSD = file.NewDiscovery(conf, logger)
+			storeSDs = append(storeSDs, file.NewDiscovery(conf, logger))
+		}
+		if len(*httpSDURLs) > 0 {
+			httpSDFailures := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+				Name: "thanos_querier_store_http_sd_refresh_failures_total",
+				Help: "Total number of failed refreshes of store addresses from HTTP service discovery endpoints.",
+			})
+			for _, u := range *httpSDURLs {
+				httpSD, err := httpsd.NewDiscovery(logger, u, time.Duration(*httpSDInterval), httpSDFailures)
+				if err != nil {
+					return errors.Wrap(err, "create HTTP service discovery")
+				}
+				storeSDs = append(storeSDs, httpSD)
+			}
+		}
+		gossipPeer, err := newGossipPeer(g, logger, reg, gossipConfig, gossip.State{Component: comp.String()})
+		if err != nil {
+			return errors.Wrap(err, "create gossip peer")
+		}
+		if gossipPeer != nil {
+			storeSDs = append(storeSDs, gossip.NewStoreDiscovery(gossipPeer))
 		}
 
 		promql.SetDefaultEvaluationInterval(time.Duration(*defaultEvaluationInterval))
 
+		httpAuth, err := newHTTPAuthenticator(logger, reg, httpAuthConfig)
+		if err != nil {
+			return errors.Wrap(err, "create HTTP authenticator")
+		}
+
+		var authorizer authz.Authorizer
+		authzContentYaml, err := authzConfig.Content()
+		if err != nil {
+			return errors.Wrap(err, "get content of authorization configuration")
+		}
+		if len(authzContentYaml) > 0 {
+			authorizer, err = authz.NewAuthorizer(logger, reg, authzContentYaml)
+			if err != nil {
+				return errors.Wrap(err, "create authorizer")
+			}
+		}
+
+		tenantVerifier, err := newTenantVerifier(logger, reg, tenantSignatureConfig, *tenantHeader)
+		if err != nil {
+			return errors.Wrap(err, "create tenant signature verifier")
+		}
+
+		auditLogger, err := newAuditLogger(logger, reg, auditConfig)
+		if err != nil {
+			return errors.Wrap(err, "create audit logger")
+		}
+
+		var queryLogger *querylog.Logger
+		queryLogContentYaml, err := queryLogConfig.Content()
+		if err != nil {
+			return errors.Wrap(err, "get content of query log configuration")
+		}
+		if len(queryLogContentYaml) > 0 {
+			queryLogger, err = querylog.NewLogger(logger, reg, queryLogContentYaml)
+			if err != nil {
+				return errors.Wrap(err, "create query logger")
+			}
+		}
+
+		exporter, err := newExporter(g, logger, reg, exportConfig)
+		if err != nil {
+			return errors.Wrap(err, "create exporter")
+		}
+
+		rateLimiter, err := newRateLimiter(reg, rateLimitConfig, *tenantHeader)
+		if err != nil {
+			return errors.Wrap(err, "create rate limiter")
+		}
+
+		grpcPeers, err := newGRPCPeerAllowlist(logger, reg, grpcPeerAllowlistConfig, *grpcClientCA)
+		if err != nil {
+			return errors.Wrap(err, "create gRPC peer allowlist")
+		}
+
+		reqLogger, err := newRequestLogger(g, logger, reg, requestLoggingConfig)
+		if err != nil {
+			return errors.Wrap(err, "create request logger")
+		}
+
+		interner, err := newLabelInterner(g, reg, labelInternConfig)
+		if err != nil {
+			return err
+		}
+
+		var storeConcurrency *query.AdaptiveConcurrencyConfig
+		if *storeAdaptiveConcurrency {
+			if *storeConcurrencyMinLimit < 1 || *storeConcurrencyMaxLimit < *storeConcurrencyMinLimit {
+				return errors.Errorf("invalid adaptive concurrency limits %d to %d of stores", *storeConcurrencyMinLimit, *storeConcurrencyMaxLimit)
+			}
+			storeConcurrency = &query.AdaptiveConcurrencyConfig{
+				MinLimit:         *storeConcurrencyMinLimit,
+				MaxLimit:         *storeConcurrencyMaxLimit,
+				LatencyThreshold: time.Duration(*storeConcurrencyLatencyThreshold),
+			}
+		}
+
+		var storeGroups query.StoreGroups
+		storeGroupsContentYaml, err := storeGroupsConfig.Content()
+		if err != nil {
+			return errors.Wrap(err, "get content of store groups configuration")
+		}
+		if len(storeGroupsContentYaml) > 0 {
+			storeGroups, err = query.ParseStoreGroups(storeGroupsContentYaml)
+			if err != nil {
+				return errors.Wrap(err, "parse store groups")
+			}
+		}
+
+		var tenantEndpoints []query.TenantEndpoint
+		tenantEndpointsContentYaml, err := tenantEndpointsConfig.Content()
+		if err != nil {
+			return errors.Wrap(err, "get content of tenant endpoints configuration")
+		}
+		if len(tenantEndpointsContentYaml) > 0 {
+			tenantEndpoints, err = query.ParseTenantEndpoints(tenantEndpointsContentYaml)
+			if err != nil {
+				return errors.Wrap(err, "parse tenant endpoints")
+			}
+		}
+
 		return runQuery(
 			g,
 			logger,
@@ -142,37 +354,113 @@ func registerQuery(m map[string]setupFunc, app *kingpin.Application) {
 			*grpcCert,
 			*grpcKey,
 			*grpcClientCA,
+			grpcPeers,
+			reqLogger,
+			*zone,
+			tenantEndpoints,
 			*secure,
 			*cert,
 			*key,
 			*caCert,
 			*serverName,
+			*compression,
+			interner,
+			int32(*windowSize),
+			int32(*connWindowSize),
 			*httpBindAddr,
 			time.Duration(*httpGracePeriod),
+			httpAuth,
 			*webRoutePrefix,
 			*webExternalPrefix,
 			*webPrefixHeaderName,
 			*maxConcurrentQueries,
 			time.Duration(*queryTimeout),
 			time.Duration(*storeResponseTimeout),
+			int64(*storeResponseBatchSize),
+			*storeResponseSortWindow,
+			*enableArrowSeries,
 			*replicaLabels,
 			selectorLset,
 			*stores,
 			*enableAutodownsampling,
+			time.Duration(*mixedResolutionAge),
 			*enablePartialResponse,
-			fileSD,
+			storeSDs,
 			time.Duration(*dnsSDInterval),
+			*dnsSDHonorTTL,
 			*dnsSDResolver,
 			time.Duration(*unhealthyStoreTimeout),
+			*enableStoreDrain,
+			storeConcurrency,
+			storeGroups,
 			time.Duration(*instantDefaultMaxSourceResolution),
 			*strictStores,
+			authorizer,
+			*tenantHeader,
+			tenantVerifier,
+			auditLogger,
+			queryLogger,
+			exporter,
+			rateLimiter,
+			*queryCostBudget,
+			time.Duration(*queryCostSampleInterval),
+			*queryCostDownsample,
+			time.Duration(*queryCostRefreshInterval),
 			component.Query,
 		)
 	}
 }
 
+type exportConfig struct {
+	objStoreConfig *extflag.PathOrContent
+	maxConcurrent  *int
+}
+
+func regExportFlags(cmd *kingpin.CmdClause) *exportConfig {
+	return &exportConfig{
+		objStoreConfig: regCommonObjStoreFlags(cmd, "-export", false, "Exports of range query results to the bucket are disabled if empty. See https://thanos.io/components/query.md/#query-export"),
+		maxConcurrent: cmd.Flag("query.export.max-concurrent", "Maximum number of range queries exported to the bucket concurrently. Further exports wait until others finished.").
+			Default("2").Int(),
+	}
+}
+
+// newExporter returns the exporter of range query results, or nil if exports are disabled.
+func newExporter(g *run.Group, logger log.Logger, reg prometheus.Registerer, conf *exportConfig) (*v1.Exporter, error) {
+	confContentYaml, err := conf.objStoreConfig.Content()
+	if err != nil {
+		return nil, err
+	}
+	if len(confContentYaml) == 0 {
+		return nil, nil
+	}
+	if *conf.maxConcurrent <= 0 {
+		return nil, errors.New("maximum number of concurrent exports must be positive")
+	}
+	bkt, err := client.NewBucket(logger, confContentYaml, extprom.WrapRegistererWithPrefix("thanos_query_export_", reg), component.Query.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "create export bucket client")
+	}
+	exporter := v1.NewExporter(log.With(logger, "component", "export"), reg, bkt, *conf.maxConcurrent)
+
+	done := make(chan struct{})
+	g.Add(func() error {
+		<-done
+		return nil
+	}, func(error) {
+		exporter.Stop()
+		runutil.CloseWithLogOnErr(logger, bkt, "export bucket client")
+		close(done)
+	})
+	return exporter, nil
+}
+
 // runQuery starts a server that exposes PromQL Query API. It is responsible for querying configured
 // store nodes, merging and duplicating the data to satisfy user query.
+// targetGroupDiscoverer discovers target groups of store addresses, like file and HTTP service discovery.
+type targetGroupDiscoverer interface {
+	Run(ctx context.Context, up chan<- []*targetgroup.Group)
+}
+
 func runQuery(
 	g *run.Group,
 	logger log.Logger,
@@ -183,30 +471,58 @@ func runQuery(
 	grpcCert string,
 	grpcKey string,
 	grpcClientCA string,
+	grpcPeers *grpcserver.PeerAllowlist,
+	reqLogger *logging.RequestLogger,
+	zone string,
+	tenantEndpoints []query.TenantEndpoint,
 	secure bool,
 	cert string,
 	key string,
 	caCert string,
 	serverName string,
+	compression string,
+	interner *strutil.Interner,
+	windowSize int32,
+	connWindowSize int32,
 	httpBindAddr string,
 	httpGracePeriod time.Duration,
+	httpAuth *httpserver.Authenticator,
 	webRoutePrefix string,
 	webExternalPrefix string,
 	webPrefixHeaderName string,
 	maxConcurrentQueries int,
 	queryTimeout time.Duration,
 	storeResponseTimeout time.Duration,
+	storeResponseBatchSize int64,
+	storeResponseSortWindow int,
+	enableArrowSeries bool,
 	replicaLabels []string,
 	selectorLset labels.Labels,
 	storeAddrs []string,
 	enableAutodownsampling bool,
+	mixedResolutionAge time.Duration,
 	enablePartialResponse bool,
-	fileSD *file.Discovery,
+	storeSDs []targetGroupDiscoverer,
 	dnsSDInterval time.Duration,
+	dnsSDHonorTTL bool,
 	dnsSDResolver string,
 	unhealthyStoreTimeout time.Duration,
+	enableStoreDrain bool,
+	storeConcurrency *query.AdaptiveConcurrencyConfig,
+	storeGroups query.StoreGroups,
 	instantDefaultMaxSourceResolution time.Duration,
 	strictStores []string,
+	authorizer authz.Authorizer,
+	tenantHeader string,
+	tenantVerifier *httpserver.TenantVerifier,
+	auditLogger *audit.Logger,
+	queryLogger *querylog.Logger,
+	exporter *v1.Exporter,
+	rateLimiter *ratelimit.Limiter,
+	queryCostBudget uint64,
+	queryCostSampleInterval time.Duration,
+	queryCostDownsample bool,
+	queryCostRefreshInterval time.Duration,
 	comp component.Component,
 ) error {
 	// TODO(bplotka in PR #513 review): Move arguments into struct.
@@ -215,10 +531,16 @@ func runQuery(
 		Help: "The number of times a duplicated store addresses is detected from the different configs in query",
 	})
 
-	dialOpts, err := extgrpc.StoreClientGRPCOpts(logger, reg, tracer, secure, cert, key, caCert, serverName)
+	dialOpts, err := extgrpc.StoreClientGRPCOpts(logger, reg, tracer, secure, cert, key, caCert, serverName, compression, interner)
 	if err != nil {
 		return errors.Wrap(err, "building gRPC client")
 	}
+	if windowSize > 0 {
+		dialOpts = append(dialOpts, grpc.WithInitialWindowSize(windowSize))
+	}
+	if connWindowSize > 0 {
+		dialOpts = append(dialOpts, grpc.WithInitialConnWindowSize(connWindowSize))
+	}
 
 	fileSDCache := cache.New()
 	dnsProvider := dns.NewProvider(
@@ -253,9 +575,12 @@ func runQuery(
 			},
 			dialOpts,
 			unhealthyStoreTimeout,
+			storeConcurrency,
+			storeGroups,
+			zone,
 		)
-		proxy            = store.NewProxyStore(logger, reg, stores.Get, component.Query, selectorLset, storeResponseTimeout)
-		queryableCreator = query.NewQueryableCreator(logger, proxy)
+		proxy            = store.NewProxyStore(logger, reg, stores.Get, component.Query, selectorLset, storeResponseTimeout, storeResponseBatchSize, storeResponseSortWindow, enableArrowSeries, replicaLabels)
+		queryableCreator = query.NewQueryableCreator(logger, reg, proxy, mixedResolutionAge)
 		engine           = promql.NewEngine(
 			promql.EngineOpts{
 				Logger:        logger,
@@ -280,25 +605,26 @@ func runQuery(
 			stores.Close()
 		})
 	}
-	// Run File Service Discovery and update the store set when the files are modified.
-	if fileSD != nil {
-		var fileSDUpdates chan []*targetgroup.Group
-		ctxRun, cancelRun := context.WithCancel(context.Background())
-
-		fileSDUpdates = make(chan []*targetgroup.Group)
-
-		g.Add(func() error {
-			fileSD.Run(ctxRun, fileSDUpdates)
-			return nil
-		}, func(error) {
-			cancelRun()
-		})
+	// Run file and HTTP Service Discovery and update the store set when the discovered addresses change.
+	if len(storeSDs) > 0 {
+		sdUpdates := make(chan []*targetgroup.Group)
+
+		for _, sd := range storeSDs {
+			sd := sd
+			ctxRun, cancelRun := context.WithCancel(context.Background())
+			g.Add(func() error {
+				sd.Run(ctxRun, sdUpdates)
+				return nil
+			}, func(error) {
+				cancelRun()
+			})
+		}
 
 		ctxUpdate, cancelUpdate := context.WithCancel(context.Background())
 		g.Add(func() error {
 			for {
 				select {
-				case update := <-fileSDUpdates:
+				case update := <-sdUpdates:
 					// Discoverers sometimes send nil updates so need to check for it to avoid panics.
 					if update == nil {
 						continue
@@ -311,15 +637,20 @@ func runQuery(
 				}
 			}
 		}, func(error) {
+			// The channel is not closed, as discoveries may still be sending until they are stopped.
 			cancelUpdate()
-			close(fileSDUpdates)
 		})
 	}
 	// Periodically update the addresses from static flags and file SD by resolving them using DNS SD if necessary.
 	{
 		ctx, cancel := context.WithCancel(context.Background())
 		g.Add(func() error {
-			return runutil.Repeat(dnsSDInterval, ctx.Done(), func() error {
+			return runutil.RepeatDynamic(func() time.Duration {
+				if dnsSDHonorTTL {
+					return dnsProvider.RefreshInterval(dnsSDInterval)
+				}
+				return dnsSDInterval
+			}, ctx.Done(), func() error {
 				dnsProvider.Resolve(ctx, append(fileSDCache.Addresses(), storeAddrs...))
 				return nil
 			})
@@ -328,6 +659,26 @@ func runQuery(
 		})
 	}
 
+	// Periodically refresh cardinality statistics of stores for query cost estimation.
+	var costEstimator *query.CostEstimator
+	if queryCostBudget > 0 {
+		if queryCostSampleInterval <= 0 {
+			return errors.New("query cost sample interval must be positive")
+		}
+		costEstimator = query.NewCostEstimator(logger, reg, stores.GetStatusClients, queryCostBudget, queryCostSampleInterval, queryCostDownsample)
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			return runutil.Repeat(queryCostRefreshInterval, ctx.Done(), func() error {
+				if err := costEstimator.Refresh(ctx); err != nil {
+					level.Warn(logger).Log("msg", "refreshing cardinality statistics for query cost estimation failed", "err", err)
+				}
+				return nil
+			})
+		}, func(error) {
+			cancel()
+		})
+	}
+
 	grpcProbe := prober.NewGRPC()
 	httpProbe := prober.NewHTTP()
 	statusProber := prober.Combine(
@@ -353,17 +704,28 @@ func runQuery(
 
 		ins := extpromhttp.NewInstrumentationMiddleware(reg)
 		// TODO(bplotka in PR #513 review): pass all flags, not only the flags needed by prefix rewriting.
-		ui.NewQueryUI(logger, reg, stores, webExternalPrefix, webPrefixHeaderName).Register(router, ins)
+		ui.NewQueryUI(logger, reg, stores, webExternalPrefix, webPrefixHeaderName, enableStoreDrain).Register(router, ins)
 
-		api := v1.NewAPI(logger, reg, engine, queryableCreator, enableAutodownsampling, enablePartialResponse, replicaLabels, instantDefaultMaxSourceResolution)
+		api := v1.NewAPI(logger, reg, engine, queryableCreator, enableAutodownsampling, enablePartialResponse, replicaLabels, instantDefaultMaxSourceResolution, authorizer, tenantHeader, auditLogger, rateLimiter, stores.GetStatusClients, stores.GetStoreStatus, costEstimator, usage.NewAccountant(reg), queryLogger, exporter)
 
 		api.Register(router.WithPrefix(path.Join(webRoutePrefix, "/api/v1")), tracer, logger, ins)
+		api.RegisterFederation(router, tracer, logger, ins)
+
+		if enableStoreDrain {
+			adminRouter := router.WithPrefix(path.Join(webRoutePrefix, "/api/v1/stores"))
+			adminRouter.Post("/drain", ins.NewHandler("store_drain", storeDrainHandler(stores.Drain)))
+			adminRouter.Post("/undrain", ins.NewHandler("store_undrain", storeDrainHandler(stores.Undrain)))
+		}
 
 		srv := httpserver.New(logger, reg, comp, httpProbe,
 			httpserver.WithListen(httpBindAddr),
 			httpserver.WithGracePeriod(httpGracePeriod),
+			httpserver.WithRequestLogging(reqLogger),
+			httpserver.WithAuthentication(httpAuth),
+			httpserver.WithTenantVerification(tenantVerifier),
 		)
-		srv.Handle("/", router)
+		// Goroutines of requests are labeled with their tenant, so they can be told apart in CPU profiles.
+		srv.Handle("/", profiling.TenantLabelsMiddleware(tenantHeader, router))
 
 		g.Add(func() error {
 			statusProber.Healthy()
@@ -378,7 +740,7 @@ func runQuery(
 	}
 	// Start query (proxy) gRPC StoreAPI.
 	{
-		tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), grpcCert, grpcKey, grpcClientCA)
+		tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), extprom.WrapRegistererWith(prometheus.Labels{"protocol": "grpc"}, reg), grpcCert, grpcKey, grpcClientCA)
 		if err != nil {
 			return errors.Wrap(err, "setup gRPC server")
 		}
@@ -387,6 +749,10 @@ func runQuery(
 			grpcserver.WithListen(grpcBindAddr),
 			grpcserver.WithGracePeriod(grpcGracePeriod),
 			grpcserver.WithTLSConfig(tlsCfg),
+			grpcserver.WithPeerAllowlist(grpcPeers),
+			grpcserver.WithRequestLogging(reqLogger),
+			grpcserver.WithZone(zone),
+			grpcserver.WithRateLimiter(rateLimiter),
 		)
 
 		g.Add(func() error {
@@ -396,12 +762,54 @@ func runQuery(
 			statusProber.NotReady(err)
 			s.Shutdown(err)
 		})
-	}
 
+		// Virtual StoreAPIs of tenants share the TLS configuration of the StoreAPI. Their gRPC metrics are registered
+		// with a prefix, as metrics of the same name have to have the same labels.
+		for _, e := range tenantEndpoints {
+			ts := grpcserver.New(log.With(logger, "tenant", e.Tenant),
+				extprom.WrapRegistererWithPrefix("thanos_query_tenant_", extprom.WrapRegistererWith(prometheus.Labels{"tenant": e.Tenant}, reg)),
+				tracer, comp, grpcProbe,
+				store.NewTenantStore(proxy, e.Labels(), e.Matchers(), enableArrowSeries),
+				grpcserver.WithListen(e.Listen),
+				grpcserver.WithGracePeriod(grpcGracePeriod),
+				grpcserver.WithTLSConfig(tlsCfg),
+				grpcserver.WithPeerAllowlist(grpcPeers),
+				grpcserver.WithRequestLogging(reqLogger),
+				grpcserver.WithZone(zone),
+				grpcserver.WithRateLimiter(rateLimiter),
+			)
+
+			g.Add(func() error {
+				return ts.ListenAndServe()
+			}, func(err error) {
+				ts.Shutdown(err)
+			})
+		}
+	}
 	level.Info(logger).Log("msg", "starting query node")
 	return nil
 }
 
+// storeDrainHandler drains or undrains the store with the address given by the addr parameter.
+func storeDrainHandler(drain func(addr string) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := r.FormValue("addr")
+		if addr == "" {
+			http.Error(w, "no addr parameter provided", http.StatusBadRequest)
+			return
+		}
+		if err := drain(addr); err != nil {
+			if errors.Cause(err) == query.ErrStoreNotFound {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
 func removeDuplicateStoreSpecs(logger log.Logger, duplicatedStores prometheus.Counter, specs []query.StoreSpec) []query.StoreSpec {
 	set := make(map[string]query.StoreSpec)
 	for _, spec := range specs {
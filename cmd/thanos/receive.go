@@ -16,17 +16,22 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/storage/tsdb"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/thanos-io/thanos/pkg/audit"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/discovery/gossip"
 	"github.com/thanos-io/thanos/pkg/extflag"
 	"github.com/thanos-io/thanos/pkg/extgrpc"
 	"github.com/thanos-io/thanos/pkg/extprom"
+	"github.com/thanos-io/thanos/pkg/logging"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
+	"github.com/thanos-io/thanos/pkg/ratelimit"
 	"github.com/thanos-io/thanos/pkg/receive"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	grpcserver "github.com/thanos-io/thanos/pkg/server/grpc"
@@ -41,7 +46,16 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 	cmd := app.Command(comp.String(), "Accept Prometheus remote write API requests and write to local tsdb (EXPERIMENTAL, this may change drastically without notice)")
 
 	httpBindAddr, httpGracePeriod := regHTTPFlags(cmd)
+	httpAuthConfig := regHTTPAuthFlags(cmd)
+	tenantSignatureConfig := regTenantSignatureFlags(cmd)
+	auditConfig := regAuditFlags(cmd)
+	rateLimitConfig := regRateLimitFlags(cmd, "remote write requests")
 	grpcBindAddr, grpcGracePeriod, grpcCert, grpcKey, grpcClientCA := regGRPCFlags(cmd)
+	grpcPeerAllowlistConfig := regGRPCPeerAllowlistFlags(cmd)
+	requestLoggingConfig := regRequestLoggingFlags(cmd)
+
+	zone := regZoneFlag(cmd)
+	gossipConfig := regGossipFlags(cmd)
 
 	rwAddress := cmd.Flag("remote-write.address", "Address to listen on for remote write requests.").
 		Default("0.0.0.0:19291").String()
@@ -70,12 +84,37 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 
 	local := cmd.Flag("receive.local-endpoint", "Endpoint of local receive node. Used to identify the local node in the hashring configuration.").String()
 
+	gossipHashring := cmd.Flag("receive.gossip-hashring", "Name of the hashring the local node joins if hashrings are gossiped, i.e. if --gossip.address is set and --receive.hashrings-file is not.").
+		Default("").String()
+
+	gossipHashringTenants := cmd.Flag("receive.gossip-hashring-tenant", "Tenant of the hashring the local node joins if hashrings are gossiped (repeatable). Hashrings without tenants receive requests of all tenants not matched by other hashrings.").
+		PlaceHolder("<tenant>").Strings()
+
 	tenantHeader := cmd.Flag("receive.tenant-header", "HTTP header to determine tenant for write requests.").Default(receive.DefaultTenantHeader).String()
 
 	replicaHeader := cmd.Flag("receive.replica-header", "HTTP header specifying the replica number of a write request.").Default(receive.DefaultReplicaHeader).String()
 
 	replicationFactor := cmd.Flag("receive.replication-factor", "How many times to replicate incoming write requests.").Default("1").Uint64()
 
+	requestTimeout := modelDuration(cmd.Flag("receive.request-timeout", "Maximum time to handle a write request, including writing locally and forwarding to other nodes. The deadline is propagated to forwarded requests. 0 means the deadline of the client only.").
+		Default("0s"))
+
+	tenantRequestTimeouts := cmd.Flag("receive.tenant-request-timeout", "Maximum time to handle a write request of the given tenant, overriding --receive.request-timeout (repeatable).").
+		PlaceHolder("<tenant>=<duration>").StringMap()
+
+	localWriteTimeout := modelDuration(cmd.Flag("receive.local-write-timeout", "Maximum time waited for writing a request into the local TSDB. Writes exceeding it complete in the background, but fail the request on this node. 0 means no timeout.").
+		Default("0s"))
+
+	forwardTimeout := modelDuration(cmd.Flag("receive.forward-timeout", "Maximum time of each request forwarded to another node, so a single slow node does not consume the whole deadline of a write request. 0 means no timeout.").
+		Default("0s"))
+
+	kafkaConfig := extflag.RegisterPathOrContent(cmd, "receive.kafka-config", "YAML file with configuration of the Kafka topic remote write requests are published to by routers and consumed from by ingestors, instead of being forwarded by the hashring. Kafka is not used if empty. See format details: https://thanos.io/receive-kafka.md/#configuration", false)
+
+	ingestConfig := extflag.RegisterPathOrContent(cmd, "receive.ingest-config", "YAML file with per-tenant relabeling and validation rules applied to the series of write requests before they are written. Series are not relabeled nor validated if empty. See format details: https://thanos.io/receive-ingest.md/#configuration", false)
+
+	kafkaRole := cmd.Flag("receive.kafka-role", "Role of this node if --receive.kafka-config is set. Routers publish remote write requests to the Kafka topic and acknowledge them once Kafka has them. Ingestors consume the partitions of the topic assigned to them and write them into their TSDB.").
+		Default("router").Enum("router", "ingestor")
+
 	tsdbMinBlockDuration := modelDuration(cmd.Flag("tsdb.min-block-duration", "Min duration for local TSDB blocks").Default("2h").Hidden())
 	tsdbMaxBlockDuration := modelDuration(cmd.Flag("tsdb.max-block-duration", "Max duration for local TSDB blocks").Default("2h").Hidden())
 	ignoreBlockSize := cmd.Flag("shipper.ignore-unequal-block-size", "If true receive will not require min and max block size flags to be set to the same value. Only use this if you want to keep long retention and compaction enabled, as in the worst case it can result in ~2h data loss for your Thanos bucket storage.").Default("false").Hidden().Bool()
@@ -116,6 +155,82 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 			*local = fmt.Sprintf("http://%s:%s/api/v1/receive", hostname, port)
 		}
 
+		httpAuth, err := newHTTPAuthenticator(logger, reg, httpAuthConfig)
+		if err != nil {
+			return errors.Wrap(err, "create HTTP authenticator")
+		}
+
+		tenantVerifier, err := newTenantVerifier(logger, reg, tenantSignatureConfig, *tenantHeader)
+		if err != nil {
+			return errors.Wrap(err, "create tenant signature verifier")
+		}
+
+		auditLogger, err := newAuditLogger(logger, reg, auditConfig)
+		if err != nil {
+			return errors.Wrap(err, "create audit logger")
+		}
+
+		rateLimiter, err := newRateLimiter(reg, rateLimitConfig, *tenantHeader)
+		if err != nil {
+			return errors.Wrap(err, "create rate limiter")
+		}
+
+		grpcPeers, err := newGRPCPeerAllowlist(logger, reg, grpcPeerAllowlistConfig, *grpcClientCA)
+		if err != nil {
+			return errors.Wrap(err, "create gRPC peer allowlist")
+		}
+
+		reqLogger, err := newRequestLogger(g, logger, reg, requestLoggingConfig)
+		if err != nil {
+			return errors.Wrap(err, "create request logger")
+		}
+
+		tenantTimeouts := make(map[string]time.Duration, len(*tenantRequestTimeouts))
+		for tenant, v := range *tenantRequestTimeouts {
+			d, err := model.ParseDuration(v)
+			if err != nil {
+				return errors.Wrapf(err, "parse request timeout of tenant %q", tenant)
+			}
+			tenantTimeouts[tenant] = time.Duration(d)
+		}
+
+		var validator *receive.Validator
+		ingestConfYaml, err := ingestConfig.Content()
+		if err != nil {
+			return err
+		}
+		if len(ingestConfYaml) > 0 {
+			validator, err = receive.NewValidator(reg, ingestConfYaml)
+			if err != nil {
+				return errors.Wrap(err, "create ingest validator")
+			}
+		}
+
+		var kafkaConf *receive.KafkaConfig
+		kafkaConfYaml, err := kafkaConfig.Content()
+		if err != nil {
+			return err
+		}
+		if len(kafkaConfYaml) > 0 {
+			kafkaConf, err = receive.ParseKafkaConfig(kafkaConfYaml)
+			if err != nil {
+				return errors.Wrap(err, "parse Kafka config")
+			}
+		}
+
+		gossipPeer, err := newGossipPeer(g, logger, reg, gossipConfig, gossip.State{
+			Component: comp.String(),
+			StoreAPI:  *grpcBindAddr,
+			Receive: &gossip.ReceiveState{
+				Endpoint: *local,
+				Hashring: *gossipHashring,
+				Tenants:  *gossipHashringTenants,
+			},
+		})
+		if err != nil {
+			return errors.Wrap(err, "create gossip peer")
+		}
+
 		return runReceive(
 			g,
 			logger,
@@ -126,8 +241,15 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 			*grpcCert,
 			*grpcKey,
 			*grpcClientCA,
+			grpcPeers,
+			reqLogger,
+			*zone,
 			*httpBindAddr,
 			time.Duration(*httpGracePeriod),
+			httpAuth,
+			tenantVerifier,
+			auditLogger,
+			rateLimiter,
 			*rwAddress,
 			*rwServerCert,
 			*rwServerKey,
@@ -142,10 +264,18 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application) {
 			*ignoreBlockSize,
 			lset,
 			cw,
+			gossipPeer,
 			*local,
 			*tenantHeader,
 			*replicaHeader,
 			*replicationFactor,
+			time.Duration(*requestTimeout),
+			tenantTimeouts,
+			time.Duration(*localWriteTimeout),
+			time.Duration(*forwardTimeout),
+			validator,
+			kafkaConf,
+			*kafkaRole,
 			comp,
 		)
 	}
@@ -161,8 +291,15 @@ func runReceive(
 	grpcCert string,
 	grpcKey string,
 	grpcClientCA string,
+	grpcPeers *grpcserver.PeerAllowlist,
+	reqLogger *logging.RequestLogger,
+	zone string,
 	httpBindAddr string,
 	httpGracePeriod time.Duration,
+	httpAuth *httpserver.Authenticator,
+	tenantVerifier *httpserver.TenantVerifier,
+	auditLogger *audit.Logger,
+	rateLimiter *ratelimit.Limiter,
 	rwAddress string,
 	rwServerCert string,
 	rwServerKey string,
@@ -177,35 +314,72 @@ func runReceive(
 	ignoreBlockSize bool,
 	lset labels.Labels,
 	cw *receive.ConfigWatcher,
+	gossipPeer *gossip.Peer,
 	endpoint string,
 	tenantHeader string,
 	replicaHeader string,
 	replicationFactor uint64,
+	requestTimeout time.Duration,
+	tenantRequestTimeouts map[string]time.Duration,
+	localWriteTimeout time.Duration,
+	forwardTimeout time.Duration,
+	validator *receive.Validator,
+	kafkaConf *receive.KafkaConfig,
+	kafkaRole string,
 	comp component.SourceStoreAPI,
 ) error {
 	logger = log.With(logger, "component", "receive")
 	level.Warn(logger).Log("msg", "setting up receive; the Thanos receive component is EXPERIMENTAL, it may break significantly without notice")
 
 	localStorage := &tsdb.ReadyStorage{}
-	rwTLSConfig, err := tls.NewServerConfig(log.With(logger, "protocol", "HTTP"), rwServerCert, rwServerKey, rwServerClientCA)
+	rwTLSConfig, err := tls.NewServerConfig(log.With(logger, "protocol", "HTTP"), extprom.WrapRegistererWith(prometheus.Labels{"protocol": "http"}, reg), rwServerCert, rwServerKey, rwServerClientCA)
 	if err != nil {
 		return err
 	}
-	dialOpts, err := extgrpc.StoreClientGRPCOpts(logger, reg, tracer, rwServerCert != "", rwClientCert, rwClientKey, rwClientServerCA, rwClientServerName)
+	dialOpts, err := extgrpc.StoreClientGRPCOpts(logger, reg, tracer, rwServerCert != "", rwClientCert, rwClientKey, rwClientServerCA, rwClientServerName, extgrpc.CompressionNone, nil)
 	if err != nil {
 		return err
 	}
 
+	var (
+		kafkaPublisher *receive.KafkaPublisher
+		kafkaIngestor  *receive.KafkaIngestor
+	)
+	if kafkaConf != nil {
+		switch kafkaRole {
+		case "router":
+			kafkaPublisher, err = receive.NewKafkaPublisher(reg, kafkaConf)
+			if err != nil {
+				return errors.Wrap(err, "create Kafka publisher")
+			}
+		case "ingestor":
+			kafkaIngestor, err = receive.NewKafkaIngestor(log.With(logger, "component", "kafka-ingestor"), reg, kafkaConf)
+			if err != nil {
+				return errors.Wrap(err, "create Kafka ingestor")
+			}
+		}
+	}
+
 	webHandler := receive.NewHandler(log.With(logger, "component", "receive-handler"), &receive.Options{
-		ListenAddress:     rwAddress,
-		Registry:          reg,
-		Endpoint:          endpoint,
-		TenantHeader:      tenantHeader,
-		ReplicaHeader:     replicaHeader,
-		ReplicationFactor: replicationFactor,
-		Tracer:            tracer,
-		TLSConfig:         rwTLSConfig,
-		DialOpts:          dialOpts,
+		ListenAddress:         rwAddress,
+		Registry:              reg,
+		Endpoint:              endpoint,
+		TenantHeader:          tenantHeader,
+		ReplicaHeader:         replicaHeader,
+		ReplicationFactor:     replicationFactor,
+		Tracer:                tracer,
+		TLSConfig:             rwTLSConfig,
+		DialOpts:              dialOpts,
+		Authenticator:         httpAuth,
+		TenantVerifier:        tenantVerifier,
+		AuditLogger:           auditLogger,
+		RateLimiter:           rateLimiter,
+		KafkaPublisher:        kafkaPublisher,
+		RequestTimeout:        requestTimeout,
+		TenantRequestTimeouts: tenantRequestTimeouts,
+		LocalWriteTimeout:     localWriteTimeout,
+		ForwardTimeout:        forwardTimeout,
+		Validator:             validator,
 	})
 
 	grpcProbe := prober.NewGRPC()
@@ -295,7 +469,11 @@ func runReceive(
 					}
 					level.Info(logger).Log("msg", "tsdb started")
 					localStorage.Set(db.Get(), startTimeMargin)
-					webHandler.SetWriter(receive.NewWriter(log.With(logger, "component", "receive-writer"), localStorage))
+					writer := receive.NewWriter(log.With(logger, "component", "receive-writer"), localStorage)
+					webHandler.SetWriter(writer)
+					if kafkaIngestor != nil {
+						kafkaIngestor.SetWriter(writer)
+					}
 					statusProber.Ready()
 					level.Info(logger).Log("msg", "server is ready to receive web requests")
 					dbReady <- struct{}{}
@@ -309,10 +487,10 @@ func runReceive(
 
 	level.Debug(logger).Log("msg", "setting up hashring")
 	{
-		// Note: the hashring configuration watcher
+		// Note: the hashring configuration watcher or gossip
 		// is the sender and thus closes the chan.
-		// In the single-node case, which has no configuration
-		// watcher, we close the chan ourselves.
+		// In the single-node case, which has neither,
+		// we close the chan ourselves.
 		updates := make(chan receive.Hashring, 1)
 
 		if cw != nil {
@@ -328,6 +506,13 @@ func runReceive(
 			}, func(error) {
 				cancel()
 			})
+		} else if gossipPeer != nil {
+			ctx, cancel := context.WithCancel(context.Background())
+			g.Add(func() error {
+				return receive.HashringFromGossip(ctx, updates, gossipPeer)
+			}, func(error) {
+				cancel()
+			})
 		} else {
 			cancel := make(chan struct{})
 			g.Add(func() error {
@@ -350,6 +535,9 @@ func runReceive(
 						return nil
 					}
 					webHandler.SetWriter(nil)
+					if kafkaIngestor != nil {
+						kafkaIngestor.SetWriter(nil)
+					}
 					webHandler.Hashring(h)
 					msg := "hashring has changed; server is not ready to receive web requests."
 					statusProber.NotReady(errors.New(msg))
@@ -369,6 +557,8 @@ func runReceive(
 	srv := httpserver.New(logger, reg, comp, httpProbe,
 		httpserver.WithListen(httpBindAddr),
 		httpserver.WithGracePeriod(httpGracePeriod),
+		httpserver.WithRequestLogging(reqLogger),
+		httpserver.WithAuthentication(httpAuth),
 	)
 	g.Add(func() error {
 		statusProber.Healthy()
@@ -388,7 +578,7 @@ func runReceive(
 		g.Add(func() error {
 			defer close(startGRPC)
 
-			tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), grpcCert, grpcKey, grpcClientCA)
+			tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), extprom.WrapRegistererWith(prometheus.Labels{"protocol": "grpc"}, reg), grpcCert, grpcKey, grpcClientCA)
 			if err != nil {
 				return errors.Wrap(err, "setup gRPC server")
 			}
@@ -407,6 +597,10 @@ func runReceive(
 					grpcserver.WithListen(grpcBindAddr),
 					grpcserver.WithGracePeriod(grpcGracePeriod),
 					grpcserver.WithTLSConfig(tlsCfg),
+					grpcserver.WithPeerAllowlist(grpcPeers),
+					grpcserver.WithRequestLogging(reqLogger),
+					grpcserver.WithZone(zone),
+					grpcserver.WithStatusServer(tsdbStore),
 				)
 				startGRPC <- struct{}{}
 			}
@@ -435,10 +629,22 @@ func runReceive(
 			},
 			func(err error) {
 				webHandler.Close()
+				if kafkaPublisher != nil {
+					runutil.CloseWithLogOnErr(logger, kafkaPublisher, "Kafka publisher")
+				}
 			},
 		)
 	}
 
+	if kafkaIngestor != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			return kafkaIngestor.Run(ctx)
+		}, func(error) {
+			cancel()
+		})
+	}
+
 	if upload {
 		// The background shipper continuously scans the data directory and uploads
 		// new blocks to Google Cloud Storage or an S3-compatible storage service.
@@ -5,6 +5,7 @@ package main
 
 import (
 	"context"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"net/url"
@@ -12,6 +13,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -24,8 +26,11 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/route"
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/storage/tsdb"
 	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
 	"github.com/prometheus/prometheus/util/strutil"
@@ -33,16 +38,21 @@ import (
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/component"
 	"github.com/thanos-io/thanos/pkg/discovery/dns"
+	"github.com/thanos-io/thanos/pkg/discovery/gossip"
 	"github.com/thanos-io/thanos/pkg/extflag"
 	"github.com/thanos-io/thanos/pkg/extprom"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	http_util "github.com/thanos-io/thanos/pkg/http"
+	"github.com/thanos-io/thanos/pkg/logging"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
 	"github.com/thanos-io/thanos/pkg/promclient"
 	"github.com/thanos-io/thanos/pkg/query"
+	"github.com/thanos-io/thanos/pkg/receive"
 	thanosrule "github.com/thanos-io/thanos/pkg/rule"
 	v1 "github.com/thanos-io/thanos/pkg/rule/api"
+	"github.com/thanos-io/thanos/pkg/rule/remotewrite"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	grpcserver "github.com/thanos-io/thanos/pkg/server/grpc"
 	httpserver "github.com/thanos-io/thanos/pkg/server/http"
@@ -61,7 +71,13 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 	cmd := app.Command(comp.String(), "ruler evaluating Prometheus rules against given Query nodes, exposing Store API and storing old blocks in bucket")
 
 	httpBindAddr, httpGracePeriod := regHTTPFlags(cmd)
+	httpAuthConfig := regHTTPAuthFlags(cmd)
 	grpcBindAddr, grpcGracePeriod, grpcCert, grpcKey, grpcClientCA := regGRPCFlags(cmd)
+	grpcPeerAllowlistConfig := regGRPCPeerAllowlistFlags(cmd)
+	requestLoggingConfig := regRequestLoggingFlags(cmd)
+
+	zone := regZoneFlag(cmd)
+	gossipConfig := regGossipFlags(cmd)
 
 	labelStrs := cmd.Flag("label", "Labels to be applied to all generated metrics (repeated). Similar to external labels for Prometheus, used to identify ruler and its blocks as unique source.").
 		PlaceHolder("<name>=\"<value>\"").Strings()
@@ -72,8 +88,16 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 		Default("rules/").Strings()
 	resendDelay := modelDuration(cmd.Flag("resend-delay", "Minimum amount of time to wait before resending an alert to Alertmanager.").
 		Default("1m"))
+	outageTolerance := modelDuration(cmd.Flag("for-outage-tolerance", "Max time to tolerate Ruler outage for restoring 'for' state of alerts from ALERTS_FOR_STATE series queried from query API servers.").
+		Default("1h"))
+	forGracePeriod := modelDuration(cmd.Flag("for-grace-period", "Minimum duration between alert and restored 'for' state. This is maintained only for alerts with configured 'for' time greater than grace period.").
+		Default("10m"))
 	evalInterval := modelDuration(cmd.Flag("eval-interval", "The default evaluation interval to use.").
 		Default("30s"))
+	evalConcurrency := cmd.Flag("eval-concurrency", "Maximum number of rules within a rule group evaluated concurrently. Only consecutive rules not consuming results of each other are evaluated concurrently. 1 evaluates all rules sequentially.").
+		Default("1").Int()
+	evalJitter := modelDuration(cmd.Flag("eval-jitter", "Maximum delay of rule group evaluations, to spread query load of groups evaluated at the same time. Each group is delayed by a duration derived from its name and file, at most half of its interval. Evaluation timestamps are not changed. 0 disables the jitter.").
+		Default("0s"))
 	tsdbBlockDuration := modelDuration(cmd.Flag("tsdb.block-duration", "Block duration for TSDB block.").
 		Default("2h"))
 	tsdbRetention := modelDuration(cmd.Flag("tsdb.retention", "Block retention time on local disk.").
@@ -87,17 +111,29 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 	alertmgrsConfig := extflag.RegisterPathOrContent(cmd, "alertmanagers.config", "YAML file that contains alerting configuration. See format details: https://thanos.io/components/rule.md/#configuration. If defined, it takes precedence over the '--alertmanagers.url' and '--alertmanagers.send-timeout' flags.", false)
 	alertmgrsDNSSDInterval := modelDuration(cmd.Flag("alertmanagers.sd-dns-interval", "Interval between DNS resolutions of Alertmanager hosts.").
 		Default("30s"))
+	alertmgrsDNSSDHonorTTL := cmd.Flag("alertmanagers.sd-dns-honor-ttl", "Resolve Alertmanager hosts again when their DNS records expire instead of every --alertmanagers.sd-dns-interval, which is then only used if TTLs are unknown. Never resolves more often than every second. Requires the miekgdns resolver.").
+		Default("false").Bool()
 
 	alertQueryURL := cmd.Flag("alert.query-url", "The external Thanos Query URL that would be set in all alerts 'Source' field").String()
 
 	alertExcludeLabels := cmd.Flag("alert.label-drop", "Labels by name to drop before sending to alertmanager. This allows alert to be deduplicated on replica label (repeated). Similar Prometheus alert relabelling").
 		Strings()
+	alertRelabelConfig := extflag.RegisterPathOrContent(cmd, "alert.relabel-config", "YAML file that contains alert relabelling configuration applied to alerts before sending them to Alertmanager, after external labels are attached and '--alert.label-drop' labels are dropped. It follows native Prometheus alert_relabel_configs syntax. See format details: https://prometheus.io/docs/prometheus/latest/configuration/configuration/#alert_relabel_configs", false)
 	webRoutePrefix := cmd.Flag("web.route-prefix", "Prefix for API and UI endpoints. This allows thanos UI to be served on a sub-path. This option is analogous to --web.route-prefix of Promethus.").Default("").String()
 	webExternalPrefix := cmd.Flag("web.external-prefix", "Static prefix for all HTML links and redirect URLs in the UI query web interface. Actual endpoints are still served on / or the web.route-prefix. This allows thanos UI to be served behind a reverse proxy that strips a URL sub-path.").Default("").String()
 	webPrefixHeaderName := cmd.Flag("web.prefix-header", "Name of HTTP request header used for dynamic prefixing of UI links and redirects. This option is ignored if web.external-prefix argument is set. Security risk: enable this option only if a reverse proxy in front of thanos is resetting the header. The --web.prefix-header=X-Forwarded-Prefix option can be useful, for example, if Thanos UI is served via Traefik reverse proxy with PathPrefixStrip option enabled, which sends the stripped prefix value in X-Forwarded-Prefix header. This allows thanos UI to be served on a sub-path.").Default("").String()
 
 	objStoreConfig := regCommonObjStoreFlags(cmd, "", false)
 
+	ruleAPIDir := cmd.Flag("rule-api.dir", "Directory for rule files managed via the rule files API. If set, the API is enabled and rule files from this directory are loaded in addition to '--rule-file'.").
+		String()
+	ruleAPITokenFile := cmd.Flag("rule-api.bearer-token-file", "Path to file with the bearer token required by the rule files API. Required if '--rule-api.dir' is set.").
+		PlaceHolder("<path>").String()
+	ruleAPIObjStorePrefix := cmd.Flag("rule-api.objstore-prefix", "If set, rule files managed via the rule files API are persisted in the bucket given by '--objstore.config' under this prefix, and downloaded to '--rule-api.dir' on startup.").
+		String()
+
+	remoteWriteConfig := extflag.RegisterPathOrContent(cmd, "remote-write.config", "YAML file that contains remote write configuration. See format details: https://thanos.io/components/rule.md/#configuration. If defined, Ruler runs in stateless mode: evaluation results are kept only in the WAL and forwarded to remote write endpoints instead of being stored in a local TSDB, exposed via StoreAPI and shipped to the bucket.", false)
+
 	queries := cmd.Flag("query", "Addresses of statically configured query API servers (repeatable). The scheme may be prefixed with 'dns+' or 'dnssrv+' to detect query API servers through respective DNS lookups.").
 		PlaceHolder("<query>").Strings()
 
@@ -112,9 +148,18 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 	dnsSDInterval := modelDuration(cmd.Flag("query.sd-dns-interval", "Interval between DNS resolutions.").
 		Default("30s"))
 
+	dnsSDHonorTTL := cmd.Flag("query.sd-dns-honor-ttl", "Resolve DNS names of query API servers again when their records expire instead of every --query.sd-dns-interval, which is then only used if TTLs are unknown. Never resolves more often than every second. Requires the miekgdns resolver.").
+		Default("false").Bool()
+
 	dnsSDResolver := cmd.Flag("query.sd-dns-resolver", "Resolver to use. Possible options: [golang, miekgdns]").
 		Default("golang").Hidden().String()
 
+	queryHealthCheckInterval := modelDuration(cmd.Flag("query.health-check-interval", "Interval between health checks of query API endpoints. Endpoints that failed a query or a health check are tried only after healthy ones, until they pass a health check or for this long. 0 disables active health checks.").
+		Default("30s"))
+
+	queryTenantHeader := cmd.Flag("query.tenant-header", "HTTP header used to pass 'source_tenants' of a rule group to query API servers. Multiple tenants are separated by '|'.").
+		Default(receive.DefaultTenantHeader).String()
+
 	m[comp.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, reload <-chan struct{}, _ bool) error {
 		lset, err := parseFlagLabels(*labelStrs)
 		if err != nil {
@@ -133,6 +178,41 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 			WALCompression:    *walCompression,
 		}
 
+		var (
+			ruleFileStore *thanosrule.FileStore
+			ruleAPIToken  string
+		)
+		if *ruleAPIDir != "" {
+			if *ruleAPITokenFile == "" {
+				return errors.New("--rule-api.bearer-token-file is required if --rule-api.dir is set")
+			}
+			b, err := ioutil.ReadFile(*ruleAPITokenFile)
+			if err != nil {
+				return errors.Wrap(err, "read rule API bearer token file")
+			}
+			ruleAPIToken = strings.TrimSpace(string(b))
+			if ruleAPIToken == "" {
+				return errors.New("rule API bearer token file is empty")
+			}
+
+			var bkt objstore.Bucket
+			if *ruleAPIObjStorePrefix != "" {
+				confContentYaml, err := objStoreConfig.Content()
+				if err != nil {
+					return err
+				}
+				if len(confContentYaml) == 0 {
+					return errors.New("--objstore.config is required if --rule-api.objstore-prefix is set")
+				}
+				// Bucket metrics are already registered by the shipper bucket client.
+				bkt, err = client.NewBucket(logger, confContentYaml, nil, component.Rule.String())
+				if err != nil {
+					return err
+				}
+			}
+			ruleFileStore = thanosrule.NewFileStore(logger, *ruleAPIDir, bkt, *ruleAPIObjStorePrefix)
+		}
+
 		// Parse and check query configuration.
 		lookupQueries := map[string]struct{}{}
 		for _, q := range *queries {
@@ -154,6 +234,11 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 			return errors.New("--query/--query.sd-files and --query.config* parameters cannot be defined at the same time")
 		}
 
+		remoteWriteConfigYAML, err := remoteWriteConfig.Content()
+		if err != nil {
+			return err
+		}
+
 		// Parse and check alerting configuration.
 		alertmgrsConfigYAML, err := alertmgrsConfig.Content()
 		if err != nil {
@@ -163,6 +248,34 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 			return errors.New("--alertmanagers.url and --alertmanagers.config* parameters cannot be defined at the same time")
 		}
 
+		alertRelabelConfigYAML, err := alertRelabelConfig.Content()
+		if err != nil {
+			return err
+		}
+		alertRelabelConfigs, err := parseRelabelConfig(alertRelabelConfigYAML)
+		if err != nil {
+			return errors.Wrap(err, "parse alert relabel config")
+		}
+
+		httpAuth, err := newHTTPAuthenticator(logger, reg, httpAuthConfig)
+		if err != nil {
+			return errors.Wrap(err, "create HTTP authenticator")
+		}
+
+		grpcPeers, err := newGRPCPeerAllowlist(logger, reg, grpcPeerAllowlistConfig, *grpcClientCA)
+		if err != nil {
+			return errors.Wrap(err, "create gRPC peer allowlist")
+		}
+
+		reqLogger, err := newRequestLogger(g, logger, reg, requestLoggingConfig)
+		if err != nil {
+			return errors.Wrap(err, "create request logger")
+		}
+
+		if _, err := newGossipPeer(g, logger, reg, gossipConfig, gossip.State{Component: comp.String(), StoreAPI: *grpcBindAddr}); err != nil {
+			return errors.Wrap(err, "create gossip peer")
+		}
+
 		return runRule(g,
 			logger,
 			reg,
@@ -173,30 +286,46 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application) {
 			*alertmgrsTimeout,
 			alertmgrsConfigYAML,
 			time.Duration(*alertmgrsDNSSDInterval),
+			*alertmgrsDNSSDHonorTTL,
 			*grpcBindAddr,
 			time.Duration(*grpcGracePeriod),
 			*grpcCert,
 			*grpcKey,
 			*grpcClientCA,
+			grpcPeers,
+			reqLogger,
+			*zone,
 			*httpBindAddr,
 			time.Duration(*httpGracePeriod),
+			httpAuth,
 			*webRoutePrefix,
 			*webExternalPrefix,
 			*webPrefixHeaderName,
 			time.Duration(*resendDelay),
+			time.Duration(*outageTolerance),
+			time.Duration(*forGracePeriod),
 			time.Duration(*evalInterval),
+			*evalConcurrency,
+			time.Duration(*evalJitter),
 			*dataDir,
 			*ruleFiles,
+			ruleFileStore,
+			ruleAPIToken,
 			objStoreConfig,
+			remoteWriteConfigYAML,
 			tsdbOpts,
 			alertQueryURL,
 			*alertExcludeLabels,
+			alertRelabelConfigs,
 			*queries,
 			*fileSDFiles,
 			time.Duration(*fileSDInterval),
 			queryConfigYAML,
 			time.Duration(*dnsSDInterval),
+			*dnsSDHonorTTL,
 			*dnsSDResolver,
+			time.Duration(*queryHealthCheckInterval),
+			*queryTenantHeader,
 			comp,
 		)
 	}
@@ -259,30 +388,46 @@ func runRule(
 	alertmgrsTimeout time.Duration,
 	alertmgrsConfigYAML []byte,
 	alertmgrsDNSSDInterval time.Duration,
+	alertmgrsDNSSDHonorTTL bool,
 	grpcBindAddr string,
 	grpcGracePeriod time.Duration,
 	grpcCert string,
 	grpcKey string,
 	grpcClientCA string,
+	grpcPeers *grpcserver.PeerAllowlist,
+	reqLogger *logging.RequestLogger,
+	zone string,
 	httpBindAddr string,
 	httpGracePeriod time.Duration,
+	httpAuth *httpserver.Authenticator,
 	webRoutePrefix string,
 	webExternalPrefix string,
 	webPrefixHeaderName string,
 	resendDelay time.Duration,
+	outageTolerance time.Duration,
+	forGracePeriod time.Duration,
 	evalInterval time.Duration,
+	evalConcurrency int,
+	evalJitter time.Duration,
 	dataDir string,
 	ruleFiles []string,
+	ruleFileStore *thanosrule.FileStore,
+	ruleAPIToken string,
 	objStoreConfig *extflag.PathOrContent,
+	remoteWriteConfigYAML []byte,
 	tsdbOpts *tsdb.Options,
 	alertQueryURL *url.URL,
 	alertExcludeLabels []string,
+	alertRelabelConfigs []*relabel.Config,
 	queryAddrs []string,
 	querySDFiles []string,
 	querySDInterval time.Duration,
 	queryConfigYAML []byte,
 	dnsSDInterval time.Duration,
+	dnsSDHonorTTL bool,
 	dnsSDResolver string,
+	queryHealthCheckInterval time.Duration,
+	queryTenantHeader string,
 	comp component.Component,
 ) error {
 	metrics := newRuleMetrics(reg)
@@ -323,7 +468,17 @@ func runRule(
 		extprom.WrapRegistererWithPrefix("thanos_ruler_query_apis_", reg),
 		dns.ResolverType(dnsSDResolver),
 	)
-	var queryClients []*http_util.Client
+	// Failed endpoints are avoided at least for the health check interval, or a minute if active checks are disabled.
+	healthBackoff := queryHealthCheckInterval
+	if healthBackoff == 0 {
+		healthBackoff = 1 * time.Minute
+	}
+	endpointsHealth := thanosrule.NewEndpointsHealth(logger, reg, healthBackoff)
+
+	var (
+		queryClients      []*http_util.Client
+		namedQueryClients = map[string]*http_util.Client{}
+	)
 	for _, cfg := range queryCfg {
 		c, err := http_util.NewHTTPClient(cfg.HTTPClientConfig, "query")
 		if err != nil {
@@ -335,22 +490,82 @@ func runRule(
 			return err
 		}
 		queryClients = append(queryClients, queryClient)
+		if cfg.Name != "" {
+			namedQueryClients[cfg.Name] = queryClient
+		}
 		// Discover and resolve query addresses.
-		addDiscoveryGroups(g, queryClient, dnsSDInterval)
-	}
+		addDiscoveryGroups(g, queryClient, dnsSDInterval, dnsSDHonorTTL)
 
-	db, err := tsdb.Open(dataDir, log.With(logger, "component", "tsdb"), reg, tsdbOpts)
-	if err != nil {
-		return errors.Wrap(err, "open TSDB")
+		if queryHealthCheckInterval > 0 {
+			ctx, cancel := context.WithCancel(context.Background())
+			g.Add(func() error {
+				return runutil.Repeat(queryHealthCheckInterval, ctx.Done(), func() error {
+					endpointsHealth.Check(ctx, queryClient)
+					return nil
+				})
+			}, func(error) {
+				cancel()
+			})
+		}
 	}
-	{
-		done := make(chan struct{})
-		g.Add(func() error {
-			<-done
-			return db.Close()
-		}, func(error) {
-			close(done)
-		})
+
+	var (
+		st       storage.Storage
+		storeSrv storepb.StoreServer
+	)
+	if len(remoteWriteConfigYAML) == 0 {
+		db, err := tsdb.Open(dataDir, log.With(logger, "component", "tsdb"), reg, tsdbOpts)
+		if err != nil {
+			return errors.Wrap(err, "open TSDB")
+		}
+		{
+			done := make(chan struct{})
+			g.Add(func() error {
+				<-done
+				return db.Close()
+			}, func(error) {
+				close(done)
+			})
+		}
+		st = tsdb.Adapter(db, 0)
+		storeSrv = store.NewTSDBStore(logger, reg, db, component.Rule, lset)
+	} else {
+		rwCfg, err := remotewrite.LoadConfig(remoteWriteConfigYAML)
+		if err != nil {
+			return errors.Wrap(err, "parse remote write config")
+		}
+		rwStorage, err := remotewrite.NewStorage(
+			logger,
+			reg,
+			dataDir,
+			lset,
+			rwCfg,
+			1*time.Minute,
+		)
+		if err != nil {
+			return errors.Wrap(err, "open remote write storage")
+		}
+		{
+			ctx, cancel := context.WithCancel(context.Background())
+			g.Add(func() error {
+				// Only series which were not updated within the last block duration are dropped from the WAL.
+				err := runutil.Repeat(time.Duration(tsdbOpts.MinBlockDuration), ctx.Done(), func() error {
+					mint := timestamp.FromTime(time.Now().Add(-time.Duration(tsdbOpts.MinBlockDuration)))
+					if err := rwStorage.Truncate(mint); err != nil {
+						level.Warn(logger).Log("msg", "truncating remote write WAL failed", "err", err)
+					}
+					return nil
+				})
+				if cerr := rwStorage.Close(); cerr != nil {
+					level.Warn(logger).Log("msg", "closing remote write storage failed", "err", cerr)
+				}
+				return err
+			}, func(error) {
+				cancel()
+			})
+		}
+		st = rwStorage
+		level.Info(logger).Log("msg", "remote write configured, ruler runs in stateless mode")
 	}
 
 	// Build the Alertmanager clients.
@@ -393,14 +608,14 @@ func runRule(
 			return err
 		}
 		// Discover and resolve Alertmanager addresses.
-		addDiscoveryGroups(g, amClient, alertmgrsDNSSDInterval)
+		addDiscoveryGroups(g, amClient, alertmgrsDNSSDInterval, alertmgrsDNSSDHonorTTL)
 
 		alertmgrs = append(alertmgrs, alert.NewAlertmanager(logger, amClient, time.Duration(cfg.Timeout), cfg.APIVersion))
 	}
 
 	// Run rule evaluation and alert notifications.
 	var (
-		alertQ  = alert.NewQueue(logger, reg, 10000, 100, labelsTSDBToProm(lset), alertExcludeLabels)
+		alertQ  = alert.NewQueue(logger, reg, 10000, 100, labelsTSDBToProm(lset), alertExcludeLabels, alertRelabelConfigs)
 		ruleMgr = thanosrule.NewManager(dataDir)
 	)
 	{
@@ -426,41 +641,124 @@ func runRule(
 			}
 			alertQ.Push(res)
 		}
-		st := tsdb.Adapter(db, 0)
-
 		opts := rules.ManagerOptions{
-			NotifyFunc:  notify,
-			Logger:      log.With(logger, "component", "rules"),
-			Appendable:  st,
-			ExternalURL: nil,
-			TSDB:        st,
-			ResendDelay: resendDelay,
+			NotifyFunc:      notify,
+			Logger:          log.With(logger, "component", "rules"),
+			Appendable:      st,
+			ExternalURL:     nil,
+			ResendDelay:     resendDelay,
+			OutageTolerance: outageTolerance,
+			ForGracePeriod:  forGracePeriod,
 		}
 
-		// TODO(bwplotka): Hide this behind thanos rules.Manager.
-		for _, strategy := range storepb.PartialResponseStrategy_value {
-			s := storepb.PartialResponseStrategy(strategy)
+		// Groups selecting a named query configuration are evaluated by a dedicated manager that
+		// queries only the endpoints of that configuration.
+		clientsByName := map[string][]*http_util.Client{"": queryClients}
+		for name, c := range namedQueryClients {
+			clientsByName[name] = []*http_util.Client{c}
+		}
 
-			ctx, cancel := context.WithCancel(context.Background())
-			ctx = tracing.ContextWithTracer(ctx, tracer)
+		// Metrics of rule evaluation are shared by all managers with the same labels. Managers created on rule reload
+		// for groups with source tenants come and go with their groups, and are not told apart by their tenants, as
+		// the number of tenant combinations is unbounded.
+		var (
+			mgrMetricsMtx sync.Mutex
+			mgrMetrics    = map[string]*rules.Metrics{}
+		)
+		newRuleManager := func(ctx context.Context, s storepb.PartialResponseStrategy, name string, sourceTenants []string, queryOffset time.Duration) *rules.Manager {
+			mgrLabels := prometheus.Labels{"strategy": strings.ToLower(s.String())}
+			if len(namedQueryClients) > 0 {
+				mgrLabels["query_config"] = name
+			}
+			var headers http.Header
+			if len(sourceTenants) > 0 {
+				headers = http.Header{}
+				headers.Set(queryTenantHeader, strings.Join(sourceTenants, "|"))
+			}
+			if queryOffset != 0 {
+				mgrLabels["query_offset"] = model.Duration(queryOffset).String()
+			}
+			mgrReg := extprom.WrapRegistererWith(mgrLabels, reg)
 
 			opts := opts
-			opts.Registerer = extprom.WrapRegistererWith(prometheus.Labels{"strategy": strings.ToLower(s.String())}, reg)
+			mgrMetricsMtx.Lock()
+			metricsKey := labels.FromMap(mgrLabels).String()
+			if _, ok := mgrMetrics[metricsKey]; !ok {
+				mgrMetrics[metricsKey] = rules.NewGroupMetrics(mgrReg)
+			}
+			opts.Metrics = mgrMetrics[metricsKey]
+			mgrMetricsMtx.Unlock()
+			// Only managers created on startup are registered themselves, as a collector of their group intervals,
+			// since managers created on rule reload may share their labels.
+			if len(sourceTenants) == 0 && queryOffset == 0 {
+				opts.Registerer = mgrReg
+			}
 			opts.Context = ctx
-			opts.QueryFunc = queryFunc(logger, queryClients, metrics.duplicatedQuery, metrics.ruleEvalWarnings, s)
-
+			opts.QueryFunc = queryFunc(logger, clientsByName[name], endpointsHealth, metrics.duplicatedQuery, metrics.ruleEvalWarnings, s, headers)
+			if queryOffset != 0 {
+				opts.QueryFunc = thanosrule.OffsetQueryFunc(opts.QueryFunc, queryOffset)
+				opts.Appendable = thanosrule.NewOffsetAppendable(opts.Appendable, queryOffset)
+			}
+			// Alerts `for` state is restored from the same query APIs the rules are evaluated against.
+			opts.TSDB = thanosrule.NewForStateStorage(st, lset, forStateQueryFunc(logger, clientsByName[name], endpointsHealth, metrics.duplicatedQuery, s, headers))
+
+			var groupQueryFuncs []thanosrule.GroupQueryFunc
+			if evalConcurrency > 1 {
+				e := thanosrule.NewConcurrentEvaluator(opts.QueryFunc, evalConcurrency)
+				opts.QueryFunc = e.Query
+				groupQueryFuncs = append(groupQueryFuncs, e)
+			}
+			if evalJitter > 0 {
+				j := thanosrule.NewEvaluationJitter(opts.QueryFunc, evalJitter)
+				opts.QueryFunc = j.Query
+				groupQueryFuncs = append(groupQueryFuncs, j)
+			}
 			mgr := rules.NewManager(&opts)
-			ruleMgr.SetRuleManager(s, mgr)
+			if len(groupQueryFuncs) > 0 {
+				ruleMgr.SetGroupQueryFuncs(mgr, groupQueryFuncs...)
+			}
+			return mgr
+		}
+
+		// Groups with source tenants or query offset are evaluated by managers created on rule reload, one per
+		// distinct set of tenants and query offset.
+		{
+			ctx, cancel := context.WithCancel(context.Background())
+			ctx = tracing.ContextWithTracer(ctx, tracer)
+
+			ruleMgr.SetDynamicRuleManagerFunc(func(s storepb.PartialResponseStrategy, name string, sourceTenants []string, queryOffset time.Duration) *rules.Manager {
+				return newRuleManager(ctx, s, name, sourceTenants, queryOffset)
+			})
 			g.Add(func() error {
-				mgr.Run()
 				<-ctx.Done()
-
 				return nil
 			}, func(error) {
 				cancel()
-				mgr.Stop()
+				ruleMgr.Stop()
 			})
 		}
+
+		// TODO(bwplotka): Hide this behind thanos rules.Manager.
+		for _, strategy := range storepb.PartialResponseStrategy_value {
+			s := storepb.PartialResponseStrategy(strategy)
+
+			for name := range clientsByName {
+				ctx, cancel := context.WithCancel(context.Background())
+				ctx = tracing.ContextWithTracer(ctx, tracer)
+
+				mgr := newRuleManager(ctx, s, name, nil, 0)
+				ruleMgr.SetQueryConfigRuleManager(s, name, mgr)
+				g.Add(func() error {
+					mgr.Run()
+					<-ctx.Done()
+
+					return nil
+				}, func(error) {
+					cancel()
+					mgr.Stop()
+				})
+			}
+		}
 	}
 	// Run the alert sender.
 	{
@@ -487,9 +785,19 @@ func runRule(
 
 	// Handle reload and termination interrupts.
 	reloadWebhandler := make(chan chan error)
+	if ruleFileStore != nil {
+		// Rule files managed via API are loaded the same way as configured rule files.
+		ruleFiles = append(ruleFiles, filepath.Join(ruleFileStore.Dir(), "*.yaml"), filepath.Join(ruleFileStore.Dir(), "*.yml"))
+	}
 	{
 		ctx, cancel := context.WithCancel(context.Background())
 		g.Add(func() error {
+			if ruleFileStore != nil {
+				defer runutil.CloseWithLogOnErr(logger, ruleFileStore, "rule file store")
+				if err := ruleFileStore.Sync(ctx); err != nil {
+					return errors.Wrap(err, "sync rule files managed via API")
+				}
+			}
 			// Initialize rules.
 			if err := reloadRules(logger, ruleFiles, ruleMgr, evalInterval, metrics); err != nil {
 				level.Error(logger).Log("msg", "initialize rules failed", "err", err)
@@ -524,19 +832,20 @@ func runRule(
 		prober.NewInstrumentation(comp, logger, extprom.WrapRegistererWithPrefix("thanos_", reg)),
 	)
 
-	// Start gRPC server.
-	{
-		store := store.NewTSDBStore(logger, reg, db, component.Rule, lset)
-
-		tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), grpcCert, grpcKey, grpcClientCA)
+	// Start gRPC server. Stateless ruler has no local data, so StoreAPI is not exposed.
+	if storeSrv != nil {
+		tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), extprom.WrapRegistererWith(prometheus.Labels{"protocol": "grpc"}, reg), grpcCert, grpcKey, grpcClientCA)
 		if err != nil {
 			return errors.Wrap(err, "setup gRPC server")
 		}
 
-		s := grpcserver.New(logger, reg, tracer, comp, grpcProbe, store,
+		s := grpcserver.New(logger, reg, tracer, comp, grpcProbe, storeSrv,
 			grpcserver.WithListen(grpcBindAddr),
 			grpcserver.WithGracePeriod(grpcGracePeriod),
 			grpcserver.WithTLSConfig(tlsCfg),
+			grpcserver.WithPeerAllowlist(grpcPeers),
+			grpcserver.WithRequestLogging(reqLogger),
+			grpcserver.WithZone(zone),
 		)
 
 		g.Add(func() error {
@@ -578,14 +887,29 @@ func runRule(
 		api := v1.NewAPI(logger, reg, ruleMgr)
 		api.Register(router.WithPrefix(path.Join(webRoutePrefix, "/api/v1")), tracer, logger, ins)
 
+		if ruleFileStore != nil {
+			reload := func() error {
+				reloadMsg := make(chan error)
+				reloadWebhandler <- reloadMsg
+				return <-reloadMsg
+			}
+			filesAPI := v1.NewRuleFilesAPI(logger, ruleFileStore, reload, ruleAPIToken)
+			filesAPI.Register(router.WithPrefix(path.Join(webRoutePrefix, "/api/v1")), tracer, logger, ins)
+		}
+
 		srv := httpserver.New(logger, reg, comp, httpProbe,
 			httpserver.WithListen(httpBindAddr),
 			httpserver.WithGracePeriod(httpGracePeriod),
+			httpserver.WithRequestLogging(reqLogger),
+			httpserver.WithAuthentication(httpAuth),
 		)
 		srv.Handle("/", router)
 
 		g.Add(func() error {
 			statusProber.Healthy()
+			if storeSrv == nil {
+				statusProber.Ready()
+			}
 
 			return srv.ListenAndServe()
 		}, func(err error) {
@@ -601,7 +925,9 @@ func runRule(
 		return err
 	}
 
-	if len(confContentYaml) > 0 {
+	if len(confContentYaml) > 0 && storeSrv == nil {
+		level.Warn(logger).Log("msg", "bucket configuration is ignored in stateless mode, uploads will be disabled")
+	} else if len(confContentYaml) > 0 {
 		// The background shipper continuously scans the data directory and uploads
 		// new blocks to Google Cloud Storage or an S3-compatible storage service.
 		bkt, err := client.NewBucket(logger, confContentYaml, reg, component.Rule.String())
@@ -685,13 +1011,16 @@ func removeDuplicateQueryEndpoints(logger log.Logger, duplicatedQueriers prometh
 }
 
 // queryFunc returns query function that hits the HTTP query API of query peers in randomized order until we get a result
-// back or the context get canceled.
+// back or the context get canceled. Endpoints considered unhealthy are tried only after all healthy ones failed.
+// Given headers are added to every query request.
 func queryFunc(
 	logger log.Logger,
 	queriers []*http_util.Client,
+	endpointsHealth *thanosrule.EndpointsHealth,
 	duplicatedQuery prometheus.Counter,
 	ruleEvalWarnings *prometheus.CounterVec,
 	partialResponseStrategy storepb.PartialResponseStrategy,
+	headers http.Header,
 ) rules.QueryFunc {
 	var spanID string
 
@@ -705,40 +1034,106 @@ func queryFunc(
 		panic(errors.Errorf("unknown partial response strategy %v", partialResponseStrategy).Error())
 	}
 
+	promClients := newPromClients(logger, queriers)
+
+	return func(ctx context.Context, q string, t time.Time) (v promql.Vector, err error) {
+		for _, e := range orderedQueryEndpoints(logger, queriers, promClients, endpointsHealth, duplicatedQuery) {
+			var warns []string
+			tracing.DoInSpan(ctx, spanID, func(ctx context.Context) {
+				v, warns, err = e.client.PromqlQueryInstant(ctx, e.url, q, t, promclient.QueryOptions{
+					Deduplicate:             true,
+					PartialResponseStrategy: partialResponseStrategy,
+					HTTPHeaders:             headers,
+				})
+			})
+			if err != nil {
+				level.Error(logger).Log("err", err, "query", q, "endpoint", e.url.String())
+				endpointsHealth.QueryFailed(e.url)
+				continue
+			}
+			endpointsHealth.QuerySucceeded(e.url)
+			if len(warns) > 0 {
+				ruleEvalWarnings.WithLabelValues(strings.ToLower(partialResponseStrategy.String())).Inc()
+				// TODO(bwplotka): Propagate those to UI, probably requires changing rule manager code ):
+				level.Warn(logger).Log("warnings", strings.Join(warns, ", "), "query", q)
+			}
+			return v, nil
+		}
+		return nil, errors.New("no query API server reachable")
+	}
+}
+
+// forStateQueryFunc returns query function for restoring `for` state of alerts with the same endpoint selection
+// as queryFunc.
+func forStateQueryFunc(
+	logger log.Logger,
+	queriers []*http_util.Client,
+	endpointsHealth *thanosrule.EndpointsHealth,
+	duplicatedQuery prometheus.Counter,
+	partialResponseStrategy storepb.PartialResponseStrategy,
+	headers http.Header,
+) thanosrule.MatrixQueryFunc {
+	promClients := newPromClients(logger, queriers)
+
+	return func(ctx context.Context, q string, t time.Time) (m model.Matrix, err error) {
+		for _, e := range orderedQueryEndpoints(logger, queriers, promClients, endpointsHealth, duplicatedQuery) {
+			tracing.DoInSpan(ctx, "/rule_restore_for_state HTTP[client]", func(ctx context.Context) {
+				m, _, err = e.client.QueryInstantMatrix(ctx, e.url, q, t, promclient.QueryOptions{
+					Deduplicate:             true,
+					PartialResponseStrategy: partialResponseStrategy,
+					HTTPHeaders:             headers,
+				})
+			})
+			if err != nil {
+				level.Error(logger).Log("msg", "query for alerts 'for' state failed", "err", err, "query", q, "endpoint", e.url.String())
+				endpointsHealth.QueryFailed(e.url)
+				continue
+			}
+			endpointsHealth.QuerySucceeded(e.url)
+			return m, nil
+		}
+		return nil, errors.New("no query API server reachable")
+	}
+}
+
+type queryEndpoint struct {
+	client *promclient.Client
+	url    *url.URL
+}
+
+func newPromClients(logger log.Logger, queriers []*http_util.Client) []*promclient.Client {
 	promClients := make([]*promclient.Client, 0, len(queriers))
 	for _, q := range queriers {
 		promClients = append(promClients, promclient.NewClient(logger, q))
 	}
+	return promClients
+}
 
-	return func(ctx context.Context, q string, t time.Time) (v promql.Vector, err error) {
-		for _, i := range rand.Perm(len(queriers)) {
-			promClient := promClients[i]
-			endpoints := removeDuplicateQueryEndpoints(logger, duplicatedQuery, queriers[i].Endpoints())
-			for _, i := range rand.Perm(len(endpoints)) {
-				var warns []string
-				tracing.DoInSpan(ctx, spanID, func(ctx context.Context) {
-					v, warns, err = promClient.PromqlQueryInstant(ctx, endpoints[i], q, t, promclient.QueryOptions{
-						Deduplicate:             true,
-						PartialResponseStrategy: partialResponseStrategy,
-					})
-				})
-				if err != nil {
-					level.Error(logger).Log("err", err, "query", q)
-					continue
-				}
-				if len(warns) > 0 {
-					ruleEvalWarnings.WithLabelValues(strings.ToLower(partialResponseStrategy.String())).Inc()
-					// TODO(bwplotka): Propagate those to UI, probably requires changing rule manager code ):
-					level.Warn(logger).Log("warnings", strings.Join(warns, ", "), "query", q)
-				}
-				return v, nil
+// orderedQueryEndpoints returns endpoints of all queriers with healthy endpoints first. Queriers are in randomized
+// order, while endpoints of each querier keep the order of their provider, e.g. by priority and weight of DNS SRV records.
+func orderedQueryEndpoints(
+	logger log.Logger,
+	queriers []*http_util.Client,
+	promClients []*promclient.Client,
+	endpointsHealth *thanosrule.EndpointsHealth,
+	duplicatedQuery prometheus.Counter,
+) []queryEndpoint {
+	var healthy, unhealthy []queryEndpoint
+	for _, i := range rand.Perm(len(queriers)) {
+		endpoints := removeDuplicateQueryEndpoints(logger, duplicatedQuery, queriers[i].Endpoints())
+		for _, u := range endpoints {
+			e := queryEndpoint{client: promClients[i], url: u}
+			if endpointsHealth.Healthy(e.url) {
+				healthy = append(healthy, e)
+				continue
 			}
+			unhealthy = append(unhealthy, e)
 		}
-		return nil, errors.New("no query API server reachable")
 	}
+	return append(healthy, unhealthy...)
 }
 
-func addDiscoveryGroups(g *run.Group, c *http_util.Client, interval time.Duration) {
+func addDiscoveryGroups(g *run.Group, c *http_util.Client, interval time.Duration, honorTTL bool) {
 	ctx, cancel := context.WithCancel(context.Background())
 	g.Add(func() error {
 		c.Discover(ctx)
@@ -748,7 +1143,12 @@ func addDiscoveryGroups(g *run.Group, c *http_util.Client, interval time.Duratio
 	})
 
 	g.Add(func() error {
-		return runutil.Repeat(interval, ctx.Done(), func() error {
+		return runutil.RepeatDynamic(func() time.Duration {
+			if honorTTL {
+				return c.RefreshInterval(interval)
+			}
+			return interval
+		}, ctx.Done(), func() error {
 			c.Resolve(ctx)
 			return nil
 		})
@@ -5,27 +5,36 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"math"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/oklog/run"
+	"github.com/oklog/ulid"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/discovery/gossip"
 	"github.com/thanos-io/thanos/pkg/extflag"
 	"github.com/thanos-io/thanos/pkg/exthttp"
 	"github.com/thanos-io/thanos/pkg/extprom"
+	"github.com/thanos-io/thanos/pkg/logging"
 	thanosmodel "github.com/thanos-io/thanos/pkg/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
 	"github.com/thanos-io/thanos/pkg/promclient"
@@ -47,6 +56,11 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application) {
 
 	httpBindAddr, httpGracePeriod := regHTTPFlags(cmd)
 	grpcBindAddr, grpcGracePeriod, grpcCert, grpcKey, grpcClientCA := regGRPCFlags(cmd)
+	grpcPeerAllowlistConfig := regGRPCPeerAllowlistFlags(cmd)
+	requestLoggingConfig := regRequestLoggingFlags(cmd)
+
+	zone := regZoneFlag(cmd)
+	gossipConfig := regGossipFlags(cmd)
 
 	promURL := cmd.Flag("prometheus.url", "URL at which to reach Prometheus's API. For better performance use local network.").
 		Default("http://localhost:9090").URL()
@@ -68,16 +82,68 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application) {
 
 	reloaderRuleDirs := cmd.Flag("reloader.rule-dir", "Rule directories for the reloader to refresh (repeated field).").Strings()
 
+	reloaderCfgDirs := cmd.Flag("reloader.config-dir", "Config directories watched by the reloader (repeated field).").Strings()
+
+	reloaderCfgDirsOutput := cmd.Flag("reloader.config-envsubst-dir", "Output directory for environment variable substituted files from config directories. Files from each config directory are written into the subdirectory with the config directory base name.").
+		Default("").String()
+
+	reloaderValidateCmd := cmd.Flag("reloader.validate-command", "Command validating changed configuration before Prometheus reload is triggered, e.g. 'promtool check config /etc/prometheus/prometheus.yml'. Arguments are split by whitespace. If the command fails, substituted output files are rolled back and reload is not triggered.").
+		Default("").String()
+
 	objStoreConfig := regCommonObjStoreFlags(cmd, "", false)
 
-	uploadCompacted := cmd.Flag("shipper.upload-compacted", "If true sidecar will try to upload compacted blocks as well. Useful for migration purposes. Works only if compaction is disabled on Prometheus. Do it once and then disable the flag when done.").Default("false").Bool()
+	uploadCompacted := cmd.Flag("shipper.upload-compacted", "If true sidecar will try to upload compacted blocks as well. Useful for migration purposes, or for Prometheus with local compaction enabled. Compacted blocks are uploaded only if they overlap solely with bucket blocks made of their own sources, which are then replaced.").Default("false").Bool()
 
 	ignoreBlockSize := cmd.Flag("shipper.ignore-unequal-block-size", "If true sidecar will not require prometheus min and max block size flags to be set to the same value. Only use this if you want to keep long retention and compaction enabled on your Prometheus instance, as in the worst case it can result in ~2h data loss for your Thanos bucket storage.").Default("false").Hidden().Bool()
 
+	uploadBandwidthLimit := cmd.Flag("shipper.upload-bandwidth-limit", "Maximum total bandwidth of block uploads per second, e.g. 10MB. 0 means no limit.").
+		Default("0").Bytes()
+
+	uploadWindows := cmd.Flag("shipper.upload-window", "Daily time window in UTC, in HH:MM-HH:MM format, in which block uploads are started (repeated). Windows can span midnight, e.g. 22:00-06:00. Upload running at the end of the window is finished. If not set, blocks are uploaded at any time.").
+		PlaceHolder("<HH:MM-HH:MM>").Strings()
+
+	enableFlushEndpoint := cmd.Flag("shipper.enable-flush-endpoint", "If true, sidecar exposes POST /api/v1/flush HTTP endpoint, which snapshots Prometheus TSDB including its head and immediately uploads the block created from the head. Useful before the node is terminated. Requires Prometheus --web.enable-admin-api flag and object storage configuration.").
+		Default("false").Bool()
+
+	uploadMinTime := thanosmodel.TimeOrDuration(cmd.Flag("shipper.upload.min-time", "Start of time range of blocks to upload. Older blocks are not uploaded. Useful to upload only part of long retention Prometheus data. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("0000-01-01T00:00:00Z"))
+
+	uploadMaxTime := thanosmodel.TimeOrDuration(cmd.Flag("shipper.upload.max-time", "End of time range of blocks to upload. Newer blocks are not uploaded. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("9999-12-31T23:59:59Z"))
+
+	uploadBlocks := cmd.Flag("shipper.upload.block", "ID of the local block to upload (repeated). If set, only the given blocks are uploaded, new blocks included, so it's meant for one-off backfill.").
+		PlaceHolder("<ULID>").Strings()
+
+	metadataCacheTTL := modelDuration(cmd.Flag("prometheus.metadata-cache-ttl", "How long Prometheus /api/v1/metadata responses served by sidecar under the same path are cached. 0 disables caching.").
+		Default("1m"))
+
+	targetsCacheTTL := modelDuration(cmd.Flag("prometheus.targets-cache-ttl", "How long Prometheus /api/v1/targets responses served by sidecar under the same path are cached. 0 disables caching.").
+		Default("10s"))
+
 	minTime := thanosmodel.TimeOrDuration(cmd.Flag("min-time", "Start of time range limit to serve. Thanos sidecar will serve only metrics, which happened later than this value. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
 		Default("0000-01-01T00:00:00Z"))
 
+	minTimeFromRetention := cmd.Flag("min-time.from-retention", "If true, sidecar serves only metrics within Prometheus time based retention minus --min-time.retention-margin, so it overlaps with data served from object storage as little as possible. Retention is queried from Prometheus status API. If --min-time is set as well, the later of both is used.").
+		Default("false").Bool()
+
+	minTimeRetentionMargin := modelDuration(cmd.Flag("min-time.retention-margin", "Safety margin subtracted from Prometheus retention when --min-time.from-retention is used, so sidecar does not advertise data Prometheus may be about to delete.").
+		Default("2h"))
+
 	m[component.Sidecar.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		windows, err := shipper.ParseUploadWindows(*uploadWindows)
+		if err != nil {
+			return errors.Wrap(err, "parse upload windows")
+		}
+
+		var blockIDs []ulid.ULID
+		for _, b := range *uploadBlocks {
+			id, err := ulid.Parse(b)
+			if err != nil {
+				return errors.Wrapf(err, "parse block ID %q", b)
+			}
+			blockIDs = append(blockIDs, id)
+		}
+
 		rl := reloader.New(
 			log.With(logger, "component", "reloader"),
 			reloader.ReloadURLFromBase(*promURL),
@@ -85,6 +151,33 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application) {
 			*reloaderCfgOutputFile,
 			*reloaderRuleDirs,
 		)
+		if len(*reloaderCfgDirs) > 0 {
+			seen := map[string]struct{}{}
+			for _, d := range *reloaderCfgDirs {
+				if _, ok := seen[filepath.Base(d)]; ok && *reloaderCfgDirsOutput != "" {
+					return errors.Errorf("config directories with the same base name %q can't be substituted into the same output directory", filepath.Base(d))
+				}
+				seen[filepath.Base(d)] = struct{}{}
+			}
+			rl.WithConfigDirs(*reloaderCfgDirs, *reloaderCfgDirsOutput)
+		}
+		if args := strings.Fields(*reloaderValidateCmd); len(args) > 0 {
+			rl.WithValidation(reloader.CommandValidation(args[0], args[1:]...))
+		}
+
+		grpcPeers, err := newGRPCPeerAllowlist(logger, reg, grpcPeerAllowlistConfig, *grpcClientCA)
+		if err != nil {
+			return errors.Wrap(err, "create gRPC peer allowlist")
+		}
+
+		reqLogger, err := newRequestLogger(g, logger, reg, requestLoggingConfig)
+		if err != nil {
+			return errors.Wrap(err, "create request logger")
+		}
+
+		if _, err := newGossipPeer(g, logger, reg, gossipConfig, gossip.State{Component: component.Sidecar.String(), StoreAPI: *grpcBindAddr}); err != nil {
+			return errors.Wrap(err, "create gossip peer")
+		}
 
 		return runSidecar(
 			g,
@@ -96,6 +189,9 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application) {
 			*grpcCert,
 			*grpcKey,
 			*grpcClientCA,
+			grpcPeers,
+			reqLogger,
+			*zone,
 			*httpBindAddr,
 			time.Duration(*httpGracePeriod),
 			*promURL,
@@ -105,8 +201,18 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application) {
 			rl,
 			*uploadCompacted,
 			*ignoreBlockSize,
+			int64(*uploadBandwidthLimit),
+			windows,
+			*uploadMinTime,
+			*uploadMaxTime,
+			blockIDs,
+			*enableFlushEndpoint,
+			time.Duration(*metadataCacheTTL),
+			time.Duration(*targetsCacheTTL),
 			component.Sidecar,
 			*minTime,
+			*minTimeFromRetention,
+			time.Duration(*minTimeRetentionMargin),
 			*connectionPoolSize,
 			*connectionPoolSizePerHost,
 		)
@@ -123,6 +229,9 @@ func runSidecar(
 	grpcCert string,
 	grpcKey string,
 	grpcClientCA string,
+	grpcPeers *grpcserver.PeerAllowlist,
+	reqLogger *logging.RequestLogger,
+	zone string,
 	httpBindAddr string,
 	httpGracePeriod time.Duration,
 	promURL *url.URL,
@@ -132,8 +241,16 @@ func runSidecar(
 	reloader *reloader.Reloader,
 	uploadCompacted bool,
 	ignoreBlockSize bool,
+	uploadBandwidthLimit int64,
+	uploadWindows shipper.UploadWindows,
+	uploadMinTime, uploadMaxTime thanosmodel.TimeOrDurationValue,
+	uploadBlocks []ulid.ULID,
+	enableFlushEndpoint bool,
+	metadataCacheTTL, targetsCacheTTL time.Duration,
 	comp component.Component,
 	limitMinTime thanosmodel.TimeOrDurationValue,
+	minTimeFromRetention bool,
+	minTimeRetentionMargin time.Duration,
 	connectionPoolSize int,
 	connectionPoolSizePerHost int,
 ) error {
@@ -145,7 +262,8 @@ func runSidecar(
 		mint: limitMinTime.PrometheusTimestamp(),
 		maxt: math.MaxInt64,
 
-		limitMinTime: limitMinTime,
+		limitMinTime:    limitMinTime,
+		retentionMargin: minTimeRetentionMargin,
 	}
 
 	confContentYaml, err := objStoreConfig.Content()
@@ -170,6 +288,7 @@ func runSidecar(
 	srv := httpserver.New(logger, reg, comp, httpProbe,
 		httpserver.WithListen(httpBindAddr),
 		httpserver.WithGracePeriod(httpGracePeriod),
+		httpserver.WithRequestLogging(reqLogger),
 	)
 
 	g.Add(func() error {
@@ -199,7 +318,7 @@ func runSidecar(
 			// Only check Prometheus's flags when upload is enabled.
 			if uploads {
 				// Check prometheus's flags to ensure sane sidecar flags.
-				if err := validatePrometheus(ctx, logger, ignoreBlockSize, m); err != nil {
+				if err := validatePrometheus(ctx, logger, ignoreBlockSize, uploadCompacted, m); err != nil {
 					return errors.Wrap(err, "validate Prometheus flags")
 				}
 			}
@@ -234,6 +353,12 @@ func runSidecar(
 				return errors.New("no external labels configured on Prometheus server, uniquely identifying external labels must be configured")
 			}
 
+			if minTimeFromRetention {
+				if err := m.UpdateRetention(ctx, logger); err != nil {
+					level.Warn(logger).Log("msg", "failed to derive min time from Prometheus retention, will retry with next heartbeat", "err", err)
+				}
+			}
+
 			// Periodically query the Prometheus config. We use this as a heartbeat as well as for updating
 			// the external labels we apply.
 			return runutil.Repeat(30*time.Second, ctx.Done(), func() error {
@@ -248,6 +373,11 @@ func runSidecar(
 					lastHeartbeat.SetToCurrentTime()
 				}
 
+				if minTimeFromRetention {
+					if err := m.UpdateRetention(iterCtx, logger); err != nil {
+						level.Warn(logger).Log("msg", "failed to derive min time from Prometheus retention", "err", err)
+					}
+				}
 				return nil
 			})
 		}, func(error) {
@@ -269,12 +399,17 @@ func runSidecar(
 		t.MaxIdleConns = connectionPoolSize
 		c := &http.Client{Transport: tracing.HTTPTripperware(logger, t)}
 
+		// Metadata and targets change rarely compared to how often queriers may ask for them, so serve cached
+		// responses instead of hitting Prometheus on every request.
+		srv.Handle("/api/v1/metadata", promclient.NewAPICache(logger, extprom.WrapRegistererWith(prometheus.Labels{"api": "metadata"}, reg), c, promURL, "/api/v1/metadata", metadataCacheTTL))
+		srv.Handle("/api/v1/targets", promclient.NewAPICache(logger, extprom.WrapRegistererWith(prometheus.Labels{"api": "targets"}, reg), c, promURL, "/api/v1/targets", targetsCacheTTL))
+
 		promStore, err := store.NewPrometheusStore(logger, c, promURL, component.Sidecar, m.Labels, m.Timestamps)
 		if err != nil {
 			return errors.Wrap(err, "create Prometheus store")
 		}
 
-		tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), grpcCert, grpcKey, grpcClientCA)
+		tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), extprom.WrapRegistererWith(prometheus.Labels{"protocol": "grpc"}, reg), grpcCert, grpcKey, grpcClientCA)
 		if err != nil {
 			return errors.Wrap(err, "setup gRPC server")
 		}
@@ -283,6 +418,10 @@ func runSidecar(
 			grpcserver.WithListen(grpcBindAddr),
 			grpcserver.WithGracePeriod(grpcGracePeriod),
 			grpcserver.WithTLSConfig(tlsCfg),
+			grpcserver.WithPeerAllowlist(grpcPeers),
+			grpcserver.WithRequestLogging(reqLogger),
+			grpcserver.WithZone(zone),
+			grpcserver.WithStatusServer(promStore),
 		)
 		g.Add(func() error {
 			statusProber.Ready()
@@ -308,10 +447,25 @@ func runSidecar(
 			}
 		}()
 
+		if uploadBandwidthLimit > 0 {
+			bkt = objstore.BucketWithUploadRateLimit(bkt, uploadBandwidthLimit)
+		}
+
 		if err := promclient.IsWALDirAccessible(dataDir); err != nil {
 			level.Error(logger).Log("err", err)
 		}
 
+		var s *shipper.Shipper
+		if uploadCompacted {
+			s = shipper.NewWithCompacted(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource)
+		} else {
+			s = shipper.New(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource)
+		}
+
+		if enableFlushEndpoint {
+			srv.Handle("/api/v1/flush", flushHandler(logger, promURL, dataDir, s, m.Labels))
+		}
+
 		ctx, cancel := context.WithCancel(context.Background())
 		g.Add(func() error {
 			defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
@@ -328,16 +482,15 @@ func runSidecar(
 				return errors.Wrapf(err, "aborting as no external labels found after waiting %s", promReadyTimeout)
 			}
 
-			var s *shipper.Shipper
-			if uploadCompacted {
-				s = shipper.NewWithCompacted(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource)
-			} else {
-				s = shipper.New(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource)
-			}
-
 			return runutil.Repeat(30*time.Second, ctx.Done(), func() error {
-				if uploaded, err := s.Sync(ctx); err != nil {
-					level.Warn(logger).Log("err", err, "uploaded", uploaded)
+				if uploadWindows.Contains(time.Now()) {
+					// Time range can be relative to the current time.
+					s.WithUploadSelector(shipper.NewUploadSelector(uploadMinTime.PrometheusTimestamp(), uploadMaxTime.PrometheusTimestamp(), uploadBlocks))
+					if uploaded, err := s.Sync(ctx); err != nil {
+						level.Warn(logger).Log("err", err, "uploaded", uploaded)
+					}
+				} else {
+					level.Debug(logger).Log("msg", "outside of upload windows, not uploading blocks")
 				}
 
 				minTime, _, err := s.Timestamps()
@@ -357,7 +510,51 @@ func runSidecar(
 	return nil
 }
 
-func validatePrometheus(ctx context.Context, logger log.Logger, ignoreBlockSize bool, m *promMetadata) error {
+// flushHandler snapshots Prometheus TSDB including head and uploads blocks from the snapshot, which are not in the
+// bucket, as a result just the block with the head data.
+func flushHandler(logger log.Logger, promURL *url.URL, dataDir string, s *shipper.Shipper, lset func() labels.Labels) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "only POST method is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if len(lset()) == 0 {
+			http.Error(w, "no external labels loaded from Prometheus yet", http.StatusServiceUnavailable)
+			return
+		}
+
+		name, err := promclient.Snapshot(r.Context(), logger, promURL, false)
+		if err != nil {
+			level.Error(logger).Log("msg", "flush failed", "err", err)
+			http.Error(w, errors.Wrap(err, "snapshot Prometheus TSDB").Error(), http.StatusInternalServerError)
+			return
+		}
+		snapshotDir := filepath.Join(dataDir, "snapshots", name)
+		defer func() {
+			if err := os.RemoveAll(snapshotDir); err != nil {
+				level.Warn(logger).Log("msg", "failed to remove Prometheus snapshot", "dir", snapshotDir, "err", err)
+			}
+		}()
+
+		uploaded, err := s.UploadSnapshot(r.Context(), snapshotDir)
+		if err != nil {
+			level.Error(logger).Log("msg", "flush failed", "uploaded", uploaded, "err", err)
+			http.Error(w, errors.Wrap(err, "upload snapshot").Error(), http.StatusInternalServerError)
+			return
+		}
+		level.Info(logger).Log("msg", "flushed Prometheus head", "snapshot", name, "uploaded", uploaded)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(struct {
+			Snapshot string `json:"snapshot"`
+			Uploaded int    `json:"uploaded"`
+		}{Snapshot: name, Uploaded: uploaded}); err != nil {
+			level.Warn(logger).Log("msg", "failed to write flush response", "err", err)
+		}
+	})
+}
+
+func validatePrometheus(ctx context.Context, logger log.Logger, ignoreBlockSize, uploadCompacted bool, m *promMetadata) error {
 	var (
 		flagErr error
 		flags   promclient.Flags
@@ -380,6 +577,10 @@ func validatePrometheus(ctx context.Context, logger log.Logger, ignoreBlockSize
 
 	// Check if compaction is disabled.
 	if flags.TSDBMinTime != flags.TSDBMaxTime {
+		if uploadCompacted {
+			level.Info(logger).Log("msg", "Prometheus compaction is enabled; compacted blocks will be uploaded, replacing blocks made of their sources", "min-block-duration", flags.TSDBMinTime, "max-block-duration", flags.TSDBMaxTime)
+			return nil
+		}
 		if !ignoreBlockSize {
 			return errors.Errorf("found that TSDB Max time is %s and Min time is %s. "+
 				"Compaction needs to be disabled (storage.tsdb.min-block-duration = storage.tsdb.max-block-duration)", flags.TSDBMaxTime, flags.TSDBMinTime)
@@ -403,6 +604,11 @@ type promMetadata struct {
 	labels labels.Labels
 
 	limitMinTime thanosmodel.TimeOrDurationValue
+
+	// retention is Prometheus time based retention, if known. Only data newer than retention minus
+	// retentionMargin is served.
+	retention       time.Duration
+	retentionMargin time.Duration
 }
 
 func (s *promMetadata) UpdateLabels(ctx context.Context, logger log.Logger) error {
@@ -418,12 +624,43 @@ func (s *promMetadata) UpdateLabels(ctx context.Context, logger log.Logger) erro
 	return nil
 }
 
+// UpdateRetention fetches Prometheus time based retention used to limit the served time range.
+func (s *promMetadata) UpdateRetention(ctx context.Context, logger log.Logger) error {
+	retention, err := promclient.Retention(ctx, logger, s.promURL)
+	if err != nil {
+		return err
+	}
+	if retention <= s.retentionMargin {
+		return errors.Errorf("Prometheus retention %s is not longer than retention margin %s", retention, s.retentionMargin)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.retention != retention {
+		level.Info(logger).Log("msg", "limiting min time to Prometheus retention", "retention", retention, "margin", s.retentionMargin)
+	}
+	s.retention = retention
+	return nil
+}
+
+// minTimeLimit returns the minimum time to serve. It has to be called with mtx held.
+func (s *promMetadata) minTimeLimit() int64 {
+	limit := s.limitMinTime.PrometheusTimestamp()
+	if s.retention > 0 {
+		if r := timestamp.FromTime(time.Now().Add(-s.retention + s.retentionMargin)); r > limit {
+			limit = r
+		}
+	}
+	return limit
+}
+
 func (s *promMetadata) UpdateTimestamps(mint int64, maxt int64) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	if mint < s.limitMinTime.PrometheusTimestamp() {
-		mint = s.limitMinTime.PrometheusTimestamp()
+	if limit := s.minTimeLimit(); mint < limit {
+		mint = limit
 	}
 
 	s.mint = mint
@@ -455,5 +692,9 @@ func (s *promMetadata) Timestamps() (mint int64, maxt int64) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
+	// Retention based limit moves with time, also when no blocks are shipped.
+	if limit := s.minTimeLimit(); s.mint < limit {
+		return limit, s.maxt
+	}
 	return s.mint, s.maxt
 }
@@ -5,9 +5,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/oklog/run"
@@ -18,44 +24,66 @@ import (
 	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/discovery/gossip"
 	"github.com/thanos-io/thanos/pkg/extflag"
 	"github.com/thanos-io/thanos/pkg/extprom"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/logging"
+	"github.com/thanos-io/thanos/pkg/memlimit"
 	"github.com/thanos-io/thanos/pkg/model"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
+	"github.com/thanos-io/thanos/pkg/receive"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	grpcserver "github.com/thanos-io/thanos/pkg/server/grpc"
 	httpserver "github.com/thanos-io/thanos/pkg/server/http"
 	"github.com/thanos-io/thanos/pkg/store"
 	storecache "github.com/thanos-io/thanos/pkg/store/cache"
+	"github.com/thanos-io/thanos/pkg/strutil"
 	"github.com/thanos-io/thanos/pkg/tls"
 	"github.com/thanos-io/thanos/pkg/ui"
+	"github.com/thanos-io/thanos/pkg/usage"
 	"gopkg.in/alecthomas/kingpin.v2"
 	yaml "gopkg.in/yaml.v2"
 )
 
 const fetcherConcurrency = 32
 
+// Ratios of the soft memory limit used as the index cache size and chunk pool size if they are not set by flags.
+const (
+	indexCacheMemoryRatio = 0.1
+	chunkPoolMemoryRatio  = 0.5
+)
+
 // registerStore registers a store command.
 func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 	cmd := app.Command(component.Store.String(), "store node giving access to blocks in a bucket provider. Now supported GCS, S3, Azure, Swift and Tencent COS.")
 
 	httpBindAddr, httpGracePeriod := regHTTPFlags(cmd)
 	grpcBindAddr, grpcGracePeriod, grpcCert, grpcKey, grpcClientCA := regGRPCFlags(cmd)
+	grpcPeerAllowlistConfig := regGRPCPeerAllowlistFlags(cmd)
+	requestLoggingConfig := regRequestLoggingFlags(cmd)
+
+	zone := regZoneFlag(cmd)
+	gossipConfig := regGossipFlags(cmd)
 
 	dataDir := cmd.Flag("data-dir", "Data directory in which to cache remote blocks.").
 		Default("./data").String()
 
-	indexCacheSize := cmd.Flag("index-cache-size", "Maximum size of items held in the in-memory index cache. Ignored if --index-cache.config or --index-cache.config-file option is specified.").
-		Default("250MB").Bytes()
+	indexCacheSizeSet := false
+	indexCacheSize := cmd.Flag("index-cache-size", "Maximum size of items held in the in-memory index cache. Ignored if --index-cache.config or --index-cache.config-file option is specified. If not set and a soft memory limit is set (see --memory.limit), 10% of the limit is used.").
+		Default("250MB").Action(flagSetAction(&indexCacheSizeSet)).Bytes()
 
 	indexCacheConfig := extflag.RegisterPathOrContent(cmd, "index-cache.config",
 		"YAML file that contains index cache configuration. See format details: https://thanos.io/components/store.md/#index-cache",
 		false)
 
-	chunkPoolSize := cmd.Flag("chunk-pool-size", "Maximum size of concurrently allocatable bytes reserved strictly to reuse for chunks in memory.").
-		Default("2GB").Bytes()
+	enableIndexCacheInvalidateEndpoint := cmd.Flag("index-cache.enable-invalidate-endpoint", "If true, Store Gateway exposes POST /api/v1/index-cache/invalidate HTTP endpoint, which invalidates all items of types given by the repeated type query parameter (postings or series, all if not given) in the index cache. Memcached index cache items are invalidated by bumping the version of their keys, so the endpoint invalidates them for all store gateways sharing the memcached.").
+		Default("false").Bool()
+
+	chunkPoolSizeSet := false
+	chunkPoolSize := cmd.Flag("chunk-pool-size", "Maximum size of concurrently allocatable bytes reserved strictly to reuse for chunks in memory. If not set and a soft memory limit is set (see --memory.limit), 50% of the limit is used.").
+		Default("2GB").Action(flagSetAction(&chunkPoolSizeSet)).Bytes()
 
 	maxSampleCount := cmd.Flag("store.grpc.series-sample-limit",
 		"Maximum amount of samples returned via a single Series call. 0 means no limit. NOTE: For efficiency we take 120 as the number of samples in chunk (it cannot be bigger than that), so the actual number of samples might be lower, even though the maximum could be hit.").
@@ -71,6 +99,9 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 	blockSyncConcurrency := cmd.Flag("block-sync-concurrency", "Number of goroutines to use when constructing index-cache.json blocks from object storage.").
 		Default("20").Int()
 
+	lazyIndexHeaderConcurrency := cmd.Flag("store.lazy-index-header-concurrency", "If positive, blocks are served as soon as they are discovered in the bucket, and their index-headers are built on first use by up to this number of goroutines, instead of being built by the sync before serving the blocks. This shortens the time until new blocks, e.g. uploaded by shut down sidecars, are queryable.").
+		Default("0").Int()
+
 	minTime := model.TimeOrDuration(cmd.Flag("min-time", "Start of time range limit to serve. Thanos Store will serve only metrics, which happened later than this value. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
 		Default("0000-01-01T00:00:00Z"))
 
@@ -89,6 +120,9 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 	enablePostingsCompression := cmd.Flag("experimental.enable-index-cache-postings-compression", "If true, Store Gateway will reencode and compress postings before storing them into cache. Compressed postings take about 10% of the original size.").
 		Hidden().Default("false").Bool()
 
+	enableArrowSeries := cmd.Flag("experimental.enable-arrow-series", "If true, Store Gateway will advertise the arrow_series capability and send series of raw data as Arrow record batches to queriers requesting them.").
+		Hidden().Default("false").Bool()
+
 	consistencyDelay := modelDuration(cmd.Flag("consistency-delay", "Minimum age of all blocks before they are being read. Set it to safe value (e.g 30m) if your object storage is eventually consistent. GCS and S3 are (roughly) strongly consistent.").
 		Default("0s"))
 
@@ -102,17 +136,56 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 	webExternalPrefix := cmd.Flag("web.external-prefix", "Static prefix for all HTML links and redirect URLs in the bucket web UI interface. Actual endpoints are still served on / or the web.route-prefix. This allows thanos bucket web UI to be served behind a reverse proxy that strips a URL sub-path.").Default("").String()
 	webPrefixHeaderName := cmd.Flag("web.prefix-header", "Name of HTTP request header used for dynamic prefixing of UI links and redirects. This option is ignored if web.external-prefix argument is set. Security risk: enable this option only if a reverse proxy in front of thanos is resetting the header. The --web.prefix-header=X-Forwarded-Prefix option can be useful, for example, if Thanos UI is served via Traefik reverse proxy with PathPrefixStrip option enabled, which sends the stripped prefix value in X-Forwarded-Prefix header. This allows thanos UI to be served on a sub-path.").Default("").String()
 
+	tenantHeader := cmd.Flag("store.tenant-header", "gRPC metadata key of the tenant that usage of Series calls is accounted to. Queriers forward the tenant of Query API requests with the key of their tenant header.").Default(receive.DefaultTenantHeader).String()
+	usageReportInterval := modelDuration(cmd.Flag("usage.report-interval", "Interval of uploading reports of usage of tenants to the bucket. Reports are not uploaded if 0.").Default("0s"))
+
+	expensiveBlocksLimit := cmd.Flag("store.expensive-blocks-limit", "Number of the blocks with the most bytes fetched by Series requests exposed as metrics, and returned by default by the /api/v1/blocks/expensive endpoint. 0 exposes no metrics.").
+		Default("10").Int()
+
+	labelInternConfig := regLabelInternFlags(cmd)
+
 	m[component.Store.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ <-chan struct{}, debugLogging bool) error {
 		if minTime.PrometheusTimestamp() > maxTime.PrometheusTimestamp() {
 			return errors.Errorf("invalid argument: --min-time '%s' can't be greater than --max-time '%s'",
 				minTime, maxTime)
 		}
 
+		grpcPeers, err := newGRPCPeerAllowlist(logger, reg, grpcPeerAllowlistConfig, *grpcClientCA)
+		if err != nil {
+			return errors.Wrap(err, "create gRPC peer allowlist")
+		}
+
+		reqLogger, err := newRequestLogger(g, logger, reg, requestLoggingConfig)
+		if err != nil {
+			return errors.Wrap(err, "create request logger")
+		}
+
+		if _, err := newGossipPeer(g, logger, reg, gossipConfig, gossip.State{Component: component.Store.String(), StoreAPI: *grpcBindAddr}); err != nil {
+			return errors.Wrap(err, "create gossip peer")
+		}
+
+		interner, err := newLabelInterner(g, reg, labelInternConfig)
+		if err != nil {
+			return err
+		}
+
+		indexCacheSizeBytes, chunkPoolSizeBytes := uint64(*indexCacheSize), uint64(*chunkPoolSize)
+		if limit := memlimit.Current(); limit > 0 {
+			if !indexCacheSizeSet {
+				indexCacheSizeBytes = memlimit.Budget(limit, indexCacheMemoryRatio, indexCacheSizeBytes)
+			}
+			if !chunkPoolSizeSet {
+				chunkPoolSizeBytes = memlimit.Budget(limit, chunkPoolMemoryRatio, chunkPoolSizeBytes)
+			}
+			level.Info(logger).Log("msg", "memory budgets", "index_cache_size", units.Base2Bytes(indexCacheSizeBytes).String(), "chunk_pool_size", units.Base2Bytes(chunkPoolSizeBytes).String())
+		}
+
 		return runStore(g,
 			logger,
 			reg,
 			tracer,
 			indexCacheConfig,
+			*enableIndexCacheInvalidateEndpoint,
 			objStoreConfig,
 			*dataDir,
 			*grpcBindAddr,
@@ -120,16 +193,20 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 			*grpcCert,
 			*grpcKey,
 			*grpcClientCA,
+			grpcPeers,
+			reqLogger,
+			*zone,
 			*httpBindAddr,
 			time.Duration(*httpGracePeriod),
-			uint64(*indexCacheSize),
-			uint64(*chunkPoolSize),
+			indexCacheSizeBytes,
+			chunkPoolSizeBytes,
 			uint64(*maxSampleCount),
 			*maxConcurrent,
 			component.Store,
 			debugLogging,
 			*syncInterval,
 			*blockSyncConcurrency,
+			*lazyIndexHeaderConcurrency,
 			&store.FilterConfig{
 				MinTime: *minTime,
 				MaxTime: *maxTime,
@@ -138,10 +215,15 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application) {
 			*advertiseCompatibilityLabel,
 			*disableIndexHeader,
 			*enablePostingsCompression,
+			*enableArrowSeries,
 			time.Duration(*consistencyDelay),
 			time.Duration(*ignoreDeletionMarksDelay),
 			*webExternalPrefix,
 			*webPrefixHeaderName,
+			interner,
+			*tenantHeader,
+			time.Duration(*usageReportInterval),
+			*expensiveBlocksLimit,
 		)
 	}
 }
@@ -153,11 +235,16 @@ func runStore(
 	reg *prometheus.Registry,
 	tracer opentracing.Tracer,
 	indexCacheConfig *extflag.PathOrContent,
+	enableIndexCacheInvalidateEndpoint bool,
 	objStoreConfig *extflag.PathOrContent,
 	dataDir string,
 	grpcBindAddr string,
 	grpcGracePeriod time.Duration,
-	grpcCert, grpcKey, grpcClientCA, httpBindAddr string,
+	grpcCert, grpcKey, grpcClientCA string,
+	grpcPeers *grpcserver.PeerAllowlist,
+	reqLogger *logging.RequestLogger,
+	zone string,
+	httpBindAddr string,
 	httpGracePeriod time.Duration,
 	indexCacheSizeBytes, chunkPoolSizeBytes, maxSampleCount uint64,
 	maxConcurrency int,
@@ -165,12 +252,17 @@ func runStore(
 	verbose bool,
 	syncInterval time.Duration,
 	blockSyncConcurrency int,
+	lazyIndexHeaderConcurrency int,
 	filterConf *store.FilterConfig,
 	selectorRelabelConf *extflag.PathOrContent,
-	advertiseCompatibilityLabel, disableIndexHeader, enablePostingsCompression bool,
+	advertiseCompatibilityLabel, disableIndexHeader, enablePostingsCompression, enableArrowSeries bool,
 	consistencyDelay time.Duration,
 	ignoreDeletionMarksDelay time.Duration,
 	externalPrefix, prefixHeader string,
+	interner *strutil.Interner,
+	tenantHeader string,
+	usageReportInterval time.Duration,
+	expensiveBlocksLimit int,
 ) error {
 	grpcProbe := prober.NewGRPC()
 	httpProbe := prober.NewHTTP()
@@ -183,6 +275,7 @@ func runStore(
 	srv := httpserver.New(logger, reg, component, httpProbe,
 		httpserver.WithListen(httpBindAddr),
 		httpserver.WithGracePeriod(httpGracePeriod),
+		httpserver.WithRequestLogging(reqLogger),
 	)
 
 	g.Add(func() error {
@@ -242,6 +335,13 @@ func runStore(
 	if err != nil {
 		return errors.Wrap(err, "create index cache")
 	}
+	if enableIndexCacheInvalidateEndpoint {
+		ic, ok := indexCache.(storecache.InvalidatableIndexCache)
+		if !ok {
+			return errors.Errorf("index cache %T does not support invalidation", indexCache)
+		}
+		srv.Handle("/api/v1/index-cache/invalidate", indexCacheInvalidateHandler(logger, ic))
+	}
 
 	ignoreDeletionMarkFilter := block.NewIgnoreDeletionMarkFilter(logger, bkt, ignoreDeletionMarksDelay)
 	metaFetcher, err := block.NewMetaFetcher(logger, fetcherConcurrency, bkt, dataDir, extprom.WrapRegistererWithPrefix("thanos_", reg),
@@ -259,6 +359,7 @@ func runStore(
 	if !disableIndexHeader {
 		level.Info(logger).Log("msg", "index-header instead of index-cache.json enabled")
 	}
+	accountant := usage.NewAccountant(reg)
 	bs, err := store.NewBucketStore(
 		logger,
 		reg,
@@ -275,10 +376,18 @@ func runStore(
 		advertiseCompatibilityLabel,
 		!disableIndexHeader,
 		enablePostingsCompression,
+		interner,
+		accountant,
+		tenantHeader,
+		enableArrowSeries,
+		lazyIndexHeaderConcurrency,
 	)
 	if err != nil {
 		return errors.Wrap(err, "create object storage store")
 	}
+	if expensiveBlocksLimit > 0 {
+		reg.MustRegister(store.NewExpensiveBlocksCollector(bs, expensiveBlocksLimit))
+	}
 
 	// bucketStoreReady signals when bucket store is ready.
 	bucketStoreReady := make(chan struct{})
@@ -309,9 +418,18 @@ func runStore(
 			cancel()
 		})
 	}
+	if usageReportInterval > 0 {
+		reporter := usage.NewReporter(logger, reg, bkt, accountant, component.String(), usageReportInterval)
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			return reporter.Run(ctx)
+		}, func(error) {
+			cancel()
+		})
+	}
 	// Start query (proxy) gRPC StoreAPI.
 	{
-		tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), grpcCert, grpcKey, grpcClientCA)
+		tlsCfg, err := tls.NewServerConfig(log.With(logger, "protocol", "gRPC"), extprom.WrapRegistererWith(prometheus.Labels{"protocol": "grpc"}, reg), grpcCert, grpcKey, grpcClientCA)
 		if err != nil {
 			return errors.Wrap(err, "setup gRPC server")
 		}
@@ -320,6 +438,9 @@ func runStore(
 			grpcserver.WithListen(grpcBindAddr),
 			grpcserver.WithGracePeriod(grpcGracePeriod),
 			grpcserver.WithTLSConfig(tlsCfg),
+			grpcserver.WithPeerAllowlist(grpcPeers),
+			grpcserver.WithRequestLogging(reqLogger),
+			grpcserver.WithZone(zone),
 		)
 
 		g.Add(func() error {
@@ -334,9 +455,13 @@ func runStore(
 	// Add bucket UI for loaded blocks.
 	{
 		r := route.New()
+		ins := extpromhttp.NewInstrumentationMiddleware(reg)
 		compactorView := ui.NewBucketUI(logger, "", path.Join(externalPrefix, "/loaded"), prefixHeader)
-		compactorView.Register(r, extpromhttp.NewInstrumentationMiddleware(reg))
+		compactorView.Register(r, ins)
 		metaFetcher.UpdateOnChange(compactorView.Set)
+		ui.NewStoreUI(logger, externalPrefix, prefixHeader, bs.LoadedBlocks).Register(r, ins)
+		srv.Handle("/api/v1/blocks/loaded", ins.NewHandler("loaded_blocks", loadedBlocksHandler(logger, bs.LoadedBlocks)))
+		srv.Handle("/api/v1/blocks/expensive", ins.NewHandler("expensive_blocks", expensiveBlocksHandler(logger, bs.ExpensiveBlocks, expensiveBlocksLimit)))
 		srv.Handle("/", r)
 	}
 
@@ -344,6 +469,108 @@ func runStore(
 	return nil
 }
 
+// indexCacheInvalidateHandler invalidates all items of types given by the type query parameter in the index cache.
+func indexCacheInvalidateHandler(logger log.Logger, c storecache.InvalidatableIndexCache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "only POST method is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, errors.Wrap(err, "parse form").Error(), http.StatusBadRequest)
+			return
+		}
+		types := r.Form["type"]
+		if len(types) == 0 {
+			types = storecache.ItemTypes()
+		}
+		for _, typ := range types {
+			if !isIndexCacheItemType(typ) {
+				http.Error(w, fmt.Sprintf("unknown type %q, expected one of %v", typ, storecache.ItemTypes()), http.StatusBadRequest)
+				return
+			}
+		}
+
+		for _, typ := range types {
+			if err := c.Invalidate(r.Context(), typ); err != nil {
+				level.Error(logger).Log("msg", "index cache invalidation failed", "type", typ, "err", err)
+				http.Error(w, errors.Wrapf(err, "invalidate %s", typ).Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		level.Info(logger).Log("msg", "invalidated index cache", "types", strings.Join(types, ","))
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(struct {
+			Invalidated []string `json:"invalidated"`
+		}{Invalidated: types}); err != nil {
+			level.Warn(logger).Log("msg", "failed to write index cache invalidation response", "err", err)
+		}
+	})
+}
+
+// loadedBlocksHandler responds with blocks currently loaded by the bucket store and their totals.
+func loadedBlocksHandler(logger log.Logger, loadedBlocks func() []store.LoadedBlock) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET method is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		blocks := loadedBlocks()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(struct {
+			Blocks []store.LoadedBlock      `json:"blocks"`
+			Totals store.LoadedBlocksTotals `json:"totals"`
+		}{Blocks: blocks, Totals: store.SumLoadedBlocks(blocks)}); err != nil {
+			level.Warn(logger).Log("msg", "failed to write loaded blocks response", "err", err)
+		}
+	})
+}
+
+// expensiveBlocksHandler responds with statistics of the blocks with the highest cost given by the by query parameter,
+// fetched bytes by default. The limit query parameter limits the number of blocks.
+func expensiveBlocksHandler(logger log.Logger, expensiveBlocks func(limit int, cost string) []store.BlockQueryStats, defaultLimit int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET method is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		limit := defaultLimit
+		if l := r.URL.Query().Get("limit"); l != "" {
+			var err error
+			if limit, err = strconv.Atoi(l); err != nil || limit < 0 {
+				http.Error(w, fmt.Sprintf("invalid limit %q", l), http.StatusBadRequest)
+				return
+			}
+		}
+		cost := store.BlockCostFetchedBytes
+		if c := r.URL.Query().Get("by"); c != "" {
+			if !store.IsBlockCost(c) {
+				http.Error(w, fmt.Sprintf("unknown cost %q, expected one of %v", c, store.BlockCosts()), http.StatusBadRequest)
+				return
+			}
+			cost = c
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(struct {
+			Blocks []store.BlockQueryStats `json:"blocks"`
+		}{Blocks: expensiveBlocks(limit, cost)}); err != nil {
+			level.Warn(logger).Log("msg", "failed to write expensive blocks response", "err", err)
+		}
+	})
+}
+
+func isIndexCacheItemType(typ string) bool {
+	for _, t := range storecache.ItemTypes() {
+		if strings.EqualFold(t, typ) {
+			return true
+		}
+	}
+	return false
+}
+
 func parseRelabelConfig(contentYaml []byte) ([]*relabel.Config, error) {
 	var relabelConfig []*relabel.Config
 	if err := yaml.Unmarshal(contentYaml, &relabelConfig); err != nil {
@@ -0,0 +1,833 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/run"
+	"github.com/oklog/ulid"
+	"github.com/olekukonko/tablewriter"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/extflag"
+	"github.com/thanos-io/thanos/pkg/extprom"
+	thanosmodel "github.com/thanos-io/thanos/pkg/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/client"
+	"github.com/thanos-io/thanos/pkg/promclient"
+	"github.com/thanos-io/thanos/pkg/querybench"
+	"github.com/thanos-io/thanos/pkg/receive"
+	thanosrule "github.com/thanos-io/thanos/pkg/rule"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func registerTools(m map[string]setupFunc, app *kingpin.Application, name string) {
+	cmd := app.Command(name, "Tools utility commands")
+
+	registerToolsRules(m, cmd, name)
+	registerToolsQuery(m, cmd, name)
+	registerToolsTSDB(m, cmd, name)
+}
+
+func registerToolsRules(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("rules", "Rules utility commands")
+
+	registerToolsRulesBackfill(m, cmd, name+" rules")
+	registerToolsRulesMaterialize(m, cmd, name+" rules")
+}
+
+func registerToolsRulesBackfill(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("backfill", "Evaluate recording rules over a historical time range against a query API and upload the results as blocks into the bucket.")
+
+	ruleFiles := cmd.Flag("rule-file", "Rule files to evaluate. Only recording rules are evaluated (repeated).").
+		Required().ExistingFiles()
+
+	queryURL := cmd.Flag("query", "URL of the query API to evaluate rules against, e.g. http://thanos-query:10902.").
+		Required().URL()
+
+	queryTenantHeader := cmd.Flag("query.tenant-header", "HTTP header used to pass source tenants of a rule group to the query API.").
+		Default(receive.DefaultTenantHeader).String()
+
+	minTime := thanosmodel.TimeOrDuration(cmd.Flag("min-time", "Start of time range to evaluate rules in. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Required())
+
+	maxTime := thanosmodel.TimeOrDuration(cmd.Flag("max-time", "End of time range to evaluate rules in. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("0s"))
+
+	evalInterval := modelDuration(cmd.Flag("eval-interval", "The default evaluation interval to use for groups without an interval.").
+		Default("30s"))
+
+	blockDuration := modelDuration(cmd.Flag("block-duration", "Time range of the produced blocks.").
+		Default("2h"))
+
+	labelStrs := cmd.Flag("label", "Labels to be applied to produced blocks (repeated). Use external labels of the Ruler the rules would be evaluated by otherwise.").
+		PlaceHolder("<name>=\"<value>\"").Strings()
+
+	dataDir := cmd.Flag("data-dir", "Data directory in which to write blocks before uploading them.").
+		Default("./data").String()
+
+	objStoreConfig := regCommonObjStoreFlags(cmd, "", true)
+
+	m[name+" backfill"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		lset, err := parseFlagLabels(*labelStrs)
+		if err != nil {
+			return errors.Wrap(err, "parse labels")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			return runRulesBackfill(
+				ctx,
+				logger,
+				reg,
+				*ruleFiles,
+				*queryURL,
+				*queryTenantHeader,
+				timestamp.Time(minTime.PrometheusTimestamp()),
+				timestamp.Time(maxTime.PrometheusTimestamp()),
+				time.Duration(*evalInterval),
+				time.Duration(*blockDuration),
+				lset,
+				*dataDir,
+				objStoreConfig,
+			)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	}
+}
+
+func runRulesBackfill(
+	ctx context.Context,
+	logger log.Logger,
+	reg *prometheus.Registry,
+	ruleFiles []string,
+	queryURL *url.URL,
+	queryTenantHeader string,
+	mint, maxt time.Time,
+	evalInterval time.Duration,
+	blockDuration time.Duration,
+	lset labels.Labels,
+	dataDir string,
+	objStoreConfig *extflag.PathOrContent,
+) error {
+	if !mint.Before(maxt) {
+		return errors.Errorf("min time %s has to be before max time %s", mint.UTC().Format(time.RFC3339), maxt.UTC().Format(time.RFC3339))
+	}
+	if blockDuration <= 0 {
+		return errors.New("block duration has to be positive")
+	}
+	if len(lset) == 0 {
+		return errors.New("no labels configured, produced blocks would not be distinguishable from other sources")
+	}
+
+	groups, err := thanosrule.ParseRuleFiles(ruleFiles)
+	if err != nil {
+		return errors.Wrap(err, "parse rule files")
+	}
+
+	confContentYaml, err := objStoreConfig.Content()
+	if err != nil {
+		return err
+	}
+	bkt, err := client.NewBucket(logger, confContentYaml, reg, component.Rule.String())
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+	queryFnFactory := rulesQueryFuncFactory(logger, queryURL, queryTenantHeader)
+	for _, r := range thanosrule.BackfillRanges(mint, maxt, blockDuration) {
+		if err := backfillBlock(ctx, logger, bkt, groups, queryFnFactory, r[0], r[1], evalInterval, lset, dataDir, metadata.RulerBackfillSource); err != nil {
+			return err
+		}
+	}
+	level.Info(logger).Log("msg", "backfill done", "mint", mint.UTC().Format(time.RFC3339), "maxt", maxt.UTC().Format(time.RFC3339))
+	return nil
+}
+
+// rulesQueryFuncFactory returns query functions evaluating rules of a group as instant queries against the given
+// query API, with the partial response strategy and source tenants of the group.
+func rulesQueryFuncFactory(logger log.Logger, queryURL *url.URL, queryTenantHeader string) thanosrule.BackfillQueryFuncFactory {
+	promClient := promclient.NewClient(logger, http.DefaultClient)
+	return func(g thanosrule.RuleGroup) rules.QueryFunc {
+		opts := promclient.QueryOptions{
+			Deduplicate:             true,
+			PartialResponseStrategy: *g.PartialResponseStrategy,
+		}
+		if len(g.SourceTenants) > 0 {
+			opts.HTTPHeaders = http.Header{queryTenantHeader: []string{strings.Join(g.SourceTenants, "|")}}
+		}
+		return func(ctx context.Context, q string, t time.Time) (promql.Vector, error) {
+			v, warns, err := promClient.PromqlQueryInstant(ctx, queryURL, q, t, opts)
+			if err != nil {
+				return nil, err
+			}
+			if len(warns) > 0 {
+				level.Warn(logger).Log("msg", "rule evaluation returned warnings", "query", q, "warnings", strings.Join(warns, ", "))
+			}
+			return v, nil
+		}
+	}
+}
+
+func registerToolsRulesMaterialize(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("materialize", "Periodically evaluate recording rules over completed time ranges against a query API and upload the results as blocks into the bucket.")
+
+	ruleFiles := cmd.Flag("rule-file", "Rule files to evaluate. Only recording rules are evaluated (repeated).").
+		Required().ExistingFiles()
+
+	queryURL := cmd.Flag("query", "URL of the query API to evaluate rules against, e.g. http://thanos-query:10902.").
+		Required().URL()
+
+	queryTenantHeader := cmd.Flag("query.tenant-header", "HTTP header used to pass source tenants of a rule group to the query API.").
+		Default(receive.DefaultTenantHeader).String()
+
+	minTime := thanosmodel.TimeOrDuration(cmd.Flag("min-time", "Start of time range to materialize rules from, if no blocks with the given labels are in the bucket yet. Otherwise rules are materialized from the end of the latest such block. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("0s"))
+
+	delay := modelDuration(cmd.Flag("delay", "Time to wait after the end of a block range before materializing it, so all samples of the range are available from the query API.").
+		Default("10m"))
+
+	interval := modelDuration(cmd.Flag("interval", "Interval of checking for completed block ranges to materialize.").
+		Default("1m"))
+
+	evalInterval := modelDuration(cmd.Flag("eval-interval", "The default evaluation interval to use for groups without an interval.").
+		Default("30s"))
+
+	blockDuration := modelDuration(cmd.Flag("block-duration", "Time range of the produced blocks.").
+		Default("2h"))
+
+	labelStrs := cmd.Flag("label", "Labels to be applied to produced blocks (repeated). They must not be used by any other source of blocks, as progress is tracked by the blocks with these labels in the bucket.").
+		Required().PlaceHolder("<name>=\"<value>\"").Strings()
+
+	dataDir := cmd.Flag("data-dir", "Data directory in which to write blocks before uploading them.").
+		Default("./data").String()
+
+	objStoreConfig := regCommonObjStoreFlags(cmd, "", true)
+
+	m[name+" materialize"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		lset, err := parseFlagLabels(*labelStrs)
+		if err != nil {
+			return errors.Wrap(err, "parse labels")
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			return runRulesMaterialize(
+				ctx,
+				logger,
+				reg,
+				*ruleFiles,
+				*queryURL,
+				*queryTenantHeader,
+				timestamp.Time(minTime.PrometheusTimestamp()),
+				time.Duration(*delay),
+				time.Duration(*interval),
+				time.Duration(*evalInterval),
+				time.Duration(*blockDuration),
+				lset,
+				*dataDir,
+				objStoreConfig,
+			)
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	}
+}
+
+func runRulesMaterialize(
+	ctx context.Context,
+	logger log.Logger,
+	reg *prometheus.Registry,
+	ruleFiles []string,
+	queryURL *url.URL,
+	queryTenantHeader string,
+	mint time.Time,
+	delay time.Duration,
+	interval time.Duration,
+	evalInterval time.Duration,
+	blockDuration time.Duration,
+	lset labels.Labels,
+	dataDir string,
+	objStoreConfig *extflag.PathOrContent,
+) error {
+	if blockDuration <= 0 || interval <= 0 {
+		return errors.New("block duration and interval have to be positive")
+	}
+	if len(lset) == 0 {
+		return errors.New("no labels configured, produced blocks would not be distinguishable from other sources")
+	}
+
+	groups, err := thanosrule.ParseRuleFiles(ruleFiles)
+	if err != nil {
+		return errors.Wrap(err, "parse rule files")
+	}
+
+	confContentYaml, err := objStoreConfig.Content()
+	if err != nil {
+		return err
+	}
+	bkt, err := client.NewBucket(logger, confContentYaml, reg, component.Rule.String())
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+	fetcher, err := block.NewMetaFetcher(logger, fetcherConcurrency, bkt, "", extprom.WrapRegistererWithPrefix("thanos_", reg), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	materializedUntil := promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_rule_materialized_until_timestamp_seconds",
+		Help: "Time up to which rules were materialized into the bucket.",
+	})
+	failures := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "thanos_rule_materialize_failures_total",
+		Help: "Total number of failed attempts to materialize rules into the bucket.",
+	})
+
+	queryFnFactory := rulesQueryFuncFactory(logger, queryURL, queryTenantHeader)
+	// Ranges without samples produce no blocks, so progress is tracked in memory as well to not evaluate them again.
+	next := mint
+	materialize := func() error {
+		metas, _, err := fetcher.Fetch(ctx)
+		if err != nil {
+			return errors.Wrap(err, "fetch metas")
+		}
+		start := next
+		if until, ok := thanosrule.MaterializedUntil(metas, lset); ok && until.After(start) {
+			start = until
+		}
+		materializedUntil.Set(float64(start.Unix()))
+
+		// Only block ranges completed at least the delay ago are materialized.
+		end := time.Now().Add(-delay).Truncate(blockDuration)
+		for _, r := range thanosrule.BackfillRanges(start, end, blockDuration) {
+			if err := backfillBlock(ctx, logger, bkt, groups, queryFnFactory, r[0], r[1], evalInterval, lset, dataDir, metadata.RulerMaterializeSource); err != nil {
+				return err
+			}
+			next = r[1]
+			materializedUntil.Set(float64(next.Unix()))
+		}
+		return nil
+	}
+
+	return runutil.Repeat(interval, ctx.Done(), func() error {
+		if err := materialize(); err != nil {
+			failures.Inc()
+			level.Error(logger).Log("msg", "materializing rules failed, retrying", "err", err)
+		}
+		return nil
+	})
+}
+
+// backfillBlock evaluates rules within [mint, maxt) into a single block and uploads it.
+func backfillBlock(
+	ctx context.Context,
+	logger log.Logger,
+	bkt objstore.Bucket,
+	groups []thanosrule.RuleGroup,
+	queryFnFactory thanosrule.BackfillQueryFuncFactory,
+	mint, maxt time.Time,
+	evalInterval time.Duration,
+	lset labels.Labels,
+	dataDir string,
+	source metadata.SourceType,
+) (err error) {
+	rng := timestamp.FromTime(maxt) - timestamp.FromTime(mint)
+	head, err := tsdb.NewHead(nil, logger, nil, rng)
+	if err != nil {
+		return errors.Wrap(err, "create head")
+	}
+	defer runutil.CloseWithErrCapture(&err, head, "TSDB head")
+
+	app := head.Appender()
+	samples, err := thanosrule.Backfill(ctx, groups, evalInterval, mint, maxt, queryFnFactory, app)
+	if err != nil {
+		if rerr := app.Rollback(); rerr != nil {
+			err = errors.Wrapf(err, "rollback failed: %v", rerr)
+		}
+		return err
+	}
+	if err := app.Commit(); err != nil {
+		return errors.Wrap(err, "commit")
+	}
+	if samples == 0 {
+		level.Info(logger).Log("msg", "no samples produced, skipping block", "mint", mint.UTC().Format(time.RFC3339), "maxt", maxt.UTC().Format(time.RFC3339))
+		return nil
+	}
+
+	if err := os.MkdirAll(dataDir, os.ModePerm); err != nil {
+		return errors.Wrapf(err, "create %s", dataDir)
+	}
+	comp, err := tsdb.NewLeveledCompactor(ctx, nil, logger, []int64{rng}, nil)
+	if err != nil {
+		return errors.Wrap(err, "create compactor")
+	}
+	id, err := comp.Write(dataDir, head, timestamp.FromTime(mint), timestamp.FromTime(maxt), nil)
+	if err != nil {
+		return errors.Wrap(err, "write block")
+	}
+	if id == (ulid.ULID{}) {
+		return errors.New("no block written")
+	}
+
+	bdir := filepath.Join(dataDir, id.String())
+	defer func() {
+		if rerr := os.RemoveAll(bdir); rerr != nil {
+			level.Warn(logger).Log("msg", "failed to remove block dir", "dir", bdir, "err", rerr)
+		}
+	}()
+
+	if _, err := metadata.InjectThanos(logger, bdir, metadata.Thanos{
+		Labels:     lset.Map(),
+		Downsample: metadata.ThanosDownsample{Resolution: 0},
+		Source:     source,
+	}, nil); err != nil {
+		return errors.Wrap(err, "inject thanos meta")
+	}
+
+	if err := block.Upload(ctx, logger, bkt, bdir); err != nil {
+		return errors.Wrapf(err, "upload block %s", id)
+	}
+	level.Info(logger).Log("msg", "uploaded block", "id", id, "mint", mint.UTC().Format(time.RFC3339), "maxt", maxt.UTC().Format(time.RFC3339), "samples", samples)
+	return nil
+}
+
+func registerToolsQuery(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("query", "Query utility commands")
+
+	registerToolsQueryBench(m, cmd, name+" query")
+}
+
+func registerToolsQueryBench(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("bench", "Replay queries from a Prometheus query log or a file of queries against a query API and report latency percentiles and response sizes.")
+
+	queryURL := cmd.Flag("query", "URL of the query API to replay queries against, e.g. http://thanos-query:10902.").
+		Required().URL()
+
+	queryFile := cmd.Flag("query-file", "File with queries, one per line. Each line is either an entry of the Prometheus query log in JSON, or a PromQL expression.").
+		Required().ExistingFile()
+
+	headers := cmd.Flag("header", "HTTP header added to each query request, e.g. 'THANOS-TENANT: team-a' (repeated).").
+		PlaceHolder("<name>: <value>").Strings()
+
+	concurrency := cmd.Flag("concurrency", "Number of queries executed at once.").
+		Default("1").Int()
+
+	repeat := cmd.Flag("repeat", "Number of times all queries are replayed.").
+		Default("1").Int()
+
+	timeout := modelDuration(cmd.Flag("timeout", "Timeout of a single query.").
+		Default("2m"))
+
+	rng := modelDuration(cmd.Flag("range", "Time range of PromQL expressions from the query file, evaluated as range queries ending now. Expressions are evaluated as instant queries if zero.").
+		Default("0s"))
+
+	step := modelDuration(cmd.Flag("step", "Step of range queries of PromQL expressions from the query file.").
+		Default("30s"))
+
+	shiftToNow := cmd.Flag("time-shift.to-now", "Shift time ranges of all queries, so the latest query ends now. Keeps the relative time ranges of logged queries, e.g. to replay an old query log against recent data.").
+		Bool()
+
+	shift := cmd.Flag("time-shift", "Duration added to time ranges of all queries, after shifting to now, e.g. -7d to query data of a week before.").
+		Default("0s").String()
+
+	m[name+" bench"] = func(g *run.Group, logger log.Logger, _ *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		if *concurrency < 1 || *repeat < 1 {
+			return errors.New("concurrency and repeat have to be positive")
+		}
+		shiftDur, err := parseSignedDuration(*shift)
+		if err != nil {
+			return errors.Wrap(err, "parse time shift")
+		}
+		header := http.Header{}
+		for _, h := range *headers {
+			parts := strings.SplitN(h, ":", 2)
+			if len(parts) != 2 {
+				return errors.Errorf("invalid header %q, expected <name>: <value>", h)
+			}
+			header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			f, err := os.Open(*queryFile)
+			if err != nil {
+				return err
+			}
+			defer runutil.CloseWithLogOnErr(logger, f, "query file")
+
+			now := time.Now()
+			queries, err := querybench.ParseQueries(f, now, time.Duration(*rng), time.Duration(*step))
+			if err != nil {
+				return errors.Wrapf(err, "parse %s", *queryFile)
+			}
+			if len(queries) == 0 {
+				return errors.Errorf("no queries found in %s", *queryFile)
+			}
+			if *shiftToNow {
+				querybench.ShiftToNow(queries, now)
+			}
+			querybench.Shift(queries, shiftDur)
+
+			level.Info(logger).Log("msg", "replaying queries", "queries", len(queries), "repeat", *repeat, "concurrency", *concurrency)
+			b := querybench.New(logger, http.DefaultClient, *queryURL, header, *concurrency, time.Duration(*timeout))
+			begin := time.Now()
+			results, err := b.Run(ctx, queries, *repeat)
+			elapsed := time.Since(begin)
+			printQueryBench(os.Stdout, results, elapsed)
+			return err
+		}, func(error) {
+			cancel()
+		})
+		return nil
+	}
+}
+
+// parseSignedDuration parses a Prometheus duration with an optional minus sign.
+func parseSignedDuration(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	}
+	d, err := model.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return sign * time.Duration(d), nil
+}
+
+// printQueryBench prints summaries of instant, range and all queries of the benchmark run.
+func printQueryBench(w io.Writer, results []querybench.Result, elapsed time.Duration) {
+	var instant, rng []querybench.Result
+	for _, r := range results {
+		if r.Query.IsRange() {
+			rng = append(rng, r)
+			continue
+		}
+		instant = append(instant, r)
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"TYPE", "QUERIES", "ERRORS", "P50", "P90", "P99", "MAX", "BYTES", "QPS"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	for _, row := range []struct {
+		name    string
+		results []querybench.Result
+	}{
+		{name: "instant", results: instant},
+		{name: "range", results: rng},
+		{name: "all", results: results},
+	} {
+		s := querybench.Summarize(row.results)
+		table.Append([]string{
+			row.name,
+			strconv.Itoa(s.Queries),
+			strconv.Itoa(s.Errors),
+			s.P50.String(),
+			s.P90.String(),
+			s.P99.String(),
+			s.Max.String(),
+			strconv.FormatInt(s.Bytes, 10),
+			strconv.FormatFloat(float64(s.Queries)/elapsed.Seconds(), 'f', 2, 64),
+		})
+	}
+	table.Render()
+
+	// Show distinct errors, so failing queries can be found without debug logging.
+	seen := map[string]struct{}{}
+	for _, r := range results {
+		if r.Err == nil {
+			continue
+		}
+		if _, ok := seen[r.Err.Error()]; ok {
+			continue
+		}
+		seen[r.Err.Error()] = struct{}{}
+		fmt.Fprintf(w, "query %q failed: %v\n", r.Query.Expr, r.Err)
+	}
+}
+
+func registerToolsTSDB(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("tsdb", "TSDB utility commands")
+
+	registerToolsTSDBExport(m, cmd, name+" tsdb")
+	registerToolsTSDBImport(m, cmd, name+" tsdb")
+}
+
+func registerToolsTSDBExport(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("export", "Export samples of series selected by a series selector from raw blocks in the bucket to the OpenMetrics text format.")
+
+	match := cmd.Flag("match", "Series selector of the exported series, e.g. '{job=\"node\"}' or 'up'.").
+		Required().String()
+
+	sel := regBlockSelectionFlags(cmd)
+
+	output := cmd.Flag("output", "File to write the OpenMetrics text to. '-' writes to stdout.").
+		Default("-").String()
+
+	dataDir := cmd.Flag("data-dir", "Data directory in which blocks are downloaded, all blocks of the same external labels at once.").
+		Default("./data").String()
+
+	objStoreConfig := regCommonObjStoreFlags(cmd, "", true)
+
+	m[name+" export"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		matchers, err := promql.ParseMetricSelector(*match)
+		if err != nil {
+			return errors.Wrap(err, "parse series selector")
+		}
+		selector, err := sel.parse()
+		if err != nil {
+			return err
+		}
+
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, component.Bucket.String())
+		if err != nil {
+			return err
+		}
+
+		fetcher, err := block.NewMetaFetcher(logger, fetcherConcurrency, bkt, "", extprom.WrapRegistererWithPrefix(extpromPrefix, reg), []block.MetadataFilter{
+			block.NewIgnoreDeletionMarkFilter(logger, bkt, 0),
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx := context.Background()
+		metas, _, err := fetcher.Fetch(ctx)
+		if err != nil {
+			return err
+		}
+		blockMetas, err := selector.selectBlocks(ctx, bkt, metas)
+		if err != nil {
+			return err
+		}
+
+		var w io.Writer = os.Stdout
+		if *output != "-" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return errors.Wrap(err, "create output file")
+			}
+			defer runutil.CloseWithLogOnErr(logger, f, "output file")
+			w = f
+		}
+		omw := block.NewOpenMetricsWriter(w)
+
+		// Blocks of the same external labels are queried together, so overlapping blocks are deduplicated.
+		groups := map[string][]*metadata.Meta{}
+		var keys []string
+		for _, meta := range blockMetas {
+			if meta.Thanos.Downsample.Resolution != 0 {
+				level.Info(logger).Log("msg", "skipping downsampled block", "id", meta.ULID, "resolution", meta.Thanos.Downsample.Resolution)
+				continue
+			}
+			key := compact.GroupKey(meta.Thanos)
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], meta)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if err := exportGroup(ctx, logger, bkt, filepath.Join(*dataDir, key), groups[key], matchers, selector.minTime, selector.maxTime, omw); err != nil {
+				return errors.Wrapf(err, "export blocks of group %s", key)
+			}
+		}
+		if err := omw.Close(); err != nil {
+			return err
+		}
+		level.Info(logger).Log("msg", "export done", "blocks", len(blockMetas), "series", omw.Series, "samples", omw.Samples)
+		return nil
+	}
+}
+
+// exportGroup downloads blocks of the same external labels into dir and writes matching series within [mint, maxt].
+func exportGroup(
+	ctx context.Context,
+	logger log.Logger,
+	bkt objstore.Bucket,
+	dir string,
+	metas []*metadata.Meta,
+	matchers []*labels.Matcher,
+	mint, maxt int64,
+	w *block.OpenMetricsWriter,
+) (err error) {
+	defer func() {
+		if rerr := os.RemoveAll(dir); rerr != nil {
+			level.Warn(logger).Log("msg", "failed to remove block dir", "dir", dir, "err", rerr)
+		}
+	}()
+
+	var ss tsdb.SeriesSet
+	for _, meta := range metas {
+		bdir := filepath.Join(dir, meta.ULID.String())
+		if err := block.Download(ctx, logger, bkt, meta.ULID, bdir); err != nil {
+			return errors.Wrapf(err, "download block %s", meta.ULID)
+		}
+
+		// Readers are closed once all series are written, errors are captured in the named err.
+		b, berr := tsdb.OpenBlock(logger, bdir, nil)
+		if berr != nil {
+			return errors.Wrapf(berr, "open block %s", meta.ULID)
+		}
+		defer runutil.CloseWithErrCapture(&err, b, "block")
+
+		q, qerr := tsdb.NewBlockQuerier(b, mint, maxt)
+		if qerr != nil {
+			return errors.Wrapf(qerr, "create querier of block %s", meta.ULID)
+		}
+		defer runutil.CloseWithErrCapture(&err, q, "block querier")
+
+		bss, serr := q.Select(matchers...)
+		if serr != nil {
+			return errors.Wrapf(serr, "select series of block %s", meta.ULID)
+		}
+		if ss == nil {
+			ss = bss
+			continue
+		}
+		// Samples of the same series in overlapping blocks are deduplicated.
+		ss = tsdb.NewMergedVerticalSeriesSet(ss, bss)
+	}
+	return w.WriteSeries(ss, labels.FromMap(metas[0].Thanos.Labels), mint, maxt)
+}
+
+func registerToolsTSDBImport(m map[string]setupFunc, root *kingpin.CmdClause, name string) {
+	cmd := root.Command("import", "Import samples from the OpenMetrics text, e.g. produced by 'tools tsdb export', into new blocks uploaded into the bucket.")
+
+	input := cmd.Flag("input", "File to read the OpenMetrics text from. '-' reads from stdin. All samples have to have timestamps.").
+		Required().String()
+
+	labelStrs := cmd.Flag("label", "External labels of produced blocks (repeated). Labels of the same names are removed from imported series, if their values match.").
+		PlaceHolder("<name>=\"<value>\"").Strings()
+
+	blockDuration := modelDuration(cmd.Flag("block-duration", "Time range of the produced blocks.").
+		Default("2h"))
+
+	dataDir := cmd.Flag("data-dir", "Data directory in which to write blocks before uploading them.").
+		Default("./data").String()
+
+	objStoreConfig := regCommonObjStoreFlags(cmd, "", true)
+
+	m[name+" import"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ <-chan struct{}, _ bool) error {
+		lset, err := parseFlagLabels(*labelStrs)
+		if err != nil {
+			return errors.Wrap(err, "parse labels")
+		}
+		if len(lset) == 0 {
+			return errors.New("no labels configured, produced blocks would not be distinguishable from other sources")
+		}
+
+		var r io.Reader = os.Stdin
+		if *input != "-" {
+			f, err := os.Open(*input)
+			if err != nil {
+				return errors.Wrap(err, "open input file")
+			}
+			defer runutil.CloseWithLogOnErr(logger, f, "input file")
+			r = f
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return errors.Wrap(err, "read input")
+		}
+
+		confContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+		bkt, err := client.NewBucket(logger, confContentYaml, reg, component.Bucket.String())
+		if err != nil {
+			return err
+		}
+
+		// Dummy actor to immediately kill the group after the run function returns.
+		g.Add(func() error { return nil }, func(error) {})
+
+		defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+		ctx := context.Background()
+		ids, err := block.ImportOpenMetrics(ctx, logger, b, *dataDir, lset, int64(time.Duration(*blockDuration)/time.Millisecond))
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := uploadImportedBlock(ctx, logger, bkt, filepath.Join(*dataDir, id.String()), lset); err != nil {
+				return errors.Wrapf(err, "upload block %s", id)
+			}
+		}
+		level.Info(logger).Log("msg", "import done", "blocks", len(ids))
+		return nil
+	}
+}
+
+// uploadImportedBlock injects Thanos metadata into the block and uploads it. The local block is removed afterwards.
+func uploadImportedBlock(ctx context.Context, logger log.Logger, bkt objstore.Bucket, bdir string, lset labels.Labels) error {
+	defer func() {
+		if rerr := os.RemoveAll(bdir); rerr != nil {
+			level.Warn(logger).Log("msg", "failed to remove block dir", "dir", bdir, "err", rerr)
+		}
+	}()
+
+	meta, err := metadata.InjectThanos(logger, bdir, metadata.Thanos{
+		Labels:     lset.Map(),
+		Downsample: metadata.ThanosDownsample{Resolution: 0},
+		Source:     metadata.ToolsImportSource,
+	}, nil)
+	if err != nil {
+		return errors.Wrap(err, "inject thanos meta")
+	}
+
+	if err := block.Upload(ctx, logger, bkt, bdir); err != nil {
+		return err
+	}
+	level.Info(logger).Log("msg", "uploaded block", "id", meta.ULID, "mint", meta.MinTime, "maxt", meta.MaxTime, "samples", meta.Stats.NumSamples)
+	return nil
+}
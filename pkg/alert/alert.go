@@ -25,6 +25,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
 
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/tracing"
@@ -90,14 +91,16 @@ type Queue struct {
 	capacity        int
 	toAddLset       labels.Labels
 	toExcludeLabels labels.Labels
+	relabelConfigs  []*relabel.Config
 
 	mtx   sync.Mutex
 	queue []*Alert
 	morec chan struct{}
 
-	pushed  prometheus.Counter
-	popped  prometheus.Counter
-	dropped prometheus.Counter
+	pushed         prometheus.Counter
+	popped         prometheus.Counter
+	dropped        prometheus.Counter
+	relabelDropped prometheus.Counter
 }
 
 func relabelLabels(lset labels.Labels, excludeLset []string) (toAdd labels.Labels, toExclude labels.Labels) {
@@ -120,7 +123,8 @@ func relabelLabels(lset labels.Labels, excludeLset []string) (toAdd labels.Label
 
 // NewQueue returns a new queue. The given label set is attached to all alerts pushed to the queue.
 // The given exclude label set tells what label names to drop including external labels.
-func NewQueue(logger log.Logger, reg prometheus.Registerer, capacity, maxBatchSize int, externalLset labels.Labels, excludeLabels []string) *Queue {
+// The given relabel configs are applied as the last step and can drop alerts.
+func NewQueue(logger log.Logger, reg prometheus.Registerer, capacity, maxBatchSize int, externalLset labels.Labels, excludeLabels []string, relabelConfigs []*relabel.Config) *Queue {
 	toAdd, toExclude := relabelLabels(externalLset, excludeLabels)
 
 	if logger == nil {
@@ -133,6 +137,7 @@ func NewQueue(logger log.Logger, reg prometheus.Registerer, capacity, maxBatchSi
 		maxBatchSize:    maxBatchSize,
 		toAddLset:       toAdd,
 		toExcludeLabels: toExclude,
+		relabelConfigs:  relabelConfigs,
 
 		dropped: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 			Name: "thanos_alert_queue_alerts_dropped_total",
@@ -146,6 +151,10 @@ func NewQueue(logger log.Logger, reg prometheus.Registerer, capacity, maxBatchSi
 			Name: "thanos_alert_queue_alerts_popped_total",
 			Help: "Total number of alerts popped from the queue.",
 		}),
+		relabelDropped: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_alert_queue_alerts_relabel_dropped_total",
+			Help: "Total number of alerts dropped by relabeling before being pushed to the queue.",
+		}),
 	}
 	_ = promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
 		Name: "thanos_alert_queue_capacity",
@@ -213,8 +222,8 @@ func (q *Queue) Push(alerts []*Alert) {
 
 	q.pushed.Add(float64(len(alerts)))
 
-	// Attach external labels and drop excluded labels before sending.
-	// TODO(bwplotka): User proper relabelling with https://github.com/thanos-io/thanos/issues/660.
+	// Attach external labels, drop excluded labels and apply relabeling before sending.
+	relabeled := alerts[:0]
 	for _, a := range alerts {
 		lb := labels.NewBuilder(labels.Labels{})
 		for _, l := range a.Labels {
@@ -226,7 +235,18 @@ func (q *Queue) Push(alerts []*Alert) {
 		for _, l := range q.toAddLset {
 			lb.Set(l.Name, l.Value)
 		}
-		a.Labels = lb.Labels()
+		a.Labels = relabel.Process(lb.Labels(), q.relabelConfigs...)
+		if a.Labels == nil {
+			continue
+		}
+		relabeled = append(relabeled, a)
+	}
+	if d := len(alerts) - len(relabeled); d > 0 {
+		q.relabelDropped.Add(float64(d))
+	}
+	alerts = relabeled
+	if len(alerts) == 0 {
+		return
 	}
 
 	// Queue capacity should be significantly larger than a single alert
@@ -13,7 +13,9 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
 
 	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/thanos-io/thanos/pkg/testutil"
@@ -25,7 +27,7 @@ func TestQueue_Pop_all_Pushed(t *testing.T) {
 	pushes := 3
 
 	q := NewQueue(
-		nil, nil, qcapacity, batchsize, nil, nil,
+		nil, nil, qcapacity, batchsize, nil, nil, nil,
 	)
 	for i := 0; i < pushes; i++ {
 		q.Push([]*Alert{
@@ -49,6 +51,7 @@ func TestQueue_Push_Relabelled(t *testing.T) {
 		nil, nil, 10, 10,
 		labels.FromStrings("a", "1", "replica", "A"), // Labels to be added.
 		[]string{"b", "replica"},                     // Labels to be dropped (excluding those added).
+		nil,
 	)
 
 	q.Push([]*Alert{
@@ -63,6 +66,48 @@ func TestQueue_Push_Relabelled(t *testing.T) {
 	testutil.Equals(t, labels.FromStrings("a", "1"), q.queue[2].Labels)
 }
 
+func TestQueue_Push_RelabelConfigs(t *testing.T) {
+	q := NewQueue(
+		nil, nil, 10, 10,
+		labels.FromStrings("cluster", "eu", "replica", "A"),
+		[]string{"replica"},
+		[]*relabel.Config{
+			{
+				// Drop alerts with test severity.
+				SourceLabels: model.LabelNames{"severity"},
+				Regex:        relabel.MustNewRegexp("test"),
+				Action:       relabel.Drop,
+			},
+			{
+				// Route by cluster, external labels are already attached.
+				SourceLabels: model.LabelNames{"cluster"},
+				Regex:        relabel.MustNewRegexp("(.*)"),
+				TargetLabel:  "team",
+				Replacement:  "platform-$1",
+				Action:       relabel.Replace,
+			},
+		},
+	)
+
+	q.Push([]*Alert{
+		{Labels: labels.FromStrings("alertname", "A", "severity", "page")},
+		{Labels: labels.FromStrings("alertname", "B", "severity", "test")},
+	})
+
+	testutil.Equals(t, 1, len(q.queue))
+	testutil.Equals(t, labels.FromStrings("alertname", "A", "cluster", "eu", "severity", "page", "team", "platform-eu"), q.queue[0].Labels)
+
+	// Batch with all alerts dropped does not notify consumers.
+	q.Push([]*Alert{{Labels: labels.FromStrings("alertname", "B", "severity", "test")}})
+	testutil.Equals(t, 1, len(q.queue))
+	testutil.Equals(t, 1, len(q.Pop(nil)))
+	select {
+	case <-q.morec:
+		t.Fatal("unexpected notification")
+	default:
+	}
+}
+
 func assertSameHosts(t *testing.T, expected []*url.URL, found []*url.URL) {
 	testutil.Equals(t, len(expected), len(found))
 
@@ -66,7 +66,7 @@ func DefaultAlertmanagerConfig() AlertmanagerConfig {
 			FileSDConfigs:   []http_util.FileSDConfig{},
 		},
 		Timeout:    model.Duration(time.Second * 10),
-		APIVersion: APIv1,
+		APIVersion: APIv2,
 	}
 }
 
@@ -127,6 +127,6 @@ func BuildAlertmanagerConfig(address string, timeout time.Duration) (Alertmanage
 			StaticAddresses: []string{host},
 		},
 		Timeout:    model.Duration(timeout),
-		APIVersion: APIv1,
+		APIVersion: APIv2,
 	}, nil
 }
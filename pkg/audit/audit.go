@@ -0,0 +1,188 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package audit implements structured audit logs of read and write API requests.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gopkg.in/yaml.v2"
+)
+
+type SinkType string
+
+const (
+	FILE   SinkType = "FILE"
+	STDOUT SinkType = "STDOUT"
+)
+
+const (
+	DecisionAllow = "allow"
+	DecisionDeny  = "deny"
+	DecisionError = "error"
+)
+
+// Config is the configuration of audit logging.
+type Config struct {
+	Sink SinkConfig `yaml:"sink"`
+	// RedactionRules redact sensitive values from events before they are written.
+	RedactionRules []RedactionRule `yaml:"redaction_rules"`
+}
+
+// SinkConfig configures where events are written.
+type SinkConfig struct {
+	Type SinkType `yaml:"type"`
+	// Path is the file events are appended to, for FILE sinks.
+	Path string `yaml:"path"`
+}
+
+// Event is an audit event of an API request.
+type Event struct {
+	Time time.Time `json:"time"`
+	// Tenant is the tenant the request is made for, if any.
+	Tenant string `json:"tenant,omitempty"`
+	// User is the authenticated user making the request, if any.
+	User string `json:"user,omitempty"`
+	// API is the name of the requested API, e.g. "query" or "remote_write".
+	API string `json:"api"`
+	// Query is the PromQL query of query APIs.
+	Query string `json:"query,omitempty"`
+	// Matchers are the series selectors of series and label APIs.
+	Matchers []string `json:"matchers,omitempty"`
+	// Decision is the authorization decision, if the request was authorized.
+	Decision string `json:"decision,omitempty"`
+	// EnforcedMatchers are matchers added to series selectors by authorization.
+	EnforcedMatchers []string `json:"enforced_matchers,omitempty"`
+	// ResultSize is the number of series, or label names and values, returned by read APIs and the number of series
+	// written by write APIs.
+	ResultSize int `json:"result_size"`
+	// Error is the error of failed requests.
+	Error string `json:"error,omitempty"`
+}
+
+type eventContextKey struct{}
+
+// ContextWithEvent returns a context carrying the event of the request, so handlers can add details to it.
+func ContextWithEvent(ctx context.Context, e *Event) context.Context {
+	return context.WithValue(ctx, eventContextKey{}, e)
+}
+
+// EventFromContext returns the event of the request carried by the context, or nil if the request is not audited.
+func EventFromContext(ctx context.Context) *Event {
+	e, _ := ctx.Value(eventContextKey{}).(*Event)
+	return e
+}
+
+// Logger writes audit events as JSON lines to its sink.
+type Logger struct {
+	logger   log.Logger
+	redactor *redactor
+	now      func() time.Time
+
+	mtx sync.Mutex
+	w   io.Writer
+	enc *json.Encoder
+
+	events   *prometheus.CounterVec
+	failures prometheus.Counter
+}
+
+// NewLogger parses the YAML audit configuration and returns an audit logger.
+func NewLogger(logger log.Logger, reg prometheus.Registerer, conf []byte) (*Logger, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing audit config")
+	}
+
+	w, err := OpenSink(config.Sink)
+	if err != nil {
+		return nil, err
+	}
+	return newLogger(logger, reg, w, config.RedactionRules)
+}
+
+// OpenSink opens the configured sink. Sinks of FILE type are closed by CloseSink.
+func OpenSink(c SinkConfig) (io.Writer, error) {
+	switch c.Type {
+	case FILE:
+		if c.Path == "" {
+			return nil, errors.New("no path of FILE sink configured")
+		}
+		f, err := os.OpenFile(c.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, errors.Wrap(err, "open sink file")
+		}
+		return f, nil
+	case STDOUT:
+		return os.Stdout, nil
+	default:
+		return nil, errors.Errorf("sink with type %s is not supported", c.Type)
+	}
+}
+
+// CloseSink closes the sink, if it is a file.
+func CloseSink(w io.Writer) error {
+	if f, ok := w.(*os.File); ok && f != os.Stdout {
+		return f.Close()
+	}
+	return nil
+}
+
+func newLogger(logger log.Logger, reg prometheus.Registerer, w io.Writer, rules []RedactionRule) (*Logger, error) {
+	r, err := newRedactor(rules)
+	if err != nil {
+		return nil, errors.Wrap(err, "redaction rules")
+	}
+	return &Logger{
+		logger:   logger,
+		redactor: r,
+		now:      time.Now,
+		w:        w,
+		enc:      json.NewEncoder(w),
+		events: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_audit_events_total",
+			Help: "Total number of audit events written.",
+		}, []string{"api"}),
+		failures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_audit_event_write_failures_total",
+			Help: "Total number of audit events which failed to be written.",
+		}),
+	}, nil
+}
+
+// Log redacts and writes the event.
+func (l *Logger) Log(e Event) {
+	if e.Time.IsZero() {
+		e.Time = l.now()
+	}
+	l.redactor.redact(&e)
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if err := l.enc.Encode(e); err != nil {
+		l.failures.Inc()
+		level.Error(l.logger).Log("msg", "failed to write audit event", "api", e.API, "err", err)
+		return
+	}
+	l.events.WithLabelValues(e.API).Inc()
+}
+
+// Close closes the sink, if it is a file.
+func (l *Logger) Close() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return CloseSink(l.w)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestLogger_Redaction(t *testing.T) {
+	for _, tcase := range []struct {
+		name     string
+		rules    []RedactionRule
+		event    Event
+		expected Event
+	}{
+		{
+			name: "no rules",
+			event: Event{
+				Tenant:   "team-a",
+				User:     "alice",
+				API:      "query",
+				Query:    `sum(up{customer="acme"})`,
+				Matchers: []string{`{customer="acme"}`},
+			},
+			expected: Event{
+				Tenant:   "team-a",
+				User:     "alice",
+				API:      "query",
+				Query:    `sum(up{customer="acme"})`,
+				Matchers: []string{`{customer="acme"}`},
+			},
+		},
+		{
+			name:  "label values",
+			rules: []RedactionRule{{LabelNames: "customer|secret_.*"}},
+			event: Event{
+				API:              "query",
+				Query:            `sum(rate(up{customer="acme",job="a"}[5m])) / count(up{secret_id=~"1.*"})`,
+				Matchers:         []string{`up{customer!="acme"}`, `{job="a"}`},
+				EnforcedMatchers: []string{`{customer="acme"}`},
+			},
+			expected: Event{
+				API:              "query",
+				Query:            `sum(rate(up{customer="<redacted>",job="a"}[5m])) / count(up{secret_id=~"<redacted>"})`,
+				Matchers:         []string{`{customer!="<redacted>",__name__="up"}`, `{job="a"}`},
+				EnforcedMatchers: []string{`{customer="<redacted>"}`},
+			},
+		},
+		{
+			name:  "metric names",
+			rules: []RedactionRule{{LabelNames: "__name__"}},
+			event: Event{
+				API:   "query",
+				Query: `up{job="a"}`,
+			},
+			expected: Event{
+				API:   "query",
+				Query: `{__name__="<redacted>",job="a"}`,
+			},
+		},
+		{
+			name:  "invalid query",
+			rules: []RedactionRule{{LabelNames: "customer"}},
+			event: Event{
+				API:   "query",
+				Query: `up{customer="acme"`,
+			},
+			expected: Event{
+				API:   "query",
+				Query: "<redacted>",
+			},
+		},
+		{
+			name:  "fields",
+			rules: []RedactionRule{{Fields: []string{"user", "query"}}, {Fields: []string{"matchers"}}},
+			event: Event{
+				Tenant:   "team-a",
+				User:     "alice",
+				API:      "series",
+				Query:    `up`,
+				Matchers: []string{`{job="a"}`},
+			},
+			expected: Event{
+				Tenant:   "team-a",
+				User:     "<redacted>",
+				API:      "series",
+				Query:    "<redacted>",
+				Matchers: []string{"<redacted>"},
+			},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := newLogger(log.NewNopLogger(), nil, &buf, tcase.rules)
+			testutil.Ok(t, err)
+			l.now = func() time.Time { return time.Unix(1, 0).UTC() }
+
+			l.Log(tcase.event)
+
+			var got Event
+			testutil.Ok(t, json.Unmarshal(buf.Bytes(), &got))
+			tcase.expected.Time = time.Unix(1, 0).UTC()
+			testutil.Equals(t, tcase.expected, got)
+		})
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+	path := filepath.Join(dir, "audit.log")
+
+	for _, conf := range []string{
+		"sink: {type: UNKNOWN}",
+		"sink: {type: FILE}",
+		"sink: {type: STDOUT}\nredaction_rules: [{}]",
+		"sink: {type: STDOUT}\nredaction_rules: [{fields: [unknown]}]",
+		"sink: {type: STDOUT}\nredaction_rules: [{label_names: '('}]",
+	} {
+		_, err := NewLogger(log.NewNopLogger(), nil, []byte(conf))
+		testutil.NotOk(t, err)
+	}
+
+	reg := prometheus.NewRegistry()
+	l, err := NewLogger(log.NewNopLogger(), reg, []byte("sink: {type: FILE, path: "+path+"}"))
+	testutil.Ok(t, err)
+	l.Log(Event{API: "query", ResultSize: 2})
+	l.Log(Event{API: "remote_write", Error: "conflict"})
+	testutil.Ok(t, l.Close())
+	testutil.Equals(t, 1.0, promtest.ToFloat64(l.events.WithLabelValues("query")))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(l.events.WithLabelValues("remote_write")))
+
+	b, err := ioutil.ReadFile(path)
+	testutil.Ok(t, err)
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	testutil.Equals(t, 2, len(lines))
+	var e Event
+	testutil.Ok(t, json.Unmarshal(lines[1], &e))
+	testutil.Equals(t, "remote_write", e.API)
+	testutil.Equals(t, "conflict", e.Error)
+
+	// Writes to closed sinks fail.
+	l.Log(Event{API: "query"})
+	testutil.Equals(t, 1.0, promtest.ToFloat64(l.failures))
+}
+
+func TestEventFromContext(t *testing.T) {
+	testutil.Assert(t, EventFromContext(context.Background()) == nil, "expected no event")
+
+	e := &Event{API: "query"}
+	testutil.Equals(t, e, EventFromContext(ContextWithEvent(context.Background(), e)))
+}
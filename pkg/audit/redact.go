@@ -0,0 +1,159 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package audit
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+)
+
+const redactedValue = "<redacted>"
+
+const (
+	fieldTenant   = "tenant"
+	fieldUser     = "user"
+	fieldQuery    = "query"
+	fieldMatchers = "matchers"
+)
+
+// RedactionRule configures values redacted from events.
+type RedactionRule struct {
+	// LabelNames is a regular expression matching label names, whose matcher values are redacted from queries and
+	// matchers.
+	LabelNames string `yaml:"label_names"`
+	// Fields are event fields redacted entirely, any of "tenant", "user", "query" or "matchers".
+	Fields []string `yaml:"fields"`
+}
+
+type redactor struct {
+	labelNames []*regexp.Regexp
+	fields     map[string]struct{}
+}
+
+func newRedactor(rules []RedactionRule) (*redactor, error) {
+	r := &redactor{fields: map[string]struct{}{}}
+	for _, rule := range rules {
+		if rule.LabelNames == "" && len(rule.Fields) == 0 {
+			return nil, errors.New("rule without label_names or fields")
+		}
+		if rule.LabelNames != "" {
+			re, err := regexp.Compile("^(?:" + rule.LabelNames + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "compile label_names %s", rule.LabelNames)
+			}
+			r.labelNames = append(r.labelNames, re)
+		}
+		for _, f := range rule.Fields {
+			switch f {
+			case fieldTenant, fieldUser, fieldQuery, fieldMatchers:
+				r.fields[f] = struct{}{}
+			default:
+				return nil, errors.Errorf("unknown field %s", f)
+			}
+		}
+	}
+	return r, nil
+}
+
+func (r *redactor) redactField(f string) bool {
+	_, ok := r.fields[f]
+	return ok
+}
+
+func (r *redactor) redactLabel(name string) bool {
+	for _, re := range r.labelNames {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) redact(e *Event) {
+	if r.redactField(fieldTenant) && e.Tenant != "" {
+		e.Tenant = redactedValue
+	}
+	if r.redactField(fieldUser) && e.User != "" {
+		e.User = redactedValue
+	}
+	if e.Query != "" {
+		if r.redactField(fieldQuery) {
+			e.Query = redactedValue
+		} else if len(r.labelNames) > 0 {
+			e.Query = r.redactQuery(e.Query)
+		}
+	}
+	e.Matchers = r.redactSelectors(e.Matchers)
+	e.EnforcedMatchers = r.redactSelectors(e.EnforcedMatchers)
+}
+
+// redactQuery redacts matcher values of matching labels from the query. Queries which cannot be parsed are redacted
+// entirely, as their contents are unknown.
+func (r *redactor) redactQuery(q string) string {
+	expr, err := promql.ParseExpr(q)
+	if err != nil {
+		return redactedValue
+	}
+	promql.Inspect(expr, func(node promql.Node, _ []promql.Node) error {
+		switch n := node.(type) {
+		case *promql.VectorSelector:
+			n.LabelMatchers = r.redactMatchers(n.LabelMatchers)
+			if r.redactLabel(labels.MetricName) {
+				n.Name = ""
+			}
+		case *promql.MatrixSelector:
+			n.LabelMatchers = r.redactMatchers(n.LabelMatchers)
+			if r.redactLabel(labels.MetricName) {
+				n.Name = ""
+			}
+		}
+		return nil
+	})
+	return expr.String()
+}
+
+func (r *redactor) redactSelectors(selectors []string) []string {
+	if len(selectors) == 0 || (!r.redactField(fieldMatchers) && len(r.labelNames) == 0) {
+		return selectors
+	}
+	redacted := make([]string, 0, len(selectors))
+	for _, s := range selectors {
+		if r.redactField(fieldMatchers) {
+			redacted = append(redacted, redactedValue)
+			continue
+		}
+		ms, err := promql.ParseMetricSelector(s)
+		if err != nil {
+			redacted = append(redacted, redactedValue)
+			continue
+		}
+		redacted = append(redacted, FormatMatchers(r.redactMatchers(ms)))
+	}
+	return redacted
+}
+
+func (r *redactor) redactMatchers(ms []*labels.Matcher) []*labels.Matcher {
+	redacted := make([]*labels.Matcher, 0, len(ms))
+	for _, m := range ms {
+		if r.redactLabel(m.Name) {
+			// The redacted value is a valid regular expression, so creating the matcher cannot fail.
+			m = labels.MustNewMatcher(m.Type, m.Name, redactedValue)
+		}
+		redacted = append(redacted, m)
+	}
+	return redacted
+}
+
+// FormatMatchers returns the matchers as series selector.
+func FormatMatchers(ms []*labels.Matcher) string {
+	s := make([]string, 0, len(ms))
+	for _, m := range ms {
+		s = append(s, m.String())
+	}
+	return "{" + strings.Join(s, ",") + "}"
+}
@@ -0,0 +1,164 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package authz implements authorization of read requests by tenant, API and series matchers.
+package authz
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"gopkg.in/yaml.v2"
+)
+
+type AuthorizerType string
+
+const (
+	STATIC  AuthorizerType = "STATIC"
+	WEBHOOK AuthorizerType = "WEBHOOK"
+)
+
+const (
+	decisionAllow = "allow"
+	decisionDeny  = "deny"
+	decisionError = "error"
+)
+
+// AuthorizerConfig is the configuration of an authorizer.
+type AuthorizerConfig struct {
+	Type   AuthorizerType `yaml:"type"`
+	Config interface{}    `yaml:"config"`
+}
+
+// Request is a read request to be authorized.
+type Request struct {
+	// Tenant is the tenant the request is made for, if any.
+	Tenant string `json:"tenant"`
+	// User is the authenticated user making the request, if any.
+	User string `json:"user"`
+	// API is the name of the requested API, e.g. "query" or "series".
+	API string `json:"api"`
+	// Matchers are the matchers of all series selectors of the request.
+	Matchers [][]Matcher `json:"matchers"`
+}
+
+// Matcher is a label matcher of a series selector.
+type Matcher struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// NewRequest returns a request with the given matchers of series selectors.
+func NewRequest(tenant, user, api string, matcherSets [][]*labels.Matcher) Request {
+	r := Request{Tenant: tenant, User: user, API: api, Matchers: make([][]Matcher, 0, len(matcherSets))}
+	for _, ms := range matcherSets {
+		set := make([]Matcher, 0, len(ms))
+		for _, m := range ms {
+			set = append(set, Matcher{Name: m.Name, Type: m.Type.String(), Value: m.Value})
+		}
+		r.Matchers = append(r.Matchers, set)
+	}
+	return r
+}
+
+// Decision is the result of authorization of a request.
+type Decision struct {
+	// Allow is true if the request is allowed.
+	Allow bool
+	// Reason explains the decision.
+	Reason string
+	// Matchers have to be added to all series selectors of an allowed request, limiting which series it reads.
+	Matchers []*labels.Matcher
+}
+
+// Authorizer authorizes read requests.
+type Authorizer interface {
+	Authorize(ctx context.Context, r Request) (Decision, error)
+}
+
+// NewAuthorizer creates an authorizer from the YAML configuration.
+func NewAuthorizer(logger log.Logger, reg prometheus.Registerer, confContentYaml []byte) (Authorizer, error) {
+	level.Info(logger).Log("msg", "loading authorization configuration")
+	authzConfig := &AuthorizerConfig{}
+	if err := yaml.UnmarshalStrict(confContentYaml, authzConfig); err != nil {
+		return nil, errors.Wrap(err, "parsing config authorization YAML")
+	}
+
+	var config []byte
+	var err error
+	if authzConfig.Config != nil {
+		config, err = yaml.Marshal(authzConfig.Config)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal content of authorization configuration")
+		}
+	}
+
+	var a Authorizer
+	switch strings.ToUpper(string(authzConfig.Type)) {
+	case string(STATIC):
+		a, err = NewStaticAuthorizer(config)
+	case string(WEBHOOK):
+		a, err = NewWebhookAuthorizer(config)
+	default:
+		return nil, errors.Errorf("authorization with type %s is not supported", authzConfig.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "create %s authorizer", authzConfig.Type)
+	}
+	return NewInstrumentedAuthorizer(logger, reg, a), nil
+}
+
+type instrumentedAuthorizer struct {
+	logger    log.Logger
+	authz     Authorizer
+	decisions *prometheus.CounterVec
+}
+
+// NewInstrumentedAuthorizer returns an authorizer counting and logging decisions of the given one.
+func NewInstrumentedAuthorizer(logger log.Logger, reg prometheus.Registerer, a Authorizer) Authorizer {
+	return &instrumentedAuthorizer{
+		logger: logger,
+		authz:  a,
+		decisions: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_authorization_decisions_total",
+			Help: "Total number of authorization decisions of requests by API and decision.",
+		}, []string{"api", "decision"}),
+	}
+}
+
+func (a *instrumentedAuthorizer) Authorize(ctx context.Context, r Request) (Decision, error) {
+	d, err := a.authz.Authorize(ctx, r)
+	if err != nil {
+		a.decisions.WithLabelValues(r.API, decisionError).Inc()
+		level.Warn(a.logger).Log("msg", "authorization failed", "tenant", r.Tenant, "user", r.User, "api", r.API, "err", err)
+		return Decision{}, err
+	}
+	if !d.Allow {
+		a.decisions.WithLabelValues(r.API, decisionDeny).Inc()
+		level.Debug(a.logger).Log("msg", "request denied", "tenant", r.Tenant, "user", r.User, "api", r.API, "reason", d.Reason)
+		return d, nil
+	}
+	a.decisions.WithLabelValues(r.API, decisionAllow).Inc()
+	return d, nil
+}
+
+// parseMatchers parses matchers in the PromQL format, e.g. `namespace="a"`.
+func parseMatchers(ms []string) ([]*labels.Matcher, error) {
+	var res []*labels.Matcher
+	for _, m := range ms {
+		parsed, err := promql.ParseMetricSelector("{" + m + "}")
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse matcher %s", m)
+		}
+		res = append(res, parsed...)
+	}
+	return res, nil
+}
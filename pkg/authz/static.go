@@ -0,0 +1,96 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package authz
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"gopkg.in/yaml.v2"
+)
+
+// StaticConfig is the configuration of the static authorizer.
+type StaticConfig struct {
+	// Tenants are policies of tenants by tenant name.
+	Tenants map[string]StaticTenantPolicy `yaml:"tenants"`
+	// AllowUnknownTenants allows requests of tenants without policy, including requests without tenant, unrestricted.
+	AllowUnknownTenants bool `yaml:"allow_unknown_tenants"`
+}
+
+// StaticTenantPolicy is the policy of requests of a tenant.
+type StaticTenantPolicy struct {
+	// Users are the authenticated users allowed to make requests for the tenant. All users if empty.
+	Users []string `yaml:"users"`
+	// APIs are the APIs the tenant is allowed to request. All APIs if empty.
+	APIs []string `yaml:"apis"`
+	// Matchers are added to all series selectors of requests of the tenant, e.g. `namespace="a"`.
+	Matchers []string `yaml:"matchers"`
+}
+
+type staticTenantPolicy struct {
+	users    map[string]struct{}
+	apis     map[string]struct{}
+	matchers []*labels.Matcher
+}
+
+// StaticAuthorizer authorizes requests by policies of tenants in its configuration.
+type StaticAuthorizer struct {
+	tenants             map[string]staticTenantPolicy
+	allowUnknownTenants bool
+}
+
+// NewStaticAuthorizer creates a static authorizer from the YAML configuration.
+func NewStaticAuthorizer(conf []byte) (*StaticAuthorizer, error) {
+	var config StaticConfig
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing static authorizer config")
+	}
+
+	a := &StaticAuthorizer{
+		tenants:             make(map[string]staticTenantPolicy, len(config.Tenants)),
+		allowUnknownTenants: config.AllowUnknownTenants,
+	}
+	for tenant, p := range config.Tenants {
+		matchers, err := parseMatchers(p.Matchers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "policy of tenant %s", tenant)
+		}
+		a.tenants[tenant] = staticTenantPolicy{users: toSet(p.Users), apis: toSet(p.APIs), matchers: matchers}
+	}
+	return a, nil
+}
+
+func toSet(ss []string) map[string]struct{} {
+	if len(ss) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(ss))
+	for _, s := range ss {
+		set[s] = struct{}{}
+	}
+	return set
+}
+
+// Authorize authorizes the request by the policy of its tenant.
+func (a *StaticAuthorizer) Authorize(_ context.Context, r Request) (Decision, error) {
+	p, ok := a.tenants[r.Tenant]
+	if !ok {
+		if a.allowUnknownTenants {
+			return Decision{Allow: true}, nil
+		}
+		return Decision{Reason: "no policy for tenant " + r.Tenant}, nil
+	}
+	if p.users != nil {
+		if _, ok := p.users[r.User]; !ok {
+			return Decision{Reason: "user " + r.User + " not allowed for tenant " + r.Tenant}, nil
+		}
+	}
+	if p.apis != nil {
+		if _, ok := p.apis[r.API]; !ok {
+			return Decision{Reason: "API " + r.API + " not allowed for tenant " + r.Tenant}, nil
+		}
+	}
+	return Decision{Allow: true, Matchers: p.matchers}, nil
+}
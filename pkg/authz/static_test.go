@@ -0,0 +1,154 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package authz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestNewAuthorizer(t *testing.T) {
+	for _, tcase := range []struct {
+		name string
+		conf string
+		err  bool
+	}{
+		{
+			name: "static",
+			conf: `
+type: static
+config:
+  tenants:
+    team-a:
+      matchers: ['namespace="a"']
+`,
+		},
+		{
+			name: "webhook",
+			conf: `
+type: WEBHOOK
+config:
+  url: http://opa:8181/v1/data/thanos/authz
+  timeout: 1s
+`,
+		},
+		{
+			name: "webhook without url",
+			conf: `
+type: WEBHOOK
+config:
+  timeout: 1s
+`,
+			err: true,
+		},
+		{
+			name: "invalid matcher",
+			conf: `
+type: STATIC
+config:
+  tenants:
+    team-a:
+      matchers: ['namespace']
+`,
+			err: true,
+		},
+		{
+			name: "unknown type",
+			conf: `type: OPA`,
+			err:  true,
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := NewAuthorizer(log.NewNopLogger(), nil, []byte(tcase.conf))
+			if tcase.err {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+		})
+	}
+}
+
+func TestStaticAuthorizer(t *testing.T) {
+	a, err := NewStaticAuthorizer([]byte(`
+tenants:
+  team-a:
+    matchers: ['namespace="a"', 'cluster=~"eu-.*"']
+  team-b:
+    users: [alice]
+    apis: [query, query_range]
+`))
+	testutil.Ok(t, err)
+
+	for _, tcase := range []struct {
+		name     string
+		req      Request
+		expected Decision
+	}{
+		{
+			name: "tenant with matchers",
+			req:  Request{Tenant: "team-a", API: "series"},
+			expected: Decision{Allow: true, Matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "namespace", "a"),
+				labels.MustNewMatcher(labels.MatchRegexp, "cluster", "eu-.*"),
+			}},
+		},
+		{
+			name:     "allowed user and API",
+			req:      Request{Tenant: "team-b", User: "alice", API: "query"},
+			expected: Decision{Allow: true},
+		},
+		{
+			name:     "user not allowed",
+			req:      Request{Tenant: "team-b", User: "bob", API: "query"},
+			expected: Decision{Reason: "user bob not allowed for tenant team-b"},
+		},
+		{
+			name:     "API not allowed",
+			req:      Request{Tenant: "team-b", User: "alice", API: "series"},
+			expected: Decision{Reason: "API series not allowed for tenant team-b"},
+		},
+		{
+			name:     "unknown tenant",
+			req:      Request{Tenant: "team-c", API: "query"},
+			expected: Decision{Reason: "no policy for tenant team-c"},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			d, err := a.Authorize(context.Background(), tcase.req)
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.expected.Allow, d.Allow)
+			testutil.Equals(t, tcase.expected.Reason, d.Reason)
+			testutil.Equals(t, len(tcase.expected.Matchers), len(d.Matchers))
+			for i := range tcase.expected.Matchers {
+				testutil.Equals(t, tcase.expected.Matchers[i].String(), d.Matchers[i].String())
+			}
+		})
+	}
+
+	a, err = NewStaticAuthorizer([]byte(`allow_unknown_tenants: true`))
+	testutil.Ok(t, err)
+	d, err := a.Authorize(context.Background(), Request{API: "query"})
+	testutil.Ok(t, err)
+	testutil.Equals(t, Decision{Allow: true}, d)
+}
+
+func TestInstrumentedAuthorizer(t *testing.T) {
+	static, err := NewStaticAuthorizer([]byte("tenants:\n  team-a: {}"))
+	testutil.Ok(t, err)
+	a := NewInstrumentedAuthorizer(log.NewNopLogger(), prometheus.NewRegistry(), static).(*instrumentedAuthorizer)
+
+	for _, tenant := range []string{"team-a", "team-a", "team-b"} {
+		_, err := a.Authorize(context.Background(), Request{Tenant: tenant, API: "query"})
+		testutil.Ok(t, err)
+	}
+	testutil.Equals(t, 2.0, promtest.ToFloat64(a.decisions.WithLabelValues("query", decisionAllow)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(a.decisions.WithLabelValues("query", decisionDeny)))
+}
@@ -0,0 +1,111 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package authz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"gopkg.in/yaml.v2"
+)
+
+// WebhookConfig is the configuration of the webhook authorizer.
+type WebhookConfig struct {
+	// URL is the URL requests to authorize are posted to.
+	URL string `yaml:"url"`
+	// Timeout is the timeout of requests to the webhook.
+	Timeout model.Duration `yaml:"timeout"`
+}
+
+var defaultWebhookConfig = WebhookConfig{
+	Timeout: model.Duration(5 * time.Second),
+}
+
+// WebhookAuthorizer authorizes requests by posting them to an external webhook. Requests and responses follow the
+// format of the Open Policy Agent data API, so it can be pointed at an OPA policy decision, e.g.
+// http://opa:8181/v1/data/thanos/authz.
+type WebhookAuthorizer struct {
+	url    string
+	client *http.Client
+}
+
+type webhookRequest struct {
+	Input Request `json:"input"`
+}
+
+type webhookResponse struct {
+	Result *webhookDecision `json:"result"`
+}
+
+type webhookDecision struct {
+	Allow    bool     `json:"allow"`
+	Reason   string   `json:"reason"`
+	Matchers []string `json:"matchers"`
+}
+
+// NewWebhookAuthorizer creates a webhook authorizer from the YAML configuration.
+func NewWebhookAuthorizer(conf []byte) (*WebhookAuthorizer, error) {
+	config := defaultWebhookConfig
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing webhook authorizer config")
+	}
+	if config.URL == "" {
+		return nil, errors.New("no webhook URL configured")
+	}
+	return &WebhookAuthorizer{
+		url:    config.URL,
+		client: &http.Client{Timeout: time.Duration(config.Timeout)},
+	}, nil
+}
+
+// Authorize posts the request to the webhook and returns its decision.
+func (a *WebhookAuthorizer) Authorize(ctx context.Context, r Request) (_ Decision, err error) {
+	b, err := json.Marshal(webhookRequest{Input: r})
+	if err != nil {
+		return Decision{}, errors.Wrap(err, "encode request")
+	}
+	req, err := http.NewRequest(http.MethodPost, a.url, bytes.NewReader(b))
+	if err != nil {
+		return Decision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return Decision{}, errors.Wrap(err, "request webhook")
+	}
+	defer runutil.CloseWithErrCapture(&err, resp.Body, "close webhook response body")
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Decision{}, errors.Wrap(err, "read webhook response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, errors.Errorf("unexpected webhook response status %s", resp.Status)
+	}
+
+	var wr webhookResponse
+	if err := json.Unmarshal(body, &wr); err != nil {
+		return Decision{}, errors.Wrap(err, "decode webhook response")
+	}
+	// OPA returns no result if the policy decision is undefined, deny such requests.
+	if wr.Result == nil {
+		return Decision{Reason: "no decision"}, nil
+	}
+	if !wr.Result.Allow {
+		return Decision{Reason: wr.Result.Reason}, nil
+	}
+	matchers, err := parseMatchers(wr.Result.Matchers)
+	if err != nil {
+		return Decision{}, errors.Wrap(err, "webhook decision")
+	}
+	return Decision{Allow: true, Reason: wr.Result.Reason, Matchers: matchers}, nil
+}
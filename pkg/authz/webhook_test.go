@@ -0,0 +1,66 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestWebhookAuthorizer(t *testing.T) {
+	var received webhookRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, http.MethodPost, r.Method)
+		testutil.Ok(t, json.NewDecoder(r.Body).Decode(&received))
+
+		switch received.Input.Tenant {
+		case "team-a":
+			_, _ = w.Write([]byte(`{"result": {"allow": true, "matchers": ["namespace=\"a\""]}}`))
+		case "team-b":
+			_, _ = w.Write([]byte(`{"result": {"allow": false, "reason": "not allowed"}}`))
+		case "undefined":
+			_, _ = w.Write([]byte(`{}`))
+		case "invalid":
+			_, _ = w.Write([]byte(`{"result": {"allow": true, "matchers": ["namespace"]}}`))
+		default:
+			http.Error(w, "error", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	a, err := NewWebhookAuthorizer([]byte("url: " + srv.URL))
+	testutil.Ok(t, err)
+	ctx := context.Background()
+
+	d, err := a.Authorize(ctx, NewRequest("team-a", "alice", "query", [][]*labels.Matcher{
+		{labels.MustNewMatcher(labels.MatchEqual, "__name__", "up"), labels.MustNewMatcher(labels.MatchNotRegexp, "job", "a|b")},
+	}))
+	testutil.Ok(t, err)
+	testutil.Equals(t, Request{Tenant: "team-a", User: "alice", API: "query", Matchers: [][]Matcher{
+		{{Name: "__name__", Type: "=", Value: "up"}, {Name: "job", Type: "!~", Value: "a|b"}},
+	}}, received.Input)
+	testutil.Assert(t, d.Allow, "expected request to be allowed")
+	testutil.Equals(t, 1, len(d.Matchers))
+	testutil.Equals(t, `namespace="a"`, d.Matchers[0].String())
+
+	d, err = a.Authorize(ctx, Request{Tenant: "team-b"})
+	testutil.Ok(t, err)
+	testutil.Equals(t, Decision{Reason: "not allowed"}, d)
+
+	// Undefined decisions deny requests.
+	d, err = a.Authorize(ctx, Request{Tenant: "undefined"})
+	testutil.Ok(t, err)
+	testutil.Equals(t, Decision{Reason: "no decision"}, d)
+
+	_, err = a.Authorize(ctx, Request{Tenant: "invalid"})
+	testutil.NotOk(t, err)
+	_, err = a.Authorize(ctx, Request{Tenant: "error"})
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// IndexCardinality describes cardinality of labels in a block index.
+type IndexCardinality struct {
+	Series int `json:"series"`
+	// LabelNames are sorted by number of values, descending.
+	LabelNames []LabelNameCardinality `json:"labelNames"`
+	// LabelPairs are sorted by number of series, descending.
+	LabelPairs []LabelPairCardinality `json:"labelPairs"`
+}
+
+// LabelNameCardinality is the number of values of the label and series with the label.
+type LabelNameCardinality struct {
+	Name   string `json:"name"`
+	Values int    `json:"values"`
+	Series int    `json:"series"`
+}
+
+// LabelPairCardinality is the number of series with the label pair.
+type LabelPairCardinality struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Series int    `json:"series"`
+}
+
+// SeriesSet is a set of hashes of series label sets, used to compare series of blocks.
+type SeriesSet map[uint64]struct{}
+
+// Churn returns the number of series in s which are not in prev, and the other way around.
+func (s SeriesSet) Churn(prev SeriesSet) (added, removed int) {
+	for h := range s {
+		if _, ok := prev[h]; !ok {
+			added++
+		}
+	}
+	for h := range prev {
+		if _, ok := s[h]; !ok {
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// AnalyzeIndex reads all series of the index file and returns cardinality of its labels, with up to limit top label
+// names and label pairs (all if limit is not positive), and the set of its series.
+func AnalyzeIndex(fn string, limit int) (c IndexCardinality, series SeriesSet, err error) {
+	r, err := index.NewFileReader(fn)
+	if err != nil {
+		return c, nil, errors.Wrap(err, "open index file")
+	}
+	defer runutil.CloseWithErrCapture(&err, r, "analyze index file reader")
+
+	p, err := r.Postings(index.AllPostingsKey())
+	if err != nil {
+		return c, nil, errors.Wrap(err, "get all postings")
+	}
+
+	var (
+		lset  labels.Labels
+		chks  []chunks.Meta
+		pairs = map[string]map[string]int{}
+	)
+	series = SeriesSet{}
+	for p.Next() {
+		if err := r.Series(p.At(), &lset, &chks); err != nil {
+			return c, nil, errors.Wrap(err, "read series")
+		}
+		series[lset.Hash()] = struct{}{}
+		for _, l := range lset {
+			values, ok := pairs[l.Name]
+			if !ok {
+				values = map[string]int{}
+				pairs[l.Name] = values
+			}
+			values[l.Value]++
+		}
+	}
+	if p.Err() != nil {
+		return c, nil, errors.Wrap(p.Err(), "iterate postings")
+	}
+
+	c.Series = len(series)
+	for name, values := range pairs {
+		n := LabelNameCardinality{Name: name, Values: len(values)}
+		for value, s := range values {
+			n.Series += s
+			c.LabelPairs = append(c.LabelPairs, LabelPairCardinality{Name: name, Value: value, Series: s})
+		}
+		c.LabelNames = append(c.LabelNames, n)
+	}
+
+	sort.Slice(c.LabelNames, func(i, j int) bool {
+		if c.LabelNames[i].Values != c.LabelNames[j].Values {
+			return c.LabelNames[i].Values > c.LabelNames[j].Values
+		}
+		return c.LabelNames[i].Name < c.LabelNames[j].Name
+	})
+	sort.Slice(c.LabelPairs, func(i, j int) bool {
+		if c.LabelPairs[i].Series != c.LabelPairs[j].Series {
+			return c.LabelPairs[i].Series > c.LabelPairs[j].Series
+		}
+		if c.LabelPairs[i].Name != c.LabelPairs[j].Name {
+			return c.LabelPairs[i].Name < c.LabelPairs[j].Name
+		}
+		return c.LabelPairs[i].Value < c.LabelPairs[j].Value
+	})
+	if limit > 0 {
+		if len(c.LabelNames) > limit {
+			c.LabelNames = c.LabelNames[:limit]
+		}
+		if len(c.LabelPairs) > limit {
+			c.LabelPairs = c.LabelPairs[:limit]
+		}
+	}
+	return c, series, nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+)
+
+func TestAnalyzeIndex(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-block-analyze")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	b1, err := e2eutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		labels.FromStrings("__name__", "up", "job", "a", "instance", "1"),
+		labels.FromStrings("__name__", "up", "job", "a", "instance", "2"),
+		labels.FromStrings("__name__", "up", "job", "b", "instance", "1"),
+	}, 10, 0, 1000, labels.Labels{{Name: "ext1", Value: "val1"}}, 124)
+	testutil.Ok(t, err)
+	b2, err := e2eutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		labels.FromStrings("__name__", "up", "job", "a", "instance", "2"),
+		labels.FromStrings("__name__", "up", "job", "b", "instance", "1"),
+		labels.FromStrings("__name__", "up", "job", "b", "instance", "2"),
+		labels.FromStrings("__name__", "up", "job", "c", "instance", "1"),
+	}, 10, 1000, 2000, labels.Labels{{Name: "ext1", Value: "val1"}}, 124)
+	testutil.Ok(t, err)
+
+	c1, s1, err := AnalyzeIndex(filepath.Join(tmpDir, b1.String(), IndexFilename), 0)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 3, c1.Series)
+	testutil.Equals(t, []LabelNameCardinality{
+		{Name: "instance", Values: 2, Series: 3},
+		{Name: "job", Values: 2, Series: 3},
+		{Name: "__name__", Values: 1, Series: 3},
+	}, c1.LabelNames)
+	testutil.Equals(t, []LabelPairCardinality{
+		{Name: "__name__", Value: "up", Series: 3},
+		{Name: "instance", Value: "1", Series: 2},
+		{Name: "job", Value: "a", Series: 2},
+		{Name: "instance", Value: "2", Series: 1},
+		{Name: "job", Value: "b", Series: 1},
+	}, c1.LabelPairs)
+
+	c2, s2, err := AnalyzeIndex(filepath.Join(tmpDir, b2.String(), IndexFilename), 2)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 4, c2.Series)
+	testutil.Equals(t, []LabelNameCardinality{
+		{Name: "job", Values: 3, Series: 4},
+		{Name: "instance", Values: 2, Series: 4},
+	}, c2.LabelNames)
+	testutil.Equals(t, 2, len(c2.LabelPairs))
+
+	added, removed := s2.Churn(s1)
+	testutil.Equals(t, 2, added)
+	testutil.Equals(t, 1, removed)
+}
@@ -10,6 +10,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -60,12 +62,81 @@ func Download(ctx context.Context, logger log.Logger, bucket objstore.Bucket, id
 		return errors.Wrapf(err, "stat %s", chunksDir)
 	}
 
+	meta, err := metadata.Read(dst)
+	if err != nil {
+		return errors.Wrap(err, "read meta")
+	}
+	return VerifyFiles(dst, meta.Thanos.Files)
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// gatherFileStats returns sizes and checksums of the block files uploaded by Upload.
+func gatherFileStats(bdir string, withIndexCache bool) ([]metadata.File, error) {
+	fis, err := ioutil.ReadDir(filepath.Join(bdir, ChunksDirname))
+	if err != nil {
+		return nil, err
+	}
+	var relPaths []string
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		relPaths = append(relPaths, path.Join(ChunksDirname, fi.Name()))
+	}
+	relPaths = append(relPaths, IndexFilename)
+	if withIndexCache {
+		relPaths = append(relPaths, IndexCacheFilename)
+	}
+
+	files := make([]metadata.File, 0, len(relPaths))
+	for _, rel := range relPaths {
+		size, sum, err := fileChecksum(filepath.Join(bdir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, metadata.File{RelPath: rel, SizeBytes: size, CRC32C: sum})
+	}
+	return files, nil
+}
+
+func fileChecksum(fn string) (size int64, sum string, err error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return 0, "", err
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close file")
+
+	h := crc32.New(castagnoliTable)
+	if size, err = io.Copy(h, f); err != nil {
+		return 0, "", errors.Wrapf(err, "read %s", fn)
+	}
+	return size, fmt.Sprintf("%08x", h.Sum32()), nil
+}
+
+// VerifyFiles checks that files of the block in the given directory match the sizes and checksums from the meta.
+func VerifyFiles(bdir string, files []metadata.File) error {
+	for _, f := range files {
+		fn := filepath.Join(bdir, filepath.FromSlash(f.RelPath))
+		size, sum, err := fileChecksum(fn)
+		if err != nil {
+			return errors.Wrapf(err, "verify %s", f.RelPath)
+		}
+		if size != f.SizeBytes {
+			return errors.Errorf("block file %s is corrupted: size %d does not match expected %d", fn, size, f.SizeBytes)
+		}
+		if sum != f.CRC32C {
+			return errors.Errorf("block file %s is corrupted: CRC32C checksum %s does not match expected %s", fn, sum, f.CRC32C)
+		}
+	}
 	return nil
 }
 
 // Upload uploads block from given block dir that ends with block id.
 // It makes sure cleanup is done on error to avoid partial block uploads.
 // It also verifies basic features of Thanos block.
+// The uploaded meta.json records sizes and checksums of the block files in its files section, while the local meta.json
+// is left unchanged, so the two differ in that section.
 // TODO(bplotka): Ensure bucket operations have reasonable backoff retries.
 func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, bdir string) error {
 	df, err := os.Stat(bdir)
@@ -96,6 +167,18 @@ func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, bdir st
 		return errors.Wrap(err, "upload meta file to debug dir")
 	}
 
+	// Checksums are recorded in the uploaded meta.json, so corrupted files are detected on download.
+	meta.Thanos.Files, err = gatherFileStats(bdir, meta.Thanos.Source == metadata.CompactorSource)
+	if err != nil {
+		return errors.Wrap(err, "gather block file stats")
+	}
+	metaEncoded := bytes.Buffer{}
+	enc := json.NewEncoder(&metaEncoded)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(meta); err != nil {
+		return errors.Wrap(err, "encode meta file")
+	}
+
 	if err := objstore.UploadDir(ctx, logger, bkt, path.Join(bdir, ChunksDirname), path.Join(id.String(), ChunksDirname)); err != nil {
 		return cleanUp(logger, bkt, id, errors.Wrap(err, "upload chunks"))
 	}
@@ -112,7 +195,7 @@ func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, bdir st
 
 	// Meta.json always need to be uploaded as a last item. This will allow to assume block directories without meta file
 	// to be pending uploads.
-	if err := objstore.UploadFile(ctx, logger, bkt, path.Join(bdir, MetaFilename), path.Join(id.String(), MetaFilename)); err != nil {
+	if err := bkt.Upload(ctx, path.Join(id.String(), MetaFilename), &metaEncoded); err != nil {
 		return cleanUp(logger, bkt, id, errors.Wrap(err, "upload meta file"))
 	}
 
@@ -156,6 +239,53 @@ func MarkForDeletion(ctx context.Context, logger log.Logger, bkt objstore.Bucket
 	return nil
 }
 
+// MarkForNoCompact creates a file which stores information about why the block should be excluded from compaction.
+// An existing mark is replaced.
+func MarkForNoCompact(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, reason metadata.NoCompactReason, details string) error {
+	markFile := path.Join(id.String(), metadata.NoCompactMarkFilename)
+
+	mark, err := json.Marshal(metadata.NoCompactMark{
+		ID:            id,
+		NoCompactTime: time.Now().Unix(),
+		Reason:        reason,
+		Details:       details,
+		Version:       metadata.NoCompactMarkVersion1,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encode no-compact mark")
+	}
+
+	if err := bkt.Upload(ctx, markFile, bytes.NewBuffer(mark)); err != nil {
+		return errors.Wrapf(err, "upload file %s to bucket", markFile)
+	}
+
+	level.Info(logger).Log("msg", "block has been marked for no compaction", "block", id, "reason", reason)
+	return nil
+}
+
+// MarkForNoDownsample creates a file which stores information about why the block should be excluded from downsampling.
+// An existing mark is replaced.
+func MarkForNoDownsample(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID, details string) error {
+	markFile := path.Join(id.String(), metadata.NoDownsampleMarkFilename)
+
+	mark, err := json.Marshal(metadata.NoDownsampleMark{
+		ID:               id,
+		NoDownsampleTime: time.Now().Unix(),
+		Details:          details,
+		Version:          metadata.NoDownsampleMarkVersion1,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encode no-downsample mark")
+	}
+
+	if err := bkt.Upload(ctx, markFile, bytes.NewBuffer(mark)); err != nil {
+		return errors.Wrapf(err, "upload file %s to bucket", markFile)
+	}
+
+	level.Info(logger).Log("msg", "block has been marked for no downsampling", "block", id)
+	return nil
+}
+
 // Delete removes directory that is meant to be block directory.
 // NOTE: Always prefer this method for deleting blocks.
 //  * We have to delete block's files in the certain order (meta.json first)
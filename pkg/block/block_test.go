@@ -143,21 +143,28 @@ func TestUpload(t *testing.T) {
 		testutil.Equals(t, 1, len(bkt.Objects()))
 	}
 	e2eutil.Copy(t, path.Join(tmpDir, b1.String(), MetaFilename), path.Join(tmpDir, "test", b1.String(), MetaFilename))
+	// Samples of the block are random, so sizes of its chunks vary.
+	expectedFiles := []metadata.File{
+		localFile(t, path.Join(tmpDir, b1.String()), path.Join(ChunksDirname, "000001")),
+		localFile(t, path.Join(tmpDir, b1.String()), IndexFilename),
+	}
 	{
 		// Full block.
 		testutil.Ok(t, Upload(ctx, log.NewNopLogger(), bkt, path.Join(tmpDir, "test", b1.String())))
 		testutil.Equals(t, 4, len(bkt.Objects()))
-		testutil.Equals(t, 3751, len(bkt.Objects()[path.Join(b1.String(), ChunksDirname, "000001")]))
+		testutil.Equals(t, int(expectedFiles[0].SizeBytes), len(bkt.Objects()[path.Join(b1.String(), ChunksDirname, "000001")]))
 		testutil.Equals(t, 401, len(bkt.Objects()[path.Join(b1.String(), IndexFilename)]))
-		testutil.Equals(t, 365, len(bkt.Objects()[path.Join(b1.String(), MetaFilename)]))
+		testutil.Equals(t, expectedFiles, uploadedFiles(t, bkt, b1))
+		// Debug meta.json is the local one, without files.
+		testutil.Equals(t, 365, len(bkt.Objects()[path.Join(DebugMetas, fmt.Sprintf("%s.json", b1.String()))]))
 	}
 	{
 		// Test Upload is idempotent.
 		testutil.Ok(t, Upload(ctx, log.NewNopLogger(), bkt, path.Join(tmpDir, "test", b1.String())))
 		testutil.Equals(t, 4, len(bkt.Objects()))
-		testutil.Equals(t, 3751, len(bkt.Objects()[path.Join(b1.String(), ChunksDirname, "000001")]))
+		testutil.Equals(t, int(expectedFiles[0].SizeBytes), len(bkt.Objects()[path.Join(b1.String(), ChunksDirname, "000001")]))
 		testutil.Equals(t, 401, len(bkt.Objects()[path.Join(b1.String(), IndexFilename)]))
-		testutil.Equals(t, 365, len(bkt.Objects()[path.Join(b1.String(), MetaFilename)]))
+		testutil.Equals(t, expectedFiles, uploadedFiles(t, bkt, b1))
 	}
 	{
 		// Upload with no external labels should be blocked.
@@ -176,6 +183,26 @@ func TestUpload(t *testing.T) {
 	}
 }
 
+// uploadedFiles returns files recorded in the uploaded meta.json of the block, checking the rest of the meta matches
+// the local one.
+func uploadedFiles(t *testing.T, bkt *inmem.Bucket, id ulid.ULID) []metadata.File {
+	m := &metadata.Meta{}
+	testutil.Ok(t, json.Unmarshal(bkt.Objects()[path.Join(id.String(), MetaFilename)], m))
+
+	local := &metadata.Meta{}
+	testutil.Ok(t, json.Unmarshal(bkt.Objects()[path.Join(DebugMetas, fmt.Sprintf("%s.json", id.String()))], local))
+	files := m.Thanos.Files
+	m.Thanos.Files = nil
+	testutil.Equals(t, local, m)
+	return files
+}
+
+func localFile(t *testing.T, bdir, rel string) metadata.File {
+	size, sum, err := fileChecksum(path.Join(bdir, rel))
+	testutil.Ok(t, err)
+	return metadata.File{RelPath: rel, SizeBytes: size, CRC32C: sum}
+}
+
 func TestDelete(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -223,6 +250,49 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestDownload_VerifiesChecksums(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-block-download")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	bkt := inmem.NewBucket()
+	b1, err := e2eutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		{{Name: "a", Value: "1"}},
+		{{Name: "a", Value: "2"}},
+		{{Name: "b", Value: "1"}},
+	}, 100, 0, 1000, labels.Labels{{Name: "ext1", Value: "val1"}}, 124)
+	testutil.Ok(t, err)
+	testutil.Ok(t, Upload(ctx, log.NewNopLogger(), bkt, path.Join(tmpDir, b1.String())))
+
+	rc, err := bkt.Get(ctx, path.Join(b1.String(), MetaFilename))
+	testutil.Ok(t, err)
+	m := &metadata.Meta{}
+	testutil.Ok(t, json.NewDecoder(rc).Decode(m))
+	testutil.Ok(t, rc.Close())
+	testutil.Equals(t, 2, len(m.Thanos.Files))
+	testutil.Equals(t, path.Join(ChunksDirname, "000001"), m.Thanos.Files[0].RelPath)
+	testutil.Equals(t, IndexFilename, m.Thanos.Files[1].RelPath)
+
+	testutil.Ok(t, Download(ctx, log.NewNopLogger(), bkt, b1, path.Join(tmpDir, "ok")))
+
+	// Flip a byte of the uploaded index, keeping the size.
+	index := append([]byte{}, bkt.Objects()[path.Join(b1.String(), IndexFilename)]...)
+	index[len(index)/2] ^= 0xff
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(b1.String(), IndexFilename), bytes.NewReader(index)))
+
+	err = Download(ctx, log.NewNopLogger(), bkt, b1, path.Join(tmpDir, "corrupted"))
+	testutil.NotOk(t, err)
+	testutil.Assert(t, strings.Contains(err.Error(), "CRC32C checksum"), "unexpected error: %v", err)
+
+	// Truncated chunks file.
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(b1.String(), ChunksDirname, "000001"), bytes.NewReader([]byte("chunks"))))
+	err = Download(ctx, log.NewNopLogger(), bkt, b1, path.Join(tmpDir, "truncated"))
+	testutil.NotOk(t, err)
+	testutil.Assert(t, strings.Contains(err.Error(), "size 6 does not match"), "unexpected error: %v", err)
+}
+
 func TestMarkForDeletion(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
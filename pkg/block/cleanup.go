@@ -0,0 +1,185 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// GarbageKind is a reason for a block directory to be removed from the bucket.
+type GarbageKind string
+
+const (
+	// PartialUploadGarbage is a block directory with block files, but no meta.json, e.g. left by aborted upload.
+	PartialUploadGarbage GarbageKind = "partial-upload"
+	// DanglingMarkersGarbage is a block directory with markers only, e.g. left by interrupted deletion of the block.
+	DanglingMarkersGarbage GarbageKind = "dangling-markers"
+	// EmptyPrefixGarbage is a block directory without any objects, e.g. left by interrupted deletion from filesystem
+	// bucket or by placeholder objects of directories.
+	EmptyPrefixGarbage GarbageKind = "empty-prefix"
+)
+
+// markerFilenames are files of block markers which are not part of block data.
+var markerFilenames = map[string]struct{}{
+	metadata.DeletionMarkFilename:     {},
+	metadata.NoCompactMarkFilename:    {},
+	metadata.NoDownsampleMarkFilename: {},
+}
+
+// Garbage is a block directory which can be removed from the bucket.
+type Garbage struct {
+	ID   ulid.ULID
+	Kind GarbageKind
+	// Files are all objects within the block directory.
+	Files []string
+	// Bytes is the total size of files.
+	Bytes uint64
+}
+
+// FindGarbage finds block directories without meta.json. Directories of partial uploads and empty ones are reported
+// once the block ID is older than the partialUploadDelay, as upload of the block may be still in progress otherwise.
+// Directories with markers only are reported once all their markers are older than the markerDelay.
+func FindGarbage(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, now time.Time, partialUploadDelay, markerDelay time.Duration) ([]Garbage, error) {
+	var ids []ulid.ULID
+	if err := bkt.Iter(ctx, "", func(name string) error {
+		if id, ok := IsBlockDir(strings.TrimSuffix(name, objstore.DirDelim)); ok {
+			ids = append(ids, id)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "list block directories")
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Compare(ids[j]) < 0 })
+
+	var res []Garbage
+	for _, id := range ids {
+		g := Garbage{ID: id}
+		if err := iterFiles(ctx, bkt, id.String()+objstore.DirDelim, func(name string) error {
+			g.Files = append(g.Files, name)
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "list files of block %s", id)
+		}
+
+		markersOnly := true
+		live := false
+		for _, f := range g.Files {
+			base := path.Base(f)
+			if base == MetaFilename {
+				live = true
+				break
+			}
+			if _, ok := markerFilenames[base]; !ok {
+				markersOnly = false
+			}
+		}
+		if live {
+			continue
+		}
+
+		switch {
+		case len(g.Files) == 0:
+			g.Kind = EmptyPrefixGarbage
+		case markersOnly:
+			g.Kind = DanglingMarkersGarbage
+		default:
+			g.Kind = PartialUploadGarbage
+		}
+
+		if g.Kind == DanglingMarkersGarbage {
+			markedAt, err := latestMarkTime(ctx, logger, bkt, id)
+			if err != nil {
+				return nil, err
+			}
+			if now.Sub(markedAt) <= markerDelay {
+				continue
+			}
+		} else if now.Sub(ulid.Time(id.Time())) <= partialUploadDelay {
+			continue
+		}
+
+		for _, f := range g.Files {
+			size, err := bkt.ObjectSize(ctx, f)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get size of %s", f)
+			}
+			g.Bytes += size
+		}
+		res = append(res, g)
+	}
+	return res, nil
+}
+
+// latestMarkTime returns time of the latest marker of the block. Markers which can't be read are assumed to be old.
+func latestMarkTime(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, id ulid.ULID) (time.Time, error) {
+	var latest int64
+	deletion, err := metadata.ReadDeletionMark(ctx, bkt, logger, id.String())
+	switch errors.Cause(err) {
+	case nil:
+		latest = deletion.DeletionTime
+	case metadata.ErrorDeletionMarkNotFound, metadata.ErrorUnmarshalDeletionMark:
+	default:
+		return time.Time{}, errors.Wrapf(err, "read deletion mark of block %s", id)
+	}
+	noCompact, err := metadata.ReadNoCompactMark(ctx, bkt, logger, id.String())
+	switch errors.Cause(err) {
+	case nil:
+		if noCompact.NoCompactTime > latest {
+			latest = noCompact.NoCompactTime
+		}
+	case metadata.ErrorNoCompactMarkNotFound, metadata.ErrorUnmarshalNoCompactMark:
+	default:
+		return time.Time{}, errors.Wrapf(err, "read no-compact mark of block %s", id)
+	}
+	noDownsample, err := metadata.ReadNoDownsampleMark(ctx, bkt, logger, id.String())
+	switch errors.Cause(err) {
+	case nil:
+		if noDownsample.NoDownsampleTime > latest {
+			latest = noDownsample.NoDownsampleTime
+		}
+	case metadata.ErrorNoDownsampleMarkNotFound, metadata.ErrorUnmarshalNoDownsampleMark:
+	default:
+		return time.Time{}, errors.Wrapf(err, "read no-downsample mark of block %s", id)
+	}
+	return time.Unix(latest, 0), nil
+}
+
+// iterFiles calls f for each object within the directory, recursively.
+func iterFiles(ctx context.Context, bkt objstore.BucketReader, dir string, f func(name string) error) error {
+	return bkt.Iter(ctx, dir, func(name string) error {
+		if strings.HasSuffix(name, objstore.DirDelim) {
+			// Placeholder objects of directories may be listed within them.
+			if name == dir {
+				return nil
+			}
+			return iterFiles(ctx, bkt, name, f)
+		}
+		return f(name)
+	})
+}
+
+// DeleteGarbage removes the block directory found by FindGarbage.
+func DeleteGarbage(ctx context.Context, logger log.Logger, bkt objstore.Bucket, g Garbage) error {
+	if g.Kind == EmptyPrefixGarbage {
+		// Deletes the placeholder object of the directory, or the empty directory of filesystem bucket.
+		if err := bkt.Delete(ctx, g.ID.String()+objstore.DirDelim); err != nil && !bkt.IsObjNotFoundErr(err) {
+			return errors.Wrapf(err, "delete empty prefix of block %s", g.ID)
+		}
+	} else if err := Delete(ctx, logger, bkt, g.ID); err != nil {
+		return errors.Wrapf(err, "delete block %s", g.ID)
+	}
+	level.Info(logger).Log("msg", "deleted garbage", "block", g.ID, "kind", g.Kind, "files", len(g.Files), "bytes", g.Bytes)
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore/filesystem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestFindGarbage(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	dir, err := ioutil.TempDir("", "test-find-garbage")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt, err := filesystem.NewBucket(dir)
+	testutil.Ok(t, err)
+
+	now := time.Now()
+	old, fresh := now.Add(-72*time.Hour), now.Add(-time.Hour)
+	newID := func(t time.Time, seq uint64) ulid.ULID {
+		id := ulid.MustNew(ulid.Timestamp(t), nil)
+		id[15] = byte(seq)
+		return id
+	}
+	upload := func(name string, content []byte) {
+		testutil.Ok(t, bkt.Upload(ctx, name, bytes.NewReader(content)))
+	}
+	uploadDeletionMark := func(id ulid.ULID, markedAt time.Time) {
+		b, err := json.Marshal(metadata.DeletionMark{ID: id, DeletionTime: markedAt.Unix(), Version: metadata.DeletionMarkVersion1})
+		testutil.Ok(t, err)
+		upload(path.Join(id.String(), metadata.DeletionMarkFilename), b)
+	}
+
+	live := newID(old, 1)
+	upload(path.Join(live.String(), MetaFilename), []byte("{}"))
+	upload(path.Join(live.String(), IndexFilename), []byte("index"))
+
+	oldPartial := newID(old, 2)
+	upload(path.Join(oldPartial.String(), IndexFilename), []byte("index"))
+	upload(path.Join(oldPartial.String(), ChunksDirname, "000001"), []byte("chunks"))
+
+	freshPartial := newID(fresh, 3)
+	upload(path.Join(freshPartial.String(), ChunksDirname, "000001"), []byte("chunks"))
+
+	oldMarkers := newID(old, 4)
+	uploadDeletionMark(oldMarkers, old)
+	upload(path.Join(oldMarkers.String(), metadata.NoCompactMarkFilename), []byte("not a valid no-compact-mark.json"))
+
+	freshMarkers := newID(old, 5)
+	uploadDeletionMark(freshMarkers, fresh)
+
+	empty := newID(old, 6)
+	testutil.Ok(t, os.MkdirAll(filepath.Join(dir, empty.String(), ChunksDirname), os.ModePerm))
+
+	// Unrelated directories are ignored.
+	upload("debug/metas/"+oldPartial.String()+".json", []byte("{}"))
+
+	garbage, err := FindGarbage(ctx, logger, bkt, now, 48*time.Hour, 24*time.Hour)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []Garbage{
+		{
+			ID:   oldPartial,
+			Kind: PartialUploadGarbage,
+			Files: []string{
+				path.Join(oldPartial.String(), ChunksDirname, "000001"),
+				path.Join(oldPartial.String(), IndexFilename),
+			},
+			Bytes: 11,
+		},
+		{
+			ID:   oldMarkers,
+			Kind: DanglingMarkersGarbage,
+			Files: []string{
+				path.Join(oldMarkers.String(), metadata.DeletionMarkFilename),
+				path.Join(oldMarkers.String(), metadata.NoCompactMarkFilename),
+			},
+			Bytes: garbageSize(t, dir, oldMarkers),
+		},
+		{ID: empty, Kind: EmptyPrefixGarbage},
+	}, garbage)
+
+	for _, g := range garbage {
+		testutil.Ok(t, DeleteGarbage(ctx, logger, bkt, g))
+		_, err := os.Stat(filepath.Join(dir, g.ID.String()))
+		testutil.Assert(t, os.IsNotExist(err), "directory of %s should be deleted", g.ID)
+	}
+
+	garbage, err = FindGarbage(ctx, logger, bkt, now, 48*time.Hour, 24*time.Hour)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(garbage))
+
+	for _, id := range []ulid.ULID{live, freshPartial, freshMarkers} {
+		_, err := os.Stat(filepath.Join(dir, id.String()))
+		testutil.Ok(t, err)
+	}
+}
+
+func garbageSize(t *testing.T, dir string, id ulid.ULID) uint64 {
+	var size uint64
+	testutil.Ok(t, filepath.Walk(filepath.Join(dir, id.String()), func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += uint64(info.Size())
+		}
+		return nil
+	}))
+	return size
+}
@@ -6,6 +6,7 @@ package block
 import (
 	"context"
 	"encoding/json"
+	"hash/fnv"
 	"io/ioutil"
 	"os"
 	"path"
@@ -69,6 +70,10 @@ const (
 	// Blocks that are marked for deletion can be loaded as well. This is done to make sure that we load blocks that are meant to be deleted,
 	// but don't have a replacement block yet.
 	markedForDeletionMeta = "marked-for-deletion"
+	// Blocks that are marked for no compaction are excluded only by compactor.
+	markedForNoCompactMeta = "marked-for-no-compact"
+	// Blocks that belong to other shards of sharded jobs.
+	shardExcludedMeta = "shard-excluded"
 
 	// Modified label values.
 	replicaRemovedMeta = "replica-label-removed"
@@ -110,6 +115,8 @@ func newFetcherMetrics(reg prometheus.Registerer) *fetcherMetrics {
 		[]string{timeExcludedMeta},
 		[]string{duplicateMeta},
 		[]string{markedForDeletionMeta},
+		[]string{markedForNoCompactMeta},
+		[]string{shardExcludedMeta},
 	)
 	m.modified = extprom.NewTxGaugeVec(
 		reg,
@@ -783,3 +790,90 @@ func (f *IgnoreDeletionMarkFilter) Filter(ctx context.Context, metas map[ulid.UL
 	}
 	return nil
 }
+
+// IgnoreNoCompactMarkFilter is a filter that filters out the blocks that are marked for no compaction. Marks older than
+// the given expiry are ignored, so blocks marked by a process that never finished its work are eventually compacted.
+// Marks made manually by the operator never expire.
+// It is meant to be used only by compactor, after DeduplicateFilter, so marked blocks can still be garbage collected
+// once their replacement is uploaded.
+// Not go-routine safe.
+type IgnoreNoCompactMarkFilter struct {
+	logger log.Logger
+	expiry time.Duration
+	bkt    objstore.BucketReader
+}
+
+// NewIgnoreNoCompactMarkFilter creates IgnoreNoCompactMarkFilter.
+func NewIgnoreNoCompactMarkFilter(logger log.Logger, bkt objstore.BucketReader, expiry time.Duration) *IgnoreNoCompactMarkFilter {
+	return &IgnoreNoCompactMarkFilter{
+		logger: logger,
+		bkt:    bkt,
+		expiry: expiry,
+	}
+}
+
+// Filter filters out blocks that are marked for no compaction, unless the mark expired.
+func (f *IgnoreNoCompactMarkFilter) Filter(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, synced *extprom.TxGaugeVec, _ bool) error {
+	for id := range metas {
+		mark, err := metadata.ReadNoCompactMark(ctx, f.bkt, f.logger, id.String())
+		if err == metadata.ErrorNoCompactMarkNotFound {
+			continue
+		}
+		if errors.Cause(err) == metadata.ErrorUnmarshalNoCompactMark {
+			level.Warn(f.logger).Log("msg", "found partial no-compact-mark.json; if we will see it happening often for the same block, consider manually deleting no-compact-mark.json from the object storage", "block", id, "err", err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if mark.Reason != metadata.ManualNoCompactReason && time.Since(time.Unix(mark.NoCompactTime, 0)).Seconds() > f.expiry.Seconds() {
+			level.Warn(f.logger).Log("msg", "ignoring expired no-compact-mark.json", "block", id, "reason", mark.Reason)
+			continue
+		}
+		synced.WithLabelValues(markedForNoCompactMeta).Inc()
+		delete(metas, id)
+	}
+	return nil
+}
+
+// ShardFilter is a filter that filters out the blocks that do not belong to the given shard. Blocks are assigned to
+// shards by the hash of their key, e.g. compaction group key, so blocks with the same key always belong to the same
+// shard. It allows to split work on the bucket between multiple independent processes.
+type ShardFilter struct {
+	shards int
+	shard  int
+	key    func(*metadata.Meta) string
+}
+
+// NewShardFilter creates ShardFilter selecting blocks of the shard with the given index, out of the given number of
+// shards.
+func NewShardFilter(shards, shard int, key func(*metadata.Meta) string) (*ShardFilter, error) {
+	if shards < 1 {
+		return nil, errors.Errorf("number of shards must be positive, got %d", shards)
+	}
+	if shard < 0 || shard >= shards {
+		return nil, errors.Errorf("shard index must be between 0 and %d, got %d", shards-1, shard)
+	}
+	return &ShardFilter{shards: shards, shard: shard, key: key}, nil
+}
+
+// Contains returns true if the block belongs to the shard. Nil filter contains all blocks.
+func (f *ShardFilter) Contains(m *metadata.Meta) bool {
+	if f == nil || f.shards == 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(f.key(m)))
+	return int(h.Sum32()%uint32(f.shards)) == f.shard
+}
+
+// Filter filters out blocks that do not belong to the shard.
+func (f *ShardFilter) Filter(_ context.Context, metas map[ulid.ULID]*metadata.Meta, synced *extprom.TxGaugeVec, _ bool) error {
+	for id, m := range metas {
+		if !f.Contains(m) {
+			synced.WithLabelValues(shardExcludedMeta).Inc()
+			delete(metas, id)
+		}
+	}
+	return nil
+}
@@ -1109,3 +1109,100 @@ func TestIgnoreDeletionMarkFilter_Filter(t *testing.T) {
 		testutil.Equals(t, expected, input)
 	})
 }
+
+func TestIgnoreNoCompactMarkFilter_Filter(t *testing.T) {
+	objtesting.ForeachStore(t, func(t *testing.T, bkt objstore.Bucket) {
+		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+		defer cancel()
+
+		f := NewIgnoreNoCompactMarkFilter(log.NewNopLogger(), bkt, 24*time.Hour)
+
+		testutil.Ok(t, MarkForNoCompact(ctx, log.NewNopLogger(), bkt, ULID(1), metadata.ReplacementUploadNoCompactReason, ""))
+
+		expired := &metadata.NoCompactMark{
+			ID:            ULID(2),
+			NoCompactTime: time.Now().Add(-48 * time.Hour).Unix(),
+			Reason:        metadata.ReplacementUploadNoCompactReason,
+			Version:       1,
+		}
+		var buf bytes.Buffer
+		testutil.Ok(t, json.NewEncoder(&buf).Encode(&expired))
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(expired.ID.String(), metadata.NoCompactMarkFilename), &buf))
+
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(ULID(3).String(), metadata.NoCompactMarkFilename), bytes.NewBufferString("not a valid no-compact-mark.json")))
+
+		// Manual marks do not expire.
+		manual := &metadata.NoCompactMark{
+			ID:            ULID(5),
+			NoCompactTime: time.Now().Add(-48 * time.Hour).Unix(),
+			Reason:        metadata.ManualNoCompactReason,
+			Version:       1,
+		}
+		buf.Reset()
+		testutil.Ok(t, json.NewEncoder(&buf).Encode(&manual))
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(manual.ID.String(), metadata.NoCompactMarkFilename), &buf))
+
+		input := map[ulid.ULID]*metadata.Meta{
+			ULID(1): {},
+			ULID(2): {},
+			ULID(3): {},
+			ULID(4): {},
+			ULID(5): {},
+		}
+
+		expected := map[ulid.ULID]*metadata.Meta{
+			ULID(2): {},
+			ULID(3): {},
+			ULID(4): {},
+		}
+
+		m := newTestFetcherMetrics()
+		testutil.Ok(t, f.Filter(ctx, input, m.synced, false))
+		testutil.Equals(t, 2.0, promtest.ToFloat64(m.synced.WithLabelValues(markedForNoCompactMeta)))
+		testutil.Equals(t, expected, input)
+	})
+}
+
+func TestShardFilter_Filter(t *testing.T) {
+	_, err := NewShardFilter(0, 0, nil)
+	testutil.NotOk(t, err)
+	_, err = NewShardFilter(2, 2, nil)
+	testutil.NotOk(t, err)
+
+	key := func(m *metadata.Meta) string { return m.Thanos.Labels["a"] }
+	input := map[ulid.ULID]*metadata.Meta{}
+	for i := 0; i < 100; i++ {
+		m := &metadata.Meta{}
+		m.ULID = ULID(i)
+		// Every key is shared by two blocks.
+		m.Thanos.Labels = map[string]string{"a": fmt.Sprintf("%d", i/2)}
+		input[m.ULID] = m
+	}
+
+	const shards = 3
+	var total int
+	for shard := 0; shard < shards; shard++ {
+		f, err := NewShardFilter(shards, shard, key)
+		testutil.Ok(t, err)
+
+		metas := map[ulid.ULID]*metadata.Meta{}
+		for id, m := range input {
+			metas[id] = m
+		}
+		m := newTestFetcherMetrics()
+		testutil.Ok(t, f.Filter(context.Background(), metas, m.synced, false))
+		testutil.Equals(t, float64(len(input)-len(metas)), promtest.ToFloat64(m.synced.WithLabelValues(shardExcludedMeta)))
+		testutil.Assert(t, len(metas) > 0, "shard %d should not be empty", shard)
+		total += len(metas)
+
+		for id, m := range metas {
+			// Blocks with the same key belong to the same shard.
+			testutil.Assert(t, f.Contains(input[ULID(int(id.Time())^1)]), "block %s of key %s in different shard", id, key(m))
+		}
+	}
+	// Each block belongs to exactly one shard.
+	testutil.Equals(t, len(input), total)
+
+	var nilFilter *ShardFilter
+	testutil.Assert(t, nilFilter.Contains(input[ULID(0)]), "nil filter should contain all blocks")
+}
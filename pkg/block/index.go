@@ -21,6 +21,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/index"
@@ -60,6 +61,11 @@ type Stats struct {
 	// OutOfOrderLabels represents the number of postings that contained out
 	// of order labels, a bug present in Prometheus 2.8.0 and below.
 	OutOfOrderLabels int
+	// DuplicatedSeries represents number of series with the same label set as the previous series in the index.
+	DuplicatedSeries int
+	// CorruptedChunks represents number of chunks that cannot be read, because their length does not match the data
+	// in the chunk segment file or their checksum does not match. It is gathered only by GatherBlockIssueStats.
+	CorruptedChunks int
 }
 
 // PrometheusIssue5372Err returns an error if the Stats object indicates
@@ -105,6 +111,14 @@ func (i Stats) CriticalErr() error {
 		errMsg = append(errMsg, fmt.Sprintf("found %d chunks completely outside the block time range", i.CompleteOutsideChunks))
 	}
 
+	if i.DuplicatedSeries > 0 {
+		errMsg = append(errMsg, fmt.Sprintf("found %d duplicated series", i.DuplicatedSeries))
+	}
+
+	if i.CorruptedChunks > 0 {
+		errMsg = append(errMsg, fmt.Sprintf("found %d corrupted chunks", i.CorruptedChunks))
+	}
+
 	if len(errMsg) > 0 {
 		return errors.New(strings.Join(errMsg, ", "))
 	}
@@ -154,6 +168,8 @@ func GatherIndexIssueStats(logger log.Logger, fn string, minTime int64, maxTime
 		lastLset labels.Labels
 		lset     labels.Labels
 		chks     []chunks.Meta
+
+		lastOutOfOrderLabels bool
 	)
 
 	// Per series.
@@ -169,13 +185,12 @@ func GatherIndexIssueStats(logger log.Logger, fn string, minTime int64, maxTime
 		if len(lset) == 0 {
 			return stats, errors.Errorf("empty label set detected for series %d", id)
 		}
-		if lastLset != nil && labels.Compare(lastLset, lset) >= 0 {
-			return stats, errors.Errorf("series %v out of order; previous %v", lset, lastLset)
-		}
+		outOfOrderLabels := false
 		l0 := lset[0]
 		for _, l := range lset[1:] {
 			if l.Name < l0.Name {
 				stats.OutOfOrderLabels++
+				outOfOrderLabels = true
 				level.Warn(logger).Log("msg",
 					"out-of-order label set: known bug in Prometheus 2.8.0 and below",
 					"labelset", lset.String(),
@@ -184,6 +199,17 @@ func GatherIndexIssueStats(logger log.Logger, fn string, minTime int64, maxTime
 			}
 			l0 = l
 		}
+		if lastLset != nil {
+			switch c := labels.Compare(lastLset, lset); {
+			case c == 0:
+				stats.DuplicatedSeries++
+				level.Warn(logger).Log("msg", "duplicated series", "labelset", lset.String(), "series", fmt.Sprintf("%d", id))
+			case c > 0 && !outOfOrderLabels && !lastOutOfOrderLabels:
+				// Series with out of order labels are sorted by their repair, so their order is not checked.
+				return stats, errors.Errorf("series %v out of order; previous %v", lset, lastLset)
+			}
+		}
+		lastOutOfOrderLabels = outOfOrderLabels
 		if len(chks) == 0 {
 			return stats, errors.Errorf("empty chunks for series %d", id)
 		}
@@ -228,22 +254,82 @@ func GatherIndexIssueStats(logger log.Logger, fn string, minTime int64, maxTime
 		}
 	}
 	if p.Err() != nil {
-		return stats, errors.Wrap(err, "walk postings")
+		return stats, errors.Wrap(p.Err(), "walk postings")
+	}
+
+	return stats, nil
+}
+
+// GatherBlockIssueStats returns index issue stats of the block in the given directory, gathered by
+// GatherIndexIssueStats, together with the number of corrupted chunks. It requires the whole block being present,
+// since all chunks are read.
+func GatherBlockIssueStats(logger log.Logger, bdir string, minTime int64, maxTime int64) (stats Stats, err error) {
+	stats, err = GatherIndexIssueStats(logger, filepath.Join(bdir, IndexFilename), minTime, maxTime)
+	if err != nil {
+		return stats, err
+	}
+
+	ir, err := index.NewFileReader(filepath.Join(bdir, IndexFilename))
+	if err != nil {
+		return stats, errors.Wrap(err, "open index file")
+	}
+	defer runutil.CloseWithErrCapture(&err, ir, "gather block issue index reader")
+
+	cr, err := chunks.NewDirReader(filepath.Join(bdir, ChunksDirname), nil)
+	if err != nil {
+		return stats, errors.Wrap(err, "open chunks dir")
+	}
+	defer runutil.CloseWithErrCapture(&err, cr, "gather block issue chunk reader")
+
+	p, err := ir.Postings(index.AllPostingsKey())
+	if err != nil {
+		return stats, errors.Wrap(err, "get all postings")
+	}
+	var (
+		lset labels.Labels
+		chks []chunks.Meta
+	)
+	for p.Next() {
+		if err := ir.Series(p.At(), &lset, &chks); err != nil {
+			return stats, errors.Wrap(err, "read series")
+		}
+		for _, c := range chks {
+			if _, err := cr.Chunk(c.Ref); err != nil {
+				stats.CorruptedChunks++
+				level.Warn(logger).Log("msg", "corrupted chunk", "labelset", lset.String(), "ref", c.Ref, "err", err)
+			}
+		}
+	}
+	if p.Err() != nil {
+		return stats, errors.Wrap(p.Err(), "walk postings")
 	}
 
 	return stats, nil
 }
 
-type ignoreFnType func(mint, maxt int64, prev *chunks.Meta, curr *chunks.Meta) (bool, error)
+// IgnoreChunkFn returns true if the current chunk of a series should be dropped by the repair. The previous chunk is
+// the last chunk kept.
+type IgnoreChunkFn func(mint, maxt int64, prev *chunks.Meta, curr *chunks.Meta) (bool, error)
+
+// Repair actions recorded in the meta of the repaired block.
+const (
+	RepairSortLabels           = "sort_labels"
+	RepairMergeDuplicateSeries = "merge_duplicate_series"
+	RepairDropDuplicateSeries  = "drop_duplicate_series"
+	RepairDropChunks           = "drop_chunks"
+	RepairDropCorruptedChunks  = "drop_corrupted_chunks"
+)
 
 // Repair open the block with given id in dir and creates a new one with fixed data.
 // It:
 // - removes out of order duplicates
 // - all "complete" outsiders (they will not accessed anyway)
 // - removes all near "complete" outside chunks introduced by https://github.com/prometheus/tsdb/issues/347.
-// Fixable inconsistencies are resolved in the new block.
+// - sorts out of order labels and merges chunks of duplicated series.
+// - removes corrupted chunks, if IgnoreCorruptedChunk is given.
+// Fixable inconsistencies are resolved in the new block. The repair is recorded in the meta of the new block.
 // TODO(bplotka): https://github.com/thanos-io/thanos/issues/378.
-func Repair(logger log.Logger, dir string, id ulid.ULID, source metadata.SourceType, ignoreChkFns ...ignoreFnType) (resid ulid.ULID, err error) {
+func Repair(logger log.Logger, dir string, id ulid.ULID, source metadata.SourceType, ignoreChkFns ...IgnoreChunkFn) (resid ulid.ULID, err error) {
 	if len(ignoreChkFns) == 0 {
 		return resid, errors.New("no ignore chunk function specified")
 	}
@@ -298,6 +384,12 @@ func Repair(logger log.Logger, dir string, id ulid.ULID, source metadata.SourceT
 	resmeta.ULID = resid
 	resmeta.Stats = tsdb.BlockStats{} // Reset stats.
 	resmeta.Thanos.Source = source    // Update source.
+	resmeta.Thanos.Files = nil
+	resmeta.Thanos.Repair = &metadata.ThanosRepair{
+		Origin:  id,
+		Time:    timestamp.FromTime(time.Now()),
+		Actions: map[string]int{},
+	}
 
 	if err := rewrite(logger, indexr, chunkr, indexw, chunkw, &resmeta, ignoreChkFns); err != nil {
 		return resid, errors.Wrap(err, "rewrite block")
@@ -331,8 +423,13 @@ func IgnoreIssue347OutsideChunk(_ int64, maxt int64, _ *chunks.Meta, curr *chunk
 	return false, nil
 }
 
+// IgnoreCorruptedChunk drops chunks which cannot be read. Without it, repair of blocks with corrupted chunks fails.
+func IgnoreCorruptedChunk(_ int64, _ int64, _ *chunks.Meta, curr *chunks.Meta) (bool, error) {
+	return curr.Chunk == nil, nil
+}
+
 func IgnoreDuplicateOutsideChunk(_ int64, _ int64, last *chunks.Meta, curr *chunks.Meta) (bool, error) {
-	if last == nil {
+	if last == nil || last.Chunk == nil || curr.Chunk == nil {
 		return false, nil
 	}
 
@@ -358,7 +455,7 @@ func IgnoreDuplicateOutsideChunk(_ int64, _ int64, last *chunks.Meta, curr *chun
 
 // sanitizeChunkSequence ensures order of the input chunks and drops any duplicates.
 // It errors if the sequence contains non-dedupable overlaps.
-func sanitizeChunkSequence(chks []chunks.Meta, mint int64, maxt int64, ignoreChkFns []ignoreFnType) ([]chunks.Meta, error) {
+func sanitizeChunkSequence(chks []chunks.Meta, mint int64, maxt int64, ignoreChkFns []IgnoreChunkFn) ([]chunks.Meta, error) {
 	if len(chks) == 0 {
 		return nil, nil
 	}
@@ -401,6 +498,29 @@ type seriesRepair struct {
 	chks []chunks.Meta
 }
 
+// repaired records the repair action in the meta of the repaired block.
+func repaired(meta *metadata.Meta, action string, n int) {
+	if n == 0 || meta.Thanos.Repair == nil {
+		return
+	}
+	meta.Thanos.Repair.Actions[action] += n
+}
+
+// mergeChunks returns chunks of both duplicated series, if they do not overlap after removing ignored chunks.
+func mergeChunks(a, b []chunks.Meta, mint int64, maxt int64, ignoreChkFns []IgnoreChunkFn) ([]chunks.Meta, error) {
+	chks, err := sanitizeChunkSequence(append(append([]chunks.Meta{}, a...), b...), mint, maxt, ignoreChkFns)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(chks); i++ {
+		if chks[i].MinTime <= chks[i-1].MaxTime {
+			return nil, errors.Errorf("chunks overlap: [%d, %d] and [%d, %d]",
+				chks[i-1].MinTime, chks[i-1].MaxTime, chks[i].MinTime, chks[i].MaxTime)
+		}
+	}
+	return chks, nil
+}
+
 // rewrite writes all data from the readers back into the writers while cleaning
 // up mis-ordered and duplicated chunks.
 func rewrite(
@@ -408,7 +528,7 @@ func rewrite(
 	indexr tsdb.IndexReader, chunkr tsdb.ChunkReader,
 	indexw tsdb.IndexWriter, chunkw tsdb.ChunkWriter,
 	meta *metadata.Meta,
-	ignoreChkFns []ignoreFnType,
+	ignoreChkFns []IgnoreChunkFn,
 ) error {
 	symbols := indexr.Symbols()
 	for symbols.Next() {
@@ -443,19 +563,33 @@ func rewrite(
 			return errors.Wrap(err, "series")
 		}
 		// Make sure labels are in sorted order.
-		sort.Sort(lset)
+		if !sort.IsSorted(lset) {
+			sort.Sort(lset)
+			repaired(meta, RepairSortLabels, 1)
+		}
 
+		// Chunks which cannot be read are kept without data, so they can be dropped by IgnoreCorruptedChunk.
+		readErrs := map[uint64]error{}
 		for i, c := range chks {
 			chks[i].Chunk, err = chunkr.Chunk(c.Ref)
 			if err != nil {
-				return errors.Wrap(err, "chunk read")
+				readErrs[c.Ref] = err
+				chks[i].Chunk = nil
 			}
 		}
 
+		n := len(chks)
 		chks, err := sanitizeChunkSequence(chks, meta.MinTime, meta.MaxTime, ignoreChkFns)
 		if err != nil {
 			return err
 		}
+		for _, c := range chks {
+			if c.Chunk == nil {
+				return errors.Wrap(readErrs[c.Ref], "chunk read")
+			}
+		}
+		repaired(meta, RepairDropCorruptedChunks, len(readErrs))
+		repaired(meta, RepairDropChunks, n-len(chks)-len(readErrs))
 
 		if len(chks) == 0 {
 			continue
@@ -473,26 +607,39 @@ func rewrite(
 
 	// Sort the series, if labels are re-ordered then the ordering of series
 	// will be different.
-	sort.Slice(series, func(i, j int) bool {
+	sort.SliceStable(series, func(i, j int) bool {
 		return labels.Compare(series[i].lset, series[j].lset) < 0
 	})
 
-	lastSet := labels.Labels{}
-	// Build a new TSDB block.
+	// The TSDB library will throw an error if we add a series with
+	// identical labels as the last series. This means that we have
+	// discovered a duplicate time series in the old block. We merge their
+	// chunks if possible, otherwise we drop all duplicate series preserving
+	// the first one.
+	merged := make([]seriesRepair, 0, len(series))
 	for _, s := range series {
-		// The TSDB library will throw an error if we add a series with
-		// identical labels as the last series. This means that we have
-		// discovered a duplicate time series in the old block. We drop
-		// all duplicate series preserving the first one.
-		// TODO: Add metric to count dropped series if repair becomes a daemon
-		// rather than a batch job.
-		if labels.Compare(lastSet, s.lset) == 0 {
+		if len(merged) == 0 || labels.Compare(merged[len(merged)-1].lset, s.lset) != 0 {
+			merged = append(merged, s)
+			continue
+		}
+		last := &merged[len(merged)-1]
+		chks, err := mergeChunks(last.chks, s.chks, meta.MinTime, meta.MaxTime, ignoreChkFns)
+		if err != nil {
 			level.Warn(logger).Log("msg",
 				"dropping duplicate series in tsdb block found",
 				"labelset", s.lset.String(),
+				"err", err,
 			)
+			repaired(meta, RepairDropDuplicateSeries, 1)
 			continue
 		}
+		level.Warn(logger).Log("msg", "merging duplicate series in tsdb block found", "labelset", s.lset.String())
+		last.chks = chks
+		repaired(meta, RepairMergeDuplicateSeries, 1)
+	}
+
+	// Build a new TSDB block.
+	for _, s := range merged {
 		if err := chunkw.WriteChunks(s.chks...); err != nil {
 			return errors.Wrap(err, "write chunks")
 		}
@@ -517,7 +664,6 @@ func rewrite(
 		}
 		postings.Add(i, s.lset)
 		i++
-		lastSet = s.lset
 	}
 	return nil
 }
@@ -8,11 +8,14 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
@@ -61,7 +64,7 @@ func TestRewrite(t *testing.T) {
 
 	defer cw.Close()
 
-	testutil.Ok(t, rewrite(log.NewNopLogger(), ir, cr, iw, cw, m, []ignoreFnType{func(mint, maxt int64, prev *chunks.Meta, curr *chunks.Meta) (bool, error) {
+	testutil.Ok(t, rewrite(log.NewNopLogger(), ir, cr, iw, cw, m, []IgnoreChunkFn{func(mint, maxt int64, prev *chunks.Meta, curr *chunks.Meta) (bool, error) {
 		return curr.MaxTime == 696, nil
 	}}))
 
@@ -85,3 +88,133 @@ func TestRewrite(t *testing.T) {
 	}
 
 }
+
+// faultyIndexReader returns series with the known index issues.
+type faultyIndexReader struct {
+	tsdb.IndexReader
+
+	firstChks []chunks.Meta
+	corrupted map[uint64]bool
+}
+
+func (r *faultyIndexReader) Series(ref uint64, lset *labels.Labels, chks *[]chunks.Meta) error {
+	if err := r.IndexReader.Series(ref, lset, chks); err != nil {
+		return err
+	}
+	switch lset.String() {
+	case `{a="1"}`:
+		r.firstChks = append([]chunks.Meta{}, *chks...)
+	case `{a="1", b="1"}`:
+		*lset = labels.Labels{{Name: "b", Value: "1"}, {Name: "a", Value: "1"}}
+	case `{a="2"}`:
+		// Duplicate of the first series with the same chunks.
+		*lset = labels.Labels{{Name: "a", Value: "1"}}
+		*chks = append((*chks)[:0], r.firstChks...)
+	case `{a="4"}`:
+		for _, c := range *chks {
+			r.corrupted[c.Ref] = true
+		}
+	}
+	return nil
+}
+
+type faultyChunkReader struct {
+	tsdb.ChunkReader
+
+	corrupted map[uint64]bool
+}
+
+func (r *faultyChunkReader) Chunk(ref uint64) (chunkenc.Chunk, error) {
+	if r.corrupted[ref] {
+		return nil, errors.New("checksum mismatch")
+	}
+	return r.ChunkReader.Chunk(ref)
+}
+
+func TestRewrite_RepairIssues(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-rewrite-repair")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	b, err := e2eutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		{{Name: "a", Value: "1"}},
+		{{Name: "a", Value: "2"}},
+		{{Name: "a", Value: "3"}},
+		{{Name: "a", Value: "4"}},
+		{{Name: "a", Value: "1"}, {Name: "b", Value: "1"}},
+	}, 100, 0, 1000, nil, 124)
+	testutil.Ok(t, err)
+
+	for _, c := range []struct {
+		name         string
+		ignoreChkFns []IgnoreChunkFn
+		expectedErr  string
+	}{
+		{
+			name:         "corrupted chunks are not dropped by default",
+			ignoreChkFns: []IgnoreChunkFn{IgnoreDuplicateOutsideChunk},
+			expectedErr:  "checksum mismatch",
+		},
+		{
+			name:         "all issues repaired",
+			ignoreChkFns: []IgnoreChunkFn{IgnoreCorruptedChunk, IgnoreDuplicateOutsideChunk},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ir, err := index.NewFileReader(filepath.Join(tmpDir, b.String(), IndexFilename))
+			testutil.Ok(t, err)
+			defer func() { testutil.Ok(t, ir.Close()) }()
+
+			cr, err := chunks.NewDirReader(filepath.Join(tmpDir, b.String(), ChunksDirname), nil)
+			testutil.Ok(t, err)
+			defer func() { testutil.Ok(t, cr.Close()) }()
+
+			corrupted := map[uint64]bool{}
+			m := &metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{ULID: ULID(1), MinTime: 0, MaxTime: 1000},
+				Thanos: metadata.Thanos{
+					Repair: &metadata.ThanosRepair{Origin: b, Actions: map[string]int{}},
+				},
+			}
+			resdir := filepath.Join(tmpDir, m.ULID.String())
+			testutil.Ok(t, os.RemoveAll(resdir))
+			testutil.Ok(t, os.MkdirAll(resdir, os.ModePerm))
+
+			iw, err := index.NewWriter(ctx, filepath.Join(resdir, IndexFilename))
+			testutil.Ok(t, err)
+			defer iw.Close()
+
+			cw, err := chunks.NewWriter(filepath.Join(resdir, ChunksDirname))
+			testutil.Ok(t, err)
+			defer cw.Close()
+
+			err = rewrite(log.NewNopLogger(),
+				&faultyIndexReader{IndexReader: ir, corrupted: corrupted},
+				&faultyChunkReader{ChunkReader: cr, corrupted: corrupted},
+				iw, cw, m, c.ignoreChkFns,
+			)
+			if c.expectedErr != "" {
+				testutil.NotOk(t, err)
+				testutil.Assert(t, strings.Contains(err.Error(), c.expectedErr), "unexpected error: %v", err)
+				return
+			}
+			testutil.Ok(t, err)
+			testutil.Ok(t, iw.Close())
+			testutil.Ok(t, cw.Close())
+
+			testutil.Equals(t, map[string]int{
+				RepairSortLabels:           1,
+				RepairMergeDuplicateSeries: 1,
+				RepairDropCorruptedChunks:  len(corrupted),
+			}, m.Thanos.Repair.Actions)
+			testutil.Equals(t, uint64(3), m.Stats.NumSeries)
+
+			stats, err := GatherBlockIssueStats(log.NewNopLogger(), resdir, m.MinTime, m.MaxTime)
+			testutil.Ok(t, err)
+			testutil.Ok(t, stats.AnyErr())
+			testutil.Equals(t, 3, stats.TotalSeries)
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 
 	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/fileutil"
@@ -24,14 +25,19 @@ import (
 type SourceType string
 
 const (
-	UnknownSource         SourceType = ""
-	SidecarSource         SourceType = "sidecar"
-	ReceiveSource         SourceType = "receive"
-	CompactorSource       SourceType = "compactor"
-	CompactorRepairSource SourceType = "compactor.repair"
-	RulerSource           SourceType = "ruler"
-	BucketRepairSource    SourceType = "bucket.repair"
-	TestSource            SourceType = "test"
+	UnknownSource          SourceType = ""
+	SidecarSource          SourceType = "sidecar"
+	ReceiveSource          SourceType = "receive"
+	CompactorSource        SourceType = "compactor"
+	CompactorRepairSource  SourceType = "compactor.repair"
+	RulerSource            SourceType = "ruler"
+	RulerBackfillSource    SourceType = "ruler.backfill"
+	RulerMaterializeSource SourceType = "ruler.materialize"
+	BucketRepairSource     SourceType = "bucket.repair"
+	BucketRewriteSource    SourceType = "bucket.rewrite"
+	BucketSplitSource      SourceType = "bucket.split"
+	ToolsImportSource      SourceType = "tools.import"
+	TestSource             SourceType = "test"
 )
 
 const (
@@ -59,6 +65,33 @@ type Thanos struct {
 
 	// Source is a real upload source of the block.
 	Source SourceType `json:"source"`
+
+	// Files are the files of the block with their sizes and checksums, as uploaded. They are only recorded in the
+	// uploaded meta.json, not in the local one of the uploaded block. Empty for blocks uploaded before checksums were
+	// recorded.
+	Files []File `json:"files,omitempty"`
+
+	// Repair describes where the block comes from, if it was created by repairing another block.
+	Repair *ThanosRepair `json:"repair,omitempty"`
+}
+
+// ThanosRepair describes the repair which created the block.
+type ThanosRepair struct {
+	// Origin is the ID of the repaired block.
+	Origin ulid.ULID `json:"origin"`
+	// Time is the unix time in milliseconds when the block was repaired.
+	Time int64 `json:"time"`
+	// Actions are the numbers of series or chunks changed by each repair action.
+	Actions map[string]int `json:"actions,omitempty"`
+}
+
+// File describes a file of the block.
+type File struct {
+	// RelPath is the path of the file relative to the block directory.
+	RelPath   string `json:"rel_path"`
+	SizeBytes int64  `json:"size_bytes"`
+	// CRC32C is the hex encoded CRC-32 checksum of the file content, using the Castagnoli polynomial.
+	CRC32C string `json:"crc32c"`
 }
 
 type ThanosDownsample struct {
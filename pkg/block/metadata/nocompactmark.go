@@ -0,0 +1,92 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	// NoCompactMarkFilename is the known json filename to store details about why block should not be compacted.
+	NoCompactMarkFilename = "no-compact-mark.json"
+
+	// NoCompactMarkVersion1 is the version of no-compact-mark file supported by Thanos.
+	NoCompactMarkVersion1 = 1
+)
+
+// ErrorNoCompactMarkNotFound is the error when no-compact-mark.json file is not found.
+var ErrorNoCompactMarkNotFound = errors.New("no-compact-mark.json not found")
+
+// ErrorUnmarshalNoCompactMark is the error when unmarshalling no-compact-mark.json file.
+var ErrorUnmarshalNoCompactMark = errors.New("unmarshal no-compact-mark.json")
+
+// NoCompactReason is a reason for a block to be excluded from compaction.
+type NoCompactReason string
+
+const (
+	// ReplacementUploadNoCompactReason is a reason used by the shipper when a compacted block that replaces the marked
+	// block is being uploaded.
+	ReplacementUploadNoCompactReason NoCompactReason = "replacement-upload"
+	// ManualNoCompactReason is a reason used when the block is marked by the operator, e.g. with bucket mark command.
+	// Manual marks never expire.
+	ManualNoCompactReason NoCompactReason = "manual"
+)
+
+// NoCompactMark stores block id and why block should not be compacted.
+type NoCompactMark struct {
+	// ID of the tsdb block.
+	ID ulid.ULID `json:"id"`
+
+	// NoCompactTime is a unix timestamp of when the block was marked for no compaction.
+	NoCompactTime int64 `json:"no_compact_time"`
+
+	// Reason is why the block should not be compacted.
+	Reason NoCompactReason `json:"reason"`
+
+	// Details is a human readable explanation of the reason.
+	Details string `json:"details,omitempty"`
+
+	// Version of the file.
+	Version int `json:"version"`
+}
+
+// ReadNoCompactMark reads the given no-compact mark file from <dir>/no-compact-mark.json in bucket.
+func ReadNoCompactMark(ctx context.Context, bkt objstore.BucketReader, logger log.Logger, dir string) (*NoCompactMark, error) {
+	markFile := path.Join(dir, NoCompactMarkFilename)
+
+	r, err := bkt.Get(ctx, markFile)
+	if err != nil {
+		if bkt.IsObjNotFoundErr(err) {
+			return nil, ErrorNoCompactMarkNotFound
+		}
+		return nil, errors.Wrapf(err, "get file: %s", markFile)
+	}
+
+	defer runutil.CloseWithLogOnErr(logger, r, "close bkt no-compact-mark reader")
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read file: %s", markFile)
+	}
+
+	mark := NoCompactMark{}
+	if err := json.Unmarshal(content, &mark); err != nil {
+		return nil, errors.Wrapf(ErrorUnmarshalNoCompactMark, "file: %s; err: %v", markFile, err.Error())
+	}
+
+	if mark.Version != NoCompactMarkVersion1 {
+		return nil, errors.Errorf("unexpected no-compact-mark file version %d", mark.Version)
+	}
+
+	return &mark, nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	// NoDownsampleMarkFilename is the known json filename to store details about why block should not be downsampled.
+	NoDownsampleMarkFilename = "no-downsample-mark.json"
+
+	// NoDownsampleMarkVersion1 is the version of no-downsample-mark file supported by Thanos.
+	NoDownsampleMarkVersion1 = 1
+)
+
+// ErrorNoDownsampleMarkNotFound is the error when no-downsample-mark.json file is not found.
+var ErrorNoDownsampleMarkNotFound = errors.New("no-downsample-mark.json not found")
+
+// ErrorUnmarshalNoDownsampleMark is the error when unmarshalling no-downsample-mark.json file.
+var ErrorUnmarshalNoDownsampleMark = errors.New("unmarshal no-downsample-mark.json")
+
+// NoDownsampleMark stores block id and why block should not be downsampled.
+type NoDownsampleMark struct {
+	// ID of the tsdb block.
+	ID ulid.ULID `json:"id"`
+
+	// NoDownsampleTime is a unix timestamp of when the block was marked for no downsampling.
+	NoDownsampleTime int64 `json:"no_downsample_time"`
+
+	// Details is a human readable explanation of why the block should not be downsampled.
+	Details string `json:"details,omitempty"`
+
+	// Version of the file.
+	Version int `json:"version"`
+}
+
+// ReadNoDownsampleMark reads the given no-downsample mark file from <dir>/no-downsample-mark.json in bucket.
+func ReadNoDownsampleMark(ctx context.Context, bkt objstore.BucketReader, logger log.Logger, dir string) (*NoDownsampleMark, error) {
+	markFile := path.Join(dir, NoDownsampleMarkFilename)
+
+	r, err := bkt.Get(ctx, markFile)
+	if err != nil {
+		if bkt.IsObjNotFoundErr(err) {
+			return nil, ErrorNoDownsampleMarkNotFound
+		}
+		return nil, errors.Wrapf(err, "get file: %s", markFile)
+	}
+
+	defer runutil.CloseWithLogOnErr(logger, r, "close bkt no-downsample-mark reader")
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read file: %s", markFile)
+	}
+
+	mark := NoDownsampleMark{}
+	if err := json.Unmarshal(content, &mark); err != nil {
+		return nil, errors.Wrapf(ErrorUnmarshalNoDownsampleMark, "file: %s; err: %v", markFile, err.Error())
+	}
+
+	if mark.Version != NoDownsampleMarkVersion1 {
+		return nil, errors.Errorf("unexpected no-downsample-mark file version %d", mark.Version)
+	}
+
+	return &mark, nil
+}
@@ -0,0 +1,258 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/textparse"
+	"github.com/prometheus/prometheus/pkg/value"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// OpenMetricsWriter writes series in the OpenMetrics text format, one sample per line.
+type OpenMetricsWriter struct {
+	w *bufio.Writer
+
+	// Series and Samples are numbers of written series and samples.
+	Series, Samples int
+}
+
+// NewOpenMetricsWriter returns OpenMetricsWriter writing to w. Close has to be called once all series are written.
+func NewOpenMetricsWriter(w io.Writer) *OpenMetricsWriter {
+	return &OpenMetricsWriter{w: bufio.NewWriter(w)}
+}
+
+// WriteSeries writes samples of series within [mint, maxt]. Given labels are added to all series, overriding series
+// labels with the same names, e.g. external labels of blocks the series are read from. Stale markers are skipped.
+func (w *OpenMetricsWriter) WriteSeries(ss tsdb.SeriesSet, extLset labels.Labels, mint, maxt int64) error {
+	for ss.Next() {
+		s := ss.At()
+		lset := s.Labels()
+		if len(extLset) > 0 {
+			b := labels.NewBuilder(lset)
+			for _, l := range extLset {
+				b.Set(l.Name, l.Value)
+			}
+			lset = b.Labels()
+		}
+		metric, err := formatOpenMetricsSeries(lset)
+		if err != nil {
+			return err
+		}
+
+		var written bool
+		it := s.Iterator()
+		for ok := it.Seek(mint); ok; ok = it.Next() {
+			t, v := it.At()
+			if t > maxt {
+				break
+			}
+			if value.IsStaleNaN(v) {
+				continue
+			}
+			if _, err := w.w.WriteString(metric + " " + formatOpenMetricsValue(v) + " " + strconv.FormatFloat(float64(t)/1000, 'f', -1, 64) + "\n"); err != nil {
+				return errors.Wrap(err, "write sample")
+			}
+			w.Samples++
+			written = true
+		}
+		if it.Err() != nil {
+			return errors.Wrapf(it.Err(), "iterate series %s", lset)
+		}
+		if written {
+			w.Series++
+		}
+	}
+	return errors.Wrap(ss.Err(), "iterate series")
+}
+
+// Close writes the end of the OpenMetrics text and flushes the writer. It doesn't close the underlying writer.
+func (w *OpenMetricsWriter) Close() error {
+	if _, err := w.w.WriteString("# EOF\n"); err != nil {
+		return errors.Wrap(err, "write EOF")
+	}
+	return errors.Wrap(w.w.Flush(), "flush")
+}
+
+var openMetricsEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatOpenMetricsSeries(lset labels.Labels) (string, error) {
+	name := lset.Get(labels.MetricName)
+	if name == "" {
+		return "", errors.Errorf("series %s without metric name", lset)
+	}
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	first := true
+	for _, l := range lset {
+		if l.Name == labels.MetricName {
+			continue
+		}
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		b.WriteString(l.Name)
+		b.WriteString(`="`)
+		b.WriteString(openMetricsEscaper.Replace(l.Value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+func formatOpenMetricsValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// ImportOpenMetrics writes samples of the OpenMetrics text into blocks in dir, one block per time range of the given
+// duration with any samples, aligned to the duration. All samples have to have timestamps. Labels of the given
+// external labels are removed from series, e.g. the ones added on export. Series with a different value of any of
+// these labels are rejected.
+func ImportOpenMetrics(ctx context.Context, logger log.Logger, b []byte, dir string, extLset labels.Labels, blockDuration int64) ([]ulid.ULID, error) {
+	if blockDuration <= 0 {
+		return nil, errors.New("block duration has to be positive")
+	}
+
+	mint, maxt := int64(math.MaxInt64), int64(math.MinInt64)
+	if err := parseOpenMetrics(b, extLset, func(_ labels.Labels, t int64, _ float64) error {
+		if t < mint {
+			mint = t
+		}
+		if t > maxt {
+			maxt = t
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if mint > maxt {
+		return nil, nil
+	}
+
+	start := mint - mint%blockDuration
+	if mint < 0 && mint%blockDuration != 0 {
+		start -= blockDuration
+	}
+	var ids []ulid.ULID
+	for ; start <= maxt; start += blockDuration {
+		id, err := importOpenMetricsBlock(ctx, logger, b, dir, extLset, start, start+blockDuration)
+		if err != nil {
+			return nil, errors.Wrapf(err, "import block of range [%d, %d)", start, start+blockDuration)
+		}
+		if id != (ulid.ULID{}) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// importOpenMetricsBlock writes samples within [mint, maxt) into a single block. It returns zero ULID if there are no
+// such samples.
+func importOpenMetricsBlock(ctx context.Context, logger log.Logger, b []byte, dir string, extLset labels.Labels, mint, maxt int64) (_ ulid.ULID, err error) {
+	head, err := tsdb.NewHead(nil, logger, nil, maxt-mint)
+	if err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "create head")
+	}
+	defer runutil.CloseWithErrCapture(&err, head, "TSDB head")
+
+	var samples int
+	app := head.Appender()
+	if err := parseOpenMetrics(b, extLset, func(lset labels.Labels, t int64, v float64) error {
+		if t < mint || t >= maxt {
+			return nil
+		}
+		if _, err := app.Add(lset, t, v); err != nil {
+			return errors.Wrapf(err, "add sample of series %s at %d", lset, t)
+		}
+		samples++
+		return nil
+	}); err != nil {
+		if rerr := app.Rollback(); rerr != nil {
+			err = errors.Wrapf(err, "rollback failed: %v", rerr)
+		}
+		return ulid.ULID{}, err
+	}
+	if err := app.Commit(); err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "commit")
+	}
+	if samples == 0 {
+		return ulid.ULID{}, nil
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return ulid.ULID{}, errors.Wrapf(err, "create %s", dir)
+	}
+	comp, err := tsdb.NewLeveledCompactor(ctx, nil, logger, []int64{maxt - mint}, nil)
+	if err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "create compactor")
+	}
+	id, err := comp.Write(dir, head, mint, maxt, nil)
+	if err != nil {
+		return ulid.ULID{}, errors.Wrap(err, "write block")
+	}
+	return id, nil
+}
+
+// parseOpenMetrics calls f for each sample of the OpenMetrics text, with external labels removed from its series.
+func parseOpenMetrics(b []byte, extLset labels.Labels, f func(lset labels.Labels, t int64, v float64) error) error {
+	p := textparse.NewOpenMetricsParser(b)
+	for {
+		e, err := p.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "parse OpenMetrics")
+		}
+		if e != textparse.EntrySeries {
+			continue
+		}
+
+		series, ts, v := p.Series()
+		if ts == nil {
+			return errors.Errorf("sample of series %s without timestamp", series)
+		}
+		var lset labels.Labels
+		p.Metric(&lset)
+
+		if len(extLset) > 0 {
+			bld := labels.NewBuilder(lset)
+			for _, l := range extLset {
+				if val := lset.Get(l.Name); val != "" {
+					if val != l.Value {
+						return errors.Errorf("series %s has label %s different from external label value %q", lset, l.Name, l.Value)
+					}
+					bld.Del(l.Name)
+				}
+			}
+			lset = bld.Labels()
+		}
+		if err := f(lset, *ts, v); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+)
+
+func TestOpenMetrics_ExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-block-openmetrics")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	mint := timestamp.FromTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	maxt := mint + 4*int64(time.Hour/time.Millisecond)
+	id, err := e2eutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		labels.FromStrings(labels.MetricName, "up", "job", "a"),
+		labels.FromStrings(labels.MetricName, "up", "job", "b\"\n\\"),
+		labels.FromStrings(labels.MetricName, "other", "job", "a"),
+	}, 100, mint, maxt, labels.Labels{{Name: "ext1", Value: "val1"}}, 0)
+	testutil.Ok(t, err)
+
+	b, err := tsdb.OpenBlock(log.NewNopLogger(), filepath.Join(tmpDir, id.String()), nil)
+	testutil.Ok(t, err)
+	q, err := tsdb.NewBlockQuerier(b, math.MinInt64, math.MaxInt64)
+	testutil.Ok(t, err)
+	ss, err := q.Select(labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"))
+	testutil.Ok(t, err)
+
+	var buf bytes.Buffer
+	w := NewOpenMetricsWriter(&buf)
+	testutil.Ok(t, w.WriteSeries(ss, labels.Labels{{Name: "ext1", Value: "val1"}}, mint, maxt))
+	testutil.Ok(t, w.Close())
+	testutil.Ok(t, q.Close())
+	testutil.Ok(t, b.Close())
+	testutil.Equals(t, 2, w.Series)
+	testutil.Equals(t, 200, w.Samples)
+
+	exported := buf.Bytes()
+	testutil.Assert(t, bytes.HasSuffix(exported, []byte("\n# EOF\n")), "expected EOF at the end of export")
+
+	// Import into 2h blocks, with the external label removed from series.
+	importDir := filepath.Join(tmpDir, "import")
+	ids, err := ImportOpenMetrics(ctx, log.NewNopLogger(), exported, importDir, labels.Labels{{Name: "ext1", Value: "val1"}}, int64(2*time.Hour/time.Millisecond))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(ids))
+
+	orig := readSamples(t, filepath.Join(tmpDir, id.String()))
+	merged := map[string][]int64{}
+	for _, resid := range ids {
+		for s, ts := range readSamples(t, filepath.Join(importDir, resid.String())) {
+			merged[s] = append(merged[s], ts...)
+		}
+	}
+	delete(orig, `{__name__="other", job="a"}`)
+	testutil.Equals(t, orig, merged)
+
+	// Values of external labels have to match labels of imported series.
+	_, err = ImportOpenMetrics(ctx, log.NewNopLogger(), exported, importDir, labels.Labels{{Name: "ext1", Value: "val2"}}, int64(2*time.Hour/time.Millisecond))
+	testutil.NotOk(t, err)
+
+	// Samples have to have timestamps.
+	_, err = ImportOpenMetrics(ctx, log.NewNopLogger(), []byte("up{job=\"a\"} 1\n# EOF\n"), importDir, nil, int64(2*time.Hour/time.Millisecond))
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,302 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// DeletionRequest deletes samples of series matching all matchers within the time range, both ends inclusive.
+type DeletionRequest struct {
+	Matchers []*labels.Matcher
+	MinTime  int64
+	MaxTime  int64
+}
+
+// NewDeletionRequest returns deletion request of samples of the matching series from the whole block.
+func NewDeletionRequest(matchers ...*labels.Matcher) DeletionRequest {
+	return DeletionRequest{Matchers: matchers, MinTime: math.MinInt64, MaxTime: math.MaxInt64}
+}
+
+func (d DeletionRequest) matches(lset labels.Labels) bool {
+	for _, m := range d.Matchers {
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// RewriteStats describes changes made by rewriting a block.
+type RewriteStats struct {
+	// SeriesRelabeled is the number of series with labels changed by relabeling.
+	SeriesRelabeled int
+	// SeriesDropped is the number of series dropped by relabeling.
+	SeriesDropped int
+	// SeriesDeleted is the number of series with all samples deleted.
+	SeriesDeleted int
+	// SamplesDeleted is the number of samples removed by deletions.
+	SamplesDeleted uint64
+}
+
+// Changed returns true if rewriting made any change to the block.
+func (s RewriteStats) Changed() bool {
+	return s.SeriesRelabeled > 0 || s.SeriesDropped > 0 || s.SeriesDeleted > 0 || s.SamplesDeleted > 0
+}
+
+type rewriteSeries struct {
+	ref       uint64
+	lset      labels.Labels
+	deletions []DeletionRequest
+}
+
+// Rewrite writes a new block into dir, with series of the block with the given id relabeled by relabelConfigs and
+// samples removed by deletions. Deletions match labels of series before relabeling. Relabeling which results in
+// multiple series with the same labels is not supported, as it would require merging their samples.
+// Downsampled blocks can't be rewritten.
+func Rewrite(
+	logger log.Logger,
+	dir string,
+	id ulid.ULID,
+	source metadata.SourceType,
+	deletions []DeletionRequest,
+	relabelConfigs []*relabel.Config,
+) (resid ulid.ULID, stats RewriteStats, err error) {
+	bdir := filepath.Join(dir, id.String())
+	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))
+	resid = ulid.MustNew(ulid.Now(), entropy)
+
+	meta, err := metadata.Read(bdir)
+	if err != nil {
+		return resid, stats, errors.Wrap(err, "read meta file")
+	}
+	if meta.Thanos.Downsample.Resolution > 0 {
+		return resid, stats, errors.New("cannot rewrite downsampled block")
+	}
+
+	b, err := tsdb.OpenBlock(logger, bdir, nil)
+	if err != nil {
+		return resid, stats, errors.Wrap(err, "open block")
+	}
+	defer runutil.CloseWithErrCapture(&err, b, "rewrite block reader")
+
+	indexr, err := b.Index()
+	if err != nil {
+		return resid, stats, errors.Wrap(err, "open index")
+	}
+	defer runutil.CloseWithErrCapture(&err, indexr, "rewrite index reader")
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return resid, stats, errors.Wrap(err, "open chunks")
+	}
+	defer runutil.CloseWithErrCapture(&err, chunkr, "rewrite chunk reader")
+
+	// Labels of all series are needed to write the symbols first.
+	series, err := relabelSeries(indexr, deletions, relabelConfigs, &stats)
+	if err != nil {
+		return resid, stats, err
+	}
+
+	resdir := filepath.Join(dir, resid.String())
+
+	chunkw, err := chunks.NewWriter(filepath.Join(resdir, ChunksDirname))
+	if err != nil {
+		return resid, stats, errors.Wrap(err, "open chunk writer")
+	}
+	defer runutil.CloseWithErrCapture(&err, chunkw, "rewrite chunk writer")
+
+	indexw, err := index.NewWriter(context.TODO(), filepath.Join(resdir, IndexFilename))
+	if err != nil {
+		return resid, stats, errors.Wrap(err, "open index writer")
+	}
+	defer runutil.CloseWithErrCapture(&err, indexw, "rewrite index writer")
+
+	resmeta := *meta
+	resmeta.ULID = resid
+	resmeta.Stats = tsdb.BlockStats{}
+	resmeta.Thanos.Source = source
+	resmeta.Thanos.Files = nil
+
+	symbols := map[string]struct{}{}
+	for _, s := range series {
+		for _, l := range s.lset {
+			symbols[l.Name] = struct{}{}
+			symbols[l.Value] = struct{}{}
+		}
+	}
+	sorted := make([]string, 0, len(symbols))
+	for s := range symbols {
+		sorted = append(sorted, s)
+	}
+	sort.Strings(sorted)
+	for _, s := range sorted {
+		if err := indexw.AddSymbol(s); err != nil {
+			return resid, stats, errors.Wrap(err, "add symbol")
+		}
+	}
+
+	var (
+		i    uint64
+		chks []chunks.Meta
+	)
+	for _, s := range series {
+		if err := indexr.Series(s.ref, &labels.Labels{}, &chks); err != nil {
+			return resid, stats, errors.Wrap(err, "series")
+		}
+		var reschks []chunks.Meta
+		for _, c := range chks {
+			c.Chunk, err = chunkr.Chunk(c.Ref)
+			if err != nil {
+				return resid, stats, errors.Wrap(err, "chunk read")
+			}
+			cs, deleted, err := deleteSamples(c, s.deletions)
+			if err != nil {
+				return resid, stats, errors.Wrapf(err, "delete samples of series %s", s.lset)
+			}
+			stats.SamplesDeleted += uint64(deleted)
+			reschks = append(reschks, cs...)
+		}
+		if len(reschks) == 0 {
+			stats.SeriesDeleted++
+			continue
+		}
+
+		if err := chunkw.WriteChunks(reschks...); err != nil {
+			return resid, stats, errors.Wrap(err, "write chunks")
+		}
+		if err := indexw.AddSeries(i, s.lset, reschks...); err != nil {
+			return resid, stats, errors.Wrap(err, "add series")
+		}
+
+		resmeta.Stats.NumChunks += uint64(len(reschks))
+		resmeta.Stats.NumSeries++
+		for _, chk := range reschks {
+			resmeta.Stats.NumSamples += uint64(chk.Chunk.NumSamples())
+		}
+		i++
+	}
+
+	if err := metadata.Write(logger, resdir, &resmeta); err != nil {
+		return resid, stats, err
+	}
+	return resid, stats, nil
+}
+
+// relabelSeries returns references and new labels of all series of the index which are not dropped, in order of the
+// new labels, together with the deletions matching them.
+func relabelSeries(indexr tsdb.IndexReader, deletions []DeletionRequest, relabelConfigs []*relabel.Config, stats *RewriteStats) ([]rewriteSeries, error) {
+	all, err := indexr.Postings(index.AllPostingsKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "postings")
+	}
+
+	var (
+		series []rewriteSeries
+		chks   []chunks.Meta
+	)
+	for all.Next() {
+		var lset labels.Labels
+		if err := indexr.Series(all.At(), &lset, &chks); err != nil {
+			return nil, errors.Wrap(err, "series")
+		}
+
+		s := rewriteSeries{ref: all.At(), lset: lset}
+		for _, d := range deletions {
+			if d.matches(lset) {
+				s.deletions = append(s.deletions, d)
+			}
+		}
+		if len(relabelConfigs) > 0 {
+			s.lset = relabel.Process(lset, relabelConfigs...)
+			// Series without labels are dropped, like by Prometheus.
+			if len(s.lset) == 0 {
+				stats.SeriesDropped++
+				continue
+			}
+			if !labels.Equal(lset, s.lset) {
+				stats.SeriesRelabeled++
+			}
+		}
+		series = append(series, s)
+	}
+	if all.Err() != nil {
+		return nil, errors.Wrap(all.Err(), "iterate series")
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		return labels.Compare(series[i].lset, series[j].lset) < 0
+	})
+	for i := 1; i < len(series); i++ {
+		if labels.Equal(series[i-1].lset, series[i].lset) {
+			return nil, errors.Errorf("relabeling results in multiple series with labels %s", series[i].lset)
+		}
+	}
+	return series, nil
+}
+
+// deleteSamples returns the chunk with samples within time ranges of deletions removed, and the number of removed
+// samples. Chunks not overlapping with deletions are returned as they are.
+func deleteSamples(c chunks.Meta, deletions []DeletionRequest) ([]chunks.Meta, int, error) {
+	var overlapping []DeletionRequest
+	for _, d := range deletions {
+		if d.MinTime <= c.MaxTime && d.MaxTime >= c.MinTime {
+			overlapping = append(overlapping, d)
+		}
+	}
+	if len(overlapping) == 0 {
+		return []chunks.Meta{c}, 0, nil
+	}
+
+	var (
+		res     = chunks.Meta{MinTime: math.MaxInt64, MaxTime: math.MinInt64}
+		resc    = chunkenc.NewXORChunk()
+		deleted = 0
+	)
+	app, err := resc.Appender()
+	if err != nil {
+		return nil, 0, err
+	}
+	it := c.Chunk.Iterator(nil)
+Samples:
+	for it.Next() {
+		t, v := it.At()
+		for _, d := range overlapping {
+			if t >= d.MinTime && t <= d.MaxTime {
+				deleted++
+				continue Samples
+			}
+		}
+		app.Append(t, v)
+		if t < res.MinTime {
+			res.MinTime = t
+		}
+		res.MaxTime = t
+	}
+	if it.Err() != nil {
+		return nil, 0, errors.Wrap(it.Err(), "iterate chunk")
+	}
+	if resc.NumSamples() == 0 {
+		return nil, deleted, nil
+	}
+	res.Chunk = resc
+	return []chunks.Meta{res}, deleted, nil
+}
@@ -0,0 +1,123 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+)
+
+func TestRewrite_DeletionsAndRelabeling(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-block-rewrite")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	// Samples at 0, 100, ..., 900.
+	id, err := e2eutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		labels.FromStrings("a", "1", "b", "1"),
+		labels.FromStrings("a", "2", "b", "1"),
+		labels.FromStrings("a", "3", "b", "1"),
+		labels.FromStrings("a", "4", "b", "1"),
+	}, 10, 0, 1100, labels.Labels{{Name: "ext1", Value: "val1"}}, 0)
+	testutil.Ok(t, err)
+
+	deleteRange := NewDeletionRequest(labels.MustNewMatcher(labels.MatchEqual, "a", "1"))
+	deleteRange.MinTime, deleteRange.MaxTime = 200, 500
+	relabelConfigs := []*relabel.Config{
+		{Action: relabel.Drop, SourceLabels: model.LabelNames{"a"}, Regex: relabel.MustNewRegexp("3"), Separator: ";"},
+		{Action: relabel.LabelDrop, Regex: relabel.MustNewRegexp("b")},
+	}
+
+	resid, stats, err := Rewrite(log.NewNopLogger(), tmpDir, id, metadata.BucketRewriteSource, []DeletionRequest{
+		deleteRange,
+		NewDeletionRequest(labels.MustNewMatcher(labels.MatchEqual, "a", "2")),
+	}, relabelConfigs)
+	testutil.Ok(t, err)
+	testutil.Equals(t, RewriteStats{SeriesRelabeled: 3, SeriesDropped: 1, SeriesDeleted: 1, SamplesDeleted: 14}, stats)
+
+	meta, err := metadata.Read(filepath.Join(tmpDir, resid.String()))
+	testutil.Ok(t, err)
+	testutil.Equals(t, metadata.BucketRewriteSource, meta.Thanos.Source)
+	testutil.Equals(t, uint64(2), meta.Stats.NumSeries)
+	testutil.Equals(t, uint64(16), meta.Stats.NumSamples)
+
+	testutil.Equals(t, map[string][]int64{
+		`{a="1"}`: {0, 100, 600, 700, 800, 900},
+		`{a="4"}`: {0, 100, 200, 300, 400, 500, 600, 700, 800, 900},
+	}, readSamples(t, filepath.Join(tmpDir, resid.String())))
+
+	// Relabeling to the same labels is not supported.
+	_, _, err = Rewrite(log.NewNopLogger(), tmpDir, id, metadata.BucketRewriteSource, nil, []*relabel.Config{
+		{Action: relabel.LabelDrop, Regex: relabel.MustNewRegexp("a")},
+	})
+	testutil.NotOk(t, err)
+
+	// Series without any label left are dropped.
+	_, stats, err = Rewrite(log.NewNopLogger(), tmpDir, id, metadata.BucketRewriteSource, nil, []*relabel.Config{
+		{Action: relabel.LabelDrop, Regex: relabel.MustNewRegexp("a|b")},
+	})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 4, stats.SeriesDropped)
+
+	// No changes.
+	_, stats, err = Rewrite(log.NewNopLogger(), tmpDir, id, metadata.BucketRewriteSource, []DeletionRequest{
+		NewDeletionRequest(labels.MustNewMatcher(labels.MatchEqual, "a", "5")),
+	}, nil)
+	testutil.Ok(t, err)
+	testutil.Assert(t, !stats.Changed(), "expected no changes")
+}
+
+// readSamples returns timestamps of samples of all series of the block.
+func readSamples(t *testing.T, dir string) map[string][]int64 {
+	b, err := tsdb.OpenBlock(log.NewNopLogger(), dir, nil)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, b.Close()) }()
+
+	indexr, err := b.Index()
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, indexr.Close()) }()
+	chunkr, err := b.Chunks()
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, chunkr.Close()) }()
+
+	all, err := indexr.Postings(index.AllPostingsKey())
+	testutil.Ok(t, err)
+
+	res := map[string][]int64{}
+	for all.Next() {
+		var (
+			lset labels.Labels
+			chks []chunks.Meta
+		)
+		testutil.Ok(t, indexr.Series(all.At(), &lset, &chks))
+		for _, c := range chks {
+			chk, err := chunkr.Chunk(c.Ref)
+			testutil.Ok(t, err)
+			it := chk.Iterator(nil)
+			for it.Next() {
+				ts, _ := it.At()
+				res[lset.String()] = append(res[lset.String()], ts)
+			}
+			testutil.Ok(t, it.Err())
+		}
+	}
+	testutil.Ok(t, all.Err())
+	return res
+}
@@ -0,0 +1,309 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// SplitBy is the way a block is split into multiple blocks.
+type SplitBy string
+
+const (
+	// SplitByTime splits the block into blocks of consecutive time ranges of equal length. Chunks crossing borders of
+	// the time ranges are cut.
+	SplitByTime SplitBy = "time"
+	// SplitBySeries splits series of the block between blocks by the hash of their labels. All blocks cover the time
+	// range of the original block, so they overlap each other.
+	SplitBySeries SplitBy = "series"
+)
+
+// splitPart is a block written by Split.
+type splitPart struct {
+	dir              string
+	meta             metadata.Meta
+	symbols          map[string]struct{}
+	chunkw           *chunks.Writer
+	indexw           *index.Writer
+	minTime, maxTime int64
+	refs             uint64
+}
+
+func (p *splitPart) open() (err error) {
+	if p.chunkw, err = chunks.NewWriter(filepath.Join(p.dir, ChunksDirname)); err != nil {
+		return errors.Wrap(err, "open chunk writer")
+	}
+	if p.indexw, err = index.NewWriter(context.TODO(), filepath.Join(p.dir, IndexFilename)); err != nil {
+		return errors.Wrap(err, "open index writer")
+	}
+
+	sorted := make([]string, 0, len(p.symbols))
+	for s := range p.symbols {
+		sorted = append(sorted, s)
+	}
+	sort.Strings(sorted)
+	for _, s := range sorted {
+		if err := p.indexw.AddSymbol(s); err != nil {
+			return errors.Wrap(err, "add symbol")
+		}
+	}
+	return nil
+}
+
+// Close closes writers of the part, if still open.
+func (p *splitPart) Close() error {
+	var errs []error
+	if p.chunkw != nil {
+		errs = append(errs, p.chunkw.Close())
+		p.chunkw = nil
+	}
+	if p.indexw != nil {
+		errs = append(errs, p.indexw.Close())
+		p.indexw = nil
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Split writes blocks into dir, with data of the block with the given id split into n parts. Produced blocks keep
+// external labels, resolution and compaction level of the original block, and have it as their parent. Parts without
+// any series are not written, so fewer than n blocks may be returned.
+// Downsampled blocks can be split by series only.
+func Split(logger log.Logger, dir string, id ulid.ULID, source metadata.SourceType, by SplitBy, n int) (resids []ulid.ULID, err error) {
+	if n < 2 {
+		return nil, errors.Errorf("block has to be split into at least 2 parts, got %d", n)
+	}
+	if by != SplitByTime && by != SplitBySeries {
+		return nil, errors.Errorf("unknown split %q", by)
+	}
+
+	bdir := filepath.Join(dir, id.String())
+	meta, err := metadata.Read(bdir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read meta file")
+	}
+	if by == SplitByTime && meta.Thanos.Downsample.Resolution > 0 {
+		return nil, errors.New("cannot split downsampled block by time")
+	}
+
+	b, err := tsdb.OpenBlock(logger, bdir, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "open block")
+	}
+	defer runutil.CloseWithErrCapture(&err, b, "split block reader")
+
+	indexr, err := b.Index()
+	if err != nil {
+		return nil, errors.Wrap(err, "open index")
+	}
+	defer runutil.CloseWithErrCapture(&err, indexr, "split index reader")
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return nil, errors.Wrap(err, "open chunks")
+	}
+	defer runutil.CloseWithErrCapture(&err, chunkr, "split chunk reader")
+
+	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))
+	parts := make([]*splitPart, n)
+	step := (meta.MaxTime - meta.MinTime + int64(n) - 1) / int64(n)
+	for i := range parts {
+		p := &splitPart{meta: *meta, symbols: map[string]struct{}{}, minTime: meta.MinTime, maxTime: meta.MaxTime}
+		if by == SplitByTime {
+			p.minTime = meta.MinTime + int64(i)*step
+			p.maxTime = p.minTime + step
+			if p.maxTime > meta.MaxTime {
+				p.maxTime = meta.MaxTime
+			}
+		}
+		p.meta.ULID = ulid.MustNew(ulid.Now(), entropy)
+		p.meta.MinTime, p.meta.MaxTime = p.minTime, p.maxTime
+		p.meta.Stats = tsdb.BlockStats{}
+		p.meta.Compaction.Parents = []tsdb.BlockDesc{{ULID: meta.ULID, MinTime: meta.MinTime, MaxTime: meta.MaxTime}}
+		p.meta.Thanos.Source = source
+		p.meta.Thanos.Files = nil
+		p.dir = filepath.Join(dir, p.meta.ULID.String())
+		parts[i] = p
+	}
+	defer func() {
+		for _, p := range parts {
+			runutil.CloseWithErrCapture(&err, p, "split part writers")
+		}
+	}()
+
+	// Symbols of series of each part have to be written first.
+	if err := forEachSeries(indexr, func(lset labels.Labels, chks []chunks.Meta) error {
+		for _, p := range splitTargets(parts, by, lset, chks) {
+			for _, l := range lset {
+				p.symbols[l.Name] = struct{}{}
+				p.symbols[l.Value] = struct{}{}
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for _, p := range parts {
+		if len(p.symbols) == 0 {
+			continue
+		}
+		if err := p.open(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := forEachSeries(indexr, func(lset labels.Labels, chks []chunks.Meta) error {
+		for i := range chks {
+			c, err := chunkr.Chunk(chks[i].Ref)
+			if err != nil {
+				return errors.Wrap(err, "chunk read")
+			}
+			chks[i].Chunk = c
+		}
+		for _, p := range splitTargets(parts, by, lset, chks) {
+			reschks, err := cutChunks(chks, p.minTime, p.maxTime)
+			if err != nil {
+				return errors.Wrapf(err, "cut chunks of series %s", lset)
+			}
+			if len(reschks) == 0 {
+				continue
+			}
+			if err := p.chunkw.WriteChunks(reschks...); err != nil {
+				return errors.Wrap(err, "write chunks")
+			}
+			if err := p.indexw.AddSeries(p.refs, lset, reschks...); err != nil {
+				return errors.Wrap(err, "add series")
+			}
+			p.refs++
+
+			p.meta.Stats.NumSeries++
+			p.meta.Stats.NumChunks += uint64(len(reschks))
+			for _, c := range reschks {
+				p.meta.Stats.NumSamples += uint64(c.Chunk.NumSamples())
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, p := range parts {
+		if err := p.Close(); err != nil {
+			return nil, errors.Wrap(err, "close part writers")
+		}
+		if p.meta.Stats.NumSeries == 0 {
+			if err := os.RemoveAll(p.dir); err != nil {
+				return nil, errors.Wrap(err, "remove empty part")
+			}
+			continue
+		}
+		if err := metadata.Write(logger, p.dir, &p.meta); err != nil {
+			return nil, err
+		}
+		resids = append(resids, p.meta.ULID)
+	}
+	return resids, nil
+}
+
+// forEachSeries calls f for all series of the index, in order of their labels.
+func forEachSeries(indexr tsdb.IndexReader, f func(lset labels.Labels, chks []chunks.Meta) error) error {
+	all, err := indexr.Postings(index.AllPostingsKey())
+	if err != nil {
+		return errors.Wrap(err, "postings")
+	}
+	for all.Next() {
+		var (
+			lset labels.Labels
+			chks []chunks.Meta
+		)
+		if err := indexr.Series(all.At(), &lset, &chks); err != nil {
+			return errors.Wrap(err, "series")
+		}
+		if err := f(lset, chks); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(all.Err(), "iterate series")
+}
+
+// splitTargets returns parts the series with the given chunks is written to.
+func splitTargets(parts []*splitPart, by SplitBy, lset labels.Labels, chks []chunks.Meta) []*splitPart {
+	if by == SplitBySeries {
+		return []*splitPart{parts[lset.Hash()%uint64(len(parts))]}
+	}
+	var res []*splitPart
+	for _, p := range parts {
+		for _, c := range chks {
+			if c.MinTime < p.maxTime && c.MaxTime >= p.minTime {
+				res = append(res, p)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// cutChunks returns chunks with samples within [mint, maxt). Chunks within the range are returned as they are,
+// chunks crossing its borders are re-encoded.
+func cutChunks(chks []chunks.Meta, mint, maxt int64) ([]chunks.Meta, error) {
+	var res []chunks.Meta
+	for _, c := range chks {
+		if c.MaxTime < mint || c.MinTime >= maxt {
+			continue
+		}
+		if c.MinTime >= mint && c.MaxTime < maxt {
+			res = append(res, c)
+			continue
+		}
+
+		cut := chunks.Meta{MinTime: math.MaxInt64, MaxTime: math.MinInt64}
+		xor := chunkenc.NewXORChunk()
+		app, err := xor.Appender()
+		if err != nil {
+			return nil, err
+		}
+		it := c.Chunk.Iterator(nil)
+		for it.Next() {
+			t, v := it.At()
+			if t < mint || t >= maxt {
+				continue
+			}
+			app.Append(t, v)
+			if t < cut.MinTime {
+				cut.MinTime = t
+			}
+			cut.MaxTime = t
+		}
+		if it.Err() != nil {
+			return nil, errors.Wrap(it.Err(), "iterate chunk")
+		}
+		if xor.NumSamples() == 0 {
+			continue
+		}
+		cut.Chunk = xor
+		res = append(res, cut)
+	}
+	return res, nil
+}
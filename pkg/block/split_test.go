@@ -0,0 +1,94 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+)
+
+func TestSplit(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-block-split")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	var series []labels.Labels
+	for i := 0; i < 20; i++ {
+		series = append(series, labels.FromStrings("a", string(rune('a'+i))))
+	}
+	extLset := labels.Labels{{Name: "ext1", Value: "val1"}}
+	id, err := e2eutil.CreateBlock(ctx, tmpDir, series, 10, 0, 1000, extLset, 0)
+	testutil.Ok(t, err)
+	orig := readSamples(t, filepath.Join(tmpDir, id.String()))
+
+	t.Run("by time", func(t *testing.T) {
+		ids, err := Split(log.NewNopLogger(), tmpDir, id, metadata.BucketSplitSource, SplitByTime, 3)
+		testutil.Ok(t, err)
+		testutil.Equals(t, 3, len(ids))
+
+		merged := map[string][]int64{}
+		var prevMax int64
+		for _, resid := range ids {
+			meta, err := metadata.Read(filepath.Join(tmpDir, resid.String()))
+			testutil.Ok(t, err)
+			testutil.Equals(t, metadata.BucketSplitSource, meta.Thanos.Source)
+			testutil.Equals(t, extLset.Map(), meta.Thanos.Labels)
+			testutil.Equals(t, id, meta.Compaction.Parents[0].ULID)
+			testutil.Equals(t, prevMax, meta.MinTime)
+			testutil.Equals(t, uint64(20), meta.Stats.NumSeries)
+			prevMax = meta.MaxTime
+
+			for s, ts := range readSamples(t, filepath.Join(tmpDir, resid.String())) {
+				for _, v := range ts {
+					testutil.Assert(t, v >= meta.MinTime && v < meta.MaxTime, "sample %d of %s out of block range", v, s)
+				}
+				merged[s] = append(merged[s], ts...)
+			}
+		}
+		testutil.Equals(t, int64(1000), prevMax)
+		testutil.Equals(t, orig, merged)
+	})
+
+	t.Run("by series", func(t *testing.T) {
+		ids, err := Split(log.NewNopLogger(), tmpDir, id, metadata.BucketSplitSource, SplitBySeries, 4)
+		testutil.Ok(t, err)
+		testutil.Assert(t, len(ids) > 1, "expected multiple blocks, got %d", len(ids))
+
+		merged := map[string][]int64{}
+		var numSeries uint64
+		for _, resid := range ids {
+			meta, err := metadata.Read(filepath.Join(tmpDir, resid.String()))
+			testutil.Ok(t, err)
+			testutil.Equals(t, int64(0), meta.MinTime)
+			testutil.Equals(t, int64(1000), meta.MaxTime)
+			numSeries += meta.Stats.NumSeries
+
+			for s, ts := range readSamples(t, filepath.Join(tmpDir, resid.String())) {
+				_, ok := merged[s]
+				testutil.Assert(t, !ok, "series %s in multiple blocks", s)
+				merged[s] = ts
+			}
+		}
+		testutil.Equals(t, uint64(20), numSeries)
+		testutil.Equals(t, orig, merged)
+	})
+
+	t.Run("downsampled by time", func(t *testing.T) {
+		dsid, err := e2eutil.CreateBlock(ctx, tmpDir, series[:1], 10, 0, 1000, extLset, 300000)
+		testutil.Ok(t, err)
+		_, err = Split(log.NewNopLogger(), tmpDir, dsid, metadata.BucketSplitSource, SplitByTime, 2)
+		testutil.NotOk(t, err)
+	})
+}
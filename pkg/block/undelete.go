@@ -0,0 +1,107 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// DeletedBlock is a block deleted from the bucket with object versioning, which still has previous versions of its files.
+type DeletedBlock struct {
+	ID ulid.ULID
+	// Files are the most recent versions of deleted files of the block.
+	Files []objstore.ObjectVersion
+}
+
+// Restorable returns true if meta.json of the block can be restored, so the block is valid after restoring its files.
+func (b DeletedBlock) Restorable() bool {
+	for _, f := range b.Files {
+		if f.Name == path.Join(b.ID.String(), MetaFilename) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeletedBlocks returns blocks whose files were deleted but previous versions of them are kept by the bucket. If ids are
+// given, only these blocks are checked.
+func DeletedBlocks(ctx context.Context, bkt objstore.VersionedBucket, ids ...ulid.ULID) ([]DeletedBlock, error) {
+	prefixes := []string{""}
+	if len(ids) > 0 {
+		prefixes = prefixes[:0]
+		for _, id := range ids {
+			prefixes = append(prefixes, id.String()+objstore.DirDelim)
+		}
+	}
+
+	blocks := map[ulid.ULID]*DeletedBlock{}
+	for _, prefix := range prefixes {
+		deleted, err := objstore.DeletedObjects(ctx, bkt, prefix)
+		if err != nil {
+			return nil, errors.Wrapf(err, "find deleted objects with prefix %q", prefix)
+		}
+		for _, v := range deleted {
+			id, ok := IsBlockDir(strings.SplitN(v.Name, objstore.DirDelim, 2)[0])
+			if !ok {
+				continue
+			}
+			// Restored deletion mark would make compactor delete the block again.
+			if path.Base(v.Name) == metadata.DeletionMarkFilename {
+				continue
+			}
+			if _, ok := blocks[id]; !ok {
+				blocks[id] = &DeletedBlock{ID: id}
+			}
+			blocks[id].Files = append(blocks[id].Files, v)
+		}
+	}
+
+	res := make([]DeletedBlock, 0, len(blocks))
+	for _, b := range blocks {
+		res = append(res, *b)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID.Compare(res[j].ID) < 0 })
+	return res, nil
+}
+
+// Undelete restores previous versions of deleted files of the block. The meta.json is restored last, so interrupted
+// restore leaves a partial block, like interrupted upload. Deletion mark of the block is removed, if still present.
+func Undelete(ctx context.Context, logger log.Logger, bkt objstore.VersionedBucket, b DeletedBlock) error {
+	if !b.Restorable() {
+		return errors.Errorf("no previous version of %s of block %s found", MetaFilename, b.ID)
+	}
+
+	var meta objstore.ObjectVersion
+	for _, f := range b.Files {
+		if path.Base(f.Name) == MetaFilename {
+			meta = f
+			continue
+		}
+		if err := bkt.RestoreVersion(ctx, f); err != nil {
+			return errors.Wrapf(err, "restore %s", f.Name)
+		}
+		level.Debug(logger).Log("msg", "restored file", "file", f.Name, "version", f.VersionID, "bucket", bkt.Name())
+	}
+
+	markFile := path.Join(b.ID.String(), metadata.DeletionMarkFilename)
+	if err := bkt.Delete(ctx, markFile); err != nil && !bkt.IsObjNotFoundErr(err) {
+		return errors.Wrapf(err, "delete %s", markFile)
+	}
+
+	if err := bkt.RestoreVersion(ctx, meta); err != nil {
+		return errors.Wrapf(err, "restore %s", meta.Name)
+	}
+	level.Info(logger).Log("msg", "restored block", "id", b.ID, "files", len(b.Files), "bucket", bkt.Name())
+	return nil
+}
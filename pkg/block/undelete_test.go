@@ -0,0 +1,144 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package block
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+)
+
+type version struct {
+	objstore.ObjectVersion
+	content []byte
+}
+
+// versionedBucket keeps all versions of objects, like buckets with object versioning enabled.
+type versionedBucket struct {
+	*inmem.Bucket
+
+	versions map[string][]version
+}
+
+func (b *versionedBucket) add(name string, content []byte, deleteMarker bool) {
+	vs := b.versions[name]
+	b.versions[name] = append(vs, version{
+		ObjectVersion: objstore.ObjectVersion{
+			Name:         name,
+			VersionID:    strconv.Itoa(len(vs)),
+			LastModified: time.Unix(int64(len(vs)), 0),
+			Size:         int64(len(content)),
+			DeleteMarker: deleteMarker,
+		},
+		content: content,
+	})
+}
+
+func (b *versionedBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	b.add(name, content, false)
+	return b.Bucket.Upload(ctx, name, bytes.NewReader(content))
+}
+
+func (b *versionedBucket) Delete(ctx context.Context, name string) error {
+	if err := b.Bucket.Delete(ctx, name); err != nil {
+		return err
+	}
+	b.add(name, nil, true)
+	return nil
+}
+
+func (b *versionedBucket) IterVersions(_ context.Context, prefix string, f func(objstore.ObjectVersion) error) error {
+	var names []string
+	for name := range b.versions {
+		if len(name) >= len(prefix) && name[:len(prefix)] == prefix {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		vs := b.versions[name]
+		for i := len(vs) - 1; i >= 0; i-- {
+			v := vs[i].ObjectVersion
+			v.Latest = i == len(vs)-1
+			if err := f(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *versionedBucket) RestoreVersion(ctx context.Context, v objstore.ObjectVersion) error {
+	id, err := strconv.Atoi(v.VersionID)
+	if err != nil {
+		return err
+	}
+	return b.Upload(ctx, v.Name, bytes.NewReader(b.versions[v.Name][id].content))
+}
+
+func TestUndelete(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-block-undelete")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	bkt := &versionedBucket{Bucket: inmem.NewBucket(), versions: map[string][]version{}}
+	b1, err := e2eutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		{{Name: "a", Value: "1"}},
+		{{Name: "b", Value: "1"}},
+	}, 100, 0, 1000, labels.Labels{{Name: "ext1", Value: "val1"}}, 124)
+	testutil.Ok(t, err)
+	testutil.Ok(t, Upload(ctx, log.NewNopLogger(), bkt, path.Join(tmpDir, b1.String())))
+	uploaded := map[string][]byte{}
+	for name, content := range bkt.Objects() {
+		uploaded[name] = content
+	}
+
+	// Live blocks are not reported.
+	deleted, err := DeletedBlocks(ctx, bkt)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(deleted))
+
+	testutil.Ok(t, MarkForDeletion(ctx, log.NewNopLogger(), bkt, b1))
+	testutil.Ok(t, Delete(ctx, log.NewNopLogger(), bkt, b1))
+
+	deleted, err = DeletedBlocks(ctx, bkt)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(deleted))
+	testutil.Equals(t, b1, deleted[0].ID)
+	// Chunks, index and meta.json, without the deletion mark.
+	testutil.Equals(t, 3, len(deleted[0].Files))
+	testutil.Assert(t, deleted[0].Restorable(), "block should be restorable")
+
+	testutil.Ok(t, Undelete(ctx, log.NewNopLogger(), bkt, deleted[0]))
+	testutil.Equals(t, uploaded, bkt.Objects())
+
+	ok, err := bkt.Exists(ctx, path.Join(b1.String(), metadata.DeletionMarkFilename))
+	testutil.Ok(t, err)
+	testutil.Assert(t, !ok, "deletion mark should not be restored")
+
+	deleted, err = DeletedBlocks(ctx, bkt, b1)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(deleted))
+}
@@ -23,9 +23,13 @@ import (
 )
 
 const (
-	opSet             = "set"
-	opGetMulti        = "getmulti"
-	reasonMaxItemSize = "max-item-size"
+	opSet                 = "set"
+	opGetMulti            = "getmulti"
+	reasonMaxItemSize     = "max-item-size"
+	reasonAsyncBufferFull = "async-buffer-full"
+
+	queueRegular  = "regular"
+	queuePriority = "priority"
 )
 
 var (
@@ -84,9 +88,16 @@ type MemcachedClientConfig struct {
 	MaxAsyncConcurrency int `yaml:"max_async_concurrency"`
 
 	// MaxAsyncBufferSize specifies the maximum number of enqueued asynchronous
-	// operations allowed.
+	// operations allowed. Operations enqueued when the buffer is full are dropped.
 	MaxAsyncBufferSize int `yaml:"max_async_buffer_size"`
 
+	// MaxAsyncPriorityItemSize specifies the maximum size of items, whose asynchronous
+	// store operations are enqueued in a separate buffer of MaxAsyncBufferSize
+	// operations, and processed before operations storing bigger items. This way small
+	// items, which are cheap to store, are not dropped when the buffer is full of big
+	// ones. If set to 0, operations are processed in order they were enqueued.
+	MaxAsyncPriorityItemSize model.Bytes `yaml:"max_async_priority_item_size"`
+
 	// MaxGetMultiConcurrency specifies the maximum number of concurrent connections
 	// running GetMulti() operations. If set to 0, concurrency is unlimited.
 	MaxGetMultiConcurrency int `yaml:"max_get_multi_concurrency"`
@@ -104,6 +115,12 @@ type MemcachedClientConfig struct {
 
 	// DNSProviderUpdateInterval specifies the DNS discovery update interval.
 	DNSProviderUpdateInterval time.Duration `yaml:"dns_provider_update_interval"`
+
+	// AutoDiscovery configures the client to treat the resolved addresses as
+	// configuration endpoints of a memcached cluster (ie. AWS ElastiCache), and
+	// to use the nodes they return for the "config get cluster" command as memcached
+	// servers. Nodes are rediscovered every DNSProviderUpdateInterval.
+	AutoDiscovery bool `yaml:"auto_discovery"`
 }
 
 func (c *MemcachedClientConfig) validate() error {
@@ -136,8 +153,10 @@ type memcachedClient struct {
 	// Channel used to notify internal goroutines when they should quit.
 	stop chan struct{}
 
-	// Channel used to enqueue async operations.
-	asyncQueue chan func()
+	// Channels used to enqueue async operations. Operations from the priority
+	// queue are processed first.
+	asyncQueue         chan func()
+	asyncPriorityQueue chan func()
 
 	// Gate used to enforce the max number of concurrent GetMulti() operations.
 	getMultiGate *gate.Gate
@@ -150,6 +169,8 @@ type memcachedClient struct {
 	failures   *prometheus.CounterVec
 	skipped    *prometheus.CounterVec
 	duration   *prometheus.HistogramVec
+
+	asyncQueueLength *prometheus.GaugeVec
 }
 
 type memcachedGetMultiResult struct {
@@ -230,6 +251,28 @@ func newMemcachedClient(
 		ConstLabels: prometheus.Labels{"name": name},
 	}, []string{"operation", "reason"})
 
+	if config.MaxAsyncPriorityItemSize > 0 {
+		c.asyncPriorityQueue = make(chan func(), config.MaxAsyncBufferSize)
+	}
+
+	c.asyncQueueLength = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "thanos_memcached_async_queue_length",
+		Help:        "Number of asynchronous operations enqueued and not yet processed.",
+		ConstLabels: prometheus.Labels{"name": name},
+	}, []string{"queue"})
+	c.asyncQueueLength.WithLabelValues(queueRegular)
+	if c.asyncPriorityQueue != nil {
+		c.asyncQueueLength.WithLabelValues(queuePriority)
+	}
+
+	_ = promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "thanos_memcached_async_queue_capacity",
+		Help:        "Maximum number of asynchronous operations enqueued in a single queue.",
+		ConstLabels: prometheus.Labels{"name": name},
+	}, func() float64 {
+		return float64(config.MaxAsyncBufferSize)
+	})
+
 	c.duration = promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
 		Name:        "thanos_memcached_operation_duration_seconds",
 		Help:        "Duration of operations against memcached.",
@@ -271,7 +314,8 @@ func (c *memcachedClient) SetAsync(ctx context.Context, key string, value []byte
 		return nil
 	}
 
-	return c.enqueueAsync(func() {
+	priority := c.asyncPriorityQueue != nil && uint64(len(value)) <= uint64(c.config.MaxAsyncPriorityItemSize)
+	return c.enqueueAsync(priority, func() {
 		start := time.Now()
 		c.operations.WithLabelValues(opSet).Inc()
 
@@ -408,11 +452,24 @@ func (c *memcachedClient) getMultiSingle(ctx context.Context, keys []string) (it
 	return items, err
 }
 
-func (c *memcachedClient) enqueueAsync(op func()) error {
+// enqueueAsync enqueues the operation, to the priority queue if requested. The operation
+// is dropped if the queue is full.
+func (c *memcachedClient) enqueueAsync(priority bool, op func()) error {
+	queue, name := c.asyncQueue, queueRegular
+	if priority {
+		queue, name = c.asyncPriorityQueue, queuePriority
+	}
+
+	// The length is increased first, so it never goes negative when the operation
+	// gets processed right after being enqueued.
+	length := c.asyncQueueLength.WithLabelValues(name)
+	length.Inc()
 	select {
-	case c.asyncQueue <- op:
+	case queue <- op:
 		return nil
 	default:
+		length.Dec()
+		c.skipped.WithLabelValues(opSet, reasonAsyncBufferFull).Inc()
 		return errMemcachedAsyncBufferFull
 	}
 }
@@ -421,8 +478,24 @@ func (c *memcachedClient) asyncQueueProcessLoop() {
 	defer c.workers.Done()
 
 	for {
+		// Process priority operations first, if any. Receiving from the nil
+		// priority queue blocks, so there is no priority if it is disabled.
+		select {
+		case op := <-c.asyncPriorityQueue:
+			c.asyncQueueLength.WithLabelValues(queuePriority).Dec()
+			op()
+			continue
+		case <-c.stop:
+			return
+		default:
+		}
+
 		select {
+		case op := <-c.asyncPriorityQueue:
+			c.asyncQueueLength.WithLabelValues(queuePriority).Dec()
+			op()
 		case op := <-c.asyncQueue:
+			c.asyncQueueLength.WithLabelValues(queueRegular).Dec()
 			op()
 		case <-c.stop:
 			return
@@ -462,5 +535,37 @@ func (c *memcachedClient) resolveAddrs() error {
 		return errors.New("no server address resolved")
 	}
 
+	if c.config.AutoDiscovery {
+		var err error
+		if servers, err = c.discoverServers(ctx, servers); err != nil {
+			return err
+		}
+	}
+
 	return c.selector.SetServers(servers...)
 }
+
+// discoverServers returns addresses of memcached cluster nodes, as returned by the
+// first of the given configuration endpoints responding with the cluster config.
+func (c *memcachedClient) discoverServers(ctx context.Context, endpoints []string) ([]string, error) {
+	var lastErr error
+	for _, endpoint := range endpoints {
+		config, err := discoverMemcachedCluster(ctx, endpoint, c.config.Timeout)
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "failed to discover memcached cluster nodes", "endpoint", endpoint, "err", err)
+			lastErr = err
+			continue
+		}
+		if len(config.nodes) == 0 {
+			lastErr = errors.Errorf("no nodes returned by configuration endpoint %s", endpoint)
+			continue
+		}
+
+		servers := make([]string, 0, len(config.nodes))
+		for _, n := range config.nodes {
+			servers = append(servers, n.addr())
+		}
+		return servers, nil
+	}
+	return nil, errors.Wrap(lastErr, "discover memcached cluster nodes")
+}
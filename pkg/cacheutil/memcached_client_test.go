@@ -163,6 +163,45 @@ func TestMemcachedClient_SetAsyncWithCustomMaxItemSize(t *testing.T) {
 	testutil.Equals(t, 1.0, prom_testutil.ToFloat64(client.skipped.WithLabelValues(opSet, reasonMaxItemSize)))
 }
 
+func TestMemcachedClient_SetAsyncWithPriority(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx := context.Background()
+	config := defaultMemcachedClientConfig
+	config.Addresses = []string{"127.0.0.1:11211"}
+	config.MaxAsyncConcurrency = 1
+	config.MaxAsyncBufferSize = 1
+	config.MaxAsyncPriorityItemSize = 5
+	backendMock := newMemcachedClientBackendMock()
+	backendMock.setStarted = make(chan struct{}, 10)
+	backendMock.setRelease = make(chan struct{})
+
+	client, err := prepare(config, backendMock)
+	testutil.Ok(t, err)
+	defer client.Stop()
+
+	// The only worker is blocked storing the first item.
+	testutil.Ok(t, client.SetAsync(ctx, "big-1", []byte("big-value-1"), time.Second))
+	<-backendMock.setStarted
+
+	testutil.Ok(t, client.SetAsync(ctx, "big-2", []byte("big-value-2"), time.Second))
+	testutil.Equals(t, errMemcachedAsyncBufferFull, client.SetAsync(ctx, "big-3", []byte("big-value-3"), time.Second))
+	testutil.Ok(t, client.SetAsync(ctx, "small", []byte("s"), time.Second))
+	testutil.Equals(t, 1.0, prom_testutil.ToFloat64(client.skipped.WithLabelValues(opSet, reasonAsyncBufferFull)))
+	testutil.Equals(t, 1.0, prom_testutil.ToFloat64(client.asyncQueueLength.WithLabelValues(queueRegular)))
+	testutil.Equals(t, 1.0, prom_testutil.ToFloat64(client.asyncQueueLength.WithLabelValues(queuePriority)))
+
+	close(backendMock.setRelease)
+	testutil.Ok(t, backendMock.waitItems(3))
+
+	// The small item enqueued later is stored before the big one.
+	backendMock.lock.Lock()
+	testutil.Equals(t, []string{"big-1", "small", "big-2"}, backendMock.setOrder)
+	backendMock.lock.Unlock()
+	testutil.Equals(t, 0.0, prom_testutil.ToFloat64(client.asyncQueueLength.WithLabelValues(queueRegular)))
+	testutil.Equals(t, 0.0, prom_testutil.ToFloat64(client.asyncQueueLength.WithLabelValues(queuePriority)))
+}
+
 func TestMemcachedClient_GetMulti(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -388,6 +427,11 @@ type memcachedClientBackendMock struct {
 	items          map[string]*memcache.Item
 	getMultiCount  int
 	getMultiErrors int
+
+	// If set, Set() signals setStarted and blocks until setRelease is closed.
+	setStarted chan struct{}
+	setRelease chan struct{}
+	setOrder   []string
 }
 
 func newMemcachedClientBackendMock() *memcachedClientBackendMock {
@@ -416,10 +460,16 @@ func (c *memcachedClientBackendMock) GetMulti(keys []string) (map[string]*memcac
 }
 
 func (c *memcachedClientBackendMock) Set(item *memcache.Item) error {
+	if c.setStarted != nil {
+		c.setStarted <- struct{}{}
+		<-c.setRelease
+	}
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
 	c.items[item.Key] = item
+	c.setOrder = append(c.setOrder, item.Key)
 
 	return nil
 }
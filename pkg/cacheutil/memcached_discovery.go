@@ -0,0 +1,139 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package cacheutil
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// memcachedClusterConfig is the cluster config returned by the "config get cluster" command
+// of memcached configuration endpoints, e.g. of AWS ElastiCache clusters.
+type memcachedClusterConfig struct {
+	// Version is incremented every time nodes of the cluster change.
+	version int
+	nodes   []memcachedClusterNode
+}
+
+type memcachedClusterNode struct {
+	dns  string
+	ip   string
+	port int
+}
+
+// addr returns the address of the node, by IP if known.
+func (n memcachedClusterNode) addr() string {
+	host := n.ip
+	if host == "" {
+		host = n.dns
+	}
+	return net.JoinHostPort(host, strconv.Itoa(n.port))
+}
+
+// discoverMemcachedCluster fetches the cluster config from the memcached configuration endpoint at addr. The timeout
+// applies to the whole exchange, context deadline is used if it is not positive.
+func discoverMemcachedCluster(ctx context.Context, addr string, timeout time.Duration) (_ *memcachedClusterConfig, err error) {
+	conn, err := (&net.Dialer{Timeout: timeout}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connect to configuration endpoint %s", addr)
+	}
+	defer runutil.CloseWithErrCapture(&err, conn, "configuration endpoint connection")
+
+	if timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, errors.Wrap(err, "set deadline")
+		}
+	} else if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, errors.Wrap(err, "set deadline")
+		}
+	}
+	if _, err := fmt.Fprint(conn, "config get cluster\r\n"); err != nil {
+		return nil, errors.Wrapf(err, "send config command to configuration endpoint %s", addr)
+	}
+	config, err := parseMemcachedClusterConfig(bufio.NewReader(conn))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read cluster config from configuration endpoint %s", addr)
+	}
+	return config, nil
+}
+
+// parseMemcachedClusterConfig parses the response of the "config get cluster" command, which looks like:
+//
+//	CONFIG cluster 0 147
+//	12
+//	node1.example.com|10.0.0.1|11211 node2.example.com|10.0.0.2|11211
+//
+//	END
+func parseMemcachedClusterConfig(r *bufio.Reader) (*memcachedClusterConfig, error) {
+	line, err := readMemcachedLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "CONFIG cluster ") {
+		return nil, errors.Errorf("unexpected response %q, the endpoint may not support cluster auto-discovery", line)
+	}
+
+	line, err = readMemcachedLine(r)
+	if err != nil {
+		return nil, err
+	}
+	version, err := strconv.Atoi(line)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse config version %q", line)
+	}
+
+	line, err = readMemcachedLine(r)
+	if err != nil {
+		return nil, err
+	}
+	config := &memcachedClusterConfig{version: version}
+	for _, n := range strings.Fields(line) {
+		parts := strings.Split(n, "|")
+		if len(parts) != 3 {
+			return nil, errors.Errorf("node %q is not in the dns|ip|port format", n)
+		}
+		port, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse port of node %q", n)
+		}
+		if parts[0] == "" && parts[1] == "" {
+			return nil, errors.Errorf("node %q has neither dns nor ip", n)
+		}
+		config.nodes = append(config.nodes, memcachedClusterNode{dns: parts[0], ip: parts[1], port: port})
+	}
+
+	for {
+		line, err = readMemcachedLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if line == "END" {
+			return config, nil
+		}
+		if line != "" {
+			return nil, errors.Errorf("unexpected line %q after nodes", line)
+		}
+	}
+}
+
+func readMemcachedLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "read response")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
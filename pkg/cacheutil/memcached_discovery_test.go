@@ -0,0 +1,110 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package cacheutil
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fortytw2/leaktest"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestParseMemcachedClusterConfig(t *testing.T) {
+	for _, tcase := range []struct {
+		name     string
+		input    string
+		expected *memcachedClusterConfig
+		err      bool
+	}{
+		{
+			name:  "single node",
+			input: "CONFIG cluster 0 51\r\n1\nnode1.example.com|10.0.0.1|11211\n\r\nEND\r\n",
+			expected: &memcachedClusterConfig{version: 1, nodes: []memcachedClusterNode{
+				{dns: "node1.example.com", ip: "10.0.0.1", port: 11211},
+			}},
+		},
+		{
+			name:  "multiple nodes, some without ip",
+			input: "CONFIG cluster 0 147\r\n12\nnode1.example.com|10.0.0.1|11211 node2.example.com||11212\n\r\nEND\r\n",
+			expected: &memcachedClusterConfig{version: 12, nodes: []memcachedClusterNode{
+				{dns: "node1.example.com", ip: "10.0.0.1", port: 11211},
+				{dns: "node2.example.com", port: 11212},
+			}},
+		},
+		{
+			name:  "not supported by server",
+			input: "ERROR\r\n",
+			err:   true,
+		},
+		{
+			name:  "invalid node",
+			input: "CONFIG cluster 0 10\r\n1\nnode1.example.com:11211\n\r\nEND\r\n",
+			err:   true,
+		},
+		{
+			name:  "truncated",
+			input: "CONFIG cluster 0 51\r\n1\nnode1.example.com|10.0.0.1|11211\n",
+			err:   true,
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			config, err := parseMemcachedClusterConfig(bufio.NewReader(strings.NewReader(tcase.input)))
+			if tcase.err {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.expected, config)
+		})
+	}
+}
+
+func TestMemcachedClient_AutoDiscovery(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	testutil.Ok(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err == nil && line == "config get cluster\r\n" {
+				_, _ = conn.Write([]byte("CONFIG cluster 0 75\r\n3\nnode2.example.com|10.0.0.2|11211 node1.example.com|10.0.0.1|11211\n\r\nEND\r\n"))
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	config := defaultMemcachedClientConfig
+	config.Addresses = []string{l.Addr().String()}
+	config.AutoDiscovery = true
+	client, err := prepare(config, newMemcachedClientBackendMock())
+	testutil.Ok(t, err)
+	defer client.Stop()
+
+	var servers []string
+	testutil.Ok(t, client.selector.Each(func(addr net.Addr) error {
+		servers = append(servers, addr.String())
+		return nil
+	}))
+	testutil.Equals(t, []string{"10.0.0.1:11211", "10.0.0.2:11211"}, servers)
+
+	testutil.Ok(t, l.Close())
+	<-done
+
+	// Unreachable configuration endpoints fail the discovery.
+	_, err = discoverMemcachedCluster(context.Background(), l.Addr().String(), time.Second)
+	testutil.NotOk(t, err)
+}
@@ -780,14 +780,21 @@ func (cg *Group) deleteBlock(b string) error {
 	return nil
 }
 
+// scheduledGroup is a group sent to the compaction workers, with the index of its group config, or -1.
+type scheduledGroup struct {
+	group *Group
+	rule  int
+}
+
 // BucketCompactor compacts blocks in a bucket.
 type BucketCompactor struct {
-	logger      log.Logger
-	sy          *Syncer
-	comp        tsdb.Compactor
-	compactDir  string
-	bkt         objstore.Bucket
-	concurrency int
+	logger       log.Logger
+	sy           *Syncer
+	comp         tsdb.Compactor
+	compactDir   string
+	bkt          objstore.Bucket
+	concurrency  int
+	groupConfigs []*groupConfig
 }
 
 // NewBucketCompactor creates a new bucket compactor.
@@ -798,17 +805,23 @@ func NewBucketCompactor(
 	compactDir string,
 	bkt objstore.Bucket,
 	concurrency int,
+	groupConfigs []GroupConfig,
 ) (*BucketCompactor, error) {
 	if concurrency <= 0 {
 		return nil, errors.Errorf("invalid concurrency level (%d), concurrency level must be > 0", concurrency)
 	}
+	configs, err := newGroupConfigs(groupConfigs)
+	if err != nil {
+		return nil, err
+	}
 	return &BucketCompactor{
-		logger:      logger,
-		sy:          sy,
-		comp:        comp,
-		compactDir:  compactDir,
-		bkt:         bkt,
-		concurrency: concurrency,
+		logger:       logger,
+		sy:           sy,
+		comp:         comp,
+		compactDir:   compactDir,
+		bkt:          bkt,
+		concurrency:  concurrency,
+		groupConfigs: configs,
 	}, nil
 }
 
@@ -825,10 +838,13 @@ func (c *BucketCompactor) Compact(ctx context.Context) error {
 		var (
 			wg                     sync.WaitGroup
 			workCtx, workCtxCancel = context.WithCancel(ctx)
-			groupChan              = make(chan *Group)
+			groupChan              = make(chan scheduledGroup)
 			errChan                = make(chan error, c.concurrency)
 			finishedAllGroups      = true
 			mtx                    sync.Mutex
+			// doneChan receives the config index of every group compacted by the workers. It is made once the
+			// groups are known, before any group is sent to the workers.
+			doneChan chan int
 		)
 		defer workCtxCancel()
 
@@ -838,8 +854,10 @@ func (c *BucketCompactor) Compact(ctx context.Context) error {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				for g := range groupChan {
+				for sg := range groupChan {
+					g := sg.group
 					shouldRerunGroup, _, err := g.Compact(workCtx, c.compactDir, c.comp)
+					doneChan <- sg.rule
 					if err == nil {
 						if shouldRerunGroup {
 							mtx.Lock()
@@ -889,16 +907,29 @@ func (c *BucketCompactor) Compact(ctx context.Context) error {
 			return errors.Wrap(err, "build compaction groups")
 		}
 
-		// Send all groups found during this pass to the compaction workers.
+		// Send all groups found during this pass to the compaction workers, by priority and within the concurrency
+		// of their group configs.
 		var groupErrs terrors.MultiError
+		sched := newGroupScheduler(c.groupConfigs, groups)
+		doneChan = make(chan int, len(groups))
 
 	groupLoop:
-		for _, g := range groups {
+		for sched.Len() > 0 {
+			var (
+				sendChan chan<- scheduledGroup
+				next     scheduledGroup
+			)
+			if g, rule := sched.next(); g != nil {
+				sendChan, next = groupChan, scheduledGroup{group: g, rule: rule}
+			}
 			select {
 			case groupErr := <-errChan:
 				groupErrs.Add(groupErr)
 				break groupLoop
-			case groupChan <- g:
+			case rule := <-doneChan:
+				sched.done(rule)
+			case sendChan <- next:
+				sched.start(next.group, next.rule)
 			}
 		}
 		close(groupChan)
@@ -191,7 +191,7 @@ func TestGroup_Compact_e2e(t *testing.T) {
 		comp, err := tsdb.NewLeveledCompactor(ctx, reg, logger, []int64{1000, 3000}, nil)
 		testutil.Ok(t, err)
 
-		bComp, err := NewBucketCompactor(logger, sy, comp, dir, bkt, 2)
+		bComp, err := NewBucketCompactor(logger, sy, comp, dir, bkt, 2, nil)
 		testutil.Ok(t, err)
 
 		// Compaction on empty should not fail.
@@ -81,8 +81,10 @@ func Downsample(
 	}()
 
 	// Copy original meta to the new one. Update downsampling resolution and ULID for a new block.
+	// Files of the original block are not part of the new one.
 	newMeta := *origMeta
 	newMeta.Thanos.Downsample.Resolution = resolution
+	newMeta.Thanos.Files = nil
 	newMeta.ULID = uid
 
 	// Writes downsampled chunks right into the files, avoiding excess memory allocation.
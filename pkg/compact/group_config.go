@@ -0,0 +1,149 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package compact
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"gopkg.in/yaml.v2"
+)
+
+// GroupConfig configures the compaction of the groups whose external labels match a selector.
+type GroupConfig struct {
+	// Selector selects groups by their external labels, e.g. {tenant="prod"}.
+	Selector string `yaml:"selector"`
+	// Priority orders the compaction of groups: groups of higher priority are compacted first. Groups not selected
+	// by any config have priority 0.
+	Priority int `yaml:"priority"`
+	// MaxConcurrency is the maximum number of groups selected by the config compacted at once, within the global
+	// compaction concurrency. Not limited if zero.
+	MaxConcurrency int `yaml:"max_concurrency"`
+}
+
+// ParseGroupConfigs parses the YAML list of group configs.
+func ParseGroupConfigs(content []byte) ([]GroupConfig, error) {
+	var configs []GroupConfig
+	if err := yaml.UnmarshalStrict(content, &configs); err != nil {
+		return nil, errors.Wrap(err, "parsing group config")
+	}
+	return configs, nil
+}
+
+// groupConfig is a parsed GroupConfig.
+type groupConfig struct {
+	GroupConfig
+	matchers []*labels.Matcher
+}
+
+func newGroupConfigs(configs []GroupConfig) ([]*groupConfig, error) {
+	res := make([]*groupConfig, 0, len(configs))
+	for _, c := range configs {
+		if c.MaxConcurrency < 0 {
+			return nil, errors.Errorf("negative max concurrency of group config %s", c.Selector)
+		}
+		ms, err := promql.ParseMetricSelector(c.Selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse selector %s of group config", c.Selector)
+		}
+		res = append(res, &groupConfig{GroupConfig: c, matchers: ms})
+	}
+	return res, nil
+}
+
+func (c *groupConfig) matches(lset labels.Labels) bool {
+	for _, m := range c.matchers {
+		if !m.Matches(lset.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// groupScheduler orders groups to compact by the priority of their configs, and limits the number of groups of each
+// config compacted at once.
+type groupScheduler struct {
+	configs []*groupConfig
+	running []int
+
+	// pending are the groups left to compact, by descending priority, with the index of their config, or -1.
+	pending []*Group
+	rules   []int
+}
+
+// newGroupScheduler returns a scheduler of the groups. Groups use the first config selecting them. Groups of the
+// same priority keep their order.
+func newGroupScheduler(configs []*groupConfig, groups []*Group) *groupScheduler {
+	s := &groupScheduler{
+		configs: configs,
+		running: make([]int, len(configs)),
+		pending: make([]*Group, len(groups)),
+		rules:   make([]int, len(groups)),
+	}
+	copy(s.pending, groups)
+	for i, g := range s.pending {
+		s.rules[i] = -1
+		for j, c := range configs {
+			if c.matches(g.Labels()) {
+				s.rules[i] = j
+				break
+			}
+		}
+	}
+	sort.Stable(s)
+	return s
+}
+
+func (s *groupScheduler) Len() int { return len(s.pending) }
+
+func (s *groupScheduler) Less(i, j int) bool {
+	return s.priority(s.rules[i]) > s.priority(s.rules[j])
+}
+
+func (s *groupScheduler) Swap(i, j int) {
+	s.pending[i], s.pending[j] = s.pending[j], s.pending[i]
+	s.rules[i], s.rules[j] = s.rules[j], s.rules[i]
+}
+
+func (s *groupScheduler) priority(rule int) int {
+	if rule < 0 {
+		return 0
+	}
+	return s.configs[rule].Priority
+}
+
+// next returns the pending group of highest priority whose config allows compacting another group, and the index
+// of its config. It returns nil if no pending group can be compacted until a running one is done.
+func (s *groupScheduler) next() (*Group, int) {
+	for i, rule := range s.rules {
+		if rule >= 0 && s.configs[rule].MaxConcurrency > 0 && s.running[rule] >= s.configs[rule].MaxConcurrency {
+			continue
+		}
+		return s.pending[i], rule
+	}
+	return nil, -1
+}
+
+// start marks the group returned by next as running.
+func (s *groupScheduler) start(g *Group, rule int) {
+	for i := range s.pending {
+		if s.pending[i] == g {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			break
+		}
+	}
+	if rule >= 0 {
+		s.running[rule]++
+	}
+}
+
+// done marks a group of the config as done.
+func (s *groupScheduler) done(rule int) {
+	if rule >= 0 {
+		s.running[rule]--
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package compact
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestGroupScheduler(t *testing.T) {
+	configs, err := ParseGroupConfigs([]byte(`
+- selector: '{tenant="prod"}'
+  priority: 10
+  max_concurrency: 1
+- selector: '{tenant=~"dev-.*"}'
+  priority: -1
+`))
+	testutil.Ok(t, err)
+	parsed, err := newGroupConfigs(configs)
+	testutil.Ok(t, err)
+
+	group := func(tenant, replica string) *Group {
+		return &Group{labels: labels.FromStrings("tenant", tenant, "replica", replica)}
+	}
+	var (
+		dev   = group("dev-a", "0")
+		test0 = group("test", "0")
+		prod0 = group("prod", "0")
+		test1 = group("test", "1")
+		prod1 = group("prod", "1")
+	)
+	s := newGroupScheduler(parsed, []*Group{dev, test0, prod0, test1, prod1})
+	testutil.Equals(t, []*Group{prod0, prod1, test0, test1, dev}, s.pending)
+	testutil.Equals(t, []int{0, 0, -1, -1, 1}, s.rules)
+
+	// Groups of configs at their max concurrency are skipped until a group of the config is done.
+	next := func(expected *Group, expectedRule int) {
+		t.Helper()
+		g, rule := s.next()
+		testutil.Equals(t, expected, g)
+		testutil.Equals(t, expectedRule, rule)
+		s.start(g, rule)
+	}
+	next(prod0, 0)
+	next(test0, -1)
+	next(test1, -1)
+	s.done(0)
+	next(prod1, 0)
+	next(dev, 1)
+	testutil.Equals(t, 0, s.Len())
+
+	g, _ := s.next()
+	testutil.Assert(t, g == nil, "expected no group left")
+
+	_, err = newGroupConfigs([]GroupConfig{{Selector: "{tenant="}})
+	testutil.NotOk(t, err)
+	_, err = newGroupConfigs([]GroupConfig{{Selector: `{tenant="prod"}`, MaxConcurrency: -1}})
+	testutil.NotOk(t, err)
+}
@@ -6,6 +6,7 @@ package miekgdns
 import (
 	"context"
 	"net"
+	"time"
 
 	"github.com/miekg/dns"
 	"github.com/pkg/errors"
@@ -20,6 +21,13 @@ type Resolver struct {
 }
 
 func (r *Resolver) LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error) {
+	cname, addrs, _, err = r.LookupSRVTTL(ctx, service, proto, name)
+	return cname, addrs, err
+}
+
+// LookupSRVTTL looks up SRV records like LookupSRV, also returning the minimum TTL of the records. The TTL is negative
+// if there are no records.
+func (r *Resolver) LookupSRVTTL(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, ttl time.Duration, err error) {
 	var target string
 	if service == "" && proto == "" {
 		target = name
@@ -29,7 +37,7 @@ func (r *Resolver) LookupSRV(ctx context.Context, service, proto, name string) (
 
 	response, err := r.lookupWithSearchPath(target, dns.Type(dns.TypeSRV))
 	if err != nil {
-		return "", nil, err
+		return "", nil, 0, err
 	}
 
 	for _, record := range response.Answer {
@@ -42,20 +50,27 @@ func (r *Resolver) LookupSRV(ctx context.Context, service, proto, name string) (
 				Port:     addr.Port,
 			})
 		default:
-			return "", nil, errors.Errorf("invalid SRV response record %s", record)
+			return "", nil, 0, errors.Errorf("invalid SRV response record %s", record)
 		}
 	}
 
-	return "", addrs, nil
+	return "", addrs, minTTL(response.Answer), nil
 }
 
 func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	resp, _, err := r.LookupIPAddrTTL(ctx, host)
+	return resp, err
+}
+
+// LookupIPAddrTTL looks up IP addresses like LookupIPAddr, also returning the minimum TTL of the records. The TTL is
+// negative if there are no records.
+func (r *Resolver) LookupIPAddrTTL(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
 	response, err := r.lookupWithSearchPath(host, dns.Type(dns.TypeAAAA))
 	if err != nil || len(response.Answer) == 0 {
 		// Ugly fallback to A lookup.
 		response, err = r.lookupWithSearchPath(host, dns.Type(dns.TypeA))
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	}
 
@@ -67,8 +82,18 @@ func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr,
 		case *dns.AAAA:
 			resp = append(resp, net.IPAddr{IP: addr.AAAA})
 		default:
-			return nil, errors.Errorf("invalid A or AAAA response record %s", record)
+			return nil, 0, errors.Errorf("invalid A or AAAA response record %s", record)
+		}
+	}
+	return resp, minTTL(response.Answer), nil
+}
+
+func minTTL(records []dns.RR) time.Duration {
+	ttl := time.Duration(-1)
+	for _, record := range records {
+		if t := time.Duration(record.Header().Ttl) * time.Second; ttl < 0 || t < ttl {
+			ttl = t
 		}
 	}
-	return resp, nil
+	return ttl
 }
@@ -8,6 +8,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -22,7 +23,10 @@ type Provider struct {
 	sync.Mutex
 	resolver Resolver
 	// A map from domain name to a slice of resolved targets.
-	resolved map[string][]string
+	resolved map[string][]record
+	// ttl is the minimum TTL of the DNS records resolved last, if ttlKnown.
+	ttl      time.Duration
+	ttlKnown bool
 	logger   log.Logger
 
 	resolverAddrs         *extprom.TxGaugeVec
@@ -30,6 +34,9 @@ type Provider struct {
 	resolverFailuresCount prometheus.Counter
 }
 
+// MinRefreshInterval is the minimum interval between resolutions honoring TTLs, e.g. of records with TTL 0.
+const MinRefreshInterval = 1 * time.Second
+
 type ResolverType string
 
 const (
@@ -56,7 +63,7 @@ func (t ResolverType) ToResolver(logger log.Logger) ipLookupResolver {
 func NewProvider(logger log.Logger, reg prometheus.Registerer, resolverType ResolverType) *Provider {
 	p := &Provider{
 		resolver: NewResolver(resolverType.ToResolver(logger)),
-		resolved: make(map[string][]string),
+		resolved: make(map[string][]record),
 		logger:   logger,
 		resolverAddrs: extprom.NewTxGaugeVec(reg, prometheus.GaugeOpts{
 			Name: "dns_provider_results",
@@ -79,7 +86,7 @@ func NewProvider(logger log.Logger, reg prometheus.Registerer, resolverType Reso
 func (p *Provider) Clone() *Provider {
 	return &Provider{
 		resolver:              p.resolver,
-		resolved:              make(map[string][]string),
+		resolved:              make(map[string][]record),
 		logger:                p.logger,
 		resolverAddrs:         p.resolverAddrs,
 		resolverLookupsCount:  p.resolverLookupsCount,
@@ -114,17 +121,20 @@ func (p *Provider) Resolve(ctx context.Context, addrs []string) {
 	p.resolverAddrs.ResetTx()
 	defer p.resolverAddrs.Submit()
 
-	resolvedAddrs := map[string][]string{}
+	resolvedAddrs := map[string][]record{}
+	var (
+		minTTL   = time.Duration(-1)
+		ttlKnown = true
+	)
 	for _, addr := range addrs {
-		var resolved []string
 		qtype, name := GetQTypeName(addr)
 		if qtype == "" {
-			resolvedAddrs[name] = []string{name}
+			resolvedAddrs[name] = []record{{addr: name}}
 			p.resolverAddrs.WithLabelValues(name).Set(1.0)
 			continue
 		}
 
-		resolved, err := p.resolver.Resolve(ctx, name, QType(qtype))
+		resolved, ttl, ok, err := p.resolve(ctx, name, QType(qtype))
 		p.resolverLookupsCount.Inc()
 		if err != nil {
 			// The DNS resolution failed. Continue without modifying the old records.
@@ -133,20 +143,66 @@ func (p *Provider) Resolve(ctx context.Context, addrs []string) {
 			// Use cached values.
 			resolved = p.resolved[addr]
 		}
+		if err != nil || !ok {
+			ttlKnown = false
+		} else if minTTL < 0 || ttl < minTTL {
+			minTTL = ttl
+		}
 		resolvedAddrs[addr] = resolved
 		p.resolverAddrs.WithLabelValues(addr).Set(float64(len(resolved)))
 	}
 	p.resolved = resolvedAddrs
+	p.ttl, p.ttlKnown = minTTL, ttlKnown && minTTL >= 0
+}
+
+func (p *Provider) resolve(ctx context.Context, name string, qtype QType) ([]record, time.Duration, bool, error) {
+	if r, ok := p.resolver.(recordResolver); ok {
+		return r.resolveRecords(ctx, name, qtype)
+	}
+
+	addrs, err := p.resolver.Resolve(ctx, name, qtype)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	recs := make([]record, 0, len(addrs))
+	for _, addr := range addrs {
+		recs = append(recs, record{addr: addr})
+	}
+	return recs, 0, false, nil
+}
+
+// RefreshInterval returns the interval until addresses should be resolved again to honor the TTLs of their DNS
+// records: the minimum TTL of the records resolved last, but at least MinRefreshInterval. If the TTLs are not known,
+// e.g. because the resolver does not return them or a resolution failed, the given interval is returned.
+func (p *Provider) RefreshInterval(interval time.Duration) time.Duration {
+	p.Lock()
+	defer p.Unlock()
+
+	if !p.ttlKnown {
+		return interval
+	}
+	if p.ttl < MinRefreshInterval {
+		return MinRefreshInterval
+	}
+	return p.ttl
 }
 
-// Addresses returns the latest addresses present in the Provider.
+// Addresses returns the latest addresses present in the Provider, in the order to try them in for load distribution
+// as of RFC 2782: by ascending priority of their SRV records, and in random order weighted by the weights of their
+// SRV records within each priority. Addresses not resolved from SRV records have priority and weight 0.
 func (p *Provider) Addresses() []string {
 	p.Lock()
 	defer p.Unlock()
 
-	var result []string
+	var recs []record
 	for _, addrs := range p.resolved {
-		result = append(result, addrs...)
+		recs = append(recs, addrs...)
+	}
+	orderRecords(recs)
+
+	var result []string
+	for _, rec := range recs {
+		result = append(result, rec.addr)
 	}
 	return result
 }
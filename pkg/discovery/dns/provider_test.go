@@ -5,8 +5,10 @@ package dns
 
 import (
 	"context"
+	"net"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
@@ -91,6 +93,37 @@ func TestProvider(t *testing.T) {
 
 }
 
+func TestProvider_RefreshInterval(t *testing.T) {
+	prv := NewProvider(log.NewNopLogger(), nil, "")
+	ttls := map[string]time.Duration{
+		"a.mycompany.com": 40 * time.Second,
+		"b.mycompany.com": 20 * time.Second,
+	}
+	prv.resolver = &dnsSD{resolver: mockTTLResolver{
+		mockHostnameResolver: mockHostnameResolver{resultIPs: map[string][]net.IPAddr{
+			"a.mycompany.com": {net.IPAddr{IP: net.ParseIP("192.168.0.1")}},
+			"b.mycompany.com": {net.IPAddr{IP: net.ParseIP("192.168.0.2")}},
+		}},
+		ttls: ttls,
+	}}
+	ctx := context.Background()
+
+	// Static addresses have no TTL.
+	prv.Resolve(ctx, []string{"192.168.0.3:9090"})
+	testutil.Equals(t, time.Minute, prv.RefreshInterval(time.Minute))
+
+	prv.Resolve(ctx, []string{"192.168.0.3:9090", "dns+a.mycompany.com:9090", "dns+b.mycompany.com:9090"})
+	testutil.Equals(t, 20*time.Second, prv.RefreshInterval(time.Minute))
+
+	ttls["b.mycompany.com"] = 0
+	prv.Resolve(ctx, []string{"dns+a.mycompany.com:9090", "dns+b.mycompany.com:9090"})
+	testutil.Equals(t, MinRefreshInterval, prv.RefreshInterval(time.Minute))
+
+	// Failed resolutions are retried after the interval.
+	prv.Resolve(ctx, []string{"dns+a.mycompany.com:9090", "dns+b.mycompany.com"})
+	testutil.Equals(t, time.Minute, prv.RefreshInterval(time.Minute))
+}
+
 type mockResolver struct {
 	res map[string][]string
 	err error
@@ -5,9 +5,12 @@ package dns
 
 import (
 	"context"
+	"math/rand"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -36,6 +39,26 @@ type ipLookupResolver interface {
 	LookupSRV(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, err error)
 }
 
+// ttlLookupResolver is an ipLookupResolver also returning the minimum TTL of the looked up records, which is negative
+// if there are none.
+type ttlLookupResolver interface {
+	LookupIPAddrTTL(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error)
+	LookupSRVTTL(ctx context.Context, service, proto, name string) (cname string, addrs []*net.SRV, ttl time.Duration, err error)
+}
+
+// record is a resolved address with the priority and weight of the SRV record it was resolved from, if any.
+type record struct {
+	addr     string
+	priority uint16
+	weight   uint16
+}
+
+// recordResolver is a Resolver also resolving SRV priorities and weights, and the minimum TTL of the DNS records of
+// the addresses, if known.
+type recordResolver interface {
+	resolveRecords(ctx context.Context, name string, qtype QType) (recs []record, ttl time.Duration, ttlKnown bool, err error)
+}
+
 type dnsSD struct {
 	resolver ipLookupResolver
 }
@@ -46,10 +69,20 @@ func NewResolver(resolver ipLookupResolver) Resolver {
 }
 
 func (s *dnsSD) Resolve(ctx context.Context, name string, qtype QType) ([]string, error) {
-	var (
-		res    []string
-		scheme string
-	)
+	recs, _, _, err := s.resolveRecords(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, rec := range recs {
+		res = append(res, rec.addr)
+	}
+	return res, nil
+}
+
+func (s *dnsSD) resolveRecords(ctx context.Context, name string, qtype QType) (res []record, ttl time.Duration, ttlKnown bool, err error) {
+	var scheme string
 
 	schemeSplit := strings.Split(name, "//")
 	if len(schemeSplit) > 1 {
@@ -64,22 +97,50 @@ func (s *dnsSD) Resolve(ctx context.Context, name string, qtype QType) ([]string
 		host, port = name, ""
 	}
 
+	// TTLs are known only if the underlying resolver returns them, and there are records.
+	ttlResolver, ttlKnown := s.resolver.(ttlLookupResolver)
+	ttl = -1
+	observeTTL := func(t time.Duration) {
+		if t < 0 {
+			ttlKnown = false
+			return
+		}
+		if ttl < 0 || t < ttl {
+			ttl = t
+		}
+	}
+	lookupIPAddr := func(host string) ([]net.IPAddr, error) {
+		if ttlResolver == nil {
+			return s.resolver.LookupIPAddr(ctx, host)
+		}
+		ips, t, err := ttlResolver.LookupIPAddrTTL(ctx, host)
+		observeTTL(t)
+		return ips, err
+	}
+
 	switch qtype {
 	case A:
 		if port == "" {
-			return nil, errors.Errorf("missing port in address given for dns lookup: %v", name)
+			return nil, 0, false, errors.Errorf("missing port in address given for dns lookup: %v", name)
 		}
-		ips, err := s.resolver.LookupIPAddr(ctx, host)
+		ips, err := lookupIPAddr(host)
 		if err != nil {
-			return nil, errors.Wrapf(err, "lookup IP addresses %q", host)
+			return nil, 0, false, errors.Wrapf(err, "lookup IP addresses %q", host)
 		}
 		for _, ip := range ips {
-			res = append(res, appendScheme(scheme, net.JoinHostPort(ip.String(), port)))
+			res = append(res, record{addr: appendScheme(scheme, net.JoinHostPort(ip.String(), port))})
 		}
 	case SRV, SRVNoA:
-		_, recs, err := s.resolver.LookupSRV(ctx, "", "", host)
+		var recs []*net.SRV
+		if ttlResolver == nil {
+			_, recs, err = s.resolver.LookupSRV(ctx, "", "", host)
+		} else {
+			var t time.Duration
+			_, recs, t, err = ttlResolver.LookupSRVTTL(ctx, "", "", host)
+			observeTTL(t)
+		}
 		if err != nil {
-			return nil, errors.Wrapf(err, "lookup SRV records %q", host)
+			return nil, 0, false, errors.Wrapf(err, "lookup SRV records %q", host)
 		}
 
 		for _, rec := range recs {
@@ -90,23 +151,60 @@ func (s *dnsSD) Resolve(ctx context.Context, name string, qtype QType) ([]string
 			}
 
 			if qtype == SRVNoA {
-				res = append(res, appendScheme(scheme, net.JoinHostPort(rec.Target, resPort)))
+				res = append(res, record{addr: appendScheme(scheme, net.JoinHostPort(rec.Target, resPort)), priority: rec.Priority, weight: rec.Weight})
 				continue
 			}
 			// Do A lookup for the domain in SRV answer.
-			resIPs, err := s.resolver.LookupIPAddr(ctx, rec.Target)
+			resIPs, err := lookupIPAddr(rec.Target)
 			if err != nil {
-				return nil, errors.Wrapf(err, "look IP addresses %q", rec.Target)
+				return nil, 0, false, errors.Wrapf(err, "look IP addresses %q", rec.Target)
 			}
 			for _, resIP := range resIPs {
-				res = append(res, appendScheme(scheme, net.JoinHostPort(resIP.String(), resPort)))
+				res = append(res, record{addr: appendScheme(scheme, net.JoinHostPort(resIP.String(), resPort)), priority: rec.Priority, weight: rec.Weight})
 			}
 		}
 	default:
-		return nil, errors.Errorf("invalid lookup scheme %q", qtype)
+		return nil, 0, false, errors.Errorf("invalid lookup scheme %q", qtype)
 	}
 
-	return res, nil
+	return res, ttl, ttlKnown && ttl >= 0, nil
+}
+
+// orderRecords orders records to try them in for load distribution as of RFC 2782: by ascending priority, and in
+// random order weighted by their weights within each priority. Records with weight 0 are ordered last, randomly.
+func orderRecords(recs []record) {
+	rand.Shuffle(len(recs), func(i, j int) { recs[i], recs[j] = recs[j], recs[i] })
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].priority < recs[j].priority })
+
+	for i := 0; i < len(recs); {
+		j := i + 1
+		for j < len(recs) && recs[j].priority == recs[i].priority {
+			j++
+		}
+		shuffleByWeight(recs[i:j])
+		i = j
+	}
+}
+
+// shuffleByWeight orders the records randomly, with a chance to be ordered first proportional to their weight.
+func shuffleByWeight(recs []record) {
+	sum := 0
+	for _, rec := range recs {
+		sum += int(rec.weight)
+	}
+	for sum > 0 && len(recs) > 1 {
+		n := rand.Intn(sum)
+		s := 0
+		for i := range recs {
+			s += int(recs[i].weight)
+			if s > n {
+				recs[0], recs[i] = recs[i], recs[0]
+				break
+			}
+		}
+		sum -= int(recs[0].weight)
+		recs = recs[1:]
+	}
 }
 
 func appendScheme(scheme, host string) string {
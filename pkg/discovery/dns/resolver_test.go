@@ -8,6 +8,7 @@ import (
 	"net"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/testutil"
@@ -196,3 +197,81 @@ func testDnsSd(t *testing.T, tt DNSSDTest) {
 	sort.Strings(result)
 	testutil.Equals(t, tt.expectedResult, result)
 }
+
+type mockTTLResolver struct {
+	mockHostnameResolver
+	ttls map[string]time.Duration
+}
+
+func (m mockTTLResolver) LookupIPAddrTTL(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	ips, err := m.LookupIPAddr(ctx, host)
+	return ips, m.ttls[host], err
+}
+
+func (m mockTTLResolver) LookupSRVTTL(ctx context.Context, service, proto, name string) (string, []*net.SRV, time.Duration, error) {
+	cname, addrs, err := m.LookupSRV(ctx, service, proto, name)
+	return cname, addrs, m.ttls[name], err
+}
+
+func TestDnsSD_ResolveRecords(t *testing.T) {
+	resolver := mockHostnameResolver{
+		resultSRVs: map[string][]*net.SRV{
+			"_test._tcp.mycompany.com": {
+				&net.SRV{Target: "alt1.mycompany.com.", Port: 8080, Priority: 10, Weight: 60},
+				&net.SRV{Target: "alt2.mycompany.com.", Port: 8081, Priority: 20, Weight: 40},
+			},
+		},
+		resultIPs: map[string][]net.IPAddr{
+			"alt1.mycompany.com.": {net.IPAddr{IP: net.ParseIP("192.168.0.1")}},
+			"alt2.mycompany.com.": {net.IPAddr{IP: net.ParseIP("192.168.0.2")}},
+		},
+	}
+	expected := []record{
+		{addr: "192.168.0.1:8080", priority: 10, weight: 60},
+		{addr: "192.168.0.2:8081", priority: 20, weight: 40},
+	}
+
+	// TTLs are not known from resolvers not returning them.
+	recs, _, ttlKnown, err := (&dnsSD{resolver: resolver}).resolveRecords(context.Background(), "_test._tcp.mycompany.com", SRV)
+	testutil.Ok(t, err)
+	testutil.Equals(t, expected, recs)
+	testutil.Assert(t, !ttlKnown, "TTL known")
+
+	// The minimum TTL of SRV and A records is returned.
+	ttlResolver := mockTTLResolver{mockHostnameResolver: resolver, ttls: map[string]time.Duration{
+		"_test._tcp.mycompany.com": time.Minute,
+		"alt1.mycompany.com.":      30 * time.Second,
+		"alt2.mycompany.com.":      45 * time.Second,
+	}}
+	recs, ttl, ttlKnown, err := (&dnsSD{resolver: ttlResolver}).resolveRecords(context.Background(), "_test._tcp.mycompany.com", SRV)
+	testutil.Ok(t, err)
+	testutil.Equals(t, expected, recs)
+	testutil.Assert(t, ttlKnown, "TTL not known")
+	testutil.Equals(t, 30*time.Second, ttl)
+
+	// TTLs are not known if there are no records.
+	ttlResolver.ttls["alt2.mycompany.com."] = -1
+	_, _, ttlKnown, err = (&dnsSD{resolver: ttlResolver}).resolveRecords(context.Background(), "_test._tcp.mycompany.com", SRV)
+	testutil.Ok(t, err)
+	testutil.Assert(t, !ttlKnown, "TTL known")
+}
+
+func TestOrderRecords(t *testing.T) {
+	first := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		recs := []record{
+			{addr: "backup", priority: 20, weight: 100},
+			{addr: "heavy", priority: 10, weight: 90},
+			{addr: "light", priority: 10, weight: 10},
+			{addr: "zero", priority: 10, weight: 0},
+		}
+		orderRecords(recs)
+
+		// Records of lower priority and weight 0 are ordered last.
+		testutil.Equals(t, "zero", recs[2].addr)
+		testutil.Equals(t, "backup", recs[3].addr)
+		first[recs[0].addr]++
+	}
+	testutil.Equals(t, 1000, first["heavy"]+first["light"])
+	testutil.Assert(t, first["heavy"] > 800 && first["light"] > 50, "records not ordered by weight: %v", first)
+}
@@ -0,0 +1,343 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package gossip implements an optional memberlist based gossip cluster. Components join it advertising their state,
+// e.g. the address of their StoreAPI or the hashring of receive nodes, so they discover each other without files
+// generated by external controllers.
+package gossip
+
+import (
+	"context"
+	"encoding/json"
+	stdlog "log"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/hashicorp/memberlist"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+// leaveTimeout is the time to wait for the leave message to be gossiped before shutting down.
+const leaveTimeout = 5 * time.Second
+
+// Config configures the membership of a gossip cluster.
+type Config struct {
+	// BindAddress is the host:port to listen on for gossip traffic, over both TCP and UDP.
+	BindAddress string
+	// AdvertiseAddress is the host:port advertised to other members. If empty, it is derived from the bind address,
+	// or a private IP of the host if the bind address is unspecified.
+	AdvertiseAddress string
+	// Peers are host:port addresses of members to join. Host names resolving to multiple addresses join all of them.
+	Peers []string
+	// GossipInterval is the interval between gossip messages to random members.
+	GossipInterval time.Duration
+	// PushPullInterval is the interval between full state synchronizations with a random member.
+	PushPullInterval time.Duration
+	// RefreshInterval is the interval of joining the peers again while no other member is known, e.g. because all
+	// peers were unavailable at startup.
+	RefreshInterval time.Duration
+}
+
+// State is the state a member shares with all other members.
+type State struct {
+	// Component is the type of the component, e.g. "sidecar".
+	Component string `json:"component"`
+	// StoreAPI is the address of the StoreAPI served by the member, if any.
+	StoreAPI string `json:"store_api,omitempty"`
+	// Receive is the hashring membership of receive nodes.
+	Receive *ReceiveState `json:"receive,omitempty"`
+}
+
+// ReceiveState is the hashring membership of a receive node.
+type ReceiveState struct {
+	// Endpoint is the remote write endpoint of the node, as used in hashring configurations.
+	Endpoint string `json:"endpoint"`
+	// Hashring is the name of the hashring of the node.
+	Hashring string `json:"hashring,omitempty"`
+	// Tenants are the tenants of the hashring. Hashrings without tenants receive requests of all tenants not matched
+	// by other hashrings.
+	Tenants []string `json:"tenants,omitempty"`
+}
+
+// Peer is a member of a gossip cluster.
+type Peer struct {
+	logger log.Logger
+	mlist  *memberlist.Memberlist
+	peers  []string
+
+	refreshInterval time.Duration
+
+	mtx         sync.Mutex
+	meta        []byte
+	subscribers []chan struct{}
+
+	joinFailures  prometheus.Counter
+	stateFailures prometheus.Counter
+}
+
+// NewPeer creates a member of a gossip cluster sharing the given state. The cluster is joined by Run.
+func NewPeer(logger log.Logger, reg prometheus.Registerer, cfg Config, state State) (*Peer, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	if cfg.RefreshInterval <= 0 {
+		return nil, errors.New("gossip refresh interval must be positive")
+	}
+
+	bindHost, bindPort, err := splitHostPort(cfg.BindAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid gossip bind address %s", cfg.BindAddress)
+	}
+
+	p := &Peer{
+		logger:          logger,
+		peers:           cfg.Peers,
+		refreshInterval: cfg.RefreshInterval,
+		joinFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_gossip_join_failures_total",
+			Help: "Total number of failed attempts to join the peers of the gossip cluster.",
+		}),
+		stateFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_gossip_invalid_member_states_total",
+			Help: "Total number of states of members which could not be parsed.",
+		}),
+	}
+
+	conf := memberlist.DefaultLANConfig()
+	conf.Name = ulid.MustNew(ulid.Now(), rand.New(rand.NewSource(time.Now().UnixNano()))).String()
+	conf.BindAddr = bindHost
+	conf.BindPort = bindPort
+	if cfg.AdvertiseAddress != "" {
+		host, port, err := splitHostPort(cfg.AdvertiseAddress)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid gossip advertise address %s", cfg.AdvertiseAddress)
+		}
+		conf.AdvertiseAddr = host
+		conf.AdvertisePort = port
+	}
+	if cfg.GossipInterval > 0 {
+		conf.GossipInterval = cfg.GossipInterval
+	}
+	if cfg.PushPullInterval > 0 {
+		conf.PushPullInterval = cfg.PushPullInterval
+	}
+	conf.Delegate = (*delegate)(p)
+	conf.Events = (*delegate)(p)
+	conf.Logger = stdlog.New(log.NewStdlibAdapter(level.Debug(log.With(logger, "component", "memberlist"))), "", 0)
+
+	p.mlist, err = memberlist.Create(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "create memberlist")
+	}
+
+	// The StoreAPI is advertised with the address of the member if it listens on all interfaces.
+	if state.StoreAPI != "" {
+		host, port, err := net.SplitHostPort(state.StoreAPI)
+		if err != nil {
+			_ = p.mlist.Shutdown()
+			return nil, errors.Wrapf(err, "invalid StoreAPI address %s", state.StoreAPI)
+		}
+		if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+			state.StoreAPI = net.JoinHostPort(p.mlist.LocalNode().Addr.String(), port)
+		}
+	}
+	meta, err := json.Marshal(state)
+	if err != nil {
+		_ = p.mlist.Shutdown()
+		return nil, errors.Wrap(err, "marshal member state")
+	}
+	if len(meta) > memberlist.MetaMaxSize {
+		_ = p.mlist.Shutdown()
+		return nil, errors.Errorf("member state of %d bytes exceeds the limit of %d bytes", len(meta), memberlist.MetaMaxSize)
+	}
+	p.mtx.Lock()
+	p.meta = meta
+	p.mtx.Unlock()
+	if err := p.mlist.UpdateNode(leaveTimeout); err != nil {
+		_ = p.mlist.Shutdown()
+		return nil, errors.Wrap(err, "update member state")
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "thanos_gossip_members",
+		Help: "Number of alive members of the gossip cluster, including this one.",
+	}, func() float64 {
+		return float64(p.mlist.NumMembers())
+	})
+	return p, nil
+}
+
+func splitHostPort(addr string) (string, int, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "invalid port %s", port)
+	}
+	return host, p, nil
+}
+
+// Run joins the peers of the cluster and rejoins them every refresh interval while no other member is known, until
+// the context is done. The cluster is left gracefully before returning.
+func (p *Peer) Run(ctx context.Context) error {
+	defer func() {
+		if err := p.mlist.Leave(leaveTimeout); err != nil {
+			level.Warn(p.logger).Log("msg", "leaving gossip cluster failed", "err", err)
+		}
+		if err := p.mlist.Shutdown(); err != nil {
+			level.Warn(p.logger).Log("msg", "shutting down gossip failed", "err", err)
+		}
+	}()
+
+	tick := time.NewTicker(p.refreshInterval)
+	defer tick.Stop()
+
+	for {
+		if len(p.peers) > 0 && p.mlist.NumMembers() <= 1 {
+			n, err := p.mlist.Join(p.peers)
+			if err != nil {
+				p.joinFailures.Inc()
+				level.Warn(p.logger).Log("msg", "joining gossip peers failed", "peers", len(p.peers), "joined", n, "err", err)
+			} else {
+				level.Info(p.logger).Log("msg", "joined gossip cluster", "joined", n, "members", p.mlist.NumMembers())
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+// Members returns the states of all alive members, including this one.
+func (p *Peer) Members() []State {
+	var states []State
+	for _, n := range p.mlist.Members() {
+		var s State
+		if err := json.Unmarshal(n.Meta, &s); err != nil {
+			p.stateFailures.Inc()
+			level.Debug(p.logger).Log("msg", "invalid member state", "member", n.Name, "addr", n.Address(), "err", err)
+			continue
+		}
+		states = append(states, s)
+	}
+	return states
+}
+
+// StoreAPIs returns the sorted addresses of StoreAPIs of all alive members.
+func (p *Peer) StoreAPIs() []string {
+	var addrs []string
+	for _, s := range p.Members() {
+		if s.StoreAPI != "" {
+			addrs = append(addrs, s.StoreAPI)
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// Subscribe returns a channel receiving a value after members joined, left or changed their state. Notifications
+// are coalesced while the previous one was not received.
+func (p *Peer) Subscribe() <-chan struct{} {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	ch := make(chan struct{}, 1)
+	p.subscribers = append(p.subscribers, ch)
+	return ch
+}
+
+func (p *Peer) notify() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, ch := range p.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// delegate implements the memberlist delegates of a peer.
+type delegate Peer
+
+// NodeMeta returns the state of the member.
+func (d *delegate) NodeMeta(int) []byte {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.meta
+}
+
+func (d *delegate) NotifyMsg([]byte)                           {}
+func (d *delegate) GetBroadcasts(overhead, limit int) [][]byte { return nil }
+func (d *delegate) LocalState(join bool) []byte                { return nil }
+func (d *delegate) MergeRemoteState(buf []byte, join bool)     {}
+
+func (d *delegate) NotifyJoin(*memberlist.Node)   { (*Peer)(d).notify() }
+func (d *delegate) NotifyLeave(*memberlist.Node)  { (*Peer)(d).notify() }
+func (d *delegate) NotifyUpdate(*memberlist.Node) { (*Peer)(d).notify() }
+
+// StoreDiscovery discovers the StoreAPIs of members of a gossip cluster as a single target group.
+type StoreDiscovery struct {
+	peer *Peer
+}
+
+// NewStoreDiscovery returns a discovery of the StoreAPIs of members of the cluster of the given peer.
+func NewStoreDiscovery(p *Peer) *StoreDiscovery {
+	return &StoreDiscovery{peer: p}
+}
+
+// Run sends the StoreAPIs of members on the channel whenever they change, until the context is done.
+func (d *StoreDiscovery) Run(ctx context.Context, up chan<- []*targetgroup.Group) {
+	changes := d.peer.Subscribe()
+
+	var last []string
+	for {
+		addrs := d.peer.StoreAPIs()
+		if last == nil || !equalStrings(addrs, last) {
+			tg := &targetgroup.Group{Source: "gossip"}
+			for _, addr := range addrs {
+				tg.Targets = append(tg.Targets, model.LabelSet{model.AddressLabel: model.LabelValue(addr)})
+			}
+			select {
+			case up <- []*targetgroup.Group{tg}:
+			case <-ctx.Done():
+				return
+			}
+			last = append(make([]string, 0, len(addrs)), addrs...)
+		}
+
+		select {
+		case <-changes:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
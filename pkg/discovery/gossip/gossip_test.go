@@ -0,0 +1,68 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package gossip
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestPeer_Members(t *testing.T) {
+	p1, err := NewPeer(nil, nil, Config{BindAddress: "127.0.0.1:0", RefreshInterval: 100 * time.Millisecond}, State{Component: "query"})
+	testutil.Ok(t, err)
+	addr := fmt.Sprintf("127.0.0.1:%d", p1.mlist.LocalNode().Port)
+	p2, err := NewPeer(nil, nil, Config{BindAddress: "127.0.0.1:0", Peers: []string{addr}, RefreshInterval: 100 * time.Millisecond}, State{
+		Component: "sidecar",
+		StoreAPI:  "0.0.0.0:10901",
+	})
+	testutil.Ok(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	up := make(chan []*targetgroup.Group)
+	go NewStoreDiscovery(p1).Run(ctx, up)
+	testutil.Equals(t, []*targetgroup.Group{{Source: "gossip"}}, <-up)
+
+	errs := make(chan error, 2)
+	for _, p := range []*Peer{p1, p2} {
+		go func(p *Peer) { errs <- p.Run(ctx) }(p)
+	}
+
+	// The StoreAPI listening on all interfaces is advertised with the address of the member.
+	testutil.Equals(t, []*targetgroup.Group{{
+		Source:  "gossip",
+		Targets: []model.LabelSet{{model.AddressLabel: "127.0.0.1:10901"}},
+	}}, <-up)
+	testutil.Equals(t, 2, len(p1.Members()))
+	testutil.Equals(t, []string{"127.0.0.1:10901"}, p2.StoreAPIs())
+
+	cancel()
+	testutil.Ok(t, <-errs)
+	testutil.Ok(t, <-errs)
+}
+
+func TestNewPeer(t *testing.T) {
+	_, err := NewPeer(nil, nil, Config{BindAddress: "127.0.0.1", RefreshInterval: time.Second}, State{})
+	testutil.NotOk(t, err)
+	_, err = NewPeer(nil, nil, Config{BindAddress: "127.0.0.1:0"}, State{})
+	testutil.NotOk(t, err)
+
+	// States must fit into the metadata of members.
+	tenants := make([]string, 100)
+	for i := range tenants {
+		tenants[i] = fmt.Sprintf("tenant-%d", i)
+	}
+	_, err = NewPeer(nil, nil, Config{BindAddress: "127.0.0.1:0", RefreshInterval: time.Second}, State{
+		Component: "receive",
+		Receive:   &ReceiveState{Endpoint: "http://receive:19291/api/v1/receive", Tenants: tenants},
+	})
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,150 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package http implements HTTP service discovery as done by Prometheus, fetching target groups periodically from a
+// URL returning a JSON document.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/refresh"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/thanos-io/thanos/pkg/exthttp"
+)
+
+// maxResponseSize is the maximum size of documents of targets, so a misbehaving server cannot exhaust memory.
+const maxResponseSize = 32 << 20
+
+// refreshIntervalHeader is the request header with the refresh interval in seconds, so servers can set caching.
+const refreshIntervalHeader = "X-Prometheus-Refresh-Interval-Seconds"
+
+// targetGroup is a target group of the JSON document, e.g. {"targets": ["store-1:10901"], "labels": {"zone": "a"}}.
+type targetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// Discovery periodically fetches target groups from a URL. Groups are identified by the URL and their index in the
+// document.
+type Discovery struct {
+	*refresh.Discovery
+
+	url             string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	// lastGroups is the number of groups of the last refresh, so groups not in the document anymore are cleared.
+	lastGroups int
+
+	failures prometheus.Counter
+}
+
+// NewDiscovery returns a discovery of target groups fetched from the URL every refresh interval. Failed refreshes are
+// counted by the given counter, if not nil.
+func NewDiscovery(logger log.Logger, sdURL string, refreshInterval time.Duration, failures prometheus.Counter) (*Discovery, error) {
+	u, err := url.Parse(sdURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse HTTP SD URL %s", sdURL)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("HTTP SD URL %s must have http or https scheme", sdURL)
+	}
+	if refreshInterval <= 0 {
+		return nil, errors.New("HTTP SD refresh interval must be positive")
+	}
+
+	d := &Discovery{
+		url:             sdURL,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Transport: exthttp.NewTransport(), Timeout: refreshInterval},
+		failures:        failures,
+	}
+	d.Discovery = refresh.NewDiscovery(logger, "http", refreshInterval, d.refresh)
+	return d, nil
+}
+
+func (d *Discovery) refresh(ctx context.Context) ([]*targetgroup.Group, error) {
+	tgs, err := d.fetch(ctx)
+	if err != nil {
+		if d.failures != nil {
+			d.failures.Inc()
+		}
+		return nil, err
+	}
+
+	// Clear groups which are not in the document anymore by sending them without targets.
+	for i := len(tgs); i < d.lastGroups; i++ {
+		tgs = append(tgs, &targetgroup.Group{Source: d.source(i)})
+	}
+	d.lastGroups = len(tgs)
+	return tgs, nil
+}
+
+func (d *Discovery) source(i int) string {
+	return fmt.Sprintf("%s:%d", d.url, i)
+}
+
+func (d *Discovery) fetch(ctx context.Context) ([]*targetgroup.Group, error) {
+	req, err := http.NewRequest(http.MethodGet, d.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(refreshIntervalHeader, strconv.FormatFloat(d.refreshInterval.Seconds(), 'f', -1, 64))
+
+	resp, err := d.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch targets from %s", d.url)
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("fetch targets from %s: unexpected status %s", d.url, resp.Status)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return nil, errors.Errorf("fetch targets from %s: unexpected content type %q", d.url, resp.Header.Get("Content-Type"))
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read targets from %s", d.url)
+	}
+	if len(b) > maxResponseSize {
+		return nil, errors.Errorf("targets of %s exceed %d bytes", d.url, maxResponseSize)
+	}
+
+	var groups []targetGroup
+	if err := json.Unmarshal(b, &groups); err != nil {
+		return nil, errors.Wrapf(err, "parse targets of %s", d.url)
+	}
+
+	tgs := make([]*targetgroup.Group, 0, len(groups))
+	for i, g := range groups {
+		tg := &targetgroup.Group{Source: d.source(i), Labels: model.LabelSet{}}
+		for name, value := range g.Labels {
+			tg.Labels[model.LabelName(name)] = model.LabelValue(value)
+		}
+		for _, t := range g.Targets {
+			tg.Targets = append(tg.Targets, model.LabelSet{model.AddressLabel: model.LabelValue(t)})
+		}
+		tgs = append(tgs, tg)
+	}
+	return tgs, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestDiscovery_Refresh(t *testing.T) {
+	body := `[{"targets": ["store-1:10901", "store-2:10901"], "labels": {"zone": "a"}}, {"targets": ["store-3:10901"]}]`
+	contentType := "application/json; charset=utf-8"
+	var refreshInterval string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshInterval = r.Header.Get(refreshIntervalHeader)
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	failures := prometheus.NewCounter(prometheus.CounterOpts{Name: "failures"})
+	d, err := NewDiscovery(nil, srv.URL, time.Minute, failures)
+	testutil.Ok(t, err)
+
+	tgs, err := d.refresh(context.Background())
+	testutil.Ok(t, err)
+	testutil.Equals(t, "60", refreshInterval)
+	testutil.Equals(t, []*targetgroup.Group{
+		{
+			Source:  srv.URL + ":0",
+			Targets: []model.LabelSet{{model.AddressLabel: "store-1:10901"}, {model.AddressLabel: "store-2:10901"}},
+			Labels:  model.LabelSet{"zone": "a"},
+		},
+		{
+			Source:  srv.URL + ":1",
+			Targets: []model.LabelSet{{model.AddressLabel: "store-3:10901"}},
+			Labels:  model.LabelSet{},
+		},
+	}, tgs)
+
+	// Groups not in the document anymore are cleared.
+	body = `[{"targets": ["store-1:10901"]}]`
+	tgs, err = d.refresh(context.Background())
+	testutil.Ok(t, err)
+	testutil.Equals(t, []*targetgroup.Group{
+		{
+			Source:  srv.URL + ":0",
+			Targets: []model.LabelSet{{model.AddressLabel: "store-1:10901"}},
+			Labels:  model.LabelSet{},
+		},
+		{Source: srv.URL + ":1"},
+	}, tgs)
+
+	contentType = "text/plain"
+	_, err = d.refresh(context.Background())
+	testutil.NotOk(t, err)
+
+	contentType = "application/json"
+	body = `{"targets": []}`
+	_, err = d.refresh(context.Background())
+	testutil.NotOk(t, err)
+	testutil.Equals(t, 2.0, promtest.ToFloat64(failures))
+}
+
+func TestNewDiscovery(t *testing.T) {
+	_, err := NewDiscovery(nil, "file:///etc/stores.json", time.Minute, nil)
+	testutil.NotOk(t, err)
+	_, err = NewDiscovery(nil, "http://inventory/stores", 0, nil)
+	testutil.NotOk(t, err)
+	_, err = NewDiscovery(nil, "https://inventory/stores", time.Minute, nil)
+	testutil.Ok(t, err)
+}
@@ -11,37 +11,52 @@ import (
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extprom"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/strutil"
 	"github.com/thanos-io/thanos/pkg/tls"
 	"github.com/thanos-io/thanos/pkg/tracing"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
 )
 
-// StoreClientGRPCOpts creates gRPC dial options for connecting to a store client.
-func StoreClientGRPCOpts(logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, secure bool, cert, key, caCert, serverName string) ([]grpc.DialOption, error) {
+// StoreClientGRPCOpts creates gRPC dial options for connecting to a store client. Requests are compressed with the
+// given compressor registered by RegisterCompressors, unless it is CompressionNone. Labels of received series are
+// interned by the given interner, unless it is nil.
+func StoreClientGRPCOpts(logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, secure bool, cert, key, caCert, serverName, compression string, interner *strutil.Interner) ([]grpc.DialOption, error) {
 	grpcMets := grpc_prometheus.NewClientMetrics()
 	grpcMets.EnableClientHandlingTimeHistogram(
 		grpc_prometheus.WithHistogramBuckets([]float64{0.001, 0.01, 0.1, 0.3, 0.6, 1, 3, 6, 9, 20, 30, 60, 90, 120}),
 	)
+	unaryInterceptors := []grpc.UnaryClientInterceptor{
+		grpcMets.UnaryClientInterceptor(),
+		tracing.UnaryClientInterceptor(tracer),
+	}
+	streamInterceptors := []grpc.StreamClientInterceptor{
+		grpcMets.StreamClientInterceptor(),
+		tracing.StreamClientInterceptor(tracer),
+	}
+	if compression != CompressionNone && compression != "" {
+		if encoding.GetCompressor(compression) == nil {
+			return nil, errors.Errorf("gRPC compressor %q is not registered", compression)
+		}
+		n := newCompressionNegotiator(logger, compression)
+		unaryInterceptors = append(unaryInterceptors, n.UnaryClientInterceptor())
+		streamInterceptors = append(streamInterceptors, n.StreamClientInterceptor())
+	}
 	dialOpts := []grpc.DialOption{
 		// We want to make sure that we can receive huge gRPC messages from storeAPI.
 		// On TCP level we can be fine, but the gRPC overhead for huge messages could be significant.
 		// Current limit is ~2GB.
 		// TODO(bplotka): Split sent chunks on store node per max 4MB chunks if needed.
 		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(math.MaxInt32)),
-		grpc.WithUnaryInterceptor(
-			grpc_middleware.ChainUnaryClient(
-				grpcMets.UnaryClientInterceptor(),
-				tracing.UnaryClientInterceptor(tracer),
-			),
-		),
-		grpc.WithStreamInterceptor(
-			grpc_middleware.ChainStreamClient(
-				grpcMets.StreamClientInterceptor(),
-				tracing.StreamClientInterceptor(tracer),
-			),
-		),
+		// Series are unmarshalled without copying their labels and chunks out of received messages.
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(storepb.Codec{Interner: interner})),
+		grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(unaryInterceptors...)),
+		grpc.WithStreamInterceptor(grpc_middleware.ChainStreamClient(streamInterceptors...)),
 	}
 	if reg != nil {
 		reg.MustRegister(grpcMets)
@@ -53,7 +68,11 @@ func StoreClientGRPCOpts(logger log.Logger, reg *prometheus.Registry, tracer ope
 
 	level.Info(logger).Log("msg", "enabling client to server TLS")
 
-	tlsCfg, err := tls.NewClientConfig(logger, cert, key, caCert, serverName)
+	var tlsReg prometheus.Registerer
+	if reg != nil {
+		tlsReg = extprom.WrapRegistererWith(prometheus.Labels{"protocol": "grpc"}, reg)
+	}
+	tlsCfg, err := tls.NewClientConfig(logger, tlsReg, cert, key, caCert, serverName)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,241 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package extgrpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// CompressionNone disables compression of gRPC messages.
+	CompressionNone = "none"
+	// CompressionZstd compresses gRPC messages with zstd.
+	CompressionZstd = "zstd"
+)
+
+// compressionRecheckInterval is the interval after which servers found to not support compression are tried with
+// compression again, e.g. after they were upgraded.
+const compressionRecheckInterval = 10 * time.Minute
+
+var registerCompressorsOnce sync.Once
+
+// RegisterCompressors registers compressors of gRPC messages, so servers decompress requests compressed by them and
+// compress their responses likewise, and clients can use them. It has to be called before any gRPC server or client
+// is created. Compressors are registered once per process, with metrics registered in the given registerer.
+func RegisterCompressors(reg prometheus.Registerer) {
+	registerCompressorsOnce.Do(func() {
+		encoding.RegisterCompressor(newZstdCompressor(reg))
+	})
+}
+
+type compressionMetrics struct {
+	uncompressedBytes *prometheus.CounterVec
+	compressedBytes   *prometheus.CounterVec
+	duration          *prometheus.CounterVec
+}
+
+func newCompressionMetrics(reg prometheus.Registerer) *compressionMetrics {
+	return &compressionMetrics{
+		uncompressedBytes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_grpc_compression_uncompressed_bytes_total",
+			Help: "Total number of bytes of gRPC messages before compression or after decompression.",
+		}, []string{"compressor", "operation"}),
+		compressedBytes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_grpc_compression_compressed_bytes_total",
+			Help: "Total number of bytes of gRPC messages after compression or before decompression.",
+		}, []string{"compressor", "operation"}),
+		duration: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_grpc_compression_duration_seconds_total",
+			Help: "Total time spent compressing or decompressing gRPC messages. Compression runs on a single goroutine per message, so it approximates the CPU time used.",
+		}, []string{"compressor", "operation"}),
+	}
+}
+
+func (m *compressionMetrics) observe(compressor, operation string, uncompressed, compressed int, d time.Duration) {
+	m.uncompressedBytes.WithLabelValues(compressor, operation).Add(float64(uncompressed))
+	m.compressedBytes.WithLabelValues(compressor, operation).Add(float64(compressed))
+	m.duration.WithLabelValues(compressor, operation).Add(d.Seconds())
+}
+
+// zstdCompressor is a gRPC compressor using zstd. gRPC messages are held in memory anyway, so each message is
+// compressed as a whole by the shared encoder and decoder, which are safe for concurrent use in that mode.
+type zstdCompressor struct {
+	enc     *zstd.Encoder
+	dec     *zstd.Decoder
+	bufs    sync.Pool
+	metrics *compressionMetrics
+}
+
+func newZstdCompressor(reg prometheus.Registerer) *zstdCompressor {
+	// Neither fails without options returning errors.
+	enc, _ := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	dec, _ := zstd.NewReader(nil)
+	return &zstdCompressor{
+		enc:     enc,
+		dec:     dec,
+		bufs:    sync.Pool{New: func() interface{} { return &bytes.Buffer{} }},
+		metrics: newCompressionMetrics(reg),
+	}
+}
+
+func (c *zstdCompressor) Name() string { return CompressionZstd }
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &zstdWriter{c: c, w: w, buf: c.getBuffer()}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	// The compressed message is not referenced once decoded, so it is read into a pooled buffer.
+	buf := c.getBuffer()
+	defer c.bufs.Put(buf)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+
+	out := c.getBuffer()
+	start := time.Now()
+	b, err := c.dec.DecodeAll(buf.Bytes(), out.Bytes()[:0])
+	if err != nil {
+		c.bufs.Put(out)
+		return nil, errors.Wrap(err, "zstd decode")
+	}
+	c.metrics.observe(CompressionZstd, "decompress", len(b), buf.Len(), time.Since(start))
+	*out = *bytes.NewBuffer(b)
+	return &pooledReader{buf: out, pool: &c.bufs}, nil
+}
+
+func (c *zstdCompressor) getBuffer() *bytes.Buffer {
+	buf := c.bufs.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// pooledReader reads the buffer and puts it back to the pool once it is read entirely. gRPC reads decompressed
+// messages entirely into a buffer of its own, so the decompressed message is reused by other messages afterwards.
+type pooledReader struct {
+	buf  *bytes.Buffer
+	pool *sync.Pool
+}
+
+func (r *pooledReader) Read(p []byte) (int, error) {
+	if r.buf == nil {
+		return 0, io.EOF
+	}
+	n, err := r.buf.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.buf)
+		r.buf = nil
+	}
+	return n, err
+}
+
+// zstdWriter buffers the message and writes it compressed on close. gRPC copies written messages, so both buffers
+// are reused by other messages afterwards.
+type zstdWriter struct {
+	c   *zstdCompressor
+	w   io.Writer
+	buf *bytes.Buffer
+}
+
+func (w *zstdWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *zstdWriter) Close() error {
+	out := w.c.getBuffer()
+	defer w.c.bufs.Put(out)
+	defer w.c.bufs.Put(w.buf)
+
+	start := time.Now()
+	b := w.c.enc.EncodeAll(w.buf.Bytes(), out.Bytes()[:0])
+	w.c.metrics.observe(CompressionZstd, "compress", w.buf.Len(), len(b), time.Since(start))
+	_, err := w.w.Write(b)
+	// The encoded message may have outgrown the buffer, so the grown one is kept in the pool.
+	*out = *bytes.NewBuffer(b[:0])
+	return err
+}
+
+// compressionNegotiator compresses requests of clients with the compressor, unless their server does not support it.
+// Such servers are detected by unary calls failing, which are retried uncompressed. Stores are health checked by
+// unary Info calls before they are streamed from, so streams are negotiated by them.
+type compressionNegotiator struct {
+	logger     log.Logger
+	compressor string
+
+	mtx         sync.Mutex
+	unsupported map[string]time.Time
+}
+
+func newCompressionNegotiator(logger log.Logger, compressor string) *compressionNegotiator {
+	return &compressionNegotiator{logger: logger, compressor: compressor, unsupported: map[string]time.Time{}}
+}
+
+func (n *compressionNegotiator) supported(target string) bool {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	t, ok := n.unsupported[target]
+	if !ok {
+		return true
+	}
+	if time.Since(t) > compressionRecheckInterval {
+		delete(n.unsupported, target)
+		return true
+	}
+	return false
+}
+
+func (n *compressionNegotiator) markUnsupported(target string) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if _, ok := n.unsupported[target]; !ok {
+		level.Warn(n.logger).Log("msg", "gRPC server does not support compression, falling back to uncompressed requests", "compressor", n.compressor, "target", target)
+	}
+	n.unsupported[target] = time.Now()
+}
+
+// isUnsupportedCompression returns true if the error is returned by gRPC servers without a decompressor of the request.
+func isUnsupportedCompression(err error) bool {
+	return status.Code(err) == codes.Unimplemented && strings.Contains(status.Convert(err).Message(), "grpc-encoding")
+}
+
+func (n *compressionNegotiator) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !n.supported(cc.Target()) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.UseCompressor(n.compressor))...)
+		if !isUnsupportedCompression(err) {
+			return err
+		}
+		n.markUnsupported(cc.Target())
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func (n *compressionNegotiator) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if n.supported(cc.Target()) {
+			opts = append(opts, grpc.UseCompressor(n.compressor))
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
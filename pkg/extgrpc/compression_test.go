@@ -0,0 +1,139 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package extgrpc
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/health"
+	grpc_health "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestZstdCompressor(t *testing.T) {
+	c := newZstdCompressor(prometheus.NewRegistry())
+
+	msg := bytes.Repeat([]byte("series chunk "), 1000)
+	var compressed bytes.Buffer
+	w, err := c.Compress(&compressed)
+	testutil.Ok(t, err)
+	_, err = w.Write(msg)
+	testutil.Ok(t, err)
+	testutil.Ok(t, w.Close())
+	testutil.Assert(t, compressed.Len() < len(msg), "expected %d bytes compressed to fewer, got %d", len(msg), compressed.Len())
+
+	r, err := c.Decompress(bytes.NewReader(compressed.Bytes()))
+	testutil.Ok(t, err)
+	decompressed, err := ioutil.ReadAll(r)
+	testutil.Ok(t, err)
+	testutil.Equals(t, msg, decompressed)
+
+	testutil.Equals(t, float64(len(msg)), promtest.ToFloat64(c.metrics.uncompressedBytes.WithLabelValues(CompressionZstd, "compress")))
+	testutil.Equals(t, float64(compressed.Len()), promtest.ToFloat64(c.metrics.compressedBytes.WithLabelValues(CompressionZstd, "compress")))
+	testutil.Equals(t, float64(len(msg)), promtest.ToFloat64(c.metrics.uncompressedBytes.WithLabelValues(CompressionZstd, "decompress")))
+	testutil.Equals(t, float64(compressed.Len()), promtest.ToFloat64(c.metrics.compressedBytes.WithLabelValues(CompressionZstd, "decompress")))
+
+	_, err = c.Decompress(bytes.NewReader([]byte("not zstd")))
+	testutil.NotOk(t, err)
+}
+
+func TestCompressionNegotiator(t *testing.T) {
+	cc, err := grpc.Dial("localhost:1", grpc.WithInsecure())
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, cc.Close()) }()
+
+	compressed := func(opts []grpc.CallOption) bool {
+		for _, o := range opts {
+			if c, ok := o.(grpc.CompressorCallOption); ok && c.CompressorType == CompressionZstd {
+				return true
+			}
+		}
+		return false
+	}
+
+	n := newCompressionNegotiator(log.NewNopLogger(), CompressionZstd)
+	unary := n.UnaryClientInterceptor()
+	stream := n.StreamClientInterceptor()
+
+	var calls []bool
+	supportedInvoker := func(_ context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls = append(calls, compressed(opts))
+		return nil
+	}
+	unsupportedInvoker := func(_ context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls = append(calls, compressed(opts))
+		if compressed(opts) {
+			return status.Errorf(codes.Unimplemented, "grpc: Decompressor is not installed for grpc-encoding %q", CompressionZstd)
+		}
+		return nil
+	}
+	streamer := func(_ context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		calls = append(calls, compressed(opts))
+		return nil, nil
+	}
+
+	testutil.Ok(t, unary(context.Background(), "/thanos.Store/Info", nil, nil, cc, supportedInvoker))
+	_, err = stream(context.Background(), nil, cc, "/thanos.Store/Series", streamer)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []bool{true, true}, calls)
+
+	// Servers not supporting compression are retried uncompressed, and requested uncompressed from then on.
+	calls = nil
+	testutil.Ok(t, unary(context.Background(), "/thanos.Store/Info", nil, nil, cc, unsupportedInvoker))
+	testutil.Ok(t, unary(context.Background(), "/thanos.Store/Info", nil, nil, cc, unsupportedInvoker))
+	_, err = stream(context.Background(), nil, cc, "/thanos.Store/Series", streamer)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []bool{true, false, false, false}, calls)
+
+	// Other errors are not retried.
+	calls = nil
+	n = newCompressionNegotiator(log.NewNopLogger(), CompressionZstd)
+	err = n.UnaryClientInterceptor()(context.Background(), "/thanos.Store/Info", nil, nil, cc, func(_ context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls = append(calls, compressed(opts))
+		return status.Error(codes.Unimplemented, "unknown method")
+	})
+	testutil.Equals(t, codes.Unimplemented, status.Code(err))
+	testutil.Equals(t, []bool{true}, calls)
+}
+
+func TestStoreClientGRPCOpts_Compression(t *testing.T) {
+	RegisterCompressors(nil)
+	c, ok := encoding.GetCompressor(CompressionZstd).(*zstdCompressor)
+	testutil.Assert(t, ok, "expected zstd compressor to be registered")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	testutil.Ok(t, err)
+	srv := grpc.NewServer()
+	grpc_health.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Stop()
+
+	_, err = StoreClientGRPCOpts(log.NewNopLogger(), nil, nil, false, "", "", "", "", "unknown", nil)
+	testutil.NotOk(t, err)
+
+	opts, err := StoreClientGRPCOpts(log.NewNopLogger(), nil, nil, false, "", "", "", "", CompressionZstd, nil)
+	testutil.Ok(t, err)
+	cc, err := grpc.Dial(l.Addr().String(), opts...)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, cc.Close()) }()
+
+	resp, err := grpc_health.NewHealthClient(cc).Check(context.Background(), &grpc_health.HealthCheckRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, grpc_health.HealthCheckResponse_SERVING, resp.Status)
+
+	// The response is compressed by the server, as the request was, and decompressed by the client.
+	testutil.Assert(t, promtest.ToFloat64(c.metrics.compressedBytes.WithLabelValues(CompressionZstd, "compress")) > 0, "expected compressed response")
+	testutil.Assert(t, promtest.ToFloat64(c.metrics.compressedBytes.WithLabelValues(CompressionZstd, "decompress")) > 0, "expected decompressed response")
+}
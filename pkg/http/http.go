@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"path"
 	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	config_util "github.com/prometheus/common/config"
@@ -262,3 +263,14 @@ func (c *Client) Discover(ctx context.Context) {
 func (c *Client) Resolve(ctx context.Context) {
 	c.provider.Resolve(ctx, append(c.fileSDCache.Addresses(), c.staticAddresses...))
 }
+
+// RefreshInterval returns the interval until the resolved addresses expire if the provider knows it, e.g. from DNS
+// record TTLs, or the given interval otherwise.
+func (c *Client) RefreshInterval(interval time.Duration) time.Duration {
+	if p, ok := c.provider.(interface {
+		RefreshInterval(time.Duration) time.Duration
+	}); ok {
+		return p.RefreshInterval(interval)
+	}
+	return interval
+}
@@ -0,0 +1,362 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package logging implements logging of HTTP and gRPC requests, decided per request by a policy of rules which can be
+// reloaded at runtime.
+package logging
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+)
+
+// Rule decides how matching requests are logged.
+type Rule struct {
+	// Paths are patterns of HTTP request paths or of full gRPC method names, e.g. "/api/v1/*" or "/thanos.Store/*".
+	// All paths match if empty.
+	Paths []string `yaml:"paths"`
+	// Methods are HTTP request methods, e.g. GET. All methods match if empty. Not supported by gRPC rules.
+	Methods []string `yaml:"methods"`
+	// StatusClasses restrict logging of finished requests to those with a status of the classes. These are classes
+	// of HTTP status codes, e.g. "2xx" or "5xx", or gRPC status code names, e.g. "OK" or "Unavailable". Finished
+	// requests of any status are logged if empty.
+	StatusClasses []string `yaml:"status_classes"`
+	// LogStart logs requests when they are received.
+	LogStart bool `yaml:"log_start"`
+	// LogFinish logs requests when they are finished, with their status and duration.
+	LogFinish bool `yaml:"log_finish"`
+	// PayloadSizes adds the sizes of request and response payloads in bytes to logs of finished requests.
+	PayloadSizes bool `yaml:"payload_sizes"`
+	// SampleRate is the ratio of matching requests logged, between 0 and 1. Defaults to 1.
+	SampleRate *float64 `yaml:"sample_rate"`
+}
+
+// Config is the configuration of request logging. Requests are logged as decided by the first rule matching them,
+// and not logged if no rule matches.
+type Config struct {
+	HTTP []Rule `yaml:"http"`
+	GRPC []Rule `yaml:"grpc"`
+}
+
+// decision is how a request is logged.
+type decision struct {
+	logStart      bool
+	logFinish     bool
+	payloadSizes  bool
+	statusClasses []string
+}
+
+func (d decision) logsFinish(class string) bool {
+	if !d.logFinish {
+		return false
+	}
+	if len(d.statusClasses) == 0 {
+		return true
+	}
+	for _, c := range d.statusClasses {
+		if strings.EqualFold(c, class) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestLogger logs HTTP and gRPC requests as decided by its configuration.
+type RequestLogger struct {
+	logger log.Logger
+
+	mtx    sync.RWMutex
+	config Config
+
+	randMtx sync.Mutex
+	rand    *rand.Rand
+
+	lastReloadSuccessful prometheus.Gauge
+}
+
+// NewRequestLogger parses the YAML request logging configuration and returns a request logger.
+func NewRequestLogger(logger log.Logger, reg prometheus.Registerer, conf []byte) (*RequestLogger, error) {
+	l := &RequestLogger{
+		logger: logger,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		lastReloadSuccessful: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "thanos_request_logging_config_last_reload_successful",
+			Help: "Whether the last reload of the request logging configuration was successful.",
+		}),
+	}
+	if err := l.ApplyConfig(conf); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func parseConfig(conf []byte) (Config, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return Config{}, errors.Wrap(err, "parsing request logging config")
+	}
+	for protocol, rules := range map[string][]Rule{"http": config.HTTP, "grpc": config.GRPC} {
+		for i, r := range rules {
+			if protocol == "grpc" && len(r.Methods) > 0 {
+				return Config{}, errors.Errorf("methods of %s rule %d are not supported", protocol, i)
+			}
+			for _, p := range r.Paths {
+				if _, err := path.Match(p, ""); err != nil {
+					return Config{}, errors.Wrapf(err, "invalid pattern %s of %s rule %d", p, protocol, i)
+				}
+			}
+			if r.SampleRate != nil && (*r.SampleRate < 0 || *r.SampleRate > 1) {
+				return Config{}, errors.Errorf("sample rate of %s rule %d must be between 0 and 1", protocol, i)
+			}
+		}
+	}
+	return config, nil
+}
+
+// ApplyConfig parses the YAML request logging configuration and applies it to requests received from now on. The
+// previous configuration is kept if parsing fails.
+func (l *RequestLogger) ApplyConfig(conf []byte) error {
+	config, err := parseConfig(conf)
+	if err != nil {
+		l.lastReloadSuccessful.Set(0)
+		return err
+	}
+
+	l.mtx.Lock()
+	l.config = config
+	l.mtx.Unlock()
+
+	l.lastReloadSuccessful.Set(1)
+	return nil
+}
+
+// decide returns how a request is logged by the first of the rules matching its path and method, and whether it is
+// logged at all.
+func (l *RequestLogger) decide(rules func(Config) []Rule, reqPath, method string) (decision, bool) {
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+
+	for _, r := range rules(l.config) {
+		if len(r.Paths) > 0 && !matchAny(r.Paths, reqPath) {
+			continue
+		}
+		if len(r.Methods) > 0 && !containsFold(r.Methods, method) {
+			continue
+		}
+		if !r.LogStart && !r.LogFinish {
+			return decision{}, false
+		}
+		if r.SampleRate != nil && *r.SampleRate < 1 && !l.sample(*r.SampleRate) {
+			return decision{}, false
+		}
+		return decision{
+			logStart:      r.LogStart,
+			logFinish:     r.LogFinish,
+			payloadSizes:  r.PayloadSizes,
+			statusClasses: r.StatusClasses,
+		}, true
+	}
+	return decision{}, false
+}
+
+// sample returns true for the given ratio of calls.
+func (l *RequestLogger) sample(rate float64) bool {
+	l.randMtx.Lock()
+	defer l.randMtx.Unlock()
+	return l.rand.Float64() < rate
+}
+
+func matchAny(patterns []string, v string) bool {
+	for _, p := range patterns {
+		// Patterns are validated on parsing, so matching cannot fail.
+		if ok, _ := path.Match(p, v); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, v string) bool {
+	for _, s := range values {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func httpRules(c Config) []Rule { return c.HTTP }
+func grpcRules(c Config) []Rule { return c.GRPC }
+
+// responseWriter records the status code and size of responses.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush implements http.Flusher, so streamed responses are not buffered by logging.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// httpStatusClass returns the class of an HTTP status code, e.g. "2xx".
+func httpStatusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// Handler returns a handler logging requests before and after calling next, as decided by the configuration.
+func (l *RequestLogger) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d, ok := l.decide(httpRules, r.URL.Path, r.Method)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if d.logStart {
+			level.Info(l.logger).Log("msg", "HTTP request started", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+		}
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		if !d.logsFinish(httpStatusClass(rw.status)) {
+			return
+		}
+		kvs := []interface{}{"msg", "HTTP request finished", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr, "status", rw.status, "duration", time.Since(start)}
+		if d.payloadSizes {
+			kvs = append(kvs, "request_bytes", r.ContentLength, "response_bytes", rw.size)
+		}
+		level.Info(l.logger).Log(kvs...)
+	})
+}
+
+// messageSize returns the size of a gRPC message in bytes, or 0 if it is not a protobuf message.
+func messageSize(m interface{}) int {
+	if msg, ok := m.(proto.Message); ok {
+		return proto.Size(msg)
+	}
+	return 0
+}
+
+// logGRPCFinish logs a finished gRPC request if decided for the status of its error.
+func (l *RequestLogger) logGRPCFinish(d decision, method string, start time.Time, err error, reqSize, respSize int) {
+	code := status.Code(err)
+	if !d.logsFinish(code.String()) {
+		return
+	}
+	kvs := []interface{}{"msg", "gRPC request finished", "method", method, "code", code.String(), "duration", time.Since(start)}
+	if d.payloadSizes {
+		kvs = append(kvs, "request_bytes", reqSize, "response_bytes", respSize)
+	}
+	if err != nil {
+		kvs = append(kvs, "err", err)
+	}
+	level.Info(l.logger).Log(kvs...)
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor logging unary requests as decided by the configuration.
+func (l *RequestLogger) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		d, ok := l.decide(grpcRules, info.FullMethod, "")
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		if d.logStart {
+			level.Info(l.logger).Log("msg", "gRPC request started", "method", info.FullMethod)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		var reqSize, respSize int
+		if d.payloadSizes {
+			reqSize, respSize = messageSize(req), messageSize(resp)
+		}
+		l.logGRPCFinish(d, info.FullMethod, start, err, reqSize, respSize)
+		return resp, err
+	}
+}
+
+// serverStream records the sizes of messages received and sent.
+type serverStream struct {
+	grpc.ServerStream
+	recvSize, sendSize int
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.recvSize += messageSize(m)
+	return nil
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.sendSize += messageSize(m)
+	return nil
+}
+
+// StreamServerInterceptor returns a gRPC interceptor logging streaming requests as decided by the configuration.
+func (l *RequestLogger) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		d, ok := l.decide(grpcRules, info.FullMethod, "")
+		if !ok {
+			return handler(srv, ss)
+		}
+
+		if d.logStart {
+			level.Info(l.logger).Log("msg", "gRPC request started", "method", info.FullMethod)
+		}
+		start := time.Now()
+		if !d.payloadSizes {
+			err := handler(srv, ss)
+			l.logGRPCFinish(d, info.FullMethod, start, err, 0, 0)
+			return err
+		}
+
+		s := &serverStream{ServerStream: ss}
+		err := handler(srv, s)
+		l.logGRPCFinish(d, info.FullMethod, start, err, s.recvSize, s.sendSize)
+		return err
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewRequestLogger(t *testing.T) {
+	for _, tcase := range []struct {
+		conf string
+		err  bool
+	}{
+		{conf: ``},
+		{conf: `http: [{paths: ["/api/v1/*"], log_finish: true, sample_rate: 0.5}]`},
+		{conf: `grpc: [{paths: ["/thanos.Store/*"], log_start: true}]`},
+		{conf: `grpc: [{methods: [GET], log_start: true}]`, err: true},
+		{conf: `http: [{paths: ["[-"], log_start: true}]`, err: true},
+		{conf: `http: [{log_start: true, sample_rate: 2}]`, err: true},
+		{conf: `http: [{log: true}]`, err: true},
+	} {
+		t.Run(tcase.conf, func(t *testing.T) {
+			_, err := NewRequestLogger(log.NewNopLogger(), nil, []byte(tcase.conf))
+			testutil.Equals(t, tcase.err, err != nil)
+		})
+	}
+}
+
+func TestRequestLogger_Handler(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewRequestLogger(log.NewLogfmtLogger(&buf), nil, []byte(`
+http:
+  - paths: ["/-/healthy"]
+  - paths: ["/api/v1/*"]
+    methods: [POST]
+    status_classes: ["5xx"]
+    log_start: true
+    log_finish: true
+    payload_sizes: true
+  - paths: ["/api/v1/*"]
+    log_finish: true
+    sample_rate: 0
+`))
+	testutil.Ok(t, err)
+
+	h := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte("response"))
+	}))
+	for _, tcase := range []struct {
+		method, path string
+		logs         []string
+		suffix       string
+	}{
+		{method: "GET", path: "/-/healthy"},
+		// Sampled out by the last rule.
+		{method: "GET", path: "/api/v1/query"},
+		// Started, but not finished with a matching status class.
+		{method: "POST", path: "/api/v1/query", logs: []string{`msg="HTTP request started" method=POST path=/api/v1/query`}},
+		{method: "POST", path: "/api/v1/fail", logs: []string{
+			`msg="HTTP request started" method=POST path=/api/v1/fail`,
+			`msg="HTTP request finished" method=POST path=/api/v1/fail remote=192.0.2.1:1234 status=500 duration=`,
+		}, suffix: "request_bytes=4 response_bytes=8"},
+		{method: "GET", path: "/other"},
+	} {
+		t.Run(tcase.method+" "+tcase.path, func(t *testing.T) {
+			buf.Reset()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tcase.method, tcase.path, strings.NewReader("body")))
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(tcase.logs) == 0 {
+				testutil.Equals(t, "", buf.String())
+				return
+			}
+			testutil.Equals(t, len(tcase.logs), len(lines))
+			for i, exp := range tcase.logs {
+				testutil.Assert(t, strings.Contains(lines[i], exp), "expected %q in %q", exp, lines[i])
+			}
+			testutil.Assert(t, strings.HasSuffix(lines[len(lines)-1], tcase.suffix), "expected suffix %q of %q", tcase.suffix, lines[len(lines)-1])
+		})
+	}
+}
+
+func TestRequestLogger_GRPC(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewRequestLogger(log.NewLogfmtLogger(&buf), nil, []byte(`
+grpc:
+  - paths: ["/thanos.Store/Info"]
+    log_finish: true
+    payload_sizes: true
+  - status_classes: [Unavailable]
+    log_finish: true
+`))
+	testutil.Ok(t, err)
+
+	interceptor := l.UnaryServerInterceptor()
+	_, err = interceptor(context.Background(), &storepb.InfoRequest{}, &grpc.UnaryServerInfo{FullMethod: "/thanos.Store/Info"}, func(context.Context, interface{}) (interface{}, error) {
+		return &storepb.InfoResponse{MinTime: 1}, nil
+	})
+	testutil.Ok(t, err)
+	testutil.Assert(t, strings.Contains(buf.String(), `msg="gRPC request finished" method=/thanos.Store/Info code=OK`), "unexpected log: %s", buf.String())
+	testutil.Assert(t, strings.Contains(buf.String(), "request_bytes=0 response_bytes=2"), "unexpected log: %s", buf.String())
+
+	buf.Reset()
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/thanos.Store/LabelNames"}, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.InvalidArgument, "invalid")
+	})
+	testutil.NotOk(t, err)
+	testutil.Equals(t, "", buf.String())
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/thanos.Store/LabelNames"}, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	})
+	testutil.NotOk(t, err)
+	testutil.Assert(t, strings.Contains(buf.String(), `code=Unavailable`), "unexpected log: %s", buf.String())
+}
+
+func TestRequestLogger_ApplyConfig(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewRequestLogger(log.NewLogfmtLogger(&buf), nil, []byte(`http: [{log_start: true}]`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(l.lastReloadSuccessful))
+
+	h := l.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	testutil.Assert(t, buf.Len() > 0, "request not logged")
+
+	// Invalid configurations are not applied.
+	testutil.NotOk(t, l.ApplyConfig([]byte(`http: [{sample_rate: -1}]`)))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(l.lastReloadSuccessful))
+	buf.Reset()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	testutil.Assert(t, buf.Len() > 0, "request not logged")
+
+	testutil.Ok(t, l.ApplyConfig([]byte(``)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(l.lastReloadSuccessful))
+	buf.Reset()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	testutil.Equals(t, "", buf.String())
+}
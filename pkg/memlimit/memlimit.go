@@ -0,0 +1,72 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package memlimit sets the soft memory limit of the Go runtime, optionally detected from the cgroup of the process, and
+// derives memory budgets of components from it.
+package memlimit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EnvName is the environment variable the Go runtime reads its memory limit from. It takes precedence over flags.
+const EnvName = "GOMEMLIMIT"
+
+// unlimitedCgroupV1 is the value from which cgroup v1 memory limits are considered unset. Unset limits are the
+// maximal int64 value rounded down to the page size.
+const unlimitedCgroupV1 = 1 << 62
+
+// CgroupLimit returns the memory limit of the cgroup the process runs in, assuming the cgroup filesystem of its
+// namespace is mounted at /sys/fs/cgroup, as in containers. It returns 0 if the cgroup has no memory limit.
+func CgroupLimit() (int64, error) {
+	return cgroupLimit("/sys/fs/cgroup")
+}
+
+func cgroupLimit(root string) (int64, error) {
+	// Unified hierarchy of cgroup v2.
+	b, err := ioutil.ReadFile(filepath.Join(root, "memory.max"))
+	if err == nil {
+		s := strings.TrimSpace(string(b))
+		if s == "max" {
+			return 0, nil
+		}
+		limit, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, errors.Wrap(err, "parse cgroup v2 memory.max")
+		}
+		return limit, nil
+	}
+	if !os.IsNotExist(err) {
+		return 0, errors.Wrap(err, "read cgroup v2 memory.max")
+	}
+
+	b, err = ioutil.ReadFile(filepath.Join(root, "memory", "memory.limit_in_bytes"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, errors.New("no cgroup memory controller found")
+		}
+		return 0, errors.Wrap(err, "read cgroup v1 memory.limit_in_bytes")
+	}
+	limit, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "parse cgroup v1 memory.limit_in_bytes")
+	}
+	if limit >= unlimitedCgroupV1 {
+		return 0, nil
+	}
+	return limit, nil
+}
+
+// Budget returns the given ratio of the memory limit, or def if there is no limit.
+func Budget(limit int64, ratio float64, def uint64) uint64 {
+	if limit <= 0 {
+		return def
+	}
+	return uint64(float64(limit) * ratio)
+}
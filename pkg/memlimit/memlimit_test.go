@@ -0,0 +1,77 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package memlimit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestCgroupLimit(t *testing.T) {
+	for _, tcase := range []struct {
+		name  string
+		files map[string]string
+
+		expected int64
+		err      bool
+	}{
+		{
+			name:     "cgroup v2",
+			files:    map[string]string{"memory.max": "1073741824\n"},
+			expected: 1073741824,
+		},
+		{
+			name:     "cgroup v2 without limit",
+			files:    map[string]string{"memory.max": "max\n"},
+			expected: 0,
+		},
+		{
+			name:  "cgroup v2 malformed",
+			files: map[string]string{"memory.max": "1G\n"},
+			err:   true,
+		},
+		{
+			name:     "cgroup v1",
+			files:    map[string]string{"memory/memory.limit_in_bytes": "536870912\n"},
+			expected: 536870912,
+		},
+		{
+			name:     "cgroup v1 without limit",
+			files:    map[string]string{"memory/memory.limit_in_bytes": "9223372036854771712\n"},
+			expected: 0,
+		},
+		{
+			name: "no memory controller",
+			err:  true,
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "cgroup")
+			testutil.Ok(t, err)
+			defer func() { testutil.Ok(t, os.RemoveAll(root)) }()
+
+			for name, content := range tcase.files {
+				testutil.Ok(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), os.ModePerm))
+				testutil.Ok(t, ioutil.WriteFile(filepath.Join(root, name), []byte(content), os.ModePerm))
+			}
+
+			limit, err := cgroupLimit(root)
+			if tcase.err {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.expected, limit)
+		})
+	}
+}
+
+func TestBudget(t *testing.T) {
+	testutil.Equals(t, uint64(250), Budget(0, 0.1, 250))
+	testutil.Equals(t, uint64(100), Budget(1000, 0.1, 250))
+}
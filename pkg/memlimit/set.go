@@ -0,0 +1,28 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+//go:build go1.19
+// +build go1.19
+
+package memlimit
+
+import (
+	"math"
+	"runtime/debug"
+)
+
+// Set sets the soft memory limit of the Go runtime.
+func Set(limit int64) error {
+	debug.SetMemoryLimit(limit)
+	return nil
+}
+
+// Current returns the soft memory limit of the Go runtime, either set by Set or by the GOMEMLIMIT environment
+// variable. It returns 0 if there is no limit.
+func Current() int64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit == math.MaxInt64 {
+		return 0
+	}
+	return limit
+}
@@ -0,0 +1,20 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+//go:build !go1.19
+// +build !go1.19
+
+package memlimit
+
+import "github.com/pkg/errors"
+
+// Set sets the soft memory limit of the Go runtime.
+func Set(int64) error {
+	return errors.New("soft memory limit requires Thanos built with Go 1.19 or newer")
+}
+
+// Current returns the soft memory limit of the Go runtime, either set by Set or by the GOMEMLIMIT environment
+// variable. It returns 0 if there is no limit.
+func Current() int64 {
+	return 0
+}
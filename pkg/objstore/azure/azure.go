@@ -27,30 +27,31 @@ const (
 // Config Azure storage configuration.
 type Config struct {
 	StorageAccountName string `yaml:"storage_account"`
-	StorageAccountKey  string `yaml:"storage_account_key"`
-	ContainerName      string `yaml:"container"`
-	Endpoint           string `yaml:"endpoint"`
-	MaxRetries         int    `yaml:"max_retries"`
+	// StorageAccountKey is optional. If empty, Azure AD credentials from the environment, workload identity or
+	// managed identity are used.
+	StorageAccountKey string `yaml:"storage_account_key"`
+	ContainerName     string `yaml:"container"`
+	Endpoint          string `yaml:"endpoint"`
+	MaxRetries        int    `yaml:"max_retries"`
+	// UserAssignedID is the client ID of user-assigned managed identity. If empty, system-assigned one is used.
+	UserAssignedID string `yaml:"user_assigned_id"`
 }
 
 // Bucket implements the store.Bucket interface against Azure APIs.
 type Bucket struct {
 	logger       log.Logger
 	containerURL blob.ContainerURL
+	credential   blob.Credential
 	config       *Config
 }
 
 // Validate checks to see if any of the config options are set.
 func (conf *Config) validate() error {
-	if conf.StorageAccountName == "" ||
-		conf.StorageAccountKey == "" {
-		return errors.New("invalid Azure storage configuration")
+	if conf.StorageAccountName == "" {
+		return errors.New("no Azure storage_account specified")
 	}
-	if conf.StorageAccountName == "" && conf.StorageAccountKey != "" {
-		return errors.New("no Azure storage_account specified while storage_account_key is present in config file; both should be present")
-	}
-	if conf.StorageAccountName != "" && conf.StorageAccountKey == "" {
-		return errors.New("no Azure storage_account_key specified while storage_account is present in config file; both should be present")
+	if conf.StorageAccountKey != "" && conf.UserAssignedID != "" {
+		return errors.New("user_assigned_id can't be used with storage_account_key")
 	}
 	if conf.ContainerName == "" {
 		return errors.New("no Azure container specified")
@@ -78,7 +79,11 @@ func NewBucket(logger log.Logger, azureConfig []byte, component string) (*Bucket
 	}
 
 	ctx := context.Background()
-	container, err := createContainer(ctx, conf)
+	cred, err := newCredential(ctx, logger, conf, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create Azure credential")
+	}
+	container, err := createContainer(ctx, conf, cred)
 	if err != nil {
 		ret, ok := err.(blob.StorageError)
 		if !ok {
@@ -86,7 +91,7 @@ func NewBucket(logger log.Logger, azureConfig []byte, component string) (*Bucket
 		}
 		if ret.ServiceCode() == "ContainerAlreadyExists" {
 			level.Debug(logger).Log("msg", "Getting connection to existing Azure blob container", "container", conf.ContainerName)
-			container, err = getContainer(ctx, conf)
+			container, err = getContainer(ctx, conf, cred)
 			if err != nil {
 				return nil, errors.Wrapf(err, "cannot get existing Azure blob container: %s", container)
 			}
@@ -100,6 +105,7 @@ func NewBucket(logger log.Logger, azureConfig []byte, component string) (*Bucket
 	bkt := &Bucket{
 		logger:       logger,
 		containerURL: container,
+		credential:   cred,
 		config:       &conf,
 	}
 	return bkt, nil
@@ -182,7 +188,7 @@ func (b *Bucket) getBlobReader(ctx context.Context, name string, offset, length
 		return nil, errors.New("X-Ms-Error-Code: [BlobNotFound]")
 	}
 
-	blobURL, err := getBlobURL(ctx, *b.config, name)
+	blobURL, err := getBlobURL(ctx, *b.config, b.credential, name)
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot get Azure blob URL, address: %s", name)
 	}
@@ -233,7 +239,7 @@ func (b *Bucket) GetRange(ctx context.Context, name string, off, length int64) (
 
 // ObjectSize returns the size of the specified object.
 func (b *Bucket) ObjectSize(ctx context.Context, name string) (uint64, error) {
-	blobURL, err := getBlobURL(ctx, *b.config, name)
+	blobURL, err := getBlobURL(ctx, *b.config, b.credential, name)
 	if err != nil {
 		return 0, errors.Wrapf(err, "cannot get Azure blob URL, blob: %s", name)
 	}
@@ -248,7 +254,7 @@ func (b *Bucket) ObjectSize(ctx context.Context, name string) (uint64, error) {
 // Exists checks if the given object exists.
 func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
 	level.Debug(b.logger).Log("msg", "check if blob exists", "blob", name)
-	blobURL, err := getBlobURL(ctx, *b.config, name)
+	blobURL, err := getBlobURL(ctx, *b.config, b.credential, name)
 	if err != nil {
 		return false, errors.Wrapf(err, "cannot get Azure blob URL, address: %s", name)
 	}
@@ -266,7 +272,7 @@ func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
 // Upload the contents of the reader as an object into the bucket.
 func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
 	level.Debug(b.logger).Log("msg", "Uploading blob", "blob", name)
-	blobURL, err := getBlobURL(ctx, *b.config, name)
+	blobURL, err := getBlobURL(ctx, *b.config, b.credential, name)
 	if err != nil {
 		return errors.Wrapf(err, "cannot get Azure blob URL, address: %s", name)
 	}
@@ -284,7 +290,7 @@ func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
 // Delete removes the object with the given name.
 func (b *Bucket) Delete(ctx context.Context, name string) error {
 	level.Debug(b.logger).Log("msg", "Deleting blob", "blob", name)
-	blobURL, err := getBlobURL(ctx, *b.config, name)
+	blobURL, err := getBlobURL(ctx, *b.config, b.credential, name)
 	if err != nil {
 		return errors.Wrapf(err, "cannot get Azure blob URL, address: %s", name)
 	}
@@ -16,6 +16,7 @@ func TestConfig_validate(t *testing.T) {
 		ContainerName      string
 		Endpoint           string
 		MaxRetries         int
+		UserAssignedID     string
 	}
 	tests := []struct {
 		name         string
@@ -52,6 +53,27 @@ func TestConfig_validate(t *testing.T) {
 				StorageAccountKey:  "",
 				ContainerName:      "roo",
 			},
+			wantErr:      false,
+			wantEndpoint: azureDefaultEndpoint,
+		},
+		{
+			name: "user assigned identity",
+			fields: fields{
+				StorageAccountName: "foo",
+				ContainerName:      "roo",
+				UserAssignedID:     "id",
+			},
+			wantErr:      false,
+			wantEndpoint: azureDefaultEndpoint,
+		},
+		{
+			name: "user assigned identity with account key",
+			fields: fields{
+				StorageAccountName: "foo",
+				StorageAccountKey:  "bar",
+				ContainerName:      "roo",
+				UserAssignedID:     "id",
+			},
 			wantErr: true,
 		},
 		{
@@ -92,6 +114,7 @@ func TestConfig_validate(t *testing.T) {
 				ContainerName:      tt.fields.ContainerName,
 				Endpoint:           tt.fields.Endpoint,
 				MaxRetries:         tt.fields.MaxRetries,
+				UserAssignedID:     tt.fields.UserAssignedID,
 			}
 			err := conf.validate()
 			if (err != nil) != tt.wantErr {
@@ -0,0 +1,206 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	blob "github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
+)
+
+const (
+	storageScope    = "https://storage.azure.com/.default"
+	storageResource = "https://storage.azure.com/"
+
+	defaultAuthorityHost = "https://login.microsoftonline.com/"
+
+	// tokenRefreshMargin is how long before expiration tokens are refreshed.
+	tokenRefreshMargin = 5 * time.Minute
+	// tokenRetryInterval is how long to wait before retrying failed token refresh.
+	tokenRetryInterval = 30 * time.Second
+)
+
+// msiEndpoint is the Azure Instance Metadata Service token endpoint.
+var msiEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// tokenSource returns Azure AD access token for Azure Storage and its lifetime.
+type tokenSource struct {
+	name  string
+	token func(ctx context.Context) (string, time.Duration, error)
+}
+
+// newCredential returns the credential used to authorize requests to the storage account. The storage account
+// key is used if configured, otherwise Azure AD tokens are obtained from the first working source of:
+//   - service principal client secret from AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET env variables,
+//   - workload identity federated token from AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_FEDERATED_TOKEN_FILE env
+//     variables, as injected into AKS pods by Azure Workload Identity,
+//   - managed identity of the VM, optionally the user-assigned one with the configured client ID.
+func newCredential(ctx context.Context, logger log.Logger, conf Config, client *http.Client) (blob.Credential, error) {
+	if conf.StorageAccountKey != "" {
+		return blob.NewSharedKeyCredential(conf.StorageAccountName, conf.StorageAccountKey)
+	}
+
+	var errs tsdberrors.MultiError
+	for _, s := range tokenSources(conf, client) {
+		tok, ttl, err := s.token(ctx)
+		if err != nil {
+			errs.Add(errors.Wrapf(err, "%s", s.name))
+			continue
+		}
+		level.Info(logger).Log("msg", "using Azure AD credentials", "source", s.name)
+
+		s := s
+		first := true
+		return blob.NewTokenCredential(tok, func(c blob.TokenCredential) time.Duration {
+			if first {
+				// Called right away, the initial token is fresh.
+				first = false
+				return refreshIn(ttl)
+			}
+			tok, ttl, err := s.token(context.Background())
+			if err != nil {
+				level.Warn(logger).Log("msg", "failed to refresh Azure AD token", "source", s.name, "err", err)
+				return tokenRetryInterval
+			}
+			c.SetToken(tok)
+			return refreshIn(ttl)
+		}), nil
+	}
+	return nil, errors.Wrap(errs.Err(), "no storage_account_key configured and no Azure AD credentials available")
+}
+
+func refreshIn(ttl time.Duration) time.Duration {
+	if ttl-tokenRefreshMargin < tokenRetryInterval {
+		return tokenRetryInterval
+	}
+	return ttl - tokenRefreshMargin
+}
+
+func tokenSources(conf Config, client *http.Client) []tokenSource {
+	var (
+		tenantID      = os.Getenv("AZURE_TENANT_ID")
+		clientID      = os.Getenv("AZURE_CLIENT_ID")
+		authorityHost = os.Getenv("AZURE_AUTHORITY_HOST")
+		sources       []tokenSource
+	)
+	if authorityHost == "" {
+		authorityHost = defaultAuthorityHost
+	}
+	if secret := os.Getenv("AZURE_CLIENT_SECRET"); secret != "" && tenantID != "" && clientID != "" {
+		sources = append(sources, tokenSource{
+			name: "environment",
+			token: func(ctx context.Context) (string, time.Duration, error) {
+				return clientCredentialsToken(ctx, client, authorityHost, tenantID, url.Values{
+					"client_id":     {clientID},
+					"client_secret": {secret},
+				})
+			},
+		})
+	}
+	if tokenFile := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); tokenFile != "" && tenantID != "" && clientID != "" {
+		sources = append(sources, tokenSource{
+			name: "workload identity",
+			token: func(ctx context.Context) (string, time.Duration, error) {
+				// Federated token is rotated in the file, so it's read on every refresh.
+				assertion, err := ioutil.ReadFile(tokenFile)
+				if err != nil {
+					return "", 0, errors.Wrap(err, "read federated token")
+				}
+				return clientCredentialsToken(ctx, client, authorityHost, tenantID, url.Values{
+					"client_id":             {clientID},
+					"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+					"client_assertion":      {strings.TrimSpace(string(assertion))},
+				})
+			},
+		})
+	}
+	sources = append(sources, tokenSource{
+		name: "managed identity",
+		token: func(ctx context.Context) (string, time.Duration, error) {
+			return msiToken(ctx, client, msiEndpoint, conf.UserAssignedID)
+		},
+	})
+	return sources
+}
+
+type tokenResponse struct {
+	AccessToken string      `json:"access_token"`
+	ExpiresIn   json.Number `json:"expires_in"`
+}
+
+// clientCredentialsToken requests token using OAuth 2.0 client credentials flow.
+func clientCredentialsToken(ctx context.Context, client *http.Client, authorityHost, tenantID string, params url.Values) (string, time.Duration, error) {
+	params.Set("grant_type", "client_credentials")
+	params.Set("scope", storageScope)
+
+	u := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authorityHost, "/"), url.PathEscape(tenantID))
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(params.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doTokenRequest(ctx, client, req)
+}
+
+// msiToken requests token from Azure Instance Metadata Service.
+func msiToken(ctx context.Context, client *http.Client, endpoint, userAssignedID string) (string, time.Duration, error) {
+	params := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {storageResource},
+	}
+	if userAssignedID != "" {
+		params.Set("client_id", userAssignedID)
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Metadata", "true")
+	return doTokenRequest(ctx, client, req)
+}
+
+func doTokenRequest(ctx context.Context, client *http.Client, req *http.Request) (string, time.Duration, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", 0, errors.Wrap(err, "request token")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "read token response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, errors.Errorf("got non-200 response code for token request: %v, response: %v", resp.StatusCode, string(b))
+	}
+
+	var r tokenResponse
+	if err := json.Unmarshal(b, &r); err != nil {
+		return "", 0, errors.Wrap(err, "unmarshal token response")
+	}
+	if r.AccessToken == "" {
+		return "", 0, errors.New("no access token in token response")
+	}
+	expiresIn, err := strconv.ParseInt(r.ExpiresIn.String(), 10, 64)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "parse token expiration %q", r.ExpiresIn)
+	}
+	return r.AccessToken, time.Duration(expiresIn) * time.Second, nil
+}
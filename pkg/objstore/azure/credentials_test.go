@@ -0,0 +1,99 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package azure
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	blob "github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func setEnv(t *testing.T, env map[string]string) func() {
+	for k, v := range env {
+		testutil.Ok(t, os.Setenv(k, v))
+	}
+	return func() {
+		for k := range env {
+			testutil.Ok(t, os.Unsetenv(k))
+		}
+	}
+}
+
+func TestNewCredential(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "azure-credentials")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+	tokenFile := filepath.Join(dir, "token")
+	testutil.Ok(t, ioutil.WriteFile(tokenFile, []byte("federated-token\n"), 0600))
+
+	aad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "/tenant/oauth2/v2.0/token", r.URL.Path)
+		testutil.Ok(t, r.ParseForm())
+		testutil.Equals(t, "client", r.PostForm.Get("client_id"))
+		testutil.Equals(t, storageScope, r.PostForm.Get("scope"))
+		if r.PostForm.Get("client_assertion") != "federated-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token_type":"Bearer","expires_in":3599,"access_token":"aad-token"}`))
+	}))
+	defer aad.Close()
+
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "true", r.Header.Get("Metadata"))
+		testutil.Equals(t, storageResource, r.URL.Query().Get("resource"))
+		if r.URL.Query().Get("client_id") != "user-assigned" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// IMDS returns expiration as a string.
+		_, _ = w.Write([]byte(`{"access_token":"msi-token","expires_in":"86399","token_type":"Bearer"}`))
+	}))
+	defer imds.Close()
+	defer func(e string) { msiEndpoint = e }(msiEndpoint)
+	msiEndpoint = imds.URL
+
+	t.Run("shared key", func(t *testing.T) {
+		cred, err := newCredential(ctx, log.NewNopLogger(), Config{StorageAccountName: "foo", StorageAccountKey: "Zm9vCg=="}, nil)
+		testutil.Ok(t, err)
+		_, ok := cred.(*blob.SharedKeyCredential)
+		testutil.Assert(t, ok, "expected shared key credential, got %T", cred)
+	})
+	t.Run("workload identity", func(t *testing.T) {
+		defer setEnv(t, map[string]string{
+			"AZURE_TENANT_ID":            "tenant",
+			"AZURE_CLIENT_ID":            "client",
+			"AZURE_FEDERATED_TOKEN_FILE": tokenFile,
+			"AZURE_AUTHORITY_HOST":       aad.URL,
+		})()
+		cred, err := newCredential(ctx, log.NewNopLogger(), Config{StorageAccountName: "foo"}, nil)
+		testutil.Ok(t, err)
+		testutil.Equals(t, "aad-token", cred.(blob.TokenCredential).Token())
+	})
+	t.Run("fallback to managed identity", func(t *testing.T) {
+		defer setEnv(t, map[string]string{
+			"AZURE_TENANT_ID":            "tenant",
+			"AZURE_CLIENT_ID":            "client",
+			"AZURE_FEDERATED_TOKEN_FILE": filepath.Join(dir, "not-existing"),
+			"AZURE_AUTHORITY_HOST":       aad.URL,
+		})()
+		cred, err := newCredential(ctx, log.NewNopLogger(), Config{StorageAccountName: "foo", UserAssignedID: "user-assigned"}, nil)
+		testutil.Ok(t, err)
+		testutil.Equals(t, "msi-token", cred.(blob.TokenCredential).Token())
+	})
+	t.Run("no credentials", func(t *testing.T) {
+		_, err := newCredential(ctx, log.NewNopLogger(), Config{StorageAccountName: "foo"}, nil)
+		testutil.NotOk(t, err)
+	})
+}
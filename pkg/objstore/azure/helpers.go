@@ -18,12 +18,7 @@ const DirDelim = "/"
 
 var errorCodeRegex = regexp.MustCompile(`X-Ms-Error-Code:\D*\[(\w+)\]`)
 
-func getContainerURL(ctx context.Context, conf Config) (blob.ContainerURL, error) {
-	c, err := blob.NewSharedKeyCredential(conf.StorageAccountName, conf.StorageAccountKey)
-	if err != nil {
-		return blob.ContainerURL{}, err
-	}
-
+func getContainerURL(ctx context.Context, conf Config, c blob.Credential) (blob.ContainerURL, error) {
 	retryOptions := blob.RetryOptions{
 		MaxTries: int32(conf.MaxRetries),
 	}
@@ -44,8 +39,8 @@ func getContainerURL(ctx context.Context, conf Config) (blob.ContainerURL, error
 	return service.NewContainerURL(conf.ContainerName), nil
 }
 
-func getContainer(ctx context.Context, conf Config) (blob.ContainerURL, error) {
-	c, err := getContainerURL(ctx, conf)
+func getContainer(ctx context.Context, conf Config, cred blob.Credential) (blob.ContainerURL, error) {
+	c, err := getContainerURL(ctx, conf, cred)
 	if err != nil {
 		return blob.ContainerURL{}, err
 	}
@@ -54,8 +49,8 @@ func getContainer(ctx context.Context, conf Config) (blob.ContainerURL, error) {
 	return c, err
 }
 
-func createContainer(ctx context.Context, conf Config) (blob.ContainerURL, error) {
-	c, err := getContainerURL(ctx, conf)
+func createContainer(ctx context.Context, conf Config, cred blob.Credential) (blob.ContainerURL, error) {
+	c, err := getContainerURL(ctx, conf, cred)
 	if err != nil {
 		return blob.ContainerURL{}, err
 	}
@@ -66,8 +61,8 @@ func createContainer(ctx context.Context, conf Config) (blob.ContainerURL, error
 	return c, err
 }
 
-func getBlobURL(ctx context.Context, conf Config, blobName string) (blob.BlockBlobURL, error) {
-	c, err := getContainerURL(ctx, conf)
+func getBlobURL(ctx context.Context, conf Config, cred blob.Credential, blobName string) (blob.BlockBlobURL, error) {
+	c, err := getContainerURL(ctx, conf, cred)
 	if err != nil {
 		return blob.BlockBlobURL{}, err
 	}
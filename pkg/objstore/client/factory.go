@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -15,8 +16,11 @@ import (
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/azure"
 	"github.com/thanos-io/thanos/pkg/objstore/cos"
+	"github.com/thanos-io/thanos/pkg/objstore/encryption"
 	"github.com/thanos-io/thanos/pkg/objstore/filesystem"
 	"github.com/thanos-io/thanos/pkg/objstore/gcs"
+	"github.com/thanos-io/thanos/pkg/objstore/obs"
+	"github.com/thanos-io/thanos/pkg/objstore/oci"
 	"github.com/thanos-io/thanos/pkg/objstore/oss"
 	"github.com/thanos-io/thanos/pkg/objstore/s3"
 	"github.com/thanos-io/thanos/pkg/objstore/swift"
@@ -33,11 +37,25 @@ const (
 	SWIFT      ObjProvider = "SWIFT"
 	COS        ObjProvider = "COS"
 	ALIYUNOSS  ObjProvider = "ALIYUNOSS"
+	OCI        ObjProvider = "OCI"
+	OBS        ObjProvider = "OBS"
+	// REPLICATING is a primary bucket replicated asynchronously to secondary buckets.
+	REPLICATING ObjProvider = "REPLICATING"
+	// ENCRYPTED is a bucket with objects encrypted client-side.
+	ENCRYPTED ObjProvider = "ENCRYPTED"
 )
 
 type BucketConfig struct {
-	Type   ObjProvider `yaml:"type"`
-	Config interface{} `yaml:"config"`
+	Type       ObjProvider               `yaml:"type"`
+	Config     interface{}               `yaml:"config"`
+	Middleware objstore.MiddlewareConfig `yaml:"middleware"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, defaulting middleware also for inner bucket configurations.
+func (c *BucketConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain BucketConfig
+	*c = BucketConfig{Middleware: objstore.DefaultMiddlewareConfig}
+	return unmarshal((*plain)(c))
 }
 
 // NewBucket initializes and returns new object storage clients.
@@ -68,6 +86,20 @@ func NewBucket(logger log.Logger, confContentYaml []byte, reg prometheus.Registe
 		bucket, err = cos.NewBucket(logger, config, component)
 	case string(ALIYUNOSS):
 		bucket, err = oss.NewBucket(logger, config, component)
+	case string(OCI):
+		bucket, err = oci.NewBucket(logger, config, component)
+	case string(OBS):
+		bucket, err = obs.NewBucket(logger, config, component)
+	case string(REPLICATING):
+		if bucketConf.Middleware != objstore.DefaultMiddlewareConfig {
+			return nil, errors.New("middleware of REPLICATING bucket has to be configured in primary and replicas bucket configurations")
+		}
+		return newReplicatingBucket(logger, config, reg, component)
+	case string(ENCRYPTED):
+		if bucketConf.Middleware != objstore.DefaultMiddlewareConfig {
+			return nil, errors.New("middleware of ENCRYPTED bucket has to be configured in the inner bucket configuration")
+		}
+		return newEncryptedBucket(logger, config, reg, component)
 	case string(FILESYSTEM):
 		bucket, err = filesystem.NewBucketFromConfig(config)
 	default:
@@ -76,5 +108,119 @@ func NewBucket(logger log.Logger, confContentYaml []byte, reg prometheus.Registe
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("create %s client", bucketConf.Type))
 	}
-	return objstore.BucketWithMetrics(bucket.Name(), bucket, reg), nil
+	bucket = objstore.BucketWithMiddleware(logger, bucket, bucketConf.Middleware, reg)
+	return objstore.BucketWithTracing(objstore.BucketWithMetrics(bucket.Name(), bucket, reg)), nil
+}
+
+// NewVersionedBucket initializes object storage client of the provider supporting object versioning. Unlike
+// NewBucket, the client is not instrumented and middleware is not applied.
+// NOTE: confContentYaml can contain secrets.
+func NewVersionedBucket(logger log.Logger, confContentYaml []byte, component string) (objstore.VersionedBucket, error) {
+	bucketConf := &BucketConfig{}
+	if err := yaml.UnmarshalStrict(confContentYaml, bucketConf); err != nil {
+		return nil, errors.Wrap(err, "parsing config YAML file")
+	}
+
+	config, err := yaml.Marshal(bucketConf.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal content of bucket configuration")
+	}
+
+	var bucket objstore.VersionedBucket
+	switch strings.ToUpper(string(bucketConf.Type)) {
+	case string(GCS):
+		bucket, err = gcs.NewBucket(context.Background(), logger, config, component)
+	case string(S3):
+		bucket, err = s3.NewBucket(logger, config, component)
+	case string(OBS):
+		bucket, err = obs.NewBucket(logger, config, component)
+	default:
+		return nil, errors.Errorf("object versioning of bucket with type %s is not supported", bucketConf.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("create %s client", bucketConf.Type))
+	}
+	return bucket, nil
+}
+
+// ReplicatingConfig stores the configuration for bucket replicated to secondary buckets.
+type ReplicatingConfig struct {
+	Primary  BucketConfig   `yaml:"primary"`
+	Replicas []BucketConfig `yaml:"replicas"`
+	// QueueDir is a local directory the operations waiting for replication are persisted in.
+	QueueDir      string        `yaml:"queue_dir"`
+	RetryInterval time.Duration `yaml:"retry_interval"`
+}
+
+var DefaultReplicatingConfig = ReplicatingConfig{
+	RetryInterval: 30 * time.Second,
+}
+
+func newReplicatingBucket(logger log.Logger, conf []byte, reg prometheus.Registerer, component string) (objstore.Bucket, error) {
+	config := DefaultReplicatingConfig
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parse replicating bucket config")
+	}
+	if len(config.Replicas) == 0 {
+		return nil, errors.New("no replicas in replicating bucket config")
+	}
+
+	newInner := func(c BucketConfig, reg prometheus.Registerer) (objstore.Bucket, error) {
+		if strings.ToUpper(string(c.Type)) == string(REPLICATING) {
+			return nil, errors.New("nested replicating buckets are not supported")
+		}
+		b, err := yaml.Marshal(c)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal inner bucket configuration")
+		}
+		return NewBucket(logger, b, reg, component)
+	}
+
+	primary, err := newInner(config.Primary, reg)
+	if err != nil {
+		return nil, errors.Wrap(err, "create primary bucket")
+	}
+	replicas := make([]objstore.Bucket, 0, len(config.Replicas))
+	for i, c := range config.Replicas {
+		// Replicas are not instrumented by bucket metrics, as those can't be registered more than once.
+		replica, err := newInner(c, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "create replica bucket %d", i)
+		}
+		replicas = append(replicas, replica)
+	}
+	bkt, err := objstore.NewReplicatingBucket(logger, reg, primary, replicas, config.QueueDir, config.RetryInterval)
+	if err != nil {
+		return nil, errors.Wrap(err, "create REPLICATING client")
+	}
+	return bkt, nil
+}
+
+// EncryptedConfig stores the configuration for bucket with objects encrypted client-side.
+type EncryptedConfig struct {
+	Bucket            BucketConfig `yaml:"bucket"`
+	encryption.Config `yaml:",inline"`
+}
+
+func newEncryptedBucket(logger log.Logger, conf []byte, reg prometheus.Registerer, component string) (objstore.Bucket, error) {
+	config := EncryptedConfig{Config: encryption.DefaultConfig}
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parse encrypted bucket config")
+	}
+	if strings.ToUpper(string(config.Bucket.Type)) == string(ENCRYPTED) {
+		return nil, errors.New("nested encrypted buckets are not supported")
+	}
+	b, err := yaml.Marshal(config.Bucket)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal inner bucket configuration")
+	}
+	inner, err := NewBucket(logger, b, reg, component)
+	if err != nil {
+		return nil, errors.Wrap(err, "create inner bucket")
+	}
+	bkt, err := encryption.NewBucket(logger, inner, config.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "create ENCRYPTED client")
+	}
+	return bkt, nil
 }
@@ -76,6 +76,7 @@ func NewBucket(logger log.Logger, conf []byte, component string) (*Bucket, error
 		Transport: &cos.AuthorizationTransport{
 			SecretID:  config.SecretId,
 			SecretKey: config.SecretKey,
+			Transport: objstore.TracingTransport(http.DefaultTransport),
 		},
 	})
 
@@ -0,0 +1,442 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package encryption implements client-side envelope encryption of objects stored in any object storage bucket.
+//
+// Each object is encrypted with its own random data key using AES-256-GCM. The data key is wrapped by the configured
+// key provider and stored in the object header, followed by the content split into segments encrypted separately,
+// so ranges of the object can be read and decrypted without reading the whole object.
+//
+// Object format:
+//
+//	magic (8 bytes) | header length (4 bytes, big endian) | JSON header | segments
+//
+// Each segment holds segmentSize bytes of content, except the last one which holds less, possibly none. Segments
+// are sealed with nonce made of the header nonce prefix and the segment index.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	"github.com/go-kit/kit/log"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	magic         = "THANOSE1"
+	prefixSize    = len(magic) + 4
+	segmentSize   = 64 * 1024
+	tagSize       = 16
+	nonceSize     = 12
+	noncePrefix   = nonceSize - 4
+	dataKeySize   = 32
+	headerVersion = 1
+
+	// maxHeaderSize limits header size read from objects.
+	maxHeaderSize = 64 * 1024
+	// headerReadSize is the size of the first read of the object, fitting header of any of supported key providers.
+	headerReadSize = 4 * 1024
+)
+
+// header stores the wrapped data key of the object.
+type header struct {
+	Version    int    `json:"version"`
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Nonce      []byte `json:"nonce"`
+}
+
+// Bucket encrypts objects uploaded to the underlying bucket and decrypts objects read from it. Objects not
+// encrypted by Bucket can't be read through it.
+type Bucket struct {
+	objstore.Bucket
+
+	logger log.Logger
+	keys   KeyWrapper
+	// dataKeys caches unwrapped data keys by wrapped ones, so reading objects doesn't call key provider every time.
+	dataKeys *lru.Cache
+}
+
+// NewBucket returns Bucket encrypting objects in the given bucket with data keys wrapped by the key provider
+// from the config.
+func NewBucket(logger log.Logger, bkt objstore.Bucket, conf Config) (*Bucket, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	keys, err := newKeyWrapper(conf)
+	if err != nil {
+		return nil, err
+	}
+	return NewBucketWithKeyWrapper(logger, bkt, keys, conf.KeyCacheSize)
+}
+
+// NewBucketWithKeyWrapper returns Bucket encrypting objects in the given bucket with data keys wrapped by the
+// given key wrapper, caching up to keyCacheSize unwrapped keys.
+func NewBucketWithKeyWrapper(logger log.Logger, bkt objstore.Bucket, keys KeyWrapper, keyCacheSize int) (*Bucket, error) {
+	if keyCacheSize <= 0 {
+		keyCacheSize = DefaultConfig.KeyCacheSize
+	}
+	cache, err := lru.New(keyCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Bucket{Bucket: bkt, logger: logger, keys: keys, dataKeys: cache}, nil
+}
+
+// Upload encrypts the content with a new data key and uploads it.
+func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	dataKey := make([]byte, dataKeySize)
+	nonce := make([]byte, noncePrefix)
+	if _, err := rand.Read(dataKey); err != nil {
+		return errors.Wrap(err, "generate data key")
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return errors.Wrap(err, "generate nonce")
+	}
+	keyID, wrapped, err := b.keys.Wrap(ctx, dataKey)
+	if err != nil {
+		return errors.Wrap(err, "wrap data key")
+	}
+	hdr, err := json.Marshal(header{Version: headerVersion, KeyID: keyID, WrappedKey: wrapped, Nonce: nonce})
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	prefix := make([]byte, prefixSize, prefixSize+len(hdr))
+	copy(prefix, magic)
+	binary.BigEndian.PutUint32(prefix[len(magic):], uint32(len(hdr)))
+
+	er := &encryptingReader{r: r, aead: aead, nonce: nonce, buf: append(prefix, hdr...)}
+	if size, err := objstore.TryToGetSize(r); err == nil {
+		// Let the provider know the upload size, so it doesn't have to buffer the content.
+		return b.Bucket.Upload(ctx, name, &sizedEncryptingReader{
+			encryptingReader: er,
+			size:             int64(len(er.buf)) + encryptedSize(size),
+		})
+	}
+	return b.Bucket.Upload(ctx, name, er)
+}
+
+// encryptedSize returns number of bytes of segments of content of the given size.
+func encryptedSize(size int64) int64 {
+	return size + (size/segmentSize+1)*tagSize
+}
+
+// Get returns a reader of decrypted content of the object.
+func (b *Bucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	rc, err := b.Bucket.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	aead, nonce, err := b.readHeader(ctx, name, rc)
+	if err != nil {
+		runutil.CloseWithLogOnErr(b.logger, rc, "encrypted object reader")
+		return nil, err
+	}
+	return &decryptingReader{rc: rc, aead: aead, nonce: nonce, verifyEnd: true}, nil
+}
+
+// GetRange returns a reader of decrypted content of the object in the given range. Only segments of the object
+// holding the range are read.
+func (b *Bucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if off < 0 {
+		return nil, errors.Errorf("invalid offset %d", off)
+	}
+	if length == 0 || length < -1 {
+		return nil, errors.Errorf("invalid length %d", length)
+	}
+
+	rc, err := b.Bucket.GetRange(ctx, name, 0, headerReadSize)
+	if err != nil {
+		return nil, err
+	}
+	defer runutil.CloseWithLogOnErr(b.logger, rc, "encrypted object header reader")
+	hdr, err := readHeaderBytes(rc)
+	if err != nil {
+		if _, ok := errors.Cause(err).(headerTooLongErr); !ok {
+			return nil, errors.Wrapf(err, "read header of %s", name)
+		}
+		// Header doesn't fit into the first read.
+		hrc, err := b.Bucket.GetRange(ctx, name, 0, int64(prefixSize)+int64(errors.Cause(err).(headerTooLongErr)))
+		if err != nil {
+			return nil, err
+		}
+		defer runutil.CloseWithLogOnErr(b.logger, hrc, "encrypted object header reader")
+		if hdr, err = readHeaderBytes(hrc); err != nil {
+			return nil, errors.Wrapf(err, "read header of %s", name)
+		}
+	}
+	aead, nonce, err := b.parseHeader(ctx, hdr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse header of %s", name)
+	}
+
+	first := off / segmentSize
+	start := int64(prefixSize+len(hdr)) + first*(segmentSize+tagSize)
+	clength := int64(-1)
+	if length > 0 {
+		clength = ((off+length-1)/segmentSize - first + 1) * (segmentSize + tagSize)
+	}
+	crc, err := b.Bucket.GetRange(ctx, name, start, clength)
+	if err != nil {
+		return nil, err
+	}
+
+	dr := &decryptingReader{rc: crc, aead: aead, nonce: nonce, segment: uint32(first)}
+	if _, err := io.CopyN(ioutil.Discard, dr, off-first*segmentSize); err != nil && err != io.EOF {
+		runutil.CloseWithLogOnErr(b.logger, dr, "encrypted object reader")
+		return nil, err
+	}
+	if length < 0 {
+		return dr, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{Reader: io.LimitReader(dr, length), Closer: dr}, nil
+}
+
+// ObjectSize returns the size of the decrypted content of the object.
+func (b *Bucket) ObjectSize(ctx context.Context, name string) (uint64, error) {
+	size, err := b.Bucket.ObjectSize(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	rc, err := b.Bucket.GetRange(ctx, name, 0, int64(prefixSize))
+	if err != nil {
+		return 0, err
+	}
+	defer runutil.CloseWithLogOnErr(b.logger, rc, "encrypted object header reader")
+	hlen, err := readHeaderLength(rc)
+	if err != nil {
+		return 0, errors.Wrapf(err, "read header of %s", name)
+	}
+
+	body := int64(size) - int64(prefixSize) - int64(hlen)
+	full, last := body/(segmentSize+tagSize), body%(segmentSize+tagSize)
+	if last < tagSize {
+		return 0, errors.Errorf("object %s is truncated", name)
+	}
+	return uint64(full*segmentSize + last - tagSize), nil
+}
+
+func (b *Bucket) readHeader(ctx context.Context, name string, r io.Reader) (cipher.AEAD, []byte, error) {
+	hdr, err := readHeaderBytes(r)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "read header of %s", name)
+	}
+	aead, nonce, err := b.parseHeader(ctx, hdr)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "parse header of %s", name)
+	}
+	return aead, nonce, nil
+}
+
+func (b *Bucket) parseHeader(ctx context.Context, hdr []byte) (cipher.AEAD, []byte, error) {
+	var h header
+	if err := json.Unmarshal(hdr, &h); err != nil {
+		return nil, nil, err
+	}
+	if h.Version != headerVersion {
+		return nil, nil, errors.Errorf("unsupported encryption header version %d", h.Version)
+	}
+	if len(h.Nonce) != noncePrefix {
+		return nil, nil, errors.Errorf("invalid nonce size %d", len(h.Nonce))
+	}
+
+	cacheKey := h.KeyID + "/" + string(h.WrappedKey)
+	if dataKey, ok := b.dataKeys.Get(cacheKey); ok {
+		aead, err := newAEAD(dataKey.([]byte))
+		return aead, h.Nonce, err
+	}
+	dataKey, err := b.keys.Unwrap(ctx, h.KeyID, h.WrappedKey)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "unwrap data key with key %s", h.KeyID)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.dataKeys.Add(cacheKey, dataKey)
+	return aead, h.Nonce, err
+}
+
+// headerTooLongErr is returned when the reader ends before the header of the given length.
+type headerTooLongErr uint32
+
+func (e headerTooLongErr) Error() string {
+	return "header too long"
+}
+
+func readHeaderLength(r io.Reader) (uint32, error) {
+	prefix := make([]byte, prefixSize)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return 0, errors.Wrap(err, "read prefix")
+	}
+	if !bytes.Equal(prefix[:len(magic)], []byte(magic)) {
+		return 0, errors.New("object is not encrypted")
+	}
+	hlen := binary.BigEndian.Uint32(prefix[len(magic):])
+	if hlen > maxHeaderSize {
+		return 0, errors.Errorf("header size %d exceeds the limit", hlen)
+	}
+	return hlen, nil
+}
+
+func readHeaderBytes(r io.Reader) ([]byte, error) {
+	hlen, err := readHeaderLength(r)
+	if err != nil {
+		return nil, err
+	}
+	hdr := make([]byte, hlen)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			return nil, headerTooLongErr(hlen)
+		}
+		return nil, err
+	}
+	return hdr, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
+
+func segmentNonce(prefix []byte, segment uint32) []byte {
+	nonce := make([]byte, nonceSize)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefix:], segment)
+	return nonce
+}
+
+// encryptingReader reads content from the underlying reader and returns it encrypted segment by segment, after
+// the initial content of buf.
+type encryptingReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	nonce   []byte
+	segment uint32
+
+	buf  []byte
+	done bool
+	err  error
+}
+
+func (r *encryptingReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.next()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *encryptingReader) next() error {
+	plain := make([]byte, segmentSize)
+	n, err := io.ReadFull(r.r, plain)
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		// Segment shorter than segmentSize, possibly empty, is the last one.
+		r.done = true
+	default:
+		return err
+	}
+	r.buf = r.aead.Seal(plain[:0], segmentNonce(r.nonce, r.segment), plain[:n], nil)
+	r.segment++
+	return nil
+}
+
+// sizedEncryptingReader is encryptingReader with known size of encrypted content.
+type sizedEncryptingReader struct {
+	*encryptingReader
+
+	size int64
+}
+
+// Size returns the number of bytes returned by the reader.
+func (r *sizedEncryptingReader) Size() int64 {
+	return r.size
+}
+
+// decryptingReader decrypts segments of encrypted content read from the underlying reader.
+type decryptingReader struct {
+	rc      io.ReadCloser
+	aead    cipher.AEAD
+	nonce   []byte
+	segment uint32
+	// verifyEnd is true if the underlying reader reads till the end of the object, so the end of the content has
+	// to be the last segment.
+	verifyEnd bool
+
+	buf  []byte
+	done bool
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *decryptingReader) next() error {
+	sealed := make([]byte, segmentSize+tagSize)
+	n, err := io.ReadFull(r.rc, sealed)
+	switch err {
+	case nil:
+	case io.ErrUnexpectedEOF:
+		r.done = true
+	case io.EOF:
+		if r.verifyEnd {
+			return errors.New("encrypted object is truncated")
+		}
+		r.done = true
+		return nil
+	default:
+		return err
+	}
+	plain, err := r.aead.Open(sealed[:0], segmentNonce(r.nonce, r.segment), sealed[:n], nil)
+	if err != nil {
+		return errors.Wrapf(err, "decrypt segment %d", r.segment)
+	}
+	r.buf = plain
+	r.segment++
+	return nil
+}
+
+func (r *decryptingReader) Close() error {
+	return r.rc.Close()
+}
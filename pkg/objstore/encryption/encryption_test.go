@@ -0,0 +1,145 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, dataKeySize))
+}
+
+func readAll(t *testing.T, bkt objstore.Bucket, name string, off, length int64) []byte {
+	var (
+		rc  = ioutil.NopCloser(bytes.NewReader(nil))
+		err error
+	)
+	if off == 0 && length == -1 {
+		rc, err = bkt.Get(context.Background(), name)
+	} else {
+		rc, err = bkt.GetRange(context.Background(), name, off, length)
+	}
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, rc.Close()) }()
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	return b
+}
+
+func TestBucket(t *testing.T) {
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+	conf := Config{
+		KeyProvider: StaticKeys,
+		Static:      StaticConfig{PrimaryKeyID: "1", Keys: []StaticKey{{ID: "1", Key: testKey(1)}}},
+	}
+	bkt, err := NewBucket(log.NewNopLogger(), inner, conf)
+	testutil.Ok(t, err)
+
+	r := rand.New(rand.NewSource(0))
+	for _, size := range []int{0, 1, segmentSize - 1, segmentSize, segmentSize + 1, 3*segmentSize + 123} {
+		content := make([]byte, size)
+		_, _ = r.Read(content)
+
+		testutil.Ok(t, bkt.Upload(ctx, "obj", bytes.NewReader(content)))
+
+		// Content is not stored in plain text.
+		raw := readAll(t, inner, "obj", 0, -1)
+		if size > 0 {
+			testutil.Assert(t, !bytes.Contains(raw, content), "content stored unencrypted")
+		}
+
+		testutil.Equals(t, content, readAll(t, bkt, "obj", 0, -1))
+		s, err := bkt.ObjectSize(ctx, "obj")
+		testutil.Ok(t, err)
+		testutil.Equals(t, uint64(size), s)
+
+		for _, rng := range [][2]int64{
+			{0, 1}, {1, 10}, {segmentSize - 5, 10}, {segmentSize, segmentSize}, {int64(size) - 1, 1}, {int64(size) / 2, -1}, {10, 100 * segmentSize},
+		} {
+			off, length := rng[0], rng[1]
+			if off < 0 || off >= int64(size) {
+				continue
+			}
+			end := int64(size)
+			if length > 0 && off+length < end {
+				end = off + length
+			}
+			testutil.Equals(t, content[off:end], readAll(t, bkt, "obj", off, length))
+		}
+	}
+
+	// Object truncated at segment boundary is detected.
+	raw := readAll(t, inner, "obj", 0, -1)
+	testutil.Ok(t, inner.Upload(ctx, "obj", bytes.NewReader(raw[:len(raw)-(123+tagSize)])))
+	rc, err := bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	_, err = ioutil.ReadAll(rc)
+	testutil.NotOk(t, err)
+
+	// Modified object is detected.
+	raw[len(raw)-1] ^= 1
+	testutil.Ok(t, inner.Upload(ctx, "obj", bytes.NewReader(raw)))
+	rc, err = bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	_, err = ioutil.ReadAll(rc)
+	testutil.NotOk(t, err)
+}
+
+func TestBucket_KeyRotation(t *testing.T) {
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+
+	old, err := NewBucket(log.NewNopLogger(), inner, Config{
+		KeyProvider: StaticKeys,
+		Static:      StaticConfig{PrimaryKeyID: "1", Keys: []StaticKey{{ID: "1", Key: testKey(1)}}},
+	})
+	testutil.Ok(t, err)
+	testutil.Ok(t, old.Upload(ctx, "old", bytes.NewBufferString("old content")))
+
+	rotated, err := NewBucket(log.NewNopLogger(), inner, Config{
+		KeyProvider: StaticKeys,
+		Static:      StaticConfig{PrimaryKeyID: "2", Keys: []StaticKey{{ID: "1", Key: testKey(1)}, {ID: "2", Key: testKey(2)}}},
+	})
+	testutil.Ok(t, err)
+	testutil.Ok(t, rotated.Upload(ctx, "new", bytes.NewBufferString("new content")))
+
+	testutil.Equals(t, "old content", string(readAll(t, rotated, "old", 0, -1)))
+	testutil.Equals(t, "new content", string(readAll(t, rotated, "new", 0, -1)))
+
+	// Objects encrypted with the new key can't be read without it.
+	_, err = old.Get(ctx, "new")
+	testutil.NotOk(t, err)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, tcase := range []struct {
+		conf Config
+		ok   bool
+	}{
+		{conf: Config{KeyProvider: StaticKeys, Static: StaticConfig{PrimaryKeyID: "a", Keys: []StaticKey{{ID: "a", Key: testKey(1)}}}}, ok: true},
+		{conf: Config{KeyProvider: StaticKeys, Static: StaticConfig{PrimaryKeyID: "b", Keys: []StaticKey{{ID: "a", Key: testKey(1)}}}}},
+		{conf: Config{KeyProvider: StaticKeys}},
+		{conf: Config{KeyProvider: GCPKMS, GCPKMS: GCPKMSConfig{KeyName: "projects/p/locations/l/keyRings/r/cryptoKeys/k"}}, ok: true},
+		{conf: Config{KeyProvider: GCPKMS}},
+		{conf: Config{KeyProvider: "vault"}},
+	} {
+		err := tcase.conf.validate()
+		testutil.Equals(t, tcase.ok, err == nil)
+	}
+
+	_, err := NewStaticKeyWrapper(StaticConfig{PrimaryKeyID: "a", Keys: []StaticKey{{ID: "a", Key: base64.StdEncoding.EncodeToString([]byte("short"))}}})
+	testutil.NotOk(t, err)
+}
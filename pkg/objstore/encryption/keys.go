@@ -0,0 +1,195 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+const (
+	// StaticKeys wraps data keys with keys from the configuration.
+	StaticKeys = "static"
+	// GCPKMS wraps data keys with Google Cloud KMS key.
+	GCPKMS = "gcp-kms"
+)
+
+// Config stores the configuration of client-side encryption.
+type Config struct {
+	// KeyProvider is either static or gcp-kms.
+	KeyProvider string       `yaml:"key_provider"`
+	Static      StaticConfig `yaml:"static"`
+	GCPKMS      GCPKMSConfig `yaml:"gcp_kms"`
+	// KeyCacheSize is the maximum number of unwrapped data keys kept in memory.
+	KeyCacheSize int `yaml:"key_cache_size"`
+}
+
+// StaticConfig configures keyset used to wrap data keys. New objects use the primary key, while all keys
+// can unwrap data keys of existing objects, so keys can be rotated by adding a new primary key and keeping the
+// old ones until no objects use them.
+type StaticConfig struct {
+	PrimaryKeyID string      `yaml:"primary_key_id"`
+	Keys         []StaticKey `yaml:"keys"`
+}
+
+// StaticKey is a named 32 bytes AES key encoded in base64.
+type StaticKey struct {
+	ID  string `yaml:"id"`
+	Key string `yaml:"key"`
+}
+
+// GCPKMSConfig configures Google Cloud KMS key used to wrap data keys. Key versions are rotated by Cloud KMS.
+type GCPKMSConfig struct {
+	// KeyName is in projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key> form.
+	KeyName string `yaml:"key_name"`
+	// ServiceAccount is the JSON content of service account credentials. If empty, default credentials are used.
+	ServiceAccount string `yaml:"service_account"`
+}
+
+var DefaultConfig = Config{
+	KeyProvider:  StaticKeys,
+	KeyCacheSize: 10000,
+}
+
+func (conf Config) validate() error {
+	switch conf.KeyProvider {
+	case StaticKeys:
+		if len(conf.Static.Keys) == 0 {
+			return errors.New("no static encryption keys configured")
+		}
+		found := false
+		for _, k := range conf.Static.Keys {
+			if k.ID == "" {
+				return errors.New("static encryption key with empty id")
+			}
+			found = found || k.ID == conf.Static.PrimaryKeyID
+		}
+		if !found {
+			return errors.Errorf("primary encryption key %q not found in static keys", conf.Static.PrimaryKeyID)
+		}
+	case GCPKMS:
+		if conf.GCPKMS.KeyName == "" {
+			return errors.New("no Google Cloud KMS key_name configured")
+		}
+	default:
+		return errors.Errorf("unsupported encryption key provider %q", conf.KeyProvider)
+	}
+	return nil
+}
+
+// KeyWrapper wraps and unwraps data keys of objects.
+type KeyWrapper interface {
+	// Wrap returns the data key encrypted with the current key and ID of the key.
+	Wrap(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// Unwrap returns the data key decrypted with the key with the given ID.
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+func newKeyWrapper(conf Config) (KeyWrapper, error) {
+	switch conf.KeyProvider {
+	case StaticKeys:
+		return NewStaticKeyWrapper(conf.Static)
+	case GCPKMS:
+		return newGCPKMSKeyWrapper(context.Background(), conf.GCPKMS)
+	}
+	return nil, errors.Errorf("unsupported encryption key provider %q", conf.KeyProvider)
+}
+
+type staticKeyWrapper struct {
+	primary string
+	keys    map[string][]byte
+}
+
+// NewStaticKeyWrapper returns KeyWrapper wrapping data keys with AES-256-GCM using the configured keys.
+func NewStaticKeyWrapper(conf StaticConfig) (KeyWrapper, error) {
+	w := &staticKeyWrapper{primary: conf.PrimaryKeyID, keys: map[string][]byte{}}
+	for _, k := range conf.Keys {
+		key, err := base64.StdEncoding.DecodeString(k.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decode encryption key %s", k.ID)
+		}
+		if len(key) != dataKeySize {
+			return nil, errors.Errorf("encryption key %s has %d bytes, 32 bytes are required", k.ID, len(key))
+		}
+		if _, ok := w.keys[k.ID]; ok {
+			return nil, errors.Errorf("duplicated encryption key %s", k.ID)
+		}
+		w.keys[k.ID] = key
+	}
+	if _, ok := w.keys[w.primary]; !ok {
+		return nil, errors.Errorf("primary encryption key %q not found", w.primary)
+	}
+	return w, nil
+}
+
+func (w *staticKeyWrapper) Wrap(_ context.Context, dataKey []byte) (string, []byte, error) {
+	aead, err := newAEAD(w.keys[w.primary])
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, nonceSize, nonceSize+len(dataKey)+tagSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return w.primary, aead.Seal(nonce, nonce, dataKey, []byte(w.primary)), nil
+}
+
+func (w *staticKeyWrapper) Unwrap(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := w.keys[keyID]
+	if !ok {
+		return nil, errors.Errorf("encryption key %s not found", keyID)
+	}
+	if len(wrapped) < nonceSize {
+		return nil, errors.New("wrapped key too short")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], []byte(keyID))
+}
+
+type gcpKMSKeyWrapper struct {
+	client  *kms.KeyManagementClient
+	keyName string
+}
+
+func newGCPKMSKeyWrapper(ctx context.Context, conf GCPKMSConfig) (KeyWrapper, error) {
+	var opts []option.ClientOption
+	if conf.ServiceAccount != "" {
+		credentials, err := google.CredentialsFromJSON(ctx, []byte(conf.ServiceAccount), kms.DefaultAuthScopes()...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create credentials from JSON")
+		}
+		opts = append(opts, option.WithCredentials(credentials))
+	}
+	client, err := kms.NewKeyManagementClient(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "create Google Cloud KMS client")
+	}
+	return &gcpKMSKeyWrapper{client: client, keyName: conf.KeyName}, nil
+}
+
+func (w *gcpKMSKeyWrapper) Wrap(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	resp, err := w.client.Encrypt(ctx, &kmspb.EncryptRequest{Name: w.keyName, Plaintext: dataKey})
+	if err != nil {
+		return "", nil, err
+	}
+	return w.keyName, resp.Ciphertext, nil
+}
+
+func (w *gcpKMSKeyWrapper) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	resp, err := w.client.Decrypt(ctx, &kmspb.DecryptRequest{Name: keyID, Ciphertext: wrapped})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package filesystem
+
+import (
+	"io"
+	"os"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// directIOAlignment is the alignment of buffers, offsets and sizes of reads with O_DIRECT. Logical block size of
+	// most devices is 512 bytes, 4KiB covers also advanced format drives.
+	directIOAlignment = 4096
+	directIOBufSize   = 256 * directIOAlignment
+)
+
+// directReader reads the file opened with O_DIRECT using aligned reads into aligned buffer. The file is read past
+// the end of the requested range up to the alignment.
+type directReader struct {
+	f *os.File
+
+	buf       []byte
+	data      []byte
+	pos       int64
+	remaining int64
+	eof       bool
+}
+
+func newDirectReader(file string, off, length int64) (*directReader, error) {
+	f, err := openDirect(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open %s with O_DIRECT", file)
+	}
+	r := &directReader{
+		f:         f,
+		buf:       alignedBuffer(directIOBufSize),
+		pos:       off - off%directIOAlignment,
+		remaining: length,
+	}
+	// Skip the start of the first aligned block.
+	if skip := off - r.pos; skip > 0 {
+		if err := r.fill(); err != nil && err != io.EOF {
+			_ = f.Close()
+			return nil, err
+		}
+		if int64(len(r.data)) < skip {
+			r.data = r.data[:0]
+		} else {
+			r.data = r.data[skip:]
+		}
+	}
+	return r, nil
+}
+
+func (r *directReader) fill() error {
+	if r.eof {
+		return io.EOF
+	}
+	n, err := r.f.ReadAt(r.buf, r.pos)
+	r.pos += int64(n)
+	r.data = r.buf[:n]
+	if err == io.EOF || n < len(r.buf) {
+		r.eof = true
+		return nil
+	}
+	return err
+}
+
+func (r *directReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	if len(r.data) == 0 {
+		if err := r.fill(); err != nil {
+			return 0, err
+		}
+		if len(r.data) == 0 {
+			return 0, io.EOF
+		}
+	}
+	if r.remaining > 0 && int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	if r.remaining > 0 {
+		r.remaining -= int64(n)
+	}
+	return n, nil
+}
+
+func (r *directReader) Close() error {
+	return r.f.Close()
+}
+
+// alignedBuffer returns buffer of the given size starting at address aligned to directIOAlignment.
+func alignedBuffer(size int) []byte {
+	b := make([]byte, size+directIOAlignment)
+	off := int(uintptr(unsafe.Pointer(&b[0])) & (directIOAlignment - 1))
+	if off != 0 {
+		off = directIOAlignment - off
+	}
+	return b[off : off+size]
+}
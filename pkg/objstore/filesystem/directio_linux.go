@@ -0,0 +1,15 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+)
+
+const directIOSupported = true
+
+func openDirect(file string) (*os.File, error) {
+	return os.OpenFile(file, os.O_RDONLY|syscall.O_DIRECT, 0)
+}
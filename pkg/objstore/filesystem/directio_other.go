@@ -0,0 +1,18 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// +build !linux
+
+package filesystem
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const directIOSupported = false
+
+func openDirect(string) (*os.File, error) {
+	return nil, errors.New("O_DIRECT is not supported on this platform")
+}
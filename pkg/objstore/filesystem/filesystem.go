@@ -8,8 +8,10 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"gopkg.in/yaml.v2"
@@ -19,9 +21,18 @@ import (
 	"github.com/pkg/errors"
 )
 
+// tmpInfix is part of names of temporary files of atomic uploads, which are hidden from listing.
+const tmpInfix = ".thanos-tmp-"
+
 // Config stores the configuration for storing and accessing blobs in filesystem.
 type Config struct {
 	Directory string `yaml:"directory"`
+	// Fsync makes uploads and deletes synced to the disk, including the parent directory, before they return.
+	Fsync bool `yaml:"fsync"`
+	// AtomicUploads makes uploads written to a temporary file renamed once complete, so partial objects are never visible.
+	AtomicUploads bool `yaml:"atomic_uploads"`
+	// DirectIO makes reads bypass the page cache using O_DIRECT. Supported on Linux only.
+	DirectIO bool `yaml:"direct_io"`
 }
 
 // Bucket implements the objstore.Bucket interfaces against filesystem that binary runs on.
@@ -29,6 +40,10 @@ type Config struct {
 // NOTE: It does not follow symbolic links.
 type Bucket struct {
 	rootDir string
+
+	fsync         bool
+	atomicUploads bool
+	directIO      bool
 }
 
 // NewBucketFromConfig returns a new filesystem.Bucket from config.
@@ -37,10 +52,25 @@ func NewBucketFromConfig(conf []byte) (*Bucket, error) {
 	if err := yaml.Unmarshal(conf, &c); err != nil {
 		return nil, err
 	}
+	return NewBucketWithConfig(c)
+}
+
+// NewBucketWithConfig returns a new filesystem.Bucket with the given durability and IO options.
+func NewBucketWithConfig(c Config) (*Bucket, error) {
 	if c.Directory == "" {
 		return nil, errors.New("missing directory for filesystem bucket")
 	}
-	return NewBucket(c.Directory)
+	if c.DirectIO && !directIOSupported {
+		return nil, errors.New("direct_io is not supported on this platform")
+	}
+	b, err := NewBucket(c.Directory)
+	if err != nil {
+		return nil, err
+	}
+	b.fsync = c.Fsync
+	b.atomicUploads = c.AtomicUploads
+	b.directIO = c.DirectIO
+	return b, nil
 }
 
 // NewBucket returns a new filesystem.Bucket.
@@ -72,6 +102,10 @@ func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) err
 		return err
 	}
 	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") && strings.Contains(file.Name(), tmpInfix) {
+			// Skip in-progress atomic uploads.
+			continue
+		}
 		name := filepath.Join(dir, file.Name())
 
 		if file.IsDir() {
@@ -128,6 +162,10 @@ func (b *Bucket) GetRange(_ context.Context, name string, off, length int64) (io
 		return nil, errors.Wrapf(err, "stat %s", file)
 	}
 
+	if b.directIO {
+		return newDirectReader(file, off, length)
+	}
+
 	f, err := os.OpenFile(file, os.O_RDONLY, 0666)
 	if err != nil {
 		return nil, err
@@ -160,12 +198,36 @@ func (b *Bucket) Exists(_ context.Context, name string) (bool, error) {
 }
 
 // Upload writes the file specified in src to into the memory.
-func (b *Bucket) Upload(_ context.Context, name string, r io.Reader) (err error) {
+func (b *Bucket) Upload(_ context.Context, name string, r io.Reader) error {
 	file := filepath.Join(b.rootDir, name)
-	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	dst := file
+	if b.atomicUploads {
+		dst = filepath.Join(dir, fmt.Sprintf(".%s%s%d", filepath.Base(file), tmpInfix, rand.Int63()))
+	}
+	if err := b.write(dst, r); err != nil {
+		if dst != file {
+			_ = os.Remove(dst)
+		}
 		return err
 	}
+	if dst != file {
+		if err := os.Rename(dst, file); err != nil {
+			_ = os.Remove(dst)
+			return errors.Wrapf(err, "rename %s", dst)
+		}
+	}
+	if b.fsync {
+		return syncDir(dir)
+	}
+	return nil
+}
 
+func (b *Bucket) write(file string, r io.Reader) (err error) {
 	f, err := os.Create(file)
 	if err != nil {
 		return err
@@ -175,9 +237,25 @@ func (b *Bucket) Upload(_ context.Context, name string, r io.Reader) (err error)
 	if _, err := io.Copy(f, r); err != nil {
 		return errors.Wrapf(err, "copy to %s", file)
 	}
+	if b.fsync {
+		if err := f.Sync(); err != nil {
+			return errors.Wrapf(err, "sync %s", file)
+		}
+	}
 	return nil
 }
 
+// syncDir syncs the directory, so changes of its entries are persisted.
+func syncDir(dir string) (err error) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithErrCapture(&err, d, "close dir")
+
+	return errors.Wrapf(d.Sync(), "sync %s", dir)
+}
+
 func isDirEmpty(name string) (ok bool, err error) {
 	f, err := os.Open(name)
 	if err != nil {
@@ -207,6 +285,9 @@ func (b *Bucket) Delete(_ context.Context, name string) error {
 			break
 		}
 	}
+	if b.fsync {
+		return syncDir(file)
+	}
 	return nil
 }
 
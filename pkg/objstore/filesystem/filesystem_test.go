@@ -0,0 +1,85 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestBucket_AtomicUploadsAndFsync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filesystem-bucket")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	ctx := context.Background()
+	b, err := NewBucketWithConfig(Config{Directory: dir, Fsync: true, AtomicUploads: true})
+	testutil.Ok(t, err)
+
+	testutil.Ok(t, b.Upload(ctx, "a/obj", bytes.NewReader([]byte("content"))))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "a", "obj"))
+	testutil.Ok(t, err)
+	testutil.Equals(t, "content", string(content))
+
+	// Leftovers of interrupted uploads are not listed.
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "a", ".obj2"+tmpInfix+"1"), []byte("partial"), 0666))
+	var names []string
+	testutil.Ok(t, b.Iter(ctx, "a/", func(name string) error {
+		names = append(names, name)
+		return nil
+	}))
+	testutil.Equals(t, []string{"a/obj"}, names)
+
+	testutil.Ok(t, b.Delete(ctx, "a/obj"))
+	ok, err := b.Exists(ctx, "a/obj")
+	testutil.Ok(t, err)
+	testutil.Assert(t, !ok, "object should be deleted")
+}
+
+func TestBucket_DirectIO(t *testing.T) {
+	if !directIOSupported {
+		t.Skip("O_DIRECT is not supported on this platform")
+	}
+	// Temporary directory might be on tmpfs, which does not support O_DIRECT.
+	dir, err := ioutil.TempDir(".", "filesystem-bucket")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	ctx := context.Background()
+	b, err := NewBucketWithConfig(Config{Directory: dir, DirectIO: true})
+	testutil.Ok(t, err)
+
+	content := make([]byte, 3*directIOBufSize+123)
+	_, _ = rand.New(rand.NewSource(0)).Read(content)
+	testutil.Ok(t, b.Upload(ctx, "obj", bytes.NewReader(content)))
+
+	if f, err := openDirect(filepath.Join(dir, "obj")); err != nil {
+		t.Skip("O_DIRECT is not supported by the filesystem:", err)
+	} else {
+		testutil.Ok(t, f.Close())
+	}
+
+	for _, rng := range [][2]int64{
+		{0, -1}, {1, 10}, {directIOAlignment - 1, 2}, {directIOBufSize - 5, directIOBufSize + 10}, {int64(len(content)) - 3, -1}, {100, int64(len(content))},
+	} {
+		off, length := rng[0], rng[1]
+		end := int64(len(content))
+		if length >= 0 && off+length < end {
+			end = off + length
+		}
+		rc, err := b.GetRange(ctx, "obj", off, length)
+		testutil.Ok(t, err)
+		got, err := ioutil.ReadAll(rc)
+		testutil.Ok(t, err)
+		testutil.Ok(t, rc.Close())
+		testutil.Equals(t, content[off:end], got)
+	}
+}
@@ -30,6 +30,10 @@ const DirDelim = "/"
 type Config struct {
 	Bucket         string `yaml:"bucket"`
 	ServiceAccount string `yaml:"service_account"`
+	// KMSKeyName is the Cloud KMS key uploaded objects are encrypted with (CMEK), in the
+	// projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key> form. If empty, the default
+	// encryption of the bucket is used.
+	KMSKeyName string `yaml:"kms_key_name"`
 }
 
 // Bucket implements the store.Bucket and shipper.Bucket interfaces against GCS.
@@ -37,6 +41,8 @@ type Bucket struct {
 	logger log.Logger
 	bkt    *storage.BucketHandle
 	name   string
+	// kmsKeyName is the Cloud KMS key name for uploaded objects.
+	kmsKeyName string
 
 	closer io.Closer
 }
@@ -65,16 +71,20 @@ func NewBucket(ctx context.Context, logger log.Logger, conf []byte, component st
 	opts = append(opts,
 		option.WithUserAgent(fmt.Sprintf("thanos-%s/%s (%s)", component, version.Version, runtime.Version())),
 	)
+	return newBucket(ctx, logger, gc, opts)
+}
 
+func newBucket(ctx context.Context, logger log.Logger, gc Config, opts []option.ClientOption) (*Bucket, error) {
 	gcsClient, err := storage.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 	bkt := &Bucket{
-		logger: logger,
-		bkt:    gcsClient.Bucket(gc.Bucket),
-		closer: gcsClient,
-		name:   gc.Bucket,
+		logger:     logger,
+		bkt:        gcsClient.Bucket(gc.Bucket),
+		closer:     gcsClient,
+		name:       gc.Bucket,
+		kmsKeyName: gc.KMSKeyName,
 	}
 	return bkt, nil
 }
@@ -153,6 +163,7 @@ func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
 // Upload writes the file specified in src to remote GCS location specified as target.
 func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
 	w := b.bkt.Object(name).NewWriter(ctx)
+	w.KMSKeyName = b.kmsKeyName
 
 	if _, err := io.Copy(w, r); err != nil {
 		return err
@@ -0,0 +1,41 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package gcs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/api/option"
+)
+
+func TestBucket_Upload_KMSKeyName(t *testing.T) {
+	const key = "projects/p/locations/l/keyRings/r/cryptoKeys/k"
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.URL.Query().Get("kmsKeyName"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"bucket":"bucket","name":"obj","kmsKeyName":"` + key + `/cryptoKeyVersions/1"}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	for _, kmsKeyName := range []string{"", key} {
+		b, err := newBucket(ctx, log.NewNopLogger(), Config{Bucket: "bucket", KMSKeyName: kmsKeyName}, []option.ClientOption{
+			option.WithEndpoint(srv.URL + "/storage/v1/"),
+			option.WithoutAuthentication(),
+			option.WithHTTPClient(srv.Client()),
+		})
+		testutil.Ok(t, err)
+		testutil.Ok(t, b.Upload(ctx, "obj", strings.NewReader("content")))
+		testutil.Ok(t, b.Close())
+	}
+	testutil.Equals(t, []string{"", key}, got)
+}
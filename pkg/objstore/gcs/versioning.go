@@ -0,0 +1,61 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package gcs
+
+import (
+	"context"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"google.golang.org/api/iterator"
+)
+
+var _ objstore.VersionedBucket = &Bucket{}
+
+// IterVersions calls f for each generation of objects with the given prefix. Noncurrent generations are kept by
+// buckets with Object Versioning enabled.
+func (b *Bucket) IterVersions(ctx context.Context, prefix string, f func(objstore.ObjectVersion) error) error {
+	it := b.bkt.Objects(ctx, &storage.Query{
+		Prefix:   prefix,
+		Versions: true,
+	})
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := f(objstore.ObjectVersion{
+			Name:         attrs.Name,
+			VersionID:    strconv.FormatInt(attrs.Generation, 10),
+			LastModified: attrs.Updated,
+			Size:         attrs.Size,
+			Latest:       attrs.Deleted.IsZero(),
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// RestoreVersion copies the given generation of the object over its live version.
+func (b *Bucket) RestoreVersion(ctx context.Context, v objstore.ObjectVersion) error {
+	gen, err := strconv.ParseInt(v.VersionID, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "parse generation %q", v.VersionID)
+	}
+	obj := b.bkt.Object(v.Name)
+	c := obj.CopierFrom(obj.Generation(gen))
+	c.DestinationKMSKeyName = b.kmsKeyName
+	_, err = c.Run(ctx)
+	return err
+}
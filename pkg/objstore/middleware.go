@@ -0,0 +1,330 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+)
+
+// MiddlewareConfig configures retries, rate limits and timeouts of operations against the bucket.
+// Zero values disable the respective functionality.
+type MiddlewareConfig struct {
+	Retry      RetryConfig        `yaml:"retry"`
+	RateLimits OperationRateLimit `yaml:"rate_limits"`
+	Timeouts   OperationTimeout   `yaml:"timeouts"`
+}
+
+// RetryConfig configures retries of failed operations. Not found errors are never retried.
+type RetryConfig struct {
+	// MaxRetries is the maximum number of retries of a single operation.
+	MaxRetries int           `yaml:"max_retries"`
+	MinBackoff time.Duration `yaml:"min_backoff"`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// BudgetRatio is the maximum ratio of retries to operations, so retries can't multiply the load of the
+	// provider when most of the operations fail, e.g. when throttled.
+	BudgetRatio float64 `yaml:"budget_ratio"`
+	// BudgetBurst is the number of retries allowed on top of the ratio, e.g. after a period with no operations.
+	BudgetBurst int `yaml:"budget_burst"`
+}
+
+// OperationRateLimit configures maximum number of operations per second for each operation type.
+type OperationRateLimit struct {
+	Iter       float64 `yaml:"iter"`
+	ObjectSize float64 `yaml:"object_size"`
+	Get        float64 `yaml:"get"`
+	GetRange   float64 `yaml:"get_range"`
+	Exists     float64 `yaml:"exists"`
+	Upload     float64 `yaml:"upload"`
+	Delete     float64 `yaml:"delete"`
+}
+
+// OperationTimeout configures timeout of a single attempt for each operation type. Timeouts of get and get_range
+// include reading of the object.
+type OperationTimeout struct {
+	Iter       time.Duration `yaml:"iter"`
+	ObjectSize time.Duration `yaml:"object_size"`
+	Get        time.Duration `yaml:"get"`
+	GetRange   time.Duration `yaml:"get_range"`
+	Exists     time.Duration `yaml:"exists"`
+	Upload     time.Duration `yaml:"upload"`
+	Delete     time.Duration `yaml:"delete"`
+}
+
+var DefaultMiddlewareConfig = MiddlewareConfig{
+	Retry: RetryConfig{
+		MinBackoff:  100 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		BudgetRatio: 0.1,
+		BudgetBurst: 10,
+	},
+}
+
+// BucketWithMiddleware returns a bucket retrying, rate limiting and timing out operations against the given bucket
+// as configured. Each attempt is rate limited and timed out on its own.
+func BucketWithMiddleware(logger log.Logger, b Bucket, conf MiddlewareConfig, reg prometheus.Registerer) Bucket {
+	if conf.Retry.MaxRetries <= 0 && conf.RateLimits == (OperationRateLimit{}) && conf.Timeouts == (OperationTimeout{}) {
+		return b
+	}
+
+	mb := &middlewareBucket{
+		Bucket: b,
+		logger: logger,
+		retry:  conf.Retry,
+		budget: newRetryBudget(conf.Retry.BudgetRatio, conf.Retry.BudgetBurst),
+		limiters: map[string]*rate.Limiter{
+			iterOp:     newOpLimiter(conf.RateLimits.Iter),
+			sizeOp:     newOpLimiter(conf.RateLimits.ObjectSize),
+			getOp:      newOpLimiter(conf.RateLimits.Get),
+			getRangeOp: newOpLimiter(conf.RateLimits.GetRange),
+			existsOp:   newOpLimiter(conf.RateLimits.Exists),
+			uploadOp:   newOpLimiter(conf.RateLimits.Upload),
+			deleteOp:   newOpLimiter(conf.RateLimits.Delete),
+		},
+		timeouts: map[string]time.Duration{
+			iterOp:     conf.Timeouts.Iter,
+			sizeOp:     conf.Timeouts.ObjectSize,
+			getOp:      conf.Timeouts.Get,
+			getRangeOp: conf.Timeouts.GetRange,
+			existsOp:   conf.Timeouts.Exists,
+			uploadOp:   conf.Timeouts.Upload,
+			deleteOp:   conf.Timeouts.Delete,
+		},
+		retries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name:        "thanos_objstore_bucket_operation_retries_total",
+			Help:        "Total number of retried operations against a bucket.",
+			ConstLabels: prometheus.Labels{"bucket": b.Name()},
+		}, []string{"operation"}),
+		budgetExhausted: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name:        "thanos_objstore_bucket_operation_retry_budget_exhausted_total",
+			Help:        "Total number of failed operations against a bucket not retried because the retry budget was exhausted.",
+			ConstLabels: prometheus.Labels{"bucket": b.Name()},
+		}, []string{"operation"}),
+	}
+	for _, op := range []string{iterOp, sizeOp, getOp, getRangeOp, existsOp, uploadOp, deleteOp} {
+		mb.retries.WithLabelValues(op)
+		mb.budgetExhausted.WithLabelValues(op)
+	}
+	return mb
+}
+
+func newOpLimiter(opsPerSec float64) *rate.Limiter {
+	if opsPerSec <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(opsPerSec), int(math.Max(1, math.Ceil(opsPerSec))))
+}
+
+// retryBudget is a token bucket filled by operations and drained by retries.
+type retryBudget struct {
+	mtx    sync.Mutex
+	ratio  float64
+	burst  float64
+	tokens float64
+}
+
+func newRetryBudget(ratio float64, burst int) *retryBudget {
+	if ratio <= 0 && burst <= 0 {
+		// No budget configured, retries are limited only by max retries.
+		return nil
+	}
+	return &retryBudget{ratio: ratio, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (b *retryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.tokens = math.Min(b.tokens+b.ratio, math.Max(b.burst, 1))
+}
+
+func (b *retryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+type middlewareBucket struct {
+	Bucket
+
+	logger   log.Logger
+	retry    RetryConfig
+	budget   *retryBudget
+	limiters map[string]*rate.Limiter
+	timeouts map[string]time.Duration
+
+	retries         *prometheus.CounterVec
+	budgetExhausted *prometheus.CounterVec
+}
+
+// do runs f until it succeeds, fails with not retriable error or retries are exhausted. The context passed to f
+// is canceled by the returned cancel function, which has to be called once the operation result is not used anymore.
+func (b *middlewareBucket) do(ctx context.Context, op string, retriable func() bool, f func(ctx context.Context) error) (context.CancelFunc, error) {
+	b.budget.deposit()
+
+	for attempt := 0; ; attempt++ {
+		if l := b.limiters[op]; l != nil {
+			if err := l.Wait(ctx); err != nil {
+				return func() {}, err
+			}
+		}
+
+		actx, cancel := ctx, context.CancelFunc(func() {})
+		if t := b.timeouts[op]; t > 0 {
+			actx, cancel = context.WithTimeout(ctx, t)
+		}
+		err := f(actx)
+		if err == nil {
+			return cancel, nil
+		}
+		cancel()
+
+		if attempt >= b.retry.MaxRetries || ctx.Err() != nil || b.Bucket.IsObjNotFoundErr(err) || !retriable() {
+			return func() {}, err
+		}
+		if !b.budget.withdraw() {
+			b.budgetExhausted.WithLabelValues(op).Inc()
+			return func() {}, err
+		}
+
+		backoff := b.backoff(attempt)
+		level.Debug(b.logger).Log("msg", "retrying bucket operation", "operation", op, "attempt", attempt+1, "backoff", backoff, "err", err)
+		b.retries.WithLabelValues(op).Inc()
+		select {
+		case <-ctx.Done():
+			return func() {}, err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (b *middlewareBucket) backoff(attempt int) time.Duration {
+	d := b.retry.MinBackoff
+	for i := 0; i < attempt && d < b.retry.MaxBackoff; i++ {
+		d *= 2
+	}
+	if b.retry.MaxBackoff > 0 && d > b.retry.MaxBackoff {
+		d = b.retry.MaxBackoff
+	}
+	return d
+}
+
+func always() bool { return true }
+
+// Iter is retried only if it failed before calling f.
+func (b *middlewareBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	called := false
+	cancel, err := b.do(ctx, iterOp, func() bool { return !called }, func(ctx context.Context) error {
+		return b.Bucket.Iter(ctx, dir, func(name string) error {
+			called = true
+			return f(name)
+		})
+	})
+	cancel()
+	return err
+}
+
+func (b *middlewareBucket) Get(ctx context.Context, name string) (rc io.ReadCloser, err error) {
+	cancel, err := b.do(ctx, getOp, always, func(ctx context.Context) (err error) {
+		rc, err = b.Bucket.Get(ctx, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cancelOnCloseReader{ReadCloser: rc, cancel: cancel}, nil
+}
+
+func (b *middlewareBucket) GetRange(ctx context.Context, name string, off, length int64) (rc io.ReadCloser, err error) {
+	cancel, err := b.do(ctx, getRangeOp, always, func(ctx context.Context) (err error) {
+		rc, err = b.Bucket.GetRange(ctx, name, off, length)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cancelOnCloseReader{ReadCloser: rc, cancel: cancel}, nil
+}
+
+func (b *middlewareBucket) Exists(ctx context.Context, name string) (ok bool, err error) {
+	cancel, err := b.do(ctx, existsOp, always, func(ctx context.Context) (err error) {
+		ok, err = b.Bucket.Exists(ctx, name)
+		return err
+	})
+	cancel()
+	return ok, err
+}
+
+func (b *middlewareBucket) ObjectSize(ctx context.Context, name string) (size uint64, err error) {
+	cancel, err := b.do(ctx, sizeOp, always, func(ctx context.Context) (err error) {
+		size, err = b.Bucket.ObjectSize(ctx, name)
+		return err
+	})
+	cancel()
+	return size, err
+}
+
+// Upload is retried only if the reader can be rewound, i.e. it implements io.Seeker.
+func (b *middlewareBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	var (
+		seeker, seekable = r.(io.Seeker)
+		start            int64
+		first            = true
+	)
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+	cancel, err := b.do(ctx, uploadOp, func() bool { return seekable }, func(ctx context.Context) error {
+		if !first {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		first = false
+		return b.Bucket.Upload(ctx, name, r)
+	})
+	cancel()
+	return err
+}
+
+func (b *middlewareBucket) Delete(ctx context.Context, name string) error {
+	cancel, err := b.do(ctx, deleteOp, always, func(ctx context.Context) error {
+		return b.Bucket.Delete(ctx, name)
+	})
+	cancel()
+	return err
+}
+
+// cancelOnCloseReader cancels the context of the operation the reader was returned by when closed.
+type cancelOnCloseReader struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnCloseReader) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
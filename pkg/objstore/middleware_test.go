@@ -0,0 +1,128 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+// failingBucket fails the given number of first attempts of each operation.
+type failingBucket struct {
+	objstore.Bucket
+
+	failures int
+	attempts map[string]int
+}
+
+func (b *failingBucket) fail(op string) error {
+	b.attempts[op]++
+	if b.attempts[op] <= b.failures {
+		return errors.Errorf("%s failed", op)
+	}
+	return nil
+}
+
+func (b *failingBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if err := b.fail("upload"); err != nil {
+		// Consume part of the reader, so retries have to rewind it.
+		_, _ = r.Read(make([]byte, 1))
+		return err
+	}
+	return b.Bucket.Upload(ctx, name, r)
+}
+
+func (b *failingBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := b.fail("get"); err != nil {
+		return nil, err
+	}
+	return b.Bucket.Get(ctx, name)
+}
+
+func (b *failingBucket) Exists(ctx context.Context, name string) (bool, error) {
+	if err := b.fail("exists"); err != nil {
+		return false, err
+	}
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func TestBucketWithMiddleware_Retries(t *testing.T) {
+	ctx := context.Background()
+	inner := &failingBucket{Bucket: inmem.NewBucket(), failures: 2, attempts: map[string]int{}}
+	bkt := objstore.BucketWithMiddleware(log.NewNopLogger(), inner, objstore.MiddlewareConfig{
+		Retry: objstore.RetryConfig{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+	}, nil)
+
+	testutil.Ok(t, bkt.Upload(ctx, "obj", bytes.NewReader([]byte("content"))))
+	testutil.Equals(t, 3, inner.attempts["upload"])
+
+	rc, err := bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+	// Rewound reader is uploaded from the start.
+	testutil.Equals(t, "content", string(b))
+	testutil.Equals(t, 3, inner.attempts["get"])
+
+	// Not seekable readers are not retried.
+	inner.attempts = map[string]int{}
+	testutil.NotOk(t, bkt.Upload(ctx, "obj2", ioutil.NopCloser(strings.NewReader("content"))))
+	testutil.Equals(t, 1, inner.attempts["upload"])
+
+	// Not found errors are not retried.
+	_, err = bkt.Get(ctx, "not-existing")
+	testutil.Assert(t, bkt.IsObjNotFoundErr(err), "expected not found error, got %v", err)
+	testutil.Equals(t, 3, inner.attempts["get"])
+}
+
+func TestBucketWithMiddleware_RetryBudget(t *testing.T) {
+	ctx := context.Background()
+	inner := &failingBucket{Bucket: inmem.NewBucket(), failures: 100, attempts: map[string]int{}}
+	bkt := objstore.BucketWithMiddleware(log.NewNopLogger(), inner, objstore.MiddlewareConfig{
+		Retry: objstore.RetryConfig{MaxRetries: 5, MinBackoff: time.Millisecond, BudgetRatio: 0.5, BudgetBurst: 2},
+	}, nil)
+
+	// Burst of 2 retries is used by the first operation, next ones are retried only as the ratio refills the budget.
+	for i := 0; i < 10; i++ {
+		_, err := bkt.Get(ctx, "obj")
+		testutil.NotOk(t, err)
+	}
+	testutil.Equals(t, 10+2+4, inner.attempts["get"])
+}
+
+func TestBucketWithMiddleware_TimeoutAndRateLimit(t *testing.T) {
+	ctx := context.Background()
+	inner := &failingBucket{Bucket: inmem.NewBucket(), attempts: map[string]int{}}
+	bkt := objstore.BucketWithMiddleware(log.NewNopLogger(), inner, objstore.MiddlewareConfig{
+		Retry:      objstore.RetryConfig{MaxRetries: 1},
+		Timeouts:   objstore.OperationTimeout{Exists: 50 * time.Millisecond},
+		RateLimits: objstore.OperationRateLimit{Delete: 10},
+	}, nil)
+
+	// Each attempt times out on its own.
+	_, err := bkt.Exists(ctx, "obj")
+	testutil.NotOk(t, err)
+	testutil.Equals(t, context.DeadlineExceeded, errors.Cause(err))
+	testutil.Equals(t, 2, inner.attempts["exists"])
+
+	// Burst of 10 deletes is allowed straight away, the rest is limited to 10 per second.
+	start := time.Now()
+	for i := 0; i < 15; i++ {
+		testutil.Assert(t, bkt.IsObjNotFoundErr(bkt.Delete(ctx, "obj")), "expected not found error")
+	}
+	testutil.Assert(t, time.Since(start) >= 400*time.Millisecond, "deletes were not rate limited, took %v", time.Since(start))
+}
@@ -55,6 +55,14 @@ func TryToGetSize(r io.Reader) (int64, error) {
 		return int64(f.Len()), nil
 	case *strings.Reader:
 		return f.Size(), nil
+	case *rateLimitedReader:
+		if f.size < 0 {
+			return 0, errors.New("unsupported type of io.Reader wrapped by upload rate limit")
+		}
+		return f.size, nil
+	case interface{ Size() int64 }:
+		// Readers wrapping other ones, knowing how many bytes they return.
+		return f.Size(), nil
 	}
 	return 0, errors.New("unsupported type of io.Reader")
 }
@@ -200,6 +208,18 @@ const (
 	deleteOp   = "delete"
 )
 
+// Size classes of transferred objects, so latency of small objects is not hidden by transfers of big ones.
+const (
+	smallSizeClass  = "small"
+	mediumSizeClass = "medium"
+	largeSizeClass  = "large"
+
+	// Objects up to 1MiB, like meta.json and small index-headers, are small.
+	smallSizeClassMax = 1024 * 1024
+	// Objects up to 128MiB, like most of indexes, are medium. Bigger ones, like full chunk segments, are large.
+	mediumSizeClassMax = 128 * 1024 * 1024
+)
+
 // BucketWithMetrics takes a bucket and registers metrics with the given registry for
 // operations run against the bucket.
 func BucketWithMetrics(name string, b Bucket, reg prometheus.Registerer) Bucket {
@@ -224,6 +244,18 @@ func BucketWithMetrics(name string, b Bucket, reg prometheus.Registerer) Bucket
 			ConstLabels: prometheus.Labels{"bucket": name},
 			Buckets:     []float64{0.001, 0.01, 0.1, 0.3, 0.6, 1, 3, 6, 9, 20, 30, 60, 90, 120},
 		}, []string{"operation"}),
+		opsSizedDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "thanos_objstore_bucket_operation_size_class_duration_seconds",
+			Help:        "Duration of operations transferring object content against the bucket, by size class of the transferred content.",
+			ConstLabels: prometheus.Labels{"bucket": name},
+			Buckets:     []float64{0.001, 0.01, 0.1, 0.3, 0.6, 1, 3, 6, 9, 20, 30, 60, 90, 120},
+		}, []string{"operation", "size_class"}),
+		opsTransferredBytes: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "thanos_objstore_bucket_operation_transferred_bytes",
+			Help:        "Bytes transferred by operations against the bucket, by size class of the transferred content.",
+			ConstLabels: prometheus.Labels{"bucket": name},
+			Buckets:     prometheus.ExponentialBuckets(1024, 4, 12),
+		}, []string{"operation", "size_class"}),
 		lastSuccessfulUploadTime: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
 			Name: "thanos_objstore_bucket_last_successful_upload_time",
 			Help: "Second timestamp of the last successful upload to the bucket.",
@@ -234,6 +266,12 @@ func BucketWithMetrics(name string, b Bucket, reg prometheus.Registerer) Bucket
 		bkt.opsFailures.WithLabelValues(op)
 		bkt.opsDuration.WithLabelValues(op)
 	}
+	for _, op := range []string{getOp, getRangeOp, uploadOp} {
+		for _, class := range []string{smallSizeClass, mediumSizeClass, largeSizeClass} {
+			bkt.opsSizedDuration.WithLabelValues(op, class)
+			bkt.opsTransferredBytes.WithLabelValues(op, class)
+		}
+	}
 	bkt.lastSuccessfulUploadTime.WithLabelValues(b.Name())
 	return bkt
 }
@@ -244,9 +282,28 @@ type metricBucket struct {
 	ops                      *prometheus.CounterVec
 	opsFailures              *prometheus.CounterVec
 	opsDuration              *prometheus.HistogramVec
+	opsSizedDuration         *prometheus.HistogramVec
+	opsTransferredBytes      *prometheus.HistogramVec
 	lastSuccessfulUploadTime *prometheus.GaugeVec
 }
 
+// observeTransfer records duration and transferred bytes of the operation by size class of the object.
+func (b *metricBucket) observeTransfer(op string, bytes int64, duration time.Duration) {
+	class := sizeClass(bytes)
+	b.opsSizedDuration.WithLabelValues(op, class).Observe(duration.Seconds())
+	b.opsTransferredBytes.WithLabelValues(op, class).Observe(float64(bytes))
+}
+
+func sizeClass(bytes int64) string {
+	switch {
+	case bytes <= smallSizeClassMax:
+		return smallSizeClass
+	case bytes <= mediumSizeClassMax:
+		return mediumSizeClass
+	}
+	return largeSizeClass
+}
+
 func (b *metricBucket) Iter(ctx context.Context, dir string, f func(name string) error) error {
 	err := b.bkt.Iter(ctx, dir, f)
 	if err != nil {
@@ -284,6 +341,7 @@ func (b *metricBucket) Get(ctx context.Context, name string) (io.ReadCloser, err
 		getOp,
 		b.opsDuration,
 		b.opsFailures,
+		b.observeTransfer,
 	), nil
 }
 
@@ -300,6 +358,7 @@ func (b *metricBucket) GetRange(ctx context.Context, name string, off, length in
 		getRangeOp,
 		b.opsDuration,
 		b.opsFailures,
+		b.observeTransfer,
 	), nil
 }
 
@@ -319,11 +378,24 @@ func (b *metricBucket) Exists(ctx context.Context, name string) (bool, error) {
 func (b *metricBucket) Upload(ctx context.Context, name string, r io.Reader) error {
 	start := time.Now()
 
-	err := b.bkt.Upload(ctx, name, r)
+	// Readers of unknown size are counted. Readers of known size are passed as they are, so providers can still get
+	// their size.
+	size, err := TryToGetSize(r)
+	var cr *countingReader
+	if err != nil {
+		cr = &countingReader{Reader: r}
+		r = cr
+	}
+
+	err = b.bkt.Upload(ctx, name, r)
 	if err != nil {
 		b.opsFailures.WithLabelValues(uploadOp).Inc()
 	} else {
 		b.lastSuccessfulUploadTime.WithLabelValues(b.bkt.Name()).SetToCurrentTime()
+		if cr != nil {
+			size = cr.n
+		}
+		b.observeTransfer(uploadOp, size, time.Since(start))
 	}
 	b.ops.WithLabelValues(uploadOp).Inc()
 	b.opsDuration.WithLabelValues(uploadOp).Observe(time.Since(start).Seconds())
@@ -356,17 +428,38 @@ func (b *metricBucket) Name() string {
 	return b.bkt.Name()
 }
 
+// countingReader counts bytes read from the wrapped reader.
+type countingReader struct {
+	io.Reader
+
+	n int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.n += int64(n)
+	return n, err
+}
+
 type timingReadCloser struct {
 	io.ReadCloser
 
 	ok       bool
 	start    time.Time
 	op       string
+	read     int64
 	duration *prometheus.HistogramVec
 	failed   *prometheus.CounterVec
+	transfer func(op string, bytes int64, duration time.Duration)
 }
 
-func newTimingReadCloser(rc io.ReadCloser, op string, dur *prometheus.HistogramVec, failed *prometheus.CounterVec) *timingReadCloser {
+func newTimingReadCloser(
+	rc io.ReadCloser,
+	op string,
+	dur *prometheus.HistogramVec,
+	failed *prometheus.CounterVec,
+	transfer func(op string, bytes int64, duration time.Duration),
+) *timingReadCloser {
 	// Initialize the metrics with 0.
 	dur.WithLabelValues(op)
 	failed.WithLabelValues(op)
@@ -377,6 +470,7 @@ func newTimingReadCloser(rc io.ReadCloser, op string, dur *prometheus.HistogramV
 		op:         op,
 		duration:   dur,
 		failed:     failed,
+		transfer:   transfer,
 	}
 }
 
@@ -387,11 +481,16 @@ func (rc *timingReadCloser) Close() error {
 		rc.failed.WithLabelValues(rc.op).Inc()
 		rc.ok = false
 	}
+	// Failed transfers are not observed, as they would be counted in a size class by the bytes read before failing.
+	if rc.ok {
+		rc.transfer(rc.op, rc.read, time.Since(rc.start))
+	}
 	return err
 }
 
 func (rc *timingReadCloser) Read(b []byte) (n int, err error) {
 	n, err = rc.ReadCloser.Read(b)
+	rc.read += int64(n)
 	if rc.ok && err != nil && err != io.EOF {
 		rc.failed.WithLabelValues(rc.op).Inc()
 		rc.ok = false
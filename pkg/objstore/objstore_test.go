@@ -0,0 +1,87 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestBucketWithMetrics_SizeClasses(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	bkt := objstore.BucketWithMetrics("test", inmem.NewBucket(), reg)
+
+	testutil.Ok(t, bkt.Upload(ctx, "small", bytes.NewReader(make([]byte, 1024))))
+	// Reader of unknown size is counted.
+	testutil.Ok(t, bkt.Upload(ctx, "medium", ioutil.NopCloser(bytes.NewReader(make([]byte, 2*1024*1024)))))
+
+	rc, err := bkt.Get(ctx, "medium")
+	testutil.Ok(t, err)
+	_, err = io.Copy(ioutil.Discard, rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+
+	rc, err = bkt.GetRange(ctx, "medium", 0, 10)
+	testutil.Ok(t, err)
+	_, err = io.Copy(ioutil.Discard, rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+
+	mfs, err := reg.Gather()
+	testutil.Ok(t, err)
+	var bytesMetric, durationMetric *dto.MetricFamily
+	for _, mf := range mfs {
+		switch mf.GetName() {
+		case "thanos_objstore_bucket_operation_transferred_bytes":
+			bytesMetric = mf
+		case "thanos_objstore_bucket_operation_size_class_duration_seconds":
+			durationMetric = mf
+		}
+	}
+	testutil.Assert(t, bytesMetric != nil && durationMetric != nil, "expected size class metrics")
+
+	expected := map[[2]string]float64{
+		{"upload", "small"}:     1024,
+		{"upload", "medium"}:    2 * 1024 * 1024,
+		{"get", "medium"}:       2 * 1024 * 1024,
+		{"get_range", "small"}:  10,
+		{"get", "small"}:        0,
+		{"get", "large"}:        0,
+		{"get_range", "medium"}: 0,
+		{"get_range", "large"}:  0,
+		{"upload", "large"}:     0,
+	}
+	testutil.Equals(t, len(expected), len(bytesMetric.GetMetric()))
+	for i, m := range bytesMetric.GetMetric() {
+		var op, class string
+		for _, l := range m.GetLabel() {
+			switch l.GetName() {
+			case "operation":
+				op = l.GetValue()
+			case "size_class":
+				class = l.GetValue()
+			}
+		}
+		sum, ok := expected[[2]string{op, class}]
+		testutil.Assert(t, ok, "unexpected series %s %s", op, class)
+		testutil.Equals(t, sum, m.GetHistogram().GetSampleSum())
+
+		count := uint64(0)
+		if sum > 0 {
+			count = 1
+		}
+		testutil.Equals(t, count, m.GetHistogram().GetSampleCount())
+		testutil.Equals(t, count, durationMetric.GetMetric()[i].GetHistogram().GetSampleCount())
+	}
+}
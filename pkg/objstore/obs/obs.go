@@ -0,0 +1,234 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package obs implements common object storage abstractions against Huawei Cloud Object Storage Service (OBS).
+// It uses OBS s3-compatible API.
+package obs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/minio/minio-go/v6/pkg/encrypt"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/s3"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SSEKMS encrypts objects with keys managed by Huawei Cloud KMS.
+	SSEKMS = "SSE-KMS"
+	// SSEOBS encrypts objects with keys managed by OBS.
+	SSEOBS = "SSE-OBS"
+	// SSEC encrypts objects with customer provided key.
+	SSEC = "SSE-C"
+
+	// DefaultAgencyCredentialsURL is the ECS metadata endpoint returning temporary credentials of the ECS agency.
+	DefaultAgencyCredentialsURL = "http://169.254.169.254/openstack/latest/securitykey"
+)
+
+// Config stores the configuration for OBS bucket.
+type Config struct {
+	Bucket   string `yaml:"bucket"`
+	Endpoint string `yaml:"endpoint"`
+	Region   string `yaml:"region"`
+	// AccessKey and SecretKey are AK/SK credentials. If both are empty, temporary credentials of the agency
+	// assigned to ECS instance are used.
+	AccessKey string        `yaml:"access_key"`
+	SecretKey string        `yaml:"secret_key"`
+	Insecure  bool          `yaml:"insecure"`
+	SSEConfig SSEConfig     `yaml:"sse_config"`
+	HTTP      s3.HTTPConfig `yaml:"http_config"`
+	// PartSize used for multipart upload. Only used if uploaded object size is known and larger than configured PartSize.
+	PartSize uint64 `yaml:"part_size"`
+}
+
+// SSEConfig stores server-side encryption configuration of uploaded objects.
+type SSEConfig struct {
+	// Type is one of SSE-KMS, SSE-OBS or SSE-C. Empty means no server-side encryption.
+	Type string `yaml:"type"`
+	// KMSKeyID is ID of the KMS key used with SSE-KMS. If empty, the default OBS key of the project is used.
+	KMSKeyID string `yaml:"kms_key_id"`
+	// CustomerKeyFile is a path to the file with 32 bytes key used with SSE-C.
+	CustomerKeyFile string `yaml:"customer_key_file"`
+}
+
+var DefaultConfig = Config{
+	HTTP:     s3.DefaultConfig.HTTPConfig,
+	PartSize: s3.DefaultConfig.PartSize,
+}
+
+func parseConfig(conf []byte) (Config, error) {
+	config := DefaultConfig
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+func (conf Config) validate() error {
+	if conf.Bucket == "" {
+		return errors.New("no OBS bucket in config file")
+	}
+	if conf.Endpoint == "" {
+		return errors.New("no OBS endpoint in config file")
+	}
+	if (conf.AccessKey == "") != (conf.SecretKey == "") {
+		return errors.New("both OBS access_key and secret_key have to be specified, or none of them to use ECS agency")
+	}
+	switch conf.SSEConfig.Type {
+	case "", SSEOBS, SSEKMS:
+	case SSEC:
+		if conf.SSEConfig.CustomerKeyFile == "" {
+			return errors.New("customer_key_file is required with SSE-C")
+		}
+		if conf.Insecure {
+			return errors.New("SSE-C requires HTTPS")
+		}
+	default:
+		return errors.Errorf("unsupported OBS server-side encryption type %q", conf.SSEConfig.Type)
+	}
+	return nil
+}
+
+// NewBucket returns a new Bucket using the provided OBS config values.
+func NewBucket(logger log.Logger, conf []byte, component string) (*s3.Bucket, error) {
+	config, err := parseConfig(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse OBS config")
+	}
+	return NewBucketWithConfig(logger, config, component)
+}
+
+// NewBucketWithConfig returns a new Bucket using the provided OBS config struct.
+func NewBucketWithConfig(logger log.Logger, config Config, component string) (*s3.Bucket, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	var creds *credentials.Credentials
+	if config.AccessKey != "" {
+		creds = credentials.NewStaticV4(config.AccessKey, config.SecretKey, "")
+	} else {
+		creds = credentials.New(&AgencyCredentials{URL: DefaultAgencyCredentialsURL})
+	}
+
+	sse, err := serverSideEncryption(config.SSEConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.NewBucketWithCredentials(logger, s3.Config{
+		Bucket:     config.Bucket,
+		Endpoint:   config.Endpoint,
+		Region:     config.Region,
+		Insecure:   config.Insecure,
+		HTTPConfig: config.HTTP,
+		PartSize:   config.PartSize,
+	}, creds, sse, component)
+}
+
+func serverSideEncryption(conf SSEConfig) (encrypt.ServerSide, error) {
+	switch conf.Type {
+	case SSEKMS:
+		sse, err := encrypt.NewSSEKMS(conf.KMSKeyID, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "create SSE-KMS encryption")
+		}
+		return sse, nil
+	case SSEOBS:
+		return encrypt.NewSSE(), nil
+	case SSEC:
+		key, err := ioutil.ReadFile(conf.CustomerKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read SSE-C customer key")
+		}
+		sse, err := encrypt.NewSSEC(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "create SSE-C encryption")
+		}
+		return sse, nil
+	}
+	return nil, nil
+}
+
+// AgencyCredentials retrieves temporary credentials of the agency assigned to ECS instance from the ECS metadata
+// service. Credentials are refreshed before they expire.
+type AgencyCredentials struct {
+	credentials.Expiry
+
+	URL    string
+	Client *http.Client
+}
+
+// Retrieve implements credentials.Provider.
+func (a *AgencyCredentials) Retrieve() (credentials.Value, error) {
+	c := a.Client
+	if c == nil {
+		c = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := c.Get(a.URL)
+	if err != nil {
+		return credentials.Value{}, errors.Wrap(err, "request ECS agency credentials")
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return credentials.Value{}, errors.Wrap(err, "read ECS agency credentials")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return credentials.Value{}, errors.Errorf("got non-200 response code for ECS agency credentials: %v, response: %v", resp.StatusCode, string(b))
+	}
+
+	var r struct {
+		Credential struct {
+			Access        string    `json:"access"`
+			Secret        string    `json:"secret"`
+			SecurityToken string    `json:"securitytoken"`
+			ExpiresAt     time.Time `json:"expires_at"`
+		} `json:"credential"`
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return credentials.Value{}, errors.Wrap(err, "unmarshal ECS agency credentials")
+	}
+
+	// Refresh credentials 5 minutes before they expire.
+	a.SetExpiration(r.Credential.ExpiresAt, 5*time.Minute)
+	return credentials.Value{
+		AccessKeyID:     r.Credential.Access,
+		SecretAccessKey: r.Credential.Secret,
+		SessionToken:    r.Credential.SecurityToken,
+		SignerType:      credentials.SignatureV4,
+	}, nil
+}
+
+// NewTestBucket creates test bkt client that before returning creates temporary bucket.
+// In a close function it empties and deletes the bucket.
+func NewTestBucket(t testing.TB) (objstore.Bucket, func(), error) {
+	c := s3.Config{
+		Bucket:     os.Getenv("OBS_BUCKET"),
+		Endpoint:   os.Getenv("OBS_ENDPOINT"),
+		Region:     os.Getenv("OBS_REGION"),
+		AccessKey:  os.Getenv("OBS_ACCESS_KEY"),
+		SecretKey:  os.Getenv("OBS_SECRET_KEY"),
+		HTTPConfig: s3.DefaultConfig.HTTPConfig,
+		PartSize:   s3.DefaultConfig.PartSize,
+	}
+	if err := s3.ValidateForTests(c); err != nil {
+		return nil, nil, errors.Wrap(err, "OBS_ENDPOINT, OBS_ACCESS_KEY and OBS_SECRET_KEY env variables are required")
+	}
+	if c.Bucket != "" && os.Getenv("THANOS_ALLOW_EXISTING_BUCKET_USE") == "" {
+		return nil, nil, errors.New("OBS_BUCKET is defined. Normally this tests will create temporary bucket " +
+			"and delete it after test. Unset OBS_BUCKET env variable to use default logic. If you really want to run " +
+			"tests against provided (NOT USED!) bucket, set THANOS_ALLOW_EXISTING_BUCKET_USE=true.")
+	}
+	return s3.NewTestBucketFromConfig(t, c.Region, c, true)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package obs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestParseConfig(t *testing.T) {
+	for _, tcase := range []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{name: "AK/SK", input: "bucket: b\nendpoint: obs.eu-west-101.myhuaweicloud.eu\naccess_key: ak\nsecret_key: sk", ok: true},
+		{name: "ECS agency", input: "bucket: b\nendpoint: obs.eu-west-101.myhuaweicloud.eu", ok: true},
+		{name: "no bucket", input: "endpoint: obs.eu-west-101.myhuaweicloud.eu"},
+		{name: "no endpoint", input: "bucket: b"},
+		{name: "AK without SK", input: "bucket: b\nendpoint: e\naccess_key: ak"},
+		{name: "SSE-KMS", input: "bucket: b\nendpoint: e\nsse_config:\n  type: SSE-KMS\n  kms_key_id: key", ok: true},
+		{name: "SSE-C without key", input: "bucket: b\nendpoint: e\nsse_config:\n  type: SSE-C"},
+		{name: "SSE-C insecure", input: "bucket: b\nendpoint: e\ninsecure: true\nsse_config:\n  type: SSE-C\n  customer_key_file: /key"},
+		{name: "unknown SSE", input: "bucket: b\nendpoint: e\nsse_config:\n  type: SSE-S3"},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			conf, err := parseConfig([]byte(tcase.input))
+			testutil.Ok(t, err)
+			err = conf.validate()
+			if tcase.ok {
+				testutil.Ok(t, err)
+				return
+			}
+			testutil.NotOk(t, err)
+		})
+	}
+}
+
+func TestAgencyCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"credential":{"access":"ak","secret":"sk","securitytoken":"token","expires_at":"2999-01-01T00:00:00.000000Z"}}`))
+	}))
+	defer srv.Close()
+
+	creds := credentials.New(&AgencyCredentials{URL: srv.URL})
+	v, err := creds.Get()
+	testutil.Ok(t, err)
+	testutil.Equals(t, credentials.Value{
+		AccessKeyID:     "ak",
+		SecretAccessKey: "sk",
+		SessionToken:    "token",
+		SignerType:      credentials.SignatureV4,
+	}, v)
+	testutil.Assert(t, !creds.IsExpired(), "credentials should not be expired")
+}
@@ -0,0 +1,393 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/common/auth"
+	"github.com/oracle/oci-go-sdk/objectstorage"
+	"github.com/oracle/oci-go-sdk/objectstorage/transfer"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ConfigFileAuth authenticates using the OCI SDK config file, ~/.oci/config by default.
+	ConfigFileAuth = "config-file"
+	// InstancePrincipalAuth authenticates as the OCI compute instance Thanos runs on.
+	InstancePrincipalAuth = "instance-principal"
+
+	// DefaultPartSize is the part size of multipart uploads.
+	DefaultPartSize = 1024 * 1024 * 128
+)
+
+// Config stores the configuration for OCI Object Storage bucket.
+type Config struct {
+	Bucket string `yaml:"bucket"`
+	// Namespace of the bucket. If empty, the namespace of the tenancy is used.
+	Namespace string `yaml:"namespace"`
+	// Region overrides the region from the authentication, if set.
+	Region string `yaml:"region"`
+	// Auth is either config-file or instance-principal.
+	Auth                 string `yaml:"auth"`
+	ConfigFile           string `yaml:"config_file"`
+	Profile              string `yaml:"profile"`
+	PrivateKeyPassphrase string `yaml:"private_key_passphrase"`
+	// PartSize is the part size of multipart uploads used for objects larger than it.
+	PartSize int64 `yaml:"part_size"`
+}
+
+func (conf *Config) validate() error {
+	if conf.Bucket == "" {
+		return errors.New("no OCI bucket specified")
+	}
+	switch conf.Auth {
+	case "", ConfigFileAuth:
+	case InstancePrincipalAuth:
+		if conf.ConfigFile != "" || conf.Profile != "" || conf.PrivateKeyPassphrase != "" {
+			return errors.New("config_file, profile and private_key_passphrase can't be used with instance-principal auth")
+		}
+	default:
+		return errors.Errorf("unsupported OCI auth %q, expected %s or %s", conf.Auth, ConfigFileAuth, InstancePrincipalAuth)
+	}
+	if conf.PartSize < 0 {
+		return errors.New("part_size can't be negative")
+	}
+	return nil
+}
+
+// Bucket implements the store.Bucket interface against OCI Object Storage.
+type Bucket struct {
+	logger    log.Logger
+	client    objectstorage.ObjectStorageClient
+	namespace string
+	name      string
+	partSize  int64
+}
+
+// NewBucket returns a new Bucket using the provided OCI config values.
+func NewBucket(logger log.Logger, conf []byte, component string) (*Bucket, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parse OCI config")
+	}
+	return NewBucketWithConfig(logger, config, component)
+}
+
+// NewBucketWithConfig returns a new Bucket using the provided OCI config struct.
+func NewBucketWithConfig(logger log.Logger, config Config, component string) (*Bucket, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	provider, err := configurationProvider(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "create OCI configuration provider")
+	}
+	client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(provider)
+	if err != nil {
+		return nil, errors.Wrap(err, "create OCI object storage client")
+	}
+	if config.Region != "" {
+		client.SetRegion(config.Region)
+	}
+	// Default client timeout includes reading the body, which is too short for large objects.
+	client.HTTPClient = &http.Client{Transport: objstore.TracingTransport(http.DefaultTransport)}
+	client.UserAgent = fmt.Sprintf("thanos-%s", component)
+
+	namespace := config.Namespace
+	if namespace == "" {
+		resp, err := client.GetNamespace(context.Background(), objectstorage.GetNamespaceRequest{})
+		if err != nil {
+			return nil, errors.Wrap(err, "get OCI object storage namespace")
+		}
+		namespace = *resp.Value
+	}
+
+	partSize := config.PartSize
+	if partSize == 0 {
+		partSize = DefaultPartSize
+	}
+
+	return &Bucket{
+		logger:    logger,
+		client:    client,
+		namespace: namespace,
+		name:      config.Bucket,
+		partSize:  partSize,
+	}, nil
+}
+
+func configurationProvider(config Config) (common.ConfigurationProvider, error) {
+	if config.Auth == InstancePrincipalAuth {
+		return auth.InstancePrincipalConfigurationProvider()
+	}
+	if config.ConfigFile == "" && config.Profile == "" {
+		if config.PrivateKeyPassphrase != "" {
+			return common.ConfigurationProviderFromFile(defaultConfigFile(), config.PrivateKeyPassphrase)
+		}
+		return common.DefaultConfigProvider(), nil
+	}
+
+	file := config.ConfigFile
+	if file == "" {
+		file = defaultConfigFile()
+	}
+	if config.Profile != "" {
+		return common.ConfigurationProviderFromFileWithProfile(file, config.Profile, config.PrivateKeyPassphrase)
+	}
+	return common.ConfigurationProviderFromFile(file, config.PrivateKeyPassphrase)
+}
+
+func defaultConfigFile() string {
+	home, _ := os.UserHomeDir()
+	return home + "/.oci/config"
+}
+
+// Name returns the bucket name for OCI.
+func (b *Bucket) Name() string {
+	return b.name
+}
+
+// Iter calls f for each entry in the given directory (not recursive). The argument to f is the full
+// object name including the prefix of the inspected directory.
+func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	if dir != "" {
+		dir = strings.TrimSuffix(dir, objstore.DirDelim) + objstore.DirDelim
+	}
+
+	var start *string
+	for {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "context closed while iterating bucket")
+		}
+		resp, err := b.client.ListObjects(ctx, objectstorage.ListObjectsRequest{
+			NamespaceName: common.String(b.namespace),
+			BucketName:    common.String(b.name),
+			Prefix:        common.String(dir),
+			Delimiter:     common.String(objstore.DirDelim),
+			Start:         start,
+		})
+		if err != nil {
+			return errors.Wrap(err, "list OCI objects")
+		}
+
+		for _, o := range resp.Objects {
+			if err := f(*o.Name); err != nil {
+				return err
+			}
+		}
+		for _, p := range resp.Prefixes {
+			if err := f(p); err != nil {
+				return err
+			}
+		}
+		if resp.NextStartWith == nil {
+			return nil
+		}
+		start = resp.NextStartWith
+	}
+}
+
+func (b *Bucket) getRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if len(name) == 0 {
+		return nil, errors.New("given object name should not empty")
+	}
+
+	req := objectstorage.GetObjectRequest{
+		NamespaceName: common.String(b.namespace),
+		BucketName:    common.String(b.name),
+		ObjectName:    common.String(name),
+	}
+	if length != -1 {
+		req.Range = common.String(fmt.Sprintf("bytes=%d-%d", off, off+length-1))
+	} else if off > 0 {
+		req.Range = common.String(fmt.Sprintf("bytes=%d-", off))
+	}
+
+	resp, err := b.client.GetObject(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Content, nil
+}
+
+// Get returns a reader for the given object name.
+func (b *Bucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.getRange(ctx, name, 0, -1)
+}
+
+// GetRange returns a new range reader for the given object name and range.
+func (b *Bucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return b.getRange(ctx, name, off, length)
+}
+
+// Exists checks if the given object exists in the bucket.
+func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
+	_, err := b.head(ctx, name)
+	if err != nil {
+		if b.IsObjNotFoundErr(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "head OCI object")
+	}
+	return true, nil
+}
+
+// ObjectSize returns the size of the specified object.
+func (b *Bucket) ObjectSize(ctx context.Context, name string) (uint64, error) {
+	resp, err := b.head(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if resp.ContentLength == nil {
+		return 0, errors.New("content-length header not found")
+	}
+	return uint64(*resp.ContentLength), nil
+}
+
+func (b *Bucket) head(ctx context.Context, name string) (objectstorage.HeadObjectResponse, error) {
+	return b.client.HeadObject(ctx, objectstorage.HeadObjectRequest{
+		NamespaceName: common.String(b.namespace),
+		BucketName:    common.String(b.name),
+		ObjectName:    common.String(name),
+	})
+}
+
+// Upload the contents of the reader as an object into the bucket.
+// Objects larger than the part size or of unknown size are uploaded with multipart upload.
+func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if size, err := objstore.TryToGetSize(r); err == nil && size <= b.partSize {
+		if _, err := b.client.PutObject(ctx, objectstorage.PutObjectRequest{
+			NamespaceName: common.String(b.namespace),
+			BucketName:    common.String(b.name),
+			ObjectName:    common.String(name),
+			ContentLength: common.Int64(size),
+			PutObjectBody: ioutil.NopCloser(r),
+		}); err != nil {
+			return errors.Wrap(err, "upload OCI object")
+		}
+		return nil
+	}
+
+	if _, err := transfer.NewUploadManager().UploadStream(ctx, transfer.UploadStreamRequest{
+		UploadRequest: transfer.UploadRequest{
+			NamespaceName:       common.String(b.namespace),
+			BucketName:          common.String(b.name),
+			ObjectName:          common.String(name),
+			PartSize:            common.Int64(b.partSize),
+			ObjectStorageClient: &b.client,
+		},
+		StreamReader: r,
+	}); err != nil {
+		return errors.Wrap(err, "multipart upload OCI object")
+	}
+	return nil
+}
+
+// Delete removes the object with the given name.
+func (b *Bucket) Delete(ctx context.Context, name string) error {
+	if _, err := b.client.DeleteObject(ctx, objectstorage.DeleteObjectRequest{
+		NamespaceName: common.String(b.namespace),
+		BucketName:    common.String(b.name),
+		ObjectName:    common.String(name),
+	}); err != nil {
+		return errors.Wrap(err, "delete OCI object")
+	}
+	return nil
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
+func (b *Bucket) IsObjNotFoundErr(err error) bool {
+	se, ok := common.IsServiceError(errors.Cause(err))
+	return ok && se.GetHTTPStatusCode() == http.StatusNotFound
+}
+
+func (b *Bucket) Close() error { return nil }
+
+// NewTestBucket creates test bkt client that before returning creates temporary bucket.
+// In a close function it empties and deletes the bucket.
+func NewTestBucket(t testing.TB) (objstore.Bucket, func(), error) {
+	c := Config{
+		Bucket:     os.Getenv("OCI_BUCKET"),
+		Region:     os.Getenv("OCI_REGION"),
+		Auth:       os.Getenv("OCI_AUTH"),
+		ConfigFile: os.Getenv("OCI_CONFIG_FILE"),
+		Profile:    os.Getenv("OCI_PROFILE"),
+	}
+	compartment := os.Getenv("OCI_COMPARTMENT_OCID")
+	if c.Bucket != "" && os.Getenv("THANOS_ALLOW_EXISTING_BUCKET_USE") == "true" {
+		t.Log("OCI_BUCKET is defined. Normally this tests will create temporary bucket " +
+			"and delete it after test. Unset OCI_BUCKET env variable to use default logic. If you really want to run " +
+			"tests against provided (NOT USED!) bucket, set THANOS_ALLOW_EXISTING_BUCKET_USE=true.")
+		return NewTestBucketFromConfig(t, c, compartment, true)
+	}
+	if c.Bucket == "" && compartment == "" {
+		return nil, nil, errors.New("OCI_COMPARTMENT_OCID env variable is required to create the test bucket")
+	}
+	return NewTestBucketFromConfig(t, c, compartment, false)
+}
+
+func NewTestBucketFromConfig(t testing.TB, c Config, compartment string, reuseBucket bool) (objstore.Bucket, func(), error) {
+	createBucket := c.Bucket == ""
+	if createBucket {
+		src := rand.NewSource(time.Now().UnixNano())
+		c.Bucket = strings.Replace(fmt.Sprintf("test_%s_%x", strings.ToLower(t.Name()), src.Int63()), "_", "-", -1)
+		if len(c.Bucket) >= 63 {
+			c.Bucket = c.Bucket[:63]
+		}
+	}
+
+	b, err := NewBucketWithConfig(log.NewNopLogger(), c, "thanos-e2e-test")
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx := context.Background()
+
+	if reuseBucket {
+		if err := b.Iter(ctx, "", func(f string) error {
+			return errors.Errorf("bucket %s is not empty", c.Bucket)
+		}); err != nil {
+			return nil, nil, errors.Wrapf(err, "OCI check bucket %s", c.Bucket)
+		}
+
+		t.Log("WARNING. Reusing", c.Bucket, "OCI bucket for OCI tests. Manual cleanup afterwards is required")
+		return b, func() {}, nil
+	}
+
+	if createBucket {
+		if _, err := b.client.CreateBucket(ctx, objectstorage.CreateBucketRequest{
+			NamespaceName: common.String(b.namespace),
+			CreateBucketDetails: objectstorage.CreateBucketDetails{
+				Name:          common.String(c.Bucket),
+				CompartmentId: common.String(compartment),
+			},
+		}); err != nil {
+			return nil, nil, errors.Wrapf(err, "create OCI bucket %s", c.Bucket)
+		}
+	}
+
+	return b, func() {
+		objstore.EmptyBucket(t, ctx, b)
+		if _, err := b.client.DeleteBucket(ctx, objectstorage.DeleteBucketRequest{
+			NamespaceName: common.String(b.namespace),
+			BucketName:    common.String(c.Bucket),
+		}); err != nil {
+			t.Logf("deleting bucket %s failed: %s", c.Bucket, err)
+		}
+	}, nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package oci
+
+import (
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	for _, tcase := range []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{name: "no bucket", input: `namespace: ns`},
+		{name: "config file auth by default", input: `bucket: b`, ok: true},
+		{name: "config file auth with profile", input: "bucket: b\nauth: config-file\nconfig_file: /oci/config\nprofile: THANOS", ok: true},
+		{name: "instance principal auth", input: "bucket: b\nauth: instance-principal\nregion: eu-frankfurt-1", ok: true},
+		{name: "instance principal auth with config file", input: "bucket: b\nauth: instance-principal\nconfig_file: /oci/config"},
+		{name: "unknown auth", input: "bucket: b\nauth: api-key"},
+		{name: "negative part size", input: "bucket: b\npart_size: -1"},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			var conf Config
+			testutil.Ok(t, yaml.UnmarshalStrict([]byte(tcase.input), &conf))
+			err := conf.validate()
+			if tcase.ok {
+				testutil.Ok(t, err)
+				return
+			}
+			testutil.NotOk(t, err)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package oss
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	// EndpointTypeAuto selects the internal endpoint when running on an ECS instance in the region of the bucket.
+	EndpointTypeAuto = "auto"
+	// EndpointTypeInternal is the endpoint accessible only from the Alibaba Cloud network of the region, without
+	// traffic costs.
+	EndpointTypeInternal = "internal"
+	// EndpointTypePublic is the endpoint accessible from the internet.
+	EndpointTypePublic = "public"
+
+	accelerateEndpoint = "oss-accelerate.aliyuncs.com"
+)
+
+// ecsMetadataRegionURL returns the region of the ECS instance. It is a variable for tests.
+var ecsMetadataRegionURL = "http://100.100.100.200/latest/meta-data/region-id"
+
+// resolveEndpoint returns the endpoint configured explicitly or the one selected for the region of the bucket.
+func resolveEndpoint(logger log.Logger, config Config) (string, error) {
+	if config.TransferAcceleration {
+		if config.EndpointType == EndpointTypeInternal {
+			return "", errors.New("transfer acceleration can't be used with internal endpoint")
+		}
+		return accelerateEndpoint, nil
+	}
+	if config.Endpoint != "" {
+		return config.Endpoint, nil
+	}
+	if config.Region == "" {
+		return "", errors.New("either endpoint or region has to be specified")
+	}
+
+	region := strings.TrimPrefix(config.Region, "oss-")
+	internal := false
+	switch config.EndpointType {
+	case EndpointTypeInternal:
+		internal = true
+	case EndpointTypePublic:
+	case "", EndpointTypeAuto:
+		ecs, err := ecsRegion()
+		if err != nil {
+			level.Debug(logger).Log("msg", "not running on ECS instance, using public endpoint", "err", err)
+		}
+		internal = ecs == region
+	default:
+		return "", errors.Errorf("unknown endpoint type %q", config.EndpointType)
+	}
+
+	if internal {
+		return "oss-" + region + "-internal.aliyuncs.com", nil
+	}
+	return "oss-" + region + ".aliyuncs.com", nil
+}
+
+// ecsRegion returns the region of the ECS instance, reported by the instance metadata service.
+func ecsRegion() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, ecsMetadataRegionURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(log.NewNopLogger(), resp.Body, "close metadata response body")
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected metadata response %s", resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
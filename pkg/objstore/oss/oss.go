@@ -20,6 +20,7 @@ import (
 	alioss "github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"gopkg.in/yaml.v2"
 )
@@ -29,10 +30,29 @@ const PartSize = 1024 * 1024 * 128
 
 // Config stores the configuration for oss bucket.
 type Config struct {
-	Endpoint        string `yaml:"endpoint"`
-	Bucket          string `yaml:"bucket"`
-	AccessKeyID     string `yaml:"access_key_id"`
-	AccessKeySecret string `yaml:"access_key_secret"`
+	// Endpoint overrides the endpoint selected for the region.
+	Endpoint string `yaml:"endpoint"`
+	Bucket   string `yaml:"bucket"`
+	// Region of the bucket is used to select the endpoint, if not given explicitly.
+	Region string `yaml:"region"`
+	// EndpointType is auto, internal or public.
+	EndpointType         string `yaml:"endpoint_type"`
+	TransferAcceleration bool   `yaml:"transfer_acceleration"`
+	AccessKeyID          string `yaml:"access_key_id"`
+	AccessKeySecret      string `yaml:"access_key_secret"`
+	// RoleARN is the role assumed with the access key through STS, e.g. for access to bucket of another account.
+	RoleARN             string         `yaml:"role_arn"`
+	RoleSessionName     string         `yaml:"role_session_name"`
+	RoleSessionDuration model.Duration `yaml:"role_session_duration"`
+	STSEndpoint         string         `yaml:"sts_endpoint"`
+}
+
+// DefaultConfig is the default oss configuration.
+var DefaultConfig = Config{
+	EndpointType:        EndpointTypeAuto,
+	RoleSessionName:     "thanos",
+	RoleSessionDuration: model.Duration(time.Hour),
+	STSEndpoint:         "sts.aliyuncs.com",
 }
 
 // Bucket implements the store.Bucket interface.
@@ -45,12 +65,11 @@ type Bucket struct {
 }
 
 func NewTestBucket(t testing.TB) (objstore.Bucket, func(), error) {
-	c := Config{
-		Endpoint:        os.Getenv("ALIYUNOSS_ENDPOINT"),
-		Bucket:          os.Getenv("ALIYUNOSS_BUCKET"),
-		AccessKeyID:     os.Getenv("ALIYUNOSS_ACCESS_KEY_ID"),
-		AccessKeySecret: os.Getenv("ALIYUNOSS_ACCESS_KEY_SECRET"),
-	}
+	c := DefaultConfig
+	c.Endpoint = os.Getenv("ALIYUNOSS_ENDPOINT")
+	c.Bucket = os.Getenv("ALIYUNOSS_BUCKET")
+	c.AccessKeyID = os.Getenv("ALIYUNOSS_ACCESS_KEY_ID")
+	c.AccessKeySecret = os.Getenv("ALIYUNOSS_ACCESS_KEY_SECRET")
 
 	if c.Endpoint == "" || c.AccessKeyID == "" || c.AccessKeySecret == "" {
 		return nil, nil, errors.New("aliyun oss endpoint or access_key_id or access_key_secret " +
@@ -152,17 +171,35 @@ func (b *Bucket) ObjectSize(ctx context.Context, name string) (uint64, error) {
 
 // NewBucket returns a new Bucket using the provided oss config values.
 func NewBucket(logger log.Logger, conf []byte, component string) (*Bucket, error) {
-	var config Config
+	config := DefaultConfig
 	if err := yaml.Unmarshal(conf, &config); err != nil {
 		return nil, errors.Wrap(err, "parse aliyun oss config file failed")
 	}
 
-	if config.Endpoint == "" || config.Bucket == "" || config.AccessKeyID == "" || config.AccessKeySecret == "" {
-		return nil, errors.New("aliyun oss endpoint or bucket or access_key_id or access_key_secret " +
+	if config.Bucket == "" || config.AccessKeyID == "" || config.AccessKeySecret == "" {
+		return nil, errors.New("aliyun oss bucket or access_key_id or access_key_secret " +
 			"is not present in config file")
 	}
 
-	client, err := alioss.New(config.Endpoint, config.AccessKeyID, config.AccessKeySecret)
+	endpoint, err := resolveEndpoint(logger, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "select aliyun oss endpoint")
+	}
+
+	var opts []alioss.ClientOption
+	if config.RoleARN != "" {
+		// STS accepts sessions between 15 minutes and the maximum session duration of the role, 1 hour by default.
+		if time.Duration(config.RoleSessionDuration) < 15*time.Minute {
+			return nil, errors.New("role_session_duration has to be at least 15m")
+		}
+		provider, err := newSTSCredentialsProvider(logger, config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "assume role %s", config.RoleARN)
+		}
+		opts = append(opts, alioss.SetCredentialsProvider(provider))
+	}
+
+	client, err := alioss.New(endpoint, config.AccessKeyID, config.AccessKeySecret, opts...)
 	if err != nil {
 		return nil, errors.Wrap(err, "create aliyun oss client failed")
 	}
@@ -0,0 +1,118 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package oss
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSignRPC(t *testing.T) {
+	// Example from https://www.alibabacloud.com/help/doc-detail/25492.htm.
+	q := url.Values{
+		"AccessKeyId":      {"testid"},
+		"Action":           {"DescribeRegions"},
+		"Format":           {"XML"},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureNonce":   {"3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf"},
+		"SignatureVersion": {"1.0"},
+		"Timestamp":        {"2016-02-23T12:46:24Z"},
+		"Version":          {"2014-05-26"},
+	}
+	testutil.Equals(t, "OLeaidS1JvxuMvnyHOwuJ+uX5qY=", signRPC("GET", q, "testsecret"))
+}
+
+func TestSTSCredentialsProvider(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		testutil.Equals(t, "AssumeRole", q.Get("Action"))
+		testutil.Equals(t, "acs:ram::123:role/thanos", q.Get("RoleArn"))
+		testutil.Equals(t, "3600", q.Get("DurationSeconds"))
+		testutil.Equals(t, "id", q.Get("AccessKeyId"))
+
+		sig := q.Get("Signature")
+		q.Del("Signature")
+		testutil.Equals(t, signRPC(http.MethodGet, q, "secret"), sig)
+
+		_, _ = fmt.Fprintf(w, `{"RequestId":"1","Credentials":{"AccessKeyId":"STS.%d","AccessKeySecret":"s","SecurityToken":"t","Expiration":"%s"}}`,
+			requests, now.Add(time.Hour).Format(time.RFC3339))
+	}))
+	defer srv.Close()
+
+	c := DefaultConfig
+	c.AccessKeyID, c.AccessKeySecret = "id", "secret"
+	c.RoleARN = "acs:ram::123:role/thanos"
+	c.STSEndpoint = srv.URL
+	p, err := newSTSCredentialsProvider(log.NewNopLogger(), c)
+	testutil.Ok(t, err)
+	p.now = func() time.Time { return now }
+
+	creds := p.GetCredentials()
+	testutil.Equals(t, "STS.1", creds.GetAccessKeyID())
+	testutil.Equals(t, "t", creds.GetSecurityToken())
+	testutil.Equals(t, 1, requests)
+
+	// Credentials are refreshed when close to expiration.
+	p.now = func() time.Time { return now.Add(50 * time.Minute) }
+	testutil.Equals(t, "STS.2", p.GetCredentials().GetAccessKeyID())
+	testutil.Equals(t, 2, requests)
+}
+
+func TestResolveEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("cn-hangzhou"))
+	}))
+	defer srv.Close()
+	defer func(u string) { ecsMetadataRegionURL = u }(ecsMetadataRegionURL)
+	ecsMetadataRegionURL = srv.URL
+
+	for _, tcase := range []struct {
+		config   Config
+		expected string
+		err      bool
+	}{
+		{config: Config{Endpoint: "oss.example.com", Region: "cn-hangzhou"}, expected: "oss.example.com"},
+		{config: Config{Region: "cn-hangzhou"}, expected: "oss-cn-hangzhou-internal.aliyuncs.com"},
+		{config: Config{Region: "oss-cn-hangzhou", EndpointType: EndpointTypeAuto}, expected: "oss-cn-hangzhou-internal.aliyuncs.com"},
+		{config: Config{Region: "cn-beijing"}, expected: "oss-cn-beijing.aliyuncs.com"},
+		{config: Config{Region: "cn-hangzhou", EndpointType: EndpointTypePublic}, expected: "oss-cn-hangzhou.aliyuncs.com"},
+		{config: Config{Region: "cn-beijing", EndpointType: EndpointTypeInternal}, expected: "oss-cn-beijing-internal.aliyuncs.com"},
+		{config: Config{Region: "cn-hangzhou", TransferAcceleration: true}, expected: "oss-accelerate.aliyuncs.com"},
+		{config: Config{Region: "cn-hangzhou", EndpointType: EndpointTypeInternal, TransferAcceleration: true}, err: true},
+		{config: Config{Region: "cn-hangzhou", EndpointType: "vpc"}, err: true},
+		{config: Config{}, err: true},
+	} {
+		endpoint, err := resolveEndpoint(log.NewNopLogger(), tcase.config)
+		if tcase.err {
+			testutil.NotOk(t, err)
+			continue
+		}
+		testutil.Ok(t, err)
+		testutil.Equals(t, tcase.expected, endpoint)
+	}
+}
+
+func TestNewBucket_RoleSessionDuration(t *testing.T) {
+	c := DefaultConfig
+	c.Bucket, c.Endpoint, c.AccessKeyID, c.AccessKeySecret = "thanos", "oss.example.com", "id", "secret"
+	c.RoleARN = "acs:ram::123:role/thanos"
+	c.RoleSessionDuration = model.Duration(time.Minute)
+	conf, err := yaml.Marshal(c)
+	testutil.Ok(t, err)
+
+	_, err = NewBucket(log.NewNopLogger(), conf, "test")
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,172 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package oss
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	alioss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// credentials are the temporary credentials of an assumed role.
+type credentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	AccessKeySecret string    `json:"AccessKeySecret"`
+	SecurityToken   string    `json:"SecurityToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+func (c credentials) GetAccessKeyID() string     { return c.AccessKeyID }
+func (c credentials) GetAccessKeySecret() string { return c.AccessKeySecret }
+func (c credentials) GetSecurityToken() string   { return c.SecurityToken }
+
+// stsCredentialsProvider provides credentials of the role assumed with the STS AssumeRole API, using the access key of
+// the RAM user. Credentials are refreshed before they expire.
+type stsCredentialsProvider struct {
+	logger     log.Logger
+	endpoint   string
+	httpClient *http.Client
+	now        func() time.Time
+
+	accessKeyID, accessKeySecret string
+	roleARN, sessionName         string
+	duration                     time.Duration
+
+	mtx   sync.Mutex
+	creds credentials
+}
+
+func newSTSCredentialsProvider(logger log.Logger, config Config) (*stsCredentialsProvider, error) {
+	endpoint := config.STSEndpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	p := &stsCredentialsProvider{
+		logger:          logger,
+		endpoint:        endpoint,
+		httpClient:      &http.Client{Timeout: 30 * time.Second},
+		now:             time.Now,
+		accessKeyID:     config.AccessKeyID,
+		accessKeySecret: config.AccessKeySecret,
+		roleARN:         config.RoleARN,
+		sessionName:     config.RoleSessionName,
+		duration:        time.Duration(config.RoleSessionDuration),
+	}
+	// Fail early on wrong role or permissions.
+	creds, err := p.assumeRole()
+	if err != nil {
+		return nil, err
+	}
+	p.creds = creds
+	return p, nil
+}
+
+// GetCredentials implements alioss.CredentialsProvider. If the credentials can't be refreshed, the current ones are
+// returned until they expire, so the request fails with an authorization error.
+func (p *stsCredentialsProvider) GetCredentials() alioss.Credentials {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	// Refresh when less than a fifth of the session duration is left, so in-flight requests do not use expired ones.
+	if p.now().Add(p.duration / 5).Before(p.creds.Expiration) {
+		return p.creds
+	}
+	creds, err := p.assumeRole()
+	if err != nil {
+		level.Warn(p.logger).Log("msg", "failed to refresh assumed role credentials", "role", p.roleARN, "expiration", p.creds.Expiration, "err", err)
+		return p.creds
+	}
+	p.creds = creds
+	return p.creds
+}
+
+// assumeRole requests credentials of the role from the STS API, signed as described in
+// https://www.alibabacloud.com/help/doc-detail/28761.htm.
+func (p *stsCredentialsProvider) assumeRole() (credentials, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return credentials{}, err
+	}
+	q := url.Values{
+		"Action":           {"AssumeRole"},
+		"RoleArn":          {p.roleARN},
+		"RoleSessionName":  {p.sessionName},
+		"DurationSeconds":  {strconv.Itoa(int(p.duration.Seconds()))},
+		"Format":           {"JSON"},
+		"Version":          {"2015-04-01"},
+		"AccessKeyId":      {p.accessKeyID},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureVersion": {"1.0"},
+		"SignatureNonce":   {hex.EncodeToString(nonce)},
+		"Timestamp":        {p.now().UTC().Format("2006-01-02T15:04:05Z")},
+	}
+	q.Set("Signature", signRPC(http.MethodGet, q, p.accessKeySecret))
+
+	resp, err := p.httpClient.Get(p.endpoint + "/?" + q.Encode())
+	if err != nil {
+		return credentials{}, errors.Wrap(err, "assume role")
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(p.logger, resp.Body, "close STS response body")
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return credentials{}, errors.Wrap(err, "read STS response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return credentials{}, errors.Errorf("assume role %s: unexpected response %s: %s", p.roleARN, resp.Status, b)
+	}
+	var res struct {
+		Credentials credentials `json:"Credentials"`
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return credentials{}, errors.Wrap(err, "decode STS response")
+	}
+	level.Debug(p.logger).Log("msg", "assumed role", "role", p.roleARN, "expiration", res.Credentials.Expiration)
+	return res.Credentials, nil
+}
+
+// signRPC returns the signature of the RPC style API request with the given query parameters.
+func signRPC(method string, q url.Values, secret string) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	params := make([]string, 0, len(keys))
+	for _, k := range keys {
+		params = append(params, percentEncode(k)+"="+percentEncode(q.Get(k)))
+	}
+	stringToSign := method + "&" + percentEncode("/") + "&" + percentEncode(strings.Join(params, "&"))
+
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	_, _ = mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// percentEncode encodes the string as required by RFC 3986, which differs from the query escaping in the handling
+// of spaces, asterisks and tildes.
+func percentEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.Replace(s, "+", "%20", -1)
+	s = strings.Replace(s, "*", "%2A", -1)
+	return strings.Replace(s, "%7E", "~", -1)
+}
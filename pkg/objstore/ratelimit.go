@@ -0,0 +1,62 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// minUploadBurst is the minimum number of bytes read at once from a rate limited upload.
+const minUploadBurst = 32 * 1024
+
+// BucketWithUploadRateLimit returns a bucket limiting the total bandwidth of all uploads to the given number of
+// bytes per second. Other operations are not limited.
+func BucketWithUploadRateLimit(b Bucket, bytesPerSec int64) Bucket {
+	burst := int(bytesPerSec)
+	if burst < minUploadBurst {
+		burst = minUploadBurst
+	}
+	return &rateLimitedBucket{
+		Bucket:  b,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSec), burst),
+	}
+}
+
+type rateLimitedBucket struct {
+	Bucket
+
+	limiter *rate.Limiter
+}
+
+func (b *rateLimitedBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	rr := &rateLimitedReader{ctx: ctx, r: r, limiter: b.limiter, size: -1}
+	if size, err := TryToGetSize(r); err == nil {
+		rr.size = size
+	}
+	return b.Bucket.Upload(ctx, name, rr)
+}
+
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+	// size of the underlying reader or -1 if unknown, so providers can still guess the upload size.
+	size int64
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.limiter.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
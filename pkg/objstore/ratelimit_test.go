@@ -0,0 +1,39 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestBucketWithUploadRateLimit(t *testing.T) {
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+	bkt := objstore.BucketWithUploadRateLimit(inner, 64*1024)
+
+	// First burst is allowed straight away, the rest has to wait for tokens.
+	content := bytes.Repeat([]byte("a"), 64*1024+32*1024)
+	start := time.Now()
+	testutil.Ok(t, bkt.Upload(ctx, "obj", bytes.NewBuffer(content)))
+	testutil.Assert(t, time.Since(start) >= 400*time.Millisecond, "upload was not rate limited, took %v", time.Since(start))
+
+	rc, err := bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Equals(t, content, b)
+
+	// Upload is cancelled with context.
+	cctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	testutil.NotOk(t, bkt.Upload(cctx, "obj2", bytes.NewBuffer(bytes.Repeat([]byte("a"), 256*1024))))
+}
@@ -0,0 +1,363 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
+)
+
+const (
+	replicationUploadOp = "upload"
+	replicationDeleteOp = "delete"
+)
+
+// replicationOp is a pending operation persisted in the replication queue of a replica.
+type replicationOp struct {
+	Op   string `json:"op"`
+	Name string `json:"name"`
+}
+
+// ReplicatingBucket is a bucket which writes every upload and delete to the primary bucket and replicates it to
+// secondary buckets asynchronously. Reads are served from the primary bucket only.
+//
+// Operations to replicate are persisted in the queue directory, one queue per replica, so they are not lost on
+// restart. Each replica queue is processed in order and failed operations are retried until they succeed.
+// Uploaded objects are read back from the primary bucket when replicated, so objects deleted in the meantime
+// are not replicated at all.
+type ReplicatingBucket struct {
+	logger        log.Logger
+	primary       Bucket
+	replicas      []*replica
+	retryInterval time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type replica struct {
+	bkt Bucket
+	dir string
+
+	mtx     sync.Mutex
+	nextSeq uint64
+	wakeup  chan struct{}
+
+	pending  prometheus.Gauge
+	ops      *prometheus.CounterVec
+	failures *prometheus.CounterVec
+}
+
+// NewReplicatingBucket returns ReplicatingBucket replicating writes of the primary bucket to the given
+// secondary buckets, with queues persisted in the subdirectories of the queue directory named by the index of
+// the secondary bucket. Replication runs in the background until the bucket is closed.
+func NewReplicatingBucket(logger log.Logger, reg prometheus.Registerer, primary Bucket, secondaries []Bucket, queueDir string, retryInterval time.Duration) (*ReplicatingBucket, error) {
+	if queueDir == "" {
+		return nil, errors.New("replication queue directory is required")
+	}
+	if retryInterval <= 0 {
+		return nil, errors.New("replication retry interval has to be positive")
+	}
+
+	pending := promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thanos_objstore_replication_pending_operations",
+		Help: "Number of operations waiting for replication to the replica bucket.",
+	}, []string{"replica"})
+	ops := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_objstore_replication_operations_total",
+		Help: "Total number of operations replicated to the replica bucket.",
+	}, []string{"replica", "operation"})
+	failures := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_objstore_replication_operation_failures_total",
+		Help: "Total number of failed attempts to replicate operations to the replica bucket.",
+	}, []string{"replica", "operation"})
+
+	b := &ReplicatingBucket{
+		logger:        logger,
+		primary:       primary,
+		retryInterval: retryInterval,
+	}
+	for i, s := range secondaries {
+		idx := strconv.Itoa(i)
+		r := &replica{
+			bkt:      s,
+			dir:      filepath.Join(queueDir, idx),
+			wakeup:   make(chan struct{}, 1),
+			pending:  pending.WithLabelValues(idx),
+			ops:      ops.MustCurryWith(prometheus.Labels{"replica": idx}),
+			failures: failures.MustCurryWith(prometheus.Labels{"replica": idx}),
+		}
+		for _, op := range []string{replicationUploadOp, replicationDeleteOp} {
+			r.ops.WithLabelValues(op)
+			r.failures.WithLabelValues(op)
+		}
+		if err := os.MkdirAll(r.dir, os.ModePerm); err != nil {
+			return nil, errors.Wrapf(err, "create replication queue dir %s", r.dir)
+		}
+		seqs, err := r.queued()
+		if err != nil {
+			return nil, err
+		}
+		if len(seqs) > 0 {
+			r.nextSeq = seqs[len(seqs)-1] + 1
+		}
+		r.pending.Set(float64(len(seqs)))
+		b.replicas = append(b.replicas, r)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	for _, r := range b.replicas {
+		b.wg.Add(1)
+		go func(r *replica) {
+			defer b.wg.Done()
+			b.replicate(ctx, r)
+		}(r)
+	}
+	return b, nil
+}
+
+// Upload uploads the object to the primary bucket and queues its replication.
+func (b *ReplicatingBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if err := b.primary.Upload(ctx, name, r); err != nil {
+		return err
+	}
+	return b.enqueue(replicationOp{Op: replicationUploadOp, Name: name})
+}
+
+// Delete removes the object from the primary bucket and queues its removal from replicas.
+func (b *ReplicatingBucket) Delete(ctx context.Context, name string) error {
+	if err := b.primary.Delete(ctx, name); err != nil {
+		return err
+	}
+	return b.enqueue(replicationOp{Op: replicationDeleteOp, Name: name})
+}
+
+func (b *ReplicatingBucket) enqueue(op replicationOp) error {
+	for _, r := range b.replicas {
+		if err := r.enqueue(op); err != nil {
+			return errors.Wrapf(err, "queue %s of %s for replication", op.Op, op.Name)
+		}
+	}
+	return nil
+}
+
+func (r *replica) enqueue(op replicationOp) error {
+	b, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// Write into temporary file first, so partially written operations are never processed.
+	f := filepath.Join(r.dir, fmt.Sprintf("%020d.json", r.nextSeq))
+	if err := ioutil.WriteFile(f+".tmp", b, 0666); err != nil {
+		return err
+	}
+	if err := os.Rename(f+".tmp", f); err != nil {
+		return err
+	}
+	r.nextSeq++
+	r.pending.Inc()
+
+	select {
+	case r.wakeup <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// queued returns sequence numbers of queued operations in order.
+func (r *replica) queued() ([]uint64, error) {
+	fis, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read replication queue dir %s", r.dir)
+	}
+	var seqs []uint64
+	for _, fi := range fis {
+		if !strings.HasSuffix(fi.Name(), ".json") {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(fi.Name(), ".json"), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+func (r *replica) file(seq uint64) string {
+	return filepath.Join(r.dir, fmt.Sprintf("%020d.json", seq))
+}
+
+// replicate processes the replica queue in order until context is canceled.
+func (b *ReplicatingBucket) replicate(ctx context.Context, r *replica) {
+	for {
+		seqs, err := r.queued()
+		if err != nil {
+			level.Error(b.logger).Log("msg", "failed to list replication queue", "dir", r.dir, "err", err)
+		}
+
+		for _, seq := range seqs {
+			for {
+				err := b.process(ctx, r, seq)
+				if err == nil {
+					break
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				level.Warn(b.logger).Log("msg", "replication failed; retrying", "replica", r.bkt.Name(), "op", r.file(seq), "err", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(b.retryInterval):
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wakeup:
+		case <-time.After(b.retryInterval):
+		}
+	}
+}
+
+func (b *ReplicatingBucket) process(ctx context.Context, r *replica, seq uint64) error {
+	f := r.file(seq)
+	c, err := ioutil.ReadFile(f)
+	if err != nil {
+		return errors.Wrapf(err, "read %s", f)
+	}
+	var op replicationOp
+	if err := json.Unmarshal(c, &op); err != nil {
+		// Corrupted operation can't ever succeed.
+		level.Error(b.logger).Log("msg", "dropping corrupted replication operation", "op", f, "err", err)
+		return b.done(r, f)
+	}
+
+	switch op.Op {
+	case replicationUploadOp:
+		err = b.replicateUpload(ctx, r, op.Name)
+	case replicationDeleteOp:
+		err = r.bkt.Delete(ctx, op.Name)
+		if err != nil && r.bkt.IsObjNotFoundErr(err) {
+			err = nil
+		}
+	default:
+		level.Error(b.logger).Log("msg", "dropping unknown replication operation", "op", f, "type", op.Op)
+		return b.done(r, f)
+	}
+	if err != nil {
+		r.failures.WithLabelValues(op.Op).Inc()
+		return errors.Wrapf(err, "%s %s", op.Op, op.Name)
+	}
+	r.ops.WithLabelValues(op.Op).Inc()
+	return b.done(r, f)
+}
+
+func (b *ReplicatingBucket) done(r *replica, f string) error {
+	if err := os.Remove(f); err != nil {
+		return errors.Wrapf(err, "remove %s", f)
+	}
+	r.pending.Dec()
+	return nil
+}
+
+// replicateUpload copies the object from the primary bucket to the replica. The object is downloaded into the
+// queue directory first, so its size is known to the replica bucket upload.
+func (b *ReplicatingBucket) replicateUpload(ctx context.Context, r *replica, name string) (err error) {
+	tmp := filepath.Join(r.dir, "upload.tmp")
+	if err := DownloadFile(ctx, b.logger, b.primary, name, tmp); err != nil {
+		if b.primary.IsObjNotFoundErr(errors.Cause(err)) {
+			// Deleted since, its deletion is in the queue as well.
+			return nil
+		}
+		return err
+	}
+	defer func() {
+		if rerr := os.Remove(tmp); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	f, err := os.Open(tmp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return r.bkt.Upload(ctx, name, f)
+}
+
+// Iter calls f for each entry in the given directory of the primary bucket.
+func (b *ReplicatingBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	return b.primary.Iter(ctx, dir, f)
+}
+
+// Get returns a reader for the given object name from the primary bucket.
+func (b *ReplicatingBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.primary.Get(ctx, name)
+}
+
+// GetRange returns a new range reader for the given object name and range from the primary bucket.
+func (b *ReplicatingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return b.primary.GetRange(ctx, name, off, length)
+}
+
+// Exists checks if the given object exists in the primary bucket.
+func (b *ReplicatingBucket) Exists(ctx context.Context, name string) (bool, error) {
+	return b.primary.Exists(ctx, name)
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found in the primary bucket.
+func (b *ReplicatingBucket) IsObjNotFoundErr(err error) bool {
+	return b.primary.IsObjNotFoundErr(err)
+}
+
+// ObjectSize returns the size of the specified object in the primary bucket.
+func (b *ReplicatingBucket) ObjectSize(ctx context.Context, name string) (uint64, error) {
+	return b.primary.ObjectSize(ctx, name)
+}
+
+// Name returns the name of the primary bucket.
+func (b *ReplicatingBucket) Name() string {
+	return b.primary.Name()
+}
+
+// Close stops replication and closes all buckets. Pending operations stay in the queue.
+func (b *ReplicatingBucket) Close() error {
+	b.cancel()
+	b.wg.Wait()
+
+	var errs tsdberrors.MultiError
+	errs.Add(b.primary.Close())
+	for _, r := range b.replicas {
+		errs.Add(r.bkt.Close())
+	}
+	return errs.Err()
+}
@@ -0,0 +1,114 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+// flakyBucket fails uploads while failing is set.
+type flakyBucket struct {
+	objstore.Bucket
+
+	mtx     sync.Mutex
+	failing bool
+}
+
+func (b *flakyBucket) setFailing(f bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.failing = f
+}
+
+func (b *flakyBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	b.mtx.Lock()
+	failing := b.failing
+	b.mtx.Unlock()
+	if failing {
+		return errors.New("upload failed")
+	}
+	return b.Bucket.Upload(ctx, name, r)
+}
+
+func objects(t *testing.T, bkt objstore.Bucket) map[string]string {
+	objs := map[string]string{}
+	testutil.Ok(t, bkt.Iter(context.Background(), "", func(name string) error {
+		rc, err := bkt.Get(context.Background(), name)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		b, err := ioutil.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		objs[name] = string(b)
+		return nil
+	}))
+	return objs
+}
+
+func waitFor(t *testing.T, f func() bool) {
+	for i := 0; i < 100; i++ {
+		if f() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("timed out waiting for replication")
+}
+
+func TestReplicatingBucket(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "replicating-bucket")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	primary := inmem.NewBucket()
+	ok := inmem.NewBucket()
+	flaky := &flakyBucket{Bucket: inmem.NewBucket(), failing: true}
+
+	bkt, err := objstore.NewReplicatingBucket(log.NewNopLogger(), nil, primary, []objstore.Bucket{ok, flaky}, dir, 50*time.Millisecond)
+	testutil.Ok(t, err)
+
+	testutil.Ok(t, bkt.Upload(ctx, "a", strings.NewReader("a")))
+	testutil.Ok(t, bkt.Upload(ctx, "b", strings.NewReader("b")))
+	testutil.Ok(t, bkt.Delete(ctx, "a"))
+
+	exp := map[string]string{"b": "b"}
+	testutil.Equals(t, exp, objects(t, primary))
+	waitFor(t, func() bool { return len(objects(t, ok)) == 1 })
+	testutil.Equals(t, exp, objects(t, ok))
+	testutil.Equals(t, map[string]string{}, objects(t, flaky))
+
+	// Pending operations survive restart.
+	testutil.Ok(t, bkt.Close())
+	flaky.setFailing(false)
+	bkt, err = objstore.NewReplicatingBucket(log.NewNopLogger(), nil, primary, []objstore.Bucket{ok, flaky}, dir, 50*time.Millisecond)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, bkt.Close()) }()
+
+	waitFor(t, func() bool { return len(objects(t, flaky)) == 1 })
+	testutil.Equals(t, exp, objects(t, flaky))
+
+	testutil.Ok(t, bkt.Upload(ctx, "c", bytes.NewBufferString("c")))
+	exp["c"] = "c"
+	waitFor(t, func() bool { return len(objects(t, ok)) == 2 && len(objects(t, flaky)) == 2 })
+	testutil.Equals(t, exp, objects(t, ok))
+	testutil.Equals(t, exp, objects(t, flaky))
+}
@@ -7,8 +7,11 @@ package s3
 import (
 	"context"
 	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
@@ -34,6 +37,15 @@ import (
 // DirDelim is the delimiter used to model a directory structure in an object store bucket.
 const DirDelim = "/"
 
+const (
+	// SSES3 is the name of the SSE-S3 server-side encryption type with keys managed by S3.
+	SSES3 = "SSE-S3"
+	// SSEKMS is the name of the SSE-KMS server-side encryption type with keys managed by KMS.
+	SSEKMS = "SSE-KMS"
+	// SSEC is the name of the SSE-C server-side encryption type with customer provided keys.
+	SSEC = "SSE-C"
+)
+
 var DefaultConfig = Config{
 	PutUserMetadata: map[string]string{},
 	HTTPConfig: HTTPConfig{
@@ -47,21 +59,39 @@ var DefaultConfig = Config{
 
 // Config stores the configuration for s3 bucket.
 type Config struct {
-	Bucket          string            `yaml:"bucket"`
-	Endpoint        string            `yaml:"endpoint"`
-	Region          string            `yaml:"region"`
-	AccessKey       string            `yaml:"access_key"`
-	Insecure        bool              `yaml:"insecure"`
-	SignatureV2     bool              `yaml:"signature_version2"`
+	Bucket      string `yaml:"bucket"`
+	Endpoint    string `yaml:"endpoint"`
+	Region      string `yaml:"region"`
+	AccessKey   string `yaml:"access_key"`
+	Insecure    bool   `yaml:"insecure"`
+	SignatureV2 bool   `yaml:"signature_version2"`
+	// SSEEncryption enables SSE-S3 encryption. Deprecated: use SSEConfig instead.
 	SSEEncryption   bool              `yaml:"encrypt_sse"`
 	SecretKey       string            `yaml:"secret_key"`
 	PutUserMetadata map[string]string `yaml:"put_user_metadata"`
 	HTTPConfig      HTTPConfig        `yaml:"http_config"`
 	TraceConfig     TraceConfig       `yaml:"trace"`
+	SSEConfig       SSEConfig         `yaml:"sse_config"`
 	// PartSize used for multipart upload. Only used if uploaded object size is known and larger than configured PartSize.
 	PartSize uint64 `yaml:"part_size"`
 }
 
+// SSEConfig deals with the configuration of server-side encryption of uploaded objects.
+type SSEConfig struct {
+	// Type is one of SSE-S3, SSE-KMS or SSE-C. Empty means no server-side encryption.
+	Type string `yaml:"type"`
+	// KMSKeyID is ID, ARN or alias of the KMS key used with SSE-KMS. If empty, AWS managed key is used.
+	KMSKeyID string `yaml:"kms_key_id"`
+	// KMSEncryptionContext is encryption context used with SSE-KMS.
+	KMSEncryptionContext map[string]string `yaml:"kms_encryption_context"`
+	// EncryptionKey is a path to the file with 32 bytes key used with SSE-C.
+	EncryptionKey string `yaml:"encryption_key"`
+	// BucketKeyEnabled enables S3 Bucket Keys for SSE-KMS, which reduces the number of KMS requests.
+	BucketKeyEnabled bool `yaml:"bucket_key_enabled"`
+	// VerifyReads makes reads fail for objects not encrypted as configured.
+	VerifyReads bool `yaml:"verify_reads"`
+}
+
 type TraceConfig struct {
 	Enable bool `yaml:"enable"`
 }
@@ -79,8 +109,16 @@ type Bucket struct {
 	name            string
 	client          *minio.Client
 	sse             encrypt.ServerSide
+	verifySSE       func(http.Header) error
 	putUserMetadata map[string]string
 	partSize        uint64
+
+	// Used for requests not supported by the minio client.
+	endpoint   string
+	secure     bool
+	region     string
+	creds      *credentials.Credentials
+	httpClient *http.Client
 }
 
 // parseConfig unmarshals a buffer into a Config with default HTTPConfig values.
@@ -136,12 +174,120 @@ func NewBucketWithConfig(logger log.Logger, config Config, component string) (*B
 		}
 	}
 
-	client, err := minio.NewWithCredentials(config.Endpoint, credentials.NewChainCredentials(chain), !config.Insecure, config.Region)
+	sse, err := serverSideEncryption(config)
+	if err != nil {
+		return nil, err
+	}
+	bkt, err := NewBucketWithCredentials(logger, config, credentials.NewChainCredentials(chain), sse, component)
+	if err != nil {
+		return nil, err
+	}
+	if config.SSEConfig.VerifyReads {
+		bkt.verifySSE = sseVerifier(config)
+	}
+	return bkt, nil
+}
+
+// serverSideEncryption returns server-side encryption of uploaded objects, if any.
+func serverSideEncryption(config Config) (encrypt.ServerSide, error) {
+	if config.SSEEncryption {
+		return encrypt.NewSSE(), nil
+	}
+
+	switch config.SSEConfig.Type {
+	case SSES3:
+		return encrypt.NewSSE(), nil
+	case SSEKMS:
+		sse := kmsSSE{keyID: config.SSEConfig.KMSKeyID, bucketKey: config.SSEConfig.BucketKeyEnabled}
+		if len(config.SSEConfig.KMSEncryptionContext) > 0 {
+			b, err := json.Marshal(config.SSEConfig.KMSEncryptionContext)
+			if err != nil {
+				return nil, errors.Wrap(err, "marshal SSE-KMS encryption context")
+			}
+			sse.context = base64.StdEncoding.EncodeToString(b)
+		}
+		return sse, nil
+	case SSEC:
+		key, err := ioutil.ReadFile(config.SSEConfig.EncryptionKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "read SSE-C encryption key")
+		}
+		sse, err := encrypt.NewSSEC(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "initialize s3 client SSE-C")
+		}
+		return sse, nil
+	}
+	return nil, nil
+}
+
+// kmsSSE is SSE-KMS encryption. Unlike encrypt.NewSSEKMS it sends encryption context in the header expected by S3
+// and supports S3 Bucket Keys.
+type kmsSSE struct {
+	keyID     string
+	context   string
+	bucketKey bool
+}
+
+func (s kmsSSE) Type() encrypt.Type { return encrypt.KMS }
+
+func (s kmsSSE) Marshal(h http.Header) {
+	h.Set("X-Amz-Server-Side-Encryption", "aws:kms")
+	if s.keyID != "" {
+		h.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", s.keyID)
+	}
+	if s.context != "" {
+		h.Set("X-Amz-Server-Side-Encryption-Context", s.context)
+	}
+	if s.bucketKey {
+		h.Set("X-Amz-Server-Side-Encryption-Bucket-Key-Enabled", "true")
+	}
+}
+
+// sseVerifier returns function checking that headers of read object match configured server-side encryption.
+func sseVerifier(config Config) func(http.Header) error {
+	return func(h http.Header) error {
+		typ := config.SSEConfig.Type
+		if config.SSEEncryption {
+			typ = SSES3
+		}
+
+		switch typ {
+		case SSES3:
+			if got := h.Get("X-Amz-Server-Side-Encryption"); got != "AES256" {
+				return errors.Errorf("expected SSE-S3 encryption, got %q", got)
+			}
+		case SSEKMS:
+			if got := h.Get("X-Amz-Server-Side-Encryption"); got != "aws:kms" {
+				return errors.Errorf("expected SSE-KMS encryption, got %q", got)
+			}
+			// S3 returns ARN of the key, which can't be matched with an alias.
+			keyID := config.SSEConfig.KMSKeyID
+			if keyID == "" || strings.HasPrefix(keyID, "alias/") || strings.Contains(keyID, ":alias/") {
+				return nil
+			}
+			if got := h.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"); got != keyID && !strings.HasSuffix(got, "/"+keyID) {
+				return errors.Errorf("expected SSE-KMS encryption with key %s, got key %q", keyID, got)
+			}
+		case SSEC:
+			if got := h.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm"); got != "AES256" {
+				return errors.Errorf("expected SSE-C encryption, got %q", got)
+			}
+		}
+		return nil
+	}
+}
+
+// NewBucketWithCredentials returns a new Bucket using the provided s3 config values, except that given credentials
+// and server-side encryption are used instead of these configured. It allows providers built on top of
+// s3-compatible APIs to reuse this implementation.
+func NewBucketWithCredentials(logger log.Logger, config Config, creds *credentials.Credentials, sse encrypt.ServerSide, component string) (*Bucket, error) {
+	client, err := minio.NewWithCredentials(config.Endpoint, creds, !config.Insecure, config.Region)
 	if err != nil {
 		return nil, errors.Wrap(err, "initialize s3 client")
 	}
 	client.SetAppInfo(fmt.Sprintf("thanos-%s", component), fmt.Sprintf("%s (%s)", version.Version, runtime.Version()))
-	client.SetCustomTransport(&http.Transport{
+	transport := objstore.TracingTransport(&http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
 			Timeout:   30 * time.Second,
@@ -166,11 +312,7 @@ func NewBucketWithConfig(logger log.Logger, config Config, component string) (*B
 		DisableCompression: true,
 		TLSClientConfig:    &tls.Config{InsecureSkipVerify: config.HTTPConfig.InsecureSkipVerify},
 	})
-
-	var sse encrypt.ServerSide
-	if config.SSEEncryption {
-		sse = encrypt.NewSSE()
-	}
+	client.SetCustomTransport(transport)
 
 	if config.TraceConfig.Enable {
 		logWriter := log.NewStdlibAdapter(level.Debug(logger), log.MessageKey("s3TraceMsg"))
@@ -184,6 +326,11 @@ func NewBucketWithConfig(logger log.Logger, config Config, component string) (*B
 		sse:             sse,
 		putUserMetadata: config.PutUserMetadata,
 		partSize:        config.PartSize,
+		endpoint:        config.Endpoint,
+		secure:          !config.Insecure,
+		region:          config.Region,
+		creds:           creds,
+		httpClient:      &http.Client{Transport: transport},
 	}
 	return bkt, nil
 }
@@ -206,6 +353,28 @@ func validate(conf Config) error {
 	if conf.AccessKey != "" && conf.SecretKey == "" {
 		return errors.New("no s3 secret_key specified while access_key is present in config file; either both should be present in config or envvars/IAM should be used.")
 	}
+
+	if conf.SSEEncryption && conf.SSEConfig.Type != "" {
+		return errors.New("encrypt_sse can't be used together with sse_config; use sse_config only")
+	}
+	switch conf.SSEConfig.Type {
+	case "", SSES3, SSEKMS:
+	case SSEC:
+		if conf.SSEConfig.EncryptionKey == "" {
+			return errors.New("sse_config.encryption_key is required with SSE-C")
+		}
+		if conf.Insecure {
+			return errors.New("SSE-C requires HTTPS, insecure can't be used")
+		}
+	default:
+		return errors.Errorf("unsupported s3 server-side encryption type %q, expected one of %s, %s, %s", conf.SSEConfig.Type, SSES3, SSEKMS, SSEC)
+	}
+	if conf.SSEConfig.Type != SSEKMS && (conf.SSEConfig.KMSKeyID != "" || len(conf.SSEConfig.KMSEncryptionContext) > 0 || conf.SSEConfig.BucketKeyEnabled) {
+		return errors.New("sse_config.kms_key_id, kms_encryption_context and bucket_key_enabled can be used only with SSE-KMS")
+	}
+	if conf.SSEConfig.Type != SSEC && conf.SSEConfig.EncryptionKey != "" {
+		return errors.New("sse_config.encryption_key can be used only with SSE-C")
+	}
 	return nil
 }
 
@@ -274,6 +443,18 @@ func (b *Bucket) getRange(ctx context.Context, name string, off, length int64) (
 		return nil, err
 	}
 
+	if b.verifySSE != nil {
+		// Object info is known after the first request, so this does not send another one.
+		info, err := r.Stat()
+		if err == nil {
+			err = errors.Wrapf(b.verifySSE(info.Metadata), "verify server-side encryption of %s", name)
+		}
+		if err != nil {
+			runutil.CloseWithLogOnErr(b.logger, r, "s3 get range obj close")
+			return nil, err
+		}
+	}
+
 	return r, nil
 }
 
@@ -289,7 +470,7 @@ func (b *Bucket) GetRange(ctx context.Context, name string, off, length int64) (
 
 // Exists checks if the given object exists.
 func (b *Bucket) Exists(_ context.Context, name string) (bool, error) {
-	_, err := b.client.StatObject(b.name, name, minio.StatObjectOptions{})
+	_, err := b.client.StatObject(b.name, name, b.statOptions())
 	if err != nil {
 		if b.IsObjNotFoundErr(err) {
 			return false, nil
@@ -334,13 +515,18 @@ func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
 
 // ObjectSize returns the size of the specified object.
 func (b *Bucket) ObjectSize(_ context.Context, name string) (uint64, error) {
-	objInfo, err := b.client.StatObject(b.name, name, minio.StatObjectOptions{})
+	objInfo, err := b.client.StatObject(b.name, name, b.statOptions())
 	if err != nil {
 		return 0, err
 	}
 	return uint64(objInfo.Size), nil
 }
 
+// statOptions returns options for stat requests, which need the key of objects encrypted with customer provided key.
+func (b *Bucket) statOptions() minio.StatObjectOptions {
+	return minio.StatObjectOptions{GetObjectOptions: minio.GetObjectOptions{ServerSideEncryption: b.sse}}
+}
+
 // Delete removes the object with the given name.
 func (b *Bucket) Delete(_ context.Context, name string) error {
 	return b.client.RemoveObject(b.name, name)
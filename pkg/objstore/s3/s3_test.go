@@ -4,9 +4,18 @@
 package s3
 
 import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-kit/kit/log"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -136,3 +145,139 @@ http_config:
 	testutil.Ok(t, err)
 	testutil.Assert(t, cfg2.PartSize == 1024*1024*100, "when part size should be set to 100MiB")
 }
+
+func TestValidate_SSEConfig(t *testing.T) {
+	for _, tcase := range []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{name: "SSE-S3", input: "sse_config:\n  type: SSE-S3", ok: true},
+		{name: "deprecated encrypt_sse", input: "encrypt_sse: true", ok: true},
+		{name: "encrypt_sse with sse_config", input: "encrypt_sse: true\nsse_config:\n  type: SSE-S3"},
+		{name: "SSE-KMS", input: "sse_config:\n  type: SSE-KMS\n  kms_key_id: key\n  kms_encryption_context:\n    a: b\n  bucket_key_enabled: true", ok: true},
+		{name: "SSE-C", input: "sse_config:\n  type: SSE-C\n  encryption_key: /key", ok: true},
+		{name: "SSE-C without key", input: "sse_config:\n  type: SSE-C"},
+		{name: "SSE-C insecure", input: "insecure: true\nsse_config:\n  type: SSE-C\n  encryption_key: /key"},
+		{name: "bucket key without SSE-KMS", input: "sse_config:\n  type: SSE-S3\n  bucket_key_enabled: true"},
+		{name: "KMS key without SSE-KMS", input: "sse_config:\n  kms_key_id: key"},
+		{name: "unknown type", input: "sse_config:\n  type: SSE-X"},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			cfg, err := parseConfig([]byte("bucket: b\nendpoint: e\n" + tcase.input))
+			testutil.Ok(t, err)
+			err = validate(cfg)
+			if tcase.ok {
+				testutil.Ok(t, err)
+				return
+			}
+			testutil.NotOk(t, err)
+		})
+	}
+}
+
+func TestServerSideEncryption_BucketKey(t *testing.T) {
+	cfg := Config{SSEConfig: SSEConfig{
+		Type:                 SSEKMS,
+		KMSKeyID:             "key",
+		KMSEncryptionContext: map[string]string{"a": "b"},
+		BucketKeyEnabled:     true,
+	}}
+	sse, err := serverSideEncryption(cfg)
+	testutil.Ok(t, err)
+
+	h := http.Header{}
+	sse.Marshal(h)
+	testutil.Equals(t, "aws:kms", h.Get("X-Amz-Server-Side-Encryption"))
+	testutil.Equals(t, "key", h.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+	testutil.Equals(t, base64.StdEncoding.EncodeToString([]byte(`{"a":"b"}`)), h.Get("X-Amz-Server-Side-Encryption-Context"))
+	testutil.Equals(t, "true", h.Get("X-Amz-Server-Side-Encryption-Bucket-Key-Enabled"))
+
+	cfg.SSEConfig.BucketKeyEnabled = false
+	cfg.SSEConfig.KMSEncryptionContext = nil
+	sse, err = serverSideEncryption(cfg)
+	testutil.Ok(t, err)
+	h = http.Header{}
+	sse.Marshal(h)
+	testutil.Equals(t, "", h.Get("X-Amz-Server-Side-Encryption-Bucket-Key-Enabled"))
+	testutil.Equals(t, "", h.Get("X-Amz-Server-Side-Encryption-Context"))
+}
+
+func TestSSEVerifier(t *testing.T) {
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+
+	kms := sseVerifier(Config{SSEConfig: SSEConfig{Type: SSEKMS, KMSKeyID: "1234"}})
+	testutil.Ok(t, kms(header("X-Amz-Server-Side-Encryption", "aws:kms", "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", "arn:aws:kms:us-east-1:123:key/1234")))
+	testutil.NotOk(t, kms(header("X-Amz-Server-Side-Encryption", "aws:kms", "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", "arn:aws:kms:us-east-1:123:key/5678")))
+	testutil.NotOk(t, kms(header("X-Amz-Server-Side-Encryption", "AES256")))
+	testutil.NotOk(t, kms(header()))
+
+	alias := sseVerifier(Config{SSEConfig: SSEConfig{Type: SSEKMS, KMSKeyID: "alias/thanos"}})
+	testutil.Ok(t, alias(header("X-Amz-Server-Side-Encryption", "aws:kms", "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", "arn:aws:kms:us-east-1:123:key/1234")))
+
+	s3 := sseVerifier(Config{SSEEncryption: true})
+	testutil.Ok(t, s3(header("X-Amz-Server-Side-Encryption", "AES256")))
+	testutil.NotOk(t, s3(header()))
+
+	c := sseVerifier(Config{SSEConfig: SSEConfig{Type: SSEC}})
+	testutil.Ok(t, c(header("X-Amz-Server-Side-Encryption-Customer-Algorithm", "AES256")))
+	testutil.NotOk(t, c(header()))
+}
+
+func TestBucket_Versions(t *testing.T) {
+	var copySources []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Assert(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/"), "request not signed")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("key-marker") == "":
+			testutil.Equals(t, "/bucket/", r.URL.Path)
+			testutil.Equals(t, "01/", r.URL.Query().Get("prefix"))
+			_, _ = w.Write([]byte(`<ListVersionsResult>
+	<IsTruncated>true</IsTruncated><NextKeyMarker>01/index</NextKeyMarker><NextVersionIdMarker>v1</NextVersionIdMarker>
+	<DeleteMarker><Key>01/index</Key><VersionId>v2</VersionId><IsLatest>true</IsLatest><LastModified>2020-01-02T00:00:00.000Z</LastModified></DeleteMarker>
+	<Version><Key>01/index</Key><VersionId>v1</VersionId><IsLatest>false</IsLatest><LastModified>2020-01-01T00:00:00.000Z</LastModified><Size>10</Size></Version>
+</ListVersionsResult>`))
+		case r.Method == http.MethodGet:
+			testutil.Equals(t, "v1", r.URL.Query().Get("version-id-marker"))
+			_, _ = w.Write([]byte(`<ListVersionsResult>
+	<Version><Key>01/meta.json</Key><VersionId>v3</VersionId><IsLatest>true</IsLatest><LastModified>2020-01-01T00:00:00.000Z</LastModified><Size>5</Size></Version>
+</ListVersionsResult>`))
+		case r.Method == http.MethodPut:
+			testutil.Equals(t, "/bucket/01/index", r.URL.Path)
+			copySources = append(copySources, r.Header.Get("X-Amz-Copy-Source"))
+			_, _ = w.Write([]byte(`<CopyObjectResult></CopyObjectResult>`))
+		}
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	testutil.Ok(t, err)
+	b, err := NewBucketWithCredentials(log.NewNopLogger(), Config{
+		Bucket:   "bucket",
+		Endpoint: u.Host,
+		Region:   "eu-west-1",
+		Insecure: true,
+	}, credentials.NewStaticV4("key", "secret", ""), nil, "test")
+	testutil.Ok(t, err)
+
+	var versions []objstore.ObjectVersion
+	testutil.Ok(t, b.IterVersions(context.Background(), "01/", func(v objstore.ObjectVersion) error {
+		versions = append(versions, v)
+		return nil
+	}))
+	testutil.Equals(t, []objstore.ObjectVersion{
+		{Name: "01/index", VersionID: "v2", LastModified: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), Latest: true, DeleteMarker: true},
+		{Name: "01/index", VersionID: "v1", LastModified: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Size: 10},
+		{Name: "01/meta.json", VersionID: "v3", LastModified: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Size: 5, Latest: true},
+	}, versions)
+
+	testutil.NotOk(t, b.RestoreVersion(context.Background(), versions[0]))
+	testutil.Ok(t, b.RestoreVersion(context.Background(), versions[1]))
+	testutil.Equals(t, []string{"/bucket/01/index?versionId=v1"}, copySources)
+}
@@ -0,0 +1,161 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/minio/minio-go/v6/pkg/encrypt"
+	"github.com/minio/minio-go/v6/pkg/s3signer"
+	"github.com/minio/minio-go/v6/pkg/s3utils"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// emptySHA256 is the SHA-256 of empty request payload.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+var _ objstore.VersionedBucket = &Bucket{}
+
+type listVersionsResult struct {
+	IsTruncated         bool
+	NextKeyMarker       string
+	NextVersionIdMarker string
+
+	Versions      []objectVersion `xml:"Version"`
+	DeleteMarkers []objectVersion `xml:"DeleteMarker"`
+}
+
+type objectVersion struct {
+	Key          string
+	VersionID    string `xml:"VersionId"`
+	IsLatest     bool
+	LastModified time.Time
+	Size         int64
+}
+
+// IterVersions calls f for each version and delete marker of objects with the given prefix. Versions are kept by
+// buckets with versioning enabled.
+// The minio client does not support listing object versions, so the request is made directly.
+func (b *Bucket) IterVersions(ctx context.Context, prefix string, f func(objstore.ObjectVersion) error) error {
+	keyMarker, versionMarker := "", ""
+	for {
+		q := url.Values{"versions": {""}, "prefix": {prefix}}
+		if keyMarker != "" {
+			q.Set("key-marker", keyMarker)
+			q.Set("version-id-marker", versionMarker)
+		}
+		var res listVersionsResult
+		if err := b.do(ctx, http.MethodGet, "", q, nil, func(body io.Reader) error {
+			return xml.NewDecoder(body).Decode(&res)
+		}); err != nil {
+			return errors.Wrap(err, "list object versions")
+		}
+
+		versions := make([]objstore.ObjectVersion, 0, len(res.Versions)+len(res.DeleteMarkers))
+		for _, v := range res.Versions {
+			versions = append(versions, v.objstoreVersion(false))
+		}
+		for _, v := range res.DeleteMarkers {
+			versions = append(versions, v.objstoreVersion(true))
+		}
+		// Versions and delete markers of the same key are listed together, newest first.
+		sort.SliceStable(versions, func(i, j int) bool {
+			if versions[i].Name != versions[j].Name {
+				return versions[i].Name < versions[j].Name
+			}
+			return versions[i].LastModified.After(versions[j].LastModified)
+		})
+		for _, v := range versions {
+			if err := f(v); err != nil {
+				return err
+			}
+		}
+
+		if !res.IsTruncated {
+			return nil
+		}
+		keyMarker, versionMarker = res.NextKeyMarker, res.NextVersionIdMarker
+	}
+}
+
+func (v objectVersion) objstoreVersion(deleteMarker bool) objstore.ObjectVersion {
+	return objstore.ObjectVersion{
+		Name:         v.Key,
+		VersionID:    v.VersionID,
+		LastModified: v.LastModified,
+		Size:         v.Size,
+		Latest:       v.IsLatest,
+		DeleteMarker: deleteMarker,
+	}
+}
+
+// RestoreVersion copies the given version of the object over its current version or delete marker.
+func (b *Bucket) RestoreVersion(ctx context.Context, v objstore.ObjectVersion) error {
+	if v.DeleteMarker {
+		return errors.Errorf("version %s of %s is a delete marker", v.VersionID, v.Name)
+	}
+	h := http.Header{}
+	h.Set("X-Amz-Copy-Source", s3utils.EncodePath("/"+b.name+"/"+v.Name)+"?versionId="+url.QueryEscape(v.VersionID))
+	if b.sse != nil {
+		b.sse.Marshal(h)
+		if b.sse.Type() == encrypt.SSEC {
+			encrypt.SSECopy(b.sse).Marshal(h)
+		}
+	}
+	return errors.Wrapf(b.do(ctx, http.MethodPut, v.Name, nil, h, nil), "copy version %s of %s", v.VersionID, v.Name)
+}
+
+// do makes the signed request against the bucket and passes the response body of successful request to handle.
+func (b *Bucket) do(ctx context.Context, method, object string, q url.Values, h http.Header, handle func(io.Reader) error) error {
+	region := b.region
+	if region == "" {
+		var err error
+		if region, err = b.client.GetBucketLocation(b.name); err != nil {
+			return errors.Wrap(err, "get bucket location")
+		}
+	}
+	creds, err := b.creds.Get()
+	if err != nil {
+		return errors.Wrap(err, "get credentials")
+	}
+
+	scheme := "https"
+	if !b.secure {
+		scheme = "http"
+	}
+	u := url.URL{Scheme: scheme, Host: b.endpoint, Path: "/" + b.name + "/" + object, RawQuery: q.Encode()}
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	req = s3signer.SignV4(*req, creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, region)
+
+	resp, err := b.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(b.logger, resp.Body, "close response body")
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		return errors.Errorf("unexpected response %s: %s", resp.Status, body)
+	}
+	if handle == nil {
+		return nil
+	}
+	return handle(resp.Body)
+}
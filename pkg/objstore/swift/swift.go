@@ -5,14 +5,19 @@
 package swift
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack"
 	"github.com/gophercloud/gophercloud/openstack/objectstorage/v1/containers"
@@ -27,26 +32,46 @@ import (
 const DirDelim = "/"
 
 type SwiftConfig struct {
-	AuthUrl           string `yaml:"auth_url"`
-	Username          string `yaml:"username"`
-	UserDomainName    string `yaml:"user_domain_name"`
-	UserDomainID      string `yaml:"user_domain_id"`
-	UserId            string `yaml:"user_id"`
-	Password          string `yaml:"password"`
-	DomainId          string `yaml:"domain_id"`
-	DomainName        string `yaml:"domain_name"`
-	ProjectID         string `yaml:"project_id"`
-	ProjectName       string `yaml:"project_name"`
-	ProjectDomainID   string `yaml:"project_domain_id"`
-	ProjectDomainName string `yaml:"project_domain_name"`
-	RegionName        string `yaml:"region_name"`
-	ContainerName     string `yaml:"container_name"`
+	AuthUrl                     string `yaml:"auth_url"`
+	Username                    string `yaml:"username"`
+	UserDomainName              string `yaml:"user_domain_name"`
+	UserDomainID                string `yaml:"user_domain_id"`
+	UserId                      string `yaml:"user_id"`
+	Password                    string `yaml:"password"`
+	DomainId                    string `yaml:"domain_id"`
+	DomainName                  string `yaml:"domain_name"`
+	ApplicationCredentialID     string `yaml:"application_credential_id"`
+	ApplicationCredentialName   string `yaml:"application_credential_name"`
+	ApplicationCredentialSecret string `yaml:"application_credential_secret"`
+	ProjectID                   string `yaml:"project_id"`
+	ProjectName                 string `yaml:"project_name"`
+	ProjectDomainID             string `yaml:"project_domain_id"`
+	ProjectDomainName           string `yaml:"project_domain_name"`
+	RegionName                  string `yaml:"region_name"`
+	ContainerName               string `yaml:"container_name"`
+	// LargeObjectSegmentSize is the size of segments of objects uploaded as large objects, which are all objects bigger
+	// than this size. It has to be lower than the maximum object size of the cluster, 5GiB by default.
+	LargeObjectSegmentSize uint64 `yaml:"large_object_segment_size"`
+	// LargeObjectSegmentsContainer is the container segments are uploaded to, <container_name>_segments by default.
+	LargeObjectSegmentsContainer string `yaml:"large_object_segments_container"`
+	// UseDynamicLargeObjects makes large objects uploaded as dynamic instead of static large objects, for clusters
+	// without the SLO middleware.
+	UseDynamicLargeObjects bool `yaml:"use_dynamic_large_objects"`
+}
+
+// DefaultConfig is the default Swift configuration.
+var DefaultConfig = SwiftConfig{
+	LargeObjectSegmentSize: 1024 * 1024 * 1024,
 }
 
 type Container struct {
 	logger log.Logger
 	client *gophercloud.ServiceClient
 	name   string
+
+	segmentSize       uint64
+	segmentsContainer string
+	useDLO            bool
 }
 
 func NewContainer(logger log.Logger, conf []byte) (*Container, error) {
@@ -72,10 +97,17 @@ func NewContainer(logger log.Logger, conf []byte) (*Container, error) {
 		return nil, err
 	}
 
+	segmentsContainer := sc.LargeObjectSegmentsContainer
+	if segmentsContainer == "" {
+		segmentsContainer = sc.ContainerName + "_segments"
+	}
 	return &Container{
-		logger: logger,
-		client: client,
-		name:   sc.ContainerName,
+		logger:            logger,
+		client:            client,
+		name:              sc.ContainerName,
+		segmentSize:       sc.LargeObjectSegmentSize,
+		segmentsContainer: segmentsContainer,
+		useDLO:            sc.UseDynamicLargeObjects,
 	}, nil
 }
 
@@ -158,27 +190,131 @@ func (c *Container) IsObjNotFoundErr(err error) bool {
 	return ok
 }
 
-// Upload writes the contents of the reader as an object into the container.
+// Upload writes the contents of the reader as an object into the container. Objects bigger than the segment size
+// are uploaded as large objects.
 func (c *Container) Upload(ctx context.Context, name string, r io.Reader) error {
-	options := &objects.CreateOpts{Content: r}
-	res := objects.Create(c.client, c.name, name, options)
-	return res.Err
+	size, err := objstore.TryToGetSize(r)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "could not guess file size, using large object upload", "name", name, "err", err)
+		size = -1
+	}
+	if size >= 0 && uint64(size) <= c.segmentSize {
+		return objects.Create(c.client, c.name, name, &objects.CreateOpts{Content: r}).Err
+	}
+	return c.uploadLargeObject(name, r)
+}
+
+// sloSegment is an entry of the static large object manifest.
+type sloSegment struct {
+	Path      string `json:"path"`
+	Etag      string `json:"etag"`
+	SizeBytes int64  `json:"size_bytes"`
 }
 
-// Delete removes the object with the given name.
+// uploadLargeObject uploads content in segments to the segments container and then the manifest object linking them.
+func (c *Container) uploadLargeObject(name string, r io.Reader) error {
+	if err := containers.Create(c.client, c.segmentsContainer, nil).Err; err != nil {
+		return errors.Wrapf(err, "create segments container %s", c.segmentsContainer)
+	}
+
+	// Segments of every upload have unique prefix, so an overwritten object does not reference segments of another one.
+	prefix := fmt.Sprintf("%s/%d/", name, time.Now().UnixNano())
+	br := bufio.NewReader(r)
+	var segments []sloSegment
+	for i := 0; ; i++ {
+		if _, err := br.Peek(1); err == io.EOF {
+			// Empty object has no segments, but the manifest can't be empty.
+			if i > 0 {
+				break
+			}
+		} else if err != nil {
+			return errors.Wrap(err, "read content")
+		}
+		segment := fmt.Sprintf("%s%08d", prefix, i)
+		lr := &io.LimitedReader{R: br, N: int64(c.segmentSize)}
+		res := objects.Create(c.client, c.segmentsContainer, segment, &objects.CreateOpts{Content: lr})
+		h, err := res.Extract()
+		if err != nil {
+			return errors.Wrapf(err, "upload segment %s", segment)
+		}
+		segments = append(segments, sloSegment{
+			Path:      "/" + c.segmentsContainer + "/" + segment,
+			Etag:      h.ETag,
+			SizeBytes: int64(c.segmentSize) - lr.N,
+		})
+	}
+
+	if c.useDLO {
+		return errors.Wrap(objects.Create(c.client, c.name, name, &objects.CreateOpts{
+			Content:        bytes.NewReader(nil),
+			ObjectManifest: c.segmentsContainer + "/" + prefix,
+		}).Err, "upload dynamic large object manifest")
+	}
+	manifest, err := json.Marshal(segments)
+	if err != nil {
+		return errors.Wrap(err, "encode static large object manifest")
+	}
+	return errors.Wrap(objects.Create(c.client, c.name, name, &objects.CreateOpts{
+		Content:           bytes.NewReader(manifest),
+		MultipartManifest: "put",
+	}).Err, "upload static large object manifest")
+}
+
+// Delete removes the object with the given name, including segments of large objects.
 func (c *Container) Delete(ctx context.Context, name string) error {
+	h, err := objects.Get(c.client, c.name, name, nil).Extract()
+	if err != nil {
+		return err
+	}
+	switch {
+	case h.StaticLargeObject:
+		return objects.Delete(c.client, c.name, name, objects.DeleteOpts{MultipartManifest: "delete"}).Err
+	case h.ObjectManifest != "":
+		if err := objects.Delete(c.client, c.name, name, nil).Err; err != nil {
+			return err
+		}
+		return c.deleteSegments(h.ObjectManifest)
+	}
 	return objects.Delete(c.client, c.name, name, nil).Err
 }
 
+// deleteSegments removes segments of dynamic large object with the given <container>/<prefix> manifest.
+func (c *Container) deleteSegments(manifest string) error {
+	parts := strings.SplitN(manifest, "/", 2)
+	if len(parts) != 2 {
+		return errors.Errorf("invalid dynamic large object manifest %q", manifest)
+	}
+	container, prefix := parts[0], parts[1]
+	return objects.List(c.client, container, &objects.ListOpts{Full: false, Prefix: prefix}).EachPage(func(page pagination.Page) (bool, error) {
+		names, err := objects.ExtractNames(page)
+		if err != nil {
+			return false, err
+		}
+		for _, segment := range names {
+			if err := objects.Delete(c.client, container, segment, nil).Err; err != nil {
+				if _, ok := err.(gophercloud.ErrDefault404); !ok {
+					return false, errors.Wrapf(err, "delete segment %s", segment)
+				}
+			}
+		}
+		return true, nil
+	})
+}
+
 func (*Container) Close() error {
 	// Nothing to close.
 	return nil
 }
 
 func parseConfig(conf []byte) (*SwiftConfig, error) {
-	var sc SwiftConfig
-	err := yaml.UnmarshalStrict(conf, &sc)
-	return &sc, err
+	sc := DefaultConfig
+	if err := yaml.UnmarshalStrict(conf, &sc); err != nil {
+		return &sc, err
+	}
+	if sc.LargeObjectSegmentSize == 0 {
+		return &sc, errors.New("large_object_segment_size has to be positive")
+	}
+	return &sc, nil
 }
 
 func authOptsFromConfig(sc *SwiftConfig) (gophercloud.AuthOptions, error) {
@@ -192,6 +328,10 @@ func authOptsFromConfig(sc *SwiftConfig) (gophercloud.AuthOptions, error) {
 		TenantID:         sc.ProjectID,
 		TenantName:       sc.ProjectName,
 
+		ApplicationCredentialID:     sc.ApplicationCredentialID,
+		ApplicationCredentialName:   sc.ApplicationCredentialName,
+		ApplicationCredentialSecret: sc.ApplicationCredentialSecret,
+
 		// Allow Gophercloud to re-authenticate automatically.
 		AllowReauth: true,
 	}
@@ -237,30 +377,36 @@ func (c *Container) deleteContainer(name string) error {
 }
 
 func configFromEnv() SwiftConfig {
-	c := SwiftConfig{
-		AuthUrl:           os.Getenv("OS_AUTH_URL"),
-		Username:          os.Getenv("OS_USERNAME"),
-		Password:          os.Getenv("OS_PASSWORD"),
-		RegionName:        os.Getenv("OS_REGION_NAME"),
-		ContainerName:     os.Getenv("OS_CONTAINER_NAME"),
-		ProjectID:         os.Getenv("OS_PROJECT_ID"),
-		ProjectName:       os.Getenv("OS_PROJECT_NAME"),
-		UserDomainID:      os.Getenv("OS_USER_DOMAIN_ID"),
-		UserDomainName:    os.Getenv("OS_USER_DOMAIN_NAME"),
-		ProjectDomainID:   os.Getenv("OS_PROJET_DOMAIN_ID"),
-		ProjectDomainName: os.Getenv("OS_PROJECT_DOMAIN_NAME"),
-	}
+	c := DefaultConfig
+	c.AuthUrl = os.Getenv("OS_AUTH_URL")
+	c.Username = os.Getenv("OS_USERNAME")
+	c.Password = os.Getenv("OS_PASSWORD")
+	c.ApplicationCredentialID = os.Getenv("OS_APPLICATION_CREDENTIAL_ID")
+	c.ApplicationCredentialName = os.Getenv("OS_APPLICATION_CREDENTIAL_NAME")
+	c.ApplicationCredentialSecret = os.Getenv("OS_APPLICATION_CREDENTIAL_SECRET")
+	c.RegionName = os.Getenv("OS_REGION_NAME")
+	c.ContainerName = os.Getenv("OS_CONTAINER_NAME")
+	c.ProjectID = os.Getenv("OS_PROJECT_ID")
+	c.ProjectName = os.Getenv("OS_PROJECT_NAME")
+	c.UserDomainID = os.Getenv("OS_USER_DOMAIN_ID")
+	c.UserDomainName = os.Getenv("OS_USER_DOMAIN_NAME")
+	c.ProjectDomainID = os.Getenv("OS_PROJET_DOMAIN_ID")
+	c.ProjectDomainName = os.Getenv("OS_PROJECT_DOMAIN_NAME")
 
 	return c
 }
 
 // validateForTests checks to see the config options for tests are set.
 func validateForTests(conf SwiftConfig) error {
-	if conf.AuthUrl == "" ||
-		conf.Username == "" ||
+	if conf.AuthUrl == "" || conf.RegionName == "" {
+		return errors.New("insufficient swift test configuration information")
+	}
+	if conf.ApplicationCredentialID != "" && conf.ApplicationCredentialSecret != "" {
+		return nil
+	}
+	if conf.Username == "" ||
 		conf.Password == "" ||
-		(conf.ProjectName == "" && conf.ProjectID == "") ||
-		conf.RegionName == "" {
+		(conf.ProjectName == "" && conf.ProjectID == "") {
 		return errors.New("insufficient swift test configuration information")
 	}
 	return nil
@@ -309,6 +455,7 @@ func NewTestContainer(t testing.TB) (objstore.Bucket, func(), error) {
 	}
 
 	c.name = tmpContainerName
+	c.segmentsContainer = tmpContainerName + "_segments"
 	t.Log("created temporary container for swift tests with name", tmpContainerName)
 
 	return c, func() {
@@ -316,5 +463,11 @@ func NewTestContainer(t testing.TB) (objstore.Bucket, func(), error) {
 		if err := c.deleteContainer(tmpContainerName); err != nil {
 			t.Logf("deleting container %s failed: %s", tmpContainerName, err)
 		}
+		// Segments container is created only by large object uploads.
+		if err := c.deleteContainer(c.segmentsContainer); err != nil {
+			if _, ok := err.(gophercloud.ErrDefault404); !ok {
+				t.Logf("deleting container %s failed: %s", c.segmentsContainer, err)
+			}
+		}
 	}, nil
 }
@@ -4,8 +4,20 @@
 package swift
 
 import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/go-kit/kit/log"
+	"github.com/gophercloud/gophercloud"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -24,6 +36,29 @@ project_domain_name: projectDomain`)
 	testutil.Equals(t, "userDomain", cfg.UserDomainName)
 	testutil.Equals(t, "thanosProject", cfg.ProjectName)
 	testutil.Equals(t, "projectDomain", cfg.ProjectDomainName)
+	testutil.Equals(t, DefaultConfig.LargeObjectSegmentSize, cfg.LargeObjectSegmentSize)
+	testutil.Equals(t, false, cfg.UseDynamicLargeObjects)
+}
+
+func TestParseConfig_LargeObjects(t *testing.T) {
+	input := []byte(`auth_url: http://identity.something.com/v3
+application_credential_id: appCredID
+application_credential_secret: appCredSecret
+large_object_segment_size: 104857600
+large_object_segments_container: segments
+use_dynamic_large_objects: true`)
+
+	cfg, err := parseConfig(input)
+	testutil.Ok(t, err)
+
+	testutil.Equals(t, "appCredID", cfg.ApplicationCredentialID)
+	testutil.Equals(t, "appCredSecret", cfg.ApplicationCredentialSecret)
+	testutil.Equals(t, uint64(104857600), cfg.LargeObjectSegmentSize)
+	testutil.Equals(t, "segments", cfg.LargeObjectSegmentsContainer)
+	testutil.Equals(t, true, cfg.UseDynamicLargeObjects)
+
+	_, err = parseConfig([]byte(`large_object_segment_size: 0`))
+	testutil.NotOk(t, err)
 }
 
 func TestParseConfigFail(t *testing.T) {
@@ -53,3 +88,134 @@ func TestAuthOptsFromConfig(t *testing.T) {
 	testutil.Equals(t, "projectDomain", authOpts.Scope.DomainName)
 	testutil.Equals(t, "thanosProject", authOpts.Scope.ProjectName)
 }
+
+func TestAuthOptsFromConfig_ApplicationCredentials(t *testing.T) {
+	input := &SwiftConfig{
+		AuthUrl:                     "http://identity.something.com/v3",
+		ApplicationCredentialName:   "appCred",
+		ApplicationCredentialSecret: "appCredSecret",
+		Username:                    "thanos",
+		UserDomainName:              "userDomain",
+	}
+
+	authOpts, err := authOptsFromConfig(input)
+	testutil.Ok(t, err)
+
+	testutil.Equals(t, "appCred", authOpts.ApplicationCredentialName)
+	testutil.Equals(t, "appCredSecret", authOpts.ApplicationCredentialSecret)
+	testutil.Equals(t, "thanos", authOpts.Username)
+	testutil.Equals(t, "userDomain", authOpts.DomainName)
+}
+
+// fakeSwift is a minimal Swift API server keeping objects in memory, with large object manifests.
+type fakeSwift struct {
+	mtx       sync.Mutex
+	objects   map[string][]byte
+	manifests map[string]string
+	slo       map[string]bool
+}
+
+func (s *fakeSwift) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	parts := strings.SplitN(name, "/", 2)
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		w.WriteHeader(http.StatusCreated)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		var names []string
+		for n := range s.objects {
+			if p := parts[0] + "/" + r.URL.Query().Get("prefix"); strings.HasPrefix(n, p) {
+				names = append(names, strings.TrimPrefix(n, parts[0]+"/"))
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Join(names, "\n")))
+	case r.Method == http.MethodPut:
+		b, _ := ioutil.ReadAll(r.Body)
+		sum := md5.Sum(b)
+		s.objects[name] = b
+		s.slo[name] = r.URL.Query().Get("multipart-manifest") == "put"
+		s.manifests[name] = r.Header.Get("X-Object-Manifest")
+		w.Header().Set("ETag", hex.EncodeToString(sum[:]))
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodHead:
+		if _, ok := s.objects[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if s.slo[name] {
+			w.Header().Set("X-Static-Large-Object", "True")
+		}
+		if m := s.manifests[name]; m != "" {
+			w.Header().Set("X-Object-Manifest", m)
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete:
+		if _, ok := s.objects[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if s.slo[name] && r.URL.Query().Get("multipart-manifest") == "delete" {
+			var segments []sloSegment
+			_ = json.Unmarshal(s.objects[name], &segments)
+			for _, seg := range segments {
+				delete(s.objects, strings.TrimPrefix(seg.Path, "/"))
+			}
+		}
+		delete(s.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestContainer_LargeObjects(t *testing.T) {
+	for _, useDLO := range []bool{false, true} {
+		fake := &fakeSwift{objects: map[string][]byte{}, manifests: map[string]string{}, slo: map[string]bool{}}
+		srv := httptest.NewServer(fake)
+
+		c := &Container{
+			logger: log.NewNopLogger(),
+			client: &gophercloud.ServiceClient{
+				ProviderClient: &gophercloud.ProviderClient{HTTPClient: *srv.Client()},
+				Endpoint:       srv.URL + "/",
+			},
+			name:              "thanos",
+			segmentSize:       4,
+			segmentsContainer: "thanos_segments",
+			useDLO:            useDLO,
+		}
+		ctx := context.Background()
+
+		// Objects not bigger than the segment size are uploaded as they are.
+		testutil.Ok(t, c.Upload(ctx, "small", bytes.NewReader([]byte("abcd"))))
+		testutil.Equals(t, []byte("abcd"), fake.objects["thanos/small"])
+
+		testutil.Ok(t, c.Upload(ctx, "large", bytes.NewReader([]byte("abcdefghij"))))
+		var segments []string
+		for name, b := range fake.objects {
+			if strings.HasPrefix(name, "thanos_segments/large/") {
+				segments = append(segments, string(b))
+			}
+		}
+		testutil.Equals(t, 3, len(segments))
+		if useDLO {
+			testutil.Assert(t, strings.HasPrefix(fake.manifests["thanos/large"], "thanos_segments/large/"), "expected dynamic large object manifest")
+		} else {
+			var manifest []sloSegment
+			testutil.Ok(t, json.Unmarshal(fake.objects["thanos/large"], &manifest))
+			testutil.Equals(t, 3, len(manifest))
+			testutil.Equals(t, []int64{4, 4, 2}, []int64{manifest[0].SizeBytes, manifest[1].SizeBytes, manifest[2].SizeBytes})
+		}
+
+		// Deleting large object removes its segments too.
+		testutil.Ok(t, c.Delete(ctx, "large"))
+		testutil.Ok(t, c.Delete(ctx, "small"))
+		testutil.Equals(t, 0, len(fake.objects))
+
+		srv.Close()
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/thanos-io/thanos/pkg/tracing"
+)
+
+// BucketWithTracing returns a bucket starting a span for every operation against the given bucket. Spans are children
+// of the span found in the operation context, so for example object storage requests made by store gateway while
+// serving a query are part of the query trace.
+func BucketWithTracing(b Bucket) Bucket {
+	return &tracingBucket{bkt: b}
+}
+
+type tracingBucket struct {
+	bkt Bucket
+}
+
+func (t *tracingBucket) startSpan(ctx context.Context, op string, tags opentracing.Tags) (opentracing.Span, context.Context) {
+	tags["objstore.bucket"] = t.bkt.Name()
+	return tracing.StartSpan(ctx, "bucket_"+op, tags)
+}
+
+// finishSpan marks the span as failed unless err is nil or a not found error, and finishes it.
+func (t *tracingBucket) finishSpan(span opentracing.Span, err error) {
+	if err != nil && err != io.EOF {
+		if t.bkt.IsObjNotFoundErr(err) {
+			span.SetTag("objstore.not_found", true)
+		} else {
+			ext.Error.Set(span, true)
+			span.LogKV("error", err.Error())
+		}
+	}
+	span.Finish()
+}
+
+func (t *tracingBucket) Iter(ctx context.Context, dir string, f func(name string) error) (err error) {
+	span, ctx := t.startSpan(ctx, iterOp, opentracing.Tags{"objstore.prefix": dir})
+	defer func() { t.finishSpan(span, err) }()
+
+	objects := 0
+	err = t.bkt.Iter(ctx, dir, func(name string) error {
+		objects++
+		return f(name)
+	})
+	span.SetTag("objstore.objects", objects)
+	return err
+}
+
+func (t *tracingBucket) ObjectSize(ctx context.Context, name string) (_ uint64, err error) {
+	span, ctx := t.startSpan(ctx, sizeOp, opentracing.Tags{"objstore.name": name})
+	defer func() { t.finishSpan(span, err) }()
+
+	return t.bkt.ObjectSize(ctx, name)
+}
+
+func (t *tracingBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	span, ctx := t.startSpan(ctx, getOp, opentracing.Tags{"objstore.name": name})
+	rc, err := t.bkt.Get(ctx, name)
+	if err != nil {
+		t.finishSpan(span, err)
+		return nil, err
+	}
+	return &tracingReadCloser{ReadCloser: rc, bkt: t, span: span}, nil
+}
+
+func (t *tracingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	span, ctx := t.startSpan(ctx, getRangeOp, opentracing.Tags{
+		"objstore.name":   name,
+		"objstore.offset": off,
+		"objstore.length": length,
+	})
+	rc, err := t.bkt.GetRange(ctx, name, off, length)
+	if err != nil {
+		t.finishSpan(span, err)
+		return nil, err
+	}
+	return &tracingReadCloser{ReadCloser: rc, bkt: t, span: span}, nil
+}
+
+func (t *tracingBucket) Exists(ctx context.Context, name string) (_ bool, err error) {
+	span, ctx := t.startSpan(ctx, existsOp, opentracing.Tags{"objstore.name": name})
+	defer func() { t.finishSpan(span, err) }()
+
+	return t.bkt.Exists(ctx, name)
+}
+
+func (t *tracingBucket) Upload(ctx context.Context, name string, r io.Reader) (err error) {
+	span, ctx := t.startSpan(ctx, uploadOp, opentracing.Tags{"objstore.name": name})
+	defer func() { t.finishSpan(span, err) }()
+
+	// The reader is not wrapped, as providers and retries depend on its type.
+	if size, err := TryToGetSize(r); err == nil {
+		span.SetTag("objstore.bytes", size)
+	}
+	return t.bkt.Upload(ctx, name, r)
+}
+
+func (t *tracingBucket) Delete(ctx context.Context, name string) (err error) {
+	span, ctx := t.startSpan(ctx, deleteOp, opentracing.Tags{"objstore.name": name})
+	defer func() { t.finishSpan(span, err) }()
+
+	return t.bkt.Delete(ctx, name)
+}
+
+func (t *tracingBucket) IsObjNotFoundErr(err error) bool {
+	return t.bkt.IsObjNotFoundErr(err)
+}
+
+func (t *tracingBucket) Close() error {
+	return t.bkt.Close()
+}
+
+func (t *tracingBucket) Name() string {
+	return t.bkt.Name()
+}
+
+// tracingReadCloser counts read bytes and finishes the span of the operation on close.
+type tracingReadCloser struct {
+	io.ReadCloser
+
+	bkt   *tracingBucket
+	span  opentracing.Span
+	bytes int64
+	err   error
+}
+
+func (rc *tracingReadCloser) Read(b []byte) (int, error) {
+	n, err := rc.ReadCloser.Read(b)
+	rc.bytes += int64(n)
+	if err != nil && err != io.EOF && rc.err == nil {
+		rc.err = err
+	}
+	return n, err
+}
+
+func (rc *tracingReadCloser) Close() error {
+	err := rc.ReadCloser.Close()
+	if rc.span == nil {
+		return err
+	}
+	if rc.err == nil {
+		rc.err = err
+	}
+	rc.span.SetTag("objstore.bytes", rc.bytes)
+	rc.bkt.finishSpan(rc.span, rc.err)
+	rc.span = nil
+	return err
+}
+
+// TracingTransport returns HTTP round tripper recording status codes of provider responses in the span found in the
+// request context, which is the operation span when requests are made through a bucket wrapped with BucketWithTracing.
+// Retried requests are logged as separate span events.
+func TracingTransport(next http.RoundTripper) http.RoundTripper {
+	return &tracingTransport{next: next}
+}
+
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(r)
+
+	span := opentracing.SpanFromContext(r.Context())
+	if span == nil {
+		return resp, err
+	}
+	if err != nil {
+		span.LogKV("http.method", r.Method, "error", err.Error())
+		return resp, err
+	}
+	ext.HTTPStatusCode.Set(span, uint16(resp.StatusCode))
+	span.LogKV("http.method", r.Method, "http.status_code", resp.StatusCode)
+	return resp, err
+}
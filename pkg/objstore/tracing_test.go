@@ -0,0 +1,86 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"github.com/thanos-io/thanos/pkg/tracing"
+)
+
+func TestBucketWithTracing(t *testing.T) {
+	tracer := mocktracer.New()
+	parent := tracer.StartSpan("query")
+	ctx := opentracing.ContextWithSpan(tracing.ContextWithTracer(context.Background(), tracer), parent)
+
+	bkt := objstore.BucketWithTracing(inmem.NewBucket())
+	testutil.Ok(t, bkt.Upload(ctx, "dir/obj", bytes.NewReader([]byte("content"))))
+
+	rc, err := bkt.GetRange(ctx, "dir/obj", 2, 3)
+	testutil.Ok(t, err)
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "nte", string(b))
+	testutil.Equals(t, 1, len(tracer.FinishedSpans()))
+	testutil.Ok(t, rc.Close())
+
+	_, err = bkt.Get(ctx, "dir/not-existing")
+	testutil.Assert(t, bkt.IsObjNotFoundErr(err), "expected not found error")
+
+	testutil.Ok(t, bkt.Iter(ctx, "dir/", func(string) error { return nil }))
+
+	spans := tracer.FinishedSpans()
+	testutil.Equals(t, 4, len(spans))
+	for _, s := range spans {
+		testutil.Equals(t, parent.Context().(mocktracer.MockSpanContext).SpanID, s.ParentID)
+		testutil.Equals(t, "inmem", s.Tag("objstore.bucket"))
+	}
+
+	testutil.Equals(t, "bucket_upload", spans[0].OperationName)
+	testutil.Equals(t, int64(7), spans[0].Tag("objstore.bytes"))
+
+	testutil.Equals(t, "bucket_get_range", spans[1].OperationName)
+	testutil.Equals(t, "dir/obj", spans[1].Tag("objstore.name"))
+	testutil.Equals(t, int64(2), spans[1].Tag("objstore.offset"))
+	testutil.Equals(t, int64(3), spans[1].Tag("objstore.length"))
+	testutil.Equals(t, int64(3), spans[1].Tag("objstore.bytes"))
+
+	testutil.Equals(t, "bucket_get", spans[2].OperationName)
+	testutil.Equals(t, true, spans[2].Tag("objstore.not_found"))
+	testutil.Equals(t, nil, spans[2].Tag("error"))
+
+	testutil.Equals(t, "bucket_iter", spans[3].OperationName)
+	testutil.Equals(t, "dir/", spans[3].Tag("objstore.prefix"))
+	testutil.Equals(t, 1, spans[3].Tag("objstore.objects"))
+}
+
+func TestTracingTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	tracer := mocktracer.New()
+	span := tracer.StartSpan("bucket_get")
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	testutil.Ok(t, err)
+	resp, err := objstore.TracingTransport(http.DefaultTransport).RoundTrip(req.WithContext(ctx))
+	testutil.Ok(t, err)
+	testutil.Ok(t, resp.Body.Close())
+	span.Finish()
+
+	testutil.Equals(t, uint16(http.StatusServiceUnavailable), tracer.FinishedSpans()[0].Tag("http.status_code"))
+}
@@ -0,0 +1,69 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package objstore
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ObjectVersion describes a version of an object in a bucket with object versioning enabled.
+type ObjectVersion struct {
+	Name string
+	// VersionID identifies the version among versions of the object.
+	VersionID    string
+	LastModified time.Time
+	Size         int64
+	// Latest is true for the current version of the object.
+	Latest bool
+	// DeleteMarker is true if the version records deletion of the object and has no content.
+	DeleteMarker bool
+}
+
+// VersionedBucket is a bucket of a provider keeping previous versions of objects when object versioning is enabled, so
+// deleted or overwritten objects can be restored.
+type VersionedBucket interface {
+	Bucket
+
+	// IterVersions calls f for each version of all objects with the given prefix, including versions of deleted objects.
+	// Versions of the same object are iterated one after another.
+	IterVersions(ctx context.Context, prefix string, f func(v ObjectVersion) error) error
+
+	// RestoreVersion makes the given version the current version of the object.
+	RestoreVersion(ctx context.Context, v ObjectVersion) error
+}
+
+// DeletedObjects returns the most recent restorable version of each object with the given prefix that is deleted,
+// i.e. has no current version with content, sorted by object name.
+func DeletedObjects(ctx context.Context, bkt VersionedBucket, prefix string) ([]ObjectVersion, error) {
+	var (
+		live   = map[string]bool{}
+		latest = map[string]ObjectVersion{}
+	)
+	if err := bkt.IterVersions(ctx, prefix, func(v ObjectVersion) error {
+		if v.DeleteMarker {
+			return nil
+		}
+		if v.Latest {
+			live[v.Name] = true
+			return nil
+		}
+		if l, ok := latest[v.Name]; !ok || v.LastModified.After(l.LastModified) {
+			latest[v.Name] = v
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	deleted := make([]ObjectVersion, 0, len(latest))
+	for name, v := range latest {
+		if !live[name] {
+			deleted = append(deleted, v)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i].Name < deleted[j].Name })
+	return deleted, nil
+}
@@ -0,0 +1,284 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package profiling implements continuous profiling: periodically captured profiles of the process are pushed to a
+// profiling backend implementing the Pyroscope HTTP ingest API.
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	http_util "github.com/thanos-io/thanos/pkg/http"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"gopkg.in/yaml.v2"
+)
+
+type ProfileType string
+
+const (
+	CPU       ProfileType = "cpu"
+	Heap      ProfileType = "heap"
+	Goroutine ProfileType = "goroutine"
+	Mutex     ProfileType = "mutex"
+	Block     ProfileType = "block"
+)
+
+// TenantLabel is the pprof label of the tenant of requests, set by TenantLabelsMiddleware.
+const TenantLabel = "tenant"
+
+// Config is the configuration of continuous profiling.
+type Config struct {
+	// URL is the base URL of the profiling backend.
+	URL string `yaml:"url"`
+	// ApplicationName is the name profiles are pushed with.
+	ApplicationName string `yaml:"application_name"`
+	// TenantID is sent as the X-Scope-OrgID header to backends supporting multiple tenants, if not empty.
+	TenantID string `yaml:"tenant_id"`
+	// Labels are attached to all profiles, in addition to the component.
+	Labels map[string]string `yaml:"labels"`
+	// Interval is the interval of capturing and pushing profiles.
+	Interval model.Duration `yaml:"interval"`
+	// CPUDuration is the duration of the CPU profile captured each interval.
+	CPUDuration model.Duration `yaml:"cpu_duration"`
+	// ProfileTypes are the types of profiles captured.
+	ProfileTypes     []ProfileType          `yaml:"profile_types"`
+	HTTPClientConfig http_util.ClientConfig `yaml:"http_config"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ApplicationName: "thanos",
+		Interval:        model.Duration(time.Minute),
+		CPUDuration:     model.Duration(10 * time.Second),
+		ProfileTypes:    []ProfileType{CPU, Heap},
+	}
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig()
+	type plain Config
+	return unmarshal((*plain)(c))
+}
+
+// Profiler periodically captures profiles and pushes them to the profiling backend.
+type Profiler struct {
+	logger log.Logger
+	client *http.Client
+	conf   Config
+	url    string
+	name   string
+
+	pushes *prometheus.CounterVec
+}
+
+// NewProfiler parses the YAML profiling configuration and returns a profiler of the given component.
+func NewProfiler(logger log.Logger, reg prometheus.Registerer, conf []byte, component string) (*Profiler, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing profiling config")
+	}
+	return NewProfilerWithConfig(logger, reg, config, component)
+}
+
+// NewProfilerWithConfig returns a profiler of the given component with the given configuration.
+func NewProfilerWithConfig(logger log.Logger, reg prometheus.Registerer, config Config, component string) (*Profiler, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse profiling backend URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("profiling backend URL %q must be http or https", config.URL)
+	}
+	if config.ApplicationName == "" {
+		return nil, errors.New("empty application name of profiles")
+	}
+	if config.Interval <= 0 {
+		return nil, errors.New("profiling interval must be positive")
+	}
+	if config.CPUDuration <= 0 || config.CPUDuration > config.Interval {
+		return nil, errors.New("CPU profile duration must be positive and not longer than the interval")
+	}
+	if len(config.ProfileTypes) == 0 {
+		return nil, errors.New("no profile types")
+	}
+	for _, t := range config.ProfileTypes {
+		switch t {
+		case CPU, Heap, Goroutine, Mutex, Block:
+		default:
+			return nil, errors.Errorf("profile type %q is not supported", t)
+		}
+	}
+	client, err := http_util.NewHTTPClient(config.HTTPClientConfig, "profiling")
+	if err != nil {
+		return nil, errors.Wrap(err, "create HTTP client of profiling backend")
+	}
+
+	labels := map[string]string{"component": component}
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/ingest"
+
+	return &Profiler{
+		logger: log.With(logger, "component", "profiling"),
+		client: client,
+		conf:   config,
+		url:    u.String(),
+		name:   appName(config.ApplicationName, labels),
+		pushes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_profiling_pushes_total",
+			Help: "Total number of profiles pushed to the profiling backend, by profile type and result: success or error.",
+		}, []string{"type", "result"}),
+	}, nil
+}
+
+// appName returns the application name with labels, in the format of the Pyroscope ingest API.
+func appName(app string, labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, k := range names {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return app + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Run captures and pushes profiles every interval until the context is canceled.
+func (p *Profiler) Run(ctx context.Context) error {
+	for _, t := range p.conf.ProfileTypes {
+		// Mutex and block profiles are empty unless enabled, e.g. by the DEBUG environment variable.
+		switch t {
+		case Mutex:
+			if runtime.SetMutexProfileFraction(-1) == 0 {
+				runtime.SetMutexProfileFraction(10)
+			}
+		case Block:
+			runtime.SetBlockProfileRate(10)
+		}
+	}
+
+	return runutil.Repeat(time.Duration(p.conf.Interval), ctx.Done(), func() error {
+		for _, t := range p.conf.ProfileTypes {
+			from := time.Now()
+			var buf bytes.Buffer
+			if err := p.capture(ctx, t, &buf); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				p.pushes.WithLabelValues(string(t), "error").Inc()
+				level.Warn(p.logger).Log("msg", "capturing profile failed", "type", t, "err", err)
+				continue
+			}
+			if err := p.push(ctx, t, from, time.Now(), buf.Bytes()); err != nil {
+				p.pushes.WithLabelValues(string(t), "error").Inc()
+				level.Warn(p.logger).Log("msg", "pushing profile failed", "type", t, "err", err)
+				continue
+			}
+			p.pushes.WithLabelValues(string(t), "success").Inc()
+		}
+		return nil
+	})
+}
+
+// capture writes the profile of the given type in the gzipped pprof format. CPU profiles are captured for the CPU
+// duration, or until the context is canceled.
+func (p *Profiler) capture(ctx context.Context, t ProfileType, w io.Writer) error {
+	if t != CPU {
+		return pprof.Lookup(string(t)).WriteTo(w, 0)
+	}
+
+	// Fails if the CPU is profiled already, e.g. by a request of the /debug/pprof/profile endpoint.
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return err
+	}
+	select {
+	case <-time.After(time.Duration(p.conf.CPUDuration)):
+	case <-ctx.Done():
+	}
+	pprof.StopCPUProfile()
+	return ctx.Err()
+}
+
+func (p *Profiler) push(ctx context.Context, t ProfileType, from, until time.Time, profile []byte) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(profile); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	q.Set("name", p.name)
+	q.Set("from", strconv.FormatInt(from.Unix(), 10))
+	q.Set("until", strconv.FormatInt(until.Unix(), 10))
+	q.Set("format", "pprof")
+	q.Set("spyName", "gospy")
+	if t == CPU {
+		q.Set("sampleRate", "100")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url+"?"+q.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if p.conf.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", p.conf.TenantID)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(p.logger, resp.Body, "profiling backend response")
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("profiling backend responded %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// TenantLabelsMiddleware labels the goroutines of HTTP requests with the tenant in the given header, if any, so
+// samples of CPU profiles can be attributed to tenants.
+func TenantLabelsMiddleware(tenantHeader string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.Header.Get(tenantHeader)
+		if tenant == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		pprof.Do(r.Context(), pprof.Labels(TenantLabel, tenant), func(ctx context.Context) {
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package profiling
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestNewProfiler_Config(t *testing.T) {
+	for _, tcase := range []struct {
+		conf string
+		err  bool
+	}{
+		{conf: `url: http://pyroscope:4040`},
+		{conf: `{url: http://pyroscope:4040, interval: 30s, cpu_duration: 30s, profile_types: [cpu, heap, goroutine, mutex, block]}`},
+		{conf: `url: ""`, err: true},
+		{conf: `url: pyroscope:4040`, err: true},
+		{conf: `{url: http://pyroscope:4040, application_name: ""}`, err: true},
+		{conf: `{url: http://pyroscope:4040, interval: 10s, cpu_duration: 20s}`, err: true},
+		{conf: `{url: http://pyroscope:4040, profile_types: []}`, err: true},
+		{conf: `{url: http://pyroscope:4040, profile_types: [allocs]}`, err: true},
+		{conf: `{url: http://pyroscope:4040, unknown: field}`, err: true},
+	} {
+		t.Run(tcase.conf, func(t *testing.T) {
+			_, err := NewProfiler(log.NewNopLogger(), nil, []byte(tcase.conf), "query")
+			if tcase.err {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+		})
+	}
+}
+
+func TestProfiler_Run(t *testing.T) {
+	type push struct {
+		query    map[string]string
+		tenantID string
+		profile  []byte
+	}
+	var (
+		mtx    sync.Mutex
+		pushes []push
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "/prefix/ingest", r.URL.Path)
+		f, _, err := r.FormFile("profile")
+		testutil.Ok(t, err)
+		profile, err := ioutil.ReadAll(f)
+		testutil.Ok(t, err)
+
+		p := push{query: map[string]string{}, tenantID: r.Header.Get("X-Scope-OrgID"), profile: profile}
+		for _, k := range []string{"name", "format", "sampleRate"} {
+			p.query[k] = r.URL.Query().Get(k)
+		}
+		mtx.Lock()
+		pushes = append(pushes, p)
+		mtx.Unlock()
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	p, err := NewProfilerWithConfig(log.NewNopLogger(), reg, Config{
+		URL:             srv.URL + "/prefix/",
+		ApplicationName: "thanos",
+		TenantID:        "team-a",
+		Labels:          map[string]string{"cluster": "eu-1"},
+		Interval:        model.Duration(time.Hour),
+		CPUDuration:     model.Duration(100 * time.Millisecond),
+		ProfileTypes:    []ProfileType{CPU, Heap},
+	}, "query")
+	testutil.Ok(t, err)
+
+	// Profiles are pushed right away, and then every interval.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- p.Run(ctx) }()
+	for promtest.ToFloat64(p.pushes.WithLabelValues("heap", "success")) == 0 {
+		select {
+		case err := <-done:
+			t.Fatalf("profiler stopped: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	testutil.Ok(t, <-done)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	testutil.Equals(t, 2, len(pushes))
+	testutil.Equals(t, map[string]string{"name": "thanos{cluster=eu-1,component=query}", "format": "pprof", "sampleRate": "100"}, pushes[0].query)
+	testutil.Equals(t, map[string]string{"name": "thanos{cluster=eu-1,component=query}", "format": "pprof", "sampleRate": ""}, pushes[1].query)
+	for _, p := range pushes {
+		testutil.Equals(t, "team-a", p.tenantID)
+		// Profiles are gzipped.
+		testutil.Assert(t, len(p.profile) > 2 && p.profile[0] == 0x1f && p.profile[1] == 0x8b, "expected gzipped profile")
+	}
+}
+
+func TestTenantLabelsMiddleware(t *testing.T) {
+	var tenant string
+	var ok bool
+	h := TenantLabelsMiddleware("THANOS-TENANT", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok = pprof.Label(r.Context(), TenantLabel)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/query", nil)
+	req.Header.Set("THANOS-TENANT", "team-a")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	testutil.Assert(t, ok, "expected tenant label")
+	testutil.Equals(t, "team-a", tenant)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/query", nil))
+	testutil.Assert(t, !ok, "expected no tenant label")
+}
@@ -0,0 +1,151 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package promclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"golang.org/x/sync/singleflight"
+)
+
+// APICache fetches responses of Prometheus read-only APIs, e.g. /api/v1/metadata or /api/v1/targets, and caches
+// successful responses for the configured TTL, so frequent requests don't hit Prometheus every time. Concurrent
+// requests for the same response are sent to Prometheus only once.
+type APICache struct {
+	logger  log.Logger
+	client  HTTPClient
+	base    *url.URL
+	apiPath string
+	ttl     time.Duration
+
+	group singleflight.Group
+
+	mtx     sync.Mutex
+	entries map[string]apiCacheEntry
+
+	requests *prometheus.CounterVec
+}
+
+type apiCacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// NewAPICache returns APICache for the given API path of the Prometheus with the given base URL. TTL equal to 0
+// disables caching.
+func NewAPICache(logger log.Logger, reg prometheus.Registerer, client HTTPClient, base *url.URL, apiPath string, ttl time.Duration) *APICache {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	c := &APICache{
+		logger:  logger,
+		client:  client,
+		base:    base,
+		apiPath: apiPath,
+		ttl:     ttl,
+		entries: map[string]apiCacheEntry{},
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_prometheus_api_cache_requests_total",
+			Help: "Total number of requests for cached Prometheus API responses.",
+		}, []string{"result"}),
+	}
+	c.requests.WithLabelValues("hit")
+	c.requests.WithLabelValues("miss")
+	return c
+}
+
+// Get returns the response body of the API for the given parameters.
+func (c *APICache) Get(ctx context.Context, params url.Values) ([]byte, error) {
+	key := params.Encode()
+
+	now := time.Now()
+	c.mtx.Lock()
+	e, ok := c.entries[key]
+	c.mtx.Unlock()
+	if ok && now.Before(e.expires) {
+		c.requests.WithLabelValues("hit").Inc()
+		return e.body, nil
+	}
+	c.requests.WithLabelValues("miss").Inc()
+
+	body, err, _ := c.group.Do(key, func() (interface{}, error) {
+		b, err := c.fetch(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		if c.ttl > 0 {
+			c.set(key, b, time.Now())
+		}
+		return b, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return body.([]byte), nil
+}
+
+func (c *APICache) set(key string, b []byte, now time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	// Parameters are arbitrary, so remove expired entries to keep the cache bounded by the number of distinct
+	// requests within TTL.
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = apiCacheEntry{body: b, expires: now.Add(c.ttl)}
+}
+
+func (c *APICache) fetch(ctx context.Context, params url.Values) ([]byte, error) {
+	u := *c.base
+	u.Path = path.Join(u.Path, c.apiPath)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create request")
+	}
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "request %s", u.String())
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(c.logger, resp.Body, "api cache request body")
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("got non-200 response code: %v, response: %v", resp.StatusCode, string(b))
+	}
+	return b, nil
+}
+
+// ServeHTTP serves the cached API response.
+func (c *APICache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	b, err := c.Get(r.Context(), r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
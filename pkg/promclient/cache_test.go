@@ -0,0 +1,71 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package promclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestAPICache_Get(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, "failed", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"path":%q,"call":%d}`, r.URL.Path, n)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	testutil.Ok(t, err)
+
+	ctx := context.Background()
+	c := NewAPICache(nil, prometheus.NewRegistry(), http.DefaultClient, u, "/api/v1/targets", time.Hour)
+
+	b, err := c.Get(ctx, nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, `{"path":"/api/v1/targets","call":1}`, string(b))
+
+	// Cached.
+	b, err = c.Get(ctx, nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, `{"path":"/api/v1/targets","call":1}`, string(b))
+
+	// Different parameters are cached separately.
+	b, err = c.Get(ctx, url.Values{"state": []string{"active"}})
+	testutil.Ok(t, err)
+	testutil.Equals(t, `{"path":"/api/v1/targets","call":2}`, string(b))
+
+	// Errors are not cached.
+	_, err = c.Get(ctx, url.Values{"fail": []string{"1"}})
+	testutil.NotOk(t, err)
+	_, err = c.Get(ctx, url.Values{"fail": []string{"1"}})
+	testutil.NotOk(t, err)
+	testutil.Equals(t, int32(4), atomic.LoadInt32(&calls))
+
+	testutil.Equals(t, 1.0, promtestutil.ToFloat64(c.requests.WithLabelValues("hit")))
+	testutil.Equals(t, 4.0, promtestutil.ToFloat64(c.requests.WithLabelValues("miss")))
+
+	// Zero TTL disables caching.
+	c = NewAPICache(nil, prometheus.NewRegistry(), http.DefaultClient, u, "/api/v1/metadata", 0)
+	b, err = c.Get(ctx, nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, `{"path":"/api/v1/metadata","call":5}`, string(b))
+	b, err = c.Get(ctx, nil)
+	testutil.Ok(t, err)
+	testutil.Equals(t, `{"path":"/api/v1/metadata","call":6}`, string(b))
+}
@@ -214,6 +214,92 @@ func ConfiguredFlags(ctx context.Context, logger log.Logger, base *url.URL) (Fla
 
 }
 
+// ErrNoTimeRetention is returned by Retention when Prometheus has no time based retention configured, e.g. only
+// size based one.
+var ErrNoTimeRetention = errors.New("no time based retention configured")
+
+// Retention returns time based retention of Prometheus TSDB from /api/v1/status/runtimeinfo Prometheus endpoint,
+// or from /api/v1/status/flags endpoint for older Prometheus versions without runtime info.
+// Added to Prometheus from v2.14.
+func Retention(ctx context.Context, logger log.Logger, base *url.URL) (time.Duration, error) {
+	u := *base
+	u.Path = path.Join(u.Path, "/api/v1/status/runtimeinfo")
+
+	var d struct {
+		Data struct {
+			StorageRetention string `json:"storageRetention"`
+		} `json:"data"`
+	}
+	ok, err := getStatus(ctx, logger, u, &d)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		// Retention is formatted as "<duration>", "<size>" or "<duration> or <size>".
+		for _, r := range strings.Split(d.Data.StorageRetention, " or ") {
+			if dur, err := model.ParseDuration(strings.TrimSpace(r)); err == nil && dur > 0 {
+				return time.Duration(dur), nil
+			}
+		}
+		return 0, ErrNoTimeRetention
+	}
+
+	u.Path = path.Join(base.Path, "/api/v1/status/flags")
+	var f struct {
+		Data map[string]string `json:"data"`
+	}
+	ok, err = getStatus(ctx, logger, u, &f)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrFlagEndpointNotFound
+	}
+	// Newer flag overrides the deprecated one, if set.
+	for _, name := range []string{"storage.tsdb.retention.time", "storage.tsdb.retention"} {
+		if dur, err := model.ParseDuration(f.Data[name]); err == nil && dur > 0 {
+			return time.Duration(dur), nil
+		}
+	}
+	// Without time retention flags Prometheus uses 15d, unless size based retention is set.
+	if sz := f.Data["storage.tsdb.retention.size"]; sz != "" && sz != "0B" {
+		return 0, ErrNoTimeRetention
+	}
+	return 15 * 24 * time.Hour, nil
+}
+
+// getStatus requests given Prometheus status endpoint and unmarshals the response into v. It returns false if the
+// endpoint is not found.
+func getStatus(ctx context.Context, logger log.Logger, u url.URL, v interface{}) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, errors.Wrap(err, "create request")
+	}
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, errors.Wrapf(err, "request status against %s", u.String())
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(logger, resp.Body, "query body")
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, errors.New("failed to read body")
+	}
+
+	switch resp.StatusCode {
+	case 404:
+		return false, nil
+	case 200:
+		if err := json.Unmarshal(b, v); err != nil {
+			return false, errors.Wrapf(err, "unmarshal response: %v", string(b))
+		}
+		return true, nil
+	default:
+		return false, errors.Errorf("got non-200 response code: %v, response: %v", resp.StatusCode, string(b))
+	}
+}
+
 // Snapshot will request Prometheus to perform snapshot in directory returned by this function.
 // Returned directory is relative to Prometheus data-dir.
 // NOTE: `--web.enable-admin-api` flag has to be set on Prometheus.
@@ -263,6 +349,8 @@ func Snapshot(ctx context.Context, logger log.Logger, base *url.URL, skipHead bo
 type QueryOptions struct {
 	Deduplicate             bool
 	PartialResponseStrategy storepb.PartialResponseStrategy
+	// HTTPHeaders are added to the query request, e.g. to select tenants by proxies in front of the query API.
+	HTTPHeaders http.Header
 }
 
 func (p *QueryOptions) AddTo(values url.Values) error {
@@ -318,16 +406,43 @@ func defaultClient(logger log.Logger) *Client {
 	)
 }
 
-// QueryInstant performs an instant query and returns results in model.Vector type.
-func (c *Client) QueryInstant(ctx context.Context, base *url.URL, query string, t time.Time, opts QueryOptions) (model.Vector, []string, error) {
+// queryResponse is the response of both Prometheus and Thanos Query instant query API.
+type queryResponse struct {
+	// Decode only ResultType and load Result only as RawJson since we don't know
+	// structure of the Result yet.
+	Data struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+
+	Error     string `json:"error,omitempty"`
+	ErrorType string `json:"errorType,omitempty"`
+	// Extra field supported by Thanos Querier.
+	Warnings []string `json:"warnings"`
+
+	statusCode int
+}
+
+// err returns an error for response with unexpected result type.
+func (r *queryResponse) err() error {
+	if r.Warnings != nil {
+		return errors.Errorf("error: %s, type: %s, warning: %s", r.Error, r.ErrorType, strings.Join(r.Warnings, ", "))
+	}
+	if r.Error != "" {
+		return errors.Errorf("error: %s, type: %s", r.Error, r.ErrorType)
+	}
+	return errors.Errorf("received status code: %d, unknown response type: '%q'", r.statusCode, r.Data.ResultType)
+}
+
+func (c *Client) queryInstant(ctx context.Context, base *url.URL, query string, t time.Time, opts QueryOptions) (*queryResponse, error) {
 	params, err := url.ParseQuery(base.RawQuery)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "parse raw query %s", base.RawQuery)
+		return nil, errors.Wrapf(err, "parse raw query %s", base.RawQuery)
 	}
 	params.Add("query", query)
 	params.Add("time", t.Format(time.RFC3339Nano))
 	if err := opts.AddTo(params); err != nil {
-		return nil, nil, errors.Wrap(err, "add thanos opts query params")
+		return nil, errors.Wrap(err, "add thanos opts query params")
 	}
 
 	u := *base
@@ -338,38 +453,37 @@ func (c *Client) QueryInstant(ctx context.Context, base *url.URL, query string,
 
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "create GET request")
+		return nil, errors.Wrap(err, "create GET request")
+	}
+	for k, v := range opts.HTTPHeaders {
+		req.Header[k] = v
 	}
 
 	req = req.WithContext(ctx)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, nil, errors.Wrapf(err, "perform GET request against %s", u.String())
+		return nil, errors.Wrapf(err, "perform GET request against %s", u.String())
 	}
 	defer runutil.ExhaustCloseWithLogOnErr(c.logger, resp.Body, "query body")
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "read query instant response")
+		return nil, errors.Wrap(err, "read query instant response")
 	}
 
-	// Decode only ResultType and load Result only as RawJson since we don't know
-	// structure of the Result yet.
-	var m struct {
-		Data struct {
-			ResultType string          `json:"resultType"`
-			Result     json.RawMessage `json:"result"`
-		} `json:"data"`
-
-		Error     string `json:"error,omitempty"`
-		ErrorType string `json:"errorType,omitempty"`
-		// Extra field supported by Thanos Querier.
-		Warnings []string `json:"warnings"`
+	m := &queryResponse{statusCode: resp.StatusCode}
+	if err = json.Unmarshal(body, m); err != nil {
+		return nil, errors.Wrap(err, "unmarshal query instant response")
 	}
+	return m, nil
+}
 
-	if err = json.Unmarshal(body, &m); err != nil {
-		return nil, nil, errors.Wrap(err, "unmarshal query instant response")
+// QueryInstant performs an instant query and returns results in model.Vector type.
+func (c *Client) QueryInstant(ctx context.Context, base *url.URL, query string, t time.Time, opts QueryOptions) (model.Vector, []string, error) {
+	m, err := c.queryInstant(ctx, base, query, t, opts)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var vectorResult model.Vector
@@ -387,18 +501,29 @@ func (c *Client) QueryInstant(ctx context.Context, base *url.URL, query string,
 			return nil, nil, errors.Wrap(err, "decode result into ValueTypeScalar")
 		}
 	default:
-		if m.Warnings != nil {
-			return nil, nil, errors.Errorf("error: %s, type: %s, warning: %s", m.Error, m.ErrorType, strings.Join(m.Warnings, ", "))
-		}
-		if m.Error != "" {
-			return nil, nil, errors.Errorf("error: %s, type: %s", m.Error, m.ErrorType)
-		}
-
-		return nil, nil, errors.Errorf("received status code: %d, unknown response type: '%q'", resp.StatusCode, m.Data.ResultType)
+		return nil, nil, m.err()
 	}
 	return vectorResult, m.Warnings, nil
 }
 
+// QueryInstantMatrix performs an instant query of range vector expression, e.g. range selector, and returns
+// results in model.Matrix type.
+func (c *Client) QueryInstantMatrix(ctx context.Context, base *url.URL, query string, t time.Time, opts QueryOptions) (model.Matrix, []string, error) {
+	m, err := c.queryInstant(ctx, base, query, t, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m.Data.ResultType != string(promql.ValueTypeMatrix) {
+		return nil, nil, m.err()
+	}
+
+	var matrixResult model.Matrix
+	if err = json.Unmarshal(m.Data.Result, &matrixResult); err != nil {
+		return nil, nil, errors.Wrap(err, "decode result into ValueTypeMatrix")
+	}
+	return matrixResult, m.Warnings, nil
+}
+
 // QueryInstant performs an instant query using a default HTTP client and returns results in model.Vector type.
 func QueryInstant(ctx context.Context, logger log.Logger, base *url.URL, query string, t time.Time, opts QueryOptions) (model.Vector, []string, error) {
 	return defaultClient(logger).QueryInstant(ctx, base, query, t, opts)
@@ -0,0 +1,95 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package promclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestRetention(t *testing.T) {
+	for _, tcase := range []struct {
+		name        string
+		runtimeInfo string
+		flags       string
+		expected    time.Duration
+		expectedErr error
+	}{
+		{
+			name:        "runtime info with time retention",
+			runtimeInfo: `{"status":"success","data":{"storageRetention":"15d"}}`,
+			expected:    15 * 24 * time.Hour,
+		},
+		{
+			name:        "runtime info with time and size retention",
+			runtimeInfo: `{"status":"success","data":{"storageRetention":"6h or 512MiB"}}`,
+			expected:    6 * time.Hour,
+		},
+		{
+			name:        "runtime info with size retention only",
+			runtimeInfo: `{"status":"success","data":{"storageRetention":"512MiB"}}`,
+			expectedErr: ErrNoTimeRetention,
+		},
+		{
+			name:     "flags with time retention",
+			flags:    `{"status":"success","data":{"storage.tsdb.retention":"0s","storage.tsdb.retention.time":"2d","storage.tsdb.retention.size":"0B"}}`,
+			expected: 2 * 24 * time.Hour,
+		},
+		{
+			name:     "flags with deprecated time retention",
+			flags:    `{"status":"success","data":{"storage.tsdb.retention":"1w","storage.tsdb.retention.time":"0s"}}`,
+			expected: 7 * 24 * time.Hour,
+		},
+		{
+			name:     "flags with default retention",
+			flags:    `{"status":"success","data":{"storage.tsdb.retention":"0s","storage.tsdb.retention.time":"0s","storage.tsdb.retention.size":"0B"}}`,
+			expected: 15 * 24 * time.Hour,
+		},
+		{
+			name:        "flags with size retention only",
+			flags:       `{"status":"success","data":{"storage.tsdb.retention":"0s","storage.tsdb.retention.time":"0s","storage.tsdb.retention.size":"1GB"}}`,
+			expectedErr: ErrNoTimeRetention,
+		},
+		{
+			name:        "no endpoints",
+			expectedErr: ErrFlagEndpointNotFound,
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var resp string
+				switch r.URL.Path {
+				case "/api/v1/status/runtimeinfo":
+					resp = tcase.runtimeInfo
+				case "/api/v1/status/flags":
+					resp = tcase.flags
+				}
+				if resp == "" {
+					http.NotFound(w, r)
+					return
+				}
+				_, _ = w.Write([]byte(resp))
+			}))
+			defer srv.Close()
+
+			u, err := url.Parse(srv.URL)
+			testutil.Ok(t, err)
+
+			r, err := Retention(context.Background(), log.NewNopLogger(), u)
+			if tcase.expectedErr != nil {
+				testutil.Equals(t, tcase.expectedErr, err)
+				return
+			}
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.expected, r)
+		})
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/route"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thanos-io/thanos/pkg/extprom"
+	"github.com/thanos-io/thanos/pkg/gate"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/usage"
+)
+
+const (
+	// exportDir is the directory of exports in the bucket.
+	exportDir = "exports"
+	// exportStatusTimeout is the timeout of writing the status of finished exports, which is written even if the
+	// export was canceled.
+	exportStatusTimeout = time.Minute
+)
+
+// ExportStatus is the status of an export.
+type ExportStatus string
+
+const (
+	ExportRunning ExportStatus = "running"
+	ExportDone    ExportStatus = "done"
+	ExportFailed  ExportStatus = "failed"
+)
+
+// Export is the state of a range query exported to the bucket. It is stored next to the result in the bucket, so
+// exports can be looked up by any querier using the same bucket.
+type Export struct {
+	ID     string       `json:"id"`
+	Status ExportStatus `json:"status"`
+	Tenant string       `json:"tenant,omitempty"`
+	Query  string       `json:"query"`
+	Start  time.Time    `json:"start"`
+	End    time.Time    `json:"end"`
+	// Step is the query resolution step in seconds.
+	Step float64 `json:"step"`
+	// Object is the name of the result in the bucket, once the export is done. It has the format of responses of
+	// the query_range API.
+	Object   string     `json:"object,omitempty"`
+	Warnings []string   `json:"warnings,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Created  time.Time  `json:"created"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+func exportStatusName(id string) string { return path.Join(exportDir, id, "status.json") }
+func exportResultName(id string) string { return path.Join(exportDir, id, "result.json") }
+
+// Exporter executes range queries in the background and writes their results into the bucket, so large results do
+// not need to be streamed to clients holding the connection open.
+type Exporter struct {
+	logger log.Logger
+	bkt    objstore.Bucket
+	gate   *gate.Gate
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	exports *prometheus.CounterVec
+}
+
+// NewExporter returns an exporter writing results into the given bucket, executing at most maxConcurrent exports
+// at once. Further exports wait until others finished.
+func NewExporter(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, maxConcurrent int) *Exporter {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Exporter{
+		logger: logger,
+		bkt:    bkt,
+		gate:   gate.NewGate(maxConcurrent, extprom.WrapRegistererWithPrefix("thanos_query_export_", reg)),
+		ctx:    ctx,
+		cancel: cancel,
+		exports: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_query_exports_total",
+			Help: "Total number of finished exports of range queries to the bucket by result.",
+		}, []string{"result"}),
+	}
+}
+
+// Start stores the given export as running and executes it in the background. The export is returned with its
+// assigned ID.
+func (e *Exporter) Start(ctx context.Context, exp Export, exec func(context.Context) *promql.Result) (*Export, error) {
+	exp.ID = ulid.MustNew(ulid.Now(), rand.New(rand.NewSource(time.Now().UnixNano()))).String()
+	exp.Status = ExportRunning
+	exp.Created = time.Now()
+	if err := e.writeStatus(ctx, &exp); err != nil {
+		return nil, err
+	}
+
+	e.wg.Add(1)
+	go func(exp Export) {
+		defer e.wg.Done()
+
+		err := e.run(&exp, exec)
+		now := time.Now()
+		exp.Finished = &now
+		if err != nil {
+			e.exports.WithLabelValues("error").Inc()
+			level.Warn(e.logger).Log("msg", "export failed", "id", exp.ID, "err", err)
+			exp.Status, exp.Error = ExportFailed, err.Error()
+		} else {
+			e.exports.WithLabelValues("success").Inc()
+			exp.Status, exp.Object = ExportDone, exportResultName(exp.ID)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), exportStatusTimeout)
+		defer cancel()
+		if err := e.writeStatus(ctx, &exp); err != nil {
+			level.Error(e.logger).Log("msg", "failed to write export status", "id", exp.ID, "err", err)
+		}
+	}(exp)
+
+	return &exp, nil
+}
+
+func (e *Exporter) run(exp *Export, exec func(context.Context) *promql.Result) error {
+	if err := e.gate.IsMyTurn(e.ctx); err != nil {
+		return errors.Wrap(err, "wait for turn")
+	}
+	defer e.gate.Done()
+
+	res := exec(e.ctx)
+	if res.Err != nil {
+		return res.Err
+	}
+	for _, w := range res.Warnings {
+		exp.Warnings = append(exp.Warnings, w.Error())
+	}
+
+	// The result is encoded while it is uploaded, to not hold it in memory twice.
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(json.NewEncoder(pw).Encode(&response{
+			Status:   statusSuccess,
+			Data:     &queryData{ResultType: res.Value.Type(), Result: res.Value},
+			Warnings: exp.Warnings,
+		}))
+	}()
+	err := e.bkt.Upload(e.ctx, exportResultName(exp.ID), pr)
+	// Unblocks the encoder if the upload failed.
+	pr.CloseWithError(err)
+	return errors.Wrap(err, "upload result")
+}
+
+func (e *Exporter) writeStatus(ctx context.Context, exp *Export) error {
+	b, err := json.Marshal(exp)
+	if err != nil {
+		return errors.Wrap(err, "marshal export status")
+	}
+	return errors.Wrap(e.bkt.Upload(ctx, exportStatusName(exp.ID), bytes.NewReader(b)), "upload export status")
+}
+
+// Get returns the export with the given ID. Exports running on a querier which stopped without finishing them keep
+// the running status.
+func (e *Exporter) Get(ctx context.Context, id string) (*Export, error) {
+	if _, err := ulid.Parse(id); err != nil {
+		return nil, errors.Wrapf(err, "invalid export ID %s", id)
+	}
+	r, err := e.bkt.Get(ctx, exportStatusName(id))
+	if err != nil {
+		return nil, err
+	}
+	defer runutil.CloseWithLogOnErr(e.logger, r, "export status reader")
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read export status")
+	}
+	var exp Export
+	if err := json.Unmarshal(b, &exp); err != nil {
+		return nil, errors.Wrap(err, "unmarshal export status")
+	}
+	return &exp, nil
+}
+
+// Stop cancels running exports and waits until their status is written.
+func (e *Exporter) Stop() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+func (api *API) queryRangeExport(r *http.Request) (interface{}, []error, *ApiError) {
+	rq, apiErr := api.parseRangeQuery(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	tenant := r.Header.Get(api.tenantHeader)
+	exp, err := api.exporter.Start(r.Context(), Export{
+		Tenant: tenant,
+		Query:  rq.qs,
+		Start:  rq.start,
+		End:    rq.end,
+		Step:   rq.step.Seconds(),
+	}, func(ctx context.Context) *promql.Result {
+		if rq.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, rq.timeout)
+			defer cancel()
+		}
+		begin := time.Now()
+		res := rq.qry.Exec(ctx)
+		api.accountant.Add(tenant, usage.Usage{QuerySeconds: time.Since(begin).Seconds()})
+		return res
+	})
+	if err != nil {
+		return nil, nil, &ApiError{ErrorInternal, err}
+	}
+	return exp, rq.warnings, nil
+}
+
+func (api *API) queryRangeExportStatus(r *http.Request) (interface{}, []error, *ApiError) {
+	id := route.Param(r.Context(), "id")
+	if _, err := ulid.Parse(id); err != nil {
+		return nil, nil, &ApiError{errorBadData, errors.Wrapf(err, "invalid export ID %s", id)}
+	}
+	exp, err := api.exporter.Get(r.Context(), id)
+	if err != nil {
+		if api.exporter.bkt.IsObjNotFoundErr(errors.Cause(err)) {
+			return nil, nil, &ApiError{ErrorNotFound, errors.Errorf("export %s not found", id)}
+		}
+		return nil, nil, &ApiError{ErrorInternal, err}
+	}
+	// Exports of other tenants are not disclosed.
+	if exp.Tenant != r.Header.Get(api.tenantHeader) {
+		return nil, nil, &ApiError{ErrorNotFound, errors.Errorf("export %s not found", id)}
+	}
+	return exp, nil, nil
+}
@@ -0,0 +1,132 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/route"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/query"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+)
+
+func TestQueryRangeExport(t *testing.T) {
+	db, err := e2eutil.NewTSDB()
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, db.Close()) }()
+
+	app := db.Appender()
+	for i := int64(0); i < 10; i++ {
+		_, err := app.Add(labels.FromStrings("__name__", "test_metric"), i*60000, float64(i))
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	bkt := inmem.NewBucket()
+	exporter := NewExporter(nil, nil, bkt, 1)
+	defer exporter.Stop()
+	api := &API{
+		queryableCreate: query.NewQueryableCreator(nil, nil, store.NewTSDBStore(nil, nil, db, component.Query, nil), 0),
+		queryEngine: promql.NewEngine(promql.EngineOpts{
+			MaxConcurrent: 20,
+			MaxSamples:    10000,
+			Timeout:       100 * time.Second,
+		}),
+		tenantHeader: "THANOS-TENANT",
+		exporter:     exporter,
+		now:          time.Now,
+	}
+
+	newRequest := func(method string, params url.Values, tenant string) *http.Request {
+		r, err := http.NewRequest(method, "http://example.com", strings.NewReader(params.Encode()))
+		testutil.Ok(t, err)
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("THANOS-TENANT", tenant)
+		return r
+	}
+
+	data, _, apiErr := api.queryRangeExport(newRequest("POST", url.Values{
+		"query": []string{"test_metric"},
+		"start": []string{"0"},
+		"end":   []string{"540"},
+		"step":  []string{"60"},
+	}, "team-a"))
+	testutil.Assert(t, apiErr == nil, "unexpected error %v", apiErr)
+	exp := data.(*Export)
+	testutil.Equals(t, ExportRunning, exp.Status)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	status := func(id, tenant string) (*Export, *ApiError) {
+		r := newRequest("GET", nil, tenant)
+		r = r.WithContext(route.WithParam(context.Background(), "id", id))
+		data, _, apiErr := api.queryRangeExportStatus(r)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		return data.(*Export), nil
+	}
+	var done *Export
+	testutil.Ok(t, runutil.Retry(10*time.Millisecond, ctx.Done(), func() error {
+		done, apiErr = status(exp.ID, "team-a")
+		if apiErr != nil {
+			return apiErr
+		}
+		if done.Status == ExportRunning {
+			return errors.New("export still running")
+		}
+		return nil
+	}))
+	testutil.Equals(t, ExportDone, done.Status)
+	testutil.Equals(t, "test_metric", done.Query)
+	testutil.Equals(t, 60.0, done.Step)
+	testutil.Equals(t, "exports/"+exp.ID+"/result.json", done.Object)
+
+	rc, err := bkt.Get(context.Background(), done.Object)
+	testutil.Ok(t, err)
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+
+	var resp struct {
+		Status string `json:"status"`
+		Data   struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Metric map[string]string `json:"metric"`
+				Values [][]interface{}   `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	testutil.Ok(t, json.Unmarshal(b, &resp))
+	testutil.Equals(t, "success", resp.Status)
+	testutil.Equals(t, "matrix", resp.Data.ResultType)
+	testutil.Equals(t, 1, len(resp.Data.Result))
+	testutil.Equals(t, map[string]string{"__name__": "test_metric"}, resp.Data.Result[0].Metric)
+	testutil.Equals(t, 10, len(resp.Data.Result[0].Values))
+
+	// Exports of other tenants and unknown exports are not found.
+	_, apiErr = status(exp.ID, "team-b")
+	testutil.Equals(t, ErrorNotFound, apiErr.Typ)
+	_, apiErr = status("01E5K3NK1T8GEEAJ6D7PX2KCWA", "team-a")
+	testutil.Equals(t, ErrorNotFound, apiErr.Typ)
+	_, apiErr = status("../../blocks", "team-a")
+	testutil.Equals(t, errorBadData, apiErr.Typ)
+}
@@ -0,0 +1,228 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package v1
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/route"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/pkg/value"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/thanos-io/thanos/pkg/audit"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/tracing"
+)
+
+// RegisterFederation registers the federation endpoint in the given router. It is served next to the UI, as by
+// Prometheus, not in the API.
+func (api *API) RegisterFederation(r *route.Router, tracer opentracing.Tracer, logger log.Logger, ins extpromhttp.InstrumentationMiddleware) {
+	var h http.Handler = http.HandlerFunc(api.federation)
+	if api.rateLimiter != nil {
+		h = api.rateLimiter.Handler(h)
+	}
+	r.Get("/federate", ins.NewHandler("federate", tracing.HTTPMiddleware(tracer, "federate", logger, h)))
+}
+
+// federation serves the latest samples of series selected by the match[] parameters in the Prometheus exposition
+// format, as the federation endpoint of Prometheus. Series are deduplicated as for other APIs.
+func (api *API) federation(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, errors.Wrap(err, "parse form").Error(), http.StatusBadRequest)
+		return
+	}
+
+	var e *audit.Event
+	if api.auditLogger != nil {
+		e = api.newAuditEvent(r, "federate")
+		e.Matchers = r.Form["match[]"]
+		r = r.WithContext(audit.ContextWithEvent(r.Context(), e))
+	}
+	vec, apiErr := api.federationSamples(r)
+	if e != nil {
+		if apiErr != nil {
+			e.Error = apiErr.Error()
+		}
+		e.ResultSize = len(vec)
+		api.auditLogger.Log(*e)
+	}
+	if apiErr != nil {
+		http.Error(w, apiErr.Err.Error(), apiErrorCode(apiErr.Typ))
+		return
+	}
+
+	format := expfmt.Negotiate(r.Header)
+	w.Header().Set("Content-Type", string(format))
+	if err := writeFederation(expfmt.NewEncoder(w, format), vec); err != nil {
+		level.Error(api.logger).Log("msg", "federation failed", "err", err)
+	}
+}
+
+// federationSamples returns the latest samples of all selected series within the lookback delta, sorted by name.
+func (api *API) federationSamples(r *http.Request) (promql.Vector, *ApiError) {
+	var matcherSets [][]*labels.Matcher
+	for _, s := range r.Form["match[]"] {
+		matchers, err := promql.ParseMetricSelector(s)
+		if err != nil {
+			return nil, &ApiError{errorBadData, err}
+		}
+		matcherSets = append(matcherSets, matchers)
+	}
+
+	enableDedup, apiErr := api.parseEnableDedupParam(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	replicaLabels, apiErr := api.parseReplicaLabelsParam(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	enablePartialResponse, apiErr := api.parsePartialResponseParam(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	enforced, apiErr := api.authorize(r, "federate", matcherSets)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for i := range matcherSets {
+		matcherSets[i] = append(matcherSets[i], enforced...)
+	}
+
+	var (
+		mint = timestamp.FromTime(api.now().Add(-promql.LookbackDelta))
+		maxt = timestamp.FromTime(api.now())
+	)
+	q, err := api.queryableCreate(enableDedup, replicaLabels, 0, enablePartialResponse, false).Querier(r.Context(), mint, maxt)
+	if err != nil {
+		return nil, &ApiError{errorExec, err}
+	}
+	defer runutil.CloseWithLogOnErr(api.logger, q, "queryable federation")
+
+	params := &storage.SelectParams{
+		Start: mint,
+		End:   maxt,
+	}
+	var sets []storage.SeriesSet
+	for _, ms := range matcherSets {
+		s, warnings, err := q.Select(params, ms...)
+		if err != nil {
+			return nil, &ApiError{errorExec, err}
+		}
+		// The exposition formats cannot carry warnings of partial responses.
+		for _, w := range warnings {
+			level.Warn(api.logger).Log("msg", "partial response of federation", "warning", w)
+		}
+		sets = append(sets, s)
+	}
+
+	vec := promql.Vector{}
+	set := storage.NewMergeSeriesSet(sets, nil)
+	it := storage.NewBuffer(int64(promql.LookbackDelta / 1e6))
+	for set.Next() {
+		s := set.At()
+		it.Reset(s.Iterator())
+
+		var t int64
+		var v float64
+
+		ok := it.Seek(maxt)
+		if ok {
+			t, v = it.Values()
+		} else {
+			t, v, ok = it.PeekBack(1)
+			if !ok {
+				continue
+			}
+		}
+		// The exposition formats do not support stale markers, so they are dropped as by Prometheus.
+		if value.IsStaleNaN(v) {
+			continue
+		}
+		vec = append(vec, promql.Sample{
+			Metric: s.Labels(),
+			Point:  promql.Point{T: t, V: v},
+		})
+	}
+	if set.Err() != nil {
+		return nil, &ApiError{errorExec, set.Err()}
+	}
+
+	sort.SliceStable(vec, func(i, j int) bool {
+		return vec[i].Metric.Get(labels.MetricName) < vec[j].Metric.Get(labels.MetricName)
+	})
+	return vec, nil
+}
+
+// writeFederation encodes samples sorted by name as untyped metric families. An empty instance label is added to
+// series without one, so scrapers honoring labels do not attach the instance label of the querier.
+func writeFederation(enc expfmt.Encoder, vec promql.Vector) error {
+	var (
+		lastMetricName string
+		protMetricFam  *dto.MetricFamily
+	)
+	for _, s := range vec {
+		name := s.Metric.Get(labels.MetricName)
+		if name == "" {
+			// Nameless metrics cannot be exposed.
+			continue
+		}
+		if name != lastMetricName {
+			if protMetricFam != nil {
+				if err := enc.Encode(protMetricFam); err != nil {
+					return err
+				}
+			}
+			protMetricFam = &dto.MetricFamily{
+				Type: dto.MetricType_UNTYPED.Enum(),
+				Name: proto.String(name),
+			}
+			lastMetricName = name
+		}
+
+		protMetric := &dto.Metric{
+			Untyped:     &dto.Untyped{Value: proto.Float64(s.V)},
+			TimestampMs: proto.Int64(s.T),
+		}
+		instanceSeen := false
+		for _, l := range s.Metric {
+			// No value means unset, so such labels are never exposed.
+			if l.Name == labels.MetricName || l.Value == "" {
+				continue
+			}
+			if l.Name == model.InstanceLabel {
+				instanceSeen = true
+			}
+			protMetric.Label = append(protMetric.Label, &dto.LabelPair{
+				Name:  proto.String(l.Name),
+				Value: proto.String(l.Value),
+			})
+		}
+		if !instanceSeen {
+			protMetric.Label = append(protMetric.Label, &dto.LabelPair{
+				Name:  proto.String(model.InstanceLabel),
+				Value: proto.String(""),
+			})
+		}
+		protMetricFam.Metric = append(protMetricFam.Metric, protMetric)
+	}
+	// Still have to ship off the last MetricFamily, if any.
+	if protMetricFam != nil {
+		return enc.Encode(protMetricFam)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+)
+
+// counterSuffixes are suffixes of names of counter metrics, by the naming conventions of Prometheus.
+var counterSuffixes = []string{"_total", "_count", "_sum", "_bucket"}
+
+// counterFuncs are functions which are meaningful on the raw values of counters.
+var counterFuncs = map[string]struct{}{
+	"rate":             {},
+	"irate":            {},
+	"increase":         {},
+	"resets":           {},
+	"absent":           {},
+	"absent_over_time": {},
+	"count_over_time":  {},
+	"timestamp":        {},
+}
+
+// collapsingAggregations are aggregations which without grouping collapse all aggregated series into a single one.
+var collapsingAggregations = map[promql.ItemType]struct{}{
+	promql.SUM:      {},
+	promql.AVG:      {},
+	promql.MIN:      {},
+	promql.MAX:      {},
+	promql.STDDEV:   {},
+	promql.STDVAR:   {},
+	promql.QUANTILE: {},
+}
+
+type formatQueryData struct {
+	Query string     `json:"query"`
+	Lints []lintHint `json:"lints"`
+}
+
+// lintHint is a likely mistake in a query. Expressions of PromQL have no positions, so the offending expression itself
+// is given.
+type lintHint struct {
+	Expr    string `json:"expr"`
+	Message string `json:"message"`
+}
+
+// formatQuery responds with the query formatted the way PromQL prints its expressions. With lint parameter set, likely
+// mistakes in the query are responded along with it.
+func (api *API) formatQuery(r *http.Request) (interface{}, []error, *ApiError) {
+	expr, err := promql.ParseExpr(r.FormValue("query"))
+	if err != nil {
+		return nil, nil, &ApiError{errorBadData, err}
+	}
+
+	lint := false
+	if val := r.FormValue("lint"); val != "" {
+		lint, err = strconv.ParseBool(val)
+		if err != nil {
+			return nil, nil, &ApiError{errorBadData, errors.Wrapf(err, "'lint' parameter")}
+		}
+	}
+	if !lint {
+		return expr.String(), nil, nil
+	}
+	return &formatQueryData{Query: expr.String(), Lints: lintQuery(expr)}, nil, nil
+}
+
+// lintQuery returns hints about counters used without rate and aggregations collapsing all series of a metric.
+func lintQuery(expr promql.Expr) []lintHint {
+	hints := []lintHint{}
+	promql.Inspect(expr, func(node promql.Node, path []promql.Node) error {
+		if name, _, ok := selector(node); ok {
+			if isCounterName(name) && !withinCounterFunc(path) {
+				hints = append(hints, lintHint{
+					Expr:    node.String(),
+					Message: fmt.Sprintf("%q looks like a counter, its raw value only ever grows; consider rate or increase", name),
+				})
+			}
+			return nil
+		}
+		n, ok := node.(*promql.AggregateExpr)
+		if !ok {
+			return nil
+		}
+		if _, ok := collapsingAggregations[n.Op]; !ok || n.Without || len(n.Grouping) > 0 {
+			return nil
+		}
+		for _, name := range unfilteredMetrics(n.Expr) {
+			hints = append(hints, lintHint{
+				Expr:    n.String(),
+				Message: fmt.Sprintf("%s aggregates all series of %q into a single one; consider label matchers or grouping by labels", n.Op, name),
+			})
+		}
+		return nil
+	})
+	return hints
+}
+
+// selector returns the metric name and matchers of vector and matrix selectors.
+func selector(node promql.Node) (string, []*labels.Matcher, bool) {
+	switch n := node.(type) {
+	case *promql.VectorSelector:
+		return n.Name, n.LabelMatchers, true
+	case *promql.MatrixSelector:
+		return n.Name, n.LabelMatchers, true
+	}
+	return "", nil, false
+}
+
+func isCounterName(name string) bool {
+	for _, s := range counterSuffixes {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func withinCounterFunc(path []promql.Node) bool {
+	for _, n := range path {
+		switch n := n.(type) {
+		case *promql.Call:
+			if _, ok := counterFuncs[n.Func.Name]; ok {
+				return true
+			}
+		case *promql.AggregateExpr:
+			if n.Op == promql.COUNT || n.Op == promql.COUNT_VALUES {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unfilteredMetrics returns names of metrics selected by the node without any matchers but the name, thus selecting
+// all of their series. Nested aggregations are linted on their own, so they are not descended into.
+func unfilteredMetrics(node promql.Node) []string {
+	if _, ok := node.(*promql.AggregateExpr); ok {
+		return nil
+	}
+	if name, matchers, ok := selector(node); ok {
+		if name == "" {
+			return nil
+		}
+		for _, m := range matchers {
+			if m.Name != labels.MetricName {
+				return nil
+			}
+		}
+		return []string{name}
+	}
+
+	var names []string
+	for _, c := range promql.Children(node) {
+		names = append(names, unfilteredMetrics(c)...)
+	}
+	return names
+}
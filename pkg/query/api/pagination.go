@@ -0,0 +1,107 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// page is a page of results of a paginated API, responded with the cursor of the next page if there is one.
+type page struct {
+	items      interface{}
+	nextCursor string
+}
+
+// pageCursor is the position of a page in results of paginated APIs. Results of stores are merged in sorted order, so
+// the last item of the previous page is all that is needed to continue enumerating them.
+type pageCursor struct {
+	Series labels.Labels `json:"s,omitempty"`
+	Value  string        `json:"v,omitempty"`
+}
+
+func encodeCursor(c pageCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errors.Wrap(err, "decode cursor")
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, errors.Wrap(err, "decode cursor")
+	}
+	return c, nil
+}
+
+// parsePaginationParams returns the limit and the cursor of the request. Zero limit means results are not paginated.
+func parsePaginationParams(r *http.Request) (limit int, cursor *pageCursor, _ *ApiError) {
+	if val := r.FormValue("limit"); val != "" {
+		var err error
+		limit, err = strconv.Atoi(val)
+		if err != nil || limit < 0 {
+			return 0, nil, &ApiError{errorBadData, errors.Errorf("cannot parse parameter limit %q to a non-negative integer", val)}
+		}
+	}
+	if val := r.FormValue("cursor"); val != "" {
+		if limit == 0 {
+			return 0, nil, &ApiError{errorBadData, errors.New("parameter cursor requires parameter limit")}
+		}
+		c, err := decodeCursor(val)
+		if err != nil {
+			return 0, nil, &ApiError{errorBadData, err}
+		}
+		cursor = &c
+	}
+	return limit, cursor, nil
+}
+
+// paginateValues returns the page of sorted values following the cursor.
+func paginateValues(vals []string, limit int, cursor *pageCursor) interface{} {
+	if limit == 0 {
+		return vals
+	}
+	if cursor != nil {
+		vals = vals[sort.Search(len(vals), func(i int) bool { return vals[i] > cursor.Value }):]
+	}
+	p := &page{items: vals}
+	if len(vals) > limit {
+		p.items = vals[:limit]
+		p.nextCursor = encodeCursor(pageCursor{Value: vals[limit-1]})
+	}
+	return p
+}
+
+// paginateSeries returns the page of labels of series of the sorted set following the cursor. Series of later pages
+// are not iterated.
+func paginateSeries(set storage.SeriesSet, limit int, cursor *pageCursor) (interface{}, error) {
+	metrics := []labels.Labels{}
+	for set.Next() {
+		lset := set.At().Labels()
+		if cursor != nil && labels.Compare(lset, cursor.Series) <= 0 {
+			continue
+		}
+		if limit > 0 && len(metrics) == limit {
+			return &page{items: metrics, nextCursor: encodeCursor(pageCursor{Series: metrics[limit-1]})}, nil
+		}
+		metrics = append(metrics, lset)
+	}
+	if set.Err() != nil {
+		return nil, set.Err()
+	}
+	if limit == 0 {
+		return metrics, nil
+	}
+	return &page{items: metrics}, nil
+}
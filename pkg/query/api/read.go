@@ -0,0 +1,206 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package v1
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/thanos-io/thanos/pkg/audit"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	// remoteReadSampleLimit is the maximum number of samples of a single query of sampled remote read responses,
+	// as the default of Prometheus.
+	remoteReadSampleLimit = 5e7
+	// remoteReadMaxBytesInFrame is the maximum size of frames of streamed remote read responses, as the default of
+	// Prometheus.
+	remoteReadMaxBytesInFrame = 1024 * 1024
+)
+
+// remoteRead implements the Prometheus remote read API, with sampled and streamed XOR chunks responses. Series
+// are deduplicated, and are read from raw data unless max_source_resolution is set, as for other APIs.
+func (api *API) remoteRead(w http.ResponseWriter, r *http.Request) {
+	req, err := remote.DecodeReadRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	matcherSets := make([][]*labels.Matcher, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		matchers, err := remote.FromLabelMatchers(q.Matchers)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		matcherSets = append(matcherSets, matchers)
+	}
+
+	var e *audit.Event
+	if api.auditLogger != nil {
+		e = api.newAuditEvent(r, "remote_read")
+		for _, ms := range matcherSets {
+			e.Matchers = append(e.Matchers, audit.FormatMatchers(ms))
+		}
+		r = r.WithContext(audit.ContextWithEvent(r.Context(), e))
+	}
+	series, apiErr := api.serveRemoteRead(w, r, req, matcherSets)
+	if e != nil {
+		if apiErr != nil {
+			e.Error = apiErr.Error()
+		}
+		e.ResultSize = series
+		api.auditLogger.Log(*e)
+	}
+	if apiErr != nil {
+		http.Error(w, apiErr.Err.Error(), apiErrorCode(apiErr.Typ))
+	}
+}
+
+// serveRemoteRead writes the response of the remote read request and returns the number of series in it. An error
+// is returned only if nothing was written yet.
+func (api *API) serveRemoteRead(w http.ResponseWriter, r *http.Request, req *prompb.ReadRequest, matcherSets [][]*labels.Matcher) (int, *ApiError) {
+	enableDedup, apiErr := api.parseEnableDedupParam(r)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	replicaLabels, apiErr := api.parseReplicaLabelsParam(r)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	maxSourceResolution, apiErr := api.parseDownsamplingParamMillis(r, 0)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	enablePartialResponse, apiErr := api.parsePartialResponseParam(r)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+
+	enforced, apiErr := api.authorize(r, "remote_read", matcherSets)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	for i := range matcherSets {
+		matcherSets[i] = append(matcherSets[i], enforced...)
+	}
+
+	responseType, err := remote.NegotiateResponseType(req.AcceptedResponseTypes)
+	if err != nil {
+		return 0, &ApiError{errorBadData, err}
+	}
+
+	queryable := api.queryableCreate(enableDedup, replicaLabels, maxSourceResolution, enablePartialResponse, false)
+	selectSeries := func(i int, fn func(storage.SeriesSet) error) error {
+		q := req.Queries[i]
+		querier, err := queryable.Querier(r.Context(), q.StartTimestampMs, q.EndTimestampMs)
+		if err != nil {
+			return err
+		}
+		defer runutil.CloseWithLogOnErr(api.logger, querier, "queryable remote read")
+
+		var params *storage.SelectParams
+		if q.Hints != nil {
+			params = &storage.SelectParams{
+				Start: q.Hints.StartMs,
+				End:   q.Hints.EndMs,
+				Step:  q.Hints.StepMs,
+				Func:  q.Hints.Func,
+			}
+		}
+		set, warnings, err := querier.Select(params, matcherSets[i]...)
+		if err != nil {
+			return err
+		}
+		// Remote read responses cannot carry warnings of partial responses.
+		for _, w := range warnings {
+			level.Warn(api.logger).Log("msg", "partial response of remote read", "warning", w)
+		}
+		return fn(set)
+	}
+
+	var series int
+	switch responseType {
+	case prompb.ReadRequest_STREAMED_XOR_CHUNKS:
+		f, ok := w.(http.Flusher)
+		if !ok {
+			return 0, &ApiError{ErrorInternal, errors.New("internal http.ResponseWriter does not implement http.Flusher interface")}
+		}
+		w.Header().Set("Content-Type", "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse")
+
+		sw := &startedWriter{Writer: w}
+		for i := range req.Queries {
+			err := selectSeries(i, func(set storage.SeriesSet) error {
+				return remote.StreamChunkedReadResponses(remote.NewChunkedWriter(sw, f), int64(i), &countingSeriesSet{SeriesSet: set, n: &series}, nil, remoteReadMaxBytesInFrame)
+			})
+			if err != nil {
+				if !sw.started {
+					return 0, &ApiError{errorExec, err}
+				}
+				// Frames were written already, so the response is truncated instead.
+				level.Error(api.logger).Log("msg", "streaming remote read response", "err", err)
+				return series, nil
+			}
+		}
+		return series, nil
+	default:
+		// On empty or unknown types in req.AcceptedResponseTypes we default to non streamed, raw samples response.
+		resp := prompb.ReadResponse{
+			Results: make([]*prompb.QueryResult, len(req.Queries)),
+		}
+		for i := range req.Queries {
+			err := selectSeries(i, func(set storage.SeriesSet) (err error) {
+				resp.Results[i], err = remote.ToQueryResult(set, remoteReadSampleLimit)
+				return err
+			})
+			if err != nil {
+				if httpErr, ok := err.(remote.HTTPError); ok && httpErr.Status() == http.StatusBadRequest {
+					return 0, &ApiError{errorBadData, err}
+				}
+				return 0, &ApiError{errorExec, err}
+			}
+			series += len(resp.Results[i].Timeseries)
+		}
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Header().Set("Content-Encoding", "snappy")
+		if err := remote.EncodeReadResponse(&resp, w); err != nil {
+			level.Error(api.logger).Log("msg", "writing remote read response", "err", err)
+		}
+		return series, nil
+	}
+}
+
+// startedWriter records whether writing a response started.
+type startedWriter struct {
+	io.Writer
+	started bool
+}
+
+func (w *startedWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.Writer.Write(b)
+}
+
+// countingSeriesSet counts series iterated by streamed responses.
+type countingSeriesSet struct {
+	storage.SeriesSet
+	n *int
+}
+
+func (s *countingSeriesSet) Next() bool {
+	if !s.SeriesSet.Next() {
+		return false
+	}
+	*s.n++
+	return true
+}
@@ -0,0 +1,117 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package v1
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/query"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+// storeTypeAPIs are gRPC APIs served by Thanos components next to the StoreAPI. The Info API does not tell which APIs
+// a store serves, so they are known for Thanos components only.
+var storeTypeAPIs = map[component.StoreAPI][]string{
+	component.Sidecar: {"status"},
+	component.Receive: {"status"},
+}
+
+type storesData struct {
+	// MinTime, MaxTime and LabelSets are the topology of the global view, i.e. of all stores queries fan out to.
+	MinTime   int64           `json:"minTime"`
+	MaxTime   int64           `json:"maxTime"`
+	LabelSets []labels.Labels `json:"labelSets"`
+	Stores    []storeInfo     `json:"stores"`
+}
+
+type storeInfo struct {
+	Name      string          `json:"name"`
+	StoreType string          `json:"storeType"`
+	APIs      []string        `json:"apis"`
+	MinTime   int64           `json:"minTime"`
+	MaxTime   int64           `json:"maxTime"`
+	LabelSets []labels.Labels `json:"labelSets"`
+	Zone      string          `json:"zone,omitempty"`
+	LastCheck *time.Time      `json:"lastCheck,omitempty"`
+	LastError string          `json:"lastError,omitempty"`
+	Healthy   bool            `json:"healthy"`
+	Drained   bool            `json:"drained"`
+}
+
+// stores responds with the Info API data of all stores known to the querier, and the time range and label sets of the
+// global view they make up.
+func (api *API) stores(r *http.Request) (interface{}, []error, *ApiError) {
+	// External labels of all stores are shown, so tenants allowed to read a subset of series only are denied.
+	if apiErr := api.authorizeLabels(r, "stores"); apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	var statuses []query.StoreStatus
+	if api.storeStatuses != nil {
+		statuses = api.storeStatuses()
+	}
+
+	res := storesData{
+		MinTime:   math.MaxInt64,
+		MaxTime:   math.MinInt64,
+		LabelSets: []labels.Labels{},
+		Stores:    make([]storeInfo, 0, len(statuses)),
+	}
+	seen := map[uint64]struct{}{}
+	for _, s := range statuses {
+		info := storeInfo{
+			Name:      s.Name,
+			APIs:      []string{"store"},
+			MinTime:   s.MinTime,
+			MaxTime:   s.MaxTime,
+			LabelSets: make([]labels.Labels, 0, len(s.LabelSets)),
+			Zone:      s.Zone,
+			Healthy:   s.LastError == nil,
+			Drained:   s.Drained,
+		}
+		if s.StoreType != nil {
+			info.StoreType = s.StoreType.String()
+			info.APIs = append(info.APIs, storeTypeAPIs[s.StoreType]...)
+		}
+		if !s.LastCheck.IsZero() {
+			lastCheck := s.LastCheck
+			info.LastCheck = &lastCheck
+		}
+		if s.LastError != nil {
+			info.LastError = s.LastError.Error()
+		}
+		for _, ls := range s.LabelSets {
+			info.LabelSets = append(info.LabelSets, storepb.LabelsToPromLabels(ls.Labels))
+		}
+		res.Stores = append(res.Stores, info)
+
+		if !info.Healthy || info.Drained {
+			continue
+		}
+		if info.MinTime < res.MinTime {
+			res.MinTime = info.MinTime
+		}
+		if info.MaxTime > res.MaxTime {
+			res.MaxTime = info.MaxTime
+		}
+		for _, lset := range info.LabelSets {
+			if _, ok := seen[lset.Hash()]; ok {
+				continue
+			}
+			seen[lset.Hash()] = struct{}{}
+			res.LabelSets = append(res.LabelSets, lset)
+		}
+	}
+	if res.MinTime > res.MaxTime {
+		// No store is queried, so there is no time range.
+		res.MinTime, res.MaxTime = 0, 0
+	}
+	sort.Slice(res.LabelSets, func(i, j int) bool { return labels.Compare(res.LabelSets[i], res.LabelSets[j]) < 0 })
+	return res, nil, nil
+}
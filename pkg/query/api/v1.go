@@ -39,10 +39,18 @@ import (
 	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/thanos-io/thanos/pkg/audit"
+	"github.com/thanos-io/thanos/pkg/authz"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	"github.com/thanos-io/thanos/pkg/query"
+	"github.com/thanos-io/thanos/pkg/querylog"
+	"github.com/thanos-io/thanos/pkg/ratelimit"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	httpserver "github.com/thanos-io/thanos/pkg/server/http"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/tracing"
+	"github.com/thanos-io/thanos/pkg/usage"
 )
 
 type status string
@@ -61,6 +69,11 @@ const (
 	errorExec     ErrorType = "execution"
 	errorBadData  ErrorType = "bad_data"
 	ErrorInternal ErrorType = "internal"
+	// ErrorNotFound and ErrorUnauthorized are used by APIs managing resources.
+	ErrorNotFound     ErrorType = "not_found"
+	ErrorUnauthorized ErrorType = "unauthorized"
+	// ErrorForbidden is used for requests denied by authorization.
+	ErrorForbidden ErrorType = "forbidden"
 )
 
 var corsHeaders = map[string]string{
@@ -85,6 +98,8 @@ type response struct {
 	ErrorType ErrorType   `json:"errorType,omitempty"`
 	Error     string      `json:"error,omitempty"`
 	Warnings  []string    `json:"warnings,omitempty"`
+	// NextCursor is the cursor of the next page of paginated APIs, if there is one.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 // Enables cross-site script calls.
@@ -102,6 +117,8 @@ type API struct {
 	logger          log.Logger
 	queryableCreate query.QueryableCreator
 	queryEngine     *promql.Engine
+	statusClients   func() []store.StatusClient
+	storeStatuses   func() []query.StoreStatus
 
 	enableAutodownsampling                 bool
 	enablePartialResponse                  bool
@@ -109,6 +126,22 @@ type API struct {
 	reg                                    prometheus.Registerer
 	defaultInstantQueryMaxSourceResolution time.Duration
 
+	// authorizer authorizes requests if set, using the tenant in the tenantHeader header.
+	authorizer   authz.Authorizer
+	tenantHeader string
+	// auditLogger logs audit events of requests if set.
+	auditLogger *audit.Logger
+	// queryLogger logs executed queries if set.
+	queryLogger *querylog.Logger
+	// rateLimiter limits the rate of requests if set.
+	rateLimiter *ratelimit.Limiter
+	// costEstimator rejects or downsamples queries exceeding its cost budget if set.
+	costEstimator *query.CostEstimator
+	// accountant accounts query seconds to the tenant in the tenantHeader header if set.
+	accountant *usage.Accountant
+	// exporter exports results of range queries to the bucket if set.
+	exporter *Exporter
+
 	now func() time.Time
 }
 
@@ -122,6 +155,16 @@ func NewAPI(
 	enablePartialResponse bool,
 	replicaLabels []string,
 	defaultInstantQueryMaxSourceResolution time.Duration,
+	authorizer authz.Authorizer,
+	tenantHeader string,
+	auditLogger *audit.Logger,
+	rateLimiter *ratelimit.Limiter,
+	statusClients func() []store.StatusClient,
+	storeStatuses func() []query.StoreStatus,
+	costEstimator *query.CostEstimator,
+	accountant *usage.Accountant,
+	queryLogger *querylog.Logger,
+	exporter *Exporter,
 ) *API {
 	return &API{
 		logger:                                 logger,
@@ -132,6 +175,16 @@ func NewAPI(
 		replicaLabels:                          replicaLabels,
 		reg:                                    reg,
 		defaultInstantQueryMaxSourceResolution: defaultInstantQueryMaxSourceResolution,
+		authorizer:                             authorizer,
+		tenantHeader:                           tenantHeader,
+		auditLogger:                            auditLogger,
+		rateLimiter:                            rateLimiter,
+		statusClients:                          statusClients,
+		storeStatuses:                          storeStatuses,
+		costEstimator:                          costEstimator,
+		accountant:                             accountant,
+		queryLogger:                            queryLogger,
+		exporter:                               exporter,
 
 		now: time.Now,
 	}
@@ -140,16 +193,43 @@ func NewAPI(
 // Register the API's endpoints in the given router.
 func (api *API) Register(r *route.Router, tracer opentracing.Tracer, logger log.Logger, ins extpromhttp.InstrumentationMiddleware) {
 	instr := func(name string, f ApiFunc) http.HandlerFunc {
-		hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var hf http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			SetCORS(w)
-			if data, warnings, err := f(r); err != nil {
+			// The tenant is forwarded to StoreAPIs, so their usage is accounted to it.
+			r = r.WithContext(usage.OutgoingContextWithTenant(r.Context(), api.tenantHeader, r.Header.Get(api.tenantHeader)))
+			var e *audit.Event
+			if api.auditLogger != nil && name != "options" {
+				e = api.newAuditEvent(r, name)
+				r = r.WithContext(audit.ContextWithEvent(r.Context(), e))
+			}
+			var ql *querylog.Entry
+			if api.queryLogger != nil && (name == "query" || name == "query_range") {
+				ql = &querylog.Entry{Tenant: r.Header.Get(api.tenantHeader), API: name}
+				r = r.WithContext(querylog.ContextWithEntry(r.Context(), ql))
+				cw := &countingResponseWriter{ResponseWriter: w}
+				w = cw
+				defer func() { api.logQuery(ql, cw.n) }()
+			}
+			data, warnings, err := f(r)
+			if e != nil {
+				api.logAuditEvent(e, data, err)
+			}
+			if ql != nil && err != nil {
+				ql.Status, ql.Error = string(err.Typ), err.Err.Error()
+			}
+			if err != nil {
 				RespondError(w, err, data)
+			} else if p, ok := data.(*page); ok {
+				respond(w, p.items, warnings, p.nextCursor)
 			} else if data != nil {
 				Respond(w, data, warnings)
 			} else {
 				w.WriteHeader(http.StatusNoContent)
 			}
 		})
+		if api.rateLimiter != nil && name != "options" {
+			hf = api.rateLimiter.Handler(hf)
+		}
 		return ins.NewHandler(name, tracing.HTTPMiddleware(tracer, name, logger, gziphandler.GzipHandler(hf)))
 	}
 
@@ -161,6 +241,11 @@ func (api *API) Register(r *route.Router, tracer opentracing.Tracer, logger log.
 	r.Get("/query_range", instr("query_range", api.queryRange))
 	r.Post("/query_range", instr("query_range", api.queryRange))
 
+	if api.exporter != nil {
+		r.Post("/query_range/export", instr("query_range_export", api.queryRangeExport))
+		r.Get("/query_range/export/:id", instr("query_range_export_status", api.queryRangeExportStatus))
+	}
+
 	r.Get("/label/:name/values", instr("label_values", api.labelValues))
 
 	r.Get("/series", instr("series", api.series))
@@ -168,6 +253,20 @@ func (api *API) Register(r *route.Router, tracer opentracing.Tracer, logger log.
 
 	r.Get("/labels", instr("label_names", api.labelNames))
 	r.Post("/labels", instr("label_names", api.labelNames))
+
+	r.Get("/format_query", instr("format_query", api.formatQuery))
+	r.Post("/format_query", instr("format_query", api.formatQuery))
+
+	r.Get("/status/tsdb", instr("tsdb_status", api.tsdbStatus))
+
+	r.Get("/stores", instr("stores", api.stores))
+
+	// Remote read responses are protobuf, so they are not wrapped by instr.
+	var rr http.Handler = http.HandlerFunc(api.remoteRead)
+	if api.rateLimiter != nil {
+		rr = api.rateLimiter.Handler(rr)
+	}
+	r.Post("/read", ins.NewHandler("remote_read", tracing.HTTPMiddleware(tracer, "remote_read", logger, rr)))
 }
 
 type queryData struct {
@@ -292,16 +391,31 @@ func (api *API) query(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
+	qs, apiErr := api.authorizeQuery(r, "query", r.FormValue("query"))
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	maxSourceResolution, costWarnings, apiErr := api.checkQueryCost(qs, ts, ts, maxSourceResolution)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	// We are starting promQL tracing span here, because we have no control over promQL code.
 	span, ctx := tracing.StartSpan(ctx, "promql_instant_query")
 	defer span.Finish()
 
-	qry, err := api.queryEngine.NewInstantQuery(api.queryableCreate(enableDedup, replicaLabels, maxSourceResolution, enablePartialResponse, false), r.FormValue("query"), ts)
+	qry, err := api.queryEngine.NewInstantQuery(api.queryableCreate(enableDedup, replicaLabels, maxSourceResolution, enablePartialResponse, false), qs, ts)
 	if err != nil {
 		return nil, nil, &ApiError{errorBadData, err}
 	}
 
+	begin := time.Now()
 	res := qry.Exec(ctx)
+	api.accountant.Add(r.Header.Get(api.tenantHeader), usage.Usage{QuerySeconds: time.Since(begin).Seconds()})
+	if e := querylog.EntryFromContext(r.Context()); e != nil {
+		e.Query, e.Start, e.End, e.Duration = qs, ts, ts, time.Since(begin)
+	}
 	if res.Err != nil {
 		switch res.Err.(type) {
 		case promql.ErrQueryCanceled:
@@ -317,103 +431,155 @@ func (api *API) query(r *http.Request) (interface{}, []error, *ApiError) {
 	return &queryData{
 		ResultType: res.Value.Type(),
 		Result:     res.Value,
-	}, res.Warnings, nil
+	}, append(costWarnings, res.Warnings...), nil
 }
 
-func (api *API) queryRange(r *http.Request) (interface{}, []error, *ApiError) {
+// rangeQuery is a parsed and authorized range query request.
+type rangeQuery struct {
+	qs         string
+	start, end time.Time
+	step       time.Duration
+	// timeout is the timeout requested for the query, if any.
+	timeout  time.Duration
+	qry      promql.Query
+	warnings []error
+}
+
+// parseRangeQuery parses, authorizes and creates the range query of the given request.
+func (api *API) parseRangeQuery(r *http.Request) (*rangeQuery, *ApiError) {
 	start, err := parseTime(r.FormValue("start"))
 	if err != nil {
-		return nil, nil, &ApiError{errorBadData, err}
+		return nil, &ApiError{errorBadData, err}
 	}
 	end, err := parseTime(r.FormValue("end"))
 	if err != nil {
-		return nil, nil, &ApiError{errorBadData, err}
+		return nil, &ApiError{errorBadData, err}
 	}
 	if end.Before(start) {
 		err := errors.New("end timestamp must not be before start time")
-		return nil, nil, &ApiError{errorBadData, err}
+		return nil, &ApiError{errorBadData, err}
 	}
 
 	step, err := parseDuration(r.FormValue("step"))
 	if err != nil {
-		return nil, nil, &ApiError{errorBadData, errors.Wrap(err, "param step")}
+		return nil, &ApiError{errorBadData, errors.Wrap(err, "param step")}
 	}
 
 	if step <= 0 {
 		err := errors.New("zero or negative query resolution step widths are not accepted. Try a positive integer")
-		return nil, nil, &ApiError{errorBadData, err}
+		return nil, &ApiError{errorBadData, err}
 	}
 
 	// For safety, limit the number of returned points per timeseries.
 	// This is sufficient for 60s resolution for a week or 1h resolution for a year.
 	if end.Sub(start)/step > 11000 {
 		err := errors.New("exceeded maximum resolution of 11,000 points per timeseries. Try decreasing the query resolution (?step=XX)")
-		return nil, nil, &ApiError{errorBadData, err}
+		return nil, &ApiError{errorBadData, err}
 	}
 
-	ctx := r.Context()
+	var timeout time.Duration
 	if to := r.FormValue("timeout"); to != "" {
-		var cancel context.CancelFunc
-		timeout, err := parseDuration(to)
+		timeout, err = parseDuration(to)
 		if err != nil {
-			return nil, nil, &ApiError{errorBadData, err}
+			return nil, &ApiError{errorBadData, err}
 		}
-
-		ctx, cancel = context.WithTimeout(ctx, timeout)
-		defer cancel()
 	}
 
 	enableDedup, apiErr := api.parseEnableDedupParam(r)
 	if apiErr != nil {
-		return nil, nil, apiErr
+		return nil, apiErr
 	}
 
 	replicaLabels, apiErr := api.parseReplicaLabelsParam(r)
 	if apiErr != nil {
-		return nil, nil, apiErr
+		return nil, apiErr
 	}
 
 	// If no max_source_resolution is specified fit at least 5 samples between steps.
 	maxSourceResolution, apiErr := api.parseDownsamplingParamMillis(r, step/5)
 	if apiErr != nil {
-		return nil, nil, apiErr
+		return nil, apiErr
 	}
 
 	enablePartialResponse, apiErr := api.parsePartialResponseParam(r)
 	if apiErr != nil {
-		return nil, nil, apiErr
+		return nil, apiErr
 	}
 
-	// We are starting promQL tracing span here, because we have no control over promQL code.
-	span, ctx := tracing.StartSpan(ctx, "promql_range_query")
-	defer span.Finish()
+	qs, apiErr := api.authorizeQuery(r, "query_range", r.FormValue("query"))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	maxSourceResolution, costWarnings, apiErr := api.checkQueryCost(qs, start, end, maxSourceResolution)
+	if apiErr != nil {
+		return nil, apiErr
+	}
 
 	qry, err := api.queryEngine.NewRangeQuery(
 		api.queryableCreate(enableDedup, replicaLabels, maxSourceResolution, enablePartialResponse, false),
-		r.FormValue("query"),
+		qs,
 		start,
 		end,
 		step,
 	)
 	if err != nil {
-		return nil, nil, &ApiError{errorBadData, err}
+		return nil, &ApiError{errorBadData, err}
 	}
 
-	res := qry.Exec(ctx)
+	return &rangeQuery{
+		qs:       qs,
+		start:    start,
+		end:      end,
+		step:     step,
+		timeout:  timeout,
+		qry:      qry,
+		warnings: costWarnings,
+	}, nil
+}
+
+func (api *API) queryRange(r *http.Request) (interface{}, []error, *ApiError) {
+	rq, apiErr := api.parseRangeQuery(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	ctx := r.Context()
+	if rq.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rq.timeout)
+		defer cancel()
+	}
+
+	// We are starting promQL tracing span here, because we have no control over promQL code.
+	span, ctx := tracing.StartSpan(ctx, "promql_range_query")
+	defer span.Finish()
+
+	begin := time.Now()
+	res := rq.qry.Exec(ctx)
+	api.accountant.Add(r.Header.Get(api.tenantHeader), usage.Usage{QuerySeconds: time.Since(begin).Seconds()})
+	if e := querylog.EntryFromContext(r.Context()); e != nil {
+		e.Query, e.Start, e.End, e.Step, e.Duration = rq.qs, rq.start, rq.end, rq.step, time.Since(begin)
+	}
 	if res.Err != nil {
-		switch res.Err.(type) {
-		case promql.ErrQueryCanceled:
-			return nil, nil, &ApiError{errorCanceled, res.Err}
-		case promql.ErrQueryTimeout:
-			return nil, nil, &ApiError{errorTimeout, res.Err}
-		}
-		return nil, nil, &ApiError{errorExec, res.Err}
+		return nil, nil, queryExecError(res.Err)
 	}
 
 	return &queryData{
 		ResultType: res.Value.Type(),
 		Result:     res.Value,
-	}, res.Warnings, nil
+	}, append(rq.warnings, res.Warnings...), nil
+}
+
+// queryExecError returns the API error of a failed query execution.
+func queryExecError(err error) *ApiError {
+	switch err.(type) {
+	case promql.ErrQueryCanceled:
+		return &ApiError{errorCanceled, err}
+	case promql.ErrQueryTimeout:
+		return &ApiError{errorTimeout, err}
+	}
+	return &ApiError{errorExec, err}
 }
 
 func (api *API) labelValues(r *http.Request) (interface{}, []error, *ApiError) {
@@ -429,6 +595,15 @@ func (api *API) labelValues(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
+	limit, cursor, apiErr := parsePaginationParams(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	if apiErr := api.authorizeLabels(r, "label_values"); apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	q, err := api.queryableCreate(true, nil, 0, enablePartialResponse, false).Querier(ctx, math.MinInt64, math.MaxInt64)
 	if err != nil {
 		return nil, nil, &ApiError{errorExec, err}
@@ -442,7 +617,7 @@ func (api *API) labelValues(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, &ApiError{errorExec, err}
 	}
 
-	return vals, warnings, nil
+	return paginateValues(vals, limit, cursor), warnings, nil
 }
 
 var (
@@ -505,6 +680,19 @@ func (api *API) series(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
+	limit, cursor, apiErr := parsePaginationParams(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	enforced, apiErr := api.authorize(r, "series", matcherSets)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	for i := range matcherSets {
+		matcherSets[i] = append(matcherSets[i], enforced...)
+	}
+
 	q, err := api.queryableCreate(enableDedup, replicaLabels, math.MaxInt64, enablePartialResponse, true).
 		Querier(r.Context(), timestamp.FromTime(start), timestamp.FromTime(end))
 	if err != nil {
@@ -514,7 +702,6 @@ func (api *API) series(r *http.Request) (interface{}, []error, *ApiError) {
 
 	var (
 		warnings []error
-		metrics  = []labels.Labels{}
 		sets     []storage.SeriesSet
 	)
 	for _, mset := range matcherSets {
@@ -526,17 +713,252 @@ func (api *API) series(r *http.Request) (interface{}, []error, *ApiError) {
 		sets = append(sets, s)
 	}
 
-	set := storage.NewMergeSeriesSet(sets, nil)
-	for set.Next() {
-		metrics = append(metrics, set.At().Labels())
-	}
-	if set.Err() != nil {
-		return nil, nil, &ApiError{errorExec, set.Err()}
+	metrics, err := paginateSeries(storage.NewMergeSeriesSet(sets, nil), limit, cursor)
+	if err != nil {
+		return nil, nil, &ApiError{errorExec, err}
 	}
 	return metrics, warnings, nil
 }
 
+// tsdbHeadStats are statistics of the head blocks of all stores, as in the TSDB status API of Prometheus.
+type tsdbHeadStats struct {
+	NumSeries  uint64 `json:"numSeries"`
+	ChunkCount uint64 `json:"chunkCount"`
+	MinTime    int64  `json:"minTime"`
+	MaxTime    int64  `json:"maxTime"`
+}
+
+// tsdbStat is an item of top lists of the TSDB status API.
+type tsdbStat struct {
+	Name  string `json:"name"`
+	Value uint64 `json:"value"`
+}
+
+// tsdbStatus is the response of the TSDB status API, compatible with the TSDB status API of Prometheus.
+type tsdbStatus struct {
+	HeadStats                   tsdbHeadStats `json:"headStats"`
+	SeriesCountByMetricName     []tsdbStat    `json:"seriesCountByMetricName"`
+	LabelValueCountByLabelName  []tsdbStat    `json:"labelValueCountByLabelName"`
+	MemoryInBytesByLabelName    []tsdbStat    `json:"memoryInBytesByLabelName"`
+	SeriesCountByLabelValuePair []tsdbStat    `json:"seriesCountByLabelValuePair"`
+}
+
+func tsdbStats(stats []storepb.Statistic) []tsdbStat {
+	res := make([]tsdbStat, 0, len(stats))
+	for _, s := range stats {
+		res = append(res, tsdbStat{Name: s.Name, Value: s.Value})
+	}
+	return res
+}
+
+// tsdbStatus returns cardinality statistics of the head blocks of all stores implementing the Status API, e.g.
+// sidecars and receivers, merged into a single global view.
+func (api *API) tsdbStatus(r *http.Request) (interface{}, []error, *ApiError) {
+	enablePartialResponse, apiErr := api.parsePartialResponseParam(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	// Statistics are not limited to series of the tenant, so tenants allowed to read a subset of series only are denied.
+	if apiErr := api.authorizeLabels(r, "tsdb_status"); apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	var clients []store.StatusClient
+	if api.statusClients != nil {
+		clients = api.statusClients()
+	}
+	s, warnings, err := store.TSDBStatus(r.Context(), clients, enablePartialResponse)
+	if err != nil {
+		return nil, nil, &ApiError{errorExec, err}
+	}
+
+	res := tsdbStatus{
+		HeadStats: tsdbHeadStats{
+			NumSeries:  s.NumSeries,
+			ChunkCount: s.NumChunks,
+			MinTime:    s.MinTime,
+			MaxTime:    s.MaxTime,
+		},
+		SeriesCountByMetricName:     tsdbStats(s.SeriesCountByMetricName),
+		LabelValueCountByLabelName:  tsdbStats(s.LabelValueCountByLabelName),
+		MemoryInBytesByLabelName:    tsdbStats(s.MemoryInBytesByLabelName),
+		SeriesCountByLabelValuePair: tsdbStats(s.SeriesCountByLabelValuePair),
+	}
+	return res, warnings, nil
+}
+
+// checkQueryCost checks the estimated cost of the query against the budget, if a cost estimator is set. It returns the
+// maximum source resolution to evaluate the query with, with a warning if it was increased to fit the budget.
+func (api *API) checkQueryCost(qs string, start, end time.Time, maxSourceResolution int64) (int64, []error, *ApiError) {
+	if api.costEstimator == nil {
+		return maxSourceResolution, nil, nil
+	}
+	res, err := api.costEstimator.Check(qs, start, end, maxSourceResolution)
+	if err != nil {
+		return 0, nil, &ApiError{errorBadData, err}
+	}
+	if res == maxSourceResolution {
+		return res, nil, nil
+	}
+	return res, []error{errors.Errorf("query is evaluated with max_source_resolution of %s to fit the cost budget", time.Duration(res)*time.Millisecond)}, nil
+}
+
+// authorize authorizes the request of the given API, reading series selected by the given matchers. It returns
+// matchers which have to be added to all series selectors of the request.
+func (api *API) authorize(r *http.Request, apiName string, matcherSets [][]*labels.Matcher) ([]*labels.Matcher, *ApiError) {
+	if api.authorizer == nil {
+		return nil, nil
+	}
+
+	e := audit.EventFromContext(r.Context())
+	user, _ := httpserver.UserFromContext(r.Context())
+	d, err := api.authorizer.Authorize(r.Context(), authz.NewRequest(r.Header.Get(api.tenantHeader), user, apiName, matcherSets))
+	if err != nil {
+		if e != nil {
+			e.Decision = audit.DecisionError
+		}
+		return nil, &ApiError{ErrorInternal, errors.Wrap(err, "authorize request")}
+	}
+	if !d.Allow {
+		if e != nil {
+			e.Decision = audit.DecisionDeny
+		}
+		return nil, &ApiError{ErrorForbidden, errors.Errorf("request denied: %s", d.Reason)}
+	}
+	if e != nil {
+		e.Decision = audit.DecisionAllow
+		if len(d.Matchers) > 0 {
+			e.EnforcedMatchers = []string{audit.FormatMatchers(d.Matchers)}
+		}
+	}
+	return d.Matchers, nil
+}
+
+// newAuditEvent returns the audit event of the request of the given API, with details known before it is handled.
+func (api *API) newAuditEvent(r *http.Request, name string) *audit.Event {
+	user, _ := httpserver.UserFromContext(r.Context())
+	e := &audit.Event{
+		Tenant: r.Header.Get(api.tenantHeader),
+		User:   user,
+		API:    name,
+		Query:  r.FormValue("query"),
+	}
+	if name == "series" {
+		e.Matchers = r.Form["match[]"]
+	}
+	return e
+}
+
+// logAuditEvent logs the audit event with the result of the request.
+func (api *API) logAuditEvent(e *audit.Event, data interface{}, apiErr *ApiError) {
+	if apiErr != nil {
+		e.Error = apiErr.Error()
+	}
+	if p, ok := data.(*page); ok {
+		data = p.items
+	}
+	switch d := data.(type) {
+	case *queryData:
+		switch v := d.Result.(type) {
+		case promql.Vector:
+			e.ResultSize = len(v)
+		case promql.Matrix:
+			e.ResultSize = len(v)
+		case promql.Scalar, promql.String:
+			e.ResultSize = 1
+		}
+	case []labels.Labels:
+		e.ResultSize = len(d)
+	case []string:
+		e.ResultSize = len(d)
+	}
+	api.auditLogger.Log(*e)
+}
+
+// countingResponseWriter counts bytes written to the response.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *countingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// logQuery logs the query log entry of the request with the size of the response, if a query was executed.
+func (api *API) logQuery(e *querylog.Entry, responseBytes int64) {
+	if e.Query == "" {
+		return
+	}
+	if e.Status == "" {
+		e.Status = querylog.StatusSuccess
+	}
+	e.ResponseBytes = responseBytes
+	api.queryLogger.Log(*e)
+}
+
+// authorizeQuery authorizes the PromQL query of the given API, and returns the query with matchers enforced by the
+// authorizer added to all its series selectors.
+func (api *API) authorizeQuery(r *http.Request, apiName string, qs string) (string, *ApiError) {
+	if api.authorizer == nil {
+		return qs, nil
+	}
+
+	expr, err := promql.ParseExpr(qs)
+	if err != nil {
+		return "", &ApiError{errorBadData, err}
+	}
+	var matcherSets [][]*labels.Matcher
+	promql.Inspect(expr, func(node promql.Node, _ []promql.Node) error {
+		switch n := node.(type) {
+		case *promql.VectorSelector:
+			matcherSets = append(matcherSets, n.LabelMatchers)
+		case *promql.MatrixSelector:
+			matcherSets = append(matcherSets, n.LabelMatchers)
+		}
+		return nil
+	})
+
+	enforced, apiErr := api.authorize(r, apiName, matcherSets)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	if len(enforced) == 0 {
+		return qs, nil
+	}
+	promql.Inspect(expr, func(node promql.Node, _ []promql.Node) error {
+		switch n := node.(type) {
+		case *promql.VectorSelector:
+			n.LabelMatchers = append(n.LabelMatchers, enforced...)
+		case *promql.MatrixSelector:
+			n.LabelMatchers = append(n.LabelMatchers, enforced...)
+		}
+		return nil
+	})
+	return expr.String(), nil
+}
+
+// authorizeLabels authorizes the request of the given label API. Label APIs cannot be limited to series selected by
+// matchers, so requests are denied if the authorizer enforces any.
+func (api *API) authorizeLabels(r *http.Request, apiName string) *ApiError {
+	enforced, apiErr := api.authorize(r, apiName, nil)
+	if apiErr != nil {
+		return apiErr
+	}
+	if len(enforced) > 0 {
+		return &ApiError{ErrorForbidden, errors.New("request denied: labels cannot be limited to series allowed by authorization")}
+	}
+	return nil
+}
+
 func Respond(w http.ResponseWriter, data interface{}, warnings []error) {
+	respond(w, data, warnings, "")
+}
+
+func respond(w http.ResponseWriter, data interface{}, warnings []error, nextCursor string) {
 	w.Header().Set("Content-Type", "application/json")
 	if len(warnings) > 0 {
 		w.Header().Set("Cache-Control", "no-store")
@@ -544,8 +966,9 @@ func Respond(w http.ResponseWriter, data interface{}, warnings []error) {
 	w.WriteHeader(http.StatusOK)
 
 	resp := &response{
-		Status: statusSuccess,
-		Data:   data,
+		Status:     statusSuccess,
+		Data:       data,
+		NextCursor: nextCursor,
 	}
 	for _, warn := range warnings {
 		resp.Warnings = append(resp.Warnings, warn.Error())
@@ -556,9 +979,20 @@ func Respond(w http.ResponseWriter, data interface{}, warnings []error) {
 func RespondError(w http.ResponseWriter, apiErr *ApiError, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(apiErrorCode(apiErr.Typ))
 
+	_ = json.NewEncoder(w).Encode(&response{
+		Status:    statusError,
+		ErrorType: apiErr.Typ,
+		Error:     apiErr.Err.Error(),
+		Data:      data,
+	})
+}
+
+// apiErrorCode returns the HTTP status code of errors of the given type.
+func apiErrorCode(typ ErrorType) int {
 	var code int
-	switch apiErr.Typ {
+	switch typ {
 	case errorBadData:
 		code = http.StatusBadRequest
 	case errorExec:
@@ -567,17 +1001,16 @@ func RespondError(w http.ResponseWriter, apiErr *ApiError, data interface{}) {
 		code = http.StatusServiceUnavailable
 	case ErrorInternal:
 		code = http.StatusInternalServerError
+	case ErrorNotFound:
+		code = http.StatusNotFound
+	case ErrorUnauthorized:
+		code = http.StatusUnauthorized
+	case ErrorForbidden:
+		code = http.StatusForbidden
 	default:
 		code = http.StatusInternalServerError
 	}
-	w.WriteHeader(code)
-
-	_ = json.NewEncoder(w).Encode(&response{
-		Status:    statusError,
-		ErrorType: apiErr.Typ,
-		Error:     apiErr.Err.Error(),
-		Data:      data,
-	})
+	return code
 }
 
 func parseTime(s string) (time.Time, error) {
@@ -613,6 +1046,15 @@ func (api *API) labelNames(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
+	limit, cursor, apiErr := parsePaginationParams(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	if apiErr := api.authorizeLabels(r, "label_names"); apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	q, err := api.queryableCreate(true, nil, 0, enablePartialResponse, false).Querier(ctx, math.MinInt64, math.MaxInt64)
 	if err != nil {
 		return nil, nil, &ApiError{errorExec, err}
@@ -624,5 +1066,5 @@ func (api *API) labelNames(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, &ApiError{errorExec, err}
 	}
 
-	return names, warnings, nil
+	return paginateValues(names, limit, cursor), warnings, nil
 }
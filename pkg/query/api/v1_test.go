@@ -17,6 +17,7 @@
 package v1
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -26,6 +27,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -33,19 +36,30 @@ import (
 
 	"github.com/fortytw2/leaktest"
 	"github.com/go-kit/kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/route"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/thanos-io/thanos/pkg/audit"
+	"github.com/thanos-io/thanos/pkg/authz"
 	"github.com/thanos-io/thanos/pkg/compact"
 	"github.com/thanos-io/thanos/pkg/component"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	"github.com/thanos-io/thanos/pkg/query"
+	"github.com/thanos-io/thanos/pkg/querylog"
 	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
 	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 )
 
 func TestEndpoints(t *testing.T) {
@@ -101,7 +115,7 @@ func TestEndpoints(t *testing.T) {
 
 	now := time.Now()
 	api := &API{
-		queryableCreate: query.NewQueryableCreator(nil, store.NewTSDBStore(nil, nil, db, component.Query, nil)),
+		queryableCreate: query.NewQueryableCreator(nil, nil, store.NewTSDBStore(nil, nil, db, component.Query, nil), 0),
 		queryEngine: promql.NewEngine(promql.EngineOpts{
 			Logger:        nil,
 			Reg:           nil,
@@ -1152,3 +1166,855 @@ func TestParseDownsamplingParamMillis(t *testing.T) {
 
 	}
 }
+
+func TestAuthorization(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	db, err := e2eutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	app := db.Appender()
+	for _, lbls := range []labels.Labels{
+		labels.FromStrings("__name__", "test_metric1", "foo", "bar"),
+		labels.FromStrings("__name__", "test_metric1", "foo", "boo"),
+		labels.FromStrings("__name__", "test_metric2", "foo", "boo"),
+	} {
+		_, err := app.Add(lbls, 0, 1)
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	authorizer, err := authz.NewStaticAuthorizer([]byte(`
+tenants:
+  team-a:
+    matchers: ['foo="bar"']
+  team-b:
+    apis: [label_names]
+`))
+	testutil.Ok(t, err)
+	api := &API{
+		queryableCreate: query.NewQueryableCreator(nil, nil, store.NewTSDBStore(nil, nil, db, component.Query, nil), 0),
+		queryEngine: promql.NewEngine(promql.EngineOpts{
+			MaxConcurrent: 20,
+			MaxSamples:    10000,
+			Timeout:       100 * time.Second,
+		}),
+		authorizer:   authorizer,
+		tenantHeader: "THANOS-TENANT",
+		now:          func() time.Time { return time.Unix(1, 0) },
+	}
+
+	for _, tcase := range []struct {
+		name     string
+		endpoint ApiFunc
+		tenant   string
+		query    url.Values
+		response interface{}
+		errType  ErrorType
+	}{
+		{
+			name:     "instant query with enforced matchers",
+			endpoint: api.query,
+			tenant:   "team-a",
+			query:    url.Values{"query": []string{`sum by (foo) (test_metric1) + on() group_left count(test_metric2)`}},
+			response: &queryData{
+				ResultType: promql.ValueTypeVector,
+				Result:     promql.Vector{},
+			},
+		},
+		{
+			name:     "range query with enforced matchers",
+			endpoint: api.queryRange,
+			tenant:   "team-a",
+			query: url.Values{
+				"query": []string{`count_over_time({__name__=~"test_metric.*"}[5m])`},
+				"start": []string{"0"},
+				"end":   []string{"0"},
+				"step":  []string{"1"},
+			},
+			response: &queryData{
+				ResultType: promql.ValueTypeMatrix,
+				Result: promql.Matrix{
+					promql.Series{Metric: labels.FromStrings("foo", "bar"), Points: []promql.Point{{T: 0, V: 1}}},
+				},
+			},
+		},
+		{
+			name:     "series with enforced matchers",
+			endpoint: api.series,
+			tenant:   "team-a",
+			query:    url.Values{"match[]": []string{`test_metric1`, `test_metric2`}},
+			response: []labels.Labels{labels.FromStrings("__name__", "test_metric1", "foo", "bar")},
+		},
+		{
+			name:     "label names cannot be limited by matchers",
+			endpoint: api.labelNames,
+			tenant:   "team-a",
+			errType:  ErrorForbidden,
+		},
+		{
+			name:     "allowed label names",
+			endpoint: api.labelNames,
+			tenant:   "team-b",
+			response: []string{"__name__", "foo"},
+		},
+		{
+			name:     "TSDB status cannot be limited by matchers",
+			endpoint: api.tsdbStatus,
+			tenant:   "team-a",
+			errType:  ErrorForbidden,
+		},
+		{
+			name:     "API not allowed",
+			endpoint: api.query,
+			tenant:   "team-b",
+			query:    url.Values{"query": []string{`test_metric1`}},
+			errType:  ErrorForbidden,
+		},
+		{
+			name:     "unknown tenant",
+			endpoint: api.series,
+			query:    url.Values{"match[]": []string{`test_metric1`}},
+			errType:  ErrorForbidden,
+		},
+		{
+			name:     "invalid query",
+			endpoint: api.query,
+			tenant:   "team-a",
+			query:    url.Values{"query": []string{`test_metric1{`}},
+			errType:  errorBadData,
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "http://example.com?"+tcase.query.Encode(), nil)
+			testutil.Ok(t, err)
+			req.Header.Set("THANOS-TENANT", tcase.tenant)
+
+			resp, _, apiErr := tcase.endpoint(req)
+			if tcase.errType != errorNone {
+				testutil.Assert(t, apiErr != nil, "expected error of type %q, got none", tcase.errType)
+				testutil.Equals(t, tcase.errType, apiErr.Typ)
+				return
+			}
+			testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+			testutil.Equals(t, tcase.response, resp)
+		})
+	}
+}
+
+type testStatusClient struct {
+	resp *storepb.TSDBStatusResponse
+	err  error
+}
+
+func (c testStatusClient) TSDBStatus(context.Context, *storepb.TSDBStatusRequest, ...grpc.CallOption) (*storepb.TSDBStatusResponse, error) {
+	return c.resp, c.err
+}
+
+func (c testStatusClient) String() string { return "test" }
+
+func TestTSDBStatus(t *testing.T) {
+	api := &API{
+		statusClients: func() []store.StatusClient {
+			return []store.StatusClient{
+				testStatusClient{resp: &storepb.TSDBStatusResponse{
+					NumSeries:               2,
+					NumChunks:               3,
+					MinTime:                 1000,
+					MaxTime:                 2000,
+					SeriesCountByMetricName: []storepb.Statistic{{Name: "up", Value: 2}},
+				}},
+				testStatusClient{resp: &storepb.TSDBStatusResponse{
+					NumSeries:               1,
+					NumChunks:               1,
+					MinTime:                 1500,
+					MaxTime:                 2500,
+					SeriesCountByMetricName: []storepb.Statistic{{Name: "up", Value: 1}},
+				}},
+				testStatusClient{err: grpcstatus.Error(codes.Unimplemented, "unknown service thanos.Status")},
+			}
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	testutil.Ok(t, err)
+	resp, warnings, apiErr := api.tsdbStatus(req)
+	testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+	testutil.Equals(t, 0, len(warnings))
+	testutil.Equals(t, tsdbStatus{
+		HeadStats:                   tsdbHeadStats{NumSeries: 3, ChunkCount: 4, MinTime: 1000, MaxTime: 2500},
+		SeriesCountByMetricName:     []tsdbStat{{Name: "up", Value: 3}},
+		LabelValueCountByLabelName:  []tsdbStat{},
+		MemoryInBytesByLabelName:    []tsdbStat{},
+		SeriesCountByLabelValuePair: []tsdbStat{},
+	}, resp)
+}
+
+func TestQueryCost(t *testing.T) {
+	estimator := query.NewCostEstimator(log.NewNopLogger(), nil, func() []store.StatusClient {
+		return []store.StatusClient{
+			testStatusClient{resp: &storepb.TSDBStatusResponse{
+				NumSeries:               100,
+				SeriesCountByMetricName: []storepb.Statistic{{Name: "up", Value: 100}},
+			}},
+		}
+	}, 100000, 15*time.Second, true)
+	testutil.Ok(t, estimator.Refresh(context.Background()))
+	api := &API{costEstimator: estimator, now: time.Now}
+
+	// 100 series read over 10d are 5760100 samples raw, 288100 at 5m resolution and 24100 at 1h.
+	start, end := time.Unix(0, 0), time.Unix(10*24*3600, 0)
+	res, warnings, apiErr := api.checkQueryCost("up", start, end, 0)
+	testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+	testutil.Equals(t, compact.ResolutionLevel1h, compact.ResolutionLevel(res))
+	testutil.Equals(t, 1, len(warnings))
+
+	res, warnings, apiErr = api.checkQueryCost("up", end, end, 0)
+	testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+	testutil.Equals(t, int64(0), res)
+	testutil.Equals(t, 0, len(warnings))
+
+	_, _, apiErr = api.checkQueryCost("up[100d]", start, end, 0)
+	testutil.Assert(t, apiErr != nil, "expected query exceeding the budget to be rejected")
+	testutil.Equals(t, errorBadData, apiErr.Typ)
+}
+
+func TestAuditLogging(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	db, err := e2eutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	app := db.Appender()
+	for _, lbls := range []labels.Labels{
+		labels.FromStrings("__name__", "test_metric1", "foo", "bar"),
+		labels.FromStrings("__name__", "test_metric1", "foo", "boo"),
+	} {
+		_, err := app.Add(lbls, 0, 1)
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	dir, err := ioutil.TempDir("", "audit")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+	auditFile := filepath.Join(dir, "audit.log")
+	auditLogger, err := audit.NewLogger(log.NewNopLogger(), nil, []byte(`
+sink:
+  type: FILE
+  path: `+auditFile+`
+redaction_rules:
+  - label_names: foo
+`))
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, auditLogger.Close()) }()
+
+	authorizer, err := authz.NewStaticAuthorizer([]byte(`
+tenants:
+  team-a:
+    matchers: ['foo="bar"']
+`))
+	testutil.Ok(t, err)
+	api := &API{
+		logger:          log.NewNopLogger(),
+		queryableCreate: query.NewQueryableCreator(nil, nil, store.NewTSDBStore(nil, nil, db, component.Query, nil), 0),
+		queryEngine: promql.NewEngine(promql.EngineOpts{
+			MaxConcurrent: 20,
+			MaxSamples:    10000,
+			Timeout:       100 * time.Second,
+		}),
+		authorizer:   authorizer,
+		tenantHeader: "THANOS-TENANT",
+		auditLogger:  auditLogger,
+		now:          func() time.Time { return time.Unix(1, 0) },
+	}
+	r := route.New()
+	api.Register(r, &opentracing.NoopTracer{}, log.NewNopLogger(), extpromhttp.NewNopInstrumentationMiddleware())
+
+	for _, tcase := range []struct {
+		path   string
+		tenant string
+		query  url.Values
+	}{
+		{path: "/query", tenant: "team-a", query: url.Values{"query": []string{`test_metric1{foo=~"b.*"}`}}},
+		{path: "/series", tenant: "team-b", query: url.Values{"match[]": []string{`test_metric1`}}},
+		{path: "/series", tenant: "team-a", query: url.Values{"match[]": []string{`test_metric1`}}},
+	} {
+		req, err := http.NewRequest(http.MethodGet, "http://example.com"+tcase.path+"?"+tcase.query.Encode(), nil)
+		testutil.Ok(t, err)
+		req.Header.Set("THANOS-TENANT", tcase.tenant)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	b, err := ioutil.ReadFile(auditFile)
+	testutil.Ok(t, err)
+	var events []audit.Event
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var e audit.Event
+		testutil.Ok(t, json.Unmarshal([]byte(line), &e))
+		e.Time = time.Time{}
+		events = append(events, e)
+	}
+	testutil.Equals(t, []audit.Event{
+		{
+			Tenant:           "team-a",
+			API:              "query",
+			Query:            `test_metric1{foo=~"<redacted>"}`,
+			Decision:         audit.DecisionAllow,
+			EnforcedMatchers: []string{`{foo="<redacted>"}`},
+			ResultSize:       1,
+		},
+		{
+			Tenant:   "team-b",
+			API:      "series",
+			Matchers: []string{`{__name__="test_metric1"}`},
+			Decision: audit.DecisionDeny,
+			Error:    "forbidden: request denied: no policy for tenant team-b",
+		},
+		{
+			Tenant:           "team-a",
+			API:              "series",
+			Matchers:         []string{`{__name__="test_metric1"}`},
+			Decision:         audit.DecisionAllow,
+			EnforcedMatchers: []string{`{foo="<redacted>"}`},
+			ResultSize:       1,
+		},
+	}, events)
+}
+
+func TestQueryLogging(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	db, err := e2eutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	app := db.Appender()
+	for _, lbls := range []labels.Labels{
+		labels.FromStrings("__name__", "test_metric1", "foo", "bar"),
+		labels.FromStrings("__name__", "test_metric1", "foo", "boo"),
+	} {
+		_, err := app.Add(lbls, 0, 1)
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	dir, err := ioutil.TempDir("", "query-log")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+	queryLogFile := filepath.Join(dir, "query.log")
+	queryLogger, err := querylog.NewLogger(log.NewNopLogger(), nil, []byte(`
+sink:
+  type: FILE
+  path: `+queryLogFile+`
+`))
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, queryLogger.Close()) }()
+
+	api := &API{
+		logger:          log.NewNopLogger(),
+		queryableCreate: query.NewQueryableCreator(nil, nil, store.NewTSDBStore(nil, nil, db, component.Query, nil), 0),
+		queryEngine: promql.NewEngine(promql.EngineOpts{
+			MaxConcurrent: 20,
+			MaxSamples:    10000,
+			Timeout:       100 * time.Second,
+		}),
+		tenantHeader: "THANOS-TENANT",
+		queryLogger:  queryLogger,
+		now:          func() time.Time { return time.Unix(1, 0) },
+	}
+	r := route.New()
+	api.Register(r, &opentracing.NoopTracer{}, log.NewNopLogger(), extpromhttp.NewNopInstrumentationMiddleware())
+
+	for _, tcase := range []struct {
+		path  string
+		query url.Values
+	}{
+		{path: "/query", query: url.Values{"query": []string{`test_metric1{foo="bar"}`}}},
+		{path: "/query_range", query: url.Values{"query": []string{`sum( test_metric1 )`}, "start": []string{"0"}, "end": []string{"60"}, "step": []string{"30"}}},
+		{path: "/query", query: url.Values{"query": []string{`test_metric1 + on() test_metric1`}}},
+		// Queries which are not executed and other APIs are not logged.
+		{path: "/query", query: url.Values{"query": []string{`test_metric1{`}}},
+		{path: "/series", query: url.Values{"match[]": []string{`test_metric1`}}},
+	} {
+		req, err := http.NewRequest(http.MethodGet, "http://example.com"+tcase.path+"?"+tcase.query.Encode(), nil)
+		testutil.Ok(t, err)
+		req.Header.Set("THANOS-TENANT", "team-a")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	b, err := ioutil.ReadFile(queryLogFile)
+	testutil.Ok(t, err)
+	var entries []querylog.Entry
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var e querylog.Entry
+		testutil.Ok(t, json.Unmarshal([]byte(line), &e))
+		testutil.Assert(t, e.ResponseBytes > 0, "expected response bytes of %s", e.Query)
+		e.Time, e.DurationSeconds, e.ResponseBytes, e.Error = time.Time{}, 0, 0, ""
+		e.Start, e.End = e.Start.UTC(), e.End.UTC()
+		entries = append(entries, e)
+	}
+	testutil.Equals(t, []querylog.Entry{
+		{Tenant: "team-a", API: "query", Query: `test_metric1{foo="bar"}`, Start: time.Unix(1, 0).UTC(), End: time.Unix(1, 0).UTC(), Status: querylog.StatusSuccess},
+		{Tenant: "team-a", API: "query_range", Query: `sum(test_metric1)`, Start: time.Unix(0, 0).UTC(), End: time.Unix(60, 0).UTC(), StepSeconds: 30, Status: querylog.StatusSuccess},
+		{Tenant: "team-a", API: "query", Query: `test_metric1 + on() test_metric1`, Start: time.Unix(1, 0).UTC(), End: time.Unix(1, 0).UTC(), Status: string(errorExec)},
+	}, entries)
+}
+
+func TestRemoteRead(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	db, err := e2eutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	app := db.Appender()
+	for _, lbls := range []labels.Labels{
+		labels.FromStrings("__name__", "test_metric1", "foo", "bar", "replica", "a"),
+		labels.FromStrings("__name__", "test_metric1", "foo", "bar", "replica", "b"),
+		labels.FromStrings("__name__", "test_metric1", "foo", "boo", "replica", "a"),
+	} {
+		for i := int64(0); i < 3; i++ {
+			_, err := app.Add(lbls, i*1000, float64(i))
+			testutil.Ok(t, err)
+		}
+	}
+	testutil.Ok(t, app.Commit())
+
+	authorizer, err := authz.NewStaticAuthorizer([]byte(`
+tenants:
+  team-a:
+    matchers: ['foo="bar"']
+`))
+	testutil.Ok(t, err)
+	api := &API{
+		queryableCreate: query.NewQueryableCreator(nil, nil, store.NewTSDBStore(nil, nil, db, component.Query, nil), 0),
+		replicaLabels:   []string{"replica"},
+	}
+
+	read := func(t *testing.T, api *API, url, tenant string, req *prompb.ReadRequest) *httptest.ResponseRecorder {
+		b, err := proto.Marshal(req)
+		testutil.Ok(t, err)
+		r := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(snappy.Encode(nil, b)))
+		r.Header.Set("THANOS-TENANT", tenant)
+		rec := httptest.NewRecorder()
+		api.remoteRead(rec, r)
+		return rec
+	}
+	queries := []*prompb.Query{{
+		StartTimestampMs: 0,
+		EndTimestampMs:   2000,
+		Matchers:         []*prompb.LabelMatcher{{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "test_metric1"}},
+	}}
+	samples := []prompb.Sample{{Timestamp: 0, Value: 0}, {Timestamp: 1000, Value: 1}, {Timestamp: 2000, Value: 2}}
+
+	t.Run("sampled", func(t *testing.T) {
+		rec := read(t, api, "/api/v1/read", "", &prompb.ReadRequest{Queries: queries})
+		testutil.Equals(t, http.StatusOK, rec.Code)
+		testutil.Equals(t, "snappy", rec.Header().Get("Content-Encoding"))
+
+		b, err := snappy.Decode(nil, rec.Body.Bytes())
+		testutil.Ok(t, err)
+		var resp prompb.ReadResponse
+		testutil.Ok(t, proto.Unmarshal(b, &resp))
+		testutil.Equals(t, 1, len(resp.Results))
+		// Replicas are deduplicated.
+		testutil.Equals(t, []*prompb.TimeSeries{
+			{Labels: []prompb.Label{{Name: "__name__", Value: "test_metric1"}, {Name: "foo", Value: "bar"}}, Samples: samples},
+			{Labels: []prompb.Label{{Name: "__name__", Value: "test_metric1"}, {Name: "foo", Value: "boo"}}, Samples: samples},
+		}, resp.Results[0].Timeseries)
+	})
+
+	t.Run("sampled without deduplication", func(t *testing.T) {
+		rec := read(t, api, "/api/v1/read?dedup=false", "", &prompb.ReadRequest{Queries: queries})
+		testutil.Equals(t, http.StatusOK, rec.Code)
+
+		b, err := snappy.Decode(nil, rec.Body.Bytes())
+		testutil.Ok(t, err)
+		var resp prompb.ReadResponse
+		testutil.Ok(t, proto.Unmarshal(b, &resp))
+		testutil.Equals(t, 3, len(resp.Results[0].Timeseries))
+	})
+
+	t.Run("streamed", func(t *testing.T) {
+		rec := read(t, api, "/api/v1/read", "", &prompb.ReadRequest{
+			Queries:               queries,
+			AcceptedResponseTypes: []prompb.ReadRequest_ResponseType{prompb.ReadRequest_STREAMED_XOR_CHUNKS},
+		})
+		testutil.Equals(t, http.StatusOK, rec.Code)
+		testutil.Equals(t, "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse", rec.Header().Get("Content-Type"))
+
+		var series [][]prompb.Label
+		r := remote.NewChunkedReader(rec.Body, remoteReadMaxBytesInFrame, nil)
+		for {
+			var resp prompb.ChunkedReadResponse
+			err := r.NextProto(&resp)
+			if err == io.EOF {
+				break
+			}
+			testutil.Ok(t, err)
+			testutil.Equals(t, int64(0), resp.QueryIndex)
+			for _, s := range resp.ChunkedSeries {
+				series = append(series, s.Labels)
+				testutil.Equals(t, 1, len(s.Chunks))
+				testutil.Equals(t, prompb.Chunk_XOR, s.Chunks[0].Type)
+			}
+		}
+		testutil.Equals(t, [][]prompb.Label{
+			{{Name: "__name__", Value: "test_metric1"}, {Name: "foo", Value: "bar"}},
+			{{Name: "__name__", Value: "test_metric1"}, {Name: "foo", Value: "boo"}},
+		}, series)
+	})
+
+	t.Run("enforced matchers", func(t *testing.T) {
+		api := *api
+		api.authorizer = authorizer
+		api.tenantHeader = "THANOS-TENANT"
+
+		rec := read(t, &api, "/api/v1/read", "team-a", &prompb.ReadRequest{Queries: queries})
+		testutil.Equals(t, http.StatusOK, rec.Code)
+		b, err := snappy.Decode(nil, rec.Body.Bytes())
+		testutil.Ok(t, err)
+		var resp prompb.ReadResponse
+		testutil.Ok(t, proto.Unmarshal(b, &resp))
+		testutil.Equals(t, 1, len(resp.Results[0].Timeseries))
+
+		rec = read(t, &api, "/api/v1/read", "team-b", &prompb.ReadRequest{Queries: queries})
+		testutil.Equals(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/read", bytes.NewReader([]byte("not snappy")))
+		rec := httptest.NewRecorder()
+		api.remoteRead(rec, r)
+		testutil.Equals(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestFederation(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	db, err := e2eutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	app := db.Appender()
+	for _, s := range []struct {
+		lbls labels.Labels
+		t    int64
+		v    float64
+	}{
+		{lbls: labels.FromStrings("__name__", "up", "job", "a", "replica", "a"), t: 60000, v: 1},
+		{lbls: labels.FromStrings("__name__", "up", "job", "a", "replica", "a"), t: 90000, v: 0},
+		{lbls: labels.FromStrings("__name__", "up", "job", "a", "replica", "b"), t: 90000, v: 0},
+		{lbls: labels.FromStrings("__name__", "up", "job", "b", "instance", "b:80"), t: 60000, v: 1},
+		{lbls: labels.FromStrings("__name__", "requests_total", "job", "a"), t: 60000, v: 10},
+		// Samples older than the lookback delta are not federated.
+		{lbls: labels.FromStrings("__name__", "up", "job", "c"), t: 0, v: 1},
+	} {
+		_, err := app.Add(s.lbls, s.t, s.v)
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	authorizer, err := authz.NewStaticAuthorizer([]byte(`
+tenants:
+  team-a:
+    matchers: ['job="b"']
+`))
+	testutil.Ok(t, err)
+	api := &API{
+		queryableCreate: query.NewQueryableCreator(nil, nil, store.NewTSDBStore(nil, nil, db, component.Query, nil), 0),
+		replicaLabels:   []string{"replica"},
+		now:             func() time.Time { return time.Unix(301, 0) },
+	}
+
+	federate := func(api *API, query url.Values, tenant string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/federate?"+query.Encode(), nil)
+		r.Header.Set("THANOS-TENANT", tenant)
+		rec := httptest.NewRecorder()
+		api.federation(rec, r)
+		return rec
+	}
+
+	rec := federate(api, url.Values{"match[]": []string{"up", `{__name__="requests_total"}`}}, "")
+	testutil.Equals(t, http.StatusOK, rec.Code)
+	testutil.Equals(t, `# TYPE requests_total untyped
+requests_total{job="a",instance=""} 10 60000
+# TYPE up untyped
+up{instance="b:80",job="b"} 1 60000
+up{job="a",instance=""} 0 90000
+`, rec.Body.String())
+
+	rec = federate(api, url.Values{"match[]": []string{"up{"}}, "")
+	testutil.Equals(t, http.StatusBadRequest, rec.Code)
+
+	api.authorizer = authorizer
+	api.tenantHeader = "THANOS-TENANT"
+	rec = federate(api, url.Values{"match[]": []string{"up"}}, "team-a")
+	testutil.Equals(t, http.StatusOK, rec.Code)
+	testutil.Equals(t, `# TYPE up untyped
+up{instance="b:80",job="b"} 1 60000
+`, rec.Body.String())
+
+	rec = federate(api, url.Values{"match[]": []string{"up"}}, "team-b")
+	testutil.Equals(t, http.StatusForbidden, rec.Code)
+}
+
+func TestPagination(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	db, err := e2eutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	app := db.Appender()
+	for _, lset := range []labels.Labels{
+		labels.FromStrings("__name__", "up", "job", "a"),
+		labels.FromStrings("__name__", "up", "job", "b"),
+		labels.FromStrings("__name__", "up", "job", "c"),
+		labels.FromStrings("__name__", "up", "instance", "d:80", "job", "d"),
+		labels.FromStrings("__name__", "requests_total", "job", "a"),
+	} {
+		_, err := app.Add(lset, 0, 1)
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	api := &API{
+		queryableCreate: query.NewQueryableCreator(nil, nil, store.NewTSDBStore(nil, nil, db, component.Query, nil), 0),
+		now:             func() time.Time { return time.Unix(0, 0) },
+	}
+
+	// enumerate calls the API with the given limit until there are no more pages, and returns all pages.
+	enumerate := func(f ApiFunc, ctx context.Context, query url.Values) []interface{} {
+		var pages []interface{}
+		for {
+			r, err := http.NewRequest(http.MethodGet, "http://example.com?"+query.Encode(), nil)
+			testutil.Ok(t, err)
+			data, _, apiErr := f(r.WithContext(ctx))
+			testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+
+			p, ok := data.(*page)
+			testutil.Assert(t, ok, "expected page, got %T", data)
+			pages = append(pages, p.items)
+			if p.nextCursor == "" {
+				return pages
+			}
+			query.Set("cursor", p.nextCursor)
+		}
+	}
+
+	testutil.Equals(t, []interface{}{
+		[]labels.Labels{
+			labels.FromStrings("__name__", "up", "instance", "d:80", "job", "d"),
+			labels.FromStrings("__name__", "up", "job", "a"),
+		},
+		[]labels.Labels{
+			labels.FromStrings("__name__", "up", "job", "b"),
+			labels.FromStrings("__name__", "up", "job", "c"),
+		},
+	}, enumerate(api.series, context.Background(), url.Values{"match[]": []string{"up"}, "limit": []string{"2"}}))
+
+	testutil.Equals(t, []interface{}{
+		[]string{"__name__", "instance"},
+		[]string{"job"},
+	}, enumerate(api.labelNames, context.Background(), url.Values{"limit": []string{"2"}}))
+
+	testutil.Equals(t, []interface{}{
+		[]string{"a", "b", "c"},
+		[]string{"d"},
+	}, enumerate(api.labelValues, route.WithParam(context.Background(), "name", "job"), url.Values{"limit": []string{"3"}}))
+
+	// Responses are not paginated without limit.
+	r, err := http.NewRequest(http.MethodGet, "http://example.com?match[]=requests_total", nil)
+	testutil.Ok(t, err)
+	data, _, apiErr := api.series(r)
+	testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+	testutil.Equals(t, []labels.Labels{labels.FromStrings("__name__", "requests_total", "job", "a")}, data)
+
+	for _, query := range []string{"limit=-1", "limit=a", "cursor=" + encodeCursor(pageCursor{Value: "a"}), "limit=1&cursor=e30x"} {
+		r, err := http.NewRequest(http.MethodGet, "http://example.com?"+query, nil)
+		testutil.Ok(t, err)
+		_, _, apiErr := api.labelNames(r)
+		testutil.Assert(t, apiErr != nil && apiErr.Typ == errorBadData, "expected bad data error for %q, got %v", query, apiErr)
+	}
+}
+
+func TestFormatQuery(t *testing.T) {
+	api := &API{}
+
+	for _, tc := range []struct {
+		query string
+		lint  bool
+
+		expected interface{}
+		errType  ErrorType
+	}{
+		{
+			query:    `sum(rate(http_requests_total{job="a"}[5m]))by(code)`,
+			expected: `sum by(code) (rate(http_requests_total{job="a"}[5m]))`,
+		},
+		{
+			query:   `sum(rate(`,
+			errType: errorBadData,
+		},
+		{
+			query:    `sum by(code) (rate(http_requests_total{job="a"}[5m]))`,
+			lint:     true,
+			expected: &formatQueryData{Query: `sum by(code) (rate(http_requests_total{job="a"}[5m]))`, Lints: []lintHint{}},
+		},
+		{
+			query: `http_requests_total{job="a"} > 0 or count(http_requests_total) > 0`,
+			lint:  true,
+			expected: &formatQueryData{
+				Query: `http_requests_total{job="a"} > 0 or count(http_requests_total) > 0`,
+				Lints: []lintHint{{
+					Expr:    `http_requests_total{job="a"}`,
+					Message: `"http_requests_total" looks like a counter, its raw value only ever grows; consider rate or increase`,
+				}},
+			},
+		},
+		{
+			query: `avg(up) + sum(rate(http_requests_total{job="a"}[5m]))`,
+			lint:  true,
+			expected: &formatQueryData{
+				Query: `avg(up) + sum(rate(http_requests_total{job="a"}[5m]))`,
+				Lints: []lintHint{{
+					Expr:    `avg(up)`,
+					Message: `avg aggregates all series of "up" into a single one; consider label matchers or grouping by labels`,
+				}},
+			},
+		},
+		{
+			// Only the innermost aggregation selects all series of the metric.
+			query: `max(sum without(instance) (up))`,
+			lint:  true,
+			expected: &formatQueryData{
+				Query: `max(sum without(instance) (up))`,
+				Lints: []lintHint{},
+			},
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			query := url.Values{"query": []string{tc.query}}
+			if tc.lint {
+				query.Set("lint", "true")
+			}
+			r, err := http.NewRequest(http.MethodGet, "http://example.com?"+query.Encode(), nil)
+			testutil.Ok(t, err)
+
+			data, _, apiErr := api.formatQuery(r)
+			if tc.errType != errorNone {
+				testutil.Assert(t, apiErr != nil, "expected error")
+				testutil.Equals(t, tc.errType, apiErr.Typ)
+				return
+			}
+			testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+			testutil.Equals(t, tc.expected, data)
+		})
+	}
+}
+
+func TestStores(t *testing.T) {
+	lastCheck := time.Unix(100, 0)
+	api := &API{
+		storeStatuses: func() []query.StoreStatus {
+			return []query.StoreStatus{
+				{
+					Name:      "sidecar-a:10901",
+					LastCheck: lastCheck,
+					StoreType: component.Sidecar,
+					LabelSets: []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "a"}, {Name: "replica", Value: "0"}}}},
+					MinTime:   1000,
+					MaxTime:   5000,
+				},
+				{
+					Name:      "store:10901",
+					LastCheck: lastCheck,
+					StoreType: component.Store,
+					LabelSets: []storepb.LabelSet{
+						{Labels: []storepb.Label{{Name: "cluster", Value: "a"}, {Name: "replica", Value: "0"}}},
+						{Labels: []storepb.Label{{Name: "cluster", Value: "b"}}},
+					},
+					MinTime: 0,
+					MaxTime: 3000,
+				},
+				{
+					// Drained and failing stores are not part of the global view.
+					Name:      "store-drained:10901",
+					LastCheck: lastCheck,
+					StoreType: component.Store,
+					LabelSets: []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "c"}}}},
+					MinTime:   -1000,
+					MaxTime:   3000,
+					Drained:   true,
+				},
+				{
+					Name:      "unknown:10901",
+					LastError: errors.New("connection refused"),
+				},
+			}
+		},
+	}
+
+	r, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	testutil.Ok(t, err)
+	data, _, apiErr := api.stores(r)
+	testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+	testutil.Equals(t, storesData{
+		MinTime: 0,
+		MaxTime: 5000,
+		LabelSets: []labels.Labels{
+			labels.FromStrings("cluster", "a", "replica", "0"),
+			labels.FromStrings("cluster", "b"),
+		},
+		Stores: []storeInfo{
+			{
+				Name:      "sidecar-a:10901",
+				StoreType: "sidecar",
+				APIs:      []string{"store", "status"},
+				MinTime:   1000,
+				MaxTime:   5000,
+				LabelSets: []labels.Labels{labels.FromStrings("cluster", "a", "replica", "0")},
+				LastCheck: &lastCheck,
+				Healthy:   true,
+			},
+			{
+				Name:      "store:10901",
+				StoreType: "store",
+				APIs:      []string{"store"},
+				MinTime:   0,
+				MaxTime:   3000,
+				LabelSets: []labels.Labels{labels.FromStrings("cluster", "a", "replica", "0"), labels.FromStrings("cluster", "b")},
+				LastCheck: &lastCheck,
+				Healthy:   true,
+			},
+			{
+				Name:      "store-drained:10901",
+				StoreType: "store",
+				APIs:      []string{"store"},
+				MinTime:   -1000,
+				MaxTime:   3000,
+				LabelSets: []labels.Labels{labels.FromStrings("cluster", "c")},
+				LastCheck: &lastCheck,
+				Healthy:   true,
+				Drained:   true,
+			},
+			{
+				Name:      "unknown:10901",
+				APIs:      []string{"store"},
+				LabelSets: []labels.Labels{},
+				LastError: "connection refused",
+			},
+		},
+	}, data)
+
+	// Without stores there is no global view.
+	api.storeStatuses = func() []query.StoreStatus { return nil }
+	data, _, apiErr = api.stores(r)
+	testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+	testutil.Equals(t, storesData{LabelSets: []labels.Labels{}, Stores: []storeInfo{}}, data)
+}
@@ -15,6 +15,8 @@ import (
 )
 
 type Config struct {
+	// Name allows rule groups to select this configuration via `query_config_name` field.
+	Name             string                    `yaml:"name"`
 	HTTPClientConfig http_util.ClientConfig    `yaml:"http_config"`
 	EndpointsConfig  http_util.EndpointsConfig `yaml:",inline"`
 }
@@ -42,6 +44,16 @@ func LoadConfigs(confYAML []byte) ([]Config, error) {
 	if err := yaml.UnmarshalStrict(confYAML, &queryCfg); err != nil {
 		return nil, err
 	}
+	names := map[string]struct{}{}
+	for _, cfg := range queryCfg {
+		if cfg.Name == "" {
+			continue
+		}
+		if _, ok := names[cfg.Name]; ok {
+			return nil, errors.Errorf("duplicated query configuration name %q", cfg.Name)
+		}
+		names[cfg.Name] = struct{}{}
+	}
 	return queryCfg, nil
 }
 
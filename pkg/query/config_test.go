@@ -93,3 +93,24 @@ func TestBuildQueryConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfigs(t *testing.T) {
+	cfgs, err := LoadConfigs([]byte(`
+- name: frontend
+  static_configs: ["query-frontend:9090"]
+- static_configs: ["query:9090"]
+`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(cfgs))
+	testutil.Equals(t, "frontend", cfgs[0].Name)
+	testutil.Equals(t, []string{"query-frontend:9090"}, cfgs[0].EndpointsConfig.StaticAddresses)
+	testutil.Equals(t, "", cfgs[1].Name)
+
+	_, err = LoadConfigs([]byte(`
+- name: frontend
+  static_configs: ["query-frontend-1:9090"]
+- name: frontend
+  static_configs: ["query-frontend-2:9090"]
+`))
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,243 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+// QueryCost is the estimated cost of a query.
+type QueryCost struct {
+	// Series is the number of series selected by all series selectors of the query.
+	Series uint64
+	// Samples is the number of samples read of them.
+	Samples uint64
+}
+
+// ErrQueryTooExpensive is returned for queries whose estimated cost exceeds the budget.
+type ErrQueryTooExpensive struct {
+	Cost   QueryCost
+	Budget uint64
+}
+
+func (e ErrQueryTooExpensive) Error() string {
+	return fmt.Sprintf("query is estimated to read %d samples of %d series, exceeding the budget of %d samples; "+
+		"select fewer series, e.g. by more specific matchers, or query a shorter time range or with a higher max_source_resolution",
+		e.Cost.Samples, e.Cost.Series, e.Budget)
+}
+
+// cardinalityStats are series counts of the head blocks of all stores, by metric name and label value pair. Only the
+// top ones are known; others are known to have at most as many series as the last top one.
+type cardinalityStats struct {
+	numSeries uint64
+
+	byMetricName        map[string]uint64
+	metricNameBound     uint64
+	byLabelValuePair    map[string]uint64
+	labelValuePairBound uint64
+}
+
+func newCardinalityStats(s *storepb.TSDBStatusResponse) *cardinalityStats {
+	c := &cardinalityStats{numSeries: s.NumSeries}
+	c.byMetricName, c.metricNameBound = statisticsBound(s.SeriesCountByMetricName, s.NumSeries)
+	c.byLabelValuePair, c.labelValuePairBound = statisticsBound(s.SeriesCountByLabelValuePair, s.NumSeries)
+	return c
+}
+
+// statisticsBound returns the given top statistics by name, and the upper bound of values not listed.
+func statisticsBound(stats []storepb.Statistic, total uint64) (map[string]uint64, uint64) {
+	res := make(map[string]uint64, len(stats))
+	for _, s := range stats {
+		res[s.Name] = s.Value
+	}
+	if len(stats) < store.TSDBStatusTopLimit {
+		// All are listed.
+		return res, 0
+	}
+	bound := stats[len(stats)-1].Value
+	if bound > total {
+		bound = total
+	}
+	return res, bound
+}
+
+// series estimates the number of series selected by the given matchers, as the lowest count of series of the metric
+// name and label value pairs matched exactly. Other matchers are not considered, so it is an upper bound.
+func (c *cardinalityStats) series(matchers []*labels.Matcher) uint64 {
+	res := c.numSeries
+	for _, m := range matchers {
+		if m.Type != labels.MatchEqual || m.Value == "" {
+			continue
+		}
+		var (
+			n  uint64
+			ok bool
+		)
+		if m.Name == labels.MetricName {
+			if n, ok = c.byMetricName[m.Value]; !ok {
+				n = c.metricNameBound
+			}
+		} else if n, ok = c.byLabelValuePair[m.Name+"="+m.Value]; !ok {
+			n = c.labelValuePairBound
+		}
+		if n < res {
+			res = n
+		}
+	}
+	return res
+}
+
+// CostEstimator estimates costs of queries before they are executed, from cardinality statistics of the head blocks
+// of stores implementing the Status API, e.g. sidecars and receivers, which are refreshed periodically. Queries
+// estimated to exceed the budget are rejected or, if enabled, evaluated on downsampled data, if that fits the budget.
+//
+// Estimates are rough: series selected by selectors are estimated by their metric names and label value pairs among
+// the top ones of the statistics, assuming series of the head blocks are the same over the queried time range, and
+// raw samples are assumed to be scraped every sample interval.
+type CostEstimator struct {
+	logger         log.Logger
+	statusClients  func() []store.StatusClient
+	budget         uint64
+	sampleInterval time.Duration
+	downsample     bool
+
+	mtx   sync.RWMutex
+	stats *cardinalityStats
+
+	decisions *prometheus.CounterVec
+}
+
+// NewCostEstimator returns a cost estimator with the given budget of samples read by queries. Samples of raw data are
+// assumed to be scraped every sampleInterval. If downsample is true, queries exceeding the budget are evaluated on
+// downsampled data if that fits the budget, instead of being rejected.
+func NewCostEstimator(logger log.Logger, reg prometheus.Registerer, statusClients func() []store.StatusClient, budget uint64, sampleInterval time.Duration, downsample bool) *CostEstimator {
+	return &CostEstimator{
+		logger:         logger,
+		statusClients:  statusClients,
+		budget:         budget,
+		sampleInterval: sampleInterval,
+		downsample:     downsample,
+		decisions: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_query_cost_decisions_total",
+			Help: "Total number of queries whose cost was estimated, by decision: accepted, downsampled or rejected.",
+		}, []string{"decision"}),
+	}
+}
+
+// Refresh fetches cardinality statistics of stores.
+func (c *CostEstimator) Refresh(ctx context.Context) error {
+	s, warnings, err := store.TSDBStatus(ctx, c.statusClients(), true)
+	if err != nil {
+		return errors.Wrap(err, "fetch TSDB statuses")
+	}
+	for _, w := range warnings {
+		level.Warn(c.logger).Log("msg", "cardinality statistics of store are not known", "err", w)
+	}
+
+	c.mtx.Lock()
+	c.stats = newCardinalityStats(s)
+	c.mtx.Unlock()
+	return nil
+}
+
+// Estimate estimates the cost of the given query evaluated from start to end, which are equal for instant queries,
+// with the given maximum source resolution in milliseconds. It returns a zero cost if statistics were not fetched yet.
+func (c *CostEstimator) Estimate(qs string, start, end time.Time, resolution int64) (QueryCost, error) {
+	expr, err := promql.ParseExpr(qs)
+	if err != nil {
+		return QueryCost{}, err
+	}
+
+	c.mtx.RLock()
+	stats := c.stats
+	c.mtx.RUnlock()
+	if stats == nil {
+		return QueryCost{}, nil
+	}
+
+	interval := time.Duration(resolution) * time.Millisecond
+	if interval < c.sampleInterval {
+		interval = c.sampleInterval
+	}
+	if interval <= 0 {
+		return QueryCost{}, errors.New("sample interval must be positive")
+	}
+
+	var cost QueryCost
+	span := end.Sub(start)
+	promql.Inspect(expr, func(node promql.Node, path []promql.Node) error {
+		var (
+			matchers []*labels.Matcher
+			window   time.Duration
+		)
+		switch n := node.(type) {
+		case *promql.VectorSelector:
+			matchers, window = n.LabelMatchers, promql.LookbackDelta
+		case *promql.MatrixSelector:
+			matchers, window = n.LabelMatchers, n.Range
+		default:
+			return nil
+		}
+		// Subqueries evaluate their expressions over their range before each step.
+		for _, p := range path {
+			if sq, ok := p.(*promql.SubqueryExpr); ok {
+				window += sq.Range
+			}
+		}
+
+		series := stats.series(matchers)
+		cost.Series += series
+		cost.Samples += series * uint64((span+window)/interval+1)
+		return nil
+	})
+	return cost, nil
+}
+
+// Check checks the estimated cost of the given query against the budget and returns the resolution to evaluate it
+// with. If the budget is exceeded, it returns a higher resolution fitting the budget if downsampling is enabled and
+// there is one, and an ErrQueryTooExpensive error otherwise.
+func (c *CostEstimator) Check(qs string, start, end time.Time, resolution int64) (int64, error) {
+	cost, err := c.Estimate(qs, start, end, resolution)
+	if err != nil {
+		return 0, err
+	}
+	if cost.Samples <= c.budget {
+		c.decisions.WithLabelValues("accepted").Inc()
+		return resolution, nil
+	}
+
+	if c.downsample {
+		for _, res := range []int64{downsample.ResLevel1, downsample.ResLevel2} {
+			if res <= resolution {
+				continue
+			}
+			dcost, err := c.Estimate(qs, start, end, res)
+			if err != nil {
+				return 0, err
+			}
+			if dcost.Samples <= c.budget {
+				c.decisions.WithLabelValues("downsampled").Inc()
+				level.Debug(c.logger).Log("msg", "query downsampled to fit cost budget", "query", strings.TrimSpace(qs), "samples", cost.Samples, "resolution", res)
+				return res, nil
+			}
+		}
+	}
+	c.decisions.WithLabelValues("rejected").Inc()
+	return 0, ErrQueryTooExpensive{Cost: cost, Budget: c.budget}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testStatusClient struct {
+	resp *storepb.TSDBStatusResponse
+	err  error
+}
+
+func (c testStatusClient) TSDBStatus(context.Context, *storepb.TSDBStatusRequest, ...grpc.CallOption) (*storepb.TSDBStatusResponse, error) {
+	return c.resp, c.err
+}
+
+func (c testStatusClient) String() string { return "test" }
+
+func newTestCostEstimator(t *testing.T, budget uint64, downsample bool) *CostEstimator {
+	c := NewCostEstimator(log.NewNopLogger(), nil, func() []store.StatusClient {
+		return []store.StatusClient{
+			testStatusClient{resp: &storepb.TSDBStatusResponse{
+				NumSeries:                   600,
+				SeriesCountByMetricName:     []storepb.Statistic{{Name: "http_requests_total", Value: 400}, {Name: "up", Value: 60}},
+				SeriesCountByLabelValuePair: []storepb.Statistic{{Name: "job=api", Value: 50}},
+			}},
+			testStatusClient{resp: &storepb.TSDBStatusResponse{
+				NumSeries:               400,
+				SeriesCountByMetricName: []storepb.Statistic{{Name: "http_requests_total", Value: 100}, {Name: "up", Value: 40}},
+			}},
+			testStatusClient{err: status.Error(codes.Unimplemented, "unknown service thanos.Status")},
+		}
+	}, budget, 15*time.Second, downsample)
+	testutil.Ok(t, c.Refresh(context.Background()))
+	return c
+}
+
+func TestCostEstimator_Estimate(t *testing.T) {
+	c := newTestCostEstimator(t, 0, false)
+	end := time.Unix(100000, 0)
+
+	for _, tcase := range []struct {
+		query string
+		start time.Time
+		exp   QueryCost
+	}{
+		// 5m of lookback delta of 15s samples.
+		{query: "up", start: end, exp: QueryCost{Series: 100, Samples: 100 * 21}},
+		{query: `rate(http_requests_total{job="api"}[1m])`, start: end.Add(-time.Hour), exp: QueryCost{Series: 50, Samples: 50 * 245}},
+		{query: `up / on(job) http_requests_total{job="api"}`, start: end, exp: QueryCost{Series: 150, Samples: 150 * 21}},
+		// Metric names not listed have no series, as the lists are not truncated.
+		{query: "unknown", start: end, exp: QueryCost{}},
+		{query: `{instance=~".+"}`, start: end, exp: QueryCost{Series: 1000, Samples: 1000 * 21}},
+		{query: "max_over_time(up[5m:1m])", start: end, exp: QueryCost{Series: 100, Samples: 100 * 41}},
+		{query: "1 + 1", start: end, exp: QueryCost{}},
+	} {
+		t.Run(tcase.query, func(t *testing.T) {
+			cost, err := c.Estimate(tcase.query, tcase.start, end, 0)
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.exp, cost)
+		})
+	}
+
+	_, err := c.Estimate("up{", end, end, 0)
+	testutil.NotOk(t, err)
+
+	// Metric names and label value pairs not listed in truncated lists have at most as many series as the last one.
+	var stats []storepb.Statistic
+	for i := 0; i < store.TSDBStatusTopLimit; i++ {
+		stats = append(stats, storepb.Statistic{Name: string(rune('a' + i)), Value: uint64(100 - i)})
+	}
+	s := newCardinalityStats(&storepb.TSDBStatusResponse{NumSeries: 1000, SeriesCountByMetricName: stats})
+	testutil.Equals(t, uint64(91), s.metricNameBound)
+	testutil.Equals(t, uint64(0), s.labelValuePairBound)
+}
+
+func TestCostEstimator_Check(t *testing.T) {
+	end := time.Unix(100000, 0)
+	start := end.Add(-24 * time.Hour)
+	// 500 series of raw samples over 25h are 3000500 samples, 150500 samples at 5m resolution and 13000 at 1h.
+	const query = "rate(http_requests_total[1h])"
+
+	c := newTestCostEstimator(t, 200000, false)
+	res, err := c.Check(query, end, end, 0)
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(0), res)
+
+	_, err = c.Check(query, start, end, 0)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, ErrQueryTooExpensive{Cost: QueryCost{Series: 500, Samples: 3000500}, Budget: 200000}, err)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(c.decisions.WithLabelValues("accepted")))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(c.decisions.WithLabelValues("rejected")))
+
+	// With downsampling, the lowest resolution fitting the budget is returned.
+	c = newTestCostEstimator(t, 200000, true)
+	res, err = c.Check(query, start, end, 0)
+	testutil.Ok(t, err)
+	testutil.Equals(t, downsample.ResLevel1, res)
+
+	c = newTestCostEstimator(t, 20000, true)
+	res, err = c.Check(query, start, end, 0)
+	testutil.Ok(t, err)
+	testutil.Equals(t, downsample.ResLevel2, res)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(c.decisions.WithLabelValues("downsampled")))
+
+	c = newTestCostEstimator(t, 10000, true)
+	_, err = c.Check(query, start, end, 0)
+	testutil.NotOk(t, err)
+
+	// Queries are accepted until statistics are fetched.
+	c = NewCostEstimator(log.NewNopLogger(), nil, func() []store.StatusClient { return nil }, 1, 15*time.Second, false)
+	res, err = c.Check(query, start, end, downsample.ResLevel1)
+	testutil.Ok(t, err)
+	testutil.Equals(t, downsample.ResLevel1, res)
+}
@@ -8,6 +8,8 @@ import (
 	"sort"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
@@ -137,7 +139,9 @@ func newChunkSeries(lset []storepb.Label, chunks []storepb.AggrChunk, mint, maxt
 	})
 
 	return &chunkSeries{
-		lset:   storepb.LabelsToPromLabels(lset),
+		// Labels are not modified by PromQL, so they are not copied. Labels of series received from stores reference
+		// the received messages.
+		lset:   storepb.LabelsToPromLabelsUnsafe(lset),
 		chunks: chunks,
 		mint:   mint,
 		maxt:   maxt,
@@ -200,23 +204,15 @@ func getFirstIterator(cs ...*storepb.Chunk) chunkenc.Iterator {
 		if c == nil {
 			continue
 		}
-		chk, err := chunkenc.FromData(chunkEncoding(c.Type), c.Data)
+		it, err := c.Iterator()
 		if err != nil {
 			return errSeriesIterator{err}
 		}
-		return chk.Iterator(nil)
+		return it
 	}
 	return errSeriesIterator{errors.New("no valid chunk found")}
 }
 
-func chunkEncoding(e storepb.Chunk_Encoding) chunkenc.Encoding {
-	switch e {
-	case storepb.Chunk_XOR:
-		return chunkenc.EncXOR
-	}
-	return 255 // Invalid.
-}
-
 type errSeriesIterator struct {
 	err error
 }
@@ -329,9 +325,34 @@ func (it *chunkSeriesIterator) Err() error {
 	return it.chunks[it.i].Err()
 }
 
+// dedupMetrics quantify the cost of deduplication and the health of replicated series.
+type dedupMetrics struct {
+	droppedSamples  prometheus.Counter
+	replicaSwitches prometheus.Counter
+	replicaGaps     prometheus.Counter
+}
+
+func newDedupMetrics(reg prometheus.Registerer) *dedupMetrics {
+	return &dedupMetrics{
+		droppedSamples: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_query_dedup_dropped_samples_total",
+			Help: "Total number of samples of replicas that were dropped as duplicates during deduplication.",
+		}),
+		replicaSwitches: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_query_dedup_replica_switches_total",
+			Help: "Total number of times deduplication switched the replica it takes samples from within a series.",
+		}),
+		replicaGaps: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_query_dedup_replica_gaps_total",
+			Help: "Total number of gaps detected in a replica while another replica still had samples, causing a replica switch.",
+		}),
+	}
+}
+
 type dedupSeriesSet struct {
 	set           storage.SeriesSet
 	replicaLabels map[string]struct{}
+	metrics       *dedupMetrics
 
 	replicas []storage.Series
 	lset     labels.Labels
@@ -339,8 +360,8 @@ type dedupSeriesSet struct {
 	ok       bool
 }
 
-func newDedupSeriesSet(set storage.SeriesSet, replicaLabels map[string]struct{}) storage.SeriesSet {
-	s := &dedupSeriesSet{set: set, replicaLabels: replicaLabels}
+func newDedupSeriesSet(set storage.SeriesSet, replicaLabels map[string]struct{}, metrics *dedupMetrics) storage.SeriesSet {
+	s := &dedupSeriesSet{set: set, replicaLabels: replicaLabels, metrics: metrics}
 	s.ok = s.set.Next()
 	if s.ok {
 		s.peek = s.set.At()
@@ -404,7 +425,7 @@ func (s *dedupSeriesSet) At() storage.Series {
 	// before advancing.
 	repl := make([]storage.Series, len(s.replicas))
 	copy(repl, s.replicas)
-	return newDedupSeries(s.lset, repl...)
+	return newDedupSeries(s.lset, s.metrics, repl...)
 }
 
 func (s *dedupSeriesSet) Err() error {
@@ -421,10 +442,11 @@ func (s seriesWithLabels) Labels() labels.Labels { return s.lset }
 type dedupSeries struct {
 	lset     labels.Labels
 	replicas []storage.Series
+	metrics  *dedupMetrics
 }
 
-func newDedupSeries(lset labels.Labels, replicas ...storage.Series) *dedupSeries {
-	return &dedupSeries{lset: lset, replicas: replicas}
+func newDedupSeries(lset labels.Labels, metrics *dedupMetrics, replicas ...storage.Series) *dedupSeries {
+	return &dedupSeries{lset: lset, replicas: replicas, metrics: metrics}
 }
 
 func (s *dedupSeries) Labels() labels.Labels {
@@ -434,7 +456,7 @@ func (s *dedupSeries) Labels() labels.Labels {
 func (s *dedupSeries) Iterator() (it storage.SeriesIterator) {
 	it = s.replicas[0].Iterator()
 	for _, o := range s.replicas[1:] {
-		it = newDedupSeriesIterator(it, o.Iterator())
+		it = newDedupSeriesIterator(it, o.Iterator(), s.metrics)
 	}
 	return it
 }
@@ -442,41 +464,89 @@ func (s *dedupSeries) Iterator() (it storage.SeriesIterator) {
 type dedupSeriesIterator struct {
 	a, b storage.SeriesIterator
 
-	aok, bok   bool
-	lastT      int64
-	penA, penB int64
-	useA       bool
+	aok, bok           bool
+	aStarted, bStarted bool
+	lastT              int64
+	penA, penB         int64
+	useA               bool
+	metrics            *dedupMetrics
 }
 
-func newDedupSeriesIterator(a, b storage.SeriesIterator) *dedupSeriesIterator {
+func newDedupSeriesIterator(a, b storage.SeriesIterator, metrics *dedupMetrics) *dedupSeriesIterator {
 	return &dedupSeriesIterator{
-		a:     a,
-		b:     b,
-		lastT: math.MinInt64,
-		aok:   true,
-		bok:   true,
+		a:       a,
+		b:       b,
+		lastT:   math.MinInt64,
+		aok:     true,
+		bok:     true,
+		metrics: metrics,
+	}
+}
+
+// seek advances the given replica iterator to the first sample at or after t like Seek does,
+// but counts the samples passed over on the way that were never returned as duplicates.
+// The current sample of the replica was returned if emitted is true.
+func (it *dedupSeriesIterator) seek(r storage.SeriesIterator, started *bool, emitted bool, t int64) bool {
+	ok, dropped := seekCounting(r, *started, emitted, t)
+	*started = true
+	if dropped > 0 {
+		it.metrics.droppedSamples.Add(float64(dropped))
 	}
+	return ok
+}
+
+func seekCounting(r storage.SeriesIterator, started, emitted bool, t int64) (ok bool, passed int) {
+	if started {
+		if ts, _ := r.At(); ts >= t {
+			return true, 0
+		}
+		if !emitted {
+			passed++
+		}
+	}
+	for r.Next() {
+		if ts, _ := r.At(); ts >= t {
+			return true, passed
+		}
+		passed++
+	}
+	return false, passed
+}
+
+// use selects the replica the next sample is taken from and accounts for switching between replicas.
+// A switch while both replicas still have samples means the replica switched from has a gap.
+func (it *dedupSeriesIterator) use(a bool) {
+	if it.lastT != math.MinInt64 && it.useA != a {
+		it.metrics.replicaSwitches.Inc()
+		if it.aok && it.bok {
+			it.metrics.replicaGaps.Inc()
+		}
+	}
+	it.useA = a
 }
 
 func (it *dedupSeriesIterator) Next() bool {
+	emittedA := it.lastT != math.MinInt64 && it.useA
+	emittedB := it.lastT != math.MinInt64 && !it.useA
+
 	// Advance both iterators to at least the next highest timestamp plus the potential penalty.
 	if it.aok {
-		it.aok = it.a.Seek(it.lastT + 1 + it.penA)
+		it.aok = it.seek(it.a, &it.aStarted, emittedA, it.lastT+1+it.penA)
 	}
 	if it.bok {
-		it.bok = it.b.Seek(it.lastT + 1 + it.penB)
+		it.bok = it.seek(it.b, &it.bStarted, emittedB, it.lastT+1+it.penB)
 	}
 	// Handle basic cases where one iterator is exhausted before the other.
 	if !it.aok {
-		it.useA = false
 		if it.bok {
+			it.use(false)
 			it.lastT, _ = it.b.At()
 			it.penB = 0
 		}
 		return it.bok
 	}
 	if !it.bok {
-		it.useA = true
+		it.use(true)
 		it.lastT, _ = it.a.At()
 		it.penA = 0
 		return true
@@ -488,7 +558,7 @@ func (it *dedupSeriesIterator) Next() bool {
 	ta, _ := it.a.At()
 	tb, _ := it.b.At()
 
-	it.useA = ta <= tb
+	it.use(ta <= tb)
 
 	// For the series we didn't pick, add a penalty twice as high as the delta of the last two
 	// samples to the next seek against it.
@@ -7,12 +7,17 @@ import (
 	"context"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/tracing"
 )
@@ -25,39 +30,54 @@ import (
 // partialResponse controls `partialResponseDisabled` option of StoreAPI and partial response behaviour of proxy.
 type QueryableCreator func(deduplicate bool, replicaLabels []string, maxResolutionMillis int64, partialResponse, skipChunks bool) storage.Queryable
 
-// NewQueryableCreator creates QueryableCreator.
-func NewQueryableCreator(logger log.Logger, proxy storepb.StoreServer) QueryableCreator {
+// NewQueryableCreator creates QueryableCreator. If mixedResolutionAge is positive, data older than it is selected
+// downsampled to 1h by queries requesting a lower maximum resolution, while more recent data is selected with the
+// requested resolution.
+func NewQueryableCreator(logger log.Logger, reg prometheus.Registerer, proxy storepb.StoreServer, mixedResolutionAge time.Duration) QueryableCreator {
+	dedupMetrics := newDedupMetrics(reg)
+
 	return func(deduplicate bool, replicaLabels []string, maxResolutionMillis int64, partialResponse, skipChunks bool) storage.Queryable {
 		return &queryable{
 			logger:              logger,
+			dedupMetrics:        dedupMetrics,
 			replicaLabels:       replicaLabels,
 			proxy:               proxy,
 			deduplicate:         deduplicate,
 			maxResolutionMillis: maxResolutionMillis,
 			partialResponse:     partialResponse,
 			skipChunks:          skipChunks,
+			mixedResolutionAge:  mixedResolutionAge,
 		}
 	}
 }
 
 type queryable struct {
 	logger              log.Logger
+	dedupMetrics        *dedupMetrics
 	replicaLabels       []string
 	proxy               storepb.StoreServer
 	deduplicate         bool
 	maxResolutionMillis int64
 	partialResponse     bool
 	skipChunks          bool
+	mixedResolutionAge  time.Duration
 }
 
 // Querier returns a new storage querier against the underlying proxy store API.
 func (q *queryable) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
-	return newQuerier(ctx, q.logger, mint, maxt, q.replicaLabels, q.proxy, q.deduplicate, int64(q.maxResolutionMillis), q.partialResponse, q.skipChunks), nil
+	querier := newQuerier(ctx, q.logger, q.dedupMetrics, mint, maxt, q.replicaLabels, q.proxy, q.deduplicate, int64(q.maxResolutionMillis), q.partialResponse, q.skipChunks)
+	if q.mixedResolutionAge > 0 {
+		// The boundary is aligned to the resolution of downsampled data, so it is stable for an hour.
+		querier.downsampledBefore = timestamp.FromTime(time.Now().Add(-q.mixedResolutionAge))
+		querier.downsampledBefore -= querier.downsampledBefore % downsample.ResLevel2
+	}
+	return querier, nil
 }
 
 type querier struct {
 	ctx                 context.Context
 	logger              log.Logger
+	dedupMetrics        *dedupMetrics
 	cancel              func()
 	mint, maxt          int64
 	replicaLabels       map[string]struct{}
@@ -66,6 +86,9 @@ type querier struct {
 	maxResolutionMillis int64
 	partialResponse     bool
 	skipChunks          bool
+	// downsampledBefore is the timestamp before which data downsampled to 1h is selected if maxResolutionMillis is
+	// lower. It is disabled if 0.
+	downsampledBefore int64
 }
 
 // newQuerier creates implementation of storage.Querier that fetches data from the proxy
@@ -73,6 +96,7 @@ type querier struct {
 func newQuerier(
 	ctx context.Context,
 	logger log.Logger,
+	dedupMetrics *dedupMetrics,
 	mint, maxt int64,
 	replicaLabels []string,
 	proxy storepb.StoreServer,
@@ -84,6 +108,9 @@ func newQuerier(
 	if logger == nil {
 		logger = log.NewNopLogger()
 	}
+	if dedupMetrics == nil {
+		dedupMetrics = newDedupMetrics(nil)
+	}
 	ctx, cancel := context.WithCancel(ctx)
 
 	rl := make(map[string]struct{})
@@ -93,6 +120,7 @@ func newQuerier(
 	return &querier{
 		ctx:                 ctx,
 		logger:              logger,
+		dedupMetrics:        dedupMetrics,
 		cancel:              cancel,
 		mint:                mint,
 		maxt:                maxt,
@@ -124,6 +152,20 @@ func (s *seriesServer) Send(r *storepb.SeriesResponse) error {
 		return nil
 	}
 
+	if b := r.GetBatch(); b != nil {
+		s.seriesSet = append(s.seriesSet, b.Series...)
+		return nil
+	}
+
+	if b := r.GetArrowBatch(); b != nil {
+		series, err := storepb.DecodeArrowBatch(b)
+		if err != nil {
+			return errors.Wrap(err, "decode Arrow batch")
+		}
+		s.seriesSet = append(s.seriesSet, series...)
+		return nil
+	}
+
 	if r.GetSeries() == nil {
 		return errors.New("no seriesSet")
 	}
@@ -196,16 +238,36 @@ func (q *querier) Select(params *storage.SelectParams, ms ...*labels.Matcher) (s
 	queryAggrs, resAggr := aggrsFromFunc(params.Func)
 
 	resp := &seriesServer{ctx: ctx}
-	if err := q.proxy.Series(&storepb.SeriesRequest{
-		MinTime:                 params.Start,
-		MaxTime:                 params.End,
-		Matchers:                sms,
-		MaxResolutionWindow:     q.maxResolutionMillis,
-		Aggregates:              queryAggrs,
-		PartialResponseDisabled: !q.partialResponse,
-		SkipChunks:              q.skipChunks,
-	}, resp); err != nil {
-		return nil, nil, errors.Wrap(err, "proxy Series()")
+	windows := q.selectWindows(params.Start, params.End)
+	for _, w := range windows {
+		n := len(resp.seriesSet)
+		if err := q.proxy.Series(&storepb.SeriesRequest{
+			MinTime:                 w.mint,
+			MaxTime:                 w.maxt,
+			Matchers:                sms,
+			MaxResolutionWindow:     w.maxResolutionMillis,
+			Aggregates:              queryAggrs,
+			PartialResponseDisabled: !q.partialResponse,
+			SkipChunks:              q.skipChunks,
+			// The proxy ignores it unless it is enabled to request Arrow batches from stores.
+			ArrowBatches: true,
+		}, resp); err != nil {
+			return nil, nil, errors.Wrap(err, "proxy Series()")
+		}
+		if w.maxt < params.End {
+			// Chunks are not trimmed by stores, so downsampled chunks overlapping the following window would take
+			// precedence over its data.
+			if err := trimSeriesChunks(resp.seriesSet[n:], w.maxt); err != nil {
+				return nil, nil, errors.Wrap(err, "trim chunks")
+			}
+		}
+	}
+	if len(windows) > 1 {
+		span.SetTag("mixedResolution", true)
+		// Equal series of all windows have to be adjacent to be merged.
+		sort.SliceStable(resp.seriesSet, func(i, j int) bool {
+			return storepb.CompareLabels(resp.seriesSet[i].Labels, resp.seriesSet[j].Labels) < 0
+		})
 	}
 
 	var warns storage.Warnings
@@ -237,7 +299,79 @@ func (q *querier) Select(params *storage.SelectParams, ms ...*labels.Matcher) (s
 	// The merged series set assembles all potentially-overlapping time ranges
 	// of the same series into a single one. The series are ordered so that equal series
 	// from different replicas are sequential. We can now deduplicate those.
-	return newDedupSeriesSet(set, q.replicaLabels), warns, nil
+	return newDedupSeriesSet(set, q.replicaLabels, q.dedupMetrics), warns, nil
+}
+
+type selectWindow struct {
+	mint, maxt          int64
+	maxResolutionMillis int64
+}
+
+// selectWindows splits the time range of a Select into windows selected with different maximum resolutions. Data
+// before downsampledBefore is selected downsampled to 1h if a lower maximum resolution is requested, so long range
+// queries use downsampled data for old and raw data for recent portions, e.g. beyond the retention of raw data.
+func (q *querier) selectWindows(mint, maxt int64) []selectWindow {
+	if q.downsampledBefore == 0 || q.maxResolutionMillis >= downsample.ResLevel2 || mint >= q.downsampledBefore {
+		return []selectWindow{{mint: mint, maxt: maxt, maxResolutionMillis: q.maxResolutionMillis}}
+	}
+	if maxt < q.downsampledBefore {
+		return []selectWindow{{mint: mint, maxt: maxt, maxResolutionMillis: downsample.ResLevel2}}
+	}
+	return []selectWindow{
+		{mint: mint, maxt: q.downsampledBefore - 1, maxResolutionMillis: downsample.ResLevel2},
+		{mint: q.downsampledBefore, maxt: maxt, maxResolutionMillis: q.maxResolutionMillis},
+	}
+}
+
+// trimSeriesChunks removes samples after maxt from the chunks of the series, re-encoding chunks overlapping maxt.
+func trimSeriesChunks(set []storepb.Series, maxt int64) error {
+	for i := range set {
+		chks := set[i].Chunks[:0]
+		for _, c := range set[i].Chunks {
+			if c.MinTime > maxt {
+				continue
+			}
+			if c.MaxTime > maxt {
+				for _, chk := range []**storepb.Chunk{&c.Raw, &c.Count, &c.Sum, &c.Min, &c.Max, &c.Counter} {
+					if *chk == nil {
+						continue
+					}
+					trimmed, err := trimChunk(*chk, maxt)
+					if err != nil {
+						return err
+					}
+					*chk = trimmed
+				}
+				c.MaxTime = maxt
+			}
+			chks = append(chks, c)
+		}
+		set[i].Chunks = chks
+	}
+	return nil
+}
+
+func trimChunk(c *storepb.Chunk, maxt int64) (*storepb.Chunk, error) {
+	it, err := c.Iterator()
+	if err != nil {
+		return nil, err
+	}
+	res := chunkenc.NewXORChunk()
+	app, err := res.Appender()
+	if err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		t, v := it.At()
+		if t > maxt {
+			break
+		}
+		app.Append(t, v)
+	}
+	if it.Err() != nil {
+		return nil, it.Err()
+	}
+	return &storepb.Chunk{Type: storepb.Chunk_XOR, Data: res.Bytes()}, nil
 }
 
 // sortDedupLabels re-sorts the set so that the same series with different replica
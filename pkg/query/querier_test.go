@@ -17,10 +17,12 @@ import (
 
 	"github.com/fortytw2/leaktest"
 	"github.com/pkg/errors"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/thanos-io/thanos/pkg/compact/downsample"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
@@ -28,7 +30,7 @@ import (
 func TestQueryableCreator_MaxResolution(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 	testProxy := &storeServer{resps: []*storepb.SeriesResponse{}}
-	queryableCreator := NewQueryableCreator(nil, testProxy)
+	queryableCreator := NewQueryableCreator(nil, nil, testProxy, 0)
 
 	oneHourMillis := int64(1*time.Hour) / int64(time.Millisecond)
 	queryable := queryableCreator(false, nil, oneHourMillis, false, false)
@@ -58,7 +60,7 @@ func TestQuerier_DownsampledData(t *testing.T) {
 		},
 	}
 
-	q := NewQueryableCreator(nil, testProxy)(false, nil, 9999999, false, false)
+	q := NewQueryableCreator(nil, nil, testProxy, 0)(false, nil, 9999999, false, false)
 
 	engine := promql.NewEngine(
 		promql.EngineOpts{
@@ -179,7 +181,7 @@ func TestQuerier_Series(t *testing.T) {
 
 	// Querier clamps the range to [1,300], which should drop some samples of the result above.
 	// The store API allows endpoints to send more data then initially requested.
-	q := newQuerier(context.Background(), nil, 1, 300, []string{""}, testProxy, false, 0, true, false)
+	q := newQuerier(context.Background(), nil, nil, 1, 300, []string{""}, testProxy, false, 0, true, false)
 	defer func() { testutil.Ok(t, q.Close()) }()
 
 	res, _, err := q.Select(&storage.SelectParams{})
@@ -220,6 +222,67 @@ func TestQuerier_Series(t *testing.T) {
 	testutil.Equals(t, len(expected), i)
 }
 
+func TestQuerier_SelectMixedResolution(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	testProxy := &resolutionStoreServer{resps: map[int64][]*storepb.SeriesResponse{
+		downsample.ResLevel2: {
+			// Chunks overlapping the window of raw data are trimmed.
+			storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{10, 1}, {50, 2}, {90, 3}, {130, 4}}),
+			storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{20, 1}}),
+		},
+		downsample.ResLevel0: {
+			storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{100, 10}, {150, 11}}),
+			storeSeriesResponse(t, labels.FromStrings("a", "c"), []sample{{120, 5}}),
+		},
+	}}
+
+	q := newQuerier(context.Background(), nil, nil, 0, 300, nil, testProxy, false, 0, true, false)
+	q.downsampledBefore = 100
+	defer func() { testutil.Ok(t, q.Close()) }()
+
+	res, _, err := q.Select(&storage.SelectParams{Start: 0, End: 300})
+	testutil.Ok(t, err)
+
+	testutil.Equals(t, 2, len(testProxy.reqs))
+	testutil.Equals(t, []int64{0, 99, downsample.ResLevel2}, []int64{testProxy.reqs[0].MinTime, testProxy.reqs[0].MaxTime, testProxy.reqs[0].MaxResolutionWindow})
+	testutil.Equals(t, []int64{100, 300, downsample.ResLevel0}, []int64{testProxy.reqs[1].MinTime, testProxy.reqs[1].MaxTime, testProxy.reqs[1].MaxResolutionWindow})
+
+	expected := []struct {
+		lset    labels.Labels
+		samples []sample
+	}{
+		{lset: labels.FromStrings("a", "a"), samples: []sample{{10, 1}, {50, 2}, {90, 3}, {100, 10}, {150, 11}}},
+		{lset: labels.FromStrings("a", "b"), samples: []sample{{20, 1}}},
+		{lset: labels.FromStrings("a", "c"), samples: []sample{{120, 5}}},
+	}
+	i := 0
+	for res.Next() {
+		testutil.Assert(t, i < len(expected), "more series than expected")
+		testutil.Equals(t, expected[i].lset, res.At().Labels())
+		testutil.Equals(t, expected[i].samples, expandSeries(t, res.At().Iterator()))
+		i++
+	}
+	testutil.Ok(t, res.Err())
+	testutil.Equals(t, len(expected), i)
+
+	// Queries of recent data only, or of downsampled data, are not split.
+	for _, tcase := range []struct {
+		mint, maxResolution int64
+		expected            []int64
+	}{
+		{mint: 100, maxResolution: 0, expected: []int64{100, 300, downsample.ResLevel0}},
+		{mint: 0, maxResolution: downsample.ResLevel2, expected: []int64{0, 300, downsample.ResLevel2}},
+	} {
+		testProxy.reqs = nil
+		q.maxResolutionMillis = tcase.maxResolution
+		_, _, err := q.Select(&storage.SelectParams{Start: tcase.mint, End: 300})
+		testutil.Ok(t, err)
+		testutil.Equals(t, 1, len(testProxy.reqs))
+		testutil.Equals(t, tcase.expected, []int64{testProxy.reqs[0].MinTime, testProxy.reqs[0].MaxTime, testProxy.reqs[0].MaxResolutionWindow})
+	}
+}
+
 func TestSortReplicaLabel(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -552,7 +615,7 @@ func TestDedupSeriesSet(t *testing.T) {
 				maxt: math.MaxInt64,
 				set:  newStoreSeriesSet(series),
 			}
-			dedupSet := newDedupSeriesSet(set, test.dedupLabels)
+			dedupSet := newDedupSeriesSet(set, test.dedupLabels, newDedupMetrics(nil))
 
 			i := 0
 			for dedupSet.Next() {
@@ -603,20 +666,43 @@ func TestDedupSeriesIterator(t *testing.T) {
 	}
 	for i, c := range cases {
 		t.Logf("case %d:", i)
+		m := newDedupMetrics(nil)
 		it := newDedupSeriesIterator(
 			&SampleIterator{l: c.a, i: -1},
 			&SampleIterator{l: c.b, i: -1},
+			m,
 		)
 		res := expandSeries(t, it)
 		testutil.Equals(t, c.exp, res)
+		testutil.Equals(t, float64(len(c.a)+len(c.b)-len(c.exp)), promtest.ToFloat64(m.droppedSamples))
 	}
 }
 
+func TestDedupSeriesIterator_Metrics(t *testing.T) {
+	m := newDedupMetrics(nil)
+	// Replica a has a gap bigger than 2 deltas, so dedup switches to b. Once b ends earlier
+	// than a, dedup switches back to a.
+	it := newDedupSeriesIterator(
+		&SampleIterator{l: []sample{
+			{10000, 1}, {20000, 1}, {30000, 1}, {60000, 1}, {70000, 1}, {80000, 1}, {90000, 1}, {100000, 1}, {110000, 1},
+		}, i: -1},
+		&SampleIterator{l: []sample{{10100, 2}, {20100, 2}, {30100, 2}, {40100, 2}, {50100, 2}, {60100, 2}}, i: -1},
+		m,
+	)
+	res := expandSeries(t, it)
+	testutil.Equals(t, []sample{{10000, 1}, {20000, 1}, {30000, 1}, {50100, 2}, {60100, 2}, {100000, 1}, {110000, 1}}, res)
+
+	testutil.Equals(t, 8.0, promtest.ToFloat64(m.droppedSamples))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(m.replicaSwitches))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(m.replicaGaps))
+}
+
 func BenchmarkDedupSeriesIterator(b *testing.B) {
 	run := func(b *testing.B, s1, s2 []sample) {
 		it := newDedupSeriesIterator(
 			&SampleIterator{l: s1, i: -1},
 			&SampleIterator{l: s2, i: -1},
+			newDedupMetrics(nil),
 		)
 		b.ResetTimer()
 		var total int64
@@ -681,7 +767,7 @@ func (s *SampleIterator) At() (int64, float64) {
 }
 
 func (s *SampleIterator) Next() bool {
-	if s.i >= len(s.l) {
+	if s.i >= len(s.l)-1 {
 		return false
 	}
 	s.i++
@@ -720,6 +806,24 @@ func (s *storeServer) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesS
 	return nil
 }
 
+// resolutionStoreServer responds with the series of the requested maximum resolution.
+type resolutionStoreServer struct {
+	storepb.StoreServer
+
+	resps map[int64][]*storepb.SeriesResponse
+	reqs  []*storepb.SeriesRequest
+}
+
+func (s *resolutionStoreServer) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	s.reqs = append(s.reqs, r)
+	for _, resp := range s.resps[r.MaxResolutionWindow] {
+		if err := srv.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // storeSeriesResponse creates test storepb.SeriesResponse that includes series with single chunk that stores all the given samples.
 func storeSeriesResponse(t testing.TB, lset labels.Labels, smplChunks ...[]sample) *storepb.SeriesResponse {
 	var s storepb.Series
@@ -0,0 +1,67 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package query
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// StoreGroup is a group of stores with a priority, e.g. store gateways of the local region. Stores of groups with
+// lower priority are only queried if groups of higher priority cannot serve the requested time range.
+type StoreGroup struct {
+	Name string `yaml:"name"`
+	// Priority of the group. Groups with lower values are preferred.
+	Priority int `yaml:"priority"`
+	// Stores are patterns of addresses of stores in the group, as matched by path.Match, e.g. "10.0.1.*:10901".
+	Stores []string `yaml:"stores"`
+	// Zone is the availability zone of stores in the group, overriding the zone they advertise, if not empty.
+	Zone string `yaml:"zone"`
+}
+
+// StoreGroups are groups of stores. Each store is in the first group with a pattern matching its address, if any.
+type StoreGroups []StoreGroup
+
+// ParseStoreGroups parses the YAML store groups configuration.
+func ParseStoreGroups(conf []byte) (StoreGroups, error) {
+	var groups StoreGroups
+	if err := yaml.UnmarshalStrict(conf, &groups); err != nil {
+		return nil, errors.Wrap(err, "parsing store groups config")
+	}
+
+	names := make(map[string]struct{}, len(groups))
+	for _, g := range groups {
+		if g.Name == "" {
+			return nil, errors.New("store group without name")
+		}
+		if _, ok := names[g.Name]; ok {
+			return nil, errors.Errorf("duplicate store group %s", g.Name)
+		}
+		names[g.Name] = struct{}{}
+
+		if len(g.Stores) == 0 {
+			return nil, errors.Errorf("store group %s has no stores", g.Name)
+		}
+		for _, p := range g.Stores {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, errors.Wrapf(err, "store group %s: invalid pattern %q", g.Name, p)
+			}
+		}
+	}
+	return groups, nil
+}
+
+// group returns the group of the store with the given address.
+func (g StoreGroups) group(addr string) (*StoreGroup, bool) {
+	for i := range g {
+		for _, p := range g[i].Stores {
+			if ok, _ := path.Match(p, addr); ok {
+				return &g[i], true
+			}
+		}
+	}
+	return nil, false
+}
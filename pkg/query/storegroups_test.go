@@ -0,0 +1,43 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package query
+
+import (
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestParseStoreGroups(t *testing.T) {
+	groups, err := ParseStoreGroups([]byte(`
+- name: local
+  stores: ["10.0.1.*:10901", "store-local:10901"]
+- name: remote
+  priority: 1
+  stores: ["*"]
+`))
+	testutil.Ok(t, err)
+
+	g, ok := groups.group("10.0.1.5:10901")
+	testutil.Assert(t, ok, "store not in group")
+	testutil.Equals(t, "local", g.Name)
+	g, ok = groups.group("10.0.2.5:10901")
+	testutil.Assert(t, ok, "store not in group")
+	testutil.Equals(t, "remote", g.Name)
+	testutil.Equals(t, 1, g.Priority)
+
+	_, ok = StoreGroups(nil).group("10.0.1.5:10901")
+	testutil.Assert(t, !ok, "store in group without groups")
+
+	for _, conf := range []string{
+		`[{stores: ["*"]}]`,
+		`[{name: local}]`,
+		`[{name: local, stores: ["["]}]`,
+		`[{name: local, stores: ["*"]}, {name: local, stores: ["*"]}]`,
+		`[{name: local, store: ["*"]}]`,
+	} {
+		_, err := ParseStoreGroups([]byte(conf))
+		testutil.NotOk(t, err)
+	}
+}
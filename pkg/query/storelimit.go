@@ -0,0 +1,146 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package query
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// concurrencyBackoff is the factor the concurrency limit of a store is multiplied by on signs of its overload.
+const concurrencyBackoff = 0.5
+
+// AdaptiveConcurrencyConfig configures adaptive limits of concurrent Series calls of each store.
+type AdaptiveConcurrencyConfig struct {
+	// MinLimit and MaxLimit bound the limit. Limits start at MinLimit.
+	MinLimit int
+	MaxLimit int
+	// LatencyThreshold is the duration of calls above which they are considered signs of overload like failures.
+	// 0 means only failures are.
+	LatencyThreshold time.Duration
+}
+
+// concurrencyLimiter limits concurrent calls of a store to a limit adapted by additive increase and multiplicative
+// decrease (AIMD), as TCP congestion control does: each successful call increases the limit by 1/limit, so by one
+// per limit calls, and each failed or slow call halves it. Thus the limit converges to the concurrency the store
+// handles, and calls of an overloaded or recovering store are queued in the querier instead of piling up in the store,
+// e.g. when queries are retried.
+//
+// Until the first decrease, each successful call increases the limit by one, so it doubles with each limit calls and
+// quickly reaches the concurrency of healthy stores, starting at the minimum.
+type concurrencyLimiter struct {
+	conf AdaptiveConcurrencyConfig
+
+	mtx       sync.Mutex
+	limit     float64
+	slowStart bool
+	inFlight  int
+	// epoch is incremented by each decrease. Calls started before the last decrease do not decrease the limit again,
+	// as they likely failed for the same reason.
+	epoch   uint64
+	waiting []chan uint64
+}
+
+func newConcurrencyLimiter(conf AdaptiveConcurrencyConfig) *concurrencyLimiter {
+	if conf.MinLimit < 1 {
+		conf.MinLimit = 1
+	}
+	if conf.MaxLimit < conf.MinLimit {
+		conf.MaxLimit = conf.MinLimit
+	}
+	return &concurrencyLimiter{conf: conf, limit: float64(conf.MinLimit), slowStart: true}
+}
+
+// acquire waits until a call can be started within the limit, or the context is done. The returned function has to
+// be called with the outcome of the call once it is finished. A nil limiter returns a nil function.
+func (l *concurrencyLimiter) acquire(ctx context.Context) (func(error), error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	l.mtx.Lock()
+	if len(l.waiting) == 0 && l.inFlight < int(l.limit) {
+		l.inFlight++
+		epoch := l.epoch
+		l.mtx.Unlock()
+		return l.releaser(epoch), nil
+	}
+	ch := make(chan uint64, 1)
+	l.waiting = append(l.waiting, ch)
+	l.mtx.Unlock()
+
+	select {
+	case epoch := <-ch:
+		return l.releaser(epoch), nil
+	case <-ctx.Done():
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	for i, w := range l.waiting {
+		if w == ch {
+			l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+			return nil, ctx.Err()
+		}
+	}
+	// The call was let in meanwhile, so its slot is passed on.
+	l.inFlight--
+	l.admit()
+	return nil, ctx.Err()
+}
+
+func (l *concurrencyLimiter) releaser(epoch uint64) func(error) {
+	start := time.Now()
+	return func(err error) { l.release(epoch, time.Since(start), err) }
+}
+
+func (l *concurrencyLimiter) release(epoch uint64, d time.Duration, err error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.inFlight--
+	switch {
+	case isCanceled(err):
+		// Calls canceled by the caller say nothing about the store.
+	case err != nil || (l.conf.LatencyThreshold > 0 && d > l.conf.LatencyThreshold):
+		if epoch == l.epoch {
+			l.epoch++
+			l.slowStart = false
+			l.limit *= concurrencyBackoff
+			if l.limit < float64(l.conf.MinLimit) {
+				l.limit = float64(l.conf.MinLimit)
+			}
+		}
+	default:
+		if l.slowStart {
+			l.limit++
+		} else {
+			l.limit += 1 / l.limit
+		}
+		if l.limit > float64(l.conf.MaxLimit) {
+			l.limit = float64(l.conf.MaxLimit)
+		}
+	}
+	l.admit()
+}
+
+// admit lets waiting calls in, in order, as long as the limit allows.
+func (l *concurrencyLimiter) admit() {
+	for len(l.waiting) > 0 && l.inFlight < int(l.limit) {
+		l.inFlight++
+		l.waiting[0] <- l.epoch
+		l.waiting = l.waiting[1:]
+	}
+}
+
+// get returns the current limit and the number of calls in flight and waiting. A nil limiter returns zeros.
+func (l *concurrencyLimiter) get() (limit, inFlight, waiting int) {
+	if l == nil {
+		return 0, 0, 0
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return int(l.limit), l.inFlight, len(l.waiting)
+}
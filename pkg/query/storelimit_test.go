@@ -0,0 +1,122 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestConcurrencyLimiter_AIMD(t *testing.T) {
+	l := newConcurrencyLimiter(AdaptiveConcurrencyConfig{MinLimit: 2, MaxLimit: 10, LatencyThreshold: time.Second})
+	ctx := context.Background()
+
+	limit := func() int {
+		limit, _, _ := l.get()
+		return limit
+	}
+	call := func(err error) {
+		release, aerr := l.acquire(ctx)
+		testutil.Ok(t, aerr)
+		release(err)
+	}
+
+	// Slow start increases the limit by one with each successful call, up to the maximum.
+	testutil.Equals(t, 2, limit())
+	for i := 0; i < 3; i++ {
+		call(nil)
+	}
+	testutil.Equals(t, 5, limit())
+	for i := 0; i < 10; i++ {
+		call(nil)
+	}
+	testutil.Equals(t, 10, limit())
+
+	// Calls failing together halve the limit once.
+	var releases []func(error)
+	for i := 0; i < 4; i++ {
+		release, err := l.acquire(ctx)
+		testutil.Ok(t, err)
+		releases = append(releases, release)
+	}
+	for _, release := range releases {
+		release(errors.New("unavailable"))
+	}
+	testutil.Equals(t, 5, limit())
+
+	// Canceled calls do not change the limit.
+	call(context.Canceled)
+	testutil.Equals(t, 5, limit())
+
+	// After a decrease, the limit increases by one with each limit successful calls.
+	for i := 0; i < 6; i++ {
+		call(nil)
+	}
+	testutil.Equals(t, 6, limit())
+
+	// Slow calls decrease the limit, but not below the minimum.
+	_, err := l.acquire(ctx)
+	testutil.Ok(t, err)
+	l.release(l.epoch, 2*time.Second, nil)
+	testutil.Equals(t, 3, limit())
+	call(errors.New("unavailable"))
+	call(errors.New("unavailable"))
+	testutil.Equals(t, 2, limit())
+}
+
+func TestConcurrencyLimiter_Wait(t *testing.T) {
+	l := newConcurrencyLimiter(AdaptiveConcurrencyConfig{MinLimit: 1, MaxLimit: 1})
+
+	release, err := l.acquire(context.Background())
+	testutil.Ok(t, err)
+
+	// Calls above the limit wait until their context is done.
+	waitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(waitCtx)
+	testutil.Equals(t, context.DeadlineExceeded, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// ...or until calls in flight are finished.
+	admitted := make(chan func(error))
+	go func() {
+		r, err := l.acquire(context.Background())
+		testutil.Ok(t, err)
+		admitted <- r
+	}()
+	testutil.Ok(t, runutil.Retry(10*time.Millisecond, ctx.Done(), func() error {
+		if _, _, waiting := l.get(); waiting != 1 {
+			return errors.Errorf("expected 1 waiting call, got %d", waiting)
+		}
+		return nil
+	}))
+	select {
+	case <-admitted:
+		t.Fatal("expected call to wait")
+	default:
+	}
+
+	release(nil)
+	release = <-admitted
+	_, inFlight, waiting := l.get()
+	testutil.Equals(t, 1, inFlight)
+	testutil.Equals(t, 0, waiting)
+	release(nil)
+
+	_, inFlight, _ = l.get()
+	testutil.Equals(t, 0, inFlight)
+
+	// A nil limiter does not limit.
+	var nl *concurrencyLimiter
+	release, err = nl.acquire(context.Background())
+	testutil.Ok(t, err)
+	testutil.Assert(t, release == nil, "expected no release of nil limiter")
+}
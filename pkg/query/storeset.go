@@ -27,15 +27,18 @@ const (
 	unhealthyStoreMessage = "removing store because it's unhealthy or does not exist"
 )
 
+// ErrStoreNotFound is returned when draining stores unknown to the store set.
+var ErrStoreNotFound = errors.New("store not found")
+
 type StoreSpec interface {
 	// Addr returns StoreAPI Address for the store spec. It is used as ID for store.
 	Addr() string
-	// Metadata returns current labels, store type and min, max ranges for store.
+	// Metadata returns current labels, store type, min, max ranges, zone and capabilities for store.
 	// It can change for every call for this method.
 	// If metadata call fails we assume that store is no longer accessible and we should not use it.
 	// NOTE: It is implementation responsibility to retry until context timeout, but a caller responsibility to manage
 	// given store connection.
-	Metadata(ctx context.Context, client storepb.StoreClient) (labelSets []storepb.LabelSet, mint int64, maxt int64, storeType component.StoreAPI, err error)
+	Metadata(ctx context.Context, client storepb.StoreClient) (labelSets []storepb.LabelSet, mint int64, maxt int64, storeType component.StoreAPI, zone string, capabilities []string, err error)
 	// StrictStatic returns true if the StoreAPI has been statically defined and it is under a strict mode.
 	StrictStatic() bool
 }
@@ -48,6 +51,21 @@ type StoreStatus struct {
 	StoreType component.StoreAPI
 	MinTime   int64
 	MaxTime   int64
+	Zone      string
+	// Drained is true if the store is excluded from fanout.
+	Drained bool
+	// RequestStats are statistics of recent requests of the store, if it is active.
+	RequestStats StoreRequestStats
+	// ConcurrencyLimit is the current adaptive limit of concurrent Series calls of the store, with the number of calls
+	// in flight and waiting, if it is active and limits are enabled.
+	ConcurrencyLimit StoreConcurrencyLimit
+}
+
+// StoreConcurrencyLimit is the state of the adaptive concurrency limit of a store.
+type StoreConcurrencyLimit struct {
+	Limit    int
+	InFlight int
+	Waiting  int
 }
 
 type grpcStoreSpec struct {
@@ -73,16 +91,16 @@ func (s *grpcStoreSpec) Addr() string {
 
 // Metadata method for gRPC store API tries to reach host Info method until context timeout. If we are unable to get metadata after
 // that time, we assume that the host is unhealthy and return error.
-func (s *grpcStoreSpec) Metadata(ctx context.Context, client storepb.StoreClient) (labelSets []storepb.LabelSet, mint int64, maxt int64, storeType component.StoreAPI, err error) {
+func (s *grpcStoreSpec) Metadata(ctx context.Context, client storepb.StoreClient) (labelSets []storepb.LabelSet, mint int64, maxt int64, storeType component.StoreAPI, zone string, capabilities []string, err error) {
 	resp, err := client.Info(ctx, &storepb.InfoRequest{}, grpc.WaitForReady(true))
 	if err != nil {
-		return nil, 0, 0, nil, errors.Wrapf(err, "fetching store info from %s", s.addr)
+		return nil, 0, 0, nil, "", nil, errors.Wrapf(err, "fetching store info from %s", s.addr)
 	}
 	if len(resp.LabelSets) == 0 && len(resp.Labels) > 0 {
 		resp.LabelSets = []storepb.LabelSet{{Labels: resp.Labels}}
 	}
 
-	return resp.LabelSets, resp.MinTime, resp.MaxTime, component.FromProto(resp.StoreType), nil
+	return resp.LabelSets, resp.MinTime, resp.MaxTime, component.FromProto(resp.StoreType), resp.Zone, resp.Capabilities, nil
 }
 
 // storeSetNodeCollector is metric collector for Guge indicated number of available storeAPIs for Querier.
@@ -173,10 +191,21 @@ type StoreSet struct {
 	// Main map of stores currently used for fanout.
 	stores       map[string]*storeRef
 	storesMetric *storeSetNodeCollector
+	// Failed requests of stores by address and failure class.
+	storeFailures *prometheus.CounterVec
+	// Addresses of stores excluded from fanout until they are undrained, even if they are removed and added again.
+	drained map[string]struct{}
 
 	// Map of statuses used only by UI.
 	storeStatuses         map[string]*StoreStatus
 	unhealthyStoreTimeout time.Duration
+
+	// Adaptive concurrency limits of Series calls of stores, if not nil.
+	concurrency *AdaptiveConcurrencyConfig
+	// Groups of stores with priorities.
+	groups StoreGroups
+	// Availability zone of the querier. Stores in it are preferred over their replicas in other zones.
+	zone string
 }
 
 // NewStoreSet returns a new set of stores from cluster peers and statically configured ones.
@@ -186,10 +215,17 @@ func NewStoreSet(
 	storeSpecs func() []StoreSpec,
 	dialOpts []grpc.DialOption,
 	unhealthyStoreTimeout time.Duration,
+	concurrency *AdaptiveConcurrencyConfig,
+	groups StoreGroups,
+	zone string,
 ) *StoreSet {
 	storesMetric := newStoreSetNodeCollector()
+	storeFailures := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_request_failures_total",
+		Help: "Total number of failed requests of stores by failure class: timeout, unavailable, resource_exhausted, canceled (by the caller) or other.",
+	}, []string{"store", "class"})
 	if reg != nil {
-		reg.MustRegister(storesMetric)
+		reg.MustRegister(storesMetric, storeFailures)
 	}
 
 	if logger == nil {
@@ -204,34 +240,48 @@ func NewStoreSet(
 		storeSpecs:            storeSpecs,
 		dialOpts:              dialOpts,
 		storesMetric:          storesMetric,
+		storeFailures:         storeFailures,
 		gRPCInfoCallTimeout:   5 * time.Second,
 		stores:                make(map[string]*storeRef),
+		drained:               make(map[string]struct{}),
 		storeStatuses:         make(map[string]*StoreStatus),
 		unhealthyStoreTimeout: unhealthyStoreTimeout,
+		concurrency:           concurrency,
+		groups:                groups,
+		zone:                  zone,
 	}
 	return ss
 }
 
 type storeRef struct {
 	storepb.StoreClient
+	storepb.StatusClient
 
 	mtx  sync.RWMutex
 	cc   *grpc.ClientConn
 	addr string
 
 	// Meta (can change during runtime).
-	labelSets []storepb.LabelSet
-	storeType component.StoreAPI
-	minTime   int64
-	maxTime   int64
+	labelSets    []storepb.LabelSet
+	storeType    component.StoreAPI
+	minTime      int64
+	maxTime      int64
+	zone         string
+	capabilities []string
+
+	stats   storeStats
+	limiter *concurrencyLimiter
+	group   *StoreGroup
 
 	logger log.Logger
 }
 
-func (s *storeRef) Update(labelSets []storepb.LabelSet, minTime int64, maxTime int64, storeType component.StoreAPI) {
+func (s *storeRef) Update(labelSets []storepb.LabelSet, minTime int64, maxTime int64, storeType component.StoreAPI, zone string, capabilities []string) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
+	s.zone = zone
+	s.capabilities = capabilities
 	s.storeType = storeType
 	s.labelSets = labelSets
 	s.minTime = minTime
@@ -306,6 +356,34 @@ func (s *storeRef) TimeRange() (mint int64, maxt int64) {
 	return s.minTime, s.maxTime
 }
 
+// Zone returns the availability zone of the store, as configured for its group or advertised by the store.
+func (s *storeRef) Zone() string {
+	if s.group != nil && s.group.Zone != "" {
+		return s.group.Zone
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.zone
+}
+
+// Capabilities returns the optional extensions of the StoreAPI advertised by the store.
+func (s *storeRef) Capabilities() []string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.capabilities
+}
+
+// Group returns the name and priority of the group of the store, if it is in one.
+func (s *storeRef) Group() (name string, priority int, ok bool) {
+	if s.group == nil {
+		return "", 0, false
+	}
+	return s.group.Name, s.group.Priority, true
+}
+
 func (s *storeRef) String() string {
 	mint, maxt := s.TimeRange()
 	return fmt.Sprintf("Addr: %s LabelSets: %v Mint: %d Maxt: %d", s.addr, storepb.LabelSetsToString(s.LabelSets()), mint, maxt)
@@ -356,6 +434,9 @@ func (s *StoreSet) Update(ctx context.Context) {
 
 		st.Close()
 		delete(stores, addr)
+		for _, class := range failureClasses {
+			s.storeFailures.DeleteLabelValues(addr, class)
+		}
 		s.updateStoreStatus(st, errors.New(unhealthyStoreMessage))
 		level.Info(s.logger).Log("msg", unhealthyStoreMessage, "address", addr, "extLset", st.LabelSetsString())
 	}
@@ -426,11 +507,16 @@ func (s *StoreSet) getActiveStores(ctx context.Context, stores map[string]*store
 					level.Warn(s.logger).Log("msg", "update of store node failed", "err", errors.Wrap(err, "dialing connection"), "address", addr)
 					return
 				}
-				st = &storeRef{StoreClient: storepb.NewStoreClient(conn), cc: conn, addr: addr, logger: s.logger}
+				st = &storeRef{StoreClient: storepb.NewStoreClient(conn), StatusClient: storepb.NewStatusClient(conn), cc: conn, addr: addr, logger: s.logger}
+				st.stats.failures = s.storeFailures.MustCurryWith(prometheus.Labels{"store": addr})
+				if s.concurrency != nil {
+					st.limiter = newConcurrencyLimiter(*s.concurrency)
+				}
+				st.group, _ = s.groups.group(addr)
 			}
 
 			// Check existing or new store. Is it healthy? What are current metadata?
-			labelSets, minTime, maxTime, storeType, err := spec.Metadata(ctx, st.StoreClient)
+			labelSets, minTime, maxTime, storeType, zone, capabilities, err := spec.Metadata(ctx, st.StoreClient)
 			if err != nil {
 				if !seenAlready {
 					// Close only if new. Unactive `s.stores` will be closed later on.
@@ -452,7 +538,7 @@ func (s *StoreSet) getActiveStores(ctx context.Context, stores map[string]*store
 			}
 
 			s.updateStoreStatus(st, nil)
-			st.Update(labelSets, minTime, maxTime, storeType)
+			st.Update(labelSets, minTime, maxTime, storeType, zone, capabilities)
 
 			mtx.Lock()
 			defer mtx.Unlock()
@@ -484,18 +570,28 @@ func (s *StoreSet) updateStoreStatus(store *storeRef, err error) {
 		status.StoreType = store.StoreType()
 		status.MinTime = mint
 		status.MaxTime = maxt
+		status.Zone = store.Zone()
 	}
 
 	s.storeStatuses[store.addr] = &status
 }
 
 func (s *StoreSet) GetStoreStatus() []StoreStatus {
+	s.storesMtx.RLock()
+	defer s.storesMtx.RUnlock()
 	s.storesStatusesMtx.RLock()
 	defer s.storesStatusesMtx.RUnlock()
 
 	statuses := make([]StoreStatus, 0, len(s.storeStatuses))
-	for _, v := range s.storeStatuses {
-		statuses = append(statuses, *v)
+	for addr, v := range s.storeStatuses {
+		status := *v
+		_, status.Drained = s.drained[addr]
+		if st, ok := s.stores[addr]; ok {
+			status.RequestStats = st.stats.get()
+			l := &status.ConcurrencyLimit
+			l.Limit, l.InFlight, l.Waiting = st.limiter.get()
+		}
+		statuses = append(statuses, status)
 	}
 
 	sort.Slice(statuses, func(i, j int) bool {
@@ -504,16 +600,110 @@ func (s *StoreSet) GetStoreStatus() []StoreStatus {
 	return statuses
 }
 
-// Get returns a list of all active stores.
+// Get returns a list of all active stores, except drained ones. If the zone of the querier is set, stores in other
+// zones are excluded if a store in its zone has the same label sets and time range, i.e. is a replica of them.
 func (s *StoreSet) Get() []store.Client {
 	s.storesMtx.RLock()
 	defer s.storesMtx.RUnlock()
 
-	stores := make([]store.Client, 0, len(s.stores))
-	for _, st := range s.stores {
+	stores := make([]*storeRef, 0, len(s.stores))
+	for addr, st := range s.stores {
+		if _, ok := s.drained[addr]; ok {
+			continue
+		}
 		stores = append(stores, st)
 	}
-	return stores
+	if s.zone != "" {
+		stores = preferZone(stores, s.zone)
+	}
+
+	clients := make([]store.Client, 0, len(stores))
+	for _, st := range stores {
+		clients = append(clients, st)
+	}
+	return clients
+}
+
+// preferZone returns the stores except the ones outside of the zone with the same label sets and time range as a
+// store in the zone.
+func preferZone(stores []*storeRef, zone string) []*storeRef {
+	type replicaKey struct {
+		labelSets  string
+		mint, maxt int64
+	}
+	keyOf := func(st *storeRef) replicaKey {
+		mint, maxt := st.TimeRange()
+		return replicaKey{labelSets: st.LabelSetsString(), mint: mint, maxt: maxt}
+	}
+
+	inZone := map[replicaKey]struct{}{}
+	for _, st := range stores {
+		if st.Zone() == zone {
+			inZone[keyOf(st)] = struct{}{}
+		}
+	}
+	if len(inZone) == 0 {
+		return stores
+	}
+
+	preferred := make([]*storeRef, 0, len(stores))
+	for _, st := range stores {
+		if st.Zone() != zone {
+			if _, ok := inZone[keyOf(st)]; ok {
+				continue
+			}
+		}
+		preferred = append(preferred, st)
+	}
+	return preferred
+}
+
+// GetStatusClients returns clients of the Status API of all active stores, except drained ones. Stores may not
+// implement it.
+func (s *StoreSet) GetStatusClients() []store.StatusClient {
+	s.storesMtx.RLock()
+	defer s.storesMtx.RUnlock()
+
+	clients := make([]store.StatusClient, 0, len(s.stores))
+	for addr, st := range s.stores {
+		if _, ok := s.drained[addr]; ok {
+			continue
+		}
+		clients = append(clients, st)
+	}
+	return clients
+}
+
+// Drain excludes the store with the given address from fanout until it is undrained, e.g. during its maintenance.
+// Drained stores are still health checked and shown in the UI. ErrStoreNotFound is returned for unknown stores.
+func (s *StoreSet) Drain(addr string) error {
+	s.storesStatusesMtx.RLock()
+	_, ok := s.storeStatuses[addr]
+	s.storesStatusesMtx.RUnlock()
+	if !ok {
+		return errors.Wrap(ErrStoreNotFound, addr)
+	}
+
+	s.storesMtx.Lock()
+	defer s.storesMtx.Unlock()
+
+	s.drained[addr] = struct{}{}
+	level.Info(s.logger).Log("msg", "drained storeAPI", "address", addr)
+	return nil
+}
+
+// Undrain includes the drained store with the given address in fanout again. ErrStoreNotFound is returned if the
+// store is not drained.
+func (s *StoreSet) Undrain(addr string) error {
+	s.storesMtx.Lock()
+	defer s.storesMtx.Unlock()
+
+	if _, ok := s.drained[addr]; !ok {
+		return errors.Wrap(ErrStoreNotFound, addr)
+	}
+	delete(s.drained, addr)
+	level.Info(s.logger).Log("msg", "undrained storeAPI", "address", addr)
+	return nil
 }
 
 func (s *StoreSet) Close() {
@@ -12,6 +12,9 @@ import (
 	"time"
 
 	"github.com/fortytw2/leaktest"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/thanos-io/thanos/pkg/component"
 	"github.com/thanos-io/thanos/pkg/store"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
@@ -184,7 +187,7 @@ func TestStoreSet_Update(t *testing.T) {
 			specs = append(specs, NewGRPCStoreSpec(addr, false))
 		}
 		return specs
-	}, testGRPCOpts, time.Minute)
+	}, testGRPCOpts, time.Minute, nil, nil, "")
 	storeSet.gRPCInfoCallTimeout = 2 * time.Second
 	defer storeSet.Close()
 
@@ -532,7 +535,7 @@ func TestStoreSet_Update_NoneAvailable(t *testing.T) {
 			specs = append(specs, NewGRPCStoreSpec(addr, false))
 		}
 		return specs
-	}, testGRPCOpts, time.Minute)
+	}, testGRPCOpts, time.Minute, nil, nil, "")
 	storeSet.gRPCInfoCallTimeout = 2 * time.Second
 
 	// Should not matter how many of these we run.
@@ -596,7 +599,7 @@ func TestQuerierStrict(t *testing.T) {
 			NewGRPCStoreSpec(st.StoreAddresses()[0], true),
 			NewGRPCStoreSpec(st.StoreAddresses()[1], false),
 		}
-	}, testGRPCOpts, time.Minute)
+	}, testGRPCOpts, time.Minute, nil, nil, "")
 	defer storeSet.Close()
 	storeSet.gRPCInfoCallTimeout = 1 * time.Second
 
@@ -624,3 +627,137 @@ func TestQuerierStrict(t *testing.T) {
 	testutil.Equals(t, curMax, storeSet.stores[staticStoreAddr].maxTime, "minimum time reported by the store node is different")
 	testutil.NotOk(t, storeSet.storeStatuses[staticStoreAddr].LastError)
 }
+
+func TestStoreSet_Drain(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	st, err := startTestStores([]testStoreMeta{
+		{
+			extlsetFn: func(addr string) []storepb.LabelSet {
+				return []storepb.LabelSet{{Labels: []storepb.Label{{Name: "addr", Value: addr}}}}
+			},
+			storeType: component.Sidecar,
+		},
+		{
+			extlsetFn: func(addr string) []storepb.LabelSet {
+				return []storepb.LabelSet{{Labels: []storepb.Label{{Name: "addr", Value: addr}}}}
+			},
+			storeType: component.Sidecar,
+		},
+	})
+	testutil.Ok(t, err)
+	defer st.Close()
+
+	storeSet := NewStoreSet(nil, nil, func() (specs []StoreSpec) {
+		for _, addr := range st.StoreAddresses() {
+			specs = append(specs, NewGRPCStoreSpec(addr, false))
+		}
+		return specs
+	}, testGRPCOpts, time.Minute, nil, nil, "")
+	defer storeSet.Close()
+
+	storeSet.Update(context.Background())
+	testutil.Equals(t, 2, len(storeSet.Get()))
+
+	drained := st.StoreAddresses()[0]
+	testutil.Ok(t, storeSet.Drain(drained))
+	testutil.Equals(t, ErrStoreNotFound, errors.Cause(storeSet.Drain("unknown:10901")))
+
+	// Drained stores are still updated, but not used for fanout.
+	storeSet.Update(context.Background())
+	stores := storeSet.Get()
+	testutil.Equals(t, 1, len(stores))
+	testutil.Equals(t, st.StoreAddresses()[1], stores[0].Addr())
+	testutil.Equals(t, 1, len(storeSet.GetStatusClients()))
+
+	_, err = stores[0].LabelNames(context.Background(), &storepb.LabelNamesRequest{})
+	testutil.NotOk(t, err)
+
+	statuses := storeSet.GetStoreStatus()
+	testutil.Equals(t, 2, len(statuses))
+	for _, s := range statuses {
+		testutil.Equals(t, s.Name == drained, s.Drained)
+		if s.Name == drained {
+			testutil.Equals(t, 0, s.RequestStats.Requests)
+			continue
+		}
+		testutil.Equals(t, 1, s.RequestStats.Requests)
+		testutil.Equals(t, 1, s.RequestStats.Failures)
+	}
+
+	testutil.Ok(t, storeSet.Undrain(drained))
+	testutil.Equals(t, ErrStoreNotFound, errors.Cause(storeSet.Undrain(drained)))
+	testutil.Equals(t, 2, len(storeSet.Get()))
+}
+
+func TestStoreStats(t *testing.T) {
+	var s storeStats
+	testutil.Equals(t, StoreRequestStats{}, s.get())
+
+	// Canceled requests are not recorded.
+	s.observe(time.Second, context.Canceled)
+	s.observe(time.Second, status.Error(codes.Canceled, "canceled"))
+	testutil.Equals(t, StoreRequestStats{}, s.get())
+
+	for i := 1; i <= 2*storeStatsWindow; i++ {
+		var err error
+		if i%4 == 0 {
+			err = errors.New("failed")
+		}
+		s.observe(time.Duration(i)*time.Millisecond, err)
+	}
+	// Only the most recent requests are taken into account.
+	stats := s.get()
+	testutil.Equals(t, StoreRequestStats{
+		Requests:   storeStatsWindow,
+		Failures:   storeStatsWindow / 4,
+		LatencyP50: 150 * time.Millisecond,
+		LatencyP90: 190 * time.Millisecond,
+		LatencyP99: 199 * time.Millisecond,
+	}, stats)
+	testutil.Equals(t, 0.25, stats.FailureRate())
+}
+
+func TestStoreStats_Failures(t *testing.T) {
+	failures := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "failures"}, []string{"store", "class"})
+	s := storeStats{failures: failures.MustCurryWith(prometheus.Labels{"store": "store-1"})}
+
+	s.observe(time.Second, nil)
+	s.observe(time.Second, context.Canceled)
+	s.observe(time.Second, status.Error(codes.Canceled, "canceled"))
+	s.observe(time.Second, errors.Wrap(context.DeadlineExceeded, "receive series"))
+	s.observe(time.Second, status.Error(codes.DeadlineExceeded, "deadline exceeded"))
+	s.observe(time.Second, status.Error(codes.Unavailable, "connection refused"))
+	s.observe(time.Second, status.Error(codes.ResourceExhausted, "rate limit exceeded"))
+	s.observe(time.Second, errors.New("failed"))
+
+	for class, exp := range map[string]float64{
+		failureCanceled:          2,
+		failureTimeout:           2,
+		failureUnavailable:       1,
+		failureResourceExhausted: 1,
+		failureOther:             1,
+	} {
+		testutil.Equals(t, exp, promtest.ToFloat64(failures.WithLabelValues("store-1", class)), "class %s", class)
+	}
+	// Requests canceled by the caller are counted, but not recorded in the window.
+	testutil.Equals(t, 6, s.get().Requests)
+}
+
+func TestPreferZone(t *testing.T) {
+	lset := []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "eu"}}}}
+	var (
+		storeA1 = &storeRef{addr: "store-a-1", labelSets: lset, minTime: 0, maxTime: 100, zone: "a"}
+		storeB1 = &storeRef{addr: "store-b-1", labelSets: lset, minTime: 0, maxTime: 100, zone: "b"}
+		// Same label sets as storeA1, but different time range, so not a replica.
+		storeB2   = &storeRef{addr: "store-b-2", labelSets: lset, minTime: 0, maxTime: 50, zone: "b"}
+		storeNone = &storeRef{addr: "store-none", labelSets: lset, minTime: 0, maxTime: 100}
+		// Zone configured for its group overrides the advertised one.
+		storeGroupA = &storeRef{addr: "store-group-a", labelSets: lset, minTime: 0, maxTime: 50, zone: "b", group: &StoreGroup{Name: "a", Zone: "a"}}
+	)
+
+	testutil.Equals(t, []*storeRef{storeA1, storeB2}, preferZone([]*storeRef{storeA1, storeB1, storeB2, storeNone}, "a"))
+	testutil.Equals(t, []*storeRef{storeA1, storeGroupA}, preferZone([]*storeRef{storeA1, storeB1, storeB2, storeGroupA}, "a"))
+	testutil.Equals(t, []*storeRef{storeB1, storeB2, storeNone}, preferZone([]*storeRef{storeB1, storeB2, storeNone}, "a"))
+	testutil.Equals(t, []*storeRef{storeB1, storeB2}, preferZone([]*storeRef{storeA1, storeB1, storeB2}, "b"))
+}
@@ -0,0 +1,219 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package query
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// storeStatsWindow is the number of most recent requests of a store its request statistics are calculated of.
+const storeStatsWindow = 100
+
+// Classes of failed requests of stores.
+const (
+	failureTimeout           = "timeout"
+	failureUnavailable       = "unavailable"
+	failureResourceExhausted = "resource_exhausted"
+	failureCanceled          = "canceled"
+	failureOther             = "other"
+)
+
+var failureClasses = []string{failureTimeout, failureUnavailable, failureResourceExhausted, failureCanceled, failureOther}
+
+// failureClass returns the class of the error of a failed request, so a store being down can be told apart from
+// callers canceling requests, e.g. of slow dashboards.
+func failureClass(err error) string {
+	switch {
+	case isCanceled(err):
+		return failureCanceled
+	case errors.Cause(err) == context.DeadlineExceeded || status.Code(err) == codes.DeadlineExceeded:
+		return failureTimeout
+	case status.Code(err) == codes.Unavailable:
+		return failureUnavailable
+	case status.Code(err) == codes.ResourceExhausted:
+		return failureResourceExhausted
+	}
+	return failureOther
+}
+
+// StoreRequestStats are statistics of the most recent requests of a store.
+type StoreRequestStats struct {
+	Requests int
+	Failures int
+
+	LatencyP50 time.Duration
+	LatencyP90 time.Duration
+	LatencyP99 time.Duration
+}
+
+// FailureRate returns the ratio of failed requests.
+func (s StoreRequestStats) FailureRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Requests)
+}
+
+type storeRequest struct {
+	duration time.Duration
+	failed   bool
+}
+
+// storeStats records durations and failures of a rolling window of the most recent requests of a store.
+type storeStats struct {
+	mtx      sync.Mutex
+	requests [storeStatsWindow]storeRequest
+	next     int
+	full     bool
+
+	// failures counts failed requests by class, if not nil.
+	failures *prometheus.CounterVec
+}
+
+// observe records a finished request. Failed requests are counted by class, but requests canceled by the caller are
+// not recorded in the window, as they say nothing about the store.
+func (s *storeStats) observe(d time.Duration, err error) {
+	if err != nil && s.failures != nil {
+		s.failures.WithLabelValues(failureClass(err)).Inc()
+	}
+	if isCanceled(err) {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.requests[s.next] = storeRequest{duration: d, failed: err != nil}
+	s.next++
+	if s.next == storeStatsWindow {
+		s.next = 0
+		s.full = true
+	}
+}
+
+func isCanceled(err error) bool {
+	return errors.Cause(err) == context.Canceled || status.Code(err) == codes.Canceled
+}
+
+func (s *storeStats) get() StoreRequestStats {
+	s.mtx.Lock()
+	requests := s.requests[:s.next]
+	if s.full {
+		requests = s.requests[:]
+	}
+	durations := make([]time.Duration, 0, len(requests))
+	res := StoreRequestStats{Requests: len(requests)}
+	for _, r := range requests {
+		durations = append(durations, r.duration)
+		if r.failed {
+			res.Failures++
+		}
+	}
+	s.mtx.Unlock()
+
+	if len(durations) == 0 {
+		return res
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	quantile := func(q float64) time.Duration {
+		return durations[int(q*float64(len(durations)-1))]
+	}
+	res.LatencyP50 = quantile(0.5)
+	res.LatencyP90 = quantile(0.9)
+	res.LatencyP99 = quantile(0.99)
+	return res
+}
+
+// observedSeriesClient records the series request in stats once its stream is finished, and releases its slot of
+// the concurrency limit of the store, if any.
+type observedSeriesClient struct {
+	storepb.Store_SeriesClient
+
+	start   time.Time
+	stats   *storeStats
+	release func(error)
+
+	once     sync.Once
+	finished chan struct{}
+}
+
+func newObservedSeriesClient(ctx context.Context, cl storepb.Store_SeriesClient, start time.Time, stats *storeStats, release func(error)) *observedSeriesClient {
+	c := &observedSeriesClient{Store_SeriesClient: cl, start: start, stats: stats, release: release, finished: make(chan struct{})}
+	if release != nil {
+		// Callers may stop receiving before the stream is finished, but they cancel its context then.
+		go func() {
+			select {
+			case <-ctx.Done():
+				c.finish(ctx.Err())
+			case <-c.finished:
+			}
+		}()
+	}
+	return c
+}
+
+func (c *observedSeriesClient) finish(err error) {
+	c.once.Do(func() {
+		close(c.finished)
+		c.stats.observe(time.Since(c.start), err)
+		if c.release != nil {
+			c.release(err)
+		}
+	})
+}
+
+func (c *observedSeriesClient) Recv() (*storepb.SeriesResponse, error) {
+	resp, err := c.Store_SeriesClient.Recv()
+	if err == io.EOF {
+		c.finish(nil)
+	} else if err != nil {
+		c.finish(err)
+	}
+	return resp, err
+}
+
+// Series calls the store within its concurrency limit, if any, recording the request in its stats.
+func (s *storeRef) Series(ctx context.Context, req *storepb.SeriesRequest, opts ...grpc.CallOption) (storepb.Store_SeriesClient, error) {
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "wait for concurrency limit of store")
+	}
+	start := time.Now()
+	cl, err := s.StoreClient.Series(ctx, req, opts...)
+	if err != nil {
+		s.stats.observe(time.Since(start), err)
+		if release != nil {
+			release(err)
+		}
+		return nil, err
+	}
+	return newObservedSeriesClient(ctx, cl, start, &s.stats, release), nil
+}
+
+// LabelNames calls the store, recording the request in its stats.
+func (s *storeRef) LabelNames(ctx context.Context, req *storepb.LabelNamesRequest, opts ...grpc.CallOption) (*storepb.LabelNamesResponse, error) {
+	start := time.Now()
+	resp, err := s.StoreClient.LabelNames(ctx, req, opts...)
+	s.stats.observe(time.Since(start), err)
+	return resp, err
+}
+
+// LabelValues calls the store, recording the request in its stats.
+func (s *storeRef) LabelValues(ctx context.Context, req *storepb.LabelValuesRequest, opts ...grpc.CallOption) (*storepb.LabelValuesResponse, error) {
+	start := time.Now()
+	resp, err := s.StoreClient.LabelValues(ctx, req, opts...)
+	s.stats.observe(time.Since(start), err)
+	return resp, err
+}
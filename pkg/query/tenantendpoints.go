@@ -0,0 +1,86 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package query
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"gopkg.in/yaml.v2"
+)
+
+// TenantEndpoint is a virtual StoreAPI endpoint of a tenant, serving the series of the tenant only, with the
+// external labels of the tenant, on its own gRPC address.
+type TenantEndpoint struct {
+	Tenant string `yaml:"tenant"`
+	// Listen is the address the gRPC StoreAPI of the tenant listens on, e.g. "0.0.0.0:10911".
+	Listen string `yaml:"listen_address"`
+	// ExternalLabels are advertised by the endpoint and added to all its series, e.g. {tenant: "team-a"}.
+	ExternalLabels map[string]string `yaml:"external_labels"`
+	// Selector selects the series of the tenant, e.g. {namespace=~"team-a-.*"}. All series are served if empty.
+	Selector string `yaml:"selector"`
+
+	matchers []storepb.LabelMatcher
+}
+
+// ParseTenantEndpoints parses the YAML tenant endpoints configuration.
+func ParseTenantEndpoints(conf []byte) ([]TenantEndpoint, error) {
+	var endpoints []TenantEndpoint
+	if err := yaml.UnmarshalStrict(conf, &endpoints); err != nil {
+		return nil, errors.Wrap(err, "parsing tenant endpoints config")
+	}
+
+	tenants := make(map[string]struct{}, len(endpoints))
+	for i, e := range endpoints {
+		if e.Tenant == "" {
+			return nil, errors.New("tenant endpoint without tenant")
+		}
+		if _, ok := tenants[e.Tenant]; ok {
+			return nil, errors.Errorf("duplicate tenant endpoint %s", e.Tenant)
+		}
+		tenants[e.Tenant] = struct{}{}
+
+		if e.Listen == "" {
+			return nil, errors.Errorf("tenant endpoint %s has no listen address", e.Tenant)
+		}
+		if len(e.ExternalLabels) == 0 {
+			return nil, errors.Errorf("tenant endpoint %s has no external labels", e.Tenant)
+		}
+		for n, v := range e.ExternalLabels {
+			if v == "" {
+				return nil, errors.Errorf("tenant endpoint %s has empty external label %s", e.Tenant, n)
+			}
+		}
+		if e.Selector == "" {
+			continue
+		}
+		ms, err := promql.ParseMetricSelector(e.Selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse selector of tenant endpoint %s", e.Tenant)
+		}
+		endpoints[i].matchers, err = translateMatchers(ms...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse selector of tenant endpoint %s", e.Tenant)
+		}
+	}
+	return endpoints, nil
+}
+
+// Labels returns the external labels of the endpoint.
+func (e TenantEndpoint) Labels() labels.Labels {
+	lset := make(labels.Labels, 0, len(e.ExternalLabels))
+	for n, v := range e.ExternalLabels {
+		lset = append(lset, labels.Label{Name: n, Value: v})
+	}
+	sort.Sort(lset)
+	return lset
+}
+
+// Matchers returns the matchers of the selector of the endpoint.
+func (e TenantEndpoint) Matchers() []storepb.LabelMatcher {
+	return e.matchers
+}
@@ -0,0 +1,47 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package query
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestParseTenantEndpoints(t *testing.T) {
+	endpoints, err := ParseTenantEndpoints([]byte(`
+- tenant: team-a
+  listen_address: 0.0.0.0:10911
+  external_labels:
+    tenant: team-a
+    tier: gold
+  selector: '{namespace=~"team-a-.*"}'
+- tenant: team-b
+  listen_address: 0.0.0.0:10912
+  external_labels:
+    tenant: team-b
+`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(endpoints))
+
+	testutil.Equals(t, labels.FromStrings("tenant", "team-a", "tier", "gold"), endpoints[0].Labels())
+	testutil.Equals(t, []storepb.LabelMatcher{{Name: "namespace", Value: "team-a-.*", Type: storepb.LabelMatcher_RE}}, endpoints[0].Matchers())
+	testutil.Equals(t, labels.FromStrings("tenant", "team-b"), endpoints[1].Labels())
+	testutil.Equals(t, 0, len(endpoints[1].Matchers()))
+
+	for _, conf := range []string{
+		`[{listen_address: ":10911", external_labels: {tenant: a}}]`,
+		`[{tenant: a, external_labels: {tenant: a}}]`,
+		`[{tenant: a, listen_address: ":10911"}]`,
+		`[{tenant: a, listen_address: ":10911", external_labels: {tenant: ""}}]`,
+		`[{tenant: a, listen_address: ":10911", external_labels: {tenant: a}, selector: "{"}]`,
+		`[{tenant: a, listen_address: ":10911", external_labels: {tenant: a}}, {tenant: a, listen_address: ":10912", external_labels: {tenant: a}}]`,
+		`[{tenant: a, listen: ":10911", external_labels: {tenant: a}}]`,
+	} {
+		_, err := ParseTenantEndpoints([]byte(conf))
+		testutil.NotOk(t, err, conf)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package querybench
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// HTTPClient sends an HTTP request and returns the response.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Result is the result of a single query execution.
+type Result struct {
+	Query    Query
+	Duration time.Duration
+	// Bytes is the size of the response body.
+	Bytes int64
+	Err   error
+}
+
+// Bench replays queries against the query API.
+type Bench struct {
+	logger log.Logger
+	client HTTPClient
+	base   *url.URL
+	header http.Header
+
+	concurrency int
+	timeout     time.Duration
+}
+
+// New returns Bench executing queries against the query API at the given base URL, with the given number of queries in
+// flight at once. Each query is limited by the given timeout, unless it is zero. The header is added to each request.
+func New(logger log.Logger, client HTTPClient, base *url.URL, header http.Header, concurrency int, timeout time.Duration) *Bench {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Bench{
+		logger:      logger,
+		client:      client,
+		base:        base,
+		header:      header,
+		concurrency: concurrency,
+		timeout:     timeout,
+	}
+}
+
+// Run executes the queries repeat times in the given order and returns their results in the same order. Failed
+// queries are reported in results, Run fails only if the context is canceled. Results of queries started before the
+// cancellation are returned in that case too.
+func (b *Bench) Run(ctx context.Context, queries []Query, repeat int) ([]Result, error) {
+	results := make([]Result, len(queries)*repeat)
+
+	var (
+		wg   sync.WaitGroup
+		jobs = make(chan int)
+	)
+	for i := 0; i < b.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j] = b.exec(ctx, queries[j%len(queries)])
+			}
+		}()
+	}
+
+	var (
+		sent int
+		err  error
+	)
+produce:
+	for ; sent < len(results); sent++ {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break produce
+		case jobs <- sent:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results[:sent], err
+}
+
+// queryResponse is the part of the query API response needed to tell failed queries.
+type queryResponse struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	ErrorType string `json:"errorType,omitempty"`
+}
+
+func (b *Bench) exec(ctx context.Context, q Query) Result {
+	res := Result{Query: q}
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	params := url.Values{"query": {q.Expr}}
+	u := *b.base
+	if q.IsRange() {
+		u.Path = path.Join(u.Path, "/api/v1/query_range")
+		params.Set("start", formatTime(q.Start))
+		params.Set("end", formatTime(q.End))
+		params.Set("step", strconv.FormatFloat(q.Step.Seconds(), 'f', -1, 64))
+	} else {
+		u.Path = path.Join(u.Path, "/api/v1/query")
+		params.Set("time", formatTime(q.End))
+	}
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		res.Err = errors.Wrap(err, "create request")
+		return res
+	}
+	for k, v := range b.header {
+		req.Header[k] = v
+	}
+
+	begin := time.Now()
+	resp, err := b.client.Do(req.WithContext(ctx))
+	if err != nil {
+		res.Duration = time.Since(begin)
+		res.Err = errors.Wrap(err, "perform request")
+		return res
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(b.logger, resp.Body, "query body")
+
+	body, err := ioutil.ReadAll(resp.Body)
+	res.Duration = time.Since(begin)
+	res.Bytes = int64(len(body))
+	if err != nil {
+		res.Err = errors.Wrap(err, "read response")
+		return res
+	}
+
+	var r queryResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		res.Err = errors.Wrapf(err, "unmarshal response with status code %d", resp.StatusCode)
+		return res
+	}
+	if resp.StatusCode != http.StatusOK || r.Status != "success" {
+		res.Err = errors.Errorf("query failed with status code %d: %s: %s", resp.StatusCode, r.ErrorType, r.Error)
+	}
+	level.Debug(b.logger).Log("msg", "query done", "query", q.Expr, "duration", res.Duration, "bytes", res.Bytes, "err", res.Err)
+	return res
+}
+
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', -1, 64)
+}
+
+// Summary summarizes results of queries.
+type Summary struct {
+	Queries int
+	Errors  int
+	// Bytes is the total size of response bodies.
+	Bytes int64
+
+	P50, P90, P99, Max time.Duration
+}
+
+// Summarize computes the summary of the given results. Latencies include failed queries.
+func Summarize(results []Result) Summary {
+	s := Summary{Queries: len(results)}
+	if len(results) == 0 {
+		return s
+	}
+
+	durations := make([]time.Duration, 0, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			s.Errors++
+		}
+		s.Bytes += r.Bytes
+		durations = append(durations, r.Duration)
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	s.P50 = quantile(durations, 0.5)
+	s.P90 = quantile(durations, 0.9)
+	s.P99 = quantile(durations, 0.99)
+	s.Max = durations[len(durations)-1]
+	return s
+}
+
+// quantile returns the nearest-rank quantile of sorted durations.
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
@@ -0,0 +1,99 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package querybench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestBench_Run(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		requests []url.Values
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "tenant-a", r.Header.Get("THANOS-TENANT"))
+		mtx.Lock()
+		requests = append(requests, r.URL.Query())
+		mtx.Unlock()
+
+		q := r.URL.Query().Get("query")
+		switch {
+		case q == "fail":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"status":"error","errorType":"execution","error":"failed"}`)
+		case r.URL.Path == "/prefix/api/v1/query_range":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[]}}`)
+		case r.URL.Path == "/prefix/api/v1/query":
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL + "/prefix")
+	testutil.Ok(t, err)
+
+	now := time.Unix(1585742400, 0)
+	queries := []Query{
+		{Expr: "up", Start: now, End: now},
+		{Expr: "rate(a[5m])", Start: now.Add(-time.Hour), End: now, Step: 30 * time.Second},
+		{Expr: "fail", Start: now, End: now},
+	}
+	b := New(nil, http.DefaultClient, u, http.Header{"Thanos-Tenant": {"tenant-a"}}, 2, time.Minute)
+	results, err := b.Run(context.Background(), queries, 2)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 6, len(results))
+	testutil.Equals(t, 6, len(requests))
+
+	for i, r := range results {
+		testutil.Equals(t, queries[i%3], r.Query)
+		testutil.Assert(t, r.Bytes > 0, "response size of %s should be recorded", r.Query.Expr)
+		if r.Query.Expr == "fail" {
+			testutil.NotOk(t, r.Err)
+			continue
+		}
+		testutil.Ok(t, r.Err)
+	}
+	for _, p := range requests {
+		if p.Get("query") == "rate(a[5m])" {
+			testutil.Equals(t, "1585738800", p.Get("start"))
+			testutil.Equals(t, "1585742400", p.Get("end"))
+			testutil.Equals(t, "30", p.Get("step"))
+			continue
+		}
+		testutil.Equals(t, "1585742400", p.Get("time"))
+	}
+
+	s := Summarize(results)
+	testutil.Equals(t, 6, s.Queries)
+	testutil.Equals(t, 2, s.Errors)
+	testutil.Assert(t, s.P50 <= s.P90 && s.P90 <= s.P99 && s.P99 <= s.Max, "quantiles should be ordered: %+v", s)
+}
+
+func TestSummarize(t *testing.T) {
+	var results []Result
+	for i := 1; i <= 100; i++ {
+		results = append(results, Result{Duration: time.Duration(i) * time.Millisecond, Bytes: 10})
+	}
+	testutil.Equals(t, Summary{
+		Queries: 100,
+		Bytes:   1000,
+		P50:     50 * time.Millisecond,
+		P90:     90 * time.Millisecond,
+		P99:     99 * time.Millisecond,
+		Max:     100 * time.Millisecond,
+	}, Summarize(results))
+	testutil.Equals(t, Summary{}, Summarize(nil))
+}
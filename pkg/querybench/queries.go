@@ -0,0 +1,114 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package querybench replays queries against a Prometheus compatible query API and reports their latencies.
+package querybench
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Query is a single query to replay. Queries with zero Step are instant queries evaluated at End.
+type Query struct {
+	Expr  string
+	Start time.Time
+	End   time.Time
+	Step  time.Duration
+}
+
+// IsRange returns true if the query is a range query.
+func (q Query) IsRange() bool {
+	return q.Step > 0
+}
+
+// queryLogEntry is an entry of the Prometheus query log, enabled by global.query_log_file option of Prometheus 2.16+.
+type queryLogEntry struct {
+	Params struct {
+		Query string    `json:"query"`
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+		// Step is in seconds.
+		Step int64 `json:"step"`
+	} `json:"params"`
+}
+
+// ParseQueries parses queries, one per line. A line is either an entry of the Prometheus query log in JSON, or a
+// PromQL expression. Expressions are evaluated at now as instant queries, or as range queries over the given range
+// ending at now, if the range is not zero. Empty lines and lines starting with # are skipped.
+func ParseQueries(r io.Reader, now time.Time, rng, step time.Duration) ([]Query, error) {
+	if rng > 0 && step <= 0 {
+		return nil, errors.New("step of range queries has to be positive")
+	}
+
+	var (
+		queries []Query
+		lineNum int
+	)
+	s := bufio.NewScanner(r)
+	// Query log entries may contain long queries.
+	s.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for s.Scan() {
+		lineNum++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, "{") {
+			q := Query{Expr: line, Start: now, End: now}
+			if rng > 0 {
+				q.Start = now.Add(-rng)
+				q.Step = step
+			}
+			queries = append(queries, q)
+			continue
+		}
+
+		var e queryLogEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, errors.Wrapf(err, "parse query log entry at line %d", lineNum)
+		}
+		if e.Params.Query == "" {
+			return nil, errors.Errorf("no query in query log entry at line %d", lineNum)
+		}
+		queries = append(queries, Query{
+			Expr:  e.Params.Query,
+			Start: e.Params.Start,
+			End:   e.Params.End,
+			Step:  time.Duration(e.Params.Step) * time.Second,
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "read queries")
+	}
+	return queries, nil
+}
+
+// Shift moves time ranges of the queries by the given duration.
+func Shift(queries []Query, d time.Duration) {
+	for i := range queries {
+		queries[i].Start = queries[i].Start.Add(d)
+		queries[i].End = queries[i].End.Add(d)
+	}
+}
+
+// ShiftToNow moves time ranges of the queries, so the latest one ends at now. Relative time ranges of queries are
+// kept, e.g. to replay an old query log against recent data.
+func ShiftToNow(queries []Query, now time.Time) {
+	if len(queries) == 0 {
+		return
+	}
+	latest := queries[0].End
+	for _, q := range queries[1:] {
+		if q.End.After(latest) {
+			latest = q.End
+		}
+	}
+	Shift(queries, now.Sub(latest))
+}
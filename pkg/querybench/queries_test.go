@@ -0,0 +1,65 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package querybench
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestParseQueries(t *testing.T) {
+	now := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	input := `# Comment.
+up
+
+{"params":{"end":"2020-02-08T14:59:50.368Z","query":"rate(http_requests_total[5m])","start":"2020-02-08T13:59:50.368Z","step":15},"stats":{"timings":{"evalTotalTime":0.000447452}},"ts":"2020-02-08T14:59:50.387Z"}
+{"params":{"end":"2020-02-08T15:00:00Z","query":"up == 0","start":"2020-02-08T15:00:00Z","step":0},"ts":"2020-02-08T15:00:00.001Z"}
+`
+	queries, err := ParseQueries(strings.NewReader(input), now, 0, 0)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []Query{
+		{Expr: "up", Start: now, End: now},
+		{
+			Expr:  "rate(http_requests_total[5m])",
+			Start: time.Date(2020, 2, 8, 13, 59, 50, 368000000, time.UTC),
+			End:   time.Date(2020, 2, 8, 14, 59, 50, 368000000, time.UTC),
+			Step:  15 * time.Second,
+		},
+		{Expr: "up == 0", Start: time.Date(2020, 2, 8, 15, 0, 0, 0, time.UTC), End: time.Date(2020, 2, 8, 15, 0, 0, 0, time.UTC)},
+	}, queries)
+	testutil.Assert(t, !queries[0].IsRange(), "plain query should be instant")
+	testutil.Assert(t, queries[1].IsRange(), "query with step should be range")
+
+	// Plain queries become range queries with range given.
+	queries, err = ParseQueries(strings.NewReader("up"), now, time.Hour, time.Minute)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []Query{{Expr: "up", Start: now.Add(-time.Hour), End: now, Step: time.Minute}}, queries)
+
+	_, err = ParseQueries(strings.NewReader("up"), now, time.Hour, 0)
+	testutil.NotOk(t, err)
+	_, err = ParseQueries(strings.NewReader(`{"params":{}}`), now, 0, 0)
+	testutil.NotOk(t, err)
+	_, err = ParseQueries(strings.NewReader(`{"params":`), now, 0, 0)
+	testutil.NotOk(t, err)
+}
+
+func TestShiftToNow(t *testing.T) {
+	now := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	base := time.Date(2020, 2, 8, 15, 0, 0, 0, time.UTC)
+	queries := []Query{
+		{Expr: "a", Start: base.Add(-2 * time.Hour), End: base.Add(-time.Hour), Step: time.Minute},
+		{Expr: "b", Start: base, End: base},
+	}
+	ShiftToNow(queries, now)
+	testutil.Equals(t, []Query{
+		{Expr: "a", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour), Step: time.Minute},
+		{Expr: "b", Start: now, End: now},
+	}, queries)
+
+	Shift(queries, -time.Hour)
+	testutil.Equals(t, now.Add(-time.Hour), queries[1].End)
+}
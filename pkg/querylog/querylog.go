@@ -0,0 +1,194 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package querylog implements structured logs of executed PromQL queries, for analysis of query patterns.
+package querylog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thanos-io/thanos/pkg/audit"
+	"gopkg.in/yaml.v2"
+)
+
+// StatusSuccess is the status of successfully executed queries. Failed queries have the type of their error as status.
+const StatusSuccess = "success"
+
+// Config is the configuration of query logging.
+type Config struct {
+	// Sink configures where entries are written, like the sink of audit logs.
+	Sink     audit.SinkConfig `yaml:"sink"`
+	Sampling SamplingConfig   `yaml:"sampling"`
+}
+
+// SamplingConfig configures which queries are logged.
+type SamplingConfig struct {
+	// Rate is the fraction of queries logged, from 0 to 1.
+	Rate float64 `yaml:"rate"`
+	// SlowQueryThreshold is the duration above which queries are logged regardless of the rate, if not 0.
+	SlowQueryThreshold model.Duration `yaml:"slow_query_threshold"`
+	// Errors logs failed queries regardless of the rate.
+	Errors bool `yaml:"errors"`
+}
+
+func DefaultConfig() Config {
+	return Config{Sampling: SamplingConfig{Rate: 1}}
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig()
+	type plain Config
+	return unmarshal((*plain)(c))
+}
+
+// Entry is a log entry of an executed query.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Tenant is the tenant the query is made for, if any.
+	Tenant string `json:"tenant,omitempty"`
+	// API is the name of the query API, "query" or "query_range".
+	API string `json:"api"`
+	// Query is the executed query, normalized by formatting it. Matchers enforced by authorization are included.
+	Query string `json:"query"`
+	// Start and End are the evaluation time range, which are equal for instant queries, and Step is the resolution
+	// step of range queries.
+	Start time.Time     `json:"start"`
+	End   time.Time     `json:"end"`
+	Step  time.Duration `json:"-"`
+	// Duration is the time the query took to execute.
+	Duration time.Duration `json:"-"`
+	// StepSeconds and DurationSeconds are Step and Duration in seconds, set when the entry is written.
+	StepSeconds     float64 `json:"step_seconds,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Status is StatusSuccess, or the type of the error of failed queries.
+	Status string `json:"status"`
+	// Error is the error of failed queries.
+	Error string `json:"error,omitempty"`
+	// ResponseBytes is the size of the uncompressed response.
+	ResponseBytes int64 `json:"response_bytes"`
+}
+
+type entryContextKey struct{}
+
+// ContextWithEntry returns a context carrying the entry of the request, so query handlers can fill it.
+func ContextWithEntry(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, entryContextKey{}, e)
+}
+
+// EntryFromContext returns the entry of the request carried by the context, or nil if queries are not logged.
+func EntryFromContext(ctx context.Context) *Entry {
+	e, _ := ctx.Value(entryContextKey{}).(*Entry)
+	return e
+}
+
+// Logger writes entries of sampled queries as JSON lines to its sink.
+type Logger struct {
+	logger   log.Logger
+	sampling SamplingConfig
+	now      func() time.Time
+
+	mtx  sync.Mutex
+	w    io.Writer
+	enc  *json.Encoder
+	rand *rand.Rand
+
+	entries  *prometheus.CounterVec
+	failures prometheus.Counter
+}
+
+// NewLogger parses the YAML query log configuration and returns a query logger.
+func NewLogger(logger log.Logger, reg prometheus.Registerer, conf []byte) (*Logger, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing query log config")
+	}
+	if config.Sampling.Rate < 0 || config.Sampling.Rate > 1 {
+		return nil, errors.Errorf("sampling rate %v is not between 0 and 1", config.Sampling.Rate)
+	}
+	w, err := audit.OpenSink(config.Sink)
+	if err != nil {
+		return nil, err
+	}
+	return newLogger(logger, reg, w, config.Sampling), nil
+}
+
+func newLogger(logger log.Logger, reg prometheus.Registerer, w io.Writer, sampling SamplingConfig) *Logger {
+	return &Logger{
+		logger:   logger,
+		sampling: sampling,
+		now:      time.Now,
+		w:        w,
+		enc:      json.NewEncoder(w),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		entries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_query_log_entries_total",
+			Help: "Total number of executed queries, by whether they were logged: logged or dropped by sampling.",
+		}, []string{"result"}),
+		failures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_query_log_entry_write_failures_total",
+			Help: "Total number of query log entries which failed to be written.",
+		}),
+	}
+}
+
+// sampled returns true if the entry is logged.
+func (l *Logger) sampled(e *Entry) bool {
+	if l.sampling.Errors && e.Status != StatusSuccess {
+		return true
+	}
+	if l.sampling.SlowQueryThreshold > 0 && e.Duration > time.Duration(l.sampling.SlowQueryThreshold) {
+		return true
+	}
+	return l.sampling.Rate >= 1 || l.rand.Float64() < l.sampling.Rate
+}
+
+// Log normalizes and writes the entry, if it is sampled.
+func (l *Logger) Log(e Entry) {
+	l.mtx.Lock()
+	sampled := l.sampled(&e)
+	l.mtx.Unlock()
+	if !sampled {
+		l.entries.WithLabelValues("dropped").Inc()
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = l.now()
+	}
+	// Queries which cannot be parsed are logged as requested.
+	if expr, err := promql.ParseExpr(e.Query); err == nil {
+		e.Query = expr.String()
+	}
+	e.StepSeconds, e.DurationSeconds = e.Step.Seconds(), e.Duration.Seconds()
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if err := l.enc.Encode(e); err != nil {
+		l.failures.Inc()
+		level.Error(l.logger).Log("msg", "failed to write query log entry", "api", e.API, "err", err)
+		return
+	}
+	l.entries.WithLabelValues("logged").Inc()
+}
+
+// Close closes the sink, if it is a file.
+func (l *Logger) Close() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return audit.CloseSink(l.w)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package querylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestNewLogger_Config(t *testing.T) {
+	for _, tcase := range []struct {
+		conf string
+		err  bool
+	}{
+		{conf: `{sink: {type: STDOUT}}`},
+		{conf: `{sink: {type: STDOUT}, sampling: {rate: 0.1, slow_query_threshold: 10s, errors: true}}`},
+		{conf: `{sink: {type: STDOUT}, sampling: {rate: 2}}`, err: true},
+		{conf: `{sink: {type: FILE}}`, err: true},
+		{conf: `{sink: {type: KAFKA}}`, err: true},
+		{conf: `{sink: {type: STDOUT}, unknown: field}`, err: true},
+	} {
+		t.Run(tcase.conf, func(t *testing.T) {
+			_, err := NewLogger(log.NewNopLogger(), nil, []byte(tcase.conf))
+			if tcase.err {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+		})
+	}
+}
+
+func TestLogger_Log(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger(log.NewNopLogger(), prometheus.NewRegistry(), &buf, SamplingConfig{Rate: 1})
+	now := time.Unix(1000, 0).UTC()
+	l.now = func() time.Time { return now }
+
+	// Queries are normalized, unless they cannot be parsed.
+	l.Log(Entry{
+		Tenant:   "team-a",
+		API:      "query_range",
+		Query:    `sum by(job)(rate( http_requests_total{job="api"}[5m] ))`,
+		Start:    now.Add(-time.Hour),
+		End:      now,
+		Step:     time.Minute,
+		Duration: 1500 * time.Millisecond,
+		Status:   StatusSuccess,
+	})
+	l.Log(Entry{API: "query", Query: `up{`, Start: now, End: now, Status: "bad_data", Error: "parse error"})
+
+	var entries []Entry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e Entry
+		testutil.Ok(t, json.Unmarshal([]byte(line), &e))
+		e.Start, e.End, e.Time = e.Start.UTC(), e.End.UTC(), e.Time.UTC()
+		entries = append(entries, e)
+	}
+	testutil.Equals(t, []Entry{
+		{
+			Time:            now,
+			Tenant:          "team-a",
+			API:             "query_range",
+			Query:           `sum by(job) (rate(http_requests_total{job="api"}[5m]))`,
+			Start:           now.Add(-time.Hour),
+			End:             now,
+			StepSeconds:     60,
+			DurationSeconds: 1.5,
+			Status:          StatusSuccess,
+		},
+		{
+			Time:   now,
+			API:    "query",
+			Query:  `up{`,
+			Start:  now,
+			End:    now,
+			Status: "bad_data",
+			Error:  "parse error",
+		},
+	}, entries)
+	testutil.Equals(t, 2.0, promtest.ToFloat64(l.entries.WithLabelValues("logged")))
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := newLogger(log.NewNopLogger(), nil, &buf, SamplingConfig{
+		Rate:               0,
+		SlowQueryThreshold: model.Duration(time.Second),
+		Errors:             true,
+	})
+
+	l.Log(Entry{API: "query", Query: "up", Duration: time.Millisecond, Status: StatusSuccess})
+	l.Log(Entry{API: "query", Query: "slow", Duration: 2 * time.Second, Status: StatusSuccess})
+	l.Log(Entry{API: "query", Query: "failed", Duration: time.Millisecond, Status: "execution"})
+
+	testutil.Equals(t, 1.0, promtest.ToFloat64(l.entries.WithLabelValues("dropped")))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(l.entries.WithLabelValues("logged")))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	testutil.Equals(t, 2, len(lines))
+	testutil.Assert(t, strings.Contains(lines[0], `"query":"slow"`), "expected slow query logged, got %s", lines[0])
+	testutil.Assert(t, strings.Contains(lines[1], `"query":"failed"`), "expected failed query logged, got %s", lines[1])
+}
@@ -0,0 +1,271 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package ratelimit implements token bucket rate limiting of HTTP and gRPC requests by tenant or client IP.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+)
+
+type KeyType string
+
+const (
+	TENANT    KeyType = "TENANT"
+	CLIENT_IP KeyType = "CLIENT_IP"
+)
+
+// retryAfterHeader is the header of HTTP responses, and the metadata key of gRPC responses, with the number of
+// seconds after which rate limited requests can be retried.
+const retryAfterHeader = "Retry-After"
+
+// grpcHealthPrefix is the method prefix of the gRPC health service, which is not limited so probes keep working.
+const grpcHealthPrefix = "/grpc.health.v1.Health/"
+
+// Config is the configuration of rate limits.
+type Config struct {
+	// Key determines whose requests share a limit, TENANT or CLIENT_IP.
+	Key KeyType `yaml:"key"`
+	// Default is the limit of keys without override.
+	Default Limit `yaml:"default"`
+	// Overrides are limits of specific tenants or client IPs.
+	Overrides map[string]Limit `yaml:"overrides"`
+}
+
+// Limit is a token bucket limit.
+type Limit struct {
+	// RequestsPerSecond is the rate requests are allowed at. Requests are not limited if zero.
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	// Burst is the number of requests allowed at once. Defaults to RequestsPerSecond, rounded up.
+	Burst int `yaml:"burst"`
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Max(1, math.Ceil(l.RequestsPerSecond)))
+}
+
+// bucket is the token bucket of a key.
+type bucket struct {
+	limiter *rate.Limiter
+	// idleTimeout is the time after which an unused bucket is full again, so it can be removed.
+	idleTimeout time.Duration
+	lastUsed    time.Time
+}
+
+// Limiter limits the rate of requests per tenant or client IP.
+type Limiter struct {
+	key          KeyType
+	tenantHeader string
+	def          Limit
+	overrides    map[string]Limit
+	now          func() time.Time
+
+	mtx         sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+
+	limited *prometheus.CounterVec
+}
+
+// NewLimiter parses the YAML rate limit configuration and returns a limiter. The tenant of requests is read from
+// the given header.
+func NewLimiter(reg prometheus.Registerer, conf []byte, tenantHeader string) (*Limiter, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing rate limit config")
+	}
+	return NewLimiterWithConfig(reg, config, tenantHeader)
+}
+
+// NewLimiterWithConfig returns a limiter with the given configuration.
+func NewLimiterWithConfig(reg prometheus.Registerer, config Config, tenantHeader string) (*Limiter, error) {
+	switch config.Key {
+	case TENANT, CLIENT_IP:
+	default:
+		return nil, errors.Errorf("rate limit key %s is not supported", config.Key)
+	}
+	for key, limit := range config.Overrides {
+		if limit.RequestsPerSecond < 0 || limit.Burst < 0 {
+			return nil, errors.Errorf("negative limit of %s", key)
+		}
+	}
+	if config.Default.RequestsPerSecond < 0 || config.Default.Burst < 0 {
+		return nil, errors.New("negative default limit")
+	}
+
+	l := &Limiter{
+		key:          config.Key,
+		tenantHeader: tenantHeader,
+		def:          config.Default,
+		overrides:    config.Overrides,
+		now:          time.Now,
+		buckets:      map[string]*bucket{},
+		limited: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_rate_limited_requests_total",
+			Help: "Total number of requests rejected because of exceeded rate limits.",
+		}, []string{"protocol"}),
+	}
+	l.limited.WithLabelValues("http")
+	l.limited.WithLabelValues("grpc")
+	return l, nil
+}
+
+// allow takes a token of the bucket of the key. If the bucket is empty, it returns false and the duration after
+// which a token is available.
+func (l *Limiter) allow(key string) (bool, time.Duration) {
+	limit, ok := l.overrides[key]
+	if !ok {
+		limit = l.def
+	}
+	if limit.RequestsPerSecond == 0 {
+		return true, 0
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		burst := limit.burst()
+		b = &bucket{
+			limiter:     rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), burst),
+			idleTimeout: time.Duration(float64(burst) / limit.RequestsPerSecond * float64(time.Second)),
+		}
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// cleanup removes buckets which are full again, at most once a minute. It has to be called with mtx held.
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < time.Minute {
+		return
+	}
+	l.lastCleanup = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastUsed) > b.idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// retryAfterSeconds returns the number of seconds clients have to wait, rounded up as required by Retry-After.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Handler returns a handler calling next for requests within their limit, and responding with 429 Too Many Requests
+// otherwise.
+func (l *Limiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var key string
+		switch l.key {
+		case TENANT:
+			key = r.Header.Get(l.tenantHeader)
+		case CLIENT_IP:
+			key = r.RemoteAddr
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				key = host
+			}
+		}
+
+		if ok, retryAfter := l.allow(key); !ok {
+			l.limited.WithLabelValues("http").Inc()
+			w.Header().Set(retryAfterHeader, retryAfterSeconds(retryAfter))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// grpcKey returns the key of a gRPC request. Tenants are read from the metadata key of the tenant header.
+func (l *Limiter) grpcKey(ctx context.Context) string {
+	switch l.key {
+	case TENANT:
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get(strings.ToLower(l.tenantHeader)); len(v) > 0 {
+				return v[0]
+			}
+		}
+	case CLIENT_IP:
+		if p, ok := peer.FromContext(ctx); ok {
+			if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+				return host
+			}
+			return p.Addr.String()
+		}
+	}
+	return ""
+}
+
+// grpcAllow returns a ResourceExhausted error for requests exceeding their limit, after setting the retry-after
+// response header.
+func (l *Limiter) grpcAllow(ctx context.Context, setHeader func(metadata.MD) error) error {
+	ok, retryAfter := l.allow(l.grpcKey(ctx))
+	if ok {
+		return nil
+	}
+	l.limited.WithLabelValues("grpc").Inc()
+	// Failing to set the header does not change the result of the request.
+	_ = setHeader(metadata.Pairs(strings.ToLower(retryAfterHeader), retryAfterSeconds(retryAfter)))
+	return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor rejecting unary requests exceeding their limit.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, grpcHealthPrefix) {
+			return handler(ctx, req)
+		}
+		if err := l.grpcAllow(ctx, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC interceptor rejecting streaming requests exceeding their limit.
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, grpcHealthPrefix) {
+			return handler(srv, ss)
+		}
+		if err := l.grpcAllow(ss.Context(), ss.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
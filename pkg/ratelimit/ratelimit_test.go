@@ -0,0 +1,143 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	l, err := NewLimiter(nil, []byte(`
+key: TENANT
+default:
+  requests_per_second: 1
+  burst: 2
+overrides:
+  unlimited:
+    requests_per_second: 0
+  fast:
+    requests_per_second: 10
+`), "THANOS-TENANT")
+	testutil.Ok(t, err)
+	now := time.Unix(1000, 0)
+	l.now = func() time.Time { return now }
+
+	// Burst is allowed at once, after which requests are allowed at the configured rate.
+	for i := 0; i < 2; i++ {
+		ok, _ := l.allow("team-a")
+		testutil.Assert(t, ok, "expected request %d to be allowed", i)
+	}
+	ok, retryAfter := l.allow("team-a")
+	testutil.Assert(t, !ok, "expected request to be limited")
+	testutil.Equals(t, time.Second, retryAfter)
+
+	// Limits are separate per key.
+	ok, _ = l.allow("team-b")
+	testutil.Assert(t, ok, "expected request of other key to be allowed")
+
+	now = now.Add(500 * time.Millisecond)
+	ok, retryAfter = l.allow("team-a")
+	testutil.Assert(t, !ok, "expected request to be limited")
+	testutil.Equals(t, 500*time.Millisecond, retryAfter)
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.allow("team-a")
+	testutil.Assert(t, ok, "expected request to be allowed after refill")
+
+	for i := 0; i < 100; i++ {
+		ok, _ = l.allow("unlimited")
+		testutil.Assert(t, ok, "expected unlimited request %d to be allowed", i)
+	}
+	for i := 0; i < 10; i++ {
+		ok, _ = l.allow("fast")
+		testutil.Assert(t, ok, "expected request %d within burst of override to be allowed", i)
+	}
+	ok, _ = l.allow("fast")
+	testutil.Assert(t, !ok, "expected request to be limited")
+
+	// Buckets are removed once full again.
+	testutil.Equals(t, 3, len(l.buckets))
+	now = now.Add(time.Minute)
+	_, _ = l.allow("team-a")
+	testutil.Equals(t, 1, len(l.buckets))
+}
+
+func TestNewLimiter(t *testing.T) {
+	for _, conf := range []string{
+		"key: UNKNOWN",
+		"key: TENANT\ndefault: {requests_per_second: -1}",
+		"key: TENANT\noverrides: {team-a: {burst: -1}}",
+		"key: TENANT\nunknown: 1",
+	} {
+		_, err := NewLimiter(nil, []byte(conf), "THANOS-TENANT")
+		testutil.NotOk(t, err)
+	}
+}
+
+func TestLimiter_Handler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	l, err := NewLimiterWithConfig(reg, Config{Key: CLIENT_IP, Default: Limit{RequestsPerSecond: 0.5}}, "")
+	testutil.Ok(t, err)
+	h := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/query", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	testutil.Equals(t, http.StatusOK, request("10.0.0.1:1234").Code)
+	// Clients are identified by IP, regardless of port.
+	rec := request("10.0.0.1:5678")
+	testutil.Equals(t, http.StatusTooManyRequests, rec.Code)
+	testutil.Equals(t, "2", rec.Header().Get("Retry-After"))
+	testutil.Equals(t, http.StatusOK, request("10.0.0.2:1234").Code)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(l.limited.WithLabelValues("http")))
+}
+
+func TestLimiter_GRPC(t *testing.T) {
+	l, err := NewLimiterWithConfig(nil, Config{Key: TENANT, Default: Limit{RequestsPerSecond: 1}}, "THANOS-TENANT")
+	testutil.Ok(t, err)
+	handler := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+	info := &grpc.UnaryServerInfo{FullMethod: "/thanos.Store/Info"}
+	interceptor := l.UnaryServerInterceptor()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("thanos-tenant", "team-a"))
+	_, err = interceptor(ctx, nil, info, handler)
+	testutil.Ok(t, err)
+	_, err = interceptor(ctx, nil, info, handler)
+	testutil.Equals(t, codes.ResourceExhausted, status.Code(err))
+
+	// Health checks are not limited.
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	testutil.Ok(t, err)
+
+	_, err = interceptor(metadata.NewIncomingContext(context.Background(), metadata.Pairs("thanos-tenant", "team-b")), nil, info, handler)
+	testutil.Ok(t, err)
+
+	l, err = NewLimiterWithConfig(nil, Config{Key: CLIENT_IP, Default: Limit{RequestsPerSecond: 1}}, "")
+	testutil.Ok(t, err)
+	interceptor = l.UnaryServerInterceptor()
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+	_, err = interceptor(ctx, nil, info, handler)
+	testutil.Ok(t, err)
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5678}})
+	_, err = interceptor(ctx, nil, info, handler)
+	testutil.Equals(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -31,8 +32,12 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/thanos-io/thanos/pkg/audit"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/profiling"
+	"github.com/thanos-io/thanos/pkg/ratelimit"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	httpserver "github.com/thanos-io/thanos/pkg/server/http"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/tracing"
 )
@@ -44,6 +49,13 @@ const (
 	DefaultReplicaHeader = "THANOS-REPLICA"
 )
 
+// Phases of handling remote write requests, with durations and deadline exceeded requests tracked per phase.
+const (
+	phaseRequest    = "request"
+	phaseLocalWrite = "local_write"
+	phaseForward    = "forward"
+)
+
 // conflictErr is returned whenever an operation fails due to any conflict-type error.
 var conflictErr = errors.New("conflict")
 
@@ -61,6 +73,30 @@ type Options struct {
 	Tracer            opentracing.Tracer
 	TLSConfig         *tls.Config
 	DialOpts          []grpc.DialOption
+	// Authenticator authenticates remote write requests, if set.
+	Authenticator *httpserver.Authenticator
+	// TenantVerifier verifies signatures of tenant headers of remote write requests, if set.
+	TenantVerifier *httpserver.TenantVerifier
+	// AuditLogger logs audit events of remote write requests, if set.
+	AuditLogger *audit.Logger
+	// RateLimiter limits the rate of remote write requests, if set.
+	RateLimiter *ratelimit.Limiter
+	// KafkaPublisher publishes remote write requests to Kafka instead of forwarding them to the nodes of the
+	// hashring, if set.
+	KafkaPublisher *KafkaPublisher
+	// Validator relabels and validates the series of remote write requests before they are forwarded and written,
+	// if set.
+	Validator *Validator
+	// RequestTimeout bounds the time spent handling a remote write request, including writing locally and
+	// forwarding to other nodes, if non-zero. The deadline is propagated to forwarded requests.
+	RequestTimeout time.Duration
+	// TenantRequestTimeouts override RequestTimeout for the requests of the given tenants.
+	TenantRequestTimeouts map[string]time.Duration
+	// LocalWriteTimeout bounds the time waited for a write into the local TSDB, if non-zero.
+	LocalWriteTimeout time.Duration
+	// ForwardTimeout bounds each request forwarded to another node, if non-zero, so a single slow node does not
+	// consume the whole deadline of the remote write request.
+	ForwardTimeout time.Duration
 }
 
 // Handler serves a Prometheus remote write receiving HTTP endpoint.
@@ -77,6 +113,8 @@ type Handler struct {
 
 	// Metrics.
 	forwardRequestsTotal *prometheus.CounterVec
+	phaseDuration        *prometheus.HistogramVec
+	phaseDeadlineTotal   *prometheus.CounterVec
 }
 
 func NewHandler(logger log.Logger, o *Options) *Handler {
@@ -96,6 +134,19 @@ func NewHandler(logger log.Logger, o *Options) *Handler {
 				Help: "The number of forward requests.",
 			}, []string{"result"},
 		),
+		phaseDuration: promauto.With(o.Registry).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "thanos_receive_phase_duration_seconds",
+				Help:    "Duration of the phases of handling remote write requests: the whole request, local writes and forwarded requests.",
+				Buckets: []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			}, []string{"phase"},
+		),
+		phaseDeadlineTotal: promauto.With(o.Registry).NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "thanos_receive_phase_deadline_exceeded_total",
+				Help: "The number of phases of handling remote write requests which failed because their deadline was exceeded.",
+			}, []string{"phase"},
+		),
 	}
 
 	ins := extpromhttp.NewNopInstrumentationMiddleware()
@@ -111,7 +162,11 @@ func NewHandler(logger log.Logger, o *Options) *Handler {
 		return ins.NewHandler(name, http.HandlerFunc(next))
 	}
 
-	h.router.Post("/api/v1/receive", instrf("receive", readyf(h.receiveHTTP)))
+	var receive http.HandlerFunc = h.receiveHTTP
+	if o.RateLimiter != nil {
+		receive = o.RateLimiter.Handler(receive).ServeHTTP
+	}
+	h.router.Post("/api/v1/receive", instrf("receive", readyf(receive)))
 
 	return h
 }
@@ -137,7 +192,11 @@ func (h *Handler) Hashring(hashring Hashring) {
 }
 
 // Verifies whether the server is ready or not.
+// Handlers publishing to Kafka need neither hashring nor writer.
 func (h *Handler) isReady() bool {
+	if h.options.KafkaPublisher != nil {
+		return true
+	}
 	h.mtx.RLock()
 	hr := h.hashring != nil
 	sr := h.writer != nil
@@ -185,8 +244,17 @@ func (h *Handler) Run() error {
 
 	errlog := stdlog.New(log.NewStdlibAdapter(level.Error(h.logger)), "", 0)
 
+	// Goroutines of requests are labeled with their tenant, so they can be told apart in CPU profiles.
+	var handler http.Handler = profiling.TenantLabelsMiddleware(h.options.TenantHeader, h.router)
+	if h.options.TenantVerifier != nil {
+		handler = h.options.TenantVerifier.Handler(handler)
+	}
+	if h.options.Authenticator != nil {
+		handler = h.options.Authenticator.Handler(handler)
+	}
+
 	httpSrv := &http.Server{
-		Handler:   h.router,
+		Handler:   handler,
 		ErrorLog:  errlog,
 		TLSConfig: h.options.TLSConfig,
 	}
@@ -208,12 +276,20 @@ type replica struct {
 	replicated bool
 }
 
-func (h *Handler) handleRequest(ctx context.Context, rep uint64, tenant string, wreq *prompb.WriteRequest) error {
+func (h *Handler) handleRequest(ctx context.Context, rep uint64, tenant string, wreq *prompb.WriteRequest) (err error) {
 	// The replica value in the header is one-indexed, thus we need >.
 	if rep > h.options.ReplicationFactor {
 		return errBadReplica
 	}
 
+	timeout := h.options.RequestTimeout
+	if t, ok := h.options.TenantRequestTimeouts[tenant]; ok {
+		timeout = t
+	}
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	defer h.observePhase(ctx, phaseRequest, time.Now(), &err)
+
 	r := replica{
 		n:          rep,
 		replicated: rep != 0,
@@ -224,6 +300,27 @@ func (h *Handler) handleRequest(ctx context.Context, rep uint64, tenant string,
 		r.n--
 	}
 
+	// Series are relabeled and validated once, by the node receiving the request from the client. Valid series of
+	// requests with invalid series are still written.
+	var verr error
+	if h.options.Validator != nil && !r.replicated {
+		verr = h.options.Validator.Process(tenant, wreq)
+	}
+	defer func() {
+		if err == nil {
+			err = verr
+		}
+	}()
+
+	// Requests published to Kafka are durable once acknowledged, and
+	// are replicated by the topic rather than the hashring.
+	if h.options.KafkaPublisher != nil {
+		tracing.DoInSpan(ctx, "receive_kafka_publish", func(ctx context.Context) {
+			err = h.options.KafkaPublisher.Publish(tenant, wreq)
+		})
+		return err
+	}
+
 	// Forward any time series as necessary. All time series
 	// destined for the local node will be written to the receiver.
 	// Time series will be replicated as necessary.
@@ -236,7 +333,32 @@ func (h *Handler) handleRequest(ctx context.Context, rep uint64, tenant string,
 	return nil
 }
 
+// withTimeout returns a context canceled after the timeout, if non-zero, or once the parent context is.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// observePhase tracks the duration of a phase started at start, and whether it failed because the deadline of its
+// context was exceeded.
+func (h *Handler) observePhase(ctx context.Context, phase string, start time.Time, err *error) {
+	h.phaseDuration.WithLabelValues(phase).Observe(time.Since(start).Seconds())
+	if *err != nil && ctx.Err() == context.DeadlineExceeded {
+		h.phaseDeadlineTotal.WithLabelValues(phase).Inc()
+	}
+}
+
 func (h *Handler) receiveHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		wreq prompb.WriteRequest
+		err  error
+	)
+	if h.options.AuditLogger != nil {
+		defer func() { h.logAuditEvent(r, &wreq, err) }()
+	}
+
 	compressed, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -250,8 +372,7 @@ func (h *Handler) receiveHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var wreq prompb.WriteRequest
-	if err := proto.Unmarshal(reqBuf, &wreq); err != nil {
+	if err = proto.Unmarshal(reqBuf, &wreq); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -260,6 +381,7 @@ func (h *Handler) receiveHTTP(w http.ResponseWriter, r *http.Request) {
 	// If the header is empty, we assume the request is not yet replicated.
 	if replicaRaw := r.Header.Get(h.options.ReplicaHeader); replicaRaw != "" {
 		if rep, err = strconv.ParseUint(replicaRaw, 10, 64); err != nil {
+			err = errors.Wrap(err, "could not parse replica header")
 			http.Error(w, "could not parse replica header", http.StatusBadRequest)
 			return
 		}
@@ -268,12 +390,12 @@ func (h *Handler) receiveHTTP(w http.ResponseWriter, r *http.Request) {
 	tenant := r.Header.Get(h.options.TenantHeader)
 
 	err = h.handleRequest(r.Context(), rep, tenant, &wreq)
-	switch err {
+	switch errors.Cause(err) {
 	case nil:
 		return
 	case conflictErr:
 		http.Error(w, err.Error(), http.StatusConflict)
-	case errBadReplica:
+	case errBadReplica, errInvalidSeries:
 		http.Error(w, err.Error(), http.StatusBadRequest)
 	default:
 		level.Error(h.logger).Log("err", err, "msg", "internal server error")
@@ -281,6 +403,21 @@ func (h *Handler) receiveHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// logAuditEvent logs the audit event of the remote write request.
+func (h *Handler) logAuditEvent(r *http.Request, wreq *prompb.WriteRequest, err error) {
+	user, _ := httpserver.UserFromContext(r.Context())
+	e := audit.Event{
+		Tenant:     r.Header.Get(h.options.TenantHeader),
+		User:       user,
+		API:        "remote_write",
+		ResultSize: len(wreq.Timeseries),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	h.options.AuditLogger.Log(e)
+}
+
 // forward accepts a write request, batches its time series by
 // corresponding endpoint, and forwards them in parallel to the
 // correct endpoint. Requests destined for the local node are written
@@ -357,27 +494,10 @@ func (h *Handler) parallelizeRequests(ctx context.Context, tenant string, replic
 		// can be ignored if the replication factor is met.
 		if endpoint == h.options.Endpoint {
 			go func(endpoint string) {
-				var err error
-				h.mtx.RLock()
-				if h.writer == nil {
-					err = errors.New("storage is not ready")
-				} else {
-					// Create a span to track writing the request into TSDB.
-					tracing.DoInSpan(ctx, "receive_tsdb_write", func(ctx context.Context) {
-
-						err = h.writer.Write(wreqs[endpoint])
-					})
-					// When a MultiError is added to another MultiError, the error slices are concatenated, not nested.
-					// To avoid breaking the counting logic, we need to flatten the error.
-					if errs, ok := err.(terrors.MultiError); ok {
-						if countCause(errs, isConflict) > 0 {
-							err = errors.Wrap(conflictErr, errs.Error())
-						} else {
-							err = errors.New(errs.Error())
-						}
-					}
-				}
-				h.mtx.RUnlock()
+				ctx, cancel := withTimeout(ctx, h.options.LocalWriteTimeout)
+				defer cancel()
+
+				err := h.writeLocal(ctx, wreqs[endpoint])
 				if err != nil {
 					level.Error(h.logger).Log("msg", "storing locally", "err", err, "endpoint", endpoint)
 				}
@@ -389,6 +509,10 @@ func (h *Handler) parallelizeRequests(ctx context.Context, tenant string, replic
 		go func(endpoint string) {
 			var err error
 
+			ctx, cancel := withTimeout(ctx, h.options.ForwardTimeout)
+			defer cancel()
+			defer h.observePhase(ctx, phaseForward, time.Now(), &err)
+
 			// Increment the counters as necessary now that
 			// the requests will go out.
 			defer func() {
@@ -439,6 +563,49 @@ func (h *Handler) parallelizeRequests(ctx context.Context, tenant string, replic
 	return errs.Err()
 }
 
+// writeLocal writes the request into the local TSDB. Appends can't be canceled, so once the deadline of the context
+// is exceeded, the write is no longer waited for, but completes in the background.
+func (h *Handler) writeLocal(ctx context.Context, wreq *prompb.WriteRequest) (err error) {
+	defer h.observePhase(ctx, phaseLocalWrite, time.Now(), &err)
+
+	// Requests forwarded by nodes which are no longer waiting for them aren't written.
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "storing locally")
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		var err error
+		h.mtx.RLock()
+		if h.writer == nil {
+			err = errors.New("storage is not ready")
+		} else {
+			// Create a span to track writing the request into TSDB.
+			tracing.DoInSpan(ctx, "receive_tsdb_write", func(ctx context.Context) {
+				err = h.writer.Write(wreq)
+			})
+			// When a MultiError is added to another MultiError, the error slices are concatenated, not nested.
+			// To avoid breaking the counting logic, we need to flatten the error.
+			if errs, ok := err.(terrors.MultiError); ok {
+				if countCause(errs, isConflict) > 0 {
+					err = errors.Wrap(conflictErr, errs.Error())
+				} else {
+					err = errors.New(errs.Error())
+				}
+			}
+		}
+		h.mtx.RUnlock()
+		errc <- err
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "storing locally")
+	}
+}
+
 // replicate replicates a write request to (replication-factor) nodes
 // selected by the tenant and time series.
 // The function only returns when all replication requests have finished
@@ -483,12 +650,12 @@ func (h *Handler) replicate(ctx context.Context, tenant string, wreq *prompb.Wri
 // RemoteWrite implements the gRPC remote write handler for storepb.WriteableStore.
 func (h *Handler) RemoteWrite(ctx context.Context, r *storepb.WriteRequest) (*storepb.WriteResponse, error) {
 	err := h.handleRequest(ctx, uint64(r.Replica), r.Tenant, &prompb.WriteRequest{Timeseries: r.Timeseries})
-	switch err {
+	switch errors.Cause(err) {
 	case nil:
 		return &storepb.WriteResponse{}, nil
 	case conflictErr:
 		return nil, status.Error(codes.AlreadyExists, err.Error())
-	case errBadReplica:
+	case errBadReplica, errInvalidSeries:
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	default:
 		return nil, status.Error(codes.Internal, err.Error())
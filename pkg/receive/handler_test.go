@@ -6,23 +6,31 @@ package receive
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
 	"github.com/pkg/errors"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/storage"
 	terrors "github.com/prometheus/prometheus/tsdb/errors"
+	"github.com/thanos-io/thanos/pkg/audit"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/store/storepb/prompb"
+	"github.com/thanos-io/thanos/pkg/testutil"
 	"google.golang.org/grpc"
 )
 
@@ -546,6 +554,79 @@ func cycleErrors(errs []error) func() error {
 	}
 }
 
+func TestReceiveAuditLogging(t *testing.T) {
+	dir, err := ioutil.TempDir("", "receive-audit")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+	auditFile := filepath.Join(dir, "audit.log")
+	auditLogger, err := audit.NewLogger(log.NewNopLogger(), nil, []byte("sink: {type: FILE, path: "+auditFile+"}"))
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, auditLogger.Close()) }()
+
+	handlers, _ := newHandlerHashring([]*fakeAppendable{
+		{appender: newFakeAppender(nil, nil, nil, nil)},
+	}, 1)
+	h := handlers[0]
+	h.options.AuditLogger = auditLogger
+
+	wreq := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		{Labels: []prompb.Label{{Name: "foo", Value: "bar"}}, Samples: []prompb.Sample{{Value: 1, Timestamp: 1}}},
+		{Labels: []prompb.Label{{Name: "foo", Value: "baz"}}, Samples: []prompb.Sample{{Value: 1, Timestamp: 1}}},
+	}}
+	status, err := makeRequest(h, "tenant-a", wreq)
+	testutil.Ok(t, err)
+	testutil.Equals(t, http.StatusOK, status)
+
+	b, err := ioutil.ReadFile(auditFile)
+	testutil.Ok(t, err)
+	var e audit.Event
+	testutil.Ok(t, json.Unmarshal(b, &e))
+	testutil.Equals(t, "tenant-a", e.Tenant)
+	testutil.Equals(t, "remote_write", e.API)
+	testutil.Equals(t, 2, e.ResultSize)
+	testutil.Equals(t, "", e.Error)
+}
+
+func TestReceiveTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	blockingErrFn := func() error {
+		<-release
+		return nil
+	}
+	defer close(release)
+
+	handlers, _ := newHandlerHashring([]*fakeAppendable{
+		{appender: newFakeAppender(nil, nil, nil, nil)},
+		{appender: newFakeAppender(nil, nil, nil, nil)},
+		{appender: newFakeAppender(nil, nil, nil, nil), appenderErr: blockingErrFn},
+	}, 3)
+	for _, h := range handlers {
+		h.options.ForwardTimeout = 50 * time.Millisecond
+	}
+	wreq := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		{Labels: []prompb.Label{{Name: "foo", Value: "bar"}}, Samples: []prompb.Sample{{Value: 1, Timestamp: 1}}},
+	}}
+
+	// The slow node exceeds the deadline of the forwarded request, which still meets the replication threshold.
+	status, err := makeRequest(handlers[0], "tenant-a", wreq)
+	testutil.Ok(t, err)
+	testutil.Equals(t, http.StatusOK, status)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(handlers[0].phaseDeadlineTotal.WithLabelValues(phaseForward)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(handlers[2].phaseDeadlineTotal.WithLabelValues(phaseLocalWrite)))
+	testutil.Equals(t, 0.0, promtest.ToFloat64(handlers[0].phaseDeadlineTotal.WithLabelValues(phaseRequest)))
+
+	// Requests of tenants with a request timeout fail once it is exceeded.
+	handlers, _ = newHandlerHashring([]*fakeAppendable{
+		{appender: newFakeAppender(nil, nil, nil, nil), appenderErr: blockingErrFn},
+	}, 1)
+	h := handlers[0]
+	h.options.TenantRequestTimeouts = map[string]time.Duration{"tenant-b": 10 * time.Millisecond}
+	status, err = makeRequest(h, "tenant-b", wreq)
+	testutil.Ok(t, err)
+	testutil.Equals(t, http.StatusInternalServerError, status)
+	testutil.Equals(t, 1.0, promtest.ToFloat64(h.phaseDeadlineTotal.WithLabelValues(phaseRequest)))
+}
+
 // makeRequest is a helper to make a correct request against a remote write endpoint given a request.
 func makeRequest(h *Handler, tenant string, wreq *prompb.WriteRequest) (int, error) {
 	buf, err := proto.Marshal(wreq)
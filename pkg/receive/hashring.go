@@ -6,11 +6,14 @@ package receive
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/cespare/xxhash"
 	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/discovery/gossip"
 	"github.com/thanos-io/thanos/pkg/store/storepb/prompb"
 )
 
@@ -181,3 +184,93 @@ func HashringFromConfig(ctx context.Context, updates chan<- Hashring, cw *Config
 		}
 	}
 }
+
+// gossipSettleTime is the time without membership changes before a hashring is built from gossiped state, so nodes
+// joining or leaving at the same time cause a single hashring change.
+const gossipSettleTime = 5 * time.Second
+
+// HashringFromGossip creates multi-tenant hashrings from the hashring
+// membership gossiped by receive nodes of the cluster of the given peer.
+// Hashrings are returned on the updates channel when the membership changed.
+// The updates chan is closed before exiting.
+func HashringFromGossip(ctx context.Context, updates chan<- Hashring, p *gossip.Peer) error {
+	defer close(updates)
+	changes := p.Subscribe()
+
+	var last []HashringConfig
+	for {
+		cfg := hashringConfigFromMembers(p.Members())
+		if len(cfg) > 0 && !reflect.DeepEqual(cfg, last) {
+			select {
+			case updates <- newMultiHashring(cfg):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			last = cfg
+		}
+
+		select {
+		case <-changes:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		// Wait for the membership to settle.
+		for settled := false; !settled; {
+			select {
+			case <-changes:
+			case <-time.After(gossipSettleTime):
+				settled = true
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// hashringConfigFromMembers returns the hashring configuration of the receive nodes among the given members.
+// Endpoints of each hashring are sorted and deduplicated, e.g. of restarted nodes whose former membership did not
+// expire yet, so all nodes build the same hashrings. Hashrings with tenants are ordered first, as hashrings without
+// tenants match all tenants.
+func hashringConfigFromMembers(members []gossip.State) []HashringConfig {
+	hashrings := map[string]*HashringConfig{}
+	tenants := map[string]map[string]struct{}{}
+	for _, m := range members {
+		if m.Receive == nil || m.Receive.Endpoint == "" {
+			continue
+		}
+		h, ok := hashrings[m.Receive.Hashring]
+		if !ok {
+			h = &HashringConfig{Hashring: m.Receive.Hashring}
+			hashrings[m.Receive.Hashring] = h
+			tenants[m.Receive.Hashring] = map[string]struct{}{}
+		}
+		h.Endpoints = append(h.Endpoints, m.Receive.Endpoint)
+		for _, t := range m.Receive.Tenants {
+			tenants[m.Receive.Hashring][t] = struct{}{}
+		}
+	}
+
+	cfg := make([]HashringConfig, 0, len(hashrings))
+	for name, h := range hashrings {
+		for t := range tenants[name] {
+			h.Tenants = append(h.Tenants, t)
+		}
+		sort.Strings(h.Tenants)
+		sort.Strings(h.Endpoints)
+		endpoints := h.Endpoints[:0]
+		for i, e := range h.Endpoints {
+			if i == 0 || e != h.Endpoints[i-1] {
+				endpoints = append(endpoints, e)
+			}
+		}
+		h.Endpoints = endpoints
+		cfg = append(cfg, *h)
+	}
+	sort.Slice(cfg, func(i, j int) bool {
+		if (len(cfg[i].Tenants) == 0) != (len(cfg[j].Tenants) == 0) {
+			return len(cfg[i].Tenants) > 0
+		}
+		return cfg[i].Hashring < cfg[j].Hashring
+	})
+	return cfg
+}
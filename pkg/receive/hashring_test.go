@@ -6,7 +6,9 @@ package receive
 import (
 	"testing"
 
+	"github.com/thanos-io/thanos/pkg/discovery/gossip"
 	"github.com/thanos-io/thanos/pkg/store/storepb/prompb"
+	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
 func TestHash(t *testing.T) {
@@ -170,3 +172,21 @@ func TestHashringGet(t *testing.T) {
 		}
 	}
 }
+
+func TestHashringConfigFromMembers(t *testing.T) {
+	cfg := hashringConfigFromMembers([]gossip.State{
+		{Component: "sidecar", StoreAPI: "sidecar:10901"},
+		{Component: "receive", Receive: &gossip.ReceiveState{Endpoint: "node3"}},
+		{Component: "receive", Receive: &gossip.ReceiveState{Endpoint: "node2", Hashring: "b", Tenants: []string{"tenant2"}}},
+		{Component: "receive", Receive: &gossip.ReceiveState{Endpoint: "node1", Hashring: "b", Tenants: []string{"tenant1"}}},
+		{Component: "receive", Receive: &gossip.ReceiveState{Endpoint: "node4"}},
+		// Former membership of a restarted node.
+		{Component: "receive", Receive: &gossip.ReceiveState{Endpoint: "node3"}},
+		{Component: "receive", Receive: &gossip.ReceiveState{Endpoint: "node5", Hashring: "a", Tenants: []string{"tenant3"}}},
+	})
+	testutil.Equals(t, []HashringConfig{
+		{Hashring: "a", Tenants: []string{"tenant3"}, Endpoints: []string{"node5"}},
+		{Hashring: "b", Tenants: []string{"tenant1", "tenant2"}, Endpoints: []string{"node1", "node2"}},
+		{Endpoints: []string{"node3", "node4"}},
+	}, cfg)
+}
@@ -0,0 +1,384 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package receive
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	terrors "github.com/prometheus/prometheus/tsdb/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/store/storepb/prompb"
+)
+
+// kafkaMessageOverhead is the size reserved for the envelope of messages and the write request besides its series.
+const kafkaMessageOverhead = 1024
+
+// KafkaConfig configures the topic remote write requests are published to by routers and consumed from by ingestors.
+type KafkaConfig struct {
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+	// Version is the Kafka version of the brokers, at least 0.10.2.0 for consumer groups.
+	Version  string `yaml:"version"`
+	ClientID string `yaml:"client_id"`
+	// ConsumerGroup is the consumer group of ingestors, which share the partitions of the topic.
+	ConsumerGroup string `yaml:"consumer_group"`
+	// MaxMessageBytes is the maximum size of messages. Requests are split into multiple messages if needed.
+	MaxMessageBytes int `yaml:"max_message_bytes"`
+	// Compression is the compression codec of messages, one of none, gzip, snappy and lz4.
+	Compression string `yaml:"compression"`
+	// RetryInterval is the interval of retrying to write consumed requests into the local TSDB.
+	RetryInterval model.Duration `yaml:"retry_interval"`
+}
+
+// ParseKafkaConfig parses the YAML Kafka configuration.
+func ParseKafkaConfig(conf []byte) (*KafkaConfig, error) {
+	c := &KafkaConfig{
+		Version:         "2.1.0",
+		ClientID:        "thanos-receive",
+		ConsumerGroup:   "thanos-receive",
+		MaxMessageBytes: 1000000,
+		Compression:     "snappy",
+		RetryInterval:   model.Duration(time.Second),
+	}
+	if err := yaml.UnmarshalStrict(conf, c); err != nil {
+		return nil, errors.Wrap(err, "parsing Kafka config")
+	}
+	if len(c.Brokers) == 0 {
+		return nil, errors.New("no Kafka brokers")
+	}
+	if c.Topic == "" {
+		return nil, errors.New("no Kafka topic")
+	}
+	if c.MaxMessageBytes <= kafkaMessageOverhead {
+		return nil, errors.Errorf("max message size must be larger than %d bytes", kafkaMessageOverhead)
+	}
+	if c.RetryInterval <= 0 {
+		return nil, errors.New("retry interval must be positive")
+	}
+	if _, err := c.saramaConfig(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *KafkaConfig) saramaConfig() (*sarama.Config, error) {
+	version, err := sarama.ParseKafkaVersion(c.Version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid Kafka version %s", c.Version)
+	}
+	if !version.IsAtLeast(sarama.V0_10_2_0) {
+		return nil, errors.Errorf("Kafka version %s does not support consumer groups, at least 0.10.2.0 is required", c.Version)
+	}
+
+	cfg := sarama.NewConfig()
+	cfg.ClientID = c.ClientID
+	cfg.Version = version
+	switch c.Compression {
+	case "none":
+		cfg.Producer.Compression = sarama.CompressionNone
+	case "gzip":
+		cfg.Producer.Compression = sarama.CompressionGZIP
+	case "snappy":
+		cfg.Producer.Compression = sarama.CompressionSnappy
+	case "lz4":
+		cfg.Producer.Compression = sarama.CompressionLZ4
+	default:
+		return nil, errors.Errorf("unknown compression %s", c.Compression)
+	}
+	// Requests are only acknowledged to clients once all in-sync replicas of the partitions have them.
+	cfg.Producer.RequiredAcks = sarama.WaitForAll
+	cfg.Producer.Return.Successes = true
+	cfg.Producer.Partitioner = sarama.NewManualPartitioner
+	cfg.Producer.MaxMessageBytes = c.MaxMessageBytes
+	// New ingestors replay all requests still retained by the topic.
+	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	cfg.Consumer.Return.Errors = true
+
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid Kafka config")
+	}
+	return cfg, nil
+}
+
+// KafkaPublisher publishes remote write requests to the partitions of a Kafka topic. Series are partitioned by their
+// hash and tenant, like by hashrings, so each series is always consumed by the same ingestor.
+type KafkaPublisher struct {
+	producer        sarama.SyncProducer
+	partitions      func() ([]int32, error)
+	close           func() error
+	topic           string
+	maxMessageBytes int
+
+	messages *prometheus.CounterVec
+}
+
+// NewKafkaPublisher returns a publisher to the topic of the given configuration.
+func NewKafkaPublisher(reg prometheus.Registerer, conf *KafkaConfig) (*KafkaPublisher, error) {
+	cfg, err := conf.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := sarama.NewClient(conf.Brokers, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "create Kafka client")
+	}
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, errors.Wrap(err, "create Kafka producer")
+	}
+	return newKafkaPublisher(reg, producer, func() ([]int32, error) {
+		return client.Partitions(conf.Topic)
+	}, func() error {
+		if err := producer.Close(); err != nil {
+			return err
+		}
+		return client.Close()
+	}, conf.Topic, conf.MaxMessageBytes), nil
+}
+
+func newKafkaPublisher(reg prometheus.Registerer, producer sarama.SyncProducer, partitions func() ([]int32, error), close func() error, topic string, maxMessageBytes int) *KafkaPublisher {
+	return &KafkaPublisher{
+		producer:        producer,
+		partitions:      partitions,
+		close:           close,
+		topic:           topic,
+		maxMessageBytes: maxMessageBytes,
+		messages: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_receive_kafka_published_messages_total",
+			Help: "Total number of messages with remote write requests published to Kafka.",
+		}, []string{"result"}),
+	}
+}
+
+// Publish publishes the series of the write request, split into a message per partition, or multiple if they exceed
+// the maximum message size. It returns once all messages were acknowledged by Kafka.
+func (p *KafkaPublisher) Publish(tenant string, wreq *prompb.WriteRequest) error {
+	partitions, err := p.partitions()
+	if err != nil {
+		return errors.Wrapf(err, "get partitions of topic %s", p.topic)
+	}
+	if len(partitions) == 0 {
+		return errors.Errorf("topic %s has no partitions", p.topic)
+	}
+
+	byPartition := map[int32][]prompb.TimeSeries{}
+	for i := range wreq.Timeseries {
+		part := partitions[hash(tenant, &wreq.Timeseries[i])%uint64(len(partitions))]
+		byPartition[part] = append(byPartition[part], wreq.Timeseries[i])
+	}
+	keys := make([]int32, 0, len(byPartition))
+	for part := range byPartition {
+		keys = append(keys, part)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var msgs []*sarama.ProducerMessage
+	for _, part := range keys {
+		for _, series := range splitSeries(byPartition[part], p.maxMessageBytes-kafkaMessageOverhead-len(tenant)) {
+			b, err := (&storepb.WriteRequest{Tenant: tenant, Timeseries: series}).Marshal()
+			if err != nil {
+				return errors.Wrap(err, "marshal write request")
+			}
+			msgs = append(msgs, &sarama.ProducerMessage{
+				Topic:     p.topic,
+				Partition: part,
+				Key:       sarama.StringEncoder(tenant),
+				Value:     sarama.ByteEncoder(b),
+			})
+		}
+	}
+
+	if err := p.producer.SendMessages(msgs); err != nil {
+		failed := len(msgs)
+		if perrs, ok := err.(sarama.ProducerErrors); ok {
+			failed = len(perrs)
+		}
+		p.messages.WithLabelValues("success").Add(float64(len(msgs) - failed))
+		p.messages.WithLabelValues("error").Add(float64(failed))
+		return errors.Wrapf(err, "publish to topic %s", p.topic)
+	}
+	p.messages.WithLabelValues("success").Add(float64(len(msgs)))
+	return nil
+}
+
+// splitSeries splits series into batches of at most the given encoded size. Series larger than it are in batches of
+// their own.
+func splitSeries(series []prompb.TimeSeries, maxBytes int) [][]prompb.TimeSeries {
+	var (
+		batches [][]prompb.TimeSeries
+		start   int
+		size    int
+	)
+	for i := range series {
+		// Each series is encoded with its size and the field tag.
+		s := series[i].Size() + 16
+		if size+s > maxBytes && i > start {
+			batches = append(batches, series[start:i])
+			start, size = i, 0
+		}
+		size += s
+	}
+	if start < len(series) {
+		batches = append(batches, series[start:])
+	}
+	return batches
+}
+
+// Close closes the connections to Kafka.
+func (p *KafkaPublisher) Close() error {
+	return p.close()
+}
+
+// KafkaIngestor consumes remote write requests from the partitions of a Kafka topic assigned to it as member of a
+// consumer group, and writes them into the local TSDB. Offsets are only committed after requests were written, so
+// requests are replayed after restarts.
+type KafkaIngestor struct {
+	logger        log.Logger
+	group         sarama.ConsumerGroup
+	topic         string
+	retryInterval time.Duration
+
+	mtx    sync.RWMutex
+	writer *Writer
+
+	messages *prometheus.CounterVec
+	retries  prometheus.Counter
+}
+
+// NewKafkaIngestor returns an ingestor of the topic of the given configuration.
+func NewKafkaIngestor(logger log.Logger, reg prometheus.Registerer, conf *KafkaConfig) (*KafkaIngestor, error) {
+	cfg, err := conf.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	group, err := sarama.NewConsumerGroup(conf.Brokers, conf.ConsumerGroup, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "create Kafka consumer group")
+	}
+	return newKafkaIngestor(logger, reg, group, conf.Topic, time.Duration(conf.RetryInterval)), nil
+}
+
+func newKafkaIngestor(logger log.Logger, reg prometheus.Registerer, group sarama.ConsumerGroup, topic string, retryInterval time.Duration) *KafkaIngestor {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	return &KafkaIngestor{
+		logger:        logger,
+		group:         group,
+		topic:         topic,
+		retryInterval: retryInterval,
+		messages: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_receive_kafka_consumed_messages_total",
+			Help: "Total number of messages with remote write requests consumed from Kafka.",
+		}, []string{"result"}),
+		retries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_receive_kafka_write_retries_total",
+			Help: "Total number of retried writes of consumed remote write requests into the local TSDB.",
+		}),
+	}
+}
+
+// SetWriter sets the writer of consumed requests. Consumption is paused while
+// the writer is nil, e.g. while the TSDB is reloaded.
+func (i *KafkaIngestor) SetWriter(w *Writer) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.writer = w
+}
+
+// Run consumes the topic until the context is done. Sessions end on rebalances of the consumer group, after which
+// the topic is consumed again.
+func (i *KafkaIngestor) Run(ctx context.Context) error {
+	defer runutil.CloseWithLogOnErr(i.logger, i.group, "Kafka consumer group")
+
+	go func() {
+		for err := range i.group.Errors() {
+			level.Warn(i.logger).Log("msg", "Kafka consumer error", "err", err)
+		}
+	}()
+
+	for ctx.Err() == nil {
+		if err := i.group.Consume(ctx, []string{i.topic}, (*kafkaGroupHandler)(i)); err != nil {
+			level.Error(i.logger).Log("msg", "consuming Kafka topic failed", "topic", i.topic, "err", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(i.retryInterval):
+			}
+		}
+	}
+	return nil
+}
+
+// ingest writes the request of the message into the local TSDB, retrying until it was written or the context is
+// done. Samples rejected by the TSDB, e.g. duplicates of requests replayed after a restart, are not retried.
+func (i *KafkaIngestor) ingest(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var wreq storepb.WriteRequest
+	if err := wreq.Unmarshal(msg.Value); err != nil {
+		i.messages.WithLabelValues("invalid").Inc()
+		level.Warn(i.logger).Log("msg", "skipping invalid Kafka message", "partition", msg.Partition, "offset", msg.Offset, "err", err)
+		return nil
+	}
+
+	for {
+		i.mtx.RLock()
+		w := i.writer
+		i.mtx.RUnlock()
+
+		err := errors.New("storage is not ready")
+		if w != nil {
+			err = w.Write(&prompb.WriteRequest{Timeseries: wreq.Timeseries})
+		}
+		if err == nil {
+			i.messages.WithLabelValues("success").Inc()
+			return nil
+		}
+		errs, ok := err.(terrors.MultiError)
+		if !ok {
+			errs = terrors.MultiError{err}
+		}
+		if countCause(errs, isConflict) == len(errs) {
+			i.messages.WithLabelValues("conflict").Inc()
+			return nil
+		}
+
+		i.retries.Inc()
+		level.Debug(i.logger).Log("msg", "writing Kafka message failed, retrying", "partition", msg.Partition, "offset", msg.Offset, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(i.retryInterval):
+		}
+	}
+}
+
+// kafkaGroupHandler handles the sessions of an ingestor.
+type kafkaGroupHandler KafkaIngestor
+
+func (h *kafkaGroupHandler) Setup(sarama.ConsumerGroupSession) error   { return nil }
+func (h *kafkaGroupHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+// ConsumeClaim ingests the messages of a claimed partition, marking them as consumed once written.
+func (h *kafkaGroupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		if err := (*KafkaIngestor)(h).ingest(sess.Context(), msg); err != nil {
+			return err
+		}
+		sess.MarkMessage(msg, "")
+	}
+	return nil
+}
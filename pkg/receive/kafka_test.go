@@ -0,0 +1,130 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package receive
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-kit/kit/log"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/store/storepb/prompb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestParseKafkaConfig(t *testing.T) {
+	for _, tcase := range []struct {
+		conf string
+		err  bool
+	}{
+		{conf: `{brokers: [kafka:9092], topic: remote-write}`},
+		{conf: `{brokers: [kafka:9092], topic: remote-write, version: 0.11.0.0, compression: lz4, retry_interval: 5s}`},
+		{conf: `{topic: remote-write}`, err: true},
+		{conf: `{brokers: [kafka:9092]}`, err: true},
+		{conf: `{brokers: [kafka:9092], topic: remote-write, version: 0.10.0.0}`, err: true},
+		{conf: `{brokers: [kafka:9092], topic: remote-write, compression: zstd}`, err: true},
+		{conf: `{brokers: [kafka:9092], topic: remote-write, max_message_bytes: 100}`, err: true},
+		{conf: `{brokers: [kafka:9092], topic: remote-write, partitions: 3}`, err: true},
+	} {
+		t.Run(tcase.conf, func(t *testing.T) {
+			_, err := ParseKafkaConfig([]byte(tcase.conf))
+			testutil.Equals(t, tcase.err, err != nil)
+		})
+	}
+}
+
+type fakeSyncProducer struct {
+	sarama.SyncProducer
+	msgs []*sarama.ProducerMessage
+	err  error
+}
+
+func (p *fakeSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.msgs = append(p.msgs, msgs...)
+	return p.err
+}
+
+func TestKafkaPublisher_Publish(t *testing.T) {
+	wreq := &prompb.WriteRequest{}
+	for i := 0; i < 100; i++ {
+		wreq.Timeseries = append(wreq.Timeseries, prompb.TimeSeries{
+			Labels:  []prompb.Label{{Name: "series", Value: fmt.Sprintf("%d", i)}},
+			Samples: []prompb.Sample{{Timestamp: int64(i), Value: float64(i)}},
+		})
+	}
+	partitions := []int32{0, 1, 2}
+
+	producer := &fakeSyncProducer{}
+	p := newKafkaPublisher(nil, producer, func() ([]int32, error) { return partitions, nil }, nil, "remote-write", kafkaMessageOverhead+512)
+	testutil.Ok(t, p.Publish("tenant-a", wreq))
+
+	// Each partition gets multiple messages of at most the maximum size, with all series of their hash.
+	published := 0
+	for _, msg := range producer.msgs {
+		testutil.Equals(t, "remote-write", msg.Topic)
+		b, err := msg.Value.Encode()
+		testutil.Ok(t, err)
+		testutil.Assert(t, len(b) <= kafkaMessageOverhead+512, "message of %d bytes exceeds limit", len(b))
+
+		var r storepb.WriteRequest
+		testutil.Ok(t, r.Unmarshal(b))
+		testutil.Equals(t, "tenant-a", r.Tenant)
+		for i := range r.Timeseries {
+			testutil.Equals(t, partitions[hash("tenant-a", &r.Timeseries[i])%3], msg.Partition)
+		}
+		published += len(r.Timeseries)
+	}
+	testutil.Equals(t, 100, published)
+	testutil.Assert(t, len(producer.msgs) > 3, "expected requests to be split, got %d messages", len(producer.msgs))
+	testutil.Equals(t, float64(len(producer.msgs)), promtest.ToFloat64(p.messages.WithLabelValues("success")))
+
+	producer.err = sarama.ProducerErrors{{Err: sarama.ErrNotEnoughReplicas}}
+	testutil.NotOk(t, p.Publish("tenant-a", wreq))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(p.messages.WithLabelValues("error")))
+}
+
+func TestKafkaIngestor_Ingest(t *testing.T) {
+	wreq := storepb.WriteRequest{
+		Tenant: "tenant-a",
+		Timeseries: []prompb.TimeSeries{{
+			Labels:  []prompb.Label{{Name: "foo", Value: "bar"}},
+			Samples: []prompb.Sample{{Timestamp: 1, Value: 1}},
+		}},
+	}
+	b, err := wreq.Marshal()
+	testutil.Ok(t, err)
+	msg := &sarama.ConsumerMessage{Value: b}
+
+	i := newKafkaIngestor(nil, nil, nil, "remote-write", time.Millisecond)
+
+	// Messages are only ingested once the storage is ready.
+	app := newFakeAppender(nil, nil, nil, nil)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		i.SetWriter(NewWriter(log.NewNopLogger(), &fakeAppendable{appender: app}))
+	}()
+	testutil.Ok(t, i.ingest(context.Background(), msg))
+	testutil.Equals(t, 1, len(app.samples))
+	testutil.Assert(t, promtest.ToFloat64(i.retries) > 0, "expected retries")
+
+	// Samples rejected by the TSDB, e.g. of replayed requests, are not retried.
+	i.SetWriter(NewWriter(log.NewNopLogger(), &fakeAppendable{appender: newFakeAppender(func() error { return storage.ErrDuplicateSampleForTimestamp }, nil, nil, nil)}))
+	testutil.Ok(t, i.ingest(context.Background(), msg))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(i.messages.WithLabelValues("conflict")))
+
+	// Failed writes are retried until the context is done.
+	i.SetWriter(NewWriter(log.NewNopLogger(), &fakeAppendable{appender: newFakeAppender(nil, nil, func() error { return storage.ErrNotFound }, nil)}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	testutil.NotOk(t, i.ingest(ctx, msg))
+
+	testutil.Ok(t, i.ingest(context.Background(), &sarama.ConsumerMessage{Value: []byte("invalid")}))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(i.messages.WithLabelValues("invalid")))
+}
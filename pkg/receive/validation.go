@@ -0,0 +1,194 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package receive
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"gopkg.in/yaml.v2"
+
+	"github.com/thanos-io/thanos/pkg/store/storepb/prompb"
+)
+
+// Rules series can be rejected by, as reported by the rule label of thanos_receive_rejected_series_total.
+const (
+	ruleRelabel             = "relabel_configs"
+	ruleMaxLabelNames       = "max_label_names_per_series"
+	ruleMaxLabelValueLength = "max_label_value_length"
+	ruleMetricNamePatterns  = "metric_name_patterns"
+)
+
+// errInvalidSeries is returned if series of a remote write request fail validation.
+var errInvalidSeries = errors.New("invalid series")
+
+// IngestConfig is the configuration of relabeling and validation of the series of remote write requests.
+type IngestConfig struct {
+	// Default are the rules of tenants without override.
+	Default IngestRules `yaml:"default"`
+	// Overrides are the rules of specific tenants.
+	Overrides map[string]IngestRules `yaml:"overrides"`
+}
+
+// IngestRules are the relabeling and validation rules applied to series before they are written.
+type IngestRules struct {
+	// RelabelConfigs are applied to the labels of series first. Series dropped by them are not written.
+	RelabelConfigs []*relabel.Config `yaml:"relabel_configs"`
+	// MaxLabelNamesPerSeries is the maximum number of labels of series. Not limited if zero.
+	MaxLabelNamesPerSeries int `yaml:"max_label_names_per_series"`
+	// MaxLabelValueLength is the maximum length of label values of series. Not limited if zero.
+	MaxLabelValueLength int `yaml:"max_label_value_length"`
+	// MetricNamePatterns are anchored regular expressions one of which the metric names of series must match. Not
+	// validated if empty.
+	MetricNamePatterns []string `yaml:"metric_name_patterns"`
+}
+
+// ingestRules are parsed IngestRules.
+type ingestRules struct {
+	IngestRules
+	metricNames []*regexp.Regexp
+}
+
+func newIngestRules(r IngestRules) (*ingestRules, error) {
+	if r.MaxLabelNamesPerSeries < 0 || r.MaxLabelValueLength < 0 {
+		return nil, errors.New("negative limit")
+	}
+	res := &ingestRules{IngestRules: r}
+	for _, p := range r.MetricNamePatterns {
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse metric name pattern %q", p)
+		}
+		res.metricNames = append(res.metricNames, re)
+	}
+	return res, nil
+}
+
+// Validator relabels and validates the series of remote write requests by tenant.
+type Validator struct {
+	def       *ingestRules
+	overrides map[string]*ingestRules
+
+	rejected *prometheus.CounterVec
+}
+
+// NewValidator parses the YAML ingest configuration and returns a validator.
+func NewValidator(reg prometheus.Registerer, conf []byte) (*Validator, error) {
+	var config IngestConfig
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing ingest config")
+	}
+	return NewValidatorWithConfig(reg, config)
+}
+
+// NewValidatorWithConfig returns a validator with the given configuration.
+func NewValidatorWithConfig(reg prometheus.Registerer, config IngestConfig) (*Validator, error) {
+	def, err := newIngestRules(config.Default)
+	if err != nil {
+		return nil, errors.Wrap(err, "default rules")
+	}
+	v := &Validator{
+		def:       def,
+		overrides: make(map[string]*ingestRules, len(config.Overrides)),
+		rejected: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_receive_rejected_series_total",
+			Help: "Total number of series of remote write requests not written because of relabeling or validation rules.",
+		}, []string{"rule"}),
+	}
+	for tenant, r := range config.Overrides {
+		if v.overrides[tenant], err = newIngestRules(r); err != nil {
+			return nil, errors.Wrapf(err, "rules of tenant %s", tenant)
+		}
+	}
+	for _, rule := range []string{ruleRelabel, ruleMaxLabelNames, ruleMaxLabelValueLength, ruleMetricNamePatterns} {
+		v.rejected.WithLabelValues(rule)
+	}
+	return v, nil
+}
+
+// Process relabels and validates the series of the request of the tenant in place. Series dropped by relabeling are
+// removed silently. Invalid series are removed too, and reported by the returned error, so the valid series can still
+// be written.
+func (v *Validator) Process(tenant string, wreq *prompb.WriteRequest) error {
+	r, ok := v.overrides[tenant]
+	if !ok {
+		r = v.def
+	}
+
+	var (
+		invalid  int
+		firstErr error
+	)
+	res := wreq.Timeseries[:0]
+	for _, ts := range wreq.Timeseries {
+		if len(r.RelabelConfigs) > 0 {
+			lset := relabel.Process(promLabels(ts.Labels), r.RelabelConfigs...)
+			if lset == nil {
+				v.rejected.WithLabelValues(ruleRelabel).Inc()
+				continue
+			}
+			ts.Labels = prompbLabels(lset)
+		}
+		if rule, err := r.validate(ts.Labels); err != nil {
+			v.rejected.WithLabelValues(rule).Inc()
+			if firstErr == nil {
+				firstErr = err
+			}
+			invalid++
+			continue
+		}
+		res = append(res, ts)
+	}
+	wreq.Timeseries = res
+
+	if invalid > 0 {
+		return errors.Wrapf(errInvalidSeries, "%d series rejected, first: %s", invalid, firstErr)
+	}
+	return nil
+}
+
+// validate returns the rule the labels of a series violate, and why, if any.
+func (r *ingestRules) validate(lset []prompb.Label) (string, error) {
+	if r.MaxLabelNamesPerSeries > 0 && len(lset) > r.MaxLabelNamesPerSeries {
+		return ruleMaxLabelNames, errors.Errorf("series %s has %d labels, more than %d", promLabels(lset), len(lset), r.MaxLabelNamesPerSeries)
+	}
+	var name string
+	for _, l := range lset {
+		if r.MaxLabelValueLength > 0 && len(l.Value) > r.MaxLabelValueLength {
+			return ruleMaxLabelValueLength, errors.Errorf("value of label %s of series %s is longer than %d", l.Name, promLabels(lset), r.MaxLabelValueLength)
+		}
+		if l.Name == labels.MetricName {
+			name = l.Value
+		}
+	}
+	if len(r.metricNames) == 0 {
+		return "", nil
+	}
+	for _, re := range r.metricNames {
+		if re.MatchString(name) {
+			return "", nil
+		}
+	}
+	return ruleMetricNamePatterns, errors.Errorf("metric name %q matches no allowed pattern", name)
+}
+
+func promLabels(lset []prompb.Label) labels.Labels {
+	res := make(labels.Labels, 0, len(lset))
+	for _, l := range lset {
+		res = append(res, labels.Label{Name: l.Name, Value: l.Value})
+	}
+	return res
+}
+
+func prompbLabels(lset labels.Labels) []prompb.Label {
+	res := make([]prompb.Label, 0, len(lset))
+	for _, l := range lset {
+		res = append(res, prompb.Label{Name: l.Name, Value: l.Value})
+	}
+	return res
+}
@@ -0,0 +1,101 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package receive
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/store/storepb/prompb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func series(lset ...string) prompb.TimeSeries {
+	ts := prompb.TimeSeries{Samples: []prompb.Sample{{Value: 1, Timestamp: 1}}}
+	for i := 0; i < len(lset); i += 2 {
+		ts.Labels = append(ts.Labels, prompb.Label{Name: lset[i], Value: lset[i+1]})
+	}
+	return ts
+}
+
+func TestValidator_Process(t *testing.T) {
+	v, err := NewValidator(nil, []byte(`
+default:
+  relabel_configs:
+  - source_labels: [__name__]
+    regex: debug_.*
+    action: drop
+  - regex: pod_ip
+    action: labeldrop
+  max_label_names_per_series: 3
+  max_label_value_length: 16
+  metric_name_patterns: ["http_.*", "up"]
+overrides:
+  tenant-b:
+    max_label_names_per_series: 1
+`))
+	testutil.Ok(t, err)
+
+	wreq := &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		series("__name__", "up", "job", "a"),
+		series("__name__", "debug_requests_total", "job", "a"),
+		series("__name__", "http_requests", "instance", "a", "job", "a", "pod_ip", "10.0.0.1"),
+		series("__name__", "http_requests", "instance", "a", "job", "a", "path", "/"),
+		series("__name__", "http_requests", "path", "/a/very/long/path"),
+		series("__name__", "node_load1"),
+	}}
+	err = v.Process("tenant-a", wreq)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, errInvalidSeries, errors.Cause(err))
+	testutil.Equals(t, []prompb.TimeSeries{
+		series("__name__", "up", "job", "a"),
+		series("__name__", "http_requests", "instance", "a", "job", "a"),
+	}, wreq.Timeseries)
+
+	testutil.Equals(t, 1.0, promtest.ToFloat64(v.rejected.WithLabelValues(ruleRelabel)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(v.rejected.WithLabelValues(ruleMaxLabelNames)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(v.rejected.WithLabelValues(ruleMaxLabelValueLength)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(v.rejected.WithLabelValues(ruleMetricNamePatterns)))
+
+	// Overrides replace the default rules.
+	wreq = &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		series("__name__", "debug_requests_total"),
+		series("__name__", "up", "job", "a"),
+	}}
+	err = v.Process("tenant-b", wreq)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, []prompb.TimeSeries{series("__name__", "debug_requests_total")}, wreq.Timeseries)
+	testutil.Equals(t, 2.0, promtest.ToFloat64(v.rejected.WithLabelValues(ruleMaxLabelNames)))
+
+	_, err = NewValidator(nil, []byte(`
+default:
+  metric_name_patterns: ["("]
+`))
+	testutil.NotOk(t, err)
+}
+
+func TestReceiveValidation(t *testing.T) {
+	v, err := NewValidator(nil, []byte(`
+default:
+  max_label_value_length: 3
+`))
+	testutil.Ok(t, err)
+
+	app := newFakeAppender(nil, nil, nil, nil)
+	handlers, _ := newHandlerHashring([]*fakeAppendable{{appender: app}}, 1)
+	h := handlers[0]
+	h.options.Validator = v
+
+	// Valid series are written, while the request fails because of the invalid ones.
+	status, err := makeRequest(h, "tenant-a", &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+		series("foo", "bar"),
+		series("foo", "long"),
+	}})
+	testutil.Ok(t, err)
+	testutil.Equals(t, http.StatusBadRequest, status)
+	testutil.Equals(t, 1, len(app.samples))
+	testutil.Equals(t, []prompb.Sample{{Value: 1, Timestamp: 1}}, app.samples[`{foo="bar"}`])
+}
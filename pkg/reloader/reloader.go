@@ -10,8 +10,10 @@
 // 	* Optionally, specify different different output file for watched `cfgFile` (`cfgOutputFile`).
 // 	This will also try decompress the `cfgFile` if needed and substitute ALL the envvars using Kubernetes substitution format: (`$(var)`)
 // 	* Watch on changes against certain directories (`ruleDires`).
+// 	* Optionally, watch on changes against config directories (`WithConfigDirs`), substituting envvars into output directory.
+// 	* Optionally, validate changed configuration before the reload (`WithValidation`), rolling back output files on failure.
 //
-// Once any of those two changes Prometheus on given `reloadURL` will be notified, causing Prometheus to reload configuration and rules.
+// Once any of those changes Prometheus on given `reloadURL` will be notified, causing Prometheus to reload configuration and rules.
 //
 // This and below for reloader:
 //
@@ -62,6 +64,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -84,11 +87,16 @@ type Reloader struct {
 	cfgFile       string
 	cfgOutputFile string
 	ruleDirs      []string
+	cfgDirs       []string
+	cfgDirsOutput string
+	validate      func(ctx context.Context) error
 	watchInterval time.Duration
 	retryInterval time.Duration
 
-	lastCfgHash  []byte
-	lastRuleHash []byte
+	lastCfgHash     []byte
+	lastCfgDirsHash []byte
+	lastRuleHash    []byte
+	lastInvalidHash []byte
 }
 
 var firstGzipBytes = []byte{0x1f, 0x8b, 0x08}
@@ -118,6 +126,33 @@ func (r *Reloader) WithWatchInterval(duration time.Duration) {
 	r.watchInterval = duration
 }
 
+// WithConfigDirs sets directories with config files to watch. If outputDir is not empty, files from each directory are
+// decompressed if needed, environment variables are substituted and the output is written into
+// <outputDir>/<base name of the directory>, keeping the directory structure.
+func (r *Reloader) WithConfigDirs(dirs []string, outputDir string) {
+	r.cfgDirs = dirs
+	r.cfgDirsOutput = outputDir
+}
+
+// WithValidation sets a function to validate changed configuration before the reload is triggered. It is called after
+// the output files are written. If it fails, the previous output files are restored and reload is not triggered
+// until the configuration changes again.
+func (r *Reloader) WithValidation(validate func(ctx context.Context) error) {
+	r.validate = validate
+}
+
+// CommandValidation returns validation function running the given command, e.g. `promtool check config <file>`.
+// Validation fails if the command exits with non-zero code.
+func CommandValidation(name string, args ...string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+		if err != nil {
+			return errors.Wrapf(err, "run %s; output: %s", name, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+}
+
 // Watch starts to watch periodically the config file and rules and process them until the context
 // gets canceled. Config file gets env expanded if cfgOutputFile is specified and reload is trigger if
 // config or rules changed.
@@ -143,6 +178,19 @@ func (r *Reloader) Watch(ctx context.Context) error {
 		}
 	}
 
+	if len(r.cfgDirs) > 0 {
+		for _, cfgDir := range r.cfgDirs {
+			watchables[cfgDir] = struct{}{}
+			if err := watcher.Add(cfgDir); err != nil {
+				return errors.Wrapf(err, "add config dir %s to watcher", cfgDir)
+			}
+		}
+
+		if err := r.apply(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Watch rule dirs in best effort manner.
 	for _, ruleDir := range r.ruleDirs {
 		watchables[filepath.Dir(ruleDir)] = struct{}{}
@@ -155,9 +203,11 @@ func (r *Reloader) Watch(ctx context.Context) error {
 	defer tick.Stop()
 
 	level.Info(r.logger).Log(
-		"msg", "started watching config file and non-recursively rule dirs for changes",
+		"msg", "started watching config file, config dirs and non-recursively rule dirs for changes",
 		"cfg", r.cfgFile,
 		"out", r.cfgOutputFile,
+		"cfg_dirs", strings.Join(r.cfgDirs, ","),
+		"cfg_dirs_out", r.cfgDirsOutput,
 		"dirs", strings.Join(r.ruleDirs, ","))
 
 	for {
@@ -182,13 +232,15 @@ func (r *Reloader) Watch(ctx context.Context) error {
 	}
 }
 
-// apply triggers Prometheus reload if rules or config changed. If cfgOutputFile is set, we also
-// expand env vars into config file before reloading.
+// apply triggers Prometheus reload if rules or config changed. If cfgOutputFile or cfgDirsOutput is set, we also
+// expand env vars into config files before reloading. If validation is set, it is run after the output is written
+// and before reload. If validation fails, the previous output is restored and no reload is triggered.
 // Reload is retried in retryInterval until watchInterval.
 func (r *Reloader) apply(ctx context.Context) error {
 	var (
-		cfgHash  []byte
-		ruleHash []byte
+		cfgHash     []byte
+		cfgDirsHash []byte
+		ruleHash    []byte
 	)
 	if r.cfgFile != "" {
 		h := sha256.New()
@@ -196,84 +248,61 @@ func (r *Reloader) apply(ctx context.Context) error {
 			return errors.Wrap(err, "hash file")
 		}
 		cfgHash = h.Sum(nil)
-		if r.cfgOutputFile != "" {
-			b, err := ioutil.ReadFile(r.cfgFile)
-			if err != nil {
-				return errors.Wrap(err, "read file")
-			}
-
-			// Detect and extract gzipped file.
-			if bytes.Equal(b[0:3], firstGzipBytes) {
-				zr, err := gzip.NewReader(bytes.NewReader(b))
-				if err != nil {
-					return errors.Wrap(err, "create gzip reader")
-				}
-				defer runutil.CloseWithLogOnErr(r.logger, zr, "gzip reader close")
-
-				b, err = ioutil.ReadAll(zr)
-				if err != nil {
-					return errors.Wrap(err, "read compressed config file")
-				}
-			}
-
-			b, err = expandEnv(b)
-			if err != nil {
-				return errors.Wrap(err, "expand environment variables")
-			}
+	}
 
-			tmpFile := r.cfgOutputFile + ".tmp"
-			defer func() {
-				_ = os.Remove(tmpFile)
-			}()
-			if err := ioutil.WriteFile(tmpFile, b, 0666); err != nil {
-				return errors.Wrap(err, "write file")
-			}
-			if err := os.Rename(tmpFile, r.cfgOutputFile); err != nil {
-				return errors.Wrap(err, "rename file")
+	if len(r.cfgDirs) > 0 {
+		h := sha256.New()
+		for _, cfgDir := range r.cfgDirs {
+			if err := hashDir(h, cfgDir); err != nil {
+				return errors.Wrap(err, "build config dirs hash")
 			}
 		}
+		cfgDirsHash = h.Sum(nil)
 	}
 
-	h := sha256.New()
-	for _, ruleDir := range r.ruleDirs {
-		walkDir, err := filepath.EvalSymlinks(ruleDir)
-		if err != nil {
-			return errors.Wrap(err, "ruleDir symlink eval")
-		}
-		err = filepath.Walk(walkDir, func(path string, f os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-
-			// filepath.Walk uses Lstat to retriev os.FileInfo. Lstat does not
-			// follow symlinks. Make sure to follow a symlink before checking
-			// if it is a directory.
-			targetFile, err := os.Stat(path)
-			if err != nil {
-				return err
-			}
-
-			if targetFile.IsDir() {
-				return nil
-			}
-
-			if err := hashFile(h, path); err != nil {
-				return err
+	if len(r.ruleDirs) > 0 {
+		h := sha256.New()
+		for _, ruleDir := range r.ruleDirs {
+			if err := hashDir(h, ruleDir); err != nil {
+				return errors.Wrap(err, "build hash")
 			}
-			return nil
-		})
-		if err != nil {
-			return errors.Wrap(err, "build hash")
 		}
-	}
-	if len(r.ruleDirs) > 0 {
 		ruleHash = h.Sum(nil)
 	}
 
-	if bytes.Equal(r.lastCfgHash, cfgHash) && bytes.Equal(r.lastRuleHash, ruleHash) {
+	if bytes.Equal(r.lastCfgHash, cfgHash) && bytes.Equal(r.lastCfgDirsHash, cfgDirsHash) && bytes.Equal(r.lastRuleHash, ruleHash) {
 		// Nothing to do.
 		return nil
 	}
+	invalidHash := bytes.Join([][]byte{cfgHash, cfgDirsHash, ruleHash}, []byte{'\xff'})
+	if bytes.Equal(r.lastInvalidHash, invalidHash) {
+		// Nothing changed since the last failed validation.
+		return nil
+	}
+
+	outputs, err := r.renderOutputs()
+	if err != nil {
+		return err
+	}
+	prev, err := writeOutputs(outputs)
+	if err != nil {
+		return err
+	}
+
+	if r.validate != nil {
+		validateCtx, cancel := context.WithTimeout(ctx, r.watchInterval)
+		err := r.validate(validateCtx)
+		cancel()
+		if err != nil {
+			level.Error(r.logger).Log("msg", "validation of changed configuration failed, rolling back and skipping reload", "err", err)
+			if _, err := writeOutputs(prev); err != nil {
+				return errors.Wrap(err, "roll back configuration")
+			}
+			r.lastInvalidHash = invalidHash
+			return nil
+		}
+	}
+	r.lastInvalidHash = nil
 
 	// Retry trigger reload until it succeeded or next tick is near.
 	retryCtx, cancel := context.WithTimeout(ctx, r.watchInterval)
@@ -285,11 +314,14 @@ func (r *Reloader) apply(ctx context.Context) error {
 		}
 
 		r.lastCfgHash = cfgHash
+		r.lastCfgDirsHash = cfgDirsHash
 		r.lastRuleHash = ruleHash
 		level.Info(r.logger).Log(
 			"msg", "Prometheus reload triggered",
 			"cfg_in", r.cfgFile,
 			"cfg_out", r.cfgOutputFile,
+			"cfg_dirs", strings.Join(r.cfgDirs, ", "),
+			"cfg_dirs_out", r.cfgDirsOutput,
 			"rule_dirs", strings.Join(r.ruleDirs, ", "))
 		return nil
 	}); err != nil {
@@ -299,6 +331,147 @@ func (r *Reloader) apply(ctx context.Context) error {
 	return nil
 }
 
+// renderOutputs returns content of all output files by their path. Nil content means the file should not exist.
+func (r *Reloader) renderOutputs() (map[string][]byte, error) {
+	outputs := map[string][]byte{}
+	if r.cfgFile != "" && r.cfgOutputFile != "" {
+		b, err := renderFile(r.logger, r.cfgFile)
+		if err != nil {
+			return nil, err
+		}
+		outputs[r.cfgOutputFile] = b
+	}
+
+	if r.cfgDirsOutput == "" {
+		return outputs, nil
+	}
+	for _, cfgDir := range r.cfgDirs {
+		outDir := filepath.Join(r.cfgDirsOutput, filepath.Base(cfgDir))
+
+		// Files removed from the config dir are removed from the output too.
+		if err := walkFiles(outDir, func(path string) error {
+			outputs[path] = nil
+			return nil
+		}); err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return nil, errors.Wrapf(err, "list output dir %s", outDir)
+		}
+
+		walkDir, err := filepath.EvalSymlinks(cfgDir)
+		if err != nil {
+			return nil, errors.Wrap(err, "cfgDir symlink eval")
+		}
+		if err := walkFiles(walkDir, func(path string) error {
+			rel, err := filepath.Rel(walkDir, path)
+			if err != nil {
+				return err
+			}
+			b, err := renderFile(r.logger, path)
+			if err != nil {
+				return errors.Wrapf(err, "file %s", path)
+			}
+			outputs[filepath.Join(outDir, rel)] = b
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
+}
+
+// renderFile reads the given file, decompresses it if needed and substitutes environment variables.
+func renderFile(logger log.Logger, fn string) ([]byte, error) {
+	b, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+
+	// Detect and extract gzipped file.
+	if bytes.HasPrefix(b, firstGzipBytes) {
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, errors.Wrap(err, "create gzip reader")
+		}
+		defer runutil.CloseWithLogOnErr(logger, zr, "gzip reader close")
+
+		b, err = ioutil.ReadAll(zr)
+		if err != nil {
+			return nil, errors.Wrap(err, "read compressed config file")
+		}
+	}
+
+	b, err = expandEnv(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "expand environment variables")
+	}
+	return b, nil
+}
+
+// writeOutputs writes the given files atomically, removing files with nil content. It returns the previous
+// content of the files, which can be written back to restore them.
+func writeOutputs(outputs map[string][]byte) (map[string][]byte, error) {
+	prev := make(map[string][]byte, len(outputs))
+	for fn, b := range outputs {
+		p, err := ioutil.ReadFile(fn)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "read file %s", fn)
+		}
+		prev[fn] = p
+
+		if b == nil {
+			if err := os.Remove(fn); err != nil && !os.IsNotExist(err) {
+				return nil, errors.Wrapf(err, "remove file %s", fn)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(fn), os.ModePerm); err != nil {
+			return nil, errors.Wrap(err, "create output dir")
+		}
+		tmpFile := fn + ".tmp"
+		if err := ioutil.WriteFile(tmpFile, b, 0666); err != nil {
+			_ = os.Remove(tmpFile)
+			return nil, errors.Wrap(err, "write file")
+		}
+		if err := os.Rename(tmpFile, fn); err != nil {
+			_ = os.Remove(tmpFile)
+			return nil, errors.Wrap(err, "rename file")
+		}
+	}
+	return prev, nil
+}
+
+// hashDir hashes all files in the given directory recursively, following symlinks.
+func hashDir(h hash.Hash, dir string) error {
+	walkDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return errors.Wrap(err, "dir symlink eval")
+	}
+	return walkFiles(walkDir, func(path string) error {
+		return hashFile(h, path)
+	})
+}
+
+// walkFiles calls f for all files in the given directory recursively.
+func walkFiles(dir string, f func(path string) error) error {
+	return filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// filepath.Walk uses Lstat to retriev os.FileInfo. Lstat does not
+		// follow symlinks. Make sure to follow a symlink before checking
+		// if it is a directory.
+		targetFile, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if targetFile.IsDir() {
+			return nil
+		}
+		return f(path)
+	})
+}
+
 func hashFile(h hash.Hash, fn string) error {
 	f, err := os.Open(fn)
 	if err != nil {
@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -19,6 +20,7 @@ import (
 	"time"
 
 	"github.com/fortytw2/leaktest"
+	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -271,3 +273,102 @@ func TestReloader_RuleApply(t *testing.T) {
 	testutil.Ok(t, err)
 	testutil.Equals(t, 5, reloads.Load().(int))
 }
+
+func TestReloader_ConfigDirApplyWithValidation(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	l, err := net.Listen("tcp", "localhost:0")
+	testutil.Ok(t, err)
+
+	reloads := 0
+	srv := &http.Server{}
+	srv.Handler = http.HandlerFunc(func(resp http.ResponseWriter, r *http.Request) {
+		reloads++
+		resp.WriteHeader(http.StatusOK)
+	})
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	defer func() { testutil.Ok(t, srv.Close()) }()
+
+	reloadURL, err := url.Parse(fmt.Sprintf("http://%s", l.Addr().String()))
+	testutil.Ok(t, err)
+
+	dir, err := ioutil.TempDir("", "reloader-cfg-dir-test")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	var (
+		in1 = path.Join(dir, "in1")
+		in2 = path.Join(dir, "in2")
+		out = path.Join(dir, "out")
+	)
+	testutil.Ok(t, os.MkdirAll(path.Join(in1, "sub"), os.ModePerm))
+	testutil.Ok(t, os.Mkdir(in2, os.ModePerm))
+	testutil.Ok(t, os.Setenv("TEST_RELOADER_THANOS_ENV", "2"))
+
+	testutil.Ok(t, ioutil.WriteFile(path.Join(in1, "a.yaml"), []byte("a: $(TEST_RELOADER_THANOS_ENV)"), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(path.Join(in1, "sub", "b.yaml"), []byte("b: 1"), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(path.Join(in2, "c.yaml"), []byte("c: 1"), os.ModePerm))
+
+	var validations int
+	reloader := New(nil, reloadURL, "", "", nil)
+	reloader.WithConfigDirs([]string{in1, in2}, out)
+	reloader.WithValidation(func(context.Context) error {
+		validations++
+		// Output is written before validation.
+		b, err := ioutil.ReadFile(path.Join(out, "in1", "a.yaml"))
+		testutil.Ok(t, err)
+		if string(b) == "a: invalid" {
+			return errors.New("invalid config")
+		}
+		return nil
+	})
+	reloader.retryInterval = 100 * time.Millisecond
+
+	expectOutput := func(expected map[string]string) {
+		t.Helper()
+		files := map[string]string{}
+		testutil.Ok(t, walkFiles(out, func(p string) error {
+			b, err := ioutil.ReadFile(p)
+			testutil.Ok(t, err)
+			rel, err := filepath.Rel(out, p)
+			testutil.Ok(t, err)
+			files[rel] = string(b)
+			return nil
+		}))
+		testutil.Equals(t, expected, files)
+	}
+
+	ctx := context.Background()
+	testutil.Ok(t, reloader.apply(ctx))
+	testutil.Equals(t, 1, reloads)
+	expectOutput(map[string]string{"in1/a.yaml": "a: 2", "in1/sub/b.yaml": "b: 1", "in2/c.yaml": "c: 1"})
+
+	// No changes, no reload.
+	testutil.Ok(t, reloader.apply(ctx))
+	testutil.Equals(t, 1, reloads)
+	testutil.Equals(t, 1, validations)
+
+	// Invalid change is rolled back.
+	testutil.Ok(t, ioutil.WriteFile(path.Join(in1, "a.yaml"), []byte("a: invalid"), os.ModePerm))
+	testutil.Ok(t, os.Remove(path.Join(in2, "c.yaml")))
+	testutil.Ok(t, reloader.apply(ctx))
+	testutil.Equals(t, 1, reloads)
+	testutil.Equals(t, 2, validations)
+	expectOutput(map[string]string{"in1/a.yaml": "a: 2", "in1/sub/b.yaml": "b: 1", "in2/c.yaml": "c: 1"})
+
+	// Invalid config is not validated again until changed.
+	testutil.Ok(t, reloader.apply(ctx))
+	testutil.Equals(t, 2, validations)
+
+	testutil.Ok(t, ioutil.WriteFile(path.Join(in1, "a.yaml"), []byte("a: 3"), os.ModePerm))
+	testutil.Ok(t, reloader.apply(ctx))
+	testutil.Equals(t, 2, reloads)
+	testutil.Equals(t, 3, validations)
+	expectOutput(map[string]string{"in1/a.yaml": "a: 3", "in1/sub/b.yaml": "b: 1"})
+
+	// Command validation.
+	testutil.Ok(t, CommandValidation("true")(ctx))
+	testutil.NotOk(t, CommandValidation("false")(ctx))
+}
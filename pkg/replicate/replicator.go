@@ -25,6 +25,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/component"
 	"github.com/thanos-io/thanos/pkg/extflag"
 	"github.com/thanos-io/thanos/pkg/extprom"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/prober"
 	"github.com/thanos-io/thanos/pkg/runutil"
@@ -74,6 +75,10 @@ func RunReplicate(
 	fromObjStoreConfig *extflag.PathOrContent,
 	toObjStoreConfig *extflag.PathOrContent,
 	singleRun bool,
+	interval time.Duration,
+	concurrency int,
+	bandwidthLimit int64,
+	mirrorDeletions bool,
 ) error {
 	logger = log.With(logger, "component", "replicate")
 
@@ -140,6 +145,10 @@ func RunReplicate(
 	if err != nil {
 		return err
 	}
+	if bandwidthLimit > 0 {
+		// Objects are streamed from origin to target bucket, so this limits the download as well.
+		toBkt = objstore.BucketWithUploadRateLimit(toBkt, bandwidthLimit)
+	}
 
 	replicationRunCounter := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 		Name: "thanos_replicate_replication_runs_total",
@@ -163,6 +172,10 @@ func RunReplicate(
 		compaction,
 	).Filter
 	metrics := newReplicationMetrics(reg)
+	opts := replicationOptions{
+		concurrency:     concurrency,
+		mirrorDeletions: mirrorDeletions,
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	replicateFn := func() error {
@@ -177,7 +190,7 @@ func RunReplicate(
 		logger := log.With(logger, "replication-run-id", ulid.String())
 		level.Info(logger).Log("msg", "running replication attempt")
 
-		if err := newReplicationScheme(logger, metrics, blockFilter, fetcher, fromBkt, toBkt, reg).execute(ctx); err != nil {
+		if err := newReplicationScheme(logger, metrics, blockFilter, fetcher, fromBkt, toBkt, opts, reg).execute(ctx); err != nil {
 			return errors.Wrap(err, "replication execute")
 		}
 
@@ -192,7 +205,7 @@ func RunReplicate(
 			return replicateFn()
 		}
 
-		return runutil.Repeat(time.Minute, ctx.Done(), func() error {
+		return runutil.Repeat(interval, ctx.Done(), func() error {
 			start := time.Now()
 			if err := replicateFn(); err != nil {
 				level.Error(logger).Log("msg", "running replication failed", "err", err)
@@ -6,10 +6,15 @@ package replicate
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"path"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -23,6 +28,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/compact"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	"golang.org/x/sync/errgroup"
 )
 
 // BlockFilter is block filter that filters out compacted and unselected blocks.
@@ -99,6 +105,14 @@ func (bf *BlockFilter) Filter(b *metadata.Meta) bool {
 
 type blockFilterFunc func(b *metadata.Meta) bool
 
+// replicationOptions configure how blocks are replicated.
+type replicationOptions struct {
+	// concurrency is the number of blocks replicated at once.
+	concurrency int
+	// mirrorDeletions enables deleting replicated blocks from the target bucket once they are deleted from the origin.
+	mirrorDeletions bool
+}
+
 // TODO: Add filters field.
 type replicationScheme struct {
 	fromBkt objstore.BucketReader
@@ -106,6 +120,7 @@ type replicationScheme struct {
 
 	blockFilter blockFilterFunc
 	fetcher     thanosblock.MetadataFetcher
+	opts        replicationOptions
 
 	logger  log.Logger
 	metrics *replicationMetrics
@@ -120,7 +135,10 @@ type replicationMetrics struct {
 
 	blocksAlreadyReplicated prometheus.Counter
 	blocksReplicated        prometheus.Counter
+	blocksDeleted           prometheus.Counter
 	objectsReplicated       prometheus.Counter
+	bytesReplicated         prometheus.Counter
+	objectVerifyFailures    prometheus.Counter
 }
 
 func newReplicationMetrics(reg prometheus.Registerer) *replicationMetrics {
@@ -145,10 +163,22 @@ func newReplicationMetrics(reg prometheus.Registerer) *replicationMetrics {
 			Name: "thanos_replicate_blocks_replicated_total",
 			Help: "Total number of blocks replicated.",
 		}),
+		blocksDeleted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_replicate_blocks_deleted_total",
+			Help: "Total number of replicated blocks deleted from the target bucket after being deleted from the origin bucket.",
+		}),
 		objectsReplicated: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 			Name: "thanos_replicate_objects_replicated_total",
 			Help: "Total number of objects replicated.",
 		}),
+		bytesReplicated: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_replicate_bytes_replicated_total",
+			Help: "Total number of bytes of objects replicated.",
+		}),
+		objectVerifyFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_replicate_object_verification_failures_total",
+			Help: "Total number of replicated objects whose size or checksum did not match.",
+		}),
 	}
 	return m
 }
@@ -160,11 +190,15 @@ func newReplicationScheme(
 	fetcher thanosblock.MetadataFetcher,
 	from objstore.BucketReader,
 	to objstore.Bucket,
+	opts replicationOptions,
 	reg prometheus.Registerer,
 ) *replicationScheme {
 	if logger == nil {
 		logger = log.NewNopLogger()
 	}
+	if opts.concurrency < 1 {
+		opts.concurrency = 1
+	}
 
 	return &replicationScheme{
 		logger:      logger,
@@ -172,13 +206,20 @@ func newReplicationScheme(
 		fetcher:     fetcher,
 		fromBkt:     from,
 		toBkt:       to,
+		opts:        opts,
 		metrics:     metrics,
 		reg:         reg,
 	}
 }
 
 func (rs *replicationScheme) execute(ctx context.Context) error {
+	state, err := readState(ctx, rs.logger, rs.toBkt)
+	if err != nil {
+		return errors.Wrap(err, "read replication state from target bucket")
+	}
+
 	availableBlocks := []*metadata.Meta{}
+	originBlocks := map[ulid.ULID]struct{}{}
 
 	level.Debug(rs.logger).Log("msg", "scanning blocks available blocks for replication")
 
@@ -189,6 +230,13 @@ func (rs *replicationScheme) execute(ctx context.Context) error {
 		if !ok {
 			return nil
 		}
+		originBlocks[id] = struct{}{}
+
+		if _, ok := state.Blocks[id]; ok {
+			level.Debug(rs.logger).Log("msg", "skipping block as already replicated according to replication state", "block_uuid", id.String())
+			rs.metrics.blocksAlreadyReplicated.Inc()
+			return nil
+		}
 
 		rs.metrics.originMetaLoads.Inc()
 
@@ -235,19 +283,90 @@ func (rs *replicationScheme) execute(ctx context.Context) error {
 		return candidateBlocks[i].BlockMeta.MinTime < candidateBlocks[j].BlockMeta.MinTime
 	})
 
-	for _, b := range candidateBlocks {
-		if err := rs.ensureBlockIsReplicated(ctx, b.BlockMeta.ULID); err != nil {
-			return errors.Wrapf(err, "ensure block %v is replicated", b.BlockMeta.ULID.String())
-		}
+	if err := rs.replicateBlocks(ctx, state, candidateBlocks); err != nil {
+		return err
 	}
 
+	if rs.opts.mirrorDeletions {
+		return rs.mirrorDeletedBlocks(ctx, state, originBlocks)
+	}
 	return nil
 }
 
+// replicateBlocks replicates the given blocks by up to the configured number of workers. Blocks are started in the
+// given order. Each replicated block is recorded in the replication state right away, so interrupted replication
+// resumes with the blocks not replicated yet.
+func (rs *replicationScheme) replicateBlocks(ctx context.Context, state *replicationState, metas []*metadata.Meta) error {
+	var (
+		mtx sync.Mutex
+		ch  = make(chan *metadata.Meta)
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	for i := 0; i < rs.opts.concurrency; i++ {
+		g.Go(func() error {
+			for meta := range ch {
+				if err := rs.ensureBlockIsReplicated(gctx, meta); err != nil {
+					return errors.Wrapf(err, "ensure block %v is replicated", meta.ULID.String())
+				}
+
+				mtx.Lock()
+				state.Blocks[meta.ULID] = replicatedBlock{ReplicatedAt: time.Now().Unix()}
+				err := writeState(gctx, rs.toBkt, state)
+				mtx.Unlock()
+				if err != nil {
+					return errors.Wrap(err, "write replication state to target bucket")
+				}
+			}
+			return nil
+		})
+	}
+
+feed:
+	for _, meta := range metas {
+		select {
+		case ch <- meta:
+		case <-gctx.Done():
+			break feed
+		}
+	}
+	close(ch)
+
+	return g.Wait()
+}
+
+// mirrorDeletedBlocks deletes blocks recorded in the replication state from the target bucket, if they are no longer
+// present in the origin bucket.
+func (rs *replicationScheme) mirrorDeletedBlocks(ctx context.Context, state *replicationState, originBlocks map[ulid.ULID]struct{}) error {
+	if len(originBlocks) == 0 && len(state.Blocks) > 0 {
+		// Most likely misconfigured origin bucket, rather than all blocks deleted.
+		level.Warn(rs.logger).Log("msg", "no blocks found in origin bucket, not mirroring deletions", "replicated_blocks", len(state.Blocks))
+		return nil
+	}
+
+	deleted := 0
+	for id := range state.Blocks {
+		if _, ok := originBlocks[id]; ok {
+			continue
+		}
+
+		level.Info(rs.logger).Log("msg", "deleting replicated block deleted from origin bucket", "block_uuid", id.String())
+		if err := thanosblock.Delete(ctx, rs.logger, rs.toBkt, id); err != nil {
+			return errors.Wrapf(err, "delete block %v from target bucket", id.String())
+		}
+		delete(state.Blocks, id)
+		rs.metrics.blocksDeleted.Inc()
+		deleted++
+	}
+	if deleted == 0 {
+		return nil
+	}
+	return errors.Wrap(writeState(ctx, rs.toBkt, state), "write replication state to target bucket")
+}
+
 // ensureBlockIsReplicated ensures that a block present in the origin bucket is
 // present in the target bucket.
-func (rs *replicationScheme) ensureBlockIsReplicated(ctx context.Context, id ulid.ULID) error {
-	blockID := id.String()
+func (rs *replicationScheme) ensureBlockIsReplicated(ctx context.Context, meta *metadata.Meta) error {
+	blockID := meta.ULID.String()
 	chunksDir := path.Join(blockID, thanosblock.ChunksDirname)
 	indexFile := path.Join(blockID, thanosblock.IndexFilename)
 	metaFile := path.Join(blockID, thanosblock.MetaFilename)
@@ -286,15 +405,21 @@ func (rs *replicationScheme) ensureBlockIsReplicated(ctx context.Context, id uli
 			// If the origin meta file content and target meta file content is
 			// equal, we know we have already successfully replicated
 			// previously.
-			level.Debug(rs.logger).Log("msg", "skipping block as already replicated", "block_uuid", id.String())
+			level.Debug(rs.logger).Log("msg", "skipping block as already replicated", "block_uuid", blockID)
 			rs.metrics.blocksAlreadyReplicated.Inc()
 
 			return nil
 		}
 	}
 
+	// Blocks uploaded by older versions have no files recorded and are verified by size only.
+	files := make(map[string]*metadata.File, len(meta.Thanos.Files))
+	for i, f := range meta.Thanos.Files {
+		files[path.Join(blockID, f.RelPath)] = &meta.Thanos.Files[i]
+	}
+
 	if err := rs.fromBkt.Iter(ctx, chunksDir, func(objectName string) error {
-		err := rs.ensureObjectReplicated(ctx, objectName)
+		err := rs.ensureObjectReplicated(ctx, objectName, files[objectName])
 		if err != nil {
 			return errors.Wrapf(err, "replicate object %v", objectName)
 		}
@@ -304,7 +429,7 @@ func (rs *replicationScheme) ensureBlockIsReplicated(ctx context.Context, id uli
 		return err
 	}
 
-	if err := rs.ensureObjectReplicated(ctx, indexFile); err != nil {
+	if err := rs.ensureObjectReplicated(ctx, indexFile, files[indexFile]); err != nil {
 		return errors.Wrap(err, "replicate index file")
 	}
 
@@ -319,9 +444,10 @@ func (rs *replicationScheme) ensureBlockIsReplicated(ctx context.Context, id uli
 	return nil
 }
 
-// ensureBlockIsReplicated ensures that an object present in the origin bucket
-// is present in the target bucket.
-func (rs *replicationScheme) ensureObjectReplicated(ctx context.Context, objectName string) error {
+// ensureObjectReplicated ensures that an object present in the origin bucket
+// is present in the target bucket. If the expected file is known, the object is
+// verified against its size and checksum.
+func (rs *replicationScheme) ensureObjectReplicated(ctx context.Context, objectName string, expected *metadata.File) error {
 	level.Debug(rs.logger).Log("msg", "ensuring object is replicated", "object", objectName)
 
 	exists, err := rs.toBkt.Exists(ctx, objectName)
@@ -329,11 +455,22 @@ func (rs *replicationScheme) ensureObjectReplicated(ctx context.Context, objectN
 		return errors.Wrapf(err, "check if %v exists in target bucket", objectName)
 	}
 
-	// skip if already exists.
-	if exists {
+	// skip if already exists, unless it is known to be different.
+	if exists && expected == nil {
 		level.Debug(rs.logger).Log("msg", "skipping object as already replicated", "object", objectName)
 		return nil
 	}
+	if exists {
+		size, err := rs.toBkt.ObjectSize(ctx, objectName)
+		if err != nil {
+			return errors.Wrapf(err, "get size of %v in target bucket", objectName)
+		}
+		if int64(size) == expected.SizeBytes {
+			level.Debug(rs.logger).Log("msg", "skipping object as already replicated", "object", objectName)
+			return nil
+		}
+		level.Warn(rs.logger).Log("msg", "object in target bucket has unexpected size, replicating again", "object", objectName, "size", size, "expected", expected.SizeBytes)
+	}
 
 	level.Debug(rs.logger).Log("msg", "object not present in target bucket, replicating", "object", objectName)
 
@@ -342,18 +479,76 @@ func (rs *replicationScheme) ensureObjectReplicated(ctx context.Context, objectN
 		return errors.Wrapf(err, "get %v from origin bucket", objectName)
 	}
 
-	defer r.Close()
+	defer runutil.CloseWithLogOnErr(rs.logger, r, "close origin object")
 
-	if err = rs.toBkt.Upload(ctx, objectName, r); err != nil {
+	cr := newChecksumReader(r)
+	if err = rs.toBkt.Upload(ctx, objectName, cr); err != nil {
 		return errors.Wrapf(err, "upload %v to target bucket", objectName)
 	}
 
+	if err := rs.verifyObject(ctx, objectName, cr, expected); err != nil {
+		rs.metrics.objectVerifyFailures.Inc()
+		// Remove the corrupted copy, so the next attempt replicates it again.
+		if derr := rs.toBkt.Delete(ctx, objectName); derr != nil {
+			level.Warn(rs.logger).Log("msg", "failed to delete corrupted object from target bucket", "object", objectName, "err", derr)
+		}
+		return err
+	}
+
 	level.Info(rs.logger).Log("msg", "object replicated", "object", objectName)
 	rs.metrics.objectsReplicated.Inc()
+	rs.metrics.bytesReplicated.Add(float64(cr.n))
+
+	return nil
+}
+
+// verifyObject checks the object read from the origin bucket against the expected file, if known, and that the
+// object uploaded to the target bucket has the same size.
+func (rs *replicationScheme) verifyObject(ctx context.Context, objectName string, cr *checksumReader, expected *metadata.File) error {
+	if expected != nil {
+		if cr.n != expected.SizeBytes {
+			return errors.Errorf("size %d of %v read from origin bucket does not match expected %d", cr.n, objectName, expected.SizeBytes)
+		}
+		if sum := cr.sum(); sum != expected.CRC32C {
+			return errors.Errorf("CRC32C checksum %s of %v read from origin bucket does not match expected %s", sum, objectName, expected.CRC32C)
+		}
+	}
 
+	size, err := rs.toBkt.ObjectSize(ctx, objectName)
+	if err != nil {
+		return errors.Wrapf(err, "get size of %v in target bucket", objectName)
+	}
+	if int64(size) != cr.n {
+		return errors.Errorf("size %d of %v in target bucket does not match %d bytes read from origin bucket", size, objectName, cr.n)
+	}
 	return nil
 }
 
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// checksumReader computes size and CRC32C checksum of the data read through it.
+type checksumReader struct {
+	r io.Reader
+	h hash.Hash32
+	n int64
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	return &checksumReader{r: r, h: crc32.New(castagnoliTable)}
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	_, _ = r.h.Write(p[:n])
+	return n, err
+}
+
+// sum returns the hex encoded checksum, as recorded in block meta.
+func (r *checksumReader) sum() string {
+	return fmt.Sprintf("%08x", r.h.Sum32())
+}
+
 // loadMeta loads the meta.json from the origin bucket and returns the meta
 // struct as well as if failed, whether the failure was due to the meta.json
 // not being present or partial. The distinction is important, as if missing or
@@ -8,9 +8,11 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -62,6 +64,17 @@ func testMeta(ulid ulid.ULID) *metadata.Meta {
 	}
 }
 
+// blockObjects returns objects of the bucket without the replication state.
+func blockObjects(bkt *inmem.Bucket) map[string][]byte {
+	objs := map[string][]byte{}
+	for name, b := range bkt.Objects() {
+		if name != StateFilename {
+			objs[name] = b
+		}
+	}
+	return objs
+}
+
 func TestReplicationSchemeAll(t *testing.T) {
 	var cases = []struct {
 		name     string
@@ -80,7 +93,7 @@ func TestReplicationSchemeAll(t *testing.T) {
 				_ = originBucket.Upload(ctx, path.Join(testULID(0).String(), "chunks", "000001"), bytes.NewReader(nil))
 			},
 			assert: func(ctx context.Context, t *testing.T, originBucket, targetBucket *inmem.Bucket) {
-				if len(targetBucket.Objects()) != 0 {
+				if len(blockObjects(targetBucket)) != 0 {
 					t.Fatal("TargetBucket should have been empty but is not.")
 				}
 			},
@@ -91,7 +104,7 @@ func TestReplicationSchemeAll(t *testing.T) {
 				_ = originBucket.Upload(ctx, path.Join(testULID(0).String(), "meta.json"), bytes.NewReader([]byte("{")))
 			},
 			assert: func(ctx context.Context, t *testing.T, originBucket, targetBucket *inmem.Bucket) {
-				if len(targetBucket.Objects()) != 0 {
+				if len(blockObjects(targetBucket)) != 0 {
 					t.Fatal("TargetBucket should have been empty but is not.")
 				}
 			},
@@ -109,7 +122,7 @@ func TestReplicationSchemeAll(t *testing.T) {
 				_ = originBucket.Upload(ctx, path.Join(ulid.String(), "index"), bytes.NewReader(nil))
 			},
 			assert: func(ctx context.Context, t *testing.T, originBucket, targetBucket *inmem.Bucket) {
-				if len(targetBucket.Objects()) != 3 {
+				if len(blockObjects(targetBucket)) != 3 {
 					t.Fatal("TargetBucket should have one block made up of three objects replicated.")
 				}
 			},
@@ -162,7 +175,7 @@ func TestReplicationSchemeAll(t *testing.T) {
 			},
 			assert: func(ctx context.Context, t *testing.T, originBucket, targetBucket *inmem.Bucket) {
 				expected := 3
-				got := len(targetBucket.Objects())
+				got := len(blockObjects(targetBucket))
 				if got != expected {
 					t.Fatalf("TargetBucket should have one block made up of three objects replicated. Got %d but expected %d objects.", got, expected)
 				}
@@ -191,7 +204,7 @@ func TestReplicationSchemeAll(t *testing.T) {
 			},
 			assert: func(ctx context.Context, t *testing.T, originBucket, targetBucket *inmem.Bucket) {
 				expected := 6
-				got := len(targetBucket.Objects())
+				got := len(blockObjects(targetBucket))
 				if got != expected {
 					t.Fatalf("TargetBucket should have two blocks made up of three objects replicated. Got %d but expected %d objects.", got, expected)
 				}
@@ -221,7 +234,7 @@ func TestReplicationSchemeAll(t *testing.T) {
 			},
 			assert: func(ctx context.Context, t *testing.T, originBucket, targetBucket *inmem.Bucket) {
 				expected := 3
-				got := len(targetBucket.Objects())
+				got := len(blockObjects(targetBucket))
 				if got != expected {
 					t.Fatalf("TargetBucket should have one block made up of three objects replicated. Got %d but expected %d objects.", got, expected)
 				}
@@ -241,7 +254,7 @@ func TestReplicationSchemeAll(t *testing.T) {
 				_ = originBucket.Upload(ctx, path.Join(ulid.String(), "index"), bytes.NewReader(nil))
 			},
 			assert: func(ctx context.Context, t *testing.T, originBucket, targetBucket *inmem.Bucket) {
-				if len(targetBucket.Objects()) != 0 {
+				if len(blockObjects(targetBucket)) != 0 {
 					t.Fatal("TargetBucket should have been empty but is not.")
 				}
 			},
@@ -283,7 +296,7 @@ func TestReplicationSchemeAll(t *testing.T) {
 				_ = originBucket.Upload(ctx, path.Join("01DQYXMK8G108CEBQ79Y84DYVY", "index"), bytes.NewReader(nil))
 			},
 			assert: func(ctx context.Context, t *testing.T, originBucket, targetBucket *inmem.Bucket) {
-				if len(targetBucket.Objects()) != 3 {
+				if len(blockObjects(targetBucket)) != 3 {
 					t.Fatal("TargetBucket should have one block does not.")
 				}
 
@@ -323,6 +336,7 @@ func TestReplicationSchemeAll(t *testing.T) {
 			fetcher,
 			originBucket,
 			targetBucket,
+			replicationOptions{},
 			nil,
 		)
 
@@ -332,3 +346,120 @@ func TestReplicationSchemeAll(t *testing.T) {
 		c.assert(ctx, t, originBucket, targetBucket)
 	}
 }
+
+func uploadTestBlock(ctx context.Context, t *testing.T, bkt objstore.Bucket, meta *metadata.Meta, chunks, index []byte) {
+	b, err := json.Marshal(meta)
+	testutil.Ok(t, err)
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(meta.ULID.String(), "chunks", "000001"), bytes.NewReader(chunks)))
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(meta.ULID.String(), "index"), bytes.NewReader(index)))
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(meta.ULID.String(), "meta.json"), bytes.NewReader(b)))
+}
+
+func newTestReplicationScheme(t *testing.T, originBucket, targetBucket objstore.Bucket, opts replicationOptions) *replicationScheme {
+	logger := testLogger(t.Name())
+	matcher, err := labels.NewMatcher(labels.MatchEqual, "test-labelname", "test-labelvalue")
+	testutil.Ok(t, err)
+	fetcher, err := block.NewMetaFetcher(logger, 32, originBucket, "", nil, nil, nil)
+	testutil.Ok(t, err)
+
+	filter := NewBlockFilter(logger, labels.Selector{matcher}, compact.ResolutionLevelRaw, 1).Filter
+	return newReplicationScheme(logger, newReplicationMetrics(nil), filter, fetcher, originBucket, targetBucket, opts, nil)
+}
+
+func TestReplicationScheme_State(t *testing.T) {
+	ctx := context.Background()
+	originBucket := inmem.NewBucket()
+	targetBucket := inmem.NewBucket()
+
+	var ids []ulid.ULID
+	for i := int64(0); i < 5; i++ {
+		meta := testMeta(testULID(i))
+		meta.MinTime = i
+		uploadTestBlock(ctx, t, originBucket, meta, []byte("chunks"), []byte("index"))
+		ids = append(ids, meta.ULID)
+	}
+
+	r := newTestReplicationScheme(t, originBucket, targetBucket, replicationOptions{concurrency: 3})
+	testutil.Ok(t, r.execute(ctx))
+	testutil.Equals(t, originBucket.Objects(), blockObjects(targetBucket))
+
+	state, err := readState(ctx, log.NewNopLogger(), targetBucket)
+	testutil.Ok(t, err)
+	testutil.Equals(t, len(ids), len(state.Blocks))
+
+	// Blocks recorded in the state are not replicated again, e.g. after the target compactor deleted them.
+	testutil.Ok(t, block.Delete(ctx, log.NewNopLogger(), targetBucket, ids[0]))
+	testutil.Ok(t, r.execute(ctx))
+	ok, err := targetBucket.Exists(ctx, path.Join(ids[0].String(), "meta.json"))
+	testutil.Ok(t, err)
+	testutil.Assert(t, !ok, "block recorded in replication state should not be replicated again")
+}
+
+func TestReplicationScheme_MirrorDeletions(t *testing.T) {
+	ctx := context.Background()
+	originBucket := inmem.NewBucket()
+	targetBucket := inmem.NewBucket()
+
+	meta1, meta2 := testMeta(testULID(0)), testMeta(testULID(1))
+	uploadTestBlock(ctx, t, originBucket, meta1, []byte("chunks"), []byte("index"))
+	uploadTestBlock(ctx, t, originBucket, meta2, []byte("chunks"), []byte("index"))
+	// Block not replicated by us is never deleted.
+	meta3 := testMeta(testULID(2))
+	uploadTestBlock(ctx, t, targetBucket, meta3, []byte("chunks"), []byte("index"))
+
+	testutil.Ok(t, newTestReplicationScheme(t, originBucket, targetBucket, replicationOptions{}).execute(ctx))
+	testutil.Ok(t, block.Delete(ctx, log.NewNopLogger(), originBucket, meta1.ULID))
+
+	// Without mirroring, deleted origin block is kept.
+	testutil.Ok(t, newTestReplicationScheme(t, originBucket, targetBucket, replicationOptions{}).execute(ctx))
+	testutil.Equals(t, 9, len(blockObjects(targetBucket)))
+
+	testutil.Ok(t, newTestReplicationScheme(t, originBucket, targetBucket, replicationOptions{mirrorDeletions: true}).execute(ctx))
+	for _, c := range []struct {
+		id     ulid.ULID
+		exists bool
+	}{{meta1.ULID, false}, {meta2.ULID, true}, {meta3.ULID, true}} {
+		ok, err := targetBucket.Exists(ctx, path.Join(c.id.String(), "meta.json"))
+		testutil.Ok(t, err)
+		testutil.Equals(t, c.exists, ok)
+	}
+	testutil.Equals(t, 6, len(blockObjects(targetBucket)))
+
+	state, err := readState(ctx, log.NewNopLogger(), targetBucket)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(state.Blocks))
+}
+
+func TestReplicationScheme_VerifyChecksums(t *testing.T) {
+	ctx := context.Background()
+	originBucket := inmem.NewBucket()
+	targetBucket := inmem.NewBucket()
+
+	checksum := func(b []byte) string {
+		r := newChecksumReader(bytes.NewReader(b))
+		_, err := ioutil.ReadAll(r)
+		testutil.Ok(t, err)
+		return r.sum()
+	}
+
+	meta := testMeta(testULID(0))
+	meta.Thanos.Files = []metadata.File{
+		{RelPath: "chunks/000001", SizeBytes: 6, CRC32C: checksum([]byte("chunks"))},
+		{RelPath: "index", SizeBytes: 5, CRC32C: checksum([]byte("index"))},
+	}
+	uploadTestBlock(ctx, t, originBucket, meta, []byte("chunks"), []byte("index"))
+	testutil.Ok(t, newTestReplicationScheme(t, originBucket, targetBucket, replicationOptions{}).execute(ctx))
+	testutil.Equals(t, originBucket.Objects(), blockObjects(targetBucket))
+
+	originBucket, targetBucket = inmem.NewBucket(), inmem.NewBucket()
+	// Index content differs from the one described by meta.
+	uploadTestBlock(ctx, t, originBucket, meta, []byte("chunks"), []byte("xndex"))
+
+	err := newTestReplicationScheme(t, originBucket, targetBucket, replicationOptions{}).execute(ctx)
+	testutil.NotOk(t, err)
+	testutil.Assert(t, strings.Contains(err.Error(), "CRC32C checksum"), "unexpected error: %v", err)
+	// Corrupted object is removed and the block is not recorded as replicated.
+	testutil.Equals(t, 1, len(targetBucket.Objects()))
+	_, ok := targetBucket.Objects()[path.Join(meta.ULID.String(), "chunks", "000001")]
+	testutil.Assert(t, ok, "verified chunks should be kept")
+}
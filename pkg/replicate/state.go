@@ -0,0 +1,67 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package replicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// StateFilename is the name of the object in the target bucket recording blocks replicated to it. Recorded blocks
+// are not replicated again, even if the target compactor deleted them in the meantime.
+const StateFilename = "thanos-replicate-state.json"
+
+// replicationState is the state of the replication kept in the target bucket.
+type replicationState struct {
+	// Blocks are the blocks fully replicated to the target bucket.
+	Blocks map[ulid.ULID]replicatedBlock `json:"blocks"`
+}
+
+type replicatedBlock struct {
+	// ReplicatedAt is the unix time in seconds when the block replication finished.
+	ReplicatedAt int64 `json:"replicated_at"`
+}
+
+// readState reads the replication state from the bucket. Empty state is returned if there is none yet.
+func readState(ctx context.Context, logger log.Logger, bkt objstore.BucketReader) (*replicationState, error) {
+	s := &replicationState{Blocks: map[ulid.ULID]replicatedBlock{}}
+
+	r, err := bkt.Get(ctx, StateFilename)
+	if bkt.IsObjNotFoundErr(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get %s", StateFilename)
+	}
+	defer runutil.CloseWithLogOnErr(logger, r, "close replication state")
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", StateFilename)
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal %s", StateFilename)
+	}
+	if s.Blocks == nil {
+		s.Blocks = map[ulid.ULID]replicatedBlock{}
+	}
+	return s, nil
+}
+
+// writeState uploads the replication state to the bucket.
+func writeState(ctx context.Context, bkt objstore.Bucket, s *replicationState) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrapf(err, "marshal %s", StateFilename)
+	}
+	return errors.Wrapf(bkt.Upload(ctx, StateFilename, bytes.NewReader(b)), "upload %s", StateFilename)
+}
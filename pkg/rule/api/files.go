@@ -0,0 +1,178 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package v1
+
+import (
+	"crypto/subtle"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/NYTimes/gziphandler"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/route"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	qapi "github.com/thanos-io/thanos/pkg/query/api"
+	thanosrule "github.com/thanos-io/thanos/pkg/rule"
+	"github.com/thanos-io/thanos/pkg/tracing"
+)
+
+// maxRuleFileSize is the maximum size of rule file accepted by the rule files API.
+const maxRuleFileSize = 10 << 20
+
+// RuleFilesAPI allows managing rule files at runtime. Every change is validated, persisted and followed by rules
+// reload. If the reload fails, the change is reverted.
+type RuleFilesAPI struct {
+	logger log.Logger
+	store  *thanosrule.FileStore
+	reload func() error
+	token  string
+
+	// mtx serializes changes, so a failed reload reverts only its own change.
+	mtx sync.Mutex
+}
+
+// NewRuleFilesAPI returns RuleFilesAPI storing files in the given store and calling reload after each change.
+// Requests have to be authenticated by the given bearer token.
+func NewRuleFilesAPI(logger log.Logger, store *thanosrule.FileStore, reload func() error, token string) *RuleFilesAPI {
+	return &RuleFilesAPI{
+		logger: logger,
+		store:  store,
+		reload: reload,
+		token:  token,
+	}
+}
+
+func (api *RuleFilesAPI) Register(r *route.Router, tracer opentracing.Tracer, logger log.Logger, ins extpromhttp.InstrumentationMiddleware) {
+	instr := func(name string, f qapi.ApiFunc) http.HandlerFunc {
+		hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			qapi.SetCORS(w)
+			if err := api.authenticate(r); err != nil {
+				qapi.RespondError(w, err, nil)
+				return
+			}
+			if data, warnings, err := f(r); err != nil {
+				qapi.RespondError(w, err, data)
+			} else if data != nil {
+				qapi.Respond(w, data, warnings)
+			} else {
+				w.WriteHeader(http.StatusNoContent)
+			}
+		})
+		return ins.NewHandler(name, tracing.HTTPMiddleware(tracer, name, logger, gziphandler.GzipHandler(hf)))
+	}
+
+	r.Get("/rule_files", instr("rule_files", api.list))
+	r.Get("/rule_files/:file", instr("rule_file", api.get))
+	r.Put("/rule_files/:file", instr("rule_file_put", api.put))
+	r.Del("/rule_files/:file", instr("rule_file_delete", api.delete))
+}
+
+func (api *RuleFilesAPI) authenticate(r *http.Request) *qapi.ApiError {
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, prefix)), []byte(api.token)) != 1 {
+		return &qapi.ApiError{Typ: qapi.ErrorUnauthorized, Err: errors.New("missing or invalid bearer token")}
+	}
+	return nil
+}
+
+// RuleFile is a rule file managed via RuleFilesAPI.
+type RuleFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content,omitempty"`
+}
+
+func (api *RuleFilesAPI) list(_ *http.Request) (interface{}, []error, *qapi.ApiError) {
+	names, err := api.store.List()
+	if err != nil {
+		return nil, nil, &qapi.ApiError{Typ: qapi.ErrorInternal, Err: err}
+	}
+	res := make([]RuleFile, 0, len(names))
+	for _, n := range names {
+		res = append(res, RuleFile{Name: n})
+	}
+	return res, nil, nil
+}
+
+func (api *RuleFilesAPI) get(r *http.Request) (interface{}, []error, *qapi.ApiError) {
+	name := route.Param(r.Context(), "file")
+	b, err := api.store.Get(name)
+	if err != nil {
+		return nil, nil, storeError(err)
+	}
+	return RuleFile{Name: name, Content: string(b)}, nil, nil
+}
+
+func (api *RuleFilesAPI) put(r *http.Request) (interface{}, []error, *qapi.ApiError) {
+	name := route.Param(r.Context(), "file")
+
+	b, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxRuleFileSize))
+	if err != nil {
+		return nil, nil, &qapi.ApiError{Typ: errorBadData, Err: errors.Wrap(err, "read body")}
+	}
+	if err := thanosrule.ValidateRuleGroups(b); err != nil {
+		return nil, nil, &qapi.ApiError{Typ: errorBadData, Err: errors.Wrap(err, "invalid rule file")}
+	}
+
+	api.mtx.Lock()
+	defer api.mtx.Unlock()
+
+	prev, err := api.store.Set(r.Context(), name, b)
+	if err != nil {
+		return nil, nil, storeError(err)
+	}
+	if err := api.reload(); err != nil {
+		api.revert(r, name, prev)
+		return nil, nil, &qapi.ApiError{Typ: errorBadData, Err: errors.Wrap(err, "reload rules, change reverted")}
+	}
+	return nil, nil, nil
+}
+
+func (api *RuleFilesAPI) delete(r *http.Request) (interface{}, []error, *qapi.ApiError) {
+	name := route.Param(r.Context(), "file")
+
+	api.mtx.Lock()
+	defer api.mtx.Unlock()
+
+	prev, err := api.store.Delete(r.Context(), name)
+	if err != nil {
+		return nil, nil, storeError(err)
+	}
+	if err := api.reload(); err != nil {
+		api.revert(r, name, prev)
+		return nil, nil, &qapi.ApiError{Typ: qapi.ErrorInternal, Err: errors.Wrap(err, "reload rules, change reverted")}
+	}
+	return nil, nil, nil
+}
+
+// revert restores the previous content of the file, or removes it if it did not exist, and reloads rules again.
+func (api *RuleFilesAPI) revert(r *http.Request, name string, prev []byte) {
+	var err error
+	if prev == nil {
+		_, err = api.store.Delete(r.Context(), name)
+	} else {
+		_, err = api.store.Set(r.Context(), name, prev)
+	}
+	if err != nil {
+		level.Error(api.logger).Log("msg", "failed to revert rule file change", "file", name, "err", err)
+	}
+	if err := api.reload(); err != nil {
+		level.Error(api.logger).Log("msg", "reload rules after revert failed", "file", name, "err", err)
+	}
+}
+
+func storeError(err error) *qapi.ApiError {
+	if err == thanosrule.ErrRuleFileNotFound {
+		return &qapi.ApiError{Typ: qapi.ErrorNotFound, Err: err}
+	}
+	if errors.Cause(err) == thanosrule.ErrInvalidRuleFileName {
+		return &qapi.ApiError{Typ: errorBadData, Err: err}
+	}
+	return &qapi.ApiError{Typ: qapi.ErrorInternal, Err: err}
+}
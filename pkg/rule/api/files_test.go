@@ -0,0 +1,142 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/route"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	thanosrule "github.com/thanos-io/thanos/pkg/rule"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+const validRuleFile = `groups:
+- name: test
+  partial_response_strategy: warn
+  rules:
+  - record: job:up:sum
+    expr: sum by (job) (up)
+`
+
+func TestRuleFilesAPI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_files_api")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	store := thanosrule.NewFileStore(log.NewNopLogger(), filepath.Join(dir, "rules"), bkt, "rules")
+	testutil.Ok(t, store.Sync(context.Background()))
+
+	var (
+		reloads   int
+		reloadErr error
+	)
+	api := NewRuleFilesAPI(log.NewNopLogger(), store, func() error {
+		reloads++
+		return reloadErr
+	}, "secret")
+
+	r := route.New()
+	api.Register(r, opentracing.NoopTracer{}, log.NewNopLogger(), extpromhttp.NewNopInstrumentationMiddleware())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	do := func(method, path, token, body string) (int, string) {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		testutil.Ok(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		testutil.Ok(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		testutil.Ok(t, err)
+		return resp.StatusCode, string(b)
+	}
+
+	// Authentication is required.
+	code, _ := do(http.MethodGet, "/rule_files", "", "")
+	testutil.Equals(t, http.StatusUnauthorized, code)
+	code, _ = do(http.MethodPut, "/rule_files/a.yaml", "wrong", validRuleFile)
+	testutil.Equals(t, http.StatusUnauthorized, code)
+
+	// Invalid files and names are rejected without reload.
+	code, _ = do(http.MethodPut, "/rule_files/a.yaml", "secret", "groups:\n- name: test\n  unknown: field\n")
+	testutil.Equals(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodPut, "/rule_files/a.yaml", "secret", "groups:\n- name: test\n  rules:\n  - record: a\n    expr: sum(\n")
+	testutil.Equals(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodPut, "/rule_files/a.txt", "secret", validRuleFile)
+	testutil.Equals(t, http.StatusBadRequest, code)
+	testutil.Equals(t, 0, reloads)
+
+	code, _ = do(http.MethodPut, "/rule_files/a.yaml", "secret", validRuleFile)
+	testutil.Equals(t, http.StatusNoContent, code)
+	testutil.Equals(t, 1, reloads)
+
+	code, body := do(http.MethodGet, "/rule_files", "secret", "")
+	testutil.Equals(t, http.StatusOK, code)
+	var listResp struct {
+		Data []RuleFile `json:"data"`
+	}
+	testutil.Ok(t, json.Unmarshal([]byte(body), &listResp))
+	testutil.Equals(t, []RuleFile{{Name: "a.yaml"}}, listResp.Data)
+
+	code, body = do(http.MethodGet, "/rule_files/a.yaml", "secret", "")
+	testutil.Equals(t, http.StatusOK, code)
+	var getResp struct {
+		Data RuleFile `json:"data"`
+	}
+	testutil.Ok(t, json.Unmarshal([]byte(body), &getResp))
+	testutil.Equals(t, RuleFile{Name: "a.yaml", Content: validRuleFile}, getResp.Data)
+
+	// Files are persisted in the bucket.
+	testutil.Equals(t, validRuleFile, string(bkt.Objects()["rules/a.yaml"]))
+
+	// Failed reload reverts the change.
+	reloadErr = errors.New("reload failed")
+	updated := strings.Replace(validRuleFile, "job:up:sum", "job:up:count", 1)
+	code, _ = do(http.MethodPut, "/rule_files/a.yaml", "secret", updated)
+	testutil.Equals(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodPut, "/rule_files/b.yaml", "secret", validRuleFile)
+	testutil.Equals(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodDelete, "/rule_files/a.yaml", "secret", "")
+	testutil.Equals(t, http.StatusInternalServerError, code)
+
+	b, err := store.Get("a.yaml")
+	testutil.Ok(t, err)
+	testutil.Equals(t, validRuleFile, string(b))
+	_, err = store.Get("b.yaml")
+	testutil.Equals(t, thanosrule.ErrRuleFileNotFound, err)
+	testutil.Equals(t, 1, len(bkt.Objects()))
+	reloadErr = nil
+
+	code, _ = do(http.MethodDelete, "/rule_files/a.yaml", "secret", "")
+	testutil.Equals(t, http.StatusNoContent, code)
+	code, _ = do(http.MethodGet, "/rule_files/a.yaml", "secret", "")
+	testutil.Equals(t, http.StatusNotFound, code)
+	code, _ = do(http.MethodDelete, "/rule_files/a.yaml", "secret", "")
+	testutil.Equals(t, http.StatusNotFound, code)
+	testutil.Equals(t, 0, len(bkt.Objects()))
+
+	// Files are restored from the bucket.
+	testutil.Ok(t, bkt.Upload(context.Background(), "rules/c.yaml", strings.NewReader(validRuleFile)))
+	testutil.Ok(t, store.Sync(context.Background()))
+	names, err := store.List()
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"c.yaml"}, names)
+}
@@ -4,8 +4,13 @@
 package v1
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/NYTimes/gziphandler"
@@ -24,6 +29,9 @@ import (
 	"github.com/thanos-io/thanos/pkg/tracing"
 )
 
+// errorBadData is the error type of invalid requests, responded with 400 status code by qapi.RespondError.
+const errorBadData qapi.ErrorType = "bad_data"
+
 type API struct {
 	logger        log.Logger
 	now           func() time.Time
@@ -68,9 +76,132 @@ type RulesRetriever interface {
 	AlertingRules() []thanosrule.AlertingRule
 }
 
+// rulesFilter selects rule groups and rules returned by the rules endpoint.
+type rulesFilter struct {
+	typ       string
+	names     map[string]struct{}
+	nameRegex *regexp.Regexp
+	groups    map[string]struct{}
+	files     map[string]struct{}
+	health    rules.RuleHealth
+
+	groupLimit     int
+	groupNextToken string
+}
+
+func parseRulesFilter(r *http.Request) (*rulesFilter, *qapi.ApiError) {
+	if err := r.ParseForm(); err != nil {
+		return nil, &qapi.ApiError{Typ: qapi.ErrorInternal, Err: errors.Wrap(err, "parse form")}
+	}
+
+	f := &rulesFilter{
+		typ:            strings.ToLower(r.Form.Get("type")),
+		names:          toSet(r.Form["rule_name[]"]),
+		groups:         toSet(r.Form["rule_group[]"]),
+		files:          toSet(r.Form["file[]"]),
+		health:         rules.RuleHealth(r.Form.Get("health")),
+		groupNextToken: r.Form.Get("group_next_token"),
+	}
+	if f.typ != "" && f.typ != "alert" && f.typ != "record" {
+		return nil, &qapi.ApiError{Typ: errorBadData, Err: errors.Errorf("invalid type %q, expected alert or record", f.typ)}
+	}
+	switch f.health {
+	case "", rules.HealthGood, rules.HealthBad, rules.HealthUnknown:
+	default:
+		return nil, &qapi.ApiError{Typ: errorBadData, Err: errors.Errorf("invalid health %q, expected %s, %s or %s", f.health, rules.HealthGood, rules.HealthBad, rules.HealthUnknown)}
+	}
+	if re := r.Form.Get("rule_name_regex"); re != "" {
+		var err error
+		if f.nameRegex, err = regexp.Compile("^(?:" + re + ")$"); err != nil {
+			return nil, &qapi.ApiError{Typ: errorBadData, Err: errors.Wrap(err, "parse rule_name_regex")}
+		}
+	}
+	if l := r.Form.Get("group_limit"); l != "" {
+		var err error
+		if f.groupLimit, err = strconv.Atoi(l); err != nil || f.groupLimit <= 0 {
+			return nil, &qapi.ApiError{Typ: errorBadData, Err: errors.Errorf("invalid group_limit %q, expected positive integer", l)}
+		}
+	}
+	if f.groupNextToken != "" && f.groupLimit == 0 {
+		return nil, &qapi.ApiError{Typ: errorBadData, Err: errors.New("group_next_token requires group_limit")}
+	}
+	return f, nil
+}
+
+func toSet(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func (f *rulesFilter) matchGroup(grp thanosrule.Group) bool {
+	if _, ok := f.groups[grp.Name()]; f.groups != nil && !ok {
+		return false
+	}
+	if _, ok := f.files[grp.OriginalFile()]; f.files != nil && !ok {
+		return false
+	}
+	return true
+}
+
+// filtersRules returns true if filter selects rules within groups. Groups without any selected rules are not returned then.
+func (f *rulesFilter) filtersRules() bool {
+	return f.typ != "" || f.names != nil || f.nameRegex != nil || f.health != ""
+}
+
+func (f *rulesFilter) matchRule(r rules.Rule) bool {
+	switch r.(type) {
+	case *rules.AlertingRule:
+		if f.typ == "record" {
+			return false
+		}
+	case *rules.RecordingRule:
+		if f.typ == "alert" {
+			return false
+		}
+	}
+	if _, ok := f.names[r.Name()]; f.names != nil && !ok {
+		return false
+	}
+	if f.nameRegex != nil && !f.nameRegex.MatchString(r.Name()) {
+		return false
+	}
+	if f.health != "" && r.Health() != f.health {
+		return false
+	}
+	return true
+}
+
+// groupToken returns the pagination token identifying the given group.
+func groupToken(g *RuleGroup) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(g.File+";"+g.Name)))
+}
+
 func (api *API) rules(r *http.Request) (interface{}, []error, *qapi.ApiError) {
+	f, apiErr := parseRulesFilter(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	groups := api.ruleRetriever.RuleGroups()
+	// Stable order is required for pagination.
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].OriginalFile() != groups[j].OriginalFile() {
+			return groups[i].OriginalFile() < groups[j].OriginalFile()
+		}
+		return groups[i].Name() < groups[j].Name()
+	})
+
 	res := &RuleDiscovery{}
-	for _, grp := range api.ruleRetriever.RuleGroups() {
+	for _, grp := range groups {
+		if !f.matchGroup(grp) {
+			continue
+		}
 		apiRuleGroup := &RuleGroup{
 			Name:                    grp.Name(),
 			File:                    grp.OriginalFile(),
@@ -80,6 +211,9 @@ func (api *API) rules(r *http.Request) (interface{}, []error, *qapi.ApiError) {
 		}
 
 		for _, r := range grp.Rules() {
+			if !f.matchRule(r) {
+				continue
+			}
 			var enrichedRule rule
 
 			lastError := ""
@@ -117,9 +251,29 @@ func (api *API) rules(r *http.Request) (interface{}, []error, *qapi.ApiError) {
 
 			apiRuleGroup.Rules = append(apiRuleGroup.Rules, enrichedRule)
 		}
+		if f.filtersRules() && len(apiRuleGroup.Rules) == 0 {
+			continue
+		}
 		res.RuleGroups = append(res.RuleGroups, apiRuleGroup)
 	}
 
+	if f.groupNextToken != "" {
+		start := -1
+		for i, g := range res.RuleGroups {
+			if groupToken(g) == f.groupNextToken {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return nil, nil, &qapi.ApiError{Typ: errorBadData, Err: errors.New("invalid group_next_token, group not found")}
+		}
+		res.RuleGroups = res.RuleGroups[start:]
+	}
+	if f.groupLimit > 0 && len(res.RuleGroups) > f.groupLimit {
+		res.GroupNextToken = groupToken(res.RuleGroups[f.groupLimit])
+		res.RuleGroups = res.RuleGroups[:f.groupLimit]
+	}
 	return res, nil, nil
 }
 
@@ -167,6 +321,9 @@ func rulesAlertsToAPIAlerts(s storepb.PartialResponseStrategy, rulesAlerts []*ru
 
 type RuleDiscovery struct {
 	RuleGroups []*RuleGroup `json:"groups"`
+	// GroupNextToken is set if there are more groups than group_limit. It has to be passed as group_next_token
+	// to get the next page.
+	GroupNextToken string `json:"groupNextToken,omitempty"`
 }
 
 type RuleGroup struct {
@@ -237,6 +237,78 @@ func testEndpoints(t *testing.T, api *API) {
 		},
 	}
 
+	tests = append(tests,
+		test{
+			endpointFn:   api.rules,
+			endpointName: "rules",
+			query:        url.Values{"type": []string{"record"}},
+			response: &RuleDiscovery{
+				RuleGroups: []*RuleGroup{
+					{
+						Name:                    "grp",
+						File:                    "",
+						Interval:                1,
+						PartialResponseStrategy: "WARN",
+						Rules: []rule{
+							recordingRule{
+								Name:   "recording-rule-1",
+								Query:  "vector(1)",
+								Labels: labels.Labels{},
+								Health: "unknown",
+								Type:   "recording",
+							},
+						},
+					},
+				},
+			},
+		},
+		test{
+			endpointFn:   api.rules,
+			endpointName: "rules",
+			query: url.Values{
+				"rule_name_regex": []string{"test_metric.*"},
+				"rule_name[]":     []string{"test_metric4", "recording-rule-1"},
+				"rule_group[]":    []string{"grp"},
+			},
+			response: &RuleDiscovery{
+				RuleGroups: []*RuleGroup{
+					{
+						Name:                    "grp",
+						File:                    "",
+						Interval:                1,
+						PartialResponseStrategy: "WARN",
+						Rules: []rule{
+							alertingRule{
+								Name:                    "test_metric4",
+								Query:                   "up == 1",
+								Duration:                1,
+								Labels:                  labels.Labels{},
+								Annotations:             labels.Labels{},
+								Alerts:                  []*Alert{},
+								Health:                  "unknown",
+								Type:                    "alerting",
+								PartialResponseStrategy: "WARN",
+							},
+						},
+					},
+				},
+			},
+		},
+		// Groups without matching rules are not returned.
+		test{
+			endpointFn:   api.rules,
+			endpointName: "rules",
+			query:        url.Values{"health": []string{"err"}},
+			response:     &RuleDiscovery{},
+		},
+		test{
+			endpointFn:   api.rules,
+			endpointName: "rules",
+			query:        url.Values{"rule_group[]": []string{"other"}},
+			response:     &RuleDiscovery{},
+		},
+	)
+
 	methods := func(f qapi.ApiFunc) []string {
 		return []string{http.MethodGet}
 	}
@@ -297,3 +369,63 @@ func assertAPIResponse(t *testing.T, got interface{}, exp interface{}) {
 		)
 	}
 }
+
+type groupsRetrieverMock []thanosrule.Group
+
+func (m groupsRetrieverMock) RuleGroups() []thanosrule.Group { return m }
+
+func (m groupsRetrieverMock) AlertingRules() []thanosrule.AlertingRule { return nil }
+
+func TestRulesPagination(t *testing.T) {
+	opts := &rules.ManagerOptions{Metrics: rules.NewGroupMetrics(prometheus.NewRegistry())}
+	var groups groupsRetrieverMock
+	for _, name := range []string{"c", "a", "b"} {
+		groups = append(groups, thanosrule.Group{Group: rules.NewGroup(name, "file", time.Second, nil, false, opts)})
+	}
+	api := NewAPI(nil, prometheus.NewRegistry(), groups)
+
+	get := func(q url.Values) (*RuleDiscovery, *qapi.ApiError) {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://example.com?%s", q.Encode()), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, _, apiErr := api.rules(req)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		return res.(*RuleDiscovery), nil
+	}
+	names := func(res *RuleDiscovery) (n []string) {
+		for _, g := range res.RuleGroups {
+			n = append(n, g.Name)
+		}
+		return n
+	}
+
+	res, apiErr := get(url.Values{"group_limit": []string{"2"}})
+	assertAPIError(t, apiErr)
+	assertAPIResponse(t, names(res), []string{"a", "b"})
+	if res.GroupNextToken == "" {
+		t.Fatal("expected next token")
+	}
+
+	res, apiErr = get(url.Values{"group_limit": []string{"2"}, "group_next_token": []string{res.GroupNextToken}})
+	assertAPIError(t, apiErr)
+	assertAPIResponse(t, names(res), []string{"c"})
+	if res.GroupNextToken != "" {
+		t.Fatalf("unexpected next token %q", res.GroupNextToken)
+	}
+
+	for _, q := range []url.Values{
+		{"group_limit": []string{"0"}},
+		{"group_next_token": []string{"abc"}},
+		{"group_limit": []string{"1"}, "group_next_token": []string{"abc"}},
+		{"type": []string{"unknown"}},
+		{"health": []string{"unknown-health"}},
+		{"rule_name_regex": []string{"("}},
+	} {
+		if _, apiErr := get(q); apiErr == nil || apiErr.Typ != errorBadData {
+			t.Fatalf("expected bad data error for %v, got %v", q, apiErr)
+		}
+	}
+}
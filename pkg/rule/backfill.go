@@ -0,0 +1,146 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"io/ioutil"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/tsdb"
+	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"gopkg.in/yaml.v2"
+)
+
+// BackfillQueryFuncFactory returns the query function the given group is evaluated with.
+type BackfillQueryFuncFactory func(g RuleGroup) rules.QueryFunc
+
+// ParseRuleFiles parses Thanos rule groups from the given files.
+func ParseRuleFiles(files []string) ([]RuleGroup, error) {
+	var (
+		errs   tsdberrors.MultiError
+		groups []RuleGroup
+	)
+	for _, fn := range files {
+		b, err := ioutil.ReadFile(fn)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		var rg RuleGroups
+		if err := yaml.Unmarshal(b, &rg); err != nil {
+			errs = append(errs, errors.Wrap(err, fn))
+			continue
+		}
+		groups = append(groups, rg.Groups...)
+	}
+	return groups, errs.Err()
+}
+
+// Backfill evaluates recording rules of the given groups at every evaluation step of each group within [mint, maxt)
+// and appends results to the given appender. Evaluation timestamps are aligned to the group interval, which
+// defaults to defaultInterval. Alerting rules are skipped. It returns the number of appended samples.
+//
+// NOTE: Rules are evaluated against the query API only, so rules consuming results of other rules see
+// only results already present there.
+func Backfill(
+	ctx context.Context,
+	groups []RuleGroup,
+	defaultInterval time.Duration,
+	mint, maxt time.Time,
+	queryFnFactory BackfillQueryFuncFactory,
+	app tsdb.Appender,
+) (int, error) {
+	var samples int
+	for _, g := range groups {
+		interval := time.Duration(g.Interval)
+		if interval == 0 {
+			interval = defaultInterval
+		}
+		if interval <= 0 {
+			return samples, errors.Errorf("group %q: evaluation interval has to be positive", g.Name)
+		}
+
+		recordingRules := make([]*rules.RecordingRule, 0, len(g.Rules))
+		for _, r := range g.Rules {
+			if r.Record == "" {
+				continue
+			}
+			expr, err := promql.ParseExpr(r.Expr)
+			if err != nil {
+				return samples, errors.Wrapf(err, "group %q: parse expression of rule %q", g.Name, r.Record)
+			}
+			recordingRules = append(recordingRules, rules.NewRecordingRule(r.Record, expr, labels.FromMap(r.Labels)))
+		}
+		if len(recordingRules) == 0 {
+			continue
+		}
+
+		queryFn := queryFnFactory(g)
+		ts := mint.Truncate(interval)
+		if ts.Before(mint) {
+			ts = ts.Add(interval)
+		}
+		for ; ts.Before(maxt); ts = ts.Add(interval) {
+			for _, r := range recordingRules {
+				if err := ctx.Err(); err != nil {
+					return samples, err
+				}
+
+				vector, err := r.Eval(ctx, ts, queryFn, nil)
+				if err != nil {
+					return samples, errors.Wrapf(err, "group %q: evaluate rule %q at %s", g.Name, r.Name(), ts.UTC().Format(time.RFC3339))
+				}
+				for _, s := range vector {
+					if _, err := app.Add(s.Metric, timestamp.FromTime(ts), s.V); err != nil {
+						return samples, errors.Wrapf(err, "group %q: append result of rule %q", g.Name, r.Name())
+					}
+					samples++
+				}
+			}
+		}
+	}
+	return samples, nil
+}
+
+// BackfillRanges splits [mint, maxt) into consecutive ranges aligned to the given block duration.
+func BackfillRanges(mint, maxt time.Time, d time.Duration) [][2]time.Time {
+	var ranges [][2]time.Time
+	for start := mint; start.Before(maxt); {
+		end := start.Truncate(d).Add(d)
+		if end.After(maxt) {
+			end = maxt
+		}
+		ranges = append(ranges, [2]time.Time{start, end})
+		start = end
+	}
+	return ranges
+}
+
+// MaterializedUntil returns the maximum time of blocks with exactly the given external labels, i.e. the time up to
+// which rules were materialized into the bucket. False is returned if there are no such blocks.
+func MaterializedUntil(metas map[ulid.ULID]*metadata.Meta, lset labels.Labels) (time.Time, bool) {
+	var (
+		maxt  int64
+		found bool
+	)
+	for _, m := range metas {
+		if !labels.Equal(labels.FromMap(m.Thanos.Labels), lset) {
+			continue
+		}
+		if !found || m.MaxTime > maxt {
+			maxt = m.MaxTime
+		}
+		found = true
+	}
+	return timestamp.Time(maxt), found
+}
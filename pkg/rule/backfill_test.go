@@ -0,0 +1,124 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+type sample struct {
+	lset labels.Labels
+	t    int64
+	v    float64
+}
+
+type appenderMock struct {
+	tsdb.Appender
+	samples []sample
+}
+
+func (a *appenderMock) Add(l labels.Labels, t int64, v float64) (uint64, error) {
+	a.samples = append(a.samples, sample{lset: l, t: t, v: v})
+	return 0, nil
+}
+
+func TestBackfill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_backfill")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(`
+groups:
+- name: "fast"
+  interval: 1m
+  partial_response_strategy: "warn"
+  rules:
+  - record: "job:up:sum"
+    expr: "sum by (job) (up)"
+    labels:
+      source: "rule"
+  - alert: "Down"
+    expr: "up == 0"
+- name: "default"
+  rules:
+  - record: "up:count"
+    expr: "count(up)"
+`), os.ModePerm))
+
+	groups, err := ParseRuleFiles([]string{filepath.Join(dir, "rules.yaml")})
+	testutil.Ok(t, err)
+
+	var queries []string
+	app := &appenderMock{}
+	samples, err := Backfill(context.Background(), groups, 2*time.Minute, time.Unix(30, 0), time.Unix(240, 0), func(g RuleGroup) rules.QueryFunc {
+		return func(_ context.Context, q string, ts time.Time) (promql.Vector, error) {
+			queries = append(queries, g.Name+":"+q)
+			if g.Name == "fast" {
+				testutil.Equals(t, storepb.PartialResponseStrategy_WARN, *g.PartialResponseStrategy)
+			}
+			return promql.Vector{{Metric: labels.FromStrings("job", "a"), Point: promql.Point{V: float64(ts.Unix())}}}, nil
+		}
+	}, app)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 4, samples)
+	testutil.Equals(t, []string{
+		"fast:sum by(job) (up)",
+		"fast:sum by(job) (up)",
+		"fast:sum by(job) (up)",
+		"default:count(up)",
+	}, queries)
+
+	lset := labels.FromStrings("__name__", "job:up:sum", "job", "a", "source", "rule")
+	testutil.Equals(t, []sample{
+		{lset: lset, t: 60000, v: 60},
+		{lset: lset, t: 120000, v: 120},
+		{lset: lset, t: 180000, v: 180},
+		{lset: labels.FromStrings("__name__", "up:count", "job", "a"), t: 120000, v: 120},
+	}, app.samples)
+}
+
+func TestBackfillRanges(t *testing.T) {
+	testutil.Equals(t, [][2]time.Time{
+		{time.Unix(1800, 0), time.Unix(7200, 0)},
+		{time.Unix(7200, 0), time.Unix(14400, 0)},
+		{time.Unix(14400, 0), time.Unix(15000, 0)},
+	}, BackfillRanges(time.Unix(1800, 0), time.Unix(15000, 0), 2*time.Hour))
+
+	testutil.Equals(t, 0, len(BackfillRanges(time.Unix(1800, 0), time.Unix(1800, 0), 2*time.Hour)))
+}
+
+func TestMaterializedUntil(t *testing.T) {
+	lset := labels.FromStrings("materializer", "dashboards")
+	newMeta := func(maxt int64, lbls map[string]string) *metadata.Meta {
+		m := &metadata.Meta{Thanos: metadata.Thanos{Labels: lbls}}
+		m.MaxTime = maxt
+		return m
+	}
+
+	_, ok := MaterializedUntil(nil, lset)
+	testutil.Assert(t, !ok, "expected no materialized blocks")
+
+	until, ok := MaterializedUntil(map[ulid.ULID]*metadata.Meta{
+		ulid.MustNew(1, nil): newMeta(7200000, map[string]string{"materializer": "dashboards"}),
+		ulid.MustNew(2, nil): newMeta(14400000, map[string]string{"materializer": "dashboards"}),
+		ulid.MustNew(3, nil): newMeta(21600000, map[string]string{"materializer": "dashboards", "replica": "a"}),
+		ulid.MustNew(4, nil): newMeta(28800000, map[string]string{"materializer": "other"}),
+	}, lset)
+	testutil.Assert(t, ok, "expected materialized blocks")
+	testutil.Equals(t, time.Unix(14400, 0), until)
+}
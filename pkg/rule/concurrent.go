@@ -0,0 +1,211 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/gate"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+)
+
+// maxPendingAge is how long a prefetched query result waits for its rule to be evaluated before it is dropped,
+// e.g. because the group was removed in the meantime.
+const maxPendingAge = 5 * time.Minute
+
+type pendingQuery struct {
+	query string
+	ts    int64
+}
+
+type pendingResult struct {
+	done    chan struct{}
+	created time.Time
+
+	v   promql.Vector
+	err error
+}
+
+// ConcurrentEvaluator allows independent rules within a group to be evaluated concurrently. rules.Group evaluates
+// its rules one by one, so once the query of the first rule of a batch of consecutive rules not consuming each
+// other's results is requested, queries of all rules in the batch are started concurrently. Results are returned
+// when the group requests the query of the given rule.
+type ConcurrentEvaluator struct {
+	queryFn     rules.QueryFunc
+	concurrency int
+
+	mtx     sync.Mutex
+	batches map[string][]string
+	pending map[pendingQuery]*pendingResult
+}
+
+// NewConcurrentEvaluator returns ConcurrentEvaluator that evaluates up to concurrency rules of a group at once
+// using the given query function.
+func NewConcurrentEvaluator(queryFn rules.QueryFunc, concurrency int) *ConcurrentEvaluator {
+	return &ConcurrentEvaluator{
+		queryFn:     queryFn,
+		concurrency: concurrency,
+		batches:     map[string][]string{},
+		pending:     map[pendingQuery]*pendingResult{},
+	}
+}
+
+// Update analyzes dependencies between rules of the given groups. It has to be called after every update
+// of the rules.Manager using the evaluator.
+func (e *ConcurrentEvaluator) Update(groups []*rules.Group) {
+	// Queries are identified only by expression and timestamp, so expressions used by multiple rules are
+	// always evaluated on their own.
+	count := map[string]int{}
+	for _, g := range groups {
+		for _, r := range g.Rules() {
+			if expr := ruleQuery(r); expr != nil {
+				count[expr.String()]++
+			}
+		}
+	}
+
+	batches := map[string][]string{}
+	for _, g := range groups {
+		var (
+			batch   []string
+			outputs = map[string]struct{}{}
+		)
+		flush := func() {
+			if len(batch) > 1 {
+				batches[batch[0]] = batch
+			}
+			batch = nil
+			outputs = map[string]struct{}{}
+		}
+		for _, r := range g.Rules() {
+			expr := ruleQuery(r)
+			if expr == nil || count[expr.String()] > 1 {
+				flush()
+				continue
+			}
+			q := expr.String()
+			if dependsOn(expr, outputs) {
+				flush()
+			}
+			batch = append(batch, q)
+			for _, o := range ruleOutputs(r) {
+				outputs[o] = struct{}{}
+			}
+		}
+		flush()
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.batches = batches
+}
+
+// Query implements rules.QueryFunc.
+func (e *ConcurrentEvaluator) Query(ctx context.Context, q string, t time.Time) (promql.Vector, error) {
+	e.mtx.Lock()
+	e.dropOldPending()
+
+	k := pendingQuery{query: q, ts: t.UnixNano()}
+	if p, ok := e.pending[k]; ok {
+		delete(e.pending, k)
+		e.mtx.Unlock()
+		return p.wait(ctx)
+	}
+
+	batch, ok := e.batches[q]
+	if !ok {
+		e.mtx.Unlock()
+		return e.queryFn(ctx, q, t)
+	}
+
+	results := make([]*pendingResult, 0, len(batch))
+	for i, bq := range batch {
+		p := &pendingResult{done: make(chan struct{}), created: time.Now()}
+		results = append(results, p)
+		if i > 0 {
+			e.pending[pendingQuery{query: bq, ts: t.UnixNano()}] = p
+		}
+	}
+	e.mtx.Unlock()
+
+	g := gate.New(e.concurrency)
+	for i, bq := range batch {
+		go func(bq string, p *pendingResult) {
+			defer close(p.done)
+
+			if p.err = g.Start(ctx); p.err != nil {
+				return
+			}
+			defer g.Done()
+
+			p.v, p.err = e.queryFn(ctx, bq, t)
+		}(bq, results[i])
+	}
+	return results[0].wait(ctx)
+}
+
+func (e *ConcurrentEvaluator) dropOldPending() {
+	for k, p := range e.pending {
+		if time.Since(p.created) > maxPendingAge {
+			delete(e.pending, k)
+		}
+	}
+}
+
+func (p *pendingResult) wait(ctx context.Context) (promql.Vector, error) {
+	select {
+	case <-p.done:
+		return p.v, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func ruleQuery(r rules.Rule) promql.Expr {
+	if q, ok := r.(interface{ Query() promql.Expr }); ok {
+		return q.Query()
+	}
+	return nil
+}
+
+// ruleOutputs returns metric names of series the rule writes.
+func ruleOutputs(r rules.Rule) []string {
+	if _, ok := r.(*rules.AlertingRule); ok {
+		return []string{"ALERTS", "ALERTS_FOR_STATE"}
+	}
+	return []string{r.Name()}
+}
+
+// dependsOn returns true if the expression might read any of the given metric names. Selectors without
+// exact metric name are assumed to read all of them.
+func dependsOn(expr promql.Expr, names map[string]struct{}) bool {
+	if len(names) == 0 {
+		return false
+	}
+
+	var depends bool
+	promql.Inspect(expr, func(node promql.Node, _ []promql.Node) error {
+		var name string
+		switch n := node.(type) {
+		case *promql.VectorSelector:
+			name = n.Name
+		case *promql.MatrixSelector:
+			name = n.Name
+		default:
+			return nil
+		}
+		if name == "" {
+			depends = true
+			return nil
+		}
+		if _, ok := names[name]; ok {
+			depends = true
+		}
+		return nil
+	})
+	return depends
+}
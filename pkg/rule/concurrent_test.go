@@ -0,0 +1,131 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestConcurrentEvaluator(t *testing.T) {
+	newRecordingRule := func(name, expr string) rules.Rule {
+		e, err := promql.ParseExpr(expr)
+		testutil.Ok(t, err)
+		return rules.NewRecordingRule(name, e, nil)
+	}
+	newAlertingRule := func(name, expr string) rules.Rule {
+		e, err := promql.ParseExpr(expr)
+		testutil.Ok(t, err)
+		return rules.NewAlertingRule(name, e, 0, nil, nil, nil, false, log.NewNopLogger())
+	}
+
+	opts := &rules.ManagerOptions{Metrics: rules.NewGroupMetrics(prometheus.NewRegistry())}
+	groups := []*rules.Group{
+		rules.NewGroup("group1", "file", time.Minute, []rules.Rule{
+			newRecordingRule("a", "up"),
+			newRecordingRule("b", "sum(up)"),
+			newAlertingRule("alert", "count(up) > 1"),
+			// Consumes result of the alerting rule.
+			newRecordingRule("c", "ALERTS"),
+			// Consumes result of the previous rule.
+			newRecordingRule("d", "rate(c[5m])"),
+			newRecordingRule("e", "max(up)"),
+			// Could read any metric.
+			newRecordingRule("f", `{job="a"}`),
+			newRecordingRule("g", "min(up)"),
+		}, false, opts),
+		rules.NewGroup("group2", "file", time.Minute, []rules.Rule{
+			// Also used by group 3, so it is always evaluated on its own.
+			newRecordingRule("h", "sum by (job) (up)"),
+			newRecordingRule("i", "avg(up)"),
+			newRecordingRule("j", "stddev(up)"),
+		}, false, opts),
+		rules.NewGroup("group3", "file", time.Minute, []rules.Rule{
+			newRecordingRule("k", "sum by (job) (up)"),
+		}, false, opts),
+	}
+
+	var (
+		mtx     sync.Mutex
+		queries = map[string]int{}
+		release = make(chan struct{})
+	)
+	queryFn := func(ctx context.Context, q string, _ time.Time) (promql.Vector, error) {
+		mtx.Lock()
+		queries[q]++
+		mtx.Unlock()
+
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return promql.Vector{{Point: promql.Point{V: float64(len(q))}}}, nil
+	}
+
+	e := NewConcurrentEvaluator(queryFn, 2)
+	e.Update(groups)
+	testutil.Equals(t, map[string][]string{
+		"up":          {"up", "sum(up)", "count(up) > 1"},
+		"rate(c[5m])": {"rate(c[5m])", "max(up)"},
+		`{job="a"}`:   {`{job="a"}`, "min(up)"},
+		"avg(up)":     {"avg(up)", "stddev(up)"},
+	}, e.batches)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ts := time.Unix(100, 0)
+
+	// Queries of the whole batch are started once the first one is requested, limited by concurrency.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v, err := e.Query(ctx, "up", ts)
+		testutil.Ok(t, err)
+		testutil.Equals(t, float64(len("up")), v[0].V)
+	}()
+	testutil.Ok(t, runutil.Retry(10*time.Millisecond, ctx.Done(), func() error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if len(queries) != 2 {
+			return errors.Errorf("expected 2 queries in flight, got %v", queries)
+		}
+		return nil
+	}))
+	close(release)
+	<-done
+
+	for _, q := range []string{"sum(up)", "count(up) > 1", "max(up)"} {
+		v, err := e.Query(ctx, q, ts)
+		testutil.Ok(t, err)
+		testutil.Equals(t, float64(len(q)), v[0].V)
+	}
+	mtx.Lock()
+	testutil.Equals(t, map[string]int{"up": 1, "sum(up)": 1, "count(up) > 1": 1, "max(up)": 1}, queries)
+	mtx.Unlock()
+
+	// Prefetched results are used only for the same evaluation timestamp.
+	_, err := e.Query(ctx, "up", ts.Add(time.Minute))
+	testutil.Ok(t, err)
+	_, err = e.Query(ctx, "sum(up)", ts.Add(2*time.Minute))
+	testutil.Ok(t, err)
+	testutil.Ok(t, runutil.Retry(10*time.Millisecond, ctx.Done(), func() error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if queries["sum(up)"] != 3 {
+			return errors.Errorf("expected 3 sum(up) queries, got %v", queries)
+		}
+		return nil
+	}))
+}
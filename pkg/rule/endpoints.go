@@ -0,0 +1,137 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	http_util "github.com/thanos-io/thanos/pkg/http"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+// EndpointsHealth tracks the health of query API endpoints. Endpoints that recently failed a query or a
+// health check should be tried after healthy ones, so rule evaluation quickly fails over during an outage of
+// some query APIs, while unhealthy endpoints are still used as a last resort.
+type EndpointsHealth struct {
+	logger  log.Logger
+	backoff time.Duration
+
+	mtx            sync.Mutex
+	unhealthyUntil map[string]time.Time
+
+	failures *prometheus.CounterVec
+}
+
+// NewEndpointsHealth returns EndpointsHealth which keeps a failed endpoint unhealthy for the given backoff
+// duration, unless it passes a health check earlier.
+func NewEndpointsHealth(logger log.Logger, reg prometheus.Registerer, backoff time.Duration) *EndpointsHealth {
+	h := &EndpointsHealth{
+		logger:         logger,
+		backoff:        backoff,
+		unhealthyUntil: map[string]time.Time{},
+	}
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "thanos_rule_query_endpoints_unhealthy",
+		Help: "Number of query API endpoints currently considered unhealthy.",
+	}, func() float64 {
+		h.mtx.Lock()
+		defer h.mtx.Unlock()
+
+		var n int
+		now := time.Now()
+		for _, until := range h.unhealthyUntil {
+			if now.Before(until) {
+				n++
+			}
+		}
+		return float64(n)
+	})
+	h.failures = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_rule_query_endpoint_failures_total",
+		Help: "The number of failed queries and health checks against query API endpoints.",
+	}, []string{"reason"})
+	h.failures.WithLabelValues("query")
+	h.failures.WithLabelValues("health_check")
+	return h
+}
+
+// Healthy returns true if the endpoint is not considered unhealthy.
+func (h *EndpointsHealth) Healthy(u *url.URL) bool {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	until, ok := h.unhealthyUntil[u.String()]
+	return !ok || time.Now().After(until)
+}
+
+// QueryFailed marks endpoint as unhealthy after failed query.
+func (h *EndpointsHealth) QueryFailed(u *url.URL) {
+	h.failures.WithLabelValues("query").Inc()
+	h.markUnhealthy(u)
+}
+
+// QuerySucceeded marks endpoint as healthy.
+func (h *EndpointsHealth) QuerySucceeded(u *url.URL) {
+	h.markHealthy(u)
+}
+
+func (h *EndpointsHealth) markUnhealthy(u *url.URL) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.unhealthyUntil[u.String()] = time.Now().Add(h.backoff)
+}
+
+func (h *EndpointsHealth) markHealthy(u *url.URL) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	delete(h.unhealthyUntil, u.String())
+}
+
+// Check runs a health check against the /-/healthy endpoint of all endpoints known by the given client.
+func (h *EndpointsHealth) Check(ctx context.Context, c *http_util.Client) {
+	for _, u := range c.Endpoints() {
+		if err := h.check(ctx, c, u); err != nil {
+			level.Debug(h.logger).Log("msg", "query API health check failed", "endpoint", u.String(), "err", err)
+			h.failures.WithLabelValues("health_check").Inc()
+			h.markUnhealthy(u)
+			continue
+		}
+		h.markHealthy(u)
+	}
+}
+
+func (h *EndpointsHealth) check(ctx context.Context, c *http_util.Client, u *url.URL) (err error) {
+	hu := *u
+	hu.Path = path.Join(hu.Path, "/-/healthy")
+
+	req, err := http.NewRequest(http.MethodGet, hu.String(), nil)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.backoff)
+	defer cancel()
+
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer runutil.ExhaustCloseWithErrCapture(&err, resp.Body, "health check body")
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("got non-200 response code: %v", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	http_util "github.com/thanos-io/thanos/pkg/http"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+type staticProvider []string
+
+func (p staticProvider) Resolve(context.Context, []string) {}
+func (p staticProvider) Addresses() []string               { return p }
+
+func TestEndpointsHealth(t *testing.T) {
+	var healthy = true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "/prefix/-/healthy", r.URL.Path)
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	testutil.Ok(t, err)
+
+	c, err := http_util.NewClient(nil, http_util.EndpointsConfig{Scheme: "http", PathPrefix: "/prefix"}, http.DefaultClient, staticProvider{u.Host})
+	testutil.Ok(t, err)
+	endpoint := c.Endpoints()[0]
+
+	h := NewEndpointsHealth(log.NewNopLogger(), prometheus.NewRegistry(), time.Hour)
+	testutil.Assert(t, h.Healthy(endpoint), "unknown endpoint should be healthy")
+
+	h.QueryFailed(endpoint)
+	testutil.Assert(t, !h.Healthy(endpoint), "endpoint should be unhealthy after failed query")
+
+	// Passing health check brings the endpoint back before backoff expires.
+	h.Check(context.Background(), c)
+	testutil.Assert(t, h.Healthy(endpoint), "endpoint should be healthy after passed health check")
+
+	healthy = false
+	h.Check(context.Background(), c)
+	testutil.Assert(t, !h.Healthy(endpoint), "endpoint should be unhealthy after failed health check")
+
+	h.QuerySucceeded(endpoint)
+	testutil.Assert(t, h.Healthy(endpoint), "endpoint should be healthy after successful query")
+
+	// Unhealthy state expires after backoff.
+	h = NewEndpointsHealth(log.NewNopLogger(), prometheus.NewRegistry(), time.Millisecond)
+	h.QueryFailed(endpoint)
+	time.Sleep(10 * time.Millisecond)
+	testutil.Assert(t, h.Healthy(endpoint), "endpoint should be healthy after backoff")
+}
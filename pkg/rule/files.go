@@ -0,0 +1,240 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"gopkg.in/yaml.v2"
+)
+
+var ruleFileNameRe = regexp.MustCompile(`^[a-zA-Z0-9_\-.]+\.ya?ml$`)
+
+var (
+	// ErrRuleFileNotFound is returned when the managed rule file does not exist.
+	ErrRuleFileNotFound = errors.New("rule file not found")
+	// ErrInvalidRuleFileName is returned when the managed rule file name is not a plain YAML file name.
+	ErrInvalidRuleFileName = errors.New("invalid rule file name")
+)
+
+// ValidateRuleGroups parses and validates Thanos rule groups, the same way rules.Manager would on reload.
+func ValidateRuleGroups(b []byte) error {
+	// Unknown fields are rejected, as rules.Manager does.
+	var strict struct {
+		Groups []struct {
+			rulefmt.RuleGroup `yaml:",inline"`
+
+			PartialResponseStrategy string   `yaml:"partial_response_strategy"`
+			QueryConfigName         string   `yaml:"query_config_name"`
+			SourceTenants           []string `yaml:"source_tenants"`
+			QueryOffset             string   `yaml:"query_offset"`
+		} `yaml:"groups"`
+	}
+	if err := yaml.UnmarshalStrict(b, &strict); err != nil {
+		return err
+	}
+
+	var rgs RuleGroups
+	if err := yaml.Unmarshal(b, &rgs); err != nil {
+		return err
+	}
+
+	promRgs := rulefmt.RuleGroups{}
+	for _, g := range rgs.Groups {
+		promRgs.Groups = append(promRgs.Groups, g.RuleGroup)
+	}
+	var errs tsdberrors.MultiError
+	for _, err := range promRgs.Validate() {
+		errs.Add(err)
+	}
+	return errs.Err()
+}
+
+// FileStore persists rule files managed at runtime in a local directory, and optionally in a bucket under
+// the given prefix, so they survive the loss of the local directory.
+type FileStore struct {
+	logger log.Logger
+	dir    string
+	bkt    objstore.Bucket
+	prefix string
+
+	mtx sync.Mutex
+}
+
+// NewFileStore returns FileStore keeping files in the given directory. Bucket can be nil.
+func NewFileStore(logger log.Logger, dir string, bkt objstore.Bucket, prefix string) *FileStore {
+	return &FileStore{logger: logger, dir: dir, bkt: bkt, prefix: prefix}
+}
+
+// Dir returns the directory files are stored in.
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+// Sync creates the directory and downloads all files from the bucket to it, replacing local files.
+func (s *FileStore) Sync(ctx context.Context) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return errors.Wrapf(err, "create %s", s.dir)
+	}
+	if s.bkt == nil {
+		return nil
+	}
+
+	remote := map[string]struct{}{}
+	if err := s.bkt.Iter(ctx, s.prefix, func(name string) error {
+		fn := path.Base(name)
+		if !ruleFileNameRe.MatchString(fn) {
+			return nil
+		}
+		remote[fn] = struct{}{}
+		return objstore.DownloadFile(ctx, s.logger, s.bkt, name, filepath.Join(s.dir, fn))
+	}); err != nil {
+		return errors.Wrap(err, "download rule files")
+	}
+
+	local, err := s.list()
+	if err != nil {
+		return err
+	}
+	for _, fn := range local {
+		if _, ok := remote[fn]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, fn)); err != nil {
+			return errors.Wrapf(err, "remove %s", fn)
+		}
+	}
+	return nil
+}
+
+// List returns names of all stored files.
+func (s *FileStore) List() ([]string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.list()
+}
+
+func (s *FileStore) list() ([]string, error) {
+	fis, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s", s.dir)
+	}
+	var res []string
+	for _, fi := range fis {
+		if fi.IsDir() || !ruleFileNameRe.MatchString(fi.Name()) {
+			continue
+		}
+		res = append(res, fi.Name())
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// Get returns content of the given file or ErrRuleFileNotFound.
+func (s *FileStore) Get(name string) ([]byte, error) {
+	if err := validateRuleFileName(name); err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.get(name)
+}
+
+func (s *FileStore) get(name string) ([]byte, error) {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return nil, ErrRuleFileNotFound
+	}
+	return b, err
+}
+
+// Set atomically replaces content of the given file. It returns the previous content, or nil if the file
+// did not exist.
+func (s *FileStore) Set(ctx context.Context, name string, b []byte) ([]byte, error) {
+	if err := validateRuleFileName(name); err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	prev, err := s.get(name)
+	if err != nil && err != ErrRuleFileNotFound {
+		return nil, err
+	}
+
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return nil, errors.Wrapf(err, "write %s", tmp)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		return nil, errors.Wrapf(err, "rename %s", tmp)
+	}
+
+	if s.bkt != nil {
+		if err := s.bkt.Upload(ctx, path.Join(s.prefix, name), bytes.NewReader(b)); err != nil {
+			return nil, errors.Wrapf(err, "upload %s", name)
+		}
+	}
+	return prev, nil
+}
+
+// Delete removes the given file. It returns the previous content or ErrRuleFileNotFound.
+func (s *FileStore) Delete(ctx context.Context, name string) ([]byte, error) {
+	if err := validateRuleFileName(name); err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	prev, err := s.get(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+		return nil, errors.Wrapf(err, "remove %s", name)
+	}
+
+	if s.bkt != nil {
+		if err := s.bkt.Delete(ctx, path.Join(s.prefix, name)); err != nil && !s.bkt.IsObjNotFoundErr(err) {
+			return nil, errors.Wrapf(err, "delete %s from bucket", name)
+		}
+	}
+	return prev, nil
+}
+
+// Close closes the bucket, if any.
+func (s *FileStore) Close() error {
+	if s.bkt == nil {
+		return nil
+	}
+	return s.bkt.Close()
+}
+
+func validateRuleFileName(name string) error {
+	if !ruleFileNameRe.MatchString(name) {
+		return errors.Wrapf(ErrInvalidRuleFileName, "%q does not match %s", name, ruleFileNameRe.String())
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+)
+
+// MatrixQueryFunc evaluates the given range vector expression at the given time.
+type MatrixQueryFunc func(ctx context.Context, q string, t time.Time) (model.Matrix, error)
+
+// ForStateStorage is a storage.Storage that selects series via query APIs instead of the wrapped storage.
+// rules.Manager only selects ALERTS_FOR_STATE series to restore the `for` state of alerts after restart, and
+// query APIs have those even if the local storage was lost, or when the Ruler is stateless.
+type ForStateStorage struct {
+	storage.Storage
+
+	queryFn   MatrixQueryFunc
+	extLabels labels.Labels
+}
+
+// NewForStateStorage returns ForStateStorage appending to the given storage. Given external labels of the Ruler
+// are removed from selected series having the same label values, as rules.Manager expects series exactly as written by the rules.
+func NewForStateStorage(s storage.Storage, extLabels labels.Labels, queryFn MatrixQueryFunc) *ForStateStorage {
+	return &ForStateStorage{Storage: s, queryFn: queryFn, extLabels: extLabels}
+}
+
+// Querier returns a querier selecting series within the given time range via query APIs.
+func (s *ForStateStorage) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+	return &forStateQuerier{ctx: ctx, s: s, mint: mint, maxt: maxt}, nil
+}
+
+type forStateQuerier struct {
+	ctx        context.Context
+	s          *ForStateStorage
+	mint, maxt int64
+}
+
+func (q *forStateQuerier) Select(_ *storage.SelectParams, ms ...*labels.Matcher) (storage.SeriesSet, storage.Warnings, error) {
+	matchers := make([]string, 0, len(ms))
+	for _, m := range ms {
+		matchers = append(matchers, m.String())
+	}
+	// Range selector includes samples after maxt-range, so make sure mint is included.
+	rng := model.Duration(time.Duration(q.maxt-q.mint+1) * time.Millisecond)
+	expr := fmt.Sprintf("{%s}[%s]", strings.Join(matchers, ","), rng)
+
+	m, err := q.s.queryFn(q.ctx, expr, timestamp.Time(q.maxt))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "query %s", expr)
+	}
+
+	res := &prompb.QueryResult{Timeseries: make([]*prompb.TimeSeries, 0, len(m))}
+	for _, ss := range m {
+		ts := &prompb.TimeSeries{
+			Labels:  make([]prompb.Label, 0, len(ss.Metric)),
+			Samples: make([]prompb.Sample, 0, len(ss.Values)),
+		}
+		for n, v := range ss.Metric {
+			// Only external labels of this Ruler are removed. Series with other values, e.g. of other Rulers, are kept
+			// as they are, so they don't match the alerts of this Ruler.
+			if q.s.extLabels.Get(string(n)) == string(v) {
+				continue
+			}
+			ts.Labels = append(ts.Labels, prompb.Label{Name: string(n), Value: string(v)})
+		}
+		sort.Slice(ts.Labels, func(i, j int) bool {
+			return ts.Labels[i].Name < ts.Labels[j].Name
+		})
+		for _, v := range ss.Values {
+			ts.Samples = append(ts.Samples, prompb.Sample{Timestamp: int64(v.Timestamp), Value: float64(v.Value)})
+		}
+		res.Timeseries = append(res.Timeseries, ts)
+	}
+	return remote.FromQueryResult(res), nil, nil
+}
+
+func (q *forStateQuerier) LabelValues(string) ([]string, storage.Warnings, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
+func (q *forStateQuerier) LabelNames() ([]string, storage.Warnings, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
+func (q *forStateQuerier) Close() error { return nil }
@@ -0,0 +1,82 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestForStateStorage(t *testing.T) {
+	var queries []string
+	s := NewForStateStorage(nil, labels.FromStrings("replica", "a", "cluster", "eu"), func(_ context.Context, q string, ts time.Time) (model.Matrix, error) {
+		queries = append(queries, q)
+		testutil.Equals(t, time.Unix(3600, 0).UTC(), ts.UTC())
+		return model.Matrix{
+			{
+				Metric: model.Metric{"__name__": "ALERTS_FOR_STATE", "alertname": "B", "cluster": "eu"},
+				Values: []model.SamplePair{{Timestamp: 2000, Value: 3}},
+			},
+			{
+				Metric: model.Metric{"__name__": "ALERTS_FOR_STATE", "alertname": "A", "cluster": "eu"},
+				Values: []model.SamplePair{{Timestamp: 1000, Value: 1}, {Timestamp: 2000, Value: 1}},
+			},
+			{
+				Metric: model.Metric{"__name__": "ALERTS_FOR_STATE", "alertname": "A", "cluster": "us"},
+				Values: []model.SamplePair{{Timestamp: 1000, Value: 5}},
+			},
+		}, nil
+	})
+
+	q, err := s.Querier(context.Background(), 0, 3600*1000)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, q.Close()) }()
+
+	set, _, err := q.Select(nil,
+		labels.MustNewMatcher(labels.MatchEqual, "__name__", "ALERTS_FOR_STATE"),
+		labels.MustNewMatcher(labels.MatchEqual, "severity", `"page"`),
+	)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{`{__name__="ALERTS_FOR_STATE",severity="\"page\""}[3600001ms]`}, queries)
+
+	type series struct {
+		lset    labels.Labels
+		samples []model.SamplePair
+	}
+	var got []series
+	for set.Next() {
+		s := series{lset: set.At().Labels()}
+		it := set.At().Iterator()
+		for it.Next() {
+			ts, v := it.At()
+			s.samples = append(s.samples, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(v)})
+		}
+		testutil.Ok(t, it.Err())
+		got = append(got, s)
+	}
+	testutil.Ok(t, set.Err())
+	testutil.Equals(t, []series{
+		{
+			lset:    labels.FromStrings("__name__", "ALERTS_FOR_STATE", "alertname", "A"),
+			samples: []model.SamplePair{{Timestamp: 1000, Value: 1}, {Timestamp: 2000, Value: 1}},
+		},
+		// Series of other Rulers keep their external labels, so they are not restored as the state of this Ruler.
+		{
+			lset:    labels.FromStrings("__name__", "ALERTS_FOR_STATE", "alertname", "A", "cluster", "us"),
+			samples: []model.SamplePair{{Timestamp: 1000, Value: 5}},
+		},
+		{
+			lset:    labels.FromStrings("__name__", "ALERTS_FOR_STATE", "alertname", "B"),
+			samples: []model.SamplePair{{Timestamp: 2000, Value: 3}},
+		},
+	}, got)
+
+	var _ storage.Storage = s
+}
@@ -0,0 +1,97 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+)
+
+// EvaluationJitter delays evaluations of groups by a deterministic per-group duration up to the configured jitter,
+// so groups that would otherwise start evaluating at the same time don't hit query APIs all at once. Evaluation
+// timestamps are not changed. rules.Group evaluates its rules one by one, so the delay is applied when the query
+// of the first rule of the group is requested.
+type EvaluationJitter struct {
+	queryFn rules.QueryFunc
+	jitter  time.Duration
+
+	mtx    sync.Mutex
+	delays map[string]time.Duration
+}
+
+// NewEvaluationJitter returns EvaluationJitter delaying group evaluations by up to the given jitter before
+// using the given query function.
+func NewEvaluationJitter(queryFn rules.QueryFunc, jitter time.Duration) *EvaluationJitter {
+	return &EvaluationJitter{
+		queryFn: queryFn,
+		jitter:  jitter,
+		delays:  map[string]time.Duration{},
+	}
+}
+
+// Update assigns delays to the given groups. It has to be called after every update of the rules.Manager
+// using the jitter.
+func (j *EvaluationJitter) Update(groups []*rules.Group) {
+	// Queries are identified only by expression, so groups starting with an expression used by multiple rules
+	// can't be told apart and are not delayed.
+	count := map[string]int{}
+	for _, g := range groups {
+		for _, r := range g.Rules() {
+			if expr := ruleQuery(r); expr != nil {
+				count[expr.String()]++
+			}
+		}
+	}
+
+	delays := map[string]time.Duration{}
+	for _, g := range groups {
+		if len(g.Rules()) == 0 {
+			continue
+		}
+		expr := ruleQuery(g.Rules()[0])
+		if expr == nil || count[expr.String()] > 1 {
+			continue
+		}
+
+		// Delay at most by half of the interval so the evaluation does not spill into the next one.
+		maxDelay := j.jitter
+		if maxDelay > g.Interval()/2 {
+			maxDelay = g.Interval() / 2
+		}
+		if maxDelay <= 0 {
+			continue
+		}
+
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(g.File()))
+		_, _ = h.Write([]byte{0xff})
+		_, _ = h.Write([]byte(g.Name()))
+		delays[expr.String()] = time.Duration(h.Sum64() % uint64(maxDelay))
+	}
+
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	j.delays = delays
+}
+
+// Query implements rules.QueryFunc.
+func (j *EvaluationJitter) Query(ctx context.Context, q string, t time.Time) (promql.Vector, error) {
+	j.mtx.Lock()
+	delay := j.delays[q]
+	j.mtx.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return j.queryFn(ctx, q, t)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestEvaluationJitter(t *testing.T) {
+	newRecordingRule := func(name, expr string) rules.Rule {
+		e, err := promql.ParseExpr(expr)
+		testutil.Ok(t, err)
+		return rules.NewRecordingRule(name, e, nil)
+	}
+
+	opts := &rules.ManagerOptions{Metrics: rules.NewGroupMetrics(prometheus.NewRegistry())}
+	groups := []*rules.Group{
+		rules.NewGroup("group1", "file", time.Minute, []rules.Rule{
+			newRecordingRule("a", "up"),
+			newRecordingRule("b", "sum(up)"),
+		}, false, opts),
+		rules.NewGroup("group2", "file", time.Minute, []rules.Rule{
+			newRecordingRule("c", "max(up)"),
+		}, false, opts),
+		// Short interval limits the delay.
+		rules.NewGroup("group3", "file", 10*time.Millisecond, []rules.Rule{
+			newRecordingRule("d", "min(up)"),
+		}, false, opts),
+		// The first query is shared by other groups, so the group can't be identified.
+		rules.NewGroup("group4", "file", time.Minute, []rules.Rule{
+			newRecordingRule("e", "sum(up)"),
+		}, false, opts),
+	}
+
+	var queries []string
+	j := NewEvaluationJitter(func(_ context.Context, q string, _ time.Time) (promql.Vector, error) {
+		queries = append(queries, q)
+		return nil, nil
+	}, 30*time.Second)
+	j.Update(groups)
+
+	testutil.Equals(t, 3, len(j.delays))
+	for q, d := range j.delays {
+		testutil.Assert(t, d >= 0 && d < 30*time.Second, "unexpected delay %v of %s", d, q)
+	}
+	testutil.Assert(t, j.delays["min(up)"] < 5*time.Millisecond, "unexpected delay %v", j.delays["min(up)"])
+	_, ok := j.delays["sum(up)"]
+	testutil.Assert(t, !ok, "unexpected delay of sum(up)")
+
+	// Delays are deterministic.
+	delays := j.delays
+	j.Update(groups)
+	testutil.Equals(t, delays, j.delays)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := j.Query(ctx, "min(up)", time.Now())
+	testutil.Ok(t, err)
+	_, err = j.Query(ctx, "sum(up)", time.Now())
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"min(up)", "sum(up)"}, queries)
+
+	// Delayed query is canceled with the context.
+	j.delays["up"] = time.Hour
+	cancel()
+	_, err = j.Query(ctx, "up", time.Now())
+	testutil.NotOk(t, err)
+	testutil.Equals(t, []string{"min(up)", "sum(up)"}, queries)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// OffsetQueryFunc returns a query function evaluating queries in the past by the given offset. Results keep the
+// evaluation timestamp of the group, so all samples appended by the group, including stale markers and alerts
+// written by rules.Group itself, can be shifted by OffsetAppendable alike.
+func OffsetQueryFunc(queryFn rules.QueryFunc, offset time.Duration) rules.QueryFunc {
+	return func(ctx context.Context, q string, t time.Time) (promql.Vector, error) {
+		v, err := queryFn(ctx, q, t.Add(-offset))
+		if err != nil {
+			return nil, err
+		}
+		for i := range v {
+			v[i].T = timestamp.FromTime(t)
+		}
+		return v, nil
+	}
+}
+
+// OffsetAppendable is a rules.Appendable appending samples with timestamps shifted back by the given offset. Together
+// with OffsetQueryFunc, it shifts the whole evaluation of rule groups, so results, stale markers and alerts are
+// written at the time their queries were evaluated at.
+type OffsetAppendable struct {
+	rules.Appendable
+
+	offset int64
+}
+
+// NewOffsetAppendable returns OffsetAppendable appending to the given appendable.
+func NewOffsetAppendable(a rules.Appendable, offset time.Duration) *OffsetAppendable {
+	return &OffsetAppendable{Appendable: a, offset: int64(offset / time.Millisecond)}
+}
+
+// Appender returns an appender shifting timestamps of appended samples.
+func (a *OffsetAppendable) Appender() (storage.Appender, error) {
+	app, err := a.Appendable.Appender()
+	if err != nil {
+		return nil, err
+	}
+	return &offsetAppender{Appender: app, offset: a.offset}, nil
+}
+
+type offsetAppender struct {
+	storage.Appender
+
+	offset int64
+}
+
+func (a *offsetAppender) Add(l labels.Labels, t int64, v float64) (uint64, error) {
+	return a.Appender.Add(l, t-a.offset, v)
+}
+
+func (a *offsetAppender) AddFast(l labels.Labels, ref uint64, t int64, v float64) error {
+	return a.Appender.AddFast(l, ref, t-a.offset, v)
+}
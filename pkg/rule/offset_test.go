@@ -0,0 +1,118 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package thanosrule
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/pkg/value"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+type appendedSample struct {
+	lset labels.Labels
+	t    int64
+	v    float64
+}
+
+type testAppendable struct {
+	samples []appendedSample
+}
+
+func (a *testAppendable) Appender() (storage.Appender, error) { return &testAppender{a: a}, nil }
+
+type testAppender struct {
+	a       *testAppendable
+	pending []appendedSample
+}
+
+func (a *testAppender) Add(l labels.Labels, t int64, v float64) (uint64, error) {
+	a.pending = append(a.pending, appendedSample{lset: l, t: t, v: v})
+	return 0, nil
+}
+
+func (a *testAppender) AddFast(labels.Labels, uint64, int64, float64) error {
+	return storage.ErrNotFound
+}
+
+func (a *testAppender) Commit() error {
+	a.a.samples = append(a.a.samples, a.pending...)
+	a.pending = nil
+	return nil
+}
+
+func (a *testAppender) Rollback() error {
+	a.pending = nil
+	return nil
+}
+
+func TestOffset(t *testing.T) {
+	const offset = 5 * time.Minute
+
+	// The series is returned by the first evaluation only.
+	ts := time.Unix(3600, 0)
+	var queried []time.Time
+	app := &testAppendable{}
+	opts := &rules.ManagerOptions{
+		Logger:      log.NewNopLogger(),
+		Metrics:     rules.NewGroupMetrics(prometheus.NewRegistry()),
+		ExternalURL: &url.URL{},
+		NotifyFunc:  func(context.Context, string, ...*rules.Alert) {},
+		Appendable:  NewOffsetAppendable(app, offset),
+		QueryFunc: OffsetQueryFunc(func(_ context.Context, _ string, t time.Time) (promql.Vector, error) {
+			queried = append(queried, t)
+			if !t.Equal(ts.Add(-offset)) {
+				return nil, nil
+			}
+			return promql.Vector{{Point: promql.Point{T: timestamp.FromTime(t), V: 1}, Metric: labels.FromStrings("job", "a")}}, nil
+		}, offset),
+	}
+
+	expr, err := promql.ParseExpr("up")
+	testutil.Ok(t, err)
+	g := rules.NewGroup("group", "file", time.Minute, []rules.Rule{
+		rules.NewAlertingRule("Up", expr, 0, nil, nil, nil, true, nil),
+		rules.NewRecordingRule("job:up", expr, nil),
+	}, false, opts)
+
+	// The offset is longer than the interval, so the series disappearing in the second evaluation gets its stale
+	// marker after its sample, and both are written at the time queries were evaluated at.
+	g.Eval(context.Background(), ts)
+	g.Eval(context.Background(), ts.Add(time.Minute))
+	testutil.Equals(t, []time.Time{
+		ts.Add(-offset), ts.Add(-offset),
+		ts.Add(time.Minute - offset), ts.Add(time.Minute - offset),
+	}, queried)
+
+	alerts := labels.FromStrings("__name__", "ALERTS", "alertname", "Up", "alertstate", "firing", "job", "a")
+	forState := labels.FromStrings("__name__", "ALERTS_FOR_STATE", "alertname", "Up", "job", "a")
+	recorded := labels.FromStrings("__name__", "job:up", "job", "a")
+	evalMs := timestamp.FromTime(ts.Add(-offset))
+	staleMs := timestamp.FromTime(ts.Add(time.Minute - offset))
+
+	testutil.Equals(t, []appendedSample{
+		{lset: alerts, t: evalMs, v: 1},
+		// The value of the for state is the time the alert became active at.
+		{lset: forState, t: evalMs, v: float64(ts.Unix())},
+		{lset: recorded, t: evalMs, v: 1},
+	}, app.samples[:3])
+
+	testutil.Equals(t, 6, len(app.samples))
+	for i, lset := range []labels.Labels{alerts, forState, recorded} {
+		s := app.samples[3+i]
+		testutil.Equals(t, lset, s.lset)
+		testutil.Equals(t, staleMs, s.t)
+		testutil.Assert(t, value.IsStaleNaN(s.v), "expected stale marker of %s, got %v", s.lset, s.v)
+	}
+}
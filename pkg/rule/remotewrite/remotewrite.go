@@ -0,0 +1,99 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package remotewrite implements storage for the stateless Ruler. Instead of a local TSDB, evaluation
+// results are only persisted in a WAL which is tailed by Prometheus remote write queues.
+package remotewrite
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Config represents the remote write configuration of the stateless Ruler.
+type Config struct {
+	RemoteWriteConfigs []*config.RemoteWriteConfig `yaml:"remote_write"`
+}
+
+// LoadConfig loads the remote write configuration from YAML data.
+func LoadConfig(confYaml []byte) (Config, error) {
+	var cfg Config
+	if err := yaml.UnmarshalStrict(confYaml, &cfg); err != nil {
+		return cfg, err
+	}
+	if len(cfg.RemoteWriteConfigs) == 0 {
+		return cfg, errors.New("no remote_write endpoint configured")
+	}
+	return cfg, nil
+}
+
+// Storage is a storage.Storage that writes appended samples to a local WAL only. Samples
+// are forwarded from the WAL to all configured remote write endpoints. Querying is not supported.
+type Storage struct {
+	wal    *walStorage
+	remote *remote.Storage
+}
+
+// NewStorage opens (or creates) the WAL in the given directory and starts one remote write queue per
+// configured endpoint. Given labels are attached to every sample sent to remote write endpoints.
+func NewStorage(logger log.Logger, reg prometheus.Registerer, dir string, lset labels.Labels, cfg Config, flushDeadline time.Duration) (*Storage, error) {
+	w, err := newWALStorage(log.With(logger, "component", "wal"), reg, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "open WAL")
+	}
+
+	// The start time callback is only used for remote read, which we don't configure.
+	rs := remote.NewStorage(log.With(logger, "component", "remote"), reg, func() (int64, error) { return 0, nil }, dir, flushDeadline)
+
+	globalCfg := config.DefaultGlobalConfig
+	globalCfg.ExternalLabels = lset
+	if err := rs.ApplyConfig(&config.Config{
+		GlobalConfig:       globalCfg,
+		RemoteWriteConfigs: cfg.RemoteWriteConfigs,
+	}); err != nil {
+		var errs tsdberrors.MultiError
+		errs.Add(errors.Wrap(err, "apply remote write config"))
+		errs.Add(w.Close())
+		return nil, errs.Err()
+	}
+	return &Storage{wal: w, remote: rs}, nil
+}
+
+// Appender returns an appender that writes to the WAL.
+func (s *Storage) Appender() (storage.Appender, error) {
+	return s.wal.Appender(), nil
+}
+
+// Querier implements storage.Storage. Stateless storage never returns any series.
+func (s *Storage) Querier(context.Context, int64, int64) (storage.Querier, error) {
+	return storage.NoopQuerier(), nil
+}
+
+// StartTime implements storage.Storage.
+func (s *Storage) StartTime() (int64, error) {
+	return s.wal.StartTime(), nil
+}
+
+// Truncate removes all series that did not receive samples since mint from the WAL,
+// checkpointing older segments the same way as Prometheus TSDB head does.
+func (s *Storage) Truncate(mint int64) error {
+	return s.wal.Truncate(mint)
+}
+
+// Close stops remote write queues and closes the WAL.
+func (s *Storage) Close() error {
+	var errs tsdberrors.MultiError
+	errs.Add(s.remote.Close())
+	errs.Add(s.wal.Close())
+	return errs.Err()
+}
@@ -0,0 +1,339 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package remotewrite
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/tsdb/record"
+	"github.com/prometheus/prometheus/tsdb/wal"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestLoadConfig(t *testing.T) {
+	_, err := LoadConfig([]byte(`remote_write: []`))
+	testutil.NotOk(t, err)
+
+	_, err = LoadConfig([]byte(`remote_write:
+- url: http://localhost:10908/api/v1/receive
+  unknown_field: 1
+`))
+	testutil.NotOk(t, err)
+
+	cfg, err := LoadConfig([]byte(`remote_write:
+- url: http://localhost:10908/api/v1/receive
+  name: receive
+`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(cfg.RemoteWriteConfigs))
+	testutil.Equals(t, "receive", cfg.RemoteWriteConfigs[0].Name)
+	testutil.Equals(t, "http://localhost:10908/api/v1/receive", cfg.RemoteWriteConfigs[0].URL.String())
+}
+
+func TestWALStorage_ReplayAndTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_wal")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	s, err := newWALStorage(log.NewNopLogger(), nil, dir)
+	testutil.Ok(t, err)
+
+	app := s.Appender()
+	ref1, err := app.Add(labels.FromStrings("__name__", "a"), 10, 1)
+	testutil.Ok(t, err)
+	ref2, err := app.Add(labels.FromStrings("__name__", "b"), 20, 1)
+	testutil.Ok(t, err)
+	testutil.Assert(t, ref1 != ref2, "refs should differ")
+	testutil.NotOk(t, app.AddFast(nil, 1000, 30, 1))
+	testutil.Ok(t, app.Commit())
+
+	// Rolled back series should not be kept.
+	app = s.Appender()
+	_, err = app.Add(labels.FromStrings("__name__", "c"), 30, 1)
+	testutil.Ok(t, err)
+	testutil.Ok(t, app.Rollback())
+	testutil.Equals(t, 2, len(s.series))
+	testutil.Ok(t, s.Close())
+
+	// Reopening should restore series refs from the WAL.
+	s, err = newWALStorage(log.NewNopLogger(), nil, dir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(s.series))
+	testutil.Equals(t, int64(10), s.StartTime())
+
+	app = s.Appender()
+	ref, err := app.Add(labels.FromStrings("__name__", "a"), 40, 1)
+	testutil.Ok(t, err)
+	testutil.Equals(t, ref1, ref)
+	ref3, err := app.Add(labels.FromStrings("__name__", "d"), 40, 1)
+	testutil.Ok(t, err)
+	testutil.Assert(t, ref3 > ref2, "new ref should be greater than replayed one")
+	testutil.Ok(t, app.Commit())
+
+	// Series "b" did not get any sample after 20, so it should be gone.
+	testutil.Ok(t, s.Truncate(30))
+	testutil.Equals(t, 2, len(s.series))
+	_, ok := s.series[ref2]
+	testutil.Assert(t, !ok, "series b should be truncated")
+	testutil.Ok(t, s.Close())
+
+	s, err = newWALStorage(log.NewNopLogger(), nil, dir)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, s.Close()) }()
+	testutil.Equals(t, 2, len(s.series))
+}
+
+func TestWALStorage_PendingSeries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_wal")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	s, err := newWALStorage(log.NewNopLogger(), nil, dir)
+	testutil.Ok(t, err)
+
+	// Series created by one appender is used by another one committing first.
+	app1 := s.Appender()
+	ref, err := app1.Add(labels.FromStrings("__name__", "a"), 10, 1)
+	testutil.Ok(t, err)
+	app2 := s.Appender()
+	ref2, err := app2.Add(labels.FromStrings("__name__", "a"), 10, 1)
+	testutil.Ok(t, err)
+	testutil.Equals(t, ref, ref2)
+	testutil.Ok(t, app2.Commit())
+	testWALSeriesBeforeSamples(t, dir)
+
+	// Series without committed samples yet are not truncated.
+	app3 := s.Appender()
+	ref3, err := app3.Add(labels.FromStrings("__name__", "b"), 30, 1)
+	testutil.Ok(t, err)
+	testutil.Ok(t, s.Truncate(20))
+	_, ok := s.series[ref3]
+	testutil.Assert(t, ok, "pending series b should not be truncated")
+	testutil.Ok(t, app3.Commit())
+	testutil.Ok(t, app1.Commit())
+
+	testutil.Ok(t, s.Truncate(20))
+	testutil.Ok(t, s.Close())
+	testWALSeriesBeforeSamples(t, dir)
+
+	s, err = newWALStorage(log.NewNopLogger(), nil, dir)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, s.Close()) }()
+	_, ok = s.series[ref3]
+	testutil.Assert(t, ok, "series b should be replayed")
+}
+
+func TestWALStorage_ConcurrentAppendAndTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_wal")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	s, err := newWALStorage(log.NewNopLogger(), nil, dir)
+	testutil.Ok(t, err)
+
+	const (
+		appenders  = 4
+		iterations = 200
+	)
+	// Iteration each appender is at. Series of iterations all appenders are past are not appended to anymore, so
+	// they can be truncated.
+	var (
+		progress [appenders]int64
+		wg       sync.WaitGroup
+		done     = make(chan struct{})
+	)
+	for i := 0; i < appenders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for it := int64(0); it < iterations; it++ {
+				atomic.StoreInt64(&progress[i], it)
+
+				app := s.Appender()
+				// Appenders race for creating the shared series.
+				_, err := app.Add(labels.FromStrings("__name__", "shared", "it", strconv.FormatInt(it, 10)), it, 1)
+				testutil.Ok(t, err)
+				_, err = app.Add(labels.FromStrings("__name__", "own", "it", strconv.FormatInt(it, 10), "appender", strconv.Itoa(i)), it, 1)
+				testutil.Ok(t, err)
+				if it%5 == int64(i) {
+					testutil.Ok(t, app.Rollback())
+					continue
+				}
+				testutil.Ok(t, app.Commit())
+			}
+			atomic.StoreInt64(&progress[i], iterations)
+		}(i)
+	}
+	go func() {
+		defer close(done)
+		for last := int64(-1); last < iterations; {
+			mint := int64(iterations)
+			for i := range progress {
+				if p := atomic.LoadInt64(&progress[i]); p < mint {
+					mint = p
+				}
+			}
+			if mint == last {
+				runtime.Gosched()
+				continue
+			}
+			testutil.Ok(t, s.Truncate(mint))
+			last = mint
+		}
+	}()
+	wg.Wait()
+	<-done
+
+	testutil.Ok(t, s.Close())
+	testWALSeriesBeforeSamples(t, dir)
+}
+
+// testWALSeriesBeforeSamples checks all samples in the WAL in given directory come after the Series record of their
+// series, so they can be read by the remote write WAL watcher.
+func testWALSeriesBeforeSamples(t *testing.T, dir string) {
+	walDir := filepath.Join(dir, "wal")
+
+	var ranges []wal.SegmentRange
+	cpDir, idx, err := wal.LastCheckpoint(walDir)
+	if err != nil && err != record.ErrNotFound {
+		testutil.Ok(t, err)
+	}
+	first := 0
+	if err == nil {
+		ranges = append(ranges, wal.SegmentRange{Dir: cpDir, First: -1, Last: -1})
+		first = idx + 1
+	}
+	ranges = append(ranges, wal.SegmentRange{Dir: walDir, First: first, Last: -1})
+
+	sr, err := wal.NewSegmentsRangeReader(ranges...)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, sr.Close()) }()
+
+	var (
+		dec     record.Decoder
+		seen    = map[uint64]struct{}{}
+		samples int
+		r       = wal.NewReader(sr)
+	)
+	for r.Next() {
+		rec := r.Record()
+		switch dec.Type(rec) {
+		case record.Series:
+			series, err := dec.Series(rec, nil)
+			testutil.Ok(t, err)
+			for _, rs := range series {
+				seen[rs.Ref] = struct{}{}
+			}
+		case record.Samples:
+			smpls, err := dec.Samples(rec, nil)
+			testutil.Ok(t, err)
+			for _, smpl := range smpls {
+				_, ok := seen[smpl.Ref]
+				testutil.Assert(t, ok, "sample of series %d logged before its Series record", smpl.Ref)
+				samples++
+			}
+		}
+	}
+	testutil.Ok(t, r.Err())
+	testutil.Assert(t, samples > 0, "expected samples in WAL")
+}
+
+func TestStorage_RemoteWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_remote_write")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	var (
+		mtx      sync.Mutex
+		received []prompb.TimeSeries
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compressed, err := ioutil.ReadAll(r.Body)
+		testutil.Ok(t, err)
+		b, err := snappy.Decode(nil, compressed)
+		testutil.Ok(t, err)
+
+		var req prompb.WriteRequest
+		testutil.Ok(t, proto.Unmarshal(b, &req))
+
+		mtx.Lock()
+		received = append(received, req.Timeseries...)
+		mtx.Unlock()
+	}))
+	defer srv.Close()
+
+	cfg, err := LoadConfig([]byte(`remote_write:
+- url: ` + srv.URL + `
+  queue_config:
+    batch_send_deadline: 10ms
+`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, model.Duration(10*time.Millisecond), cfg.RemoteWriteConfigs[0].QueueConfig.BatchSendDeadline)
+
+	reg := prometheus.NewRegistry()
+	s, err := NewStorage(log.NewNopLogger(), reg, dir, labels.FromStrings("replica", "a"), cfg, time.Second)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, s.Close()) }()
+
+	mfs, err := reg.Gather()
+	testutil.Ok(t, err)
+	names := map[string]bool{}
+	for _, mf := range mfs {
+		names[mf.GetName()] = true
+	}
+	for _, n := range []string{
+		"thanos_rule_remote_write_wal_series",
+		"thanos_rule_remote_write_wal_samples_appended_total",
+		"thanos_rule_remote_write_wal_truncations_total",
+		"thanos_rule_remote_write_wal_truncations_failed_total",
+	} {
+		testutil.Assert(t, names[n], "metric %s not registered", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	testutil.Ok(t, runutil.Retry(100*time.Millisecond, ctx.Done(), func() error {
+		// WAL watcher only forwards samples newer than its start time, so keep appending until we see them.
+		app, err := s.Appender()
+		testutil.Ok(t, err)
+		_, err = app.Add(labels.FromStrings("__name__", "rule_result"), timestamp.FromTime(time.Now()), 1)
+		testutil.Ok(t, err)
+		testutil.Ok(t, app.Commit())
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		if len(received) == 0 {
+			return errors.New("no series received yet")
+		}
+		return nil
+	}))
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	testutil.Equals(t, []prompb.Label{
+		{Name: "__name__", Value: "rule_result"},
+		{Name: "replica", Value: "a"},
+	}, received[0].Labels)
+}
@@ -0,0 +1,378 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package remotewrite
+
+import (
+	"math"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/record"
+	"github.com/prometheus/prometheus/tsdb/wal"
+)
+
+type memSeries struct {
+	ref    uint64
+	lset   labels.Labels
+	lastTs int64
+	// logged is true once the Series record of the series is in the WAL. Until then, the series is pending and
+	// each appender using it logs the record along with its samples.
+	logged bool
+}
+
+type walMetrics struct {
+	series           prometheus.Gauge
+	samplesAppended  prometheus.Counter
+	truncations      prometheus.Counter
+	truncationErrors prometheus.Counter
+}
+
+func newWALMetrics(reg prometheus.Registerer) *walMetrics {
+	m := &walMetrics{}
+	m.series = promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_rule_remote_write_wal_series",
+		Help: "Number of series currently tracked by the stateless ruler WAL.",
+	})
+	m.samplesAppended = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "thanos_rule_remote_write_wal_samples_appended_total",
+		Help: "Total number of samples appended to the stateless ruler WAL.",
+	})
+	m.truncations = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "thanos_rule_remote_write_wal_truncations_total",
+		Help: "Total number of WAL truncations attempted.",
+	})
+	m.truncationErrors = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "thanos_rule_remote_write_wal_truncations_failed_total",
+		Help: "Total number of WAL truncations that failed.",
+	})
+	return m
+}
+
+// walStorage keeps just enough state in memory (series references) to write valid WAL records
+// that can be read by the remote write WAL watcher.
+type walStorage struct {
+	logger  log.Logger
+	wal     *wal.WAL
+	metrics *walMetrics
+
+	mtx     sync.RWMutex
+	nextRef uint64
+	series  map[uint64]*memSeries
+	hashes  map[uint64][]*memSeries
+	minTime int64
+}
+
+func newWALStorage(logger log.Logger, reg prometheus.Registerer, dir string) (*walStorage, error) {
+	w, err := wal.New(logger, reg, filepath.Join(dir, "wal"), true)
+	if err != nil {
+		return nil, err
+	}
+	s := &walStorage{
+		logger:  logger,
+		wal:     w,
+		metrics: newWALMetrics(reg),
+		series:  map[uint64]*memSeries{},
+		hashes:  map[uint64][]*memSeries{},
+		minTime: math.MaxInt64,
+	}
+	if err := s.replay(); err != nil {
+		level.Warn(logger).Log("msg", "failed to replay WAL, starting with empty series state", "err", err)
+		s.series = map[uint64]*memSeries{}
+		s.hashes = map[uint64][]*memSeries{}
+	}
+	s.metrics.series.Set(float64(len(s.series)))
+	return s, nil
+}
+
+// replay loads series references from the last checkpoint and all segments after it, so refs
+// written after restart stay consistent with refs already present in the WAL.
+func (s *walStorage) replay() error {
+	var ranges []wal.SegmentRange
+
+	dir, idx, err := wal.LastCheckpoint(s.wal.Dir())
+	if err != nil && err != record.ErrNotFound {
+		return errors.Wrap(err, "find last checkpoint")
+	}
+	first := 0
+	if err == nil {
+		ranges = append(ranges, wal.SegmentRange{Dir: dir, First: -1, Last: -1})
+		first = idx + 1
+	}
+	ranges = append(ranges, wal.SegmentRange{Dir: s.wal.Dir(), First: first, Last: -1})
+
+	sr, err := wal.NewSegmentsRangeReader(ranges...)
+	if err != nil {
+		return errors.Wrap(err, "open segments")
+	}
+	defer sr.Close()
+
+	var (
+		dec     record.Decoder
+		series  []record.RefSeries
+		samples []record.RefSample
+		r       = wal.NewReader(sr)
+	)
+	for r.Next() {
+		rec := r.Record()
+		switch dec.Type(rec) {
+		case record.Series:
+			series, err = dec.Series(rec, series[:0])
+			if err != nil {
+				return errors.Wrap(err, "decode series")
+			}
+			for _, rs := range series {
+				if _, ok := s.series[rs.Ref]; ok {
+					continue
+				}
+				s.addSeries(&memSeries{ref: rs.Ref, lset: rs.Labels, lastTs: math.MinInt64, logged: true})
+			}
+		case record.Samples:
+			samples, err = dec.Samples(rec, samples[:0])
+			if err != nil {
+				return errors.Wrap(err, "decode samples")
+			}
+			for _, smpl := range samples {
+				if ms, ok := s.series[smpl.Ref]; ok && smpl.T > ms.lastTs {
+					ms.lastTs = smpl.T
+				}
+				if smpl.T < s.minTime {
+					s.minTime = smpl.T
+				}
+			}
+		}
+	}
+	return r.Err()
+}
+
+// addSeries registers series. Caller must hold the write lock or have exclusive access.
+func (s *walStorage) addSeries(ms *memSeries) {
+	s.series[ms.ref] = ms
+	h := ms.lset.Hash()
+	s.hashes[h] = append(s.hashes[h], ms)
+	if ms.ref >= s.nextRef {
+		s.nextRef = ms.ref + 1
+	}
+}
+
+// deleteSeries removes series. Caller must hold the write lock.
+func (s *walStorage) deleteSeries(ref uint64, ms *memSeries) {
+	delete(s.series, ref)
+	h := ms.lset.Hash()
+	hs := s.hashes[h][:0]
+	for _, other := range s.hashes[h] {
+		if other != ms {
+			hs = append(hs, other)
+		}
+	}
+	if len(hs) == 0 {
+		delete(s.hashes, h)
+		return
+	}
+	s.hashes[h] = hs
+}
+
+func (s *walStorage) getByHash(h uint64, lset labels.Labels) *memSeries {
+	for _, ms := range s.hashes[h] {
+		if labels.Equal(ms.lset, lset) {
+			return ms
+		}
+	}
+	return nil
+}
+
+// getOrCreate returns the ref of series for given labels and true if the Series record of the series is not logged yet.
+func (s *walStorage) getOrCreate(lset labels.Labels) (uint64, bool) {
+	h := lset.Hash()
+
+	s.mtx.RLock()
+	if ms := s.getByHash(h, lset); ms != nil {
+		ref, logged := ms.ref, ms.logged
+		s.mtx.RUnlock()
+		return ref, !logged
+	}
+	s.mtx.RUnlock()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if ms := s.getByHash(h, lset); ms != nil {
+		return ms.ref, !ms.logged
+	}
+	ms := &memSeries{ref: s.nextRef, lset: lset, lastTs: math.MinInt64}
+	s.addSeries(ms)
+	s.metrics.series.Set(float64(len(s.series)))
+	return ms.ref, true
+}
+
+func (s *walStorage) Appender() storage.Appender {
+	return &appender{s: s}
+}
+
+func (s *walStorage) StartTime() int64 {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.minTime
+}
+
+func (s *walStorage) Truncate(mint int64) error {
+	s.metrics.truncations.Inc()
+	if err := s.truncate(mint); err != nil {
+		s.metrics.truncationErrors.Inc()
+		return err
+	}
+	return nil
+}
+
+func (s *walStorage) truncate(mint int64) error {
+	start := time.Now()
+
+	s.mtx.Lock()
+	for ref, ms := range s.series {
+		// Pending series are about to get their first samples.
+		if ms.lastTs >= mint || !ms.logged {
+			continue
+		}
+		s.deleteSeries(ref, ms)
+	}
+	s.minTime = mint
+	s.metrics.series.Set(float64(len(s.series)))
+	s.mtx.Unlock()
+
+	first, last, err := s.wal.Segments()
+	if err != nil {
+		return errors.Wrap(err, "get segment range")
+	}
+	// Start a new segment, so low ingestion volume WAL doesn't keep more data than needed.
+	if err := s.wal.NextSegment(); err != nil {
+		return errors.Wrap(err, "next segment")
+	}
+	// Never consider the last segment for checkpoint.
+	last--
+	if last < first {
+		return nil
+	}
+
+	keep := func(id uint64) bool {
+		s.mtx.RLock()
+		defer s.mtx.RUnlock()
+		_, ok := s.series[id]
+		return ok
+	}
+	if _, err := wal.Checkpoint(s.wal, first, last, keep, mint); err != nil {
+		return errors.Wrap(err, "create checkpoint")
+	}
+	if err := s.wal.Truncate(last + 1); err != nil {
+		// Leftover segments will be ignored in the future as the checkpoint supersedes them.
+		level.Error(s.logger).Log("msg", "truncating segments failed", "err", err)
+	}
+	if err := wal.DeleteCheckpoints(s.wal.Dir(), last); err != nil {
+		level.Error(s.logger).Log("msg", "delete old checkpoints", "err", err)
+	}
+	level.Info(s.logger).Log("msg", "WAL checkpoint complete", "first", first, "last", last, "duration", time.Since(start))
+	return nil
+}
+
+func (s *walStorage) Close() error {
+	return s.wal.Close()
+}
+
+type appender struct {
+	s       *walStorage
+	series  []record.RefSeries
+	samples []record.RefSample
+}
+
+func (a *appender) Add(l labels.Labels, t int64, v float64) (uint64, error) {
+	ref, pending := a.s.getOrCreate(l)
+	if pending {
+		a.series = append(a.series, record.RefSeries{Ref: ref, Labels: l})
+	}
+	a.samples = append(a.samples, record.RefSample{Ref: ref, T: t, V: v})
+	return ref, nil
+}
+
+func (a *appender) AddFast(_ labels.Labels, ref uint64, t int64, v float64) error {
+	a.s.mtx.RLock()
+	ms, ok := a.s.series[ref]
+	if ok && !ms.logged {
+		a.series = append(a.series, record.RefSeries{Ref: ref, Labels: ms.lset})
+	}
+	a.s.mtx.RUnlock()
+	if !ok {
+		return storage.ErrNotFound
+	}
+	a.samples = append(a.samples, record.RefSample{Ref: ref, T: t, V: v})
+	return nil
+}
+
+// Commit logs samples along with Series records of pending series they belong to. Records are logged under the write
+// lock, so no other appender can log samples of a series before its Series record is logged.
+func (a *appender) Commit() error {
+	var (
+		enc  record.Encoder
+		recs [][]byte
+	)
+	if len(a.series) > 0 {
+		recs = append(recs, enc.Series(a.series, nil))
+	}
+	if len(a.samples) > 0 {
+		recs = append(recs, enc.Samples(a.samples, nil))
+	}
+
+	a.s.mtx.Lock()
+	if len(recs) > 0 {
+		if err := a.s.wal.Log(recs...); err != nil {
+			a.rollback()
+			a.s.mtx.Unlock()
+			return errors.Wrap(err, "log to WAL")
+		}
+	}
+	for _, rs := range a.series {
+		if ms, ok := a.s.series[rs.Ref]; ok {
+			ms.logged = true
+		}
+	}
+	for _, smpl := range a.samples {
+		if ms, ok := a.s.series[smpl.Ref]; ok && smpl.T > ms.lastTs {
+			ms.lastTs = smpl.T
+		}
+		if smpl.T < a.s.minTime {
+			a.s.minTime = smpl.T
+		}
+	}
+	a.s.mtx.Unlock()
+	a.s.metrics.samplesAppended.Add(float64(len(a.samples)))
+
+	a.series = a.series[:0]
+	a.samples = a.samples[:0]
+	return nil
+}
+
+func (a *appender) Rollback() error {
+	a.s.mtx.Lock()
+	a.rollback()
+	a.s.mtx.Unlock()
+	return nil
+}
+
+// rollback drops series pending in this appender, as their Series records were never logged. Appenders using them
+// concurrently log the records along with their samples anyway. Caller must hold the write lock.
+func (a *appender) rollback() {
+	for _, rs := range a.series {
+		if ms, ok := a.s.series[rs.Ref]; ok && !ms.logged {
+			a.s.deleteSeries(rs.Ref, ms)
+		}
+	}
+	a.s.metrics.series.Set(float64(len(a.s.series)))
+
+	a.series = a.series[:0]
+	a.samples = a.samples[:0]
+}
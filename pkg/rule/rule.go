@@ -4,29 +4,41 @@
 package thanosrule
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/rules"
 	tsdberrors "github.com/prometheus/prometheus/tsdb/errors"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"gopkg.in/yaml.v2"
 )
 
-const tmpRuleDir = ".tmp-rules"
+const (
+	tmpRuleDir = ".tmp-rules"
+
+	// sourceTenantsSeparator joins source tenants of a group, both in manager keys and in the tenant header.
+	sourceTenantsSeparator = "|"
+)
 
 type Group struct {
 	*rules.Group
 	originalFile            string
 	PartialResponseStrategy storepb.PartialResponseStrategy
+	QueryConfigName         string
+	SourceTenants           []string
+	QueryOffset             time.Duration
 }
 
 func (g Group) OriginalFile() string {
@@ -36,6 +48,9 @@ func (g Group) OriginalFile() string {
 type AlertingRule struct {
 	*rules.AlertingRule
 	PartialResponseStrategy storepb.PartialResponseStrategy
+	QueryConfigName         string
+	SourceTenants           []string
+	QueryOffset             time.Duration
 }
 
 type RuleGroups struct {
@@ -45,37 +60,126 @@ type RuleGroups struct {
 type RuleGroup struct {
 	rulefmt.RuleGroup
 	PartialResponseStrategy *storepb.PartialResponseStrategy
+	// QueryConfigName is the name of the query configuration the group is evaluated against.
+	// Empty means all configured query APIs.
+	QueryConfigName string
+	// SourceTenants are the tenants the group queries data of. Empty means the tenant is not set on queries.
+	SourceTenants []string
+	// QueryOffset is the duration rules of the group are evaluated in the past by, to tolerate ingestion delay.
+	QueryOffset model.Duration
+}
+
+// managerKey identifies group properties that require a separate rules.Manager, since the query
+// function is common for all groups of a single rules.Manager.
+type managerKey struct {
+	strategy        storepb.PartialResponseStrategy
+	queryConfigName string
+	// sourceTenants are sorted tenants joined with sourceTenantsSeparator.
+	sourceTenants string
+	queryOffset   time.Duration
+}
+
+// dynamic returns true if the key requires a rules.Manager created on Update.
+func (k managerKey) dynamic() bool {
+	return k.sourceTenants != "" || k.queryOffset != 0
+}
+
+func (k managerKey) tenants() []string {
+	if k.sourceTenants == "" {
+		return nil
+	}
+	return strings.Split(k.sourceTenants, sourceTenantsSeparator)
+}
+
+// DynamicRuleManagerFunc creates rules.Manager for groups with given partial response strategy and query
+// configuration that query data of given source tenants, with given query offset.
+type DynamicRuleManagerFunc func(s storepb.PartialResponseStrategy, queryConfigName string, sourceTenants []string, queryOffset time.Duration) *rules.Manager
+
+// GroupQueryFunc is a query function of rules.Manager that depends on the groups the manager evaluates.
+type GroupQueryFunc interface {
+	// Query implements rules.QueryFunc.
+	Query(ctx context.Context, q string, t time.Time) (promql.Vector, error)
+	// Update is called with groups of the manager after every update of the manager.
+	Update(groups []*rules.Group)
 }
 
 type Manager struct {
 	workDir string
-	mgrs    map[storepb.PartialResponseStrategy]*rules.Manager
+	mgrs    map[managerKey]*rules.Manager
+
+	newDynamicMgr DynamicRuleManagerFunc
+
+	// groupQueryFuncs are guarded by separate mutex, so they can be set from DynamicRuleManagerFunc.
+	groupQueryFuncsMtx sync.Mutex
+	groupQueryFuncs    map[*rules.Manager][]GroupQueryFunc
 
 	mtx       sync.RWMutex
 	ruleFiles map[string]string
+	stopped   bool
 }
 
 func NewManager(dataDir string) *Manager {
 	return &Manager{
-		workDir:   filepath.Join(dataDir, tmpRuleDir),
-		mgrs:      make(map[storepb.PartialResponseStrategy]*rules.Manager),
-		ruleFiles: make(map[string]string),
+		workDir:         filepath.Join(dataDir, tmpRuleDir),
+		mgrs:            make(map[managerKey]*rules.Manager),
+		groupQueryFuncs: make(map[*rules.Manager][]GroupQueryFunc),
+		ruleFiles:       make(map[string]string),
 	}
 }
 
+// SetRuleManager sets the rules.Manager for groups with given partial response strategy that are evaluated
+// against all configured query APIs.
 func (m *Manager) SetRuleManager(s storepb.PartialResponseStrategy, mgr *rules.Manager) {
-	m.mgrs[s] = mgr
+	m.SetQueryConfigRuleManager(s, "", mgr)
+}
+
+// SetQueryConfigRuleManager sets the rules.Manager for groups with given partial response strategy that
+// select the query configuration with the given name.
+func (m *Manager) SetQueryConfigRuleManager(s storepb.PartialResponseStrategy, queryConfigName string, mgr *rules.Manager) {
+	m.mgrs[managerKey{strategy: s, queryConfigName: queryConfigName}] = mgr
+}
+
+// SetDynamicRuleManagerFunc sets the function creating rules.Manager for groups with source tenants or query offset.
+// Managers are created on Update, once for each distinct set of source tenants and query offset, and run until
+// Stop is called, or until an Update removes all their groups. Without it, such groups are rejected.
+func (m *Manager) SetDynamicRuleManagerFunc(f DynamicRuleManagerFunc) {
+	m.newDynamicMgr = f
+}
+
+// SetGroupQueryFuncs sets the query functions used by the given rules.Manager, so they are updated with
+// the manager's groups on every Update.
+func (m *Manager) SetGroupQueryFuncs(mgr *rules.Manager, fs ...GroupQueryFunc) {
+	m.groupQueryFuncsMtx.Lock()
+	defer m.groupQueryFuncsMtx.Unlock()
+
+	m.groupQueryFuncs[mgr] = fs
+}
+
+// Stop stops all rules.Manager created for groups with source tenants or query offset.
+func (m *Manager) Stop() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.stopped = true
+	for k, mgr := range m.mgrs {
+		if k.dynamic() {
+			mgr.Stop()
+		}
+	}
 }
 
 func (m *Manager) RuleGroups() []Group {
 	m.mtx.RLock()
 	defer m.mtx.RUnlock()
 	var res []Group
-	for s, r := range m.mgrs {
+	for k, r := range m.mgrs {
 		for _, group := range r.RuleGroups() {
 			res = append(res, Group{
 				Group:                   group,
-				PartialResponseStrategy: s,
+				PartialResponseStrategy: k.strategy,
+				QueryConfigName:         k.queryConfigName,
+				SourceTenants:           k.tenants(),
+				QueryOffset:             k.queryOffset,
 				originalFile:            m.ruleFiles[group.File()],
 			})
 		}
@@ -84,10 +188,18 @@ func (m *Manager) RuleGroups() []Group {
 }
 
 func (m *Manager) AlertingRules() []AlertingRule {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
 	var res []AlertingRule
-	for s, r := range m.mgrs {
+	for k, r := range m.mgrs {
 		for _, r := range r.AlertingRules() {
-			res = append(res, AlertingRule{AlertingRule: r, PartialResponseStrategy: s})
+			res = append(res, AlertingRule{
+				AlertingRule:            r,
+				PartialResponseStrategy: k.strategy,
+				QueryConfigName:         k.queryConfigName,
+				SourceTenants:           k.tenants(),
+				QueryOffset:             k.queryOffset,
+			})
 		}
 	}
 	return res
@@ -95,7 +207,10 @@ func (m *Manager) AlertingRules() []AlertingRule {
 
 func (r *RuleGroup) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	rs := struct {
-		String string `yaml:"partial_response_strategy"`
+		String          string         `yaml:"partial_response_strategy"`
+		QueryConfigName string         `yaml:"query_config_name"`
+		SourceTenants   []string       `yaml:"source_tenants"`
+		QueryOffset     model.Duration `yaml:"query_offset"`
 	}{}
 
 	errMsg := fmt.Sprintf("failed to unmarshal 'partial_response_strategy'. Possible values are %s", strings.Join(storepb.PartialResponseStrategyValues, ","))
@@ -118,9 +233,26 @@ func (r *RuleGroup) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		p = storepb.PartialResponseStrategy_value[storepb.PartialResponseStrategy_ABORT.String()]
 	}
 
+	if err := ValidateSourceTenants(rs.SourceTenants); err != nil {
+		return errors.Wrapf(err, "group %q", rg.Name)
+	}
+
 	ps := storepb.PartialResponseStrategy(p)
 	r.RuleGroup = rg
 	r.PartialResponseStrategy = &ps
+	r.QueryConfigName = rs.QueryConfigName
+	r.SourceTenants = rs.SourceTenants
+	r.QueryOffset = rs.QueryOffset
+	return nil
+}
+
+// ValidateSourceTenants returns an error if any of the tenants can't be passed in the tenant header of queries.
+func ValidateSourceTenants(tenants []string) error {
+	for _, t := range tenants {
+		if t == "" || strings.Contains(t, sourceTenantsSeparator) {
+			return errors.Errorf("invalid source tenant %q", t)
+		}
+	}
 	return nil
 }
 
@@ -134,20 +266,26 @@ func (r RuleGroup) MarshalYAML() (interface{}, error) {
 	rs := struct {
 		RuleGroup               rulefmt.RuleGroup `yaml:",inline"`
 		PartialResponseStrategy *string           `yaml:"partial_response_strategy,omitempty"`
+		QueryConfigName         string            `yaml:"query_config_name,omitempty"`
+		SourceTenants           []string          `yaml:"source_tenants,omitempty"`
+		QueryOffset             model.Duration    `yaml:"query_offset,omitempty"`
 	}{
 		RuleGroup:               r.RuleGroup,
 		PartialResponseStrategy: ps,
+		QueryConfigName:         r.QueryConfigName,
+		SourceTenants:           r.SourceTenants,
+		QueryOffset:             r.QueryOffset,
 	}
 	return rs, nil
 }
 
 // Update updates rules from given files to all managers we hold. We decide which groups should go where, based on
-// special field in RuleGroup file.
+// special fields in RuleGroup file.
 func (m *Manager) Update(evalInterval time.Duration, files []string) error {
 	var (
-		errs            tsdberrors.MultiError
-		filesByStrategy = map[storepb.PartialResponseStrategy][]string{}
-		ruleFiles       = map[string]string{}
+		errs       tsdberrors.MultiError
+		filesByKey = map[managerKey][]string{}
+		ruleFiles  = map[string]string{}
 	)
 
 	if err := os.RemoveAll(m.workDir); err != nil {
@@ -172,52 +310,135 @@ func (m *Manager) Update(evalInterval time.Duration, files []string) error {
 
 		// NOTE: This is very ugly, but we need to reparse it into tmp dir without the field to have to reuse
 		// rules.Manager. The problem is that it uses yaml.UnmarshalStrict for some reasons.
-		groupsByStrategy := map[storepb.PartialResponseStrategy]*rulefmt.RuleGroups{}
+		groupsByKey := map[managerKey]*rulefmt.RuleGroups{}
 		for _, rg := range rg.Groups {
-			if _, ok := groupsByStrategy[*rg.PartialResponseStrategy]; !ok {
-				groupsByStrategy[*rg.PartialResponseStrategy] = &rulefmt.RuleGroups{}
+			k := managerKey{
+				strategy:        *rg.PartialResponseStrategy,
+				queryConfigName: rg.QueryConfigName,
+				sourceTenants:   sourceTenantsKey(rg.SourceTenants),
+				queryOffset:     time.Duration(rg.QueryOffset),
+			}
+			if _, ok := groupsByKey[k]; !ok {
+				groupsByKey[k] = &rulefmt.RuleGroups{}
 			}
 
-			groupsByStrategy[*rg.PartialResponseStrategy].Groups = append(
-				groupsByStrategy[*rg.PartialResponseStrategy].Groups,
-				rg.RuleGroup,
-			)
+			groupsByKey[k].Groups = append(groupsByKey[k].Groups, rg.RuleGroup)
 		}
 
-		for s, rg := range groupsByStrategy {
+		for k, rg := range groupsByKey {
 			b, err := yaml.Marshal(rg)
 			if err != nil {
 				errs = append(errs, errors.Wrapf(err, "%s: failed to marshal rule groups", fn))
 				continue
 			}
 
-			newFn := filepath.Join(m.workDir, fmt.Sprintf("%s.%x.%s", filepath.Base(fn), sha256.Sum256([]byte(fn)), s.String()))
+			suffix := k.strategy.String()
+			if k.queryConfigName != "" {
+				suffix += "." + k.queryConfigName
+			}
+			if k.sourceTenants != "" {
+				// Tenant names can contain characters not allowed in file names.
+				suffix += fmt.Sprintf(".%x", sha256.Sum256([]byte(k.sourceTenants)))
+			}
+			if k.queryOffset != 0 {
+				suffix += "." + model.Duration(k.queryOffset).String()
+			}
+			newFn := filepath.Join(m.workDir, fmt.Sprintf("%s.%x.%s", filepath.Base(fn), sha256.Sum256([]byte(fn)), suffix))
 			if err := ioutil.WriteFile(newFn, b, os.ModePerm); err != nil {
 				errs = append(errs, errors.Wrap(err, newFn))
 				continue
 			}
 
-			filesByStrategy[s] = append(filesByStrategy[s], newFn)
+			filesByKey[k] = append(filesByKey[k], newFn)
 			ruleFiles[newFn] = fn
 		}
 	}
 
 	m.mtx.Lock()
-	for s, fs := range filesByStrategy {
-		mgr, ok := m.mgrs[s]
-		if !ok {
-			errs = append(errs, errors.Errorf("no manager found for %v", s))
+	for k, fs := range filesByKey {
+		if _, ok := m.mgrs[k]; ok {
+			continue
+		}
+		_, queryConfigFound := m.mgrs[managerKey{strategy: k.strategy, queryConfigName: k.queryConfigName}]
+		if k.dynamic() && queryConfigFound {
+			if m.newDynamicMgr == nil {
+				errs = append(errs, errors.Errorf("source tenants and query offset are not supported, got %v and %v", k.tenants(), k.queryOffset))
+				continue
+			}
+			if m.stopped {
+				continue
+			}
+			mgr := m.newDynamicMgr(k.strategy, k.queryConfigName, k.tenants(), k.queryOffset)
+			mgr.Run()
+			m.mgrs[k] = mgr
 			continue
 		}
+		if k.queryConfigName != "" {
+			var origFiles []string
+			for _, f := range fs {
+				origFiles = append(origFiles, ruleFiles[f])
+			}
+			errs = append(errs, errors.Errorf("no query configuration named %q found, referenced by groups in %s", k.queryConfigName, strings.Join(origFiles, ",")))
+			continue
+		}
+		errs = append(errs, errors.Errorf("no manager found for %v", k.strategy))
+	}
+	// Managers created on Update whose groups were removed from all files are stopped and removed, so they don't
+	// keep running for sets of source tenants and query offsets no longer used.
+	for k, mgr := range m.mgrs {
+		if !k.dynamic() || len(filesByKey[k]) > 0 {
+			continue
+		}
+		mgr.Stop()
+		delete(m.mgrs, k)
+
+		m.groupQueryFuncsMtx.Lock()
+		delete(m.groupQueryFuncs, mgr)
+		m.groupQueryFuncsMtx.Unlock()
+	}
+	// Managers without any files are updated as well, so groups removed from all files are stopped.
+	// The same manager can be set for multiple keys, so collect files per manager first.
+	filesByMgr := map[*rules.Manager][]string{}
+	strategiesByMgr := map[*rules.Manager]storepb.PartialResponseStrategy{}
+	for k, mgr := range m.mgrs {
+		filesByMgr[mgr] = append(filesByMgr[mgr], filesByKey[k]...)
+		strategiesByMgr[mgr] = k.strategy
+	}
+	for mgr, fs := range filesByMgr {
 		// We add external labels in `pkg/alert.Queue`.
 		// TODO(bwplotka): Investigate if we should put ext labels here or not.
 		if err := mgr.Update(evalInterval, fs, nil); err != nil {
-			errs = append(errs, errors.Wrapf(err, "strategy %s", s))
+			errs = append(errs, errors.Wrapf(err, "strategy %s", strategiesByMgr[mgr]))
 			continue
 		}
+
+		m.groupQueryFuncsMtx.Lock()
+		queryFuncs := m.groupQueryFuncs[mgr]
+		m.groupQueryFuncsMtx.Unlock()
+		for _, f := range queryFuncs {
+			f.Update(mgr.RuleGroups())
+		}
 	}
 	m.ruleFiles = ruleFiles
 	m.mtx.Unlock()
 
 	return errs.Err()
 }
+
+// sourceTenantsKey returns sorted, deduplicated tenants joined with sourceTenantsSeparator, so groups
+// with the same set of tenants share a manager.
+func sourceTenantsKey(tenants []string) string {
+	if len(tenants) == 0 {
+		return ""
+	}
+	uniq := map[string]struct{}{}
+	for _, t := range tenants {
+		uniq[t] = struct{}{}
+	}
+	res := make([]string, 0, len(uniq))
+	for t := range uniq {
+		res = append(res, t)
+	}
+	sort.Strings(res)
+	return strings.Join(res, sourceTenantsSeparator)
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/pkg/rulefmt"
 	"github.com/prometheus/prometheus/promql"
@@ -225,6 +226,235 @@ groups:
 	}
 }
 
+func TestUpdate_QueryConfigName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_query_config")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(`
+groups:
+- name: "default"
+  rules:
+  - alert: "some"
+    expr: "up"
+- name: "frontend"
+  query_config_name: "frontend"
+  rules:
+  - alert: "some"
+    expr: "up"
+- name: "frontend warn"
+  partial_response_strategy: "warn"
+  query_config_name: "frontend"
+  rules:
+  - alert: "some"
+    expr: "up"
+`), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "unknown.yaml"), []byte(`
+groups:
+- name: "unknown"
+  query_config_name: "unknown"
+  rules:
+  - alert: "some"
+    expr: "up"
+`), os.ModePerm))
+
+	opts := rules.ManagerOptions{
+		Logger:    log.NewLogfmtLogger(os.Stderr),
+		Context:   context.Background(),
+		QueryFunc: func(context.Context, string, time.Time) (promql.Vector, error) { return nil, nil },
+	}
+	m := NewManager(dir)
+	for _, name := range []string{"", "frontend"} {
+		for _, s := range []storepb.PartialResponseStrategy{storepb.PartialResponseStrategy_ABORT, storepb.PartialResponseStrategy_WARN} {
+			// Managers have to run, otherwise replaced groups never stop.
+			mgr := rules.NewManager(&opts)
+			mgr.Run()
+			defer mgr.Stop()
+			m.SetQueryConfigRuleManager(s, name, mgr)
+		}
+	}
+
+	err = m.Update(10*time.Second, []string{filepath.Join(dir, "rules.yaml"), filepath.Join(dir, "unknown.yaml")})
+	testutil.NotOk(t, err)
+	testutil.Assert(t, strings.Contains(err.Error(), `no query configuration named "unknown" found`), err.Error())
+
+	g := m.RuleGroups()
+	sort.Slice(g, func(i, j int) bool {
+		return g[i].Name() < g[j].Name()
+	})
+	testutil.Equals(t, 3, len(g))
+	testutil.Equals(t, "default", g[0].Name())
+	testutil.Equals(t, "", g[0].QueryConfigName)
+	testutil.Equals(t, "frontend", g[1].Name())
+	testutil.Equals(t, "frontend", g[1].QueryConfigName)
+	testutil.Equals(t, storepb.PartialResponseStrategy_ABORT, g[1].PartialResponseStrategy)
+	testutil.Equals(t, "frontend warn", g[2].Name())
+	testutil.Equals(t, "frontend", g[2].QueryConfigName)
+	testutil.Equals(t, storepb.PartialResponseStrategy_WARN, g[2].PartialResponseStrategy)
+
+	// Groups removed from files are removed from their managers as well.
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(`
+groups:
+- name: "default"
+  rules:
+  - alert: "some"
+    expr: "up"
+`), os.ModePerm))
+	testutil.Ok(t, m.Update(10*time.Second, []string{filepath.Join(dir, "rules.yaml")}))
+	g = m.RuleGroups()
+	testutil.Equals(t, 1, len(g))
+	testutil.Equals(t, "default", g[0].Name())
+}
+
+func TestUpdate_SourceTenantsAndQueryOffset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rule_source_tenants")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(`
+groups:
+- name: "default"
+  rules:
+  - record: "some"
+    expr: "up"
+- name: "platform"
+  source_tenants: ["team-b", "team-a"]
+  rules:
+  - record: "some"
+    expr: "up"
+- name: "platform 2"
+  source_tenants: ["team-a", "team-b", "team-a"]
+  rules:
+  - record: "some"
+    expr: "up"
+- name: "team-a"
+  source_tenants: ["team-a"]
+  rules:
+  - record: "some"
+    expr: "up"
+- name: "team-a delayed"
+  source_tenants: ["team-a"]
+  query_offset: 1m
+  rules:
+  - record: "some"
+    expr: "up"
+`), os.ModePerm))
+
+	opts := rules.ManagerOptions{
+		Logger:    log.NewLogfmtLogger(os.Stderr),
+		Context:   context.Background(),
+		QueryFunc: func(context.Context, string, time.Time) (promql.Vector, error) { return nil, nil },
+	}
+	m := NewManager(dir)
+	for _, s := range []storepb.PartialResponseStrategy{storepb.PartialResponseStrategy_ABORT, storepb.PartialResponseStrategy_WARN} {
+		mgr := rules.NewManager(&opts)
+		mgr.Run()
+		defer mgr.Stop()
+		m.SetRuleManager(s, mgr)
+	}
+
+	// Without manager func, groups with source tenants or query offset are rejected.
+	testutil.NotOk(t, m.Update(10*time.Second, []string{filepath.Join(dir, "rules.yaml")}))
+
+	type dynamicMgr struct {
+		sourceTenants []string
+		queryOffset   time.Duration
+	}
+	var created []dynamicMgr
+	m.SetDynamicRuleManagerFunc(func(s storepb.PartialResponseStrategy, name string, sourceTenants []string, queryOffset time.Duration) *rules.Manager {
+		testutil.Equals(t, storepb.PartialResponseStrategy_ABORT, s)
+		testutil.Equals(t, "", name)
+		created = append(created, dynamicMgr{sourceTenants: sourceTenants, queryOffset: queryOffset})
+		return rules.NewManager(&opts)
+	})
+	defer m.Stop()
+
+	testutil.Ok(t, m.Update(10*time.Second, []string{filepath.Join(dir, "rules.yaml")}))
+	// Reloading the same files reuses existing managers.
+	testutil.Ok(t, m.Update(10*time.Second, []string{filepath.Join(dir, "rules.yaml")}))
+	sort.Slice(created, func(i, j int) bool {
+		if len(created[i].sourceTenants) != len(created[j].sourceTenants) {
+			return len(created[i].sourceTenants) < len(created[j].sourceTenants)
+		}
+		return created[i].queryOffset < created[j].queryOffset
+	})
+	testutil.Equals(t, []dynamicMgr{
+		{sourceTenants: []string{"team-a"}},
+		{sourceTenants: []string{"team-a"}, queryOffset: time.Minute},
+		{sourceTenants: []string{"team-a", "team-b"}},
+	}, created)
+
+	g := m.RuleGroups()
+	sort.Slice(g, func(i, j int) bool {
+		return g[i].Name() < g[j].Name()
+	})
+	testutil.Equals(t, 5, len(g))
+	testutil.Equals(t, "default", g[0].Name())
+	testutil.Equals(t, []string(nil), g[0].SourceTenants)
+	testutil.Equals(t, "platform", g[1].Name())
+	testutil.Equals(t, []string{"team-a", "team-b"}, g[1].SourceTenants)
+	testutil.Equals(t, "platform 2", g[2].Name())
+	testutil.Equals(t, []string{"team-a", "team-b"}, g[2].SourceTenants)
+	testutil.Equals(t, "team-a", g[3].Name())
+	testutil.Equals(t, []string{"team-a"}, g[3].SourceTenants)
+	testutil.Equals(t, time.Duration(0), g[3].QueryOffset)
+	testutil.Equals(t, "team-a delayed", g[4].Name())
+	testutil.Equals(t, []string{"team-a"}, g[4].SourceTenants)
+	testutil.Equals(t, time.Minute, g[4].QueryOffset)
+
+	// Managers whose groups were all removed are stopped and removed, and created again once groups are added back.
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(`
+groups:
+- name: "default"
+  rules:
+  - record: "some"
+    expr: "up"
+- name: "team-a"
+  source_tenants: ["team-a"]
+  rules:
+  - record: "some"
+    expr: "up"
+`), os.ModePerm))
+	testutil.Ok(t, m.Update(10*time.Second, []string{filepath.Join(dir, "rules.yaml")}))
+	testutil.Equals(t, 3, len(created))
+	testutil.Equals(t, 3, len(m.mgrs))
+
+	g = m.RuleGroups()
+	sort.Slice(g, func(i, j int) bool {
+		return g[i].Name() < g[j].Name()
+	})
+	testutil.Equals(t, 2, len(g))
+	testutil.Equals(t, "default", g[0].Name())
+	testutil.Equals(t, "team-a", g[1].Name())
+
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(`
+groups:
+- name: "platform"
+  source_tenants: ["team-a", "team-b"]
+  rules:
+  - record: "some"
+    expr: "up"
+`), os.ModePerm))
+	testutil.Ok(t, m.Update(10*time.Second, []string{filepath.Join(dir, "rules.yaml")}))
+	testutil.Equals(t, 4, len(created))
+	testutil.Equals(t, dynamicMgr{sourceTenants: []string{"team-a", "team-b"}}, created[3])
+	testutil.Equals(t, 3, len(m.mgrs))
+
+	g = m.RuleGroups()
+	testutil.Equals(t, 1, len(g))
+	testutil.Equals(t, "platform", g[0].Name())
+
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte(`
+groups:
+- name: "invalid"
+  source_tenants: ["team-a|team-b"]
+  rules:
+  - record: "some"
+    expr: "up"
+`), os.ModePerm))
+	testutil.NotOk(t, m.Update(10*time.Second, []string{filepath.Join(dir, "invalid.yaml")}))
+}
+
 func TestRuleGroupMarshalYAML(t *testing.T) {
 	const expected = `groups:
 - name: something1
@@ -236,6 +466,11 @@ func TestRuleGroupMarshalYAML(t *testing.T) {
   - alert: some
     expr: rate(some_metric[1h:5m] offset 1d)
   partial_response_strategy: ABORT
+  query_config_name: frontend
+  source_tenants:
+  - team-a
+  - team-b
+  query_offset: 1m
 `
 
 	a := storepb.PartialResponseStrategy_ABORT
@@ -263,6 +498,9 @@ func TestRuleGroupMarshalYAML(t *testing.T) {
 					},
 				},
 				PartialResponseStrategy: &a,
+				QueryConfigName:         "frontend",
+				SourceTenants:           []string{"team-a", "team-b"},
+				QueryOffset:             model.Duration(time.Minute),
 			},
 		},
 	}
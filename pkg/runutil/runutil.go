@@ -80,6 +80,23 @@ func Repeat(interval time.Duration, stopc <-chan struct{}, f func() error) error
 	}
 }
 
+// RepeatDynamic executes f until stopc is closed or f returns an error, waiting the interval returned by interval
+// after each execution. It executes f once right after being called.
+func RepeatDynamic(interval func() time.Duration, stopc <-chan struct{}, f func() error) error {
+	for {
+		if err := f(); err != nil {
+			return err
+		}
+		t := time.NewTimer(interval())
+		select {
+		case <-stopc:
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
 // Retry executes f every interval seconds until timeout or no error is returned from f.
 func Retry(interval time.Duration, stopc <-chan struct{}, f func() error) error {
 	return RetryWithLog(log.NewNopLogger(), interval, stopc, f)
@@ -0,0 +1,165 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package grpc
+
+import (
+	"context"
+	"crypto/x509"
+	"path"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+)
+
+// healthService is the gRPC health service, which can be called by any peer so probes keep working.
+const healthService = "grpc.health.v1.Health"
+
+// PeerAllowlistRule allows peers with matching client certificates to call services.
+type PeerAllowlistRule struct {
+	// Services are patterns of full names of allowed gRPC services, e.g. "thanos.Store". All services are allowed if
+	// empty.
+	Services []string `yaml:"services"`
+	// URIs are patterns of allowed URI SANs, e.g. SPIFFE IDs.
+	URIs []string `yaml:"uris"`
+	// DNSNames are patterns of allowed DNS name SANs.
+	DNSNames []string `yaml:"dns_names"`
+}
+
+// PeerAllowlistConfig is the configuration of gRPC peer allowlisting.
+type PeerAllowlistConfig struct {
+	Rules []PeerAllowlistRule `yaml:"rules"`
+}
+
+// PeerAllowlist allows calls of gRPC services only by peers whose client certificate matches a rule for the service.
+type PeerAllowlist struct {
+	logger log.Logger
+	rules  []PeerAllowlistRule
+
+	denied *prometheus.CounterVec
+}
+
+// NewPeerAllowlist parses the YAML peer allowlist configuration and returns a peer allowlist.
+func NewPeerAllowlist(logger log.Logger, reg prometheus.Registerer, conf []byte) (*PeerAllowlist, error) {
+	var config PeerAllowlistConfig
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing gRPC peer allowlist config")
+	}
+	if len(config.Rules) == 0 {
+		return nil, errors.New("no rules configured")
+	}
+	for i, r := range config.Rules {
+		if len(r.URIs) == 0 && len(r.DNSNames) == 0 {
+			return nil, errors.Errorf("rule %d allows no URIs or DNS names", i)
+		}
+		for _, p := range append(append(append([]string{}, r.Services...), r.URIs...), r.DNSNames...) {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid pattern %s of rule %d", p, i)
+			}
+		}
+	}
+
+	return &PeerAllowlist{
+		logger: logger,
+		rules:  config.Rules,
+		denied: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_grpc_peer_denied_total",
+			Help: "Total number of gRPC calls denied because the client certificate of the peer is not allowed to call the service.",
+		}, []string{"service"}),
+	}, nil
+}
+
+// matchAny returns true if any of the values matches any of the patterns.
+func matchAny(patterns, values []string) bool {
+	for _, p := range patterns {
+		for _, v := range values {
+			// Patterns are validated on creation, so matching cannot fail.
+			if ok, _ := path.Match(p, v); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowed returns true if the certificate matches any rule of the service.
+func (a *PeerAllowlist) allowed(service string, cert *x509.Certificate) bool {
+	uris := uriStrings(cert)
+	for _, r := range a.rules {
+		if len(r.Services) > 0 && !matchAny(r.Services, []string{service}) {
+			continue
+		}
+		if matchAny(r.URIs, uris) || matchAny(r.DNSNames, cert.DNSNames) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize returns a PermissionDenied error if the peer of the call of the method is not allowed.
+func (a *PeerAllowlist) authorize(ctx context.Context, fullMethod string) error {
+	// Full method names have the form "/<service>/<method>".
+	service := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(service, "/"); i >= 0 {
+		service = service[:i]
+	}
+	if service == healthService {
+		return nil
+	}
+
+	var cert *x509.Certificate
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			cert = info.State.PeerCertificates[0]
+		}
+	}
+	if cert == nil {
+		a.denied.WithLabelValues(service).Inc()
+		level.Debug(a.logger).Log("msg", "gRPC call without client certificate denied", "method", fullMethod)
+		return status.Error(codes.PermissionDenied, "client certificate required")
+	}
+	if !a.allowed(service, cert) {
+		a.denied.WithLabelValues(service).Inc()
+		level.Debug(a.logger).Log("msg", "gRPC call denied by peer allowlist", "method", fullMethod, "uris", strings.Join(uriStrings(cert), ","), "dns_names", strings.Join(cert.DNSNames, ","))
+		return status.Errorf(codes.PermissionDenied, "peer not allowed to call %s", service)
+	}
+	return nil
+}
+
+func uriStrings(cert *x509.Certificate) []string {
+	uris := make([]string, 0, len(cert.URIs))
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+	return uris
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor denying unary calls of peers not allowed.
+func (a *PeerAllowlist) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC interceptor denying streaming calls of peers not allowed.
+func (a *PeerAllowlist) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
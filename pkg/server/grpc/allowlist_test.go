@@ -0,0 +1,95 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func peerContext(cert *x509.Certificate) context.Context {
+	p := &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}}
+	if cert != nil {
+		p.AuthInfo = credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}}
+	}
+	return peer.NewContext(context.Background(), p)
+}
+
+func TestPeerAllowlist(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	a, err := NewPeerAllowlist(log.NewNopLogger(), reg, []byte(`
+rules:
+  - services: [thanos.Store]
+    uris: ["spiffe://example.org/ns/monitoring/sa/*"]
+  - services: [thanos.WriteableStore]
+    dns_names: ["*.receive.monitoring.svc"]
+  - uris: ["spiffe://example.org/ns/monitoring/sa/admin"]
+`))
+	testutil.Ok(t, err)
+
+	mustParseURL := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		testutil.Ok(t, err)
+		return u
+	}
+	querier := &x509.Certificate{URIs: []*url.URL{mustParseURL("spiffe://example.org/ns/monitoring/sa/querier")}}
+	admin := &x509.Certificate{URIs: []*url.URL{mustParseURL("spiffe://example.org/ns/monitoring/sa/admin")}}
+	other := &x509.Certificate{URIs: []*url.URL{mustParseURL("spiffe://example.org/ns/default/sa/querier")}}
+	receive := &x509.Certificate{DNSNames: []string{"thanos-receive-0.receive.monitoring.svc"}}
+
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	interceptor := a.UnaryServerInterceptor()
+	for _, tcase := range []struct {
+		name   string
+		cert   *x509.Certificate
+		method string
+		denied bool
+	}{
+		{name: "matching URI", cert: querier, method: "/thanos.Store/Series"},
+		{name: "URI of other service", cert: querier, method: "/thanos.WriteableStore/RemoteWrite", denied: true},
+		{name: "URI of all services", cert: admin, method: "/thanos.WriteableStore/RemoteWrite"},
+		{name: "wildcards do not match path separators", cert: other, method: "/thanos.Store/Series", denied: true},
+		{name: "matching DNS name", cert: receive, method: "/thanos.WriteableStore/RemoteWrite"},
+		{name: "DNS name of other service", cert: receive, method: "/thanos.Store/Series", denied: true},
+		{name: "no client certificate", method: "/thanos.Store/Series", denied: true},
+		{name: "health checks are always allowed", method: "/grpc.health.v1.Health/Check"},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := interceptor(peerContext(tcase.cert), nil, &grpc.UnaryServerInfo{FullMethod: tcase.method}, handler)
+			if tcase.denied {
+				testutil.Equals(t, codes.PermissionDenied, status.Code(err))
+				return
+			}
+			testutil.Ok(t, err)
+		})
+	}
+	testutil.Equals(t, 3.0, promtest.ToFloat64(a.denied.WithLabelValues("thanos.Store")))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(a.denied.WithLabelValues("thanos.WriteableStore")))
+}
+
+func TestNewPeerAllowlist(t *testing.T) {
+	for _, conf := range []string{
+		"rules: []",
+		"rules: [{services: [thanos.Store]}]",
+		"rules: [{uris: ['[']}]",
+		"unknown: 1",
+	} {
+		_, err := NewPeerAllowlist(log.NewNopLogger(), nil, []byte(conf))
+		testutil.NotOk(t, err)
+	}
+}
@@ -62,18 +62,35 @@ func New(logger log.Logger, reg prometheus.Registerer, tracer opentracing.Tracer
 		return status.Errorf(codes.Internal, "%s", p)
 	}
 
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		met.UnaryServerInterceptor(),
+		tracing.UnaryServerInterceptor(tracer),
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		met.StreamServerInterceptor(),
+		tracing.StreamServerInterceptor(tracer),
+	}
+	// Requests are logged outside of panic recovery, so recovered requests are logged with their error.
+	if options.requestLogger != nil {
+		unaryInterceptors = append(unaryInterceptors, options.requestLogger.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, options.requestLogger.StreamServerInterceptor())
+	}
+	unaryInterceptors = append(unaryInterceptors, grpc_recovery.UnaryServerInterceptor(grpc_recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)))
+	streamInterceptors = append(streamInterceptors, grpc_recovery.StreamServerInterceptor(grpc_recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)))
+
+	if options.peerAllowlist != nil {
+		unaryInterceptors = append(unaryInterceptors, options.peerAllowlist.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, options.peerAllowlist.StreamServerInterceptor())
+	}
+	if options.rateLimiter != nil {
+		unaryInterceptors = append(unaryInterceptors, options.rateLimiter.UnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, options.rateLimiter.StreamServerInterceptor())
+	}
+
 	grpcOpts := []grpc.ServerOption{
 		grpc.MaxSendMsgSize(math.MaxInt32),
-		grpc_middleware.WithUnaryServerChain(
-			met.UnaryServerInterceptor(),
-			tracing.UnaryServerInterceptor(tracer),
-			grpc_recovery.UnaryServerInterceptor(grpc_recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
-		),
-		grpc_middleware.WithStreamServerChain(
-			met.StreamServerInterceptor(),
-			tracing.StreamServerInterceptor(tracer),
-			grpc_recovery.StreamServerInterceptor(grpc_recovery.WithRecoveryHandler(grpcPanicRecoveryHandler)),
-		),
+		grpc_middleware.WithUnaryServerChain(unaryInterceptors...),
+		grpc_middleware.WithStreamServerChain(streamInterceptors...),
 	}
 
 	if options.tlsConfig != nil {
@@ -81,7 +98,13 @@ func New(logger log.Logger, reg prometheus.Registerer, tracer opentracing.Tracer
 	}
 	s := grpc.NewServer(grpcOpts...)
 
+	if options.zone != "" {
+		storeSrv = &zoneStoreServer{StoreServer: storeSrv, zone: options.zone}
+	}
 	storepb.RegisterStoreServer(s, storeSrv)
+	if options.statusSrv != nil {
+		storepb.RegisterStatusServer(s, options.statusSrv)
+	}
 	met.InitializeMetrics(s)
 	reg.MustRegister(met)
 
@@ -136,6 +159,22 @@ func (s *Server) Shutdown(err error) {
 	}
 }
 
+// zoneStoreServer is a StoreServer advertising an availability zone in its Info responses.
+type zoneStoreServer struct {
+	storepb.StoreServer
+
+	zone string
+}
+
+func (s *zoneStoreServer) Info(ctx context.Context, r *storepb.InfoRequest) (*storepb.InfoResponse, error) {
+	resp, err := s.StoreServer.Info(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	resp.Zone = s.zone
+	return resp, nil
+}
+
 // ReadWriteStoreServer is a StoreServer and a WriteableStoreServer.
 type ReadWriteStoreServer interface {
 	storepb.StoreServer
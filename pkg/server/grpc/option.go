@@ -6,13 +6,22 @@ package grpc
 import (
 	"crypto/tls"
 	"time"
+
+	"github.com/thanos-io/thanos/pkg/logging"
+	"github.com/thanos-io/thanos/pkg/ratelimit"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
 )
 
 type options struct {
 	gracePeriod time.Duration
 	listen      string
 
-	tlsConfig *tls.Config
+	tlsConfig     *tls.Config
+	rateLimiter   *ratelimit.Limiter
+	peerAllowlist *PeerAllowlist
+	requestLogger *logging.RequestLogger
+	statusSrv     storepb.StatusServer
+	zone          string
 }
 
 // Option overrides behavior of Server.
@@ -48,3 +57,40 @@ func WithTLSConfig(cfg *tls.Config) Option {
 		o.tlsConfig = cfg
 	})
 }
+
+// WithRateLimiter sets the limiter of the rate of requests to the gRPC server.
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return optionFunc(func(o *options) {
+		o.rateLimiter = l
+	})
+}
+
+// WithPeerAllowlist sets the allowlist of peers allowed to call services of the gRPC server. It requires client
+// certificates to be verified by TLS configuration.
+func WithPeerAllowlist(a *PeerAllowlist) Option {
+	return optionFunc(func(o *options) {
+		o.peerAllowlist = a
+	})
+}
+
+// WithStatusServer sets the server of the optional Status API, served next to the StoreAPI.
+func WithStatusServer(srv storepb.StatusServer) Option {
+	return optionFunc(func(o *options) {
+		o.statusSrv = srv
+	})
+}
+
+// WithRequestLogging sets the logger of requests served by the gRPC server, logging them as decided by its rules.
+func WithRequestLogging(l *logging.RequestLogger) Option {
+	return optionFunc(func(o *options) {
+		o.requestLogger = l
+	})
+}
+
+// WithZone sets the availability zone advertised by the Info API of the StoreAPI, so queriers in the same zone can
+// prefer the server over replicas in other zones.
+func WithZone(zone string) Option {
+	return optionFunc(func(o *options) {
+		o.zone = zone
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	authMethodBasic  = "basic"
+	authMethodBearer = "bearer"
+	authMethodNone   = "none"
+
+	// maxBasicAuthCacheSize is the maximum number of successfully verified credentials cached, so bcrypt hashes are
+	// not computed on every request.
+	maxBasicAuthCacheSize = 1024
+)
+
+// DefaultAuthExemptPaths are paths served without authentication, if not configured otherwise.
+var DefaultAuthExemptPaths = []string{"/metrics", "/-/healthy", "/-/ready"}
+
+// AuthConfig is the configuration of HTTP authentication. Requests are authenticated by any of the configured methods.
+type AuthConfig struct {
+	// BasicAuthUsers maps usernames to bcrypt hashes of their passwords.
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users"`
+	// OIDC configures validation of bearer tokens issued by an OpenID Connect provider.
+	OIDC *OIDCConfig `yaml:"oidc"`
+	// ExemptPaths are paths served without authentication. Paths ending with "/" exempt all paths with such prefix.
+	// Defaults to DefaultAuthExemptPaths if not set.
+	ExemptPaths []string `yaml:"exempt_paths"`
+}
+
+// OIDCConfig is the configuration of OpenID Connect ID token validation.
+type OIDCConfig struct {
+	// IssuerURL is the URL of the provider, which has to match the issuer of tokens. Keys verifying tokens are
+	// discovered from it.
+	IssuerURL string `yaml:"issuer_url"`
+	// ClientID is the audience tokens have to be issued for.
+	ClientID string `yaml:"client_id"`
+	// JWKSURL is the URL of keys verifying tokens. If empty, it is discovered from the provider configuration.
+	JWKSURL string `yaml:"jwks_url"`
+	// UsernameClaim is the claim used as username in logs. Defaults to "sub".
+	UsernameClaim string `yaml:"username_claim"`
+}
+
+// Authenticator is an HTTP middleware authenticating requests by basic auth or OIDC bearer tokens.
+type Authenticator struct {
+	logger     log.Logger
+	basicUsers map[string][]byte
+	oidc       *oidcVerifier
+	exempt     []string
+
+	// Cache of hashes of verified basic auth credentials.
+	basicCacheMtx sync.Mutex
+	basicCache    map[[sha256.Size]byte]struct{}
+
+	failures *prometheus.CounterVec
+}
+
+// NewAuthenticator parses the YAML authentication configuration and returns an authenticator.
+func NewAuthenticator(logger log.Logger, reg prometheus.Registerer, conf []byte) (*Authenticator, error) {
+	var config AuthConfig
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing HTTP authentication config")
+	}
+	return NewAuthenticatorWithConfig(logger, reg, config)
+}
+
+// NewAuthenticatorWithConfig returns an authenticator with the given configuration.
+func NewAuthenticatorWithConfig(logger log.Logger, reg prometheus.Registerer, config AuthConfig) (*Authenticator, error) {
+	if len(config.BasicAuthUsers) == 0 && config.OIDC == nil {
+		return nil, errors.New("no authentication method configured")
+	}
+
+	a := &Authenticator{
+		logger:     logger,
+		basicUsers: make(map[string][]byte, len(config.BasicAuthUsers)),
+		exempt:     config.ExemptPaths,
+		basicCache: map[[sha256.Size]byte]struct{}{},
+		failures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_http_authentication_failures_total",
+			Help: "Total number of HTTP requests rejected because of failed authentication.",
+		}, []string{"method"}),
+	}
+	if a.exempt == nil {
+		a.exempt = DefaultAuthExemptPaths
+	}
+	for user, hash := range config.BasicAuthUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, errors.Wrapf(err, "invalid bcrypt hash of password of user %s", user)
+		}
+		a.basicUsers[user] = []byte(hash)
+	}
+	if config.OIDC != nil {
+		v, err := newOIDCVerifier(*config.OIDC)
+		if err != nil {
+			return nil, errors.Wrap(err, "OIDC")
+		}
+		a.oidc = v
+	}
+	for _, m := range []string{authMethodBasic, authMethodBearer, authMethodNone} {
+		a.failures.WithLabelValues(m)
+	}
+	return a, nil
+}
+
+// Handler returns a handler calling next for authenticated requests and requests of exempt paths only.
+func (a *Authenticator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isExemptPath(a.exempt, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		method, user, err := a.authenticate(r)
+		if err != nil {
+			a.failures.WithLabelValues(method).Inc()
+			level.Debug(a.logger).Log("msg", "HTTP authentication failed", "method", method, "path", r.URL.Path, "err", err)
+
+			if len(a.basicUsers) > 0 {
+				w.Header().Add("WWW-Authenticate", `Basic realm="thanos"`)
+			}
+			if a.oidc != nil {
+				w.Header().Add("WWW-Authenticate", `Bearer realm="thanos"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		level.Debug(a.logger).Log("msg", "HTTP request authenticated", "method", method, "user", user, "path", r.URL.Path)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, user)))
+	})
+}
+
+type userContextKey struct{}
+
+// UserFromContext returns the user authenticated by the Authenticator, if the request was authenticated.
+func UserFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userContextKey{}).(string)
+	return user, ok
+}
+
+// isExemptPath returns true if the path is one of the exempt paths, or has an exempt prefix ending with "/".
+func isExemptPath(exempt []string, path string) bool {
+	for _, p := range exempt {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate returns the authentication method used by the request and the authenticated user.
+func (a *Authenticator) authenticate(r *http.Request) (method string, user string, err error) {
+	auth := r.Header.Get("Authorization")
+	switch {
+	case auth == "":
+		return authMethodNone, "", errors.New("no credentials")
+	case len(auth) > len("basic ") && strings.EqualFold(auth[:len("basic ")], "basic "):
+		if len(a.basicUsers) == 0 {
+			return authMethodBasic, "", errors.New("basic auth not enabled")
+		}
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			return authMethodBasic, "", errors.New("malformed credentials")
+		}
+		return authMethodBasic, user, a.verifyBasic(user, password)
+	case len(auth) > len("bearer ") && strings.EqualFold(auth[:len("bearer ")], "bearer "):
+		if a.oidc == nil {
+			return authMethodBearer, "", errors.New("bearer tokens not enabled")
+		}
+		user, err := a.oidc.verify(r.Context(), strings.TrimSpace(auth[len("bearer "):]))
+		return authMethodBearer, user, err
+	default:
+		return authMethodNone, "", errors.New("unsupported authorization scheme")
+	}
+}
+
+func (a *Authenticator) verifyBasic(user, password string) error {
+	hash, ok := a.basicUsers[user]
+	if !ok {
+		return errors.Errorf("unknown user %s", user)
+	}
+
+	key := sha256.Sum256([]byte(user + ":" + password))
+	a.basicCacheMtx.Lock()
+	_, cached := a.basicCache[key]
+	a.basicCacheMtx.Unlock()
+	if cached {
+		return nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+		return errors.Errorf("wrong password of user %s", user)
+	}
+
+	a.basicCacheMtx.Lock()
+	if len(a.basicCache) >= maxBasicAuthCacheSize {
+		a.basicCache = map[[sha256.Size]byte]struct{}{}
+	}
+	a.basicCache[key] = struct{}{}
+	a.basicCacheMtx.Unlock()
+	return nil
+}
@@ -0,0 +1,317 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNewAuthenticator(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	testutil.Ok(t, err)
+
+	for _, tcase := range []struct {
+		name string
+		conf string
+		err  bool
+	}{
+		{
+			name: "basic auth",
+			conf: "basic_auth_users:\n  admin: " + string(hash),
+		},
+		{
+			name: "oidc",
+			conf: "oidc:\n  issuer_url: https://issuer.example.com\n  client_id: thanos",
+		},
+		{
+			name: "no method",
+			conf: "exempt_paths: [/metrics]",
+			err:  true,
+		},
+		{
+			name: "invalid hash",
+			conf: "basic_auth_users:\n  admin: secret",
+			err:  true,
+		},
+		{
+			name: "oidc without client id",
+			conf: "oidc:\n  issuer_url: https://issuer.example.com",
+			err:  true,
+		},
+		{
+			name: "unknown field",
+			conf: "basic_auth_user:\n  admin: " + string(hash),
+			err:  true,
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			_, err := NewAuthenticator(log.NewNopLogger(), nil, []byte(tcase.conf))
+			if tcase.err {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+		})
+	}
+}
+
+func TestAuthenticator_BasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	testutil.Ok(t, err)
+
+	a, err := NewAuthenticatorWithConfig(log.NewNopLogger(), prometheus.NewRegistry(), AuthConfig{
+		BasicAuthUsers: map[string]string{"admin": string(hash)},
+		ExemptPaths:    []string{"/metrics", "/public/"},
+	})
+	testutil.Ok(t, err)
+	srv := httptest.NewServer(a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	defer srv.Close()
+
+	for _, tcase := range []struct {
+		name     string
+		path     string
+		user     string
+		password string
+		token    string
+		expected int
+	}{
+		{name: "valid credentials", path: "/api/v1/query", user: "admin", password: "secret", expected: http.StatusOK},
+		{name: "valid credentials again", path: "/api/v1/query", user: "admin", password: "secret", expected: http.StatusOK},
+		{name: "wrong password", path: "/api/v1/query", user: "admin", password: "wrong", expected: http.StatusUnauthorized},
+		{name: "unknown user", path: "/api/v1/query", user: "other", password: "secret", expected: http.StatusUnauthorized},
+		{name: "no credentials", path: "/api/v1/query", expected: http.StatusUnauthorized},
+		{name: "bearer token not enabled", path: "/api/v1/query", token: "token", expected: http.StatusUnauthorized},
+		{name: "exempt path", path: "/metrics", expected: http.StatusOK},
+		{name: "exempt prefix", path: "/public/status", expected: http.StatusOK},
+		{name: "health check not exempt", path: "/-/healthy", expected: http.StatusUnauthorized},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+tcase.path, nil)
+			testutil.Ok(t, err)
+			if tcase.user != "" {
+				req.SetBasicAuth(tcase.user, tcase.password)
+			}
+			if tcase.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tcase.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			testutil.Ok(t, err)
+			testutil.Ok(t, resp.Body.Close())
+			testutil.Equals(t, tcase.expected, resp.StatusCode)
+			if tcase.expected == http.StatusUnauthorized {
+				testutil.Equals(t, `Basic realm="thanos"`, resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+	testutil.Equals(t, 2.0, promtest.ToFloat64(a.failures.WithLabelValues(authMethodBasic)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(a.failures.WithLabelValues(authMethodBearer)))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(a.failures.WithLabelValues(authMethodNone)))
+}
+
+func TestAuthenticator_OIDC(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	testutil.Ok(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testutil.Ok(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	testutil.Ok(t, err)
+
+	jwksRequests := 0
+	mux := http.NewServeMux()
+	provider := httptest.NewServer(mux)
+	defer provider.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": provider.URL, "jwks_uri": provider.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		jwksRequests++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{
+				"kid": "rsa", "kty": "RSA", "use": "sig",
+				"n": encodeSegment(rsaKey.N.Bytes()),
+				"e": encodeSegment(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kid": "ec", "kty": "EC", "crv": "P-256",
+				"x": encodeSegment(ecKey.X.Bytes()),
+				"y": encodeSegment(ecKey.Y.Bytes()),
+			},
+		}})
+	})
+
+	a, err := NewAuthenticatorWithConfig(log.NewNopLogger(), nil, AuthConfig{
+		OIDC: &OIDCConfig{IssuerURL: provider.URL, ClientID: "thanos", UsernameClaim: "email"},
+	})
+	testutil.Ok(t, err)
+	now := time.Now()
+	a.oidc.now = func() time.Time { return now }
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   provider.URL,
+			"aud":   []string{"other", "thanos"},
+			"sub":   "1234",
+			"email": "user@example.com",
+			"exp":   now.Add(time.Hour).Unix(),
+		}
+	}
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		c := validClaims()
+		if value == nil {
+			delete(c, name)
+			return c
+		}
+		c[name] = value
+		return c
+	}
+
+	for _, tcase := range []struct {
+		name   string
+		token  string
+		user   string
+		errMsg string
+	}{
+		{name: "RS256", token: signJWT(t, "RS256", "rsa", rsaKey, validClaims()), user: "user@example.com"},
+		{name: "ES256", token: signJWT(t, "ES256", "ec", ecKey, validClaims()), user: "user@example.com"},
+		{name: "RS512 and single audience", token: signJWT(t, "RS512", "rsa", rsaKey, withClaim("aud", "thanos")), user: "user@example.com"},
+		{name: "expiry within clock skew", token: signJWT(t, "RS256", "rsa", rsaKey, withClaim("exp", now.Add(-10*time.Second).Unix())), user: "user@example.com"},
+		{name: "signed by other key", token: signJWT(t, "RS256", "rsa", otherKey, validClaims()), errMsg: "invalid token signature"},
+		{name: "algorithm not matching key", token: signJWT(t, "ES256", "rsa", ecKey, validClaims()), errMsg: "algorithm ES256 does not match RSA key"},
+		{name: "algorithm not matching key curve", token: signJWT(t, "ES384", "ec", ecKey, validClaims()), errMsg: "algorithm ES384 does not match EC key of curve P-256"},
+		{name: "unknown key", token: signJWT(t, "RS256", "other", otherKey, validClaims()), errMsg: `unknown key "other"`},
+		{name: "unsigned", token: encodeJSONSegment(t, map[string]string{"alg": "none", "kid": "rsa"}) + "." + encodeJSONSegment(t, validClaims()) + ".", errMsg: `unsupported signing algorithm "none"`},
+		{name: "wrong issuer", token: signJWT(t, "RS256", "rsa", rsaKey, withClaim("iss", "https://other.example.com")), errMsg: `unexpected issuer "https://other.example.com"`},
+		{name: "wrong audience", token: signJWT(t, "RS256", "rsa", rsaKey, withClaim("aud", "other")), errMsg: "token not issued for client thanos"},
+		{name: "expired", token: signJWT(t, "RS256", "rsa", rsaKey, withClaim("exp", now.Add(-time.Minute).Unix())), errMsg: "token expired"},
+		{name: "no expiry", token: signJWT(t, "RS256", "rsa", rsaKey, withClaim("exp", nil)), errMsg: "token without expiry"},
+		{name: "not valid yet", token: signJWT(t, "RS256", "rsa", rsaKey, withClaim("nbf", now.Add(time.Minute).Unix())), errMsg: "token not valid yet"},
+		{name: "no username", token: signJWT(t, "RS256", "rsa", rsaKey, withClaim("email", nil)), errMsg: "no email claim in token"},
+		{name: "malformed", token: "token", errMsg: "malformed token"},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/query", nil)
+			req.Header.Set("Authorization", "Bearer "+tcase.token)
+			method, user, err := a.authenticate(req)
+			testutil.Equals(t, authMethodBearer, method)
+			if tcase.errMsg != "" {
+				testutil.NotOk(t, err)
+				testutil.Equals(t, tcase.errMsg, err.Error())
+				return
+			}
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.user, user)
+		})
+	}
+	// Keys are fetched once, refetching for the unknown key is rate limited.
+	testutil.Equals(t, 1, jwksRequests)
+
+	// Unknown keys are refetched once the refresh interval passed, so rotated keys are picked up.
+	now = now.Add(oidcMinKeysRefreshInterval)
+	_, err = a.oidc.verify(context.Background(), signJWT(t, "RS256", "other", otherKey, validClaims()))
+	testutil.NotOk(t, err)
+	testutil.Equals(t, 2, jwksRequests)
+}
+
+func TestAuthenticator_OIDCFailedKeysFetch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	testutil.Ok(t, err)
+
+	fail := true
+	mux := http.NewServeMux()
+	provider := httptest.NewServer(mux)
+	defer provider.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": provider.URL, "jwks_uri": provider.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "rsa", "kty": "RSA", "use": "sig",
+			"n": encodeSegment(rsaKey.N.Bytes()),
+			"e": encodeSegment(big.NewInt(int64(rsaKey.E)).Bytes()),
+		}}})
+	})
+
+	a, err := NewAuthenticatorWithConfig(log.NewNopLogger(), nil, AuthConfig{
+		OIDC: &OIDCConfig{IssuerURL: provider.URL, ClientID: "thanos", UsernameClaim: "email"},
+	})
+	testutil.Ok(t, err)
+	now := time.Now()
+	a.oidc.now = func() time.Time { return now }
+
+	token := signJWT(t, "RS256", "rsa", rsaKey, map[string]interface{}{
+		"iss":   provider.URL,
+		"aud":   "thanos",
+		"email": "user@example.com",
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	_, err = a.oidc.verify(context.Background(), token)
+	testutil.NotOk(t, err)
+
+	// Failed fetches are not rate limited, so keys are fetched again right away once the provider recovers.
+	fail = false
+	user, err := a.oidc.verify(context.Background(), token)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "user@example.com", user)
+}
+
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims interface{}) string {
+	signed := encodeJSONSegment(t, map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encodeJSONSegment(t, claims)
+
+	hash := crypto.SHA256
+	if alg == "RS512" {
+		hash = crypto.SHA512
+	}
+	h := hash.New()
+	_, _ = h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		s, err := rsa.SignPKCS1v15(rand.Reader, k, hash, digest)
+		testutil.Ok(t, err)
+		signature = s
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		testutil.Ok(t, err)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(signature[size-len(rb):size], rb)
+		copy(signature[2*size-len(sb):], sb)
+	}
+	return signed + "." + encodeSegment(signature)
+}
+
+func encodeJSONSegment(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	testutil.Ok(t, err)
+	return encodeSegment(b)
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
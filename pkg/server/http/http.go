@@ -41,12 +41,24 @@ func New(logger log.Logger, reg *prometheus.Registry, comp component.Component,
 	registerProbes(mux, prober, logger)
 	registerProfiler(mux)
 
+	var h http.Handler = mux
+	if options.tenant != nil {
+		h = options.tenant.Handler(h)
+	}
+	if options.auth != nil {
+		h = options.auth.Handler(h)
+	}
+	// Requests are logged before authentication, so rejected requests are logged too.
+	if options.reqLogger != nil {
+		h = options.reqLogger.Handler(h)
+	}
+
 	return &Server{
 		logger: log.With(logger, "service", "http/server", "component", comp.String()),
 		comp:   comp,
 		prober: prober,
 		mux:    mux,
-		srv:    &http.Server{Addr: options.listen, Handler: mux},
+		srv:    &http.Server{Addr: options.listen, Handler: h},
 		opts:   options,
 	}
 }
@@ -0,0 +1,405 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	// oidcClockSkew is the tolerated difference of clocks of the provider and Thanos when validating token times.
+	oidcClockSkew = 30 * time.Second
+	// oidcMinKeysRefreshInterval limits refreshes of keys caused by tokens signed by unknown keys.
+	oidcMinKeysRefreshInterval = time.Minute
+	oidcRequestTimeout         = 10 * time.Second
+
+	defaultOIDCUsernameClaim = "sub"
+)
+
+// oidcVerifier verifies OpenID Connect ID tokens, signed by keys published by the provider.
+type oidcVerifier struct {
+	issuer        string
+	clientID      string
+	usernameClaim string
+	client        *http.Client
+	now           func() time.Time
+
+	mtx           sync.Mutex
+	jwksURL       string
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+	// fetch is the fetch of keys in progress, if any.
+	fetch *keysFetch
+}
+
+func newOIDCVerifier(config OIDCConfig) (*oidcVerifier, error) {
+	if config.IssuerURL == "" {
+		return nil, errors.New("no issuer URL configured")
+	}
+	if config.ClientID == "" {
+		return nil, errors.New("no client ID configured")
+	}
+	v := &oidcVerifier{
+		issuer:        config.IssuerURL,
+		clientID:      config.ClientID,
+		usernameClaim: config.UsernameClaim,
+		jwksURL:       config.JWKSURL,
+		client:        &http.Client{Timeout: oidcRequestTimeout},
+		now:           time.Now,
+	}
+	if v.usernameClaim == "" {
+		v.usernameClaim = defaultOIDCUsernameClaim
+	}
+	return v, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// audience is the "aud" claim, which is either a single string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return err
+	}
+	*a = ss
+	return nil
+}
+
+type jwtClaims struct {
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	Expiry    *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+}
+
+// verify verifies the signature and claims of the token, and returns the username it was issued for.
+func (v *oidcVerifier) verify(ctx context.Context, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return "", errors.Wrap(err, "decode token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.Wrap(err, "decode token signature")
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return "", err
+	}
+	if err := verifyJWTSignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return "", err
+	}
+
+	// Signature is valid, so claims can be trusted.
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return "", errors.Wrap(err, "decode token claims")
+	}
+	if claims.Issuer != v.issuer {
+		return "", errors.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if !claims.Audience.contains(v.clientID) {
+		return "", errors.Errorf("token not issued for client %s", v.clientID)
+	}
+	now := v.now()
+	if claims.Expiry == nil {
+		return "", errors.New("token without expiry")
+	}
+	if now.After(time.Unix(*claims.Expiry, 0).Add(oidcClockSkew)) {
+		return "", errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(oidcClockSkew).Before(time.Unix(*claims.NotBefore, 0)) {
+		return "", errors.New("token not valid yet")
+	}
+
+	var all map[string]interface{}
+	if err := decodeJWTPart(parts[1], &all); err != nil {
+		return "", errors.Wrap(err, "decode token claims")
+	}
+	user, ok := all[v.usernameClaim].(string)
+	if !ok {
+		return "", errors.Errorf("no %s claim in token", v.usernameClaim)
+	}
+	return user, nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, aud := range a {
+		if aud == s {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+var ecdsaAlgCurves = map[string]string{
+	"ES256": "P-256",
+	"ES384": "P-384",
+	"ES512": "P-521",
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return errors.Errorf("unsupported signing algorithm %q", alg)
+	}
+	h := hash.New()
+	_, _ = h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg[0] != 'R' {
+			return errors.Errorf("algorithm %s does not match RSA key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+			return errors.New("invalid token signature")
+		}
+	case *ecdsa.PublicKey:
+		// Each ECDSA algorithm is bound to its curve, as per RFC 7518.
+		if ecdsaAlgCurves[alg] != k.Curve.Params().Name {
+			return errors.Errorf("algorithm %s does not match EC key of curve %s", alg, k.Curve.Params().Name)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid token signature")
+		}
+		r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("invalid token signature")
+		}
+	default:
+		return errors.Errorf("unsupported key type %T", key)
+	}
+	return nil
+}
+
+// key returns the key of the given ID. Keys are fetched from the provider when not known yet, at most once per
+// oidcMinKeysRefreshInterval after a successful fetch, so rotated keys are picked up. Keys are fetched in the
+// background, shared by all callers waiting for them, so slow providers don't block verification of tokens signed by
+// known keys.
+func (v *oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mtx.Lock()
+	if k, ok := v.keys[kid]; ok {
+		v.mtx.Unlock()
+		return k, nil
+	}
+	if !v.keysFetchedAt.IsZero() && v.now().Sub(v.keysFetchedAt) < oidcMinKeysRefreshInterval {
+		v.mtx.Unlock()
+		return nil, errors.Errorf("unknown key %q", kid)
+	}
+	f := v.fetch
+	if f == nil {
+		f = &keysFetch{done: make(chan struct{})}
+		v.fetch = f
+		go v.refreshKeys(f, v.jwksURL)
+	}
+	v.mtx.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-f.done:
+	}
+	if f.err != nil {
+		return nil, errors.Wrap(f.err, "fetch keys")
+	}
+	if k, ok := f.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, errors.Errorf("unknown key %q", kid)
+}
+
+// keysFetch is a fetch of keys from the provider. Its keys and err are set once done is closed.
+type keysFetch struct {
+	done chan struct{}
+	keys map[string]crypto.PublicKey
+	err  error
+}
+
+// refreshKeys fetches keys from the provider, independently of requests waiting for them. The fetch time is recorded
+// only if keys were fetched, so failed fetches are retried by the next token signed by an unknown key.
+func (v *oidcVerifier) refreshKeys(f *keysFetch, jwksURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), oidcRequestTimeout)
+	defer cancel()
+
+	keys, jwksURL, err := v.fetchKeys(ctx, jwksURL)
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	f.keys, f.err = keys, err
+	close(f.done)
+	v.fetch = nil
+	if err != nil {
+		return
+	}
+	v.jwksURL = jwksURL
+	v.keys = keys
+	v.keysFetchedAt = v.now()
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys fetches signing keys of the provider from the given JWKS URL, which is discovered first if empty.
+// It returns the keys and the JWKS URL they were fetched from.
+func (v *oidcVerifier) fetchKeys(ctx context.Context, jwksURL string) (map[string]crypto.PublicKey, string, error) {
+	if jwksURL == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, strings.TrimSuffix(v.issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, "", errors.Wrap(err, "discover provider configuration")
+		}
+		if discovery.Issuer != v.issuer {
+			return nil, "", errors.Errorf("provider issuer %q does not match configured %q", discovery.Issuer, v.issuer)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, "", errors.New("no jwks_uri in provider configuration")
+		}
+		jwksURL = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, jwksURL, &jwks); err != nil {
+		return nil, "", err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Skip keys we cannot use, other ones may still verify tokens.
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, jwksURL, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("point not on curve")
+		}
+		return key, nil
+	default:
+		return nil, errors.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func (v *oidcVerifier) getJSON(ctx context.Context, url string, out interface{}) (err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer runutil.CloseWithErrCapture(&err, resp.Body, "close response body")
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "read response of %s", url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return errors.Wrapf(err, "decode response of %s", url)
+	}
+	return nil
+}
@@ -5,11 +5,16 @@ package http
 
 import (
 	"time"
+
+	"github.com/thanos-io/thanos/pkg/logging"
 )
 
 type options struct {
 	gracePeriod time.Duration
 	listen      string
+	auth        *Authenticator
+	tenant      *TenantVerifier
+	reqLogger   *logging.RequestLogger
 }
 
 // Option overrides behavior of Server.
@@ -38,3 +43,26 @@ func WithListen(s string) Option {
 		o.listen = s
 	})
 }
+
+// WithAuthentication sets authenticator of requests served by HTTP server.
+// Requests of paths not exempted by the authenticator have to be authenticated.
+func WithAuthentication(a *Authenticator) Option {
+	return optionFunc(func(o *options) {
+		o.auth = a
+	})
+}
+
+// WithTenantVerification sets verifier of signed tenant headers of requests served by HTTP server.
+// Requests of paths not exempted by the verifier have to be signed.
+func WithTenantVerification(v *TenantVerifier) Option {
+	return optionFunc(func(o *options) {
+		o.tenant = v
+	})
+}
+
+// WithRequestLogging sets the logger of requests served by HTTP server, logging them as decided by its rules.
+func WithRequestLogging(l *logging.RequestLogger) Option {
+	return optionFunc(func(o *options) {
+		o.reqLogger = l
+	})
+}
@@ -0,0 +1,172 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DefaultTenantSignatureHeader is the default header carrying signatures of tenant headers.
+	DefaultTenantSignatureHeader = "THANOS-TENANT-SIGNATURE"
+
+	defaultTenantSignatureMaxAge = 5 * time.Minute
+	// minTenantSignatureKeyLength is the minimum length of keys, so they cannot be guessed.
+	minTenantSignatureKeyLength = 16
+)
+
+// TenantSignatureConfig is the configuration of verification of signed tenant headers.
+type TenantSignatureConfig struct {
+	// SignatureHeader is the header carrying signatures. Defaults to DefaultTenantSignatureHeader.
+	SignatureHeader string `yaml:"signature_header"`
+	// Keys are the shared keys signatures are verified with. Signatures by any of the keys are accepted, so keys can
+	// be rotated.
+	Keys []string `yaml:"keys"`
+	// MaxAge is the maximum age of signatures. Defaults to 5m.
+	MaxAge model.Duration `yaml:"max_age"`
+	// ExemptPaths are paths served without verification. Paths ending with "/" exempt all paths with such prefix.
+	// Defaults to DefaultAuthExemptPaths if not set.
+	ExemptPaths []string `yaml:"exempt_paths"`
+}
+
+// TenantVerifier is an HTTP middleware rejecting requests whose tenant header is not signed by a trusted gateway.
+type TenantVerifier struct {
+	logger          log.Logger
+	tenantHeader    string
+	signatureHeader string
+	keys            [][]byte
+	maxAge          time.Duration
+	exempt          []string
+	now             func() time.Time
+
+	failures prometheus.Counter
+}
+
+// NewTenantVerifier parses the YAML tenant signature configuration and returns a verifier of signatures of the
+// given tenant header.
+func NewTenantVerifier(logger log.Logger, reg prometheus.Registerer, conf []byte, tenantHeader string) (*TenantVerifier, error) {
+	var config TenantSignatureConfig
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing tenant signature config")
+	}
+	if len(config.Keys) == 0 {
+		return nil, errors.New("no keys configured")
+	}
+
+	v := &TenantVerifier{
+		logger:          logger,
+		tenantHeader:    tenantHeader,
+		signatureHeader: config.SignatureHeader,
+		maxAge:          time.Duration(config.MaxAge),
+		exempt:          config.ExemptPaths,
+		now:             time.Now,
+		failures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_http_tenant_signature_failures_total",
+			Help: "Total number of HTTP requests rejected because of missing or invalid signatures of their tenant header.",
+		}),
+	}
+	if v.signatureHeader == "" {
+		v.signatureHeader = DefaultTenantSignatureHeader
+	}
+	if v.maxAge == 0 {
+		v.maxAge = defaultTenantSignatureMaxAge
+	}
+	if v.exempt == nil {
+		v.exempt = DefaultAuthExemptPaths
+	}
+	for i, k := range config.Keys {
+		if len(k) < minTenantSignatureKeyLength {
+			return nil, errors.Errorf("key %d is shorter than %d characters", i, minTenantSignatureKeyLength)
+		}
+		v.keys = append(v.keys, []byte(k))
+	}
+	return v, nil
+}
+
+// SignTenant returns the signature of the tenant at the given time, as expected in the signature header.
+func SignTenant(key []byte, tenant string, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return fmt.Sprintf("t=%s,sig=%s", ts, hex.EncodeToString(tenantMAC(key, tenant, ts)))
+}
+
+// tenantMAC returns the HMAC-SHA256 of the tenant and timestamp. The tenant is signed together with the timestamp,
+// so captured signatures expire.
+func tenantMAC(key []byte, tenant, ts string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(tenant + "\n" + ts))
+	return mac.Sum(nil)
+}
+
+// Handler returns a handler calling next for requests with valid signature of their tenant header and requests of
+// exempt paths only. Requests without tenant header have to be signed as well, so they cannot write to or read from
+// the default tenant.
+func (v *TenantVerifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isExemptPath(v.exempt, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenant := r.Header.Get(v.tenantHeader)
+		if err := v.verify(tenant, r.Header.Get(v.signatureHeader)); err != nil {
+			v.failures.Inc()
+			level.Debug(v.logger).Log("msg", "tenant signature verification failed", "tenant", tenant, "path", r.URL.Path, "err", err)
+			http.Error(w, "invalid tenant signature", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (v *TenantVerifier) verify(tenant, signature string) error {
+	if signature == "" {
+		return errors.New("no signature")
+	}
+	var ts, sig string
+	for _, part := range strings.Split(signature, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return errors.New("malformed signature")
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "sig":
+			sig = kv[1]
+		}
+	}
+	t, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "malformed signature timestamp")
+	}
+	mac, err := hex.DecodeString(sig)
+	if err != nil || len(mac) == 0 {
+		return errors.New("malformed signature")
+	}
+
+	if age := v.now().Sub(time.Unix(t, 0)); age > v.maxAge || age < -v.maxAge {
+		return errors.Errorf("signature age %s exceeds %s", age, v.maxAge)
+	}
+	for _, k := range v.keys {
+		if hmac.Equal(mac, tenantMAC(k, tenant, ts)) {
+			return nil
+		}
+	}
+	return errors.New("signature does not match")
+}
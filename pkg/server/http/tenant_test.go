@@ -0,0 +1,86 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestNewTenantVerifier(t *testing.T) {
+	for _, conf := range []string{
+		"keys: []",
+		"keys: [short]",
+		"key: [0123456789abcdef]",
+	} {
+		_, err := NewTenantVerifier(log.NewNopLogger(), nil, []byte(conf), "THANOS-TENANT")
+		testutil.NotOk(t, err)
+	}
+}
+
+func TestTenantVerifier(t *testing.T) {
+	oldKey, newKey, otherKey := []byte("0123456789abcdef-old"), []byte("0123456789abcdef-new"), []byte("0123456789abcdef-other")
+	reg := prometheus.NewRegistry()
+	v, err := NewTenantVerifier(log.NewNopLogger(), reg, []byte(`
+keys: [0123456789abcdef-new, 0123456789abcdef-old]
+max_age: 1m
+`), "THANOS-TENANT")
+	testutil.Ok(t, err)
+	now := time.Unix(1583144112, 0)
+	v.now = func() time.Time { return now }
+
+	var served string
+	h := v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = r.Header.Get("THANOS-TENANT")
+	}))
+
+	for _, tcase := range []struct {
+		name      string
+		path      string
+		tenant    string
+		signature string
+		code      int
+	}{
+		{name: "signed", tenant: "team-a", signature: SignTenant(newKey, "team-a", now), code: http.StatusOK},
+		{name: "signed by rotated key", tenant: "team-a", signature: SignTenant(oldKey, "team-a", now.Add(-30*time.Second)), code: http.StatusOK},
+		{name: "signed default tenant", signature: SignTenant(newKey, "", now), code: http.StatusOK},
+		{name: "exempt path", path: "/metrics", tenant: "team-a", code: http.StatusOK},
+		{name: "unsigned", tenant: "team-a", code: http.StatusUnauthorized},
+		{name: "unsigned default tenant", code: http.StatusUnauthorized},
+		{name: "signature of other tenant", tenant: "team-b", signature: SignTenant(newKey, "team-a", now), code: http.StatusUnauthorized},
+		{name: "signed by unknown key", tenant: "team-a", signature: SignTenant(otherKey, "team-a", now), code: http.StatusUnauthorized},
+		{name: "expired", tenant: "team-a", signature: SignTenant(newKey, "team-a", now.Add(-2*time.Minute)), code: http.StatusUnauthorized},
+		{name: "from the future", tenant: "team-a", signature: SignTenant(newKey, "team-a", now.Add(2*time.Minute)), code: http.StatusUnauthorized},
+		{name: "malformed", tenant: "team-a", signature: "t=1583144112,sig=xyz", code: http.StatusUnauthorized},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			served = ""
+			path := tcase.path
+			if path == "" {
+				path = "/api/v1/receive"
+			}
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			if tcase.tenant != "" {
+				req.Header.Set("THANOS-TENANT", tcase.tenant)
+			}
+			if tcase.signature != "" {
+				req.Header.Set(DefaultTenantSignatureHeader, tcase.signature)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			testutil.Equals(t, tcase.code, rec.Code)
+			if tcase.code == http.StatusOK {
+				testutil.Equals(t, tcase.tenant, served)
+			}
+		})
+	}
+	testutil.Equals(t, 7.0, promtest.ToFloat64(v.failures))
+}
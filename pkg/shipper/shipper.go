@@ -14,6 +14,8 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -36,6 +38,8 @@ type metrics struct {
 	uploads           prometheus.Counter
 	uploadFailures    prometheus.Counter
 	uploadedCompacted prometheus.Gauge
+	pendingBlocks     prometheus.Gauge
+	pendingBytes      prometheus.Gauge
 }
 
 func newMetrics(reg prometheus.Registerer, uploadCompacted bool) *metrics {
@@ -57,6 +61,14 @@ func newMetrics(reg prometheus.Registerer, uploadCompacted bool) *metrics {
 		Name: "thanos_shipper_upload_failures_total",
 		Help: "Total number of block upload failures",
 	})
+	m.pendingBlocks = promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_shipper_pending_blocks",
+		Help: "Number of selected local blocks which were not uploaded yet, as of the last sync.",
+	})
+	m.pendingBytes = promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_shipper_pending_bytes",
+		Help: "Size of selected local blocks which were not uploaded yet, as of the last sync.",
+	})
 	uploadCompactedGaugeOpts := prometheus.GaugeOpts{
 		Name: "thanos_shipper_upload_compacted_done",
 		Help: "If 1 it means shipper uploaded all compacted blocks from the filesystem.",
@@ -79,6 +91,10 @@ type Shipper struct {
 	labels          func() labels.Labels
 	source          metadata.SourceType
 	uploadCompacted bool
+	selector        *UploadSelector
+
+	// mtx serializes Sync and UploadSnapshot.
+	mtx sync.Mutex
 }
 
 // New creates a new shipper that detects new TSDB blocks in dir and uploads them
@@ -137,6 +153,49 @@ func NewWithCompacted(
 	}
 }
 
+// UploadSelector selects local blocks to upload by time range and IDs.
+type UploadSelector struct {
+	minTime, maxTime int64
+	ids              map[ulid.ULID]struct{}
+}
+
+// NewUploadSelector returns UploadSelector selecting blocks overlapping with the given time range in milliseconds.
+// If any IDs are given, only blocks with those IDs are selected.
+func NewUploadSelector(minTime, maxTime int64, ids []ulid.ULID) *UploadSelector {
+	sel := &UploadSelector{minTime: minTime, maxTime: maxTime}
+	if len(ids) > 0 {
+		sel.ids = make(map[ulid.ULID]struct{}, len(ids))
+		for _, id := range ids {
+			sel.ids[id] = struct{}{}
+		}
+	}
+	return sel
+}
+
+// Selects returns true if the block should be uploaded.
+func (sel *UploadSelector) Selects(m *metadata.Meta) bool {
+	if sel == nil {
+		return true
+	}
+	if m.MaxTime <= sel.minTime || m.MinTime > sel.maxTime {
+		return false
+	}
+	if sel.ids == nil {
+		return true
+	}
+	_, ok := sel.ids[m.ULID]
+	return ok
+}
+
+// WithUploadSelector restricts uploads to blocks selected by the given selector, e.g. to upload only part of
+// an existing Prometheus data directory. Blocks which are not selected are not uploaded.
+func (s *Shipper) WithUploadSelector(sel *UploadSelector) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.selector = sel
+}
+
 // Timestamps returns the minimum timestamp for which data is available and the highest timestamp
 // of blocks that were successfully uploaded.
 func (s *Shipper) Timestamps() (minTime, maxSyncTime int64, err error) {
@@ -194,6 +253,9 @@ func newLazyOverlapChecker(logger log.Logger, bucket objstore.Bucket, labels fun
 }
 
 func (c *lazyOverlapChecker) sync(ctx context.Context) error {
+	c.metas = nil
+	c.lookupMetas = map[ulid.ULID]struct{}{}
+
 	if err := c.bucket.Iter(ctx, "", func(path string) error {
 		id, ok := block.IsBlockDir(path)
 		if !ok {
@@ -221,24 +283,66 @@ func (c *lazyOverlapChecker) sync(ctx context.Context) error {
 	return nil
 }
 
-func (c *lazyOverlapChecker) IsOverlapping(ctx context.Context, newMeta tsdb.BlockMeta) error {
+// errAlreadyReplaced is returned when all sources of the compacted block are already part of a block in the bucket.
+var errAlreadyReplaced = errors.New("all sources of the block are already in the bucket")
+
+// Replaced returns blocks from the bucket which overlap with the given compacted block. It is allowed only
+// if all their sources are also sources of the new block, so the new block replaces them. Any other overlap
+// is an error, as is a bucket block that already contains all sources of the new block (errAlreadyReplaced).
+func (c *lazyOverlapChecker) Replaced(ctx context.Context, newMeta tsdb.BlockMeta) ([]ulid.ULID, error) {
 	if !c.synced {
 		level.Info(c.logger).Log("msg", "gathering all existing blocks from the remote bucket for check", "id", newMeta.ULID.String())
 		if err := c.sync(ctx); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	// TODO(bwplotka) so confusing! we need to sort it first. Add comment to TSDB code.
-	metas := append([]tsdb.BlockMeta{newMeta}, c.metas...)
-	sort.Slice(metas, func(i, j int) bool {
-		return metas[i].MinTime < metas[j].MinTime
+	sources := make(map[ulid.ULID]struct{}, len(newMeta.Compaction.Sources))
+	for _, id := range newMeta.Compaction.Sources {
+		sources[id] = struct{}{}
+	}
+
+	var (
+		replaced []ulid.ULID
+		// TODO(bwplotka) so confusing! we need to sort it first. Add comment to TSDB code.
+		others = []tsdb.BlockMeta{newMeta}
+	)
+	for _, m := range c.metas {
+		if m.MinTime >= newMeta.MaxTime || newMeta.MinTime >= m.MaxTime {
+			others = append(others, m)
+			continue
+		}
+		if containsSources(m.Compaction.Sources, newMeta.Compaction.Sources) {
+			return nil, errors.Wrapf(errAlreadyReplaced, "block %s", m.ULID)
+		}
+		if !containsSources(newMeta.Compaction.Sources, m.Compaction.Sources) {
+			others = append(others, m)
+			continue
+		}
+		replaced = append(replaced, m.ULID)
+	}
+
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].MinTime < others[j].MinTime
 	})
-	if o := tsdb.OverlappingBlocks(metas); len(o) > 0 {
-		// TODO(bwplotka): Consider checking if overlaps relates to block in concern?
-		return errors.Errorf("shipping compacted block %s is blocked; overlap spotted: %s", newMeta.ULID, o.String())
+	if o := tsdb.OverlappingBlocks(others); len(o) > 0 {
+		return nil, errors.Errorf("shipping compacted block %s is blocked; overlap spotted: %s", newMeta.ULID, o.String())
 	}
-	return nil
+	return replaced, nil
+}
+
+// containsSources returns true if all sub sources are in sources.
+func containsSources(sources, sub []ulid.ULID) bool {
+	set := make(map[ulid.ULID]struct{}, len(sources))
+	for _, id := range sources {
+		set[id] = struct{}{}
+	}
+	for _, id := range sub {
+		if _, ok := set[id]; !ok {
+			return false
+		}
+	}
+	return true
 }
 
 // Sync performs a single synchronization, which ensures all non-compacted local blocks have been uploaded
@@ -246,8 +350,11 @@ func (c *lazyOverlapChecker) IsOverlapping(ctx context.Context, newMeta tsdb.Blo
 //
 // If uploaded.
 //
-// It is not concurrency-safe, however it is compactor-safe (running concurrently with compactor is ok).
+// It is concurrency-safe and compactor-safe (running concurrently with compactor is ok).
 func (s *Shipper) Sync(ctx context.Context) (uploaded int, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
 	meta, err := ReadMetaFile(s.dir)
 	if err != nil {
 		// If we encounter any error, proceed with an empty meta file and overwrite it later.
@@ -268,6 +375,24 @@ func (s *Shipper) Sync(ctx context.Context) (uploaded int, err error) {
 	// Reset the uploaded slice so we can rebuild it only with blocks that still exist locally.
 	meta.Uploaded = nil
 
+	progress := newUploadProgress(s.logger)
+	if err := s.iterBlockMetas(func(m *metadata.Meta) error {
+		if _, uploaded := hasUploaded[m.ULID]; uploaded || m.Stats.NumSamples == 0 || !s.selector.Selects(m) {
+			return nil
+		}
+		if m.Compaction.Level > 1 && !s.uploadCompacted {
+			return nil
+		}
+		return progress.add(filepath.Join(s.dir, m.ULID.String()), m.ULID)
+	}); err != nil {
+		s.metrics.dirSyncFailures.Inc()
+		return 0, errors.Wrap(err, "iter local block metas")
+	}
+	defer func() {
+		s.metrics.pendingBlocks.Set(float64(len(progress.pending)))
+		s.metrics.pendingBytes.Set(float64(progress.pendingBytes()))
+	}()
+
 	var (
 		checker    = newLazyOverlapChecker(s.logger, s.bucket, s.labels)
 		uploadErrs int
@@ -281,6 +406,11 @@ func (s *Shipper) Sync(ctx context.Context) (uploaded int, err error) {
 			return nil
 		}
 
+		if !s.selector.Selects(m) {
+			level.Debug(s.logger).Log("msg", "ignoring block not selected for upload", "block", m.ULID)
+			return nil
+		}
+
 		if m.Stats.NumSamples == 0 {
 			// Ignore empty blocks.
 			level.Debug(s.logger).Log("msg", "ignoring empty block", "block", m.ULID)
@@ -293,6 +423,7 @@ func (s *Shipper) Sync(ctx context.Context) (uploaded int, err error) {
 			return errors.Wrap(err, "check exists")
 		}
 		if ok {
+			progress.done(m.ULID, false)
 			return nil
 		}
 
@@ -302,14 +433,26 @@ func (s *Shipper) Sync(ctx context.Context) (uploaded int, err error) {
 				return nil
 			}
 
-			if err := checker.IsOverlapping(ctx, m.BlockMeta); err != nil {
-				level.Error(s.logger).Log("msg", "found overlap or error during sync, cannot upload compacted block", "err", err)
+			if err := s.uploadCompactedBlock(ctx, checker, m); err != nil {
+				if errors.Cause(err) == errAlreadyReplaced {
+					level.Info(s.logger).Log("msg", "skipping compacted block, its data is already in the bucket", "block", m.ULID, "reason", err)
+					meta.Uploaded = append(meta.Uploaded, m.ULID)
+					progress.done(m.ULID, false)
+					return nil
+				}
+				level.Error(s.logger).Log("msg", "found overlap or error during sync, cannot upload compacted block", "block", m.ULID, "err", err)
 				uploadErrs++
 				return nil
 			}
+			meta.Uploaded = append(meta.Uploaded, m.ULID)
+			progress.done(m.ULID, true)
+
+			uploaded++
+			s.metrics.uploads.Inc()
+			return nil
 		}
 
-		if err := s.upload(ctx, m); err != nil {
+		if err := s.upload(ctx, s.dir, m); err != nil {
 			level.Error(s.logger).Log("msg", "shipping failed", "block", m.ULID, "err", err)
 			// No error returned, just log line. This is because we want other blocks to be uploaded even
 			// though this one failed. It will be retried on second Sync iteration.
@@ -317,6 +460,7 @@ func (s *Shipper) Sync(ctx context.Context) (uploaded int, err error) {
 			return nil
 		}
 		meta.Uploaded = append(meta.Uploaded, m.ULID)
+		progress.done(m.ULID, true)
 
 		uploaded++
 		s.metrics.uploads.Inc()
@@ -341,9 +485,158 @@ func (s *Shipper) Sync(ctx context.Context) (uploaded int, err error) {
 	return uploaded, nil
 }
 
-// sync uploads the block if not exists in remote storage.
+// uploadProgress tracks blocks pending upload within a single sync.
+type uploadProgress struct {
+	logger     log.Logger
+	total      int
+	totalBytes int64
+	pending    map[ulid.ULID]int64
+}
+
+func newUploadProgress(logger log.Logger) *uploadProgress {
+	return &uploadProgress{logger: logger, pending: map[ulid.ULID]int64{}}
+}
+
+func (p *uploadProgress) add(dir string, id ulid.ULID) error {
+	var size int64
+	if err := filepath.Walk(dir, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			size += fi.Size()
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "get size of block %s", id)
+	}
+	p.pending[id] = size
+	p.total++
+	p.totalBytes += size
+	return nil
+}
+
+// done marks the block as no longer pending. Progress is logged, if the block was uploaded.
+func (p *uploadProgress) done(id ulid.ULID, uploaded bool) {
+	if _, ok := p.pending[id]; !ok {
+		return
+	}
+	delete(p.pending, id)
+	if !uploaded {
+		return
+	}
+	level.Info(p.logger).Log(
+		"msg", "upload progress",
+		"block", id,
+		"blocks_done", p.total-len(p.pending),
+		"blocks_total", p.total,
+		"bytes_done", p.totalBytes-p.pendingBytes(),
+		"bytes_total", p.totalBytes,
+	)
+}
+
+func (p *uploadProgress) pendingBytes() int64 {
+	var b int64
+	for _, size := range p.pending {
+		b += size
+	}
+	return b
+}
+
+// uploadCompactedBlock uploads the compacted block, after making sure it can't conflict with blocks in the bucket.
+// Blocks in the bucket made only of sources of the uploaded block, e.g. uncompacted blocks uploaded before Prometheus
+// compacted them locally, are replaced: they are marked for no compaction before the upload, so the compactor does not
+// compact them concurrently, and once the new block is uploaded, the compactor treats them as duplicates and
+// garbage collects them.
+func (s *Shipper) uploadCompactedBlock(ctx context.Context, checker *lazyOverlapChecker, m *metadata.Meta) error {
+	if lset := labels.FromMap(m.Thanos.Labels); len(lset) > 0 && !labels.Equal(lset, s.labels()) {
+		return errors.Errorf("block %s has external labels %s different than current %s", m.ULID, lset, s.labels())
+	}
+
+	replaced, err := checker.Replaced(ctx, m.BlockMeta)
+	if err != nil {
+		return err
+	}
+	if len(replaced) == 0 {
+		return s.upload(ctx, s.dir, m)
+	}
+
+	for _, id := range replaced {
+		if err := block.MarkForNoCompact(ctx, s.logger, s.bucket, id, metadata.ReplacementUploadNoCompactReason, "replaced by sidecar upload of "+m.ULID.String()); err != nil {
+			return errors.Wrap(err, "mark replaced block")
+		}
+	}
+
+	// The compactor could have compacted replaced blocks before they were marked, so check once again.
+	if err := checker.sync(ctx); err != nil {
+		return err
+	}
+	if _, err := checker.Replaced(ctx, m.BlockMeta); err != nil {
+		s.unmarkReplaced(replaced)
+		return err
+	}
+	if err := s.upload(ctx, s.dir, m); err != nil {
+		s.unmarkReplaced(replaced)
+		return err
+	}
+	return nil
+}
+
+func (s *Shipper) unmarkReplaced(ids []ulid.ULID) {
+	// Use an uncancelable context, so blocks are not left excluded from compaction on shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for _, id := range ids {
+		if err := s.bucket.Delete(ctx, path.Join(id.String(), metadata.NoCompactMarkFilename)); err != nil && !s.bucket.IsObjNotFoundErr(err) {
+			level.Warn(s.logger).Log("msg", "failed to remove no-compact mark; block will be compacted once mark expires", "block", id, "err", err)
+		}
+	}
+}
+
+// UploadSnapshot uploads non-compacted blocks from the given TSDB snapshot directory, which are not in the bucket yet.
+// This is meant for the block created from the head of the TSDB by the snapshot, so the most recent data can be
+// persisted on demand. Blocks persisted locally are uploaded by Sync.
+func (s *Shipper) UploadSnapshot(ctx context.Context, snapshotDir string) (uploaded int, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var uploadErrs int
+	if err := iterBlockMetas(s.logger, snapshotDir, func(m *metadata.Meta) error {
+		if m.Stats.NumSamples == 0 || m.Compaction.Level > 1 {
+			return nil
+		}
+
+		ok, err := s.bucket.Exists(ctx, path.Join(m.ULID.String(), block.MetaFilename))
+		if err != nil {
+			return errors.Wrap(err, "check exists")
+		}
+		if ok {
+			return nil
+		}
+
+		if err := s.upload(ctx, snapshotDir, m); err != nil {
+			level.Error(s.logger).Log("msg", "shipping snapshot block failed", "block", m.ULID, "err", err)
+			uploadErrs++
+			return nil
+		}
+		uploaded++
+		s.metrics.uploads.Inc()
+		return nil
+	}); err != nil {
+		return uploaded, errors.Wrap(err, "iter snapshot block metas")
+	}
+
+	if uploadErrs > 0 {
+		s.metrics.uploadFailures.Add(float64(uploadErrs))
+		return uploaded, errors.Errorf("failed to upload %v snapshot blocks", uploadErrs)
+	}
+	return uploaded, nil
+}
+
+// upload uploads the block from the given directory.
 // TODO(khyatisoneji): Double check if block does not have deletion-mark.json for some reason, otherwise log it or return error.
-func (s *Shipper) upload(ctx context.Context, meta *metadata.Meta) error {
+func (s *Shipper) upload(ctx context.Context, srcDir string, meta *metadata.Meta) error {
 	level.Info(s.logger).Log("msg", "upload new block", "id", meta.ULID)
 
 	// We hard-link the files into a temporary upload directory so we are not affected
@@ -363,7 +656,7 @@ func (s *Shipper) upload(ctx context.Context, meta *metadata.Meta) error {
 		}
 	}()
 
-	dir := filepath.Join(s.dir, meta.ULID.String())
+	dir := filepath.Join(srcDir, meta.ULID.String())
 	if err := hardlinkBlock(dir, updir); err != nil {
 		return errors.Wrap(err, "hard link block")
 	}
@@ -383,8 +676,12 @@ func (s *Shipper) upload(ctx context.Context, meta *metadata.Meta) error {
 // meta.json file.
 // If f returns an error, the function returns with the same error.
 func (s *Shipper) iterBlockMetas(f func(m *metadata.Meta) error) error {
+	return iterBlockMetas(s.logger, s.dir, f)
+}
+
+func iterBlockMetas(logger log.Logger, blocksDir string, f func(m *metadata.Meta) error) error {
 	var metas []*metadata.Meta
-	names, err := fileutil.ReadDir(s.dir)
+	names, err := fileutil.ReadDir(blocksDir)
 	if err != nil {
 		return errors.Wrap(err, "read dir")
 	}
@@ -392,11 +689,11 @@ func (s *Shipper) iterBlockMetas(f func(m *metadata.Meta) error) error {
 		if _, ok := block.IsBlockDir(n); !ok {
 			continue
 		}
-		dir := filepath.Join(s.dir, n)
+		dir := filepath.Join(blocksDir, n)
 
 		fi, err := os.Stat(dir)
 		if err != nil {
-			level.Warn(s.logger).Log("msg", "open file failed", "err", err)
+			level.Warn(logger).Log("msg", "open file failed", "err", err)
 			continue
 		}
 		if !fi.IsDir() {
@@ -404,7 +701,7 @@ func (s *Shipper) iterBlockMetas(f func(m *metadata.Meta) error) error {
 		}
 		m, err := metadata.Read(dir)
 		if err != nil {
-			level.Warn(s.logger).Log("msg", "reading meta file failed", "err", err)
+			level.Warn(logger).Log("msg", "reading meta file failed", "err", err)
 			continue
 		}
 		metas = append(metas, m)
@@ -120,8 +120,13 @@ func TestShipper_SyncBlocks_e2e(t *testing.T) {
 				testutil.Equals(t, 0, b)
 			}
 
-			// The external labels must be attached to the meta file on upload.
+			// The external labels and file checksums must be attached to the meta file on upload.
 			meta.Thanos.Labels = extLset.Map()
+			meta.Thanos.Files = []metadata.File{
+				{RelPath: "chunks/0001", SizeBytes: 14, CRC32C: "468fa402"},
+				{RelPath: "chunks/0002", SizeBytes: 14, CRC32C: "55df57f6"},
+				{RelPath: "index", SizeBytes: 13, CRC32C: "46f51e18"},
+			}
 
 			var buf bytes.Buffer
 			enc := json.NewEncoder(&buf)
@@ -272,8 +277,13 @@ func TestShipper_SyncBlocksWithMigrating_e2e(t *testing.T) {
 			testutil.Equals(t, 1, b)
 			ids = append(ids, id)
 
-			// The external labels must be attached to the meta file on upload.
+			// The external labels and file checksums must be attached to the meta file on upload.
 			meta.Thanos.Labels = extLset.Map()
+			meta.Thanos.Files = []metadata.File{
+				{RelPath: "chunks/0001", SizeBytes: 14, CRC32C: "468fa402"},
+				{RelPath: "chunks/0002", SizeBytes: 14, CRC32C: "55df57f6"},
+				{RelPath: "index", SizeBytes: 13, CRC32C: "46f51e18"},
+			}
 
 			var buf bytes.Buffer
 			enc := json.NewEncoder(&buf)
@@ -4,6 +4,9 @@
 package shipper
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"io/ioutil"
 	"math"
 	"math/rand"
@@ -14,8 +17,12 @@ import (
 
 	"github.com/go-kit/kit/log"
 	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -175,3 +182,139 @@ func BenchmarkIterBlockMetas(b *testing.B) {
 		testutil.Ok(b, err)
 	}
 }
+
+func TestLazyOverlapChecker_Replaced(t *testing.T) {
+	ctx := context.Background()
+	bkt := inmem.NewBucket()
+	extLset := labels.FromStrings("prometheus", "prom-1")
+
+	upload := func(id ulid.ULID, mint, maxt int64, lset labels.Labels, sources ...ulid.ULID) {
+		b, err := json.Marshal(metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{
+				ULID:       id,
+				MinTime:    mint,
+				MaxTime:    maxt,
+				Version:    1,
+				Compaction: tsdb.BlockMetaCompaction{Level: 1, Sources: sources},
+			},
+			Thanos: metadata.Thanos{Labels: lset.Map(), Source: metadata.TestSource},
+		})
+		testutil.Ok(t, err)
+		testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), metadata.MetaFilename), bytes.NewReader(b)))
+	}
+	newMeta := func(mint, maxt int64, sources ...ulid.ULID) tsdb.BlockMeta {
+		return tsdb.BlockMeta{
+			ULID:       ulid.MustNew(100, nil),
+			MinTime:    mint,
+			MaxTime:    maxt,
+			Compaction: tsdb.BlockMetaCompaction{Level: 2, Sources: sources},
+		}
+	}
+
+	id1, id2, id3, other := ulid.MustNew(1, nil), ulid.MustNew(2, nil), ulid.MustNew(3, nil), ulid.MustNew(4, nil)
+	upload(id1, 0, 10, extLset, id1)
+	upload(id2, 10, 20, extLset, id2)
+	upload(id3, 20, 30, extLset, id3)
+	// Blocks with different external labels are ignored.
+	upload(other, 0, 30, labels.FromStrings("prometheus", "prom-2"), other)
+
+	c := newLazyOverlapChecker(log.NewNopLogger(), bkt, func() labels.Labels { return extLset })
+
+	replaced, err := c.Replaced(ctx, newMeta(0, 20, id1, id2))
+	testutil.Ok(t, err)
+	testutil.Equals(t, []ulid.ULID{id1, id2}, replaced)
+
+	replaced, err = c.Replaced(ctx, newMeta(30, 40, ulid.MustNew(5, nil)))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(replaced))
+
+	// Overlap with a block which is not made of sources of the new block.
+	_, err = c.Replaced(ctx, newMeta(0, 30, id1, id2, ulid.MustNew(5, nil)))
+	testutil.NotOk(t, err)
+
+	// Data of the new block is already in the bucket.
+	_, err = c.Replaced(ctx, newMeta(0, 10, id1))
+	testutil.Equals(t, errAlreadyReplaced, errors.Cause(err))
+}
+
+func TestShipper_UploadSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shipper-test")
+	testutil.Ok(t, err)
+	defer func() {
+		testutil.Ok(t, os.RemoveAll(dir))
+	}()
+
+	ctx := context.Background()
+	bkt := inmem.NewBucket()
+	extLset := labels.FromStrings("prometheus", "prom-1")
+	snapshotDir := path.Join(dir, "snapshots", "20200101T000000Z-1")
+
+	writeBlock := func(id ulid.ULID, level int) {
+		bdir := path.Join(snapshotDir, id.String())
+		testutil.Ok(t, os.MkdirAll(path.Join(bdir, "chunks"), os.ModePerm))
+		testutil.Ok(t, ioutil.WriteFile(path.Join(bdir, "chunks", "000001"), []byte("chunks"), os.ModePerm))
+		testutil.Ok(t, ioutil.WriteFile(path.Join(bdir, "index"), []byte("index"), os.ModePerm))
+		testutil.Ok(t, metadata.Write(log.NewNopLogger(), bdir, &metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{
+				ULID:       id,
+				MinTime:    1000,
+				MaxTime:    2000,
+				Version:    1,
+				Stats:      tsdb.BlockStats{NumSamples: 1},
+				Compaction: tsdb.BlockMetaCompaction{Level: level, Sources: []ulid.ULID{id}},
+			},
+		}))
+	}
+	uploadedID, headID, compactedID := ulid.MustNew(1, nil), ulid.MustNew(2, nil), ulid.MustNew(3, nil)
+	writeBlock(uploadedID, 1)
+	writeBlock(headID, 1)
+	writeBlock(compactedID, 2)
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(uploadedID.String(), metadata.MetaFilename), bytes.NewBufferString("{}")))
+
+	s := New(nil, nil, dir, bkt, func() labels.Labels { return extLset }, metadata.SidecarSource)
+	uploaded, err := s.UploadSnapshot(ctx, snapshotDir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, uploaded)
+
+	var objects []string
+	for name := range bkt.Objects() {
+		objects = append(objects, name)
+	}
+	sort.Strings(objects)
+	testutil.Equals(t, []string{
+		path.Join(uploadedID.String(), metadata.MetaFilename),
+		path.Join(headID.String(), "chunks", "000001"),
+		path.Join(headID.String(), "index"),
+		path.Join(headID.String(), metadata.MetaFilename),
+		path.Join(block.DebugMetas, headID.String()+".json"),
+	}, objects)
+
+	m, err := metadata.Read(path.Join(snapshotDir, headID.String()))
+	testutil.Ok(t, err)
+	testutil.Equals(t, metadata.Thanos{}, m.Thanos)
+
+	b := bkt.Objects()[path.Join(headID.String(), metadata.MetaFilename)]
+	testutil.Ok(t, json.Unmarshal(b, m))
+	testutil.Equals(t, extLset.Map(), m.Thanos.Labels)
+	testutil.Equals(t, metadata.SidecarSource, m.Thanos.Source)
+}
+
+func TestUploadSelector(t *testing.T) {
+	meta := func(id ulid.ULID, mint, maxt int64) *metadata.Meta {
+		return &metadata.Meta{BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: mint, MaxTime: maxt}}
+	}
+	id1, id2 := ulid.MustNew(1, nil), ulid.MustNew(2, nil)
+
+	var nilSel *UploadSelector
+	testutil.Assert(t, nilSel.Selects(meta(id1, 0, 10)), "nil selector should select all blocks")
+
+	sel := NewUploadSelector(10, 20, nil)
+	testutil.Assert(t, !sel.Selects(meta(id1, 0, 10)), "block before time range selected")
+	testutil.Assert(t, sel.Selects(meta(id1, 5, 15)), "block overlapping with time range not selected")
+	testutil.Assert(t, sel.Selects(meta(id1, 20, 30)), "block overlapping with time range not selected")
+	testutil.Assert(t, !sel.Selects(meta(id1, 21, 30)), "block after time range selected")
+
+	sel = NewUploadSelector(math.MinInt64, math.MaxInt64, []ulid.ULID{id2})
+	testutil.Assert(t, !sel.Selects(meta(id1, 0, 10)), "block not in IDs selected")
+	testutil.Assert(t, sel.Selects(meta(id2, 0, 10)), "block in IDs not selected")
+}
@@ -0,0 +1,83 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package shipper
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const windowTimeFormat = "15:04"
+
+// UploadWindow is a daily time window in UTC, in which uploads are allowed. Windows with end before start span
+// midnight.
+type UploadWindow struct {
+	// Start and End are offsets from midnight.
+	Start, End time.Duration
+}
+
+// ParseUploadWindow parses window in HH:MM-HH:MM format.
+func ParseUploadWindow(s string) (UploadWindow, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return UploadWindow{}, errors.Errorf("invalid upload window %q, expected HH:MM-HH:MM", s)
+	}
+	start, err := time.Parse(windowTimeFormat, strings.TrimSpace(parts[0]))
+	if err != nil {
+		return UploadWindow{}, errors.Wrapf(err, "parse start of upload window %q", s)
+	}
+	end, err := time.Parse(windowTimeFormat, strings.TrimSpace(parts[1]))
+	if err != nil {
+		return UploadWindow{}, errors.Wrapf(err, "parse end of upload window %q", s)
+	}
+	w := UploadWindow{
+		Start: time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute,
+		End:   time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute,
+	}
+	if w.Start == w.End {
+		return UploadWindow{}, errors.Errorf("empty upload window %q", s)
+	}
+	return w, nil
+}
+
+// Contains returns true if given time is within the window.
+func (w UploadWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	since := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	if w.Start < w.End {
+		return since >= w.Start && since < w.End
+	}
+	return since >= w.Start || since < w.End
+}
+
+// UploadWindows is a set of upload windows. Empty set allows uploads at any time.
+type UploadWindows []UploadWindow
+
+// ParseUploadWindows parses windows in HH:MM-HH:MM format.
+func ParseUploadWindows(ss []string) (UploadWindows, error) {
+	ws := make(UploadWindows, 0, len(ss))
+	for _, s := range ss {
+		w, err := ParseUploadWindow(s)
+		if err != nil {
+			return nil, err
+		}
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
+
+// Contains returns true if given time is within any of the windows, or if there are no windows.
+func (ws UploadWindows) Contains(t time.Time) bool {
+	if len(ws) == 0 {
+		return true
+	}
+	for _, w := range ws {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package shipper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestUploadWindows(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2020, 3, 10, hour, min, 0, 0, time.UTC)
+	}
+
+	ws, err := ParseUploadWindows(nil)
+	testutil.Ok(t, err)
+	testutil.Assert(t, ws.Contains(at(12, 0)), "no windows should allow uploads at any time")
+
+	ws, err = ParseUploadWindows([]string{"22:00-06:00", "12:00-13:30"})
+	testutil.Ok(t, err)
+	for _, tcase := range []struct {
+		t        time.Time
+		expected bool
+	}{
+		{t: at(21, 59), expected: false},
+		{t: at(22, 0), expected: true},
+		{t: at(0, 0), expected: true},
+		{t: at(5, 59), expected: true},
+		{t: at(6, 0), expected: false},
+		{t: at(12, 0), expected: true},
+		{t: at(13, 29), expected: true},
+		{t: at(13, 30), expected: false},
+		// Windows are in UTC.
+		{t: at(23, 0).In(time.FixedZone("UTC-8", -8*3600)), expected: true},
+	} {
+		testutil.Equals(t, tcase.expected, ws.Contains(tcase.t), "%v", tcase.t)
+	}
+
+	for _, s := range []string{"22:00", "10:00-10:00", "25:00-26:00", "a-b"} {
+		_, err := ParseUploadWindows([]string{s})
+		testutil.NotOk(t, err, s)
+	}
+}
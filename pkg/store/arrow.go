@@ -0,0 +1,89 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package store
+
+import (
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+// defaultArrowBatchBytes is the size of Arrow batches if the Series request does not request a batch size.
+const defaultArrowBatchBytes = 1024 * 1024
+
+// seriesFlusher is a Series server which has to be flushed once all series are sent.
+type seriesFlusher interface {
+	storepb.Store_SeriesServer
+	Flush() error
+}
+
+// newSeriesFlusher returns the server sending series as requested by the Series request: as Arrow batches if
+// requested and arrowSeries is enabled, or as batches of series otherwise.
+func newSeriesFlusher(srv storepb.Store_SeriesServer, req *storepb.SeriesRequest, arrowSeries bool) seriesFlusher {
+	if arrowSeries && req.ArrowBatches && req.MaxResolutionWindow == 0 && !req.SkipChunks {
+		return newArrowSeriesServer(srv, req)
+	}
+	return newBatchSeriesServer(srv, req)
+}
+
+// arrowSeriesServer encodes series of raw data sent to the Series server into Arrow batch responses of at least
+// maxBytes, unless they are the last series sent. Samples are trimmed to the requested time range. Warnings are sent
+// right away, after the series encoded so far. Flush has to be called once all series are sent.
+type arrowSeriesServer struct {
+	storepb.Store_SeriesServer
+
+	mint, maxt int64
+	maxBytes   int
+	b          *storepb.ArrowBatchBuilder
+}
+
+func newArrowSeriesServer(srv storepb.Store_SeriesServer, req *storepb.SeriesRequest) *arrowSeriesServer {
+	maxBytes := int(req.ResponseBatchBytes)
+	if maxBytes <= 0 {
+		maxBytes = defaultArrowBatchBytes
+	}
+	return &arrowSeriesServer{
+		Store_SeriesServer: srv,
+		mint:               req.MinTime,
+		maxt:               req.MaxTime,
+		maxBytes:           maxBytes,
+		b:                  storepb.NewArrowBatchBuilder(),
+	}
+}
+
+func (s *arrowSeriesServer) Send(r *storepb.SeriesResponse) error {
+	var series []storepb.Series
+	switch {
+	case r.GetSeries() != nil:
+		series = []storepb.Series{*r.GetSeries()}
+	case r.GetBatch() != nil:
+		series = r.GetBatch().Series
+	default:
+		if err := s.Flush(); err != nil {
+			return err
+		}
+		return s.Store_SeriesServer.Send(r)
+	}
+
+	for i := range series {
+		if err := s.b.Append(&series[i], s.mint, s.maxt); err != nil {
+			return errors.Wrap(err, "encode series")
+		}
+	}
+	if s.b.Size() < s.maxBytes {
+		return nil
+	}
+	return s.Flush()
+}
+
+// Flush sends the series encoded so far.
+func (s *arrowSeriesServer) Flush() error {
+	if s.b.Len() == 0 {
+		return nil
+	}
+	b, err := s.b.Encode()
+	if err != nil {
+		return errors.Wrap(err, "encode Arrow batch")
+	}
+	return s.Store_SeriesServer.Send(&storepb.SeriesResponse{Result: &storepb.SeriesResponse_ArrowBatch{ArrowBatch: b}})
+}
@@ -0,0 +1,54 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package store
+
+import (
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+)
+
+// batchSeriesServer batches series sent to the Series server into batch responses of at least maxBytes, unless they
+// are the last series sent. Warnings are sent right away, after the series batched so far. Flush has to be called
+// once all series are sent.
+type batchSeriesServer struct {
+	storepb.Store_SeriesServer
+
+	maxBytes int
+	size     int
+	batch    []storepb.Series
+}
+
+// newBatchSeriesServer returns the server batching series as requested by the Series request. It sends a response per
+// series if batches are not requested.
+func newBatchSeriesServer(srv storepb.Store_SeriesServer, req *storepb.SeriesRequest) *batchSeriesServer {
+	return &batchSeriesServer{Store_SeriesServer: srv, maxBytes: int(req.ResponseBatchBytes)}
+}
+
+func (s *batchSeriesServer) Send(r *storepb.SeriesResponse) error {
+	series := r.GetSeries()
+	if s.maxBytes <= 0 || series == nil {
+		if err := s.Flush(); err != nil {
+			return err
+		}
+		return s.Store_SeriesServer.Send(r)
+	}
+
+	s.batch = append(s.batch, *series)
+	s.size += series.Size()
+	if s.size < s.maxBytes {
+		return nil
+	}
+	return s.Flush()
+}
+
+// Flush sends the series batched so far.
+func (s *batchSeriesServer) Flush() error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+	err := s.Store_SeriesServer.Send(storepb.NewSeriesBatchResponse(s.batch))
+	// In-process servers may keep sent responses, so the batch is not reused.
+	s.batch = make([]storepb.Series, 0, len(s.batch))
+	s.size = 0
+	return err
+}
@@ -17,6 +17,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -46,6 +47,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/strutil"
 	"github.com/thanos-io/thanos/pkg/tracing"
+	"github.com/thanos-io/thanos/pkg/usage"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -253,10 +255,28 @@ type BucketStore struct {
 	// This makes them smaller, but takes extra CPU and memory.
 	// When used with in-memory cache, memory usage should decrease overall, thanks to postings being smaller.
 	enablePostingsCompression bool
+
+	// Interner of label names and values of series, if not nil.
+	interner *strutil.Interner
+
+	// accountant accounts usage of Series calls to the tenant of the gRPC metadata key of tenantHeader, if not nil.
+	accountant   *usage.Accountant
+	tenantHeader string
+
+	// Send series of raw data as Arrow batches to clients requesting them, advertised by the arrow_series capability.
+	enableArrowSeries bool
+
+	// indexHeaderGate limits the number of index-headers built at once on first use of blocks, if new blocks are
+	// admitted before their index-header is built. Nil otherwise.
+	indexHeaderGate gate.Gater
 }
 
 // NewBucketStore creates a new bucket backed store that implements the store API against
 // an object store bucket. It is optimized to work against high latency backends.
+// Label names and values of series are interned by the given interner, unless it is nil.
+// Usage of Series calls is accounted by the given accountant to the tenant of the tenant header, unless it is nil.
+// If lazyIndexHeaderConcurrency is positive, new blocks are served as soon as they are synced, and their index-headers
+// are built on first use, by up to lazyIndexHeaderConcurrency at once.
 func NewBucketStore(
 	logger log.Logger,
 	reg prometheus.Registerer,
@@ -273,6 +293,11 @@ func NewBucketStore(
 	enableCompatibilityLabel bool,
 	enableIndexHeader bool,
 	enablePostingsCompression bool,
+	interner *strutil.Interner,
+	accountant *usage.Accountant,
+	tenantHeader string,
+	enableArrowSeries bool,
+	lazyIndexHeaderConcurrency int,
 ) (*BucketStore, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -309,8 +334,18 @@ func NewBucketStore(
 		enableCompatibilityLabel:  enableCompatibilityLabel,
 		enableIndexHeader:         enableIndexHeader,
 		enablePostingsCompression: enablePostingsCompression,
+		interner:                  interner,
+		accountant:                accountant,
+		tenantHeader:              tenantHeader,
+		enableArrowSeries:         enableArrowSeries,
 	}
 	s.metrics = metrics
+	if lazyIndexHeaderConcurrency > 0 {
+		s.indexHeaderGate = gate.NewGate(
+			lazyIndexHeaderConcurrency,
+			extprom.WrapRegistererWithPrefix("thanos_bucket_store_index_header_build_", reg),
+		)
+	}
 
 	if err := os.MkdirAll(dir, 0777); err != nil {
 		return nil, errors.Wrap(err, "create dir")
@@ -461,16 +496,27 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err er
 	lset := labels.FromMap(meta.Thanos.Labels)
 	h := lset.Hash()
 
-	var indexHeaderReader indexheader.Reader
-	if s.enableIndexHeader {
-		indexHeaderReader, err = indexheader.NewBinaryReader(ctx, s.logger, s.bkt, s.dir, meta.ULID)
-		if err != nil {
-			return errors.Wrap(err, "create index header reader")
+	var (
+		indexHeaderReader indexheader.Reader
+		indexHeaderSize   int64
+	)
+	if s.indexHeaderGate != nil {
+		indexHeaderReader = &lazyIndexHeaderReader{
+			gate: s.indexHeaderGate,
+			create: func(ctx context.Context) (indexheader.Reader, int64, error) {
+				r, size, err := s.newIndexHeaderReader(ctx, meta)
+				if err != nil {
+					s.metrics.blockLoadFailures.Inc()
+					level.Warn(s.logger).Log("msg", "building index-header of block failed", "id", meta.ULID, "err", err)
+					return nil, 0, errors.Wrapf(err, "build index-header of block %s", meta.ULID)
+				}
+				return r, size, nil
+			},
 		}
 	} else {
-		indexHeaderReader, err = indexheader.NewJSONReader(ctx, s.logger, s.bkt, s.dir, meta.ULID)
+		indexHeaderReader, indexHeaderSize, err = s.newIndexHeaderReader(ctx, meta)
 		if err != nil {
-			return errors.Wrap(err, "create index cache reader")
+			return err
 		}
 	}
 	defer func() {
@@ -495,6 +541,7 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err er
 	if err != nil {
 		return errors.Wrap(err, "new bucket block")
 	}
+	b.indexHeaderSize = indexHeaderSize
 	defer func() {
 		if err != nil {
 			runutil.CloseWithErrCapture(&err, b, "index-header")
@@ -522,6 +569,36 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err er
 	return nil
 }
 
+// newIndexHeaderReader builds the index-header of the block, unless it is on disk already, and returns its reader and
+// its size on disk.
+func (s *BucketStore) newIndexHeaderReader(ctx context.Context, meta *metadata.Meta) (r indexheader.Reader, size int64, err error) {
+	if s.enableIndexHeader {
+		r, err = indexheader.NewBinaryReader(ctx, s.logger, s.bkt, s.dir, meta.ULID)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "create index header reader")
+		}
+	} else {
+		r, err = indexheader.NewJSONReader(ctx, s.logger, s.bkt, s.dir, meta.ULID)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "create index cache reader")
+		}
+	}
+	if s.interner != nil {
+		r = internedReader{Reader: r, interner: s.interner}
+	}
+
+	indexHeaderFilename := block.IndexCacheFilename
+	if s.enableIndexHeader {
+		indexHeaderFilename = block.IndexHeaderFilename
+	}
+	if fi, err := os.Stat(filepath.Join(s.dir, meta.ULID.String(), indexHeaderFilename)); err == nil {
+		size = fi.Size()
+	} else {
+		level.Warn(s.logger).Log("msg", "failed to get size of index-header", "id", meta.ULID, "err", err)
+	}
+	return r, size, nil
+}
+
 func (s *BucketStore) removeBlock(id ulid.ULID) error {
 	s.mtx.Lock()
 	b, ok := s.blocks[id]
@@ -543,6 +620,77 @@ func (s *BucketStore) removeBlock(id ulid.ULID) error {
 	return os.RemoveAll(b.dir)
 }
 
+// LoadedBlock describes a block loaded by the bucket store.
+type LoadedBlock struct {
+	ULID       ulid.ULID         `json:"ulid"`
+	MinTime    int64             `json:"minTime"`
+	MaxTime    int64             `json:"maxTime"`
+	Resolution int64             `json:"resolution"`
+	Labels     map[string]string `json:"labels"`
+	NumSeries  uint64            `json:"numSeries"`
+	NumSamples uint64            `json:"numSamples"`
+	// IndexHeaderSize is the size of the index-header (or index cache) file on disk in bytes.
+	IndexHeaderSize int64 `json:"indexHeaderSize"`
+	// LastAccess is the time of the last request reading the block. It is zero if the block was not read yet.
+	LastAccess time.Time `json:"lastAccess"`
+}
+
+// LoadedBlocks returns all blocks currently loaded by the store, sorted by external labels, resolution and time.
+func (s *BucketStore) LoadedBlocks() []LoadedBlock {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	res := make([]LoadedBlock, 0, len(s.blocks))
+	for _, b := range s.blocks {
+		lb := LoadedBlock{
+			ULID:            b.meta.ULID,
+			MinTime:         b.meta.MinTime,
+			MaxTime:         b.meta.MaxTime,
+			Resolution:      b.meta.Thanos.Downsample.Resolution,
+			Labels:          b.meta.Thanos.Labels,
+			NumSeries:       b.meta.Stats.NumSeries,
+			NumSamples:      b.meta.Stats.NumSamples,
+			IndexHeaderSize: b.getIndexHeaderSize(),
+		}
+		if t := atomic.LoadInt64(&b.lastAccess); t != 0 {
+			lb.LastAccess = time.Unix(0, t)
+		}
+		res = append(res, lb)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if c := labels.Compare(labels.FromMap(res[i].Labels), labels.FromMap(res[j].Labels)); c != 0 {
+			return c < 0
+		}
+		if res[i].Resolution != res[j].Resolution {
+			return res[i].Resolution < res[j].Resolution
+		}
+		if res[i].MinTime != res[j].MinTime {
+			return res[i].MinTime < res[j].MinTime
+		}
+		return res[i].ULID.Compare(res[j].ULID) < 0
+	})
+	return res
+}
+
+// LoadedBlocksTotals are totals of loaded blocks.
+type LoadedBlocksTotals struct {
+	Blocks          int    `json:"blocks"`
+	NumSeries       uint64 `json:"numSeries"`
+	NumSamples      uint64 `json:"numSamples"`
+	IndexHeaderSize int64  `json:"indexHeaderSize"`
+}
+
+// SumLoadedBlocks returns totals of the given loaded blocks.
+func SumLoadedBlocks(blocks []LoadedBlock) LoadedBlocksTotals {
+	res := LoadedBlocksTotals{Blocks: len(blocks)}
+	for _, b := range blocks {
+		res.NumSeries += b.NumSeries
+		res.NumSamples += b.NumSamples
+		res.IndexHeaderSize += b.IndexHeaderSize
+	}
+	return res
+}
+
 // TimeRange returns the minimum and maximum timestamp of data available in the store.
 func (s *BucketStore) TimeRange() (mint, maxt int64) {
 	s.mtx.RLock()
@@ -585,6 +733,9 @@ func (s *BucketStore) Info(context.Context, *storepb.InfoRequest) (*storepb.Info
 		// See query.StoreCompatibilityTypeLabelName comment for details.
 		res.LabelSets = append(res.LabelSets, storepb.LabelSet{Labels: []storepb.Label{{Name: CompatibilityTypeLabelName, Value: "store"}}})
 	}
+	if s.enableArrowSeries {
+		res.Capabilities = []string{storepb.CapabilityArrowSeries}
+	}
 	return res, nil
 }
 
@@ -749,6 +900,7 @@ func blockSeries(
 			if err := populateChunk(&s.chks[i], chk, req.Aggregates); err != nil {
 				return nil, nil, errors.Wrap(err, "populate chunk")
 			}
+			chunkr.stats.chunksSamples += chunkSamples(&s.chks[i])
 		}
 	}
 
@@ -894,6 +1046,9 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 			defer runutil.CloseWithLogOnErr(s.logger, chunkr, "series block")
 
 			g.Go(func() error {
+				if err := b.loadIndexHeader(gctx); err != nil {
+					return err
+				}
 				part, pstats, err := blockSeries(
 					b.meta.Thanos.Labels,
 					indexr,
@@ -905,6 +1060,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 				if err != nil {
 					return errors.Wrapf(err, "fetch series for block %s", b.meta.ULID)
 				}
+				b.addQueryStats(pstats)
 
 				mtx.Lock()
 				res = append(res, part)
@@ -940,6 +1096,10 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		s.metrics.cachedPostingsCompressionTimeSeconds.WithLabelValues("decode").Add(stats.cachedPostingsDecompressionTimeSum.Seconds())
 		s.metrics.cachedPostingsOriginalSizeBytes.Add(float64(stats.cachedPostingsOriginalSizeSum))
 		s.metrics.cachedPostingsCompressedSizeBytes.Add(float64(stats.cachedPostingsCompressedSizeSum))
+		s.accountant.Add(usage.TenantFromIncomingContext(ctx, s.tenantHeader), usage.Usage{
+			BytesFetched:     uint64(stats.postingsFetchedSizeSum + stats.seriesFetchedSizeSum + stats.chunksFetchedSizeSum),
+			SamplesProcessed: uint64(stats.chunksSamples),
+		})
 
 		level.Debug(s.logger).Log("msg", "stats query processed",
 			"stats", fmt.Sprintf("%+v", stats), "err", err)
@@ -966,6 +1126,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		// Chunks of returned series might be out of order w.r.t to their time range.
 		// This must be accounted for later by clients.
 		set := storepb.MergeSeriesSets(res...)
+		batchSrv := newSeriesFlusher(srv, req, s.enableArrowSeries)
 		for set.Next() {
 			var series storepb.Series
 
@@ -980,7 +1141,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 				s.metrics.chunkSizeBytes.Observe(float64(chunksSize(series.Chunks)))
 			}
 
-			if err = srv.Send(storepb.NewSeriesResponse(&series)); err != nil {
+			if err = batchSrv.Send(storepb.NewSeriesResponse(&series)); err != nil {
 				err = status.Error(codes.Unknown, errors.Wrap(err, "send series response").Error())
 				return
 			}
@@ -989,6 +1150,11 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 			err = status.Error(codes.Unknown, errors.Wrap(set.Err(), "expand series set").Error())
 			return
 		}
+		// Batched series have to be sent before their chunks are released.
+		if err = batchSrv.Flush(); err != nil {
+			err = status.Error(codes.Unknown, errors.Wrap(err, "send series response").Error())
+			return
+		}
 		stats.mergeDuration = time.Since(begin)
 		s.metrics.seriesMergeDuration.Observe(stats.mergeDuration.Seconds())
 
@@ -997,6 +1163,22 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	return err
 }
 
+// chunkSamples returns the number of samples of the chunk. All aggregates of downsampled chunks have the same number of
+// samples, so the first one is counted.
+func chunkSamples(chk *storepb.AggrChunk) int {
+	for _, c := range []*storepb.Chunk{chk.Raw, chk.Count, chk.Sum, chk.Min, chk.Max, chk.Counter} {
+		if c == nil {
+			continue
+		}
+		// XOR chunks begin with their number of samples.
+		if len(c.Data) < 2 {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(c.Data))
+	}
+	return 0
+}
+
 func chunksSize(chks []storepb.AggrChunk) (size int) {
 	for _, chk := range chks {
 		size += chk.Size() // This gets the encoded proto size.
@@ -1018,6 +1200,10 @@ func (s *BucketStore) LabelNames(ctx context.Context, _ *storepb.LabelNamesReque
 		g.Go(func() error {
 			defer runutil.CloseWithLogOnErr(s.logger, indexr, "label names")
 
+			if err := indexr.block.loadIndexHeader(gctx); err != nil {
+				return err
+			}
+
 			// Do it via index reader to have pending reader registered correctly.
 			res := indexr.block.indexHeaderReader.LabelNames()
 			sort.Strings(res)
@@ -1054,6 +1240,10 @@ func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesR
 		g.Go(func() error {
 			defer runutil.CloseWithLogOnErr(s.logger, indexr, "label values")
 
+			if err := indexr.block.loadIndexHeader(gctx); err != nil {
+				return err
+			}
+
 			// Do it via index reader to have pending reader registered correctly.
 			res, err := indexr.block.indexHeaderReader.LabelValues(req.Label)
 			if err != nil {
@@ -1211,6 +1401,10 @@ func (s *bucketBlockSet) labelMatchers(matchers ...*labels.Matcher) ([]*labels.M
 // bucketBlock represents a block that is located in a bucket. It holds intermediate
 // state for the block on local disk.
 type bucketBlock struct {
+	// lastAccess is the Unix time in nanoseconds of the last read of the block, accessed atomically. It is the first
+	// field to be aligned on 32-bit platforms.
+	lastAccess int64
+
 	logger     log.Logger
 	bkt        objstore.BucketReader
 	meta       *metadata.Meta
@@ -1219,6 +1413,8 @@ type bucketBlock struct {
 	chunkPool  pool.BytesPool
 
 	indexHeaderReader indexheader.Reader
+	// indexHeaderSize is the size of the index-header file on disk.
+	indexHeaderSize int64
 
 	chunkObjs []string
 
@@ -1229,6 +1425,11 @@ type bucketBlock struct {
 	seriesRefetches prometheus.Counter
 
 	enablePostingsCompression bool
+
+	// Statistics of Series requests reading the block since it was loaded.
+	queryStatsMtx sync.Mutex
+	queries       int
+	queryStats    queryStats
 }
 
 func newBucketBlock(
@@ -1309,6 +1510,7 @@ func (b *bucketBlock) readChunkRange(ctx context.Context, seq int, off, length i
 
 func (b *bucketBlock) indexReader(ctx context.Context) *bucketIndexReader {
 	b.pendingReaders.Add(1)
+	atomic.StoreInt64(&b.lastAccess, time.Now().UnixNano())
 	return newBucketIndexReader(ctx, b)
 }
 
@@ -1317,6 +1519,23 @@ func (b *bucketBlock) chunkReader(ctx context.Context) *bucketChunkReader {
 	return newBucketChunkReader(ctx, b)
 }
 
+// loadIndexHeader builds the index-header of a block admitted before it was built, unless it is built already.
+func (b *bucketBlock) loadIndexHeader(ctx context.Context) error {
+	if r, ok := b.indexHeaderReader.(*lazyIndexHeaderReader); ok {
+		return r.load(ctx)
+	}
+	return nil
+}
+
+// getIndexHeaderSize returns the size of the index-header of the block on disk, which is zero until the index-header
+// of a block admitted before it was built is built.
+func (b *bucketBlock) getIndexHeaderSize() int64 {
+	if r, ok := b.indexHeaderReader.(*lazyIndexHeaderReader); ok {
+		return r.indexHeaderSize()
+	}
+	return b.indexHeaderSize
+}
+
 // Close waits for all pending readers to finish and then closes all underlying resources.
 func (b *bucketBlock) Close() error {
 	b.pendingReaders.Wait()
@@ -1325,6 +1544,21 @@ func (b *bucketBlock) Close() error {
 
 // bucketIndexReader is a custom index reader (not conforming index.Reader interface) that reads index that is stored in
 // object storage without having to fully download it.
+// internedReader interns symbols looked up, which are label names and values of series, so series held until they
+// are sent share them with each other and with series of other requests.
+type internedReader struct {
+	indexheader.Reader
+	interner *strutil.Interner
+}
+
+func (r internedReader) LookupSymbol(o uint32) (string, error) {
+	s, err := r.Reader.LookupSymbol(o)
+	if err != nil {
+		return "", err
+	}
+	return r.interner.Intern(s), nil
+}
+
 type bucketIndexReader struct {
 	ctx   context.Context
 	block *bucketBlock
@@ -2076,6 +2310,7 @@ type queryStats struct {
 	chunksFetchedSizeSum   int
 	chunksFetchCount       int
 	chunksFetchDurationSum time.Duration
+	chunksSamples          int
 
 	getAllDuration    time.Duration
 	mergedSeriesCount int
@@ -2115,6 +2350,7 @@ func (s queryStats) merge(o *queryStats) *queryStats {
 	s.chunksFetchedSizeSum += o.chunksFetchedSizeSum
 	s.chunksFetchCount += o.chunksFetchCount
 	s.chunksFetchDurationSum += o.chunksFetchDurationSum
+	s.chunksSamples += o.chunksSamples
 
 	s.getAllDuration += o.getAllDuration
 	s.mergedSeriesCount += o.mergedSeriesCount
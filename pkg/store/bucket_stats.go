@@ -0,0 +1,178 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package store
+
+import (
+	"sort"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Costs of blocks the most expensive blocks can be ranked by.
+const (
+	BlockCostFetchedBytes  = "fetched_bytes"
+	BlockCostFetchSeconds  = "fetch_seconds"
+	BlockCostSeriesTouched = "series_touched"
+	BlockCostChunksTouched = "chunks_touched"
+	BlockCostQueries       = "queries"
+)
+
+var blockCosts = map[string]func(s *BlockQueryStats) float64{
+	BlockCostFetchedBytes:  func(s *BlockQueryStats) float64 { return float64(s.FetchedBytes) },
+	BlockCostFetchSeconds:  func(s *BlockQueryStats) float64 { return s.FetchSeconds },
+	BlockCostSeriesTouched: func(s *BlockQueryStats) float64 { return float64(s.SeriesTouched) },
+	BlockCostChunksTouched: func(s *BlockQueryStats) float64 { return float64(s.ChunksTouched) },
+	BlockCostQueries:       func(s *BlockQueryStats) float64 { return float64(s.Queries) },
+}
+
+// BlockCosts returns the costs of blocks the most expensive blocks can be ranked by.
+func BlockCosts() []string {
+	res := make([]string, 0, len(blockCosts))
+	for c := range blockCosts {
+		res = append(res, c)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// IsBlockCost returns true if blocks can be ranked by the given cost.
+func IsBlockCost(cost string) bool {
+	_, ok := blockCosts[cost]
+	return ok
+}
+
+// BlockQueryStats are statistics of Series requests reading a block since it was loaded by the store.
+type BlockQueryStats struct {
+	ULID       ulid.ULID         `json:"ulid"`
+	MinTime    int64             `json:"minTime"`
+	MaxTime    int64             `json:"maxTime"`
+	Resolution int64             `json:"resolution"`
+	Labels     map[string]string `json:"labels"`
+
+	Queries         int `json:"queries"`
+	PostingsTouched int `json:"postingsTouched"`
+	SeriesTouched   int `json:"seriesTouched"`
+	ChunksTouched   int `json:"chunksTouched"`
+	// FetchedBytes is the size of postings, series and chunks fetched from the bucket or the index cache.
+	FetchedBytes int `json:"fetchedBytes"`
+	// FetchSeconds is the time spent fetching postings, series and chunks.
+	FetchSeconds float64 `json:"fetchSeconds"`
+}
+
+// addQueryStats accounts the statistics of a Series request to the block.
+func (b *bucketBlock) addQueryStats(s *queryStats) {
+	b.queryStatsMtx.Lock()
+	defer b.queryStatsMtx.Unlock()
+
+	b.queries++
+	b.queryStats = *b.queryStats.merge(s)
+}
+
+func (b *bucketBlock) blockQueryStats() BlockQueryStats {
+	b.queryStatsMtx.Lock()
+	defer b.queryStatsMtx.Unlock()
+
+	s := b.queryStats
+	return BlockQueryStats{
+		ULID:            b.meta.ULID,
+		MinTime:         b.meta.MinTime,
+		MaxTime:         b.meta.MaxTime,
+		Resolution:      b.meta.Thanos.Downsample.Resolution,
+		Labels:          b.meta.Thanos.Labels,
+		Queries:         b.queries,
+		PostingsTouched: s.postingsTouched,
+		SeriesTouched:   s.seriesTouched,
+		ChunksTouched:   s.chunksTouched,
+		FetchedBytes:    s.postingsFetchedSizeSum + s.seriesFetchedSizeSum + s.chunksFetchedSizeSum,
+		FetchSeconds:    (s.postingsFetchDurationSum + s.seriesFetchDurationSum + s.chunksFetchDurationSum).Seconds(),
+	}
+}
+
+// ExpensiveBlocks returns the statistics of up to limit loaded blocks with the highest given cost, most expensive
+// first. Blocks which were not queried are omitted.
+func (s *BucketStore) ExpensiveBlocks(limit int, cost string) []BlockQueryStats {
+	costFn, ok := blockCosts[cost]
+	if !ok {
+		return nil
+	}
+
+	s.mtx.RLock()
+	res := make([]BlockQueryStats, 0, len(s.blocks))
+	for _, b := range s.blocks {
+		if bs := b.blockQueryStats(); bs.Queries > 0 {
+			res = append(res, bs)
+		}
+	}
+	s.mtx.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		if ci, cj := costFn(&res[i]), costFn(&res[j]); ci != cj {
+			return ci > cj
+		}
+		return res[i].ULID.Compare(res[j].ULID) < 0
+	})
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
+// expensiveBlocksCollector exposes statistics of the blocks with the most bytes fetched. The blocks exposed change over
+// time, so only a fixed number of series is exposed regardless of the number of loaded blocks.
+type expensiveBlocksCollector struct {
+	store *BucketStore
+	limit int
+
+	fetchedBytesDesc  *prometheus.Desc
+	fetchSecondsDesc  *prometheus.Desc
+	seriesTouchedDesc *prometheus.Desc
+	queriesDesc       *prometheus.Desc
+}
+
+// NewExpensiveBlocksCollector returns a collector of statistics of the limit blocks of the store with the most bytes
+// fetched.
+func NewExpensiveBlocksCollector(s *BucketStore, limit int) prometheus.Collector {
+	return &expensiveBlocksCollector{
+		store: s,
+		limit: limit,
+		fetchedBytesDesc: prometheus.NewDesc(
+			"thanos_bucket_store_expensive_block_fetched_bytes_total",
+			"Total size of postings, series and chunks fetched of the blocks with the most bytes fetched.",
+			[]string{"block"}, nil,
+		),
+		fetchSecondsDesc: prometheus.NewDesc(
+			"thanos_bucket_store_expensive_block_fetch_seconds_total",
+			"Total time spent fetching postings, series and chunks of the blocks with the most bytes fetched.",
+			[]string{"block"}, nil,
+		),
+		seriesTouchedDesc: prometheus.NewDesc(
+			"thanos_bucket_store_expensive_block_series_touched_total",
+			"Total number of series touched of the blocks with the most bytes fetched.",
+			[]string{"block"}, nil,
+		),
+		queriesDesc: prometheus.NewDesc(
+			"thanos_bucket_store_expensive_block_queries_total",
+			"Total number of Series requests reading the blocks with the most bytes fetched.",
+			[]string{"block"}, nil,
+		),
+	}
+}
+
+func (c *expensiveBlocksCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.fetchedBytesDesc
+	ch <- c.fetchSecondsDesc
+	ch <- c.seriesTouchedDesc
+	ch <- c.queriesDesc
+}
+
+func (c *expensiveBlocksCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, b := range c.store.ExpensiveBlocks(c.limit, BlockCostFetchedBytes) {
+		id := b.ULID.String()
+		ch <- prometheus.MustNewConstMetric(c.fetchedBytesDesc, prometheus.CounterValue, float64(b.FetchedBytes), id)
+		ch <- prometheus.MustNewConstMetric(c.fetchSecondsDesc, prometheus.CounterValue, b.FetchSeconds, id)
+		ch <- prometheus.MustNewConstMetric(c.seriesTouchedDesc, prometheus.CounterValue, float64(b.SeriesTouched), id)
+		ch <- prometheus.MustNewConstMetric(c.queriesDesc, prometheus.CounterValue, float64(b.Queries), id)
+	}
+}
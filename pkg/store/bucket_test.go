@@ -49,6 +49,8 @@ import (
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
 	"github.com/thanos-io/thanos/pkg/testutil/e2eutil"
+	"github.com/thanos-io/thanos/pkg/usage"
+	grpcmetadata "google.golang.org/grpc/metadata"
 	"gopkg.in/yaml.v2"
 )
 
@@ -481,6 +483,11 @@ func TestBucketStore_Info(t *testing.T) {
 		true,
 		true,
 		true,
+		nil,
+		nil,
+		"",
+		false,
+		0,
 	)
 	testutil.Ok(t, err)
 
@@ -733,6 +740,11 @@ func testSharding(t *testing.T, reuseDisk string, bkt objstore.Bucket, all ...ul
 				true,
 				true,
 				true,
+				nil,
+				nil,
+				"",
+				false,
+				0,
 			)
 			testutil.Ok(t, err)
 
@@ -1614,3 +1626,229 @@ func TestSeries_OneBlock_InMemIndexCacheSegfault(t *testing.T) {
 		testutil.Equals(t, numSeries, len(srv.SeriesSet))
 	})
 }
+
+func TestBucketStore_LoadedBlocks(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	dir, err := ioutil.TempDir("", "test-loaded-blocks")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	series := []labels.Labels{labels.FromStrings("a", "1", "b", "1")}
+
+	id1, err := e2eutil.CreateBlock(ctx, dir, series, 10, 1000, 2000, labels.Labels{{Name: "cluster", Value: "a"}}, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, block.Upload(ctx, logger, bkt, filepath.Join(dir, id1.String())))
+
+	id2, err := e2eutil.CreateBlock(ctx, dir, series, 10, 0, 1000, labels.Labels{{Name: "cluster", Value: "a"}}, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, block.Upload(ctx, logger, bkt, filepath.Join(dir, id2.String())))
+
+	metaFetcher, err := block.NewMetaFetcher(logger, 20, bkt, dir, nil, nil, nil)
+	testutil.Ok(t, err)
+
+	bucketStore, err := NewBucketStore(logger, nil, bkt, metaFetcher, filepath.Join(dir, "store"), noopCache{}, 0, 0, 99, false, 20, allowAllFilterConf, true, true, true, nil, nil, "", false, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, bucketStore.InitialSync(ctx))
+
+	blocks := bucketStore.LoadedBlocks()
+	testutil.Equals(t, 2, len(blocks))
+	// Blocks of the same labels and resolution are sorted by time.
+	testutil.Equals(t, id2, blocks[0].ULID)
+	testutil.Equals(t, id1, blocks[1].ULID)
+	for _, b := range blocks {
+		testutil.Equals(t, map[string]string{"cluster": "a"}, b.Labels)
+		testutil.Equals(t, uint64(1), b.NumSeries)
+		testutil.Assert(t, b.IndexHeaderSize > 0, "expected index-header size of %s", b.ULID)
+		testutil.Assert(t, b.LastAccess.IsZero(), "expected %s not accessed", b.ULID)
+	}
+
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, bucketStore.Series(&storepb.SeriesRequest{
+		MinTime:  1000,
+		MaxTime:  2000,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+	}, srv))
+
+	blocks = bucketStore.LoadedBlocks()
+	testutil.Assert(t, !blocks[1].LastAccess.IsZero(), "expected queried block to be accessed")
+
+	totals := SumLoadedBlocks(blocks)
+	testutil.Equals(t, 2, totals.Blocks)
+	testutil.Equals(t, uint64(2), totals.NumSeries)
+	testutil.Equals(t, blocks[0].IndexHeaderSize+blocks[1].IndexHeaderSize, totals.IndexHeaderSize)
+}
+
+func TestBucketStore_UsageAccounting(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	dir, err := ioutil.TempDir("", "test-usage-accounting")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	series := []labels.Labels{labels.FromStrings("a", "1", "b", "1"), labels.FromStrings("a", "1", "b", "2")}
+
+	id, err := e2eutil.CreateBlock(ctx, dir, series, 10, 0, 1000, labels.Labels{{Name: "cluster", Value: "a"}}, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, block.Upload(ctx, logger, bkt, filepath.Join(dir, id.String())))
+
+	metaFetcher, err := block.NewMetaFetcher(logger, 20, bkt, dir, nil, nil, nil)
+	testutil.Ok(t, err)
+
+	reg := prometheus.NewRegistry()
+	accountant := usage.NewAccountant(reg)
+	bucketStore, err := NewBucketStore(logger, nil, bkt, metaFetcher, filepath.Join(dir, "store"), noopCache{}, 0, 0, 99, false, 20, allowAllFilterConf, true, true, true, nil, accountant, "THANOS-TENANT", false, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, bucketStore.InitialSync(ctx))
+
+	for _, tenant := range []string{"team-a", ""} {
+		srv := newStoreSeriesServer(grpcmetadata.NewIncomingContext(ctx, grpcmetadata.Pairs("thanos-tenant", tenant)))
+		testutil.Ok(t, bucketStore.Series(&storepb.SeriesRequest{
+			MinTime:  0,
+			MaxTime:  1000,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+		}, srv))
+		testutil.Equals(t, 2, len(srv.SeriesSet))
+	}
+
+	// Usage of Series calls without tenant is accounted to the unknown tenant.
+	mfs, err := reg.Gather()
+	testutil.Ok(t, err)
+	usages := map[string]map[string]float64{}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			tenant := m.GetLabel()[0].GetValue()
+			if usages[tenant] == nil {
+				usages[tenant] = map[string]float64{}
+			}
+			usages[tenant][mf.GetName()] = m.GetCounter().GetValue()
+		}
+	}
+	testutil.Equals(t, 2, len(usages))
+	for _, tenant := range []string{"team-a", usage.UnknownTenant} {
+		testutil.Equals(t, 20.0, usages[tenant]["thanos_usage_samples_processed_total"])
+		testutil.Assert(t, usages[tenant]["thanos_usage_bytes_fetched_total"] > 0, "expected bytes fetched by %s", tenant)
+	}
+}
+
+func TestBucketStore_ExpensiveBlocks(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	dir, err := ioutil.TempDir("", "test-expensive-blocks")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	series := []labels.Labels{labels.FromStrings("a", "1", "b", "1"), labels.FromStrings("a", "1", "b", "2")}
+
+	// The block of cluster a has more series matching the request below.
+	idA, err := e2eutil.CreateBlock(ctx, dir, series, 10, 0, 1000, labels.Labels{{Name: "cluster", Value: "a"}}, 0)
+	testutil.Ok(t, err)
+	idB, err := e2eutil.CreateBlock(ctx, dir, series[:1], 10, 0, 1000, labels.Labels{{Name: "cluster", Value: "b"}}, 0)
+	testutil.Ok(t, err)
+	idC, err := e2eutil.CreateBlock(ctx, dir, series, 10, 2000, 3000, labels.Labels{{Name: "cluster", Value: "c"}}, 0)
+	testutil.Ok(t, err)
+	for _, id := range []ulid.ULID{idA, idB, idC} {
+		testutil.Ok(t, block.Upload(ctx, logger, bkt, filepath.Join(dir, id.String())))
+	}
+
+	metaFetcher, err := block.NewMetaFetcher(logger, 20, bkt, dir, nil, nil, nil)
+	testutil.Ok(t, err)
+
+	bucketStore, err := NewBucketStore(logger, nil, bkt, metaFetcher, filepath.Join(dir, "store"), noopCache{}, 0, 0, 99, false, 20, allowAllFilterConf, true, true, true, nil, nil, "", false, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, bucketStore.InitialSync(ctx))
+	testutil.Equals(t, 0, len(bucketStore.ExpensiveBlocks(10, BlockCostFetchedBytes)))
+
+	for i := 0; i < 2; i++ {
+		srv := newStoreSeriesServer(ctx)
+		testutil.Ok(t, bucketStore.Series(&storepb.SeriesRequest{
+			MinTime:  0,
+			MaxTime:  1000,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+		}, srv))
+		testutil.Equals(t, 3, len(srv.SeriesSet))
+	}
+
+	// Blocks not queried are omitted.
+	blocks := bucketStore.ExpensiveBlocks(10, BlockCostSeriesTouched)
+	testutil.Equals(t, 2, len(blocks))
+	testutil.Equals(t, idA, blocks[0].ULID)
+	testutil.Equals(t, idB, blocks[1].ULID)
+	testutil.Equals(t, 2, blocks[0].Queries)
+	testutil.Equals(t, 4, blocks[0].SeriesTouched)
+	testutil.Equals(t, 2, blocks[1].SeriesTouched)
+	testutil.Assert(t, blocks[0].FetchedBytes > blocks[1].FetchedBytes, "expected more bytes fetched of block %s", idA)
+
+	testutil.Equals(t, []BlockQueryStats{blocks[0]}, bucketStore.ExpensiveBlocks(1, BlockCostFetchedBytes))
+	testutil.Equals(t, 0, len(bucketStore.ExpensiveBlocks(10, "unknown")))
+
+	// Metrics are exposed for the limit blocks with the most bytes fetched.
+	testutil.Equals(t, 4, promtest.CollectAndCount(NewExpensiveBlocksCollector(bucketStore, 1)))
+	testutil.Equals(t, 8, promtest.CollectAndCount(NewExpensiveBlocksCollector(bucketStore, 10)))
+}
+
+func TestBucketStore_LazyIndexHeader(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	dir, err := ioutil.TempDir("", "test-lazy-index-header")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	bkt := inmem.NewBucket()
+	series := []labels.Labels{labels.FromStrings("a", "1", "b", "1"), labels.FromStrings("a", "1", "b", "2")}
+	idA, err := e2eutil.CreateBlock(ctx, dir, series, 10, 0, 1000, labels.Labels{{Name: "cluster", Value: "a"}}, 0)
+	testutil.Ok(t, err)
+	idB, err := e2eutil.CreateBlock(ctx, dir, series, 10, 2000, 3000, labels.Labels{{Name: "cluster", Value: "a"}}, 0)
+	testutil.Ok(t, err)
+	for _, id := range []ulid.ULID{idA, idB} {
+		testutil.Ok(t, block.Upload(ctx, logger, bkt, filepath.Join(dir, id.String())))
+	}
+
+	metaFetcher, err := block.NewMetaFetcher(logger, 20, bkt, dir, nil, nil, nil)
+	testutil.Ok(t, err)
+
+	storeDir := filepath.Join(dir, "store")
+	bucketStore, err := NewBucketStore(logger, nil, bkt, metaFetcher, storeDir, noopCache{}, 0, 0, 99, false, 20, allowAllFilterConf, true, true, true, nil, nil, "", false, 1)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, bucketStore.Close()) }()
+	testutil.Ok(t, bucketStore.InitialSync(ctx))
+
+	// Blocks are served right away, while their index-headers are not built yet.
+	indexHeaderExists := func(id ulid.ULID) bool {
+		_, err := os.Stat(filepath.Join(storeDir, id.String(), block.IndexHeaderFilename))
+		return err == nil
+	}
+	blocks := bucketStore.LoadedBlocks()
+	testutil.Equals(t, 2, len(blocks))
+	for _, b := range blocks {
+		testutil.Equals(t, int64(0), b.IndexHeaderSize)
+		testutil.Assert(t, !indexHeaderExists(b.ULID), "unexpected index-header of block %s", b.ULID)
+	}
+
+	// Only the index-headers of blocks queried are built.
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, bucketStore.Series(&storepb.SeriesRequest{
+		MinTime:  0,
+		MaxTime:  1000,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+	}, srv))
+	testutil.Equals(t, 2, len(srv.SeriesSet))
+	testutil.Assert(t, indexHeaderExists(idA), "expected index-header of block %s", idA)
+	testutil.Assert(t, !indexHeaderExists(idB), "unexpected index-header of block %s", idB)
+
+	names, err := bucketStore.LabelNames(ctx, &storepb.LabelNamesRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"a", "b"}, names.Names)
+	testutil.Assert(t, indexHeaderExists(idB), "expected index-header of block %s", idB)
+
+	for _, b := range bucketStore.LoadedBlocks() {
+		testutil.Assert(t, b.IndexHeaderSize > 0, "expected size of index-header of block %s", b.ULID)
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"context"
 	"encoding/base64"
 	"strconv"
+	"strings"
 
 	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"golang.org/x/crypto/blake2b"
 )
@@ -41,6 +43,28 @@ type IndexCache interface {
 	FetchMultiSeries(ctx context.Context, blockID ulid.ULID, ids []uint64) (hits map[uint64][]byte, misses []uint64)
 }
 
+// InvalidatableIndexCache is implemented by index caches able to invalidate all their items of a type.
+type InvalidatableIndexCache interface {
+	// Invalidate invalidates all items of the given type, either "Postings" or "Series" (case insensitive).
+	// Invalidated items are never returned by the cache again.
+	Invalidate(ctx context.Context, itemType string) error
+}
+
+// ItemTypes returns types of items stored in index caches.
+func ItemTypes() []string {
+	return []string{cacheTypePostings, cacheTypeSeries}
+}
+
+// parseItemType returns the item type matching the given one case insensitively.
+func parseItemType(itemType string) (string, error) {
+	for _, typ := range ItemTypes() {
+		if strings.EqualFold(typ, itemType) {
+			return typ, nil
+		}
+	}
+	return "", errors.Errorf("unknown item type %q, expected one of %v", itemType, ItemTypes())
+}
+
 type cacheKey struct {
 	block ulid.ULID
 	key   interface{}
@@ -82,5 +106,14 @@ func (c cacheKey) string() string {
 	}
 }
 
+// versionedString returns the string key with the given version of keys of its type. Version 0 keys are the same
+// as unversioned ones, so cached items are kept until the version is bumped for the first time.
+func (c cacheKey) versionedString(version uint64) string {
+	if version == 0 {
+		return c.string()
+	}
+	return "v" + strconv.FormatUint(version, 10) + ":" + c.string()
+}
+
 type cacheKeyPostings labels.Label
 type cacheKeySeries uint64
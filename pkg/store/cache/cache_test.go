@@ -48,6 +48,14 @@ func TestCacheKey_string(t *testing.T) {
 	}
 }
 
+func TestCacheKey_versionedString(t *testing.T) {
+	t.Parallel()
+
+	key := cacheKey{ulid.MustNew(1, nil), cacheKeySeries(12345)}
+	testutil.Equals(t, key.string(), key.versionedString(0))
+	testutil.Equals(t, "v3:"+key.string(), key.versionedString(3))
+}
+
 func TestCacheKey_string_ShouldGuaranteeReasonablyShortKeyLength(t *testing.T) {
 	t.Parallel()
 
@@ -18,8 +18,10 @@ import (
 type IndexCacheProvider string
 
 const (
-	INMEMORY  IndexCacheProvider = "IN-MEMORY"
-	MEMCACHED IndexCacheProvider = "MEMCACHED"
+	INMEMORY         IndexCacheProvider = "IN-MEMORY"
+	INMEMORY_TINYLFU IndexCacheProvider = "IN-MEMORY-TINYLFU"
+	MEMCACHED        IndexCacheProvider = "MEMCACHED"
+	TIERED           IndexCacheProvider = "TIERED"
 )
 
 // IndexCacheConfig specifies the index cache config.
@@ -45,12 +47,16 @@ func NewIndexCache(logger log.Logger, confContentYaml []byte, reg prometheus.Reg
 	switch strings.ToUpper(string(cacheConfig.Type)) {
 	case string(INMEMORY):
 		cache, err = NewInMemoryIndexCache(logger, reg, backendConfig)
+	case string(INMEMORY_TINYLFU):
+		cache, err = NewTinyLFUIndexCache(logger, reg, backendConfig)
 	case string(MEMCACHED):
 		var memcached cacheutil.MemcachedClient
 		memcached, err = cacheutil.NewMemcachedClient(logger, "index-cache", backendConfig, reg)
 		if err == nil {
 			cache, err = NewMemcachedIndexCache(logger, memcached, reg)
 		}
+	case string(TIERED):
+		cache, err = NewTieredIndexCache(logger, reg, backendConfig)
 	default:
 		return nil, errors.Errorf("index cache with type %s is not supported", cacheConfig.Type)
 	}
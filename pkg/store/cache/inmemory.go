@@ -30,17 +30,41 @@ var (
 
 const maxInt = int(^uint(0) >> 1)
 
+// inMemoryEntryOverhead is the estimated number of bytes each entry of the in-memory index cache takes up besides its
+// key and value on 64-bit platforms: its LRU list element, LRU entry and map slot, and the interfaces holding them.
+const inMemoryEntryOverhead = 144
+
+// inMemoryEntrySize returns the number of bytes the entry of the given key and value takes up in the cache.
+func inMemoryEntrySize(key cacheKey, val []byte) uint64 {
+	return key.size() + sliceHeaderSize + uint64(len(val)) + inMemoryEntryOverhead
+}
+
+type inMemoryEntry struct {
+	val []byte
+	// seq orders entries of all item types by their last use.
+	seq uint64
+}
+
+// InMemoryIndexCache is an in-memory LRU cache. Items of each type are kept in their own LRU, so items of a type can be
+// evicted when it exceeds its budget, while the least recently used items of all types are evicted when the overall
+// size is exceeded. Sizes include keys and the bookkeeping overhead of entries, so limits bound the used memory.
 type InMemoryIndexCache struct {
 	mtx sync.Mutex
 
-	logger           log.Logger
-	lru              *lru.LRU
-	maxSizeBytes     uint64
-	maxItemSizeBytes uint64
+	logger               log.Logger
+	lrus                 map[string]*lru.LRU
+	maxSizeBytes         uint64
+	maxItemSizeBytes     uint64
+	maxItemTypeSizeBytes map[string]uint64
 
-	curSize uint64
+	curSize         uint64
+	curItemTypeSize map[string]uint64
+	seq             uint64
+	// invalidating is true while items are removed on purpose, which is not counted as eviction.
+	invalidating bool
 
 	evicted          *prometheus.CounterVec
+	evictedBytes     *prometheus.CounterVec
 	requests         *prometheus.CounterVec
 	hits             *prometheus.CounterVec
 	added            *prometheus.CounterVec
@@ -56,6 +80,10 @@ type InMemoryIndexCacheConfig struct {
 	MaxSize model.Bytes `yaml:"max_size"`
 	// MaxItemSize represents maximum size of single item.
 	MaxItemSize model.Bytes `yaml:"max_item_size"`
+	// MaxPostingsSize represents maximum number of bytes postings can take up. If 0, only MaxSize limits them.
+	MaxPostingsSize model.Bytes `yaml:"max_postings_size"`
+	// MaxSeriesSize represents maximum number of bytes series can take up. If 0, only MaxSize limits them.
+	MaxSeriesSize model.Bytes `yaml:"max_series_size"`
 }
 
 // parseInMemoryIndexCacheConfig unmarshals a buffer into a InMemoryIndexCacheConfig with default values.
@@ -85,11 +113,23 @@ func NewInMemoryIndexCacheWithConfig(logger log.Logger, reg prometheus.Registere
 	if config.MaxItemSize > config.MaxSize {
 		return nil, errors.Errorf("max item size (%v) cannot be bigger than overall cache size (%v)", config.MaxItemSize, config.MaxSize)
 	}
+	maxItemTypeSizeBytes := map[string]uint64{
+		cacheTypePostings: uint64(config.MaxPostingsSize),
+		cacheTypeSeries:   uint64(config.MaxSeriesSize),
+	}
+	for typ, size := range maxItemTypeSizeBytes {
+		if size > uint64(config.MaxSize) {
+			return nil, errors.Errorf("max size of %s (%v) cannot be bigger than overall cache size (%v)", typ, model.Bytes(size), config.MaxSize)
+		}
+	}
 
 	c := &InMemoryIndexCache{
-		logger:           logger,
-		maxSizeBytes:     uint64(config.MaxSize),
-		maxItemSizeBytes: uint64(config.MaxItemSize),
+		logger:               logger,
+		lrus:                 map[string]*lru.LRU{},
+		maxSizeBytes:         uint64(config.MaxSize),
+		maxItemSizeBytes:     uint64(config.MaxItemSize),
+		maxItemTypeSizeBytes: maxItemTypeSizeBytes,
+		curItemTypeSize:      map[string]uint64{},
 	}
 
 	c.evicted = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
@@ -99,6 +139,13 @@ func NewInMemoryIndexCacheWithConfig(logger log.Logger, reg prometheus.Registere
 	c.evicted.WithLabelValues(cacheTypePostings)
 	c.evicted.WithLabelValues(cacheTypeSeries)
 
+	c.evictedBytes = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_evicted_bytes_total",
+		Help: "Total number of bytes of items that were evicted from the index cache to make space for new items.",
+	}, []string{"item_type"})
+	c.evictedBytes.WithLabelValues(cacheTypePostings)
+	c.evictedBytes.WithLabelValues(cacheTypeSeries)
+
 	c.added = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 		Name: "thanos_store_index_cache_items_added_total",
 		Help: "Total number of items that were added to the index cache.",
@@ -160,34 +207,63 @@ func NewInMemoryIndexCacheWithConfig(logger log.Logger, reg prometheus.Registere
 	}, func() float64 {
 		return float64(c.maxItemSizeBytes)
 	})
+	maxItemTypeSize := promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thanos_store_index_cache_item_type_max_size_bytes",
+		Help: "Maximum number of bytes items of a type can take up in the index cache, or 0 if only the overall maximum applies.",
+	}, []string{"item_type"})
+	for typ, size := range maxItemTypeSizeBytes {
+		maxItemTypeSize.WithLabelValues(typ).Set(float64(size))
+	}
 
-	// Initialize LRU cache with a high size limit since we will manage evictions ourselves
+	// Initialize LRU caches with a high size limit since we will manage evictions ourselves
 	// based on stored size using `RemoveOldest` method.
-	l, err := lru.NewLRU(maxInt, c.onEvict)
-	if err != nil {
-		return nil, err
+	for _, typ := range ItemTypes() {
+		l, err := lru.NewLRU(maxInt, c.onEvict)
+		if err != nil {
+			return nil, err
+		}
+		c.lrus[typ] = l
 	}
-	c.lru = l
 
 	level.Info(logger).Log(
 		"msg", "created in-memory index cache",
 		"maxItemSizeBytes", c.maxItemSizeBytes,
 		"maxSizeBytes", c.maxSizeBytes,
+		"maxPostingsSizeBytes", maxItemTypeSizeBytes[cacheTypePostings],
+		"maxSeriesSizeBytes", maxItemTypeSizeBytes[cacheTypeSeries],
 		"maxItems", "maxInt",
 	)
 	return c, nil
 }
 
 func (c *InMemoryIndexCache) onEvict(key, val interface{}) {
-	k := key.(cacheKey).keyType()
-	entrySize := sliceHeaderSize + uint64(len(val.([]byte)))
-
-	c.evicted.WithLabelValues(string(k)).Inc()
-	c.current.WithLabelValues(string(k)).Dec()
-	c.currentSize.WithLabelValues(string(k)).Sub(float64(entrySize))
-	c.totalCurrentSize.WithLabelValues(string(k)).Sub(float64(entrySize + key.(cacheKey).size()))
+	k := key.(cacheKey)
+	typ := k.keyType()
+	v := val.(*inMemoryEntry).val
+	entrySize := inMemoryEntrySize(k, v)
+
+	if !c.invalidating {
+		c.evicted.WithLabelValues(typ).Inc()
+		c.evictedBytes.WithLabelValues(typ).Add(float64(entrySize))
+	}
+	c.current.WithLabelValues(typ).Dec()
+	c.currentSize.WithLabelValues(typ).Sub(float64(sliceHeaderSize + len(v)))
+	c.totalCurrentSize.WithLabelValues(typ).Sub(float64(entrySize))
 
 	c.curSize -= entrySize
+	c.curItemTypeSize[typ] -= entrySize
+}
+
+// lookup returns the value of the given key and marks it as used.
+func (c *InMemoryIndexCache) lookup(typ string, key cacheKey) ([]byte, bool) {
+	v, ok := c.lrus[typ].Get(key)
+	if !ok {
+		return nil, false
+	}
+	e := v.(*inMemoryEntry)
+	c.seq++
+	e.seq = c.seq
+	return e.val, true
 }
 
 func (c *InMemoryIndexCache) get(typ string, key cacheKey) ([]byte, bool) {
@@ -196,21 +272,21 @@ func (c *InMemoryIndexCache) get(typ string, key cacheKey) ([]byte, bool) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	v, ok := c.lru.Get(key)
+	v, ok := c.lookup(typ, key)
 	if !ok {
 		return nil, false
 	}
 	c.hits.WithLabelValues(typ).Inc()
-	return v.([]byte), true
+	return v, true
 }
 
 func (c *InMemoryIndexCache) set(typ string, key cacheKey, val []byte) {
-	var size = sliceHeaderSize + uint64(len(val))
+	var size = inMemoryEntrySize(key, val)
 
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	if _, ok := c.lru.Get(key); ok {
+	if _, ok := c.lookup(typ, key); ok {
 		return
 	}
 
@@ -223,22 +299,26 @@ func (c *InMemoryIndexCache) set(typ string, key cacheKey, val []byte) {
 	// to ensure we don't waste huge amounts of space for something small.
 	v := make([]byte, len(val))
 	copy(v, val)
-	c.lru.Add(key, v)
+	c.seq++
+	c.lrus[typ].Add(key, &inMemoryEntry{val: v, seq: c.seq})
 
 	c.added.WithLabelValues(typ).Inc()
-	c.currentSize.WithLabelValues(typ).Add(float64(size))
-	c.totalCurrentSize.WithLabelValues(typ).Add(float64(size + key.size()))
+	c.currentSize.WithLabelValues(typ).Add(float64(sliceHeaderSize + len(v)))
+	c.totalCurrentSize.WithLabelValues(typ).Add(float64(size))
 	c.current.WithLabelValues(typ).Inc()
 	c.curSize += size
+	c.curItemTypeSize[typ] += size
 }
 
 // ensureFits tries to make sure that the passed slice will fit into the LRU cache.
 // Returns true if it will fit.
 func (c *InMemoryIndexCache) ensureFits(size uint64, typ string) bool {
-	if size > c.maxItemSizeBytes {
+	maxItemTypeSize := c.maxItemTypeSizeBytes[typ]
+	if size > c.maxItemSizeBytes || (maxItemTypeSize > 0 && size > maxItemTypeSize) {
 		level.Debug(c.logger).Log(
-			"msg", "item bigger than maxItemSizeBytes. Ignoring..",
+			"msg", "item bigger than maxItemSizeBytes or max size of its type. Ignoring..",
 			"maxItemSizeBytes", c.maxItemSizeBytes,
+			"maxItemTypeSizeBytes", maxItemTypeSize,
 			"maxSizeBytes", c.maxSizeBytes,
 			"curSize", c.curSize,
 			"itemSize", size,
@@ -247,28 +327,86 @@ func (c *InMemoryIndexCache) ensureFits(size uint64, typ string) bool {
 		return false
 	}
 
+	for maxItemTypeSize > 0 && c.curItemTypeSize[typ]+size > maxItemTypeSize {
+		if !c.evictOldest(typ) {
+			c.resetOnBrokenAccounting(size, typ)
+		}
+	}
 	for c.curSize+size > c.maxSizeBytes {
-		if _, _, ok := c.lru.RemoveOldest(); !ok {
-			level.Error(c.logger).Log(
-				"msg", "LRU has nothing more to evict, but we still cannot allocate the item. Resetting cache.",
-				"maxItemSizeBytes", c.maxItemSizeBytes,
-				"maxSizeBytes", c.maxSizeBytes,
-				"curSize", c.curSize,
-				"itemSize", size,
-				"cacheType", typ,
-			)
-			c.reset()
+		if !c.evictOldest(c.oldestItemType()) {
+			c.resetOnBrokenAccounting(size, typ)
 		}
 	}
 	return true
 }
 
+// oldestItemType returns the type of the least recently used item of all types, or an empty string if the cache is empty.
+func (c *InMemoryIndexCache) oldestItemType() string {
+	var (
+		oldest    string
+		oldestSeq uint64
+	)
+	for _, typ := range ItemTypes() {
+		_, v, ok := c.lrus[typ].GetOldest()
+		if !ok {
+			continue
+		}
+		if seq := v.(*inMemoryEntry).seq; oldest == "" || seq < oldestSeq {
+			oldest, oldestSeq = typ, seq
+		}
+	}
+	return oldest
+}
+
+// evictOldest evicts the least recently used item of the given type. Returns false if there is none.
+func (c *InMemoryIndexCache) evictOldest(typ string) bool {
+	l, ok := c.lrus[typ]
+	if !ok {
+		return false
+	}
+	_, _, ok = l.RemoveOldest()
+	return ok
+}
+
+func (c *InMemoryIndexCache) resetOnBrokenAccounting(size uint64, typ string) {
+	level.Error(c.logger).Log(
+		"msg", "LRU has nothing more to evict, but we still cannot allocate the item. Resetting cache.",
+		"maxItemSizeBytes", c.maxItemSizeBytes,
+		"maxItemTypeSizeBytes", c.maxItemTypeSizeBytes[typ],
+		"maxSizeBytes", c.maxSizeBytes,
+		"curSize", c.curSize,
+		"curItemTypeSize", c.curItemTypeSize[typ],
+		"itemSize", size,
+		"cacheType", typ,
+	)
+	c.reset()
+}
+
 func (c *InMemoryIndexCache) reset() {
-	c.lru.Purge()
+	for _, l := range c.lrus {
+		l.Purge()
+	}
 	c.current.Reset()
 	c.currentSize.Reset()
 	c.totalCurrentSize.Reset()
 	c.curSize = 0
+	c.curItemTypeSize = map[string]uint64{}
+}
+
+// Invalidate removes all items of the given type from the cache.
+func (c *InMemoryIndexCache) Invalidate(_ context.Context, itemType string) error {
+	typ, err := parseItemType(itemType)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.invalidating = true
+	c.lrus[typ].Purge()
+	c.invalidating = false
+	return nil
 }
 
 func copyString(s string) string {
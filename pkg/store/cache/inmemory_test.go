@@ -19,6 +19,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/model"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -48,15 +49,42 @@ max_item_size: 2KB
 	testutil.Ok(t, err)
 	testutil.Equals(t, uint64(1024*1024), cache.maxSizeBytes)
 	testutil.Equals(t, uint64(2*1024), cache.maxItemSizeBytes)
+	testutil.Equals(t, map[string]uint64{cacheTypePostings: 0, cacheTypeSeries: 0}, cache.maxItemTypeSizeBytes)
+
+	// Should instance an in-memory index cache with budgets of item types.
+	conf = []byte(`
+max_size: 1MB
+max_item_size: 2KB
+max_postings_size: 512KB
+max_series_size: 1MB
+`)
+	cache, err = NewInMemoryIndexCache(log.NewNopLogger(), nil, conf)
+	testutil.Ok(t, err)
+	testutil.Equals(t, map[string]uint64{cacheTypePostings: 512 * 1024, cacheTypeSeries: 1024 * 1024}, cache.maxItemTypeSizeBytes)
+
+	// Should return error on budgets of item types bigger than overall cache size.
+	conf = []byte(`
+max_size: 1MB
+max_item_size: 2KB
+max_postings_size: 2MB
+`)
+	_, err = NewInMemoryIndexCache(log.NewNopLogger(), nil, conf)
+	testutil.NotOk(t, err)
 }
 
 func TestInMemoryIndexCache_AvoidsDeadlock(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
+	id := ulid.MustNew(0, nil)
+	lbl1 := labels.Label{Name: "test2", Value: "1"}
+	size1 := inMemoryEntrySize(cacheKey{id, cacheKeyPostings(lbl1)}, []byte{42, 33, 14, 67, 11})
+	lbl2 := labels.Label{Name: "test1", Value: "1"}
+	size2 := inMemoryEntrySize(cacheKey{id, cacheKeyPostings(lbl2)}, []byte{42})
+
 	metrics := prometheus.NewRegistry()
 	cache, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), metrics, InMemoryIndexCacheConfig{
-		MaxItemSize: sliceHeaderSize + 5,
-		MaxSize:     sliceHeaderSize + 5,
+		MaxItemSize: model.Bytes(size1),
+		MaxSize:     model.Bytes(size1),
 	})
 	testutil.Ok(t, err)
 
@@ -67,27 +95,30 @@ func TestInMemoryIndexCache_AvoidsDeadlock(t *testing.T) {
 		cache.curSize = size
 	})
 	testutil.Ok(t, err)
-	cache.lru = l
+	cache.lrus[cacheTypePostings] = l
 
 	ctx := context.Background()
-	cache.StorePostings(ctx, ulid.MustNew(0, nil), labels.Label{Name: "test2", Value: "1"}, []byte{42, 33, 14, 67, 11})
+	cache.StorePostings(ctx, id, lbl1, []byte{42, 33, 14, 67, 11})
 
-	testutil.Equals(t, uint64(sliceHeaderSize+5), cache.curSize)
-	testutil.Equals(t, float64(cache.curSize), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, size1, cache.curSize)
+	testutil.Equals(t, float64(cache.curSize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(sliceHeaderSize+5), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
 
 	// This triggers deadlock logic.
-	cache.StorePostings(ctx, ulid.MustNew(0, nil), labels.Label{Name: "test1", Value: "1"}, []byte{42})
+	cache.StorePostings(ctx, id, lbl2, []byte{42})
 
-	testutil.Equals(t, uint64(sliceHeaderSize+1), cache.curSize)
-	testutil.Equals(t, float64(cache.curSize), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, size2, cache.curSize)
+	testutil.Equals(t, float64(cache.curSize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(sliceHeaderSize+1), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
 }
 
 func TestInMemoryIndexCache_UpdateItem(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
-	const maxSize = 2 * (sliceHeaderSize + 1)
+	// Fits an item of 2 bytes of either type, but not two items of 1 byte.
+	const maxSize = 2 * (sliceHeaderSize + 1 + inMemoryEntryOverhead)
 
 	var errorLogs []string
 	errorLogger := log.LoggerFunc(func(kvs ...interface{}) error {
@@ -192,25 +223,27 @@ func TestInMemoryIndexCache_UpdateItem(t *testing.T) {
 func TestInMemoryIndexCache_MaxNumberOfItemsHit(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
+	id := ulid.MustNew(0, nil)
+	size := inMemoryEntrySize(cacheKey{id, cacheKeyPostings(labels.Label{Name: "test", Value: "123"})}, []byte{42, 33})
+
 	metrics := prometheus.NewRegistry()
 	cache, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), metrics, InMemoryIndexCacheConfig{
-		MaxItemSize: 2*sliceHeaderSize + 10,
-		MaxSize:     2*sliceHeaderSize + 10,
+		MaxItemSize: model.Bytes(3 * size),
+		MaxSize:     model.Bytes(3 * size),
 	})
 	testutil.Ok(t, err)
 
 	l, err := simplelru.NewLRU(2, cache.onEvict)
 	testutil.Ok(t, err)
-	cache.lru = l
+	cache.lrus[cacheTypePostings] = l
 
-	id := ulid.MustNew(0, nil)
 	ctx := context.Background()
 
 	cache.StorePostings(ctx, id, labels.Label{Name: "test", Value: "123"}, []byte{42, 33})
 	cache.StorePostings(ctx, id, labels.Label{Name: "test", Value: "124"}, []byte{42, 33})
 	cache.StorePostings(ctx, id, labels.Label{Name: "test", Value: "125"}, []byte{42, 33})
 
-	testutil.Equals(t, uint64(2*sliceHeaderSize+4), cache.curSize)
+	testutil.Equals(t, 2*size, cache.curSize)
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.overflow.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.overflow.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
@@ -226,15 +259,19 @@ func TestInMemoryIndexCache_MaxNumberOfItemsHit(t *testing.T) {
 func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
+	id := ulid.MustNew(0, nil)
+	lbls := labels.Label{Name: "test", Value: "123"}
+	postingsSize := inMemoryEntrySize(cacheKey{id, cacheKeyPostings(lbls)}, []byte{42, 33})
+	seriesSize := inMemoryEntrySize(cacheKey{id, cacheKeySeries(1234)}, []byte{222, 223, 224})
+	maxSize := postingsSize + seriesSize
+
 	metrics := prometheus.NewRegistry()
 	cache, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), metrics, InMemoryIndexCacheConfig{
-		MaxItemSize: 2*sliceHeaderSize + 5,
-		MaxSize:     2*sliceHeaderSize + 5,
+		MaxItemSize: model.Bytes(maxSize),
+		MaxSize:     model.Bytes(maxSize),
 	})
 	testutil.Ok(t, err)
 
-	id := ulid.MustNew(0, nil)
-	lbls := labels.Label{Name: "test", Value: "123"}
 	ctx := context.Background()
 	emptyPostingsHits := map[labels.Label][]byte{}
 	emptyPostingsMisses := []labels.Label(nil)
@@ -245,12 +282,12 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	testutil.Equals(t, emptyPostingsHits, pHits, "no such key")
 	testutil.Equals(t, []labels.Label{lbls}, pMisses)
 
-	// Add sliceHeaderSize + 2 bytes.
+	// Add sliceHeaderSize + 2 bytes of value.
 	cache.StorePostings(ctx, id, lbls, []byte{42, 33})
-	testutil.Equals(t, uint64(sliceHeaderSize+2), cache.curSize)
+	testutil.Equals(t, postingsSize, cache.curSize)
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(sliceHeaderSize+2), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, float64(sliceHeaderSize+2+55), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(postingsSize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypeSeries)))
@@ -271,15 +308,15 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	testutil.Equals(t, emptyPostingsHits, pHits, "no such key")
 	testutil.Equals(t, []labels.Label{{Name: "test", Value: "124"}}, pMisses)
 
-	// Add sliceHeaderSize + 3 more bytes.
+	// Add sliceHeaderSize + 3 more bytes of value.
 	cache.StoreSeries(ctx, id, 1234, []byte{222, 223, 224})
-	testutil.Equals(t, uint64(2*sliceHeaderSize+5), cache.curSize)
+	testutil.Equals(t, maxSize, cache.curSize)
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(sliceHeaderSize+2), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, float64(sliceHeaderSize+2+55), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(postingsSize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(sliceHeaderSize+3), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypeSeries)))
-	testutil.Equals(t, float64(sliceHeaderSize+3+24), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypeSeries)))
+	testutil.Equals(t, float64(seriesSize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.overflow.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.overflow.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
@@ -291,17 +328,17 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 
 	lbls2 := labels.Label{Name: "test", Value: "124"}
 
-	// Add sliceHeaderSize + 5 + 16 bytes, should fully evict 2 last items.
-	v := []byte{42, 33, 14, 67, 11}
-	for i := 0; i < sliceHeaderSize; i++ {
-		v = append(v, 3)
+	// Add an item of the size of the cache, should fully evict 2 last items.
+	v := make([]byte, maxSize-inMemoryEntrySize(cacheKey{id, cacheKeyPostings(lbls2)}, nil))
+	for i := range v {
+		v[i] = 3
 	}
 	cache.StorePostings(ctx, id, lbls2, v)
 
-	testutil.Equals(t, uint64(2*sliceHeaderSize+5), cache.curSize)
+	testutil.Equals(t, maxSize, cache.curSize)
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, float64(2*sliceHeaderSize+5), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, float64(2*sliceHeaderSize+5+55), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(sliceHeaderSize+len(v)), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(maxSize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypeSeries)))
@@ -309,6 +346,8 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.overflow.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings))) // Eviction.
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypeSeries)))   // Eviction.
+	testutil.Equals(t, float64(postingsSize), promtest.ToFloat64(cache.evictedBytes.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(seriesSize), promtest.ToFloat64(cache.evictedBytes.WithLabelValues(cacheTypeSeries)))
 
 	// Evicted.
 	pHits, pMisses = cache.FetchMultiPostings(ctx, id, []labels.Label{lbls})
@@ -326,10 +365,10 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	// Add same item again.
 	cache.StorePostings(ctx, id, lbls2, v)
 
-	testutil.Equals(t, uint64(2*sliceHeaderSize+5), cache.curSize)
+	testutil.Equals(t, maxSize, cache.curSize)
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, float64(2*sliceHeaderSize+5), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, float64(2*sliceHeaderSize+5+55), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(sliceHeaderSize+len(v)), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(maxSize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypeSeries)))
@@ -344,10 +383,10 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 
 	// Add too big item.
 	cache.StorePostings(ctx, id, labels.Label{Name: "test", Value: "toobig"}, append(v, 5))
-	testutil.Equals(t, uint64(2*sliceHeaderSize+5), cache.curSize)
+	testutil.Equals(t, maxSize, cache.curSize)
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, float64(2*sliceHeaderSize+5), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, float64(2*sliceHeaderSize+5+55), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(sliceHeaderSize+len(v)), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(maxSize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypeSeries)))
@@ -356,7 +395,7 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypeSeries)))
 
-	_, _, ok := cache.lru.RemoveOldest()
+	_, _, ok := cache.lrus[cacheTypePostings].RemoveOldest()
 	testutil.Assert(t, ok, "something to remove")
 
 	testutil.Equals(t, uint64(0), cache.curSize)
@@ -371,17 +410,18 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	testutil.Equals(t, float64(2), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypeSeries)))
 
-	_, _, ok = cache.lru.RemoveOldest()
+	_, _, ok = cache.lrus[cacheTypePostings].RemoveOldest()
 	testutil.Assert(t, !ok, "nothing to remove")
 
 	lbls3 := labels.Label{Name: "test", Value: "124"}
+	emptySize := inMemoryEntrySize(cacheKey{id, cacheKeyPostings(lbls3)}, nil)
 
 	cache.StorePostings(ctx, id, lbls3, []byte{})
 
-	testutil.Equals(t, uint64(sliceHeaderSize), cache.curSize)
+	testutil.Equals(t, emptySize, cache.curSize)
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(sliceHeaderSize), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, float64(sliceHeaderSize+55), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(emptySize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypeSeries)))
@@ -394,20 +434,20 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	testutil.Equals(t, map[labels.Label][]byte{lbls3: []byte{}}, pHits, "key exists")
 	testutil.Equals(t, emptyPostingsMisses, pMisses)
 
-	// nil works and still allocates empty slice.
+	// nil works and still allocates empty slice. Both empty items do not fit, as entries take up more than their values.
 	lbls4 := labels.Label{Name: "test", Value: "125"}
 	cache.StorePostings(ctx, id, lbls4, []byte(nil))
 
-	testutil.Equals(t, 2*uint64(sliceHeaderSize), cache.curSize)
-	testutil.Equals(t, float64(2), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, 2*float64(sliceHeaderSize), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
-	testutil.Equals(t, 2*float64(sliceHeaderSize+55), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, emptySize, cache.curSize)
+	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(sliceHeaderSize), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(emptySize), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.totalCurrentSize.WithLabelValues(cacheTypeSeries)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.overflow.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.overflow.WithLabelValues(cacheTypeSeries)))
-	testutil.Equals(t, float64(2), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(3), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypeSeries)))
 
 	pHits, pMisses = cache.FetchMultiPostings(ctx, id, []labels.Label{lbls4})
@@ -422,3 +462,84 @@ func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	testutil.Equals(t, float64(5), promtest.ToFloat64(cache.hits.WithLabelValues(cacheTypePostings)))
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.hits.WithLabelValues(cacheTypeSeries)))
 }
+
+func TestInMemoryIndexCache_Invalidate(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	cache, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), prometheus.NewRegistry(), InMemoryIndexCacheConfig{
+		MaxItemSize: 1024,
+		MaxSize:     1024,
+	})
+	testutil.Ok(t, err)
+
+	ctx := context.Background()
+	id := ulid.MustNew(0, nil)
+	lbl := labels.Label{Name: "test", Value: "123"}
+	cache.StorePostings(ctx, id, lbl, []byte{1})
+	cache.StoreSeries(ctx, id, 1, []byte{1})
+
+	testutil.NotOk(t, cache.Invalidate(ctx, "chunks"))
+	testutil.Ok(t, cache.Invalidate(ctx, "postings"))
+
+	pHits, pMisses := cache.FetchMultiPostings(ctx, id, []labels.Label{lbl})
+	testutil.Equals(t, 0, len(pHits))
+	testutil.Equals(t, []labels.Label{lbl}, pMisses)
+	sHits, _ := cache.FetchMultiSeries(ctx, id, []uint64{1})
+	testutil.Equals(t, map[uint64][]byte{1: {1}}, sHits)
+
+	testutil.Equals(t, inMemoryEntrySize(cacheKey{id, cacheKeySeries(1)}, []byte{1}), cache.curSize)
+	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
+	// Invalidated items are not evicted.
+	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
+}
+
+func TestInMemoryIndexCache_ItemTypeBudgets(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	id := ulid.MustNew(0, nil)
+	lbl := func(i int) labels.Label { return labels.Label{Name: "test", Value: fmt.Sprintf("%03d", i)} }
+	postingsSize := inMemoryEntrySize(cacheKey{id, cacheKeyPostings(lbl(0))}, []byte{1})
+	seriesSize := inMemoryEntrySize(cacheKey{id, cacheKeySeries(0)}, []byte{1})
+
+	cache, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), prometheus.NewRegistry(), InMemoryIndexCacheConfig{
+		MaxItemSize:     model.Bytes(2*postingsSize + 3*seriesSize),
+		MaxSize:         model.Bytes(2*postingsSize + 3*seriesSize),
+		MaxPostingsSize: model.Bytes(2 * postingsSize),
+	})
+	testutil.Ok(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		cache.StoreSeries(ctx, id, uint64(i), []byte{1})
+	}
+	// Postings exceeding their budget evict older postings, not series.
+	for i := 0; i < 4; i++ {
+		cache.StorePostings(ctx, id, lbl(i), []byte{1})
+	}
+	testutil.Equals(t, 2*postingsSize, cache.curItemTypeSize[cacheTypePostings])
+	testutil.Equals(t, 3*seriesSize, cache.curItemTypeSize[cacheTypeSeries])
+	testutil.Equals(t, float64(2), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(2*postingsSize), promtest.ToFloat64(cache.evictedBytes.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypeSeries)))
+
+	hits, misses := cache.FetchMultiPostings(ctx, id, []labels.Label{lbl(0), lbl(1), lbl(2), lbl(3)})
+	testutil.Equals(t, map[labels.Label][]byte{lbl(2): {1}, lbl(3): {1}}, hits)
+	testutil.Equals(t, []labels.Label{lbl(0), lbl(1)}, misses)
+
+	// Postings bigger than their budget are not stored.
+	cache.StorePostings(ctx, id, lbl(4), make([]byte, 2*postingsSize))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.overflow.WithLabelValues(cacheTypePostings)))
+
+	// Items exceeding the overall size evict the least recently used items of all types: series 1 and 2 here,
+	// as series 0 and the postings were used after them.
+	_, _ = cache.FetchMultiSeries(ctx, id, []uint64{0})
+	v := make([]byte, 2*seriesSize-inMemoryEntrySize(cacheKey{id, cacheKeySeries(3)}, nil))
+	cache.StoreSeries(ctx, id, 3, v)
+	testutil.Equals(t, float64(2), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypeSeries)))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, cache.maxSizeBytes, cache.curSize)
+
+	sHits, sMisses := cache.FetchMultiSeries(ctx, id, []uint64{0, 1, 2, 3})
+	testutil.Equals(t, map[uint64][]byte{0: {1}, 3: v}, sHits)
+	testutil.Equals(t, []uint64{1, 2}, sMisses)
+}
@@ -5,11 +5,14 @@ package storecache
 
 import (
 	"context"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/pkg/labels"
@@ -18,6 +21,10 @@ import (
 
 const (
 	memcachedDefaultTTL = 24 * time.Hour
+
+	// Versions of keys are stored in memcached, so all store gateways sharing it use the same ones.
+	memcachedKeyVersionTTL             = 30 * 24 * time.Hour
+	memcachedKeyVersionRefreshInterval = 30 * time.Second
 )
 
 // MemcachedIndexCache is a memcached-based index cache.
@@ -25,6 +32,11 @@ type MemcachedIndexCache struct {
 	logger    log.Logger
 	memcached cacheutil.MemcachedClient
 
+	// Versions of keys by item type, refreshed from memcached periodically.
+	versionsMtx         sync.RWMutex
+	versions            map[string]uint64
+	versionsRefreshedAt time.Time
+
 	// Metrics.
 	requests *prometheus.CounterVec
 	hits     *prometheus.CounterVec
@@ -35,6 +47,7 @@ func NewMemcachedIndexCache(logger log.Logger, memcached cacheutil.MemcachedClie
 	c := &MemcachedIndexCache{
 		logger:    logger,
 		memcached: memcached,
+		versions:  map[string]uint64{},
 	}
 
 	c.requests = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
@@ -60,10 +73,10 @@ func NewMemcachedIndexCache(logger log.Logger, memcached cacheutil.MemcachedClie
 // The function enqueues the request and returns immediately: the entry will be
 // asynchronously stored in the cache.
 func (c *MemcachedIndexCache) StorePostings(ctx context.Context, blockID ulid.ULID, l labels.Label, v []byte) {
-	key := cacheKey{blockID, cacheKeyPostings(l)}.string()
+	key := cacheKey{blockID, cacheKeyPostings(l)}.versionedString(c.keyVersion(cacheTypePostings))
 
 	if err := c.memcached.SetAsync(ctx, key, v, memcachedDefaultTTL); err != nil {
-		level.Error(c.logger).Log("msg", "failed to cache postings in memcached", "err", err)
+		level.Debug(c.logger).Log("msg", "failed to cache postings in memcached", "err", err)
 	}
 }
 
@@ -75,9 +88,10 @@ func (c *MemcachedIndexCache) FetchMultiPostings(ctx context.Context, blockID ul
 	// so that we can easily reverse it back after the GetMulti().
 	keys := make([]string, 0, len(lbls))
 	keysMapping := map[labels.Label]string{}
+	version := c.keyVersion(cacheTypePostings)
 
 	for _, lbl := range lbls {
-		key := cacheKey{blockID, cacheKeyPostings(lbl)}.string()
+		key := cacheKey{blockID, cacheKeyPostings(lbl)}.versionedString(version)
 
 		keys = append(keys, key)
 		keysMapping[lbl] = key
@@ -120,10 +134,10 @@ func (c *MemcachedIndexCache) FetchMultiPostings(ctx context.Context, blockID ul
 // The function enqueues the request and returns immediately: the entry will be
 // asynchronously stored in the cache.
 func (c *MemcachedIndexCache) StoreSeries(ctx context.Context, blockID ulid.ULID, id uint64, v []byte) {
-	key := cacheKey{blockID, cacheKeySeries(id)}.string()
+	key := cacheKey{blockID, cacheKeySeries(id)}.versionedString(c.keyVersion(cacheTypeSeries))
 
 	if err := c.memcached.SetAsync(ctx, key, v, memcachedDefaultTTL); err != nil {
-		level.Error(c.logger).Log("msg", "failed to cache series in memcached", "err", err)
+		level.Debug(c.logger).Log("msg", "failed to cache series in memcached", "err", err)
 	}
 }
 
@@ -135,9 +149,10 @@ func (c *MemcachedIndexCache) FetchMultiSeries(ctx context.Context, blockID ulid
 	// so that we can easily reverse it back after the GetMulti().
 	keys := make([]string, 0, len(ids))
 	keysMapping := map[uint64]string{}
+	version := c.keyVersion(cacheTypeSeries)
 
 	for _, id := range ids {
-		key := cacheKey{blockID, cacheKeySeries(id)}.string()
+		key := cacheKey{blockID, cacheKeySeries(id)}.versionedString(version)
 
 		keys = append(keys, key)
 		keysMapping[id] = key
@@ -175,3 +190,99 @@ func (c *MemcachedIndexCache) FetchMultiSeries(ctx context.Context, blockID ulid
 	c.hits.WithLabelValues(cacheTypeSeries).Add(float64(len(hits)))
 	return hits, misses
 }
+
+// Invalidate invalidates all items of the given type, by bumping the version of their keys. The new version is
+// stored in memcached, so other store gateways using it start using the new version within
+// memcachedKeyVersionRefreshInterval. Items of previous versions are left to expire.
+func (c *MemcachedIndexCache) Invalidate(ctx context.Context, itemType string) error {
+	typ, err := parseItemType(itemType)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the version is not bumped to the one already used by other store gateways.
+	c.refreshVersions(ctx)
+
+	c.versionsMtx.Lock()
+	c.versions[typ]++
+	version := c.versions[typ]
+	c.versionsMtx.Unlock()
+
+	if err := c.memcached.SetAsync(ctx, memcachedKeyVersionKey(typ), []byte(strconv.FormatUint(version, 10)), memcachedKeyVersionTTL); err != nil {
+		return errors.Wrapf(err, "store version %d of %s keys", version, typ)
+	}
+	level.Info(c.logger).Log("msg", "invalidated memcached index cache", "type", typ, "version", version)
+	return nil
+}
+
+// keyVersion returns the current version of keys of the given item type. Once versions are due to be refreshed, the
+// first caller refreshes them, while others keep using current versions meanwhile.
+func (c *MemcachedIndexCache) keyVersion(typ string) uint64 {
+	c.versionsMtx.RLock()
+	version := c.versions[typ]
+	refresh := time.Since(c.versionsRefreshedAt) >= memcachedKeyVersionRefreshInterval
+	c.versionsMtx.RUnlock()
+	if !refresh {
+		return version
+	}
+
+	c.versionsMtx.Lock()
+	if time.Since(c.versionsRefreshedAt) < memcachedKeyVersionRefreshInterval {
+		c.versionsMtx.Unlock()
+		return version
+	}
+	c.versionsRefreshedAt = time.Now()
+	c.versionsMtx.Unlock()
+
+	// Versions are refreshed independently of the request, so its cancellation doesn't make versions stale until
+	// the next refresh.
+	c.refreshVersions(context.Background())
+
+	c.versionsMtx.RLock()
+	defer c.versionsMtx.RUnlock()
+	return c.versions[typ]
+}
+
+// refreshVersions updates versions of keys with the ones stored in memcached, if higher. Versions higher than
+// the stored ones are stored again, so they don't get lost when expired or evicted.
+func (c *MemcachedIndexCache) refreshVersions(ctx context.Context) {
+	keys := make([]string, 0, len(ItemTypes()))
+	for _, typ := range ItemTypes() {
+		keys = append(keys, memcachedKeyVersionKey(typ))
+	}
+	results := c.memcached.GetMulti(ctx, keys)
+
+	lost := map[string]uint64{}
+	c.versionsMtx.Lock()
+	c.versionsRefreshedAt = time.Now()
+	for _, typ := range ItemTypes() {
+		var stored uint64
+		if v, ok := results[memcachedKeyVersionKey(typ)]; ok {
+			parsed, err := strconv.ParseUint(string(v), 10, 64)
+			if err != nil {
+				level.Warn(c.logger).Log("msg", "ignoring invalid version of keys stored in memcached", "type", typ, "version", string(v), "err", err)
+			}
+			stored = parsed
+		}
+
+		if stored > c.versions[typ] {
+			c.versions[typ] = stored
+			continue
+		}
+		if stored < c.versions[typ] {
+			lost[typ] = c.versions[typ]
+		}
+	}
+	c.versionsMtx.Unlock()
+
+	for typ, version := range lost {
+		if err := c.memcached.SetAsync(ctx, memcachedKeyVersionKey(typ), []byte(strconv.FormatUint(version, 10)), memcachedKeyVersionTTL); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to store version of keys in memcached", "type", typ, "err", err)
+		}
+	}
+}
+
+// memcachedKeyVersionKey returns the memcached key storing the version of keys of the given item type.
+func memcachedKeyVersionKey(typ string) string {
+	return "V:" + typ
+}
@@ -198,6 +198,96 @@ func TestMemcachedIndexCache_FetchMultiSeries(t *testing.T) {
 	}
 }
 
+func TestMemcachedIndexCache_Invalidate(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx := context.Background()
+	block := ulid.MustNew(1, nil)
+	lbl := labels.Label{Name: "instance", Value: "a"}
+
+	// Two store gateways sharing the same memcached.
+	memcached := newMockedMemcachedClient(nil)
+	c1, err := NewMemcachedIndexCache(log.NewNopLogger(), memcached, nil)
+	testutil.Ok(t, err)
+	c2, err := NewMemcachedIndexCache(log.NewNopLogger(), memcached, nil)
+	testutil.Ok(t, err)
+
+	c1.StorePostings(ctx, block, lbl, []byte{1})
+	c1.StoreSeries(ctx, block, 1, []byte{1})
+	_, ok := memcached.cache[cacheKey{block, cacheKeyPostings(lbl)}.string()]
+	testutil.Assert(t, ok, "expected unversioned key before first invalidation")
+
+	testutil.NotOk(t, c2.Invalidate(ctx, "chunks"))
+	testutil.Ok(t, c2.Invalidate(ctx, "postings"))
+	testutil.Equals(t, []byte("1"), memcached.cache["V:Postings"])
+
+	hits, misses := c2.FetchMultiPostings(ctx, block, []labels.Label{lbl})
+	testutil.Equals(t, 0, len(hits))
+	testutil.Equals(t, []labels.Label{lbl}, misses)
+
+	// The other store gateway picks up the new version once versions are refreshed.
+	hits, _ = c1.FetchMultiPostings(ctx, block, []labels.Label{lbl})
+	testutil.Equals(t, map[labels.Label][]byte{lbl: {1}}, hits)
+	c1.versionsRefreshedAt = time.Time{}
+	hits, _ = c1.FetchMultiPostings(ctx, block, []labels.Label{lbl})
+	testutil.Equals(t, 0, len(hits))
+
+	// Items of other types are not invalidated.
+	seriesHits, _ := c1.FetchMultiSeries(ctx, block, []uint64{1})
+	testutil.Equals(t, map[uint64][]byte{1: {1}}, seriesHits)
+
+	c1.StorePostings(ctx, block, lbl, []byte{2})
+	_, ok = memcached.cache[cacheKey{block, cacheKeyPostings(lbl)}.versionedString(1)]
+	testutil.Assert(t, ok, "expected key of version 1")
+	hits, _ = c2.FetchMultiPostings(ctx, block, []labels.Label{lbl})
+	testutil.Equals(t, map[labels.Label][]byte{lbl: {2}}, hits)
+
+	// Lost versions are restored by store gateways knowing them.
+	delete(memcached.cache, "V:Postings")
+	c1.versionsRefreshedAt = time.Time{}
+	c1.FetchMultiPostings(ctx, block, []labels.Label{lbl})
+	testutil.Equals(t, []byte("1"), memcached.cache["V:Postings"])
+
+	// Bumping continues from the highest version known.
+	c3, err := NewMemcachedIndexCache(log.NewNopLogger(), memcached, nil)
+	testutil.Ok(t, err)
+	testutil.Ok(t, c3.Invalidate(ctx, "Postings"))
+	testutil.Equals(t, []byte("2"), memcached.cache["V:Postings"])
+}
+
+func TestMemcachedIndexCache_RefreshVersionsConcurrently(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx := context.Background()
+	block := ulid.MustNew(1, nil)
+	lbl := labels.Label{Name: "instance", Value: "a"}
+
+	memcached := &blockingMemcachedClient{
+		mockedMemcachedClient: newMockedMemcachedClient(nil),
+		versionsRequested:     make(chan struct{}),
+		release:               make(chan struct{}),
+	}
+	memcached.cache["V:Postings"] = []byte("1")
+	c, err := NewMemcachedIndexCache(log.NewNopLogger(), memcached, nil)
+	testutil.Ok(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.StorePostings(ctx, block, lbl, []byte{1})
+	}()
+	<-memcached.versionsRequested
+
+	// Other requests use the current versions while they are being refreshed.
+	testutil.Equals(t, uint64(0), c.keyVersion(cacheTypePostings))
+
+	close(memcached.release)
+	<-done
+	testutil.Equals(t, uint64(1), c.keyVersion(cacheTypePostings))
+	_, ok := memcached.cache[cacheKey{block, cacheKeyPostings(lbl)}.versionedString(1)]
+	testutil.Assert(t, ok, "expected key of version 1")
+}
+
 type mockedPostings struct {
 	block ulid.ULID
 	label labels.Label
@@ -247,3 +337,19 @@ func (c *mockedMemcachedClient) SetAsync(ctx context.Context, key string, value
 func (c *mockedMemcachedClient) Stop() {
 	// Nothing to do.
 }
+
+// blockingMemcachedClient blocks requests for versions of keys until released.
+type blockingMemcachedClient struct {
+	*mockedMemcachedClient
+
+	versionsRequested chan struct{}
+	release           chan struct{}
+}
+
+func (c *blockingMemcachedClient) GetMulti(ctx context.Context, keys []string) map[string][]byte {
+	if len(keys) > 0 && keys[0] == memcachedKeyVersionKey(cacheTypePostings) {
+		close(c.versionsRequested)
+		<-c.release
+	}
+	return c.mockedMemcachedClient.GetMulti(ctx, keys)
+}
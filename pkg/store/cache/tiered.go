@@ -0,0 +1,153 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package storecache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/extprom"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	tierL1 = "l1"
+	tierL2 = "l2"
+)
+
+// TieredIndexCacheConfig holds the config of the tiered index cache.
+type TieredIndexCacheConfig struct {
+	// L1 is the cache queried first, usually a small in-memory one.
+	L1 IndexCacheConfig `yaml:"l1"`
+	// L2 is the cache queried for items missing in L1, usually a remote one.
+	L2 IndexCacheConfig `yaml:"l2"`
+}
+
+// TieredIndexCache is an index cache serving items from the L1 cache, and falling back to the L2 cache for items
+// missing in L1. Items found in L2 are stored in L1, so hot items are served locally. New items are written to both.
+type TieredIndexCache struct {
+	logger log.Logger
+	l1, l2 IndexCache
+}
+
+// NewTieredIndexCache creates a new tiered index cache from the YAML config of both tiers. Metrics of each tier are
+// registered with the "tier" label set to either "l1" or "l2".
+func NewTieredIndexCache(logger log.Logger, reg prometheus.Registerer, conf []byte) (*TieredIndexCache, error) {
+	config := TieredIndexCacheConfig{}
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return nil, errors.Wrap(err, "parsing tiered cache config")
+	}
+
+	l1, err := newTierIndexCache(logger, reg, tierL1, config.L1)
+	if err != nil {
+		return nil, err
+	}
+	l2, err := newTierIndexCache(logger, reg, tierL2, config.L2)
+	if err != nil {
+		return nil, err
+	}
+	return NewTieredIndexCacheWithTiers(logger, l1, l2), nil
+}
+
+func newTierIndexCache(logger log.Logger, reg prometheus.Registerer, tier string, config IndexCacheConfig) (IndexCache, error) {
+	if config.Type == "" {
+		return nil, errors.Errorf("no cache type configured for tier %s", tier)
+	}
+	if strings.ToUpper(string(config.Type)) == string(TIERED) {
+		return nil, errors.Errorf("tier %s cannot be a tiered cache", tier)
+	}
+
+	conf, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal config of tier %s", tier)
+	}
+	c, err := NewIndexCache(log.With(logger, "tier", tier), conf, extprom.WrapRegistererWith(prometheus.Labels{"tier": tier}, reg))
+	if err != nil {
+		return nil, errors.Wrapf(err, "create tier %s", tier)
+	}
+	return c, nil
+}
+
+// NewTieredIndexCacheWithTiers creates a new tiered index cache from the given caches.
+func NewTieredIndexCacheWithTiers(logger log.Logger, l1, l2 IndexCache) *TieredIndexCache {
+	level.Info(logger).Log("msg", "created tiered index cache")
+	return &TieredIndexCache{logger: logger, l1: l1, l2: l2}
+}
+
+// Invalidate invalidates all items of the given type in both tiers. It fails if any of the tiers doesn't support
+// invalidation.
+func (c *TieredIndexCache) Invalidate(ctx context.Context, itemType string) error {
+	// L2 is invalidated first, so L1 is not refilled with invalidated items.
+	for _, tier := range []struct {
+		name  string
+		cache IndexCache
+	}{{name: tierL2, cache: c.l2}, {name: tierL1, cache: c.l1}} {
+		ic, ok := tier.cache.(InvalidatableIndexCache)
+		if !ok {
+			return errors.Errorf("cache of tier %s does not support invalidation", tier.name)
+		}
+		if err := ic.Invalidate(ctx, itemType); err != nil {
+			return errors.Wrapf(err, "invalidate tier %s", tier.name)
+		}
+	}
+	return nil
+}
+
+// StorePostings stores postings in both tiers.
+func (c *TieredIndexCache) StorePostings(ctx context.Context, blockID ulid.ULID, l labels.Label, v []byte) {
+	c.l1.StorePostings(ctx, blockID, l, v)
+	c.l2.StorePostings(ctx, blockID, l, v)
+}
+
+// FetchMultiPostings fetches multiple postings - each identified by a label -
+// and returns a map containing cache hits, along with a list of missing keys.
+// Postings missing in L1 are fetched from L2, and stored in L1 if found.
+func (c *TieredIndexCache) FetchMultiPostings(ctx context.Context, blockID ulid.ULID, keys []labels.Label) (hits map[labels.Label][]byte, misses []labels.Label) {
+	hits, misses = c.l1.FetchMultiPostings(ctx, blockID, keys)
+	if len(misses) == 0 {
+		return hits, misses
+	}
+
+	l2Hits, misses := c.l2.FetchMultiPostings(ctx, blockID, misses)
+	if hits == nil {
+		hits = make(map[labels.Label][]byte, len(l2Hits))
+	}
+	for l, v := range l2Hits {
+		hits[l] = v
+		c.l1.StorePostings(ctx, blockID, l, v)
+	}
+	return hits, misses
+}
+
+// StoreSeries stores series in both tiers.
+func (c *TieredIndexCache) StoreSeries(ctx context.Context, blockID ulid.ULID, id uint64, v []byte) {
+	c.l1.StoreSeries(ctx, blockID, id, v)
+	c.l2.StoreSeries(ctx, blockID, id, v)
+}
+
+// FetchMultiSeries fetches multiple series - each identified by ID - from the cache
+// and returns a map containing cache hits, along with a list of missing IDs.
+// Series missing in L1 are fetched from L2, and stored in L1 if found.
+func (c *TieredIndexCache) FetchMultiSeries(ctx context.Context, blockID ulid.ULID, ids []uint64) (hits map[uint64][]byte, misses []uint64) {
+	hits, misses = c.l1.FetchMultiSeries(ctx, blockID, ids)
+	if len(misses) == 0 {
+		return hits, misses
+	}
+
+	l2Hits, misses := c.l2.FetchMultiSeries(ctx, blockID, misses)
+	if hits == nil {
+		hits = make(map[uint64][]byte, len(l2Hits))
+	}
+	for id, v := range l2Hits {
+		hits[id] = v
+		c.l1.StoreSeries(ctx, blockID, id, v)
+	}
+	return hits, misses
+}
@@ -0,0 +1,143 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package storecache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fortytw2/leaktest"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestNewTieredIndexCache(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	for _, tcase := range []struct {
+		name string
+		conf string
+		err  bool
+	}{
+		{
+			name: "both tiers",
+			conf: `
+type: TIERED
+config:
+  l1:
+    type: IN-MEMORY
+    config:
+      max_size: 1MB
+      max_item_size: 1KB
+  l2:
+    type: in-memory-tinylfu
+    config:
+      max_size: 2MB
+      max_item_size: 1KB
+`,
+		},
+		{
+			name: "missing tier",
+			conf: `
+type: TIERED
+config:
+  l1:
+    type: IN-MEMORY
+`,
+			err: true,
+		},
+		{
+			name: "nested tiered cache",
+			conf: `
+type: TIERED
+config:
+  l1:
+    type: IN-MEMORY
+  l2:
+    type: tiered
+`,
+			err: true,
+		},
+		{
+			name: "unknown field",
+			conf: `
+type: TIERED
+config:
+  l3:
+    type: IN-MEMORY
+`,
+			err: true,
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			// Both tiers register the same metrics, distinguished by the tier label.
+			c, err := NewIndexCache(log.NewNopLogger(), []byte(tcase.conf), prometheus.NewRegistry())
+			if tcase.err {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+			_, ok := c.(*TieredIndexCache)
+			testutil.Assert(t, ok, "expected tiered index cache, got %T", c)
+		})
+	}
+}
+
+func TestTieredIndexCache(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	l1, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), prometheus.NewRegistry(), InMemoryIndexCacheConfig{MaxSize: 1024, MaxItemSize: 1024})
+	testutil.Ok(t, err)
+	l2, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), prometheus.NewRegistry(), InMemoryIndexCacheConfig{MaxSize: 1024, MaxItemSize: 1024})
+	testutil.Ok(t, err)
+	c := NewTieredIndexCacheWithTiers(log.NewNopLogger(), l1, l2)
+
+	ctx := context.Background()
+	uid := ulid.MustNew(0, nil)
+	lbl1, lbl2, lbl3 := labels.Label{Name: "a", Value: "1"}, labels.Label{Name: "a", Value: "2"}, labels.Label{Name: "a", Value: "3"}
+
+	// Stores are written through to both tiers.
+	c.StorePostings(ctx, uid, lbl1, []byte{1})
+	c.StoreSeries(ctx, uid, 1, []byte{1})
+	// Simulate items present in L2 only, e.g. stored by other store gateways, or evicted from L1.
+	l2.StorePostings(ctx, uid, lbl2, []byte{2})
+	l2.StoreSeries(ctx, uid, 2, []byte{2})
+
+	hits, misses := c.FetchMultiPostings(ctx, uid, []labels.Label{lbl1, lbl2, lbl3})
+	testutil.Equals(t, map[labels.Label][]byte{lbl1: {1}, lbl2: {2}}, hits)
+	testutil.Equals(t, []labels.Label{lbl3}, misses)
+
+	seriesHits, seriesMisses := c.FetchMultiSeries(ctx, uid, []uint64{1, 2, 3})
+	testutil.Equals(t, map[uint64][]byte{1: {1}, 2: {2}}, seriesHits)
+	testutil.Equals(t, []uint64{3}, seriesMisses)
+
+	// Only items missing in L1 were requested from L2.
+	testutil.Equals(t, 3.0, promtest.ToFloat64(l1.requests.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(l1.hits.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(l2.requests.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(l2.hits.WithLabelValues(cacheTypePostings)))
+
+	// Items found in L2 are now served from L1.
+	hits, misses = c.FetchMultiPostings(ctx, uid, []labels.Label{lbl2})
+	testutil.Equals(t, map[labels.Label][]byte{lbl2: {2}}, hits)
+	testutil.Equals(t, 0, len(misses))
+	seriesHits, seriesMisses = c.FetchMultiSeries(ctx, uid, []uint64{2})
+	testutil.Equals(t, map[uint64][]byte{2: {2}}, seriesHits)
+	testutil.Equals(t, 0, len(seriesMisses))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(l2.requests.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, 2.0, promtest.ToFloat64(l2.requests.WithLabelValues(cacheTypeSeries)))
+
+	// Invalidation removes items from both tiers.
+	testutil.Ok(t, c.Invalidate(ctx, "series"))
+	seriesHits, seriesMisses = c.FetchMultiSeries(ctx, uid, []uint64{1, 2})
+	testutil.Equals(t, 0, len(seriesHits))
+	testutil.Equals(t, []uint64{1, 2}, seriesMisses)
+	hits, _ = c.FetchMultiPostings(ctx, uid, []labels.Label{lbl1})
+	testutil.Equals(t, map[labels.Label][]byte{lbl1: {1}}, hits)
+}
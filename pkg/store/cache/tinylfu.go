@@ -0,0 +1,470 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package storecache
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+
+	"github.com/cespare/xxhash"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/model"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	DefaultTinyLFUIndexCacheConfig = TinyLFUIndexCacheConfig{
+		MaxSize:     250 * 1024 * 1024,
+		MaxItemSize: 125 * 1024 * 1024,
+	}
+)
+
+const (
+	// tinyLFUSampleSize is the number of entries sampled to choose an eviction victim from.
+	tinyLFUSampleSize = 5
+	// tinyLFUBytesPerCounter is the default ratio of the max cache size to the number of frequency counters.
+	tinyLFUBytesPerCounter = 100
+)
+
+// TinyLFUIndexCacheConfig holds the in-memory TinyLFU index cache config.
+type TinyLFUIndexCacheConfig struct {
+	// MaxSize represents overall maximum number of bytes cache can contain.
+	MaxSize model.Bytes `yaml:"max_size"`
+	// MaxItemSize represents maximum size of single item.
+	MaxItemSize model.Bytes `yaml:"max_item_size"`
+	// NumCounters is the number of counters estimating frequencies of accessed keys. It should be about 10 times the
+	// number of items the cache holds. If zero, there is one counter per 100 bytes of MaxSize.
+	NumCounters int `yaml:"num_counters"`
+}
+
+// parseTinyLFUIndexCacheConfig unmarshals a buffer into a TinyLFUIndexCacheConfig with default values.
+func parseTinyLFUIndexCacheConfig(conf []byte) (TinyLFUIndexCacheConfig, error) {
+	config := DefaultTinyLFUIndexCacheConfig
+	if err := yaml.Unmarshal(conf, &config); err != nil {
+		return TinyLFUIndexCacheConfig{}, err
+	}
+
+	return config, nil
+}
+
+type tinyLFUEntry struct {
+	val  []byte
+	size uint64
+}
+
+// TinyLFUIndexCache is an in-memory index cache admitting and evicting entries by their access frequency and size,
+// similar to Ristretto. Frequencies of all accessed keys, including the ones not in the cache, are estimated by
+// a count-min sketch. When the cache is full, victims are chosen from a small random sample of entries as the ones
+// with the lowest frequency per byte, and the new entry is admitted only if its frequency per byte is not lower than
+// that of each victim. Unlike LRU, giant entries used once don't push out many small hot entries.
+type TinyLFUIndexCache struct {
+	mtx sync.Mutex
+
+	logger           log.Logger
+	entries          map[cacheKey]*tinyLFUEntry
+	sketch           *countMinSketch
+	maxSizeBytes     uint64
+	maxItemSizeBytes uint64
+
+	curSize uint64
+
+	evicted          *prometheus.CounterVec
+	requests         *prometheus.CounterVec
+	hits             *prometheus.CounterVec
+	added            *prometheus.CounterVec
+	rejected         *prometheus.CounterVec
+	current          *prometheus.GaugeVec
+	currentSize      *prometheus.GaugeVec
+	totalCurrentSize *prometheus.GaugeVec
+	overflow         *prometheus.CounterVec
+}
+
+// NewTinyLFUIndexCache creates a new thread-safe TinyLFU cache for index entries and ensures the total cache
+// size approximately does not exceed maxBytes.
+func NewTinyLFUIndexCache(logger log.Logger, reg prometheus.Registerer, conf []byte) (*TinyLFUIndexCache, error) {
+	config, err := parseTinyLFUIndexCacheConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTinyLFUIndexCacheWithConfig(logger, reg, config)
+}
+
+// NewTinyLFUIndexCacheWithConfig creates a new thread-safe TinyLFU cache for index entries and ensures the total
+// cache size approximately does not exceed maxBytes.
+func NewTinyLFUIndexCacheWithConfig(logger log.Logger, reg prometheus.Registerer, config TinyLFUIndexCacheConfig) (*TinyLFUIndexCache, error) {
+	if config.MaxItemSize > config.MaxSize {
+		return nil, errors.Errorf("max item size (%v) cannot be bigger than overall cache size (%v)", config.MaxItemSize, config.MaxSize)
+	}
+	if config.NumCounters < 0 {
+		return nil, errors.Errorf("number of counters (%d) cannot be negative", config.NumCounters)
+	}
+	numCounters := config.NumCounters
+	if numCounters == 0 {
+		numCounters = int(config.MaxSize / tinyLFUBytesPerCounter)
+	}
+
+	c := &TinyLFUIndexCache{
+		logger:           logger,
+		entries:          map[cacheKey]*tinyLFUEntry{},
+		sketch:           newCountMinSketch(numCounters),
+		maxSizeBytes:     uint64(config.MaxSize),
+		maxItemSizeBytes: uint64(config.MaxItemSize),
+	}
+
+	c.evicted = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_items_evicted_total",
+		Help: "Total number of items that were evicted from the index cache.",
+	}, []string{"item_type"})
+	c.evicted.WithLabelValues(cacheTypePostings)
+	c.evicted.WithLabelValues(cacheTypeSeries)
+
+	c.added = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_items_added_total",
+		Help: "Total number of items that were added to the index cache.",
+	}, []string{"item_type"})
+	c.added.WithLabelValues(cacheTypePostings)
+	c.added.WithLabelValues(cacheTypeSeries)
+
+	c.rejected = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_items_rejected_total",
+		Help: "Total number of items that were not admitted to the index cache, because they were accessed less often per byte than items they would evict.",
+	}, []string{"item_type"})
+	c.rejected.WithLabelValues(cacheTypePostings)
+	c.rejected.WithLabelValues(cacheTypeSeries)
+
+	c.requests = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_requests_total",
+		Help: "Total number of requests to the cache.",
+	}, []string{"item_type"})
+	c.requests.WithLabelValues(cacheTypePostings)
+	c.requests.WithLabelValues(cacheTypeSeries)
+
+	c.overflow = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_items_overflowed_total",
+		Help: "Total number of items that could not be added to the cache due to being too big.",
+	}, []string{"item_type"})
+	c.overflow.WithLabelValues(cacheTypePostings)
+	c.overflow.WithLabelValues(cacheTypeSeries)
+
+	c.hits = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_hits_total",
+		Help: "Total number of requests to the cache that were a hit.",
+	}, []string{"item_type"})
+	c.hits.WithLabelValues(cacheTypePostings)
+	c.hits.WithLabelValues(cacheTypeSeries)
+
+	c.current = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thanos_store_index_cache_items",
+		Help: "Current number of items in the index cache.",
+	}, []string{"item_type"})
+	c.current.WithLabelValues(cacheTypePostings)
+	c.current.WithLabelValues(cacheTypeSeries)
+
+	c.currentSize = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thanos_store_index_cache_items_size_bytes",
+		Help: "Current byte size of items in the index cache.",
+	}, []string{"item_type"})
+	c.currentSize.WithLabelValues(cacheTypePostings)
+	c.currentSize.WithLabelValues(cacheTypeSeries)
+
+	c.totalCurrentSize = promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "thanos_store_index_cache_total_size_bytes",
+		Help: "Current byte size of items (both value and key) in the index cache.",
+	}, []string{"item_type"})
+	c.totalCurrentSize.WithLabelValues(cacheTypePostings)
+	c.totalCurrentSize.WithLabelValues(cacheTypeSeries)
+
+	_ = promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "thanos_store_index_cache_max_size_bytes",
+		Help: "Maximum number of bytes to be held in the index cache.",
+	}, func() float64 {
+		return float64(c.maxSizeBytes)
+	})
+	_ = promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "thanos_store_index_cache_max_item_size_bytes",
+		Help: "Maximum number of bytes for single entry to be held in the index cache.",
+	}, func() float64 {
+		return float64(c.maxItemSizeBytes)
+	})
+
+	level.Info(logger).Log(
+		"msg", "created in-memory TinyLFU index cache",
+		"maxItemSizeBytes", c.maxItemSizeBytes,
+		"maxSizeBytes", c.maxSizeBytes,
+		"numCounters", numCounters,
+	)
+	return c, nil
+}
+
+func (c *TinyLFUIndexCache) get(typ string, key cacheKey) ([]byte, bool) {
+	c.requests.WithLabelValues(typ).Inc()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	// Misses are counted too, so frequently requested entries are admitted once stored.
+	c.sketch.increment(hashCacheKey(key))
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.hits.WithLabelValues(typ).Inc()
+	return e.val, true
+}
+
+func (c *TinyLFUIndexCache) set(typ string, key cacheKey, val []byte) {
+	var size = sliceHeaderSize + uint64(len(val))
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if size > c.maxItemSizeBytes {
+		level.Debug(c.logger).Log(
+			"msg", "item bigger than maxItemSizeBytes. Ignoring..",
+			"maxItemSizeBytes", c.maxItemSizeBytes,
+			"maxSizeBytes", c.maxSizeBytes,
+			"curSize", c.curSize,
+			"itemSize", size,
+			"cacheType", typ,
+		)
+		c.overflow.WithLabelValues(typ).Inc()
+		return
+	}
+
+	victims, ok := c.victims(c.sketch.estimate(hashCacheKey(key)), size)
+	if !ok {
+		c.rejected.WithLabelValues(typ).Inc()
+		return
+	}
+	for _, k := range victims {
+		c.evict(k)
+	}
+
+	// The caller may be passing in a sub-slice of a huge array. Copy the data
+	// to ensure we don't waste huge amounts of space for something small.
+	v := make([]byte, len(val))
+	copy(v, val)
+	c.entries[key] = &tinyLFUEntry{val: v, size: size}
+
+	c.added.WithLabelValues(typ).Inc()
+	c.currentSize.WithLabelValues(typ).Add(float64(size))
+	c.totalCurrentSize.WithLabelValues(typ).Add(float64(size + key.size()))
+	c.current.WithLabelValues(typ).Inc()
+	c.curSize += size
+}
+
+// victims returns keys of entries to evict, so an entry of the given frequency and size fits into the cache. It returns
+// false if the entry should not be admitted, because some victim has a higher frequency per byte.
+func (c *TinyLFUIndexCache) victims(freq uint64, size uint64) ([]cacheKey, bool) {
+	var (
+		victims []cacheKey
+		chosen  = map[cacheKey]struct{}{}
+		freed   uint64
+	)
+	for c.curSize-freed+size > c.maxSizeBytes {
+		var (
+			victim     cacheKey
+			victimFreq uint64
+			victimSize uint64
+			sampled    int
+		)
+		// Iteration order of maps is random, which is good enough for sampling.
+		for k, e := range c.entries {
+			if _, ok := chosen[k]; ok {
+				continue
+			}
+			f := c.sketch.estimate(hashCacheKey(k))
+			// Compares f/e.size < victimFreq/victimSize without division.
+			if sampled == 0 || f*victimSize < victimFreq*e.size {
+				victim, victimFreq, victimSize = k, f, e.size
+			}
+			if sampled++; sampled == tinyLFUSampleSize {
+				break
+			}
+		}
+		if sampled == 0 {
+			// Only possible if the size of entries got out of sync with the current size.
+			return victims, true
+		}
+		if freq*victimSize < victimFreq*size {
+			return nil, false
+		}
+		victims = append(victims, victim)
+		chosen[victim] = struct{}{}
+		freed += victimSize
+	}
+	return victims, true
+}
+
+func (c *TinyLFUIndexCache) evict(key cacheKey) {
+	e, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+
+	k := key.keyType()
+	c.evicted.WithLabelValues(k).Inc()
+	c.current.WithLabelValues(k).Dec()
+	c.currentSize.WithLabelValues(k).Sub(float64(e.size))
+	c.totalCurrentSize.WithLabelValues(k).Sub(float64(e.size + key.size()))
+
+	c.curSize -= e.size
+}
+
+// Invalidate removes all items of the given type from the cache.
+func (c *TinyLFUIndexCache) Invalidate(_ context.Context, itemType string) error {
+	typ, err := parseItemType(itemType)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for k := range c.entries {
+		if k.keyType() == typ {
+			c.evict(k)
+		}
+	}
+	return nil
+}
+
+// StorePostings sets the postings identified by the ulid and label to the value v,
+// if the postings already exists in the cache it is not mutated.
+func (c *TinyLFUIndexCache) StorePostings(_ context.Context, blockID ulid.ULID, l labels.Label, v []byte) {
+	c.set(cacheTypePostings, cacheKey{block: blockID, key: copyToKey(l)}, v)
+}
+
+// FetchMultiPostings fetches multiple postings - each identified by a label -
+// and returns a map containing cache hits, along with a list of missing keys.
+func (c *TinyLFUIndexCache) FetchMultiPostings(_ context.Context, blockID ulid.ULID, keys []labels.Label) (hits map[labels.Label][]byte, misses []labels.Label) {
+	hits = map[labels.Label][]byte{}
+
+	for _, key := range keys {
+		if b, ok := c.get(cacheTypePostings, cacheKey{blockID, cacheKeyPostings(key)}); ok {
+			hits[key] = b
+			continue
+		}
+
+		misses = append(misses, key)
+	}
+
+	return hits, misses
+}
+
+// StoreSeries sets the series identified by the ulid and id to the value v,
+// if the series already exists in the cache it is not mutated.
+func (c *TinyLFUIndexCache) StoreSeries(_ context.Context, blockID ulid.ULID, id uint64, v []byte) {
+	c.set(cacheTypeSeries, cacheKey{blockID, cacheKeySeries(id)}, v)
+}
+
+// FetchMultiSeries fetches multiple series - each identified by ID - from the cache
+// and returns a map containing cache hits, along with a list of missing IDs.
+func (c *TinyLFUIndexCache) FetchMultiSeries(_ context.Context, blockID ulid.ULID, ids []uint64) (hits map[uint64][]byte, misses []uint64) {
+	hits = map[uint64][]byte{}
+
+	for _, id := range ids {
+		if b, ok := c.get(cacheTypeSeries, cacheKey{blockID, cacheKeySeries(id)}); ok {
+			hits[id] = b
+			continue
+		}
+
+		misses = append(misses, id)
+	}
+
+	return hits, misses
+}
+
+// hashCacheKey returns the hash of the key used by the frequency sketch.
+func hashCacheKey(k cacheKey) uint64 {
+	d := xxhash.New()
+	_, _ = d.Write(k.block[:])
+	switch key := k.key.(type) {
+	case cacheKeyPostings:
+		_, _ = d.Write([]byte{'P'})
+		_, _ = d.Write([]byte(key.Name))
+		_, _ = d.Write([]byte{0xff})
+		_, _ = d.Write([]byte(key.Value))
+	case cacheKeySeries:
+		var b [9]byte
+		b[0] = 'S'
+		binary.BigEndian.PutUint64(b[1:], uint64(key))
+		_, _ = d.Write(b[:])
+	}
+	return d.Sum64()
+}
+
+// countMinSketch estimates frequencies of hashes, with counters halved periodically, so estimates reflect recent
+// accesses.
+type countMinSketch struct {
+	rows      [4][]uint8
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+// countMinSketchSeeds are mixed into the hash, so each row maps hashes to different counters.
+var countMinSketchSeeds = [4]uint64{0x9e3779b97f4a7c15, 0xc2b2ae3d27d4eb4f, 0x165667b19e3779f9, 0x27d4eb2f165667c5}
+
+func newCountMinSketch(numCounters int) *countMinSketch {
+	// Power of two width allows masking instead of modulo.
+	width := 16
+	for width < numCounters {
+		width *= 2
+	}
+	s := &countMinSketch{mask: uint64(width - 1), resetAt: width}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *countMinSketch) index(h uint64, row int) uint64 {
+	h ^= countMinSketchSeeds[row]
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	return h & s.mask
+}
+
+func (s *countMinSketch) increment(h uint64) {
+	for i := range s.rows {
+		if idx := s.index(h, i); s.rows[i][idx] < 255 {
+			s.rows[i][idx]++
+		}
+	}
+	if s.additions++; s.additions >= s.resetAt {
+		s.halve()
+	}
+}
+
+func (s *countMinSketch) estimate(h uint64) uint64 {
+	min := uint8(255)
+	for i := range s.rows {
+		if v := s.rows[i][s.index(h, i)]; v < min {
+			min = v
+		}
+	}
+	return uint64(min)
+}
+
+func (s *countMinSketch) halve() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] /= 2
+		}
+	}
+	s.additions = 0
+}
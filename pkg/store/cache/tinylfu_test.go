@@ -0,0 +1,174 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package storecache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fortytw2/leaktest"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestNewTinyLFUIndexCache(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	// Should return error on invalid YAML config.
+	conf := []byte("invalid")
+	cache, err := NewTinyLFUIndexCache(log.NewNopLogger(), nil, conf)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, (*TinyLFUIndexCache)(nil), cache)
+
+	// Should instance a cache with default config on empty YAML config.
+	cache, err = NewTinyLFUIndexCache(log.NewNopLogger(), nil, []byte{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, uint64(DefaultTinyLFUIndexCacheConfig.MaxSize), cache.maxSizeBytes)
+	testutil.Equals(t, uint64(DefaultTinyLFUIndexCacheConfig.MaxItemSize), cache.maxItemSizeBytes)
+
+	// Should instance a cache with specified YAML config with units.
+	cache, err = NewTinyLFUIndexCache(log.NewNopLogger(), nil, []byte(`
+max_size: 1MB
+max_item_size: 2KB
+num_counters: 1000
+`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, uint64(1024*1024), cache.maxSizeBytes)
+	testutil.Equals(t, uint64(2*1024), cache.maxItemSizeBytes)
+	testutil.Equals(t, 1024, len(cache.sketch.rows[0]))
+
+	// Should return error if max item size is bigger than max size.
+	_, err = NewTinyLFUIndexCache(log.NewNopLogger(), nil, []byte(`
+max_size: 1KB
+max_item_size: 2KB
+`))
+	testutil.NotOk(t, err)
+
+	// Should be created by the factory.
+	c, err := NewIndexCache(log.NewNopLogger(), []byte(`
+type: IN-MEMORY-TINYLFU
+config:
+  max_size: 1MB
+  max_item_size: 1KB
+`), nil)
+	testutil.Ok(t, err)
+	_, ok := c.(*TinyLFUIndexCache)
+	testutil.Assert(t, ok, "expected TinyLFU index cache, got %T", c)
+}
+
+func TestTinyLFUIndexCache_UpdateItem(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	metrics := prometheus.NewRegistry()
+	cache, err := NewTinyLFUIndexCacheWithConfig(log.NewNopLogger(), metrics, TinyLFUIndexCacheConfig{
+		MaxItemSize: 1024,
+		MaxSize:     1024,
+	})
+	testutil.Ok(t, err)
+
+	ctx := context.Background()
+	uid := ulid.MustNew(0, nil)
+	lbl := labels.Label{Name: "foo", Value: "bar"}
+
+	cache.StorePostings(ctx, uid, lbl, []byte{1, 2})
+	cache.StoreSeries(ctx, uid, 1234, []byte{3})
+
+	hits, misses := cache.FetchMultiPostings(ctx, uid, []labels.Label{lbl, {Name: "foo", Value: "baz"}})
+	testutil.Equals(t, map[labels.Label][]byte{lbl: {1, 2}}, hits)
+	testutil.Equals(t, []labels.Label{{Name: "foo", Value: "baz"}}, misses)
+
+	seriesHits, seriesMisses := cache.FetchMultiSeries(ctx, uid, []uint64{1234, 5})
+	testutil.Equals(t, map[uint64][]byte{1234: {3}}, seriesHits)
+	testutil.Equals(t, []uint64{5}, seriesMisses)
+
+	// Existing entries are not mutated.
+	cache.StorePostings(ctx, uid, lbl, []byte{5})
+	hits, _ = cache.FetchMultiPostings(ctx, uid, []labels.Label{lbl})
+	testutil.Equals(t, map[labels.Label][]byte{lbl: {1, 2}}, hits)
+
+	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
+	testutil.Equals(t, float64(3), promtest.ToFloat64(cache.requests.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(cache.hits.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, uint64(2*sliceHeaderSize+3), cache.curSize)
+
+	// Too big items overflow.
+	cache.StoreSeries(ctx, uid, 1, make([]byte, 1024))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.overflow.WithLabelValues(cacheTypeSeries)))
+}
+
+func TestTinyLFUIndexCache_Admission(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	metrics := prometheus.NewRegistry()
+	cache, err := NewTinyLFUIndexCacheWithConfig(log.NewNopLogger(), metrics, TinyLFUIndexCacheConfig{
+		MaxItemSize: 1000,
+		MaxSize:     1000,
+		NumCounters: 1000,
+	})
+	testutil.Ok(t, err)
+
+	ctx := context.Background()
+	uid := ulid.MustNew(0, nil)
+
+	// Fill the cache with small entries, each requested a few times.
+	small := make([]byte, 50-sliceHeaderSize)
+	for i := uint64(0); i < 20; i++ {
+		for j := 0; j < 3; j++ {
+			cache.FetchMultiSeries(ctx, uid, []uint64{i})
+		}
+		cache.StoreSeries(ctx, uid, i, small)
+	}
+	testutil.Equals(t, uint64(1000), cache.curSize)
+	testutil.Equals(t, float64(20), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
+
+	// Giant postings requested once are not admitted, because they would evict many hotter entries.
+	giant := labels.Label{Name: "__name__", Value: "giant"}
+	for i := 0; i < 10; i++ {
+		lbl := labels.Label{Name: giant.Name, Value: fmt.Sprintf("%s%d", giant.Value, i)}
+		cache.FetchMultiPostings(ctx, uid, []labels.Label{lbl})
+		cache.StorePostings(ctx, uid, lbl, make([]byte, 800))
+	}
+	testutil.Equals(t, float64(10), promtest.ToFloat64(cache.rejected.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypeSeries)))
+	testutil.Equals(t, uint64(1000), cache.curSize)
+
+	// A small entry requested more often than the cached ones is admitted, evicting a single entry.
+	for j := 0; j < 5; j++ {
+		cache.FetchMultiSeries(ctx, uid, []uint64{100})
+	}
+	cache.StoreSeries(ctx, uid, 100, small)
+	hits, _ := cache.FetchMultiSeries(ctx, uid, []uint64{100})
+	testutil.Equals(t, 1, len(hits))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypeSeries)))
+	testutil.Equals(t, float64(20), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypeSeries)))
+	testutil.Equals(t, uint64(1000), cache.curSize)
+	testutil.Equals(t, float64(cache.curSize), promtest.ToFloat64(cache.currentSize.WithLabelValues(cacheTypeSeries)))
+}
+
+func TestCountMinSketch(t *testing.T) {
+	s := newCountMinSketch(64)
+	testutil.Equals(t, 64, len(s.rows[0]))
+
+	for i := 0; i < 10; i++ {
+		s.increment(1)
+	}
+	s.increment(2)
+	testutil.Assert(t, s.estimate(1) >= 10, "estimate of hash 1 lower than its count")
+	testutil.Assert(t, s.estimate(2) >= 1, "estimate of hash 2 lower than its count")
+	testutil.Assert(t, s.estimate(1) > s.estimate(2), "expected hash 1 to be estimated as more frequent")
+
+	// Counters are halved after a number of increments equal to their number.
+	for i := 0; i < 64-11; i++ {
+		s.increment(uint64(1000 + i))
+	}
+	testutil.Assert(t, s.estimate(1) < 10, "expected counters to be halved")
+	testutil.Equals(t, 0, s.additions)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/index"
+
+	"github.com/thanos-io/thanos/pkg/block/indexheader"
+	"github.com/thanos-io/thanos/pkg/gate"
+)
+
+var errIndexHeaderNotLoaded = errors.New("index-header not built yet")
+
+// lazyIndexHeaderReader is the index-header reader of a block admitted before its index-header was built. The
+// index-header is built on first use of the block, by up to the concurrency of the gate at once, so new blocks are
+// served as soon as they are discovered, rather than once the index-headers of all of them are built.
+type lazyIndexHeaderReader struct {
+	gate   gate.Gater
+	create func(ctx context.Context) (indexheader.Reader, int64, error)
+
+	mtx    sync.Mutex
+	r      indexheader.Reader
+	size   int64
+	closed bool
+}
+
+// load builds the index-header, unless it is built already. Failed builds are retried by the next use of the block.
+func (r *lazyIndexHeaderReader) load(ctx context.Context) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.r != nil {
+		return nil
+	}
+	if r.closed {
+		return errors.New("index-header reader closed")
+	}
+
+	if err := r.gate.IsMyTurn(ctx); err != nil {
+		return errors.Wrap(err, "wait for turn to build index-header")
+	}
+	defer r.gate.Done()
+
+	ir, size, err := r.create(ctx)
+	if err != nil {
+		return err
+	}
+	r.r, r.size = ir, size
+	return nil
+}
+
+func (r *lazyIndexHeaderReader) reader() indexheader.Reader {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.r
+}
+
+// indexHeaderSize returns the size of the index-header, which is zero until it is built.
+func (r *lazyIndexHeaderReader) indexHeaderSize() int64 {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.size
+}
+
+func (r *lazyIndexHeaderReader) Close() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.closed = true
+	if r.r == nil {
+		return nil
+	}
+	return r.r.Close()
+}
+
+func (r *lazyIndexHeaderReader) IndexVersion() int {
+	if ir := r.reader(); ir != nil {
+		return ir.IndexVersion()
+	}
+	return 0
+}
+
+func (r *lazyIndexHeaderReader) PostingsOffset(name string, value string) (index.Range, error) {
+	if ir := r.reader(); ir != nil {
+		return ir.PostingsOffset(name, value)
+	}
+	return index.Range{}, errIndexHeaderNotLoaded
+}
+
+func (r *lazyIndexHeaderReader) LookupSymbol(o uint32) (string, error) {
+	if ir := r.reader(); ir != nil {
+		return ir.LookupSymbol(o)
+	}
+	return "", errIndexHeaderNotLoaded
+}
+
+func (r *lazyIndexHeaderReader) LabelValues(name string) ([]string, error) {
+	if ir := r.reader(); ir != nil {
+		return ir.LabelValues(name)
+	}
+	return nil, errIndexHeaderNotLoaded
+}
+
+func (r *lazyIndexHeaderReader) LabelNames() []string {
+	if ir := r.reader(); ir != nil {
+		return ir.LabelNames()
+	}
+	return nil
+}
@@ -662,6 +662,94 @@ func (p *PrometheusStore) LabelValues(ctx context.Context, r *storepb.LabelValue
 	return &storepb.LabelValuesResponse{Values: m.Data}, nil
 }
 
+// promStatistic is a statistic in responses of the Prometheus TSDB status API.
+type promStatistic struct {
+	Name  string `json:"name"`
+	Value uint64 `json:"value"`
+}
+
+func promStatisticsToProto(stats []promStatistic) []storepb.Statistic {
+	res := make([]storepb.Statistic, 0, len(stats))
+	for _, s := range stats {
+		res = append(res, storepb.Statistic{Name: s.Name, Value: s.Value})
+	}
+	return res
+}
+
+// TSDBStatus returns cardinality statistics of the head block of Prometheus from its TSDB status API.
+// Statistics of the head block are reported by Prometheus v2.25 or newer only, the time range is inverted otherwise.
+func (p *PrometheusStore) TSDBStatus(ctx context.Context, _ *storepb.TSDBStatusRequest) (*storepb.TSDBStatusResponse, error) {
+	u := *p.base
+	u.Path = path.Join(u.Path, "/api/v1/status/tsdb")
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	span, ctx := tracing.StartSpan(ctx, "/prom_tsdb_status HTTP[client]")
+	defer span.Finish()
+
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(p.logger, resp.Body, "tsdb status request body")
+
+	// Prometheus versions before v2.14 do not have the TSDB status API.
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, status.Error(codes.Unimplemented, "TSDB status API not supported by Prometheus")
+	}
+
+	var m struct {
+		Data struct {
+			HeadStats *struct {
+				NumSeries  uint64 `json:"numSeries"`
+				ChunkCount uint64 `json:"chunkCount"`
+				MinTime    int64  `json:"minTime"`
+				MaxTime    int64  `json:"maxTime"`
+			} `json:"headStats"`
+			SeriesCountByMetricName     []promStatistic `json:"seriesCountByMetricName"`
+			LabelValueCountByLabelName  []promStatistic `json:"labelValueCountByLabelName"`
+			MemoryInBytesByLabelName    []promStatistic `json:"memoryInBytesByLabelName"`
+			SeriesCountByLabelValuePair []promStatistic `json:"seriesCountByLabelValuePair"`
+		} `json:"data"`
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if err = json.Unmarshal(body, &m); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if m.Status != SUCCESS {
+		code, exists := statusToCode[resp.StatusCode]
+		if !exists {
+			return nil, status.Error(codes.Internal, m.Error)
+		}
+		return nil, status.Error(code, m.Error)
+	}
+
+	res := &storepb.TSDBStatusResponse{
+		MinTime:                     math.MaxInt64,
+		MaxTime:                     math.MinInt64,
+		SeriesCountByMetricName:     promStatisticsToProto(m.Data.SeriesCountByMetricName),
+		LabelValueCountByLabelName:  promStatisticsToProto(m.Data.LabelValueCountByLabelName),
+		MemoryInBytesByLabelName:    promStatisticsToProto(m.Data.MemoryInBytesByLabelName),
+		SeriesCountByLabelValuePair: promStatisticsToProto(m.Data.SeriesCountByLabelValuePair),
+	}
+	if h := m.Data.HeadStats; h != nil {
+		res.NumSeries, res.NumChunks, res.MinTime, res.MaxTime = h.NumSeries, h.ChunkCount, h.MinTime, h.MaxTime
+	}
+	return res, nil
+}
+
 // seriesLabels returns the labels from Prometheus series API.
 func (p *PrometheusStore) seriesLabels(ctx context.Context, matchers []storepb.LabelMatcher, startTime, endTime int64) ([]map[string]string, error) {
 	u := *p.base
@@ -7,6 +7,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -22,6 +24,8 @@ import (
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/store/storepb/prompb"
 	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestPrometheusStore_Series_e2e(t *testing.T) {
@@ -450,6 +454,52 @@ func TestPrometheusStore_Info(t *testing.T) {
 	testutil.Equals(t, int64(456), resp.MaxTime)
 }
 
+func TestPrometheusStore_TSDBStatus(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/prom/api/v1/status/tsdb" || body == "" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL + "/prom")
+	testutil.Ok(t, err)
+	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar,
+		func() labels.Labels { return labels.FromStrings("region", "eu-west") },
+		func() (int64, int64) { return 123, 456 })
+	testutil.Ok(t, err)
+
+	// Prometheus without TSDB status API.
+	_, err = proxy.TSDBStatus(context.Background(), &storepb.TSDBStatusRequest{})
+	testutil.Equals(t, codes.Unimplemented, status.Code(err))
+
+	// Prometheus without head statistics.
+	body = `{"status":"success","data":{"seriesCountByMetricName":[{"name":"up","value":2}],"labelValueCountByLabelName":[{"name":"job","value":2}],"memoryInBytesByLabelName":[{"name":"job","value":6}],"seriesCountByLabelValuePair":[{"name":"job=a","value":1}]}}`
+	resp, err := proxy.TSDBStatus(context.Background(), &storepb.TSDBStatusRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, &storepb.TSDBStatusResponse{
+		MinTime:                     math.MaxInt64,
+		MaxTime:                     math.MinInt64,
+		SeriesCountByMetricName:     []storepb.Statistic{{Name: "up", Value: 2}},
+		LabelValueCountByLabelName:  []storepb.Statistic{{Name: "job", Value: 2}},
+		MemoryInBytesByLabelName:    []storepb.Statistic{{Name: "job", Value: 6}},
+		SeriesCountByLabelValuePair: []storepb.Statistic{{Name: "job=a", Value: 1}},
+	}, resp)
+
+	body = `{"status":"success","data":{"headStats":{"numSeries":2,"chunkCount":4,"minTime":1000,"maxTime":3000},"seriesCountByMetricName":[{"name":"up","value":2}]}}`
+	resp, err = proxy.TSDBStatus(context.Background(), &storepb.TSDBStatusRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, uint64(2), resp.NumSeries)
+	testutil.Equals(t, uint64(4), resp.NumChunks)
+	testutil.Equals(t, int64(1000), resp.MinTime)
+	testutil.Equals(t, int64(3000), resp.MaxTime)
+}
+
 func testSeries_SplitSamplesIntoChunksWithMaxSizeOfUint16_e2e(t *testing.T, appender tsdb.Appender, newStore func() storepb.StoreServer) {
 	baseT := timestamp.FromTime(time.Now().AddDate(0, 0, -2)) / 1000 * 1000
 
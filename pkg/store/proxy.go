@@ -4,6 +4,7 @@
 package store
 
 import (
+	"container/heap"
 	"context"
 	"fmt"
 	"io"
@@ -46,6 +47,30 @@ type Client interface {
 	Addr() string
 }
 
+// GroupedClient is a Client of a store which may be in a group of stores with a priority, e.g. store gateways of the
+// local region. Stores of groups with lower priority are only queried for series if groups of higher priority holding
+// the same label sets, apart from replica labels, cannot serve the requested time range.
+type GroupedClient interface {
+	Client
+
+	// Group returns the name and priority of the group of the store, if it is in one. Lower values are preferred.
+	Group() (name string, priority int, ok bool)
+}
+
+// CapableClient is a Client of a store advertising optional extensions of the StoreAPI it supports.
+type CapableClient interface {
+	Client
+
+	// Capabilities returns the capabilities advertised by the store, e.g. storepb.CapabilityArrowSeries.
+	Capabilities() []string
+}
+
+// arrowSeriesStore returns true if the store advertises sending series as Arrow batches.
+func arrowSeriesStore(st Client) bool {
+	cst, ok := st.(CapableClient)
+	return ok && storepb.HasCapability(cst.Capabilities(), storepb.CapabilityArrowSeries)
+}
+
 // ProxyStore implements the store API that proxies request to all given underlying stores.
 type ProxyStore struct {
 	logger         log.Logger
@@ -53,12 +78,18 @@ type ProxyStore struct {
 	component      component.StoreAPI
 	selectorLabels labels.Labels
 
-	responseTimeout time.Duration
-	metrics         *proxyStoreMetrics
+	responseTimeout    time.Duration
+	responseBatchBytes int64
+	sortWindow         int
+	arrowSeries        bool
+	replicaLabels      []string
+	metrics            *proxyStoreMetrics
 }
 
 type proxyStoreMetrics struct {
 	emptyStreamResponses prometheus.Counter
+	unsortedSeries       prometheus.Counter
+	groupFallbacks       prometheus.Counter
 }
 
 func newProxyStoreMetrics(reg prometheus.Registerer) *proxyStoreMetrics {
@@ -68,12 +99,26 @@ func newProxyStoreMetrics(reg prometheus.Registerer) *proxyStoreMetrics {
 		Name: "thanos_proxy_store_empty_stream_responses_total",
 		Help: "Total number of empty responses received.",
 	})
+	m.unsortedSeries = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "thanos_proxy_store_unsorted_series_total",
+		Help: "Total number of series received from stores out of order by more than the sort window, which cannot be merged with series of other stores.",
+	})
+	m.groupFallbacks = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "thanos_proxy_store_group_fallbacks_total",
+		Help: "Total number of Series requests sent to stores of groups of more than one priority, as groups of higher priority did not cover the requested time range.",
+	})
 
 	return &m
 }
 
 // NewProxyStore returns a new ProxyStore that uses the given clients that implements storeAPI to fan-in all series to the client.
 // Note that there is no deduplication support. Deduplication should be done on the highest level (just before PromQL).
+// Stores are requested to batch series into responses of about responseBatchBytes, unless it is 0.
+// Series of each store are merged with a heap holding the current series of each store only. Series within each response
+// are sorted, and up to sortWindow series of each store are held to sort series stores send out of order across responses.
+// If arrowSeries is enabled, series of raw data are requested as Arrow batches from stores advertising the arrow_series
+// capability for clients requesting them.
+// Stores of groups holding the same label sets apart from replicaLabels fall back to each other, see GroupedClient.
 func NewProxyStore(
 	logger log.Logger,
 	reg prometheus.Registerer,
@@ -81,6 +126,10 @@ func NewProxyStore(
 	component component.StoreAPI,
 	selectorLabels labels.Labels,
 	responseTimeout time.Duration,
+	responseBatchBytes int64,
+	sortWindow int,
+	arrowSeries bool,
+	replicaLabels []string,
 ) *ProxyStore {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -88,12 +137,16 @@ func NewProxyStore(
 
 	metrics := newProxyStoreMetrics(reg)
 	s := &ProxyStore{
-		logger:          logger,
-		stores:          stores,
-		component:       component,
-		selectorLabels:  selectorLabels,
-		responseTimeout: responseTimeout,
-		metrics:         metrics,
+		logger:             logger,
+		stores:             stores,
+		component:          component,
+		selectorLabels:     selectorLabels,
+		responseTimeout:    responseTimeout,
+		responseBatchBytes: responseBatchBytes,
+		sortWindow:         sortWindow,
+		arrowSeries:        arrowSeries,
+		replicaLabels:      replicaLabels,
+		metrics:            metrics,
 	}
 	return s
 }
@@ -104,10 +157,19 @@ func (s *ProxyStore) Info(ctx context.Context, r *storepb.InfoRequest) (*storepb
 		Labels:    make([]storepb.Label, 0, len(s.selectorLabels)),
 		StoreType: s.component.ToProto(),
 	}
+	if s.arrowSeries {
+		res.Capabilities = []string{storepb.CapabilityArrowSeries}
+	}
 
-	minTime := int64(math.MaxInt64)
-	maxTime := int64(0)
-	stores := s.stores()
+	// Stores without data, e.g. store gateways without blocks, advertise an empty time range. They are left out of
+	// the advertised time range and label sets, so that upstream queriers filter this store by the data it serves.
+	var stores []Client
+	for _, st := range s.stores() {
+		if mint, maxt := st.TimeRange(); mint > maxt {
+			continue
+		}
+		stores = append(stores, st)
+	}
 
 	// Edge case: we have no data if there are no stores.
 	if len(stores) == 0 {
@@ -117,6 +179,8 @@ func (s *ProxyStore) Info(ctx context.Context, r *storepb.InfoRequest) (*storepb
 		return res, nil
 	}
 
+	minTime := int64(math.MaxInt64)
+	maxTime := int64(math.MinInt64)
 	for _, s := range stores {
 		mint, maxt := s.TimeRange()
 		if mint < minTime {
@@ -151,6 +215,9 @@ func (s *ProxyStore) Info(ctx context.Context, r *storepb.InfoRequest) (*storepb
 	for _, v := range labelSets {
 		res.LabelSets = append(res.LabelSets, storepb.LabelSet{Labels: v})
 	}
+	sort.Slice(res.LabelSets, func(i, j int) bool {
+		return labels.Compare(storepb.LabelsToPromLabels(res.LabelSets[i].Labels), storepb.LabelsToPromLabels(res.LabelSets[j].Labels)) < 0
+	})
 
 	// We always want to enforce announcing the subset of data that
 	// selector-labels represents. If no label-sets are announced by the
@@ -164,7 +231,7 @@ func (s *ProxyStore) Info(ctx context.Context, r *storepb.InfoRequest) (*storepb
 }
 
 // mergeLabels merges label-set a and label-selector b with the selector's
-// labels having precedence. The types are distinct because of the inputs at
+// labels having precedence, sorted by name. The types are distinct because of the inputs at
 // hand where this function is used.
 func mergeLabels(a []storepb.Label, b labels.Labels) []storepb.Label {
 	ls := map[string]string{}
@@ -179,6 +246,7 @@ func mergeLabels(a []storepb.Label, b labels.Labels) []storepb.Label {
 	for k, v := range ls {
 		res = append(res, storepb.Label{Name: k, Value: v})
 	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
 
 	return res
 }
@@ -213,7 +281,8 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 	}
 
 	var (
-		g, gctx = errgroup.WithContext(srv.Context())
+		g, gctx     = errgroup.WithContext(srv.Context())
+		arrowSeries = r.ArrowBatches && r.MaxResolutionWindow == 0 && !r.SkipChunks
 
 		// Allow to buffer max 10 series response.
 		// Each might be quite large (multi chunk long series given by sidecar).
@@ -232,6 +301,7 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 				MaxResolutionWindow:     r.MaxResolutionWindow,
 				SkipChunks:              r.SkipChunks,
 				PartialResponseDisabled: r.PartialResponseDisabled,
+				ResponseBatchBytes:      s.responseBatchBytes,
 			}
 			wg = &sync.WaitGroup{}
 		)
@@ -241,6 +311,7 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 			closeFn()
 		}()
 
+		var matchingStores []Client
 		for _, st := range s.stores() {
 			// We might be able to skip the store if its meta information indicates
 			// it cannot have series matching our query.
@@ -254,6 +325,18 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 				storeDebugMsgs = append(storeDebugMsgs, fmt.Sprintf("store %s filtered out", st))
 				continue
 			}
+			matchingStores = append(matchingStores, st)
+		}
+
+		stores, skipped, fallback := selectStoreGroups(matchingStores, s.replicaLabels, r.MinTime, r.MaxTime)
+		if fallback {
+			s.metrics.groupFallbacks.Inc()
+		}
+		for _, st := range skipped {
+			storeDebugMsgs = append(storeDebugMsgs, fmt.Sprintf("store %s skipped for groups of higher priority", st))
+		}
+
+		for _, st := range stores {
 			storeDebugMsgs = append(storeDebugMsgs, fmt.Sprintf("store %s queried", st))
 
 			// This is used to cancel this stream when one operations takes too long.
@@ -263,7 +346,15 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 			})
 			defer closeSeries()
 
-			sc, err := st.Series(seriesCtx, r)
+			sr := r
+			// Arrow batches are only requested for clients requesting them, as they hold raw data trimmed to the
+			// requested time range, which is encoded into Arrow batches again for the client.
+			if s.arrowSeries && arrowSeries && arrowSeriesStore(st) {
+				arrowReq := *r
+				arrowReq.ArrowBatches = true
+				sr = &arrowReq
+			}
+			sc, err := st.Series(seriesCtx, sr)
 			if err != nil {
 				storeID := storepb.LabelSetsToString(st.LabelSets())
 				if storeID == "" {
@@ -281,7 +372,7 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 			// Schedule streamSeriesSet that translates gRPC streamed response
 			// into seriesSet (if series) or respCh if warnings.
 			seriesSet = append(seriesSet, startStreamSeriesSet(seriesCtx, s.logger, closeSeries,
-				wg, sc, respSender, st.String(), !r.PartialResponseDisabled, s.responseTimeout, s.metrics.emptyStreamResponses))
+				wg, sc, respSender, st.String(), !r.PartialResponseDisabled, s.responseTimeout, s.sortWindow, s.metrics))
 		}
 
 		level.Debug(s.logger).Log("msg", strings.Join(storeDebugMsgs, ";"))
@@ -302,11 +393,15 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 		return mergedSet.Err()
 	})
 
+	batchSrv := newSeriesFlusher(srv, r, s.arrowSeries)
 	for resp := range respRecv {
-		if err := srv.Send(resp); err != nil {
+		if err := batchSrv.Send(resp); err != nil {
 			return status.Error(codes.Unknown, errors.Wrap(err, "send series response").Error())
 		}
 	}
+	if err := batchSrv.Flush(); err != nil {
+		return status.Error(codes.Unknown, errors.Wrap(err, "send series response").Error())
+	}
 
 	if err := g.Wait(); err != nil {
 		level.Error(s.logger).Log("err", err)
@@ -366,7 +461,8 @@ func startStreamSeriesSet(
 	name string,
 	partialResponse bool,
 	responseTimeout time.Duration,
-	emptyStreamResponses prometheus.Counter,
+	sortWindow int,
+	metrics *proxyStoreMetrics,
 ) *streamSeriesSet {
 	s := &streamSeriesSet{
 		ctx:             ctx,
@@ -388,10 +484,42 @@ func startStreamSeriesSet(
 		numResponses := 0
 		defer func() {
 			if numResponses == 0 {
-				emptyStreamResponses.Inc()
+				metrics.emptyStreamResponses.Inc()
 			}
 		}()
 
+		var (
+			window   seriesHeap
+			received int
+			last     *storepb.Series
+			unsorted bool
+		)
+		send := func(series *storepb.Series) {
+			if last != nil && storepb.CompareLabels(series.Labels, last.Labels) < 0 {
+				metrics.unsortedSeries.Inc()
+				if !unsorted {
+					level.Warn(s.logger).Log("msg", "store sent series out of order by more than the sort window, which breaks merging them with series of other stores", "store", s.name, "window", sortWindow)
+					unsorted = true
+				}
+			}
+			last = series
+			select {
+			case s.recvCh <- series:
+			case <-ctx.Done():
+			}
+		}
+		push := func(series *storepb.Series) {
+			if sortWindow <= 0 {
+				send(series)
+				return
+			}
+			received++
+			heap.Push(&window, seriesHeapItem{series: series, idx: received})
+			if window.Len() > sortWindow {
+				send(heap.Pop(&window).(seriesHeapItem).series)
+			}
+		}
+
 		rCh := make(chan *recvResponse)
 		done := make(chan struct{})
 		go func() {
@@ -420,6 +548,10 @@ func startStreamSeriesSet(
 			}
 
 			if rr.err == io.EOF {
+				// Series held in the window are dropped on errors, as with partial responses the stream is incomplete anyway.
+				for window.Len() > 0 {
+					send(heap.Pop(&window).(seriesHeapItem).series)
+				}
 				close(done)
 				return
 			}
@@ -434,7 +566,43 @@ func startStreamSeriesSet(
 				s.warnCh.send(storepb.NewWarnSeriesResponse(errors.New(w)))
 				continue
 			}
-			s.recvCh <- rr.r.GetSeries()
+			if b := rr.r.GetBatch(); b != nil {
+				if !sort.SliceIsSorted(b.Series, func(i, j int) bool {
+					return storepb.CompareLabels(b.Series[i].Labels, b.Series[j].Labels) < 0
+				}) {
+					sort.SliceStable(b.Series, func(i, j int) bool {
+						return storepb.CompareLabels(b.Series[i].Labels, b.Series[j].Labels) < 0
+					})
+				}
+				for i := range b.Series {
+					push(&b.Series[i])
+				}
+				continue
+			}
+			if b := rr.r.GetArrowBatch(); b != nil {
+				series, err := storepb.DecodeArrowBatch(b)
+				if err != nil {
+					s.handleErr(errors.Wrapf(err, "decode Arrow batch from %s", s.name), done)
+					return
+				}
+				if !sort.SliceIsSorted(series, func(i, j int) bool {
+					return storepb.CompareLabels(series[i].Labels, series[j].Labels) < 0
+				}) {
+					sort.SliceStable(series, func(i, j int) bool {
+						return storepb.CompareLabels(series[i].Labels, series[j].Labels) < 0
+					})
+				}
+				for i := range series {
+					push(&series[i])
+				}
+				continue
+			}
+			series := rr.r.GetSeries()
+			if series == nil {
+				s.handleErr(errors.Errorf("unexpected series response from %s", s.name), done)
+				return
+			}
+			push(series)
 		}
 	}()
 	return s
@@ -454,6 +622,36 @@ func (s *streamSeriesSet) handleErr(err error, done chan struct{}) {
 	s.errMtx.Unlock()
 }
 
+type seriesHeapItem struct {
+	series *storepb.Series
+	idx    int
+}
+
+// seriesHeap is a heap of series ordered by their labels, and by their order of receiving for equal labels.
+type seriesHeap []seriesHeapItem
+
+func (h seriesHeap) Len() int      { return len(h) }
+func (h seriesHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h seriesHeap) Less(i, j int) bool {
+	if d := storepb.CompareLabels(h[i].series.Labels, h[j].series.Labels); d != 0 {
+		return d < 0
+	}
+	return h[i].idx < h[j].idx
+}
+
+func (h *seriesHeap) Push(x interface{}) {
+	*h = append(*h, x.(seriesHeapItem))
+}
+
+func (h *seriesHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
 // Next blocks until new message is received or stream is closed or operation is timed out.
 func (s *streamSeriesSet) Next() (ok bool) {
 	s.currSeries, ok = <-s.recvCh
@@ -472,6 +670,110 @@ func (s *streamSeriesSet) Err() error {
 	return errors.Wrap(s.err, s.name)
 }
 
+// selectStoreGroups selects the stores to query for series in the given time range. Stores not in a group are always
+// selected. Stores in groups are selected separately for each of their label sets without the given replica labels, as
+// only stores holding the same series can stand in for each other. For each of them, stores are selected by priority of
+// their groups, until the selected stores of a priority cover the time range, e.g. stores of fallback groups are
+// selected only if no store of the preferred group is healthy.
+// Fallback is true if stores of more than one priority are selected for any label sets.
+func selectStoreGroups(stores []Client, replicaLabels []string, mint, maxt int64) (selected []Client, skipped []Client, fallback bool) {
+	var (
+		keys        []string
+		byLabelSets = map[string]map[int][]Client{}
+	)
+	for _, st := range stores {
+		gst, ok := st.(GroupedClient)
+		if !ok {
+			selected = append(selected, st)
+			continue
+		}
+		_, priority, ok := gst.Group()
+		if !ok {
+			selected = append(selected, st)
+			continue
+		}
+		key := labelSetsWithoutReplicaLabels(st.LabelSets(), replicaLabels)
+		if _, ok := byLabelSets[key]; !ok {
+			keys = append(keys, key)
+			byLabelSets[key] = map[int][]Client{}
+		}
+		byLabelSets[key][priority] = append(byLabelSets[key][priority], st)
+	}
+
+	for _, key := range keys {
+		s, sk, fb := selectByPriority(byLabelSets[key], mint, maxt)
+		selected = append(selected, s...)
+		skipped = append(skipped, sk...)
+		fallback = fallback || fb
+	}
+	return selected, skipped, fallback
+}
+
+// selectByPriority selects stores of groups by priority, until the selected stores of a priority cover the time range.
+func selectByPriority(byPriority map[int][]Client, mint, maxt int64) (selected []Client, skipped []Client, fallback bool) {
+	priorities := make([]int, 0, len(byPriority))
+	for p := range byPriority {
+		priorities = append(priorities, p)
+	}
+	sort.Ints(priorities)
+
+	for i, p := range priorities {
+		selected = append(selected, byPriority[p]...)
+		if !coverTimeRange(byPriority[p], mint, maxt) {
+			continue
+		}
+		for _, p := range priorities[i+1:] {
+			skipped = append(skipped, byPriority[p]...)
+		}
+		return selected, skipped, i > 0
+	}
+	return selected, nil, len(priorities) > 1
+}
+
+// labelSetsWithoutReplicaLabels returns a string identifying the given label sets without the given replica labels.
+func labelSetsWithoutReplicaLabels(lsets []storepb.LabelSet, replicaLabels []string) string {
+	s := make([]string, 0, len(lsets))
+	for _, ls := range lsets {
+		lbls := make([]storepb.Label, 0, len(ls.Labels))
+	Labels:
+		for _, l := range ls.Labels {
+			for _, rl := range replicaLabels {
+				if l.Name == rl {
+					continue Labels
+				}
+			}
+			lbls = append(lbls, l)
+		}
+		s = append(s, storepb.LabelsToString(lbls))
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+// coverTimeRange returns true if the time ranges of the stores together cover the given time range.
+func coverTimeRange(stores []Client, mint, maxt int64) bool {
+	type timeRange struct{ mint, maxt int64 }
+	ranges := make([]timeRange, 0, len(stores))
+	for _, st := range stores {
+		storeMinTime, storeMaxTime := st.TimeRange()
+		ranges = append(ranges, timeRange{mint: storeMinTime, maxt: storeMaxTime})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].mint < ranges[j].mint })
+
+	for _, r := range ranges {
+		if r.mint > mint {
+			return false
+		}
+		if r.maxt >= maxt {
+			return true
+		}
+		if r.maxt >= mint {
+			mint = r.maxt + 1
+		}
+	}
+	return false
+}
+
 // matchStore returns true if the given store may hold data for the given label
 // matchers.
 func storeMatches(s Client, mint, maxt int64, matchers ...storepb.LabelMatcher) (bool, error) {
@@ -7,17 +7,23 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"os"
 	"sort"
 	"testing"
 	"time"
 
 	"github.com/fortytw2/leaktest"
+	"github.com/go-kit/kit/log"
 	"github.com/gogo/protobuf/proto"
+	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/extgrpc"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
 	"google.golang.org/grpc"
@@ -50,6 +56,16 @@ func (c *testClient) Addr() string {
 	return "testaddr"
 }
 
+type capableTestClient struct {
+	testClient
+
+	capabilities []string
+}
+
+func (c *capableTestClient) Capabilities() []string {
+	return c.capabilities
+}
+
 func TestProxyStore_Info(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -60,7 +76,7 @@ func TestProxyStore_Info(t *testing.T) {
 		nil,
 		func() []Client { return nil },
 		component.Query,
-		nil, 0*time.Second,
+		nil, 0*time.Second, 0, 0, false, nil,
 	)
 
 	resp, err := q.Info(ctx, &storepb.InfoRequest{})
@@ -69,6 +85,57 @@ func TestProxyStore_Info(t *testing.T) {
 	testutil.Equals(t, storepb.StoreType_QUERY, resp.StoreType)
 	testutil.Equals(t, int64(0), resp.MinTime)
 	testutil.Equals(t, int64(0), resp.MaxTime)
+
+	// Time range and label sets are those of the stores with data.
+	q = NewProxyStore(nil,
+		nil,
+		func() []Client {
+			return []Client{
+				&testClient{
+					labelSets: []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "b"}}}},
+					minTime:   100,
+					maxTime:   math.MaxInt64,
+				},
+				&testClient{
+					labelSets: []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "a"}}}},
+					minTime:   -100,
+					maxTime:   -10,
+				},
+				&testClient{
+					labelSets: []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "empty"}}}},
+					minTime:   math.MaxInt64,
+					maxTime:   math.MinInt64,
+				},
+			}
+		},
+		component.Query,
+		labels.FromStrings("region", "eu"), 0*time.Second, 0, 0, false, nil,
+	)
+
+	resp, err = q.Info(ctx, &storepb.InfoRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []storepb.LabelSet{
+		{Labels: []storepb.Label{{Name: "cluster", Value: "a"}, {Name: "region", Value: "eu"}}},
+		{Labels: []storepb.Label{{Name: "cluster", Value: "b"}, {Name: "region", Value: "eu"}}},
+	}, resp.LabelSets)
+	testutil.Equals(t, int64(-100), resp.MinTime)
+	testutil.Equals(t, int64(math.MaxInt64), resp.MaxTime)
+
+	// Stores without data only are like no stores.
+	q = NewProxyStore(nil,
+		nil,
+		func() []Client {
+			return []Client{&testClient{minTime: math.MaxInt64, maxTime: math.MinInt64}}
+		},
+		component.Query,
+		nil, 0*time.Second, 0, 0, false, nil,
+	)
+
+	resp, err = q.Info(ctx, &storepb.InfoRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []storepb.LabelSet(nil), resp.LabelSets)
+	testutil.Equals(t, int64(0), resp.MinTime)
+	testutil.Equals(t, int64(0), resp.MaxTime)
 }
 
 func TestProxyStore_Series(t *testing.T) {
@@ -422,6 +489,10 @@ func TestProxyStore_Series(t *testing.T) {
 				component.Query,
 				tc.selectorLabels,
 				0*time.Second,
+				0,
+				0,
+				false,
+				nil,
 			)
 
 			s := newStoreSeriesServer(context.Background())
@@ -888,6 +959,10 @@ func TestProxyStore_SeriesSlowStores(t *testing.T) {
 				component.Query,
 				tc.selectorLabels,
 				4*time.Second,
+				0,
+				0,
+				false,
+				nil,
 			)
 
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -937,6 +1012,10 @@ func TestProxyStore_Series_RequestParamsProxied(t *testing.T) {
 		component.Query,
 		nil,
 		0*time.Second,
+		0,
+		0,
+		false,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -997,6 +1076,10 @@ func TestProxyStore_Series_RegressionFillResponseChannel(t *testing.T) {
 		component.Query,
 		labels.FromStrings("fed", "a"),
 		0*time.Second,
+		0,
+		0,
+		false,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -1036,6 +1119,10 @@ func TestProxyStore_LabelValues(t *testing.T) {
 		component.Query,
 		nil,
 		0*time.Second,
+		0,
+		0,
+		false,
+		nil,
 	)
 
 	ctx := context.Background()
@@ -1140,6 +1227,10 @@ func TestProxyStore_LabelNames(t *testing.T) {
 				component.Query,
 				nil,
 				0*time.Second,
+				0,
+				0,
+				false,
+				nil,
 			)
 
 			ctx := context.Background()
@@ -1172,11 +1263,10 @@ func seriesEquals(t *testing.T, expected []rawSeries, got []storepb.Series) {
 		testutil.Equals(t, len(expected[i].chunks), len(series.Chunks), "unexpected number of chunks for series %v", series.Labels)
 
 		for k, chk := range series.Chunks {
-			c, err := chunkenc.FromData(chunkenc.EncXOR, chk.Raw.Data)
+			iter, err := chk.Raw.Iterator()
 			testutil.Ok(t, err)
 
 			j := 0
-			iter := c.Iterator(nil)
 			for iter.Next() {
 				testutil.Assert(t, j < len(expected[i].chunks[k]), "more samples than expected for %v chunk %d", series.Labels, k)
 
@@ -1307,8 +1397,10 @@ type storeSeriesServer struct {
 
 	ctx context.Context
 
-	SeriesSet []storepb.Series
-	Warnings  []string
+	SeriesSet    []storepb.Series
+	Warnings     []string
+	Batches      int
+	ArrowBatches int
 
 	Size int64
 }
@@ -1325,6 +1417,22 @@ func (s *storeSeriesServer) Send(r *storepb.SeriesResponse) error {
 		return nil
 	}
 
+	if b := r.GetBatch(); b != nil {
+		s.Batches++
+		s.SeriesSet = append(s.SeriesSet, b.Series...)
+		return nil
+	}
+
+	if b := r.GetArrowBatch(); b != nil {
+		series, err := storepb.DecodeArrowBatch(b)
+		if err != nil {
+			return err
+		}
+		s.ArrowBatches++
+		s.SeriesSet = append(s.SeriesSet, series...)
+		return nil
+	}
+
 	if r.GetSeries() == nil {
 		return errors.New("no seriesSet")
 	}
@@ -1430,6 +1538,114 @@ func storeSeriesResponse(t testing.TB, lset labels.Labels, smplChunks ...[]sampl
 	return storepb.NewSeriesResponse(&s)
 }
 
+type groupedTestClient struct {
+	testClient
+
+	name     string
+	priority int
+}
+
+func (c *groupedTestClient) Group() (string, int, bool) {
+	return c.name, c.priority, c.name != ""
+}
+
+func TestSelectStoreGroups(t *testing.T) {
+	var (
+		ungrouped    = &groupedTestClient{testClient: testClient{minTime: 0, maxTime: 100}}
+		localRecent  = &groupedTestClient{testClient: testClient{minTime: 50, maxTime: 100}, name: "local"}
+		localOld     = &groupedTestClient{testClient: testClient{minTime: 0, maxTime: 49}, name: "local"}
+		remote       = &groupedTestClient{testClient: testClient{minTime: 0, maxTime: 100}, name: "remote", priority: 1}
+		remoteBackup = &groupedTestClient{testClient: testClient{minTime: 0, maxTime: 100}, name: "backup", priority: 1}
+
+		clusterA = func(replica string) []storepb.LabelSet {
+			return []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "a"}, {Name: "replica", Value: replica}}}}
+		}
+		localA  = &groupedTestClient{testClient: testClient{minTime: 0, maxTime: 100, labelSets: clusterA("0")}, name: "local"}
+		remoteA = &groupedTestClient{testClient: testClient{minTime: 0, maxTime: 100, labelSets: clusterA("1")}, name: "remote", priority: 1}
+		remoteB = &groupedTestClient{testClient: testClient{minTime: 0, maxTime: 100, labelSets: []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "b"}, {Name: "replica", Value: "1"}}}}}, name: "remote", priority: 1}
+	)
+	for _, tcase := range []struct {
+		name          string
+		stores        []Client
+		mint, maxt    int64
+		replicaLabels []string
+
+		selected, skipped []Client
+		fallback          bool
+	}{
+		{
+			name:     "no groups",
+			stores:   []Client{&testClient{}, ungrouped},
+			mint:     0,
+			maxt:     100,
+			selected: []Client{&testClient{}, ungrouped},
+		},
+		{
+			name:     "preferred group covers time range",
+			stores:   []Client{remote, localRecent, ungrouped, localOld},
+			mint:     10,
+			maxt:     90,
+			selected: []Client{ungrouped, localRecent, localOld},
+			skipped:  []Client{remote},
+		},
+		{
+			name:     "preferred group missing part of time range",
+			stores:   []Client{remote, localRecent, remoteBackup},
+			mint:     10,
+			maxt:     90,
+			selected: []Client{localRecent, remote, remoteBackup},
+			fallback: true,
+		},
+		{
+			name:     "preferred group missing",
+			stores:   []Client{remote, ungrouped},
+			mint:     10,
+			maxt:     90,
+			selected: []Client{ungrouped, remote},
+		},
+		{
+			name:     "no group covers time range",
+			stores:   []Client{localOld, remote},
+			mint:     10,
+			maxt:     200,
+			selected: []Client{localOld, remote},
+			fallback: true,
+		},
+		{
+			name:          "groups of replicas",
+			stores:        []Client{remoteA, localA},
+			mint:          10,
+			maxt:          90,
+			replicaLabels: []string{"replica"},
+			selected:      []Client{localA},
+			skipped:       []Client{remoteA},
+		},
+		{
+			name:     "groups of replicas with labels differing in replica labels not given",
+			stores:   []Client{remoteA, localA},
+			mint:     10,
+			maxt:     90,
+			selected: []Client{remoteA, localA},
+		},
+		{
+			name:          "groups holding disjoint label sets",
+			stores:        []Client{remoteA, remoteB, localA},
+			mint:          10,
+			maxt:          90,
+			replicaLabels: []string{"replica"},
+			selected:      []Client{localA, remoteB},
+			skipped:       []Client{remoteA},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			selected, skipped, fallback := selectStoreGroups(tcase.stores, tcase.replicaLabels, tcase.mint, tcase.maxt)
+			testutil.Equals(t, tcase.selected, selected)
+			testutil.Equals(t, tcase.skipped, skipped)
+			testutil.Equals(t, tcase.fallback, fallback)
+		})
+	}
+}
+
 func TestMergeLabels(t *testing.T) {
 	ls := []storepb.Label{{Name: "a", Value: "b"}, {Name: "b", Value: "c"}}
 	selector := labels.Labels{{Name: "a", Value: "c"}, {Name: "c", Value: "d"}}
@@ -1442,3 +1658,260 @@ func TestMergeLabels(t *testing.T) {
 
 	testutil.Equals(t, expected, resLabels)
 }
+
+func TestProxyStore_SeriesBatches(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	a := storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{0, 0}, {2, 1}, {3, 2}})
+	b := storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{1, 1}, {2, 2}})
+	c := storeSeriesResponse(t, labels.FromStrings("a", "c"), []sample{{4, 3}})
+	m := &mockedStoreAPI{
+		RespSeries: []*storepb.SeriesResponse{
+			storepb.NewSeriesBatchResponse([]storepb.Series{*a.GetSeries(), *b.GetSeries()}),
+			storepb.NewWarnSeriesResponse(errors.New("warning")),
+			c,
+		},
+	}
+	cls := []Client{&testClient{StoreClient: m, minTime: 1, maxTime: 300}}
+	expected := []rawSeries{
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "a"}},
+			chunks: [][]sample{{{0, 0}, {2, 1}, {3, 2}}},
+		},
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "b"}},
+			chunks: [][]sample{{{1, 1}, {2, 2}}},
+		},
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "c"}},
+			chunks: [][]sample{{{4, 3}}},
+		},
+	}
+
+	for _, tcase := range []struct {
+		batchBytes      int64
+		expectedBatches int
+	}{
+		{batchBytes: 0, expectedBatches: 0},
+		{batchBytes: 1, expectedBatches: 3},
+		{batchBytes: 1024, expectedBatches: 1},
+	} {
+		t.Run(fmt.Sprintf("batch bytes %d", tcase.batchBytes), func(t *testing.T) {
+			q := NewProxyStore(nil, nil, func() []Client { return cls }, component.Query, nil, 0*time.Second, 512, 0, false, nil)
+
+			s := newStoreSeriesServer(context.Background())
+			testutil.Ok(t, q.Series(&storepb.SeriesRequest{
+				MinTime:            1,
+				MaxTime:            300,
+				Matchers:           []storepb.LabelMatcher{{Name: "a", Value: ".*", Type: storepb.LabelMatcher_RE}},
+				ResponseBatchBytes: tcase.batchBytes,
+			}, s))
+
+			// The proxy requests batches from stores regardless of being requested batches itself.
+			testutil.Equals(t, int64(512), m.LastSeriesReq.ResponseBatchBytes)
+			seriesEquals(t, expected, s.SeriesSet)
+			testutil.Equals(t, []string{"warning"}, s.Warnings)
+			testutil.Equals(t, tcase.expectedBatches, s.Batches)
+		})
+	}
+}
+
+func TestProxyStore_SeriesSortWindow(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	a := storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{0, 0}, {2, 1}})
+	b := storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{1, 1}, {2, 2}})
+	c := storeSeriesResponse(t, labels.FromStrings("a", "c"), []sample{{4, 3}})
+	cls := []Client{
+		// Series are out of order within the batch, and across responses.
+		&testClient{StoreClient: &mockedStoreAPI{
+			RespSeries: []*storepb.SeriesResponse{
+				storepb.NewSeriesBatchResponse([]storepb.Series{*c.GetSeries(), *a.GetSeries()}),
+				b,
+			},
+		}, minTime: 1, maxTime: 300},
+		&testClient{StoreClient: &mockedStoreAPI{
+			RespSeries: []*storepb.SeriesResponse{a, b},
+		}, minTime: 1, maxTime: 300},
+	}
+	req := &storepb.SeriesRequest{
+		MinTime:  1,
+		MaxTime:  300,
+		Matchers: []storepb.LabelMatcher{{Name: "a", Value: ".*", Type: storepb.LabelMatcher_RE}},
+	}
+
+	// Series within batches are sorted, but b comes after c.
+	q := NewProxyStore(nil, nil, func() []Client { return cls }, component.Query, nil, 0*time.Second, 0, 0, false, nil)
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(req, s))
+	testutil.Equals(t, 4, len(s.SeriesSet))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(q.metrics.unsortedSeries))
+
+	// With a window of a series, series are sorted and merged.
+	q = NewProxyStore(nil, nil, func() []Client { return cls }, component.Query, nil, 0*time.Second, 0, 1, false, nil)
+	s = newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(req, s))
+	seriesEquals(t, []rawSeries{
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "a"}},
+			chunks: [][]sample{{{0, 0}, {2, 1}}, {{0, 0}, {2, 1}}},
+		},
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "b"}},
+			chunks: [][]sample{{{1, 1}, {2, 2}}, {{1, 1}, {2, 2}}},
+		},
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "c"}},
+			chunks: [][]sample{{{4, 3}}},
+		},
+	}, s.SeriesSet)
+	testutil.Equals(t, 0.0, promtest.ToFloat64(q.metrics.unsortedSeries))
+}
+
+func TestProxyStore_SeriesArrowBatches(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	a := storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{0, 0}, {2, 1}, {3, 2}})
+	b := storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{1, 1}, {2, 2}})
+	c := storeSeriesResponse(t, labels.FromStrings("a", "c"), []sample{{4, 3}})
+
+	builder := storepb.NewArrowBatchBuilder()
+	defer builder.Release()
+	testutil.Ok(t, builder.Append(a.GetSeries(), 1, 300))
+	testutil.Ok(t, builder.Append(b.GetSeries(), 1, 300))
+	batch, err := builder.Encode()
+	testutil.Ok(t, err)
+
+	arrowStore := &mockedStoreAPI{
+		RespSeries: []*storepb.SeriesResponse{{Result: &storepb.SeriesResponse_ArrowBatch{ArrowBatch: batch}}},
+	}
+	otherStore := &mockedStoreAPI{RespSeries: []*storepb.SeriesResponse{c}}
+	cls := []Client{
+		&capableTestClient{
+			testClient:   testClient{StoreClient: arrowStore, minTime: 1, maxTime: 300},
+			capabilities: []string{storepb.CapabilityArrowSeries},
+		},
+		&testClient{StoreClient: otherStore, minTime: 1, maxTime: 300},
+	}
+	req := &storepb.SeriesRequest{
+		MinTime:      1,
+		MaxTime:      300,
+		Matchers:     []storepb.LabelMatcher{{Name: "a", Value: ".*", Type: storepb.LabelMatcher_RE}},
+		ArrowBatches: true,
+	}
+
+	q := NewProxyStore(nil, nil, func() []Client { return cls }, component.Query, nil, 0*time.Second, 0, 0, true, nil)
+	resp, err := q.Info(context.Background(), &storepb.InfoRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{storepb.CapabilityArrowSeries}, resp.Capabilities)
+
+	// Arrow batches are only requested from stores advertising them, and series of all stores are sent as Arrow
+	// batches trimmed to the requested time range.
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(req, s))
+	testutil.Assert(t, arrowStore.LastSeriesReq.ArrowBatches, "expected Arrow batches to be requested")
+	testutil.Assert(t, !otherStore.LastSeriesReq.ArrowBatches, "expected no Arrow batches to be requested")
+	testutil.Equals(t, 1, s.ArrowBatches)
+	seriesEquals(t, []rawSeries{
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "a"}},
+			chunks: [][]sample{{{2, 1}, {3, 2}}},
+		},
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "b"}},
+			chunks: [][]sample{{{1, 1}, {2, 2}}},
+		},
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "c"}},
+			chunks: [][]sample{{{4, 3}}},
+		},
+	}, s.SeriesSet)
+
+	// Arrow batches are not requested for downsampled data.
+	downsampledReq := *req
+	downsampledReq.MaxResolutionWindow = 300000
+	testutil.Ok(t, q.Series(&downsampledReq, newStoreSeriesServer(context.Background())))
+	testutil.Assert(t, !arrowStore.LastSeriesReq.ArrowBatches, "expected no Arrow batches to be requested")
+
+	// Proxies not enabled to send Arrow batches neither request them.
+	q = NewProxyStore(nil, nil, func() []Client { return cls }, component.Query, nil, 0*time.Second, 0, 0, false, nil)
+	resp, err = q.Info(context.Background(), &storepb.InfoRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(resp.Capabilities))
+	testutil.Ok(t, q.Series(req, newStoreSeriesServer(context.Background())))
+	testutil.Assert(t, !arrowStore.LastSeriesReq.ArrowBatches, "expected no Arrow batches to be requested")
+}
+
+func TestProxyStore_SeriesArrowBatchesOverGRPC(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	a := storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{0, 0}, {2, 1}, {3, 2}})
+	b := storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{1, 1}, {2, 2}})
+
+	// The remote store sends series of its store as Arrow batches, which have to be decoded by the store codec.
+	remote := NewProxyStore(nil, nil, func() []Client {
+		return []Client{&testClient{StoreClient: &mockedStoreAPI{RespSeries: []*storepb.SeriesResponse{a, b}}, minTime: 1, maxTime: 300}}
+	}, component.Store, nil, 0*time.Second, 0, 0, true, nil)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	testutil.Ok(t, err)
+	srv := grpc.NewServer()
+	storepb.RegisterStoreServer(srv, remote)
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Stop()
+
+	opts, err := extgrpc.StoreClientGRPCOpts(log.NewNopLogger(), nil, opentracing.NoopTracer{}, false, "", "", "", "", "", nil)
+	testutil.Ok(t, err)
+	conn, err := grpc.Dial(l.Addr().String(), opts...)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, conn.Close()) }()
+
+	cls := []Client{
+		&capableTestClient{
+			testClient:   testClient{StoreClient: storepb.NewStoreClient(conn), minTime: 1, maxTime: 300},
+			capabilities: []string{storepb.CapabilityArrowSeries},
+		},
+	}
+	q := NewProxyStore(nil, nil, func() []Client { return cls }, component.Query, nil, 0*time.Second, 0, 0, true, nil)
+
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(&storepb.SeriesRequest{
+		MinTime:      1,
+		MaxTime:      300,
+		Matchers:     []storepb.LabelMatcher{{Name: "a", Value: ".*", Type: storepb.LabelMatcher_RE}},
+		ArrowBatches: true,
+	}, s))
+	seriesEquals(t, []rawSeries{
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "a"}},
+			chunks: [][]sample{{{2, 1}, {3, 2}}},
+		},
+		{
+			lset:   []storepb.Label{{Name: "a", Value: "b"}},
+			chunks: [][]sample{{{1, 1}, {2, 2}}},
+		},
+	}, s.SeriesSet)
+}
+
+func TestProxyStore_SeriesUnexpectedResponse(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	// Responses without any result, e.g. of types unknown to the client, are not taken as series.
+	cls := []Client{
+		&testClient{StoreClient: &mockedStoreAPI{RespSeries: []*storepb.SeriesResponse{{}}}, minTime: 1, maxTime: 300},
+	}
+	q := NewProxyStore(nil, nil, func() []Client { return cls }, component.Query, nil, 0*time.Second, 0, 0, false, nil)
+	req := &storepb.SeriesRequest{
+		MinTime:                 1,
+		MaxTime:                 300,
+		Matchers:                []storepb.LabelMatcher{{Name: "a", Value: ".*", Type: storepb.LabelMatcher_RE}},
+		PartialResponseDisabled: true,
+	}
+	testutil.NotOk(t, q.Series(req, newStoreSeriesServer(context.Background())))
+
+	req.PartialResponseDisabled = false
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(req, s))
+	testutil.Equals(t, 0, len(s.SeriesSet))
+	testutil.Equals(t, 1, len(s.Warnings))
+}
@@ -0,0 +1,131 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package store
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TSDBStatusTopLimit is the number of items in top lists of TSDB statuses, as reported by Prometheus.
+const TSDBStatusTopLimit = 10
+
+// StatusClient is a client of the optional Status API of a store.
+type StatusClient interface {
+	storepb.StatusClient
+
+	String() string
+}
+
+// TSDBStatus requests TSDB statuses of all clients concurrently and merges them. Clients not implementing the Status
+// API are skipped. Errors of other clients are returned as warnings if partial response is enabled.
+func TSDBStatus(ctx context.Context, clients []StatusClient, partialResponse bool) (*storepb.TSDBStatusResponse, []error, error) {
+	var (
+		mtx      sync.Mutex
+		wg       sync.WaitGroup
+		statuses []*storepb.TSDBStatusResponse
+		warnings []error
+		errs     []error
+	)
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c StatusClient) {
+			defer wg.Done()
+
+			resp, err := c.TSDBStatus(ctx, &storepb.TSDBStatusRequest{})
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			if err != nil {
+				if status.Code(err) == codes.Unimplemented {
+					return
+				}
+				err = errors.Wrapf(err, "fetch TSDB status from store %s", c)
+				if partialResponse {
+					warnings = append(warnings, err)
+					return
+				}
+				errs = append(errs, err)
+				return
+			}
+			statuses = append(statuses, resp)
+		}(c)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, nil, errs[0]
+	}
+	return MergeTSDBStatuses(TSDBStatusTopLimit, statuses...), warnings, nil
+}
+
+// MergeTSDBStatuses merges TSDB statuses of multiple stores. Counts of series and chunks are summed, so data
+// replicated to multiple stores is counted once per replica. Values of top lists are summed by name and truncated to
+// the given limit. As stores report their top items only, merged top lists are approximate.
+func MergeTSDBStatuses(limit int, statuses ...*storepb.TSDBStatusResponse) *storepb.TSDBStatusResponse {
+	res := &storepb.TSDBStatusResponse{
+		MinTime: math.MaxInt64,
+		MaxTime: math.MinInt64,
+	}
+	var (
+		seriesCountByMetricName     = map[string]uint64{}
+		labelValueCountByLabelName  = map[string]uint64{}
+		memoryInBytesByLabelName    = map[string]uint64{}
+		seriesCountByLabelValuePair = map[string]uint64{}
+	)
+	for _, s := range statuses {
+		res.NumSeries += s.NumSeries
+		res.NumChunks += s.NumChunks
+		// Empty heads and stores not knowing their head time range report an inverted time range.
+		if s.MinTime <= s.MaxTime {
+			if s.MinTime < res.MinTime {
+				res.MinTime = s.MinTime
+			}
+			if s.MaxTime > res.MaxTime {
+				res.MaxTime = s.MaxTime
+			}
+		}
+		sumStatistics(seriesCountByMetricName, s.SeriesCountByMetricName)
+		sumStatistics(labelValueCountByLabelName, s.LabelValueCountByLabelName)
+		sumStatistics(memoryInBytesByLabelName, s.MemoryInBytesByLabelName)
+		sumStatistics(seriesCountByLabelValuePair, s.SeriesCountByLabelValuePair)
+	}
+	res.SeriesCountByMetricName = topStatistics(seriesCountByMetricName, limit)
+	res.LabelValueCountByLabelName = topStatistics(labelValueCountByLabelName, limit)
+	res.MemoryInBytesByLabelName = topStatistics(memoryInBytesByLabelName, limit)
+	res.SeriesCountByLabelValuePair = topStatistics(seriesCountByLabelValuePair, limit)
+	return res
+}
+
+func sumStatistics(sums map[string]uint64, stats []storepb.Statistic) {
+	for _, s := range stats {
+		sums[s.Name] += s.Value
+	}
+}
+
+// topStatistics returns at most limit statistics with highest values, sorted by value in descending order and by
+// name for equal values.
+func topStatistics(sums map[string]uint64, limit int) []storepb.Statistic {
+	stats := make([]storepb.Statistic, 0, len(sums))
+	for name, value := range sums {
+		stats = append(stats, storepb.Statistic{Name: name, Value: value})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Value != stats[j].Value {
+			return stats[i].Value > stats[j].Value
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats
+}
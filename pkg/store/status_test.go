@@ -0,0 +1,98 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package store
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testStatusClient struct {
+	name string
+	resp *storepb.TSDBStatusResponse
+	err  error
+}
+
+func (c testStatusClient) TSDBStatus(context.Context, *storepb.TSDBStatusRequest, ...grpc.CallOption) (*storepb.TSDBStatusResponse, error) {
+	return c.resp, c.err
+}
+
+func (c testStatusClient) String() string { return c.name }
+
+func TestMergeTSDBStatuses(t *testing.T) {
+	testutil.Equals(t, &storepb.TSDBStatusResponse{
+		MinTime:                     math.MaxInt64,
+		MaxTime:                     math.MinInt64,
+		SeriesCountByMetricName:     []storepb.Statistic{},
+		LabelValueCountByLabelName:  []storepb.Statistic{},
+		MemoryInBytesByLabelName:    []storepb.Statistic{},
+		SeriesCountByLabelValuePair: []storepb.Statistic{},
+	}, MergeTSDBStatuses(2))
+
+	merged := MergeTSDBStatuses(2,
+		&storepb.TSDBStatusResponse{
+			NumSeries:                  3,
+			NumChunks:                  6,
+			MinTime:                    1000,
+			MaxTime:                    3000,
+			SeriesCountByMetricName:    []storepb.Statistic{{Name: "up", Value: 2}, {Name: "requests_total", Value: 1}},
+			LabelValueCountByLabelName: []storepb.Statistic{{Name: "job", Value: 2}},
+		},
+		&storepb.TSDBStatusResponse{
+			NumSeries:               4,
+			NumChunks:               4,
+			MinTime:                 500,
+			MaxTime:                 2000,
+			SeriesCountByMetricName: []storepb.Statistic{{Name: "requests_total", Value: 3}, {Name: "errors_total", Value: 1}},
+		},
+		// Store not knowing its head time range.
+		&storepb.TSDBStatusResponse{
+			MinTime:                    math.MaxInt64,
+			MaxTime:                    math.MinInt64,
+			SeriesCountByMetricName:    []storepb.Statistic{{Name: "up", Value: 1}},
+			LabelValueCountByLabelName: []storepb.Statistic{{Name: "job", Value: 1}, {Name: "instance", Value: 1}},
+		},
+	)
+	testutil.Equals(t, &storepb.TSDBStatusResponse{
+		NumSeries:                   7,
+		NumChunks:                   10,
+		MinTime:                     500,
+		MaxTime:                     3000,
+		SeriesCountByMetricName:     []storepb.Statistic{{Name: "requests_total", Value: 4}, {Name: "up", Value: 3}},
+		LabelValueCountByLabelName:  []storepb.Statistic{{Name: "job", Value: 3}, {Name: "instance", Value: 1}},
+		MemoryInBytesByLabelName:    []storepb.Statistic{},
+		SeriesCountByLabelValuePair: []storepb.Statistic{},
+	}, merged)
+}
+
+func TestTSDBStatus(t *testing.T) {
+	clients := []StatusClient{
+		testStatusClient{name: "sidecar", resp: &storepb.TSDBStatusResponse{NumSeries: 2, MinTime: 1, MaxTime: 2}},
+		testStatusClient{name: "receive", resp: &storepb.TSDBStatusResponse{NumSeries: 3, MinTime: 1, MaxTime: 2}},
+		testStatusClient{name: "store", err: status.Error(codes.Unimplemented, "unknown service thanos.Status")},
+	}
+
+	s, warnings, err := TSDBStatus(context.Background(), clients, false)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(warnings))
+	testutil.Equals(t, uint64(5), s.NumSeries)
+
+	clients = append(clients, testStatusClient{name: "broken", err: errors.New("connection refused")})
+	_, _, err = TSDBStatus(context.Background(), clients, false)
+	testutil.NotOk(t, err)
+
+	s, warnings, err = TSDBStatus(context.Background(), clients, true)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(warnings))
+	testutil.Equals(t, "fetch TSDB status from store broken: connection refused", warnings[0].Error())
+	testutil.Equals(t, uint64(5), s.NumSeries)
+}
@@ -0,0 +1,235 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package storepb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// CapabilityArrowSeries is the capability of stores sending series of raw data as Arrow record batches, if requested
+// by the arrow_batches field of Series requests.
+const CapabilityArrowSeries = "arrow_series"
+
+// HasCapability returns true if the given capabilities of a store contain c.
+func HasCapability(capabilities []string, c string) bool {
+	for _, cp := range capabilities {
+		if cp == c {
+			return true
+		}
+	}
+	return false
+}
+
+// arrowSeriesSchema is the schema of Arrow record batches of series, with a row per series. Labels are held as lists
+// of names and values, samples as lists of timestamps and values, so the samples of a batch are stored in two
+// contiguous columns.
+var arrowSeriesSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "label_names", Type: arrow.ListOf(arrow.BinaryTypes.String)},
+	{Name: "label_values", Type: arrow.ListOf(arrow.BinaryTypes.String)},
+	{Name: "timestamps", Type: arrow.ListOf(arrow.PrimitiveTypes.Int64)},
+	{Name: "values", Type: arrow.ListOf(arrow.PrimitiveTypes.Float64)},
+}, nil)
+
+// ArrowBatchBuilder encodes series of raw data into Arrow record batches.
+type ArrowBatchBuilder struct {
+	b *array.RecordBuilder
+
+	names, values *array.ListBuilder
+	ts, vs        *array.ListBuilder
+
+	rows int
+	size int
+}
+
+// NewArrowBatchBuilder returns a new builder of Arrow record batches of series.
+func NewArrowBatchBuilder() *ArrowBatchBuilder {
+	b := array.NewRecordBuilder(memory.NewGoAllocator(), arrowSeriesSchema)
+	return &ArrowBatchBuilder{
+		b:      b,
+		names:  b.Field(0).(*array.ListBuilder),
+		values: b.Field(1).(*array.ListBuilder),
+		ts:     b.Field(2).(*array.ListBuilder),
+		vs:     b.Field(3).(*array.ListBuilder),
+	}
+}
+
+// Append appends the samples of the raw chunks of the series within [mint, maxt]. Samples of chunks overlapping
+// previous chunks are skipped, like by queriers iterating the chunks.
+func (b *ArrowBatchBuilder) Append(s *Series, mint, maxt int64) error {
+	b.names.Append(true)
+	b.values.Append(true)
+	names := b.names.ValueBuilder().(*array.StringBuilder)
+	values := b.values.ValueBuilder().(*array.StringBuilder)
+	for _, l := range s.Labels {
+		names.Append(l.Name)
+		values.Append(l.Value)
+		b.size += len(l.Name) + len(l.Value)
+	}
+
+	b.ts.Append(true)
+	b.vs.Append(true)
+	ts := b.ts.ValueBuilder().(*array.Int64Builder)
+	vs := b.vs.ValueBuilder().(*array.Float64Builder)
+	lastT := int64(math.MinInt64)
+	for _, c := range s.Chunks {
+		if c.Raw == nil {
+			return errors.New("series has no raw chunk")
+		}
+		it, err := c.Raw.Iterator()
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			t, v := it.At()
+			if t <= lastT || t < mint {
+				continue
+			}
+			if t > maxt {
+				break
+			}
+			ts.Append(t)
+			vs.Append(v)
+			lastT = t
+			b.size += 16
+		}
+		if it.Err() != nil {
+			return errors.Wrap(it.Err(), "iterate chunk")
+		}
+	}
+	b.rows++
+	return nil
+}
+
+// Len returns the number of series appended since the last batch was encoded.
+func (b *ArrowBatchBuilder) Len() int { return b.rows }
+
+// Size returns the approximate size in bytes of the labels and samples appended since the last batch was encoded.
+func (b *ArrowBatchBuilder) Size() int { return b.size }
+
+// Encode returns the series appended so far as an Arrow IPC stream of a record batch, and resets the builder.
+func (b *ArrowBatchBuilder) Encode() ([]byte, error) {
+	rec := b.b.NewRecord()
+	defer rec.Release()
+	b.rows, b.size = 0, 0
+
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(arrowSeriesSchema))
+	if err := w.Write(rec); err != nil {
+		return nil, errors.Wrap(err, "write record batch")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "close record batch writer")
+	}
+	return buf.Bytes(), nil
+}
+
+// Release releases the memory held by the builder.
+func (b *ArrowBatchBuilder) Release() {
+	b.b.Release()
+}
+
+// DecodeArrowBatch decodes the series of an Arrow IPC stream of record batches as sent in arrow_batch responses. Each
+// series has a single chunk of SAMPLES encoding holding all its samples.
+func DecodeArrowBatch(b []byte) ([]Series, error) {
+	r, err := ipc.NewReader(bytes.NewReader(b), ipc.WithSchema(arrowSeriesSchema))
+	if err != nil {
+		return nil, errors.Wrap(err, "read Arrow stream")
+	}
+	defer r.Release()
+
+	var res []Series
+	for r.Next() {
+		rec := r.Record()
+		names, values := rec.Column(0).(*array.List), rec.Column(1).(*array.List)
+		ts, vs := rec.Column(2).(*array.List), rec.Column(3).(*array.List)
+		nameValues, valueValues := names.ListValues().(*array.String), values.ListValues().(*array.String)
+		tsValues, vsValues := ts.ListValues().(*array.Int64).Int64Values(), vs.ListValues().(*array.Float64).Float64Values()
+
+		for i := 0; i < int(rec.NumRows()); i++ {
+			var s Series
+
+			lstart, lend := names.Offsets()[i], names.Offsets()[i+1]
+			if vstart, vend := values.Offsets()[i], values.Offsets()[i+1]; vstart != lstart || vend != lend {
+				return nil, errors.Errorf("series %d has different numbers of label names and values", i)
+			}
+			s.Labels = make([]Label, 0, lend-lstart)
+			for j := int(lstart); j < int(lend); j++ {
+				s.Labels = append(s.Labels, Label{Name: nameValues.Value(j), Value: valueValues.Value(j)})
+			}
+
+			sstart, send := ts.Offsets()[i], ts.Offsets()[i+1]
+			if vstart, vend := vs.Offsets()[i], vs.Offsets()[i+1]; vstart != sstart || vend != send {
+				return nil, errors.Errorf("series %d has different numbers of timestamps and values", i)
+			}
+			if send > sstart {
+				s.Chunks = []AggrChunk{{
+					MinTime: tsValues[sstart],
+					MaxTime: tsValues[send-1],
+					Raw:     NewSamplesChunk(tsValues[sstart:send], vsValues[sstart:send]),
+				}}
+			}
+			res = append(res, s)
+		}
+	}
+	if r.Err() != nil {
+		return nil, errors.Wrap(r.Err(), "read record batch")
+	}
+	return res, nil
+}
+
+// NewSamplesChunk returns a chunk of SAMPLES encoding holding the given samples.
+func NewSamplesChunk(ts []int64, vs []float64) *Chunk {
+	data := make([]byte, 16*len(ts))
+	for i, t := range ts {
+		binary.LittleEndian.PutUint64(data[8*i:], uint64(t))
+		binary.LittleEndian.PutUint64(data[8*(len(ts)+i):], math.Float64bits(vs[i]))
+	}
+	return &Chunk{Type: Chunk_SAMPLES, Data: data}
+}
+
+// Iterator returns an iterator over the samples of the chunk.
+func (m *Chunk) Iterator() (chunkenc.Iterator, error) {
+	switch m.Type {
+	case Chunk_XOR:
+		c, err := chunkenc.FromData(chunkenc.EncXOR, m.Data)
+		if err != nil {
+			return nil, err
+		}
+		return c.Iterator(nil), nil
+	case Chunk_SAMPLES:
+		if len(m.Data)%16 != 0 {
+			return nil, errors.Errorf("invalid size %d of samples chunk", len(m.Data))
+		}
+		return &samplesIterator{data: m.Data, n: len(m.Data) / 16, i: -1}, nil
+	}
+	return nil, errors.Errorf("unknown chunk encoding %v", m.Type)
+}
+
+type samplesIterator struct {
+	data []byte
+	n, i int
+}
+
+func (it *samplesIterator) At() (int64, float64) {
+	return int64(binary.LittleEndian.Uint64(it.data[8*it.i:])), math.Float64frombits(binary.LittleEndian.Uint64(it.data[8*(it.n+it.i):]))
+}
+
+func (it *samplesIterator) Next() bool {
+	if it.i+1 >= it.n {
+		return false
+	}
+	it.i++
+	return true
+}
+
+func (it *samplesIterator) Err() error { return nil }
@@ -0,0 +1,70 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package storepb
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func xorChunk(t *testing.T, samples ...sample) AggrChunk {
+	c := chunkenc.NewXORChunk()
+	app, err := c.Appender()
+	testutil.Ok(t, err)
+	for _, s := range samples {
+		app.Append(s.t, s.v)
+	}
+	return AggrChunk{MinTime: samples[0].t, MaxTime: samples[len(samples)-1].t, Raw: &Chunk{Type: Chunk_XOR, Data: c.Bytes()}}
+}
+
+func chunkSamples(t *testing.T, c *Chunk) (res []sample) {
+	it, err := c.Iterator()
+	testutil.Ok(t, err)
+	for it.Next() {
+		ts, v := it.At()
+		res = append(res, sample{ts, v})
+	}
+	testutil.Ok(t, it.Err())
+	return res
+}
+
+func TestArrowBatch_RoundTrip(t *testing.T) {
+	b := NewArrowBatchBuilder()
+	defer b.Release()
+
+	testutil.Ok(t, b.Append(&Series{
+		Labels: []Label{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}},
+		Chunks: []AggrChunk{
+			xorChunk(t, sample{1, 1}, sample{2, 2}, sample{3, 3}),
+			// Overlapping samples are skipped.
+			xorChunk(t, sample{3, 3}, sample{4, 4}, sample{5, 5}),
+		},
+	}, 2, 4))
+	testutil.Ok(t, b.Append(&Series{
+		Labels: []Label{{Name: "a", Value: "2"}},
+		Chunks: []AggrChunk{{MinTime: 0, MaxTime: 10, Raw: NewSamplesChunk([]int64{0, 10}, []float64{0, 10})}},
+	}, 2, 4))
+	testutil.Equals(t, 2, b.Len())
+
+	enc, err := b.Encode()
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, b.Len())
+
+	series, err := DecodeArrowBatch(enc)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 2, len(series))
+
+	testutil.Equals(t, []Label{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, series[0].Labels)
+	testutil.Equals(t, 1, len(series[0].Chunks))
+	testutil.Equals(t, int64(2), series[0].Chunks[0].MinTime)
+	testutil.Equals(t, int64(4), series[0].Chunks[0].MaxTime)
+	testutil.Equals(t, Chunk_SAMPLES, series[0].Chunks[0].Raw.Type)
+	testutil.Equals(t, []sample{{2, 2}, {3, 3}, {4, 4}}, chunkSamples(t, series[0].Chunks[0].Raw))
+
+	// Series without samples within the range keep their labels.
+	testutil.Equals(t, []Label{{Name: "a", Value: "2"}}, series[1].Labels)
+	testutil.Equals(t, 0, len(series[1].Chunks))
+}
@@ -0,0 +1,359 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package storepb
+
+import (
+	"fmt"
+	"io"
+	"unsafe"
+
+	"github.com/thanos-io/thanos/pkg/strutil"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/proto"
+)
+
+// Codec is a gRPC codec of protobuf messages, which unmarshals SeriesResponse messages without copying: label names
+// and values and chunk data of received series reference the received message instead of being copied out of it.
+// This removes the majority of allocations of clients of Series calls.
+//
+// It is safe, as gRPC allocates a new buffer for each received message and never reuses it. The price is the message
+// being kept in memory as long as anything of its series is referenced, so received series must not be modified in
+// place nor kept around longer than a query. Other messages are handled by the default codec of gRPC.
+//
+// With Interner set, label names and values are interned instead, so they are shared by all series and do not keep
+// messages in memory.
+type Codec struct {
+	Interner *strutil.Interner
+}
+
+var defaultCodec = encoding.GetCodec(proto.Name)
+
+// Name returns the name of the default codec, as the wire format does not change.
+func (Codec) Name() string { return proto.Name }
+
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	return defaultCodec.Marshal(v)
+}
+
+func (c Codec) Unmarshal(data []byte, v interface{}) error {
+	if m, ok := v.(*SeriesResponse); ok {
+		return m.unmarshalNoCopy(data, c.labelString)
+	}
+	return defaultCodec.Unmarshal(data, v)
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// wireReader reads fields of protobuf messages. Length-delimited fields are returned as subslices of the message.
+type wireReader struct {
+	b []byte
+	i int
+}
+
+func (r *wireReader) done() bool { return r.i >= len(r.b) }
+
+func (r *wireReader) varint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		if shift >= 64 {
+			return 0, ErrIntOverflowTypes
+		}
+		if r.i >= len(r.b) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		c := r.b[r.i]
+		r.i++
+		v |= uint64(c&0x7F) << shift
+		if c < 0x80 {
+			return v, nil
+		}
+	}
+}
+
+// field reads the tag of the next field and returns its number, after checking its wire type against wants, which
+// are indexed by field number starting at 1. Unknown fields are skipped, returning 0.
+func (r *wireReader) field(msg string, wants ...int) (num int, err error) {
+	start := r.i
+	tag, err := r.varint()
+	if err != nil {
+		return 0, err
+	}
+	num, typ := int(tag>>3), int(tag&0x7)
+	if num <= 0 {
+		return 0, fmt.Errorf("proto: %s: illegal tag %d (wire type %d)", msg, num, typ)
+	}
+	if num > len(wants) {
+		n, err := skipTypes(r.b[start:])
+		if err != nil {
+			return 0, err
+		}
+		r.i = start + n
+		return 0, nil
+	}
+	if typ != wants[num-1] {
+		return 0, fmt.Errorf("proto: wrong wireType = %d for field %d of %s", typ, num, msg)
+	}
+	return num, nil
+}
+
+func (r *wireReader) bytes() ([]byte, error) {
+	l, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if int(l) < 0 {
+		return nil, ErrInvalidLengthTypes
+	}
+	end := r.i + int(l)
+	if end < r.i {
+		return nil, ErrInvalidLengthTypes
+	}
+	if end > len(r.b) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	// Capacity is limited, so appending to the returned slice never overwrites the rest of the message.
+	b := r.b[r.i:end:end]
+	r.i = end
+	return b, nil
+}
+
+func yoloString(b []byte) string {
+	return *((*string)(unsafe.Pointer(&b)))
+}
+
+func (c Codec) labelString(b []byte) string {
+	if c.Interner == nil {
+		return yoloString(b)
+	}
+	return c.Interner.InternBytes(b)
+}
+
+func (m *SeriesResponse) unmarshalNoCopy(data []byte, labelString func([]byte) string) error {
+	*m = SeriesResponse{}
+	for r := (&wireReader{b: data}); !r.done(); {
+		num, err := r.field("SeriesResponse", wireBytes, wireBytes, wireBytes, wireBytes)
+		if err != nil {
+			return err
+		}
+		if num == 0 {
+			continue
+		}
+
+		b, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		switch num {
+		case 1:
+			s := &Series{}
+			if err := s.unmarshalNoCopy(b, labelString); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Series{Series: s}
+		case 2:
+			// Warnings are rare and may outlive the query, so they are copied.
+			m.Result = &SeriesResponse_Warning{Warning: string(b)}
+		case 3:
+			batch := &SeriesBatch{}
+			if err := batch.unmarshalNoCopy(b, labelString); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Batch{Batch: batch}
+		case 4:
+			// Arrow batches reference the message, and are decoded into series by the receiver.
+			m.Result = &SeriesResponse_ArrowBatch{ArrowBatch: b}
+		}
+	}
+	return nil
+}
+
+func (m *SeriesBatch) unmarshalNoCopy(data []byte, labelString func([]byte) string) error {
+	var numSeries int
+	for r := (&wireReader{b: data}); !r.done(); {
+		num, err := r.field("SeriesBatch", wireBytes)
+		if err != nil {
+			return err
+		}
+		if num == 0 {
+			continue
+		}
+		if _, err := r.bytes(); err != nil {
+			return err
+		}
+		numSeries++
+	}
+	if numSeries == 0 {
+		return nil
+	}
+
+	m.Series = make([]Series, numSeries)
+	i := 0
+	for r := (&wireReader{b: data}); !r.done(); {
+		if num, _ := r.field("SeriesBatch", wireBytes); num == 0 {
+			continue
+		}
+		b, _ := r.bytes()
+		if err := m.Series[i].unmarshalNoCopy(b, labelString); err != nil {
+			return err
+		}
+		i++
+	}
+	return nil
+}
+
+func (m *Series) unmarshalNoCopy(data []byte, labelString func([]byte) string) error {
+	// Labels and chunks are counted first, so each is allocated once.
+	var numLabels, numChunks int
+	for r := (&wireReader{b: data}); !r.done(); {
+		num, err := r.field("Series", wireBytes, wireBytes)
+		if err != nil {
+			return err
+		}
+		if num == 0 {
+			continue
+		}
+		if _, err := r.bytes(); err != nil {
+			return err
+		}
+		if num == 1 {
+			numLabels++
+		} else {
+			numChunks++
+		}
+	}
+
+	if numLabels > 0 {
+		m.Labels = make([]Label, 0, numLabels)
+	}
+	if numChunks > 0 {
+		m.Chunks = make([]AggrChunk, 0, numChunks)
+	}
+	// Chunks of aggregates are allocated in bulk too. Most series have raw chunks only, so one per aggregated chunk
+	// is allocated at first.
+	var chks []Chunk
+	for r := (&wireReader{b: data}); !r.done(); {
+		num, _ := r.field("Series", wireBytes, wireBytes)
+		if num == 0 {
+			continue
+		}
+		b, _ := r.bytes()
+
+		switch num {
+		case 1:
+			var l Label
+			if err := l.unmarshalNoCopy(b, labelString); err != nil {
+				return err
+			}
+			m.Labels = append(m.Labels, l)
+		case 2:
+			var c AggrChunk
+			if err := c.unmarshalNoCopy(b, func() *Chunk {
+				if len(chks) == cap(chks) {
+					chks = make([]Chunk, 0, numChunks)
+				}
+				chks = chks[:len(chks)+1]
+				return &chks[len(chks)-1]
+			}); err != nil {
+				return err
+			}
+			m.Chunks = append(m.Chunks, c)
+		}
+	}
+	return nil
+}
+
+func (m *Label) unmarshalNoCopy(data []byte, labelString func([]byte) string) error {
+	for r := (&wireReader{b: data}); !r.done(); {
+		num, err := r.field("Label", wireBytes, wireBytes)
+		if err != nil {
+			return err
+		}
+		if num == 0 {
+			continue
+		}
+		b, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		if num == 1 {
+			m.Name = labelString(b)
+		} else {
+			m.Value = labelString(b)
+		}
+	}
+	return nil
+}
+
+func (m *AggrChunk) unmarshalNoCopy(data []byte, newChunk func() *Chunk) error {
+	for r := (&wireReader{b: data}); !r.done(); {
+		num, err := r.field("AggrChunk", wireVarint, wireVarint, wireBytes, wireBytes, wireBytes, wireBytes, wireBytes, wireBytes)
+		if err != nil {
+			return err
+		}
+		switch num {
+		case 0:
+			continue
+		case 1, 2:
+			v, err := r.varint()
+			if err != nil {
+				return err
+			}
+			if num == 1 {
+				m.MinTime = int64(v)
+			} else {
+				m.MaxTime = int64(v)
+			}
+			continue
+		}
+
+		b, err := r.bytes()
+		if err != nil {
+			return err
+		}
+		c := newChunk()
+		if err := c.unmarshalNoCopy(b); err != nil {
+			return err
+		}
+		switch num {
+		case 3:
+			m.Raw = c
+		case 4:
+			m.Count = c
+		case 5:
+			m.Sum = c
+		case 6:
+			m.Min = c
+		case 7:
+			m.Max = c
+		case 8:
+			m.Counter = c
+		}
+	}
+	return nil
+}
+
+func (m *Chunk) unmarshalNoCopy(data []byte) error {
+	for r := (&wireReader{b: data}); !r.done(); {
+		num, err := r.field("Chunk", wireVarint, wireBytes)
+		if err != nil {
+			return err
+		}
+		switch num {
+		case 1:
+			v, err := r.varint()
+			if err != nil {
+				return err
+			}
+			m.Type = Chunk_Encoding(v)
+		case 2:
+			if m.Data, err = r.bytes(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
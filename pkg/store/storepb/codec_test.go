@@ -0,0 +1,161 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package storepb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/strutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestCodec_SeriesResponse(t *testing.T) {
+	raw := newSeries(t, labels.FromStrings("a", "1", "b", "2"), [][]sample{{{1, 1}, {2, 2}}, {{3, 3}, {4, 4}}})
+	aggr := Series{
+		Labels: []Label{{Name: "__name__", Value: "up"}, {Name: "empty", Value: ""}},
+		Chunks: []AggrChunk{
+			{
+				MinTime: -1,
+				MaxTime: 1000,
+				Count:   &Chunk{Type: Chunk_XOR, Data: []byte{1, 2}},
+				Sum:     &Chunk{Type: Chunk_XOR, Data: []byte{3}},
+				Min:     &Chunk{Type: Chunk_XOR, Data: []byte{4, 5, 6}},
+				Max:     &Chunk{Type: Chunk_XOR, Data: []byte{7}},
+				Counter: &Chunk{Type: Chunk_XOR, Data: []byte{8}},
+			},
+			{MinTime: 1001, MaxTime: 2000, Count: &Chunk{Type: Chunk_XOR, Data: []byte{9}}},
+		},
+	}
+
+	for _, tcase := range []struct {
+		name string
+		resp *SeriesResponse
+	}{
+		{name: "raw series", resp: NewSeriesResponse(&raw)},
+		{name: "aggregated series", resp: NewSeriesResponse(&aggr)},
+		{name: "empty series", resp: NewSeriesResponse(&Series{})},
+		{name: "warning", resp: NewWarnSeriesResponse(fmt.Errorf("partial response"))},
+		{name: "batch", resp: NewSeriesBatchResponse([]Series{raw, aggr, {}})},
+		{name: "empty batch", resp: NewSeriesBatchResponse(nil)},
+		{name: "arrow batch", resp: &SeriesResponse{Result: &SeriesResponse_ArrowBatch{ArrowBatch: []byte{1, 2, 3}}}},
+		{name: "empty", resp: &SeriesResponse{}},
+	} {
+		resp := tcase.resp
+		t.Run(tcase.name, func(t *testing.T) {
+			data, err := Codec{}.Marshal(resp)
+			testutil.Ok(t, err)
+
+			var exp SeriesResponse
+			testutil.Ok(t, exp.Unmarshal(data))
+
+			var got SeriesResponse
+			testutil.Ok(t, Codec{}.Unmarshal(data, &got))
+			testutil.Equals(t, exp, got)
+
+			if s := got.GetSeries(); s != nil && len(s.Chunks) > 0 {
+				// Chunks reference the message, but they can not be appended into the rest of it.
+				c := s.Chunks[0].Raw
+				if c == nil {
+					c = s.Chunks[0].Count
+				}
+				_ = append(c.Data, 0xff)
+				var again SeriesResponse
+				testutil.Ok(t, Codec{}.Unmarshal(data, &again))
+				testutil.Equals(t, exp, again)
+			}
+		})
+	}
+
+	t.Run("interned labels", func(t *testing.T) {
+		data, err := NewSeriesBatchResponse([]Series{raw, aggr}).Marshal()
+		testutil.Ok(t, err)
+
+		var exp, got SeriesResponse
+		testutil.Ok(t, exp.Unmarshal(data))
+		testutil.Ok(t, Codec{Interner: strutil.NewInterner(nil, 100)}.Unmarshal(data, &got))
+		testutil.Equals(t, exp, got)
+
+		// Interned labels do not reference the message.
+		for i := range data {
+			data[i] = 0
+		}
+		testutil.Equals(t, exp.GetBatch().Series[0].Labels, got.GetBatch().Series[0].Labels)
+		testutil.Equals(t, exp.GetBatch().Series[1].Labels, got.GetBatch().Series[1].Labels)
+	})
+
+	t.Run("unknown fields are skipped", func(t *testing.T) {
+		data, err := NewSeriesResponse(&aggr).Marshal()
+		testutil.Ok(t, err)
+		// Field 15 of SeriesResponse with varint wire type.
+		data = append(data, 15<<3, 42)
+
+		var exp, got SeriesResponse
+		testutil.Ok(t, exp.Unmarshal(data))
+		testutil.Ok(t, Codec{}.Unmarshal(data, &got))
+		testutil.Equals(t, exp, got)
+	})
+
+	t.Run("invalid messages", func(t *testing.T) {
+		data, err := NewSeriesResponse(&raw).Marshal()
+		testutil.Ok(t, err)
+
+		for i := 1; i < len(data); i++ {
+			var got SeriesResponse
+			testutil.NotOk(t, Codec{}.Unmarshal(data[:i], &got), "truncated to %d bytes", i)
+		}
+
+		var got SeriesResponse
+		// Series field with varint wire type.
+		testutil.NotOk(t, Codec{}.Unmarshal([]byte{1 << 3, 1}, &got))
+	})
+}
+
+func TestCodec_OtherMessages(t *testing.T) {
+	req := &SeriesRequest{MinTime: 1, MaxTime: 2, Matchers: []LabelMatcher{{Type: LabelMatcher_EQ, Name: "a", Value: "1"}}}
+	data, err := Codec{}.Marshal(req)
+	testutil.Ok(t, err)
+
+	var got SeriesRequest
+	testutil.Ok(t, Codec{}.Unmarshal(data, &got))
+	testutil.Equals(t, *req, got)
+}
+
+func BenchmarkCodec_UnmarshalSeriesResponse(b *testing.B) {
+	var lset labels.Labels
+	for i := 0; i < 10; i++ {
+		lset = append(lset, labels.Label{Name: fmt.Sprintf("label_%d", i), Value: fmt.Sprintf("value_%d", i)})
+	}
+	var chunks [][]sample
+	for i := 0; i < 100; i++ {
+		chunks = append(chunks, []sample{{int64(i * 2), 1}, {int64(i*2 + 1), 2}})
+	}
+	s := newSeries(b, lset, chunks)
+	data, err := NewSeriesResponse(&s).Marshal()
+	testutil.Ok(b, err)
+
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var r SeriesResponse
+			testutil.Ok(b, r.Unmarshal(data))
+		}
+	})
+	b.Run("codec", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var r SeriesResponse
+			testutil.Ok(b, Codec{}.Unmarshal(data, &r))
+		}
+	})
+	b.Run("codec with interner", func(b *testing.B) {
+		c := Codec{Interner: strutil.NewInterner(nil, 1000)}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var r SeriesResponse
+			testutil.Ok(b, c.Unmarshal(data, &r))
+		}
+	})
+}
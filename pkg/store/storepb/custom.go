@@ -4,6 +4,7 @@
 package storepb
 
 import (
+	"container/heap"
 	"strings"
 	"unsafe"
 
@@ -35,6 +36,14 @@ func NewSeriesResponse(series *Series) *SeriesResponse {
 	}
 }
 
+func NewSeriesBatchResponse(series []Series) *SeriesResponse {
+	return &SeriesResponse{
+		Result: &SeriesResponse_Batch{
+			Batch: &SeriesBatch{Series: series},
+		},
+	}
+}
+
 // CompareLabels compares two sets of labels.
 func CompareLabels(a, b []Label) int {
 	l := len(a)
@@ -72,12 +81,7 @@ func MergeSeriesSets(all ...SeriesSet) SeriesSet {
 	case 1:
 		return all[0]
 	}
-	h := len(all) / 2
-
-	return newMergedSeriesSet(
-		MergeSeriesSets(all[:h]...),
-		MergeSeriesSets(all[h:]...),
-	)
+	return newMergedSeriesSet(all...)
 }
 
 // SeriesSet is a set of series and their corresponding chunks.
@@ -88,27 +92,38 @@ type SeriesSet interface {
 	Err() error
 }
 
-// mergedSeriesSet takes two series sets as a single series set.
+// mergedSeriesSet takes many series sets as a single series set.
 type mergedSeriesSet struct {
-	a, b SeriesSet
-
-	lset         []Label
-	chunks       []AggrChunk
-	adone, bdone bool
+	all []SeriesSet
+	// h holds the sets that are not exhausted yet, by their current series. Only the current series of sets are
+	// held, so memory is bounded regardless of the number of series in sets.
+	h    seriesSetHeap
+	used []seriesSetHeapItem
+	err  error
+
+	lset   []Label
+	chunks []AggrChunk
 }
 
-// newMergedSeriesSet takes two series sets as a single series set.
-// Series that occur in both sets should have disjoint time ranges.
-// If the ranges overlap b samples are appended to a samples.
+// newMergedSeriesSet takes many series sets as a single series set.
+// Series that occur in many sets should have disjoint time ranges.
+// If the ranges overlap, samples of later sets are appended to samples of earlier ones.
 // If the single SeriesSet returns same series within many iterations,
 // merge series set will not try to merge those.
-func newMergedSeriesSet(a, b SeriesSet) *mergedSeriesSet {
-	s := &mergedSeriesSet{a: a, b: b}
-	// Initialize first elements of both sets as Next() needs
+func newMergedSeriesSet(all ...SeriesSet) *mergedSeriesSet {
+	s := &mergedSeriesSet{all: all, h: make(seriesSetHeap, 0, len(all))}
+	// Initialize first elements of all sets as Next() needs
 	// one element look-ahead.
-	s.adone = !s.a.Next()
-	s.bdone = !s.b.Next()
-
+	for i, set := range all {
+		if set.Next() {
+			s.h = append(s.h, seriesSetHeapItem{set: set, idx: i})
+			continue
+		}
+		if err := set.Err(); err != nil && s.err == nil {
+			s.err = err
+		}
+	}
+	heap.Init(&s.h)
 	return s
 }
 
@@ -117,55 +132,84 @@ func (s *mergedSeriesSet) At() ([]Label, []AggrChunk) {
 }
 
 func (s *mergedSeriesSet) Err() error {
-	if s.a.Err() != nil {
-		return s.a.Err()
+	for _, set := range s.all {
+		if err := set.Err(); err != nil {
+			return err
+		}
 	}
-	return s.b.Err()
+	return nil
 }
 
-func (s *mergedSeriesSet) compare() int {
-	if s.adone {
-		return 1
+func (s *mergedSeriesSet) Next() bool {
+	if len(s.h) == 0 || s.err != nil {
+		return false
 	}
-	if s.bdone {
-		return -1
+
+	// Take the current series of all sets containing the lowest one, in order of sets, and chain them into a single
+	// one. Each set is taken once, so series a single set returns many times are not merged.
+	s.used = s.used[:0]
+	for len(s.h) > 0 {
+		lset, chks := s.h[0].set.At()
+		if len(s.used) == 0 {
+			s.lset, s.chunks = lset, chks
+		} else {
+			if CompareLabels(s.lset, lset) != 0 {
+				break
+			}
+			// Concatenate chunks from all series sets. They may be expected of order
+			// w.r.t to their time range. This must be accounted for later.
+			if len(s.used) == 1 {
+				// Slice reuse is not generally safe with merge iterators.
+				// We err on the safe side an create a new slice.
+				s.chunks = append(make([]AggrChunk, 0, len(s.chunks)+len(chks)), s.chunks...)
+			}
+			s.chunks = append(s.chunks, chks...)
+		}
+		s.used = append(s.used, heap.Pop(&s.h).(seriesSetHeapItem))
 	}
-	lsetA, _ := s.a.At()
-	lsetB, _ := s.b.At()
-	return CompareLabels(lsetA, lsetB)
-}
 
-func (s *mergedSeriesSet) Next() bool {
-	if s.adone && s.bdone || s.Err() != nil {
-		return false
+	for _, item := range s.used {
+		if item.set.Next() {
+			heap.Push(&s.h, item)
+			continue
+		}
+		if err := item.set.Err(); err != nil && s.err == nil {
+			s.err = err
+		}
 	}
+	return true
+}
 
-	d := s.compare()
+type seriesSetHeapItem struct {
+	set SeriesSet
+	idx int
+}
 
-	// Both sets contain the current series. Chain them into a single one.
-	if d > 0 {
-		s.lset, s.chunks = s.b.At()
-		s.bdone = !s.b.Next()
-	} else if d < 0 {
-		s.lset, s.chunks = s.a.At()
-		s.adone = !s.a.Next()
-	} else {
-		// Concatenate chunks from both series sets. They may be expected of order
-		// w.r.t to their time range. This must be accounted for later.
-		lset, chksA := s.a.At()
-		_, chksB := s.b.At()
+// seriesSetHeap is a heap of series sets ordered by their current series, and by their order for equal series.
+type seriesSetHeap []seriesSetHeapItem
 
-		s.lset = lset
-		// Slice reuse is not generally safe with nested merge iterators.
-		// We err on the safe side an create a new slice.
-		s.chunks = make([]AggrChunk, 0, len(chksA)+len(chksB))
-		s.chunks = append(s.chunks, chksA...)
-		s.chunks = append(s.chunks, chksB...)
+func (h seriesSetHeap) Len() int      { return len(h) }
+func (h seriesSetHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
 
-		s.adone = !s.a.Next()
-		s.bdone = !s.b.Next()
+func (h seriesSetHeap) Less(i, j int) bool {
+	lsetI, _ := h[i].set.At()
+	lsetJ, _ := h[j].set.At()
+	if d := CompareLabels(lsetI, lsetJ); d != 0 {
+		return d < 0
 	}
-	return true
+	return h[i].idx < h[j].idx
+}
+
+func (h *seriesSetHeap) Push(x interface{}) {
+	*h = append(*h, x.(seriesSetHeapItem))
+}
+
+func (h *seriesSetHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
 }
 
 // LabelsToPromLabels converts Thanos proto labels to Prometheus labels in type safe manner.
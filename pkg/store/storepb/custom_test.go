@@ -169,6 +169,50 @@ func TestMergeSeriesSet(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "three seriesSets, {a=b} series to merge in order of sets",
+			in: [][]rawSeries{
+				{
+					{
+						lset:   labels.FromStrings("a", "b"),
+						chunks: [][]sample{{{1, 1}, {2, 2}}},
+					},
+				},
+				{
+					{
+						lset:   labels.FromStrings("a", "a"),
+						chunks: [][]sample{{{1, 1}, {2, 2}}},
+					},
+					{
+						lset:   labels.FromStrings("a", "b"),
+						chunks: [][]sample{{{3, 3}, {4, 4}}},
+					},
+				},
+				{
+					{
+						lset:   labels.FromStrings("a", "b"),
+						chunks: [][]sample{{{5, 5}, {6, 6}}},
+					},
+					{
+						lset:   labels.FromStrings("a", "c"),
+						chunks: [][]sample{{{1, 1}, {2, 2}}},
+					},
+				},
+			},
+
+			expected: []rawSeries{
+				{
+					lset:   labels.FromStrings("a", "a"),
+					chunks: [][]sample{{{1, 1}, {2, 2}}},
+				}, {
+					lset:   labels.FromStrings("a", "b"),
+					chunks: [][]sample{{{1, 1}, {2, 2}}, {{3, 3}, {4, 4}}, {{5, 5}, {6, 6}}},
+				}, {
+					lset:   labels.FromStrings("a", "c"),
+					chunks: [][]sample{{{1, 1}, {2, 2}}},
+				},
+			},
+		},
 		{
 			// SeriesSet can return same series within different iterations. MergeSeries should not try to merge those.
 			// We do it on last step possible: Querier promSet.
@@ -275,18 +319,6 @@ func seriesEquals(t *testing.T, expected []rawSeries, gotSS SeriesSet) {
 // Test the cost of merging series sets for different number of merged sets and their size.
 // The subset are all equivalent so this does not capture merging of partial or non-overlapping sets well.
 func BenchmarkMergedSeriesSet(b *testing.B) {
-	var sel func(sets []SeriesSet) SeriesSet
-	sel = func(sets []SeriesSet) SeriesSet {
-		if len(sets) == 0 {
-			return EmptySeriesSet()
-		}
-		if len(sets) == 1 {
-			return sets[0]
-		}
-		l := len(sets) / 2
-		return newMergedSeriesSet(sel(sets[:l]), sel(sets[l:]))
-	}
-
 	chunks := [][]sample{{{1, 1}, {2, 2}}, {{3, 3}, {4, 4}}}
 	for _, k := range []int{
 		100,
@@ -316,7 +348,7 @@ func BenchmarkMergedSeriesSet(b *testing.B) {
 					for _, s := range in {
 						sets = append(sets, newListSeriesSet(b, s))
 					}
-					ms := sel(sets)
+					ms := MergeSeriesSets(sets...)
 
 					i := 0
 					for ms.Next() {
@@ -256,6 +256,12 @@ type InfoResponse struct {
 	StoreType StoreType `protobuf:"varint,4,opt,name=storeType,proto3,enum=thanos.StoreType" json:"storeType,omitempty"`
 	// label_sets is an unsorted list of `LabelSet`s.
 	LabelSets []LabelSet `protobuf:"bytes,5,rep,name=label_sets,json=labelSets,proto3" json:"label_sets"`
+	// zone is the availability zone of the store, if advertised. Queriers prefer stores in their own zone over
+	// replicas with the same label sets and time range in other zones.
+	Zone string `protobuf:"bytes,6,opt,name=zone,proto3" json:"zone,omitempty"`
+	// capabilities are optional extensions of the StoreAPI supported by the store, e.g. "arrow_series". Clients only
+	// use extensions advertised by the store.
+	Capabilities []string `protobuf:"bytes,7,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
 }
 
 func (m *InfoResponse) Reset()         { *m = InfoResponse{} }
@@ -340,6 +346,13 @@ type SeriesRequest struct {
 	PartialResponseStrategy PartialResponseStrategy `protobuf:"varint,7,opt,name=partial_response_strategy,json=partialResponseStrategy,proto3,enum=thanos.PartialResponseStrategy" json:"partial_response_strategy,omitempty"`
 	// skip_chunks controls whether sending chunks or not in series responses.
 	SkipChunks bool `protobuf:"varint,8,opt,name=skip_chunks,json=skipChunks,proto3" json:"skip_chunks,omitempty"`
+	// response_batch_bytes, if not zero, allows the server to batch series into batch responses of about that many
+	// bytes, saving the overhead of a message per series. Servers not supporting batches ignore it and send a response
+	// per series.
+	ResponseBatchBytes int64 `protobuf:"varint,9,opt,name=response_batch_bytes,json=responseBatchBytes,proto3" json:"response_batch_bytes,omitempty"`
+	// arrow_batches, if set, allows the server to send series of raw data as Arrow record batches in arrow_batch
+	// responses. Clients only expect them from servers advertising the "arrow_series" capability, others ignore it.
+	ArrowBatches bool `protobuf:"varint,10,opt,name=arrow_batches,json=arrowBatches,proto3" json:"arrow_batches,omitempty"`
 }
 
 func (m *SeriesRequest) Reset()         { *m = SeriesRequest{} }
@@ -379,6 +392,8 @@ type SeriesResponse struct {
 	// Types that are valid to be assigned to Result:
 	//	*SeriesResponse_Series
 	//	*SeriesResponse_Warning
+	//	*SeriesResponse_Batch
+	//	*SeriesResponse_ArrowBatch
 	Result isSeriesResponse_Result `protobuf_oneof:"result"`
 }
 
@@ -427,9 +442,17 @@ type SeriesResponse_Series struct {
 type SeriesResponse_Warning struct {
 	Warning string `protobuf:"bytes,2,opt,name=warning,proto3,oneof" json:"warning,omitempty"`
 }
+type SeriesResponse_Batch struct {
+	Batch *SeriesBatch `protobuf:"bytes,3,opt,name=batch,proto3,oneof" json:"batch,omitempty"`
+}
+type SeriesResponse_ArrowBatch struct {
+	ArrowBatch []byte `protobuf:"bytes,4,opt,name=arrow_batch,json=arrowBatch,proto3,oneof" json:"arrow_batch,omitempty"`
+}
 
-func (*SeriesResponse_Series) isSeriesResponse_Result()  {}
-func (*SeriesResponse_Warning) isSeriesResponse_Result() {}
+func (*SeriesResponse_Series) isSeriesResponse_Result()     {}
+func (*SeriesResponse_Warning) isSeriesResponse_Result()    {}
+func (*SeriesResponse_Batch) isSeriesResponse_Result()      {}
+func (*SeriesResponse_ArrowBatch) isSeriesResponse_Result() {}
 
 func (m *SeriesResponse) GetResult() isSeriesResponse_Result {
 	if m != nil {
@@ -452,13 +475,66 @@ func (m *SeriesResponse) GetWarning() string {
 	return ""
 }
 
+func (m *SeriesResponse) GetBatch() *SeriesBatch {
+	if x, ok := m.GetResult().(*SeriesResponse_Batch); ok {
+		return x.Batch
+	}
+	return nil
+}
+
+func (m *SeriesResponse) GetArrowBatch() []byte {
+	if x, ok := m.GetResult().(*SeriesResponse_ArrowBatch); ok {
+		return x.ArrowBatch
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*SeriesResponse) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*SeriesResponse_Series)(nil),
 		(*SeriesResponse_Warning)(nil),
+		(*SeriesResponse_Batch)(nil),
+		(*SeriesResponse_ArrowBatch)(nil),
+	}
+}
+
+type SeriesBatch struct {
+	Series []Series `protobuf:"bytes,1,rep,name=series,proto3" json:"series"`
+}
+
+func (m *SeriesBatch) Reset()         { *m = SeriesBatch{} }
+func (m *SeriesBatch) String() string { return proto.CompactTextString(m) }
+func (*SeriesBatch) ProtoMessage()    {}
+func (*SeriesBatch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{7}
+}
+func (m *SeriesBatch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SeriesBatch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SeriesBatch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
 }
+func (m *SeriesBatch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SeriesBatch.Merge(m, src)
+}
+func (m *SeriesBatch) XXX_Size() int {
+	return m.Size()
+}
+func (m *SeriesBatch) XXX_DiscardUnknown() {
+	xxx_messageInfo_SeriesBatch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SeriesBatch proto.InternalMessageInfo
 
 type LabelNamesRequest struct {
 	PartialResponseDisabled bool `protobuf:"varint,1,opt,name=partial_response_disabled,json=partialResponseDisabled,proto3" json:"partial_response_disabled,omitempty"`
@@ -470,7 +546,7 @@ func (m *LabelNamesRequest) Reset()         { *m = LabelNamesRequest{} }
 func (m *LabelNamesRequest) String() string { return proto.CompactTextString(m) }
 func (*LabelNamesRequest) ProtoMessage()    {}
 func (*LabelNamesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{7}
+	return fileDescriptor_77a6da22d6a3feb1, []int{8}
 }
 func (m *LabelNamesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -508,7 +584,7 @@ func (m *LabelNamesResponse) Reset()         { *m = LabelNamesResponse{} }
 func (m *LabelNamesResponse) String() string { return proto.CompactTextString(m) }
 func (*LabelNamesResponse) ProtoMessage()    {}
 func (*LabelNamesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{8}
+	return fileDescriptor_77a6da22d6a3feb1, []int{9}
 }
 func (m *LabelNamesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -548,7 +624,7 @@ func (m *LabelValuesRequest) Reset()         { *m = LabelValuesRequest{} }
 func (m *LabelValuesRequest) String() string { return proto.CompactTextString(m) }
 func (*LabelValuesRequest) ProtoMessage()    {}
 func (*LabelValuesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{9}
+	return fileDescriptor_77a6da22d6a3feb1, []int{10}
 }
 func (m *LabelValuesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -586,7 +662,7 @@ func (m *LabelValuesResponse) Reset()         { *m = LabelValuesResponse{} }
 func (m *LabelValuesResponse) String() string { return proto.CompactTextString(m) }
 func (*LabelValuesResponse) ProtoMessage()    {}
 func (*LabelValuesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{10}
+	return fileDescriptor_77a6da22d6a3feb1, []int{11}
 }
 func (m *LabelValuesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -615,6 +691,128 @@ func (m *LabelValuesResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_LabelValuesResponse proto.InternalMessageInfo
 
+type TSDBStatusRequest struct {
+}
+
+func (m *TSDBStatusRequest) Reset()         { *m = TSDBStatusRequest{} }
+func (m *TSDBStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*TSDBStatusRequest) ProtoMessage()    {}
+func (*TSDBStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{12}
+}
+func (m *TSDBStatusRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TSDBStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TSDBStatusRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TSDBStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TSDBStatusRequest.Merge(m, src)
+}
+func (m *TSDBStatusRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *TSDBStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TSDBStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TSDBStatusRequest proto.InternalMessageInfo
+
+type Statistic struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value uint64 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *Statistic) Reset()         { *m = Statistic{} }
+func (m *Statistic) String() string { return proto.CompactTextString(m) }
+func (*Statistic) ProtoMessage()    {}
+func (*Statistic) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{13}
+}
+func (m *Statistic) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Statistic) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Statistic.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Statistic) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Statistic.Merge(m, src)
+}
+func (m *Statistic) XXX_Size() int {
+	return m.Size()
+}
+func (m *Statistic) XXX_DiscardUnknown() {
+	xxx_messageInfo_Statistic.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Statistic proto.InternalMessageInfo
+
+type TSDBStatusResponse struct {
+	/// num_series is the number of series in the head block.
+	NumSeries uint64 `protobuf:"varint,1,opt,name=num_series,json=numSeries,proto3" json:"num_series,omitempty"`
+	/// num_chunks is the number of chunks in the head block.
+	NumChunks uint64 `protobuf:"varint,2,opt,name=num_chunks,json=numChunks,proto3" json:"num_chunks,omitempty"`
+	/// min_time and max_time are the time range of samples in the head block.
+	MinTime int64 `protobuf:"varint,3,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
+	MaxTime int64 `protobuf:"varint,4,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
+	/// Top lists of the head block with highest values, sorted by value in descending order.
+	SeriesCountByMetricName     []Statistic `protobuf:"bytes,5,rep,name=series_count_by_metric_name,json=seriesCountByMetricName,proto3" json:"series_count_by_metric_name"`
+	LabelValueCountByLabelName  []Statistic `protobuf:"bytes,6,rep,name=label_value_count_by_label_name,json=labelValueCountByLabelName,proto3" json:"label_value_count_by_label_name"`
+	MemoryInBytesByLabelName    []Statistic `protobuf:"bytes,7,rep,name=memory_in_bytes_by_label_name,json=memoryInBytesByLabelName,proto3" json:"memory_in_bytes_by_label_name"`
+	SeriesCountByLabelValuePair []Statistic `protobuf:"bytes,8,rep,name=series_count_by_label_value_pair,json=seriesCountByLabelValuePair,proto3" json:"series_count_by_label_value_pair"`
+}
+
+func (m *TSDBStatusResponse) Reset()         { *m = TSDBStatusResponse{} }
+func (m *TSDBStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*TSDBStatusResponse) ProtoMessage()    {}
+func (*TSDBStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{14}
+}
+func (m *TSDBStatusResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TSDBStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TSDBStatusResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TSDBStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TSDBStatusResponse.Merge(m, src)
+}
+func (m *TSDBStatusResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *TSDBStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TSDBStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TSDBStatusResponse proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterEnum("thanos.StoreType", StoreType_name, StoreType_value)
 	proto.RegisterEnum("thanos.PartialResponseStrategy", PartialResponseStrategy_name, PartialResponseStrategy_value)
@@ -626,74 +824,99 @@ func init() {
 	proto.RegisterType((*LabelSet)(nil), "thanos.LabelSet")
 	proto.RegisterType((*SeriesRequest)(nil), "thanos.SeriesRequest")
 	proto.RegisterType((*SeriesResponse)(nil), "thanos.SeriesResponse")
+	proto.RegisterType((*SeriesBatch)(nil), "thanos.SeriesBatch")
 	proto.RegisterType((*LabelNamesRequest)(nil), "thanos.LabelNamesRequest")
 	proto.RegisterType((*LabelNamesResponse)(nil), "thanos.LabelNamesResponse")
 	proto.RegisterType((*LabelValuesRequest)(nil), "thanos.LabelValuesRequest")
 	proto.RegisterType((*LabelValuesResponse)(nil), "thanos.LabelValuesResponse")
+	proto.RegisterType((*TSDBStatusRequest)(nil), "thanos.TSDBStatusRequest")
+	proto.RegisterType((*Statistic)(nil), "thanos.Statistic")
+	proto.RegisterType((*TSDBStatusResponse)(nil), "thanos.TSDBStatusResponse")
 }
 
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor_77a6da22d6a3feb1) }
 
 var fileDescriptor_77a6da22d6a3feb1 = []byte{
-	// 927 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xcd, 0x6e, 0x23, 0x45,
-	0x10, 0x76, 0x7b, 0xfc, 0x5b, 0xde, 0x98, 0xd9, 0x8e, 0x93, 0x9d, 0x78, 0x25, 0xc7, 0x1a, 0x09,
-	0xc9, 0x0a, 0xc8, 0x01, 0x23, 0x40, 0x20, 0x2e, 0xb6, 0xd7, 0xab, 0xb5, 0xd8, 0x38, 0xd0, 0xb6,
-	0xd7, 0xfc, 0x1c, 0xcc, 0xd8, 0x69, 0x9c, 0xd1, 0xce, 0x1f, 0xd3, 0x6d, 0x92, 0x5c, 0xe1, 0x05,
-	0xb8, 0xf2, 0x0e, 0xbc, 0x05, 0x97, 0x1c, 0xf7, 0x08, 0x17, 0x04, 0xc9, 0x8b, 0xa0, 0xee, 0xe9,
-	0x71, 0x66, 0x96, 0x6c, 0x24, 0x94, 0x5b, 0xd7, 0xf7, 0x55, 0x77, 0x55, 0x7d, 0x5d, 0x35, 0x3d,
-	0x50, 0x0e, 0x83, 0x65, 0x3b, 0x08, 0x7d, 0xee, 0xe3, 0x02, 0x3f, 0xb5, 0x3c, 0x9f, 0xd5, 0x2b,
-	0xfc, 0x22, 0xa0, 0x2c, 0x02, 0xeb, 0xb5, 0x95, 0xbf, 0xf2, 0xe5, 0xf2, 0x50, 0xac, 0x14, 0x8a,
-	0x83, 0xd0, 0x77, 0x83, 0xc5, 0x61, 0xc2, 0xd3, 0x7c, 0x0b, 0xb6, 0x66, 0xa1, 0xcd, 0x29, 0xa1,
-	0x2c, 0xf0, 0x3d, 0x46, 0xcd, 0x9f, 0x11, 0x3c, 0x50, 0xc8, 0x0f, 0x6b, 0xca, 0x38, 0xee, 0x02,
-	0x70, 0xdb, 0xa5, 0x8c, 0x86, 0x36, 0x65, 0x06, 0x6a, 0x6a, 0xad, 0x4a, 0xe7, 0xb1, 0xd8, 0xed,
-	0x52, 0x7e, 0x4a, 0xd7, 0x6c, 0xbe, 0xf4, 0x83, 0x8b, 0xf6, 0xc4, 0x76, 0xe9, 0x58, 0xba, 0xf4,
-	0x72, 0x97, 0x7f, 0xed, 0x67, 0x48, 0x62, 0x13, 0xde, 0x85, 0x02, 0xa7, 0x9e, 0xe5, 0x71, 0x23,
-	0xdb, 0x44, 0xad, 0x32, 0x51, 0x16, 0x36, 0xa0, 0x18, 0xd2, 0xc0, 0xb1, 0x97, 0x96, 0xa1, 0x35,
-	0x51, 0x4b, 0x23, 0xb1, 0x69, 0x6e, 0x41, 0x65, 0xe8, 0x7d, 0xef, 0xab, 0x1c, 0xcc, 0x3f, 0x11,
-	0x3c, 0x88, 0xec, 0x28, 0x4b, 0xfc, 0x0e, 0x14, 0x1c, 0x6b, 0x41, 0x9d, 0x38, 0xa1, 0xad, 0x76,
-	0x24, 0x43, 0xfb, 0xb9, 0x40, 0x55, 0x0a, 0xca, 0x05, 0xef, 0x41, 0xc9, 0xb5, 0xbd, 0xb9, 0x48,
-	0x48, 0x26, 0xa0, 0x91, 0xa2, 0x6b, 0x7b, 0x22, 0x63, 0x49, 0x59, 0xe7, 0x11, 0xa5, 0x52, 0x70,
-	0xad, 0x73, 0x49, 0x1d, 0x42, 0x99, 0x71, 0x3f, 0xa4, 0x93, 0x8b, 0x80, 0x1a, 0xb9, 0x26, 0x6a,
-	0x55, 0x3b, 0x0f, 0xe3, 0x28, 0xe3, 0x98, 0x20, 0x37, 0x3e, 0xf8, 0x43, 0x00, 0x19, 0x70, 0xce,
-	0x28, 0x67, 0x46, 0x5e, 0xe6, 0xa5, 0xa7, 0xf2, 0x1a, 0x53, 0xae, 0x52, 0x2b, 0x3b, 0xca, 0x66,
-	0xe6, 0xc7, 0x50, 0x8a, 0xc9, 0xff, 0x55, 0x96, 0xf9, 0xab, 0x06, 0x5b, 0x91, 0xe4, 0xf1, 0x55,
-	0x25, 0x0b, 0x45, 0x6f, 0x2e, 0x34, 0x9b, 0x2e, 0xf4, 0x23, 0x41, 0xf1, 0xe5, 0x29, 0x0d, 0x99,
-	0xa1, 0xc9, 0xb0, 0xb5, 0x54, 0xd8, 0xa3, 0x88, 0x54, 0xd1, 0x37, 0xbe, 0xb8, 0x03, 0x3b, 0xe2,
-	0xc8, 0x90, 0x32, 0xdf, 0x59, 0x73, 0xdb, 0xf7, 0xe6, 0x67, 0xb6, 0x77, 0xe2, 0x9f, 0x49, 0xb1,
-	0x34, 0xb2, 0xed, 0x5a, 0xe7, 0x64, 0xc3, 0xcd, 0x24, 0x85, 0xdf, 0x05, 0xb0, 0x56, 0xab, 0x90,
-	0xae, 0x2c, 0x4e, 0x23, 0x8d, 0xaa, 0x9d, 0x07, 0x71, 0xb4, 0xee, 0x6a, 0x15, 0x92, 0x04, 0x8f,
-	0x3f, 0x85, 0xbd, 0xc0, 0x0a, 0xb9, 0x6d, 0x39, 0x22, 0x8a, 0xbc, 0xf9, 0xf9, 0x89, 0xcd, 0xac,
-	0x85, 0x43, 0x4f, 0x8c, 0x42, 0x13, 0xb5, 0x4a, 0xe4, 0x91, 0x72, 0x88, 0x3b, 0xe3, 0x89, 0xa2,
-	0xf1, 0xb7, 0xb7, 0xec, 0x65, 0x3c, 0xb4, 0x38, 0x5d, 0x5d, 0x18, 0x45, 0x79, 0x9d, 0xfb, 0x71,
-	0xe0, 0x2f, 0xd2, 0x67, 0x8c, 0x95, 0xdb, 0x7f, 0x0e, 0x8f, 0x09, 0xbc, 0x0f, 0x15, 0xf6, 0xd2,
-	0x0e, 0xe6, 0xcb, 0xd3, 0xb5, 0xf7, 0x92, 0x19, 0x25, 0x99, 0x0a, 0x08, 0xa8, 0x2f, 0x11, 0xf3,
-	0x3b, 0xa8, 0xc6, 0x57, 0xa3, 0x3a, 0xb6, 0x05, 0x85, 0xcd, 0x08, 0xa1, 0x56, 0xa5, 0x53, 0xdd,
-	0xf4, 0x92, 0x44, 0x9f, 0x65, 0x88, 0xe2, 0x71, 0x1d, 0x8a, 0x67, 0x56, 0xe8, 0xd9, 0xde, 0x2a,
-	0x1a, 0x97, 0x67, 0x19, 0x12, 0x03, 0xbd, 0x12, 0x14, 0x42, 0xca, 0xd6, 0x0e, 0x37, 0x7f, 0x43,
-	0xf0, 0x50, 0x5e, 0xcf, 0xc8, 0x72, 0x6f, 0x3a, 0xe0, 0x4e, 0xc5, 0xd0, 0x3d, 0x14, 0xcb, 0xde,
-	0x4f, 0x31, 0xf3, 0x29, 0xe0, 0x64, 0xb6, 0x4a, 0x94, 0x1a, 0xe4, 0x3d, 0x01, 0xc8, 0x76, 0x2f,
-	0x93, 0xc8, 0xc0, 0x75, 0x28, 0xa9, 0x7a, 0x99, 0x91, 0x95, 0xc4, 0xc6, 0x36, 0x7f, 0x47, 0xea,
-	0xa0, 0x17, 0x96, 0xb3, 0xbe, 0xa9, 0xbb, 0x06, 0x79, 0x39, 0x15, 0xb2, 0xc6, 0x32, 0x89, 0x8c,
-	0xbb, 0xd5, 0xc8, 0xde, 0x43, 0x0d, 0xed, 0x9e, 0x6a, 0x0c, 0x61, 0x3b, 0x55, 0x84, 0x92, 0x63,
-	0x17, 0x0a, 0x3f, 0x4a, 0x44, 0xe9, 0xa1, 0xac, 0xbb, 0x04, 0x39, 0x20, 0x50, 0xde, 0x7c, 0x8d,
-	0x70, 0x05, 0x8a, 0xd3, 0xd1, 0xe7, 0xa3, 0xe3, 0xd9, 0x48, 0xcf, 0xe0, 0x32, 0xe4, 0xbf, 0x9c,
-	0x0e, 0xc8, 0xd7, 0x3a, 0xc2, 0x25, 0xc8, 0x91, 0xe9, 0xf3, 0x81, 0x9e, 0x15, 0x1e, 0xe3, 0xe1,
-	0x93, 0x41, 0xbf, 0x4b, 0x74, 0x4d, 0x78, 0x8c, 0x27, 0xc7, 0x64, 0xa0, 0xe7, 0x04, 0x4e, 0x06,
-	0xfd, 0xc1, 0xf0, 0xc5, 0x40, 0xcf, 0x1f, 0xb4, 0xe1, 0xd1, 0x1b, 0x4a, 0x12, 0x27, 0xcd, 0xba,
-	0x44, 0x1d, 0xdf, 0xed, 0x1d, 0x93, 0x89, 0x8e, 0x0e, 0x7a, 0x90, 0x13, 0xb3, 0x8b, 0x8b, 0xa0,
-	0x91, 0xee, 0x2c, 0xe2, 0xfa, 0xc7, 0xd3, 0xd1, 0x44, 0x47, 0x02, 0x1b, 0x4f, 0x8f, 0xf4, 0xac,
-	0x58, 0x1c, 0x0d, 0x47, 0xba, 0x26, 0x17, 0xdd, 0xaf, 0xa2, 0x98, 0xd2, 0x6b, 0x40, 0xf4, 0x7c,
-	0xe7, 0xa7, 0x2c, 0xe4, 0x65, 0x21, 0xf8, 0x7d, 0xc8, 0x89, 0x6f, 0x3d, 0xde, 0x8e, 0xe5, 0x4d,
-	0xbc, 0x04, 0xf5, 0x5a, 0x1a, 0x54, 0xc2, 0x7d, 0x02, 0x85, 0x68, 0x8c, 0xf0, 0x4e, 0x7a, 0xac,
-	0xe2, 0x6d, 0xbb, 0xaf, 0xc3, 0xd1, 0xc6, 0xf7, 0x10, 0xee, 0x03, 0xdc, 0x34, 0x26, 0xde, 0x4b,
-	0x7d, 0xf9, 0x92, 0xa3, 0x55, 0xaf, 0xdf, 0x46, 0xa9, 0xf8, 0x4f, 0xa1, 0x92, 0xb8, 0x4f, 0x9c,
-	0x76, 0x4d, 0x75, 0x6a, 0xfd, 0xf1, 0xad, 0x5c, 0x74, 0x4e, 0x67, 0x04, 0x55, 0xf9, 0xf6, 0x8a,
-	0x16, 0x8c, 0xc4, 0xf8, 0x0c, 0x2a, 0x84, 0xba, 0x3e, 0xa7, 0x12, 0xc7, 0x9b, 0xf2, 0x93, 0x4f,
-	0x74, 0x7d, 0xe7, 0x35, 0x54, 0x3d, 0xe5, 0x99, 0xde, 0xdb, 0x97, 0xff, 0x34, 0x32, 0x97, 0x57,
-	0x0d, 0xf4, 0xea, 0xaa, 0x81, 0xfe, 0xbe, 0x6a, 0xa0, 0x5f, 0xae, 0x1b, 0x99, 0x57, 0xd7, 0x8d,
-	0xcc, 0x1f, 0xd7, 0x8d, 0xcc, 0x37, 0x45, 0xf9, 0x76, 0x05, 0x8b, 0x45, 0x41, 0xfe, 0x0b, 0x7c,
-	0xf0, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xe7, 0x85, 0xb9, 0xc1, 0x57, 0x08, 0x00, 0x00,
+	// 1259 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0x4b, 0x8f, 0x1b, 0x45,
+	0x10, 0xf6, 0x78, 0xfc, 0x2c, 0x7b, 0x97, 0x49, 0xaf, 0x93, 0x4c, 0x66, 0x15, 0xaf, 0x19, 0x84,
+	0x64, 0x25, 0xd1, 0x26, 0x18, 0x05, 0xc4, 0xe3, 0x62, 0x3b, 0x8e, 0xd6, 0x22, 0xeb, 0x0d, 0x6d,
+	0x3b, 0x1b, 0x1e, 0x62, 0x34, 0x76, 0x1a, 0xef, 0x28, 0x9e, 0x07, 0x33, 0x6d, 0x36, 0xe6, 0x08,
+	0x7f, 0x00, 0xfe, 0x07, 0x07, 0xfe, 0x03, 0x97, 0x1c, 0x73, 0xe4, 0x84, 0x20, 0xf9, 0x03, 0xdc,
+	0xb8, 0xa2, 0x7e, 0x8c, 0x3d, 0xb3, 0xd9, 0x58, 0x42, 0x7b, 0xeb, 0xae, 0xaf, 0xba, 0x1e, 0x5f,
+	0x57, 0x55, 0x37, 0x94, 0xc3, 0x60, 0xba, 0x1f, 0x84, 0x3e, 0xf5, 0x51, 0x81, 0x9e, 0xd8, 0x9e,
+	0x1f, 0x19, 0x15, 0xba, 0x0c, 0x48, 0x24, 0x84, 0x46, 0x6d, 0xe6, 0xcf, 0x7c, 0xbe, 0xbc, 0xcd,
+	0x56, 0x52, 0x8a, 0x82, 0xd0, 0x77, 0x83, 0xc9, 0xed, 0x84, 0xa6, 0xf9, 0x16, 0x6c, 0x1d, 0x87,
+	0x0e, 0x25, 0x98, 0x44, 0x81, 0xef, 0x45, 0xc4, 0xfc, 0x49, 0x81, 0xaa, 0x94, 0x7c, 0xb7, 0x20,
+	0x11, 0x45, 0x6d, 0x00, 0xea, 0xb8, 0x24, 0x22, 0xa1, 0x43, 0x22, 0x5d, 0x69, 0xa8, 0xcd, 0x4a,
+	0x6b, 0x97, 0x9d, 0x76, 0x09, 0x3d, 0x21, 0x8b, 0xc8, 0x9a, 0xfa, 0xc1, 0x72, 0x7f, 0xe4, 0xb8,
+	0x64, 0xc8, 0x55, 0x3a, 0xb9, 0xe7, 0x7f, 0xee, 0x65, 0x70, 0xe2, 0x10, 0xba, 0x02, 0x05, 0x4a,
+	0x3c, 0xdb, 0xa3, 0x7a, 0xb6, 0xa1, 0x34, 0xcb, 0x58, 0xee, 0x90, 0x0e, 0xc5, 0x90, 0x04, 0x73,
+	0x67, 0x6a, 0xeb, 0x6a, 0x43, 0x69, 0xaa, 0x38, 0xde, 0x9a, 0x5b, 0x50, 0xe9, 0x7b, 0xdf, 0xfa,
+	0x32, 0x06, 0xf3, 0x97, 0x2c, 0x54, 0xc5, 0x5e, 0x44, 0x89, 0x6e, 0x42, 0x61, 0x6e, 0x4f, 0xc8,
+	0x3c, 0x0e, 0x68, 0x6b, 0x5f, 0xd0, 0xb0, 0xff, 0x80, 0x49, 0x65, 0x08, 0x52, 0x05, 0x5d, 0x83,
+	0x92, 0xeb, 0x78, 0x16, 0x0b, 0x88, 0x07, 0xa0, 0xe2, 0xa2, 0xeb, 0x78, 0x2c, 0x62, 0x0e, 0xd9,
+	0xcf, 0x04, 0x24, 0x43, 0x70, 0xed, 0x67, 0x1c, 0xba, 0x0d, 0xe5, 0x88, 0xfa, 0x21, 0x19, 0x2d,
+	0x03, 0xa2, 0xe7, 0x1a, 0x4a, 0x73, 0xbb, 0x75, 0x29, 0xf6, 0x32, 0x8c, 0x01, 0xbc, 0xd6, 0x41,
+	0x77, 0x01, 0xb8, 0x43, 0x2b, 0x22, 0x34, 0xd2, 0xf3, 0x3c, 0x2e, 0x2d, 0x15, 0xd7, 0x90, 0x50,
+	0x19, 0x5a, 0x79, 0x2e, 0xf7, 0x11, 0x42, 0x90, 0xfb, 0xc1, 0xf7, 0x88, 0x5e, 0xe0, 0xd4, 0xf0,
+	0x35, 0x32, 0xa1, 0x3a, 0xb5, 0x03, 0x7b, 0xe2, 0xcc, 0x1d, 0xca, 0x58, 0x2f, 0x36, 0xd4, 0x66,
+	0x19, 0xa7, 0x64, 0xe6, 0x87, 0x50, 0x8a, 0x8d, 0xfe, 0x2f, 0x3a, 0xcc, 0x7f, 0x54, 0xd8, 0x12,
+	0x57, 0x15, 0x5f, 0x71, 0x92, 0x20, 0xe5, 0xcd, 0x04, 0x65, 0xd3, 0x04, 0x7d, 0xc0, 0x20, 0x3a,
+	0x3d, 0x21, 0x61, 0xa4, 0xab, 0xdc, 0x6d, 0x2d, 0xe5, 0xf6, 0x50, 0x80, 0xd2, 0xfb, 0x4a, 0x17,
+	0xb5, 0xe0, 0x32, 0x33, 0x19, 0x92, 0xc8, 0x9f, 0x2f, 0xa8, 0xe3, 0x7b, 0xd6, 0xa9, 0xe3, 0x3d,
+	0xf1, 0x4f, 0x39, 0xc9, 0x2a, 0xde, 0x71, 0xed, 0x67, 0x78, 0x85, 0x1d, 0x73, 0x08, 0xdd, 0x02,
+	0xb0, 0x67, 0xb3, 0x90, 0xcc, 0x6c, 0x4a, 0x04, 0xb7, 0xdb, 0xad, 0x6a, 0xec, 0xad, 0x3d, 0x9b,
+	0x85, 0x38, 0x81, 0xa3, 0x8f, 0xe1, 0x5a, 0x60, 0x87, 0xd4, 0xb1, 0xe7, 0x56, 0x28, 0x2b, 0xc6,
+	0x7a, 0xe2, 0x44, 0xf6, 0x64, 0x4e, 0x9e, 0x70, 0x9e, 0x4b, 0xf8, 0xaa, 0x54, 0x88, 0x2b, 0xea,
+	0x9e, 0x84, 0xd1, 0x57, 0xe7, 0x9c, 0x8d, 0x68, 0x68, 0x53, 0x32, 0x5b, 0xea, 0x45, 0x5e, 0x06,
+	0x7b, 0xb1, 0xe3, 0x87, 0x69, 0x1b, 0x43, 0xa9, 0xf6, 0x9a, 0xf1, 0x18, 0x40, 0x7b, 0x50, 0x89,
+	0x9e, 0x3a, 0x81, 0x35, 0x3d, 0x59, 0x78, 0x4f, 0x23, 0xbd, 0xc4, 0x43, 0x01, 0x26, 0xea, 0x72,
+	0x09, 0xba, 0x03, 0xb5, 0x95, 0xd7, 0x09, 0x23, 0xcc, 0x9a, 0x2c, 0x59, 0xc6, 0x65, 0x4e, 0x0d,
+	0x8a, 0xb1, 0x0e, 0x83, 0x3a, 0x0c, 0x41, 0xef, 0xc0, 0x96, 0x1d, 0x86, 0xfe, 0xa9, 0x50, 0x27,
+	0x91, 0x0e, 0xdc, 0x68, 0x95, 0x0b, 0x3b, 0x42, 0x66, 0xfe, 0xa6, 0xc0, 0x76, 0x7c, 0xe5, 0xb2,
+	0x83, 0x9a, 0x50, 0x58, 0xb5, 0xb4, 0xd2, 0xac, 0xb4, 0xb6, 0x57, 0xb5, 0xcd, 0xa5, 0x07, 0x19,
+	0x2c, 0x71, 0x64, 0x40, 0xf1, 0xd4, 0x0e, 0x3d, 0xc7, 0x9b, 0x89, 0xf6, 0x3d, 0xc8, 0xe0, 0x58,
+	0x80, 0x6e, 0x42, 0x9e, 0xfb, 0xe5, 0xcd, 0x53, 0x69, 0xed, 0xa4, 0x8d, 0x70, 0xf7, 0x07, 0x19,
+	0x2c, 0x74, 0xd0, 0xdb, 0x50, 0x49, 0x84, 0xca, 0xaf, 0xbb, 0x7a, 0x90, 0xc1, 0xb0, 0x0e, 0xb5,
+	0x53, 0x82, 0x42, 0x48, 0xa2, 0xc5, 0x9c, 0x9a, 0x9f, 0x40, 0x25, 0x61, 0x04, 0xdd, 0x4a, 0x84,
+	0xab, 0xbe, 0x1e, 0x6e, 0x5c, 0xe2, 0x42, 0xc7, 0xfc, 0x55, 0x81, 0x4b, 0xbc, 0x06, 0x07, 0xb6,
+	0xbb, 0x2e, 0xf3, 0x8d, 0x65, 0xa1, 0x5c, 0xa0, 0x2c, 0xb2, 0x17, 0x2b, 0x0b, 0xf3, 0x3e, 0xa0,
+	0x64, 0xb4, 0xf2, 0x86, 0x6a, 0x90, 0xf7, 0x6c, 0x57, 0x66, 0x5c, 0xc6, 0x62, 0x83, 0x0c, 0x28,
+	0x49, 0xf2, 0x23, 0x3d, 0xcb, 0x81, 0xd5, 0xde, 0xfc, 0x5d, 0x91, 0x86, 0x1e, 0xd9, 0xf3, 0xc5,
+	0x3a, 0xef, 0x1a, 0xe4, 0x79, 0xeb, 0xf3, 0x1c, 0xcb, 0x58, 0x6c, 0x36, 0xb3, 0x91, 0xbd, 0x00,
+	0x1b, 0xea, 0x05, 0xd9, 0xe8, 0xc3, 0x4e, 0x2a, 0x09, 0x49, 0xc7, 0x15, 0x28, 0x7c, 0xcf, 0x25,
+	0x92, 0x0f, 0xb9, 0xdb, 0x48, 0xc8, 0x0e, 0x5c, 0x1a, 0x0d, 0xef, 0x75, 0x86, 0xd4, 0xa6, 0x8b,
+	0x98, 0x0e, 0xf3, 0x2e, 0x94, 0x99, 0xc0, 0x89, 0xa8, 0x33, 0x65, 0xd3, 0x97, 0xf1, 0x2a, 0xa9,
+	0xe1, 0x6b, 0xc6, 0x17, 0xb7, 0xcd, 0x59, 0xc8, 0x61, 0xb1, 0x31, 0xff, 0x55, 0x01, 0x25, 0x8d,
+	0xc9, 0xb0, 0xae, 0x03, 0x78, 0x0b, 0xd7, 0x4a, 0xf4, 0x52, 0x0e, 0x97, 0xbd, 0x85, 0x2b, 0xea,
+	0x32, 0x86, 0x65, 0xc3, 0x67, 0x57, 0xb0, 0xec, 0xf7, 0xe4, 0xe4, 0x55, 0xdf, 0x3c, 0x79, 0x73,
+	0xe9, 0xc9, 0x3b, 0x86, 0x5d, 0xe1, 0xcf, 0x9a, 0xfa, 0x0b, 0x8f, 0x5a, 0x93, 0xa5, 0xe5, 0x12,
+	0x1a, 0x3a, 0x53, 0x8b, 0xe7, 0x22, 0x9e, 0x9e, 0xc4, 0x63, 0x25, 0x93, 0x95, 0x4d, 0x72, 0x55,
+	0x9c, 0xed, 0xb2, 0xa3, 0x9d, 0xe5, 0x21, 0x3f, 0xc8, 0x2a, 0x0f, 0x7d, 0x0d, 0x7b, 0xe2, 0x01,
+	0xe3, 0x09, 0xaf, 0x6d, 0x0b, 0x21, 0x37, 0x5d, 0xd8, 0x6c, 0xda, 0x98, 0xaf, 0x2e, 0x4e, 0x9a,
+	0x5f, 0xd5, 0x35, 0x7a, 0x0c, 0xd7, 0x5d, 0xe2, 0xfa, 0xe1, 0xd2, 0x72, 0x3c, 0x31, 0xd5, 0xce,
+	0xd8, 0x2e, 0x6e, 0xb6, 0xad, 0x8b, 0xd3, 0x7d, 0x8f, 0xcf, 0xbd, 0xa4, 0xe5, 0x6f, 0xa0, 0x71,
+	0x96, 0x8e, 0x64, 0x1e, 0x81, 0xed, 0x84, 0x7a, 0x69, 0xb3, 0xf1, 0xdd, 0x14, 0x27, 0xeb, 0xf2,
+	0x7b, 0x68, 0x3b, 0xe1, 0x0d, 0xcc, 0x0a, 0x26, 0x7e, 0xe5, 0x2b, 0x50, 0x1c, 0x0f, 0x3e, 0x1b,
+	0x1c, 0x1d, 0x0f, 0xb4, 0x0c, 0x2a, 0x43, 0xfe, 0xf3, 0x71, 0x0f, 0x7f, 0xa1, 0x29, 0xa8, 0x04,
+	0x39, 0x3c, 0x7e, 0xd0, 0xd3, 0xb2, 0x4c, 0x63, 0xd8, 0xbf, 0xd7, 0xeb, 0xb6, 0xb1, 0xa6, 0x32,
+	0x8d, 0xe1, 0xe8, 0x08, 0xf7, 0xb4, 0x1c, 0x93, 0xe3, 0x5e, 0xb7, 0xd7, 0x7f, 0xd4, 0xd3, 0xf2,
+	0x37, 0xf6, 0xe1, 0xea, 0x1b, 0x1a, 0x83, 0x59, 0x3a, 0x6e, 0x63, 0x69, 0xbe, 0xdd, 0x39, 0xc2,
+	0x23, 0x4d, 0xb9, 0xd1, 0x81, 0x1c, 0x7b, 0xe6, 0x50, 0x11, 0x54, 0xdc, 0x3e, 0x16, 0x58, 0xf7,
+	0x68, 0x3c, 0x18, 0x69, 0x0a, 0x93, 0x0d, 0xc7, 0x87, 0x5a, 0x96, 0x2d, 0x0e, 0xfb, 0x03, 0x4d,
+	0xe5, 0x8b, 0xf6, 0x63, 0xe1, 0x93, 0x6b, 0xf5, 0xb0, 0x96, 0x6f, 0xfd, 0x98, 0x85, 0x3c, 0x4f,
+	0x04, 0xbd, 0x07, 0x39, 0xf6, 0x9d, 0x42, 0xab, 0x79, 0x9d, 0xf8, 0x6c, 0x19, 0xb5, 0xb4, 0x50,
+	0xd6, 0xf9, 0x47, 0x50, 0x90, 0x25, 0x7d, 0x39, 0x3d, 0x7a, 0xe3, 0x63, 0x57, 0xce, 0x8a, 0xc5,
+	0xc1, 0x3b, 0x0a, 0xea, 0x02, 0xac, 0xc7, 0x1b, 0xba, 0x96, 0xfa, 0x24, 0x24, 0x07, 0xb4, 0x61,
+	0x9c, 0x07, 0x49, 0xff, 0xf7, 0xa1, 0x92, 0x98, 0x0a, 0x28, 0xad, 0x9a, 0x9a, 0x77, 0xc6, 0xee,
+	0xb9, 0x98, 0xb0, 0xd3, 0x1a, 0xc0, 0x36, 0xff, 0xde, 0xb2, 0x41, 0x26, 0xc8, 0xf8, 0x14, 0x2a,
+	0x98, 0xb8, 0x3e, 0x25, 0x5c, 0x8e, 0x56, 0xe9, 0x27, 0x7f, 0xc1, 0xc6, 0xe5, 0x33, 0x52, 0xf9,
+	0x5b, 0xce, 0xb4, 0x0e, 0xa1, 0x20, 0x26, 0x02, 0x4b, 0x73, 0x3d, 0x1f, 0xd6, 0x69, 0xbe, 0x36,
+	0x80, 0x0c, 0xe3, 0x3c, 0x48, 0x3e, 0xec, 0xef, 0x3e, 0xff, 0xbb, 0x9e, 0x79, 0xfe, 0xb2, 0xae,
+	0xbc, 0x78, 0x59, 0x57, 0xfe, 0x7a, 0x59, 0x57, 0x7e, 0x7e, 0x55, 0xcf, 0xbc, 0x78, 0x55, 0xcf,
+	0xfc, 0xf1, 0xaa, 0x9e, 0xf9, 0xb2, 0xc8, 0x7f, 0x9b, 0xc1, 0x64, 0x52, 0xe0, 0xbf, 0xf7, 0xf7,
+	0xff, 0x1b, 0x00, 0x58, 0xcc, 0xb9, 0x05, 0x09, 0x0c, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1008,6 +1231,80 @@ var _WriteableStore_serviceDesc = grpc.ServiceDesc{
 	Metadata: "rpc.proto",
 }
 
+// StatusClient is the client API for Status service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type StatusClient interface {
+	/// TSDBStatus returns cardinality statistics of the head block of the TSDB.
+	TSDBStatus(ctx context.Context, in *TSDBStatusRequest, opts ...grpc.CallOption) (*TSDBStatusResponse, error)
+}
+
+type statusClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStatusClient(cc *grpc.ClientConn) StatusClient {
+	return &statusClient{cc}
+}
+
+func (c *statusClient) TSDBStatus(ctx context.Context, in *TSDBStatusRequest, opts ...grpc.CallOption) (*TSDBStatusResponse, error) {
+	out := new(TSDBStatusResponse)
+	err := c.cc.Invoke(ctx, "/thanos.Status/TSDBStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StatusServer is the server API for Status service.
+type StatusServer interface {
+	/// TSDBStatus returns cardinality statistics of the head block of the TSDB.
+	TSDBStatus(context.Context, *TSDBStatusRequest) (*TSDBStatusResponse, error)
+}
+
+// UnimplementedStatusServer can be embedded to have forward compatible implementations.
+type UnimplementedStatusServer struct {
+}
+
+func (*UnimplementedStatusServer) TSDBStatus(ctx context.Context, req *TSDBStatusRequest) (*TSDBStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TSDBStatus not implemented")
+}
+
+func RegisterStatusServer(s *grpc.Server, srv StatusServer) {
+	s.RegisterService(&_Status_serviceDesc, srv)
+}
+
+func _Status_TSDBStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TSDBStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServer).TSDBStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/thanos.Status/TSDBStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServer).TSDBStatus(ctx, req.(*TSDBStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Status_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "thanos.Status",
+	HandlerType: (*StatusServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "TSDBStatus",
+			Handler:    _Status_TSDBStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rpc.proto",
+}
+
 func (m *WriteResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1123,6 +1420,22 @@ func (m *InfoResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Capabilities) > 0 {
+		for iNdEx := len(m.Capabilities) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Capabilities[iNdEx])
+			copy(dAtA[i:], m.Capabilities[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Capabilities[iNdEx])))
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if len(m.Zone) > 0 {
+		i -= len(m.Zone)
+		copy(dAtA[i:], m.Zone)
+		i = encodeVarintRpc(dAtA, i, uint64(len(m.Zone)))
+		i--
+		dAtA[i] = 0x32
+	}
 	if len(m.LabelSets) > 0 {
 		for iNdEx := len(m.LabelSets) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1226,6 +1539,21 @@ func (m *SeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ArrowBatches {
+		i--
+		if m.ArrowBatches {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.ResponseBatchBytes != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.ResponseBatchBytes))
+		i--
+		dAtA[i] = 0x48
+	}
 	if m.SkipChunks {
 		i--
 		if m.SkipChunks {
@@ -1368,6 +1696,80 @@ func (m *SeriesResponse_Warning) MarshalToSizedBuffer(dAtA []byte) (int, error)
 	dAtA[i] = 0x12
 	return len(dAtA) - i, nil
 }
+func (m *SeriesResponse_Batch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SeriesResponse_Batch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Batch != nil {
+		{
+			size, err := m.Batch.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *SeriesResponse_ArrowBatch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SeriesResponse_ArrowBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.ArrowBatch != nil {
+		i -= len(m.ArrowBatch)
+		copy(dAtA[i:], m.ArrowBatch)
+		i = encodeVarintRpc(dAtA, i, uint64(len(m.ArrowBatch)))
+		i--
+		dAtA[i] = 0x22
+	}
+	return len(dAtA) - i, nil
+}
+func (m *SeriesBatch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SeriesBatch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SeriesBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Series) > 0 {
+		for iNdEx := len(m.Series) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Series[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *LabelNamesRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1533,34 +1935,191 @@ func (m *LabelValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintRpc(dAtA []byte, offset int, v uint64) int {
-	offset -= sovRpc(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *TSDBStatusRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *WriteResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *TSDBStatusRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TSDBStatusRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *WriteRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *Statistic) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if len(m.Timeseries) > 0 {
-		for _, e := range m.Timeseries {
+	return dAtA[:n], nil
+}
+
+func (m *Statistic) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Statistic) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Value != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.Value))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintRpc(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TSDBStatusResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TSDBStatusResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TSDBStatusResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.SeriesCountByLabelValuePair) > 0 {
+		for iNdEx := len(m.SeriesCountByLabelValuePair) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SeriesCountByLabelValuePair[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x42
+		}
+	}
+	if len(m.MemoryInBytesByLabelName) > 0 {
+		for iNdEx := len(m.MemoryInBytesByLabelName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.MemoryInBytesByLabelName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if len(m.LabelValueCountByLabelName) > 0 {
+		for iNdEx := len(m.LabelValueCountByLabelName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.LabelValueCountByLabelName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if len(m.SeriesCountByMetricName) > 0 {
+		for iNdEx := len(m.SeriesCountByMetricName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SeriesCountByMetricName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if m.MaxTime != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.MaxTime))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.MinTime != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.MinTime))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.NumChunks != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.NumChunks))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.NumSeries != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.NumSeries))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintRpc(dAtA []byte, offset int, v uint64) int {
+	offset -= sovRpc(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *WriteResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *WriteRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Timeseries) > 0 {
+		for _, e := range m.Timeseries {
 			l = e.Size()
 			n += 1 + l + sovRpc(uint64(l))
 		}
@@ -1611,6 +2170,16 @@ func (m *InfoResponse) Size() (n int) {
 			n += 1 + l + sovRpc(uint64(l))
 		}
 	}
+	l = len(m.Zone)
+	if l > 0 {
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if len(m.Capabilities) > 0 {
+		for _, s := range m.Capabilities {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -1666,6 +2235,12 @@ func (m *SeriesRequest) Size() (n int) {
 	if m.SkipChunks {
 		n += 2
 	}
+	if m.ResponseBatchBytes != 0 {
+		n += 1 + sovRpc(uint64(m.ResponseBatchBytes))
+	}
+	if m.ArrowBatches {
+		n += 2
+	}
 	return n
 }
 
@@ -1703,6 +2278,45 @@ func (m *SeriesResponse_Warning) Size() (n int) {
 	n += 1 + l + sovRpc(uint64(l))
 	return n
 }
+func (m *SeriesResponse_Batch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Batch != nil {
+		l = m.Batch.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_ArrowBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ArrowBatch != nil {
+		l = len(m.ArrowBatch)
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Series) > 0 {
+		for _, e := range m.Series {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
 func (m *LabelNamesRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -1779,6 +2393,76 @@ func (m *LabelValuesResponse) Size() (n int) {
 	return n
 }
 
+func (m *TSDBStatusRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *Statistic) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if m.Value != 0 {
+		n += 1 + sovRpc(uint64(m.Value))
+	}
+	return n
+}
+
+func (m *TSDBStatusResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.NumSeries != 0 {
+		n += 1 + sovRpc(uint64(m.NumSeries))
+	}
+	if m.NumChunks != 0 {
+		n += 1 + sovRpc(uint64(m.NumChunks))
+	}
+	if m.MinTime != 0 {
+		n += 1 + sovRpc(uint64(m.MinTime))
+	}
+	if m.MaxTime != 0 {
+		n += 1 + sovRpc(uint64(m.MaxTime))
+	}
+	if len(m.SeriesCountByMetricName) > 0 {
+		for _, e := range m.SeriesCountByMetricName {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if len(m.LabelValueCountByLabelName) > 0 {
+		for _, e := range m.LabelValueCountByLabelName {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if len(m.MemoryInBytesByLabelName) > 0 {
+		for _, e := range m.MemoryInBytesByLabelName {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if len(m.SeriesCountByLabelValuePair) > 0 {
+		for _, e := range m.SeriesCountByLabelValuePair {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
 func sovRpc(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -2183,30 +2867,94 @@ func (m *InfoResponse) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipRpc(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Zone", wireType)
 			}
-			if skippy < 0 {
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthRpc
 			}
-			if (iNdEx + skippy) < 0 {
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
 				return ErrInvalidLengthRpc
 			}
-			if (iNdEx + skippy) > l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
+			m.Zone = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Capabilities", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Capabilities = append(m.Capabilities, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *LabelSet) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -2542,6 +3290,45 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.SkipChunks = bool(v != 0)
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResponseBatchBytes", wireType)
+			}
+			m.ResponseBatchBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ResponseBatchBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ArrowBatches", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ArrowBatches = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
@@ -2662,6 +3449,161 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 			}
 			m.Result = &SeriesResponse_Warning{string(dAtA[iNdEx:postIndex])}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Batch", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &SeriesBatch{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Batch{v}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ArrowBatch", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := make([]byte, postIndex-iNdEx)
+			copy(v, dAtA[iNdEx:postIndex])
+			m.Result = &SeriesResponse_ArrowBatch{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SeriesBatch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SeriesBatch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SeriesBatch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Series = append(m.Series, Series{})
+			if err := m.Series[len(m.Series)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
@@ -3136,6 +4078,428 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *TSDBStatusRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TSDBStatusRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TSDBStatusRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Statistic) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Statistic: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Statistic: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			m.Value = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Value |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TSDBStatusResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TSDBStatusResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TSDBStatusResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumSeries", wireType)
+			}
+			m.NumSeries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumSeries |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NumChunks", wireType)
+			}
+			m.NumChunks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NumChunks |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
+			}
+			m.MinTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+			}
+			m.MaxTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCountByMetricName", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SeriesCountByMetricName = append(m.SeriesCountByMetricName, Statistic{})
+			if err := m.SeriesCountByMetricName[len(m.SeriesCountByMetricName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelValueCountByLabelName", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LabelValueCountByLabelName = append(m.LabelValueCountByLabelName, Statistic{})
+			if err := m.LabelValueCountByLabelName[len(m.LabelValueCountByLabelName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MemoryInBytesByLabelName", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MemoryInBytesByLabelName = append(m.MemoryInBytesByLabelName, Statistic{})
+			if err := m.MemoryInBytesByLabelName[len(m.MemoryInBytesByLabelName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCountByLabelValuePair", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SeriesCountByLabelValuePair = append(m.SeriesCountByLabelValuePair, Statistic{})
+			if err := m.SeriesCountByLabelValuePair[len(m.SeriesCountByLabelValuePair)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipRpc(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
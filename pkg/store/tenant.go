@@ -0,0 +1,252 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package store
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/strutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TenantStore is a StoreServer serving the series of a tenant only, e.g. as a virtual StoreAPI endpoint of the
+// tenant exposed by a querier. Series of the tenant are selected from the underlying store by the matchers of the
+// tenant, and are served with the external labels of the tenant, so upstream queriers can tell tenants apart by their
+// label sets.
+type TenantStore struct {
+	store          storepb.StoreServer
+	externalLabels labels.Labels
+	matchers       []storepb.LabelMatcher
+	arrowSeries    bool
+}
+
+// NewTenantStore returns a TenantStore serving series of the store selected by matchers, with the given external
+// labels. All series of the store are served if no matchers are given. If arrowSeries is true, series of raw data are
+// sent as Arrow batches to clients requesting them.
+func NewTenantStore(store storepb.StoreServer, externalLabels labels.Labels, matchers []storepb.LabelMatcher, arrowSeries bool) *TenantStore {
+	return &TenantStore{
+		store:          store,
+		externalLabels: externalLabels,
+		matchers:       matchers,
+		arrowSeries:    arrowSeries,
+	}
+}
+
+// Info returns the time range of the underlying store and its label sets which may hold series of the tenant,
+// extended by the external labels of the tenant.
+func (s *TenantStore) Info(ctx context.Context, r *storepb.InfoRequest) (*storepb.InfoResponse, error) {
+	res, err := s.store.Info(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	res.Labels = mergeLabels(res.Labels, s.externalLabels)
+
+	labelSets := make(map[uint64][]storepb.Label, len(res.LabelSets))
+	for _, ls := range res.LabelSets {
+		match, err := labelSetMatches(ls, s.matchers)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if !match {
+			continue
+		}
+		merged := mergeLabels(ls.Labels, s.externalLabels)
+		labelSets[storepb.LabelsToPromLabels(merged).Hash()] = merged
+	}
+
+	res.LabelSets = make([]storepb.LabelSet, 0, len(labelSets))
+	for _, v := range labelSets {
+		res.LabelSets = append(res.LabelSets, storepb.LabelSet{Labels: v})
+	}
+	sort.Slice(res.LabelSets, func(i, j int) bool {
+		return labels.Compare(storepb.LabelsToPromLabels(res.LabelSets[i].Labels), storepb.LabelsToPromLabels(res.LabelSets[j].Labels)) < 0
+	})
+	if len(res.LabelSets) == 0 {
+		res.LabelSets = append(res.LabelSets, storepb.LabelSet{Labels: res.Labels})
+	}
+	return res, nil
+}
+
+// Series returns the series of the tenant matching the request.
+func (s *TenantStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	match, newMatchers, err := matchesExternalLabels(r.Matchers, s.externalLabels)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !match {
+		return nil
+	}
+
+	if len(newMatchers) == 0 {
+		return status.Error(codes.InvalidArgument, errors.New("no matchers specified (excluding external labels)").Error())
+	}
+
+	// Series are requested one by one, to extend their labels, and batched as requested by the client afterwards.
+	req := *r
+	req.Matchers = append(append(make([]storepb.LabelMatcher, 0, len(newMatchers)+len(s.matchers)), newMatchers...), s.matchers...)
+	req.ResponseBatchBytes = 0
+	req.ArrowBatches = false
+
+	batchSrv := newSeriesFlusher(srv, r, s.arrowSeries)
+	if err := s.store.Series(&req, &tenantSeriesServer{Store_SeriesServer: batchSrv, externalLabels: s.externalLabels}); err != nil {
+		return err
+	}
+	if err := batchSrv.Flush(); err != nil {
+		return status.Error(codes.Unknown, errors.Wrap(err, "send series response").Error())
+	}
+	return nil
+}
+
+// LabelNames returns the label names of series of the tenant, including the external labels.
+func (s *TenantStore) LabelNames(ctx context.Context, r *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error) {
+	var res *storepb.LabelNamesResponse
+	if len(s.matchers) == 0 {
+		resp, err := s.store.LabelNames(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		res = &storepb.LabelNamesResponse{Names: resp.Names, Warnings: resp.Warnings}
+	} else {
+		lsets, warnings, err := s.seriesLabels(ctx, r.PartialResponseDisabled)
+		if err != nil {
+			return nil, err
+		}
+		res = &storepb.LabelNamesResponse{Warnings: warnings}
+		names := map[string]struct{}{}
+		for _, lset := range lsets {
+			for _, l := range lset {
+				names[l.Name] = struct{}{}
+			}
+		}
+		for n := range names {
+			res.Names = append(res.Names, n)
+		}
+	}
+
+	extNames := make([]string, 0, len(s.externalLabels))
+	for _, l := range s.externalLabels {
+		extNames = append(extNames, l.Name)
+	}
+	res.Names = strutil.MergeUnsortedSlices(res.Names, extNames)
+	return res, nil
+}
+
+// LabelValues returns the values of the label of series of the tenant. The value of external labels is the one of
+// the tenant.
+func (s *TenantStore) LabelValues(ctx context.Context, r *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
+	if v := s.externalLabels.Get(r.Label); v != "" {
+		return &storepb.LabelValuesResponse{Values: []string{v}}, nil
+	}
+	if len(s.matchers) == 0 {
+		return s.store.LabelValues(ctx, r)
+	}
+
+	lsets, warnings, err := s.seriesLabels(ctx, r.PartialResponseDisabled)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]struct{}{}
+	for _, lset := range lsets {
+		if v := storepb.LabelsToPromLabels(lset).Get(r.Label); v != "" {
+			values[v] = struct{}{}
+		}
+	}
+	res := &storepb.LabelValuesResponse{Values: make([]string, 0, len(values)), Warnings: warnings}
+	for v := range values {
+		res.Values = append(res.Values, v)
+	}
+	sort.Strings(res.Values)
+	return res, nil
+}
+
+// seriesLabels returns the labels of all series of the tenant, as label APIs of the underlying store cannot be
+// limited to the series selected by the matchers of the tenant.
+func (s *TenantStore) seriesLabels(ctx context.Context, partialResponseDisabled bool) ([][]storepb.Label, []string, error) {
+	srv := &labelsSeriesServer{ctx: ctx}
+	if err := s.store.Series(&storepb.SeriesRequest{
+		MinTime:                 math.MinInt64,
+		MaxTime:                 math.MaxInt64,
+		Matchers:                s.matchers,
+		SkipChunks:              true,
+		PartialResponseDisabled: partialResponseDisabled,
+	}, srv); err != nil {
+		return nil, nil, err
+	}
+	return srv.lsets, srv.warnings, nil
+}
+
+// tenantSeriesServer extends the labels of series sent to the Series server by the external labels of the tenant.
+type tenantSeriesServer struct {
+	storepb.Store_SeriesServer
+
+	externalLabels labels.Labels
+}
+
+func (s *tenantSeriesServer) Send(r *storepb.SeriesResponse) error {
+	if series := r.GetSeries(); series != nil {
+		series.Labels = extendLabels(series.Labels, s.externalLabels)
+	}
+	if b := r.GetBatch(); b != nil {
+		for i := range b.Series {
+			b.Series[i].Labels = extendLabels(b.Series[i].Labels, s.externalLabels)
+		}
+	}
+	return s.Store_SeriesServer.Send(r)
+}
+
+// labelsSeriesServer is an in-process Series server keeping the labels of sent series.
+type labelsSeriesServer struct {
+	// This field just exist to pseudo-implement the unused methods of the interface.
+	storepb.Store_SeriesServer
+	ctx context.Context
+
+	lsets    [][]storepb.Label
+	warnings []string
+}
+
+func (s *labelsSeriesServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *labelsSeriesServer) Send(r *storepb.SeriesResponse) error {
+	if w := r.GetWarning(); w != "" {
+		s.warnings = append(s.warnings, w)
+		return nil
+	}
+	if series := r.GetSeries(); series != nil {
+		s.lsets = append(s.lsets, series.Labels)
+		return nil
+	}
+	if b := r.GetBatch(); b != nil {
+		for _, series := range b.Series {
+			s.lsets = append(s.lsets, series.Labels)
+		}
+		return nil
+	}
+	return errors.New("unexpected series response")
+}
+
+// extendLabels attaches the given labels to the label set, overwriting existing ones on collision.
+func extendLabels(lset []storepb.Label, extend labels.Labels) []storepb.Label {
+	res := make([]storepb.Label, 0, len(lset)+len(extend))
+	for _, l := range lset {
+		if extend.Get(l.Name) != "" {
+			continue
+		}
+		res = append(res, l)
+	}
+	for _, l := range extend {
+		res = append(res, storepb.Label{Name: l.Name, Value: l.Value})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
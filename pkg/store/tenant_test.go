@@ -0,0 +1,107 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package store
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestTenantStore(t *testing.T) {
+	ctx := context.Background()
+
+	api := &mockedStoreAPI{
+		RespSeries: []*storepb.SeriesResponse{
+			storeSeriesResponse(t, labels.FromStrings("__name__", "up", "namespace", "team-a-1"), []sample{{1, 1}}),
+			storeSeriesResponse(t, labels.FromStrings("__name__", "up", "namespace", "team-a-2", "tenant", "other")),
+		},
+		RespLabelNames: &storepb.LabelNamesResponse{Names: []string{"namespace"}},
+	}
+	proxy := NewProxyStore(nil, nil, func() []Client {
+		return []Client{
+			&testClient{
+				StoreClient: api,
+				labelSets:   []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "a"}}}},
+				minTime:     1,
+				maxTime:     300,
+			},
+			&testClient{
+				StoreClient: api,
+				labelSets:   []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "b"}}}},
+				minTime:     1,
+				maxTime:     300,
+			},
+		}
+	}, component.Query, nil, 0*time.Second, 0, 0, false, nil)
+
+	matchers := []storepb.LabelMatcher{
+		{Name: "namespace", Value: "team-a-.*", Type: storepb.LabelMatcher_RE},
+		{Name: "cluster", Value: "a", Type: storepb.LabelMatcher_EQ},
+	}
+	s := NewTenantStore(proxy, labels.FromStrings("tenant", "team-a"), matchers, false)
+
+	// Only label sets which may hold series of the tenant are advertised, with the external labels of the tenant.
+	info, err := s.Info(ctx, &storepb.InfoRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []storepb.Label{{Name: "tenant", Value: "team-a"}}, info.Labels)
+	testutil.Equals(t, []storepb.LabelSet{
+		{Labels: []storepb.Label{{Name: "cluster", Value: "a"}, {Name: "tenant", Value: "team-a"}}},
+	}, info.LabelSets)
+	testutil.Equals(t, int64(1), info.MinTime)
+	testutil.Equals(t, int64(300), info.MaxTime)
+
+	// Series are selected by the matchers of the tenant instead of its external labels, and extended by them.
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, s.Series(&storepb.SeriesRequest{
+		MinTime: 1,
+		MaxTime: 300,
+		Matchers: []storepb.LabelMatcher{
+			{Name: "__name__", Value: "up", Type: storepb.LabelMatcher_EQ},
+			{Name: "tenant", Value: "team-a", Type: storepb.LabelMatcher_EQ},
+		},
+	}, srv))
+	testutil.Equals(t, append([]storepb.LabelMatcher{{Name: "__name__", Value: "up", Type: storepb.LabelMatcher_EQ}}, matchers...), api.LastSeriesReq.Matchers)
+	testutil.Equals(t, 2, len(srv.SeriesSet))
+	testutil.Equals(t, []storepb.Label{{Name: "__name__", Value: "up"}, {Name: "namespace", Value: "team-a-1"}, {Name: "tenant", Value: "team-a"}}, srv.SeriesSet[0].Labels)
+	testutil.Equals(t, []storepb.Label{{Name: "__name__", Value: "up"}, {Name: "namespace", Value: "team-a-2"}, {Name: "tenant", Value: "team-a"}}, srv.SeriesSet[1].Labels)
+
+	// Series of other tenants are not requested.
+	api.LastSeriesReq = nil
+	srv = newStoreSeriesServer(ctx)
+	testutil.Ok(t, s.Series(&storepb.SeriesRequest{
+		MinTime:  1,
+		MaxTime:  300,
+		Matchers: []storepb.LabelMatcher{{Name: "tenant", Value: "team-b", Type: storepb.LabelMatcher_EQ}},
+	}, srv))
+	testutil.Equals(t, 0, len(srv.SeriesSet))
+	testutil.Assert(t, api.LastSeriesReq == nil, "expected no series request")
+
+	// Labels are those of the series of the tenant.
+	names, err := s.LabelNames(ctx, &storepb.LabelNamesRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"__name__", "namespace", "tenant"}, names.Names)
+	testutil.Equals(t, true, api.LastSeriesReq.SkipChunks)
+	testutil.Equals(t, int64(math.MinInt64), api.LastSeriesReq.MinTime)
+
+	values, err := s.LabelValues(ctx, &storepb.LabelValuesRequest{Label: "namespace"})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"team-a-1", "team-a-2"}, values.Values)
+
+	values, err = s.LabelValues(ctx, &storepb.LabelValuesRequest{Label: "tenant"})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"team-a"}, values.Values)
+
+	// Label APIs of the store are used if the tenant has no matchers.
+	s = NewTenantStore(proxy, labels.FromStrings("tenant", "team-a"), nil, false)
+	names, err = s.LabelNames(ctx, &storepb.LabelNamesRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"namespace", "tenant"}, names.Names)
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/thanos-io/thanos/pkg/component"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
@@ -111,12 +113,11 @@ func (s *TSDBStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSer
 		return status.Error(codes.Internal, err.Error())
 	}
 
-	var respSeries storepb.Series
-
 	for set.Next() {
 		series := set.At()
 
-		respSeries.Labels = s.translateAndExtendLabels(series.Labels(), s.externalLabels)
+		// Responses are not reused, as in-process receivers may retain them without marshaling.
+		respSeries := storepb.Series{Labels: s.translateAndExtendLabels(series.Labels(), s.externalLabels)}
 
 		if !r.SkipChunks {
 			// TODO(fabxc): An improvement over this trivial approach would be to directly
@@ -131,7 +132,7 @@ func (s *TSDBStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSer
 				return status.Errorf(codes.Internal, "encode chunk: %s", err)
 			}
 
-			respSeries.Chunks = append(respSeries.Chunks[:0], c...)
+			respSeries.Chunks = c
 		}
 
 		if err := srv.Send(storepb.NewSeriesResponse(&respSeries)); err != nil {
@@ -242,3 +243,59 @@ func (s *TSDBStore) LabelValues(ctx context.Context, r *storepb.LabelValuesReque
 	}
 	return &storepb.LabelValuesResponse{Values: res}, nil
 }
+
+// TSDBStatus returns cardinality statistics of the head block of the TSDB.
+func (s *TSDBStore) TSDBStatus(ctx context.Context, _ *storepb.TSDBStatusRequest) (*storepb.TSDBStatusResponse, error) {
+	head := s.db.Head()
+	numChunks, err := headChunks(head)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	stats := head.PostingsCardinalityStats(labels.MetricName)
+	return &storepb.TSDBStatusResponse{
+		NumSeries:                   head.NumSeries(),
+		NumChunks:                   numChunks,
+		MinTime:                     head.MinTime(),
+		MaxTime:                     head.MaxTime(),
+		SeriesCountByMetricName:     statistics(stats.CardinalityMetricsStats),
+		LabelValueCountByLabelName:  statistics(stats.CardinalityLabelStats),
+		MemoryInBytesByLabelName:    statistics(stats.LabelValueStats),
+		SeriesCountByLabelValuePair: statistics(stats.LabelValuePairsStats),
+	}, nil
+}
+
+// headChunks returns the number of chunks in the head block. The head does not expose it, so chunks of all series are
+// counted.
+func headChunks(head *tsdb.Head) (_ uint64, err error) {
+	ir, err := head.Index()
+	if err != nil {
+		return 0, errors.Wrap(err, "head index")
+	}
+	defer runutil.CloseWithErrCapture(&err, ir, "head index reader")
+
+	p, err := ir.Postings(index.AllPostingsKey())
+	if err != nil {
+		return 0, errors.Wrap(err, "all postings")
+	}
+	var (
+		n    uint64
+		lset labels.Labels
+		chks []chunks.Meta
+	)
+	for p.Next() {
+		if err := ir.Series(p.At(), &lset, &chks); err != nil {
+			return 0, errors.Wrap(err, "series")
+		}
+		n += uint64(len(chks))
+	}
+	return n, errors.Wrap(p.Err(), "iterate postings")
+}
+
+func statistics(stats []index.Stat) []storepb.Statistic {
+	res := make([]storepb.Statistic, 0, len(stats))
+	for _, s := range stats {
+		res = append(res, storepb.Statistic{Name: s.Name, Value: s.Count})
+	}
+	return res
+}
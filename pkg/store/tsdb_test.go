@@ -276,6 +276,41 @@ func TestTSDBStore_LabelValues(t *testing.T) {
 	}
 }
 
+func TestTSDBStore_TSDBStatus(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := e2eutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	appender := db.Appender()
+	for _, lset := range []labels.Labels{
+		labels.FromStrings("__name__", "up", "job", "a"),
+		labels.FromStrings("__name__", "up", "job", "b"),
+		labels.FromStrings("__name__", "requests_total", "job", "a"),
+	} {
+		for i := int64(1); i <= 3; i++ {
+			_, err = appender.Add(lset, i*1000, 1)
+			testutil.Ok(t, err)
+		}
+	}
+	testutil.Ok(t, appender.Commit())
+
+	tsdbStore := NewTSDBStore(nil, nil, db, component.Receive, labels.FromStrings("region", "eu-west"))
+	resp, err := tsdbStore.TSDBStatus(ctx, &storepb.TSDBStatusRequest{})
+	testutil.Ok(t, err)
+
+	testutil.Equals(t, uint64(3), resp.NumSeries)
+	testutil.Equals(t, uint64(3), resp.NumChunks)
+	testutil.Equals(t, int64(1000), resp.MinTime)
+	testutil.Equals(t, int64(3000), resp.MaxTime)
+	testutil.Equals(t, []storepb.Statistic{{Name: "up", Value: 2}, {Name: "requests_total", Value: 1}}, resp.SeriesCountByMetricName)
+	testutil.Equals(t, 4, len(resp.SeriesCountByLabelValuePair))
+}
+
 // Regression test for https://github.com/thanos-io/thanos/issues/1038.
 func TestTSDBStore_Series_SplitSamplesIntoChunksWithMaxSizeOfUint16_e2e(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
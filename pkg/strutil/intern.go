@@ -0,0 +1,148 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package strutil
+
+import (
+	"sync"
+
+	"github.com/cespare/xxhash"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	internShards = 32
+	// MaxInternLen is the maximum length of interned strings. Longer strings are rarely equal, e.g. label values
+	// containing IDs or messages, so they are not interned.
+	MaxInternLen = 256
+)
+
+// Interner is a bounded table of interned strings: it returns the same string for all equal strings interned, so
+// e.g. label names and values repeated by many series and requests share memory instead of each being allocated.
+// It is safe for concurrent use.
+//
+// Strings are kept in two generations. Interned strings are added to or moved into the current one, and each
+// eviction drops the previous generation and starts a new one. Thus strings not interned since the last but one
+// eviction are evicted. Generations are also started when the current one is full, so at most maxSize strings are
+// held.
+type Interner struct {
+	genSize int
+	shards  [internShards]internShard
+}
+
+type internShard struct {
+	mtx          sync.Mutex
+	curr, prev   map[string]string
+	hits, misses uint64
+}
+
+// NewInterner returns an interner of at most maxSize strings, with metrics registered in the given registerer.
+func NewInterner(reg prometheus.Registerer, maxSize int) *Interner {
+	genSize := maxSize / (2 * internShards)
+	if genSize < 1 {
+		genSize = 1
+	}
+	i := &Interner{genSize: genSize}
+	for s := range i.shards {
+		i.shards[s].curr = make(map[string]string, genSize)
+		i.shards[s].prev = map[string]string{}
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "thanos_intern_strings",
+		Help: "Number of strings held by the string interner.",
+	}, func() float64 {
+		return float64(i.sum(func(s *internShard) uint64 { return uint64(len(s.curr) + len(s.prev)) }))
+	})
+	for _, result := range []string{"hit", "miss"} {
+		hit := result == "hit"
+		promauto.With(reg).NewCounterFunc(prometheus.CounterOpts{
+			Name:        "thanos_intern_lookups_total",
+			Help:        "Total number of strings interned by the string interner, by whether they were held already.",
+			ConstLabels: prometheus.Labels{"result": result},
+		}, func() float64 {
+			return float64(i.sum(func(s *internShard) uint64 {
+				if hit {
+					return s.hits
+				}
+				return s.misses
+			}))
+		})
+	}
+	return i
+}
+
+func (i *Interner) sum(f func(*internShard) uint64) (sum uint64) {
+	for s := range i.shards {
+		i.shards[s].mtx.Lock()
+		sum += f(&i.shards[s])
+		i.shards[s].mtx.Unlock()
+	}
+	return sum
+}
+
+// Intern returns the interned string equal to s. A nil interner returns s.
+func (i *Interner) Intern(s string) string {
+	if i == nil || len(s) > MaxInternLen {
+		return s
+	}
+	return i.shards[xxhash.Sum64String(s)%internShards].intern(s, i.genSize)
+}
+
+// InternBytes returns the interned string equal to b, without allocating if it is interned already. A nil interner
+// returns b copied.
+func (i *Interner) InternBytes(b []byte) string {
+	if i == nil || len(b) > MaxInternLen {
+		return string(b)
+	}
+	sh := &i.shards[xxhash.Sum64(b)%internShards]
+	sh.mtx.Lock()
+	// Map lookups by converted byte slices do not allocate.
+	if s, ok := sh.curr[string(b)]; ok {
+		sh.hits++
+		sh.mtx.Unlock()
+		return s
+	}
+	sh.mtx.Unlock()
+	return sh.intern(string(b), i.genSize)
+}
+
+func (s *internShard) intern(str string, genSize int) string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if interned, ok := s.curr[str]; ok {
+		s.hits++
+		return interned
+	}
+	if interned, ok := s.prev[str]; ok {
+		s.hits++
+		str = interned
+	} else {
+		s.misses++
+	}
+	if len(s.curr) >= genSize {
+		s.rotate(genSize)
+	}
+	s.curr[str] = str
+	return str
+}
+
+func (s *internShard) rotate(genSize int) {
+	s.prev = s.curr
+	s.curr = make(map[string]string, genSize)
+}
+
+// Evict evicts strings not interned since the previous eviction. It is meant to be called periodically.
+func (i *Interner) Evict() {
+	if i == nil {
+		return
+	}
+	for s := range i.shards {
+		sh := &i.shards[s]
+		sh.mtx.Lock()
+		sh.rotate(i.genSize)
+		sh.mtx.Unlock()
+	}
+}
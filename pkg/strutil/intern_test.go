@@ -0,0 +1,64 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package strutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func sameString(a, b string) bool {
+	return len(a) == len(b) && (*[2]uintptr)(unsafe.Pointer(&a))[0] == (*[2]uintptr)(unsafe.Pointer(&b))[0]
+}
+
+func TestInterner(t *testing.T) {
+	i := NewInterner(prometheus.NewRegistry(), 1000)
+
+	a := i.Intern(strings.Repeat("a", 3))
+	testutil.Equals(t, "aaa", a)
+	testutil.Assert(t, sameString(a, i.Intern(strings.Repeat("a", 3))), "expected equal strings to be interned once")
+	testutil.Assert(t, sameString(a, i.InternBytes([]byte("aaa"))), "expected equal bytes to be interned as the string")
+
+	long := strings.Repeat("b", MaxInternLen+1)
+	testutil.Assert(t, !sameString(i.Intern(long), i.Intern(strings.Repeat("b", MaxInternLen+1))), "expected long strings not to be interned")
+
+	// Strings interned since the previous eviction survive an eviction.
+	i.Evict()
+	testutil.Assert(t, sameString(a, i.Intern("aaa")), "expected string interned before the eviction to be kept")
+	i.Evict()
+	i.Evict()
+	testutil.Assert(t, !sameString(a, i.Intern(strings.Repeat("a", 3))), "expected unused string to be evicted")
+
+	var nilInterner *Interner
+	testutil.Equals(t, "aaa", nilInterner.Intern("aaa"))
+	testutil.Equals(t, "aaa", nilInterner.InternBytes([]byte("aaa")))
+	nilInterner.Evict()
+}
+
+func TestInterner_MaxSize(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	i := NewInterner(reg, 10*2*internShards)
+
+	for n := 0; n < 10000; n++ {
+		i.Intern(fmt.Sprintf("value-%d", n))
+	}
+	size := i.sum(func(s *internShard) uint64 { return uint64(len(s.curr) + len(s.prev)) })
+	testutil.Assert(t, size <= 10*2*internShards, "expected at most %d strings, got %d", 10*2*internShards, size)
+
+	testutil.Ok(t, promtest.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+		# HELP thanos_intern_lookups_total Total number of strings interned by the string interner, by whether they were held already.
+		# TYPE thanos_intern_lookups_total counter
+		thanos_intern_lookups_total{result="hit"} 0
+		thanos_intern_lookups_total{result="miss"} 10000
+		# HELP thanos_intern_strings Number of strings held by the string interner.
+		# TYPE thanos_intern_strings gauge
+		thanos_intern_strings %d
+	`, size))))
+}
@@ -6,15 +6,17 @@ package tls
 import (
 	"crypto/tls"
 	"crypto/x509"
-	"io/ioutil"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/extprom"
 )
 
-// NewServerConfig provides new server TLS configuration.
-func NewServerConfig(logger log.Logger, cert, key, clientCA string) (*tls.Config, error) {
+// NewServerConfig provides new server TLS configuration. Changed certificate, key and client CA files are reloaded
+// on TLS handshakes, so certificates can be rotated without restart.
+func NewServerConfig(logger log.Logger, reg prometheus.Registerer, cert, key, clientCA string) (*tls.Config, error) {
 	if key == "" && cert == "" {
 		if clientCA != "" {
 			return nil, errors.New("when a client CA is used a server key and certificate must also be provided")
@@ -30,29 +32,22 @@ func NewServerConfig(logger log.Logger, cert, key, clientCA string) (*tls.Config
 		return nil, errors.New("both server key and certificate must be provided")
 	}
 
-	tlsCfg := &tls.Config{
-		MinVersion: tls.VersionTLS12,
-	}
-
-	tlsCert, err := tls.LoadX509KeyPair(cert, key)
+	r, err := newCertReloader(logger, extprom.WrapRegistererWith(prometheus.Labels{"type": "server"}, reg), cert, key, clientCA)
 	if err != nil {
 		return nil, errors.Wrap(err, "server credentials")
 	}
 
-	tlsCfg.Certificates = []tls.Certificate{tlsCert}
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.certificate(), nil
+		},
+	}
 
 	if clientCA != "" {
-		caPEM, err := ioutil.ReadFile(clientCA)
-		if err != nil {
-			return nil, errors.Wrap(err, "reading client CA")
-		}
-
-		certPool := x509.NewCertPool()
-		if !certPool.AppendCertsFromPEM(caPEM) {
-			return nil, errors.Wrap(err, "building client CA")
-		}
-		tlsCfg.ClientCAs = certPool
-		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		// Client certificates are verified against the current client CA pool, instead of a static one set in ClientCAs.
+		tlsCfg.ClientAuth = tls.RequireAnyClientCert
+		tlsCfg.VerifyPeerCertificate = r.verifyClientCertificate
 
 		level.Info(logger).Log("msg", "server TLS client verification enabled")
 	}
@@ -60,19 +55,25 @@ func NewServerConfig(logger log.Logger, cert, key, clientCA string) (*tls.Config
 	return tlsCfg, nil
 }
 
-// NewClientConfig provides new client TLS configuration.
-func NewClientConfig(logger log.Logger, cert, key, caCert, serverName string) (*tls.Config, error) {
-	var certPool *x509.CertPool
-	if caCert != "" {
-		caPEM, err := ioutil.ReadFile(caCert)
+// NewClientConfig provides new client TLS configuration. Changed client certificate and key files are reloaded on
+// TLS handshakes, so certificates can be rotated without restart. The CA is loaded once.
+func NewClientConfig(logger log.Logger, reg prometheus.Registerer, cert, key, caCert, serverName string) (*tls.Config, error) {
+	if (key != "") != (cert != "") {
+		return nil, errors.New("both client key and certificate must be provided")
+	}
+
+	var r *certReloader
+	if cert != "" || caCert != "" {
+		var err error
+		r, err = newCertReloader(logger, extprom.WrapRegistererWith(prometheus.Labels{"type": "client"}, reg), cert, key, caCert)
 		if err != nil {
-			return nil, errors.Wrap(err, "reading client CA")
+			return nil, errors.Wrap(err, "client credentials")
 		}
+	}
 
-		certPool = x509.NewCertPool()
-		if !certPool.AppendCertsFromPEM(caPEM) {
-			return nil, errors.Wrap(err, "building client CA")
-		}
+	var certPool *x509.CertPool
+	if caCert != "" {
+		certPool = r.caPool()
 		level.Info(logger).Log("msg", "TLS client using provided certificate pool")
 	} else {
 		var err error
@@ -91,16 +92,10 @@ func NewClientConfig(logger log.Logger, cert, key, caCert, serverName string) (*
 		tlsCfg.ServerName = serverName
 	}
 
-	if (key != "") != (cert != "") {
-		return nil, errors.New("both client key and certificate must be provided")
-	}
-
 	if cert != "" {
-		cert, err := tls.LoadX509KeyPair(cert, key)
-		if err != nil {
-			return nil, errors.Wrap(err, "client credentials")
+		tlsCfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate(), nil
 		}
-		tlsCfg.Certificates = []tls.Certificate{cert}
 		level.Info(logger).Log("msg", "TLS client authentication enabled")
 	}
 	return tlsCfg, nil
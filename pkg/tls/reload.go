@@ -0,0 +1,217 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package tls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// reloadCheckInterval is the minimum interval between checks of certificate files for changes, done on TLS handshakes.
+const reloadCheckInterval = 30 * time.Second
+
+// certReloader holds a certificate and CA pool loaded from files. Files are checked for changes on use, at most
+// every reloadCheckInterval, so rotated certificates are used without restart.
+type certReloader struct {
+	logger                    log.Logger
+	certFile, keyFile, caFile string
+	now                       func() time.Time
+
+	mtx                    sync.Mutex
+	checkedAt              time.Time
+	certPEM, keyPEM, caPEM []byte
+	cert                   *tls.Certificate
+	pool                   *x509.CertPool
+
+	reloads        prometheus.Counter
+	reloadFailures prometheus.Counter
+	expiry         *prometheus.GaugeVec
+}
+
+func newCertReloader(logger log.Logger, reg prometheus.Registerer, certFile, keyFile, caFile string) (*certReloader, error) {
+	r := &certReloader{
+		logger:   logger,
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		now:      time.Now,
+		reloads: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_tls_certificate_reloads_total",
+			Help: "Total number of certificate reloads caused by changed certificate files.",
+		}),
+		reloadFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "thanos_tls_certificate_reload_failures_total",
+			Help: "Total number of failed reloads of changed certificate files. Previously loaded certificates are kept in use.",
+		}),
+		expiry: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "thanos_tls_certificate_expiry_timestamp_seconds",
+			Help: "Unix timestamp of expiry of the loaded certificate. For CA bundles, the earliest expiry of its certificates.",
+		}, []string{"file"}),
+	}
+	r.checkedAt = r.now()
+	if _, err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// certificate returns the current certificate, reloading it if files changed.
+func (r *certReloader) certificate() *tls.Certificate {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.maybeReload()
+	return r.cert
+}
+
+// caPool returns the current CA pool, reloading it if files changed.
+func (r *certReloader) caPool() *x509.CertPool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.maybeReload()
+	return r.pool
+}
+
+// maybeReload reloads changed files, if they were not checked within reloadCheckInterval. It has to be called with
+// mtx held.
+func (r *certReloader) maybeReload() {
+	if r.now().Sub(r.checkedAt) < reloadCheckInterval {
+		return
+	}
+	r.checkedAt = r.now()
+
+	changed, err := r.load()
+	if err != nil {
+		r.reloadFailures.Inc()
+		level.Error(r.logger).Log("msg", "failed to reload changed certificates, keeping previous ones", "err", err)
+		return
+	}
+	if changed {
+		r.reloads.Inc()
+		level.Info(r.logger).Log("msg", "reloaded changed certificates", "cert", r.certFile, "ca", r.caFile)
+	}
+}
+
+// load loads files if their content changed. It has to be called with mtx held.
+func (r *certReloader) load() (changed bool, err error) {
+	var certPEM, keyPEM, caPEM []byte
+	if r.certFile != "" {
+		if certPEM, err = ioutil.ReadFile(r.certFile); err != nil {
+			return false, errors.Wrap(err, "reading certificate")
+		}
+		if keyPEM, err = ioutil.ReadFile(r.keyFile); err != nil {
+			return false, errors.Wrap(err, "reading key")
+		}
+	}
+	if r.caFile != "" {
+		if caPEM, err = ioutil.ReadFile(r.caFile); err != nil {
+			return false, errors.Wrap(err, "reading CA")
+		}
+	}
+	if r.cert != nil || r.pool != nil {
+		if bytes.Equal(certPEM, r.certPEM) && bytes.Equal(keyPEM, r.keyPEM) && bytes.Equal(caPEM, r.caPEM) {
+			return false, nil
+		}
+	}
+
+	// Parse everything first, so a partially rotated set of files does not replace working certificates.
+	var cert *tls.Certificate
+	if r.certFile != "" {
+		c, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return false, errors.Wrap(err, "credentials")
+		}
+		if c.Leaf, err = x509.ParseCertificate(c.Certificate[0]); err != nil {
+			return false, errors.Wrap(err, "parse certificate")
+		}
+		cert = &c
+	}
+	var (
+		pool       *x509.CertPool
+		caNotAfter time.Time
+	)
+	if r.caFile != "" {
+		pool = x509.NewCertPool()
+		if caNotAfter, err = appendCertsFromPEM(pool, caPEM); err != nil {
+			return false, errors.Wrap(err, "building CA")
+		}
+	}
+
+	r.certPEM, r.keyPEM, r.caPEM = certPEM, keyPEM, caPEM
+	r.cert, r.pool = cert, pool
+	if cert != nil {
+		r.expiry.WithLabelValues(r.certFile).Set(float64(cert.Leaf.NotAfter.Unix()))
+	}
+	if pool != nil {
+		r.expiry.WithLabelValues(r.caFile).Set(float64(caNotAfter.Unix()))
+	}
+	return true, nil
+}
+
+// appendCertsFromPEM adds certificates in PEM format to the pool and returns their earliest expiry.
+func appendCertsFromPEM(pool *x509.CertPool, pemCerts []byte) (notAfter time.Time, _ error) {
+	for len(pemCerts) > 0 {
+		var block *pem.Block
+		block, pemCerts = pem.Decode(pemCerts)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" || len(block.Headers) != 0 {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		pool.AddCert(cert)
+		if notAfter.IsZero() || cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+	if notAfter.IsZero() {
+		return notAfter, errors.New("no certificates found")
+	}
+	return notAfter, nil
+}
+
+// verifyClientCertificate verifies client certificates against the current client CA pool, as done by
+// tls.RequireAndVerifyClientCert for a static pool.
+func (r *certReloader) verifyClientCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return errors.New("no client certificate provided")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		c, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return errors.Wrap(err, "parse client certificate")
+		}
+		certs = append(certs, c)
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         r.caPool(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if _, err := certs[0].Verify(opts); err != nil {
+		return errors.Wrap(err, "verify client certificate")
+	}
+	return nil
+}
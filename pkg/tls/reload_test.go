@@ -0,0 +1,207 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testutil.Ok(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	testutil.Ok(t, err)
+	cert, err := x509.ParseCertificate(der)
+	testutil.Ok(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate and key in PEM format, signed by the CA.
+func (ca *testCA) issue(t *testing.T, name string, notAfter time.Time) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testutil.Ok(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	testutil.Ok(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	testutil.Ok(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, b []byte) {
+	testutil.Ok(t, ioutil.WriteFile(path, b, 0600))
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-reload")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	ca := newTestCA(t, "ca")
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	expiry1, expiry2 := time.Now().Add(time.Hour).Truncate(time.Second), time.Now().Add(2*time.Hour).Truncate(time.Second)
+	certPEM, keyPEM := ca.issue(t, "server", expiry1)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	writeFile(t, caFile, ca.pem)
+
+	r, err := newCertReloader(log.NewNopLogger(), prometheus.NewRegistry(), certFile, keyFile, caFile)
+	testutil.Ok(t, err)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	testutil.Equals(t, expiry1.Unix(), r.certificate().Leaf.NotAfter.Unix())
+	testutil.Equals(t, float64(expiry1.Unix()), promtest.ToFloat64(r.expiry.WithLabelValues(certFile)))
+	testutil.Equals(t, float64(ca.cert.NotAfter.Unix()), promtest.ToFloat64(r.expiry.WithLabelValues(caFile)))
+
+	// Rotated certificates are picked up once files are checked again.
+	certPEM, keyPEM = ca.issue(t, "server", expiry2)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	testutil.Equals(t, expiry1.Unix(), r.certificate().Leaf.NotAfter.Unix())
+
+	now = now.Add(reloadCheckInterval)
+	testutil.Equals(t, expiry2.Unix(), r.certificate().Leaf.NotAfter.Unix())
+	testutil.Equals(t, float64(expiry2.Unix()), promtest.ToFloat64(r.expiry.WithLabelValues(certFile)))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.reloads))
+
+	// Unchanged files are not reloaded.
+	now = now.Add(reloadCheckInterval)
+	testutil.Equals(t, expiry2.Unix(), r.certificate().Leaf.NotAfter.Unix())
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.reloads))
+
+	// Partially rotated files keep previous certificates in use.
+	certPEM, _ = ca.issue(t, "server", expiry1)
+	writeFile(t, certFile, certPEM)
+	now = now.Add(reloadCheckInterval)
+	testutil.Equals(t, expiry2.Unix(), r.certificate().Leaf.NotAfter.Unix())
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.reloads))
+	testutil.Equals(t, 1.0, promtest.ToFloat64(r.reloadFailures))
+
+	// Invalid files fail creation.
+	writeFile(t, caFile, []byte("invalid"))
+	_, err = newCertReloader(log.NewNopLogger(), nil, "", "", caFile)
+	testutil.NotOk(t, err)
+}
+
+func TestServerConfig_ClientVerification(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-config")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	ca, otherCA := newTestCA(t, "ca"), newTestCA(t, "other-ca")
+	files := map[string][]byte{"ca.crt": ca.pem}
+	files["server.crt"], files["server.key"] = ca.issue(t, "server", time.Now().Add(time.Hour))
+	files["client.crt"], files["client.key"] = ca.issue(t, "client", time.Now().Add(time.Hour))
+	files["other.crt"], files["other.key"] = otherCA.issue(t, "client", time.Now().Add(time.Hour))
+	for name, b := range files {
+		writeFile(t, filepath.Join(dir, name), b)
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	serverCfg, err := NewServerConfig(log.NewNopLogger(), prometheus.NewRegistry(), path("server.crt"), path("server.key"), path("ca.crt"))
+	testutil.Ok(t, err)
+
+	for _, tcase := range []struct {
+		name       string
+		cert, key  string
+		serverName string
+		err        bool
+	}{
+		{name: "trusted client certificate", cert: "client.crt", key: "client.key", serverName: "server"},
+		{name: "untrusted client certificate", cert: "other.crt", key: "other.key", serverName: "server", err: true},
+		{name: "no client certificate", serverName: "server", err: true},
+		{name: "wrong server name", cert: "client.crt", key: "client.key", serverName: "other", err: true},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			var cert, key string
+			if tcase.cert != "" {
+				cert, key = path(tcase.cert), path(tcase.key)
+			}
+			clientCfg, err := NewClientConfig(log.NewNopLogger(), prometheus.NewRegistry(), cert, key, path("ca.crt"), tcase.serverName)
+			testutil.Ok(t, err)
+
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			testutil.Ok(t, err)
+			defer func() { testutil.Ok(t, l.Close()) }()
+
+			serverErr := make(chan error, 1)
+			go func() {
+				conn, err := l.Accept()
+				if err != nil {
+					serverErr <- err
+					return
+				}
+				s := tls.Server(conn, serverCfg)
+				serverErr <- s.Handshake()
+				_ = s.Close()
+			}()
+
+			conn, err := net.DialTimeout("tcp", l.Addr().String(), 5*time.Second)
+			testutil.Ok(t, err)
+			c := tls.Client(conn, clientCfg)
+			testutil.Ok(t, c.SetDeadline(time.Now().Add(5*time.Second)))
+			clientErr := c.Handshake()
+			if clientErr == nil {
+				// With TLS 1.3, client certificates are verified after the client finished its handshake, so wait
+				// for the server to either close the connection or fail.
+				_, clientErr = c.Read(make([]byte, 1))
+				if clientErr == io.EOF {
+					clientErr = nil
+				}
+			}
+			_ = c.Close()
+			err = <-serverErr
+			if err == nil {
+				err = clientErr
+			}
+			if tcase.err {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+		})
+	}
+}
@@ -5,10 +5,12 @@
 // pkg/ui/templates/bucket.html
 // pkg/ui/templates/bucket_menu.html
 // pkg/ui/templates/graph.html
+// pkg/ui/templates/loaded_blocks.html
 // pkg/ui/templates/query_menu.html
 // pkg/ui/templates/rule_menu.html
 // pkg/ui/templates/rules.html
 // pkg/ui/templates/status.html
+// pkg/ui/templates/store_menu.html
 // pkg/ui/templates/stores.html
 // pkg/ui/static/css/alerts.css
 // pkg/ui/static/css/graph.css
@@ -245,6 +247,26 @@ func pkgUiTemplatesGraphHtml() (*asset, error) {
 	return a, nil
 }
 
+var _pkgUiTemplatesLoaded_blocksHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x95\x55\x4b\x6f\xdb\x30\x0c\xbe\xe7\x57\x08\x46\x0f\x1b\xd0\xd8\xc0\x6e\x1b\x6c\x0f\x2b\x3a\x60\x01\xb2\x62\x58\xbb\x1e\x76\x93\x2d\xa6\x16\x2a\x4b\x86\x24\xa7\xc9\x0c\xfd\xf7\x51\x96\xdd\xbc\x9c\x74\xcb\xc1\x11\xc9\x8f\xa4\xfc\xf1\xe1\xae\x63\xb0\xe2\x12\x48\x54\x01\x65\x91\x73\xb3\x54\x70\xf9\x4c\xec\xb6\x81\x2c\xb2\xb0\xb1\x49\x69\x4c\x44\x34\x88\x2c\x32\x76\x2b\xc0\x54\x00\x36\x22\x95\x86\x55\x16\x75\x1d\x69\xa8\xad\x7e\xa0\xc0\x37\xc4\xb9\xc4\x58\x6a\x79\xe9\x7d\x12\xdd\x22\x38\xc6\xd3\xe7\x75\x86\xb8\xa2\xe5\x82\x3d\x82\x36\x5c\x49\x44\x46\xf9\xac\xeb\x40\x32\xcc\x88\x87\xf1\x12\xa5\x92\x16\xa4\xed\xef\xc1\xf8\x9a\x94\x82\x1a\x93\xf5\x6a\x8a\x00\x3d\x5f\x89\x96\x33\xf4\x25\xf8\x4b\xab\x0f\xf9\x52\x51\x06\x8c\xdc\x08\x55\x3e\x9b\x34\x41\x4d\x30\x35\xe1\xdf\xff\xba\x2e\x7e\x50\x96\x0a\x13\x07\x94\x73\xa4\xe8\x0f\xe4\x85\xdb\x6a\xcf\x7c\xd7\xd6\xf7\xa0\x39\x78\x84\xe9\x0f\x84\x4a\x76\x04\xa0\x75\x23\x02\x22\x9c\xae\xf7\xf2\xac\x94\xae\xa9\xbd\xd9\x5a\xf4\x1c\x7d\x16\x92\xc1\xe6\x1b\x72\x0b\xfa\x9e\xff\x01\x74\x54\x2b\xc2\xbd\x72\x5e\xf5\x5a\x13\x87\x1b\x27\xc3\x95\x53\x4b\x0b\x01\xe3\x9b\x07\xa1\x7f\xce\x0b\xa5\x11\x0f\xe3\xeb\x07\xb0\x0f\xb2\x2f\xeb\x9d\x30\x00\xf2\x5f\xcb\xc5\x6d\x9a\xe0\xe1\xc4\xf2\x9d\x4b\xf2\xc0\x6b\x38\x63\xa5\x9b\x0b\xd6\x9f\x60\x94\x68\x2d\x56\x73\xda\xfe\x75\x63\x41\x4b\x2a\xc8\x92\x16\x20\xcc\x34\x28\xf0\x7d\xc6\x16\x08\x9e\x36\xf6\xb4\xce\x03\xaf\xc4\x13\x3b\x0d\x5b\x52\x63\xc9\x97\xb2\x04\x73\x14\x07\x25\x7d\x20\x1d\xf3\x58\x28\xb6\xdd\xef\x21\x4d\xe5\x13\x90\xab\xbe\x73\xc8\xa7\x8c\xbc\x36\xd3\x25\xee\x59\xde\x75\xc1\x25\xf6\x45\x70\x0e\x13\xb1\x29\x50\xe8\x1c\x4f\x36\x0e\x50\xdd\x0c\x79\x62\xac\x8f\xd7\xfd\xbf\x1f\xdd\x5c\xf6\xe3\xab\x11\xba\x2b\xa3\x73\x63\xbc\xdb\x56\x53\xaf\x21\xef\x6a\x2e\x04\x37\x80\xf3\xc7\xcc\xa9\xc7\x7b\xef\x82\xb5\xc5\x44\x9a\xbe\x0c\xe3\x3c\x9d\xf2\x40\x71\xc0\xa8\xa4\x35\x5c\x93\xab\x35\x15\x2d\x78\x62\x87\x2c\xa1\x6b\xf6\xe8\x7d\x8d\x66\x1a\x2a\xc7\xf1\x28\x28\xc3\x18\xfd\x73\xde\x68\x5e\x53\xbd\x8d\x3c\xe7\x3e\xa8\x73\x7e\x3f\x85\xc0\xb8\x6f\xd2\xc4\x3b\x4e\x5d\x24\x6c\xa1\x83\x1c\x67\x68\x1b\xee\xb6\xb7\x28\xfe\x01\x39\x6e\x8c\xcb\x35\x0c\x5b\x63\x70\x3b\x59\x1a\x6f\xd7\xd1\x77\x7a\x68\xf4\x78\x61\x7e\x83\x56\xce\x49\x58\x83\x1e\x0b\xd4\x75\x86\xcb\x12\x4e\xe1\xb8\x91\xe8\x93\x9a\x2c\xde\xe1\x90\x8c\x91\x2e\x36\x3c\x29\x95\xf0\x3c\x67\xd1\xc7\x28\xbf\x53\xe3\xa6\x15\xfd\x9a\x7e\x23\xfa\x41\x19\xd0\xba\x1b\x41\x14\xfc\x06\xcc\x67\x69\x82\x5f\x85\xdd\x97\xe3\x2f\x73\xbe\xd6\xde\xbe\x06\x00\x00")
+
+func pkgUiTemplatesLoaded_blocksHtmlBytes() ([]byte, error) {
+	return bindataRead(
+		_pkgUiTemplatesLoaded_blocksHtml,
+		"pkg/ui/templates/loaded_blocks.html",
+	)
+}
+
+func pkgUiTemplatesLoaded_blocksHtml() (*asset, error) {
+	bytes, err := pkgUiTemplatesLoaded_blocksHtmlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "pkg/ui/templates/loaded_blocks.html", size: 1726, mode: os.FileMode(420), modTime: time.Unix(1792002520, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _pkgUiTemplatesQuery_menuHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x54\xb1\x6e\xdc\x30\x0c\xdd\xf3\x15\x84\x02\x74\x73\xb4\xb7\xb6\x87\x2e\x6d\xb7\xa2\xc9\x5e\xd0\x27\xda\x16\x22\x4b\x82\x44\x5f\x53\x18\xfe\xf7\x42\xba\x3b\xe3\xec\x9c\x0f\xc8\xd0\x49\x10\x41\x3e\xbe\x27\x3e\x6a\x9a\x14\xb5\xda\x12\x08\x8b\x47\x31\xcf\x0f\x00\x00\xa5\xc5\x23\x1c\x0c\xc6\x58\xa5\x70\x83\x01\x5a\xfd\x46\xaa\x60\xe7\xe1\x14\x28\xe8\xcd\xa3\x55\x45\x1c\x2e\x01\x85\xe1\x15\x9a\x2e\x9f\xa2\xce\x38\x19\x4b\xe9\x05\xeb\xe0\x2c\xa3\xb6\x14\x8a\xd6\x8c\x5a\x5d\x65\xe5\xcc\x66\x64\x76\x16\xf8\xaf\xa7\x4a\x9c\x2e\x62\x4d\xa3\x60\xd7\x75\x86\x82\x00\x85\x8c\xe7\x5b\xc2\x35\x06\x7d\xa4\x4b\x18\x43\x47\x5c\x89\x47\x8b\xc7\x22\xf5\x24\xcb\x02\x30\x68\x3c\xb3\x26\x55\x89\x16\x4d\x2a\xc8\xd1\x94\x13\x9c\x39\xb5\xd9\x54\x18\x6c\xc8\x54\xe2\x25\xb7\x4a\x5a\x75\x87\xac\x9d\xdd\x90\xcf\x02\xa2\x47\x7b\x9b\x70\xa1\x0f\xa9\xa4\x94\x29\x65\x23\x5b\x9e\xa4\x6e\xa2\xb8\x01\x6a\x02\x5a\x25\xa0\x0f\xd4\x56\x62\x9a\xc0\x23\xf7\x3f\x03\xb5\xfa\x0d\xe6\x59\x8a\xfa\xa5\x47\xeb\x62\x29\x71\x83\x93\x9e\x5f\xab\x8d\xb2\x35\xf4\xe5\xf9\x60\x79\xc7\x1b\xda\x46\xb3\xa9\x4a\x7e\x79\x9f\x97\x73\x8d\xbe\xca\x2d\x34\xd3\x20\xea\x95\xa0\xc2\x68\xfb\xba\x2b\xa6\x0b\xe8\x7b\x51\x7f\x4b\x47\x12\x54\x4a\xa3\xff\x4f\xa7\xc8\x2e\x50\x14\xf5\x73\x3e\x3f\xdc\x0b\x54\x70\x5e\xb9\x3f\xb7\xcc\x70\x35\xc8\x53\xf3\x47\xb1\xa5\xb5\x94\x9f\x5d\xb2\x71\xf5\x02\x0e\xc1\x99\xab\x8d\xc8\xb6\xec\x31\x7a\xe7\x47\x5f\x09\x0e\x23\xed\xb8\xbb\x7e\x66\xe4\x31\xae\x8d\x79\xc0\x40\xbc\x58\xf1\x9d\x61\xde\x99\xe7\x5c\xb6\x70\x1d\xc8\x8e\x77\xf4\xc2\xca\xbc\x4b\x55\x1e\xcd\xfe\x18\x12\x4d\x51\xff\x1a\x2d\xeb\x81\xe0\x13\x0e\xfe\x0b\x7c\x1d\xb5\x51\xf0\xc3\xb6\x2e\x0c\x79\xe5\xee\x73\x95\x4a\x1f\x77\x26\xf7\x31\xff\xdc\x1b\xe5\x8e\xb1\x7a\x66\x1f\x3f\x4b\xc9\x79\x09\x9f\xb4\x93\x1d\x31\x6b\xdb\x15\x91\x31\x30\xa9\xa7\x41\x49\x01\x97\x8f\xe9\x77\x63\xd0\xbe\x8a\xfa\x3b\x19\xbf\x2b\xea\x36\xeb\x52\x8e\x66\xfb\x7f\xac\x84\x5f\x5d\x4b\x69\xf1\x58\x3f\x4c\x13\x59\x35\xcf\x0f\xff\x02\x00\x00\xff\xff\x43\xdf\x18\x28\xeb\x05\x00\x00")
 
 func pkgUiTemplatesQuery_menuHtmlBytes() ([]byte, error) {
@@ -325,7 +347,27 @@ func pkgUiTemplatesStatusHtml() (*asset, error) {
 	return a, nil
 }
 
-var _pkgUiTemplatesStoresHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x56\x41\x6b\xeb\x38\x10\xbe\xe7\x57\x0c\xa2\xd7\xc4\xd0\xcb\xc2\xe2\x78\x59\x96\xc2\x1e\xda\xf2\x20\x7d\xbd\x3e\x14\x6b\x12\x8b\x2a\x92\x91\xc6\x6d\x82\xd0\x7f\x7f\xc8\xb1\x13\xb9\x76\xd2\xf4\x5d\x0c\x1a\xcd\xcc\x37\x99\xef\x9b\x51\xbc\x17\xb8\x91\x1a\x81\x55\xc8\x05\x0b\x61\x96\x2b\xa9\xdf\x80\x0e\x35\x2e\x19\xe1\x9e\xb2\xd2\x39\x06\x16\xd5\x92\x39\x3a\x28\x74\x15\x22\x31\xa8\x2c\x6e\x96\xcc\x7b\xa8\x39\x55\x3f\x2c\x6e\xe4\x1e\x42\xc8\x1c\x71\x92\x65\x8c\xc9\x6c\xa3\xd0\x2d\x4a\xe7\xfe\x79\x5f\x7a\x0f\xeb\x46\x2a\xf1\x8a\xd6\x49\xa3\x21\x04\x56\xcc\xbc\x47\x2d\x42\x98\xcd\xce\x45\x94\x46\x13\x6a\x6a\xeb\x10\xf2\x1d\x4a\xc5\x9d\x5b\xb6\x66\x2e\x35\xda\xf9\x46\x35\x52\xb0\x62\x06\x00\xe0\xbd\xe5\x7a\x8b\x70\xe7\xc8\x58\x7c\x39\xd4\x08\x7f\x2f\x61\xb1\x32\x8d\x2d\xd1\x85\xd0\x39\xc9\x4d\xe2\xd1\x59\xf3\xea\xbe\xf0\x9e\x24\xa9\x34\x7c\xb1\x22\x2b\xf5\x36\x84\x3c\xab\xee\x7b\x0c\x54\x2e\x8d\xfa\xa9\xdf\xb4\xf9\xd0\x10\xfd\x07\x6e\xed\x4f\x69\xbd\x88\xaf\x15\xf6\xa5\x1f\x0f\xed\x77\xbe\x36\x56\xa0\xc5\xbe\xfe\xa3\x73\xec\x7b\x7a\xb6\xe7\x43\xe7\x50\x3c\x68\x51\x1b\xa9\x29\xcf\xa8\x1a\xdf\xae\x88\x53\xe3\xa6\xef\xfe\xd5\xda\x34\xba\x44\x01\x8f\x7c\x8d\x6a\x85\x74\xc1\xf1\x49\x6a\x78\x91\x3b\xbc\x70\xcb\xf7\x57\x6e\x1f\xb9\x23\x58\x35\x65\x89\xce\x6d\x1a\x05\xff\x23\x57\x54\xc1\x7f\x15\x96\x6f\x57\x22\x9e\xd0\x39\xbe\xfd\x94\x33\xcf\xd2\x06\xc4\xbb\x4f\xed\x59\x1b\x71\x38\x9f\x87\x12\x88\xf4\x4b\x2d\x70\x0f\x77\x8b\x55\x34\xb8\x31\xf3\x17\x9a\x2c\x0a\xef\x8f\xbe\x8b\x67\xbe\xc3\x28\x01\x12\x23\xa7\x9e\xd4\xa8\x72\x64\xc3\x6b\xe8\xc5\xa6\x0d\x75\xb0\x8b\xf8\x3b\x1f\xac\x35\x36\x01\x3f\xa5\x73\x35\xd7\x7d\x42\xae\xd0\x12\xb4\xdf\xb9\x3b\x76\x12\x5a\x90\x5f\x52\x0b\x59\x72\x32\x16\xe2\x30\xce\x9b\xba\x46\x5b\x72\x87\xac\x68\xea\x3c\x8b\x39\xa6\xca\x48\x44\x7b\x13\xa6\x88\x5d\xb4\x5f\x42\x0a\xf3\xa1\xaf\x80\x9e\x46\x20\xe1\x6f\xdc\xc4\x71\xe8\xb7\x26\xe6\x1c\x34\x94\xc2\xb9\x8e\x4e\x12\xaa\x57\x7c\x94\xc5\x89\x90\xce\x36\xd5\x9c\xcf\xaa\xb8\x5a\xf3\x18\xaa\x85\x39\x81\x1e\xa1\xa6\x70\x4e\x69\x53\x32\xd6\x5c\x6c\x11\xda\xef\xbc\xb6\x72\xc7\xed\x81\x45\x49\xb6\xf9\x3a\x49\xc6\x75\xdb\x19\x5e\xb9\x6a\x30\x04\x76\x89\x0c\xb8\x40\xc8\x09\x7b\x44\x0c\x8c\x86\xef\xab\x3c\x79\x76\x81\x81\x3c\x6b\xc9\x2b\x6e\x90\x82\xf7\x1b\x63\x77\x9c\xe2\x72\x71\xc4\x77\x75\x4f\xd4\x93\xd4\xd1\x76\x61\x0e\xaf\xc4\xf1\xfd\xf5\x38\x27\x75\x89\xe9\x7c\xb6\x6b\x2a\x04\xe0\x5b\x73\xa3\x5c\x93\x27\xe5\xea\x84\xc3\xf7\x27\x6e\x42\xe7\x47\xc4\x5b\xe1\xfe\x78\x38\x87\xdc\x8f\x36\xc8\xd4\xca\x84\xd2\xa8\x08\xb7\x64\x7f\x4d\xd4\xfd\x6c\xc0\x1d\x77\xb0\xc5\xad\x74\x14\xc7\xf8\x3b\xf8\x83\x7a\x07\x5a\x1b\xe8\x6b\x5c\xe9\x3a\x7d\x40\x92\x3f\x11\x69\xf7\x3f\xb8\xd5\x52\x6f\x59\x31\x55\x65\x9e\x09\xf9\x3e\x7c\xd7\x3b\x53\x7f\xfc\x1d\x00\x00\xff\xff\xff\x5c\x1c\xa9\x36\x09\x00\x00")
+var _pkgUiTemplatesStore_menuHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb5\x54\xb1\x8e\xdb\x30\x0c\xdd\xef\x2b\x04\x75\xd6\x69\x2f\x6c\x0f\x37\x75\xe8\x50\xe0\x6e\x2f\x68\x8b\x76\x84\x30\x92\x21\xd1\x45\x0a\x23\xff\x5e\x4a\xb9\xdc\x25\x4e\x80\x02\x05\xea\xc5\x26\xf1\xc8\xf7\x9e\x48\x6b\x5d\x1d\x8e\x3e\xa0\xd2\x01\x7e\xe9\xd3\xe9\x49\xc9\xd3\xc8\xb7\x1a\x08\x72\x6e\x4b\xba\x87\xa4\x46\x7f\x44\x67\x38\xce\xea\x9c\x30\x78\x9c\x21\x38\x93\x0f\x97\x84\x83\xb4\x57\xfd\x54\xdf\xba\xab\x7d\x6a\x2f\xe7\x3f\x7a\x0d\x31\x30\x08\x59\x32\x23\x2d\xde\x5d\xa1\x2a\xb2\x5f\x98\x63\x50\xfc\x7b\xc6\x56\x9f\x03\x7d\x2b\x43\x04\x4c\x13\x61\xd2\xca\x01\xc3\x7b\x54\xfa\x12\xc1\x9c\xf1\x92\x86\x34\x21\xb7\xfa\x8b\x14\x99\xc2\x89\x81\xb5\x82\xe4\xe1\x5d\x35\xba\x56\x8f\x40\xa5\xa0\x66\x0b\x26\x45\x3a\xd3\x6c\x2a\x08\x7a\xa4\x56\xbf\x55\xaa\xe2\xd5\x4f\xc0\x5e\x94\xdd\x8a\xaf\x06\xb2\x34\x7f\x2c\xd8\xf8\xa1\x94\x34\xb6\x40\x36\xb6\xed\xd9\xea\x26\x0b\x9b\x46\x7d\x12\xe1\x5a\xed\x12\x8e\xad\x5e\x57\x35\x03\xef\x7e\x48\xe0\x8f\xea\x74\xb2\x3d\xc5\x61\x9f\x75\xf7\xb6\x83\x10\xb3\x7a\xe5\x98\xb0\xb1\xb0\xe9\x59\x46\xe1\xdd\xc6\xe5\x2d\xcd\xe5\x28\xd5\xc7\x99\x3e\xf0\xb9\xd0\xa6\xaa\xec\xce\x3d\xae\x62\xc9\x5f\x61\x8d\x67\x3c\xc8\x31\x5c\x9b\x33\xe4\xc3\xfe\xaf\xc6\xbe\x47\x90\xb1\xa9\x97\x1a\x16\x67\x8d\x25\xff\x7f\x28\xa9\x52\x59\xdd\xbd\x2c\xc3\x1e\xf9\x1f\xc8\x1e\x22\xef\xa6\x7a\xa3\x62\xc7\x3c\xe7\xaf\xd6\x72\x1d\xe0\xb3\x8f\x56\x56\x98\x7d\x98\x4c\x96\x75\x66\x74\xcf\x07\x91\xa4\x2e\xab\xfd\xb3\x27\x90\xe2\xee\x1b\xd2\x7c\x37\xe7\xcf\xcd\x7a\xa4\xba\xb1\x0b\x6d\x37\x50\x16\xe3\xea\x8f\xfd\x0c\x1b\x2b\x3a\xbb\xa7\x75\xc5\xe0\xe4\x6e\xf8\x03\xa5\xd9\xf5\xa5\x2d\x04\x00\x00")
+
+func pkgUiTemplatesStore_menuHtmlBytes() ([]byte, error) {
+	return bindataRead(
+		_pkgUiTemplatesStore_menuHtml,
+		"pkg/ui/templates/store_menu.html",
+	)
+}
+
+func pkgUiTemplatesStore_menuHtml() (*asset, error) {
+	bytes, err := pkgUiTemplatesStore_menuHtmlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "pkg/ui/templates/store_menu.html", size: 1069, mode: os.FileMode(420), modTime: time.Unix(1792002520, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _pkgUiTemplatesStoresHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa5\x57\x6d\x6f\xdb\x36\x10\xfe\xde\x5f\x41\x10\xf9\x60\x63\xb5\xd4\x0d\x28\xd0\xa4\x76\x86\xa2\x49\xd0\x02\x69\x11\xc4\x59\xb7\x6f\x05\x2d\xd2\x36\x11\x9a\xd4\x48\x2a\x71\x20\xe8\xbf\xef\x48\x4a\xb6\x64\xbd\xc4\x5e\x03\xc4\x12\xc9\x7b\xbf\x87\x77\xa7\x3c\xa7\x6c\xc9\x25\x43\x78\xcd\x08\xc5\x45\xf1\x66\x2a\xb8\x7c\x44\xf6\x25\x65\x33\x6c\xd9\xd6\xc6\x89\x31\x18\x69\x26\x66\xd8\xd8\x17\xc1\xcc\x9a\x31\x8b\xd1\x5a\xb3\xe5\x0c\xe7\x39\x4a\x89\x5d\xdf\xc1\x82\x6f\x51\x51\xc4\xc6\x12\xcb\x13\xc7\x13\xeb\x0c\x88\x23\x78\xfb\xf3\x69\x06\x74\x8b\x8c\x0b\xfa\x83\x69\xc3\x95\x04\x4a\x7c\xf9\x26\xcf\x99\xa4\xa0\x11\x5e\x2a\x23\x12\x25\x2d\x93\xd6\xdb\x41\xf9\x13\x4a\x04\x31\x66\xe6\xb7\x09\x10\xe8\xc9\x52\x64\x9c\x02\x2f\x82\xbf\x3c\xd7\x44\xae\x18\x3a\x33\x56\x69\xf6\x00\x16\xa3\x8b\x19\x8a\xe6\x2a\xd3\x09\x33\x20\x22\x10\xf1\x65\x8d\xa2\xdc\x9d\xae\xff\xb8\xcc\x73\xcb\xad\xa8\xb3\x47\x73\xab\xb9\x5c\x15\xc5\x34\x86\xf3\x92\x9d\x09\x53\xe7\xfa\x4b\x3e\x4a\xf5\x2c\x91\xa3\x6f\x90\x79\x57\x3c\x95\x25\x0b\x10\x5b\x9a\x1e\x16\xfe\x77\xb2\x50\x9a\x32\xcd\x2a\xfb\x03\xb1\x8b\x7b\x7d\xad\xf7\x8b\x92\xe0\xf2\x5a\xd2\x54\x71\x69\xa7\x31\x2c\x5a\xa7\x73\x08\x79\x66\xba\xcf\x3e\x49\xa9\x32\x99\x30\x8a\x6e\xc9\x82\x89\x39\xb3\x3d\x84\xdf\x38\xb8\xc4\x37\xac\xe7\x94\x6c\x07\x4e\x6f\x89\xb1\x68\x9e\x25\x10\x74\xb3\xcc\x04\xfa\xc2\x88\xb0\x6b\xf4\x79\xcd\x92\xc7\x6e\x8e\x7b\x96\x40\x96\xd1\x3d\xfb\x37\x63\xa6\xcf\x24\x2f\xf6\x1b\xc8\x24\xab\x0e\xc5\x21\xaf\xd1\xb5\x74\x91\xbd\xd2\x80\x8e\x32\xfc\x0d\xf7\x13\x0b\x68\x33\x5d\xdc\xfb\x7c\x79\xe2\xb8\x1e\x77\x47\x7f\x90\x95\x85\xa2\x2f\xfb\x75\x13\x79\x0e\x75\x5c\x52\xb6\x05\x7b\xe6\x6e\xc3\xb4\x01\xd7\x93\x5b\x0a\x28\x0c\xb4\xd1\x77\xb2\x61\x0e\x79\x96\xb6\x88\x2a\x2c\xb9\xcb\xc5\x70\xf3\x78\x17\x0b\xa9\x6c\xa9\x36\x72\x91\xbb\xd6\x5a\xe9\x83\x90\x78\x71\x26\x25\xb2\x12\x48\x04\xd3\x16\xf9\xdf\x89\x09\x09\x44\x5e\xc9\x4f\xf0\x87\x27\x04\xa4\x21\x57\x03\x26\x59\x9a\x32\x9d\x10\x03\xda\xb3\x74\x1a\x3b\x19\x5d\x66\xd4\xee\xca\x51\x3a\xa9\x8b\xa2\x7e\x55\x25\x85\x0b\x37\xa0\xb4\x91\xc9\x26\x3e\x42\x3c\x3c\x3c\x18\x3d\xc5\xb2\x67\xa2\x25\x94\x82\xd7\x4d\x0b\xa2\x4f\xb1\xae\x33\xc5\x6d\xd6\x93\xca\x48\x1f\x50\x5b\x80\x15\x55\x19\x70\xa0\xdd\xc1\xa5\xdc\xeb\x0a\xd0\x21\x66\x07\x6d\x6e\xab\xf2\x6a\x76\x4a\x83\xaa\x2e\x3d\x9d\x09\x59\x10\x0a\x82\xfc\xef\x24\xd5\x7c\x43\xf4\x0b\x76\x17\xc6\xcb\x2b\x2f\x8c\xeb\x41\xe5\xc6\x0f\x22\x32\xd8\xc1\x7d\xc9\x18\x82\x4b\x77\x62\xda\xa5\xe1\x35\x39\x40\xdd\x9d\x01\x38\x70\xc9\xbb\x3c\x02\x0a\x79\xbe\x54\x7a\x43\xac\xab\xb8\x80\xbf\x4d\x5a\x25\x0a\x8a\xb4\xdb\xeb\xa9\x12\x03\x7c\x64\x3b\xcc\x67\x38\xf4\x88\x7a\xf5\xf0\xb5\xbb\x28\x10\x59\xa9\x23\xe1\x9a\xe7\xcf\x1c\x8a\x7e\x29\xa3\xac\xec\xae\x35\x99\xbe\xcb\x59\x11\x75\x13\xd4\x4e\x61\xf8\x08\xaf\x6f\x51\xe5\xe2\x1d\xdc\x3f\xd7\x41\xa2\x1b\xc2\x45\xa6\xd9\x3d\xdc\x52\x20\x5c\xc2\x0a\x2e\xe3\xa2\x23\x61\xe9\xfb\x77\x3b\xee\xab\x4c\x13\xd7\x18\x10\xf8\x0a\xe3\x46\xf2\x72\xf7\xfe\x5d\x51\xbc\x45\xe9\xf9\x10\xcd\x79\x49\x73\x3e\x44\x73\xde\xe9\x4c\x4f\x5d\xfc\xae\x76\xae\x9d\x50\xd7\xfa\xf6\xeb\xf1\xff\xac\x64\x92\x69\xed\x6c\xba\xe5\x1b\x6e\x7b\x73\xd0\x77\xea\x62\x08\x49\xf8\x2a\x6f\x04\x5f\xad\x81\x02\xa9\xa5\xcb\x4a\x49\x8f\x92\x4a\x01\x74\x7e\xa6\x39\xb4\xbc\x84\x08\xe1\x33\x14\xfd\x4d\xb8\xf5\x63\x14\x7a\x0e\x6f\xbf\xe4\xdc\xd1\xf8\xdb\xd7\xfb\xa1\xfe\xf7\x3f\xfa\x11\xee\x2b\x25\xc7\xaa\xfb\xa5\xe6\x70\xc4\xa0\xf3\x4a\x38\x06\xda\xdf\x22\xb3\x16\xf0\x1b\xa6\xfd\xb0\xc0\xbb\xea\x6b\x25\x82\xff\x89\xd9\x84\x07\x83\x9c\x53\x28\xc1\xc8\x4b\x9d\xf8\xce\x87\x11\x25\x96\x4c\x88\x9f\xb3\x66\x38\x93\x8d\x5d\x4a\xb5\x2f\xcf\xf5\x01\x07\xc3\x04\xed\x89\xa6\x71\xd0\x77\xca\x1c\x71\x82\xb9\xfb\x06\xde\x67\xec\x11\xa6\x5e\xbd\x6a\xe8\x51\x09\x1c\x9a\x35\x5b\xce\x76\x4d\x89\x70\xdd\x84\xc3\x90\x33\xb1\x8d\x86\xf3\x4a\xc6\x87\x52\x55\x07\x60\xa1\xd4\x98\x30\x9a\x6a\xb6\xe2\xc6\xba\xf9\x61\xc0\xee\x96\x8d\x87\x1e\xec\x9b\x5c\xa3\xb1\xb5\xbd\xa9\xd7\xe2\xfa\x27\x5d\xc7\xb0\x85\x2f\xbb\xac\x9c\xc6\xc0\xd5\xfc\xca\x2a\xb7\x42\x05\x6b\x5e\x8c\xa9\x49\x34\x4f\x6d\xa0\x3f\x1b\xe1\xa8\x9e\xff\x71\x94\x08\x9e\x3c\x8e\x96\xf0\x49\xe4\x30\x30\x1a\xa3\x7c\x67\xdc\x13\xd1\xa8\xc4\x17\x8c\x2c\x23\xbb\xe6\x66\xfc\x71\x77\x7a\x16\xa5\xca\xd8\xd1\xdd\xa7\x87\x2f\x3f\xef\xee\xaf\x6f\xbe\xfe\x83\x7e\x43\x38\x26\x29\x8f\x9f\x7e\x8f\x83\xd1\x31\x86\xbd\x20\x22\x72\xa8\x1a\xe1\x00\x35\x3c\x86\xb2\xe8\x10\x76\x71\x70\x0a\x5b\x78\x5c\x8c\x1b\x79\x88\xa8\x92\xac\x61\x21\x12\x2a\xf1\x6d\x26\x82\x8f\x70\x45\xe8\x68\xfc\x11\x1d\x32\xb9\xc6\xb7\x67\xda\xae\xb5\xe3\xf3\xc1\x1d\xe1\x1b\xdf\x14\x91\x55\xa8\xd7\x3e\xe7\x4b\x88\xfc\x85\x27\x02\x01\xa0\xcc\xa4\xf0\xed\xc4\x1e\x60\xe2\xf5\x1a\x43\x30\xdc\x13\x0a\x5a\x19\xe5\x2a\x23\xd5\xf3\x3f\x2f\xe0\x1d\x9f\x4f\x10\x00\x00")
 
 func pkgUiTemplatesStoresHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -340,7 +382,7 @@ func pkgUiTemplatesStoresHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/templates/stores.html", size: 2358, mode: os.FileMode(420), modTime: time.Unix(1585516941, 0)}
+	info := bindataFileInfo{name: "pkg/ui/templates/stores.html", size: 4175, mode: os.FileMode(420), modTime: time.Unix(1792004705, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -505,7 +547,7 @@ func pkgUiStaticJsBucketJs() (*asset, error) {
 	return a, nil
 }
 
-var _pkgUiStaticJsGraphJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xe4\x7d\xeb\x7a\xdb\x38\xb2\xe0\xef\xf5\x53\x20\x9c\x7c\x11\x15\xcb\x94\x9d\x3e\xdd\x3b\x23\x59\xee\x4d\x27\xce\xc4\xe7\xe4\x76\x62\xa7\x2f\xc7\xed\xe3\x0f\x22\x21\x89\x31\x45\xf2\x00\x90\x6d\x4d\xa2\xc7\xda\x17\xd8\x27\xdb\x0f\x85\x3b\x09\x5d\x9c\x9e\x99\x6f\x2f\xf9\x21\x87\x20\x50\x28\x14\xaa\x0a\x85\x42\xa1\x78\x8b\x29\xfa\x40\xab\x39\xe1\x33\xb2\x60\x68\xe4\x3e\x7c\xfd\x8a\xbe\xac\x86\x7b\xa2\xca\x94\xe2\x7a\x76\x41\xe6\x75\x81\x39\x19\xee\x41\xd9\xd9\xbb\x0f\x9f\x2e\xae\x5f\x9e\xfe\xf4\xfe\xd3\xbb\x17\xa7\xd7\xbf\x3c\x3f\xbb\x40\x23\xf4\xfd\xe1\xe1\x10\xf5\xfb\x68\xce\xa0\xd2\xf9\xe9\x8b\xf7\xef\x5e\xa2\x11\x3a\x3a\x3c\x3c\x1c\xee\xed\x59\xf0\xc9\x5f\x05\x4c\x34\x42\x93\x45\x99\xf2\xbc\x2a\x63\x52\x90\x39\x29\x79\x0f\x55\xb5\x78\x66\x3d\x34\xc3\x65\x56\x90\x17\x33\x5c\x4e\x89\x7e\xfa\x48\xe6\xd5\x2d\xe9\xa2\x2f\x7b\x08\xf1\x59\xce\x12\x52\xa0\x11\x52\x6d\x87\xba\x10\x10\x7e\x7d\xf1\xf6\x0d\x1a\xa1\x72\x51\x14\xe6\x85\x82\x8d\x46\xba\x17\xf3\xc6\xed\x0c\x8d\xbc\xbe\x1b\x75\x24\x0a\x2e\xea\x12\x1d\xe4\xa1\x18\x8b\x16\x5d\xd1\x74\x65\xda\xd3\x3c\xbd\x61\x33\x7c\xa7\xc7\xee\xa1\x96\x61\x8e\xd1\x08\x5d\x5e\x0d\xf7\x74\x51\x5e\xe6\x3c\xc7\x45\xfe\x37\x12\x77\x87\x7b\xab\x00\x01\x13\x9e\xcf\xc9\x2b\x9c\xf2\x8a\x8a\x41\x09\x34\xa2\x65\x34\x40\x3f\x1c\xa2\xa7\xf2\xe7\xd9\xbf\xa0\xa7\xe8\xbb\x1f\xbe\xef\x89\x57\x77\xed\x57\xff\x1d\x5e\x64\x8d\x17\x50\x38\xb3\x85\xf0\x3c\x87\x67\xf8\x2f\x8b\x06\xe8\x28\x8c\x11\xe3\xa4\xfe\x19\x17\x0b\x22\x10\xba\x14\x95\x8f\x58\xd4\x43\xd1\xd1\xa1\xfc\x33\x17\xbf\xdf\xc3\xef\x91\xfc\xf3\xdd\xa1\x7c\x9a\x89\xdf\x67\xf0\xfb\x03\xfc\x1e\xc9\x87\xa3\x0c\x5e\x64\x11\x74\x7d\x74\x07\x4f\xf0\xfb\x2f\xf0\xfb\x67\xf8\x3d\x5a\x42\xf9\x32\xda\xbb\x0a\xa1\x55\x2e\xe6\xf0\x1f\x81\x55\x88\x15\x93\x9a\x56\xbc\xe2\xcb\x9a\x38\x64\x6f\x4f\xb2\xe0\x6a\x46\x8a\x09\x1a\xc1\x14\x89\xd9\x13\x8f\x49\x9e\x79\xd2\xd3\xec\x74\x7f\x1f\x66\xb5\xdf\x47\xe7\x84\xa3\x8c\x4c\xf0\xa2\xe0\x9a\x07\x13\x0d\x44\x3f\x03\x30\x05\x76\xd8\x7c\x49\x05\x4b\x5e\xe7\x65\xbd\xe0\xba\x56\xe8\xd5\xd7\xaf\x40\x51\xd1\x3c\x9f\xa0\xd8\xab\xc7\xf1\x18\x8d\x46\x23\xb4\x28\x33\x32\xc9\x4b\x92\x69\x06\x6e\xd7\x42\x47\xc0\xc2\x21\x28\x73\x7c\x7f\xcd\xaa\x05\x4d\xc9\x35\x25\xac\x2a\x16\xa2\xb8\x0d\xf7\xbf\x35\xc0\xae\x69\x86\xa2\x43\x16\xc9\xbe\x24\xa1\x5e\x52\x7c\x27\x35\x0f\x4a\xab\x92\xd3\xaa\x60\x08\x97\x19\x3c\xe0\xbc\x24\x14\x4d\x68\x35\x47\xaf\x41\xe6\xc6\x98\x32\xc4\x95\x86\x4a\xf6\xd4\x44\x59\x69\x97\xc3\xeb\xd4\x98\xcf\x3e\x50\x32\xc9\xef\x3b\x03\xf4\xe1\xf9\xc5\xeb\xeb\x0f\x1f\x4f\x5f\x9d\xfd\xda\x93\xaf\xc7\x8b\xbc\xc8\x7e\x26\x94\xe5\x55\xd9\x19\xa0\x9f\x3e\x9d\xbd\x79\x79\xfd\xf3\xe9\xc7\xf3\xb3\xf7\xef\xb4\x20\x7f\xfe\xf7\x05\xa1\xcb\x84\xdc\x73\x52\x66\xb1\xd1\x55\xee\x10\xbb\x66\xce\x5c\x3d\xf4\x38\x7e\xbb\x60\x1c\xa7\x33\x92\x50\x52\x66\x84\xc6\x9e\x5a\x35\x7a\xaf\x6b\x9b\x93\x22\xc1\x75\x2d\xfa\xf1\xa1\x75\x35\x33\xfd\x95\x70\x44\xc9\x84\x50\x52\xa6\x84\x21\x5e\x21\x5c\x14\x88\xcf\x08\xca\x4b\x4e\x28\x61\x3c\x2f\xa7\x5a\x3b\x32\x94\x97\xf0\xce\x12\x55\xd2\x11\x97\x99\x04\x37\xce\xcb\x0c\x91\x5b\x52\x72\xa5\xca\x28\xf0\xa6\x59\x02\x7e\xa1\x02\x1d\xaa\xd9\x8e\x14\xc9\x24\x2f\xb3\x38\xfa\x13\xbc\xbd\xbe\x93\xaf\x23\xb4\xaf\x99\xd7\x0e\xe5\xbf\x04\xd5\x5e\x55\x74\x8e\x46\x1e\x2c\x05\x41\xbe\xbf\x9e\x54\x74\x1e\x99\xd1\x3d\x5f\xf0\xea\x80\x12\x26\x04\x51\xe0\xcd\xc9\x3d\x47\x98\x12\x8c\xaa\x12\x49\x2e\xaf\x28\x9a\x57\x0b\x46\xd2\x22\x4f\x6f\x14\xaa\xb2\xc5\x05\xb9\xe7\x50\xd7\x5b\x62\x34\xa3\x03\x77\x4c\x26\x8c\x70\x58\x3d\x12\xf9\xff\xd7\x24\x9f\xce\x38\x3a\x10\x25\x69\x91\x93\x52\x95\x0c\xa1\xcd\x63\xd1\x3e\x49\x19\x8b\x3b\x33\x28\xee\xf4\x50\x07\x2f\x78\xd5\x69\x96\x92\x22\x61\x29\xad\x8a\x42\x01\xdc\x57\x7d\xe9\xe5\xc0\xcc\xef\x7d\x4d\xc3\xf4\xe0\x0a\xfb\xcb\x12\xcf\xc9\x48\xd4\xbb\x8a\x1c\xbe\xb8\xaf\x69\x72\x43\x96\x35\x25\x8c\xc5\x76\x78\x7a\x74\x69\x55\x32\x8e\x88\x60\x01\x21\xc1\xdf\x49\xfc\x85\x00\x93\xe4\x6e\x96\xa7\x33\x34\x1a\xa9\xd7\x4f\x9e\xa0\x47\x24\x61\xb3\x7c\xc2\xff\x8d\x2c\x35\x80\xe6\xa4\x25\x6c\x31\x9e\xe7\x3c\xee\x0e\xd5\x6b\x92\xd4\x14\x18\xe5\xa5\x54\x65\xfa\xcd\x4a\x51\x0a\x16\xbf\xa4\x2a\xe3\xce\x0d\x59\x2e\x6a\x39\x5b\x9d\x1e\xca\xc8\xb8\x5a\x94\x29\x89\x5b\x6b\x27\x6a\xcc\x9b\x5d\x3f\x11\x5a\xf5\x42\xe6\x86\x14\x94\x55\xd7\xa7\x67\x02\xac\x10\x20\xca\x3a\xf0\x6d\x00\xb0\xe8\x4b\x91\x73\xcd\x00\xa7\x5e\x46\xb2\x45\xfd\x13\x2f\xb5\x24\x58\x42\x29\x76\x86\x0a\xd7\x63\x5e\xba\xb3\x56\xe2\x71\x41\x5e\x8a\x37\xeb\xda\x01\x99\xe4\x9c\x03\x04\x77\xd2\x6b\x4c\xc5\xaa\xf4\x91\xb0\xba\x2a\x19\xd9\xd4\xbb\xaa\x2a\xf4\x2b\xd4\x6d\x20\xd2\x80\xb4\x03\x32\x4d\x80\x2e\x5e\xb0\xe8\x9c\xb9\xcb\xd1\x06\x40\xce\x0a\xe5\xc2\x10\x7a\xf1\x86\x64\x9b\xc6\xa4\xaa\x34\x86\xa2\x4a\x77\xe8\x59\xd5\x74\x7b\xcd\x4b\x46\x28\x7f\x4b\x38\xcd\xd3\x75\x10\x18\x29\x48\xaa\x40\xc8\xfa\xd7\x73\x68\xe0\x91\x80\x4c\x28\x61\xb3\x33\x21\x51\xb7\xb8\xd8\x05\x96\x6a\xe2\x42\x99\xe3\xfb\x73\x58\x17\x3f\x9a\x65\x71\x23\x59\x5d\x70\xc1\x45\xd5\xa1\xb3\xe1\x5c\xa1\x1a\xaa\x82\x5c\xc0\xda\x1e\x52\xc4\xaa\x42\xd4\x58\xc4\x44\x03\xb4\xa6\x89\xd4\xfe\x66\x3d\x89\x1c\x41\xe1\x78\xcc\xc2\xad\xf0\xa5\x30\x78\x0f\x78\x35\x9d\x16\x64\xd4\xe1\x78\xdc\x71\x89\x21\x1a\x26\xe4\xbf\x5a\x76\x4b\x57\xfc\xc4\x11\x9b\x55\x77\xcd\xda\x55\x29\xcb\xcb\x64\x0c\x55\xa3\x1e\x6a\x6b\x01\xa1\xf8\x39\xa6\x53\x50\xfc\x8f\x63\x92\xc8\x07\xa5\x68\x02\xf6\x8f\x7c\x2f\x64\x86\x94\x3c\xee\x26\x79\x99\x91\xfb\xd8\xad\xef\xea\x08\xfd\x42\xe8\xda\xc7\x71\xf4\x27\xb1\x16\x2a\x08\x98\x73\x1a\x47\x98\xe6\xf8\x40\xdb\x33\x51\xb7\x9b\xcc\x30\x7b\x51\x60\xc6\xe2\x88\x92\xa2\xc2\x59\xd4\x6d\x28\x61\xa9\x7a\xc1\xea\x70\xb5\xec\xca\x2c\x93\x1f\x09\x5f\xd0\x12\x89\x4d\x07\x43\x93\x2a\x5d\x30\x34\xc6\xe9\x8d\xb0\x06\x60\x81\xc9\x4b\xc6\x09\xce\x50\x35\x41\x12\x96\x30\x0a\x92\x90\x10\x24\x63\x98\x9a\x1b\xb2\xcc\xaa\xbb\x52\x98\xd3\x14\x60\x07\x29\x69\x15\x26\xf4\xe9\x91\x04\x8a\x6f\x71\x11\xfb\x4f\x5d\x55\x47\x42\x5d\xb3\x88\xac\xba\x56\x1d\x53\x5a\xad\x59\x20\xe5\xbb\xa8\x9b\xcc\xf2\x4c\x51\x1d\x9a\xdc\x61\x5a\x0a\x9b\x27\xdc\x48\xbd\x6d\x37\x83\xca\xcf\xa5\xb9\xb0\x9e\xc5\xc5\xc2\xd1\x14\x0c\x2d\x9d\x06\x82\xd7\xc4\xa9\xbd\x7c\x7e\x9f\xb3\xb5\xb5\x97\xd7\xf8\x3e\x67\x4e\xf5\x82\x4c\x49\x99\xad\x41\x47\xbe\x74\xf5\x60\x9d\x97\x25\x59\x47\x2b\xf5\xd6\x5d\x8b\x6e\x71\x71\xce\x31\x5f\x23\x9c\xf0\xfe\x9a\x89\x0a\xae\x34\x93\x32\x7b\x89\x39\x09\xb7\x71\x74\x2d\x29\xb3\xb6\x8e\x57\x8d\xc5\x3e\x97\x88\x5d\x6b\x9d\xa7\x37\x84\xc6\x92\x99\x8a\x2a\xc5\x05\x19\xa0\x0e\x29\x3b\xd2\x18\x17\xa6\x20\xe6\x03\xd4\xf9\xed\xb7\xdf\x7e\x3b\x78\xfb\xf6\xe0\xe5\x4b\xf4\xfa\xf5\x60\x3e\x57\xef\x79\x55\x15\x63\x4c\x3f\x14\x38\x05\xeb\x76\x80\x3a\xe3\x8a\xf3\x4a\xbf\x67\x79\x46\x7e\x5a\x9e\xe7\x19\x19\x20\x4e\x17\x44\x95\xce\xaa\xbb\x8b\x2a\xc3\xcb\x9f\x16\x9c\x57\x65\xf3\xd5\x8b\x82\x60\xda\x2e\xac\x98\x07\x44\x60\xff\x1f\x55\x29\xd0\xfd\x74\xf1\x02\xfa\x5b\x75\x83\x1b\x2d\x43\x08\x5f\x68\x2c\x25\x70\xdc\x11\xff\xbd\xc8\xe7\xe4\x03\xd0\xa3\xd3\x05\x02\xad\x03\xa3\x37\x63\x1e\x1c\xa1\xf8\xb2\x5a\xd9\x2d\xae\xac\x76\xd1\x97\x90\x0e\x51\xd8\x86\x96\x2e\x6d\xfc\xb4\x41\x2c\x6a\x81\xd7\x47\x59\x5d\x03\x31\x4a\x84\x9d\x9b\x85\xb8\x65\xd9\x29\x69\x77\xd7\x6b\xa9\x0d\x60\xaf\xd8\x39\xea\x34\xac\xe2\x79\x25\xe6\x73\x2b\x93\xc9\x6a\x6d\x3e\x93\xe5\x7f\x98\xcd\x06\x8c\xfd\xdf\xc4\x69\xa2\x26\xe3\x78\x5e\xbb\x0b\x5d\x26\x85\xb5\x24\x77\xe8\x65\x8b\xa9\x4c\x8b\xa7\x47\x87\x87\x87\x5d\xcb\x9e\x96\x80\x6b\xb9\x53\xfc\x48\x5e\x44\xa4\x60\x24\xe0\x1b\x70\x26\xc7\xe3\xfd\x1d\x80\xaf\x07\xe4\x71\xbf\x82\xf4\x4d\xcc\xaf\x1d\x39\x7c\x59\x10\xe0\x5c\x69\x79\xb6\x58\x57\x54\xca\xd3\xca\x58\xa5\xd6\x4e\x95\xfc\xd8\x49\xa6\xc5\xb2\x9e\x89\x2a\x1d\x67\xe5\xf7\x65\x22\x6e\xad\xe8\x16\x0a\xce\x32\xb5\xfa\x8f\x79\x79\x50\xd3\x7c\x8e\xe9\x32\x32\xdb\x2c\x01\xd8\xa9\x63\x3a\x3b\x48\x67\x24\xbd\x69\xd4\xa3\xe0\x77\x6c\x55\x5d\x94\x50\x99\x64\xba\xba\x9a\xb3\x75\x28\x79\x60\x1e\x86\x55\xab\xab\xcd\x98\x79\x83\x58\x69\x07\x8b\x37\x29\xb1\xa3\x64\x1c\x1c\x1b\x7b\x3c\x4d\xdf\x10\xed\xc5\x0e\xd7\x2e\xb9\xff\x7a\xfe\xfe\x9d\x9d\x8d\x7e\x1f\x9d\x4d\x1c\x97\xc8\x1d\x66\x48\xf5\xd2\x83\xe2\x8a\xe6\xd3\xbc\xc4\x05\x62\x84\xe6\x84\x21\xf0\xd1\x4e\x2b\x8e\xe6\x0b\x8e\x39\xc9\x2c\x9c\x98\x09\xcd\x92\x75\xc1\x45\x75\x47\x50\x49\x48\x26\x2c\x30\x4a\x60\x33\x4e\x17\x29\x47\x39\x97\x2e\x2b\x0f\xb2\xc0\x08\xe0\x26\xee\x7c\x28\x67\xb0\x34\x6e\x29\x2e\x99\xd0\x53\x2f\x85\xd0\x34\xc6\xe2\x6e\xbb\x5b\x1a\xb6\x45\x8b\x1f\x51\xe7\xb0\x83\x06\x42\xe9\x6a\x73\xad\x49\x6d\x03\x48\x2a\x7c\x70\x5f\xc6\xee\x46\xb9\xdf\x47\x17\x60\xb4\x1b\x71\xb1\x0e\x4c\x69\xcd\xbf\xf7\x64\x49\x9a\xbf\xa7\x45\x0f\xe5\x9c\xcc\x35\xf1\x1f\x9b\xf2\xae\x65\xa5\x4e\x83\x37\x3a\x5d\x8f\x75\x3a\x01\x4e\xd3\xe3\x00\xcd\x7e\xce\x2b\x8a\xa7\x24\x61\x84\x9f\x71\x32\x8f\x45\x87\x3d\x33\xd8\xd5\xb0\x81\xe6\x64\xf2\xc7\xf1\x74\x10\x59\x87\xab\x1a\xca\x76\x3c\x0f\x3b\x8e\x83\xa8\xdf\x47\xe0\x2a\x28\xf2\x14\x0b\xfc\x04\x8d\x0b\xf0\x1d\x67\x8b\xfa\xda\x55\x4a\xda\x19\xb1\x46\x25\x29\x26\x00\x60\xa7\xe0\x82\x08\xae\xd2\x02\xf6\x2d\x1a\xf9\x08\x4e\x15\x82\x1d\xe9\xbb\x38\x80\xae\x3a\x92\x0f\xb4\xf8\x94\x15\x47\x8c\x70\x94\x97\xb2\x2d\x93\x6d\x7b\x68\x8e\x6f\x88\xe0\x79\xd9\x36\x4b\x5c\x63\xe0\x56\xaf\xfd\xe8\xeb\x57\xf5\xa0\x0f\x43\xe4\xf0\x1d\x51\x76\x31\xb7\xba\xd4\xe3\xb7\xd8\x52\xa5\x87\x5a\xc8\x36\x54\x9e\xcf\x02\x5b\x9b\xb6\x9c\x3e\xeb\xb4\x4f\xd3\xc9\xe3\x8a\xa0\x3f\x86\x80\x1c\x8a\xf1\x5a\x4c\xec\xae\x30\xcc\x6a\x8d\x05\x65\x57\x32\x78\xeb\xf5\x96\xde\x14\xd7\x86\x7b\xda\x4e\xb6\xe6\x26\xf5\x83\xf4\x20\x21\xed\x41\xd2\xec\xdc\x72\x55\xb9\x9c\xdd\x76\x79\x6d\xe6\xf1\x0f\x7e\xfd\x3f\xca\xed\xaa\xfb\x03\x8d\xdb\x3f\x9b\xf1\xc3\xc3\x59\x27\x02\x41\x4a\xda\xb9\x09\x0d\x66\xb3\x60\x7c\x1b\xc0\xbd\xf5\xfe\xca\x8d\x82\xd3\xa8\xee\x0a\xcf\x3a\x3a\xac\x11\xa3\x20\xde\xdf\x2a\x51\xdf\x44\x04\x4f\xce\x76\x47\x67\x9b\xc8\x7d\x2b\x2e\x0d\x27\xf7\x3a\x3f\xa3\x25\xf9\xc6\xa3\x3a\x07\x52\xeb\x7c\x20\x3c\xb5\xd6\xe7\xe8\x58\x8d\xda\xdb\xe5\x58\x1c\xda\xd1\xb8\xb9\x56\xc8\xdd\xb6\xce\x51\xa6\x84\x6e\x82\x0b\x46\x86\x66\xf3\xe4\x7a\x4c\x8c\x23\xa8\x3d\x26\xb9\xe3\x1c\xc3\xf6\x4d\x3b\x80\xd3\x6b\xf0\x60\x5f\x45\xdd\x00\x33\x6b\x87\x5a\x4a\x09\x66\xe4\xa3\x42\xd0\xed\x74\x13\xf0\x8c\xec\x00\x3c\x23\x01\xe0\xbb\xa2\x4e\xca\x6c\x17\xc4\x4f\xcb\xec\x81\x68\x6f\x01\xac\x91\x76\x00\xef\x8a\xb2\xdc\xe4\xed\x82\xf5\x5b\xa8\xf9\x40\xc4\xb7\x83\xd7\xb8\xfb\xe0\x83\xce\xd3\x80\xeb\xa4\xe1\x11\x95\x1e\x7b\xf1\x2e\xa2\xa4\x2e\x70\x2a\x36\xac\x5f\x38\xb9\xe7\x83\x00\x3c\xf0\x91\xf4\xd0\xbc\xca\xc8\x00\x45\x63\x32\xa9\x28\x89\x56\x2d\x37\xab\xf6\xbe\x8a\x55\x89\x12\x78\xca\xcb\xa9\x95\x79\x79\x24\x2a\x2c\x53\xb9\xc9\x0d\x78\x55\xf4\x91\x84\xa8\xa4\x5c\x29\xa6\xc5\x46\xa5\x2d\x6b\x6d\x92\x36\x73\xee\x20\x74\x9b\xd8\xeb\xbf\xa4\xf9\x44\x79\x7a\xfb\x7d\xe4\x84\x5a\xc0\x5c\xa1\x59\x2e\x96\xd1\xa5\x5a\x08\x1f\x05\xd7\xe7\x48\x55\xb2\xae\xf2\xa0\x55\x6d\xaa\xf5\x60\x93\x95\x30\x4e\xf3\x72\x9a\x4f\x96\xf1\xe5\x55\xd7\xf7\x25\xd4\x55\xbd\x28\x30\x27\x67\x40\x7f\xa1\x4d\xe5\x1c\x30\xa5\x19\xcc\xa2\xec\xb8\xb2\x5d\x3a\xb4\x54\xcf\x2a\x1c\x1b\x63\x63\x4c\x7c\x7a\xac\xf3\x3d\x30\x3f\xd2\x44\x16\x8e\x69\x75\xc7\x08\x15\x8d\x5d\xe7\x4e\x57\xd0\x47\x14\xc6\x5d\xd4\x57\x01\x57\xb0\x83\x49\xf0\x67\x7c\x1f\xeb\x35\x05\x21\x81\x52\x95\x0d\x50\xf4\xd7\xd3\x8b\xa8\x67\x8a\x17\xb4\xf0\x62\x22\xd0\x3e\x8a\xfa\xb8\xce\xfb\xb7\x47\x7d\x98\x9b\x1f\xe1\x77\xc4\xa1\x0b\xa7\xa1\xd8\xab\x5e\x2c\x6b\xc1\xa4\x9f\x59\x55\x3a\x6f\x80\x3e\x8b\x34\x25\x8c\x0d\xec\x00\x45\xa5\x1e\x1c\xe6\x9f\x73\xcc\x17\xcc\xae\x77\xca\x65\x30\x41\x50\x47\x6c\x65\xf9\x82\xa1\x47\xa3\x11\x8a\x14\x98\xa8\x59\xd9\x4e\xc1\xac\xba\x3b\xa5\xb4\xa2\x71\x04\x7f\x24\x3f\xe5\xe5\x14\x9c\x68\x89\xf5\x48\xd8\x7f\x92\x5f\xfd\xf2\x95\xf7\x24\xe7\x80\xde\x1a\x6a\x03\x5e\xb0\x5b\xa7\x84\x2d\x0a\x7e\x79\x78\x35\x6c\xb5\xc8\x72\xd8\x59\xbe\xc5\x7c\x96\xe0\x31\x8b\xdd\x09\x3b\x70\xe0\x69\x73\xd2\x1d\x38\xb4\x3d\x19\xa1\xef\x0e\xdb\x23\x85\x98\x30\x31\xce\x5f\xe4\x31\x46\xdc\x1a\x11\x42\xd1\x71\x96\xdf\xa2\x54\xac\x9e\xa3\xdf\x23\x5c\x10\xca\x11\xfc\x1e\xa8\xb3\x8f\xdf\xa3\x93\x63\xc6\x69\x55\x4e\x4f\x14\x98\x47\xc7\x7d\x55\x80\x5e\x12\x4e\x52\x4e\x32\x14\xa1\xfd\x00\x70\x81\x5c\xc2\xab\x57\xf9\x3d\xc9\xe2\x67\xdd\x60\x9d\x08\x31\x92\x56\x65\xc6\x80\xee\xd0\x44\x86\xa7\xa0\x31\xe1\x77\x84\x94\x68\x59\x2d\x0c\x13\x83\xbb\x84\xcf\x88\xa2\x4a\xe2\x06\x20\x52\x52\xe4\x84\xa1\xaa\x44\x38\x4d\x17\x14\x73\x22\x41\x42\x13\x80\x0d\xa2\x33\x87\x10\x8b\x14\x2f\x18\x41\x8b\x92\xdc\xd7\x72\x04\x52\x9d\xc8\x59\x62\xc9\x71\x3f\xcb\x6f\x4f\xa2\x06\xbe\xdd\x75\x73\xbf\xb2\x3c\x0c\xe7\x4c\x83\xb6\x78\xea\x7f\x61\xe6\x13\x56\x4b\x90\xf7\x64\x1f\xab\x75\xe1\x7c\x56\x41\xac\x55\x49\x3b\xc5\xa4\x35\x84\x3e\x28\xf2\x9b\x04\xbe\xc0\x63\x52\xf4\xaf\xaf\xc5\xc2\x70\x7d\xdd\xbf\x85\x78\x3e\xd3\x72\x9d\xc4\x3f\x4c\xd6\x1f\x20\xe7\x9b\x89\x8c\x6f\x71\x5e\x08\x0a\x21\x79\x34\xcf\x1e\xf9\xd2\xde\x94\xf3\x95\x15\xbb\x1a\x4f\xc9\x8b\xaa\x9c\xe4\xd3\x04\x17\x85\xa5\xb0\x91\x73\x58\x56\x79\x95\x55\x03\x94\x55\xc6\xb1\x07\xf8\xd8\x06\x3f\xa2\xf7\x14\xa5\xb8\x14\x9b\xbe\xcf\x0b\xc6\x51\x91\xdf\x12\xc1\xb8\x82\xb3\x45\x17\xa6\xbf\x49\x45\x51\x0c\x5e\x65\x08\x43\x44\x39\x3a\x0e\xe3\x90\x14\xa4\x9c\xf2\xd9\x10\xe5\xfb\xfb\x01\x5a\xb8\x86\xc2\xe5\xe1\x95\xb1\xd8\x71\x96\xc5\x62\x45\x78\x0f\xcf\x71\x10\xf4\x65\x7e\xd5\x0b\x77\x7a\x99\x5f\x75\xbb\x41\x3a\x41\xa7\x93\xc5\xdf\xfe\xb6\xfc\x08\x12\x65\x42\xea\xe4\x3f\x10\xb6\x01\xec\x64\x7b\x1e\xe1\x45\xdd\x76\xf9\x1c\xd7\x03\xf4\x65\xb5\xb6\x23\x61\x15\x08\xfe\xc2\x33\x82\x65\xec\x9b\xc1\xca\x48\xe6\x26\xb9\xfc\x76\x76\x59\xe9\x23\x96\x2d\xd2\xe9\x61\xe8\x4a\x24\x20\x0b\xa8\xc8\x20\x2c\xb9\x7d\x42\x23\x49\xa2\xd7\xd2\x22\x49\x72\xe6\x6e\x62\x9d\xb9\x30\xb5\x34\x1b\xa4\x55\x99\x62\x1e\x9e\xc8\x2e\x1a\x84\xe7\xd1\x0f\x14\xe3\x86\x92\x92\x42\x78\xc1\xab\x73\xb0\x44\x07\xd2\x56\x53\x07\x50\x80\xe9\x40\xfd\x95\x65\x39\x27\x73\x36\x00\x63\x42\x16\xcc\x31\x4f\x67\xc4\xa1\x3b\x8a\x5d\x6f\xa9\x76\x8d\xdc\x11\x34\xc3\xb7\x44\x31\x00\x70\x7d\xba\xa0\x94\x94\x5c\xd2\xa1\x87\xd8\x4d\x5e\xef\x59\x3d\xd0\xe4\x2f\x49\x08\x50\x09\xb0\xea\xc1\x63\x6b\x8a\xdb\x0d\xdc\xea\xc3\xf5\x95\xe7\xb8\x16\x1c\xbc\xda\x50\x85\x6a\x3e\x87\xc2\x64\x92\x17\x9c\xd0\xd8\x42\x4f\x94\x05\x1f\xf7\x51\x7f\xda\x43\x51\xd4\xed\xa9\x05\x5a\xd2\xcf\x93\x8f\x9a\x0a\x5d\xa9\xd7\x5d\xcf\x42\xaa\x2b\xc6\xc5\x3b\xbd\x06\xdb\x35\x6a\xd5\xdd\x8a\x5e\x32\xa9\xe8\x29\x4e\x67\xd6\x3c\xa7\x01\x65\xd1\x18\xf9\x25\x4d\xf4\xe9\xc3\x15\x1a\x21\x3a\x0c\xf4\x68\x24\x52\xd9\xf4\x62\x92\x51\x5e\x06\xe1\xe9\x18\xbd\x3d\xc5\x46\x94\xb7\x19\xc4\x51\xfc\xf0\x98\x88\x6a\x16\x6b\xdc\x1b\xbb\x78\x6b\x05\x19\xc4\x7e\x7c\x95\xb0\xb4\xa2\xd2\x94\x0a\xbc\xc7\xea\xbd\x1d\x96\x1e\x03\x78\xdb\x0e\xd1\x8f\x08\x27\xf2\x20\xf8\x45\x35\xaf\x31\x25\xf1\x58\x48\x52\x6e\xc6\x6e\xa8\xe0\x0c\x9e\xf9\xbe\x14\x79\x0c\x32\xcb\x19\xac\x07\x10\x79\x3b\x83\x50\x5d\x84\x27\x5c\x98\x35\x9c\xe3\x74\x06\x16\xc0\x8c\x20\x23\x81\xa8\x2e\x16\xd3\xbc\xec\x21\xcc\x50\xce\x25\x94\x8a\xcf\x08\xbd\xcb\x19\x41\x63\x4a\xf0\x0d\x6b\xb4\xd0\x34\xc2\x45\xce\x97\xc9\xde\x9a\xb8\x1b\x4f\xbb\x8c\xf3\x32\x53\xff\x3f\xbd\x25\x25\x67\x5a\x85\xae\x36\xea\xb4\x29\xe1\xef\x4d\xc0\xf4\x76\x13\xa3\x11\x60\xbd\x1a\xfa\x51\xd7\xe0\x52\xd2\x57\x00\x10\x8a\x9c\xc8\x3f\xc5\xff\x91\x09\x6b\xd0\x05\x8c\x93\xba\x59\x02\x47\x57\xfa\xd1\x3d\x0d\x16\x82\x72\xb5\xde\x93\x20\xeb\x74\x13\xe2\x89\x07\x44\x6f\xf5\x74\x44\xb4\xbb\xd5\x12\x96\x8e\xbd\x49\x92\x88\x47\x27\x94\x2b\xc9\xcb\xe7\x94\xe2\x65\x2c\xca\x7b\xde\x10\xbb\xc2\x5c\x77\xac\x75\x08\xb3\x55\x50\xc0\x6e\x52\x4b\x39\x3a\x41\x9e\x4d\xaf\x68\x07\x7b\xef\x2b\xa7\x67\x68\x63\xe5\x10\xf9\x1e\xbc\x6d\x51\xf4\xdb\x3d\x7c\x3e\x1c\x15\x82\xdc\xd8\xdc\x0e\x9d\x1a\x32\x0c\x8e\x35\x22\xe3\xa4\x8f\x00\xc4\xc3\xdc\xa4\xd9\x66\xd1\x62\xca\xc8\x4b\x61\xc8\x4b\x54\xad\xce\x12\xac\x71\x41\xee\xb9\xe5\x35\x28\xfa\x78\xaa\xf6\xb7\x1f\xc9\xf4\xf4\xbe\x8e\xa3\xff\x8c\x2f\x0f\x0f\xfe\x72\xb5\xdf\x8d\x2f\x97\x77\xd9\x6c\xce\xae\xf6\xbb\x8f\xe5\xe2\x2d\x1a\xc9\xc5\x49\xf0\x9c\x81\x98\x40\x59\xac\xc0\x99\xd0\x89\x47\xaa\x6a\x17\x7d\xd1\xd6\xa1\xb9\xe3\xa0\x5e\xe9\x59\x7b\x34\x42\xdf\x35\x22\x59\x7e\x38\xd4\xce\x03\xd1\x2b\xcc\x17\x1a\x21\x18\xde\x59\xc9\x35\x80\xcb\xa3\x2b\x83\xd9\xa2\xcc\xc5\x52\xa2\xdf\x3c\xbb\x72\xc8\x27\xdb\x3f\x6d\x5f\x1e\x71\xae\xf6\x5c\x0a\x00\x57\x3b\x58\x25\x8e\x77\x70\x67\x21\x06\xe2\x9c\xab\x4d\x9b\x3d\x79\xb1\x73\x25\x57\x67\x1b\xe7\xeb\xc4\xf2\x85\xec\xd9\x0d\x37\x82\x42\x56\xad\xa0\xb9\x87\xc2\x71\x08\x85\x0d\x40\xc1\x6a\xf5\x7d\xe6\x0d\x5c\xb7\x34\x1e\x36\x0d\x91\xb6\x97\x07\x6d\x70\x32\xdb\x8d\xa3\xbb\xd1\x58\xed\xe2\x05\xf2\xdc\xb9\xff\xfc\x09\xdb\x3e\x53\xe8\x00\x1d\x89\x59\x3d\x91\xb3\x7b\x70\xb0\x76\xd6\x4e\xfe\xff\x99\xb5\x29\xe1\xa7\x26\x12\x72\xfb\x94\x81\xc2\x71\x03\xf6\xd0\xd7\xaf\xc8\x2b\xf0\xb1\xa6\x3a\x9e\x57\x79\x9c\x95\xae\x71\xc3\xe8\x76\x89\x20\xdc\xbe\x89\x11\x0b\x3e\x3d\x7f\xd8\x60\x9c\xb0\x32\x79\x66\x63\x9a\x3b\xd1\xb4\xcc\x16\x9a\x48\x31\x85\x7e\x06\x37\x48\xb7\x20\xc6\x82\x38\x01\xa8\x8d\x97\xf0\x76\x21\x8b\x42\x68\x47\x4d\x7a\x5a\x06\x8e\x8f\xd7\x90\xa5\x24\x77\x0a\x65\x35\x75\x9a\x40\x2e\x91\x95\x18\xaa\xba\xb0\x5f\xdf\x59\x7e\x51\x1f\x3d\xeb\xa1\x8e\xf2\xaf\x75\x82\xf4\x56\x80\x9d\x77\x3e\xeb\xef\xa8\x90\xfe\xd1\xe3\x66\x8b\x31\xa7\x38\xe5\xff\x47\x0d\x7e\x4a\xf8\x5b\x1d\x7b\xfa\x10\xb1\x56\x01\xab\x46\xaa\x55\x64\xe2\x43\x85\x7a\x87\xd0\xc8\xdd\x65\xfa\x01\x03\x09\x88\xf4\x5b\x07\x4d\x4d\x64\x55\xf6\xad\x02\xdd\x46\x68\xbb\x3c\xef\x1e\x89\xba\xa3\x38\x3f\x90\x2a\x9b\x39\x5b\x13\xa9\x25\xd0\x47\x87\xeb\x18\x55\x35\xf9\x3b\x09\xe9\x3f\x7e\x34\x46\x4c\xff\xd1\x43\x72\x6a\xef\x7e\xe7\x3a\x2d\x08\xa6\xd2\xc5\xd7\xf5\x0b\xf5\x01\x49\xb7\xb1\xfe\xb6\x2c\x04\xbb\xf6\xdb\xc0\x15\x1d\x19\xc0\x66\xd5\x5d\x1c\xb8\x32\x91\x90\x79\xcd\x97\xb1\x1b\x46\x8c\x29\xdf\x70\x1c\xf7\xf7\xb0\xdb\xd4\xc5\x56\xbb\xd1\x33\xdb\x8d\xf5\xbb\x5f\x7d\xf1\x4d\x6f\xaa\xaf\xa2\xae\x1e\xfd\xd7\xaf\xf2\x78\x6a\x8e\xef\x63\xf8\xcf\xa4\xa8\x2a\xea\x5b\x74\x7d\xf4\xec\xfb\xc3\x6e\x0f\x1d\x39\x1b\xac\xd6\xbe\x72\xb7\x2d\xa7\x6a\x6f\xef\x8f\xb4\x6c\x07\xe7\xc8\x12\x7a\xd2\xac\xdd\xd4\x48\xa6\x9e\x7b\x2a\x0b\xa3\xff\x75\x46\xbd\x33\x59\x5d\x98\xe0\x71\x45\x1d\x95\x0b\xfb\x31\x5a\xe8\x9e\xd4\x81\x83\x7e\xac\x31\xc5\x73\x7b\x63\x3c\x02\x28\xd1\xa0\xb9\x41\x36\xe1\x5a\xb2\xbe\x8c\x0d\x44\xa3\x35\x31\x8b\xe8\x47\xd4\xe1\x74\x41\x20\x56\x09\x5c\xae\x52\x86\x54\xe3\x66\x5c\x8f\x03\x67\x53\xe8\x53\x1b\xe2\xa6\xbb\xfe\xc6\xf7\xa0\x3a\x05\xa6\x45\x23\x33\x27\x07\x1e\x7b\x0e\xdd\xaa\xf2\x1e\x92\xaa\x38\xf4\x81\x10\x31\x6a\xcb\x98\xde\xdb\x75\x3e\x8a\x00\xaf\xc8\x76\x0b\x5a\x88\x5d\xc9\x86\x33\x68\x19\x21\x13\xa9\x18\x08\x39\x75\xae\xc2\x08\x9c\x37\xb9\x51\x43\xa0\x76\x34\x31\xdb\x95\x87\x32\x46\xd8\x0b\x8a\x53\x63\xe1\x52\xbc\x25\x5f\xee\x8a\xed\x37\xe3\xf9\x42\x46\x4c\x6d\xc7\xd4\x09\xb6\x93\x6c\x2b\xff\xd3\x70\x8b\xfd\x3a\xa3\x68\xb4\xee\x4c\xb0\xa1\x3f\xe4\x35\x45\xf9\x32\xea\x7a\x67\x85\x0b\x5a\x6c\x3b\x01\x14\xe5\x03\x85\xc4\x3f\xfb\x54\x10\x5a\xc1\xb1\xd0\x96\xd3\xbf\x56\x57\xea\x68\x9c\xad\x01\xaf\x57\x13\xbf\x6e\xf0\x9c\xcc\xf3\xc3\xca\xb9\x54\x4f\xfe\x31\x96\xa1\x4a\x6c\x4e\x1a\xfd\xf9\xdd\x76\xdc\x75\x3f\xa3\x3d\x08\xa6\x6d\xd2\x4e\x94\xa1\x47\x23\x14\x81\xda\x6b\x50\x0c\x94\x30\xa5\x2e\x79\x44\x9b\xfb\x19\x4d\xb4\xf2\x81\xfb\x08\x8f\x42\xc9\x41\xf4\x3f\x42\x05\x37\x35\xdb\x48\xca\xbb\x90\x1b\xf7\x4c\x9a\x8d\xe5\xfc\x5e\x90\x7b\xee\x35\xda\x7a\xea\x4b\xee\x49\xba\x80\xbc\x16\xea\xd4\x31\x42\xfb\x02\x6c\xeb\x90\xdd\xa1\x5e\x5a\xcd\xeb\x82\x70\xb2\x33\x01\x47\x6b\x08\xb8\x9e\x99\xc0\x8a\xb6\xce\xcd\x60\x58\xce\x81\x35\x15\x86\x5e\x43\x5e\x71\x5c\x88\xe2\x73\x79\xcf\x04\x52\xd4\x6c\x9a\x21\x79\x41\x64\xc3\x34\xad\x6d\xa4\x4e\x8e\x84\xf0\xc2\xba\x10\xb1\x14\x17\x98\xb6\x02\x6b\xda\x28\x1d\x05\x26\x37\x9f\x6c\xec\x05\x30\x2c\x17\x45\xb1\x1d\xfa\x26\x30\xda\x6d\x18\xe4\x93\x95\xef\xea\xb1\x66\xda\x8c\xcf\x8b\x38\x7a\x53\x61\x19\x2e\x22\x19\xc5\x4c\xd1\x3e\x8a\xe6\x0c\x1d\x8f\x29\xea\x9f\x20\xbb\x10\xc9\x5a\xce\x72\xb5\x8f\x22\x5d\x4d\xbc\x89\x2e\x04\xe6\x32\xfe\x44\x5e\x0a\x92\x2d\x1a\x03\x6a\x1e\xdd\x35\xa3\x4d\x2d\xea\x3b\x1c\x36\x1b\x11\x70\x57\x90\x39\x9b\x6e\x71\x86\x88\x16\x89\xd0\x29\x50\xb7\x51\xae\x8d\xdb\x6d\x61\x6a\xc6\xc4\xde\xdd\x2c\x77\x3a\xee\x74\x9a\xfd\x6a\x02\xec\x30\xe4\x5f\xcc\x55\xee\xdd\x07\xad\xb4\xb3\x9c\x7b\x6f\xd8\xfa\xcd\x43\x06\x1e\xc0\xe0\x01\xdd\xbb\x83\x37\x2f\x76\x1b\xbe\x77\xd9\x76\x87\xee\x5d\xc3\x4f\xb0\x66\xb5\xe0\x67\x2f\xb5\xcc\xdd\xe5\x65\x56\xdd\xc9\x11\x5d\xc8\x97\xcd\x9a\x66\x03\x94\x37\x52\x58\x84\xb6\x27\x8d\x1b\xc3\x76\x8f\x02\x1b\x2d\x0d\xc1\x3f\x5a\x31\x37\xaf\x74\x97\x68\xa4\xf1\x62\x52\x3d\x0a\xac\xc2\x41\xa6\x01\xe7\x2d\x94\x37\x6f\x24\xef\x41\xa2\x18\x33\x82\xa7\x2a\xfb\xdc\x76\x6a\xcb\x74\x4c\x6f\xf0\x98\x14\x9e\x91\x06\x41\x50\xcc\x92\x1c\x9e\xcf\x21\x8a\x94\xa9\x4c\x6d\x8e\x43\x1d\xde\xc2\x65\x10\xa7\x99\x24\x8a\x7c\x25\x16\x65\x1d\x51\xe5\xa8\x5b\x17\x6a\x52\x2f\xd8\x2c\xb6\x81\x02\x68\x5f\x81\xdd\x77\x22\x04\xd4\x8a\xc7\x52\x5c\x93\xd7\x17\x6f\xdf\x28\x3c\x2f\xe1\x8f\x09\xe0\x59\xf9\x1e\xa6\x42\x8f\xce\x0f\x10\x94\xc5\xbf\x47\xb6\x2b\x8d\xc9\xe7\x2a\x2f\xe3\xe8\x78\x4c\x4f\xa2\xae\xec\x1e\x22\xe8\xb6\x12\x53\xc6\xd4\x5c\x54\x17\xec\x9d\x3c\x51\x5d\x4b\x4e\xae\x6b\xa8\x37\x89\x26\x8e\xd8\x9d\x76\x3a\xd0\xeb\x97\x68\xb8\x89\xf8\x5b\xa9\xbf\x9d\xfc\x01\xfa\x1b\x92\x8f\x7e\x8f\x0c\x5d\x34\x7d\x45\xf9\xef\x91\x89\x18\x82\xd5\x47\xfc\xa8\xd1\xec\x8f\x42\x64\xec\x49\x1a\xae\x22\xc7\x71\x26\x1b\xec\x76\x6a\xfa\xb3\x3a\x63\x34\xb4\x84\x43\x43\x4b\x4a\x29\xb1\x50\xf5\x55\x51\x61\xae\xde\x6b\xa1\xcc\xd9\x3b\xfc\x4e\x94\x19\xbf\x47\xbf\x8f\xa2\xfd\xb3\x72\x12\xf5\x50\x74\xa0\xfe\xc2\x33\xba\xcb\x8b\x02\x8d\x89\x04\x96\x09\x71\xaa\xd0\x3b\xfc\x0e\x8d\x97\x2e\xfc\x6e\x82\x2e\x66\x44\x83\x4a\x71\xd9\xe1\xa2\x11\xc4\x96\x93\xac\x87\x58\x05\x17\xda\x11\x9f\x91\x39\xc2\x0c\x4d\x71\xcd\x50\x0c\x96\x80\x77\x1d\xca\xdc\x7d\xf2\xce\x43\xb7\x12\xc5\xbb\x12\xdb\xdc\x57\x6d\x74\x82\xd5\xb8\x20\xdc\xdc\x8a\xff\xa8\x12\x32\x26\x2f\xaa\xa2\xa2\xc9\x07\xf9\xd2\xba\x8d\xc0\x38\x77\x0c\x26\xc1\x43\x73\xcc\x69\x7e\x1f\xf9\x2a\xca\x1a\xa9\x2a\x3e\x2e\x67\x70\x3f\xac\x9a\x20\x59\x1f\x22\x3a\x1e\xa1\x0f\x05\xc1\x8c\xa8\xdc\x5b\x18\xa5\x15\xa5\x24\xe5\x90\xa7\x85\x30\x96\x57\xa5\x09\x16\x55\xd4\x90\x7c\xbe\xb2\x6e\x5a\xac\xa3\x13\xa9\x09\x79\xb1\x7a\x93\xb3\x66\x48\xc3\xd0\x3c\x49\x2e\xb6\x31\x0d\x9c\x29\x59\x35\x97\xd0\xac\x50\xa8\x60\x08\x6d\x1b\x0e\x5d\x55\xc5\x9c\xc8\xa9\x86\x89\xaf\x63\x28\xac\x6a\x92\xbe\x21\x4f\x25\xd8\x8e\x6d\xac\xa1\x01\x6c\xde\x59\x25\x66\x48\xe1\xf6\x32\x80\xdf\x9e\xd7\x7c\xa0\xfe\xfa\x7b\x51\xce\x64\x44\x05\xf3\x29\xe5\x08\x90\xfc\xd7\xe8\x44\xfc\xbb\x1f\xc8\xc3\xf9\xcb\xc3\x2b\x37\x62\x6b\x39\x70\xd6\x46\x90\x4c\x09\xed\xf2\xe8\xaa\x6b\xad\x52\x1b\x4d\x64\x37\x21\x85\xd8\xc2\x29\x0e\x4c\xe0\x31\x96\x2d\xe4\x66\x1e\xc8\x01\x66\x6f\x2b\xac\x8b\x39\x82\x2b\x23\x82\x61\xc6\x18\x28\x40\x5c\x14\x68\x9e\x33\x26\x4c\x15\xc6\x49\xcd\x12\xcb\x02\xe4\xce\x58\xd8\x4a\x65\x4a\x31\xa8\x9c\x4d\x86\x51\xa2\xdc\x59\xf6\x8d\x8f\x68\x88\x38\x3a\xf6\xcb\x49\x99\x89\xd2\xfd\x66\x6d\x52\x7b\x81\x80\xcf\x8b\xa2\xba\x03\xe8\x13\xa1\x34\x04\x7a\x75\x95\x97\x1c\xe5\xa5\x8c\xe8\x4e\x97\x89\x7b\x88\x2b\x4d\x7e\x13\x2d\x23\x70\x7c\xf2\x04\xc9\xe2\xcb\xba\x62\x57\xc9\x3d\x3a\x16\xfd\xb6\xba\x95\x5e\x41\x77\x3a\xcd\xc0\xa5\x4a\x77\x80\x38\xa6\x79\x5d\x41\x62\x4e\x35\x51\xcd\xed\x6a\x03\xc4\x97\xfb\x01\xe2\x3d\xa4\xc2\x5c\x57\xdd\x76\x88\x0e\x42\x26\x8b\xab\x69\x6b\x27\xd6\x9e\x97\xe0\x1d\xed\xbf\x56\x8a\xdc\x8d\x67\x51\x40\x13\x97\x82\xda\xeb\xe7\x9b\x61\x90\x30\x0a\x12\xd8\xe2\x72\x89\x38\xc5\x29\x61\x42\x4d\xe1\x12\x91\xfb\x5c\x26\x8c\x04\x35\x9e\xf8\x99\x88\xac\xd7\xdb\xe9\xce\xa6\x31\x4a\x67\x79\x91\x51\x52\xc6\xdd\x40\xb8\x93\xad\xdb\xb8\x31\x04\x2f\x20\x31\x92\xf7\x62\xd5\xcc\xb0\xf4\x38\xee\x38\x66\x4b\x24\x53\x2b\x9d\x48\x93\xc4\xb1\xb2\x75\x8a\xa5\x46\x75\x95\x5b\xa9\x5d\xdf\xa2\xdf\x4a\xb3\xb9\xad\x12\x74\x65\x8f\x00\x48\x99\xa9\x03\x80\xb5\x9e\x6d\x41\xf9\x17\x55\x79\x2b\x64\x97\x57\xe8\xd3\xbb\xb3\x5f\x91\xc9\xc1\xa2\xd3\x6c\x3a\x1e\x84\xdd\x4f\x46\xbf\x7e\x45\xdf\xfd\xa0\x7a\x38\x9a\xe9\xec\xb2\x49\xe0\x74\x42\xa3\x79\x60\x3a\x32\xc3\xdc\xae\x77\x3e\xe0\x0c\xe2\xa7\x55\x2e\x8c\xbb\x9c\xcf\x50\x5e\xde\xe6\x2c\x1f\x17\x04\x45\x42\x2a\x22\xa9\x30\x19\xc2\x1c\xc2\x18\x53\x88\x4c\x5e\x50\x92\xa1\xfb\x03\x31\x09\x68\x5c\x2d\xca\x0c\x03\x00\x52\xb2\x05\x25\x4c\x83\xe7\x33\xcc\x25\xe7\x31\x84\x29\x41\x59\xce\xea\x02\x2f\x49\x26\x7b\xc2\x68\x92\xdf\x5b\x38\x40\x05\x2f\xb5\x59\x89\xeb\x1a\x02\x2e\x2b\xe8\xda\x44\x79\x1b\xf8\x62\xe0\xba\x19\x54\xb1\x59\x37\xac\xfa\xb9\x3c\x14\x5a\xe6\xc4\x52\xcd\x89\x51\x91\x34\x5a\x94\x90\x2d\x13\xf4\x81\xa9\xd5\xd2\x0b\xab\x26\x5c\x5f\xbb\x1d\xa0\x23\xa9\xcd\xd4\x8c\xb4\x7a\x31\x2a\x47\x55\x08\x76\x60\xaf\xd7\xbf\xab\xee\x50\x4a\x09\xdc\x91\x99\x11\xb0\x6d\x7c\x21\x6e\xa5\x9e\x76\xad\x1f\x99\xe4\x43\x62\xa0\xc2\x10\x07\x0e\xf3\x9b\xf5\x4f\x26\x38\x1d\xd8\xa3\x23\x47\xb0\xc1\xbf\x21\xf3\x9d\xc6\xdd\x1e\xa8\xe3\x9e\xda\x7e\x66\x7c\xb6\xa1\xcd\x2f\xe2\x3d\x38\xc7\xfe\x7c\xd8\x43\xcf\x4c\x3b\xb9\x2b\x23\x74\x10\xc8\xe9\xf2\xa3\x8a\x0c\x8d\xd0\x00\x45\x45\x5e\x12\xed\xa9\x86\xdd\x5f\x5d\x15\x58\xf9\x72\xc4\x3b\x4c\x95\x7b\x5a\xfb\x6b\x0c\xbf\xab\x98\xf6\x5c\xd4\xc4\x0b\x5e\x45\x3d\x8f\xa8\xaf\xf2\x32\x83\xdb\x46\x8c\x28\xce\xec\x30\x34\xc7\xf7\xfd\x79\x5e\xee\xad\xc9\x36\x23\x94\x2e\xa7\xd6\xb4\xe8\xf7\xd1\x2f\x33\x52\xea\xb4\x32\xc2\x2e\x94\x17\xba\x33\xb3\x16\xcf\xf1\xbd\x5d\x8b\x37\xc8\x22\xb7\xde\x25\xc3\x2d\xa2\x7d\xba\xa0\x54\x96\xbf\x75\x21\xc9\xec\x51\x6a\x05\x0b\x43\x14\xa5\x1f\xc4\x8a\xdc\xf4\x81\x9a\x17\xc9\x12\x9d\x34\x3a\x78\xf2\x04\xb9\xaf\x1f\x85\x1c\x7c\x4d\x94\x9c\x06\x01\x2f\xad\x59\x4a\x05\x25\xf6\x47\x7e\x6b\xc5\xed\xee\x82\xe1\xf1\x72\x22\xc9\x37\xc7\xf7\x4f\x8f\x92\xc3\xef\xd7\x57\xcb\x4b\x4d\x1b\x6f\xa5\x87\x19\x80\x77\x67\xe5\x24\x2f\x73\xbe\x1c\x36\x66\xe6\xc0\x7f\xf1\xc0\x19\xfa\xfb\x4c\xc2\x31\xe0\xb8\x0b\xe9\xe5\x58\x36\x12\x3c\x34\xc7\xf3\x1d\x67\x76\xbe\xfb\x7c\xae\x9c\x9c\x0c\x80\xd5\x08\xa6\xa9\x19\xf4\x17\x9e\x4c\xb4\x6f\xfd\xcd\x6b\x67\x53\xfc\x1e\xe8\x7a\xa1\xb4\x56\xeb\x81\xc7\x87\xc9\xd1\xd3\xd8\x5c\xd1\x14\x85\x07\x02\x5e\xd7\x6e\x4a\xb6\x74\xbb\x15\xc2\x4a\x3b\xd5\x04\x2b\xdd\x2b\xd3\xa4\xad\x77\x13\x30\x7f\xe0\x84\xe0\x8b\xd4\x32\x83\x90\xca\x76\x6e\x73\x2f\xb7\xc0\xfa\x4d\xa9\xf2\xb5\xc0\xa4\xde\xab\x68\x4e\x4a\x6e\x34\x25\x99\xe8\xa8\x7b\x9e\xa7\x37\xaf\x54\x5e\x3c\xb8\xd2\x22\x93\xe4\xfd\xdb\xdb\x9f\x2e\x7a\x81\x35\x02\xd0\x51\x6b\x84\x7b\xe5\xdb\x27\x9d\xca\x79\x6e\x47\x31\xab\x6e\x09\x7d\x49\x38\xce\x8b\xf0\x58\x5e\xdb\x0a\xbb\x0d\x48\xa2\xe9\xdd\x3e\x89\xa5\xce\xef\xa1\xfb\x1e\x5a\xfa\x6a\x53\x85\x3c\x75\x8e\x59\x8d\x4b\x6d\x2a\x8a\xc2\xe8\xa4\x83\xf6\xed\x01\xce\x3d\x7a\x0a\x06\x5c\x37\xe1\xd5\xa7\x8b\x17\xd2\xb1\x13\x77\xd1\x3e\xea\x1c\xf7\x45\xdb\x93\xce\xd0\x01\xcb\xee\x30\x4f\x67\x6d\xc0\x30\x8e\x6b\xf9\x36\x92\x69\xb7\x46\xd1\x18\xa7\x37\x53\x2a\x4c\xa2\x03\xb5\x3b\xec\xc0\xee\x06\xd4\x05\x94\x88\x6e\x84\xe5\xda\xee\x28\xad\x4a\xae\x62\x24\x64\x97\xfb\x48\x8d\x36\x09\xf9\xd3\xc0\x30\x93\x4e\xb5\x01\x72\x1d\x8c\x4b\x35\x12\x59\x62\xba\x70\xc2\xbb\xa0\xc2\x98\x02\x59\x74\xaf\x4e\x91\xf2\x0a\x5b\x1f\xaa\x8f\x46\xdb\x5e\x01\x6f\x84\x4e\x6f\x1a\x98\xf8\x37\xf0\x2e\x68\x8f\xc8\x66\xc6\x20\xd9\xc8\x10\x4e\x6f\xb3\x7c\x3a\x2b\x84\x69\x02\x29\x52\x03\x5d\xfe\x44\x66\xf8\x36\xaf\x68\xa2\x54\xf5\x6b\xdd\x20\x46\x3b\xb1\x9e\xc4\x6b\xa0\xfe\xfa\x9d\xb3\x19\x29\x6e\xe5\x31\xc2\x0e\x3d\xcb\xdc\x6a\xbb\x31\xfc\xba\x5e\xdd\x48\x02\x93\x27\x65\xab\x13\x9c\xe5\x7f\xfb\x96\x2d\xa7\xaf\xa6\x9a\x07\x7e\x01\x4d\x60\x36\x05\x26\x14\xe1\x5b\x4d\xc4\x0d\x56\x81\x55\x37\x3b\x04\x74\x07\x82\x43\xb6\x04\x6b\x84\x69\x22\xf6\xd6\x0a\x0b\x95\x21\x90\xa1\x1a\x33\x06\x37\x86\x6d\x02\xc1\x49\x45\x8d\x3d\x28\x37\x3c\xe0\x30\x75\xb2\x06\x32\x7c\x4b\xf6\xd4\xae\xc8\xc9\x15\xf8\xfc\x5f\x9f\xff\x6a\x32\x68\x89\x5d\x4c\x45\x33\x42\x65\x9a\xc1\x03\xe3\x13\x45\x39\x97\x6e\x5b\xa7\x4f\x09\xec\x4e\x58\xa2\x02\xe2\x82\x11\x2a\x36\x58\x62\x7f\x24\x2f\x9c\x01\x3e\x6e\x5e\x68\x93\x62\x50\xf9\x1b\xbd\x8d\x62\x38\x35\x21\x38\x5f\xb7\xba\x23\x82\x5e\xd3\x77\x15\xa0\x09\xee\x21\x86\x26\x42\x23\x36\x3c\xa1\x6d\xbf\xc0\x05\x1e\xfb\x99\x25\xdd\x64\x41\xce\x09\x91\x49\x61\xb8\x13\x17\x34\x42\x6f\x1a\x01\xab\x78\x27\x3e\x90\x81\x88\x36\xeb\xd1\x66\x2c\x5d\x4a\x4b\x7f\xb8\x3e\x20\xf9\xa9\xca\x96\x9a\xd4\x0e\x38\x3f\x53\xfb\x35\x24\x07\x40\x7c\x5c\x65\x2a\x47\x27\xb4\xf3\xa2\x14\xd9\x5d\xce\xd3\x59\xdc\x38\xff\x57\xf7\x94\x31\x23\x28\xba\x25\x29\xaf\x68\x34\xd8\x73\xcd\x43\x73\x09\x56\x09\xb5\x58\x48\xda\xc7\xf2\xfe\xcc\xea\xee\x95\xb3\x24\x3a\xe6\xf4\xe4\x98\x67\x28\xad\x0a\xb1\x86\x8d\x3a\xcf\x3a\x27\xc7\xf9\x49\x29\x27\xfc\xb8\x9f\x9f\x1c\xf7\x79\x26\x7e\xe8\x49\xd4\xb8\x79\xe9\x6e\xa5\x3d\x7c\xd4\x62\x90\xcf\x73\x2e\xd5\xa6\x8b\x6e\xb7\xe1\xd5\x74\xae\x26\x05\x62\x0a\xfc\x0b\xf6\x30\x99\xca\xc0\xd5\x29\x3e\xf2\x2b\x77\xd9\x35\xa7\x56\x21\xd7\xb6\xf1\x6c\x0f\x37\xd1\xe2\xa4\x71\x7e\x27\x41\xaa\x53\x36\x41\x0b\x55\x45\x79\xae\x2f\x8f\xae\xec\x2b\x97\x4c\x92\x30\x70\x6b\x74\x68\x26\x52\x1d\x4f\x04\x27\xf2\xff\xd1\x09\xbb\xfd\xf6\x09\xbb\x6d\x4e\x98\xb9\xd1\x77\x41\xee\x05\xde\x91\x39\xfc\x30\xe8\x7d\x96\xe8\x7d\x46\xc7\xe8\x56\x9f\x2d\x68\xdc\x3e\xfb\xd9\x1a\x2c\xa4\xfd\x91\xa9\x7c\xf9\xf9\x4a\x4d\x29\xfa\x1f\x62\x9a\xdd\xf2\x43\x39\xd5\x63\xda\x3f\x89\x7c\x07\xf3\x1f\xe4\x25\x07\x93\x9d\x59\x49\x9d\xfe\x48\x56\x0a\xf7\x2e\xab\x78\x3d\xb9\x33\xb1\x8e\x73\x9b\x1d\x81\x4d\xbd\xb9\x23\xa8\xe2\x75\xe4\x8c\xda\xef\xb3\xbb\xa5\x53\xe5\x20\x1d\x04\x57\xa2\x4f\x25\x5b\xd4\x75\x45\x39\xc9\xd4\xd5\x4c\x38\xb9\x6b\x01\x59\x7d\xb3\x9d\x15\xfe\x7a\x5c\x28\x89\x4b\xf3\xb3\x4f\x9e\x93\xdc\xe9\xfc\x63\xb8\x78\x67\x9c\xec\xfe\xce\xc5\x6b\x69\x11\xc3\x63\x76\xbd\x74\x53\x1a\x2d\xcd\x3a\x2f\x5f\x9d\x8c\xd0\x11\x79\xf6\x2f\x8d\xdb\x2e\xf1\x12\xf5\x65\x79\xc2\x2b\x67\xe3\x14\xfd\x16\x0d\xfd\xe4\xe1\x2e\x94\xa3\x35\x50\x8e\x9a\x50\xfe\x63\x03\x94\xa3\x3f\x87\xa1\x1c\xfd\xb9\x09\xe5\x74\x13\x94\xef\xd7\x40\xf9\xbe\x09\xe5\xc3\x26\x28\xcf\xd6\x40\x79\xd6\x84\x72\xb1\x01\xca\x5f\xc2\x40\xfe\xd2\x84\xf1\xd7\x0d\x30\x7e\x08\xc3\xf8\xa1\x09\xe3\xed\x06\x18\xcd\xdb\xd0\x0a\xc6\x77\x4d\x18\x37\xeb\x61\x34\x20\x2c\x43\xf5\xbc\x35\x6a\x53\xc5\x63\x81\xd4\xc1\x3a\xde\x3b\x68\x33\xdf\x32\x8c\x98\x82\xb3\x86\xfb\x0e\xda\xec\xf7\xb7\x4d\x70\xd6\xf1\xdf\x41\x9b\x01\xf1\x46\x38\x6b\x38\xf0\xa0\xcd\x82\x93\x8d\x70\xd6\xf0\xe0\x41\x9b\x09\xeb\x4d\x70\xfe\x62\x97\xb7\x06\xa0\x16\x23\x96\x9b\xe0\xac\xe1\xc4\x83\x16\x2b\xfe\xaf\xff\xb9\x0e\xcc\x11\x39\x58\xc3\x8b\x07\x2d\x66\x9c\xaf\xc7\x25\xc4\x63\x5b\x32\x65\x38\x76\x8c\x97\xc7\x40\x5a\x33\x9b\xc2\x4f\xde\x3e\xff\xf5\xfa\xfc\xf4\xe3\xd9\xe9\xf9\xf5\xbb\x4f\x6f\xd5\x87\x51\xed\xad\x17\xc2\x18\x86\x8b\xe0\xd1\xfa\x1c\x6d\xaf\x08\x4f\x67\x4e\x8a\x36\xdf\xb4\xdb\x87\x04\x6c\xd2\xe7\x35\x5e\x70\x7d\x8a\x96\x97\x53\x54\x95\xc5\x12\x4d\x72\xca\xb8\x69\xdb\x40\x67\x1f\x45\x49\x64\xa2\x09\x7d\xc0\x27\x8d\xca\xad\x9d\x9c\x8e\x92\x57\x83\xf0\xc3\x58\x14\x2c\x56\x17\x79\x4a\xe2\xc3\x5e\x13\x58\x23\x06\x48\x56\x87\x84\x25\x32\x8d\x92\x4d\x73\xa4\xae\xe6\xd8\x64\x47\x03\x74\x09\xde\x20\xb9\x93\xd6\x4f\xcd\x44\x4a\x03\x99\x03\x12\xce\x53\xe3\x70\xd6\x67\x95\x2e\xb2\x23\x4f\x54\x05\x98\xc6\xb2\xf9\x01\xb7\x2e\xe9\x07\x6a\x34\xb2\x44\x85\x57\x7a\x40\xd6\x66\x5b\x01\x2f\x2b\xe0\xf6\xe9\xe3\x1b\x7b\xc6\xee\xd6\x0a\xda\xee\x5e\x05\x79\x64\xb8\xb2\xc1\x9c\xde\x5b\x7d\xee\x00\x5d\xe1\x2c\x93\x6e\x24\x64\x3e\x83\xf7\x38\x8e\xfe\x84\xb3\xec\x5a\x7d\xb6\x48\xa5\xfd\xf4\x6a\xcb\xcf\x43\x89\xa2\x1e\xfa\xb2\xea\xb6\xed\x8a\xc6\xf0\xf5\x80\xda\x24\x10\x83\x53\xe1\x9f\x62\x2e\x20\xbf\x3d\x23\x98\xca\x2f\x13\x46\x51\x43\x26\x75\x10\x94\x22\x1e\xcc\xed\x07\x7d\xe3\x25\x0c\x27\x61\x8b\xb1\xb4\x19\xe3\xa3\x2e\xf0\x1d\x8f\x3b\x4f\x3a\xe6\x2a\x9b\x85\xf1\x9a\x14\xb5\x71\x0b\x36\x07\xf3\xef\x8d\x6a\xb1\x1b\xca\xd1\x84\x21\x07\x6c\x9b\xb0\xd8\xc1\x74\x2b\xb5\x34\x95\x5d\x6a\xe9\x4f\x82\xfa\x7c\xd3\xc6\x55\xba\x38\x80\x64\x8f\xcd\xe7\x38\x9d\x0f\xb2\x29\x87\xbf\xfa\x58\xa9\x34\xb3\xc5\xcc\x4a\x07\xc9\xa7\x8f\x6f\xec\xd4\x76\x9d\xd7\xd2\xdc\x6c\xcc\x7d\x77\x0f\x12\x27\xee\x79\xc9\xf3\xa4\xf0\x49\x16\xd4\xf1\x13\xc0\x54\x72\x76\xba\xca\xbd\xd8\x8e\xf0\xd5\x51\x21\xc6\xf9\x68\xbf\x35\x21\xc8\xd5\xef\xa3\x77\xef\x2f\x4e\x07\x8d\x5c\x4b\x63\x82\x6e\x48\x0d\xa9\xd7\xd9\xb2\x4c\x65\x84\x40\x7f\xc1\xf3\x42\xa8\x49\xfd\x37\xad\xca\xdb\x64\x5a\x0d\x00\xee\x9b\xbc\xbc\x79\x55\xd1\x53\x13\x69\xb7\x61\x2a\x0c\x59\xc2\xc2\x0b\xb3\x2a\x57\x17\x88\xba\x6c\x52\xc1\x0b\x34\x9b\x4a\x39\x83\xc4\x43\x6e\x70\x5e\x43\x03\x48\x3a\xd8\x14\x4c\x3a\x42\xe6\x0f\xf3\xaa\x03\xe2\xfd\xf8\x33\x49\xb9\xce\x45\xe7\x32\xee\x94\x94\x84\x62\x2e\x79\x57\x56\xf3\x94\x8f\xc6\xdf\xd3\xe9\x8f\x65\x04\x56\xec\xc0\xd6\x41\xd8\xf2\xf3\x9f\x32\xf6\xf5\x89\xfa\x82\x98\x52\xaf\xc0\x23\xe7\x1c\x73\x12\x7f\x59\xf5\x50\x14\xf5\x90\x8c\xe7\xf9\x51\xec\xe9\x1c\xd2\x6e\x15\x18\x87\x3b\xdd\x79\x92\xec\x07\x94\x6e\x4d\x4d\x70\xba\x54\x6a\x37\x0b\xa0\x8b\xbe\xa8\x21\x4e\xc1\x9f\x0d\xf5\x02\xf7\x34\x82\x54\xdf\x61\x81\x68\x36\x69\xaa\xcc\x7f\xf7\xf4\x9b\x81\xe6\x2a\x13\xc3\x8b\xe0\x01\x26\x99\xdf\x44\x1e\xda\xc1\xb0\xce\xca\x5b\x5c\xe4\x59\x40\x1f\xc9\x14\x6c\xae\x3e\x93\xcd\x84\x79\xa1\xa6\xfd\x15\xad\xe6\xef\x65\x07\x0a\x40\xbb\xbb\x1e\x3a\xdc\x91\x32\x89\xed\x5d\x9e\x2e\xa2\x11\xea\xff\xe7\xf4\xf7\x6c\xff\xf7\x24\xd9\x1f\x25\xfb\x8f\xfb\x0f\x23\x56\x60\x84\x2e\xbd\x80\x3b\x2f\x16\x75\xa1\x8f\xe3\xd5\x30\x9d\xf2\xd6\xdc\xdb\x77\x8d\x25\xe8\xc1\x83\x4b\x38\x61\xdc\x85\x37\x0c\x5f\xf6\xd9\x3a\xc8\x4d\xf3\xb1\x86\x3d\x7a\x92\x65\xcf\xac\xce\x11\x0b\xae\x53\xc1\x1a\x13\xad\x3d\x56\x63\xad\xad\xe1\x6b\xd9\xef\x27\x42\xff\x02\x3c\x2f\xc5\x22\x40\x93\x1f\xd4\x8e\x9d\x2e\xf5\x22\x5b\x2e\xe6\x63\x42\xdf\x4f\x64\xa7\xaf\x2a\x2a\xa0\x68\x81\x75\xd1\xd9\x79\x1a\xec\x0b\x19\x9c\xca\x7e\xc9\xf9\x2c\x6e\x21\xa9\x88\x6d\xee\x8d\x29\x0a\x6c\xc2\x67\x3b\x25\xb6\x0d\xc2\x1a\xb7\xeb\xfb\xe9\x3a\x97\xc6\x1b\xa0\xda\x85\xfe\x42\xb2\x13\x4d\x8c\xd1\xd3\x22\x89\xa2\x85\x61\xc3\x56\x06\x3f\x6b\x83\x3a\xd2\xfd\x7e\xf2\xbe\x54\xeb\x72\x1b\x3f\x33\xcf\x12\xc8\xf3\x34\x5d\xcc\x17\x05\xe6\x70\x59\x6c\x07\x65\xb2\x86\x63\xd1\xbe\x4a\x39\xd0\x02\x6b\x62\xef\xec\x87\xd6\x9b\x69\xe8\x9c\xda\x0f\x16\xb5\xf5\x83\xdf\xae\x86\xbd\x44\x88\xc8\x67\xee\x56\x98\x90\x3b\x89\xb6\xf5\x3b\x3c\x27\xcf\xcb\x4c\xdf\xf5\xe0\x72\x46\xa5\xe5\x3a\xea\x38\x8b\xb9\xad\x8e\x46\x81\xb6\x90\x93\xbc\x51\x59\x03\xcd\x48\x5a\x65\xe4\xd3\xc7\xb3\x17\xd5\xbc\xae\x4a\x52\x6a\x5a\x7a\x00\x8e\xae\x6c\x76\xd3\xdf\xf7\x21\xbd\x29\x8a\xba\x3a\x5d\xb9\x90\x24\x17\x85\x11\x8a\x38\x1e\x3b\x57\x6a\xfc\x2e\x4d\xa2\x0a\xa7\x58\x7e\xad\x80\xe3\x31\xca\x19\xc4\xec\x4d\x09\x55\x7e\x68\xd7\x52\xbd\xb4\xdd\x5c\x99\xa1\xfe\xac\xf3\x23\xae\x02\xd3\xdf\xce\x42\xb8\x6d\xd2\x9b\x7a\xcc\x9d\x6a\xc7\x68\x53\xbd\x44\x53\x61\xa5\xe4\x8a\x4d\x61\xb7\xfc\xc0\xfe\x02\xa6\x56\xcb\x7a\x69\x58\x5d\x86\xcb\x6a\x8d\x61\x58\x03\xe7\x9e\xf2\xf5\x4d\x3e\xc9\x96\xf2\x31\xb9\x21\x4b\xe6\xf5\xd4\x6d\x33\xe9\x8d\xfd\x1a\xbc\x03\xe9\x52\xa1\xb0\x8f\x6e\xc8\xf2\x4a\xdb\xad\x0a\xca\xa5\x28\x6b\x05\xbc\x3b\xad\x8d\x4d\x7f\x31\x23\x8c\x20\x7e\x57\xa9\x2c\x04\x0c\xc5\x39\x7b\x49\x6a\x4a\x52\xcc\x89\xdc\x07\x09\xf3\x1b\x97\x19\xa2\x24\xcb\x29\x49\xf9\x45\xf5\x36\x9f\x0a\xca\x65\x9f\x3e\xbe\xe9\x0a\x28\x98\x12\x84\xb3\x8c\x64\xca\xb5\x51\x51\xf8\xf0\xf1\x1d\xa6\x19\xdc\xf8\xc6\x3c\x1f\xe7\x45\xce\x97\x62\xcb\x50\x15\x3a\x27\xbc\xf4\x76\x27\x7b\x26\x43\x6d\xa8\xeb\x0d\x1b\xd5\x19\x66\xb3\x0d\x0b\xa8\xfd\xfc\x85\xd6\xb1\x52\xe8\xb2\x57\x14\x4f\x55\x56\x93\x80\x18\x86\x7a\x91\xa7\xb9\x74\x69\x24\xcb\xf1\x61\x34\x80\x2a\xd5\x1f\x1f\x75\xa5\x6c\x65\xb4\xaa\xe1\x60\x5f\xc0\x41\x7f\x02\xe7\x57\x0a\x61\x42\x31\x69\xb9\xf0\x1c\x94\xad\x31\x48\x85\x94\xad\xf6\x2c\x99\x82\x13\xe1\x6c\x8b\xfe\xd8\x30\x03\x7b\xa2\x3f\x32\xda\xb0\x04\x34\x9d\x22\xde\x02\x5b\xf9\x52\x67\xd5\xb3\x11\xbb\x80\xf4\x8b\x3a\xae\x54\x55\xbb\x08\xd4\x66\x91\xaa\x1a\xd2\xa4\xe5\x69\xe5\x8b\x36\x64\xf4\x08\xef\xc0\x1a\x44\x0e\x64\x29\x69\xec\xb8\x60\xa2\xfb\x4f\x9f\xee\xa1\xa7\x48\x26\x61\x52\x59\x2b\xd0\x4c\x6e\x6a\xcc\xa7\x1e\x45\x8d\xa7\x4f\xfb\xca\x2d\xe7\xa6\xbb\x50\x8e\x39\x93\xda\x3c\x90\x9b\x5d\xb1\xd6\x56\x4f\x9c\xfa\xa0\x15\x40\x3f\xb0\x6e\xb9\x3d\x9d\x65\xba\x95\xe0\x78\x10\xdc\xfe\xe9\x1c\xec\xfa\x0b\x8e\xf2\x66\x48\x96\xdf\x26\x0d\xc8\x43\xa7\xb2\xfa\xfe\x9c\xfb\xdd\x47\x73\xb7\xa5\x93\xeb\xba\xfa\x65\x52\x4d\x26\x71\x07\x7c\x65\x1d\x77\x7d\x5c\x97\xed\xdd\x39\x74\x17\x2a\xdc\x7c\x66\xcc\x74\x36\x74\x97\x41\xdb\x4b\xa9\x3b\xe9\x85\x32\xde\xc3\x1d\xcf\x07\x7d\xe4\xac\x91\xa9\x64\x1d\x1e\x9e\xcb\x7c\xf7\xef\x96\x85\xa1\x4f\x26\x01\xf0\x2e\x7f\xc3\xdc\x7a\x49\x53\x36\xcf\xab\x16\x03\xe9\x3b\xf1\xb3\x17\x9b\xe9\x64\xe7\xf9\xbc\xd6\x1f\xcf\xf0\xf7\xff\xce\xd7\x16\x40\xa6\xdf\x4f\x62\x95\x67\x5e\xec\xfa\x0f\x8e\x6c\xf8\xb2\x0f\xa5\xa9\x3f\xbd\x39\x53\x1f\x35\x64\x6a\x93\x6c\x85\xe3\x01\xfe\x67\x77\x00\x33\xcc\x44\x4d\x05\x4e\xdb\x53\x1e\xf0\x6d\xd8\xfb\x20\x2c\xf6\x01\x48\x6a\x0f\xb3\xb5\x0f\x9d\x15\xcc\x0c\x3b\xd0\x02\x9c\x81\xb2\xfa\xb0\x39\x6b\x2f\xaa\x05\xac\x10\x81\x66\x6e\xae\x8f\x20\x21\x43\x68\x03\xd6\x0e\xe4\x03\xf4\xfd\x61\xcf\xe9\x4a\xcb\x66\xf0\x43\x51\x86\xf2\xad\x0f\x45\xb5\xbb\xd2\x93\xb3\xe1\x9b\x0d\x41\x14\x87\x0f\x55\x0d\xd2\x07\x69\xbe\x31\x21\x61\x9f\x13\xef\x53\x10\x0f\xfb\x5a\x84\x1b\x88\x0b\xa2\xa6\xa5\x7e\xd0\xfe\x08\xae\x46\xe6\x1f\x87\x8a\x72\x56\xaf\xd1\xb2\x3b\x7c\xf7\x77\xd3\x67\x83\x1b\x4a\xda\xd6\xcc\xd9\xc1\x2e\x1f\x0e\x0e\xaf\x40\x3d\x79\xb3\x67\xd8\x24\xe0\x64\xf2\xf7\xa0\xa0\x43\x9e\x6f\xa5\x8e\xfe\x68\xf2\x96\x0f\x15\x37\xa8\xe3\x01\xfb\x83\x04\x02\x4b\xd2\xa1\xd0\xba\x81\x3b\x04\x9b\xeb\x32\x9b\xa0\xae\xe5\xa1\x6d\xda\x52\xde\x62\xe0\x9c\x95\xc9\x45\x40\xe6\x0b\x35\x9f\x50\xf0\x57\xa6\x4d\xf5\x12\xf3\xb5\x16\x83\x54\x70\xa1\xd2\xdb\x98\x97\x64\x42\xa8\xd8\xc0\xde\x6a\x4b\xab\x9a\xa0\x49\x09\x3b\x91\x3b\x9c\xf3\x0f\x84\xe6\x55\x26\xd0\x93\x0b\x05\xb1\x9f\x73\x10\x1b\xdf\x14\x17\x05\x11\x1b\x9c\x9a\x08\x6b\xba\x58\x5a\x5b\x3b\x23\xe3\x6a\x51\xa6\x24\x9e\x94\x3d\x07\x94\x93\x3c\x43\xa6\x4c\x71\xf6\x59\x2d\xbb\xc0\x4b\xf8\xa2\xea\x3b\x5e\x04\x4c\x21\x91\x06\xa6\xd3\x85\x30\xa9\x9d\x0f\xcc\xb1\xb4\xaa\x89\xf7\xd9\x39\x8b\x75\x79\x5b\xdd\x90\x57\xae\xf1\xa1\xbb\x2f\x13\x5c\xd7\xc5\x32\x86\xd6\x3d\x00\xef\x05\x97\x29\x0c\x20\x10\xcf\x64\x7c\xd1\xf0\xbc\x41\x0e\x65\x76\x45\x77\xef\xe1\xa6\x14\x90\xa7\x03\x86\x12\xa4\xe4\x39\x5f\xbe\x95\x5f\x74\x81\x9e\xa2\x27\xd1\x00\x45\x4f\xf0\xbc\x1e\xea\x0f\x47\x1c\x43\x49\xc1\x4d\xc1\x09\x14\x4c\x4d\x41\x27\xea\x0c\x50\xe7\xc9\x7f\x2d\x2a\x3e\xec\xa8\x3a\x9d\x48\x14\xfd\xe9\xbb\xbf\x98\x92\xbe\x2c\xb9\x7f\xf6\x6a\xd8\x31\x29\x20\x15\x01\x54\xb8\x81\x42\xcf\x3a\x50\x2e\x9f\x1c\x9f\x44\x9d\xdf\xfb\x57\xfd\x69\xcf\xf9\x2a\x0a\x6b\x18\xc5\x66\x18\x97\xcc\xec\xa1\x5d\x0a\xe4\x65\xce\x15\xd5\x65\x22\xbf\x73\xc2\x17\xb5\x0a\xa1\x4f\x71\x3a\x23\xea\x6b\x3e\xd6\x33\xe2\x25\xfc\x0b\x7e\xed\x8b\x71\xcc\xf3\xb4\xff\x99\xc9\x0d\xc1\x35\x27\xf3\xba\xc0\x5c\xc7\x42\x8f\x31\xfd\xf1\x76\x24\x76\x09\x3f\x7d\x3a\x7b\xf3\xf2\xfa\xe7\xd3\x8f\xe7\x67\xef\xdf\xa9\xdb\x94\xad\xb4\x7e\x42\x9e\x04\x86\x7b\x8e\xa4\x5d\x28\x88\x2a\x44\x53\x8b\xd3\xdb\x05\xe3\x02\x67\xbd\xbd\x13\x2d\x87\xae\x35\x1b\xdc\x92\xfb\xf9\x1a\x82\xbb\xd1\x86\xdd\xea\x86\x62\x52\xa4\x0e\xf0\x03\x87\x67\xb1\x93\x15\x00\x3c\x38\x92\xdc\x0d\xe9\xb7\xd3\xf2\x38\x16\x15\xba\xc3\xbd\xff\x1d\x00\x00\xff\xff\x38\xdd\x68\xd2\x42\x97\x00\x00")
+var _pkgUiStaticJsGraphJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xe5\x7d\xed\x76\x1b\x37\xb2\xe0\xef\xd5\x53\xc0\x1c\x9f\x90\xb4\xa8\x96\xe4\x8c\xb3\x13\x51\x52\xae\x6d\xc9\x63\xdd\x6b\xd9\x8a\x25\x27\x99\xab\xe8\xea\xb4\x48\x50\x6c\xbb\xc9\xe6\x74\x37\x25\x71\x32\x7c\xac\x7d\x81\x7d\xb2\xad\x2a\x7c\xa3\xd1\x4d\xca\x99\x99\xb3\x77\x37\xe7\x84\x72\xa3\x81\x42\xa1\x50\x55\x28\x14\x0a\xd5\x77\x71\xce\xce\xf2\x6c\xc2\xcb\x31\x9f\x17\xec\xc0\x7e\xf8\xfb\xdf\xd9\x6f\xcb\xfe\xc6\x1d\x54\xb9\xcd\xe3\xd9\xf8\x82\x4f\x66\x69\x5c\xf2\xfe\x06\x95\x9d\xbc\x3f\xfb\x74\x71\x7d\x74\xfc\xea\xc3\xa7\xf7\xaf\x8f\xaf\x7f\x7e\x79\x72\x01\xed\x5f\xec\xec\xf4\xd9\xf6\x36\x9b\x14\x54\xe9\xfc\xf8\xf5\x87\xf7\x47\x50\xbe\xbb\x03\x2f\x36\x36\x0c\xf8\xe8\xcf\x08\x13\xde\x8c\xe6\xd3\x41\x99\x64\xd3\x0e\x4f\xf9\x84\x4f\xcb\x1e\xcb\x66\xf8\x5c\xf4\xd8\x38\x9e\x0e\x53\xfe\x1a\xfe\xdc\x72\xf5\xf4\x91\x4f\xb2\x3b\xde\x65\xbf\x6d\x30\x56\x8e\x93\x22\xe2\x29\x00\x91\x6d\xfb\xaa\x90\x10\x7e\x7b\x71\xfa\x0e\xde\x4d\xe7\x69\xaa\x5f\x48\xd8\x50\x2c\xff\xa5\xdf\xd8\x9d\xc1\x6b\xfb\xd1\xab\x23\x50\xb0\x51\x17\xe8\x30\x07\xc5\x0e\xb6\xe8\x62\xd3\xa5\x6e\x9f\x27\x83\x2f\xc5\x38\xbe\x57\x63\x77\x50\x1b\xc6\x65\x0c\x65\x97\x57\x40\x27\x59\x94\x4c\x93\x32\x89\xd3\xe4\x6f\xbc\x03\x90\x96\x01\x02\x46\x65\x32\xe1\x6f\xe2\x41\x99\xe5\x38\x28\x44\xa3\xb5\x68\xed\xb1\xef\x76\xd8\x33\xf1\xf3\xfc\x8f\xf0\xf3\xed\x77\x2f\x7a\xf8\xea\xbe\xfa\xea\x7f\xd2\x8b\xa1\xf7\x82\x0a\xc7\xa6\x90\x9e\x27\xf4\x4c\xff\x2c\xe0\x9f\xbb\x61\x8c\x8a\x92\xcf\x7e\x8a\xd3\x39\x47\x84\x2e\xb1\xf2\x6e\xd1\xea\xc1\xef\x8e\xf8\x33\xc1\xdf\x17\xf4\xbb\x2b\xfe\x7c\xbb\x23\x9e\xc6\xf8\xfb\x9c\x7e\xbf\xa3\xdf\x5d\xf1\xb0\x3b\xa4\x17\xf0\x4b\xd0\xee\xe9\x89\x7e\xff\x48\xbf\x7f\xa2\xdf\xdd\x05\x95\x2f\x5a\x1b\x57\x21\xb4\xa6\xf3\x09\xfd\x03\xb1\x0a\xb1\x62\x34\xcb\xb3\x32\x2b\x17\x33\x6e\x91\xbd\x3a\xc9\xc8\xd5\x05\x4f\x47\xf0\x06\xa7\x08\x67\x0f\x1f\xa3\x64\xe8\x48\x8f\xdf\xe9\xe6\x26\xcd\x2a\x48\xc6\x39\x2f\xd9\x90\x8f\xe2\x79\x5a\x2a\x1e\x8c\x14\x10\xf5\x4c\xc0\x24\xd8\xbe\xff\x32\x47\x96\xbc\x4e\xa6\xb3\x79\xa9\x6a\x85\x5e\x81\xf8\x22\x45\xb1\x79\x32\x62\x1d\xa7\x5e\x19\xdf\xb0\x83\x83\x03\x36\x9f\x02\x26\xc9\x94\x0f\x15\x03\x57\x6b\xb1\x5d\x62\xe1\x10\x94\x49\xfc\x70\x5d\x64\xf3\x7c\xc0\xaf\x73\x5e\x64\xe9\x1c\x8b\xab\x70\xff\x87\x07\xb6\xa6\x19\x6b\x01\x7f\x88\xbe\x04\xa1\x8e\xf2\xf8\x5e\x68\x1e\x36\xc8\xa6\x65\x9e\xa5\x05\x03\xf9\xa2\x87\x18\x80\xe7\x6c\x04\xe4\x66\x6f\x49\xe6\x6e\x62\xe0\xff\x52\x6a\xa8\x68\x43\x4e\x94\x91\x76\x31\xbc\xf6\x2c\x2e\xc7\x67\x39\xe0\xf6\xd0\xde\x63\x67\x2f\x2f\xde\x5e\x9f\x7d\x3c\x7e\x73\xf2\x4b\x4f\xbc\xbe\x99\x27\xe9\xf0\x27\x9e\x17\xd0\x0a\x2a\xbc\xfa\x74\xf2\xee\xe8\xfa\xa7\xe3\x8f\xe7\x27\x1f\xde\x2b\x41\xfe\xfc\xe3\x9c\xe7\x8b\x88\x3f\x94\x7c\x3a\xec\x68\x5d\x65\x0f\xb1\xab\xe7\xcc\xd6\x43\x4f\x3b\xa7\xf3\xa2\x8c\x07\x63\x1e\xe5\xd0\x94\xe7\x1d\x47\xad\x6a\xbd\xd7\x35\xcd\x79\x1a\xc5\xb3\x19\xf6\xe3\x42\xeb\x2a\x66\xfa\x33\x30\x13\x0c\x87\x03\xc0\x01\xc8\x5b\x99\xb1\x38\x4d\x81\x31\x39\x4b\xa6\x25\x94\x16\x65\x32\xbd\x55\xda\xb1\x80\x42\x7a\x67\x88\x2a\xe8\x08\x14\x14\xe0\x6e\x12\xa0\x2f\xbf\x83\xba\x52\x95\xe5\xc4\x9b\x7a\x09\xf8\x39\x47\x74\x72\xc5\x76\x80\x1e\xcc\xf2\xb0\xd3\xfa\x03\xbd\xbd\xbe\x17\xaf\x5b\x6c\x53\x31\xaf\x19\xca\x5f\x91\x6a\x6f\xb2\x7c\x02\x8d\x6d\x58\x12\x82\x78\x7f\x3d\x82\x0a\x2d\x3d\xba\x97\xf3\x32\xdb\x82\x41\xa0\x20\x22\xde\x25\x10\x9d\xc5\x39\x8f\x19\x30\x8c\xe0\xf2\x2c\x67\x93\x6c\x5e\xf0\x41\x0a\xaa\x55\xa2\x2a\x5a\x5c\x40\x65\xaa\xeb\x2c\x31\x8a\xd1\x89\x3b\x46\xa3\x82\x97\xb4\x7a\x44\xe2\xdf\x6f\x79\x72\x3b\x2e\xd9\x16\x96\x00\x44\xa0\x83\x28\xe9\x53\x9b\xa7\xd8\x3e\x1a\x14\x45\xa7\x3d\xa6\xe2\x76\x8f\xb5\x63\xc0\xb1\xed\x97\x42\xf3\x62\x00\x0c\x9b\x4a\x80\x9b\xb2\x2f\xb5\x1c\xe8\xf9\x7d\x98\xe5\x61\x7a\x94\x12\xfb\xcb\x69\x3c\xe1\x07\x58\xef\xaa\x65\xf1\x05\x3c\x47\x5f\xf8\x62\x06\x43\x2d\x3a\x66\x78\x6a\x74\x30\xb5\x45\xc9\x38\xb2\x00\x4a\xf0\xb7\x02\x7f\x14\x60\x1e\xdd\x8f\x93\x01\xac\x3d\x07\xf2\xf5\x37\xdf\xb0\x27\x3c\x2a\xc6\xc9\xa8\xfc\x0f\xbe\x50\x00\xfc\x49\x8b\x8a\xf9\xcd\x24\x29\x3b\xdd\xbe\x7c\xcd\x41\x5d\x12\xa3\x1c\x09\x55\xa6\xde\x2c\x25\xa5\x68\xf1\x8b\x00\xa7\x36\xa0\x39\x9f\x89\xd9\x02\xca\x0c\xf9\x4d\x06\xe8\xf2\x4e\x65\xed\x64\xde\xbc\x99\xf5\x13\xa0\xf6\x42\xe6\x86\x10\x94\x65\xd7\xa5\x67\x44\xac\x10\x20\x4a\x1d\xf8\x2a\x00\x5a\xf4\x85\xc8\xd9\x66\x80\x47\x7f\x1c\xdc\xea\x61\x51\xfd\x14\xa4\x91\xd4\x86\x20\x53\xfd\x70\x54\x07\x28\x07\x71\xf9\xaa\x9c\x2a\x51\x33\x33\x21\xe5\x45\xd4\xb8\xbe\x29\xa7\x16\x5f\xe8\x66\x3e\x11\x1c\x6c\x44\x2d\x1b\x1f\xab\xe7\x21\x1f\xce\x67\x4d\x1d\x53\x05\xaf\x5f\x3e\x8d\x6f\x52\x7e\x84\x6f\xea\xda\x11\xa5\x04\x37\x13\x04\x9b\x9d\x67\x71\x8e\xeb\xed\x47\x5e\xcc\x80\x71\x79\x53\xef\xb2\x2a\xae\x1c\x54\xd7\x43\xc4\x83\xb4\x06\x32\x3e\x40\x1b\x2f\x5a\x4e\x4f\xec\x85\xb6\x01\x90\xb5\xf6\xda\x30\x50\xe3\x7f\xe1\xc3\xa6\x31\xc9\x2a\xde\x50\x64\xe9\x1a\x3d\xcb\x9a\x76\xaf\x09\x8c\x24\x2f\x4f\x79\x09\x16\x67\x1d\x04\x28\xe4\x03\x09\x42\xd4\xbf\x9e\x50\x03\x87\x04\x7c\x04\x94\x19\x9f\xa0\xae\xb8\x8b\xd3\x75\x60\xc9\x26\x36\x14\x58\xf2\xcf\x69\xc5\xff\xa8\x17\xfc\x46\xb2\xda\xe0\x82\xe6\x82\x45\x67\xcd\xb9\xa8\xf4\xb2\x94\x5f\x90\xd5\x12\x5a\x62\x64\x85\x96\xb7\x3c\x63\x03\x56\xd3\x44\xac\x6b\x7a\xa5\x6c\x59\x82\x02\xd6\x51\x11\x6e\x15\x5f\xa2\x29\xbf\x55\x66\xb7\xb7\x29\x3f\x68\x43\xc5\xb6\x4d\x0c\x6c\x18\xf1\xbf\x56\x2c\xb2\x2e\xfe\xc0\xd8\xc7\xd9\xbd\x5f\x1b\x24\x98\xca\xa7\xd1\x0d\x55\x05\x43\xb7\xaa\xdf\x70\x49\x03\xd5\x76\x4b\x4b\x1a\xac\x55\x91\x78\x90\x2a\x34\x60\xd9\x89\xf7\x28\x33\xa0\xc7\x3b\x5d\x60\x9a\x21\x7f\xe8\xd8\xf5\x6d\xed\xa7\x5e\xe0\x2a\xf2\x14\x96\x7c\x5c\xe5\x25\x84\xb8\x2c\x73\x18\x76\x9e\xc4\x5b\xca\x52\x6b\x75\xbb\xd0\xba\x78\x9d\xc6\xb0\x40\xb5\x72\x9e\x66\xf1\x10\xca\xdc\xe5\x45\x2c\x2a\x96\x22\x12\xeb\xc7\x52\x1b\x00\x1f\x79\x39\xcf\xa7\x0c\xb7\x53\x05\x1b\x65\x03\xd8\x95\xde\x00\xaf\xa3\x9d\x43\x4b\x27\xb0\x6d\xc9\xe3\x21\xac\xae\x4c\xc0\x42\x73\x27\x0a\x09\x41\x74\x43\x53\x03\x8b\xd1\x10\xc8\x88\x1b\x85\x9c\x60\x07\x29\x69\x74\x3c\xf5\xe9\x90\x84\x8a\x41\x12\x3a\xee\x53\x57\xd6\x11\x50\x6b\x96\xc7\x65\xd7\x2c\x34\x79\x9e\xd5\x2c\xfd\xe2\x5d\x0b\xe8\x97\x0c\x25\xd5\xa9\xc9\x7d\x9c\x4f\xd1\x9a\x0b\x37\x92\x6f\xab\xcd\x70\xed\xa9\x69\x83\xaf\xaa\x0d\xa8\xe6\x4b\x61\x39\xd5\xcb\x04\xae\xa1\xbe\x24\x29\x71\xd6\x10\x9c\x26\x56\xed\xc5\xcb\x87\xa4\xa8\xad\xbd\xb8\x8e\xe1\xb5\x55\x3d\xe5\xb7\x60\x03\xd7\x0d\x82\x5e\xda\x8a\x73\x96\x4c\xa7\xbc\x8e\xb8\xf2\xad\xbd\x78\xc1\xfc\x9d\x97\x71\x59\xd4\x4d\x07\xbc\xbf\x2e\xb0\x82\x2d\xfe\xd0\xe7\x11\x58\xed\xe1\x36\x96\x72\x86\x7a\xd5\x45\x41\x36\xc6\x2d\x3f\xc7\x0d\xfc\x0c\x16\x6b\xd8\x0f\x08\xee\x4b\xb3\x41\x9c\xf2\x3d\xd6\xe6\xd3\xb6\xd8\x97\x88\x05\x1b\x4a\xfe\x02\xff\x6d\x9d\x9e\x6e\x1d\x1d\xb1\xb7\x6f\xf7\x26\x13\xf9\xbe\xcc\xb2\x14\x36\x40\x67\x69\x3c\x20\x43\x1f\x6a\xde\x64\x65\x99\xa9\xf7\x05\x4c\xf0\xab\xc5\x39\xfc\xee\xb1\x32\x9f\x73\x59\x0a\x0a\xe5\x22\x1b\xc6\x8b\x57\x73\xa8\x3b\xf5\x5f\xbd\x4e\x79\x9c\x57\x0b\xb3\xc2\x01\x82\xd8\xff\x67\x36\x45\x74\x3f\x5d\xbc\xa6\xfe\x96\xdd\xe0\x9e\x53\x13\xc2\x95\x32\x43\x89\xb8\xd3\xc6\x7f\x5e\x00\xc4\x33\xa2\x07\x98\xd5\x48\xa0\x3a\x30\x6a\x5f\xea\xc0\x41\x4d\x39\x9c\x49\x13\xce\x16\x6e\xe8\x35\xa4\x74\x24\xb6\xa1\xb5\x4e\xd9\x81\x55\x10\xf3\x19\xe2\xf5\x51\x54\x57\x40\xb4\xd6\x29\xce\xf5\xca\x5d\xb1\xbf\xa4\x7a\xb0\x17\x78\xa1\x3e\x68\xdb\xdc\xde\x6d\x7b\x1b\x84\x49\x86\xf3\xb9\x92\xc9\x44\xb5\x2a\x9f\x89\xf2\xdf\xcd\x66\x7b\x45\xf1\xdf\x89\xd3\xb0\x26\x10\x77\x32\xb3\x57\xc6\xa1\x10\xd6\x29\xbf\x67\x47\x15\xa6\xd2\x2d\x9e\xa1\x9f\xb2\x6b\xd8\xd3\x10\xb0\x96\x3b\xf1\x47\xf0\x22\x6c\xfb\xc0\xe4\xac\xba\x49\xac\xc9\x71\x78\x7f\x0d\xe0\xf5\x80\x1c\xee\x97\x90\xbe\x8a\xf9\x95\x4f\xab\x5c\xa4\x9c\x38\x57\x98\xaa\x15\xd6\xc5\x4a\xc9\x20\xd3\x66\xac\x31\x6c\x05\x3f\xb6\xa3\xdb\x74\x31\x1b\x63\x95\xb6\x65\x2a\xb8\x32\xd1\xa9\x98\x00\x06\x4a\x3c\x1c\x4a\x73\x01\x0c\xe1\xad\x59\x9e\x4c\xe2\x7c\xd1\xd2\x3b\x4e\x04\x6c\xd5\xd1\x9d\x6d\x0d\xc6\x7c\xf0\xc5\xab\x97\x93\x0b\xb6\x52\x15\xc6\x84\x95\xf9\x50\x55\x97\x73\x56\x87\x92\x03\xe6\x71\x58\x55\xba\x6a\xc6\xcc\x19\xc4\x52\xf9\x9a\x9c\x49\xe9\x58\x4a\xc6\xc2\xb1\x66\xa7\x17\xa2\x3d\x6e\xf6\xcd\x92\xfb\xef\xe7\x1f\xde\x9b\xd9\x00\x6b\xeb\x64\x64\x79\x87\xee\xe3\x82\xc9\x5e\x7a\x54\x9c\xe5\xc9\x6d\x32\x85\x2d\x00\x18\x55\x09\x98\x63\xe4\xae\xbe\xcd\x4a\x36\x99\xc3\xda\xc8\x87\x06\x4e\xa7\x40\xcd\x32\xec\x92\xb7\xee\x9e\x83\xcc\x81\x32\x04\x93\x2d\xe7\xe4\x97\xc8\xe7\x83\x92\x25\xa5\xf0\xde\x39\x90\x11\x23\x82\x1b\xd9\xf3\x21\xfd\xe2\xc2\x1a\x86\x5d\x56\x81\x7a\xea\x08\x85\xc6\x1b\x8b\xed\x81\xa8\x68\xd8\x0a\x2d\x7e\x60\xed\x9d\x36\xdb\x43\xa5\xab\xec\x3b\x9f\xda\x1a\x90\x50\xf8\xe4\xc9\x75\x76\xcc\x30\xd8\x0b\xb2\xf2\xb5\xb8\x18\x5f\xae\x30\xff\x3f\x38\xb2\x24\xec\xe5\xe3\xb4\x07\xe3\xe7\x13\x45\xfc\xa7\xba\xbc\x6b\x58\xa9\xed\xf1\x06\x68\x04\x9b\x75\xda\x01\x4e\x53\xe3\x20\xcd\x7e\x5e\x66\x79\x7c\xcb\xa3\x82\x97\x27\xd0\x57\x07\x3b\xec\xe9\xc1\x2e\xfb\x1e\x9a\xa3\xd1\xef\xc7\xd3\x42\xa4\x0e\x57\x39\x94\xd5\x78\xee\xb4\x2d\x5f\x19\x3a\x83\xd1\x67\x00\x9c\x1e\x23\x7e\x48\xe3\x94\xdc\xe8\xe8\x8a\xb0\x95\x92\xf2\x5e\xd4\xa8\x24\xc9\x04\x04\xec\x98\x7c\x16\xc1\x55\x1a\x61\xdf\xc1\x0b\x07\xc1\x5b\x89\x60\x5b\x38\x3b\xb6\xa8\xab\xb6\xe0\x03\x25\x3e\x53\x10\x07\x74\x29\x26\x53\xd1\xb6\x10\x6d\x7b\x6c\x12\x7f\xe1\xc8\xf3\xa2\xed\x30\xb2\x8d\x81\x3b\xb5\xf6\xa3\xcb\x5e\x3c\xa8\x73\x21\x31\x7c\x4b\x94\x6d\xcc\x8d\x2e\x75\xf8\xad\x63\xa8\xd2\x63\x15\x64\x3d\x95\xe7\xb2\xc0\xca\xa6\x15\x2f\x51\xa3\x9f\xc9\xf2\x0a\xd9\x22\xe8\x8e\x21\x20\x87\x38\x5e\x83\x89\xd9\x46\x86\x59\xcd\x5b\x50\xd6\x25\x83\xb3\x5e\xaf\xe8\x4d\x72\x6d\xb8\xa7\xd5\x64\xf3\x77\xb5\x67\xc2\xe5\xc4\x94\xcb\x49\xb1\x73\xc5\xb7\x65\x73\x76\xd5\x47\xd6\xcc\xe3\x67\x6e\xfd\xdf\xcb\xed\xb2\xfb\x2d\x85\xdb\xbf\x9a\xf1\xc3\xc3\xa9\x13\x81\x20\x25\xcd\xdc\x84\x06\xd3\x2c\x18\x5f\x07\x70\xa3\xde\xc1\xd9\x28\x38\x5e\x75\x5b\x78\xea\xe8\x50\x23\x46\x41\xbc\xbf\x56\xa2\xbe\x8a\x08\x8e\x9c\xad\x8f\xce\x2a\x91\xfb\x5a\x5c\x3c\x6f\x77\x9d\x63\xd2\x90\xbc\xf1\xd4\xd2\x82\x54\x39\x2a\x09\x4f\xad\x71\x52\x5a\x56\xa3\x72\x8f\x59\x16\x87\xf2\x4c\x36\xd7\x0a\xf9\xe7\xea\x3c\x6b\x52\xe8\x46\x20\x9c\xbc\xaf\x37\x4f\xb6\xc7\x44\x3b\x82\xaa\x63\x12\x3b\xce\x1b\xda\xbe\x29\x8f\xf1\xe0\x9a\x5c\xde\xb0\xdf\x0c\x30\xb3\xf2\xc0\x0d\x72\x1e\x17\xfc\xa3\x44\xd0\xee\xb4\x09\xf8\x90\xaf\x01\x1c\x2a\x55\x81\xaf\x8b\x3a\x9f\x0e\xd7\x41\xfc\x18\xda\x3e\x0e\xed\x15\x80\x15\xd2\x16\xe0\x75\x51\x16\x9b\xbc\x75\xb0\x3e\xa5\x9a\x8f\x44\x7c\x35\x78\x85\xbb\x0b\x3e\xe8\x6d\x0d\xb8\x4e\x3c\x17\xaa\x70\xf1\xe3\x3b\x60\xec\x19\xfa\x13\x60\xc3\xfa\x1b\x1e\x7b\xee\x05\xe0\x91\x8f\x04\x56\x93\x0c\x1d\x0b\xad\x1b\x0e\x5b\x00\xde\x5a\x56\xfc\xb2\xca\x5d\x8b\xab\x52\xce\xe9\x29\x99\xde\x1a\x99\x17\xa7\xc3\x68\x99\x8a\x4d\x6e\xc0\xab\xa2\xce\x30\xb0\x92\x74\xa5\xe8\x16\x8d\x4a\x5b\xd4\x6a\x92\x36\x7d\x50\x81\xba\x0d\xf7\xfa\x47\x79\x32\x92\xae\x61\x40\xd8\x8a\x3a\xa1\xb9\x62\xe3\x04\x97\xd1\x85\x5c\x08\x9f\x04\xd7\xe7\x96\xac\x64\x7c\xeb\x41\xab\x5a\x57\xeb\xd1\x26\x0b\x76\x46\x39\x10\x26\x19\x2d\x3a\x97\x57\x5d\xd7\x97\x30\xcb\x66\x73\x8c\x47\x38\x21\xfa\xa3\x36\x15\x73\x50\x48\xcd\xa0\x17\x65\xcb\xf7\x6d\xd3\xa1\xa2\x7a\x96\xe1\x30\x21\x13\x6e\xe3\xd2\xa3\xce\xf7\xe0\x05\xdd\x88\xc2\x9b\x3c\xbb\x07\x34\xb1\xb1\xed\xdc\xe9\x22\x7d\xb0\x10\x20\x6c\xcb\xd8\x33\xda\xc1\x44\xf1\xe7\xf8\xa1\xa3\xd6\x14\xc6\x10\xa5\x6c\x08\x2c\xf5\xe7\xe3\x8b\x56\x4f\x17\xcf\xf3\xd4\x09\x0f\x61\x9b\xac\xb5\x1d\xcf\x92\xed\xbb\xdd\x6d\x9a\x9b\x1f\xe8\xf7\xa0\xa4\x2e\xac\x86\xb8\x57\xbd\x80\x31\x01\xc4\xcf\x45\x36\xb5\xde\x10\x7d\xe6\x83\x01\x2f\x8a\x3d\x33\x40\xac\xd4\xa3\xb8\x06\xf4\x40\xcf\x0b\xb3\xde\x49\x97\x01\x10\x1b\xeb\xe0\x56\x16\x5e\xb3\x27\x60\x23\xb5\x24\x98\x96\x5f\xd9\x4c\xc1\x38\xbb\x3f\xc6\x43\x84\x4e\x8b\xfe\x08\x7e\xc2\xb3\x03\x44\x38\x32\x1e\x09\xf3\x9f\xe0\x57\xb7\x7c\xe9\x3c\x89\x39\xc8\xef\x34\xb5\x09\x2f\xda\xad\xc3\x82\x38\x4f\xcb\xcb\x9d\xab\x7e\xa5\xc5\x30\xa1\x9d\xe5\x69\x5c\x8e\xa3\xf8\xa6\xe8\xd8\x13\xb6\x65\xc1\x53\xe6\xa4\x3d\x70\x6a\x7b\x78\xc0\xbe\xdd\xa9\x8e\x94\xc2\xe3\x70\x9c\x3f\x8b\x73\x8f\x4e\x65\x44\x8c\xb5\xf6\x87\xc9\x1d\x1b\xe0\xea\x79\xf0\x6b\x2b\x4e\x81\x9d\x19\xfd\x6e\xc9\xc3\x92\x5f\x5b\x87\xfb\x20\x09\xd9\xf4\xf6\x50\x82\x79\xb2\xbf\x2d\x0b\x60\xc7\x59\x82\x82\x02\x93\xb9\xc5\x36\x03\xc0\x11\x39\xb0\x47\xde\x24\x0f\x60\x7e\x3d\xef\x06\xeb\xb4\x60\x80\xb0\xe0\x0f\x0b\xa2\x3b\x35\x11\x91\x3a\xec\x86\x97\xf7\x9c\x4f\xd9\x02\x2c\x0a\xc5\xc4\xe4\x2e\x41\x7f\x88\xa0\x4a\x64\xc7\x62\xc2\xda\x8f\x3e\x17\xd8\x0e\xc4\x83\xc1\x3c\x47\x5f\x26\x81\xa4\x26\x04\x9b\x44\x67\x42\xd1\x26\x83\x78\x0e\xf6\xd6\x7c\x0a\x02\x2a\x46\x20\xd4\x89\x98\xa5\x22\xda\xdf\x06\xb2\x1c\xb6\x3c\x7c\xbb\x75\x73\xbf\x34\x3c\x4c\x07\x53\x7b\x2c\x10\xba\xd1\xc4\x7c\x68\xb5\x04\x79\x4f\xf4\xb1\xac\x8b\x6c\x34\x0a\xa2\x56\x25\xad\x15\x9e\xe7\x09\x7d\x50\xe4\x9b\x04\x3e\x8d\x6f\x78\xba\x7d\x7d\x8d\x0b\xc3\xf5\xf5\xf6\x1d\x85\x36\xea\x96\x75\x12\xff\x38\x59\x7f\x84\x9c\x37\x13\x39\xbe\x8b\x93\x14\x29\xc4\xc4\x59\x7e\xf1\xc4\x95\x76\x5f\xce\x97\x46\xec\x66\xb0\x60\xbc\xce\xa6\xa3\xe4\x36\x8a\xd3\xd4\x50\x58\xcb\x39\x2d\xab\x65\x36\xcc\xf6\xd8\x30\xd3\x8e\x3d\xc2\xc7\x34\xf8\x81\x7d\xc8\x81\x03\xa7\xb8\xe9\xfb\x3c\x2f\x4a\x96\x26\x77\x1c\x19\x17\x39\x1b\xbb\xd0\xfd\xc1\x1a\xce\x3a\xe4\x55\xa6\x88\x4c\xf8\xb3\x1f\xc6\x21\x4a\xf9\xf4\xb6\x1c\x43\x8d\xcd\xcd\x00\x2d\x6c\x43\x01\x74\x90\xb6\xd8\xc1\x72\xee\xe0\x8a\xf0\x81\x9e\x3b\x41\xd0\x97\xc9\x55\x8f\xd5\xbd\xe9\x76\x83\x74\x12\x31\x34\xf3\xbf\xfd\x6d\xf1\x91\x24\x4a\x47\x17\x8a\xff\x48\xd8\xf6\x68\x27\xdb\x73\x08\x8f\x75\xab\xe5\x93\x78\xb6\xc7\x7e\x5b\xd6\x76\x84\x56\x01\xf2\x57\x3c\xe6\xb1\x08\x03\xd4\x58\x69\xc9\x6c\x92\xcb\xaf\x67\x97\xa5\x3a\x62\x59\xae\x8e\x96\xd5\x18\xda\x12\x49\xc8\x12\x2a\x22\x1e\x4d\x6c\x9f\xa0\x06\x91\xe8\xad\xb0\x48\x60\x4f\x6b\x6f\x62\xad\xb9\xd0\xb5\x14\x1b\x00\x94\x41\x5c\x86\x27\xb2\x0b\x5b\xdf\xe0\x0b\x37\x66\xab\xd4\x94\x14\x14\xc2\xe8\xbd\x73\xb2\x44\xf7\x84\xad\x26\x0f\xa0\x08\xd3\x3d\xf9\x57\x94\xa1\x63\x12\xc4\x19\x8d\x09\x51\x30\x89\x4b\xb0\x5c\x2c\xba\xb3\x8e\xed\x2d\x55\xae\x11\x90\x93\x71\x0c\x22\x20\x18\x80\xb8\x1e\x34\x38\x86\x5c\x08\x3a\xf4\x58\xf1\x25\x99\x6d\x18\x3d\xe0\xf3\x97\x20\x04\xa9\x04\x5a\xf5\xe8\xb1\x32\xc5\xd5\x06\x76\xf5\x7e\x7d\x65\x60\x40\xe4\xe0\x65\x43\x95\x5c\xf1\x39\x15\x82\xa1\x9c\x96\x3c\xef\x18\xe8\x91\xb4\xe0\x3b\xdb\x6c\xfb\xb6\xc7\x5a\xad\x6e\x4f\x2e\xd0\x82\x7e\x8e\x7c\xcc\x72\xd4\x95\x6a\xdd\x75\x2c\xa4\x59\x56\x94\xf8\x4e\xad\xc1\x66\x8d\x5a\x76\x57\xa2\x87\x61\x6d\xc7\xf1\x60\x6c\xcc\xf3\x3c\xa0\x2c\xbc\x91\x5f\xe6\x91\x3a\x7d\xb8\x82\xf1\xe5\xfd\x40\x8f\x5a\x22\xa5\x4d\x8f\x93\x8c\x8e\xae\x10\x3c\x15\xae\xb8\x21\xd9\x28\x2f\xab\x0c\x62\x29\x7e\x7a\x8c\xb0\x9a\xc1\x3a\xee\xdd\xd8\x78\x2b\x05\x19\xc4\xfe\xe6\x2a\x2a\x06\xb0\x15\x22\x53\x2a\xf0\x3e\x96\xef\xcd\xb0\xd4\x18\xc8\xdb\xb6\x03\x02\x17\x47\xe2\x20\xf8\x75\x36\xc1\x40\xa0\xce\x0d\x4a\x52\xa2\xc7\xae\xa9\x60\x0d\xbe\x70\x7d\x29\xe2\x18\x04\xa6\x9b\xd6\x03\x0a\x42\x1e\x53\xd4\x32\x8b\x47\x18\x61\x1a\x97\x18\xf4\x4c\x16\x00\xc6\xf0\x6a\x4d\x31\x4b\xe7\x40\xf8\x1e\x8b\x0b\x80\x2a\xa0\x64\x50\x23\xbf\x4f\xc0\x7a\xb9\x81\x9d\xe6\x97\xc2\x6b\xa1\x68\x04\x9b\xa4\x72\x11\x6d\xd4\x04\xea\x38\xda\x05\xc3\x7e\xe4\xbf\x8f\x31\x1e\xa7\x50\x2a\x74\xd9\xa8\xd3\x60\xfb\xf0\x41\xc7\x8e\xaf\x36\x31\xbc\x58\xf3\x65\xdf\x0d\x40\x27\x97\x92\xba\x0d\x01\x66\xa1\x15\x2a\x28\xf9\xbf\xa5\xc3\x1a\x54\x01\xde\xa2\xf0\x4b\xe8\xe8\x4a\x3d\xda\xa7\xc1\x28\x28\x57\xf5\x9e\x04\x51\xa7\x1b\x71\x47\x3c\x28\xdc\xab\xa7\x82\xc3\xed\xad\x16\x5a\x3a\xe6\x52\x4d\x84\x8f\x56\xec\x17\x28\xfc\x97\x79\x1e\x2f\x3a\x58\xde\x73\x86\xd8\x45\x73\xdd\xb2\xd6\x29\xe2\x58\x42\x21\xbb\x49\x2e\xe5\xec\x90\x39\x36\xbd\xa4\x1d\xed\xbd\xaf\xac\x9e\xa9\x8d\x91\x43\xcf\x83\xb7\xea\x42\xc1\x6a\x0f\x9f\x0b\x47\x46\x63\x7b\x9b\xdb\xbe\x55\x43\xc4\xcd\xf9\xa1\x74\xc2\x47\x40\xe2\xa1\x2f\x15\xad\xb2\x68\xe3\xbc\xe0\x47\x68\xc8\x0b\x54\x8d\xce\x42\xd6\xc0\x18\x65\xc3\x6b\x54\xf4\xf1\x58\xee\x6f\x3f\xf2\xdb\xe3\x87\x59\xa7\xf5\x5f\x9d\xcb\x9d\xad\xef\xaf\x36\xbb\x9d\xcb\xc5\xfd\x70\x3c\x29\xe0\x9f\x4f\xc5\xe2\x8d\x8d\xc4\xe2\x84\x3c\xa7\x21\x46\x54\xd6\x91\xe0\x74\xe8\xc4\x13\x59\x15\xdd\x3c\xd2\x3a\xd4\xd7\x3d\xe4\x2b\x35\x6b\x4f\x60\x2f\xe6\x45\xb2\x7c\xb7\xa3\x9c\x07\xd8\x2b\xcd\x17\xf4\x49\xc3\x3b\x99\x96\x0a\xc0\xe5\xee\x95\xc6\x6c\x0e\xe6\x02\x54\x51\x6f\x9e\x5f\x59\xe4\x13\xed\x9f\xb1\xa6\x5b\x4e\x97\x08\xe0\x6a\x0d\xab\xc4\xf2\x0e\xae\x2d\xc4\x44\x9c\x73\xb9\x69\x33\x27\x2f\x66\xae\x3a\x5e\x60\xb0\x15\xfc\x17\xb2\x67\x1b\x2e\x47\x85\xac\x5a\xa4\xb9\x83\xc2\x7e\x08\x85\x06\xa0\x64\xb5\xba\x3e\x73\x0f\xd7\x15\x8d\xfb\xbe\x21\x52\xf5\xf2\x34\x39\x99\xcd\xc6\xd1\xde\x68\x2c\xd7\xf1\x02\x39\xee\xdc\x7f\xfd\x84\xad\x9e\x29\x58\x64\x77\x71\x56\x0f\xc5\xec\x6e\x6d\xd5\xce\xda\xe1\xff\x3f\xb3\x86\xe1\x00\x3a\x12\x72\xf5\x94\x91\xc2\x71\xe2\x27\xff\xfe\x77\xe6\x14\xb8\x58\xe7\x2a\x00\x58\x7a\x9c\xa5\xae\xb1\xc3\xe8\xd6\x89\x20\x5c\xbd\x89\xc1\x05\x3f\x3f\x7f\xdc\x60\xac\xb0\x32\x71\x66\xa3\x9b\x5b\xd1\xb4\x85\x29\xd4\x91\x62\x12\xfd\x21\x5d\xa6\x5d\x81\x58\x11\xc4\x89\x40\x35\xde\x47\x5c\x87\x2c\x12\xa1\x35\x35\xe9\xf1\x74\xb8\x36\x59\x60\xa5\x92\x28\xcb\xa9\x53\x04\xb2\x89\x2c\xc5\x50\xd6\xa5\xfd\xfa\xda\xf2\xcb\xb6\xd9\xf3\x1e\x6b\x4b\xff\x5a\x3b\x48\x6f\x09\xd8\x7a\xe7\xb2\xfe\x9a\x0a\xe9\x9f\x3d\x6e\xc0\xaa\xcc\x61\x6d\xfb\xbf\x6a\xf0\x80\xf2\xa9\x8a\x3d\x7d\x8c\x58\xcb\x80\x55\x2d\xd5\x32\x32\xf1\xb1\x42\xbd\x46\x68\xe4\xfa\x32\xfd\x88\x81\x04\x44\xfa\xd4\x42\x53\x11\x59\x96\x7d\xad\x40\x57\x11\x5a\x2d\xcf\xeb\x47\xa2\xae\x29\xce\x8f\xa4\x4a\x33\x67\x2b\x22\x55\x04\x7a\x77\xa7\x8e\x51\x65\x93\x7f\x90\x90\xfe\xf3\x47\xa3\xc5\xf4\x9f\x3d\x24\xab\xf6\xfa\xd7\xcf\x07\x18\x43\x2d\x5c\x7c\x5d\xb7\x50\x1d\x90\x74\xbd\xf5\xb7\x62\x21\x98\xb5\xdf\x04\xae\xa8\xc8\x00\x74\x20\x76\x02\x57\x26\x22\x3e\x99\x95\x8b\x8e\x1d\x46\x1c\xe7\x65\xc3\x71\xdc\x3f\xc2\x6e\x93\x77\x7c\xcd\x46\x4f\x6f\x37\x56\xdf\x94\x53\x9b\x6a\x3c\xe7\x96\xa3\x07\x5d\x45\xc7\x53\xb0\x53\xec\xd0\x3f\x46\x69\x06\x64\x74\x30\x04\x85\xfb\x62\xa7\xdb\x63\xbb\xd6\x06\xab\xb2\xaf\x5c\x6f\xcb\x29\xdb\x9b\xfb\x23\x15\xdb\xc1\x3a\xb2\xa4\x9e\x14\x6b\xfb\x1a\x49\xd7\xb3\x4f\x65\x69\xf4\xbf\x8c\x73\xe7\x4c\x56\x15\x46\xf1\x0d\xfa\x9a\xba\xf6\x9e\x6d\x9e\xa7\xaa\x27\x79\xe0\xa0\x1e\x81\xac\xf1\xc4\x5c\x9e\x6f\x11\x94\xd6\x9e\xbf\x41\xd6\xe1\x5a\xa2\xbe\x88\x0d\x84\x56\xf5\xf1\x7e\x18\xd4\x4f\xb1\x4a\xe4\x72\x15\x32\x24\x1b\xfb\x71\x3d\x16\x9c\xa6\xd0\xa7\x2a\xc4\xa6\xb4\x07\xda\xf7\x20\x3b\x25\xa6\x45\xb7\x83\x9c\x93\x2d\x87\x3d\xfb\x76\x55\x71\x0f\x49\x56\xec\xbb\x40\x38\x8e\xda\x30\xa6\xf3\xb6\xce\x47\x11\xe0\x15\xd1\x0e\xa6\x05\x77\x25\x0d\x67\xd0\x22\x42\xa6\x25\x63\x20\xc4\xd4\xd9\x0a\x23\x70\xde\x64\x47\x0d\x91\xda\x51\xc4\xac\x56\xee\x8b\x18\x61\x27\x28\x4e\x8e\xa5\x14\xe2\x2d\xf8\x72\x5d\x6c\xbf\x1a\xcf\xd7\x22\x62\x6a\x35\xa6\x56\xb0\x9d\x60\x5b\xf1\x0f\xcf\x2d\x06\x52\x80\xd7\x23\xc3\x67\x82\x9e\xfe\x10\xf7\x1a\xc5\xcb\x56\xd7\x39\x2b\x84\x9f\x55\x27\x80\x58\xbe\x27\x91\xf8\x57\x9f\x0a\x52\x2b\x3a\x16\x5a\x71\xfa\x57\xe9\x4a\x1e\x8d\x17\x35\xe0\xd5\x6a\xe2\xd6\x0d\x9e\x93\x39\x7e\x58\x31\x97\xf2\xc9\x3d\xc6\xd2\x54\xe9\xe8\x93\x46\x77\x7e\x57\x1d\x77\x3d\x8c\xf3\x1e\x05\xd3\xfa\xb4\xc3\x32\x74\x5a\xb5\x48\xed\x79\x14\x23\x25\x9c\xe7\x36\x79\xb0\x0d\x00\x8b\x94\xf2\xa1\xfb\x08\x4f\x42\x79\x52\xac\x13\x71\xe0\x26\xbf\x8d\xa0\xbc\x0d\xd9\xbb\x67\xe2\x37\x16\xf3\x8b\x4e\x3a\xa7\xd1\xca\x53\x5f\xfe\xc0\x07\x73\x4a\xf1\x21\x4f\x1d\xf1\x52\x2e\x80\xed\x56\xa7\x58\x53\x6f\x90\x4d\x66\x29\x2f\xf9\xda\x04\x3c\xa8\x21\x60\x3d\x33\x91\x15\x6d\x9c\x9b\xc1\xb0\x9c\x2d\x63\x2a\xf4\x9d\x86\x60\x05\xc5\x29\x16\x9f\x8b\x7b\x26\x94\xad\xa7\x69\x86\xc4\x05\x91\x86\x69\xaa\x6d\x24\x4f\x8e\x50\x78\x69\x5d\x68\xe1\xc5\x95\x38\xaf\x04\xd6\x54\x51\xda\x0d\x4c\x6e\x32\x6a\xec\x85\x30\xc4\xd3\xdf\xd5\xd0\x9b\xc0\x28\xb7\x61\x90\x4f\x96\xae\xab\xc7\x98\x69\xe3\x72\x92\x76\x5a\xef\xb2\x58\x84\x8b\x08\x46\xd1\x53\x04\xba\x1a\x14\xe6\xfe\x4d\xce\xb6\x0f\x99\x59\x88\x44\x2d\x6b\xb9\x82\x7a\xaa\x1a\xbe\x69\x5d\x20\xe6\x22\xfe\x44\x5c\x0a\x12\x2d\xbc\x01\xf9\x47\x77\x7e\xb4\xa9\x41\x5d\x1d\x36\x6f\x6f\x33\x2b\x47\x05\x93\xa7\x8b\xe2\x34\x08\xed\x0e\xd0\x15\x88\xce\x7d\x52\x8e\x59\x52\x16\xb2\x32\x46\xba\xdc\x89\xdc\x3d\x51\x93\x7d\x6d\x83\xfe\x6a\xfb\xfa\x11\x86\xb4\xb3\xd6\x04\xa2\x4f\x9a\x62\x4f\x64\x96\x0f\xb1\x82\x8a\xea\xe1\xe5\x46\x2c\x36\xbf\xd5\xd9\x68\x3d\xd6\xa2\xcb\xde\xd0\x08\x4d\xa5\x96\xd4\x06\xe1\xe5\xc8\x73\x45\x6a\x28\x26\xe0\x4b\x1c\x41\xf7\x2b\xb5\x06\x4e\x24\xb2\xa5\xb5\xde\x25\x78\xf4\x66\x00\x20\x32\x26\xb9\xcb\x46\x8d\x56\x37\x88\x48\x65\x5d\xab\x2e\x1f\xa7\xb9\xd7\xd1\xda\xcb\x8d\x26\xc5\x2b\x39\xae\x56\xf3\x7a\xdc\xac\xf3\xbf\xd0\xd5\xd6\x82\x8d\x71\xf8\x0c\x94\xea\x1c\xe3\x63\xbe\xf0\x74\xc1\x26\xb0\x34\xc6\x5f\xb8\xce\xd1\x64\xf1\x39\x1d\x90\xb2\xa4\xa0\x93\xd0\x61\xc4\x8e\xf5\xab\x82\x89\x6c\x3e\xc3\x8c\x6e\x60\xd0\x3e\x88\x2d\x38\x25\x49\xc2\x7e\xb1\x10\xa4\x07\x8f\xa1\x87\x40\x8a\x32\x49\xe5\x92\x01\x8f\x66\x14\x78\x2d\xa3\x41\x5e\x0c\xf2\xeb\xba\x82\x74\xde\x01\x7f\x1f\x22\xcb\xfc\x6d\x48\x93\x30\x79\x2c\x74\x79\xe5\x04\xd8\xba\xbb\x55\x09\xde\xb7\xef\xfe\x1b\xca\xdc\xd7\xcb\x8c\xbf\x90\xaf\xb0\x83\xea\xc8\xeb\x1f\xb7\xae\xf2\x59\x28\x08\x36\x8f\x08\x84\x1b\xd5\x2a\x56\x31\x3e\x04\x15\xe8\x4c\x80\x60\x5b\x4e\xff\x50\xa7\x2f\xce\xc6\xcd\x34\xb6\x57\x11\x9b\x27\x98\x6c\x5d\x89\x0a\x49\xcd\x75\x46\x0a\x10\x3d\x6c\x75\x55\x92\x35\x2c\x19\x64\x43\x8e\x45\x68\x80\x76\x52\x9a\xce\xae\xae\x30\xcc\x06\x73\x72\xc3\xa1\xd3\xa9\xa4\x53\xd5\xf7\x50\xbf\xd3\x12\xf2\x9f\x46\x13\x98\xd9\xf8\x96\xeb\x16\x17\x99\x11\x86\xae\x77\xeb\x82\xd0\x57\x8e\x95\x35\xe8\x7b\x2c\x53\x86\xe8\x91\x4c\x8a\xdb\x15\x67\x00\xd8\x42\x8c\x04\xeb\x7a\xe5\x6b\x76\x6d\x56\xbe\xf5\x57\x4b\xab\xe3\x76\xdb\xef\x57\xcd\xd8\x1a\x43\xfe\x59\xa7\x3c\x59\x7f\xd0\x72\x53\x22\x4c\x1e\x67\xd8\xea\xcd\x63\x06\x1e\xc0\xe0\x11\xdd\xdb\x83\xd7\x2f\xd6\x1b\xbe\x93\x63\x62\x8d\xee\x6d\xd5\x8b\x16\x19\x2c\x2d\x27\x47\x8a\xd5\xef\x93\xe9\x30\xbb\x17\x23\xba\x10\x2f\xfd\x9a\x5a\x93\x26\x5e\xaa\xa7\x90\x57\xce\x4b\x94\x61\x5c\x73\x24\xbf\x0a\x82\x1b\x51\xa0\x2f\x1c\xab\x2e\xa1\x03\x89\x57\x21\x76\x05\x88\x55\xf8\x6e\x45\xe0\xcc\x32\x98\x88\x43\xe4\x56\xd3\x23\x78\x26\xf3\xcf\xae\xa6\xb6\x48\xc8\xf8\x0e\x23\x7d\x5d\x15\x46\x25\x86\xe4\xf4\x7c\x4e\x97\x27\x0a\x99\xab\xd5\x3a\x47\xa6\xb7\x74\x07\xd2\x6a\x26\x88\x22\x5e\xa1\x0e\x56\x81\xc4\x96\x1a\xb6\xa1\x46\xb3\x39\x0c\xc5\xc4\xc7\xa1\x5a\xa1\xb6\x9b\x56\x60\x9c\x34\x37\x60\xbb\x32\xe3\x98\x0f\x52\xe2\x79\x49\x7f\x74\xdc\xea\xd2\x3d\x58\x49\xd5\xe8\xdc\xb8\x78\x51\xfc\x6b\xcb\x74\xa5\x30\xf9\x9c\x25\x53\xc0\xe4\x26\x07\x7d\x28\xba\x27\x75\xb9\x92\x98\x22\x94\xf4\x22\xbb\x28\xde\x8b\x40\xa2\x5a\x72\x96\xaa\x86\x7c\x13\x29\xe2\xa0\xf6\x07\xe1\xc1\x5e\x7f\x6b\xf5\x9b\x88\xbf\x92\xfa\xab\xc9\x1f\xa0\xbf\x26\x39\x10\x48\xd3\x45\xd1\x17\xcb\xa1\x58\xad\x94\xb4\x50\xe2\x8f\x1c\xcd\xe6\x41\x88\x8c\x3d\x41\xc3\x65\xcb\x3a\x2f\x12\x0d\xd6\x0b\x16\xfa\x49\x86\xd6\x68\x5a\x52\xac\x8c\x21\xa5\x90\x58\xaa\xfa\x26\xcd\xe2\x52\xbe\x57\x42\x99\x40\x57\xef\xb1\x4c\x1b\x56\x60\x1e\xb6\x36\x4f\xa6\x23\xcc\x85\xb5\x25\xff\xd2\x33\x48\x65\x9a\xb2\x1b\x2e\x80\x0d\x51\x9c\x32\x06\xad\xd9\xcd\xc2\x86\xdf\x8d\xd8\xc5\x98\x2b\x50\x83\x78\xda\x2e\xb1\x11\x5d\xa9\xc2\x9c\x11\x45\x46\xc6\x2e\x1a\xb3\x13\x34\x61\x6f\xe3\x59\xc1\x3a\xb4\x01\x76\x6e\x01\xeb\x2b\xbf\x4e\x18\xd0\x4a\xa2\x38\x99\x20\x7c\x77\x62\xe3\xd9\xcf\x2c\x4e\x79\xa9\x93\xc1\x7c\x94\x29\x99\xa3\xd7\x59\x0a\x8b\xd3\x99\x78\x69\xac\x11\xb2\xbf\x2c\x3f\x01\xf2\x10\x98\x87\x79\xf2\xd0\xaa\x98\xa8\x72\x8b\x20\xc3\xc2\x31\x20\x12\xec\xef\x6c\xc4\x44\x7d\x32\xdf\x9f\xb0\xb3\x14\xcf\xab\x64\xf6\xcd\x98\x0d\xb2\x3c\xe7\x83\xd2\xb2\xf7\xf5\x1d\x09\x49\x0d\xc1\xe7\x4b\x73\x3a\x19\xab\xa0\xfc\x5c\x47\x7a\x1a\xbd\x59\x16\x7e\x24\x9f\xb9\x2d\x25\xb8\xd8\x84\xf2\x81\x79\x34\x91\x29\x02\x2b\xbe\x09\x19\x03\xa8\x5c\x22\x7d\x5b\x55\x15\x56\xc0\xb0\xe7\xd9\x52\xa1\x83\x46\x35\x89\x23\x11\x47\x25\x98\x8e\x4d\x88\xbd\x06\xac\xdf\xd9\xe9\x3c\x24\x29\xec\x5e\xf6\xe8\xb7\xe7\x34\xdf\x93\x7f\x5d\x17\x2c\x40\x14\x97\x36\x5c\x4a\x59\x02\xe4\x84\xb8\xda\xde\x99\x87\x3d\x11\x93\x76\xb9\x73\x65\x07\x2a\x2f\xf6\xac\xb5\x91\x24\x53\x40\xc3\x38\x37\xe3\x8c\x31\x41\xb4\xc6\xf7\x96\xa2\xbd\x2e\x39\x30\xa2\xc7\x8e\x68\xb1\x34\xd6\x21\x59\xfb\x15\xbb\xb5\xb0\x04\x57\x5c\x84\xa1\x19\x23\xef\x07\x65\xfd\x85\x1d\x24\xde\x76\x64\x78\x10\x51\x44\x86\x05\xf8\xbd\x76\x2c\x49\x95\x29\xc4\x20\xb3\x7c\x6b\x5a\x89\x96\xd6\xb2\xaf\x8f\x46\xfa\x50\xbc\xef\x96\xc3\x7a\x89\xa5\x9b\x7e\x6d\x3e\x73\xe2\xdf\x5f\xa6\x29\xa8\x00\x84\x3e\x42\xa5\x81\xe8\xcd\x32\x4c\x48\x97\x4c\xc5\x45\xa6\xc1\x22\xb2\x63\x97\xe4\x4e\x47\x05\x89\x22\x8e\x98\xb3\x86\x8a\x2f\xe1\xe9\x2a\x7a\x60\xfb\xd8\x6f\xa5\x5b\x71\x18\x66\x4f\xa7\x1e\xb8\x50\xe9\x16\x10\xcb\x23\x05\x8f\x98\x9a\xbb\xc6\x4b\xeb\x81\xf8\x0d\xd8\xa1\xec\x31\x79\xbb\x63\x19\xd8\x2a\x31\xa6\xf3\xb8\xeb\xb6\x66\x62\x4d\x98\x40\xbc\xa6\xfd\x57\x49\x92\xbf\x7a\xdf\x6d\x53\x50\xed\x99\x5c\x33\x8c\x12\x2b\x52\x0a\xfb\x78\xba\x60\x78\xa8\x8d\x57\xcb\x46\xf0\x04\x5a\x28\x11\x29\xa3\x49\x8d\x47\x6e\x02\x3e\x7f\xa3\xe6\x65\xef\x1b\x8c\x93\x74\x08\x86\x14\xac\x0c\xd5\x28\x5f\x53\xd7\xbb\x28\x6b\xf2\x01\x3a\x2f\x96\x7e\x62\xc1\xa7\x9d\xb6\x65\xb6\xb4\x44\x46\xc1\x43\x61\x92\xb4\xab\x99\x05\xbd\xea\x32\xa5\x60\xb5\xbe\x41\xbf\x92\x68\x7b\x55\x25\xea\xca\x9c\x7c\xe3\x6e\x4f\x1c\x8c\xd5\x1e\xe8\x22\xe5\x5f\x67\xd3\x3b\x94\x5d\x58\x53\x3f\xbd\x3f\xf9\x85\xe9\xd4\x63\x2a\xd1\xb6\xe5\x38\x5f\x3f\x20\x08\xcc\xa5\x6f\xbf\x93\x3d\xec\x8e\x55\x7e\xf9\x28\x70\x28\xaf\xd0\xdc\xd2\x1d\xe9\x61\xae\xd6\x3b\x67\xf1\x90\xae\x0d\xc9\x14\x50\xc2\x01\x3b\xbd\x4b\x8a\x04\xaf\x10\xb5\x50\x2a\x5a\x42\x61\x16\x2c\x2e\xc9\x8f\x35\xa0\x0b\x39\xf3\x1c\x0c\x89\x87\x2d\x9c\x04\x86\x99\x92\x87\x31\x01\xe0\xd3\x02\xde\x14\x0a\x7c\x39\x86\x46\xb7\x22\x49\x7f\x9c\xe3\x85\xc9\x62\x96\xc6\x0b\x68\x4a\x3d\xc5\x6c\x84\xb7\x2d\x15\x1c\xa2\x82\x93\x02\x74\x0a\xd3\x43\xf7\x0c\x32\xea\x5a\x5f\x6e\xd2\xf0\x71\xe0\xaa\x19\x55\x31\xc9\xa6\x8c\xfa\xc1\x3b\x64\x0f\x18\xc3\xa9\xa8\x66\xf9\x66\x04\x8d\xe6\x53\xca\x97\x4d\xfa\x40\xd7\xaa\xe8\x85\xa5\x0f\xd7\xd5\x6e\x5b\x6c\x57\x68\x33\x39\x23\x95\x5e\xb4\xca\x91\x15\x82\x1d\x98\xac\x32\xef\x41\xd1\x0a\xb7\x04\x8d\x0c\x6d\x1b\x57\x88\x2b\x1f\x9f\xb0\xad\x1f\x91\xdb\x4a\x60\x20\xa3\xef\xf7\x2c\xe6\xd7\xeb\x9f\x48\x71\xbe\x67\x22\x26\x2c\xc1\x26\xb7\xbe\xc8\x78\x8e\xde\x2f\x54\xc7\x3d\xb9\xfd\x1c\x96\xe3\x86\x36\x3f\xe3\x7b\x3a\x13\xfa\xd3\x4e\x8f\x3d\xd7\xed\xc4\xae\x0c\x6f\xd0\x84\xd2\x77\x89\x0b\x11\x2d\xb6\x47\x7e\x36\xae\x0e\x68\x69\xf7\x37\xcb\xd2\x58\x1e\x61\xe0\x3b\x30\x60\xa4\xf3\x4d\x1e\x53\x68\x7e\x97\x57\xb9\x12\xac\x89\x97\xc1\x5a\x3d\x87\xa8\x6f\x30\x21\x3e\x5e\xb2\xc5\xe4\x36\x84\x71\xbb\xc0\x33\xfb\x6d\x68\xb1\x51\x93\x64\x0d\x95\x2e\x3a\xfc\x2c\xb9\xf9\x79\xcc\xa7\x2a\x9b\x1a\x39\x72\x29\x8f\xc9\x50\xaf\xc5\x00\xd1\xac\xc5\x0d\xb2\x58\x9a\x43\x15\xc7\x35\x8e\x17\xca\x44\xf9\xa9\x0d\x49\x24\x4d\x94\x2b\x58\x18\x22\x96\x9e\xe1\x8a\xec\x1f\xfd\xe9\x17\xd1\x02\x64\xc1\xed\x00\x96\x64\xfb\xf5\x93\xd0\xb9\x96\x8f\x92\xd5\x20\x70\x38\xa9\x97\x52\xa4\x04\x18\x15\x4e\xeb\xfe\x86\x5d\xa7\xca\xcb\x91\x20\x1f\xfc\x3e\xdb\x8d\x76\x5e\xd4\x57\x4b\xa6\x8a\x36\xce\x4a\x4f\x33\x40\xef\x60\xfb\x83\xb7\x19\x17\x7d\x6f\x66\xb6\xdc\x17\x8f\x9c\xa1\x7f\xcc\x24\xec\x13\x8e\xeb\x90\x5e\x8c\xa5\x91\xe0\xa1\x39\x9e\xac\x39\xb3\x93\xf5\xe7\x73\x69\xa5\x22\x22\xac\x0e\x68\x9a\xfc\x58\xf7\xf0\x64\x82\x91\xb7\xdb\x6f\xa8\x47\xa3\xc4\xdf\x2d\x55\x2f\x94\xcd\xb1\x1e\x78\x67\x27\xda\x7d\xd6\xd1\x99\x09\xb0\x70\x0b\xe1\x75\xbb\xdd\x35\xbb\x5d\x09\x61\xa9\x9c\x6a\xc8\x4a\x0f\xd2\x34\xa9\xea\xdd\x88\xcc\x1f\x3a\x18\xff\x4d\x68\x99\xbd\x90\xca\xb6\x92\x98\x2c\x56\xc0\xfa\x8b\x54\xe5\xb5\xc0\x84\xde\xcb\x72\xfc\xc6\x85\xd6\x94\x7c\xa4\x2e\x9b\x95\x50\xf7\x8d\x4c\x07\x4b\x37\x39\xc5\xa1\xc8\x7f\x9c\xbe\xba\xe8\x05\xd6\x08\x42\x47\xae\x11\x76\xa6\x13\x97\x74\xf2\xab\x27\x66\x14\x63\x30\xf7\xf2\x23\x5e\xc2\x32\x1d\x1e\xcb\x5b\x53\x61\xbd\x01\xa9\x13\x2d\xfb\x50\x44\xe8\xfc\x1e\x7b\x80\x05\xd4\x55\x9b\x32\xd2\xb7\xbd\x5f\xcc\xc0\xf6\x95\xa6\x22\x16\xb6\x0e\xdb\xc0\x20\x3a\x6e\xe1\x81\x3d\x23\x03\xae\x1b\x95\xd9\xa7\x8b\xd7\xc2\xb1\xd3\x41\x7f\x4e\x7b\x7f\x1b\xdb\x1e\xb6\xfb\x16\xd8\xe2\x1e\xef\x36\x55\x01\xd3\x38\xae\xc5\xdb\x96\xc8\x36\x79\xd0\xc2\x7c\xe6\xb7\x39\x9a\x44\x5b\x72\x77\xd8\xa6\xdd\x0d\xa9\x0b\x2a\xc1\x6e\xd0\x72\xad\x76\x84\xc9\xd6\x65\x68\xa0\xe8\x72\x93\xc9\xd1\x46\x21\x7f\x1a\x19\x66\xc2\xa9\xb6\xc7\x6c\x07\xe3\x42\x8e\x44\x94\xb4\xbd\x2b\x9f\x44\x25\xac\x70\x93\x13\x59\x54\xaf\x56\x91\xf4\x0a\x1b\x1f\xaa\x8b\x46\xd5\x5e\x21\x6f\x84\xca\xea\x1d\x98\xf8\x77\xf4\x2e\x68\x8f\x88\x66\xda\x20\x69\x64\x08\xab\xb7\x31\x98\x25\x29\x9a\x26\x94\x19\x3c\xd0\xe5\x2b\x3e\x8e\xef\x92\x2c\x8f\xa4\xaa\x7e\xab\x1a\x74\xd8\x5a\xac\x27\xf0\xda\x93\x7f\xdd\xce\x8b\x31\x4f\xef\xc4\x31\xc2\x1a\x3d\x8b\x94\xa2\x9d\xdf\xd5\x6b\x30\x3d\xd8\x4a\x27\x38\x7e\x20\xe5\x2b\xb6\x9c\xae\x9a\xf2\xe3\x5c\x02\x9a\x40\x6f\x0a\x74\x04\xde\xd7\x9a\x88\x0d\x56\x81\x51\x37\x6b\xdc\x63\x0a\xc4\x44\xae\x88\x51\x0c\xd3\x04\xf7\xd6\x12\x0b\x99\x18\xb7\x60\x33\x10\x7f\x4a\x94\x61\xf2\xe6\xa2\x47\x44\xd9\x83\x62\xc3\x43\x0e\x53\x2b\x59\x6e\x11\xdf\xf1\x0d\xb9\x2b\xb2\x52\xe4\xbe\xfc\xf7\x97\xbf\xe8\xc4\x91\xb8\x8b\xc9\x72\x18\xa4\xc8\xae\xbb\xa5\x7d\xa2\x14\x2f\x80\x6e\x5b\xab\x4f\x01\xec\x1e\x2d\x51\x84\x38\xc7\x8c\x33\xb0\xc1\xc2\xfd\x91\x88\xac\x21\x7c\xec\xef\x27\xe8\xcc\xba\xd2\xdf\xe8\x6c\x14\xc3\x19\x79\xc9\xf9\xba\xd2\x1d\x11\xf4\x9a\xbe\xcf\x08\x4d\x72\x0f\xa1\x53\x0b\x34\xa2\xe7\x09\xad\xfa\x05\x30\x41\x9e\x93\x50\xd9\xce\x91\x17\xca\xdc\xbb\x16\x17\x78\x11\xa7\xde\x3d\x8d\x78\x2d\x3e\xf0\x93\xfd\x35\x63\x69\x53\x5a\xf8\xc3\xd5\x01\xc9\xab\x6c\xb8\x50\xa4\xb6\xc0\xb9\x5f\x34\xb9\xa6\x9c\x38\xac\xbc\x81\xca\x02\x2a\xb5\x73\x82\xf3\x0b\xd8\x42\x83\xcd\xe9\x85\xbd\xc9\xf4\x1c\xe8\x90\x6e\xdd\x71\xbc\x5f\xdb\xda\xdb\xb0\xcd\x43\x9d\xfb\x41\x0a\x35\x2e\x24\xd5\x68\x34\x77\x66\x55\xf7\xd2\x59\xd2\xda\x2f\xf3\xc3\xfd\x12\x3f\xdc\x96\xe2\x1a\x76\xd0\x7e\xde\x3e\xdc\x4f\x0e\xa7\x62\xc2\xf7\xb7\x13\x58\xdc\xca\x21\xfe\xe0\x49\x53\xbf\xe6\x2a\x22\x63\x0e\x3e\xea\x0c\x7d\x92\x94\x42\x6d\xda\xe8\x76\x3d\xaf\xa6\x75\x23\x37\x10\x4a\xe7\xe6\x95\xa1\xc9\x94\x06\xae\xca\x6c\x95\x5c\xd9\xcb\xae\x3e\xb5\x0a\xb9\xb6\xb5\x67\xbb\xdf\x44\x8b\x43\xef\xfc\x4e\x80\x94\xa7\x6c\x48\x0b\x59\x45\x7a\xae\x2f\x77\xaf\xcc\x2b\x9b\x4c\x82\x30\x94\x2c\xa1\xaf\x27\x52\x1e\x4f\x04\x27\xf2\xff\xd1\x09\xbb\xfb\xfa\x09\xbb\xf3\x27\x4c\x5f\x64\xc7\xf0\x0e\x3c\x02\xd1\x87\x1f\x1a\xbd\xcf\x02\xbd\xcf\x80\xde\x9d\x3a\x5b\x50\xb8\x7d\x76\x93\x14\x19\x48\xb0\xad\x55\x95\x2f\x3f\x5f\xc9\x29\x65\xff\x86\xd3\x6c\x97\xef\x88\xa9\xbe\xc9\xb7\x0f\x5b\x7e\x30\xda\xef\xe2\x25\x0b\x93\xb5\x59\x49\x9e\xfe\x08\x56\x0a\xf7\x2e\xaa\x38\x3d\xd9\x33\x51\xc7\xb9\x7e\x47\x64\x53\x37\x77\x44\x55\x9c\x8e\xac\x51\xbb\x7d\x76\x57\x74\x2a\x1d\xa4\x7b\xc1\x95\xe8\xd3\xb4\x98\xcf\x64\xd0\x9c\xc8\x48\x40\x27\x77\x15\x20\xcb\xaf\xb6\xb3\xc2\xdf\x8f\x0d\xe5\x2e\xf3\x3f\xfc\xe8\x38\xc9\xad\xce\x3f\x86\x8b\xd7\xc6\xc9\xec\xef\x6c\xbc\x16\x06\x31\xd8\xe0\x5e\x2f\xec\x4c\x7e\x0b\xbd\xce\x8b\x57\x87\x07\x6c\x97\x3f\xff\xa3\x77\xc9\xb3\xb3\x40\xe7\x37\x96\xc3\xde\xc9\xda\x38\xb5\xfe\xd2\xf2\xbe\x99\x61\x43\xd9\xad\x81\xb2\xeb\x43\xf9\xcf\x06\x28\xbb\x7f\x0a\x43\x81\x72\x0f\xca\x71\x13\x94\x17\x35\x50\x5e\xf8\x50\xce\x9a\xa0\x3c\xaf\x81\xf2\xdc\x87\x72\xd1\x00\xe5\xfb\x30\x90\xef\x7d\x18\x7f\x6e\x80\xf1\x5d\x18\xc6\x77\x3e\x8c\xd3\x06\x18\xdf\x86\x61\x7c\xeb\xc3\xf8\x52\x0f\xc3\x83\xb0\x08\xd5\x73\xd6\xa8\xa6\x8a\xfb\x88\xd4\x56\x1d\xef\x6d\x55\x99\x6f\x11\x46\x4c\xc2\xd9\xad\x83\x53\x61\xbf\xbf\x35\xc1\xa9\xe3\xbf\xad\x2a\x03\xc6\x8d\x70\x5e\xd4\xc1\xa9\xb0\xe0\xa8\x11\xce\xf3\x3a\x38\x15\x26\x9c\x35\xc1\xf9\xde\xfe\x62\xa7\x03\xa8\xc2\x88\xd3\x26\x38\x35\x9c\xb8\x55\x61\xc5\xff\xfd\xbf\xea\xc0\x40\xed\x1a\x5e\xdc\xaa\x30\xe3\xa4\x1e\x97\x10\x8f\xad\x48\x10\x65\xd9\x31\x4e\xfa\x1e\x61\xcd\x34\x85\x9f\x9c\xbe\xfc\xe5\xfa\xfc\xf8\xe3\xc9\xf1\xf9\xf5\xfb\x4f\xa7\xf2\xd3\xe8\xe6\xb2\xa7\x08\x20\xa5\x28\xad\xda\xd4\xa4\x6f\x38\xe6\xce\x31\x99\x49\x5d\xd3\x6e\x93\xf2\x8e\x0a\x9f\xd7\xcd\xbc\x54\xa7\x68\xe8\x7e\xc8\xa6\xe9\x82\x8d\x92\xbc\x28\x75\x5b\x0f\x1d\x68\x1c\xb5\x74\x34\xa1\x0b\xf8\xd0\xab\x5c\xd9\xc9\xa9\xcb\x61\x2a\x0a\xd6\x09\x63\x91\xb0\x0a\xfc\x8a\x07\xef\xc0\x26\xde\x03\xe6\xc5\x00\x89\xea\x94\xa7\x4b\x64\x0f\x34\xd9\xfd\xe4\x8d\x54\x93\xe3\x6f\x8f\x5d\x92\x37\x48\xec\xa4\xd5\x93\x9f\x3f\x70\x4f\xa4\x3e\xa6\xf3\xd4\x4e\xf8\x63\x07\x32\x4b\x72\x5b\x9c\xa8\x22\x18\x6f\xd9\x3c\x8b\x2b\xb9\x69\x02\x35\xbc\xe4\x88\xe1\x95\x9e\x90\x35\x49\xc6\xc8\xcb\x4a\xb8\x7d\xfa\xf8\xce\x9c\xb1\xdb\xb5\x82\xb6\xbb\x53\x41\x1c\x19\x2e\x4d\x30\xa7\xf3\x56\x9d\x3b\x50\x57\xf1\x70\x28\xdc\x48\x4c\x7f\x08\x17\xbf\xea\x08\xc5\xd7\xf2\x6b\x7d\x32\xdb\xb5\x53\x5b\x7c\x46\x11\x8b\x7a\x30\xf2\x6e\xd5\xae\xf0\x86\xaf\x06\x54\x25\x01\x0e\x4e\x86\x7f\xe2\x5c\xd0\x67\x5d\x0a\x1e\xe7\xe2\xdb\xc4\xad\x96\x27\x93\x2a\x08\x4a\x12\x8f\xe6\xf6\x4c\x5d\xf4\x0c\xc3\xc1\xa8\x51\x61\x33\x76\x40\x71\x23\xdf\x95\x9d\xf6\x37\x6d\x7d\x83\xdb\xc0\x78\xcb\xd3\x99\x76\x0b\xfa\x83\xf9\xd1\xab\xd6\xb1\x43\x39\x7c\x18\x62\xc0\xa6\x49\xd1\xb1\x30\x5d\x49\x2d\x45\x65\x9b\x5a\xea\xa3\xe0\x2e\xdf\x54\x71\x15\x2e\x0e\x15\xd8\xfe\x87\xca\x87\x4b\xa5\xc3\x5f\x7e\xae\x7c\x43\xe7\x50\x16\x0e\x12\x98\x22\x33\xb5\x5d\xeb\xb5\x30\x37\xbd\xb9\xc7\x88\xa5\xae\xbc\xf2\xad\x53\x6e\x0a\xe1\x13\x2c\xa8\xe2\x27\x88\xa9\xc4\xec\x74\xa5\x7b\xb1\x1a\xe1\xab\xa2\x42\xb4\xf3\xd1\x7c\x62\x49\x5e\x9f\x79\xff\xe1\xe2\x78\xcf\x4b\x31\x78\xc3\xd9\x17\x3e\xa3\x2f\x8e\x14\x8b\xe9\x40\x44\x08\x6c\xcf\xcb\x24\x45\x35\xa9\xfe\x02\x01\xee\xa2\xdb\x6c\x8f\xe0\xbe\x4b\xa6\x78\xa8\x61\xae\xcf\x34\x4c\x85\x26\x4b\x58\x78\x69\x56\xc5\xea\x42\x51\x97\x3e\x15\x9c\x40\xb3\x5b\x21\x67\x94\x6f\xcf\x0e\xce\xf3\x34\x80\xa0\x83\xc9\x3c\xa8\x22\x64\x7e\x37\xaf\x5a\x20\x3e\xdc\x7c\xc6\x60\xc3\x83\x2a\xe3\xde\x72\x60\x13\x18\xf2\x8f\xa6\x9a\xa3\x7c\x14\xfe\x8e\x4e\x7f\x2a\x22\xb0\x3a\x16\x6c\x15\x84\x2d\x3e\x00\x2e\x62\x5f\xbf\x91\x1f\xce\x94\xea\x95\x78\x04\xfd\x67\x1c\x74\x15\xa6\x0b\x85\xff\xa9\xab\x1f\x70\x4f\x67\x91\x76\xa5\xc0\x58\xdc\x69\xcf\x93\x60\x3f\xa2\x74\x65\x6a\x82\xd3\x25\x33\x9a\x1a\x00\xd0\x58\x0e\xf1\x96\xfc\xd9\x54\x6f\x59\x83\xcf\x8f\xd5\xc9\x59\xb5\x40\xfc\x58\xd1\x1b\xae\xca\xfc\xd1\xd1\x6f\x1a\x9a\xad\x4c\x34\x2f\x92\x07\x98\x0f\xdd\x26\xe2\xd0\x8e\x86\x75\x32\x85\x4d\x6c\x32\x0c\xe8\x23\x91\x79\xd4\xd6\x67\xa2\x19\x9a\x17\x72\xda\xdf\x00\xe2\x1f\x44\x07\x12\x40\xb5\xbb\x1e\xac\x46\xeb\x51\x26\x32\xbd\x8b\xd3\x45\xc0\x74\xfb\xbf\x6e\x7f\x1d\x6e\xfe\x1a\x45\x9b\x07\xd1\xe6\xd3\xed\xc7\x11\x2b\x30\x42\x9b\x5e\xc4\x9d\x17\xf3\x59\xaa\x8e\xe3\xe5\x30\xad\xf2\xca\xdc\x9b\x77\xde\x12\xf4\xe8\xc1\x45\x25\x2f\x4a\x1b\x5e\x3f\x7c\x99\x6a\xe5\x20\x9b\xe6\xa3\x86\x3d\x7a\x82\x65\x4f\x8c\xce\xc1\x05\xd7\xaa\x60\x8c\x89\xca\x1e\xcb\x5b\x6b\x41\x5b\x8e\x92\x87\x0f\x23\xd4\xbf\x04\xcf\xc9\x2c\x4c\xd0\xce\xa8\x4a\xc7\xea\x52\x27\xda\x99\x4f\x6e\x78\xfe\x61\x24\x3a\x05\xba\x20\x14\x25\xb0\x36\x3a\x6b\x4f\x83\x79\x21\x82\x53\x8b\x9f\x41\xf3\x77\x2a\x48\x4a\x62\xeb\xeb\xd2\x92\x02\x4d\xf8\xac\xa6\xc4\xaa\x41\x18\xe3\xb6\xbe\x9f\xae\x95\x2b\xc5\x03\x55\x2d\x74\x17\x92\xb5\x68\xa2\x8d\x9e\x0a\x49\x24\x2d\xec\x0f\x18\xba\x89\x6b\x8d\x0d\x6a\x49\xf7\x87\xd1\x87\xa9\x5c\x97\x67\xa1\xc1\xd8\x40\x5e\x0e\x06\xf3\x09\xa6\xea\xa7\xcb\x62\x6b\x28\x93\x1a\x8e\xc5\xd0\x0f\x2b\xf1\xaa\x05\x56\xc7\xde\x29\xbb\x88\x86\xe1\x64\x5f\xb5\x6a\x3f\x5a\xd4\xea\x07\xbf\x5a\x0d\x3b\xf9\x7f\x99\xcb\xdc\x95\x30\x21\x7b\x12\x4d\x6b\x74\xdc\xbe\x9c\x0e\xd5\x5d\x8f\x52\xcc\xa8\xb0\x5c\x0f\xda\xd6\x62\x6e\xaa\x43\xb5\x6a\x5b\xfa\x14\x87\x57\x59\x01\x1d\x72\xbc\xe2\xf8\xe9\xe3\x09\x66\x7c\xce\xa6\x98\x49\x28\x00\x60\xf7\xca\x24\xf5\xfe\x75\x93\xb2\x7a\xb3\x56\x57\x7d\xa5\x03\x25\xc9\x46\x01\x0c\x76\xfc\x2a\xbd\x71\x0c\xb8\x5d\xea\xfc\x4c\x56\xb1\xf8\x48\x0f\x66\xe4\x49\x0a\x8a\xd9\xbb\xe5\xf9\x86\x95\x41\x44\xa5\x04\x36\xdd\x5c\xe9\xa1\xfe\xa4\xd2\x02\x2f\x03\xd3\x5f\x3c\x7a\xd2\x7d\x3d\x66\x4f\xb5\x65\xb4\xc9\x5e\x5a\xb7\x68\xa5\x24\x92\x4d\x69\xb7\xfc\xc8\xfe\x02\xa6\x56\xc5\x7a\xf1\xac\x2e\xcd\x65\x33\x85\x61\x58\x03\x27\x8e\xf2\x75\x4d\x3e\xc1\x96\xe2\x31\xfa\xc2\x17\x85\xd3\x53\xb7\xca\xa4\x50\x47\xcd\xa8\x05\xe9\x52\xa2\xb0\x09\xf6\xf7\xe2\x4a\xd9\xad\x12\xca\x25\x96\x55\x02\xde\xad\xd6\xda\xa6\xbf\x18\xf3\x82\xb3\xf2\x3e\x93\xd7\xb3\x0b\xbc\xa3\x74\xc4\x01\xf8\x00\xbf\x26\x4b\x32\x87\xe6\x37\x06\x5f\x82\xbe\x48\xf0\x92\xcc\x45\x76\x9a\xdc\x22\xe5\x86\xf0\xa6\x8b\x50\x30\x4a\x18\x76\x4d\x78\x9d\x9d\x5c\x1b\xa0\x77\x30\xa0\xe6\x3e\xce\x87\x94\xe8\x04\xf6\x83\x37\x09\x66\x0b\xc7\x2d\x43\x96\xaa\x4f\xa1\x08\x6f\x77\xb4\xa1\x13\xb3\x87\xba\x6e\xd8\xa8\x8e\xe3\x62\xdc\xb0\x80\x9a\xaf\x3e\x29\x1d\x2b\x84\x6e\xf8\x26\x8f\x6f\x65\x32\xaf\x80\x18\x86\x7a\x11\xa7\xb9\x80\xb2\x92\x2c\xcb\x87\xe1\x01\x95\xaa\x1f\x36\xbb\x42\xb6\x86\x79\x36\xa3\x83\x7d\x84\xc3\xfe\x40\xce\xaf\x01\x85\x09\x75\x78\xc5\x85\x67\xa1\x6c\x8c\xc1\x1c\xa5\x0c\xc6\xa0\xc9\x14\x9c\x08\x6b\x5b\xf4\xfb\x86\x19\xd8\x13\xfd\x9e\xd1\x86\x25\xc0\x77\x8a\x38\x0b\x6c\xe6\x4a\x9d\x51\xcf\x5a\xec\x02\xd2\x8f\x75\x6c\xa9\xca\xd6\x11\xa8\x66\x91\xca\x3c\x69\x52\xf2\xb4\x74\x45\x9b\x12\x1d\x84\x77\x60\x1e\x91\x03\xc9\xb9\xbc\x1d\x17\x4d\xf4\xf6\xb3\x67\x1b\xec\x19\x13\xd9\x1e\x64\xb2\x26\x36\x16\x9b\x1a\xfd\x85\x63\xac\xf1\xec\xd9\xb6\x74\xcb\xd9\x59\x9e\xa4\x63\x4e\x7f\xd1\x23\xf0\x49\x12\xc9\x5a\x2b\x3d\x71\xf2\x3b\x8e\x04\x7d\xcb\xb8\xe5\x36\xd4\xc7\x15\x2a\x79\xfd\xf7\x82\xdb\x3f\xf5\xe9\x11\xf5\xe1\x62\x71\x33\x64\x98\xdc\x45\x1e\xe4\xbe\x55\x59\x7e\x76\xd5\xfe\xdc\xb1\xbe\xdb\xd2\x4e\x54\x5d\xf5\x32\xca\x46\xa3\x4e\x9b\x7c\x65\x6d\x7b\x7d\xac\xfb\xc8\x89\x75\xe8\x8e\x2a\x5c\x7f\x5d\x53\x77\xd6\xb7\x97\x41\xd3\xcb\x54\x75\xd2\x0b\x7d\xe8\x85\xee\x78\x3e\xea\xdb\x9e\x5e\x82\xae\x3a\x3c\x1c\x97\xf9\xfa\x9f\xeb\x0c\x43\x07\x4a\x55\xc1\xdb\xfc\x4d\x73\xeb\xe4\x0a\x6b\x9e\x57\x25\x06\xc2\x77\xe2\x26\xed\xd7\xd3\x59\x9c\x27\x98\xf2\xea\x54\x5e\x75\xac\xf9\xc8\x10\xc9\xf4\x87\x51\x47\x7e\x5e\x05\x77\xfd\x5b\xbb\x26\x7c\xd9\x85\xe2\xeb\x4f\x67\xce\xe4\xb7\x7c\x0b\xb9\x49\x36\xc2\xf1\x08\xff\xb3\x3d\x00\x20\x38\xd6\x94\xe0\x94\x3d\xe5\x00\x5f\x85\xbd\x0b\xc2\x60\x1f\x80\x24\xf7\x30\x2b\xfb\x50\xc9\x30\xf5\xb0\x03\x2d\xc8\x19\x68\x27\x0b\xb2\x66\xed\x75\x36\xa7\x15\x22\xd0\xcc\x4e\x71\x15\x24\x64\x08\x6d\xc2\xda\x82\xbc\xc5\x5e\xc0\x3e\xcc\x14\x28\xd9\x0c\x7e\x1f\x51\x53\xbe\xf2\x7d\xc4\x6a\x57\x6a\x72\x1a\x3e\x55\x14\x44\xb1\xff\x58\xd5\x20\x7c\x90\xfa\xd3\x4a\x02\xf6\x39\x77\xbe\x80\xf4\xb8\x8f\x24\xd9\x81\xb8\x24\x6a\x4a\xea\xf7\xaa\xdf\x7e\x57\xc8\xfc\xf3\x50\xa9\x7c\x54\xde\xd1\xb2\x6b\x7c\xee\x3e\xf8\x15\x7a\xe7\x13\xf3\x5a\x7d\x9a\x9a\x49\xb1\xa5\x01\x34\x7c\x87\x3e\xbc\x02\xf5\xc4\xcd\x9e\xbe\x4f\xc0\xd1\xe8\x1f\x41\x41\x8b\x3c\x5f\x4b\x1d\x39\xfa\x20\x65\x2c\xca\x79\xd4\x71\x80\xfd\x4e\x02\x91\x25\x69\x51\xa8\x6e\xe0\x16\xc1\x26\xaa\xcc\xe4\x65\xad\x78\x68\x7d\x5b\xca\x59\x0c\xac\xb3\x32\xb1\x08\x88\x34\xd9\xfa\xcb\x41\xee\xca\xd4\x54\x2f\xd2\x1f\x29\xd3\x48\x05\x17\x2a\xb5\x8d\x39\xe2\x23\x9e\xe3\x06\xf6\x4e\x59\x5a\xd9\x88\x8d\xa6\xb4\x13\xb9\x8f\x93\xf2\x8c\xe7\x49\x36\x44\xf4\xc4\x42\xc1\xcd\x57\x8c\x70\xe3\x0b\x74\xc5\xaf\xb2\xc3\x56\x1b\x2f\xee\x0d\xd3\x85\xb1\xb5\x87\x1c\x2f\x4a\x82\x4e\x1b\x4d\x7b\x16\x28\x2b\x79\x86\x48\x99\x62\xed\xb3\x2a\x76\x81\x93\xf0\x45\xd6\xb7\xbc\x08\x30\xfd\xa8\xaa\xe0\x0f\x65\x35\xb2\xbe\xab\x5a\x0c\xb2\x19\x77\xbe\xb6\x6a\xb0\x86\xa1\x7e\xe1\x6f\x6c\xe3\x43\x75\x3f\xc5\x98\xad\x74\xd1\xa1\xd6\x3d\x02\xef\x04\x97\x49\x0c\x28\x10\x4f\x67\x7c\x51\xf0\x9c\x41\xca\x6f\xc0\xdb\x7b\x0f\x3b\xa5\x80\x38\x1d\xd0\x94\x00\xdc\x61\x8b\x77\x2a\x3e\x64\x26\x72\x15\x7f\x83\x29\x99\xbe\x89\x27\xb3\xbe\xfa\x5e\xd2\x3e\x95\xa4\xa5\x2e\x38\xa4\x82\x5b\x5d\xd0\x6e\xb5\xf7\x58\xfb\x9b\xbf\xce\xb3\xb2\xdf\x96\x75\xda\x2d\x2c\xfa\xc3\xb7\xdf\xeb\x92\x6d\x51\xf2\xf0\xfc\x4d\xbf\xad\x33\x1f\x4b\x02\xc8\x70\x03\x89\x9e\x71\xa0\x5c\x7e\xb3\x7f\xd8\x6a\xff\xba\x7d\x85\x7e\x14\xf3\x31\xb0\xc2\x33\x8a\xf5\x30\x2e\x0b\xbd\x87\xb6\x29\x80\x67\xc9\x92\xea\x22\xbf\x19\x30\xe7\x7c\x26\x43\xe8\x07\x20\x1d\x5c\x7e\xc4\xce\x78\x46\x9c\x3c\x68\xc1\xa4\x67\x98\x4b\x2f\x19\x6c\x7f\x2e\xc4\x86\xe0\x1a\x44\x7b\x86\xdf\xda\x94\x01\x64\x37\x71\xfe\xc3\xdd\x01\xee\x12\x5e\x7d\x3a\x79\x77\x74\xfd\xd3\xf1\xc7\xf3\x93\x0f\xef\xeb\x52\x99\xa1\x3c\x21\x86\x1b\x96\xa4\x5d\x48\x88\x32\x44\x53\x89\xd3\xe9\x1c\x03\xce\xc7\x5c\x6d\xef\xb0\xa5\x9d\xce\x2f\xbc\x25\x77\xf3\x35\x04\x77\xa3\xb5\xb7\xf9\x91\x55\xe4\x01\x7e\xe0\xf0\xac\x63\x65\x05\x20\x0f\x8e\x20\x77\x4d\x3a\xb4\x8d\x8d\xa7\x1d\xac\x00\xff\xfe\x3f\x57\x5d\x1d\x29\x44\x9f\x00\x00")
 
 func pkgUiStaticJsGraphJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -520,12 +562,12 @@ func pkgUiStaticJsGraphJs() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/static/js/graph.js", size: 38722, mode: os.FileMode(420), modTime: time.Unix(1583953499, 0)}
+	info := bindataFileInfo{name: "pkg/ui/static/js/graph.js", size: 40772, mode: os.FileMode(420), modTime: time.Unix(1792002813, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _pkgUiStaticJsGraph_templateHandlebar = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x5a\x5f\x8f\xe3\xb8\x0d\x7f\xef\xa7\x60\xd5\x97\x5d\x14\x9e\xdc\x5c\x71\xf7\x50\x38\x2e\xae\x7b\x83\x03\x0a\x0c\xb6\xd8\xdb\xbb\xd7\x80\xb1\xe8\x58\x1d\x59\xf2\x49\x72\xfe\x34\x98\xef\x5e\xc8\xb2\x3d\xce\x1f\x3b\xce\xcc\x34\xc0\xe6\x21\x9b\x91\x48\x8a\xa4\xc8\x1f\x69\x7a\xa1\xf9\xc4\x5c\xac\x41\xf0\x39\x5b\x19\x2c\xf3\xc5\xc6\x60\x59\x92\xd9\xef\x05\x7f\x7e\x66\x90\x4a\xb4\xf6\x68\x8f\x25\x7f\x82\xee\x13\x67\xda\x14\x2d\xd9\x1f\x15\x99\xdd\xc2\xaf\x1c\xd0\x34\x87\x34\x44\x7e\x3b\x32\x7a\x73\x44\x72\x48\x94\x6a\x19\xc9\x55\x74\xff\xdd\x09\x15\x40\xec\x68\xeb\xd0\x10\x82\xd1\x1b\x3b\x67\xf7\x0c\x4a\x89\x29\xe5\x5a\x72\x32\x73\xf6\xb0\x2d\x0d\x59\x2b\xb4\x82\x0f\xf5\x2f\xf8\x35\x17\x99\xfb\xeb\x83\x72\x64\x20\xd3\x06\x14\x6d\xa4\x50\x64\x3f\x32\x50\x58\xd0\x9c\xd1\xb6\x34\xac\xf6\x82\xff\x75\x64\x7c\xad\x70\xaa\x95\x33\x5a\x02\x75\xc2\x17\x42\x95\x95\x63\xc0\xd1\x61\x54\x1a\xbd\x16\x9c\xe6\xcc\xed\x4a\xc2\x9c\x90\x33\xc0\xca\xe9\x54\x17\xa5\x24\x47\x73\xa6\xb3\x8c\x81\x2d\x49\xca\x34\xa7\xf4\x69\xce\x32\x94\x96\x58\xb2\xdf\x7b\x91\xcf\xcf\xf1\xac\x35\xeb\xc4\x2f\x33\x2e\xd6\x13\x9c\xf5\xfd\x39\x5f\xf5\xc8\x68\x8d\x72\x61\x1d\x3a\x0b\x99\xd4\xe8\x22\x23\x56\xb9\x63\xc9\x59\xf9\x00\xb1\x28\x56\x60\x4d\x3a\x67\xfb\x3d\x94\xe8\xf2\x7f\x1b\xca\xc4\x16\x9e\x9f\x67\x5e\x88\x48\x67\xa2\x58\xcd\xf0\x3f\xb8\x8d\xa4\x46\x4e\xe6\x6e\x25\xb2\x7f\xac\xe7\xfb\x3d\x2c\x2b\x21\xf9\xef\x64\xea\x3b\xe8\x79\xd2\x96\x42\x29\x32\x0c\x50\xba\x39\xf3\xac\x8b\x76\x69\x82\xd1\xe7\x96\x8e\xe3\x4a\x28\x7f\xb1\xa7\xd2\xea\xbb\x6a\x29\x97\x4e\xc1\xd2\xa9\xa8\x34\xa2\x40\xb3\x03\xda\x52\x5a\x39\x5a\x2c\x9d\x62\xe0\x2f\x70\xce\x6c\xb5\x2c\x84\x63\xb0\x46\x59\x91\x0f\xa9\x9a\xa2\x0d\x97\x66\xf7\xe4\x14\x4b\x92\xd2\xee\x98\xb4\xb2\x4e\x17\x51\xb3\x38\x14\x45\x61\xbb\x95\x2c\x94\x25\xe3\x16\x05\x39\x23\xd2\x73\xf7\xa9\x4b\xe7\x9d\xda\xe8\xc5\x92\x08\x02\x0b\x04\x16\x40\x07\x69\x65\xac\x36\x10\xc5\xb3\x40\x7c\xea\xda\x70\xe6\xc9\xfa\xb2\x72\x4e\xab\xc6\x03\xe1\x0f\x76\xec\x33\x4e\x19\x56\xd2\x41\x40\x04\x5d\x3a\xef\x35\xe0\xc4\xab\xb2\xf6\xdf\xa9\xe7\x3b\x0c\x91\xbb\x32\x17\xa9\x56\x3e\xe2\x44\x12\x78\xa4\x48\xd1\xeb\x78\xa2\x62\x38\x7e\xe0\x1e\x83\x86\xb9\xe0\x9c\x54\xeb\xb9\x5a\x5c\x77\x61\xf7\xa7\x8a\xbc\xde\xba\x12\x8d\x13\x28\x17\x86\x6c\xa9\x95\xa5\x2b\x0d\x6d\xd8\xa1\x65\x7f\xbb\xad\xc7\x0a\x0d\x9a\x3d\x25\x65\xa6\x42\xf1\x45\x78\x31\x46\x1b\x40\xe9\x83\xb1\xfe\x8e\x38\xaa\x95\x4f\xed\x21\x80\xe9\x31\x6f\xd0\x28\xa1\x56\x07\xec\xcd\xda\x00\xff\x30\x40\x1c\xae\xfd\x39\x8a\x8e\x38\xbf\x7e\xfe\xf9\xf3\xdf\xe1\x93\x56\x6b\x7f\x96\xcb\x85\x05\xa7\xe1\x9f\x5a\x3b\xeb\x0c\x96\xa0\x70\xbd\x44\x73\x07\xf0\xd5\x6f\x19\xfa\xa3\x12\x86\x2c\xfc\x0b\xd7\x68\x53\x23\x4a\x77\x62\x89\xff\x18\xca\x0c\xd9\xfc\xee\x68\x33\x8a\x6e\xe4\x7e\x29\xac\x8b\x56\x46\xfb\x24\x30\x5a\xfa\x52\x84\xcb\x12\x15\xc9\x33\x8c\x00\x71\x25\x5b\x4e\x85\x6b\x6f\x73\xe4\x70\x69\x7b\xbc\x5e\xe0\x59\x56\x80\x58\x8a\x1e\x73\x24\x1c\x15\x2d\xa3\xc7\x35\x52\xae\x4e\x6a\x96\xc4\xd8\xa7\x93\x42\x3d\x31\xc8\x0d\x65\x73\xf6\x97\x3a\xc5\xda\x4a\x8b\x46\x60\x0b\x8e\x6d\xbb\xd1\xee\x75\x0a\x35\xa5\xd6\xe9\xd5\xaa\x5d\x49\x7e\xf1\x94\xf1\x0c\x93\x78\x26\xc5\xdb\x94\x6d\x89\x30\x75\x62\x4d\xa3\xba\xa7\x5a\x59\x2d\x69\x40\xfb\xa3\xdd\x51\xfd\x3f\x05\xda\x31\x0b\xe2\x59\x25\xcf\xae\xf7\x2e\xdf\xe1\xb2\x56\x80\xd4\xe0\x95\x79\xea\xa3\xb8\xe8\x73\xfb\x95\x06\xf6\xbc\x20\x14\x8a\x0c\x18\xf2\x95\x9d\xbd\xb4\x87\x8d\x4d\xe7\x8f\x38\x8a\x5b\x49\x68\x32\xb1\x1d\x24\x0e\xb9\x09\x0f\x5b\x67\x30\x75\xc4\x7d\x12\x66\xda\xa4\x5e\x0d\x5d\x95\xc4\xa1\x06\x40\x7b\x77\x92\x43\x43\x47\x96\x46\x17\xe4\x72\xaa\x6c\xe8\xcd\x16\xb5\x20\x30\x1e\x86\xc2\x0a\x94\x95\x94\x91\xa4\x6c\xc8\x4d\x8d\xd0\x80\xc8\x23\x14\x70\x5c\x39\xa4\xef\xa5\x7a\xe2\x47\x79\x0f\x6a\xd0\x28\x65\x5b\xdb\xd2\x45\x6d\xc5\x05\xb1\xc2\xf9\xfb\xfd\x35\x37\x42\x3d\x81\xcb\x09\x9c\x28\x28\xd8\x7f\x37\x6a\xf0\xd9\xf2\x05\xdd\xaf\xa8\x10\xaa\xb2\xa1\x9c\x8d\xb9\xad\xad\x64\x67\x40\xf7\xe5\x13\x45\x49\x28\x6d\x53\xdc\xdb\x39\x34\x04\xc3\xb8\xfd\x3e\x4c\x7b\x97\xdd\x04\xeb\x14\x97\x7d\xed\xfc\x04\x3a\x0b\x69\x30\xe5\x06\x7d\xc7\x3e\xe5\xfe\x7a\x4a\x8d\x93\x5b\xf1\x5f\x9a\xb3\xbf\x8d\x13\x35\xb5\x7e\xbf\xef\x89\xf5\x49\x79\xd1\xeb\x13\xa2\xfa\x6d\x71\x7d\x4d\x64\x43\xd7\xf1\x4e\x8a\xed\xee\xaa\x7e\x31\x7a\xf3\xae\xb1\x5d\xca\x6b\x42\x7b\x18\x88\xce\x34\x1f\x07\xfb\xb7\x01\xbb\x3e\xc0\xbd\x11\xe1\x6e\x1f\x0b\x1e\xe7\x48\xf1\x89\x91\xf0\x85\x36\x42\xf1\x3a\x16\xc8\xff\x2b\x8a\x37\x46\xc2\x12\xd3\xa7\x0d\x1a\x7e\x25\xd0\x0d\x11\x4e\x03\xba\x33\x50\xc7\xd1\xb5\xf5\x6a\x02\x66\x04\xdc\x23\xc5\xa7\xe0\x5d\xe7\xbc\x87\xc6\x63\x1d\xde\xc1\x87\xdf\xbe\x7e\xfa\x78\x89\xfb\x60\xd0\xf2\x9b\x72\x42\x5e\xe2\xa8\x7b\x1e\xdf\xe9\xa2\x9b\xb3\xdd\x6e\xb7\x8b\x1e\x1f\x23\x7e\xf9\x8e\x27\x02\x6c\x1b\x3a\xa4\xf8\x14\x80\x6d\x21\xf6\xfe\xc7\x4b\x74\x1d\xca\x92\xe2\x1d\xba\x7e\x9b\xf0\x3a\x3d\xa5\x7e\xe2\x6b\x54\x29\xbd\x63\x4e\x65\xda\x5c\x97\x52\xaf\x07\xd8\xeb\xc0\x71\xcc\xa0\xfe\xb0\xa8\x99\xf2\x75\x98\x63\xb5\xac\xea\x11\x8c\x50\x60\x29\xd5\x8a\xdb\xa3\xf9\xe3\x17\xb2\x77\xf0\xc1\x7e\x64\xfd\x20\x6e\x27\x47\x8e\xca\x76\x70\xe8\xd3\xf6\xe5\xef\xf6\x71\xa1\x8b\xbb\x97\x2d\xbf\x1c\xc2\xf6\xc7\xb1\x6e\xfa\x66\xfe\x99\x32\x49\x09\x81\x6d\x1d\xa6\x4f\xc4\xcf\x0e\x4c\x8e\x9d\x3e\x34\x3e\x69\x64\xbc\x29\x76\x46\xa7\x29\xcd\x01\x07\xae\xaf\x57\x46\x9e\x74\x6e\xe8\xee\x6e\xac\x18\xb4\x2d\x70\xbb\xb0\xba\x32\x29\x2d\x4c\x17\x8c\x8b\xc3\x28\xfd\x59\x6f\x94\xc5\xa2\x94\x42\xad\x20\x0c\x01\x43\xb8\x8d\x30\x5f\x78\xb4\xeb\x94\x39\x1c\x40\x62\xe5\x34\x4b\x7e\xaa\x9c\x06\xde\x3b\x74\x68\xf4\x78\x41\xda\x77\x96\x25\x9f\x95\xdc\x81\xc1\x4d\x5d\x38\x5e\x29\xe7\x87\x82\x25\x8f\xb8\x85\x1f\x8a\xf7\xd0\xea\x3e\x0f\xd2\xee\xf3\xeb\xa5\x0d\xcd\x5a\x7b\x04\x67\x67\x63\x07\x9b\x13\x9e\xb6\xc3\x93\x3b\x1a\xc2\xc1\x71\xdb\x29\x93\xa4\x95\x2f\x0d\x63\x0c\x63\x5b\x53\x26\x0a\x61\x98\x02\xcd\x48\xe4\x60\xa0\x70\x38\x26\xf9\xb6\x46\x0a\xdf\x7c\x97\x5d\xe8\x82\xd4\x65\xd9\x27\x8d\x76\xe0\xbb\x75\x9b\x3d\xea\xe3\x9b\xb5\xd8\xc1\xf6\xab\xba\xec\xc7\x9a\xc5\xf7\xd8\x6d\x06\x5c\xdf\x65\x3f\x4e\xba\xaa\x1b\xb5\xd9\xc1\x07\xff\xa7\x4e\x3b\x08\xaf\xc1\xe0\x9b\x4b\x2a\xdf\x67\x5f\x95\x54\xfd\x56\xfb\x3d\xb2\xea\xdd\x1b\xed\xd7\x6c\x3a\x5c\x4a\xea\x15\x01\x49\x50\x7f\x47\xb6\x68\x7e\xe4\x7a\x4d\xa6\xcd\x86\x45\xbd\x36\x06\xe7\x2e\x27\xe4\xa3\xe6\xb8\x3c\x79\x90\xe4\xfd\x17\xcf\x5c\x7e\x89\xf4\x77\x1f\x6b\xe3\x84\x7e\x77\xf4\xd0\xd8\x2d\x35\xdf\x8d\x9f\x64\x92\xd8\x71\x48\xb5\xb4\x25\xaa\x39\xfb\x9e\x25\xb1\x48\x94\x6e\x9a\x1a\x91\xc4\x33\xc7\xfd\x97\x19\xd5\x63\xec\x9c\x78\x56\x3b\xef\xca\xb2\x3d\xf4\x2a\xee\xba\x37\x6c\x07\x4b\xaf\x7e\xa1\x05\x1e\x76\xda\xff\x84\x70\xde\x0c\x6c\x07\xa8\x54\xe8\x35\x75\xef\x5f\x58\xf2\xa5\x5e\x80\xee\xf5\xcf\x2b\x54\x8f\x67\x5e\xdd\x97\x95\x86\xe0\x7f\x01\x00\x00\xff\xff\x4b\x0a\xff\x8a\x18\x23\x00\x00")
+var _pkgUiStaticJsGraph_templateHandlebar = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd5\x5a\x5f\x6f\xdb\x36\x10\x7f\xef\xa7\xe0\xb8\x97\x14\x83\xec\xa6\x43\xfb\x30\xd8\x1e\xba\x36\x2b\x30\x20\xc8\xd0\xa6\x7d\x35\x68\x89\xb6\xb8\x52\xa4\x4a\x52\x76\x3c\x23\xdf\x7d\x77\xa2\xa4\xc8\xb6\x24\xcb\xb1\x97\x2d\x06\xe6\x3a\xe4\xdd\xf1\xfe\xfc\xee\x78\x3a\x8d\x10\xff\x19\x45\x62\x49\x44\x34\xa6\x0b\xc3\xd2\x78\xba\x82\xef\x94\x9b\xcd\x46\x44\xf7\xf7\x94\x84\x92\x59\xbb\xb3\x47\x27\x2f\x48\xf5\x19\xcd\xb5\x49\x4a\xb2\xef\x19\x37\xeb\x29\xae\x6c\xd1\x14\x87\x14\x44\xb8\x1d\x18\xbd\xda\x21\xd9\x26\x0a\xb5\x0c\xe4\x22\xb8\x7c\xb5\x47\x05\x74\x8e\xdf\x39\x66\x38\x23\x20\x05\x68\x2f\x29\x49\x25\x0b\x79\xac\x65\xc4\xcd\x98\x5e\xdd\xa5\x86\x5b\x2b\xb4\x22\x17\xf9\x2f\xf2\x39\x16\x73\xf7\xd3\x95\x72\xdc\x10\x38\x9e\x28\xbe\x92\x42\x71\xfb\x92\x12\xc5\x12\x3e\xa6\x1c\x58\x68\xee\x05\xfc\xb5\x63\x7c\xae\x70\xa8\x95\x33\x5a\x12\x5e\x09\x9f\x0a\x95\x66\x8e\x92\x88\x39\x16\xa4\x46\x2f\x45\x04\x92\xdc\x3a\xe5\x2c\xe6\x2c\xa2\x84\x65\x4e\x87\x3a\x49\x25\x77\xb0\xa1\xe7\x73\x4a\x6c\xca\xa5\x0c\x63\x1e\x7e\x03\xb1\x4c\x5a\x4e\x27\x9b\x0d\x8a\xbc\xbf\x1f\x0d\x4b\xb3\xf6\xfc\x32\x04\xc7\xf4\x70\xd6\xeb\x26\x5f\xd5\xc8\xf8\x92\xc9\xa9\x75\xcc\x59\x32\x97\x9a\xb9\xc0\x88\x45\xec\xe8\xa4\x51\x3e\xb0\x8a\x64\x41\xac\x09\xc7\x74\xb3\x21\x29\x73\xf1\x9f\x86\xcf\xc5\x1d\xb9\xbf\x1f\xa2\x10\x11\x0e\x81\x60\xc8\xfe\x62\x77\x01\x48\x03\xcf\x0f\x16\x62\xfe\xeb\x72\x0c\xd4\xb3\x4c\xc8\xe8\x2b\x37\x79\x0c\x6a\x9e\xb4\xa9\x50\x0a\x00\x44\x98\x74\x63\x8a\xac\xd3\x72\xa9\x87\xd1\x4d\x4b\xbb\xb8\x12\x0a\x03\xbb\x2f\x2d\x8f\x55\x49\x39\x73\x8a\xc0\x7f\x10\x34\x91\x30\xb3\x86\x98\xf2\x30\x73\x7c\x0a\x6b\x94\x60\x00\x41\xd3\x6c\x96\x08\x08\x2e\xb8\x2c\xe3\x08\xa9\x9c\xa2\x84\x4b\xb1\xbb\x77\xca\x2c\x73\x0e\x2c\xf6\x22\xfc\x1f\x74\xf7\xd0\x88\xcf\x59\x26\x1d\xe2\x30\x61\xae\x38\x53\x38\x09\x1c\xbf\xe7\x4b\xc4\xc5\xbc\x06\xb3\x01\x9d\x78\xd2\xd1\xd0\x4b\xdc\x3b\xd5\x72\xc9\xc3\xca\xb8\x30\xb3\x4e\x27\x41\xb1\xd8\x86\x5d\xbf\x5d\xda\x23\x94\xe5\xc6\x4d\x13\xee\x8c\x08\x9b\x50\xa4\x53\x87\xa1\x2c\xbc\x41\x27\x01\xf1\x2c\xc4\xb3\x10\xd0\x3a\xcc\x8c\x85\xd4\x0a\x46\x43\x4f\xbc\x1f\x50\x7f\xe6\x49\x4e\xf3\x75\x08\x0e\x40\xbf\x91\x88\x47\x59\x9a\x7b\x70\x3f\xde\x55\xe5\x92\xeb\x34\x16\xe0\x01\xc4\xb9\x98\x78\x1e\x29\x42\x86\x3a\xee\xa9\xd8\xe2\x61\x8f\x1e\xaf\x61\x2c\xa2\x88\xab\xd2\x73\xb9\xb8\x0a\x26\x97\xf4\x8c\xd6\xa5\xcc\x38\x01\x19\x0b\x21\x4b\x35\x78\xfb\x48\x43\x0b\x76\x52\xb2\x9f\x6e\xeb\xae\x42\xad\x66\xf7\x49\xd4\xbe\x17\xc0\xc1\xa2\x66\x0c\xa0\x8e\x49\x04\x63\xfe\x1d\x44\x4c\x2d\xb0\xa0\xb4\x95\xb5\x1a\xf3\x8a\x19\x25\xd4\x62\x8b\xbd\x58\xeb\xc5\x0f\xb5\xc6\x6d\x31\x0b\x35\xd7\x2d\x9c\xed\x05\x6d\x7b\xed\x87\x20\xd8\xe1\xbc\xbd\xf9\x70\xf3\x0b\x79\xaf\xd5\x12\x0f\x72\xb1\xb0\xc4\x69\xf2\x9b\xd6\xce\x3a\x40\x0c\x44\x67\x39\x63\x66\x00\x84\xb8\x65\xf8\xf7\x4c\x40\x88\xc8\x1f\x6c\xc9\x6c\x68\x44\xea\xf6\x6c\xc0\x0f\x14\x73\xa0\x8a\x07\x3b\x9b\x41\xf0\x44\x81\x93\xc2\xba\x60\x61\x34\xa6\x0f\x14\x27\xbc\x3a\xd9\x2c\x65\x8a\xcb\x06\x46\x60\xcd\x64\xc9\x09\xf6\xa2\xcd\x01\xd0\xdb\x1a\x2f\x0a\x6c\x64\x05\x66\x29\x6a\xcc\x81\x70\x3c\x29\x19\xb1\x22\x72\xe5\xf2\x72\x00\x91\x63\x75\x3a\x08\xef\x37\x4a\x62\xf0\xd4\x98\xfe\x98\x27\x67\xd9\x19\x30\x23\x58\x59\x56\xcb\xf6\xa8\xdc\xab\x14\x2a\x5a\x03\xa7\x17\x8b\x72\x65\xf2\x11\x29\x47\x43\x06\x18\x91\xe2\x34\x65\x4b\x22\x16\x3a\xb1\xe4\x9d\xba\x83\xa6\x16\x04\xb4\x68\xbf\xb3\xdb\xa9\xff\x7b\x4f\xdb\x65\xc1\x68\x98\xc9\xc6\xf5\x5a\xf0\x41\x56\xae\x00\x18\xd3\x16\x32\xa4\xde\xc1\x45\x9d\x1b\x57\x8a\x82\x89\x82\x18\xdc\xfa\x06\x30\x8d\x9d\x08\x7d\x68\x67\x0b\x9b\x9a\x8f\xd8\xc1\xad\xe4\xcc\x40\x7f\xd3\x4a\xec\x73\x93\x5c\xdd\x41\xd2\x85\x8e\x47\x98\x84\x90\x11\x21\xaa\x01\x28\x86\x85\xbc\x74\xda\xc1\x5e\x0e\xb5\x1d\x09\x4d\x23\xdc\x9f\x31\xcf\xac\xef\x25\xa7\xb9\x20\x62\xb0\x80\xf9\x15\x92\x66\x12\xd2\x89\xcf\x5d\x87\x5a\xd5\x2d\xd3\x41\x41\x76\xef\x1c\x89\xbd\x5f\x4d\x7c\x27\xef\xd6\xed\xd5\x49\x59\xde\x8a\xe1\x34\xb7\xe2\x80\x58\xdf\xf6\x7c\x8e\x0d\x80\x35\x6f\x7b\x9c\x48\xb8\xb7\x7f\xd0\x69\x70\xe3\xc5\x47\xaa\x5f\x41\x22\x54\x66\xfd\x45\xd8\xe5\xb6\xf2\x0e\x6c\x28\xba\x5b\x15\xd1\x5f\x8a\x7d\xdc\x5b\x39\xd4\x83\xa1\xdb\x7e\x84\x69\x2d\xd8\x05\x58\xfb\xb8\xec\xb6\xf2\x13\xd1\x73\x9f\x06\x7d\x22\x88\x4f\x18\x7d\xe2\x57\x53\xaa\x9b\xdc\x8a\xbf\x81\xfc\xe7\x6e\xa2\xa2\x4b\xd8\x6c\x6a\x62\x31\x29\x0f\x7a\xbd\x07\xaa\x4f\xc3\xf5\x31\xc8\x26\x55\xaf\xdc\x0b\xdb\x55\xa8\x3e\xc2\x85\x79\x56\x6c\xa7\xf2\x18\x68\xb7\x17\xa2\x86\xe6\xe3\x3f\x28\x76\xf5\x02\x77\x62\x85\x7b\x7a\x2c\x60\x9d\xe3\x2a\xea\x89\x84\x4f\x7c\x25\x54\xe4\x1f\xef\xf0\x5f\xc0\xc3\x69\x48\x98\xb1\xf0\x1b\xb4\xab\xd1\x91\x85\xee\xc5\x49\x85\xae\xa1\xd4\x41\x9b\x50\xde\x57\x3d\x6a\x86\xaf\x7b\xe0\x81\x3e\xf5\xae\x72\xde\x55\xe1\xb1\xaa\xde\x91\x8b\x2f\xb7\xef\x5f\x1e\xe2\xde\x1a\x0c\x7d\x51\x4e\xc8\x43\x1c\x79\xcf\xe3\x9f\xb8\xc7\x74\x0d\x9f\xe0\xfa\x3a\x88\xa2\x7e\xe0\x39\x5c\x60\x4b\xe8\x80\xfd\xd3\x5e\xce\xf2\x25\xf6\xf2\xed\x21\xba\xaa\xca\x82\xe4\xaa\xba\x3e\xcf\xf2\xda\x3f\xa5\xde\x45\x4b\xa6\xa0\x28\x9d\x2f\xa7\x20\xf2\xc7\xa5\xd4\xe3\x0b\xec\x71\xc5\xb1\xcb\xa0\xfa\x70\xab\x98\x4a\x56\x35\x07\xda\xf5\x2c\x1f\xde\x08\x45\x2c\x07\x13\x23\xbb\x33\x2f\x05\x9a\x01\xb9\xc0\x61\x68\x0d\xc4\xe5\xa4\xcb\xf1\xb4\x1c\x74\x62\xda\x3e\xfc\x5d\x3e\x2e\x54\xb8\x7b\xd8\xc2\x65\x0f\xdb\xb7\xf4\xff\xe0\x9f\x3e\x33\x18\x0f\x6c\xeb\xa0\xa4\xf2\xa8\x71\xd4\xd2\x77\xf0\x52\xc8\x38\x09\x3b\x9d\x73\x98\xe2\x80\x2d\xd7\xe7\x2b\x1d\x4f\x3a\x4f\xe8\xee\x6a\x20\xe9\xb5\x4d\x70\xc8\xab\x33\x68\x1d\x70\x70\x54\x80\x71\xba\x8d\xd2\x0f\x7a\xa5\x2c\x4b\x52\x89\xc3\x18\x3f\x3e\xf4\x70\xeb\x60\x3e\xf0\x68\xd7\x32\xba\xc4\x99\x3c\x9d\xbc\x83\x6f\x12\xd5\x0e\x6d\x1b\x5a\x1e\x90\xf6\x0a\x1a\xb1\x1b\x25\xd7\xd0\xd6\xad\xf2\x8b\xe3\x91\x72\xde\x24\x74\x72\xcd\xee\xc8\x9b\xe4\x1c\x5a\x5d\xc6\x5e\xda\x65\x7c\xbc\xb4\xb6\x29\x6d\xf7\xcb\x88\x7e\x18\xab\x23\xcc\x3f\xb9\xe3\xdb\x8e\xd6\x41\x5b\xc3\xc8\x88\x2f\xf0\x6a\xe8\x62\xe8\xda\xea\x33\x51\xf0\xc3\x14\x52\x8c\x44\xb6\x06\x0a\xdb\x63\x92\xe7\x35\x52\x78\xf6\x5d\x76\x02\x66\x29\x77\x7c\xa3\xed\xf9\x9e\xba\xcd\x7e\x71\xb0\xb0\x3f\x45\x8b\xed\x6d\x3f\xaa\xcb\xbe\xce\x59\xb0\xc7\x2e\x33\xe0\xf8\x2e\xfb\xba\x57\xa8\x9e\xa8\xcd\xf6\x3e\xf8\x97\x3a\x6d\x2f\x3c\x2f\x06\xcf\x2e\xa9\xb0\xcf\x3e\x2a\xa9\xea\xad\xf6\x39\xb2\xea\xec\x8d\xf6\x63\x36\xf1\xb5\x01\xaf\x5d\x02\xf0\x47\xfe\x1d\xd8\xa4\xf8\x11\xeb\x25\x37\x65\x36\x4c\xf3\xb5\xae\x72\xee\xf0\xd5\x7f\xa7\x39\x2e\x9e\x5c\x49\x8e\xfe\x1b\x0d\xe1\xf7\x01\xd2\xaf\x88\xb5\x6e\x42\xdc\xed\x3c\x74\xe4\x66\x3a\x5a\x77\x9f\x64\x26\x23\x17\x81\x99\xd2\xc2\x35\x38\xa6\xaf\x21\x2c\x62\xa2\x74\xd1\xd4\x08\x08\x92\x8b\xf0\xcb\x74\xea\xd1\x75\x0e\x6c\xa3\xf3\x8e\xbc\xb6\xdb\x5e\xc2\x1d\xf7\x86\xed\x3c\x2f\xb4\x08\x96\x9d\xf2\x7f\x9a\x68\x36\x83\x95\x03\x54\x9e\x00\x6e\xaa\xf7\x2f\x74\xf2\x29\x5f\x20\xd5\xeb\x9f\x47\xa8\x3e\x1a\xa2\xba\x0f\x2b\x05\xc1\x3f\xcd\x44\xf9\x10\xc8\x23\x00\x00")
 
 func pkgUiStaticJsGraph_templateHandlebarBytes() ([]byte, error) {
 	return bindataRead(
@@ -540,7 +582,7 @@ func pkgUiStaticJsGraph_templateHandlebar() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "pkg/ui/static/js/graph_template.handlebar", size: 8984, mode: os.FileMode(420), modTime: time.Unix(1583953499, 0)}
+	info := bindataFileInfo{name: "pkg/ui/static/js/graph_template.handlebar", size: 9160, mode: os.FileMode(420), modTime: time.Unix(1792002813, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -1582,10 +1624,12 @@ var _bindata = map[string]func() (*asset, error){
 	"pkg/ui/templates/bucket.html":                                                                   pkgUiTemplatesBucketHtml,
 	"pkg/ui/templates/bucket_menu.html":                                                              pkgUiTemplatesBucket_menuHtml,
 	"pkg/ui/templates/graph.html":                                                                    pkgUiTemplatesGraphHtml,
+	"pkg/ui/templates/loaded_blocks.html":                                                            pkgUiTemplatesLoaded_blocksHtml,
 	"pkg/ui/templates/query_menu.html":                                                               pkgUiTemplatesQuery_menuHtml,
 	"pkg/ui/templates/rule_menu.html":                                                                pkgUiTemplatesRule_menuHtml,
 	"pkg/ui/templates/rules.html":                                                                    pkgUiTemplatesRulesHtml,
 	"pkg/ui/templates/status.html":                                                                   pkgUiTemplatesStatusHtml,
+	"pkg/ui/templates/store_menu.html":                                                               pkgUiTemplatesStore_menuHtml,
 	"pkg/ui/templates/stores.html":                                                                   pkgUiTemplatesStoresHtml,
 	"pkg/ui/static/css/alerts.css":                                                                   pkgUiStaticCssAlertsCss,
 	"pkg/ui/static/css/graph.css":                                                                    pkgUiStaticCssGraphCss,
@@ -1795,16 +1839,18 @@ var _bintree = &bintree{nil, map[string]*bintree{
 				}},
 			}},
 			"templates": &bintree{nil, map[string]*bintree{
-				"_base.html":       &bintree{pkgUiTemplates_baseHtml, map[string]*bintree{}},
-				"alerts.html":      &bintree{pkgUiTemplatesAlertsHtml, map[string]*bintree{}},
-				"bucket.html":      &bintree{pkgUiTemplatesBucketHtml, map[string]*bintree{}},
-				"bucket_menu.html": &bintree{pkgUiTemplatesBucket_menuHtml, map[string]*bintree{}},
-				"graph.html":       &bintree{pkgUiTemplatesGraphHtml, map[string]*bintree{}},
-				"query_menu.html":  &bintree{pkgUiTemplatesQuery_menuHtml, map[string]*bintree{}},
-				"rule_menu.html":   &bintree{pkgUiTemplatesRule_menuHtml, map[string]*bintree{}},
-				"rules.html":       &bintree{pkgUiTemplatesRulesHtml, map[string]*bintree{}},
-				"status.html":      &bintree{pkgUiTemplatesStatusHtml, map[string]*bintree{}},
-				"stores.html":      &bintree{pkgUiTemplatesStoresHtml, map[string]*bintree{}},
+				"_base.html":         &bintree{pkgUiTemplates_baseHtml, map[string]*bintree{}},
+				"alerts.html":        &bintree{pkgUiTemplatesAlertsHtml, map[string]*bintree{}},
+				"bucket.html":        &bintree{pkgUiTemplatesBucketHtml, map[string]*bintree{}},
+				"bucket_menu.html":   &bintree{pkgUiTemplatesBucket_menuHtml, map[string]*bintree{}},
+				"graph.html":         &bintree{pkgUiTemplatesGraphHtml, map[string]*bintree{}},
+				"loaded_blocks.html": &bintree{pkgUiTemplatesLoaded_blocksHtml, map[string]*bintree{}},
+				"query_menu.html":    &bintree{pkgUiTemplatesQuery_menuHtml, map[string]*bintree{}},
+				"rule_menu.html":     &bintree{pkgUiTemplatesRule_menuHtml, map[string]*bintree{}},
+				"rules.html":         &bintree{pkgUiTemplatesRulesHtml, map[string]*bintree{}},
+				"status.html":        &bintree{pkgUiTemplatesStatusHtml, map[string]*bintree{}},
+				"store_menu.html":    &bintree{pkgUiTemplatesStore_menuHtml, map[string]*bintree{}},
+				"stores.html":        &bintree{pkgUiTemplatesStoresHtml, map[string]*bintree{}},
 			}},
 		}},
 	}},
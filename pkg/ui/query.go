@@ -4,6 +4,7 @@
 package ui
 
 import (
+	"fmt"
 	"html/template"
 	"net/http"
 	"os"
@@ -25,6 +26,8 @@ import (
 type Query struct {
 	*BaseUI
 	storeSet *query.StoreSet
+	// enableStoreDrain shows actions draining stores.
+	enableStoreDrain bool
 
 	externalPrefix, prefixHeader string
 
@@ -43,20 +46,21 @@ type thanosVersion struct {
 	GoVersion string `json:"goVersion"`
 }
 
-func NewQueryUI(logger log.Logger, reg prometheus.Registerer, storeSet *query.StoreSet, externalPrefix, prefixHeader string) *Query {
+func NewQueryUI(logger log.Logger, reg prometheus.Registerer, storeSet *query.StoreSet, externalPrefix, prefixHeader string, enableStoreDrain bool) *Query {
 	cwd, err := os.Getwd()
 	if err != nil {
 		cwd = "<error retrieving current working directory>"
 	}
 	return &Query{
-		BaseUI:         NewBaseUI(logger, "query_menu.html", queryTmplFuncs()),
-		storeSet:       storeSet,
-		externalPrefix: externalPrefix,
-		prefixHeader:   prefixHeader,
-		cwd:            cwd,
-		birth:          time.Now(),
-		reg:            reg,
-		now:            model.Now,
+		BaseUI:           NewBaseUI(logger, "query_menu.html", queryTmplFuncs()),
+		storeSet:         storeSet,
+		enableStoreDrain: enableStoreDrain,
+		externalPrefix:   externalPrefix,
+		prefixHeader:     prefixHeader,
+		cwd:              cwd,
+		birth:            time.Now(),
+		reg:              reg,
+		now:              model.Now,
 	}
 }
 
@@ -68,6 +72,18 @@ func queryTmplFuncs() template.FuncMap {
 		"formatTimestamp": func(timestamp int64) string {
 			return time.Unix(timestamp/1000, 0).Format(time.RFC3339)
 		},
+		"formatDuration": func(d time.Duration) string {
+			return d.Round(100 * time.Microsecond).String()
+		},
+		"formatPercent": func(ratio float64) string {
+			return fmt.Sprintf("%.1f%%", ratio*100)
+		},
+		"formatBytes": func(size int64) string {
+			return formatBytes(size)
+		},
+		"milliseconds": func(ms int64) time.Duration {
+			return time.Duration(ms) * time.Millisecond
+		},
 		"title": strings.Title,
 	}
 }
@@ -145,10 +161,26 @@ func (q *Query) stores(w http.ResponseWriter, r *http.Request) {
 	})
 
 	q.executeTemplate(w, "stores.html", prefix, struct {
-		Stores  map[component.StoreAPI][]query.StoreStatus
-		Sources []component.StoreAPI
+		Stores      map[component.StoreAPI][]query.StoreStatus
+		Sources     []component.StoreAPI
+		EnableDrain bool
 	}{
-		Stores:  statuses,
-		Sources: sources,
+		Stores:      statuses,
+		Sources:     sources,
+		EnableDrain: q.enableStoreDrain,
 	})
 }
+
+// formatBytes formats the size with the binary unit it is at least one of.
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
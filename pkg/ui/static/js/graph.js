@@ -86,6 +86,14 @@ Prometheus.Graph.prototype.initialize = function() {
   });
 
   self.expr.change(self.handleChange);
+  self.expr.on('input', debounce(function() {
+    self.lintQuery();
+  }, INPUT_DEBOUNCE_WAIT));
+
+  self.formatBtn = self.queryForm.find(".format_btn");
+  self.formatBtn.click(function() {
+    self.formatQuery();
+  });
 
   self.dedupBtn = self.queryForm.find(".dedup_btn");
   self.enableDedup = self.queryForm.find("input[name=dedup]");
@@ -122,6 +130,7 @@ Prometheus.Graph.prototype.initialize = function() {
 
   self.error = graphWrapper.find(".error").hide();
   self.warning = graphWrapper.find(".warning").hide();
+  self.lint = graphWrapper.find(".lint").hide();
   self.graphArea = graphWrapper.find(".graph_area");
   self.graph = self.graphArea.find(".graph");
   self.yAxis = self.graphArea.find(".y_axis");
@@ -623,6 +632,75 @@ Prometheus.Graph.prototype.submitQuery = function() {
   });
 };
 
+// formatQuery replaces the expression with its formatted version.
+Prometheus.Graph.prototype.formatQuery = function() {
+  var self = this;
+  self.clearError();
+  if (!self.expr.val()) {
+    return;
+  }
+
+  $.ajax({
+    method: "GET",
+    url: PATH_PREFIX + "/api/v1/format_query",
+    dataType: "json",
+    data: {"query": self.expr.val(), "lint": "true"},
+    success: function(json) {
+      self.expr.val(json.data.query);
+      self.expr.change();
+      self.showLints(json.data.lints);
+    },
+    error: function(xhr) {
+      var err = xhr.statusText;
+      if (xhr.responseJSON !== undefined) {
+        err = xhr.responseJSON.error;
+      }
+      self.showError("Error formatting query: " + err);
+    }
+  });
+};
+
+// lintQuery shows hints about likely mistakes in the expression as it is typed. Expressions which do not parse yet are
+// not reported until executed or formatted.
+Prometheus.Graph.prototype.lintQuery = function() {
+  var self = this;
+  if (self.lintXhr) {
+    self.lintXhr.abort();
+  }
+  if (!self.expr.val()) {
+    self.showLints([]);
+    return;
+  }
+
+  self.lintXhr = $.ajax({
+    method: "GET",
+    url: PATH_PREFIX + "/api/v1/format_query",
+    dataType: "json",
+    data: {"query": self.expr.val(), "lint": "true"},
+    success: function(json) {
+      self.showLints(json.data.lints);
+    },
+    error: function(xhr, resp) {
+      if (resp != "abort") {
+        self.showLints([]);
+      }
+    }
+  });
+};
+
+Prometheus.Graph.prototype.showLints = function(lints) {
+  var self = this;
+  self.lint.empty();
+  if (!lints || lints.length === 0) {
+    self.lint.hide();
+    return;
+  }
+  lints.forEach(function(l) {
+    $("<div>").append($("<code>").text(l.expr)).append(document.createTextNode(": " + l.message)).appendTo(self.lint);
+  });
+  self.lint.show();
+};
+
 Prometheus.Graph.prototype.showError = function(msg) {
   var self = this;
   self.error.text(msg);
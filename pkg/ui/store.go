@@ -0,0 +1,50 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package ui
+
+import (
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/common/route"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/store"
+)
+
+// Store is a web UI of the store gateway, listing blocks it currently serves.
+type Store struct {
+	*BaseUI
+
+	externalPrefix, prefixHeader string
+	loadedBlocks                 func() []store.LoadedBlock
+}
+
+func NewStoreUI(logger log.Logger, externalPrefix, prefixHeader string, loadedBlocks func() []store.LoadedBlock) *Store {
+	return &Store{
+		BaseUI:         NewBaseUI(log.With(logger, "component", "storeUI"), "store_menu.html", queryTmplFuncs()),
+		externalPrefix: externalPrefix,
+		prefixHeader:   prefixHeader,
+		loadedBlocks:   loadedBlocks,
+	}
+}
+
+// Register registers http routes for store UI.
+func (s *Store) Register(r *route.Router, ins extpromhttp.InstrumentationMiddleware) {
+	instrf := func(name string, next func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+		return ins.NewHandler(name, http.HandlerFunc(next))
+	}
+	r.WithPrefix(s.externalPrefix).Get("/blocks", instrf("blocks", s.blocks))
+	r.WithPrefix(s.externalPrefix).Get("/static/*filepath", instrf("static", s.serveStaticAsset))
+}
+
+func (s *Store) blocks(w http.ResponseWriter, r *http.Request) {
+	blocks := s.loadedBlocks()
+	s.executeTemplate(w, "loaded_blocks.html", GetWebPrefix(s.logger, s.externalPrefix, s.prefixHeader, r), struct {
+		Blocks []store.LoadedBlock
+		Totals store.LoadedBlocksTotals
+	}{
+		Blocks: blocks,
+		Totals: store.SumLoadedBlocks(blocks),
+	})
+}
@@ -0,0 +1,206 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+// Package usage implements accounting of resources used by queries of tenants, e.g. for chargeback. Usage is exported
+// as metrics and optionally uploaded periodically as reports to the object storage bucket.
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnknownTenant is the tenant usage of requests without tenant is attributed to.
+const UnknownTenant = "unknown"
+
+// ReportsDir is the directory of usage reports in the bucket.
+const ReportsDir = "usage"
+
+// Usage is the usage of resources by queries.
+type Usage struct {
+	// BytesFetched is the number of bytes of postings, series and chunks fetched from the object storage or caches.
+	BytesFetched uint64 `json:"bytesFetched"`
+	// SamplesProcessed is the number of samples of chunks fetched.
+	SamplesProcessed uint64 `json:"samplesProcessed"`
+	// QuerySeconds is the time spent evaluating PromQL queries.
+	QuerySeconds float64 `json:"querySeconds"`
+}
+
+func (u *Usage) add(o Usage) {
+	u.BytesFetched += o.BytesFetched
+	u.SamplesProcessed += o.SamplesProcessed
+	u.QuerySeconds += o.QuerySeconds
+}
+
+// Report is the usage of tenants over a time range, uploaded to the bucket by Reporter.
+type Report struct {
+	Component string           `json:"component"`
+	From      time.Time        `json:"from"`
+	Until     time.Time        `json:"until"`
+	Tenants   map[string]Usage `json:"tenants"`
+}
+
+// Accountant accounts usage of tenants. A nil Accountant accounts nothing.
+type Accountant struct {
+	mtx     sync.Mutex
+	from    time.Time
+	tenants map[string]Usage
+
+	bytesFetched     *prometheus.CounterVec
+	samplesProcessed *prometheus.CounterVec
+	querySeconds     *prometheus.CounterVec
+}
+
+// NewAccountant returns an accountant exporting usage of tenants as metrics.
+func NewAccountant(reg prometheus.Registerer) *Accountant {
+	return &Accountant{
+		from:    time.Now(),
+		tenants: map[string]Usage{},
+		bytesFetched: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_usage_bytes_fetched_total",
+			Help: "Total number of bytes of postings, series and chunks fetched by queries, by tenant.",
+		}, []string{"tenant"}),
+		samplesProcessed: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_usage_samples_processed_total",
+			Help: "Total number of samples of chunks fetched by queries, by tenant.",
+		}, []string{"tenant"}),
+		querySeconds: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_usage_query_seconds_total",
+			Help: "Total time spent evaluating PromQL queries, by tenant.",
+		}, []string{"tenant"}),
+	}
+}
+
+// Add accounts the given usage to the tenant. Usage of the empty tenant is attributed to UnknownTenant.
+func (a *Accountant) Add(tenant string, u Usage) {
+	if a == nil {
+		return
+	}
+	if tenant == "" {
+		tenant = UnknownTenant
+	}
+	if u.BytesFetched > 0 {
+		a.bytesFetched.WithLabelValues(tenant).Add(float64(u.BytesFetched))
+	}
+	if u.SamplesProcessed > 0 {
+		a.samplesProcessed.WithLabelValues(tenant).Add(float64(u.SamplesProcessed))
+	}
+	if u.QuerySeconds > 0 {
+		a.querySeconds.WithLabelValues(tenant).Add(u.QuerySeconds)
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	t := a.tenants[tenant]
+	t.add(u)
+	a.tenants[tenant] = t
+}
+
+// report returns the usage of tenants since the last report, and resets it.
+func (a *Accountant) report(component string, now time.Time) Report {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	r := Report{Component: component, From: a.from, Until: now, Tenants: a.tenants}
+	a.from = now
+	a.tenants = map[string]Usage{}
+	return r
+}
+
+// Reporter periodically uploads reports of usage of tenants to the bucket.
+type Reporter struct {
+	logger     log.Logger
+	bkt        objstore.Bucket
+	accountant *Accountant
+	component  string
+	interval   time.Duration
+
+	entropy *rand.Rand
+	uploads *prometheus.CounterVec
+}
+
+// NewReporter returns a reporter uploading reports of the usage accounted by the given accountant every interval.
+func NewReporter(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, accountant *Accountant, component string, interval time.Duration) *Reporter {
+	return &Reporter{
+		logger:     log.With(logger, "component", "usage-reporter"),
+		bkt:        bkt,
+		accountant: accountant,
+		component:  component,
+		interval:   interval,
+		entropy:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		uploads: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "thanos_usage_report_uploads_total",
+			Help: "Total number of usage reports uploaded to the bucket, by result: success or error.",
+		}, []string{"result"}),
+	}
+}
+
+// ReportPath returns the path of the report of the given component with the given ID in the bucket. IDs are ULIDs of
+// the end of reports, so reports of multiple instances of a component do not overwrite each other and are sorted by time.
+func ReportPath(component string, id ulid.ULID) string {
+	return path.Join(ReportsDir, component, id.String()+".json")
+}
+
+// Run uploads a report every interval until the context is canceled. Reports without usage are not uploaded. Reports
+// failed to upload are not retried; their usage is still exported as metrics.
+func (r *Reporter) Run(ctx context.Context) error {
+	return runutil.Repeat(r.interval, ctx.Done(), func() error {
+		rep := r.accountant.report(r.component, time.Now())
+		if len(rep.Tenants) == 0 {
+			return nil
+		}
+		if err := r.upload(ctx, rep); err != nil {
+			r.uploads.WithLabelValues("error").Inc()
+			level.Warn(r.logger).Log("msg", "uploading usage report failed", "err", err)
+			return nil
+		}
+		r.uploads.WithLabelValues("success").Inc()
+		return nil
+	})
+}
+
+func (r *Reporter) upload(ctx context.Context, rep Report) error {
+	b, err := json.Marshal(rep)
+	if err != nil {
+		return errors.Wrap(err, "marshal usage report")
+	}
+	id := ulid.MustNew(ulid.Timestamp(rep.Until), r.entropy)
+	return r.bkt.Upload(ctx, ReportPath(rep.Component, id), bytes.NewReader(b))
+}
+
+// OutgoingContextWithTenant returns a context sending the tenant as the gRPC metadata key of the tenant header, so
+// usage of StoreAPI calls made with it is attributed to the tenant.
+func OutgoingContextWithTenant(ctx context.Context, tenantHeader, tenant string) context.Context {
+	if tenant == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, strings.ToLower(tenantHeader), tenant)
+}
+
+// TenantFromIncomingContext returns the tenant of the gRPC metadata key of the tenant header, or the empty tenant.
+func TenantFromIncomingContext(ctx context.Context, tenantHeader string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(strings.ToLower(tenantHeader)); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
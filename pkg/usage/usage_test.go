@@ -0,0 +1,98 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAccountant_Add(t *testing.T) {
+	a := NewAccountant(prometheus.NewRegistry())
+	a.Add("team-a", Usage{BytesFetched: 100, SamplesProcessed: 10})
+	a.Add("team-a", Usage{BytesFetched: 50, QuerySeconds: 1.5})
+	a.Add("", Usage{SamplesProcessed: 5})
+
+	testutil.Equals(t, 150.0, promtest.ToFloat64(a.bytesFetched.WithLabelValues("team-a")))
+	testutil.Equals(t, 10.0, promtest.ToFloat64(a.samplesProcessed.WithLabelValues("team-a")))
+	testutil.Equals(t, 1.5, promtest.ToFloat64(a.querySeconds.WithLabelValues("team-a")))
+	testutil.Equals(t, 5.0, promtest.ToFloat64(a.samplesProcessed.WithLabelValues(UnknownTenant)))
+
+	now := time.Now()
+	r := a.report("store", now)
+	testutil.Equals(t, map[string]Usage{
+		"team-a":      {BytesFetched: 150, SamplesProcessed: 10, QuerySeconds: 1.5},
+		UnknownTenant: {SamplesProcessed: 5},
+	}, r.Tenants)
+	testutil.Equals(t, now, r.Until)
+
+	// Reports contain usage since the last one.
+	r = a.report("store", now.Add(time.Minute))
+	testutil.Equals(t, now, r.From)
+	testutil.Equals(t, 0, len(r.Tenants))
+
+	// A nil accountant accounts nothing.
+	var na *Accountant
+	na.Add("team-a", Usage{BytesFetched: 1})
+}
+
+func TestReporter_Run(t *testing.T) {
+	bkt := inmem.NewBucket()
+	a := NewAccountant(nil)
+	a.Add("team-a", Usage{BytesFetched: 100})
+
+	reg := prometheus.NewRegistry()
+	r := NewReporter(log.NewNopLogger(), reg, bkt, a, "store", time.Hour)
+
+	// A report is uploaded right away, and then every interval.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+	for promtest.ToFloat64(r.uploads.WithLabelValues("success")) == 0 {
+		select {
+		case err := <-done:
+			t.Fatalf("reporter stopped: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	testutil.Ok(t, <-done)
+
+	objs := bkt.Objects()
+	testutil.Equals(t, 1, len(objs))
+	for name, b := range objs {
+		var rep Report
+		testutil.Ok(t, json.Unmarshal(b, &rep))
+		id, err := ulid.Parse(strings.TrimSuffix(path.Base(name), ".json"))
+		testutil.Ok(t, err)
+		testutil.Equals(t, ReportPath("store", id), name)
+		testutil.Equals(t, ulid.Timestamp(rep.Until), id.Time())
+		testutil.Equals(t, "store", rep.Component)
+		testutil.Equals(t, map[string]Usage{"team-a": {BytesFetched: 100}}, rep.Tenants)
+	}
+}
+
+func TestTenantContext(t *testing.T) {
+	ctx := OutgoingContextWithTenant(context.Background(), "THANOS-TENANT", "team-a")
+	md, ok := metadata.FromOutgoingContext(ctx)
+	testutil.Assert(t, ok, "expected outgoing metadata")
+	testutil.Equals(t, "team-a", TenantFromIncomingContext(metadata.NewIncomingContext(context.Background(), md), "THANOS-TENANT"))
+
+	ctx = OutgoingContextWithTenant(context.Background(), "THANOS-TENANT", "")
+	_, ok = metadata.FromOutgoingContext(ctx)
+	testutil.Assert(t, !ok, "expected no outgoing metadata without tenant")
+	testutil.Equals(t, "", TenantFromIncomingContext(context.Background(), "THANOS-TENANT"))
+}
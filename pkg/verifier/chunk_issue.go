@@ -0,0 +1,91 @@
+// Copyright (c) The Thanos Authors.
+// Licensed under the Apache License 2.0.
+
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+const ChunkIssueID = "chunk_issue"
+
+// ChunkIssue verifies that all chunks referenced by the index can be read, i.e. their length matches the data in the
+// chunk segment files and their checksum matches, together with all issues verified by IndexIssue.
+// It downloads whole blocks, so it is much slower than IndexIssue.
+// Repair drops corrupted chunks, so it's lossy, but the block can be compacted and queried again.
+func ChunkIssue(ctx context.Context, logger log.Logger, bkt objstore.Bucket, backupBkt objstore.Bucket, repair bool, idMatcher func(ulid.ULID) bool, fetcher block.MetadataFetcher, deleteDelay time.Duration, concurrency int, metrics *verifierMetrics) error {
+	level.Info(logger).Log("msg", "started verifying issue", "with-repair", repair, "issue", ChunkIssueID)
+
+	metas, _, err := fetcher.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := forEachBlock(ctx, metas, idMatcher, concurrency, func(ctx context.Context, meta *metadata.Meta) error {
+		return verifyChunks(ctx, logger, bkt, backupBkt, repair, meta, deleteDelay, metrics)
+	}); err != nil {
+		return err
+	}
+
+	level.Info(logger).Log("msg", "verified issue", "with-repair", repair, "issue", ChunkIssueID)
+	return nil
+}
+
+func verifyChunks(ctx context.Context, logger log.Logger, bkt objstore.Bucket, backupBkt objstore.Bucket, repair bool, meta *metadata.Meta, deleteDelay time.Duration, metrics *verifierMetrics) error {
+	id := meta.ULID
+
+	tmpdir, err := ioutil.TempDir("", fmt.Sprintf("chunk-issue-block-%s-", id))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpdir); err != nil {
+			level.Warn(logger).Log("msg", "failed to delete dir", "tmpdir", tmpdir, "err", err)
+		}
+	}()
+
+	bdir := filepath.Join(tmpdir, id.String())
+	if err := block.Download(ctx, logger, bkt, id, bdir); err != nil {
+		return errors.Wrapf(err, "download block %s", id)
+	}
+
+	stats, err := block.GatherBlockIssueStats(logger, bdir, meta.MinTime, meta.MaxTime)
+	if err != nil {
+		return errors.Wrapf(err, "gather block issues %s", id)
+	}
+
+	if err = stats.AnyErr(); err == nil {
+		return nil
+	}
+
+	level.Warn(logger).Log("msg", "detected issue", "id", id, "err", err, "issue", ChunkIssueID)
+
+	if !repair {
+		// Only verify.
+		return nil
+	}
+
+	if meta.Thanos.Downsample.Resolution > 0 {
+		return errors.New("cannot repair downsampled blocks")
+	}
+
+	return repairBlock(ctx, logger, bkt, backupBkt, tmpdir, meta, deleteDelay, metrics, ChunkIssueID,
+		block.IgnoreCorruptedChunk,
+		block.IgnoreCompleteOutsideChunk,
+		block.IgnoreDuplicateOutsideChunk,
+		block.IgnoreIssue347OutsideChunk,
+	)
+}
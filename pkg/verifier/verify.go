@@ -5,9 +5,11 @@ package verifier
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -16,10 +18,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/thanos/pkg/objstore"
+	"golang.org/x/sync/errgroup"
 )
 
 type verifierMetrics struct {
 	blocksMarkedForDeletion prometheus.Counter
+	blocksRepaired          prometheus.Counter
 }
 
 func newVerifierMetrics(reg prometheus.Registerer) *verifierMetrics {
@@ -29,13 +33,18 @@ func newVerifierMetrics(reg prometheus.Registerer) *verifierMetrics {
 		Name: "thanos_verify_blocks_marked_for_deletion_total",
 		Help: "Total number of blocks marked for deletion by verify.",
 	})
+	m.blocksRepaired = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "thanos_verify_blocks_repaired_total",
+		Help: "Total number of blocks repaired by verify.",
+	})
 
 	return &m
 }
 
 // Issue is an function that does verification and repair only if repair arg is true.
 // It should log affected blocks using warn level logs. It should be safe for issue to run on healthy bucket.
-type Issue func(ctx context.Context, logger log.Logger, bkt objstore.Bucket, backupBkt objstore.Bucket, repair bool, idMatcher func(ulid.ULID) bool, fetcher block.MetadataFetcher, deleteDelay time.Duration, metrics *verifierMetrics) error
+// Issues checking blocks one by one check up to concurrency blocks at once.
+type Issue func(ctx context.Context, logger log.Logger, bkt objstore.Bucket, backupBkt objstore.Bucket, repair bool, idMatcher func(ulid.ULID) bool, fetcher block.MetadataFetcher, deleteDelay time.Duration, concurrency int, metrics *verifierMetrics) error
 
 // Verifier runs given issues to verify if bucket is healthy.
 type Verifier struct {
@@ -46,11 +55,12 @@ type Verifier struct {
 	repair      bool
 	fetcher     block.MetadataFetcher
 	deleteDelay time.Duration
+	concurrency int
 	metrics     *verifierMetrics
 }
 
 // New returns verifier that only logs affected blocks.
-func New(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, fetcher block.MetadataFetcher, deleteDelay time.Duration, issues []Issue) *Verifier {
+func New(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, fetcher block.MetadataFetcher, deleteDelay time.Duration, concurrency int, issues []Issue) *Verifier {
 	return &Verifier{
 		logger:      logger,
 		bkt:         bkt,
@@ -58,12 +68,13 @@ func New(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, fetc
 		fetcher:     fetcher,
 		repair:      false,
 		deleteDelay: deleteDelay,
+		concurrency: concurrency,
 		metrics:     newVerifierMetrics(reg),
 	}
 }
 
 // NewWithRepair returns verifier that logs affected blocks and attempts to repair them.
-func NewWithRepair(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, backupBkt objstore.Bucket, fetcher block.MetadataFetcher, deleteDelay time.Duration, issues []Issue) *Verifier {
+func NewWithRepair(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, backupBkt objstore.Bucket, fetcher block.MetadataFetcher, deleteDelay time.Duration, concurrency int, issues []Issue) *Verifier {
 	return &Verifier{
 		logger:      logger,
 		bkt:         bkt,
@@ -72,6 +83,7 @@ func NewWithRepair(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bu
 		fetcher:     fetcher,
 		repair:      true,
 		deleteDelay: deleteDelay,
+		concurrency: concurrency,
 		metrics:     newVerifierMetrics(reg),
 	}
 }
@@ -91,7 +103,7 @@ func (v *Verifier) Verify(ctx context.Context, idMatcher func(ulid.ULID) bool) e
 	// TODO(blotka): Wrap bucket with BucketWithMetrics and print metrics after each issue (e.g how many blocks where touched).
 	// TODO(bplotka): Implement disk "bucket" to allow this verify to work on local disk space as well.
 	for _, issueFn := range v.issues {
-		err := issueFn(ctx, v.logger, v.bkt, v.backupBkt, v.repair, idMatcher, v.fetcher, v.deleteDelay, v.metrics)
+		err := issueFn(ctx, v.logger, v.bkt, v.backupBkt, v.repair, idMatcher, v.fetcher, v.deleteDelay, v.concurrency, v.metrics)
 		if err != nil {
 			return errors.Wrap(err, "verify")
 		}
@@ -100,3 +112,45 @@ func (v *Verifier) Verify(ctx context.Context, idMatcher func(ulid.ULID) bool) e
 	level.Info(v.logger).Log("msg", "verify completed", "issues", len(v.issues), "repair", v.repair)
 	return nil
 }
+
+// forEachBlock calls f for each block matching idMatcher, up to concurrency blocks at once. Blocks are started in
+// order of their IDs. It stops on the first error.
+func forEachBlock(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, idMatcher func(ulid.ULID) bool, concurrency int, f func(context.Context, *metadata.Meta) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	sorted := make([]*metadata.Meta, 0, len(metas))
+	for id, meta := range metas {
+		if idMatcher != nil && !idMatcher(id) {
+			continue
+		}
+		sorted = append(sorted, meta)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ULID.Compare(sorted[j].ULID) < 0 })
+
+	ch := make(chan *metadata.Meta)
+	g, gctx := errgroup.WithContext(ctx)
+	for i := 0; i < concurrency; i++ {
+		g.Go(func() error {
+			for meta := range ch {
+				if err := f(gctx, meta); err != nil {
+					return errors.Wrapf(err, "block %s", meta.ULID)
+				}
+			}
+			return nil
+		})
+	}
+
+feed:
+	for _, meta := range sorted {
+		select {
+		case ch <- meta:
+		case <-gctx.Done():
+			break feed
+		}
+	}
+	close(ch)
+
+	return g.Wait()
+}
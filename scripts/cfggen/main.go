@@ -18,11 +18,15 @@ import (
 	"github.com/thanos-io/thanos/pkg/alert"
 	"github.com/thanos-io/thanos/pkg/cacheutil"
 	http_util "github.com/thanos-io/thanos/pkg/http"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/azure"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/objstore/cos"
+	"github.com/thanos-io/thanos/pkg/objstore/encryption"
 	"github.com/thanos-io/thanos/pkg/objstore/filesystem"
 	"github.com/thanos-io/thanos/pkg/objstore/gcs"
+	"github.com/thanos-io/thanos/pkg/objstore/obs"
+	"github.com/thanos-io/thanos/pkg/objstore/oci"
 	"github.com/thanos-io/thanos/pkg/objstore/oss"
 	"github.com/thanos-io/thanos/pkg/objstore/s3"
 	"github.com/thanos-io/thanos/pkg/objstore/swift"
@@ -42,10 +46,25 @@ var (
 		client.AZURE:      azure.Config{},
 		client.GCS:        gcs.Config{},
 		client.S3:         s3.DefaultConfig,
-		client.SWIFT:      swift.SwiftConfig{},
+		client.SWIFT:      swift.DefaultConfig,
 		client.COS:        cos.Config{},
-		client.ALIYUNOSS:  oss.Config{},
+		client.ALIYUNOSS:  oss.DefaultConfig,
+		client.OCI:        oci.Config{},
+		client.OBS:        obs.DefaultConfig,
 		client.FILESYSTEM: filesystem.Config{},
+		client.REPLICATING: client.ReplicatingConfig{
+			Primary:       client.BucketConfig{Type: client.S3, Config: map[string]interface{}{}, Middleware: objstore.DefaultMiddlewareConfig},
+			Replicas:      []client.BucketConfig{{Type: client.S3, Config: map[string]interface{}{}, Middleware: objstore.DefaultMiddlewareConfig}},
+			RetryInterval: client.DefaultReplicatingConfig.RetryInterval,
+		},
+		client.ENCRYPTED: client.EncryptedConfig{
+			Bucket: client.BucketConfig{Type: client.S3, Config: map[string]interface{}{}, Middleware: objstore.DefaultMiddlewareConfig},
+			Config: encryption.Config{
+				KeyProvider:  encryption.DefaultConfig.KeyProvider,
+				Static:       encryption.StaticConfig{Keys: []encryption.StaticKey{{}}},
+				KeyCacheSize: encryption.DefaultConfig.KeyCacheSize,
+			},
+		},
 	}
 	tracingConfigs = map[trclient.TracingProvider]interface{}{
 		trclient.JAEGER:      jaeger.Config{},
@@ -54,8 +73,10 @@ var (
 		trclient.LIGHTSTEP:   lightstep.Config{},
 	}
 	indexCacheConfigs = map[storecache.IndexCacheProvider]interface{}{
-		storecache.INMEMORY:  storecache.InMemoryIndexCacheConfig{},
-		storecache.MEMCACHED: cacheutil.MemcachedClientConfig{},
+		storecache.INMEMORY:         storecache.InMemoryIndexCacheConfig{},
+		storecache.INMEMORY_TINYLFU: storecache.TinyLFUIndexCacheConfig{},
+		storecache.MEMCACHED:        cacheutil.MemcachedClientConfig{},
+		storecache.TIERED:           storecache.TieredIndexCacheConfig{},
 	}
 )
 
@@ -71,7 +92,7 @@ func main() {
 	}
 
 	for typ, config := range bucketConfigs {
-		if err := generate(client.BucketConfig{Type: typ, Config: config}, generateName("bucket_", string(typ)), *outputDir); err != nil {
+		if err := generate(client.BucketConfig{Type: typ, Config: config, Middleware: objstore.DefaultMiddlewareConfig}, generateName("bucket_", string(typ)), *outputDir); err != nil {
 			level.Error(logger).Log("msg", "failed to generate", "type", typ, "err", err)
 			os.Exit(1)
 		}
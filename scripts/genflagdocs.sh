@@ -34,13 +34,13 @@ fi
 
 CHECK=${1:-}
 
-commands=("compact" "query" "rule" "sidecar" "store" "bucket" "check")
+commands=("compact" "query" "rule" "sidecar" "store" "bucket" "check" "tools")
 
 for x in "${commands[@]}"; do
     ./thanos "${x}" --help &> "docs/components/flags/${x}.txt"
 done
 
-bucketCommands=("verify" "ls" "inspect" "web" "replicate" "downsample")
+bucketCommands=("verify" "ls" "inspect" "web" "replicate" "downsample" "undelete" "analyze" "rewrite" "mark" "retention" "cleanup" "split")
 for x in "${bucketCommands[@]}"; do
     ./thanos bucket "${x}" --help &> "docs/components/flags/bucket_${x}.txt"
 done
@@ -50,6 +50,12 @@ for x in "${checkCommands[@]}"; do
     ./thanos check "${x}" --help &> "docs/components/flags/check_${x}.txt"
 done
 
+toolsCommands=("rules backfill" "query bench" "tsdb export" "tsdb import")
+for x in "${toolsCommands[@]}"; do
+    # shellcheck disable=SC2086
+    ./thanos tools ${x} --help &> "docs/components/flags/tools_${x// /_}.txt"
+done
+
 # remove white noise
 ${SED_BIN} -i -e 's/[ \t]*$//' docs/components/flags/*.txt
 